@@ -5,6 +5,7 @@ import (
 	"os"
 
 	// Packages
+	client "github.com/mutablelogic/go-llm/kernel/cmd"
 	llm "github.com/mutablelogic/go-llm/kernel/cmd2"
 	mcpcmd "github.com/mutablelogic/go-llm/mcp/cmd"
 	servercmd "github.com/mutablelogic/go-server/pkg/cmd"
@@ -15,21 +16,29 @@ import (
 // TYPES
 
 type CLI struct {
-	/*	llm.SessionCommands
-		llm.ChatCommands
-		llm.ChannelCommands
-		llm.AskCommands
-		llm.EmbeddingCommands
-		llm.ConnectorCommands
-		llm.ProviderCommands
-		llm.ModelCommands
-		llm.ToolCommands
-		llm.AgentCommands
-	*/
+	ClientCommands
 	MCP mcpcmd.Commands `cmd:"" name:"mcp" help:"Interact directly with an MCP server." group:"MCP"`
 	ServerCommands
 }
 
+type ClientCommands struct {
+	client.ServerFlags
+	client.SessionCommands
+	client.ChatCommands
+	client.ChannelCommands
+	client.AskCommands
+	client.BenchCommands
+	client.EvalCommands
+	client.CommitCommands
+	client.ReviewCommands
+	client.EmbeddingCommands
+	client.ConnectorCommands
+	client.ProviderCommands
+	client.ModelCommands
+	client.ToolCommands
+	client.AgentCommands
+}
+
 type ServerCommands struct {
 	RunServer llm.RunServer `cmd:"" name:"run" help:"Run the server." group:"SERVER"`
 	servercmd.OpenAPICommands
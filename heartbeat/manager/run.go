@@ -7,7 +7,6 @@ import (
 	// Packages
 	hschema "github.com/mutablelogic/go-llm/heartbeat/schema"
 	pg "github.com/mutablelogic/go-pg"
-	types "github.com/mutablelogic/go-server/pkg/types"
 )
 
 ///////////////////////////////////////////////////////////////////////////////
@@ -44,11 +43,13 @@ func (w markFiredWriter) Update(bind *pg.Bind) error {
 }
 
 // tick checks for due heartbeats, fires the callback for each, and marks them
-// as fired. Errors are logged but do not abort the loop.
+// as fired. The heartbeats are locked until they are marked, and heartbeats
+// which another replica has locked are skipped, so that each fires once when
+// replicas share the database. Errors are logged but do not abort the loop.
 func (m *Manager) tick(ctx context.Context) error {
 	if err := m.PoolConn.Tx(ctx, func(conn pg.Conn) error {
 		var list hschema.HeartbeatList
-		if err := conn.List(ctx, &list, hschema.HeartbeatListRequest{Fired: types.Ptr(false)}); err != nil {
+		if err := conn.List(ctx, &list, hschema.HeartbeatDueSelector{}); err != nil {
 			return err
 		}
 
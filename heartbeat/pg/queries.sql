@@ -16,6 +16,13 @@ SELECT
     id, message, schedule, fired, last_fired, created, modified
 FROM ${"schema"}."heartbeat" ${where} ORDER BY last_fired ASC NULLS FIRST, created ASC
 
+--heartbeat.list_due
+SELECT
+    id, message, schedule, fired, last_fired, created, modified
+FROM ${"schema"}."heartbeat" WHERE fired = false
+ORDER BY last_fired ASC NULLS FIRST, created ASC
+FOR UPDATE SKIP LOCKED
+
 --heartbeat.list_for_user
 SELECT
     heartbeat.id,
@@ -75,6 +75,20 @@ func (id HeartbeatMarkFiredSelector) Select(bind *pg.Bind, op pg.Op) (string, er
 	return bind.Query("heartbeat.mark_fired"), nil
 }
 
+// HeartbeatDueSelector selects the heartbeats which have not fired for the
+// maturity check, and locks them until the transaction ends. Rows which
+// another replica has locked are skipped, so each heartbeat fires once.
+type HeartbeatDueSelector struct{}
+
+func (HeartbeatDueSelector) Select(bind *pg.Bind, op pg.Op) (string, error) {
+	switch op {
+	case pg.List:
+		return bind.Query("heartbeat.list_due"), nil
+	default:
+		return "", llmschema.ErrInternalServerError.Withf("unsupported HeartbeatDueSelector operation %q", op)
+	}
+}
+
 func (h HeartbeatListRequest) Select(bind *pg.Bind, op pg.Op) (string, error) {
 	// Set WHERE phrases
 	bind.Del("where")
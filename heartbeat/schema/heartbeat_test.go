@@ -54,3 +54,15 @@ func TestHeartbeatInsertRequiresSession(t *testing.T) {
 	assert.Error(err)
 	assert.ErrorIs(err, kernel.ErrBadParameter)
 }
+
+func TestHeartbeatDueSelector(t *testing.T) {
+	assert := assert.New(t)
+	b := pg.NewBind("schema", "heartbeat", "heartbeat.list_due", "LIST DUE")
+
+	query, err := (schema.HeartbeatDueSelector{}).Select(b, pg.List)
+	if assert.NoError(err) {
+		assert.Equal("LIST DUE", query)
+	}
+	_, err = (schema.HeartbeatDueSelector{}).Select(b, pg.Delete)
+	assert.Error(err)
+}
@@ -42,8 +42,8 @@ type CallAgentCommand struct {
 ///////////////////////////////////////////////////////////////////////////////
 // PUBLIC METHODS
 
-func (cmd *ListAgentsCommand) Run(ctx server.Cmd) (err error) {
-	return WithClient(ctx, func(client *httpclient.Client, _ string) error {
+func (cmd *ListAgentsCommand) Run(ctx server.Cmd, remote ServerEndpoint) (err error) {
+	return WithClient(ctx, remote, func(client *httpclient.Client, _ string) error {
 		parent, endSpan := otel.StartSpan(ctx.Tracer(), ctx.Context(), "ListAgentsCommand",
 			attribute.String("request", types.Stringify(cmd.AgentListRequest)),
 		)
@@ -63,8 +63,8 @@ func (cmd *ListAgentsCommand) Run(ctx server.Cmd) (err error) {
 	})
 }
 
-func (cmd *GetAgentCommand) Run(ctx server.Cmd) (err error) {
-	return WithClient(ctx, func(client *httpclient.Client, _ string) error {
+func (cmd *GetAgentCommand) Run(ctx server.Cmd, remote ServerEndpoint) (err error) {
+	return WithClient(ctx, remote, func(client *httpclient.Client, _ string) error {
 		parent, endSpan := otel.StartSpan(ctx.Tracer(), ctx.Context(), "GetAgentCommand",
 			attribute.String("name", cmd.Name),
 		)
@@ -80,8 +80,8 @@ func (cmd *GetAgentCommand) Run(ctx server.Cmd) (err error) {
 	})
 }
 
-func (cmd *CallAgentCommand) Run(ctx server.Cmd) (err error) {
-	return WithClient(ctx, func(client *httpclient.Client, _ string) error {
+func (cmd *CallAgentCommand) Run(ctx server.Cmd, remote ServerEndpoint) (err error) {
+	return WithClient(ctx, remote, func(client *httpclient.Client, _ string) error {
 		req, err := cmd.request()
 		if err != nil {
 			return err
@@ -13,6 +13,7 @@ import (
 	"strings"
 
 	// Packages
+	uuid "github.com/google/uuid"
 	otel "github.com/mutablelogic/go-client/pkg/otel"
 	httpclient "github.com/mutablelogic/go-llm/kernel/httpclient"
 	opt "github.com/mutablelogic/go-llm/pkg/opt"
@@ -28,7 +29,8 @@ import (
 // TYPES
 
 type AskCommands struct {
-	Ask AskCommand `cmd:"" name:"ask" help:"Send a stateless text request to a model." group:"RESPONSES"`
+	Ask     AskCommand     `cmd:"" name:"ask" help:"Send a stateless text request to a model." group:"RESPONSES"`
+	Archive ArchiveCommand `cmd:"" name:"archive" help:"Get an archived request and response by request ID." group:"RESPONSES"`
 }
 
 type AskCommand struct {
@@ -37,6 +39,13 @@ type AskCommand struct {
 	File                 []string `name:"file" help:"Path or glob pattern for files to attach (may be repeated)" optional:""`
 	Stream               bool     `name:"stream" help:"Stream the response as it is generated." default:"true" negatable:""`
 	Out                  string   `name:"out" type:"dir" help:"Path to write response attachments (defaults to stdout)" optional:""`
+	Cascade              []string `name:"cascade" help:"Stronger models to try in turn when a reply is refused, truncated, or does not match the output format (may be repeated)" optional:""`
+	Prefill              string   `name:"prefill" help:"Text which starts the reply, and which the model continues" optional:""`
+	Draft                string   `name:"draft" help:"Smaller model which drafts the reply, which the model checks and continues" optional:""`
+}
+
+type ArchiveCommand struct {
+	ID uuid.UUID `arg:"" name:"id" help:"Request ID."`
 }
 
 type markdownStream struct {
@@ -52,7 +61,7 @@ type markdownStream struct {
 ///////////////////////////////////////////////////////////////////////////////
 // PUBLIC METHODS
 
-func (cmd *AskCommand) Run(ctx server.Cmd) (err error) {
+func (cmd *AskCommand) Run(ctx server.Cmd, remote ServerEndpoint) (err error) {
 	if cmd.Model == nil {
 		if s := ctx.GetString("model"); s != "" {
 			cmd.Model = types.Ptr(s)
@@ -80,7 +89,7 @@ func (cmd *AskCommand) Run(ctx server.Cmd) (err error) {
 		return err
 	}
 
-	return WithClient(ctx, func(client *httpclient.Client, _ string) error {
+	return WithClient(ctx, remote, func(client *httpclient.Client, _ string) error {
 		parent, endSpan := otel.StartSpan(ctx.Tracer(), ctx.Context(), "AskCommand",
 			attribute.String("request", types.Stringify(req)),
 		)
@@ -106,6 +115,12 @@ func (cmd *AskCommand) Run(ctx server.Cmd) (err error) {
 			fmt.Println(response)
 			return nil
 		}
+		for _, warning := range response.Warnings {
+			fmt.Fprintln(os.Stderr, "warning:", warning)
+		}
+		if draft := response.Draft; draft != nil {
+			fmt.Fprintf(os.Stderr, "draft: %d of %d paragraphs from %q accepted (draft %v, check %v, continue %v)\n", draft.Accepted, draft.Paragraphs, draft.Model, draft.Draft, draft.Verify, draft.Continue)
+		}
 
 		text := askResponseText(response)
 		if len(req.Format) > 0 {
@@ -163,11 +178,32 @@ func (cmd AskCommand) outputFolder(defaultDir string) (string, error) {
 	return out, nil
 }
 
+func (cmd *ArchiveCommand) Run(ctx server.Cmd, remote ServerEndpoint) (err error) {
+	return WithClient(ctx, remote, func(client *httpclient.Client, _ string) error {
+		parent, endSpan := otel.StartSpan(ctx.Tracer(), ctx.Context(), "ArchiveCommand",
+			attribute.String("id", cmd.ID.String()),
+		)
+		defer func() { endSpan(err) }()
+
+		record, err := client.GetArchive(parent, cmd.ID)
+		if err != nil {
+			return err
+		}
+
+		fmt.Println(record)
+		if !record.Verified {
+			fmt.Fprintln(os.Stderr, "warning: archive record failed verification")
+		}
+		return nil
+	})
+}
+
 func (cmd AskCommand) request() (schema.AskRequest, error) {
 	req := schema.AskRequest{
 		AskRequestCore: schema.AskRequestCore{
 			GeneratorMeta: cmd.GeneratorMeta,
 			Text:          cmd.Text,
+			Prefill:       cmd.Prefill,
 		},
 	}
 
@@ -178,6 +214,12 @@ func (cmd AskCommand) request() (schema.AskRequest, error) {
 	if len(attachments) > 0 {
 		req.Attachments = attachments
 	}
+	for _, model := range cmd.Cascade {
+		req.Cascade = append(req.Cascade, schema.GeneratorMeta{Model: types.Ptr(model)})
+	}
+	if cmd.Draft != "" {
+		req.Draft = &schema.GeneratorMeta{Model: types.Ptr(cmd.Draft)}
+	}
 
 	return req, nil
 }
@@ -0,0 +1,135 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	// Packages
+	otel "github.com/mutablelogic/go-client/pkg/otel"
+	httpclient "github.com/mutablelogic/go-llm/kernel/httpclient"
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	bench "github.com/mutablelogic/go-llm/pkg/bench"
+	opt "github.com/mutablelogic/go-llm/pkg/opt"
+	server "github.com/mutablelogic/go-server"
+	types "github.com/mutablelogic/go-server/pkg/types"
+	attribute "go.opentelemetry.io/otel/attribute"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+type BenchCommands struct {
+	Bench BenchCommand `cmd:"" name:"bench" help:"Measure the latency and throughput of models." group:"RESPONSES"`
+}
+
+type BenchCommand struct {
+	Model       []string `name:"model" help:"Model to measure (may be repeated)" required:""`
+	Provider    string   `name:"provider" help:"Provider of the models" optional:""`
+	Concurrency uint     `name:"concurrency" help:"Number of requests sent at once" default:"1"`
+	Requests    uint     `name:"requests" help:"Number of requests sent to each model" default:"10"`
+	Text        string   `name:"text" help:"User input text for each request" default:"Write a short paragraph about the history of the bicycle."`
+	MaxTokens   uint     `name:"max-tokens" help:"Maximum output tokens for each request, or zero for the model's default" default:"0"`
+	Stream      bool     `name:"stream" help:"Stream the responses, to measure the time to the first token." default:"true" negatable:""`
+	Format      string   `name:"format" help:"Format of the report" enum:"markdown,json" default:"markdown"`
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+func (cmd *BenchCommand) Run(ctx server.Cmd, remote ServerEndpoint) (err error) {
+	if cmd.Requests == 0 {
+		return fmt.Errorf("requests must be greater than zero")
+	}
+	return WithClient(ctx, remote, func(client *httpclient.Client, _ string) error {
+		parent, endSpan := otel.StartSpan(ctx.Tracer(), ctx.Context(), "BenchCommand",
+			attribute.StringSlice("models", cmd.Model),
+			attribute.Int("concurrency", int(cmd.Concurrency)),
+			attribute.Int("requests", int(cmd.Requests)),
+		)
+		defer func() { endSpan(err) }()
+
+		// Measure each model in turn
+		results := make([]bench.Result, 0, len(cmd.Model))
+		for _, model := range cmd.Model {
+			if model = strings.TrimSpace(model); model == "" {
+				continue
+			}
+			if ctx.IsTerm() > 0 {
+				fmt.Fprintf(os.Stderr, "bench: %s (%d requests, concurrency %d)\n", model, cmd.Requests, cmd.Concurrency)
+			}
+			result := bench.Run(parent, cmd.Concurrency, cmd.Requests, cmd.requestFn(client, model))
+			result.Provider, result.Model = cmd.Provider, model
+			results = append(results, result)
+			if err := parent.Err(); err != nil {
+				return err
+			}
+		}
+
+		// Write the report
+		if cmd.Format == "json" {
+			data, err := json.MarshalIndent(results, "", "  ")
+			if err != nil {
+				return err
+			}
+			fmt.Println(string(data))
+			return nil
+		}
+		return bench.Markdown(os.Stdout, results)
+	})
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// request returns the ask request for a model
+func (cmd BenchCommand) request(model string) schema.AskRequest {
+	req := schema.AskRequest{
+		AskRequestCore: schema.AskRequestCore{
+			GeneratorMeta: schema.GeneratorMeta{Model: types.Ptr(model)},
+			Text:          cmd.Text,
+		},
+	}
+	if cmd.Provider != "" {
+		req.Provider = types.Ptr(cmd.Provider)
+	}
+	if cmd.MaxTokens > 0 {
+		req.MaxTokens = types.Ptr(cmd.MaxTokens)
+	}
+	return req
+}
+
+// requestFn returns a function which sends one request to the model and
+// measures it
+func (cmd BenchCommand) requestFn(client *httpclient.Client, model string) bench.RequestFn {
+	req := cmd.request(model)
+	return func(ctx context.Context) (bench.Sample, error) {
+		var once sync.Once
+		var sample bench.Sample
+		start := time.Now()
+
+		// Record the time of the first token of the reply
+		var streamFn opt.StreamFn
+		if cmd.Stream {
+			streamFn = func(role, text string) {
+				if role == schema.RoleAssistant && text != "" {
+					once.Do(func() { sample.FirstToken = time.Since(start) })
+				}
+			}
+		}
+
+		response, err := client.Ask(ctx, req, streamFn)
+		if err != nil {
+			return bench.Sample{}, err
+		}
+		sample.Latency = time.Since(start)
+		if response.Usage != nil {
+			sample.OutputTokens = response.Usage.OutputTokens
+		}
+		return sample, nil
+	}
+}
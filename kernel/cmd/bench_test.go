@@ -0,0 +1,23 @@
+package cmd
+
+import (
+	"testing"
+
+	// Packages
+	types "github.com/mutablelogic/go-server/pkg/types"
+	assert "github.com/stretchr/testify/assert"
+)
+
+func TestBenchCommandRequest(t *testing.T) {
+	assert := assert.New(t)
+
+	req := (BenchCommand{Text: "hello"}).request("llama3.2")
+	assert.Equal(types.Ptr("llama3.2"), req.Model)
+	assert.Nil(req.Provider)
+	assert.Nil(req.MaxTokens)
+	assert.Equal("hello", req.Text)
+
+	req = (BenchCommand{Provider: "ollama", MaxTokens: 64, Text: "hello"}).request("llama3.2")
+	assert.Equal(types.Ptr("ollama"), req.Provider)
+	assert.Equal(types.Ptr(uint(64)), req.MaxTokens)
+}
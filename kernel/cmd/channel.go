@@ -76,7 +76,7 @@ var channelSpinnerFrames = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "
 ///////////////////////////////////////////////////////////////////////////////
 // PUBLIC METHODS
 
-func (cmd *ChannelCommand) Run(ctx server.Cmd) (err error) {
+func (cmd *ChannelCommand) Run(ctx server.Cmd, remote ServerEndpoint) (err error) {
 	if !term.IsTerminal(int(os.Stdin.Fd())) || !term.IsTerminal(int(os.Stdout.Fd())) {
 		return fmt.Errorf("session-ui requires an interactive terminal")
 	}
@@ -89,7 +89,7 @@ func (cmd *ChannelCommand) Run(ctx server.Cmd) (err error) {
 		return err
 	}
 
-	return WithClient(ctx, func(client *httpclient.Client, _ string) error {
+	return WithClient(ctx, remote, func(client *httpclient.Client, _ string) error {
 		parent, endSpan := otel.StartSpan(ctx.Tracer(), ctx.Context(), "ChannelCommand",
 			attribute.String("session", id.String()),
 		)
@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -10,6 +11,7 @@ import (
 	uuid "github.com/google/uuid"
 	otel "github.com/mutablelogic/go-client/pkg/otel"
 	httpclient "github.com/mutablelogic/go-llm/kernel/httpclient"
+	codebase "github.com/mutablelogic/go-llm/pkg/codebase"
 	opt "github.com/mutablelogic/go-llm/pkg/opt"
 	schema "github.com/mutablelogic/go-llm/kernel/schema"
 	tui "github.com/mutablelogic/go-llm/pkg/tui"
@@ -21,23 +23,42 @@ import (
 // TYPES
 
 type ChatCommands struct {
-	Chat ChatCommand `cmd:"" name:"chat" help:"Send a message within an existing session." group:"RESPONSES"`
+	Chat      ChatCommand      `cmd:"" name:"chat" help:"Send a message within an existing session." group:"RESPONSES"`
+	Job       JobCommand       `cmd:"" name:"job" help:"Show the status of a background chat job, and its reply when completed." group:"RESPONSES"`
+	CancelJob CancelJobCommand `cmd:"" name:"job-cancel" help:"Cancel a background chat job." group:"RESPONSES"`
+	Recording RecordingCommand `cmd:"" name:"recording" help:"Step through the intermediate states of a chat turn made with --record." group:"RESPONSES"`
 }
 
 type ChatCommand struct {
 	Session       uuid.UUID `name:"session" help:"Session ID (defaults to the stored current session)" optional:""`
-	Text          string    `arg:"" help:"User input text"`
-	Tools         []string  `name:"tool" help:"Tool names to include (may be repeated; nil means all, empty means none)" optional:""`
+	Text          string    `arg:"" help:"User input text" optional:""`
+	Tools         []string  `name:"tool" help:"Tool names or glob patterns to include, prefixed with ! to deny (may be repeated; nil means all, empty means none)" optional:""`
 	MaxIterations uint      `name:"max-iterations" help:"Maximum tool-calling iterations (0 uses default)" optional:""`
 	SystemPrompt  string    `name:"system-prompt" help:"Per-request system prompt appended to the session prompt" optional:""`
+	Prefill       string    `name:"prefill" help:"Text which starts the reply, and which the model continues" optional:""`
 	Stream        bool      `name:"stream" help:"Stream the response as it is generated." default:"true" negatable:""`
 	Out           string    `name:"out" type:"dir" help:"Path to write response attachments (defaults to stdout)" optional:""`
+	DryRun        bool      `name:"dry-run" help:"Print the request which would be sent to the provider, without sending it."`
+	Simulate      bool      `name:"simulate" help:"Do not run tools. Tool calls return fixtures recorded on the server, or results generated by the model."`
+	Async         bool      `name:"async" help:"Run the turn in the background on the server, and print the job to poll with the job command."`
+	Record        bool      `name:"record" help:"Record every intermediate state of the turn on the server, and print the recording to step through with the recording command."`
+	ExportPDF     string    `name:"export-pdf" type:"path" help:"Write the session as a PDF to this path after the reply, or without sending a message when no text is given" optional:""`
+	ChatContext   `embed:""`
+}
+
+// ChatContext packs the files of directories into the chat turn
+type ChatContext struct {
+	Dirs    []string `name:"context" type:"existingdir" help:"Directory whose files are sent with the message, respecting .gitignore (may be repeated)" optional:""`
+	Include []string `name:"context-include" help:"Glob patterns of files to read from the context directories, for example *.go (may be repeated)" optional:""`
+	Exclude []string `name:"context-exclude" help:"Glob patterns of files to skip in the context directories (may be repeated)" optional:""`
+	Tokens  uint     `name:"context-tokens" help:"Token budget for the files of each context directory (0 means no limit)" default:"32000"`
+	Tree    bool     `name:"context-tree" help:"Send a tree summary of each context directory." default:"true" negatable:""`
 }
 
 ///////////////////////////////////////////////////////////////////////////////
 // PUBLIC METHODS
 
-func (cmd *ChatCommand) Run(ctx server.Cmd) (err error) {
+func (cmd *ChatCommand) Run(ctx server.Cmd, remote ServerEndpoint) (err error) {
 	if cmd.Session == uuid.Nil {
 		if value := ctx.GetString("session"); value != "" {
 			session, err := uuid.Parse(value)
@@ -53,15 +74,48 @@ func (cmd *ChatCommand) Run(ctx server.Cmd) (err error) {
 	if err := ctx.Set("session", cmd.Session.String()); err != nil {
 		return err
 	}
+	if cmd.Text == "" && cmd.ExportPDF == "" {
+		return fmt.Errorf("text is required")
+	}
 
+	if len(cmd.ChatContext.Dirs) > 0 && cmd.DryRun {
+		return fmt.Errorf("--context cannot be used with --dry-run")
+	}
+	if cmd.Async && (cmd.DryRun || cmd.Simulate || cmd.Record || cmd.ExportPDF != "") {
+		return fmt.Errorf("--async cannot be used with --dry-run, --simulate, --record or --export-pdf")
+	}
 	req := cmd.request()
 
-	return WithClient(ctx, func(client *httpclient.Client, _ string) error {
+	return WithClient(ctx, remote, func(client *httpclient.Client, _ string) error {
 		parent, endSpan := otel.StartSpan(ctx.Tracer(), ctx.Context(), "ChatCommand",
 			attribute.String("request", req.String()),
 		)
 		defer func() { endSpan(err) }()
 
+		if cmd.Text == "" {
+			return cmd.exportPDF(parent, client)
+		}
+		if cmd.DryRun {
+			dryRun, err := client.ChatDryRun(parent, req)
+			if err != nil {
+				return err
+			}
+			fmt.Println(dryRun)
+			return nil
+		}
+
+		if err := cmd.ChatContext.append(parent, client, cmd.Session); err != nil {
+			return err
+		}
+		if cmd.Async {
+			job, err := client.ChatAsync(parent, req)
+			if err != nil {
+				return err
+			}
+			fmt.Println(job)
+			return nil
+		}
+
 		widget := tui.Markdown(markdownOptsForStdout()...)
 		streamRenderer := newMarkdownStream(os.Stdout, widget)
 		var streamFn opt.StreamFn
@@ -73,7 +127,15 @@ func (cmd *ChatCommand) Run(ctx server.Cmd) (err error) {
 			}
 		}
 
-		response, err := client.Chat(parent, req, streamFn)
+		chat := client.Chat
+		if cmd.Record {
+			chat = func(ctx context.Context, req schema.ChatRequest, streamFn opt.StreamFn) (*schema.ChatResponse, error) {
+				return client.ChatRecorded(ctx, req, cmd.Simulate, streamFn)
+			}
+		} else if cmd.Simulate {
+			chat = client.ChatSimulated
+		}
+		response, err := chat(parent, req, streamFn)
 		if err != nil {
 			return err
 		}
@@ -82,6 +144,17 @@ func (cmd *ChatCommand) Run(ctx server.Cmd) (err error) {
 			fmt.Println(response)
 			return nil
 		}
+		for _, warning := range response.Warnings {
+			fmt.Fprintln(os.Stderr, "warning:", warning)
+		}
+		for _, call := range response.Trace {
+			if call.Simulated != "" {
+				fmt.Fprintf(os.Stderr, "simulated: %s (%s)\n", call.Name, call.Simulated)
+			}
+		}
+		if response.Recording != uuid.Nil {
+			fmt.Fprintln(os.Stderr, "recording:", response.Recording)
+		}
 
 		text := chatResponseText(response)
 		attachments := chatResponseAttachments(response)
@@ -100,9 +173,14 @@ func (cmd *ChatCommand) Run(ctx server.Cmd) (err error) {
 		}
 
 		if cmd.Stream {
-			return streamRenderer.Finish(text)
+			err = streamRenderer.Finish(text)
+		} else {
+			err = writeMarkdown(os.Stdout, widget, text)
+		}
+		if err != nil || cmd.ExportPDF == "" {
+			return err
 		}
-		return writeMarkdown(os.Stdout, widget, text)
+		return cmd.exportPDF(parent, client)
 	})
 }
 
@@ -116,9 +194,34 @@ func (cmd ChatCommand) request() schema.ChatRequest {
 		Tools:         cmd.Tools,
 		MaxIterations: cmd.MaxIterations,
 		SystemPrompt:  cmd.SystemPrompt,
+		Prefill:       cmd.Prefill,
 	}
 }
 
+// append reads the files of each context directory, and appends them to the
+// session as pending input, which is sent with the chat turn
+func (c ChatContext) append(ctx context.Context, client *httpclient.Client, session uuid.UUID) error {
+	for _, dir := range c.Dirs {
+		files, err := codebase.Read(dir,
+			codebase.WithInclude(c.Include...),
+			codebase.WithExclude(c.Exclude...),
+			codebase.WithTokens(c.Tokens, nil),
+			codebase.WithTree(c.Tree),
+		)
+		if err != nil {
+			return err
+		}
+		if len(files.Files) == 0 {
+			return fmt.Errorf("no files read from %q", dir)
+		}
+		if _, err := client.AppendMessage(ctx, session, files.Message()); err != nil {
+			return err
+		}
+		fmt.Fprintln(os.Stderr, "context:", files)
+	}
+	return nil
+}
+
 func (cmd ChatCommand) outputFolder(defaultDir string) (string, error) {
 	out := cmd.Out
 	if out == "" {
@@ -136,6 +239,19 @@ func (cmd ChatCommand) outputFolder(defaultDir string) (string, error) {
 	return out, nil
 }
 
+// exportPDF writes the session as a PDF to the export path
+func (cmd ChatCommand) exportPDF(ctx context.Context, client *httpclient.Client) error {
+	data, err := client.ExportSession(ctx, cmd.Session, schema.SessionExportRequest{Format: schema.ExportPDF})
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(cmd.ExportPDF, data, 0o644); err != nil {
+		return fmt.Errorf("writing export: %w", err)
+	}
+	fmt.Fprintln(os.Stderr, "exported session to", cmd.ExportPDF)
+	return nil
+}
+
 func chatResponseText(response *schema.ChatResponse) string {
 	if response == nil {
 		return ""
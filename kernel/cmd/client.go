@@ -4,29 +4,64 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
+	"strings"
 
 	// Packages
+	kong "github.com/alecthomas/kong"
 	httpclient "github.com/mutablelogic/go-llm/kernel/httpclient"
 	server "github.com/mutablelogic/go-server"
 	httpresponse "github.com/mutablelogic/go-server/pkg/httpresponse"
 )
 
+///////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// ServerFlags selects a remote go-llm server for the client commands. When
+// set, it replaces the endpoint derived from the --http.addr and --http.prefix
+// flags, so that a team can share one credentialed server.
+type ServerFlags struct {
+	Server string `name:"server" env:"${ENV_NAME}_SERVER" help:"URL of a remote go-llm server API, for example https://llm.example.com/api" optional:""`
+}
+
+// ServerEndpoint is the URL of the remote server, which ServerFlags binds
+// into the command context, so that it is passed to the Run method of client
+// commands. It is empty when the --server flag is not set.
+type ServerEndpoint string
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// AfterApply validates the server URL and binds it for the client commands
+func (f *ServerFlags) AfterApply(kctx *kong.Context) error {
+	endpoint, err := parseServerEndpoint(f.Server)
+	if err != nil {
+		return err
+	}
+	kctx.Bind(ServerEndpoint(endpoint))
+	return nil
+}
+
 ///////////////////////////////////////////////////////////////////////////////
 // PUBLIC FUNCTIONS
 
-// WithClient returns auth client configured from the global HTTP flags.
-func WithClient(ctx server.Cmd, fn func(*httpclient.Client, string) error) error {
-	return withClient(ctx, fn)
+// WithClient returns auth client configured from the global HTTP flags, or
+// for the remote server when the endpoint is not empty.
+func WithClient(ctx server.Cmd, remote ServerEndpoint, fn func(*httpclient.Client, string) error) error {
+	return withClient(ctx, remote, fn)
 }
 
 ///////////////////////////////////////////////////////////////////////////////
 // PRIVATE FUNCTIONS
 
-func withClient(ctx server.Cmd, fn func(*httpclient.Client, string) error) error {
+func withClient(ctx server.Cmd, remote ServerEndpoint, fn func(*httpclient.Client, string) error) error {
 	endpoint, opts, err := ctx.ClientEndpoint()
 	if err != nil {
 		return err
 	}
+	if remote != "" {
+		endpoint = string(remote)
+	}
 	client, err := httpclient.New(endpoint, opts...)
 	if err != nil {
 		return err
@@ -38,6 +73,24 @@ func withClient(ctx server.Cmd, fn func(*httpclient.Client, string) error) error
 	}
 }
 
+func parseServerEndpoint(value string) (string, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return "", nil
+	}
+	u, err := url.Parse(value)
+	if err != nil {
+		return "", fmt.Errorf("invalid server url %q: %w", value, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return "", fmt.Errorf("invalid server url %q: scheme must be http or https", value)
+	}
+	if u.Host == "" {
+		return "", fmt.Errorf("invalid server url %q: missing host", value)
+	}
+	return strings.TrimSuffix(u.String(), "/"), nil
+}
+
 func normalizeError(err error) error {
 	if err == nil {
 		return nil
@@ -0,0 +1,35 @@
+package cmd
+
+import "testing"
+
+func TestParseServerEndpointEmpty(t *testing.T) {
+	got, err := parseServerEndpoint("  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "" {
+		t.Fatalf("expected empty endpoint, got %q", got)
+	}
+}
+
+func TestParseServerEndpointTrimsSlash(t *testing.T) {
+	got, err := parseServerEndpoint("https://llm.example.com/api/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "https://llm.example.com/api" {
+		t.Fatalf("unexpected endpoint: %q", got)
+	}
+}
+
+func TestParseServerEndpointRejectsScheme(t *testing.T) {
+	if _, err := parseServerEndpoint("ftp://llm.example.com/api"); err == nil {
+		t.Fatal("expected error for unsupported scheme")
+	}
+}
+
+func TestParseServerEndpointRejectsMissingHost(t *testing.T) {
+	if _, err := parseServerEndpoint("http:///api"); err == nil {
+		t.Fatal("expected error for missing host")
+	}
+}
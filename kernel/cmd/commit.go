@@ -0,0 +1,161 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	// Packages
+	otel "github.com/mutablelogic/go-client/pkg/otel"
+	httpclient "github.com/mutablelogic/go-llm/kernel/httpclient"
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	git "github.com/mutablelogic/go-llm/pkg/git"
+	server "github.com/mutablelogic/go-server"
+	types "github.com/mutablelogic/go-server/pkg/types"
+	attribute "go.opentelemetry.io/otel/attribute"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+type CommitCommands struct {
+	Commit CommitCommand `cmd:"" name:"commit" help:"Write a commit message for staged changes, or a pull request description for a branch." group:"RESPONSES"`
+}
+
+type CommitCommand struct {
+	schema.GeneratorMeta `embed:""`
+	Path                 string `name:"path" help:"Path within the repository." default:"."`
+	PR                   bool   `name:"pr" help:"Write a pull request description for the changes on the branch, rather than a commit message."`
+	Base                 string `name:"base" help:"Branch which the pull request merges into." default:"main"`
+	DryRun               bool   `name:"dry-run" help:"Print the commit message without committing."`
+	Edit                 bool   `name:"edit" help:"Open the commit message in the editor before committing." default:"true" negatable:""`
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+const commitPrompt = `You write git commit messages in the Conventional Commits format.
+The first line is "<type>(<optional scope>): <summary>", where type is one of
+feat, fix, docs, style, refactor, perf, test, build, ci or chore, and the
+summary is in the imperative mood, lower case, without a full stop and at most
+72 characters. If the change needs explanation, add a blank line and a body
+wrapped at 72 characters which says what changed and why. Add a footer
+"BREAKING CHANGE: <description>" only for incompatible changes. Reply with the
+commit message only, without code fences or commentary.`
+
+const prPrompt = `You write pull request descriptions in Markdown. Start with a title line
+of at most 72 characters prefixed with "# ". Then write a short summary of
+what the change does and why, a "## Changes" section with a bulleted list of
+the notable changes, and a "## Testing" section which suggests how to verify
+the change. Do not invent details which are not in the diff. Reply with the
+description only, without code fences or commentary.`
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+func (cmd *CommitCommand) Run(ctx server.Cmd, remote ServerEndpoint) (err error) {
+	if cmd.Model == nil {
+		if s := ctx.GetString("model"); s != "" {
+			cmd.Model = types.Ptr(s)
+		}
+	}
+	if cmd.Provider == nil {
+		if s := ctx.GetString("provider"); s != "" {
+			cmd.Provider = types.Ptr(s)
+		}
+	}
+	if cmd.Model == nil {
+		return fmt.Errorf("model is required (set with --model or store a default)")
+	}
+
+	// Read the changes
+	repo, err := git.Find(ctx.Context(), cmd.Path)
+	if err != nil {
+		return err
+	}
+	req := git.DiffRequest{Staged: true}
+	if cmd.PR {
+		req = git.DiffRequest{Base: cmd.Base}
+	}
+	diff, err := repo.Diff(ctx.Context(), req, 0)
+	if err != nil {
+		return err
+	} else if diff.Empty() && cmd.PR {
+		return fmt.Errorf("no changes since %q", cmd.Base)
+	} else if diff.Empty() {
+		return fmt.Errorf("no staged changes")
+	}
+
+	return WithClient(ctx, remote, func(client *httpclient.Client, _ string) error {
+		parent, endSpan := otel.StartSpan(ctx.Tracer(), ctx.Context(), "CommitCommand",
+			attribute.Bool("pr", cmd.PR),
+			attribute.String("stat", diff.Stat),
+		)
+		defer func() { endSpan(err) }()
+
+		// Generate the message
+		response, err := client.Ask(parent, cmd.request(diff), nil)
+		if err != nil {
+			return err
+		}
+		message := commitMessage(askResponseText(response))
+		if message == "" {
+			return fmt.Errorf("the model returned an empty message")
+		}
+
+		// Print the message, or commit with it
+		if cmd.PR || cmd.DryRun {
+			fmt.Println(message)
+			return nil
+		}
+		return repo.Commit(ctx.Context(), message+"\n", cmd.Edit)
+	})
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+func (cmd CommitCommand) request(diff *git.Diff) schema.AskRequest {
+	meta := cmd.GeneratorMeta
+	prompt := commitPrompt
+	if cmd.PR {
+		prompt = prPrompt
+	}
+	if meta.SystemPrompt != nil && strings.TrimSpace(*meta.SystemPrompt) != "" {
+		prompt += "\n\n" + strings.TrimSpace(*meta.SystemPrompt)
+	}
+	meta.SystemPrompt = types.Ptr(prompt)
+
+	var text strings.Builder
+	if cmd.PR {
+		fmt.Fprintf(&text, "Changes on the branch since %s:\n\n", cmd.Base)
+	} else {
+		text.WriteString("Staged changes:\n\n")
+	}
+	text.WriteString(diff.Stat)
+	text.WriteString("\n\n")
+	text.WriteString(diff.Patch)
+	if diff.Truncated {
+		text.WriteString("\n[The diff was truncated; use the summary of changed files for the rest]\n")
+	}
+
+	return schema.AskRequest{
+		AskRequestCore: schema.AskRequestCore{
+			GeneratorMeta: meta,
+			Text:          text.String(),
+		},
+	}
+}
+
+// commitMessage removes code fences which a model may add around a message
+func commitMessage(text string) string {
+	text = strings.TrimSpace(text)
+	if strings.HasPrefix(text, "```") && strings.HasSuffix(text, "```") {
+		text = strings.TrimSuffix(text, "```")
+		if i := strings.IndexByte(text, '\n'); i >= 0 {
+			text = text[i+1:]
+		} else {
+			text = ""
+		}
+	}
+	return strings.TrimSpace(text)
+}
@@ -0,0 +1,35 @@
+package cmd
+
+import (
+	"testing"
+
+	// Packages
+	git "github.com/mutablelogic/go-llm/pkg/git"
+	types "github.com/mutablelogic/go-server/pkg/types"
+	assert "github.com/stretchr/testify/assert"
+)
+
+func TestCommitMessageRemovesCodeFence(t *testing.T) {
+	assert.Equal(t, "feat: add x", commitMessage("feat: add x\n"))
+	assert.Equal(t, "feat: add x\n\nbody", commitMessage("```text\nfeat: add x\n\nbody\n```"))
+	assert.Equal(t, "", commitMessage("```"))
+}
+
+func TestCommitCommandRequest(t *testing.T) {
+	diff := &git.Diff{Stat: " a.txt | 1 +", Patch: "+hello\n", Truncated: true}
+
+	cmd := CommitCommand{}
+	cmd.Model = types.Ptr("model")
+	req := cmd.request(diff)
+	assert.Equal(t, commitPrompt, types.Value(req.SystemPrompt))
+	assert.Equal(t, "model", types.Value(req.Model))
+	assert.Contains(t, req.Text, "Staged changes:")
+	assert.Contains(t, req.Text, "+hello")
+	assert.Contains(t, req.Text, "truncated")
+
+	cmd = CommitCommand{PR: true, Base: "main"}
+	cmd.SystemPrompt = types.Ptr("Mention the ticket.")
+	req = cmd.request(diff)
+	assert.Equal(t, prPrompt+"\n\nMention the ticket.", types.Value(req.SystemPrompt))
+	assert.Contains(t, req.Text, "since main")
+}
@@ -53,8 +53,8 @@ type UpdateConnectorCommand struct {
 ///////////////////////////////////////////////////////////////////////////////
 // PUBLIC METHODS
 
-func (cmd *ListConnectorsCommand) Run(ctx server.Cmd) (err error) {
-	return WithClient(ctx, func(client *httpclient.Client, _ string) error {
+func (cmd *ListConnectorsCommand) Run(ctx server.Cmd, remote ServerEndpoint) (err error) {
+	return WithClient(ctx, remote, func(client *httpclient.Client, _ string) error {
 		parent, endSpan := otel.StartSpan(ctx.Tracer(), ctx.Context(), "ListConnectorsCommand",
 			attribute.String("request", types.Stringify(cmd.ConnectorListRequest)),
 		)
@@ -74,8 +74,8 @@ func (cmd *ListConnectorsCommand) Run(ctx server.Cmd) (err error) {
 	})
 }
 
-func (cmd *CreateConnectorCommand) Run(ctx server.Cmd) (err error) {
-	return WithClient(ctx, func(client *httpclient.Client, _ string) error {
+func (cmd *CreateConnectorCommand) Run(ctx server.Cmd, remote ServerEndpoint) (err error) {
+	return WithClient(ctx, remote, func(client *httpclient.Client, _ string) error {
 		req, err := cmd.request()
 		if err != nil {
 			return err
@@ -110,8 +110,8 @@ func (cmd *CreateConnectorCommand) Run(ctx server.Cmd) (err error) {
 	})
 }
 
-func (cmd *GetConnectorCommand) Run(ctx server.Cmd) (err error) {
-	return WithClient(ctx, func(client *httpclient.Client, _ string) error {
+func (cmd *GetConnectorCommand) Run(ctx server.Cmd, remote ServerEndpoint) (err error) {
+	return WithClient(ctx, remote, func(client *httpclient.Client, _ string) error {
 		if _, err := schema.CanonicalURL(cmd.URL); err != nil {
 			return err
 		}
@@ -131,8 +131,8 @@ func (cmd *GetConnectorCommand) Run(ctx server.Cmd) (err error) {
 	})
 }
 
-func (cmd *UpdateConnectorCommand) Run(ctx server.Cmd) (err error) {
-	return WithClient(ctx, func(client *httpclient.Client, _ string) error {
+func (cmd *UpdateConnectorCommand) Run(ctx server.Cmd, remote ServerEndpoint) (err error) {
+	return WithClient(ctx, remote, func(client *httpclient.Client, _ string) error {
 		req, err := cmd.request()
 		if err != nil {
 			return err
@@ -173,8 +173,8 @@ func (cmd UpdateConnectorCommand) request() (schema.ConnectorMeta, error) {
 	return cmd.ConnectorMeta, nil
 }
 
-func (cmd *DeleteConnectorCommand) Run(ctx server.Cmd) (err error) {
-	return WithClient(ctx, func(client *httpclient.Client, _ string) error {
+func (cmd *DeleteConnectorCommand) Run(ctx server.Cmd, remote ServerEndpoint) (err error) {
+	return WithClient(ctx, remote, func(client *httpclient.Client, _ string) error {
 		if _, err := schema.CanonicalURL(cmd.URL); err != nil {
 			return err
 		}
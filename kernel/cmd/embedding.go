@@ -33,7 +33,7 @@ type EmbeddingCommand struct {
 ///////////////////////////////////////////////////////////////////////////////
 // PUBLIC METHODS
 
-func (cmd *EmbeddingCommand) Run(ctx server.Cmd) (err error) {
+func (cmd *EmbeddingCommand) Run(ctx server.Cmd, remote ServerEndpoint) (err error) {
 	if cmd.Model == "" {
 		cmd.Model = ctx.GetString("embedding_model")
 	}
@@ -60,7 +60,7 @@ func (cmd *EmbeddingCommand) Run(ctx server.Cmd) (err error) {
 	req := cmd.EmbeddingRequest
 	req.Input = input
 
-	return WithClient(ctx, func(client *httpclient.Client, _ string) error {
+	return WithClient(ctx, remote, func(client *httpclient.Client, _ string) error {
 		outputPath := cmd.outputPath()
 		parent, endSpan := otel.StartSpan(ctx.Tracer(), ctx.Context(), "EmbeddingCommand",
 			attribute.String("request", types.Stringify(req)),
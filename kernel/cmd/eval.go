@@ -0,0 +1,244 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	// Packages
+	uuid "github.com/google/uuid"
+	otel "github.com/mutablelogic/go-client/pkg/otel"
+	httpclient "github.com/mutablelogic/go-llm/kernel/httpclient"
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	eval "github.com/mutablelogic/go-llm/pkg/eval"
+	server "github.com/mutablelogic/go-server"
+	types "github.com/mutablelogic/go-server/pkg/types"
+	attribute "go.opentelemetry.io/otel/attribute"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+type EvalCommands struct {
+	Eval EvalCommand `cmd:"" name:"eval" help:"Run conversations between a scripted or simulated user and an agent, and check its behaviour." group:"RESPONSES"`
+}
+
+type EvalCommand struct {
+	Scenario []string `arg:"" name:"scenario" help:"YAML files of scenarios to run" type:"existingfile"`
+	Simulate bool     `name:"simulate" help:"Simulate the results of tool calls, without running the tools"`
+	Keep     bool     `name:"keep" help:"Keep the session of each scenario, rather than deleting it"`
+	Format   string   `name:"format" help:"Format of the report" enum:"markdown,json" default:"markdown"`
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+const (
+	evalUserPrompt = "You are role-playing a user who is talking to an assistant. Stay in character, " +
+		"and write only the next message of the user, without quotes or commentary. " +
+		"When you have what you want, or the conversation cannot go further, reply with %q and nothing else.\n\n" +
+		"The user: %s"
+	evalJudgePrompt = "You judge conversations between a user and an assistant. Score how well the " +
+		"assistant meets each criterion from 1 (not at all) to 5 (fully), with a short reason, " +
+		"and return one score for each criterion in the order given."
+)
+
+// Structured output of the judge
+var evalJudgeFormat = schema.JSONSchema(`{
+  "type": "object",
+  "properties": {
+    "scores": {
+      "type": "array",
+      "items": {
+        "type": "object",
+        "properties": {
+          "score": { "type": "integer", "minimum": 1, "maximum": 5 },
+          "reason": { "type": "string" }
+        },
+        "required": ["score", "reason"]
+      }
+    }
+  },
+  "required": ["scores"]
+}`)
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+func (cmd *EvalCommand) Run(ctx server.Cmd, remote ServerEndpoint) (err error) {
+	// Read the scenarios before anything is sent to the server
+	scenarios := make([]eval.Scenario, 0, len(cmd.Scenario))
+	for _, path := range cmd.Scenario {
+		scenario, err := readScenario(path)
+		if err != nil {
+			return err
+		}
+		scenarios = append(scenarios, scenario)
+	}
+
+	return WithClient(ctx, remote, func(client *httpclient.Client, _ string) error {
+		parent, endSpan := otel.StartSpan(ctx.Tracer(), ctx.Context(), "EvalCommand",
+			attribute.StringSlice("scenarios", cmd.Scenario),
+			attribute.Bool("simulate", cmd.Simulate),
+		)
+		defer func() { endSpan(err) }()
+
+		// Run each scenario in turn
+		results := make([]eval.Result, 0, len(scenarios))
+		failed := 0
+		for _, scenario := range scenarios {
+			if ctx.IsTerm() > 0 {
+				fmt.Fprintf(os.Stderr, "eval: %s\n", scenario.Name)
+			}
+			result, err := cmd.run(parent, client, scenario)
+			if err != nil {
+				return err
+			}
+			if !result.Passed {
+				failed++
+			}
+			results = append(results, result)
+			if err := parent.Err(); err != nil {
+				return err
+			}
+		}
+
+		// Write the report
+		if cmd.Format == "json" {
+			data, err := json.MarshalIndent(results, "", "  ")
+			if err != nil {
+				return err
+			}
+			fmt.Println(string(data))
+		} else if err := eval.Markdown(os.Stdout, results); err != nil {
+			return err
+		}
+		if failed > 0 {
+			return fmt.Errorf("%d of %d scenarios failed", failed, len(results))
+		}
+		return nil
+	})
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// readScenario reads a scenario from a YAML file
+func readScenario(path string) (eval.Scenario, error) {
+	r, err := os.Open(path)
+	if err != nil {
+		return eval.Scenario{}, err
+	}
+	defer r.Close()
+	scenario, err := eval.Read(r)
+	if err != nil {
+		return eval.Scenario{}, fmt.Errorf("%s: %w", path, err)
+	}
+	return scenario, nil
+}
+
+// run converses with the agent in a new session, which is deleted
+// afterwards unless it is kept
+func (cmd EvalCommand) run(ctx context.Context, client *httpclient.Client, scenario eval.Scenario) (eval.Result, error) {
+	session, err := client.CreateSession(ctx, schema.SessionInsert{
+		SessionMeta: schema.SessionMeta{
+			GeneratorMeta: scenario.Agent,
+			Title:         types.Ptr("eval: " + scenario.Name),
+			Tags:          []string{"eval"},
+		},
+	})
+	if err != nil {
+		return eval.Result{}, fmt.Errorf("%s: %w", scenario.Name, err)
+	}
+	if !cmd.Keep {
+		defer client.DeleteSession(context.WithoutCancel(ctx), session.ID)
+	}
+	return eval.Run(ctx, scenario,
+		cmd.agentFn(client, session.ID, scenario.Tools),
+		evalUserFn(client, scenario.Agent),
+		evalJudgeFn(client, schema.MergeGeneratorMeta(scenario.Judge, schema.GeneratorMeta{Provider: scenario.Agent.Provider, Model: scenario.Agent.Model})),
+	), nil
+}
+
+// agentFn returns a function which sends a turn to the session, and returns
+// the reply with the tools which were called
+func (cmd EvalCommand) agentFn(client *httpclient.Client, session uuid.UUID, tools []string) eval.AgentFn {
+	chat := client.ChatWithTrace
+	if cmd.Simulate {
+		chat = client.ChatSimulated
+	}
+	return func(ctx context.Context, text string) (eval.Reply, error) {
+		response, err := chat(ctx, schema.ChatRequest{Session: session, Text: text, Tools: tools}, nil)
+		if err != nil {
+			return eval.Reply{}, err
+		}
+		return eval.Reply{
+			Text:  schema.Message{Content: response.Content}.Text(),
+			Tools: response.Trace,
+		}, nil
+	}
+}
+
+// evalUserFn returns a function which simulates the next turn of the user,
+// with the model of the agent when the user has none
+func evalUserFn(client *httpclient.Client, agent schema.GeneratorMeta) eval.UserFn {
+	return func(ctx context.Context, user eval.User, transcript []eval.Exchange) (string, error) {
+		stop := strings.TrimSpace(user.Stop)
+		if stop == "" {
+			stop = eval.DefaultStop
+		}
+		meta := schema.MergeGeneratorMeta(user.GeneratorMeta, schema.GeneratorMeta{Provider: agent.Provider, Model: agent.Model})
+		meta.SystemPrompt = types.Ptr(fmt.Sprintf(evalUserPrompt, stop, user.Persona))
+
+		// The user replies to the transcript so far
+		var b strings.Builder
+		b.WriteString("The conversation so far:\n\n")
+		for _, exchange := range transcript {
+			fmt.Fprintf(&b, "User: %s\n\nAssistant: %s\n\n", exchange.User, exchange.Agent)
+		}
+		b.WriteString("Write the next message of the user.")
+
+		response, err := client.Ask(ctx, schema.AskRequest{AskRequestCore: schema.AskRequestCore{GeneratorMeta: meta, Text: b.String()}}, nil)
+		if err != nil {
+			return "", err
+		}
+		return schema.Message{Content: response.Content}.Text(), nil
+	}
+}
+
+// evalJudgeFn returns a function which scores the transcript against the
+// criteria with the model of the judge
+func evalJudgeFn(client *httpclient.Client, judge schema.GeneratorMeta) eval.JudgeFn {
+	return func(ctx context.Context, criteria []string, transcript []eval.Exchange) ([]eval.Score, error) {
+		judge.SystemPrompt = types.Ptr(evalJudgePrompt)
+		judge.Format = evalJudgeFormat
+
+		var b strings.Builder
+		b.WriteString("Criteria:\n\n")
+		for i, criterion := range criteria {
+			fmt.Fprintf(&b, "%d. %s\n", i+1, criterion)
+		}
+		b.WriteString("\nConversation:\n\n")
+		for _, exchange := range transcript {
+			fmt.Fprintf(&b, "User: %s\n\n", exchange.User)
+			if len(exchange.Tools) > 0 {
+				fmt.Fprintf(&b, "(The assistant called %s)\n\n", strings.Join(exchange.Tools, ", "))
+			}
+			fmt.Fprintf(&b, "Assistant: %s\n\n", exchange.Agent)
+		}
+
+		response, err := client.Ask(ctx, schema.AskRequest{AskRequestCore: schema.AskRequestCore{GeneratorMeta: judge, Text: b.String()}}, nil)
+		if err != nil {
+			return nil, err
+		}
+		var result struct {
+			Scores []eval.Score `json:"scores"`
+		}
+		if err := json.Unmarshal([]byte(schema.Message{Content: response.Content}.Text()), &result); err != nil {
+			return nil, fmt.Errorf("scores: %w", err)
+		}
+		return result.Scores, nil
+	}
+}
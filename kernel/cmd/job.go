@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	// Packages
+	uuid "github.com/google/uuid"
+	otel "github.com/mutablelogic/go-client/pkg/otel"
+	httpclient "github.com/mutablelogic/go-llm/kernel/httpclient"
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	tui "github.com/mutablelogic/go-llm/pkg/tui"
+	server "github.com/mutablelogic/go-server"
+	attribute "go.opentelemetry.io/otel/attribute"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+type JobCommand struct {
+	ID   uuid.UUID     `arg:"" name:"id" help:"Job ID."`
+	Wait time.Duration `name:"wait" help:"Poll the job until it is done or this time has passed, for example 10m." optional:""`
+}
+
+type CancelJobCommand struct {
+	ID uuid.UUID `arg:"" name:"id" help:"Job ID."`
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+// jobPollInterval is the time between polls of a job with --wait
+const jobPollInterval = 2 * time.Second
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+func (cmd *JobCommand) Run(ctx server.Cmd, remote ServerEndpoint) (err error) {
+	return WithClient(ctx, remote, func(client *httpclient.Client, _ string) error {
+		parent, endSpan := otel.StartSpan(ctx.Tracer(), ctx.Context(), "JobCommand",
+			attribute.String("id", cmd.ID.String()),
+		)
+		defer func() { endSpan(err) }()
+
+		job, err := waitJob(parent, client, cmd.ID, cmd.Wait)
+		if err != nil {
+			return err
+		}
+
+		// Print the reply of a completed job, or the job otherwise
+		if job.Status != schema.JobCompleted || job.Result == nil || ctx.IsDebug() {
+			fmt.Println(job)
+			return nil
+		}
+		for _, warning := range job.Result.Warnings {
+			fmt.Fprintln(os.Stderr, "warning:", warning)
+		}
+		return writeMarkdown(os.Stdout, tui.Markdown(markdownOptsForStdout()...), chatResponseText(job.Result))
+	})
+}
+
+func (cmd *CancelJobCommand) Run(ctx server.Cmd, remote ServerEndpoint) (err error) {
+	return WithClient(ctx, remote, func(client *httpclient.Client, _ string) error {
+		parent, endSpan := otel.StartSpan(ctx.Tracer(), ctx.Context(), "CancelJobCommand",
+			attribute.String("id", cmd.ID.String()),
+		)
+		defer func() { endSpan(err) }()
+
+		job, err := client.CancelJob(parent, cmd.ID)
+		if err != nil {
+			return err
+		}
+
+		fmt.Println(job)
+		return nil
+	})
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// waitJob returns the job, polling it until it is done or the wait has passed
+func waitJob(ctx context.Context, client *httpclient.Client, id uuid.UUID, wait time.Duration) (*schema.Job, error) {
+	deadline := time.Now().Add(wait)
+	for {
+		job, err := client.GetJob(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if job.Done() || !time.Now().Before(deadline) {
+			return job, nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(jobPollInterval):
+		}
+	}
+}
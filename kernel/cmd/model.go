@@ -22,10 +22,13 @@ type ModelCommands struct {
 	DownloadModel DownloadModelCommand `cmd:"" name:"model-download" help:"Download a model." group:"MODELS"`
 	DeleteModel   DeleteModelCommand   `cmd:"" name:"model-delete" help:"Delete a model by name." group:"MODELS"`
 	GetModel      GetModelCommand      `cmd:"" name:"model" help:"Get a model by name." group:"MODELS"`
+	LoadModel     LoadModelCommand     `cmd:"" name:"model-load" help:"Load a local model into memory." group:"MODELS"`
+	UnloadModel   UnloadModelCommand   `cmd:"" name:"model-unload" help:"Unload a local model from memory." group:"MODELS"`
 }
 
 type ListModelsCommand struct {
 	schema.ModelListRequest `embed:""`
+	Refresh                 bool `name:"refresh" help:"Fetch the models from the providers rather than the cache." optional:""`
 }
 
 type GetModelCommand struct {
@@ -46,17 +49,31 @@ type DeleteModelCommand struct {
 	Provider string `name:"provider" help:"Provider name" optional:""`
 }
 
+type LoadModelCommand struct {
+	Name     string `arg:"" name:"name" help:"Model name to load"`
+	Provider string `name:"provider" help:"Provider name" optional:""`
+}
+
+type UnloadModelCommand struct {
+	Name     string `arg:"" name:"name" help:"Model name to unload"`
+	Provider string `name:"provider" help:"Provider name" optional:""`
+}
+
 ///////////////////////////////////////////////////////////////////////////////
 // PUBLIC METHODS
 
-func (cmd *ListModelsCommand) Run(ctx server.Cmd) (err error) {
-	return WithClient(ctx, func(client *httpclient.Client, _ string) error {
+func (cmd *ListModelsCommand) Run(ctx server.Cmd, remote ServerEndpoint) (err error) {
+	return WithClient(ctx, remote, func(client *httpclient.Client, _ string) error {
 		parent, endSpan := otel.StartSpan(ctx.Tracer(), ctx.Context(), "ListModelsCommand",
 			attribute.String("request", types.Stringify(cmd.ModelListRequest)),
 		)
 		defer func() { endSpan(err) }()
 
-		models, err := client.ListModels(parent, cmd.ModelListRequest)
+		listModels := client.ListModels
+		if cmd.Refresh {
+			listModels = client.RefreshModels
+		}
+		models, err := listModels(parent, cmd.ModelListRequest)
 		if err != nil {
 			return err
 		}
@@ -71,8 +88,8 @@ func (cmd *ListModelsCommand) Run(ctx server.Cmd) (err error) {
 	})
 }
 
-func (cmd *GetModelCommand) Run(ctx server.Cmd) (err error) {
-	return WithClient(ctx, func(client *httpclient.Client, _ string) error {
+func (cmd *GetModelCommand) Run(ctx server.Cmd, remote ServerEndpoint) (err error) {
+	return WithClient(ctx, remote, func(client *httpclient.Client, _ string) error {
 		if cmd.Name == "" {
 			modelKey, providerKey := cmd.defaultKeys()
 			cmd.Name = ctx.GetString(modelKey)
@@ -127,8 +144,8 @@ func (cmd GetModelCommand) defaultKeys() (string, string) {
 	return "model", "provider"
 }
 
-func (cmd *DownloadModelCommand) Run(ctx server.Cmd) (err error) {
-	return WithClient(ctx, func(client *httpclient.Client, _ string) error {
+func (cmd *DownloadModelCommand) Run(ctx server.Cmd, remote ServerEndpoint) (err error) {
+	return WithClient(ctx, remote, func(client *httpclient.Client, _ string) error {
 		req := schema.DownloadModelRequest{
 			Provider: cmd.Provider,
 			Name:     cmd.Name,
@@ -165,8 +182,8 @@ func (cmd *DownloadModelCommand) Run(ctx server.Cmd) (err error) {
 	})
 }
 
-func (cmd *DeleteModelCommand) Run(ctx server.Cmd) (err error) {
-	return WithClient(ctx, func(client *httpclient.Client, _ string) error {
+func (cmd *DeleteModelCommand) Run(ctx server.Cmd, remote ServerEndpoint) (err error) {
+	return WithClient(ctx, remote, func(client *httpclient.Client, _ string) error {
 		req := schema.DeleteModelRequest{
 			Provider: cmd.Provider,
 			Name:     cmd.Name,
@@ -190,3 +207,55 @@ func (cmd *DeleteModelCommand) Run(ctx server.Cmd) (err error) {
 		return nil
 	})
 }
+
+func (cmd *LoadModelCommand) Run(ctx server.Cmd, remote ServerEndpoint) (err error) {
+	return WithClient(ctx, remote, func(client *httpclient.Client, _ string) error {
+		req := schema.LoadModelRequest{
+			Provider: cmd.Provider,
+			Name:     cmd.Name,
+		}
+
+		parent, endSpan := otel.StartSpan(ctx.Tracer(), ctx.Context(), "LoadModelCommand",
+			attribute.String("request", types.Stringify(req)),
+		)
+		defer func() { endSpan(err) }()
+
+		model, err := client.LoadModel(parent, req)
+		if err != nil {
+			return err
+		}
+
+		if ctx.IsDebug() {
+			fmt.Println(model)
+		} else {
+			fmt.Printf("Loaded model: %s\n", model.Name)
+		}
+		return nil
+	})
+}
+
+func (cmd *UnloadModelCommand) Run(ctx server.Cmd, remote ServerEndpoint) (err error) {
+	return WithClient(ctx, remote, func(client *httpclient.Client, _ string) error {
+		req := schema.UnloadModelRequest{
+			Provider: cmd.Provider,
+			Name:     cmd.Name,
+		}
+
+		parent, endSpan := otel.StartSpan(ctx.Tracer(), ctx.Context(), "UnloadModelCommand",
+			attribute.String("request", types.Stringify(req)),
+		)
+		defer func() { endSpan(err) }()
+
+		model, err := client.UnloadModel(parent, req)
+		if err != nil {
+			return err
+		}
+
+		if ctx.IsDebug() {
+			fmt.Println(model)
+		} else {
+			fmt.Printf("Unloaded model: %s\n", model.Name)
+		}
+		return nil
+	})
+}
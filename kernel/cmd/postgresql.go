@@ -16,6 +16,9 @@ package cmd
 
 import (
 	"context"
+	"net/url"
+	"strconv"
+	"time"
 
 	// Packages
 	pg "github.com/mutablelogic/go-pg"
@@ -29,6 +32,13 @@ import (
 type PostgresFlags struct {
 	Url      string `name:"url" env:"PG_URL" help:"PostgreSQL connection URL"`
 	Password string `name:"password" env:"PG_PASSWORD" help:"PostgreSQL password"`
+
+	// Connection pool, where zero values keep the defaults
+	MaxConns          uint          `name:"max-conns" env:"PG_MAX_CONNS" help:"Maximum number of connections in the pool" optional:""`
+	MinConns          uint          `name:"min-conns" env:"PG_MIN_CONNS" help:"Minimum number of idle connections kept in the pool" optional:""`
+	MaxConnLifetime   time.Duration `name:"max-conn-lifetime" env:"PG_MAX_CONN_LIFETIME" help:"Close connections after this time, for example 1h" optional:""`
+	MaxConnIdleTime   time.Duration `name:"max-conn-idle-time" env:"PG_MAX_CONN_IDLE_TIME" help:"Close idle connections after this time, for example 30m" optional:""`
+	HealthCheckPeriod time.Duration `name:"health-check-period" env:"PG_HEALTH_CHECK_PERIOD" help:"Time between health checks of idle connections, for example 1m" optional:""`
 }
 
 ///////////////////////////////////////////////////////////////////////////////
@@ -39,8 +49,12 @@ func (cmd *PostgresFlags) Connect(ctx server.Cmd) (pg.PoolConn, error) {
 	if cmd.Url == "" {
 		return nil, nil
 	}
+	dsn, err := cmd.url()
+	if err != nil {
+		return nil, err
+	}
 	opts := []pg.Opt{
-		pg.WithURL(cmd.Url),
+		pg.WithURL(dsn),
 		pg.WithTracer(ctx.Tracer()),
 	}
 	if cmd.Password != "" {
@@ -66,3 +80,33 @@ func (cmd *PostgresFlags) Connect(ctx server.Cmd) (pg.PoolConn, error) {
 	// Return success
 	return pool, nil
 }
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// url returns the connection URL with the connection pool parameters, which
+// replace any set in the URL
+func (cmd *PostgresFlags) url() (string, error) {
+	u, err := url.Parse(cmd.Url)
+	if err != nil {
+		return "", err
+	}
+	q := u.Query()
+	if cmd.MaxConns > 0 {
+		q.Set("pool_max_conns", strconv.FormatUint(uint64(cmd.MaxConns), 10))
+	}
+	if cmd.MinConns > 0 {
+		q.Set("pool_min_conns", strconv.FormatUint(uint64(cmd.MinConns), 10))
+	}
+	if cmd.MaxConnLifetime > 0 {
+		q.Set("pool_max_conn_lifetime", cmd.MaxConnLifetime.String())
+	}
+	if cmd.MaxConnIdleTime > 0 {
+		q.Set("pool_max_conn_idle_time", cmd.MaxConnIdleTime.String())
+	}
+	if cmd.HealthCheckPeriod > 0 {
+		q.Set("pool_health_check_period", cmd.HealthCheckPeriod.String())
+	}
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
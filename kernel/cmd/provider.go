@@ -22,8 +22,15 @@ type ProviderCommands struct {
 	DeleteProvider DeleteProviderCommand `cmd:"" name:"provider-delete" help:"Delete a provider by name." group:"PROVIDERS"`
 	GetProvider    GetProviderCommand    `cmd:"" name:"provider" help:"Get a provider by name." group:"PROVIDERS"`
 	UpdateProvider UpdateProviderCommand `cmd:"" name:"provider-update" help:"Update provider metadata." group:"PROVIDERS"`
+	Reload         ReloadCommand         `cmd:"" name:"reload" help:"Reload changed providers, connectors and agents on the server." group:"PROVIDERS"`
+
+	GetProviderCredentials    GetProviderCredentialsCommand    `cmd:"" name:"provider-credentials" help:"Get your own credentials for a provider." group:"PROVIDERS"`
+	SetProviderCredentials    SetProviderCredentialsCommand    `cmd:"" name:"provider-credentials-set" help:"Set your own credentials for a provider, used in place of the server credentials." group:"PROVIDERS"`
+	DeleteProviderCredentials DeleteProviderCredentialsCommand `cmd:"" name:"provider-credentials-delete" help:"Delete your own credentials for a provider." group:"PROVIDERS"`
 }
 
+type ReloadCommand struct{}
+
 type ListProvidersCommand struct {
 	schema.ProviderListRequest `embed:""`
 }
@@ -45,11 +52,24 @@ type UpdateProviderCommand struct {
 	schema.ProviderMeta `embed:""`
 }
 
+type GetProviderCredentialsCommand struct {
+	Name string `arg:"" name:"name" help:"Provider name"`
+}
+
+type SetProviderCredentialsCommand struct {
+	Name                       string `arg:"" name:"name" help:"Provider name"`
+	schema.ProviderCredentials `embed:""`
+}
+
+type DeleteProviderCredentialsCommand struct {
+	Name string `arg:"" name:"name" help:"Provider name"`
+}
+
 ///////////////////////////////////////////////////////////////////////////////
 // PUBLIC METHODS
 
-func (cmd *ListProvidersCommand) Run(ctx server.Cmd) (err error) {
-	return WithClient(ctx, func(client *httpclient.Client, _ string) error {
+func (cmd *ListProvidersCommand) Run(ctx server.Cmd, remote ServerEndpoint) (err error) {
+	return WithClient(ctx, remote, func(client *httpclient.Client, _ string) error {
 		parent, endSpan := otel.StartSpan(ctx.Tracer(), ctx.Context(), "ListProvidersCommand",
 			attribute.String("request", types.Stringify(cmd.ProviderListRequest)),
 		)
@@ -70,8 +90,8 @@ func (cmd *ListProvidersCommand) Run(ctx server.Cmd) (err error) {
 	})
 }
 
-func (cmd *CreateProviderCommand) Run(ctx server.Cmd) (err error) {
-	return WithClient(ctx, func(client *httpclient.Client, _ string) error {
+func (cmd *CreateProviderCommand) Run(ctx server.Cmd, remote ServerEndpoint) (err error) {
+	return WithClient(ctx, remote, func(client *httpclient.Client, _ string) error {
 		req, err := cmd.request()
 		if err != nil {
 			return err
@@ -96,8 +116,8 @@ func (cmd *CreateProviderCommand) Run(ctx server.Cmd) (err error) {
 	})
 }
 
-func (cmd *GetProviderCommand) Run(ctx server.Cmd) (err error) {
-	return WithClient(ctx, func(client *httpclient.Client, _ string) error {
+func (cmd *GetProviderCommand) Run(ctx server.Cmd, remote ServerEndpoint) (err error) {
+	return WithClient(ctx, remote, func(client *httpclient.Client, _ string) error {
 		parent, endSpan := otel.StartSpan(ctx.Tracer(), ctx.Context(), "GetProviderCommand",
 			attribute.String("name", cmd.Name),
 		)
@@ -113,8 +133,8 @@ func (cmd *GetProviderCommand) Run(ctx server.Cmd) (err error) {
 	})
 }
 
-func (cmd *DeleteProviderCommand) Run(ctx server.Cmd) (err error) {
-	return WithClient(ctx, func(client *httpclient.Client, _ string) error {
+func (cmd *DeleteProviderCommand) Run(ctx server.Cmd, remote ServerEndpoint) (err error) {
+	return WithClient(ctx, remote, func(client *httpclient.Client, _ string) error {
 		parent, endSpan := otel.StartSpan(ctx.Tracer(), ctx.Context(), "DeleteProviderCommand",
 			attribute.String("name", cmd.Name),
 		)
@@ -130,8 +150,74 @@ func (cmd *DeleteProviderCommand) Run(ctx server.Cmd) (err error) {
 	})
 }
 
-func (cmd *UpdateProviderCommand) Run(ctx server.Cmd) (err error) {
-	return WithClient(ctx, func(client *httpclient.Client, _ string) error {
+func (cmd *GetProviderCredentialsCommand) Run(ctx server.Cmd, remote ServerEndpoint) (err error) {
+	return WithClient(ctx, remote, func(client *httpclient.Client, _ string) error {
+		parent, endSpan := otel.StartSpan(ctx.Tracer(), ctx.Context(), "GetProviderCredentialsCommand",
+			attribute.String("name", cmd.Name),
+		)
+		defer func() { endSpan(err) }()
+
+		credential, err := client.GetProviderCredentials(parent, cmd.Name)
+		if err != nil {
+			return err
+		}
+
+		fmt.Println(credential)
+		return nil
+	})
+}
+
+func (cmd *SetProviderCredentialsCommand) Run(ctx server.Cmd, remote ServerEndpoint) (err error) {
+	return WithClient(ctx, remote, func(client *httpclient.Client, _ string) error {
+		parent, endSpan := otel.StartSpan(ctx.Tracer(), ctx.Context(), "SetProviderCredentialsCommand",
+			attribute.String("name", cmd.Name),
+		)
+		defer func() { endSpan(err) }()
+
+		credential, err := client.SetProviderCredentials(parent, cmd.Name, cmd.ProviderCredentials)
+		if err != nil {
+			return err
+		}
+
+		fmt.Println(credential)
+		return nil
+	})
+}
+
+func (cmd *DeleteProviderCredentialsCommand) Run(ctx server.Cmd, remote ServerEndpoint) (err error) {
+	return WithClient(ctx, remote, func(client *httpclient.Client, _ string) error {
+		parent, endSpan := otel.StartSpan(ctx.Tracer(), ctx.Context(), "DeleteProviderCredentialsCommand",
+			attribute.String("name", cmd.Name),
+		)
+		defer func() { endSpan(err) }()
+
+		credential, err := client.DeleteProviderCredentials(parent, cmd.Name)
+		if err != nil {
+			return err
+		}
+
+		fmt.Println(credential)
+		return nil
+	})
+}
+
+func (cmd *ReloadCommand) Run(ctx server.Cmd, remote ServerEndpoint) (err error) {
+	return WithClient(ctx, remote, func(client *httpclient.Client, _ string) error {
+		parent, endSpan := otel.StartSpan(ctx.Tracer(), ctx.Context(), "ReloadCommand")
+		defer func() { endSpan(err) }()
+
+		reload, err := client.Reload(parent)
+		if err != nil {
+			return err
+		}
+
+		fmt.Println(reload)
+		return nil
+	})
+}
+
+func (cmd *UpdateProviderCommand) Run(ctx server.Cmd, remote ServerEndpoint) (err error) {
+	return WithClient(ctx, remote, func(client *httpclient.Client, _ string) error {
 		req, err := cmd.request()
 		if err != nil {
 			return err
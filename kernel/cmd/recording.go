@@ -0,0 +1,116 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	// Packages
+	uuid "github.com/google/uuid"
+	otel "github.com/mutablelogic/go-client/pkg/otel"
+	httpclient "github.com/mutablelogic/go-llm/kernel/httpclient"
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	server "github.com/mutablelogic/go-server"
+	attribute "go.opentelemetry.io/otel/attribute"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+type RecordingCommand struct {
+	ID   uuid.UUID `arg:"" name:"id" help:"Recording ID, printed by chat --record."`
+	Step *uint     `name:"step" help:"Show only the step at this position, from zero." optional:""`
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+func (cmd *RecordingCommand) Run(ctx server.Cmd, remote ServerEndpoint) (err error) {
+	return WithClient(ctx, remote, func(client *httpclient.Client, _ string) error {
+		parent, endSpan := otel.StartSpan(ctx.Tracer(), ctx.Context(), "RecordingCommand",
+			attribute.String("id", cmd.ID.String()),
+		)
+		defer func() { endSpan(err) }()
+
+		recording, err := client.GetRecording(parent, cmd.ID, cmd.Step)
+		if err != nil {
+			return err
+		}
+		if ctx.IsDebug() {
+			fmt.Println(recording)
+			return nil
+		}
+
+		// Step through the recording in a terminal, or print every step
+		if cmd.Step == nil && ctx.IsTerm() > 0 {
+			return stepRecording(os.Stdout, os.Stdin, recording)
+		}
+		for _, step := range recording.Steps {
+			if err := writeRecordingStep(os.Stdout, recording, step); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// stepRecording shows one step at a time, reading the next command from r:
+// return or n for the next step, p for the previous step, a number to jump
+// to a step, and q to quit
+func stepRecording(w io.Writer, r io.Reader, recording *schema.Recording) error {
+	if len(recording.Steps) == 0 {
+		_, err := fmt.Fprintln(w, "recording has no steps")
+		return err
+	}
+	input := bufio.NewScanner(r)
+	index := 0
+	for {
+		if err := writeRecordingStep(w, recording, recording.Steps[index]); err != nil {
+			return err
+		}
+		fmt.Fprint(w, "[n]ext, [p]revious, step number or [q]uit: ")
+		if !input.Scan() {
+			fmt.Fprintln(w)
+			return input.Err()
+		}
+		switch command := strings.TrimSpace(strings.ToLower(input.Text())); command {
+		case "", "n":
+			if index+1 >= len(recording.Steps) {
+				return nil
+			}
+			index++
+		case "p":
+			index = max(index-1, 0)
+		case "q":
+			return nil
+		default:
+			if n, err := strconv.Atoi(command); err == nil && n >= 0 && n < len(recording.Steps) {
+				index = n
+			} else {
+				fmt.Fprintf(w, "no step %q\n", command)
+			}
+		}
+	}
+}
+
+// writeRecordingStep writes a heading for the step, followed by its state
+func writeRecordingStep(w io.Writer, recording *schema.Recording, step schema.RecordingStep) error {
+	fmt.Fprintf(w, "\n── step %d (last %d): %s, iteration %d, +%s ──\n",
+		step.Index, max(recording.Count, 1)-1, step.Type, step.Iteration, step.Elapsed.Round(time.Millisecond))
+	var data bytes.Buffer
+	if err := json.Indent(&data, step.Data, "", "  "); err != nil {
+		data.Reset()
+		data.Write(step.Data)
+	}
+	_, err := fmt.Fprintln(w, data.String())
+	return err
+}
@@ -0,0 +1,39 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	// Packages
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	assert "github.com/stretchr/testify/assert"
+)
+
+func TestStepRecording(t *testing.T) {
+	assert := assert.New(t)
+	recording := &schema.Recording{
+		Count: 3,
+		Steps: []schema.RecordingStep{
+			{Index: 0, Type: schema.RecordingStepPlan, Data: json.RawMessage(`{"model":"alpha"}`)},
+			{Index: 1, Type: schema.RecordingStepRequest, Data: json.RawMessage(`[{"role":"user"}]`)},
+			{Index: 2, Type: schema.RecordingStepResult, Iteration: 1, Data: json.RawMessage(`{"result":"stop"}`)},
+		},
+	}
+
+	// Step forward, back, jump to a step, and quit
+	var out bytes.Buffer
+	assert.NoError(stepRecording(&out, strings.NewReader("n\np\n2\n9\nq\n"), recording))
+	text := out.String()
+	assert.Equal(2, strings.Count(text, "step 0 (last 2): plan"))
+	assert.Equal(1, strings.Count(text, "step 1 (last 2): request"))
+	assert.Equal(2, strings.Count(text, "step 2 (last 2): result, iteration 1"))
+	assert.Contains(text, `"model": "alpha"`)
+	assert.Contains(text, `no step "9"`)
+
+	// Stepping past the last step ends the viewer
+	out.Reset()
+	assert.NoError(stepRecording(&out, strings.NewReader("\n\n\n\n"), recording))
+	assert.Equal(3, strings.Count(out.String(), "── step"))
+}
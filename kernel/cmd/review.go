@@ -0,0 +1,258 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	// Packages
+	otel "github.com/mutablelogic/go-client/pkg/otel"
+	httpclient "github.com/mutablelogic/go-llm/kernel/httpclient"
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	git "github.com/mutablelogic/go-llm/pkg/git"
+	server "github.com/mutablelogic/go-server"
+	jsonschema "github.com/mutablelogic/go-server/pkg/jsonschema"
+	types "github.com/mutablelogic/go-server/pkg/types"
+	attribute "go.opentelemetry.io/otel/attribute"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+type ReviewCommands struct {
+	Review ReviewCommand `cmd:"" name:"review" help:"Review the changes on a branch, and print comments on them." group:"RESPONSES"`
+}
+
+type ReviewCommand struct {
+	schema.GeneratorMeta `embed:""`
+	Path                 string `name:"path" help:"Path within the repository." default:"."`
+	Base                 string `name:"base" help:"Branch which the changes are compared with." default:"main"`
+	Staged               bool   `name:"staged" help:"Review staged changes rather than the changes on the branch."`
+	Output               string `name:"output" help:"Output format." enum:"text,json,github" default:"text"`
+	ChunkSize            int    `name:"chunk-size" help:"Maximum size of the diff in bytes which is reviewed in one request." default:"32768"`
+}
+
+// ReviewComment is a comment on a line of a changed file
+type ReviewComment struct {
+	File     string `json:"file" help:"Path of the file, as it appears in the diff"`
+	Line     uint   `json:"line" help:"Line number in the new version of the file"`
+	Severity string `json:"severity" enum:"info,warning,error" help:"Severity of the issue"`
+	Comment  string `json:"comment" help:"Description of the issue and how to fix it"`
+}
+
+// reviewResult is the structured output for a review of one chunk
+type reviewResult struct {
+	Comments []ReviewComment `json:"comments" help:"Comments on the changes, or an empty list if there are no issues"`
+}
+
+// githubReview is the body of a request to create a pull request review
+type githubReview struct {
+	Body     string          `json:"body"`
+	Event    string          `json:"event"`
+	Comments []githubComment `json:"comments"`
+}
+
+type githubComment struct {
+	Path string `json:"path"`
+	Line uint   `json:"line"`
+	Side string `json:"side"`
+	Body string `json:"body"`
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+const reviewPrompt = `You review code changes. The diff is in the unified format, and each
+added or unchanged line in a hunk is prefixed with its line number in the new
+version of the file. Comment only on the changed lines, and only on issues
+which matter: bugs, security problems, race conditions, missing error
+handling, and code which is hard to maintain. Do not comment on formatting or
+praise the change. Use the severity "error" for bugs, "warning" for likely
+problems and "info" for suggestions. Refer to files by the path after "b/" in
+the diff, and to lines by their number in the new version of the file.`
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+func (cmd *ReviewCommand) Run(ctx server.Cmd, remote ServerEndpoint) (err error) {
+	if cmd.Model == nil {
+		if s := ctx.GetString("model"); s != "" {
+			cmd.Model = types.Ptr(s)
+		}
+	}
+	if cmd.Provider == nil {
+		if s := ctx.GetString("provider"); s != "" {
+			cmd.Provider = types.Ptr(s)
+		}
+	}
+	if cmd.Model == nil {
+		return fmt.Errorf("model is required (set with --model or store a default)")
+	}
+
+	// Read the changes
+	repo, err := git.Find(ctx.Context(), cmd.Path)
+	if err != nil {
+		return err
+	}
+	req := git.DiffRequest{Base: cmd.Base}
+	if cmd.Staged {
+		req = git.DiffRequest{Staged: true}
+	}
+	diff, err := repo.Diff(ctx.Context(), req, 0)
+	if err != nil {
+		return err
+	} else if diff.Empty() && cmd.Staged {
+		return fmt.Errorf("no staged changes")
+	} else if diff.Empty() {
+		return fmt.Errorf("no changes since %q", cmd.Base)
+	}
+	chunks := git.Split(diff.Patch, cmd.ChunkSize)
+
+	return WithClient(ctx, remote, func(client *httpclient.Client, _ string) error {
+		parent, endSpan := otel.StartSpan(ctx.Tracer(), ctx.Context(), "ReviewCommand",
+			attribute.String("stat", diff.Stat),
+			attribute.Int("chunks", len(chunks)),
+		)
+		defer func() { endSpan(err) }()
+
+		// Review each chunk in turn
+		var comments []ReviewComment
+		for i, chunk := range chunks {
+			req, err := cmd.request(chunk, i, len(chunks))
+			if err != nil {
+				return err
+			}
+			response, err := client.Ask(parent, req, nil)
+			if err != nil {
+				return err
+			}
+			result, err := reviewComments(askResponseText(response))
+			if err != nil {
+				return fmt.Errorf("chunk %d of %d: %w", i+1, len(chunks), err)
+			}
+			comments = append(comments, result...)
+		}
+		sortReviewComments(comments)
+
+		// Print the comments
+		switch cmd.Output {
+		case "json":
+			return writeJSON(comments)
+		case "github":
+			return writeJSON(githubReviewFor(comments, diff.Truncated))
+		default:
+			if diff.Truncated {
+				fmt.Println("The diff was truncated, so the review is incomplete")
+			}
+			if len(comments) == 0 {
+				fmt.Println("No comments")
+			}
+			for _, comment := range comments {
+				fmt.Printf("%s:%d [%s] %s\n", comment.File, comment.Line, comment.Severity, comment.Comment)
+			}
+			return nil
+		}
+	})
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+func (cmd ReviewCommand) request(chunk string, i, n int) (schema.AskRequest, error) {
+	meta := cmd.GeneratorMeta
+	prompt := reviewPrompt
+	if meta.SystemPrompt != nil && strings.TrimSpace(*meta.SystemPrompt) != "" {
+		prompt += "\n\n" + strings.TrimSpace(*meta.SystemPrompt)
+	}
+	meta.SystemPrompt = types.Ptr(prompt)
+	format, err := json.Marshal(jsonschema.MustFor[reviewResult]())
+	if err != nil {
+		return schema.AskRequest{}, err
+	}
+	meta.Format = schema.JSONSchema(format)
+
+	var text strings.Builder
+	if n > 1 {
+		fmt.Fprintf(&text, "Part %d of %d of the changes:\n\n", i+1, n)
+	} else {
+		text.WriteString("Changes:\n\n")
+	}
+	text.WriteString(git.Number(chunk))
+
+	return schema.AskRequest{
+		AskRequestCore: schema.AskRequestCore{
+			GeneratorMeta: meta,
+			Text:          text.String(),
+		},
+	}, nil
+}
+
+// reviewComments decodes the structured output of a review, dropping
+// comments without a file or text
+func reviewComments(text string) ([]ReviewComment, error) {
+	var result reviewResult
+	if err := json.Unmarshal([]byte(commitMessage(text)), &result); err != nil {
+		return nil, fmt.Errorf("invalid review: %w", err)
+	}
+	comments := make([]ReviewComment, 0, len(result.Comments))
+	for _, comment := range result.Comments {
+		comment.File = strings.TrimPrefix(strings.TrimSpace(comment.File), "b/")
+		comment.Comment = strings.TrimSpace(comment.Comment)
+		if comment.File == "" || comment.Comment == "" {
+			continue
+		}
+		switch comment.Severity {
+		case "info", "warning", "error":
+		default:
+			comment.Severity = "info"
+		}
+		comments = append(comments, comment)
+	}
+	return comments, nil
+}
+
+// sortReviewComments orders comments by file and line
+func sortReviewComments(comments []ReviewComment) {
+	slices.SortStableFunc(comments, func(a, b ReviewComment) int {
+		if c := strings.Compare(a.File, b.File); c != 0 {
+			return c
+		}
+		return int(a.Line) - int(b.Line)
+	})
+}
+
+// githubReviewFor returns a pull request review with a comment on the right
+// side of the diff for each comment which refers to a line
+func githubReviewFor(comments []ReviewComment, truncated bool) githubReview {
+	review := githubReview{Event: "COMMENT", Comments: []githubComment{}}
+	var general []string
+	for _, comment := range comments {
+		body := fmt.Sprintf("**%s**: %s", comment.Severity, comment.Comment)
+		if comment.Line == 0 {
+			general = append(general, fmt.Sprintf("- `%s` %s", comment.File, body))
+			continue
+		}
+		review.Comments = append(review.Comments, githubComment{
+			Path: comment.File,
+			Line: comment.Line,
+			Side: "RIGHT",
+			Body: body,
+		})
+	}
+	review.Body = fmt.Sprintf("Automated review: %d comment(s).", len(comments))
+	if truncated {
+		review.Body += " The diff was truncated, so the review is incomplete."
+	}
+	if len(general) > 0 {
+		review.Body += "\n\n" + strings.Join(general, "\n")
+	}
+	return review
+}
+
+func writeJSON(v any) error {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"encoding/json"
+	"testing"
+
+	// Packages
+	types "github.com/mutablelogic/go-server/pkg/types"
+	assert "github.com/stretchr/testify/assert"
+)
+
+func TestReviewCommandRequest(t *testing.T) {
+	cmd := ReviewCommand{}
+	cmd.Model = types.Ptr("model")
+	req, err := cmd.request("diff --git a/a.txt b/a.txt\n@@ -1 +1 @@\n-old\n+new\n", 1, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, reviewPrompt, types.Value(req.SystemPrompt))
+	assert.Contains(t, req.Text, "Part 2 of 2")
+	assert.Contains(t, req.Text, "    1 +new")
+
+	var format map[string]any
+	assert.NoError(t, json.Unmarshal(req.Format, &format))
+	assert.Contains(t, format["properties"], "comments")
+}
+
+func TestReviewComments(t *testing.T) {
+	comments, err := reviewComments("```json\n" + `{"comments":[
+		{"file":"b/b.go","line":3,"severity":"error","comment":" nil dereference "},
+		{"file":"a.go","line":10,"severity":"critical","comment":"unchecked error"},
+		{"file":"","line":1,"severity":"info","comment":"dropped"}
+	]}` + "\n```")
+	assert.NoError(t, err)
+	sortReviewComments(comments)
+	assert.Equal(t, []ReviewComment{
+		{File: "a.go", Line: 10, Severity: "info", Comment: "unchecked error"},
+		{File: "b.go", Line: 3, Severity: "error", Comment: "nil dereference"},
+	}, comments)
+
+	_, err = reviewComments("not json")
+	assert.Error(t, err)
+}
+
+func TestGithubReview(t *testing.T) {
+	review := githubReviewFor([]ReviewComment{
+		{File: "a.go", Line: 10, Severity: "warning", Comment: "unchecked error"},
+		{File: "b.go", Severity: "info", Comment: "missing tests"},
+	}, true)
+	assert.Equal(t, "COMMENT", review.Event)
+	assert.Contains(t, review.Body, "truncated")
+	assert.Contains(t, review.Body, "`b.go` **info**: missing tests")
+	assert.Equal(t, []githubComment{
+		{Path: "a.go", Line: 10, Side: "RIGHT", Body: "**warning**: unchecked error"},
+	}, review.Comments)
+}
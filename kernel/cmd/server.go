@@ -5,22 +5,30 @@ import (
 	"context"
 	"fmt"
 	"io/fs"
+	"time"
 
 	// Packages
 	authhanders "github.com/mutablelogic/go-auth/auth/httphandler"
 	authmanager "github.com/mutablelogic/go-auth/auth/manager"
+	auth "github.com/mutablelogic/go-auth/auth/schema"
 	client "github.com/mutablelogic/go-client"
 	llm "github.com/mutablelogic/go-llm"
 	agent "github.com/mutablelogic/go-llm/etc/agent"
 	homeassistant "github.com/mutablelogic/go-llm/homeassistant/connector"
 	llmhandlers "github.com/mutablelogic/go-llm/kernel/httphandler"
 	llmmanager "github.com/mutablelogic/go-llm/kernel/manager"
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
 	memory "github.com/mutablelogic/go-llm/memory/manager"
+	git "github.com/mutablelogic/go-llm/pkg/git"
+	discord "github.com/mutablelogic/go-llm/pkg/integrations/discord"
+	email "github.com/mutablelogic/go-llm/pkg/integrations/email"
+	slack "github.com/mutablelogic/go-llm/pkg/integrations/slack"
 	prompt "github.com/mutablelogic/go-llm/toolkit/prompt"
 	pg "github.com/mutablelogic/go-pg"
 	server "github.com/mutablelogic/go-server"
 	cmd "github.com/mutablelogic/go-server/pkg/cmd"
 	httprouter "github.com/mutablelogic/go-server/pkg/httprouter"
+	types "github.com/mutablelogic/go-server/pkg/types"
 	errgroup "golang.org/x/sync/errgroup"
 )
 
@@ -46,6 +54,45 @@ type RunServer struct {
 		APIKey   string `help:"Home Assistant long-lived access token." env:"HA_TOKEN"`
 	} `embed:"" prefix:"homeassistant."`
 
+	// Git tool options
+	Git struct {
+		Root string `help:"Directory containing git repositories which the git tools may read." env:"LLM_GIT_ROOT" optional:""`
+	} `embed:"" prefix:"git."`
+
+	// Slack options
+	Slack struct {
+		AppToken string `name:"app-token" help:"Slack app-level token (xapp-) for Socket Mode." env:"SLACK_APP_TOKEN"`
+		BotToken string `name:"bot-token" help:"Slack bot token (xoxb-)." env:"SLACK_BOT_TOKEN"`
+		APIKey   string `name:"api-key" help:"API key of the user which the Slack bot chats as." env:"SLACK_LLM_KEY"`
+		Provider string `help:"Provider for new Slack sessions." optional:""`
+		Model    string `help:"Model for new Slack sessions." optional:""`
+		Approval bool   `help:"Ask for approval in Slack before a tool is run." default:"false"`
+	} `embed:"" prefix:"slack."`
+
+	// Discord options
+	Discord struct {
+		Token    string `help:"Discord bot token." env:"DISCORD_TOKEN"`
+		APIKey   string `name:"api-key" help:"API key of the user which the Discord bot chats as." env:"DISCORD_LLM_KEY"`
+		Provider string `help:"Provider for new Discord sessions." optional:""`
+		Model    string `help:"Model for new Discord sessions." optional:""`
+	} `embed:"" prefix:"discord."`
+
+	// Email gateway options
+	Email struct {
+		IMAP          string        `name:"imap" help:"IMAP server as host:port, using TLS." env:"EMAIL_IMAP"`
+		SMTP          string        `name:"smtp" help:"SMTP server as host:port, using STARTTLS when available." env:"EMAIL_SMTP"`
+		Username      string        `help:"Username for the IMAP and SMTP servers." env:"EMAIL_USERNAME"`
+		Password      string        `help:"Password for the IMAP and SMTP servers." env:"EMAIL_PASSWORD"`
+		Address       string        `help:"Address which replies are sent from." env:"EMAIL_ADDRESS"`
+		Allow         []string      `help:"Addresses or @domains which may send emails to the gateway." env:"EMAIL_ALLOW"`
+		Interval      time.Duration `help:"Time between polls of the mailbox." default:"1m"`
+		MaxAttachment int           `name:"max-attachment" help:"Largest attachment in bytes which is sent to the model." default:"10485760"`
+		APIKey        string        `name:"api-key" help:"API key of the user which the email gateway chats as." env:"EMAIL_LLM_KEY"`
+		Provider      string        `help:"Provider for new email sessions." optional:""`
+		Model         string        `help:"Model for new email sessions." optional:""`
+		SystemPrompt  string        `name:"system-prompt" help:"System prompt for new email sessions." optional:""`
+	} `embed:"" prefix:"email."`
+
 	// Other flags
 	Passphrases []string `name:"passphrase" env:"${ENV_NAME}_PASSPHRASES" help:"One or more passphrases used to encrypt credentials. "`
 	Auth        bool     `name:"auth" help:"Enable authentication for protected endpoints." default:"true" negatable:""`
@@ -91,6 +138,16 @@ func (runner *RunServer) Run(ctx server.Cmd) error {
 			}
 		}
 
+		// Add the git tools, which read repositories within the root directory
+		if runner.Git.Root != "" {
+			tools, err := git.NewTools(runner.Git.Root)
+			if err != nil {
+				return err
+			} else {
+				opts = append(opts, llmmanager.WithTools(tools...))
+			}
+		}
+
 		return runner.withLLMManager(ctx, conn, opts, func(llmmanager *llmmanager.Manager) error {
 			// Sync providers before starting the server so that any configured providers are available immediately
 			if _, _, err := llmmanager.SyncProviders(ctx.Context()); err != nil {
@@ -100,7 +157,7 @@ func (runner *RunServer) Run(ctx server.Cmd) error {
 			// Register handlers for authmanager and llmmanager
 			runner.Register(func(router *httprouter.Router) error {
 				ctx.Logger().DebugContext(ctx.Context(), "registering authmanager handlers")
-				return authhanders.RegisterManagerHandlers(authmanager, router, runner.Auth)
+				return authhanders.RegisterManagerHandlers(authmanager, runner.Auth)(router)
 			}).Register(func(router *httprouter.Router) error {
 				ctx.Logger().DebugContext(ctx.Context(), "registering llmmanager handlers")
 				return llmhandlers.RegisterHandlers(router, llmmanager, authmanager, runner.Auth)
@@ -124,6 +181,39 @@ func (runner *RunServer) Run(ctx server.Cmd) error {
 				return llmmanager.Run(errorgroup.Context(), ctx.Logger())
 			})
 
+			// Run the slack bot
+			if runner.Slack.AppToken != "" {
+				bot, err := runner.slackBot(ctx, authmanager, llmmanager)
+				if err != nil {
+					return err
+				}
+				errorgroup.Go(func() error {
+					return bot.Run(errorgroup.Context())
+				})
+			}
+
+			// Run the discord bot
+			if runner.Discord.Token != "" {
+				bot, err := runner.discordBot(ctx, authmanager, llmmanager)
+				if err != nil {
+					return err
+				}
+				errorgroup.Go(func() error {
+					return bot.Run(errorgroup.Context())
+				})
+			}
+
+			// Run the email gateway
+			if runner.Email.IMAP != "" {
+				gateway, err := runner.emailGateway(ctx, authmanager, llmmanager)
+				if err != nil {
+					return err
+				}
+				errorgroup.Go(func() error {
+					return gateway.Run(errorgroup.Context())
+				})
+			}
+
 			// Run the server
 			return errorgroup.Wait()
 		})
@@ -155,7 +245,7 @@ func (c *errorgroup) Context() context.Context {
 
 func (server *RunServer) withAuthManager(ctx server.Cmd, conn pg.PoolConn, fn func(manager *authmanager.Manager) error) error {
 	// Create the auth manager
-	authmanager, err := authmanager.New(ctx.Context(), conn, server.authManagerOpts(ctx)...)
+	authmanager, err := authmanager.New(ctx.Context(), conn, ctx.Name(), ctx.Version(), server.authManagerOpts(ctx)...)
 	if err != nil {
 		return err
 	}
@@ -172,6 +262,85 @@ func (server *RunServer) authManagerOpts(ctx server.Cmd) []authmanager.Opt {
 	}
 }
 
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS - INTEGRATIONS
+
+// slackBot returns a bot which chats as the user who owns the API key
+func (server *RunServer) slackBot(ctx server.Cmd, authmanager *authmanager.Manager, llmmanager *llmmanager.Manager) (*slack.Bot, error) {
+	user, err := integrationUser(ctx, authmanager, server.Slack.APIKey)
+	if err != nil {
+		return nil, fmt.Errorf("slack: %w", err)
+	}
+	client, err := slack.New(server.Slack.AppToken, server.Slack.BotToken)
+	if err != nil {
+		return nil, err
+	}
+	opts := []slack.Opt{
+		slack.WithLogger(ctx.Logger()),
+		slack.WithGenerator(integrationGenerator(server.Slack.Provider, server.Slack.Model)),
+	}
+	if server.Slack.Approval {
+		opts = append(opts, slack.WithApproval())
+	}
+	return slack.NewBot(client, llmmanager, user, opts...)
+}
+
+// discordBot returns a bot which chats as the user who owns the API key
+func (server *RunServer) discordBot(ctx server.Cmd, authmanager *authmanager.Manager, llmmanager *llmmanager.Manager) (*discord.Bot, error) {
+	user, err := integrationUser(ctx, authmanager, server.Discord.APIKey)
+	if err != nil {
+		return nil, fmt.Errorf("discord: %w", err)
+	}
+	client, err := discord.New(server.Discord.Token)
+	if err != nil {
+		return nil, err
+	}
+	return discord.NewBot(client, llmmanager, user,
+		discord.WithLogger(ctx.Logger()),
+		discord.WithGenerator(integrationGenerator(server.Discord.Provider, server.Discord.Model)),
+	)
+}
+
+// emailGateway returns a gateway which chats as the user who owns the API key
+func (server *RunServer) emailGateway(ctx server.Cmd, authmanager *authmanager.Manager, llmmanager *llmmanager.Manager) (*email.Gateway, error) {
+	user, err := integrationUser(ctx, authmanager, server.Email.APIKey)
+	if err != nil {
+		return nil, fmt.Errorf("email: %w", err)
+	}
+	meta := integrationGenerator(server.Email.Provider, server.Email.Model)
+	if server.Email.SystemPrompt != "" {
+		meta.SystemPrompt = types.Ptr(server.Email.SystemPrompt)
+	}
+	return email.New(llmmanager, user,
+		email.WithIMAP(server.Email.IMAP, server.Email.Username, server.Email.Password),
+		email.WithSMTP(server.Email.SMTP, server.Email.Username, server.Email.Password),
+		email.WithAddress(server.Email.Address),
+		email.WithAllow(server.Email.Allow...),
+		email.WithInterval(server.Email.Interval),
+		email.WithMaxAttachment(server.Email.MaxAttachment),
+		email.WithLogger(ctx.Logger()),
+		email.WithGenerator(meta),
+	)
+}
+
+// integrationUser returns the user who owns an API key
+func integrationUser(ctx server.Cmd, authmanager *authmanager.Manager, key string) (*auth.UserInfo, error) {
+	user, _, err := authmanager.AuthenticateKey(ctx.Context(), key)
+	return user, err
+}
+
+// integrationGenerator returns the generator settings for new sessions
+func integrationGenerator(provider, model string) schema.GeneratorMeta {
+	var meta schema.GeneratorMeta
+	if provider != "" {
+		meta.Provider = types.Ptr(provider)
+	}
+	if model != "" {
+		meta.Model = types.Ptr(model)
+	}
+	return meta
+}
+
 ///////////////////////////////////////////////////////////////////////////////
 // PRIVATE METHODS - LLM MANAGER
 
@@ -1,7 +1,10 @@
 package cmd
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
+	"os"
 
 	// Packages
 	uuid "github.com/google/uuid"
@@ -18,12 +21,28 @@ import (
 // TYPES
 
 type SessionCommands struct {
-	ListSessions  ListSessionsCommand  `cmd:"" name:"sessions" help:"List sessions." group:"SESSIONS"`
-	ListMessages  ListMessagesCommand  `cmd:"" name:"session-messages" help:"List messages for a session." group:"SESSIONS"`
-	CreateSession CreateSessionCommand `cmd:"" name:"session-create" help:"Create a new session." group:"SESSIONS"`
-	GetSession    GetSessionCommand    `cmd:"" name:"session" help:"Get a session by ID or the stored current session." group:"SESSIONS"`
-	UpdateSession UpdateSessionCommand `cmd:"" name:"session-update" help:"Update session metadata." group:"SESSIONS"`
-	DeleteSession DeleteSessionCommand `cmd:"" name:"session-delete" help:"Delete a session by ID." group:"SESSIONS"`
+	ListSessions   ListSessionsCommand   `cmd:"" name:"sessions" help:"List sessions." group:"SESSIONS"`
+	ListMessages   ListMessagesCommand   `cmd:"" name:"session-messages" help:"List messages for a session." group:"SESSIONS"`
+	AppendMessage  AppendMessageCommand  `cmd:"" name:"session-append" help:"Append input to a session, to be sent with the next chat turn." group:"SESSIONS"`
+	PinMessage     PinMessageCommand     `cmd:"" name:"session-pin" help:"Pin a message so it is never trimmed from the context window." group:"SESSIONS"`
+	CreateThread   CreateThreadCommand   `cmd:"" name:"session-thread" help:"Start a side thread on a message, and make it the current session." group:"SESSIONS"`
+	ListThreads    ListThreadsCommand    `cmd:"" name:"session-threads" help:"List the side threads on a message." group:"SESSIONS"`
+	CreateSession  CreateSessionCommand  `cmd:"" name:"session-create" help:"Create a new session." group:"SESSIONS"`
+	GetSession     GetSessionCommand     `cmd:"" name:"session" help:"Get a session by ID or the stored current session." group:"SESSIONS"`
+	SyncSession    SyncSessionCommand    `cmd:"" name:"session-sync" help:"Show the changes to a session since a cursor." group:"SESSIONS"`
+	MergeSession   MergeSessionCommand   `cmd:"" name:"session-merge" help:"Merge the messages of another session into a session." group:"SESSIONS"`
+	ShareSession   ShareSessionCommand   `cmd:"" name:"session-share" help:"Create or list read-only share links for a session." group:"SESSIONS"`
+	UnshareSession UnshareSessionCommand `cmd:"" name:"session-unshare" help:"Revoke a read-only share link for a session." group:"SESSIONS"`
+	SessionBudget  SessionBudgetCommand  `cmd:"" name:"session-budget" help:"Show the tokens for each message of a session against the context window of the model." group:"SESSIONS"`
+	Reasoning      ReasoningCommand      `cmd:"" name:"session-reasoning" help:"Show the thinking of models recorded apart from a session (admin)." group:"SESSIONS"`
+	UpdateSession  UpdateSessionCommand  `cmd:"" name:"session-update" help:"Update session metadata." group:"SESSIONS"`
+	DeleteSession  DeleteSessionCommand  `cmd:"" name:"session-delete" help:"Delete a session by ID." group:"SESSIONS"`
+	DeleteSessions DeleteSessionsCommand `cmd:"" name:"sessions-delete" help:"Delete the sessions matching a parent, user, title or tags." group:"SESSIONS"`
+	DeleteData     DeleteDataCommand     `cmd:"" name:"data-delete" help:"Delete all sessions and data with a label." group:"SESSIONS"`
+	Import         ImportCommand         `cmd:"" name:"import" help:"Import conversations exported from ChatGPT or Claude." group:"SESSIONS"`
+	Topics         TopicsCommand         `cmd:"" name:"topics" help:"Group sessions into topics using an embedding model." group:"SESSIONS"`
+	Analytics      AnalyticsCommand      `cmd:"" name:"analytics" help:"Show usage, model or tool analytics." group:"SESSIONS"`
+	Diff           DiffCommand           `cmd:"" name:"diff" help:"Compare the messages of two exported conversations." group:"SESSIONS"`
 }
 
 type ListSessionsCommand struct {
@@ -35,6 +54,30 @@ type ListMessagesCommand struct {
 	schema.MessageListRequest `embed:""`
 }
 
+type AppendMessageCommand struct {
+	Session uuid.UUID `name:"session" help:"Session ID (defaults to the stored current session)." optional:""`
+	Text    string    `arg:"" help:"User input text" optional:""`
+	Send    bool      `name:"send" help:"Generate a reply for the text and all pending input." optional:""`
+}
+
+type PinMessageCommand struct {
+	Session uuid.UUID `name:"session" help:"Session ID (defaults to the stored current session)." optional:""`
+	ID      uint64    `arg:"" name:"message" help:"Message ID."`
+	Unpin   bool      `name:"unpin" help:"Remove the pin from the message." optional:""`
+}
+
+type CreateThreadCommand struct {
+	Session            uuid.UUID `name:"session" help:"Session ID (defaults to the stored current session)." optional:""`
+	ID                 uint64    `arg:"" name:"message" help:"Message ID."`
+	schema.SessionMeta `embed:""`
+}
+
+type ListThreadsCommand struct {
+	Session                  uuid.UUID `name:"session" help:"Session ID (defaults to the stored current session)." optional:""`
+	ID                       uint64    `arg:"" name:"message" help:"Message ID."`
+	schema.ThreadListRequest `embed:""`
+}
+
 type CreateSessionCommand struct {
 	schema.SessionInsert `embed:""`
 }
@@ -42,12 +85,73 @@ type CreateSessionCommand struct {
 type GetSessionCommand struct {
 	ID      uuid.UUID `arg:"" name:"id" help:"Session ID (defaults to the stored current session)." optional:""`
 	Default bool      `name:"default" help:"Save as the default session" optional:""`
+	Summary bool      `name:"summary" help:"Show the number of messages, without the generator settings" optional:""`
+}
+
+type SyncSessionCommand struct {
+	ID                        uuid.UUID `arg:"" name:"id" help:"Session ID (defaults to the stored current session)." optional:""`
+	schema.SessionSyncRequest `embed:""`
+}
+
+type MergeSessionCommand struct {
+	Source   uuid.UUID `arg:"" name:"source" help:"Session whose messages are merged."`
+	ID       uuid.UUID `name:"into" help:"Session the messages are merged into (defaults to the stored current session)." optional:""`
+	Strategy string    `name:"strategy" help:"Append the messages, or interleave the turns of both sessions by time." enum:"append,interleave" default:"append"`
+}
+
+type ShareSessionCommand struct {
+	ID                         uuid.UUID `arg:"" name:"id" help:"Session ID (defaults to the stored current session)." optional:""`
+	List                       bool      `name:"list" help:"List the share links which have not expired, instead of creating one." optional:""`
+	schema.SessionShareRequest `embed:""`
+}
+
+type UnshareSessionCommand struct {
+	Session uuid.UUID `name:"session" help:"Session ID (defaults to the stored current session)." optional:""`
+	ID      uuid.UUID `arg:"" name:"share" help:"Share link ID."`
+}
+
+type SessionBudgetCommand struct {
+	ID uuid.UUID `arg:"" name:"id" help:"Session ID (defaults to the stored current session)." optional:""`
+}
+
+type ReasoningCommand struct {
+	ID uuid.UUID `arg:"" name:"id" help:"Session ID (defaults to the stored current session)." optional:""`
 }
 
 type DeleteSessionCommand struct {
 	ID uuid.UUID `arg:"" name:"id" help:"Session ID."`
 }
 
+type DeleteSessionsCommand struct {
+	schema.SessionBulkRequest `embed:""`
+	Progress                  bool `name:"progress" help:"Show deletion progress" default:"true" negatable:""`
+}
+
+type DeleteDataCommand struct {
+	Label string `arg:"" name:"label" help:"Session label identifying the data subject, for example user:123."`
+}
+
+type TopicsCommand struct {
+	schema.TopicRequest `embed:""`
+}
+
+type AnalyticsCommand struct {
+	Report                  string `arg:"" name:"report" help:"Analytics report." enum:"usage,models,tools" default:"usage"`
+	schema.AnalyticsRequest `embed:""`
+}
+
+type ImportCommand struct {
+	Format             string `arg:"" name:"format" help:"Export format." enum:"chatgpt,claude"`
+	File               string `arg:"" name:"file" help:"Exported conversations.json file." type:"existingfile"`
+	schema.SessionMeta `embed:""`
+}
+
+type DiffCommand struct {
+	A        string `arg:"" name:"a" help:"Exported messages to compare from." type:"existingfile"`
+	B        string `arg:"" name:"b" help:"Exported messages to compare to." type:"existingfile"`
+	ExitCode bool   `name:"exit-code" help:"Return an error when the conversations differ." optional:""`
+}
+
 type UpdateSessionCommand struct {
 	ID                 uuid.UUID `arg:"" name:"id" help:"Session ID (defaults to the stored current session)." optional:""`
 	schema.SessionMeta `embed:""`
@@ -56,8 +160,8 @@ type UpdateSessionCommand struct {
 ///////////////////////////////////////////////////////////////////////////////
 // PUBLIC METHODS
 
-func (cmd *ListSessionsCommand) Run(ctx server.Cmd) (err error) {
-	return WithClient(ctx, func(client *httpclient.Client, _ string) error {
+func (cmd *ListSessionsCommand) Run(ctx server.Cmd, remote ServerEndpoint) (err error) {
+	return WithClient(ctx, remote, func(client *httpclient.Client, _ string) error {
 		parent, endSpan := otel.StartSpan(ctx.Tracer(), ctx.Context(), "ListSessionsCommand",
 			attribute.String("request", types.Stringify(cmd.SessionListRequest)),
 		)
@@ -77,13 +181,13 @@ func (cmd *ListSessionsCommand) Run(ctx server.Cmd) (err error) {
 	})
 }
 
-func (cmd *ListMessagesCommand) Run(ctx server.Cmd) (err error) {
+func (cmd *ListMessagesCommand) Run(ctx server.Cmd, remote ServerEndpoint) (err error) {
 	id, err := resolveSessionID(cmd.Session, ctx.GetString("session"))
 	if err != nil {
 		return err
 	}
 
-	return WithClient(ctx, func(client *httpclient.Client, _ string) error {
+	return WithClient(ctx, remote, func(client *httpclient.Client, _ string) error {
 		parent, endSpan := otel.StartSpan(ctx.Tracer(), ctx.Context(), "ListMessagesCommand",
 			attribute.String("session", id.String()),
 			attribute.String("request", types.Stringify(cmd.MessageListRequest)),
@@ -104,7 +208,115 @@ func (cmd *ListMessagesCommand) Run(ctx server.Cmd) (err error) {
 	})
 }
 
-func (cmd *CreateSessionCommand) Run(ctx server.Cmd) (err error) {
+func (cmd *AppendMessageCommand) Run(ctx server.Cmd, remote ServerEndpoint) (err error) {
+	id, err := resolveSessionID(cmd.Session, ctx.GetString("session"))
+	if err != nil {
+		return err
+	}
+	if !cmd.Send && cmd.Text == "" {
+		return fmt.Errorf("text is required unless sending pending input")
+	}
+
+	return WithClient(ctx, remote, func(client *httpclient.Client, _ string) error {
+		parent, endSpan := otel.StartSpan(ctx.Tracer(), ctx.Context(), "AppendMessageCommand",
+			attribute.String("session", id.String()),
+			attribute.Bool("send", cmd.Send),
+		)
+		defer func() { endSpan(err) }()
+
+		req := schema.MessageCreateRequest{Text: cmd.Text}
+		if !cmd.Send {
+			message, err := client.AppendMessage(parent, id, req)
+			if err != nil {
+				return err
+			}
+			fmt.Println(message)
+			return nil
+		}
+
+		response, err := client.SendMessage(parent, id, req)
+		if err != nil {
+			return err
+		}
+		fmt.Println(response)
+		return nil
+	})
+}
+
+func (cmd *PinMessageCommand) Run(ctx server.Cmd, remote ServerEndpoint) (err error) {
+	id, err := resolveSessionID(cmd.Session, ctx.GetString("session"))
+	if err != nil {
+		return err
+	}
+
+	return WithClient(ctx, remote, func(client *httpclient.Client, _ string) error {
+		parent, endSpan := otel.StartSpan(ctx.Tracer(), ctx.Context(), "PinMessageCommand",
+			attribute.String("session", id.String()),
+			attribute.Int64("message", int64(cmd.ID)),
+			attribute.Bool("unpin", cmd.Unpin),
+		)
+		defer func() { endSpan(err) }()
+
+		message, err := client.UpdateMessage(parent, id, cmd.ID, schema.MessageMeta{Pinned: types.Ptr(!cmd.Unpin)})
+		if err != nil {
+			return err
+		}
+		fmt.Println(message)
+		return nil
+	})
+}
+
+func (cmd *CreateThreadCommand) Run(ctx server.Cmd, remote ServerEndpoint) (err error) {
+	id, err := resolveSessionID(cmd.Session, ctx.GetString("session"))
+	if err != nil {
+		return err
+	}
+
+	return WithClient(ctx, remote, func(client *httpclient.Client, _ string) error {
+		parent, endSpan := otel.StartSpan(ctx.Tracer(), ctx.Context(), "CreateThreadCommand",
+			attribute.String("session", id.String()),
+			attribute.Int64("message", int64(cmd.ID)),
+			attribute.String("request", cmd.SessionMeta.String()),
+		)
+		defer func() { endSpan(err) }()
+
+		thread, err := client.CreateThread(parent, id, cmd.ID, cmd.SessionMeta)
+		if err != nil {
+			return err
+		}
+		if err := ctx.Set("session", thread.ID.String()); err != nil {
+			return err
+		}
+
+		fmt.Println(thread)
+		return nil
+	})
+}
+
+func (cmd *ListThreadsCommand) Run(ctx server.Cmd, remote ServerEndpoint) (err error) {
+	id, err := resolveSessionID(cmd.Session, ctx.GetString("session"))
+	if err != nil {
+		return err
+	}
+
+	return WithClient(ctx, remote, func(client *httpclient.Client, _ string) error {
+		parent, endSpan := otel.StartSpan(ctx.Tracer(), ctx.Context(), "ListThreadsCommand",
+			attribute.String("session", id.String()),
+			attribute.Int64("message", int64(cmd.ID)),
+		)
+		defer func() { endSpan(err) }()
+
+		threads, err := client.ListThreads(parent, id, cmd.ID, cmd.ThreadListRequest)
+		if err != nil {
+			return err
+		}
+
+		fmt.Println(threads)
+		return nil
+	})
+}
+
+func (cmd *CreateSessionCommand) Run(ctx server.Cmd, remote ServerEndpoint) (err error) {
 	// Only load defaults and require a model when no parent is set.
 	// With a parent, the model/provider are inherited server-side.
 	if cmd.Parent == uuid.Nil {
@@ -123,7 +335,7 @@ func (cmd *CreateSessionCommand) Run(ctx server.Cmd) (err error) {
 		}
 	}
 
-	return WithClient(ctx, func(client *httpclient.Client, _ string) error {
+	return WithClient(ctx, remote, func(client *httpclient.Client, _ string) error {
 		parent, endSpan := otel.StartSpan(ctx.Tracer(), ctx.Context(), "CreateSessionCommand",
 			attribute.String("request", types.Stringify(cmd.SessionInsert)),
 		)
@@ -142,18 +354,30 @@ func (cmd *CreateSessionCommand) Run(ctx server.Cmd) (err error) {
 	})
 }
 
-func (cmd *GetSessionCommand) Run(ctx server.Cmd) (err error) {
+func (cmd *GetSessionCommand) Run(ctx server.Cmd, remote ServerEndpoint) (err error) {
 	id, err := resolveSessionID(cmd.ID, ctx.GetString("session"))
 	if err != nil {
 		return err
 	}
 
-	return WithClient(ctx, func(client *httpclient.Client, _ string) error {
+	return WithClient(ctx, remote, func(client *httpclient.Client, _ string) error {
 		parent, endSpan := otel.StartSpan(ctx.Tracer(), ctx.Context(), "GetSessionCommand",
 			attribute.String("id", id.String()),
 		)
 		defer func() { endSpan(err) }()
 
+		if cmd.Summary {
+			summary, err := client.GetSessionSummary(parent, id)
+			if err != nil {
+				return err
+			}
+			if err := maybeStoreDefaultSession(ctx, summary.ID, cmd.Default); err != nil {
+				return err
+			}
+			fmt.Println(summary)
+			return nil
+		}
+
 		session, err := client.GetSession(parent, id)
 		if err != nil {
 			return err
@@ -167,6 +391,152 @@ func (cmd *GetSessionCommand) Run(ctx server.Cmd) (err error) {
 	})
 }
 
+func (cmd *SyncSessionCommand) Run(ctx server.Cmd, remote ServerEndpoint) (err error) {
+	id, err := resolveSessionID(cmd.ID, ctx.GetString("session"))
+	if err != nil {
+		return err
+	}
+
+	return WithClient(ctx, remote, func(client *httpclient.Client, _ string) error {
+		parent, endSpan := otel.StartSpan(ctx.Tracer(), ctx.Context(), "SyncSessionCommand",
+			attribute.String("id", id.String()),
+			attribute.String("request", cmd.SessionSyncRequest.String()),
+		)
+		defer func() { endSpan(err) }()
+
+		sync, err := client.SyncSession(parent, id, cmd.SessionSyncRequest)
+		if err != nil {
+			return err
+		}
+
+		fmt.Println(sync)
+		return nil
+	})
+}
+
+func (cmd *MergeSessionCommand) Run(ctx server.Cmd, remote ServerEndpoint) (err error) {
+	id, err := resolveSessionID(cmd.ID, ctx.GetString("session"))
+	if err != nil {
+		return err
+	}
+
+	return WithClient(ctx, remote, func(client *httpclient.Client, _ string) error {
+		parent, endSpan := otel.StartSpan(ctx.Tracer(), ctx.Context(), "MergeSessionCommand",
+			attribute.String("id", id.String()),
+			attribute.String("source", cmd.Source.String()),
+			attribute.String("strategy", cmd.Strategy),
+		)
+		defer func() { endSpan(err) }()
+
+		merge, err := client.MergeSession(parent, id, schema.SessionMergeRequest{Source: cmd.Source, Strategy: cmd.Strategy})
+		if err != nil {
+			return err
+		}
+
+		fmt.Println(merge)
+		return nil
+	})
+}
+
+func (cmd *ShareSessionCommand) Run(ctx server.Cmd, remote ServerEndpoint) (err error) {
+	id, err := resolveSessionID(cmd.ID, ctx.GetString("session"))
+	if err != nil {
+		return err
+	}
+
+	return WithClient(ctx, remote, func(client *httpclient.Client, _ string) error {
+		parent, endSpan := otel.StartSpan(ctx.Tracer(), ctx.Context(), "ShareSessionCommand",
+			attribute.String("id", id.String()),
+			attribute.String("request", cmd.SessionShareRequest.String()),
+		)
+		defer func() { endSpan(err) }()
+
+		if cmd.List {
+			shares, err := client.ListSessionShares(parent, id)
+			if err != nil {
+				return err
+			}
+			fmt.Println(shares)
+			return nil
+		}
+
+		share, err := client.CreateSessionShare(parent, id, cmd.SessionShareRequest)
+		if err != nil {
+			return err
+		}
+
+		fmt.Println(share)
+		return nil
+	})
+}
+
+func (cmd *UnshareSessionCommand) Run(ctx server.Cmd, remote ServerEndpoint) (err error) {
+	id, err := resolveSessionID(cmd.Session, ctx.GetString("session"))
+	if err != nil {
+		return err
+	}
+
+	return WithClient(ctx, remote, func(client *httpclient.Client, _ string) error {
+		parent, endSpan := otel.StartSpan(ctx.Tracer(), ctx.Context(), "UnshareSessionCommand",
+			attribute.String("id", id.String()),
+			attribute.String("share", cmd.ID.String()),
+		)
+		defer func() { endSpan(err) }()
+
+		share, err := client.DeleteSessionShare(parent, id, cmd.ID)
+		if err != nil {
+			return err
+		}
+
+		fmt.Println(share)
+		return nil
+	})
+}
+
+func (cmd *ReasoningCommand) Run(ctx server.Cmd, remote ServerEndpoint) (err error) {
+	id, err := resolveSessionID(cmd.ID, ctx.GetString("session"))
+	if err != nil {
+		return err
+	}
+
+	return WithClient(ctx, remote, func(client *httpclient.Client, _ string) error {
+		parent, endSpan := otel.StartSpan(ctx.Tracer(), ctx.Context(), "ReasoningCommand",
+			attribute.String("id", id.String()),
+		)
+		defer func() { endSpan(err) }()
+
+		reasoning, err := client.ListSessionReasoning(parent, id)
+		if err != nil {
+			return err
+		}
+
+		fmt.Println(reasoning)
+		return nil
+	})
+}
+
+func (cmd *SessionBudgetCommand) Run(ctx server.Cmd, remote ServerEndpoint) (err error) {
+	id, err := resolveSessionID(cmd.ID, ctx.GetString("session"))
+	if err != nil {
+		return err
+	}
+
+	return WithClient(ctx, remote, func(client *httpclient.Client, _ string) error {
+		parent, endSpan := otel.StartSpan(ctx.Tracer(), ctx.Context(), "SessionBudgetCommand",
+			attribute.String("id", id.String()),
+		)
+		defer func() { endSpan(err) }()
+
+		budget, err := client.SessionBudget(parent, id)
+		if err != nil {
+			return err
+		}
+
+		fmt.Println(budget)
+		return nil
+	})
+}
+
 type sessionSetter interface {
 	Set(string, any) error
 }
@@ -192,8 +562,8 @@ func resolveSessionID(id uuid.UUID, stored string) (uuid.UUID, error) {
 	return parsed, nil
 }
 
-func (cmd *DeleteSessionCommand) Run(ctx server.Cmd) (err error) {
-	return WithClient(ctx, func(client *httpclient.Client, _ string) error {
+func (cmd *DeleteSessionCommand) Run(ctx server.Cmd, remote ServerEndpoint) (err error) {
+	return WithClient(ctx, remote, func(client *httpclient.Client, _ string) error {
 		parent, endSpan := otel.StartSpan(ctx.Tracer(), ctx.Context(), "DeleteSessionCommand",
 			attribute.String("id", cmd.ID.String()),
 		)
@@ -209,13 +579,159 @@ func (cmd *DeleteSessionCommand) Run(ctx server.Cmd) (err error) {
 	})
 }
 
-func (cmd *UpdateSessionCommand) Run(ctx server.Cmd) (err error) {
+func (cmd *DeleteSessionsCommand) Run(ctx server.Cmd, remote ServerEndpoint) (err error) {
+	return WithClient(ctx, remote, func(client *httpclient.Client, _ string) error {
+		parent, endSpan := otel.StartSpan(ctx.Tracer(), ctx.Context(), "DeleteSessionsCommand",
+			attribute.String("request", cmd.SessionBulkRequest.String()),
+		)
+		defer func() { endSpan(err) }()
+
+		var progressFn func(string, float64)
+		if cmd.Progress && !cmd.DryRun {
+			widget := tui.Progress(tui.SetWidth(max(10, min(20, ctx.IsTerm()/3))))
+			progressFn = func(status string, percent float64) {
+				fmt.Print("\r")
+				_, _ = widget.Write(os.Stdout, status, percent)
+			}
+		}
+
+		result, err := client.DeleteSessions(parent, cmd.SessionBulkRequest, progressFn)
+		if progressFn != nil {
+			fmt.Println()
+		}
+		if err != nil {
+			return err
+		}
+
+		fmt.Println(result)
+		return nil
+	})
+}
+
+func (cmd *DeleteDataCommand) Run(ctx server.Cmd, remote ServerEndpoint) (err error) {
+	return WithClient(ctx, remote, func(client *httpclient.Client, _ string) error {
+		parent, endSpan := otel.StartSpan(ctx.Tracer(), ctx.Context(), "DeleteDataCommand",
+			attribute.String("label", cmd.Label),
+		)
+		defer func() { endSpan(err) }()
+
+		report, err := client.DeleteData(parent, schema.DataDeleteRequest{Label: cmd.Label})
+		if err != nil {
+			return err
+		}
+
+		fmt.Println(report)
+		return nil
+	})
+}
+
+func (cmd *TopicsCommand) Run(ctx server.Cmd, remote ServerEndpoint) (err error) {
+	// Use the stored embedding model and provider by default
+	if cmd.Model == "" {
+		cmd.Model = ctx.GetString("embedding_model")
+	}
+	if cmd.Provider == "" {
+		cmd.Provider = ctx.GetString("embedding_provider")
+	}
+	if cmd.Model == "" {
+		return fmt.Errorf("embedding model is required (set with --model or store a default)")
+	}
+
+	return WithClient(ctx, remote, func(client *httpclient.Client, _ string) error {
+		parent, endSpan := otel.StartSpan(ctx.Tracer(), ctx.Context(), "TopicsCommand",
+			attribute.String("request", cmd.TopicRequest.String()),
+		)
+		defer func() { endSpan(err) }()
+
+		report, err := client.Topics(parent, cmd.TopicRequest)
+		if err != nil {
+			return err
+		}
+
+		fmt.Println(report)
+		return nil
+	})
+}
+
+func (cmd *AnalyticsCommand) Run(ctx server.Cmd, remote ServerEndpoint) (err error) {
+	return WithClient(ctx, remote, func(client *httpclient.Client, _ string) error {
+		parent, endSpan := otel.StartSpan(ctx.Tracer(), ctx.Context(), "AnalyticsCommand",
+			attribute.String("report", cmd.Report),
+			attribute.String("request", cmd.AnalyticsRequest.String()),
+		)
+		defer func() { endSpan(err) }()
+
+		var report fmt.Stringer
+		switch cmd.Report {
+		case "models":
+			report, err = client.ModelAnalytics(parent, cmd.AnalyticsRequest)
+		case "tools":
+			report, err = client.ToolAnalytics(parent, cmd.AnalyticsRequest)
+		default:
+			report, err = client.UsageAnalytics(parent, cmd.AnalyticsRequest)
+		}
+		if err != nil {
+			return err
+		}
+
+		fmt.Println(report)
+		return nil
+	})
+}
+
+func (cmd *ImportCommand) Run(ctx server.Cmd, remote ServerEndpoint) (err error) {
+	// Continue imported sessions with the default model and provider
+	if cmd.Model == nil {
+		if s := ctx.GetString("model"); s != "" {
+			cmd.Model = types.Ptr(s)
+		}
+	}
+	if cmd.Provider == nil {
+		if s := ctx.GetString("provider"); s != "" {
+			cmd.Provider = types.Ptr(s)
+		}
+	}
+	if cmd.Model == nil {
+		return fmt.Errorf("model is required (set with --model or store a default)")
+	}
+
+	data, err := os.ReadFile(cmd.File)
+	if err != nil {
+		return err
+	}
+
+	return WithClient(ctx, remote, func(client *httpclient.Client, _ string) error {
+		parent, endSpan := otel.StartSpan(ctx.Tracer(), ctx.Context(), "ImportCommand",
+			attribute.String("format", cmd.Format),
+			attribute.String("file", cmd.File),
+		)
+		defer func() { endSpan(err) }()
+
+		response, err := client.ImportSessions(parent, schema.SessionImportRequest{
+			SessionMeta: cmd.SessionMeta,
+			Format:      cmd.Format,
+			Data:        data,
+		})
+		if err != nil {
+			return err
+		}
+
+		if ctx.IsDebug() {
+			fmt.Println(response)
+			return nil
+		}
+
+		return writeListTable(response.Body, 0, uint64(response.Count), tui.SetWidth(ctx.IsTerm()))
+	})
+}
+
+func (cmd *UpdateSessionCommand) Run(ctx server.Cmd, remote ServerEndpoint) (err error) {
 	id, err := resolveSessionID(cmd.ID, ctx.GetString("session"))
 	if err != nil {
 		return err
 	}
 
-	return WithClient(ctx, func(client *httpclient.Client, _ string) error {
+	return WithClient(ctx, remote, func(client *httpclient.Client, _ string) error {
 		parent, endSpan := otel.StartSpan(ctx.Tracer(), ctx.Context(), "UpdateSessionCommand",
 			attribute.String("id", id.String()),
 			attribute.String("meta", types.Stringify(cmd.SessionMeta)),
@@ -231,3 +747,59 @@ func (cmd *UpdateSessionCommand) Run(ctx server.Cmd) (err error) {
 		return nil
 	})
 }
+
+func (cmd *DiffCommand) Run(ctx server.Cmd) error {
+	a, err := readConversation(cmd.A)
+	if err != nil {
+		return err
+	}
+	b, err := readConversation(cmd.B)
+	if err != nil {
+		return err
+	}
+
+	diff := schema.DiffConversations(a, b)
+	if ctx.IsDebug() {
+		fmt.Println(diff)
+	} else {
+		fmt.Print(diff.Render())
+	}
+	if cmd.ExitCode && !diff.Equal() {
+		added, removed, changed := diff.Counts()
+		return fmt.Errorf("conversations differ: %d added, %d removed, %d changed", added, removed, changed)
+	}
+	return nil
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// readConversation reads messages from a file, which holds either an array of
+// messages, a message list (the body of session-messages) or an imported
+// session with messages
+func readConversation(path string) (schema.Conversation, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	data = bytes.TrimSpace(data)
+	if bytes.HasPrefix(data, []byte("[")) {
+		var conversation schema.Conversation
+		if err := json.Unmarshal(data, &conversation); err != nil {
+			return nil, schema.ErrBadParameter.Withf("%s: %v", path, err)
+		}
+		return conversation, nil
+	}
+
+	var export struct {
+		Body     schema.Conversation `json:"body"`
+		Messages schema.Conversation `json:"messages"`
+	}
+	if err := json.Unmarshal(data, &export); err != nil {
+		return nil, schema.ErrBadParameter.Withf("%s: %v", path, err)
+	}
+	if export.Body == nil && export.Messages == nil {
+		return nil, schema.ErrBadParameter.Withf("%s: no messages", path)
+	}
+	return append(export.Body, export.Messages...), nil
+}
@@ -2,6 +2,8 @@ package cmd
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
 	"testing"
 
 	// Packages
@@ -104,3 +106,25 @@ func TestMaybeStoreDefaultSessionReturnsSetError(t *testing.T) {
 		assert.Contains(err.Error(), "boom")
 	}
 }
+
+func TestReadConversationFormats(t *testing.T) {
+	assert := assert.New(t)
+	dir := t.TempDir()
+	for name, data := range map[string]string{
+		"array.json":    `[{"role":"user","content":[{"text":"Hello"}]}]`,
+		"list.json":     `{"count":1,"body":[{"role":"user","content":[{"text":"Hello"}]}]}`,
+		"imported.json": `{"name":"Greeting","messages":[{"session":"11111111-1111-1111-1111-111111111111","role":"user","content":[{"text":"Hello"}]}]}`,
+	} {
+		path := filepath.Join(dir, name)
+		assert.NoError(os.WriteFile(path, []byte(data), 0o600))
+		conversation, err := readConversation(path)
+		if assert.NoError(err, name) && assert.Len(conversation, 1, name) {
+			assert.Equal("Hello", conversation[0].Text(), name)
+		}
+	}
+
+	path := filepath.Join(dir, "empty.json")
+	assert.NoError(os.WriteFile(path, []byte(`{"count":0}`), 0o600))
+	_, err := readConversation(path)
+	assert.Error(err)
+}
@@ -26,6 +26,8 @@ type ToolCommands struct {
 	ListTools ListToolsCommand `cmd:"" name:"tools" help:"List tools." group:"TOOLS & AGENTS"`
 	GetTool   GetToolCommand   `cmd:"" name:"tool" help:"Get a tool by name." group:"TOOLS & AGENTS"`
 	CallTool  CallToolCommand  `cmd:"" name:"tool-call" help:"Call a tool by name." group:"TOOLS & AGENTS"`
+	TestTool  TestToolCommand  `cmd:"" name:"tool-test" help:"Run a tool outside of a chat, validating its input and output." group:"TOOLS & AGENTS"`
+	ToolDocs  ToolDocsCommand  `cmd:"" name:"tool-docs" help:"Write documentation for tools as markdown or HTML." group:"TOOLS & AGENTS"`
 }
 
 type ListToolsCommand struct {
@@ -41,11 +43,21 @@ type CallToolCommand struct {
 	Input string `arg:"" name:"input" help:"JSON input payload" optional:""`
 }
 
+type ToolDocsCommand struct {
+	schema.ToolDocsRequest `embed:""`
+	Out                    string `name:"out" short:"o" help:"Write the documentation to a file instead of stdout." type:"path" optional:""`
+}
+
+type TestToolCommand struct {
+	Name string `arg:"" name:"name" help:"Tool name"`
+	Args string `name:"args" help:"JSON input payload, or read from stdin when piped" optional:""`
+}
+
 ///////////////////////////////////////////////////////////////////////////////
 // PUBLIC METHODS
 
-func (cmd *ListToolsCommand) Run(ctx server.Cmd) (err error) {
-	return WithClient(ctx, func(client *httpclient.Client, _ string) error {
+func (cmd *ListToolsCommand) Run(ctx server.Cmd, remote ServerEndpoint) (err error) {
+	return WithClient(ctx, remote, func(client *httpclient.Client, _ string) error {
 		parent, endSpan := otel.StartSpan(ctx.Tracer(), ctx.Context(), "ListToolsCommand",
 			attribute.String("request", types.Stringify(cmd.ToolListRequest)),
 		)
@@ -65,8 +77,8 @@ func (cmd *ListToolsCommand) Run(ctx server.Cmd) (err error) {
 	})
 }
 
-func (cmd *GetToolCommand) Run(ctx server.Cmd) (err error) {
-	return WithClient(ctx, func(client *httpclient.Client, _ string) error {
+func (cmd *GetToolCommand) Run(ctx server.Cmd, remote ServerEndpoint) (err error) {
+	return WithClient(ctx, remote, func(client *httpclient.Client, _ string) error {
 		parent, endSpan := otel.StartSpan(ctx.Tracer(), ctx.Context(), "GetToolCommand",
 			attribute.String("name", cmd.Name),
 		)
@@ -82,8 +94,8 @@ func (cmd *GetToolCommand) Run(ctx server.Cmd) (err error) {
 	})
 }
 
-func (cmd *CallToolCommand) Run(ctx server.Cmd) (err error) {
-	return WithClient(ctx, func(client *httpclient.Client, _ string) error {
+func (cmd *CallToolCommand) Run(ctx server.Cmd, remote ServerEndpoint) (err error) {
+	return WithClient(ctx, remote, func(client *httpclient.Client, _ string) error {
 		req, err := cmd.request()
 		if err != nil {
 			return err
@@ -108,6 +120,57 @@ func (cmd *CallToolCommand) Run(ctx server.Cmd) (err error) {
 	})
 }
 
+func (cmd *ToolDocsCommand) Run(ctx server.Cmd, remote ServerEndpoint) (err error) {
+	return WithClient(ctx, remote, func(client *httpclient.Client, _ string) error {
+		parent, endSpan := otel.StartSpan(ctx.Tracer(), ctx.Context(), "ToolDocsCommand",
+			attribute.String("request", cmd.ToolDocsRequest.String()),
+		)
+		defer func() { endSpan(err) }()
+
+		data, err := client.ToolDocs(parent, cmd.ToolDocsRequest)
+		if err != nil {
+			return err
+		}
+
+		if cmd.Out == "" {
+			_, err = os.Stdout.Write(data)
+			return err
+		}
+		if err := os.WriteFile(cmd.Out, data, 0o644); err != nil {
+			return fmt.Errorf("writing documentation: %w", err)
+		}
+		fmt.Fprintln(os.Stderr, "wrote tool documentation to", cmd.Out)
+		return nil
+	})
+}
+
+func (cmd *TestToolCommand) Run(ctx server.Cmd, remote ServerEndpoint) (err error) {
+	return WithClient(ctx, remote, func(client *httpclient.Client, _ string) error {
+		req, err := CallToolCommand{Name: cmd.Name, Input: cmd.Args}.request()
+		if err != nil {
+			return err
+		}
+
+		parent, endSpan := otel.StartSpan(ctx.Tracer(), ctx.Context(), "TestToolCommand",
+			attribute.String("name", cmd.Name),
+			attribute.String("request", req.String()),
+		)
+		defer func() { endSpan(err) }()
+
+		report, err := client.TestTool(parent, cmd.Name, req)
+		if err != nil {
+			return err
+		}
+
+		// Print the report, and fail when the test failed
+		fmt.Println(report)
+		if report.Error != "" {
+			return fmt.Errorf("%s: %s failed: %s", report.Name, report.Stage, report.Error)
+		}
+		return nil
+	})
+}
+
 func (cmd CallToolCommand) request() (schema.CallToolRequest, error) {
 	return cmd.requestWithInput(os.Stdin, stdinHasData(os.Stdin))
 }
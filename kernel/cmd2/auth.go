@@ -3,6 +3,8 @@ package cmd
 import (
 	// Packages
 	httpclient "github.com/mutablelogic/go-auth/auth/httpclient"
+	authmanager "github.com/mutablelogic/go-auth/auth/manager"
+	pg "github.com/mutablelogic/go-pg"
 	server "github.com/mutablelogic/go-server"
 )
 
@@ -21,3 +23,17 @@ func WithAuth(ctx server.Cmd, fn func(*httpclient.Client, string) error) error {
 	}
 	return fn(client, endpoint)
 }
+
+// WithAuthManager creates an auth manager with the users, groups and keys in
+// the database schema, which authenticates requests to the server.
+func WithAuthManager(ctx server.Cmd, conn pg.PoolConn, schema string, fn func(*authmanager.Manager) error) error {
+	manager, err := authmanager.New(ctx.Context(), conn, ctx.Name(), ctx.Version(),
+		authmanager.WithSchema(schema),
+		authmanager.WithTracer(ctx.Tracer()),
+	)
+	if err != nil {
+		return err
+	}
+	defer manager.Close()
+	return fn(manager)
+}
@@ -0,0 +1,38 @@
+//go:build chaos
+
+package cmd
+
+import (
+	// Packages
+	client "github.com/mutablelogic/go-client"
+	manager "github.com/mutablelogic/go-llm/kernel/manager"
+	chaos "github.com/mutablelogic/go-llm/pkg/chaos"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// ChaosFlags inject faults into the requests made to providers. They are
+// only in builds with the chaos tag, for testing and staging.
+type ChaosFlags struct {
+	Faults string `name:"chaos" env:"${ENV_NAME}_CHAOS" help:"Chance of each fault in requests to providers, such as timeout=0.05,throttle=0.1,truncate=0.05,malformed=0.05,seed=1." optional:""`
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// opts returns the manager options which inject the faults
+func (flags ChaosFlags) opts() ([]manager.Opt, error) {
+	if flags.Faults == "" {
+		return nil, nil
+	}
+	faults, err := chaos.Parse(flags.Faults)
+	if err != nil {
+		return nil, err
+	}
+	injector, err := chaos.New(faults)
+	if err != nil {
+		return nil, err
+	}
+	return []manager.Opt{manager.WithProviderClientOpts(client.OptTransport(injector.Transport))}, nil
+}
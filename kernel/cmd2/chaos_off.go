@@ -0,0 +1,21 @@
+//go:build !chaos
+
+package cmd
+
+import (
+	// Packages
+	manager "github.com/mutablelogic/go-llm/kernel/manager"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// ChaosFlags are empty in builds without the chaos tag
+type ChaosFlags struct{}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+func (ChaosFlags) opts() ([]manager.Opt, error) {
+	return nil, nil
+}
@@ -2,15 +2,32 @@ package cmd
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"io/fs"
+	"maps"
+	"net/http"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
 
 	// Packages
 	httpclient "github.com/mutablelogic/go-auth/auth/httpclient"
+	authhandler "github.com/mutablelogic/go-auth/auth/httphandler"
+	authmanager "github.com/mutablelogic/go-auth/auth/manager"
 	llm "github.com/mutablelogic/go-llm"
 	agent "github.com/mutablelogic/go-llm/etc/agent"
+	httphandler "github.com/mutablelogic/go-llm/kernel/httphandler"
 	kernel "github.com/mutablelogic/go-llm/kernel/manager"
 	manager "github.com/mutablelogic/go-llm/kernel/manager"
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	graphql "github.com/mutablelogic/go-llm/pkg/graphql"
+	ocr "github.com/mutablelogic/go-llm/pkg/ocr"
+	offline "github.com/mutablelogic/go-llm/pkg/offline"
+	rest "github.com/mutablelogic/go-llm/pkg/rest"
 	prompt "github.com/mutablelogic/go-llm/toolkit/prompt"
 	pg "github.com/mutablelogic/go-pg"
 	pgcmd "github.com/mutablelogic/go-pg/pkg/cmd"
@@ -35,7 +52,110 @@ type RunServer struct {
 	} `embed:"" prefix:"schema."`
 
 	// Other flags
-	Passphrases []string `name:"passphrase" env:"${ENV_NAME}_PASSPHRASES" help:"One or more passphrases used to encrypt credentials."`
+	Passphrases  []string                 `name:"passphrase" env:"${ENV_NAME}_PASSPHRASES" help:"One or more passphrases used to encrypt credentials."`
+	Agents       string                   `name:"agents" env:"${ENV_NAME}_AGENTS" type:"existingdir" help:"Directory of markdown agent definitions, reloaded when files change." optional:""`
+	Retention    map[string]time.Duration `name:"retention" help:"Delete sessions with a label after a period of inactivity, for example user:123=720h. An empty label applies to all sessions." optional:""`
+	ModelCache   time.Duration            `name:"model-cache" env:"${ENV_NAME}_MODEL_CACHE" help:"Time after which the cached list of models for a provider is refreshed, or zero to disable the cache." default:"5m"`
+	ToolCache    map[string]time.Duration `name:"tool-cache" help:"Keep the results of read-only tools for a period, so calls with the same input are not repeated, for example weather=10m or builtin.search=1h." optional:""`
+	CacheSize    int                      `name:"tool-cache-size" help:"Number of tool results which are cached." default:"1024"`
+	ModelAlias   map[string]string        `name:"model-alias" help:"Model names which refer to another model, for example claude-latest=claude-sonnet-4-5." optional:""`
+	Deprecated   map[string]string        `name:"model-deprecated" help:"Retired model names and their successors, for example gpt-4=gpt-4o. Responses which use a retired name include a warning." optional:""`
+	Successor    map[string]string        `name:"model-successor" help:"Models and their successors, for example claude-3-opus=claude-opus-4-1. Requests are retried with the successor when the provider reports the model does not exist or is retired." optional:""`
+	WarmModels   []string                 `name:"warm-model" help:"Local models which are loaded when the server starts and kept loaded, for example llama3.2 or ollama=llama3.2." optional:""`
+	KeepAlive    time.Duration            `name:"model-keepalive" help:"Time between requests which keep warm models loaded, or zero to load them only when the server starts." default:"4m"`
+	Unsupported  string                   `name:"unsupported-options" help:"What happens when a request sets an option which the provider does not support." enum:"error,warn,emulate" default:"error"`
+	SharedLocks  bool                     `name:"shared-locks" env:"${ENV_NAME}_SHARED_LOCKS" help:"Serialize chat turns in a session across server replicas which share the database."`
+	Archive      bool                     `name:"archive" env:"${ENV_NAME}_ARCHIVE" help:"Write every ask and chat request, with its response, to an append-only archive in the database."`
+	Dedup        bool                     `name:"dedup-tool-results" env:"${ENV_NAME}_DEDUP_TOOL_RESULTS" help:"Replace older copies of repeated tool results with a reference to the latest copy in requests to providers, to save tokens. Stored history is not changed."`
+	Tenants      bool                     `name:"tenant-credentials" env:"${ENV_NAME}_TENANT_CREDENTIALS" help:"Let each user set their own provider credentials, which are used for their requests in place of the server credentials."`
+	Reasoning    bool                     `name:"record-reasoning" env:"${ENV_NAME}_RECORD_REASONING" help:"Record the thinking of models apart from the session transcript, so it is not returned to users and administrators can retrieve it."`
+	Fixtures     string                   `name:"tool-fixtures" env:"${ENV_NAME}_TOOL_FIXTURES" type:"existingfile" help:"JSON file of recorded tool results, or a saved chat response with a trace, which are returned instead of running tools in simulated chat turns." optional:""`
+	Offline      bool                     `name:"offline" env:"${ENV_NAME}_OFFLINE" help:"Run without access to the internet: only local providers such as ollama are enabled, tools which reach outside the server are not offered, and requests to hosts which are not local are refused."`
+	Checkpoints  string                   `name:"checkpoints" enum:"off,end,resume" default:"off" help:"Store chat turns after each tool call, so that a turn interrupted when a server stops is ended with an accurate history, or resumed."`
+	IDs          string                   `name:"ids" enum:"random,sortable" default:"random" help:"IDs of jobs, recordings and archive records: random, or sortable in the order they were created."`
+	OfflineAllow []string                 `name:"offline-allow" help:"Hosts which are local when offline, in addition to loopback and private addresses, such as ollama, or .internal.example.com for the hosts in a domain." optional:""`
+	LocalAuth    bool                     `name:"local-auth" env:"${ENV_NAME}_LOCAL_AUTH" help:"Authenticate requests with the users and API keys in the auth schema, serve the endpoints which manage them, and require the admin scope for the admin endpoints. Otherwise authentication is left to a proxy in front of the server."`
+	REST         string                   `name:"rest" env:"${ENV_NAME}_REST" type:"existingfile" help:"YAML file of REST endpoints, each of which becomes a tool. Authentication headers are read from the credential stored for each endpoint." optional:""`
+
+	// Media tool options
+	Media struct {
+		Enabled  bool   `name:"enabled" help:"Add the media_transcript tool, which reads the transcripts of YouTube videos and podcasts."`
+		Provider string `name:"provider" help:"Provider which transcribes audio other than YouTube videos, such as mistral." optional:""`
+		Model    string `name:"model" help:"Model which transcribes audio, or the provider's default when empty." optional:""`
+	} `embed:"" prefix:"media."`
+
+	// OCR options
+	OCR struct {
+		Enabled  bool   `name:"enabled" help:"Extract the text of image attachments, and send it in place of the images to models which do not accept images."`
+		Provider string `name:"provider" help:"Provider which reads images, such as mistral. Images are read with tesseract when empty." optional:""`
+		Model    string `name:"model" help:"Model which reads images, or the provider's default when empty." optional:""`
+		Path     string `name:"path" help:"Path to the tesseract command, or found in the PATH when empty." optional:""`
+		Lang     string `name:"lang" help:"Languages of the text in images for tesseract, for example eng+deu." optional:""`
+	} `embed:"" prefix:"ocr."`
+
+	// Calendar tool options
+	Calendar struct {
+		URL   string `name:"url" env:"${ENV_NAME}_CALENDAR_URL" help:"URL of an ICS feed or CalDAV calendar for the calendar tools. Credentials are read from the credential stored for the URL." optional:""`
+		Write bool   `name:"write" help:"Add a tool which creates events on a CalDAV calendar. Each event must be approved."`
+	} `embed:"" prefix:"calendar."`
+
+	// GraphQL tool options
+	GraphQL struct {
+		URL    string   `name:"url" env:"${ENV_NAME}_GRAPHQL_URL" help:"URL of a GraphQL endpoint for the GraphQL tools. The bearer token is read from the credential stored for the URL." optional:""`
+		Depth  uint     `name:"depth" help:"Maximum depth of the fields selected by a query." default:"6"`
+		Fields uint     `name:"fields" help:"Maximum number of fields selected by a query." default:"200"`
+		Allow  []string `name:"allow" help:"Fields which may be queried, such as Query.repository or Repository.*. All fields may be queried when empty." optional:""`
+	} `embed:"" prefix:"graphql."`
+
+	// Workspace tool options
+	Workspace struct {
+		Root string `name:"root" env:"${ENV_NAME}_WORKSPACE" type:"existingdir" help:"Directory whose files are edited by the apply_patch tool. Each patch must be approved." optional:""`
+	} `embed:"" prefix:"workspace."`
+
+	// Admission control options
+	Admission struct {
+		Workers     int `name:"workers" env:"${ENV_NAME}_WORKERS" help:"Maximum number of generations which run at once, or zero for no limit." default:"0"`
+		Interactive int `name:"interactive" help:"Maximum number of interactive requests which wait for a worker." default:"64"`
+		Batch       int `name:"batch" help:"Maximum number of batch requests which wait for a worker." default:"32"`
+		Scheduled   int `name:"scheduled" help:"Maximum number of scheduled requests which wait for a worker." default:"16"`
+	} `embed:"" prefix:"admission."`
+
+	// Local model scheduling options
+	Local struct {
+		Capacity    map[string]float64 `name:"capacity" help:"Memory of a provider which runs models locally, in GB, for example ollama=24. Generations wait rather than load more models than fit." optional:""`
+		Memory      map[string]float64 `name:"memory" help:"Memory used by a local model when it is loaded, in GB, for example llama3.1:70b=40." optional:""`
+		Concurrency map[string]int     `name:"concurrency" help:"Maximum number of generations which run at once with a local model, for example llama3.1:70b=1." optional:""`
+	} `embed:"" prefix:"local."`
+
+	// Jailbreak screening options
+	Jailbreak struct {
+		Threshold float64 `name:"threshold" help:"Score between zero and one at which input is archived as a jailbreak attempt, or zero to disable screening." default:"0"`
+		Judge     string  `name:"judge" help:"Model which also scores input for jailbreak attempts." optional:""`
+		Prompt    string  `name:"prompt" help:"Text added to the system prompt for a turn when the input reaches the threshold." optional:""`
+	} `embed:"" prefix:"jailbreak."`
+
+	// Tool selection options
+	ToolSelect struct {
+		Model    string   `name:"model" help:"Embedding model which selects the tools relevant to each chat turn, or empty to offer all tools." optional:""`
+		Provider string   `name:"provider" help:"Provider of the embedding model." optional:""`
+		Limit    uint     `name:"limit" help:"Number of tools offered to the model for each chat turn, in addition to those always offered." default:"16"`
+		Always   []string `name:"always" help:"Tools which are always offered, as names or globs such as memory.*." optional:""`
+	} `embed:"" prefix:"tool-select."`
+
+	// Turn context options
+	Context struct {
+		UserProfile bool              `name:"user-profile" help:"Send the name, email and groups of the user with each chat turn, as context generated by the system."`
+		Flags       map[string]string `name:"flag" help:"Feature flags sent with each chat turn, as context generated by the system, for example beta=true. Values which are JSON are sent as JSON." optional:""`
+	} `embed:"" prefix:"context."`
+
+	// Circuit breaker options
+	Breaker struct {
+		Failures uint          `name:"failures" help:"Consecutive failures after which generations with a provider are rejected, or zero to disable." default:"5"`
+		Cooldown time.Duration `name:"cooldown" help:"Time after which a provider with an open circuit is sent a probe." default:"30s"`
+	} `embed:"" prefix:"breaker."`
+
+	// Fault injection options, in builds with the chaos tag
+	Chaos ChaosFlags `embed:""`
 }
 
 ///////////////////////////////////////////////////////////////////////////////
@@ -50,38 +170,80 @@ func (runner *RunServer) Run(ctx server.Cmd) error {
 		return fmt.Errorf("database connection is required")
 	}
 
+	// Authenticate requests with the local auth manager, when enabled
+	if runner.LocalAuth {
+		return WithAuthManager(ctx, conn, runner.Schema.Auth, func(authmanager *authmanager.Manager) error {
+			return runner.run(ctx, conn, authmanager)
+		})
+	}
+
 	// Create an auth client and manager, and run the server
 	return WithAuth(ctx, func(auth *httpclient.Client, endpoint string) error {
-		return runner.WithManager(ctx, conn, func(manager *kernel.Manager) error {
-			// Sync providers before starting the server so that any configured providers are available immediately
-			ctx.Logger().DebugContext(ctx.Context(), "syncing providers before server startup")
-			if _, _, err := manager.SyncProviders(ctx.Context()); err != nil {
-				ctx.Logger().ErrorContext(ctx.Context(), "failed to sync llm providers before startup", "error", err.Error())
-			}
+		return runner.run(ctx, conn, nil)
+	})
+}
+
+// run creates the manager and runs the server. When there is an auth manager,
+// it authenticates requests and runs alongside the server.
+func (runner *RunServer) run(ctx server.Cmd, conn pg.PoolConn, authmanager *authmanager.Manager) error {
+	return runner.WithManager(ctx, conn, func(manager *kernel.Manager) error {
+		// Sync providers before starting the server so that any configured providers are available immediately
+		ctx.Logger().DebugContext(ctx.Context(), "syncing providers before server startup")
+		if _, _, err := manager.SyncProviders(ctx.Context()); err != nil {
+			ctx.Logger().ErrorContext(ctx.Context(), "failed to sync llm providers before startup", "error", err.Error())
+		}
 
-			// Register HTTP handlers
+		// Register HTTP handlers, which also describe themselves in the
+		// OpenAPI specification served at {prefix}/openapi.json
+		if authmanager != nil {
 			runner.Register(func(router *httprouter.Router) error {
-				ctx.Logger().DebugContext(ctx.Context(), "TODO: registering handlers")
-				return nil
+				return authhandler.RegisterManagerHandlers(authmanager, true)(router)
 			})
+		}
+		runner.Register(func(router *httprouter.Router) error {
+			return httphandler.RegisterHandlers(router, manager, authmanager, authmanager != nil)
+		})
 
-			// Create an error group, so that the first error from any of the goroutines will
-			// be returned and the others will be cancelled
-			errgroup, errctx := errgroup.WithContext(ctx.Context())
+		// Create an error group, so that the first error from any of the goroutines will
+		// be returned and the others will be cancelled
+		errgroup, errctx := errgroup.WithContext(ctx.Context())
 
-			// Run the server
-			errgroup.Go(func() error {
-				return runner.RunServer.Run(ctx.WithContext(errctx))
-			})
+		// Run the server
+		errgroup.Go(func() error {
+			return runner.RunServer.Run(ctx.WithContext(errctx))
+		})
 
-			// Run the kernel
+		// Run the auth manager background tasks
+		if authmanager != nil {
 			errgroup.Go(func() error {
-				return manager.Run(errctx, ctx.Logger())
+				return authmanager.Run(errctx)
 			})
+		}
 
-			// Wait until cancelled
-			return errgroup.Wait()
+		// Run the kernel
+		errgroup.Go(func() error {
+			return manager.Run(errctx, ctx.Logger())
 		})
+
+		// Reload providers, connectors and agents on SIGHUP
+		errgroup.Go(func() error {
+			hup := make(chan os.Signal, 1)
+			signal.Notify(hup, syscall.SIGHUP)
+			defer signal.Stop(hup)
+			for {
+				select {
+				case <-errctx.Done():
+					return nil
+				case <-hup:
+					if _, err := manager.Reload(errctx); err != nil {
+						ctx.Logger().ErrorContext(errctx, "failed to reload", "error", err.Error())
+					}
+				}
+			}
+		})
+
+		// Wait until cancelled
+		return errgroup.Wait()
 	})
 }
 
@@ -119,6 +281,33 @@ func (server *RunServer) Opts(ctx server.Cmd) ([]manager.Opt, error) {
 		return nil, err
 	}
 
+	switch server.Checkpoints {
+	case "end":
+		opts = append(opts, manager.WithCheckpoints(false))
+	case "resume":
+		opts = append(opts, manager.WithCheckpoints(true))
+	}
+
+	if server.IDs == "sortable" {
+		opts = append(opts, manager.WithIDGenerator(manager.SortableID(nil)))
+	}
+
+	// Refuse requests to hosts which are not local, from every client in the
+	// process which uses the default transport, as well as the provider and
+	// connector clients
+	if server.Offline {
+		guard := offline.New(server.OfflineAllow...)
+		http.DefaultTransport = guard.Transport(http.DefaultTransport)
+		opts = append(opts, manager.WithOffline(guard))
+	}
+
+	// Inject faults into the requests made to providers
+	if chaosopts, err := server.Chaos.opts(); err != nil {
+		return nil, err
+	} else {
+		opts = append(opts, chaosopts...)
+	}
+
 	// Get the prompts from the embedded filesystem and set them on the manager options
 	prompts, err := server.Prompts()
 	if err != nil {
@@ -126,8 +315,186 @@ func (server *RunServer) Opts(ctx server.Cmd) ([]manager.Opt, error) {
 	}
 	opts = append(opts, manager.WithPrompts(prompts...))
 
+	// Load further agents from a directory, if set
+	if server.Agents != "" {
+		opts = append(opts, manager.WithAgentDir(server.Agents))
+	}
+
+	// Set retention policies, in label order
+	labels := slices.Sorted(maps.Keys(server.Retention))
+	for _, label := range labels {
+		opts = append(opts, manager.WithRetention(label, server.Retention[label]))
+	}
+
+	// Cache the results of tools, in name order
+	for _, name := range slices.Sorted(maps.Keys(server.ToolCache)) {
+		opts = append(opts, manager.WithToolCache(name, server.ToolCache[name]))
+	}
+
+	// Set model aliases, deprecated model names and successors, in name order
+	for _, name := range slices.Sorted(maps.Keys(server.ModelAlias)) {
+		opts = append(opts, manager.WithModelAlias(name, server.ModelAlias[name]))
+	}
+	for _, name := range slices.Sorted(maps.Keys(server.Deprecated)) {
+		opts = append(opts, manager.WithModelDeprecation(name, server.Deprecated[name]))
+	}
+	for _, name := range slices.Sorted(maps.Keys(server.Successor)) {
+		opts = append(opts, manager.WithModelSuccessor(name, server.Successor[name]))
+	}
+
+	// Load local models when the server starts, and keep them loaded
+	if len(server.WarmModels) > 0 {
+		models := make([]schema.LoadModelRequest, 0, len(server.WarmModels))
+		for _, value := range server.WarmModels {
+			if provider, name, found := strings.Cut(value, "="); found {
+				models = append(models, schema.LoadModelRequest{Provider: provider, Name: name})
+			} else {
+				models = append(models, schema.LoadModelRequest{Name: value})
+			}
+		}
+		opts = append(opts, manager.WithModelWarmup(server.KeepAlive, models...))
+	}
+
+	// Share session locks with other replicas
+	if server.SharedLocks {
+		opts = append(opts, manager.WithSharedLocks())
+	}
+
+	// Archive requests and responses
+	if server.Archive {
+		opts = append(opts, manager.WithArchive())
+	}
+	if server.Dedup {
+		opts = append(opts, manager.WithToolResultDedup())
+	}
+
+	// Use the provider credentials of each user for their requests
+	if server.Tenants {
+		opts = append(opts, manager.WithTenantCredentials())
+	}
+
+	// Record the thinking of models apart from the transcript
+	if server.Reasoning {
+		opts = append(opts, manager.WithReasoningRecord())
+	}
+
+	// Score input for jailbreak attempts
+	if server.Jailbreak.Threshold > 0 {
+		opts = append(opts, manager.WithJailbreakScreen(server.Jailbreak.Threshold, server.Jailbreak.Judge, server.Jailbreak.Prompt))
+	}
+
+	// Offer only the tools which are relevant to each chat turn
+	if server.ToolSelect.Model != "" {
+		opts = append(opts, manager.WithToolSelection(server.ToolSelect.Provider, server.ToolSelect.Model, server.ToolSelect.Limit, server.ToolSelect.Always...))
+	}
+
+	// Send the context of the environment with each chat turn
+	if server.Context.UserProfile {
+		opts = append(opts, manager.WithTurnContext("user_profile", manager.UserProfileContext()))
+	}
+	if len(server.Context.Flags) > 0 {
+		flags := make(map[string]any, len(server.Context.Flags))
+		for name, value := range server.Context.Flags {
+			if json.Valid([]byte(value)) {
+				flags[name] = json.RawMessage(value)
+			} else {
+				flags[name] = value
+			}
+		}
+		opts = append(opts, manager.WithTurnContext("feature_flags", manager.FeatureFlagsContext(flags)))
+	}
+
+	// Read the transcripts of videos and podcasts
+	if server.Media.Enabled {
+		opts = append(opts, manager.WithMediaTools(server.Media.Provider, server.Media.Model))
+	}
+
+	// Extract the text of images for models which do not accept them
+	if server.OCR.Enabled && server.OCR.Provider != "" {
+		opts = append(opts, manager.WithProviderOCR(server.OCR.Provider, server.OCR.Model))
+	} else if server.OCR.Enabled {
+		tesseract, err := ocr.NewTesseract(server.OCR.Path, server.OCR.Lang)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, manager.WithOCR(tesseract))
+	}
+
+	// Read events in a calendar, and create them when writing is enabled
+	if server.Calendar.URL != "" {
+		opts = append(opts, manager.WithCalendar(server.Calendar.URL, server.Calendar.Write))
+	}
+
+	// Read recorded tool results for simulated chat turns
+	if server.Fixtures != "" {
+		f, err := os.Open(server.Fixtures)
+		if err != nil {
+			return nil, err
+		}
+		fixtures, err := schema.ReadToolFixtures(f)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", server.Fixtures, err)
+		}
+		opts = append(opts, manager.WithToolFixtures(fixtures...))
+	}
+
+	// Add a tool for each REST endpoint
+	if server.REST != "" {
+		endpoints, err := rest.ReadFile(server.REST)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, manager.WithRESTEndpoints(endpoints...))
+	}
+
+	// Query a GraphQL endpoint
+	if server.GraphQL.URL != "" {
+		opts = append(opts, manager.WithGraphQL(server.GraphQL.URL, graphql.Limits{
+			Depth:  server.GraphQL.Depth,
+			Fields: server.GraphQL.Fields,
+			Allow:  server.GraphQL.Allow,
+		}))
+	}
+
+	// Edit files in a workspace
+	if server.Workspace.Root != "" {
+		opts = append(opts, manager.WithWorkspace(server.Workspace.Root))
+	}
+
+	// Stop sending generations to providers which are failing
+	if server.Breaker.Failures > 0 {
+		opts = append(opts, manager.WithCircuitBreaker(server.Breaker.Failures, server.Breaker.Cooldown))
+	}
+
+	// Queue generations with local models which would not fit in memory, in
+	// provider and model name order
+	for _, provider := range slices.Sorted(maps.Keys(server.Local.Capacity)) {
+		opts = append(opts, manager.WithLocalCapacity(provider, server.Local.Capacity[provider]))
+	}
+	for _, name := range slices.Sorted(maps.Keys(server.Local.Memory)) {
+		opts = append(opts, manager.WithLocalModel(name, server.Local.Memory[name], server.Local.Concurrency[name]))
+	}
+	for _, name := range slices.Sorted(maps.Keys(server.Local.Concurrency)) {
+		if _, exists := server.Local.Memory[name]; !exists {
+			opts = append(opts, manager.WithLocalModel(name, 0, server.Local.Concurrency[name]))
+		}
+	}
+
+	// Limit the generations which run at once, queueing requests by priority
+	if server.Admission.Workers > 0 {
+		opts = append(opts, manager.WithAdmission(server.Admission.Workers, map[schema.Priority]int{
+			schema.PriorityInteractive: server.Admission.Interactive,
+			schema.PriorityBatch:       server.Admission.Batch,
+			schema.PriorityScheduled:   server.Admission.Scheduled,
+		}))
+	}
+
 	// Return the options with the configured schemas and tracer
 	return append(opts,
+		manager.WithModelCache(server.ModelCache),
+		manager.WithToolCacheSize(server.CacheSize),
+		manager.WithUnsupportedOptions(manager.OptionPolicy(server.Unsupported)),
 		manager.WithSchemas(server.Schema.LLM, server.Schema.Auth),
 		manager.WithTracer(ctx.Tracer()),
 		manager.WithMeter(ctx.Meter()),
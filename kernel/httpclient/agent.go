@@ -3,11 +3,14 @@ package httpclient
 import (
 	"context"
 	"fmt"
+	"net/http"
+	"strconv"
 
 	// Packages
 	client "github.com/mutablelogic/go-client"
 	llm "github.com/mutablelogic/go-llm"
 	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	types "github.com/mutablelogic/go-server/pkg/types"
 )
 
 ///////////////////////////////////////////////////////////////////////////////
@@ -23,6 +26,21 @@ func (c *Client) ListAgents(ctx context.Context, req schema.AgentListRequest) (*
 	return &response, nil
 }
 
+// CreateAgent stores a new agent and returns version 1 of it.
+func (c *Client) CreateAgent(ctx context.Context, meta schema.AgentMeta) (*schema.Agent, error) {
+	payload, err := client.NewJSONRequest(meta)
+	if err != nil {
+		return nil, err
+	}
+
+	var response schema.Agent
+	if err := c.DoWithContext(ctx, payload, &response, client.OptPath("agent")); err != nil {
+		return nil, err
+	}
+
+	return &response, nil
+}
+
 // GetAgent returns metadata for a specific agent by name.
 func (c *Client) GetAgent(ctx context.Context, name string) (*schema.AgentMeta, error) {
 	if name == "" {
@@ -59,3 +77,37 @@ func (c *Client) CallAgent(ctx context.Context, name string, req schema.CallAgen
 
 	return resource, nil
 }
+
+// UpdateAgent stores the next version of an agent and returns it. When version
+// is non-zero, the update fails with a conflict unless the agent is at that version.
+func (c *Client) UpdateAgent(ctx context.Context, name string, version uint, meta schema.AgentMeta) (*schema.Agent, error) {
+	if name == "" {
+		return nil, fmt.Errorf("agent name cannot be empty")
+	}
+
+	payload, err := client.NewJSONRequestEx(http.MethodPatch, meta, types.ContentTypeAny)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := []client.RequestOpt{client.OptPath("agent", name)}
+	if version > 0 {
+		opts = append(opts, client.OptReqHeader("If-Match", strconv.Quote(strconv.FormatUint(uint64(version), 10))))
+	}
+
+	var response schema.Agent
+	if err := c.DoWithContext(ctx, payload, &response, opts...); err != nil {
+		return nil, err
+	}
+
+	return &response, nil
+}
+
+// DeleteAgent deletes all versions of a stored agent.
+func (c *Client) DeleteAgent(ctx context.Context, name string) error {
+	if name == "" {
+		return fmt.Errorf("agent name cannot be empty")
+	}
+
+	return c.DoWithContext(ctx, client.MethodDelete, nil, client.OptPath("agent", name))
+}
@@ -24,7 +24,7 @@ func newAgentServer(t *testing.T) *httptest.Server {
 		{Name: "builtin.bravo", Title: "Bravo Agent", Description: "B"},
 		{Name: "remote.echo", Title: "Echo Agent", Description: "Echo"},
 	}
-	mux.HandleFunc("/api/agent", func(w http.ResponseWriter, r *http.Request) {
+	mux.HandleFunc("/api/v1/agent", func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodGet {
 			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
 			return
@@ -60,8 +60,8 @@ func newAgentServer(t *testing.T) *httptest.Server {
 		w.Header().Set(types.ContentTypeHeader, types.ContentTypeJSON)
 		_ = json.NewEncoder(w).Encode(response)
 	})
-	mux.HandleFunc("/api/agent/", func(w http.ResponseWriter, r *http.Request) {
-		name, err := url.PathUnescape(r.URL.Path[len("/api/agent/"):])
+	mux.HandleFunc("/api/v1/agent/", func(w http.ResponseWriter, r *http.Request) {
+		name, err := url.PathUnescape(r.URL.Path[len("/api/v1/agent/"):])
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusBadRequest)
 			return
@@ -317,3 +317,63 @@ func TestCallAgentEscapedName(t *testing.T) {
 		t.Fatalf("unexpected response body: %s", string(data))
 	}
 }
+
+func TestUpdateAgentIfMatch(t *testing.T) {
+	var ifMatch []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPatch || r.URL.Path != "/api/v1/agent/summarize" {
+			http.Error(w, "unexpected request", http.StatusBadRequest)
+			return
+		}
+		ifMatch = append(ifMatch, r.Header.Get("If-Match"))
+		if r.Header.Get("If-Match") == `"1"` {
+			http.Error(w, "agent has been updated", http.StatusConflict)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(schema.Agent{Version: 3, AgentMeta: schema.AgentMeta{Name: "summarize"}})
+	}))
+	defer server.Close()
+
+	// The expected version is sent in the If-Match header
+	client := newAgentClient(t, server.URL)
+	response, err := client.UpdateAgent(context.Background(), "summarize", 2, schema.AgentMeta{Title: "Summarize"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response.Version != 3 {
+		t.Fatalf("expected version 3, got %d", response.Version)
+	}
+
+	// A stale version is a conflict, and no version sends no header
+	if _, err := client.UpdateAgent(context.Background(), "summarize", 1, schema.AgentMeta{}); err == nil {
+		t.Fatal("expected conflict error, got nil")
+	}
+	if _, err := client.UpdateAgent(context.Background(), "summarize", 0, schema.AgentMeta{}); err != nil {
+		t.Fatal(err)
+	}
+	if len(ifMatch) != 3 || ifMatch[0] != `"2"` || ifMatch[1] != `"1"` || ifMatch[2] != "" {
+		t.Fatalf("unexpected If-Match headers: %q", ifMatch)
+	}
+}
+
+func TestDeleteAgent(t *testing.T) {
+	var deleted string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodDelete {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		deleted = r.URL.Path
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	client := newAgentClient(t, server.URL)
+	if err := client.DeleteAgent(context.Background(), "summarize"); err != nil {
+		t.Fatal(err)
+	}
+	if deleted != "/api/v1/agent/summarize" {
+		t.Fatalf("unexpected path %q", deleted)
+	}
+}
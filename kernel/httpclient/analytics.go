@@ -0,0 +1,45 @@
+package httpclient
+
+import (
+	"context"
+
+	// Packages
+	client "github.com/mutablelogic/go-client"
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// UsageAnalytics returns the requests and tokens in each time bucket of the
+// period, by usage type.
+func (c *Client) UsageAnalytics(ctx context.Context, req schema.AnalyticsRequest) (*schema.UsageAnalytics, error) {
+	var response schema.UsageAnalytics
+	if err := c.DoWithContext(ctx, client.MethodGet, &response, client.OptPath("analytics", "usage"), client.OptQuery(req.Query())); err != nil {
+		return nil, err
+	}
+
+	return &response, nil
+}
+
+// ModelAnalytics returns the requests and tokens in each time bucket of the
+// period, by provider and model.
+func (c *Client) ModelAnalytics(ctx context.Context, req schema.AnalyticsRequest) (*schema.UsageAnalytics, error) {
+	var response schema.UsageAnalytics
+	if err := c.DoWithContext(ctx, client.MethodGet, &response, client.OptPath("analytics", "models"), client.OptQuery(req.Query())); err != nil {
+		return nil, err
+	}
+
+	return &response, nil
+}
+
+// ToolAnalytics returns the results and errors for each tool in each time
+// bucket of the period.
+func (c *Client) ToolAnalytics(ctx context.Context, req schema.AnalyticsRequest) (*schema.ToolAnalytics, error) {
+	var response schema.ToolAnalytics
+	if err := c.DoWithContext(ctx, client.MethodGet, &response, client.OptPath("analytics", "tools"), client.OptQuery(req.Query())); err != nil {
+		return nil, err
+	}
+
+	return &response, nil
+}
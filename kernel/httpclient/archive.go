@@ -0,0 +1,28 @@
+package httpclient
+
+import (
+	"context"
+	"fmt"
+
+	// Packages
+	uuid "github.com/google/uuid"
+	client "github.com/mutablelogic/go-client"
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// GetArchive returns an archived request and response by request ID.
+func (c *Client) GetArchive(ctx context.Context, id uuid.UUID) (*schema.Archive, error) {
+	if id == uuid.Nil {
+		return nil, fmt.Errorf("request ID cannot be nil")
+	}
+
+	var response schema.Archive
+	if err := c.DoWithContext(ctx, client.MethodGet, &response, client.OptPath("archive", id.String())); err != nil {
+		return nil, err
+	}
+
+	return &response, nil
+}
@@ -21,7 +21,7 @@ func newAskServer(t *testing.T) *httptest.Server {
 	t.Helper()
 
 	mux := http.NewServeMux()
-	mux.HandleFunc("/api/ask", func(w http.ResponseWriter, r *http.Request) {
+	mux.HandleFunc("/api/v1/ask", func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodPost {
 			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
 			return
@@ -137,7 +137,7 @@ func TestAskStreamLargeResultEvent(t *testing.T) {
 	large := strings.Repeat("x", 256*1024)
 
 	mux := http.NewServeMux()
-	mux.HandleFunc("/api/ask", func(w http.ResponseWriter, r *http.Request) {
+	mux.HandleFunc("/api/v1/ask", func(w http.ResponseWriter, r *http.Request) {
 		response := schema.AskResponse{
 			CompletionResponse: schema.CompletionResponse{
 				Role:   schema.RoleAssistant,
@@ -22,19 +22,19 @@ func newChannelServer(t *testing.T) *httptest.Server {
 	t.Helper()
 
 	mux := http.NewServeMux()
-	mux.HandleFunc("/api/session/", func(w http.ResponseWriter, r *http.Request) {
+	mux.HandleFunc("/api/v1/session/", func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodPost {
 			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
 			return
 		}
 
 		parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
-		if len(parts) != 4 || parts[0] != "api" || parts[1] != "session" || parts[3] != "channel" {
+		if len(parts) != 5 || parts[0] != "api" || parts[1] != "v1" || parts[2] != "session" || parts[4] != "channel" {
 			http.NotFound(w, r)
 			return
 		}
 
-		sessionID, err := uuid.Parse(parts[2])
+		sessionID, err := uuid.Parse(parts[3])
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusBadRequest)
 			return
@@ -19,6 +19,55 @@ import (
 // When streamFn is non-nil, the request is made as an SSE stream and streamed
 // delta events are dispatched to the callback before the final result is returned.
 func (c *Client) Chat(ctx context.Context, req schema.ChatRequest, streamFn opt.StreamFn) (*schema.ChatResponse, error) {
+	return c.chat(ctx, req, schema.ChatQuery{}, streamFn)
+}
+
+// ChatWithTrace is the same as Chat, but the response also includes a trace
+// of every tool call made during the turn.
+func (c *Client) ChatWithTrace(ctx context.Context, req schema.ChatRequest, streamFn opt.StreamFn) (*schema.ChatResponse, error) {
+	return c.chat(ctx, req, schema.ChatQuery{Include: []string{schema.ChatIncludeTrace}}, streamFn)
+}
+
+// ChatSimulated is the same as ChatWithTrace, but tools are not run. Each
+// tool call returns a fixture recorded on the server, or a result generated
+// by the model, and is flagged as simulated in the trace.
+func (c *Client) ChatSimulated(ctx context.Context, req schema.ChatRequest, streamFn opt.StreamFn) (*schema.ChatResponse, error) {
+	return c.chat(ctx, req, schema.ChatQuery{Include: []string{schema.ChatIncludeTrace}, Simulate: true}, streamFn)
+}
+
+// ChatRecorded is the same as ChatWithTrace, but every intermediate state of
+// the turn is recorded on the server, and the response has the ID of the
+// recording, which is read with GetRecording. When simulate is true, tools
+// are not run, as with ChatSimulated.
+func (c *Client) ChatRecorded(ctx context.Context, req schema.ChatRequest, simulate bool, streamFn opt.StreamFn) (*schema.ChatResponse, error) {
+	return c.chat(ctx, req, schema.ChatQuery{Include: []string{schema.ChatIncludeTrace}, Simulate: simulate, Record: true}, streamFn)
+}
+
+// ChatDryRun returns the request which would be sent to the provider for a
+// chat turn, without sending it or changing the session.
+func (c *Client) ChatDryRun(ctx context.Context, req schema.ChatRequest) (*schema.ChatDryRun, error) {
+	if req.Session == uuid.Nil {
+		return nil, fmt.Errorf("session ID cannot be nil")
+	}
+	req.Text = strings.TrimSpace(req.Text)
+	req.SystemPrompt = strings.TrimSpace(req.SystemPrompt)
+
+	httpReq, err := client.NewJSONRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	var response schema.ChatDryRun
+	if err := c.DoWithContext(ctx, httpReq, &response, client.OptPath("chat"), client.OptQuery(schema.ChatQuery{DryRun: true}.Query())); err != nil {
+		return nil, err
+	}
+
+	return &response, nil
+}
+
+// ChatAsync starts a chat turn which runs in the background on the server,
+// and returns the job, which is polled with GetJob for the result.
+func (c *Client) ChatAsync(ctx context.Context, req schema.ChatRequest) (*schema.Job, error) {
 	if req.Session == uuid.Nil {
 		return nil, fmt.Errorf("session ID cannot be nil")
 	}
@@ -28,30 +77,53 @@ func (c *Client) Chat(ctx context.Context, req schema.ChatRequest, streamFn opt.
 		return nil, fmt.Errorf("text cannot be empty")
 	}
 
-	if streamFn != nil {
-		return c.chatStream(ctx, req, streamFn)
+	httpReq, err := client.NewJSONRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	var response schema.Job
+	if err := c.DoWithContext(ctx, httpReq, &response, client.OptPath("chat"), client.OptQuery(schema.ChatQuery{Async: true}.Query())); err != nil {
+		return nil, err
 	}
-	return c.chatJSON(ctx, req)
+
+	return &response, nil
 }
 
 ///////////////////////////////////////////////////////////////////////////////
 // PRIVATE METHODS
 
-func (c *Client) chatJSON(ctx context.Context, req schema.ChatRequest) (*schema.ChatResponse, error) {
+func (c *Client) chat(ctx context.Context, req schema.ChatRequest, query schema.ChatQuery, streamFn opt.StreamFn) (*schema.ChatResponse, error) {
+	if req.Session == uuid.Nil {
+		return nil, fmt.Errorf("session ID cannot be nil")
+	}
+	req.Text = strings.TrimSpace(req.Text)
+	req.SystemPrompt = strings.TrimSpace(req.SystemPrompt)
+	if req.Text == "" {
+		return nil, fmt.Errorf("text cannot be empty")
+	}
+
+	if streamFn != nil {
+		return c.chatStream(ctx, req, query, streamFn)
+	}
+	return c.chatJSON(ctx, req, query)
+}
+
+func (c *Client) chatJSON(ctx context.Context, req schema.ChatRequest, query schema.ChatQuery) (*schema.ChatResponse, error) {
 	httpReq, err := client.NewJSONRequest(req)
 	if err != nil {
 		return nil, err
 	}
 
 	var response schema.ChatResponse
-	if err := c.DoWithContext(ctx, httpReq, &response, client.OptPath("chat")); err != nil {
+	if err := c.DoWithContext(ctx, httpReq, &response, client.OptPath("chat"), client.OptQuery(query.Query())); err != nil {
 		return nil, err
 	}
 
 	return &response, nil
 }
 
-func (c *Client) chatStream(ctx context.Context, req schema.ChatRequest, streamFn opt.StreamFn) (*schema.ChatResponse, error) {
+func (c *Client) chatStream(ctx context.Context, req schema.ChatRequest, query schema.ChatQuery, streamFn opt.StreamFn) (*schema.ChatResponse, error) {
 	httpReq, err := client.NewJSONRequest(req)
 	if err != nil {
 		return nil, err
@@ -87,6 +159,7 @@ func (c *Client) chatStream(ctx context.Context, req schema.ChatRequest, streamF
 	var discard struct{}
 	if err := c.DoWithContext(ctx, httpReq, &discard,
 		client.OptPath("chat"),
+		client.OptQuery(query.Query()),
 		client.OptReqHeader("Accept", "text/event-stream"),
 		client.OptTextStreamCallback(callback),
 		client.OptNoTimeout(),
@@ -20,7 +20,7 @@ func newChatServer(t *testing.T) *httptest.Server {
 	t.Helper()
 
 	mux := http.NewServeMux()
-	mux.HandleFunc("/api/chat", func(w http.ResponseWriter, r *http.Request) {
+	mux.HandleFunc("/api/v1/chat", func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodPost {
 			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
 			return
@@ -32,6 +32,28 @@ func newChatServer(t *testing.T) *httptest.Server {
 			return
 		}
 
+		if r.URL.Query().Get("dry_run") == "true" {
+			w.Header().Set(types.ContentTypeHeader, types.ContentTypeJSON)
+			_ = json.NewEncoder(w).Encode(schema.ChatDryRun{
+				Session:  req.Session,
+				Provider: schema.Ollama,
+				Model:    "llama3",
+				Messages: schema.Conversation{{Role: schema.RoleUser, Content: []schema.ContentBlock{{Text: types.Ptr(req.Text)}}}},
+			})
+			return
+		}
+
+		if r.URL.Query().Get("async") == "true" {
+			w.Header().Set(types.ContentTypeHeader, types.ContentTypeJSON)
+			w.WriteHeader(http.StatusAccepted)
+			_ = json.NewEncoder(w).Encode(schema.Job{
+				ID:      uuid.MustParse("22222222-2222-2222-2222-222222222222"),
+				Session: req.Session,
+				Status:  schema.JobRunning,
+			})
+			return
+		}
+
 		response := schema.ChatResponse{
 			CompletionResponse: schema.CompletionResponse{
 				Role:   schema.RoleAssistant,
@@ -42,6 +64,9 @@ func newChatServer(t *testing.T) *httptest.Server {
 			},
 			Usage: &schema.UsageMeta{InputTokens: 5, OutputTokens: 7},
 		}
+		if r.URL.Query().Get("include") == schema.ChatIncludeTrace {
+			response.Trace = []schema.ToolTrace{{Name: "builtin__echo", Result: `"ok"`}}
+		}
 
 		if r.Header.Get(types.ContentAcceptHeader) == types.ContentTypeTextStream {
 			stream := fmt.Sprintf(
@@ -61,6 +86,24 @@ func newChatServer(t *testing.T) *httptest.Server {
 		_ = json.NewEncoder(w).Encode(response)
 	})
 
+	mux.HandleFunc("/api/v1/job/{job}", func(w http.ResponseWriter, r *http.Request) {
+		job := schema.Job{ID: uuid.MustParse(r.PathValue("job")), Status: schema.JobCompleted}
+		switch r.Method {
+		case http.MethodGet:
+			job.Result = &schema.ChatResponse{CompletionResponse: schema.CompletionResponse{
+				Role:    schema.RoleAssistant,
+				Content: []schema.ContentBlock{{Text: types.Ptr("done")}},
+			}}
+		case http.MethodDelete:
+			job.Status, job.Error = schema.JobCancelled, "context canceled"
+		default:
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set(types.ContentTypeHeader, types.ContentTypeJSON)
+		_ = json.NewEncoder(w).Encode(job)
+	})
+
 	return httptest.NewServer(mux)
 }
 
@@ -153,3 +196,101 @@ func TestChatEmptyText(t *testing.T) {
 		t.Fatal("expected error for empty text")
 	}
 }
+
+func TestChatWithTrace(t *testing.T) {
+	server := newChatServer(t)
+	defer server.Close()
+
+	client := newChatClient(t, server.URL)
+	response, err := client.ChatWithTrace(context.Background(), schema.ChatRequest{
+		Session: uuid.New(),
+		Text:    "hello",
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(response.Trace) != 1 || response.Trace[0].Name != "builtin__echo" {
+		t.Fatalf("unexpected trace: %+v", response.Trace)
+	}
+
+	response, err = client.Chat(context.Background(), schema.ChatRequest{
+		Session: uuid.New(),
+		Text:    "hello",
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(response.Trace) != 0 {
+		t.Fatalf("expected no trace, got %+v", response.Trace)
+	}
+}
+
+func TestChatDryRun(t *testing.T) {
+	server := newChatServer(t)
+	defer server.Close()
+
+	client := newChatClient(t, server.URL)
+	session := uuid.New()
+	response, err := client.ChatDryRun(context.Background(), schema.ChatRequest{
+		Session: session,
+		Text:    " hello ",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response.Session != session || response.Model != "llama3" {
+		t.Fatalf("unexpected dry run: %+v", response)
+	}
+	if len(response.Messages) != 1 || response.Messages[0].Text() != "hello" {
+		t.Fatalf("unexpected messages: %+v", response.Messages)
+	}
+
+	if _, err := client.ChatDryRun(context.Background(), schema.ChatRequest{Text: "hello"}); err == nil {
+		t.Fatal("expected error for nil session")
+	}
+}
+
+func TestChatAsync(t *testing.T) {
+	server := newChatServer(t)
+	defer server.Close()
+
+	client := newChatClient(t, server.URL)
+	session := uuid.MustParse("11111111-1111-1111-1111-111111111111")
+	job, err := client.ChatAsync(context.Background(), schema.ChatRequest{
+		Session: session,
+		Text:    "take your time",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if job.Status != schema.JobRunning || job.Session != session {
+		t.Fatalf("unexpected job: %+v", job)
+	}
+
+	job, err = client.GetJob(context.Background(), job.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if job.Status != schema.JobCompleted || job.Result == nil || chatText(job.Result) != "done" {
+		t.Fatalf("unexpected job: %+v", job)
+	}
+
+	job, err = client.CancelJob(context.Background(), job.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if job.Status != schema.JobCancelled {
+		t.Fatalf("expected cancelled job, got %+v", job)
+	}
+
+	if _, err := client.GetJob(context.Background(), uuid.Nil); err == nil {
+		t.Fatal("expected error for nil job ID")
+	}
+}
+
+func chatText(response *schema.ChatResponse) string {
+	if len(response.Content) == 0 || response.Content[0].Text == nil {
+		return ""
+	}
+	return *response.Content[0].Text
+}
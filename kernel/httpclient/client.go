@@ -1,9 +1,12 @@
 package httpclient
 
 import (
+	"context"
+
 	// Packages
 	authclient "github.com/mutablelogic/go-auth/auth/httpclient"
 	client "github.com/mutablelogic/go-client"
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
 )
 
 ///////////////////////////////////////////////////////////////////////////////
@@ -20,9 +23,11 @@ type Client struct {
 
 // New creates a new LLM HTTP client with the given base URL and options.
 // The url parameter should point to the LLM API endpoint, e.g.
-// "http://localhost:8084/api".
+// "http://localhost:8084/api". Requests are sent to the current version of
+// the API under the endpoint, e.g. "http://localhost:8084/api/v1".
 func New(url string, opts ...client.ClientOpt) (*Client, error) {
 	c := new(Client)
+	opts = append(opts, client.OptHeader(schema.APIVersionHeader, schema.APIVersion))
 	if client, err := authclient.New(url, opts...); err != nil {
 		return nil, err
 	} else {
@@ -30,3 +35,16 @@ func New(url string, opts ...client.ClientOpt) (*Client, error) {
 	}
 	return c, nil
 }
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// DoWithContext sends a request to the current version of the API
+func (c *Client) DoWithContext(ctx context.Context, in client.Payload, out any, opts ...client.RequestOpt) error {
+	return c.Client.DoWithContext(ctx, in, out, append([]client.RequestOpt{client.OptPath(schema.APIVersion)}, opts...)...)
+}
+
+// Stream opens a stream with the current version of the API
+func (c *Client) Stream(ctx context.Context, callback func(context.Context, client.JSONStream) error, opts ...client.RequestOpt) error {
+	return c.Client.Stream(ctx, callback, append([]client.RequestOpt{client.OptPath(schema.APIVersion)}, opts...)...)
+}
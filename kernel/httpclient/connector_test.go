@@ -19,7 +19,7 @@ func newConnectorServer(t *testing.T) *httptest.Server {
 	t.Helper()
 
 	mux := http.NewServeMux()
-	mux.HandleFunc("/api/connector", func(w http.ResponseWriter, r *http.Request) {
+	mux.HandleFunc("/api/v1/connector", func(w http.ResponseWriter, r *http.Request) {
 		switch r.Method {
 		case http.MethodGet:
 			connectors := []*schema.Connector{
@@ -66,8 +66,8 @@ func newConnectorServer(t *testing.T) *httptest.Server {
 			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
 		}
 	})
-	mux.HandleFunc("/api/connector/", func(w http.ResponseWriter, r *http.Request) {
-		rawURL, err := url.PathUnescape(r.URL.Path[len("/api/connector/"):])
+	mux.HandleFunc("/api/v1/connector/", func(w http.ResponseWriter, r *http.Request) {
+		rawURL, err := url.PathUnescape(r.URL.Path[len("/api/v1/connector/"):])
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusBadRequest)
 			return
@@ -250,7 +250,7 @@ func TestCreateConnectorEmptyURL(t *testing.T) {
 
 func TestCreateConnectorMalformedJSONResponse(t *testing.T) {
 	mux := http.NewServeMux()
-	mux.HandleFunc("/api/connector", func(w http.ResponseWriter, r *http.Request) {
+	mux.HandleFunc("/api/v1/connector", func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set(types.ContentTypeHeader, types.ContentTypeJSON)
 		_, _ = w.Write(bytes.TrimSpace([]byte(`{"url":`)))
 	})
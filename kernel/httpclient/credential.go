@@ -0,0 +1,36 @@
+package httpclient
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	// Packages
+	client "github.com/mutablelogic/go-client"
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// CreateCredential stores a credential for a connector URL and returns the
+// stored credential, without its secret material.
+func (c *Client) CreateCredential(ctx context.Context, req schema.CredentialInsert) (*schema.Credential, error) {
+	req.URL = strings.TrimSpace(req.URL)
+	if req.URL == "" {
+		return nil, fmt.Errorf("url cannot be empty")
+	}
+
+	httpReq, err := client.NewJSONRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	var response schema.Credential
+	if err := c.DoWithContext(ctx, httpReq, &response, client.OptPath("credential")); err != nil {
+		return nil, err
+	}
+
+	// Return success
+	return &response, nil
+}
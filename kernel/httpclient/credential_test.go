@@ -0,0 +1,75 @@
+package httpclient_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	// Packages
+	httpclient "github.com/mutablelogic/go-llm/kernel/httpclient"
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	types "github.com/mutablelogic/go-server/pkg/types"
+)
+
+func newCredentialServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/credential", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var req schema.CredentialInsert
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set(types.ContentTypeHeader, types.ContentTypeJSON)
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(schema.Credential{
+			CredentialKey: req.CredentialKey,
+			CreatedAt:     time.Unix(1710000000, 0).UTC(),
+		})
+	})
+
+	return httptest.NewServer(mux)
+}
+
+func TestCreateCredential(t *testing.T) {
+	server := newCredentialServer(t)
+	defer server.Close()
+
+	client, err := httpclient.New(server.URL + "/api")
+	if err != nil {
+		t.Fatal(err)
+	}
+	response, err := client.CreateCredential(context.Background(), schema.CredentialInsert{
+		CredentialKey: schema.CredentialKey{URL: " https://mcp.example.com/ "},
+		Credentials:   []byte("secret"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response.URL != "https://mcp.example.com/" {
+		t.Fatalf("expected trimmed url, got %q", response.URL)
+	}
+	if response.CreatedAt.IsZero() {
+		t.Fatal("expected created_at to be set")
+	}
+}
+
+func TestCreateCredentialEmptyURL(t *testing.T) {
+	client, err := httpclient.New("http://localhost/api")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.CreateCredential(context.Background(), schema.CredentialInsert{}); err == nil {
+		t.Fatal("expected error for empty url")
+	}
+}
@@ -18,7 +18,7 @@ func newEmbeddingServer(t *testing.T) *httptest.Server {
 	t.Helper()
 
 	mux := http.NewServeMux()
-	mux.HandleFunc("/api/embedding", func(w http.ResponseWriter, r *http.Request) {
+	mux.HandleFunc("/api/v1/embedding", func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodPost {
 			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
 			return
@@ -156,7 +156,7 @@ func TestEmbeddingNotFound(t *testing.T) {
 
 func TestEmbeddingMalformedJSONResponse(t *testing.T) {
 	mux := http.NewServeMux()
-	mux.HandleFunc("/api/embedding", func(w http.ResponseWriter, r *http.Request) {
+	mux.HandleFunc("/api/v1/embedding", func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set(types.ContentTypeHeader, types.ContentTypeJSON)
 		_, _ = w.Write(bytes.TrimSpace([]byte(`{"output":`)))
 	})
@@ -0,0 +1,51 @@
+package httpclient
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+
+	// Packages
+	uuid "github.com/google/uuid"
+	client "github.com/mutablelogic/go-client"
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// export is the body of an exported session
+type export []byte
+
+var _ client.Unmarshaler = (*export)(nil)
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// ExportSession returns a session and its messages as a document in the
+// requested format.
+func (c *Client) ExportSession(ctx context.Context, id uuid.UUID, req schema.SessionExportRequest) ([]byte, error) {
+	if id == uuid.Nil {
+		return nil, fmt.Errorf("session ID cannot be nil")
+	}
+
+	var response export
+	if err := c.DoWithContext(ctx, client.MethodGet, &response, client.OptPath("session", id.String(), "export"), client.OptQuery(req.Query()), client.OptNoTimeout()); err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+func (e *export) Unmarshal(_ http.Header, body io.Reader) error {
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return err
+	}
+	*e = data
+	return nil
+}
@@ -0,0 +1,43 @@
+package httpclient
+
+import (
+	"context"
+	"fmt"
+
+	// Packages
+	uuid "github.com/google/uuid"
+	client "github.com/mutablelogic/go-client"
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// GetJob returns the status of a background job, and its result when it has
+// completed.
+func (c *Client) GetJob(ctx context.Context, id uuid.UUID) (*schema.Job, error) {
+	if id == uuid.Nil {
+		return nil, fmt.Errorf("job ID cannot be nil")
+	}
+
+	var response schema.Job
+	if err := c.DoWithContext(ctx, client.MethodGet, &response, client.OptPath("job", id.String())); err != nil {
+		return nil, err
+	}
+
+	return &response, nil
+}
+
+// CancelJob cancels a background job which is running, and returns the job.
+func (c *Client) CancelJob(ctx context.Context, id uuid.UUID) (*schema.Job, error) {
+	if id == uuid.Nil {
+		return nil, fmt.Errorf("job ID cannot be nil")
+	}
+
+	var response schema.Job
+	if err := c.DoWithContext(ctx, client.MethodDelete, &response, client.OptPath("job", id.String())); err != nil {
+		return nil, err
+	}
+
+	return &response, nil
+}
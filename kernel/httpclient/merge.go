@@ -0,0 +1,33 @@
+package httpclient
+
+import (
+	"context"
+	"fmt"
+
+	// Packages
+	uuid "github.com/google/uuid"
+	client "github.com/mutablelogic/go-client"
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// MergeSession copies the messages of the source session in the request
+// into a session, and returns the merged session.
+func (c *Client) MergeSession(ctx context.Context, id uuid.UUID, req schema.SessionMergeRequest) (*schema.SessionMerge, error) {
+	if id == uuid.Nil {
+		return nil, fmt.Errorf("session ID cannot be nil")
+	}
+	httpReq, err := client.NewJSONRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	var response schema.SessionMerge
+	if err := c.DoWithContext(ctx, httpReq, &response, client.OptPath("session", id.String(), "merge")); err != nil {
+		return nil, err
+	}
+
+	return &response, nil
+}
@@ -3,11 +3,14 @@ package httpclient
 import (
 	"context"
 	"fmt"
+	"net/http"
+	"strconv"
 
 	// Packages
 	uuid "github.com/google/uuid"
 	client "github.com/mutablelogic/go-client"
 	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	types "github.com/mutablelogic/go-server/pkg/types"
 )
 
 ///////////////////////////////////////////////////////////////////////////////
@@ -29,3 +32,69 @@ func (c *Client) ListMessages(ctx context.Context, session uuid.UUID, req schema
 
 	return &response, nil
 }
+
+// AppendMessage stores user input in a session as pending input, without
+// generating a reply. Pending input is sent with the next chat turn.
+func (c *Client) AppendMessage(ctx context.Context, session uuid.UUID, req schema.MessageCreateRequest) (*schema.Message, error) {
+	if session == uuid.Nil {
+		return nil, fmt.Errorf("session ID cannot be nil")
+	}
+	httpReq, err := client.NewJSONRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	var response schema.Message
+	if err := c.DoWithContext(ctx, httpReq, &response,
+		client.OptPath("session", session.String(), "message"),
+		client.OptQuery(schema.MessageCreateQuery{Send: types.Ptr(false)}.Query()),
+	); err != nil {
+		return nil, err
+	}
+
+	return &response, nil
+}
+
+// SendMessage generates a reply for user input together with any pending
+// input in the session. The text may be empty when there is pending input.
+func (c *Client) SendMessage(ctx context.Context, session uuid.UUID, req schema.MessageCreateRequest) (*schema.ChatResponse, error) {
+	if session == uuid.Nil {
+		return nil, fmt.Errorf("session ID cannot be nil")
+	}
+	httpReq, err := client.NewJSONRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	var response schema.ChatResponse
+	if err := c.DoWithContext(ctx, httpReq, &response,
+		client.OptPath("session", session.String(), "message"),
+	); err != nil {
+		return nil, err
+	}
+
+	return &response, nil
+}
+
+// UpdateMessage updates the metadata of a message in a session, for example
+// to pin it so that it is never trimmed from the context window.
+func (c *Client) UpdateMessage(ctx context.Context, session uuid.UUID, id uint64, meta schema.MessageMeta) (*schema.Message, error) {
+	if session == uuid.Nil {
+		return nil, fmt.Errorf("session ID cannot be nil")
+	} else if id == 0 {
+		return nil, fmt.Errorf("message ID cannot be zero")
+	}
+	httpReq, err := client.NewJSONRequestEx(http.MethodPatch, meta, types.ContentTypeAny)
+	if err != nil {
+		return nil, err
+	}
+
+	var response schema.Message
+	if err := c.DoWithContext(ctx, httpReq, &response,
+		client.OptPath("session", session.String(), "message", strconv.FormatUint(id, 10)),
+	); err != nil {
+		return nil, err
+	}
+
+	return &response, nil
+}
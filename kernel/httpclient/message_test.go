@@ -19,7 +19,34 @@ func newMessageServer(t *testing.T) *httptest.Server {
 	t.Helper()
 
 	mux := http.NewServeMux()
-	mux.HandleFunc("/api/session/11111111-1111-1111-1111-111111111111/message", func(w http.ResponseWriter, r *http.Request) {
+	mux.HandleFunc("/api/v1/session/11111111-1111-1111-1111-111111111111/message", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			var req schema.MessageCreateRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			w.Header().Set(types.ContentTypeHeader, types.ContentTypeJSON)
+			if r.URL.Query().Get("send") == "false" {
+				w.WriteHeader(http.StatusCreated)
+				_ = json.NewEncoder(w).Encode(schema.Message{
+					ID:      7,
+					Role:    schema.RoleUser,
+					Content: []schema.ContentBlock{{Text: types.Ptr(req.Text)}},
+					Meta:    map[string]any{schema.MessageMetaPending: true},
+				})
+				return
+			}
+			_ = json.NewEncoder(w).Encode(schema.ChatResponse{
+				ID: 8,
+				CompletionResponse: schema.CompletionResponse{
+					Role:    schema.RoleAssistant,
+					Content: []schema.ContentBlock{{Text: types.Ptr("noted")}},
+					Result:  schema.ResultStop,
+				},
+			})
+			return
+		}
 		if r.Method != http.MethodGet {
 			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
 			return
@@ -111,3 +138,34 @@ func TestListMessagesEmptySession(t *testing.T) {
 		t.Fatal("expected error for empty session")
 	}
 }
+
+func TestAppendMessage(t *testing.T) {
+	server := newMessageServer(t)
+	defer server.Close()
+
+	client := newMessageClient(t, server.URL)
+	message, err := client.AppendMessage(context.Background(), uuid.MustParse("11111111-1111-1111-1111-111111111111"), schema.MessageCreateRequest{Text: "first note"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !message.Pending() {
+		t.Fatal("expected a pending message")
+	}
+	if got := message.Text(); got != "first note" {
+		t.Fatalf("expected %q, got %q", "first note", got)
+	}
+}
+
+func TestSendMessage(t *testing.T) {
+	server := newMessageServer(t)
+	defer server.Close()
+
+	client := newMessageClient(t, server.URL)
+	response, err := client.SendMessage(context.Background(), uuid.MustParse("11111111-1111-1111-1111-111111111111"), schema.MessageCreateRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response.ID != 8 || response.Role != schema.RoleAssistant {
+		t.Fatalf("unexpected response: %v", response)
+	}
+}
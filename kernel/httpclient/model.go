@@ -3,12 +3,14 @@ package httpclient
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"strings"
 
 	// Packages
 	client "github.com/mutablelogic/go-client"
 	opt "github.com/mutablelogic/go-llm/pkg/opt"
 	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	types "github.com/mutablelogic/go-server/pkg/types"
 )
 
 ///////////////////////////////////////////////////////////////////////////////
@@ -25,6 +27,18 @@ func (c *Client) ListModels(ctx context.Context, req schema.ModelListRequest) (*
 	return &response, nil
 }
 
+// RefreshModels fetches the models from the providers, bypassing the
+// cache on the server, and returns them in the same way as ListModels.
+func (c *Client) RefreshModels(ctx context.Context, req schema.ModelListRequest) (*schema.ModelList, error) {
+	var response schema.ModelList
+	if err := c.DoWithContext(ctx, client.NewRequestEx(http.MethodPost, types.ContentTypeJSON), &response, client.OptPath("model", "refresh"), client.OptQuery(req.Query())); err != nil {
+		return nil, err
+	}
+
+	// Return success
+	return &response, nil
+}
+
 // GetModel retrieves a specific model, optionally scoped to a provider.
 func (c *Client) GetModel(ctx context.Context, req schema.GetModelRequest) (*schema.Model, error) {
 	req.Name = strings.TrimSpace(req.Name)
@@ -90,6 +104,50 @@ func (c *Client) DeleteModel(ctx context.Context, req schema.DeleteModelRequest)
 	return &response, nil
 }
 
+// LoadModel loads a model into memory, for providers which run models
+// locally, and returns the loaded model.
+func (c *Client) LoadModel(ctx context.Context, req schema.LoadModelRequest) (*schema.Model, error) {
+	req.Name = strings.TrimSpace(req.Name)
+	req.Provider = strings.TrimSpace(req.Provider)
+	if req.Name == "" {
+		return nil, fmt.Errorf("model name cannot be empty")
+	}
+
+	httpReq, err := client.NewJSONRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	var response schema.Model
+	if err := c.DoWithContext(ctx, httpReq, &response, client.OptPath("model", "load"), client.OptNoTimeout()); err != nil {
+		return nil, err
+	}
+
+	return &response, nil
+}
+
+// UnloadModel unloads a model from memory, for providers which run models
+// locally, and returns the unloaded model.
+func (c *Client) UnloadModel(ctx context.Context, req schema.UnloadModelRequest) (*schema.Model, error) {
+	req.Name = strings.TrimSpace(req.Name)
+	req.Provider = strings.TrimSpace(req.Provider)
+	if req.Name == "" {
+		return nil, fmt.Errorf("model name cannot be empty")
+	}
+
+	httpReq, err := client.NewJSONRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	var response schema.Model
+	if err := c.DoWithContext(ctx, httpReq, &response, client.OptPath("model", "unload")); err != nil {
+		return nil, err
+	}
+
+	return &response, nil
+}
+
 ///////////////////////////////////////////////////////////////////////////////
 // PRIVATE METHODS
 
@@ -84,3 +84,51 @@ func (c *Client) UpdateProvider(ctx context.Context, name string, req schema.Pro
 	// Return success
 	return &response, nil
 }
+
+// GetProviderCredentials returns the credentials which the user has set for
+// a provider, without the keys.
+func (c *Client) GetProviderCredentials(ctx context.Context, name string) (*schema.ProviderCredential, error) {
+	name = strings.TrimSpace(name)
+
+	var response schema.ProviderCredential
+	if err := c.DoWithContext(ctx, client.MethodGet, &response, client.OptPath("provider", name, "credentials")); err != nil {
+		return nil, err
+	}
+
+	// Return success
+	return &response, nil
+}
+
+// SetProviderCredentials sets the credentials of the user for a provider,
+// which are used for the requests of the user in place of the credentials
+// of the provider.
+func (c *Client) SetProviderCredentials(ctx context.Context, name string, req schema.ProviderCredentials) (*schema.ProviderCredential, error) {
+	name = strings.TrimSpace(name)
+
+	httpReq, err := client.NewJSONRequestEx(http.MethodPut, req, types.ContentTypeAny)
+	if err != nil {
+		return nil, err
+	}
+
+	var response schema.ProviderCredential
+	if err := c.DoWithContext(ctx, httpReq, &response, client.OptPath("provider", name, "credentials")); err != nil {
+		return nil, err
+	}
+
+	// Return success
+	return &response, nil
+}
+
+// DeleteProviderCredentials removes the credentials of the user for a
+// provider, and returns the deleted credentials without the keys.
+func (c *Client) DeleteProviderCredentials(ctx context.Context, name string) (*schema.ProviderCredential, error) {
+	name = strings.TrimSpace(name)
+
+	var response schema.ProviderCredential
+	if err := c.DoWithContext(ctx, client.MethodDelete, &response, client.OptPath("provider", name, "credentials")); err != nil {
+		return nil, err
+	}
+
+	// Return success
+	return &response, nil
+}
@@ -0,0 +1,30 @@
+package httpclient
+
+import (
+	"context"
+	"fmt"
+
+	// Packages
+	uuid "github.com/google/uuid"
+	client "github.com/mutablelogic/go-client"
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// ListSessionReasoning returns the thinking of models which is recorded
+// apart from the transcript of a session. It is an administrator operation.
+func (c *Client) ListSessionReasoning(ctx context.Context, id uuid.UUID) (schema.ReasoningList, error) {
+	if id == uuid.Nil {
+		return nil, fmt.Errorf("session ID cannot be nil")
+	}
+
+	var response schema.ReasoningList
+	if err := c.DoWithContext(ctx, client.MethodGet, &response, client.OptPath("admin", "session", id.String(), "reasoning")); err != nil {
+		return nil, err
+	}
+
+	// Return success
+	return response, nil
+}
@@ -0,0 +1,29 @@
+package httpclient
+
+import (
+	"context"
+	"fmt"
+
+	// Packages
+	uuid "github.com/google/uuid"
+	client "github.com/mutablelogic/go-client"
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// GetRecording returns the recording of a chat turn made with ChatRecorded.
+// When step is non-nil, only the step at that position is returned.
+func (c *Client) GetRecording(ctx context.Context, id uuid.UUID, step *uint) (*schema.Recording, error) {
+	if id == uuid.Nil {
+		return nil, fmt.Errorf("recording ID cannot be nil")
+	}
+
+	var response schema.Recording
+	if err := c.DoWithContext(ctx, client.MethodGet, &response, client.OptPath("recording", id.String()), client.OptQuery(schema.RecordingQuery{Step: step}.Query())); err != nil {
+		return nil, err
+	}
+
+	return &response, nil
+}
@@ -0,0 +1,27 @@
+package httpclient
+
+import (
+	"context"
+	"net/http"
+
+	// Packages
+	client "github.com/mutablelogic/go-client"
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	types "github.com/mutablelogic/go-server/pkg/types"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// Reload asks the server to rebuild the clients of providers whose settings
+// or credentials have changed, and to sync connectors and agents, without
+// restarting.
+func (c *Client) Reload(ctx context.Context) (*schema.Reload, error) {
+	var response schema.Reload
+	if err := c.DoWithContext(ctx, client.NewRequestEx(http.MethodPost, types.ContentTypeJSON), &response, client.OptPath("admin", "reload")); err != nil {
+		return nil, err
+	}
+
+	// Return success
+	return &response, nil
+}
@@ -4,11 +4,13 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"net/url"
 
 	// Packages
 	uuid "github.com/google/uuid"
 	client "github.com/mutablelogic/go-client"
 	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	opt "github.com/mutablelogic/go-llm/pkg/opt"
 	types "github.com/mutablelogic/go-server/pkg/types"
 )
 
@@ -40,6 +42,22 @@ func (c *Client) CreateSession(ctx context.Context, req schema.SessionInsert) (*
 	return &response, nil
 }
 
+// ImportSessions imports conversations exported from another tool, and
+// returns the sessions which were created.
+func (c *Client) ImportSessions(ctx context.Context, req schema.SessionImportRequest) (*schema.SessionImportResponse, error) {
+	httpReq, err := client.NewJSONRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	var response schema.SessionImportResponse
+	if err := c.DoWithContext(ctx, httpReq, &response, client.OptPath("session", "import")); err != nil {
+		return nil, err
+	}
+
+	return &response, nil
+}
+
 // GetSession returns a session by ID.
 func (c *Client) GetSession(ctx context.Context, id uuid.UUID) (*schema.Session, error) {
 	if id == uuid.Nil {
@@ -54,6 +72,36 @@ func (c *Client) GetSession(ctx context.Context, id uuid.UUID) (*schema.Session,
 	return &response, nil
 }
 
+// GetSessionSummary returns the summary of a session by ID, with the number
+// of messages and without the generator settings.
+func (c *Client) GetSessionSummary(ctx context.Context, id uuid.UUID) (*schema.SessionSummary, error) {
+	if id == uuid.Nil {
+		return nil, fmt.Errorf("session ID cannot be nil")
+	}
+
+	var response schema.SessionSummary
+	if err := c.DoWithContext(ctx, client.MethodGet, &response, client.OptPath("session", id.String()), client.OptQuery(url.Values{"summary": {"true"}})); err != nil {
+		return nil, err
+	}
+
+	return &response, nil
+}
+
+// SyncSession returns the changes to a session since the cursor of the
+// request, which is empty to return the whole session.
+func (c *Client) SyncSession(ctx context.Context, id uuid.UUID, req schema.SessionSyncRequest) (*schema.SessionSync, error) {
+	if id == uuid.Nil {
+		return nil, fmt.Errorf("session ID cannot be nil")
+	}
+
+	var response schema.SessionSync
+	if err := c.DoWithContext(ctx, client.MethodGet, &response, client.OptPath("session", id.String(), "sync"), client.OptQuery(req.Query())); err != nil {
+		return nil, err
+	}
+
+	return &response, nil
+}
+
 // DeleteSession deletes a session by ID and returns the deleted session.
 func (c *Client) DeleteSession(ctx context.Context, id uuid.UUID) (*schema.Session, error) {
 	if id == uuid.Nil {
@@ -68,6 +116,67 @@ func (c *Client) DeleteSession(ctx context.Context, id uuid.UUID) (*schema.Sessi
 	return &response, nil
 }
 
+// DeleteSessions deletes the sessions matching the request, or returns them
+// without deleting in a dry run. When progressFn is non-nil, the request is
+// made as an SSE stream and the callback is invoked as each session is deleted.
+func (c *Client) DeleteSessions(ctx context.Context, req schema.SessionBulkRequest, progressFn opt.ProgressFn) (*schema.SessionBulkResult, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	var response schema.SessionBulkResult
+	if progressFn == nil {
+		if err := c.DoWithContext(ctx, client.MethodDelete, &response, client.OptPath("session"), client.OptQuery(req.Query()), client.OptNoTimeout()); err != nil {
+			return nil, err
+		}
+		return &response, nil
+	}
+
+	var result *schema.SessionBulkResult
+	var streamErr error
+	callback := func(evt client.TextStreamEvent) error {
+		switch evt.Event {
+		case schema.EventProgress:
+			var progress schema.ProgressEvent
+			if err := evt.Json(&progress); err != nil {
+				return fmt.Errorf("malformed progress event: %w", err)
+			}
+			progressFn(progress.Status, progress.Percent)
+		case schema.EventError:
+			var streamError schema.StreamError
+			if err := evt.Json(&streamError); err != nil {
+				return fmt.Errorf("malformed error event: %w", err)
+			}
+			streamErr = fmt.Errorf("%s", streamError.Error)
+		case schema.EventResult:
+			if err := evt.Json(&response); err != nil {
+				return fmt.Errorf("malformed result event: %w", err)
+			}
+			result = &response
+		}
+		return nil
+	}
+
+	var discard struct{}
+	if err := c.DoWithContext(ctx, client.MethodDelete, &discard,
+		client.OptPath("session"),
+		client.OptQuery(req.Query()),
+		client.OptReqHeader("Accept", "text/event-stream"),
+		client.OptTextStreamCallback(callback),
+		client.OptNoTimeout(),
+	); err != nil {
+		return nil, err
+	}
+	if streamErr != nil {
+		return nil, streamErr
+	}
+	if result == nil {
+		return nil, fmt.Errorf("no result event received in stream")
+	}
+
+	return result, nil
+}
+
 // UpdateSession patches the metadata for a session by ID and returns the updated session.
 func (c *Client) UpdateSession(ctx context.Context, id uuid.UUID, meta schema.SessionMeta) (*schema.Session, error) {
 	if id == uuid.Nil {
@@ -86,3 +195,45 @@ func (c *Client) UpdateSession(ctx context.Context, id uuid.UUID, meta schema.Se
 
 	return &response, nil
 }
+
+// SessionBudget returns the tokens for each message of a session against the
+// context window of the session model.
+func (c *Client) SessionBudget(ctx context.Context, id uuid.UUID) (*schema.SessionBudget, error) {
+	if id == uuid.Nil {
+		return nil, fmt.Errorf("session ID cannot be nil")
+	}
+
+	var response schema.SessionBudget
+	if err := c.DoWithContext(ctx, client.MethodGet, &response, client.OptPath("session", id.String(), "budget")); err != nil {
+		return nil, err
+	}
+
+	return &response, nil
+}
+
+// DeleteData deletes all sessions matching the request, together with their
+// messages, memories and usage records, and returns a deletion report.
+func (c *Client) DeleteData(ctx context.Context, req schema.DataDeleteRequest) (*schema.DataDeleteReport, error) {
+	var response schema.DataDeleteReport
+	if err := c.DoWithContext(ctx, client.MethodDelete, &response, client.OptPath("data"), client.OptQuery(req.Query())); err != nil {
+		return nil, err
+	}
+
+	return &response, nil
+}
+
+// Topics groups the sessions matching the request into topics, and
+// optionally tags each session with its topic.
+func (c *Client) Topics(ctx context.Context, req schema.TopicRequest) (*schema.TopicReport, error) {
+	httpReq, err := client.NewJSONRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	var response schema.TopicReport
+	if err := c.DoWithContext(ctx, httpReq, &response, client.OptPath("analytics", "topics")); err != nil {
+		return nil, err
+	}
+
+	return &response, nil
+}
@@ -0,0 +1,64 @@
+package httpclient
+
+import (
+	"context"
+	"fmt"
+
+	// Packages
+	uuid "github.com/google/uuid"
+	client "github.com/mutablelogic/go-client"
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// CreateSessionShare creates a read-only share link for a session, and
+// returns it with the token, which is not returned again.
+func (c *Client) CreateSessionShare(ctx context.Context, id uuid.UUID, req schema.SessionShareRequest) (*schema.SessionShare, error) {
+	if id == uuid.Nil {
+		return nil, fmt.Errorf("session ID cannot be nil")
+	}
+	httpReq, err := client.NewJSONRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	var response schema.SessionShare
+	if err := c.DoWithContext(ctx, httpReq, &response, client.OptPath("session", id.String(), "share")); err != nil {
+		return nil, err
+	}
+
+	return &response, nil
+}
+
+// ListSessionShares returns the share links of a session which have not
+// expired.
+func (c *Client) ListSessionShares(ctx context.Context, id uuid.UUID) (schema.SessionShareList, error) {
+	if id == uuid.Nil {
+		return nil, fmt.Errorf("session ID cannot be nil")
+	}
+
+	var response schema.SessionShareList
+	if err := c.DoWithContext(ctx, client.MethodGet, &response, client.OptPath("session", id.String(), "share")); err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+// DeleteSessionShare revokes a share link of a session and returns it.
+func (c *Client) DeleteSessionShare(ctx context.Context, id, share uuid.UUID) (*schema.SessionShare, error) {
+	if id == uuid.Nil {
+		return nil, fmt.Errorf("session ID cannot be nil")
+	} else if share == uuid.Nil {
+		return nil, fmt.Errorf("share ID cannot be nil")
+	}
+
+	var response schema.SessionShare
+	if err := c.DoWithContext(ctx, client.MethodDelete, &response, client.OptPath("session", id.String(), "share", share.String())); err != nil {
+		return nil, err
+	}
+
+	return &response, nil
+}
@@ -0,0 +1,52 @@
+package httpclient
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	// Packages
+	uuid "github.com/google/uuid"
+	client "github.com/mutablelogic/go-client"
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// CreateThread starts a side thread on a message of a session, and returns
+// the child session for the thread.
+func (c *Client) CreateThread(ctx context.Context, session uuid.UUID, message uint64, meta schema.SessionMeta) (*schema.Session, error) {
+	if session == uuid.Nil {
+		return nil, fmt.Errorf("session ID cannot be nil")
+	} else if message == 0 {
+		return nil, fmt.Errorf("message ID cannot be zero")
+	}
+	httpReq, err := client.NewJSONRequest(meta)
+	if err != nil {
+		return nil, err
+	}
+
+	var response schema.Session
+	if err := c.DoWithContext(ctx, httpReq, &response, client.OptPath("session", session.String(), "message", strconv.FormatUint(message, 10), "thread")); err != nil {
+		return nil, err
+	}
+
+	return &response, nil
+}
+
+// ListThreads returns the side threads on a message of a session.
+func (c *Client) ListThreads(ctx context.Context, session uuid.UUID, message uint64, req schema.ThreadListRequest) (*schema.SessionList, error) {
+	if session == uuid.Nil {
+		return nil, fmt.Errorf("session ID cannot be nil")
+	} else if message == 0 {
+		return nil, fmt.Errorf("message ID cannot be zero")
+	}
+
+	var response schema.SessionList
+	if err := c.DoWithContext(ctx, client.MethodGet, &response, client.OptPath("session", session.String(), "message", strconv.FormatUint(message, 10), "thread"), client.OptQuery(req.Query())); err != nil {
+		return nil, err
+	}
+
+	return &response, nil
+}
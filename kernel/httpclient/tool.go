@@ -66,6 +66,37 @@ func (c *Client) CallTool(ctx context.Context, name string, req schema.CallToolR
 	return resource, nil
 }
 
+// ToolDocs returns documentation for the tools as a document in the
+// requested format.
+func (c *Client) ToolDocs(ctx context.Context, req schema.ToolDocsRequest) ([]byte, error) {
+	var response export
+	if err := c.DoWithContext(ctx, client.MethodGet, &response, client.OptPath("tool", "docs"), client.OptQuery(req.Query())); err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+// TestTool runs a tool outside of a chat and returns a report of the call,
+// which records a failed input or output validation, or a tool error.
+func (c *Client) TestTool(ctx context.Context, name string, req schema.CallToolRequest) (*schema.ToolTestReport, error) {
+	if name == "" {
+		return nil, fmt.Errorf("tool name cannot be empty")
+	}
+
+	payload, err := client.NewJSONRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	var response schema.ToolTestReport
+	if err := c.DoWithContext(ctx, payload, &response, client.OptPath("tool", name, "test")); err != nil {
+		return nil, err
+	}
+
+	return &response, nil
+}
+
 ///////////////////////////////////////////////////////////////////////////////
 // PUBLIC METHODS
 
@@ -32,7 +32,7 @@ func newToolServer(t *testing.T) *httptest.Server {
 		{Name: "builtin.bravo", Description: "B"},
 		{Name: "remote.echo", Description: "Echo"},
 	}
-	mux.HandleFunc("/api/tool", func(w http.ResponseWriter, r *http.Request) {
+	mux.HandleFunc("/api/v1/tool", func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodGet {
 			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
 			return
@@ -68,8 +68,8 @@ func newToolServer(t *testing.T) *httptest.Server {
 		w.Header().Set(types.ContentTypeHeader, types.ContentTypeJSON)
 		_ = json.NewEncoder(w).Encode(response)
 	})
-	mux.HandleFunc("/api/tool/", func(w http.ResponseWriter, r *http.Request) {
-		name, err := url.PathUnescape(r.URL.Path[len("/api/tool/"):])
+	mux.HandleFunc("/api/v1/tool/", func(w http.ResponseWriter, r *http.Request) {
+		name, err := url.PathUnescape(r.URL.Path[len("/api/v1/tool/"):])
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusBadRequest)
 			return
@@ -2,7 +2,10 @@ package httphandler
 
 import (
 	"context"
+	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
 
 	// Packages
 	middleware "github.com/mutablelogic/go-auth/auth/middleware"
@@ -20,8 +23,19 @@ import (
 func AgentHandler(manager *llmmanager.Manager) (string, *jsonschema.Schema, httprequest.PathItem) {
 	return "agent", nil, httprequest.NewPathItem(
 		"Agent operations",
-		"List externally exposed agents",
+		"List and create operations on agents",
 		"Tools & Agents",
+	).Post(
+		func(w http.ResponseWriter, r *http.Request) {
+			_ = createAgent(r.Context(), manager, w, r)
+		},
+		"Create agent",
+		opts.WithDescription("Stores version 1 of an agent, which is then listed in the builtin namespace. Requires the admin scope. The ETag header of the response contains the version."),
+		opts.WithJSONRequest(jsonschema.MustFor[schema.AgentMeta]()),
+		opts.WithJSONResponse(201, jsonschema.MustFor[schema.Agent]()),
+		opts.WithErrorResponse(400, "Invalid request body, agent template, schema or tools."),
+		opts.WithErrorResponse(403, "Creating agents requires the admin scope."),
+		opts.WithErrorResponse(409, "An agent with the same name already exists."),
 	).Get(
 		func(w http.ResponseWriter, r *http.Request) {
 			_ = listAgents(r.Context(), manager, w, r)
@@ -36,7 +50,7 @@ func AgentHandler(manager *llmmanager.Manager) (string, *jsonschema.Schema, http
 func AgentResourceHandler(manager *llmmanager.Manager) (string, *jsonschema.Schema, httprequest.PathItem) {
 	return "agent/{name}", nil, httprequest.NewPathItem(
 		"Agent operations",
-		"Get and call operations on agents",
+		"Get, call, update and delete operations on agents",
 		"Tools & Agents",
 	).Get(
 		func(w http.ResponseWriter, r *http.Request) {
@@ -59,6 +73,28 @@ func AgentResourceHandler(manager *llmmanager.Manager) (string, *jsonschema.Sche
 		opts.WithErrorResponse(400, "Invalid request body, path parameter, or agent call failure."),
 		opts.WithErrorResponse(404, "Agent not found."),
 		opts.WithErrorResponse(409, "Multiple agents matched; specify a fully-qualified agent name."),
+	).Patch(
+		func(w http.ResponseWriter, r *http.Request) {
+			_ = updateAgent(r.Context(), manager, w, r)
+		},
+		"Update agent",
+		opts.WithDescription("Stores the next version of an agent with the fields which are set in the request. Requires the admin scope. When the If-Match header contains a version, the update fails unless the agent is at that version. The ETag header of the response contains the new version."),
+		opts.WithJSONRequest(jsonschema.MustFor[schema.AgentMeta]()),
+		opts.WithJSONResponse(200, jsonschema.MustFor[schema.Agent]()),
+		opts.WithErrorResponse(400, "Invalid request body, If-Match header, agent template, schema or tools."),
+		opts.WithErrorResponse(403, "Updating agents requires the admin scope."),
+		opts.WithErrorResponse(404, "Agent not found."),
+		opts.WithErrorResponse(409, "The agent is not at the version in the If-Match header, or has been updated since."),
+	).Delete(
+		func(w http.ResponseWriter, r *http.Request) {
+			_ = deleteAgent(r.Context(), manager, w, r)
+		},
+		"Delete agent",
+		opts.WithDescription("Deletes all versions of a stored agent. Requires the admin scope."),
+		opts.WithNoContentResponse(204, "Agent deleted."),
+		opts.WithErrorResponse(400, "Invalid agent path parameter."),
+		opts.WithErrorResponse(403, "Deleting agents requires the admin scope."),
+		opts.WithErrorResponse(404, "Agent not found."),
 	)
 }
 
@@ -79,6 +115,21 @@ func listAgents(ctx context.Context, manager *llmmanager.Manager, w http.Respons
 	return httpresponse.JSON(w, http.StatusOK, httprequest.Indent(r), agents)
 }
 
+func createAgent(ctx context.Context, manager *llmmanager.Manager, w http.ResponseWriter, r *http.Request) error {
+	var req schema.AgentMeta
+	if err := httprequest.Read(r, &req); err != nil {
+		return httpresponse.Error(w, httpresponse.ErrBadRequest, err)
+	}
+
+	agent, err := manager.CreateAgent(ctx, req, middleware.UserFromContext(ctx))
+	if err != nil {
+		return httpresponse.Error(w, schema.HTTPErr(err))
+	}
+
+	w.Header().Set("ETag", agentETag(agent))
+	return httpresponse.JSON(w, http.StatusCreated, httprequest.Indent(r), agent)
+}
+
 func getAgent(ctx context.Context, manager *llmmanager.Manager, w http.ResponseWriter, r *http.Request) error {
 	name, err := unescapePathValue(r, "name")
 	if err != nil {
@@ -111,3 +162,59 @@ func callAgent(ctx context.Context, manager *llmmanager.Manager, w http.Response
 
 	return writeToolResource(ctx, w, resource)
 }
+
+func updateAgent(ctx context.Context, manager *llmmanager.Manager, w http.ResponseWriter, r *http.Request) error {
+	name, err := unescapePathValue(r, "name")
+	if err != nil {
+		return httpresponse.Error(w, httpresponse.ErrBadRequest, err)
+	}
+	version, err := agentIfMatch(r)
+	if err != nil {
+		return httpresponse.Error(w, httpresponse.ErrBadRequest, err)
+	}
+
+	var req schema.AgentMeta
+	if err := httprequest.Read(r, &req); err != nil {
+		return httpresponse.Error(w, httpresponse.ErrBadRequest, err)
+	}
+
+	agent, err := manager.UpdateAgent(ctx, name, version, req, middleware.UserFromContext(ctx))
+	if err != nil {
+		return httpresponse.Error(w, schema.HTTPErr(err))
+	}
+
+	w.Header().Set("ETag", agentETag(agent))
+	return httpresponse.JSON(w, http.StatusOK, httprequest.Indent(r), agent)
+}
+
+func deleteAgent(ctx context.Context, manager *llmmanager.Manager, w http.ResponseWriter, r *http.Request) error {
+	name, err := unescapePathValue(r, "name")
+	if err != nil {
+		return httpresponse.Error(w, httpresponse.ErrBadRequest, err)
+	}
+
+	if err := manager.DeleteAgent(ctx, name, middleware.UserFromContext(ctx)); err != nil {
+		return httpresponse.Error(w, schema.HTTPErr(err))
+	}
+
+	return httpresponse.Empty(w, http.StatusNoContent)
+}
+
+// agentETag returns the entity tag for the version of an agent
+func agentETag(agent *schema.Agent) string {
+	return strconv.Quote(strconv.FormatUint(uint64(agent.Version), 10))
+}
+
+// agentIfMatch returns the agent version in the If-Match header, or zero when
+// the header is not set or matches any version
+func agentIfMatch(r *http.Request) (uint, error) {
+	value := strings.TrimSpace(r.Header.Get("If-Match"))
+	if value == "" || value == "*" {
+		return 0, nil
+	}
+	version, err := strconv.ParseUint(strings.Trim(strings.TrimPrefix(value, "W/"), `"`), 10, 32)
+	if err != nil || version == 0 {
+		return 0, fmt.Errorf("invalid If-Match header %q, expected an agent version", value)
+	}
+	return uint(version), nil
+}
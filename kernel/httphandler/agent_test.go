@@ -9,6 +9,9 @@ import (
 	"testing"
 
 	// Packages
+	uuid "github.com/google/uuid"
+	middleware "github.com/mutablelogic/go-auth/auth/middleware"
+	auth "github.com/mutablelogic/go-auth/auth/schema"
 	llm "github.com/mutablelogic/go-llm"
 	llmmanager "github.com/mutablelogic/go-llm/kernel/manager"
 	opt "github.com/mutablelogic/go-llm/pkg/opt"
@@ -270,6 +273,81 @@ func TestCallAgentUnescapesName(t *testing.T) {
 	}
 }
 
+func TestAgentIfMatch(t *testing.T) {
+	for header, expected := range map[string]uint{
+		"":       0,
+		"*":      0,
+		`"3"`:    3,
+		`W/"12"`: 12,
+		"7":      7,
+	} {
+		r := httptest.NewRequest(http.MethodPatch, "/agent/alpha", nil)
+		if header != "" {
+			r.Header.Set("If-Match", header)
+		}
+		version, err := agentIfMatch(r)
+		if err != nil {
+			t.Fatalf("If-Match %q: %v", header, err)
+		}
+		if version != expected {
+			t.Fatalf("If-Match %q: expected version %d, got %d", header, expected, version)
+		}
+	}
+
+	// Entity tags which are not versions are rejected
+	for _, header := range []string{`"0"`, `"abc"`, `"-1"`} {
+		r := httptest.NewRequest(http.MethodPatch, "/agent/alpha", nil)
+		r.Header.Set("If-Match", header)
+		if _, err := agentIfMatch(r); err == nil {
+			t.Fatalf("If-Match %q: expected error", header)
+		}
+	}
+	if etag := agentETag(&schema.Agent{Version: 3}); etag != `"3"` {
+		t.Fatalf("unexpected ETag %s", etag)
+	}
+}
+
+// agentTestAuthenticator authenticates every bearer token as the user
+type agentTestAuthenticator struct {
+	user *auth.UserInfo
+}
+
+func (a agentTestAuthenticator) Issuer() (string, error) { return "test", nil }
+func (a agentTestAuthenticator) AuthenticateBearer(context.Context, string) (*auth.UserInfo, *auth.Session, error) {
+	return a.user, nil, nil
+}
+func (a agentTestAuthenticator) AuthenticateKey(context.Context, string) (*auth.UserInfo, *auth.Key, error) {
+	return a.user, nil, nil
+}
+
+func TestAgentWriteRequiresAdmin(t *testing.T) {
+	manager := &llmmanager.Manager{}
+	authn := middleware.AuthN(agentTestAuthenticator{user: &auth.UserInfo{Sub: auth.UserID(uuid.New())}})
+	_, _, list := AgentHandler(manager)
+	_, _, item := AgentResourceHandler(manager)
+
+	// Users without the admin scope cannot create, update or delete agents
+	for _, req := range []struct {
+		method, path, body string
+		handler            http.Handler
+	}{
+		{http.MethodPost, "/agent", `{"name":"alpha","template":"Hello"}`, list.Handler()},
+		{http.MethodPatch, "/agent/alpha", `{"title":"Alpha"}`, item.Handler()},
+		{http.MethodDelete, "/agent/alpha", "", item.Handler()},
+	} {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(req.method, req.path, bytes.NewReader([]byte(req.body)))
+		r.Header.Set("Authorization", "Bearer token")
+		r.Header.Set(types.ContentTypeHeader, types.ContentTypeJSON)
+		r.SetPathValue("name", "alpha")
+		authn(req.handler.ServeHTTP)(w, r)
+
+		if w.Code != http.StatusForbidden {
+			t.Fatalf("%s %s: expected 403, got %d: %s", req.method, req.path, w.Code, w.Body.String())
+		}
+	}
+}
+
 func mustAgentToolkit(t *testing.T) toolkit.Toolkit {
 	t.Helper()
 	return mustAgentToolkitWithDelegate(t, nil)
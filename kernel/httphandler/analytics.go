@@ -0,0 +1,84 @@
+package httphandler
+
+import (
+	"context"
+	"net/http"
+
+	// Packages
+	middleware "github.com/mutablelogic/go-auth/auth/middleware"
+	auth "github.com/mutablelogic/go-auth/auth/schema"
+	llmmanager "github.com/mutablelogic/go-llm/kernel/manager"
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	httprequest "github.com/mutablelogic/go-server/pkg/httprequest"
+	httpresponse "github.com/mutablelogic/go-server/pkg/httpresponse"
+	jsonschema "github.com/mutablelogic/go-server/pkg/jsonschema"
+	opts "github.com/mutablelogic/go-server/pkg/openapi"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+func UsageAnalyticsHandler(manager *llmmanager.Manager) (string, *jsonschema.Schema, httprequest.PathItem) {
+	return "analytics/usage", nil, httprequest.NewPathItem(
+		"Usage analytics",
+		"Requests and tokens in each time bucket, by usage type",
+		"Sessions",
+	).Get(
+		func(w http.ResponseWriter, r *http.Request) {
+			_ = analytics(r.Context(), manager.UsageAnalytics, w, r)
+		},
+		"Get usage analytics",
+		opts.WithQuery(jsonschema.MustFor[schema.AnalyticsRequest]()),
+		opts.WithJSONResponse(200, jsonschema.MustFor[schema.UsageAnalytics]()),
+		opts.WithErrorResponse(400, "Invalid period or bucket size."),
+	)
+}
+
+func ModelAnalyticsHandler(manager *llmmanager.Manager) (string, *jsonschema.Schema, httprequest.PathItem) {
+	return "analytics/models", nil, httprequest.NewPathItem(
+		"Model analytics",
+		"Requests and tokens in each time bucket, by provider and model",
+		"Sessions",
+	).Get(
+		func(w http.ResponseWriter, r *http.Request) {
+			_ = analytics(r.Context(), manager.ModelAnalytics, w, r)
+		},
+		"Get model analytics",
+		opts.WithQuery(jsonschema.MustFor[schema.AnalyticsRequest]()),
+		opts.WithJSONResponse(200, jsonschema.MustFor[schema.UsageAnalytics]()),
+		opts.WithErrorResponse(400, "Invalid period or bucket size."),
+	)
+}
+
+func ToolAnalyticsHandler(manager *llmmanager.Manager) (string, *jsonschema.Schema, httprequest.PathItem) {
+	return "analytics/tools", nil, httprequest.NewPathItem(
+		"Tool analytics",
+		"Tool results and errors in each time bucket, most used tools first",
+		"Sessions",
+	).Get(
+		func(w http.ResponseWriter, r *http.Request) {
+			_ = analytics(r.Context(), manager.ToolAnalytics, w, r)
+		},
+		"Get tool analytics",
+		opts.WithQuery(jsonschema.MustFor[schema.AnalyticsRequest]()),
+		opts.WithJSONResponse(200, jsonschema.MustFor[schema.ToolAnalytics]()),
+		opts.WithErrorResponse(400, "Invalid period or bucket size."),
+	)
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+func analytics[T any](ctx context.Context, fn func(context.Context, schema.AnalyticsRequest, *auth.UserInfo) (T, error), w http.ResponseWriter, r *http.Request) error {
+	var req schema.AnalyticsRequest
+	if err := httprequest.Query(r.URL.Query(), &req); err != nil {
+		return httpresponse.Error(w, httpresponse.ErrBadRequest, err)
+	}
+
+	report, err := fn(ctx, req, middleware.UserFromContext(ctx))
+	if err != nil {
+		return httpresponse.Error(w, schema.HTTPErr(err))
+	}
+
+	return httpresponse.JSON(w, http.StatusOK, httprequest.Indent(r), report)
+}
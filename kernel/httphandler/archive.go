@@ -0,0 +1,52 @@
+package httphandler
+
+import (
+	"context"
+	"net/http"
+
+	// Packages
+	uuid "github.com/google/uuid"
+	middleware "github.com/mutablelogic/go-auth/auth/middleware"
+	llmmanager "github.com/mutablelogic/go-llm/kernel/manager"
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	httprequest "github.com/mutablelogic/go-server/pkg/httprequest"
+	httpresponse "github.com/mutablelogic/go-server/pkg/httpresponse"
+	jsonschema "github.com/mutablelogic/go-server/pkg/jsonschema"
+	opts "github.com/mutablelogic/go-server/pkg/openapi"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+func ArchiveResourceHandler(manager *llmmanager.Manager) (string, *jsonschema.Schema, httprequest.PathItem) {
+	return "archive/{request}", jsonschema.MustFor[schema.ArchiveIDSelector](), httprequest.NewPathItem(
+		"Archive",
+		"Archived requests and responses, when archiving is enabled",
+		"Responses",
+	).Get(
+		func(w http.ResponseWriter, r *http.Request) {
+			_ = getArchive(r.Context(), manager, w, r)
+		},
+		"Get archived request",
+		opts.WithJSONResponse(200, jsonschema.MustFor[schema.Archive]()),
+		opts.WithErrorResponse(400, "Invalid request ID."),
+		opts.WithErrorResponse(404, "Request not found in the archive."),
+	)
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+func getArchive(ctx context.Context, manager *llmmanager.Manager, w http.ResponseWriter, r *http.Request) error {
+	id, err := uuid.Parse(r.PathValue("request"))
+	if err != nil {
+		return httpresponse.Error(w, httpresponse.ErrBadRequest, err)
+	}
+
+	record, err := manager.GetArchive(ctx, id, middleware.UserFromContext(ctx))
+	if err != nil {
+		return httpresponse.Error(w, schema.HTTPErr(err))
+	}
+
+	return httpresponse.JSON(w, http.StatusOK, httprequest.Indent(r), record)
+}
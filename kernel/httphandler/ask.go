@@ -28,13 +28,15 @@ func AskHandler(manager *llmmanager.Manager) (string, *jsonschema.Schema, httpre
 			_ = ask(r.Context(), manager, w, r)
 		},
 		"Ask model",
+		opts.WithQuery(jsonschema.MustFor[schema.AskQuery]()),
 		opts.WithJSONRequest(jsonschema.MustFor[schema.AskRequest]()),
 		opts.WithJSONResponse(200, jsonschema.MustFor[schema.AskResponse]()),
-		opts.WithTextStreamResponse(200, "SSE stream of assistant, thinking, tool, error, and result events."),
+		opts.WithTextStreamResponse(200, "SSE stream of assistant, thinking, tool, usage, error, and result events. Usage events estimate the tokens while the reply is streamed, and the last has the tokens reported by the provider with the estimate."),
 		opts.WithErrorResponse(400, "Invalid request body or ask failure."),
 		opts.WithErrorResponse(404, "Model or provider not found."),
 		opts.WithErrorResponse(409, "Multiple models matched; specify a provider."),
 		opts.WithErrorResponse(406, "Unsupported Accept header."),
+		opts.WithErrorResponse(429, "The queue for the priority is full; retry after the time in the Retry-After header."),
 		opts.WithErrorResponse(501, "Provider does not support generation."),
 	)
 }
@@ -44,17 +46,31 @@ func AskHandler(manager *llmmanager.Manager) (string, *jsonschema.Schema, httpre
 
 func ask(ctx context.Context, manager *llmmanager.Manager, w http.ResponseWriter, r *http.Request) error {
 	var req schema.AskRequest
+	var query schema.AskQuery
+	if err := httprequest.Query(r.URL.Query(), &query); err != nil {
+		return httpresponse.Error(w, httpresponse.ErrBadRequest, err)
+	}
 	if err := httprequest.Read(r, &req); err != nil {
 		return httpresponse.Error(w, httpresponse.ErrBadRequest, err)
 	}
+	ctx, err := withPriority(ctx, query.Priority)
+	if err != nil {
+		return httpresponse.Error(w, schema.HTTPErr(err))
+	}
+
+	// Wait for a worker before the response is started, so that a rejected
+	// request is returned as an error rather than in the stream
+	ctx, release, err := manager.Admit(ctx)
+	if err != nil {
+		return writeError(w, err)
+	}
+	defer release()
 
 	switch acceptType(r) {
 	case acceptStream:
-		stream := httpresponse.NewTextStream(w)
-		if stream == nil {
-			return httpresponse.Error(w, httpresponse.ErrInternalError)
-		}
+		stream := newEventStream(ctx, w)
 		defer stream.Close()
+		ctx := stream.Context()
 
 		fn := opt.StreamFn(func(role, text string) {
 			switch role {
@@ -62,12 +78,16 @@ func ask(ctx context.Context, manager *llmmanager.Manager, w http.ResponseWriter
 				stream.Write(schema.EventThinking, schema.StreamDelta{Role: role, Text: text})
 			case schema.RoleTool:
 				stream.Write(schema.EventTool, schema.StreamDelta{Role: role, Text: text})
+			case schema.EventUsage:
+				stream.WriteText(schema.EventUsage, text)
 			default:
 				stream.Write(schema.EventAssistant, schema.StreamDelta{Role: role, Text: text})
 			}
 		})
+		fn, flush := opt.SmoothStreamFn(fn, req.Interval(), int(req.StreamMinChunk))
 
 		resp, err := manager.Ask(ctx, req, middleware.UserFromContext(ctx), fn)
+		flush()
 		if err != nil {
 			stream.Write(schema.EventError, schema.StreamError{Error: err.Error()})
 			return nil
@@ -78,7 +98,7 @@ func ask(ctx context.Context, manager *llmmanager.Manager, w http.ResponseWriter
 	case acceptJSON:
 		resp, err := manager.Ask(ctx, req, middleware.UserFromContext(ctx), nil)
 		if err != nil {
-			return httpresponse.Error(w, schema.HTTPErr(err))
+			return writeError(w, err)
 		}
 		return httpresponse.JSON(w, http.StatusOK, httprequest.Indent(r), resp)
 	default:
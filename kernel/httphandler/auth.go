@@ -0,0 +1,70 @@
+package httphandler
+
+import (
+	"net/http"
+	"strings"
+
+	// Packages
+	middleware "github.com/mutablelogic/go-auth/auth/middleware"
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	httprequest "github.com/mutablelogic/go-server/pkg/httprequest"
+	httpresponse "github.com/mutablelogic/go-server/pkg/httpresponse"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+// Paths which are served without authentication, because the path itself
+// grants access
+var publicPaths = []string{
+	"share/{token}",
+}
+
+// Prefix of the paths which need the admin scope
+const adminPath = "admin/"
+
+// Methods of a path item which are authenticated
+var authMethods = []string{
+	http.MethodGet,
+	http.MethodHead,
+	http.MethodPost,
+	http.MethodPut,
+	http.MethodPatch,
+	http.MethodDelete,
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// authenticate wraps the handlers of a path item so that requests need a
+// bearer token or API key, which is checked by the authenticator, and the
+// user is set in the request context. Paths under admin/ also need the admin
+// scope.
+func authenticate(authenticator middleware.Authenticator, path string, pathitem httprequest.PathItem) {
+	for _, public := range publicPaths {
+		if path == public {
+			return
+		}
+	}
+	authn := middleware.AuthN(authenticator)
+	admin := strings.HasPrefix(path, adminPath)
+	for _, method := range authMethods {
+		pathitem.WrapHandler(method, func(next http.HandlerFunc) http.HandlerFunc {
+			if admin {
+				next = requireAdmin(next)
+			}
+			return authn(next)
+		})
+	}
+}
+
+// requireAdmin responds with forbidden unless the user has the admin scope
+func requireAdmin(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if user := middleware.UserFromContext(r.Context()); user == nil || !user.HasScope(schema.ScopeAdmin) {
+			_ = httpresponse.Error(w, httpresponse.Err(http.StatusForbidden).With("insufficient permissions"), "Required scope: "+schema.ScopeAdmin)
+			return
+		}
+		next(w, r)
+	}
+}
@@ -0,0 +1,46 @@
+package httphandler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	// Packages
+	auth "github.com/mutablelogic/go-auth/auth/schema"
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	httprequest "github.com/mutablelogic/go-server/pkg/httprequest"
+	assert "github.com/stretchr/testify/assert"
+)
+
+func TestAuthenticate(t *testing.T) {
+	serve := func(path string, user *auth.UserInfo, token bool) int {
+		pathitem := httprequest.NewPathItem("Test", "Test", "Test").Get(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		}, "Test")
+		authenticate(agentTestAuthenticator{user: user}, path, pathitem)
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/"+path, nil)
+		if token {
+			r.Header.Set("Authorization", "Bearer token")
+		}
+		pathitem.Handler().ServeHTTP(w, r)
+		return w.Code
+	}
+	user := &auth.UserInfo{Sub: auth.UserID([16]byte{1})}
+	admin := &auth.UserInfo{Sub: auth.UserID([16]byte{2}), Scopes: []string{schema.ScopeAdmin}}
+
+	t.Run("Unauthenticated", func(t *testing.T) {
+		assert.Equal(t, http.StatusUnauthorized, serve("session", user, false))
+	})
+	t.Run("User", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, serve("session", user, true))
+		assert.Equal(t, http.StatusForbidden, serve("admin/reload", user, true))
+	})
+	t.Run("Admin", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, serve("admin/reload", admin, true))
+	})
+	t.Run("Public", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, serve("share/{token}", nil, false))
+	})
+}
@@ -0,0 +1,52 @@
+package httphandler
+
+import (
+	"context"
+	"net/http"
+
+	// Packages
+	uuid "github.com/google/uuid"
+	middleware "github.com/mutablelogic/go-auth/auth/middleware"
+	llmmanager "github.com/mutablelogic/go-llm/kernel/manager"
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	httprequest "github.com/mutablelogic/go-server/pkg/httprequest"
+	httpresponse "github.com/mutablelogic/go-server/pkg/httpresponse"
+	jsonschema "github.com/mutablelogic/go-server/pkg/jsonschema"
+	opts "github.com/mutablelogic/go-server/pkg/openapi"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+func SessionBudgetHandler(manager *llmmanager.Manager) (string, *jsonschema.Schema, httprequest.PathItem) {
+	return "session/{session}/budget", jsonschema.MustFor[schema.SessionIDSelector](), httprequest.NewPathItem(
+		"Session token budget",
+		"Token counts for the messages of a session against the context window of the model",
+		"Sessions",
+	).Get(
+		func(w http.ResponseWriter, r *http.Request) {
+			_ = getSessionBudget(r.Context(), manager, w, r)
+		},
+		"Get session token budget",
+		opts.WithJSONResponse(200, jsonschema.MustFor[schema.SessionBudget]()),
+		opts.WithErrorResponse(400, "Invalid session ID."),
+		opts.WithErrorResponse(404, "Session not found."),
+	)
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+func getSessionBudget(ctx context.Context, manager *llmmanager.Manager, w http.ResponseWriter, r *http.Request) error {
+	id, err := uuid.Parse(r.PathValue("session"))
+	if err != nil {
+		return httpresponse.Error(w, httpresponse.ErrBadRequest, err)
+	}
+
+	budget, err := manager.SessionBudget(ctx, id, middleware.UserFromContext(ctx))
+	if err != nil {
+		return httpresponse.Error(w, schema.HTTPErr(err))
+	}
+
+	return httpresponse.JSON(w, http.StatusOK, httprequest.Indent(r), budget)
+}
@@ -381,6 +381,7 @@ func channelResponseFromMessage(message *schema.Message) schema.ChatResponse {
 			Role:    message.Role,
 			Content: message.Content,
 			Result:  message.Result,
+			Markup:  schema.ParseMarkup(message.Content),
 		},
 	}
 }
@@ -28,12 +28,15 @@ func ChatHandler(manager *llmmanager.Manager) (string, *jsonschema.Schema, httpr
 			_ = chat(r.Context(), manager, w, r)
 		},
 		"Chat within session",
+		opts.WithQuery(jsonschema.MustFor[schema.ChatQuery]()),
 		opts.WithJSONRequest(jsonschema.MustFor[schema.ChatRequest]()),
 		opts.WithJSONResponse(200, jsonschema.MustFor[schema.ChatResponse]()),
-		opts.WithTextStreamResponse(200, "SSE stream of assistant, thinking, tool, error, and result events."),
+		opts.WithJSONResponse(202, jsonschema.MustFor[schema.Job]()),
+		opts.WithTextStreamResponse(200, "SSE stream of assistant, thinking, tool, compaction, usage, error, and result events. Usage events estimate the tokens while the reply is streamed, and the last for each generation has the tokens reported by the provider with the estimate. With dry_run, the provider request is returned as JSON instead. With simulate, tools are not run and their results are fixtures or generated by the model. With async, a job is returned which is polled at job/{job} for the result. With record, the intermediate states of the turn are stepped through at recording/{recording}."),
 		opts.WithErrorResponse(400, "Invalid request body or chat failure."),
 		opts.WithErrorResponse(404, "Session not found."),
 		opts.WithErrorResponse(406, "Unsupported Accept header."),
+		opts.WithErrorResponse(429, "The queue for the priority is full; retry after the time in the Retry-After header."),
 		opts.WithErrorResponse(501, "Provider does not support generation."),
 	)
 }
@@ -43,17 +46,59 @@ func ChatHandler(manager *llmmanager.Manager) (string, *jsonschema.Schema, httpr
 
 func chat(ctx context.Context, manager *llmmanager.Manager, w http.ResponseWriter, r *http.Request) error {
 	var req schema.ChatRequest
+	var query schema.ChatQuery
+	if err := httprequest.Query(r.URL.Query(), &query); err != nil {
+		return httpresponse.Error(w, httpresponse.ErrBadRequest, err)
+	}
 	if err := httprequest.Read(r, &req); err != nil {
 		return httpresponse.Error(w, httpresponse.ErrBadRequest, err)
 	}
+	ctx, err := withPriority(ctx, query.Priority)
+	if err != nil {
+		return httpresponse.Error(w, schema.HTTPErr(err))
+	}
+
+	// Return the request which would be sent to the provider
+	if query.DryRun {
+		resp, err := manager.DryRunChat(ctx, req, middleware.UserFromContext(ctx))
+		if err != nil {
+			return httpresponse.Error(w, schema.HTTPErr(err))
+		}
+		return httpresponse.JSON(w, http.StatusOK, httprequest.Indent(r), resp)
+	}
+
+	// Return fixtures or simulated results for tool calls, instead of running the tools
+	if query.Simulate {
+		ctx = llmmanager.WithToolSimulation(ctx)
+	}
+
+	// Record every intermediate state of the turn
+	if query.Record {
+		ctx = llmmanager.WithRecording(ctx)
+	}
+
+	// Run the turn in the background, and return the job
+	if query.Async {
+		job, err := manager.StartChat(ctx, req, middleware.UserFromContext(ctx))
+		if err != nil {
+			return writeError(w, err)
+		}
+		return httpresponse.JSON(w, http.StatusAccepted, httprequest.Indent(r), job)
+	}
+
+	// Wait for a worker before the response is started, so that a rejected
+	// request is returned as an error rather than in the stream
+	ctx, release, err := manager.Admit(ctx)
+	if err != nil {
+		return writeError(w, err)
+	}
+	defer release()
 
 	switch acceptType(r) {
 	case acceptStream:
-		stream := httpresponse.NewTextStream(w)
-		if stream == nil {
-			return httpresponse.Error(w, httpresponse.ErrInternalError)
-		}
+		stream := newEventStream(ctx, w)
 		defer stream.Close()
+		ctx := stream.Context()
 
 		fn := opt.StreamFn(func(role, text string) {
 			switch role {
@@ -61,23 +106,35 @@ func chat(ctx context.Context, manager *llmmanager.Manager, w http.ResponseWrite
 				stream.Write(schema.EventThinking, schema.StreamDelta{Role: role, Text: text})
 			case schema.RoleTool:
 				stream.Write(schema.EventTool, schema.StreamDelta{Role: role, Text: text})
+			case schema.EventUsage:
+				stream.WriteText(schema.EventUsage, text)
+			case schema.EventCompaction:
+				stream.Write(schema.EventCompaction, schema.StreamDelta{Role: role, Text: text})
 			default:
 				stream.Write(schema.EventAssistant, schema.StreamDelta{Role: role, Text: text})
 			}
 		})
+		fn, flush := opt.SmoothStreamFn(fn, req.Interval(), int(req.StreamMinChunk))
 
 		resp, err := manager.Chat(ctx, req, fn, middleware.UserFromContext(ctx))
+		flush()
 		if err != nil {
 			stream.Write(schema.EventError, schema.StreamError{Error: err.Error()})
 			return nil
 		}
+		if !query.IncludeTrace() {
+			resp.Trace = nil
+		}
 
 		stream.Write(schema.EventResult, resp)
 		return nil
 	case acceptJSON:
 		resp, err := manager.Chat(ctx, req, nil, middleware.UserFromContext(ctx))
 		if err != nil {
-			return httpresponse.Error(w, schema.HTTPErr(err))
+			return writeError(w, err)
+		}
+		if !query.IncludeTrace() {
+			resp.Trace = nil
 		}
 		return httpresponse.JSON(w, http.StatusOK, httprequest.Indent(r), resp)
 	default:
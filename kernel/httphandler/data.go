@@ -0,0 +1,51 @@
+package httphandler
+
+import (
+	"context"
+	"net/http"
+
+	// Packages
+	middleware "github.com/mutablelogic/go-auth/auth/middleware"
+	llmmanager "github.com/mutablelogic/go-llm/kernel/manager"
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	httprequest "github.com/mutablelogic/go-server/pkg/httprequest"
+	httpresponse "github.com/mutablelogic/go-server/pkg/httpresponse"
+	jsonschema "github.com/mutablelogic/go-server/pkg/jsonschema"
+	opts "github.com/mutablelogic/go-server/pkg/openapi"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+func DataHandler(manager *llmmanager.Manager) (string, *jsonschema.Schema, httprequest.PathItem) {
+	return "data", nil, httprequest.NewPathItem(
+		"Data operations",
+		"Deletion of all data associated with a data subject",
+		"Sessions",
+	).Delete(
+		func(w http.ResponseWriter, r *http.Request) {
+			_ = deleteData(r.Context(), manager, w, r)
+		},
+		"Delete data",
+		opts.WithQuery(jsonschema.MustFor[schema.DataDeleteRequest]()),
+		opts.WithJSONResponse(200, jsonschema.MustFor[schema.DataDeleteReport]()),
+		opts.WithErrorResponse(400, "Missing label or invalid request parameters."),
+	)
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+func deleteData(ctx context.Context, manager *llmmanager.Manager, w http.ResponseWriter, r *http.Request) error {
+	var req schema.DataDeleteRequest
+	if err := httprequest.Query(r.URL.Query(), &req); err != nil {
+		return httpresponse.Error(w, httpresponse.ErrBadRequest, err)
+	}
+
+	report, err := manager.DeleteData(ctx, req, middleware.UserFromContext(ctx))
+	if err != nil {
+		return httpresponse.Error(w, schema.HTTPErr(err))
+	}
+
+	return httpresponse.JSON(w, http.StatusOK, httprequest.Indent(r), report)
+}
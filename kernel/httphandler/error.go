@@ -0,0 +1,36 @@
+package httphandler
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"strconv"
+
+	// Packages
+	llmmanager "github.com/mutablelogic/go-llm/kernel/manager"
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	httpresponse "github.com/mutablelogic/go-server/pkg/httpresponse"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// writeError writes an error response, with a Retry-After header in seconds
+// when the request was rejected and can be retried later
+func writeError(w http.ResponseWriter, err error) error {
+	if after, ok := schema.RetryAfter(err); ok {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(after.Seconds()))))
+	}
+	return httpresponse.Error(w, schema.HTTPErr(err))
+}
+
+// withPriority returns a context whose generations are admitted with the
+// priority of the request, or an error if the priority is not known
+func withPriority(ctx context.Context, priority schema.Priority) (context.Context, error) {
+	if priority == "" {
+		return ctx, nil
+	} else if !priority.Valid() {
+		return ctx, schema.ErrBadParameter.Withf("invalid priority %q", priority)
+	}
+	return llmmanager.WithPriority(ctx, priority), nil
+}
@@ -0,0 +1,85 @@
+package httphandler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	// Packages
+	uuid "github.com/google/uuid"
+	middleware "github.com/mutablelogic/go-auth/auth/middleware"
+	llmmanager "github.com/mutablelogic/go-llm/kernel/manager"
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	httprequest "github.com/mutablelogic/go-server/pkg/httprequest"
+	httpresponse "github.com/mutablelogic/go-server/pkg/httpresponse"
+	jsonschema "github.com/mutablelogic/go-server/pkg/jsonschema"
+	opts "github.com/mutablelogic/go-server/pkg/openapi"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+// Content type for each export format
+var exportTypes = map[string]string{
+	schema.ExportPDF:  "application/pdf",
+	schema.ExportHTML: "text/html; charset=utf-8",
+}
+
+// Schema for a binary response body
+var exportBody, _ = jsonschema.FromJSON(json.RawMessage(`{"type":"string","format":"binary"}`))
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+func SessionExportHandler(manager *llmmanager.Manager) (string, *jsonschema.Schema, httprequest.PathItem) {
+	return "session/{session}/export", jsonschema.MustFor[schema.SessionIDSelector](), httprequest.NewPathItem(
+		"Session export",
+		"Export a session as a document for sharing",
+		"Sessions",
+	).Get(
+		func(w http.ResponseWriter, r *http.Request) {
+			_ = exportSession(r.Context(), manager, w, r)
+		},
+		"Export session",
+		opts.WithQuery(jsonschema.MustFor[schema.SessionExportRequest]()),
+		opts.WithResponse(200, exportTypes[schema.ExportPDF], exportBody, "Session transcript, including messages, code, images and tool calls."),
+		opts.WithErrorResponse(400, "Invalid session ID or unsupported format."),
+		opts.WithErrorResponse(404, "Session not found."),
+	)
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+func exportSession(ctx context.Context, manager *llmmanager.Manager, w http.ResponseWriter, r *http.Request) error {
+	id, err := uuid.Parse(r.PathValue("session"))
+	if err != nil {
+		return httpresponse.Error(w, httpresponse.ErrBadRequest, err)
+	}
+
+	var req schema.SessionExportRequest
+	if err := httprequest.Query(r.URL.Query(), &req); err != nil {
+		return httpresponse.Error(w, httpresponse.ErrBadRequest, err)
+	}
+	if req.Format == "" {
+		req.Format = schema.ExportPDF
+	}
+	contentType, exists := exportTypes[req.Format]
+	if !exists {
+		return httpresponse.Error(w, httpresponse.ErrBadRequest.Withf("unsupported export format %q", req.Format))
+	}
+
+	// Render the export before writing, so that errors are returned as JSON
+	var buf bytes.Buffer
+	if err := manager.ExportSession(ctx, id, req, &buf, middleware.UserFromContext(ctx)); err != nil {
+		return httpresponse.Error(w, schema.HTTPErr(err))
+	}
+
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"session-%s.%s\"", id, req.Format))
+	return httpresponse.Write(w, http.StatusOK, contentType, func(writer io.Writer) (int, error) {
+		return writer.Write(buf.Bytes())
+	})
+}
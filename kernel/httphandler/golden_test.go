@@ -0,0 +1,54 @@
+package httphandler
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	// Packages
+	llmmanager "github.com/mutablelogic/go-llm/kernel/manager"
+	llmtest "github.com/mutablelogic/go-llm/pkg/test"
+	httprouter "github.com/mutablelogic/go-server/pkg/httprouter"
+)
+
+// TestGolden runs the fixtures in testdata/golden against the handlers,
+// for requests which are answered without a database. Run with -update to
+// rewrite the responses in the fixtures.
+func TestGolden(t *testing.T) {
+	llmtest.Golden(t, newGoldenRouter(t, &llmmanager.Manager{}), "testdata/golden/*.yaml")
+}
+
+// TestGoldenIntegration runs the fixtures in testdata/golden/integration
+// against the handlers, with a manager which stores its state in the test
+// database
+func TestGoldenIntegration(t *testing.T) {
+	conn := modelHandlerConn.Begin(t)
+	t.Cleanup(conn.Close)
+	ctx := newModelHandlerTestContext(t)
+
+	m, err := llmmanager.New(ctx, "test", "0.0.0", conn, llmmanager.WithPassphrase(1, "test1234"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	llmtest.RunBackground(t, func(ctx context.Context) error {
+		return m.Run(ctx, llmtest.DiscardLogger())
+	})
+	llmtest.WaitUntil(t, 5*time.Second, func() bool {
+		return m.Toolkit != nil
+	}, "timed out waiting for llmmanager Run to initialize toolkit")
+
+	llmtest.Golden(t, newGoldenRouter(t, m), "testdata/golden/integration/*.yaml")
+}
+
+func newGoldenRouter(t *testing.T, manager *llmmanager.Manager) http.Handler {
+	t.Helper()
+	router, err := httprouter.NewRouter(context.Background(), http.NewServeMux(), "/api", "", "test", "0.0.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := RegisterHandlers(router, manager, nil, false); err != nil {
+		t.Fatal(err)
+	}
+	return router
+}
@@ -0,0 +1,53 @@
+package httphandler
+
+import (
+	"context"
+	"net/http"
+
+	// Packages
+	middleware "github.com/mutablelogic/go-auth/auth/middleware"
+	llmmanager "github.com/mutablelogic/go-llm/kernel/manager"
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	httprequest "github.com/mutablelogic/go-server/pkg/httprequest"
+	httpresponse "github.com/mutablelogic/go-server/pkg/httpresponse"
+	jsonschema "github.com/mutablelogic/go-server/pkg/jsonschema"
+	opts "github.com/mutablelogic/go-server/pkg/openapi"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+func SessionImportHandler(manager *llmmanager.Manager) (string, *jsonschema.Schema, httprequest.PathItem) {
+	return "session/import", nil, httprequest.NewPathItem(
+		"Session import",
+		"Import conversations exported from other tools",
+		"Sessions",
+	).Post(
+		func(w http.ResponseWriter, r *http.Request) {
+			_ = importSessions(r.Context(), manager, w, r)
+		},
+		"Import sessions",
+		opts.WithJSONRequest(jsonschema.MustFor[schema.SessionImportRequest]()),
+		opts.WithJSONResponse(201, jsonschema.MustFor[schema.SessionImportResponse]()),
+		opts.WithErrorResponse(400, "Invalid request body, unsupported format or no conversations to import."),
+		opts.WithErrorResponse(404, "Model or provider not found."),
+		opts.WithErrorResponse(409, "Multiple models matched; specify a provider."),
+	)
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+func importSessions(ctx context.Context, manager *llmmanager.Manager, w http.ResponseWriter, r *http.Request) error {
+	var req schema.SessionImportRequest
+	if err := httprequest.Read(r, &req); err != nil {
+		return httpresponse.Error(w, httpresponse.ErrBadRequest, err)
+	}
+
+	response, err := manager.ImportSessions(ctx, req, middleware.UserFromContext(ctx))
+	if err != nil {
+		return httpresponse.Error(w, schema.HTTPErr(err))
+	}
+
+	return httpresponse.JSON(w, http.StatusCreated, httprequest.Indent(r), response)
+}
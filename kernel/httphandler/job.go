@@ -0,0 +1,74 @@
+package httphandler
+
+import (
+	"context"
+	"net/http"
+
+	// Packages
+	uuid "github.com/google/uuid"
+	middleware "github.com/mutablelogic/go-auth/auth/middleware"
+	llmmanager "github.com/mutablelogic/go-llm/kernel/manager"
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	httprequest "github.com/mutablelogic/go-server/pkg/httprequest"
+	httpresponse "github.com/mutablelogic/go-server/pkg/httpresponse"
+	jsonschema "github.com/mutablelogic/go-server/pkg/jsonschema"
+	opts "github.com/mutablelogic/go-server/pkg/openapi"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+func JobResourceHandler(manager *llmmanager.Manager) (string, *jsonschema.Schema, httprequest.PathItem) {
+	return "job/{job}", jsonschema.MustFor[schema.JobIDSelector](), httprequest.NewPathItem(
+		"Background job",
+		"Status and result of a chat turn started with async, and cancellation",
+		"Responses",
+	).Get(
+		func(w http.ResponseWriter, r *http.Request) {
+			_ = getJob(r.Context(), manager, w, r)
+		},
+		"Get job",
+		opts.WithJSONResponse(200, jsonschema.MustFor[schema.Job]()),
+		opts.WithErrorResponse(400, "Invalid job ID."),
+		opts.WithErrorResponse(404, "Job not found."),
+	).Delete(
+		func(w http.ResponseWriter, r *http.Request) {
+			_ = cancelJob(r.Context(), manager, w, r)
+		},
+		"Cancel job",
+		opts.WithJSONResponse(200, jsonschema.MustFor[schema.Job]()),
+		opts.WithErrorResponse(400, "Invalid job ID."),
+		opts.WithErrorResponse(404, "Job not found."),
+	)
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+func getJob(ctx context.Context, manager *llmmanager.Manager, w http.ResponseWriter, r *http.Request) error {
+	id, err := uuid.Parse(r.PathValue("job"))
+	if err != nil {
+		return httpresponse.Error(w, httpresponse.ErrBadRequest, err)
+	}
+
+	job, err := manager.GetJob(ctx, id, middleware.UserFromContext(ctx))
+	if err != nil {
+		return httpresponse.Error(w, schema.HTTPErr(err))
+	}
+
+	return httpresponse.JSON(w, http.StatusOK, httprequest.Indent(r), job)
+}
+
+func cancelJob(ctx context.Context, manager *llmmanager.Manager, w http.ResponseWriter, r *http.Request) error {
+	id, err := uuid.Parse(r.PathValue("job"))
+	if err != nil {
+		return httpresponse.Error(w, httpresponse.ErrBadRequest, err)
+	}
+
+	job, err := manager.CancelJob(ctx, id, middleware.UserFromContext(ctx))
+	if err != nil {
+		return httpresponse.Error(w, schema.HTTPErr(err))
+	}
+
+	return httpresponse.JSON(w, http.StatusOK, httprequest.Indent(r), job)
+}
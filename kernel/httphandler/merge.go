@@ -0,0 +1,59 @@
+package httphandler
+
+import (
+	"context"
+	"net/http"
+
+	// Packages
+	uuid "github.com/google/uuid"
+	middleware "github.com/mutablelogic/go-auth/auth/middleware"
+	llmmanager "github.com/mutablelogic/go-llm/kernel/manager"
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	httprequest "github.com/mutablelogic/go-server/pkg/httprequest"
+	httpresponse "github.com/mutablelogic/go-server/pkg/httpresponse"
+	jsonschema "github.com/mutablelogic/go-server/pkg/jsonschema"
+	opts "github.com/mutablelogic/go-server/pkg/openapi"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+func SessionMergeHandler(manager *llmmanager.Manager) (string, *jsonschema.Schema, httprequest.PathItem) {
+	return "session/{session}/merge", jsonschema.MustFor[schema.SessionIDSelector](), httprequest.NewPathItem(
+		"Session merge",
+		"Merge the messages of another session into a session",
+		"Sessions",
+	).Post(
+		func(w http.ResponseWriter, r *http.Request) {
+			_ = mergeSession(r.Context(), manager, w, r)
+		},
+		"Merge session",
+		opts.WithDescription("Copies the messages of the source session into the session, appended after its messages or interleaved with them by time. Turns are kept together, so that tool calls are followed by their results. System prompts which are already in the session are not copied. The source session is not changed."),
+		opts.WithJSONRequest(jsonschema.MustFor[schema.SessionMergeRequest]()),
+		opts.WithJSONResponse(200, jsonschema.MustFor[schema.SessionMerge]()),
+		opts.WithErrorResponse(400, "Invalid session ID or strategy, or the merged conversation has a tool call without a result."),
+		opts.WithErrorResponse(404, "Session not found."),
+	)
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+func mergeSession(ctx context.Context, manager *llmmanager.Manager, w http.ResponseWriter, r *http.Request) error {
+	id, err := uuid.Parse(r.PathValue("session"))
+	if err != nil {
+		return httpresponse.Error(w, httpresponse.ErrBadRequest, err)
+	}
+
+	var req schema.SessionMergeRequest
+	if err := httprequest.Read(r, &req); err != nil {
+		return httpresponse.Error(w, httpresponse.ErrBadRequest, err)
+	}
+
+	result, err := manager.MergeSessions(ctx, id, req.Source, req.Strategy, middleware.UserFromContext(ctx))
+	if err != nil {
+		return httpresponse.Error(w, schema.HTTPErr(err))
+	}
+
+	return httpresponse.JSON(w, http.StatusOK, httprequest.Indent(r), result)
+}
@@ -3,6 +3,7 @@ package httphandler
 import (
 	"context"
 	"net/http"
+	"strconv"
 
 	// Packages
 	uuid "github.com/google/uuid"
@@ -28,10 +29,41 @@ func SessionMessageHandler(manager *llmmanager.Manager) (string, *jsonschema.Sch
 			_ = listMessages(r.Context(), manager, w, r)
 		},
 		"List session messages",
+		opts.WithDescription("Returns a page of the messages in a session, oldest first. With order=desc the newest messages are returned first, so a client can show the latest page of a long session and page back through the history."),
 		opts.WithQuery(jsonschema.MustFor[schema.MessageListRequest]()),
 		opts.WithJSONResponse(200, jsonschema.MustFor[schema.MessageList]()),
 		opts.WithErrorResponse(400, "Invalid request parameters or session ID."),
 		opts.WithErrorResponse(404, "Session not found."),
+	).Post(
+		func(w http.ResponseWriter, r *http.Request) {
+			_ = createMessage(r.Context(), manager, w, r)
+		},
+		"Append a message to a session",
+		opts.WithDescription("Appends user input to a session. With send=false the message is stored as pending input, which is sent with the next chat turn; otherwise a reply is generated for all pending input."),
+		opts.WithQuery(jsonschema.MustFor[schema.MessageCreateQuery]()),
+		opts.WithJSONRequest(jsonschema.MustFor[schema.MessageCreateRequest]()),
+		opts.WithJSONResponse(201, jsonschema.MustFor[schema.Message]()),
+		opts.WithJSONResponse(200, jsonschema.MustFor[schema.ChatResponse]()),
+		opts.WithErrorResponse(400, "Invalid request body or session ID."),
+		opts.WithErrorResponse(404, "Session not found."),
+	)
+}
+
+func SessionMessageResourceHandler(manager *llmmanager.Manager) (string, *jsonschema.Schema, httprequest.PathItem) {
+	return "session/{session}/message/{message}", jsonschema.MustFor[schema.MessageSelector](), httprequest.NewPathItem(
+		"Session message operations",
+		"Update operations on a message in a session",
+		"Sessions",
+	).Patch(
+		func(w http.ResponseWriter, r *http.Request) {
+			_ = updateMessage(r.Context(), manager, w, r)
+		},
+		"Update message",
+		opts.WithDescription("Updates the metadata of a message. A pinned message is never trimmed from the conversation to fit in the context window of the model."),
+		opts.WithJSONRequest(jsonschema.MustFor[schema.MessageMeta]()),
+		opts.WithJSONResponse(200, jsonschema.MustFor[schema.Message]()),
+		opts.WithErrorResponse(400, "Invalid request body, session ID or message ID."),
+		opts.WithErrorResponse(404, "Session or message not found."),
 	)
 }
 
@@ -57,3 +89,64 @@ func listMessages(ctx context.Context, manager *llmmanager.Manager, w http.Respo
 
 	return httpresponse.JSON(w, http.StatusOK, httprequest.Indent(r), messages)
 }
+
+func createMessage(ctx context.Context, manager *llmmanager.Manager, w http.ResponseWriter, r *http.Request) error {
+	session, err := uuid.Parse(r.PathValue("session"))
+	if err != nil {
+		return httpresponse.Error(w, httpresponse.ErrBadRequest, err)
+	}
+
+	var req schema.MessageCreateRequest
+	var query schema.MessageCreateQuery
+	if err := httprequest.Query(r.URL.Query(), &query); err != nil {
+		return httpresponse.Error(w, httpresponse.ErrBadRequest, err)
+	}
+	if err := httprequest.Read(r, &req); err != nil {
+		return httpresponse.Error(w, httpresponse.ErrBadRequest, err)
+	}
+
+	// Store the message as pending input. Attachments are always stored
+	// first, since a chat request carries only text.
+	text := req.Text
+	if !query.Sending() || len(req.Attachments) > 0 {
+		message, err := manager.AppendMessage(ctx, session, req, middleware.UserFromContext(ctx))
+		if err != nil {
+			return httpresponse.Error(w, schema.HTTPErr(err))
+		}
+		if !query.Sending() {
+			return httpresponse.JSON(w, http.StatusCreated, httprequest.Indent(r), message)
+		}
+		text = ""
+	}
+
+	// Generate a reply for all pending input
+	resp, err := manager.Chat(ctx, schema.ChatRequest{Session: session, Text: text}, nil, middleware.UserFromContext(ctx))
+	if err != nil {
+		return writeError(w, err)
+	}
+	resp.Trace = nil
+	return httpresponse.JSON(w, http.StatusOK, httprequest.Indent(r), resp)
+}
+
+func updateMessage(ctx context.Context, manager *llmmanager.Manager, w http.ResponseWriter, r *http.Request) error {
+	session, err := uuid.Parse(r.PathValue("session"))
+	if err != nil {
+		return httpresponse.Error(w, httpresponse.ErrBadRequest, err)
+	}
+	id, err := strconv.ParseUint(r.PathValue("message"), 10, 64)
+	if err != nil {
+		return httpresponse.Error(w, httpresponse.ErrBadRequest, err)
+	}
+
+	var meta schema.MessageMeta
+	if err := httprequest.Read(r, &meta); err != nil {
+		return httpresponse.Error(w, httpresponse.ErrBadRequest, err)
+	}
+
+	message, err := manager.UpdateMessage(ctx, session, id, meta, middleware.UserFromContext(ctx))
+	if err != nil {
+		return httpresponse.Error(w, schema.HTTPErr(err))
+	}
+
+	return httpresponse.JSON(w, http.StatusOK, httprequest.Indent(r), message)
+}
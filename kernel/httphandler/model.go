@@ -45,6 +45,60 @@ func ModelHandler(manager *llmmanager.Manager) (string, *jsonschema.Schema, http
 	)
 }
 
+func ModelRefreshHandler(manager *llmmanager.Manager) (string, *jsonschema.Schema, httprequest.PathItem) {
+	return "model/refresh", nil, httprequest.NewPathItem(
+		"Model operations",
+		"Refresh the cached list of models",
+		"Models",
+	).Post(
+		func(w http.ResponseWriter, r *http.Request) {
+			_ = refreshModels(r.Context(), manager, w, r)
+		},
+		"Refresh models",
+		opts.WithQuery(jsonschema.MustFor[schema.ModelListRequest]()),
+		opts.WithJSONResponse(200, jsonschema.MustFor[schema.ModelList]()),
+		opts.WithErrorResponse(400, "Invalid request parameters or model listing failure."),
+	)
+}
+
+func ModelLoadHandler(manager *llmmanager.Manager) (string, *jsonschema.Schema, httprequest.PathItem) {
+	return "model/load", nil, httprequest.NewPathItem(
+		"Model operations",
+		"Load a model into memory",
+		"Models",
+	).Post(
+		func(w http.ResponseWriter, r *http.Request) {
+			_ = loadModel(r.Context(), manager, w, r)
+		},
+		"Load model",
+		opts.WithDescription("Loads a model into memory for a provider which runs models locally, such as Ollama, so that the next request does not wait for the model to load."),
+		opts.WithJSONRequest(jsonschema.MustFor[schema.LoadModelRequest]()),
+		opts.WithJSONResponse(200, jsonschema.MustFor[schema.Model]()),
+		opts.WithErrorResponse(400, "Invalid request body or model load failure."),
+		opts.WithErrorResponse(404, "Model not found, or no provider supports loading models."),
+		opts.WithErrorResponse(409, "Multiple providers own the model; specify a provider."),
+	)
+}
+
+func ModelUnloadHandler(manager *llmmanager.Manager) (string, *jsonschema.Schema, httprequest.PathItem) {
+	return "model/unload", nil, httprequest.NewPathItem(
+		"Model operations",
+		"Unload a model from memory",
+		"Models",
+	).Post(
+		func(w http.ResponseWriter, r *http.Request) {
+			_ = unloadModel(r.Context(), manager, w, r)
+		},
+		"Unload model",
+		opts.WithDescription("Unloads a model from memory for a provider which runs models locally, such as Ollama."),
+		opts.WithJSONRequest(jsonschema.MustFor[schema.UnloadModelRequest]()),
+		opts.WithJSONResponse(200, jsonschema.MustFor[schema.Model]()),
+		opts.WithErrorResponse(400, "Invalid request body or model unload failure."),
+		opts.WithErrorResponse(404, "Model not found, or no provider supports loading models."),
+		opts.WithErrorResponse(409, "Multiple providers own the model; specify a provider."),
+	)
+}
+
 func ModelResourceHandler(manager *llmmanager.Manager) (string, *jsonschema.Schema, httprequest.PathItem) {
 	return "model/{name}", jsonschema.MustFor[schema.ModelNameSelector](), httprequest.NewPathItem(
 		"Model operations",
@@ -105,6 +159,19 @@ func listModels(ctx context.Context, manager *llmmanager.Manager, w http.Respons
 	}
 }
 
+func refreshModels(ctx context.Context, manager *llmmanager.Manager, w http.ResponseWriter, r *http.Request) error {
+	var req schema.ModelListRequest
+	if err := httprequest.Query(r.URL.Query(), &req); err != nil {
+		return httpresponse.Error(w, httpresponse.ErrBadRequest, err)
+	}
+
+	if models, err := manager.RefreshModels(ctx, req, middleware.UserFromContext(ctx)); err != nil {
+		return httpresponse.Error(w, schema.HTTPErr(err))
+	} else {
+		return httpresponse.JSON(w, http.StatusOK, httprequest.Indent(r), models)
+	}
+}
+
 func getModel(ctx context.Context, manager *llmmanager.Manager, w http.ResponseWriter, r *http.Request, provider string) error {
 	req := schema.GetModelRequest{
 		Provider: provider,
@@ -131,6 +198,32 @@ func deleteModel(ctx context.Context, manager *llmmanager.Manager, w http.Respon
 	return httpresponse.JSON(w, http.StatusOK, httprequest.Indent(r), model)
 }
 
+func loadModel(ctx context.Context, manager *llmmanager.Manager, w http.ResponseWriter, r *http.Request) error {
+	var req schema.LoadModelRequest
+	if err := httprequest.Read(r, &req); err != nil {
+		return httpresponse.Error(w, httpresponse.ErrBadRequest, err)
+	}
+
+	model, err := manager.LoadModel(ctx, req, middleware.UserFromContext(ctx))
+	if err != nil {
+		return httpresponse.Error(w, schema.HTTPErr(err))
+	}
+	return httpresponse.JSON(w, http.StatusOK, httprequest.Indent(r), model)
+}
+
+func unloadModel(ctx context.Context, manager *llmmanager.Manager, w http.ResponseWriter, r *http.Request) error {
+	var req schema.UnloadModelRequest
+	if err := httprequest.Read(r, &req); err != nil {
+		return httpresponse.Error(w, httpresponse.ErrBadRequest, err)
+	}
+
+	model, err := manager.UnloadModel(ctx, req, middleware.UserFromContext(ctx))
+	if err != nil {
+		return httpresponse.Error(w, schema.HTTPErr(err))
+	}
+	return httpresponse.JSON(w, http.StatusOK, httprequest.Indent(r), model)
+}
+
 func downloadModel(ctx context.Context, manager *llmmanager.Manager, w http.ResponseWriter, r *http.Request) error {
 	var req schema.DownloadModelRequest
 	if err := httprequest.Read(r, &req); err != nil {
@@ -146,11 +239,9 @@ func downloadModel(ctx context.Context, manager *llmmanager.Manager, w http.Resp
 	// Respond
 	switch accept {
 	case types.ContentTypeTextStream:
-		stream := httpresponse.NewTextStream(w)
-		if stream == nil {
-			return httpresponse.Error(w, httpresponse.ErrInternalError)
-		}
+		stream := newEventStream(ctx, w)
 		defer stream.Close()
+		ctx := stream.Context()
 
 		progressFn := opt.ProgressFn(func(status string, percent float64) {
 			stream.Write(schema.EventProgress, schema.ProgressEvent{Status: status, Percent: percent})
@@ -0,0 +1,193 @@
+package httphandler
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	// Packages
+	uuid "github.com/google/uuid"
+	middleware "github.com/mutablelogic/go-auth/auth/middleware"
+	llmmanager "github.com/mutablelogic/go-llm/kernel/manager"
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	opt "github.com/mutablelogic/go-llm/pkg/opt"
+	httprequest "github.com/mutablelogic/go-server/pkg/httprequest"
+	httpresponse "github.com/mutablelogic/go-server/pkg/httpresponse"
+	jsonschema "github.com/mutablelogic/go-server/pkg/jsonschema"
+	opts "github.com/mutablelogic/go-server/pkg/openapi"
+	types "github.com/mutablelogic/go-server/pkg/types"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// OpenAIChatHandler serves chat completions in the format of the OpenAI API,
+// so that OpenAI clients can use the models of any provider
+func OpenAIChatHandler(manager *llmmanager.Manager) (string, *jsonschema.Schema, httprequest.PathItem) {
+	return "chat/completions", nil, httprequest.NewPathItem(
+		"OpenAI-compatible chat completions",
+		"Reply to a conversation kept by the client, in the format of the OpenAI API",
+		"OpenAI",
+	).Post(
+		func(w http.ResponseWriter, r *http.Request) {
+			_ = openaiChat(r.Context(), manager, w, r)
+		},
+		"Create chat completion",
+		opts.WithDescription("Tools in the request are run by the client: calls to them are returned with the tool_calls finish reason, and the results are sent as tool messages in the next request. With stream=true, the reply is sent as chunks in server-sent events, ending with [DONE]."),
+		opts.WithJSONRequest(jsonschema.MustFor[schema.OpenAIChatRequest]()),
+		opts.WithJSONResponse(200, jsonschema.MustFor[schema.OpenAIChatResponse]()),
+		opts.WithTextStreamResponse(200, "SSE stream of chat.completion.chunk objects, ending with [DONE]."),
+		opts.WithErrorResponse(400, "Invalid request body."),
+		opts.WithErrorResponse(404, "Model not found."),
+		opts.WithErrorResponse(409, "Multiple models matched."),
+		opts.WithErrorResponse(429, "The queue for the priority is full; retry after the time in the Retry-After header."),
+	)
+}
+
+// OpenAIModelHandler lists models in the format of the OpenAI API
+func OpenAIModelHandler(manager *llmmanager.Manager) (string, *jsonschema.Schema, httprequest.PathItem) {
+	return "models", nil, httprequest.NewPathItem(
+		"OpenAI-compatible models",
+		"List models in the format of the OpenAI API",
+		"OpenAI",
+	).Get(
+		func(w http.ResponseWriter, r *http.Request) {
+			_ = openaiModels(r.Context(), manager, w, r)
+		},
+		"List models",
+		opts.WithJSONResponse(200, jsonschema.MustFor[schema.OpenAIModelList]()),
+	)
+}
+
+// OpenAIEmbeddingHandler creates embeddings in the format of the OpenAI API
+func OpenAIEmbeddingHandler(manager *llmmanager.Manager) (string, *jsonschema.Schema, httprequest.PathItem) {
+	return "embeddings", nil, httprequest.NewPathItem(
+		"OpenAI-compatible embeddings",
+		"Generate embedding vectors in the format of the OpenAI API",
+		"OpenAI",
+	).Post(
+		func(w http.ResponseWriter, r *http.Request) {
+			_ = openaiEmbeddings(r.Context(), manager, w, r)
+		},
+		"Create embeddings",
+		opts.WithJSONRequest(jsonschema.MustFor[schema.OpenAIEmbeddingRequest]()),
+		opts.WithJSONResponse(200, jsonschema.MustFor[schema.OpenAIEmbeddingList]()),
+		opts.WithErrorResponse(400, "Invalid request body."),
+		opts.WithErrorResponse(404, "Model not found."),
+		opts.WithErrorResponse(409, "Multiple models matched."),
+	)
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+func openaiChat(ctx context.Context, manager *llmmanager.Manager, w http.ResponseWriter, r *http.Request) error {
+	var req schema.OpenAIChatRequest
+	if err := httprequest.Read(r, &req); err != nil {
+		return writeOpenAIError(w, schema.ErrBadParameter.With(err.Error()))
+	}
+	completion, err := req.CompletionRequest()
+	if err != nil {
+		return writeOpenAIError(w, err)
+	}
+
+	// Wait for a worker before the response is started, so that a rejected
+	// request is returned as an error rather than in the stream
+	ctx, release, err := manager.Admit(ctx)
+	if err != nil {
+		return writeOpenAIError(w, err)
+	}
+	defer release()
+
+	id, created := "chatcmpl-"+uuid.NewString(), time.Now()
+	if !req.Stream {
+		resp, err := manager.Complete(ctx, completion, middleware.UserFromContext(ctx), nil)
+		if err != nil {
+			return writeOpenAIError(w, err)
+		}
+		return httpresponse.JSON(w, http.StatusOK, httprequest.Indent(r), schema.NewOpenAIChatResponse(id, created, req.Model, resp))
+	}
+
+	// Stream the reply as chunks, starting with the role
+	stream := newEventStream(ctx, w)
+	defer stream.Close()
+	ctx = stream.Context()
+	stream.Write("", schema.NewOpenAIChunk(id, created, req.Model, schema.OpenAIMessage{Role: schema.RoleAssistant, Content: schema.NewOpenAIText("")}, nil))
+	fn := opt.StreamFn(func(role, text string) {
+		switch role {
+		case schema.RoleThinking:
+			stream.Write("", schema.NewOpenAIChunk(id, created, req.Model, schema.OpenAIMessage{ReasoningContent: text}, nil))
+		case schema.RoleAssistant:
+			stream.Write("", schema.NewOpenAIChunk(id, created, req.Model, schema.OpenAIMessage{Content: schema.NewOpenAIText(text)}, nil))
+		}
+	})
+	resp, err := manager.Complete(ctx, completion, middleware.UserFromContext(ctx), fn)
+	if err != nil {
+		stream.Write("", schema.NewOpenAIError(openAIStatus(err), err))
+		stream.WriteText("", schema.OpenAIStreamDone)
+		return nil
+	}
+
+	// End with the tool calls, the reason the reply finished, and the usage
+	if chunk := schema.NewOpenAIToolCallsChunk(id, created, req.Model, resp); chunk != nil {
+		stream.Write("", chunk)
+	}
+	stream.Write("", schema.NewOpenAIChunk(id, created, req.Model, schema.OpenAIMessage{}, types.Ptr(schema.OpenAIFinishReason(resp.Result))))
+	if req.StreamOptions != nil && req.StreamOptions.IncludeUsage {
+		stream.Write("", schema.NewOpenAIUsageChunk(id, created, req.Model, resp.Usage))
+	}
+	stream.WriteText("", schema.OpenAIStreamDone)
+	return nil
+}
+
+func openaiModels(ctx context.Context, manager *llmmanager.Manager, w http.ResponseWriter, r *http.Request) error {
+	models, err := manager.ListModels(ctx, schema.ModelListRequest{}, middleware.UserFromContext(ctx))
+	if err != nil {
+		return writeOpenAIError(w, err)
+	}
+	return httpresponse.JSON(w, http.StatusOK, httprequest.Indent(r), schema.NewOpenAIModelList(models.Body))
+}
+
+func openaiEmbeddings(ctx context.Context, manager *llmmanager.Manager, w http.ResponseWriter, r *http.Request) error {
+	var req schema.OpenAIEmbeddingRequest
+	if err := httprequest.Read(r, &req); err != nil {
+		return writeOpenAIError(w, schema.ErrBadParameter.With(err.Error()))
+	}
+	switch req.EncodingFormat {
+	case "", "float", "base64":
+	default:
+		return writeOpenAIError(w, schema.ErrBadParameter.Withf("unsupported encoding format %q", req.EncodingFormat))
+	}
+
+	resp, err := manager.Embedding(ctx, schema.EmbeddingRequest{
+		Model:                req.Model,
+		Input:                req.Input,
+		OutputDimensionality: req.Dimensions,
+	}, middleware.UserFromContext(ctx))
+	if err != nil {
+		return writeOpenAIError(w, err)
+	}
+	return httpresponse.JSON(w, http.StatusOK, httprequest.Indent(r), schema.NewOpenAIEmbeddingList(req.Model, resp, req.EncodingFormat))
+}
+
+// writeOpenAIError writes an error in the format of the OpenAI API, which
+// clients of the API read the message from
+func writeOpenAIError(w http.ResponseWriter, err error) error {
+	if after, ok := schema.RetryAfter(err); ok {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(after.Seconds()))))
+	}
+	status := openAIStatus(err)
+	return httpresponse.JSON(w, status, 2, schema.NewOpenAIError(status, err))
+}
+
+// openAIStatus returns the HTTP status code for an error
+func openAIStatus(err error) int {
+	var code httpresponse.Err
+	if errors.As(schema.HTTPErr(err), &code) {
+		return int(code)
+	}
+	return http.StatusInternalServerError
+}
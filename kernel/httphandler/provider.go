@@ -5,6 +5,7 @@ import (
 	"net/http"
 
 	// Packages
+	middleware "github.com/mutablelogic/go-auth/auth/middleware"
 	llmmanager "github.com/mutablelogic/go-llm/kernel/manager"
 	schema "github.com/mutablelogic/go-llm/kernel/schema"
 	httprequest "github.com/mutablelogic/go-server/pkg/httprequest"
@@ -70,6 +71,37 @@ func ProviderResourceHandler(manager *llmmanager.Manager) (string, *jsonschema.S
 	)
 }
 
+func ProviderCredentialsHandler(manager *llmmanager.Manager) (string, *jsonschema.Schema, httprequest.PathItem) {
+	return "provider/{name}/credentials", nil, httprequest.NewPathItem(
+		"Provider credentials",
+		"Credentials of the user for a provider, used in place of the credentials of the provider",
+		"Providers",
+	).Get(
+		func(w http.ResponseWriter, r *http.Request) {
+			_ = getProviderCredentials(r.Context(), manager, w, r)
+		},
+		"Get provider credentials",
+		opts.WithJSONResponse(200, jsonschema.MustFor[schema.ProviderCredential]()),
+		opts.WithErrorResponse(404, "No credentials for the provider."),
+	).Put(
+		func(w http.ResponseWriter, r *http.Request) {
+			_ = setProviderCredentials(r.Context(), manager, w, r)
+		},
+		"Set provider credentials",
+		opts.WithJSONRequest(jsonschema.MustFor[schema.ProviderCredentials]()),
+		opts.WithJSONResponse(200, jsonschema.MustFor[schema.ProviderCredential]()),
+		opts.WithErrorResponse(400, "Invalid credentials, or the provider did not accept them."),
+		opts.WithErrorResponse(404, "Provider not found."),
+	).Delete(
+		func(w http.ResponseWriter, r *http.Request) {
+			_ = deleteProviderCredentials(r.Context(), manager, w, r)
+		},
+		"Delete provider credentials",
+		opts.WithJSONResponse(200, jsonschema.MustFor[schema.ProviderCredential]()),
+		opts.WithErrorResponse(404, "No credentials for the provider."),
+	)
+}
+
 ///////////////////////////////////////////////////////////////////////////////
 // PRIVATE METHODS
 
@@ -129,3 +161,32 @@ func deleteProvider(ctx context.Context, manager *llmmanager.Manager, w http.Res
 		return httpresponse.JSON(w, http.StatusOK, httprequest.Indent(r), provider)
 	}
 }
+
+func getProviderCredentials(ctx context.Context, manager *llmmanager.Manager, w http.ResponseWriter, r *http.Request) error {
+	if credential, err := manager.GetProviderCredentials(ctx, r.PathValue("name"), middleware.UserFromContext(ctx)); err != nil {
+		return httpresponse.Error(w, schema.HTTPErr(err))
+	} else {
+		return httpresponse.JSON(w, http.StatusOK, httprequest.Indent(r), credential)
+	}
+}
+
+func setProviderCredentials(ctx context.Context, manager *llmmanager.Manager, w http.ResponseWriter, r *http.Request) error {
+	var req schema.ProviderCredentials
+	if err := httprequest.Read(r, &req); err != nil {
+		return httpresponse.Error(w, httpresponse.ErrBadRequest, err)
+	}
+
+	if credential, err := manager.SetProviderCredentials(ctx, r.PathValue("name"), req, middleware.UserFromContext(ctx)); err != nil {
+		return httpresponse.Error(w, schema.HTTPErr(err))
+	} else {
+		return httpresponse.JSON(w, http.StatusOK, httprequest.Indent(r), credential)
+	}
+}
+
+func deleteProviderCredentials(ctx context.Context, manager *llmmanager.Manager, w http.ResponseWriter, r *http.Request) error {
+	if credential, err := manager.DeleteProviderCredentials(ctx, r.PathValue("name"), middleware.UserFromContext(ctx)); err != nil {
+		return httpresponse.Error(w, schema.HTTPErr(err))
+	} else {
+		return httpresponse.JSON(w, http.StatusOK, httprequest.Indent(r), credential)
+	}
+}
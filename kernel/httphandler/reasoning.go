@@ -0,0 +1,53 @@
+package httphandler
+
+import (
+	"context"
+	"net/http"
+
+	// Packages
+	uuid "github.com/google/uuid"
+	middleware "github.com/mutablelogic/go-auth/auth/middleware"
+	llmmanager "github.com/mutablelogic/go-llm/kernel/manager"
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	httprequest "github.com/mutablelogic/go-server/pkg/httprequest"
+	httpresponse "github.com/mutablelogic/go-server/pkg/httpresponse"
+	jsonschema "github.com/mutablelogic/go-server/pkg/jsonschema"
+	opts "github.com/mutablelogic/go-server/pkg/openapi"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+func SessionReasoningHandler(manager *llmmanager.Manager) (string, *jsonschema.Schema, httprequest.PathItem) {
+	return "admin/session/{session}/reasoning", jsonschema.MustFor[schema.SessionIDSelector](), httprequest.NewPathItem(
+		"Admin operations",
+		"Retrieve the recorded thinking of models for a session",
+		"Sessions",
+	).Get(
+		func(w http.ResponseWriter, r *http.Request) {
+			_ = listSessionReasoning(r.Context(), manager, w, r)
+		},
+		"List recorded thinking",
+		opts.WithDescription("Returns the thinking of models for each message of the session, oldest first, which is recorded apart from the transcript when the server records reasoning. The thinking is not returned to users with the messages, or included in exports for them."),
+		opts.WithJSONResponse(200, jsonschema.MustFor[schema.ReasoningList]()),
+		opts.WithErrorResponse(400, "Invalid session ID."),
+		opts.WithErrorResponse(403, "The user does not have the admin scope."),
+		opts.WithErrorResponse(404, "Session not found."),
+	)
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+func listSessionReasoning(ctx context.Context, manager *llmmanager.Manager, w http.ResponseWriter, r *http.Request) error {
+	id, err := uuid.Parse(r.PathValue("session"))
+	if err != nil {
+		return httpresponse.Error(w, httpresponse.ErrBadRequest, err)
+	}
+
+	result, err := manager.ListReasoning(ctx, id, middleware.UserFromContext(ctx))
+	if err != nil {
+		return httpresponse.Error(w, schema.HTTPErr(err))
+	}
+	return httpresponse.JSON(w, http.StatusOK, httprequest.Indent(r), result)
+}
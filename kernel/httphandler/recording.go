@@ -0,0 +1,57 @@
+package httphandler
+
+import (
+	"context"
+	"net/http"
+
+	// Packages
+	uuid "github.com/google/uuid"
+	middleware "github.com/mutablelogic/go-auth/auth/middleware"
+	llmmanager "github.com/mutablelogic/go-llm/kernel/manager"
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	httprequest "github.com/mutablelogic/go-server/pkg/httprequest"
+	httpresponse "github.com/mutablelogic/go-server/pkg/httpresponse"
+	jsonschema "github.com/mutablelogic/go-server/pkg/jsonschema"
+	opts "github.com/mutablelogic/go-server/pkg/openapi"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+func RecordingResourceHandler(manager *llmmanager.Manager) (string, *jsonschema.Schema, httprequest.PathItem) {
+	return "recording/{recording}", jsonschema.MustFor[schema.RecordingIDSelector](), httprequest.NewPathItem(
+		"Recording",
+		"Intermediate states of a chat turn made with record, which can be stepped through",
+		"Responses",
+	).Get(
+		func(w http.ResponseWriter, r *http.Request) {
+			_ = getRecording(r.Context(), manager, w, r)
+		},
+		"Get recording",
+		opts.WithQuery(jsonschema.MustFor[schema.RecordingQuery]()),
+		opts.WithJSONResponse(200, jsonschema.MustFor[schema.Recording]()),
+		opts.WithErrorResponse(400, "Invalid recording ID or step."),
+		opts.WithErrorResponse(404, "Recording or step not found."),
+	)
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+func getRecording(ctx context.Context, manager *llmmanager.Manager, w http.ResponseWriter, r *http.Request) error {
+	id, err := uuid.Parse(r.PathValue("recording"))
+	if err != nil {
+		return httpresponse.Error(w, httpresponse.ErrBadRequest, err)
+	}
+	var query schema.RecordingQuery
+	if err := httprequest.Query(r.URL.Query(), &query); err != nil {
+		return httpresponse.Error(w, httpresponse.ErrBadRequest, err)
+	}
+
+	recording, err := manager.GetRecording(ctx, id, query.Step, middleware.UserFromContext(ctx))
+	if err != nil {
+		return httpresponse.Error(w, schema.HTTPErr(err))
+	}
+
+	return httpresponse.JSON(w, http.StatusOK, httprequest.Indent(r), recording)
+}
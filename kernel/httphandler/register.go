@@ -6,40 +6,115 @@ import (
 
 	// Packages
 	authmanager "github.com/mutablelogic/go-auth/auth/manager"
+	authschema "github.com/mutablelogic/go-auth/auth/schema"
 	llmmanager "github.com/mutablelogic/go-llm/kernel/manager"
+	httprequest "github.com/mutablelogic/go-server/pkg/httprequest"
 	httprouter "github.com/mutablelogic/go-server/pkg/httprouter"
+	jsonschema "github.com/mutablelogic/go-server/pkg/jsonschema"
+	openapi "github.com/mutablelogic/go-server/pkg/openapi/schema"
 )
 
+///////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// pathFunc returns the path, parameters and handlers of a route
+type pathFunc func(*llmmanager.Manager) (string, *jsonschema.Schema, httprequest.PathItem)
+
 ///////////////////////////////////////////////////////////////////////////////
 // PUBLIC METHODS
 
 // RegisterManagerHandlers registers manager resource handlers with the provided router.
+// When auth is set, the spec describes how requests are authenticated, and
+// when there is also an auth manager, it authenticates the requests.
+// Otherwise authentication is left to a proxy in front of the server.
 func RegisterHandlers(router *httprouter.Router, manager *llmmanager.Manager, authmanager *authmanager.Manager, auth bool) error {
 	// Add tag groups and tags
-	router.Spec().AddTagGroup("LLM Management", "Providers", "Models", "Connectors", "Tools & Agents", "Responses", "Sessions")
-
-	// TODO: Register the security scheme
-
-	// Register the security schemes, then the paths
-	return errors.Join(
-		router.RegisterPath(AgentHandler(manager)),
-		router.RegisterPath(AgentResourceHandler(manager)),
-		router.RegisterPath(CredentialHandler(manager)),
-		router.RegisterPath(ConnectorHandler(manager)),
-		router.RegisterPath(ConnectorResourceHandler(manager)),
-		router.RegisterPath(ModelHandler(manager)),
-		router.RegisterPath(ModelResourceHandler(manager)),
-		router.RegisterPath(ModelProviderResourceHandler(manager)),
-		router.RegisterPath(ProviderHandler(manager)),
-		router.RegisterPath(ProviderResourceHandler(manager)),
-		router.RegisterPath(ToolHandler(manager)),
-		router.RegisterPath(ToolResourceHandler(manager)),
-		router.RegisterPath(EmbeddingHandler(manager)),
-		router.RegisterPath(AskHandler(manager)),
-		router.RegisterPath(ChatHandler(manager)),
-		router.RegisterPath(SessionHandler(manager)),
-		router.RegisterPath(SessionResourceHandler(manager)),
-		router.RegisterPath(SessionChannelHandler(manager)),
-		router.RegisterPath(SessionMessageHandler(manager)),
+	router.Spec().AddTagGroup("LLM Management", "Providers", "Models", "Connectors", "Tools & Agents", "Responses", "Sessions", "OpenAI")
+
+	// Describe the bearer tokens and API keys which authenticate requests
+	if auth {
+		router.Spec().AddSecurityScheme(authschema.SecurityBearerAuth, openapi.SecurityScheme{
+			Type:         "http",
+			Scheme:       "bearer",
+			BearerFormat: "JWT",
+			Description:  "Token issued by the auth service for a user.",
+		})
+		router.Spec().AddSecurityScheme(authschema.SecurityAPIKeyAuth, openapi.SecurityScheme{
+			Type:        "apiKey",
+			In:          "header",
+			Name:        "X-API-Key",
+			Description: "API key of a user, issued by the auth service.",
+		})
+	}
+
+	// Register the paths under the version of the API
+	var result error
+	register := func(alias bool, fns ...pathFunc) {
+		for _, fn := range fns {
+			path, params, pathitem := fn(manager)
+			if auth && authmanager != nil {
+				authenticate(authmanager, path, pathitem)
+			}
+			result = errors.Join(result, registerVersioned(router, path, params, pathitem, alias))
+		}
+	}
+
+	// The paths which predate the version have deprecated aliases without it
+	register(true,
+		AgentHandler,
+		AgentResourceHandler,
+		CredentialHandler,
+		ConnectorHandler,
+		ConnectorResourceHandler,
+		ModelHandler,
+		ModelResourceHandler,
+		ModelProviderResourceHandler,
+		ProviderHandler,
+		ProviderResourceHandler,
+		ToolHandler,
+		ToolResourceHandler,
+		EmbeddingHandler,
+		AskHandler,
+		ChatHandler,
+		SessionHandler,
+		SessionResourceHandler,
+		SessionChannelHandler,
+		SessionMessageHandler,
+	)
+
+	// The paths which were added since are only under the version
+	register(false,
+		ModelRefreshHandler,
+		ModelLoadHandler,
+		ModelUnloadHandler,
+		ProviderCredentialsHandler,
+		ReloadHandler,
+		ToolDocsHandler,
+		ToolTestHandler,
+		JobResourceHandler,
+		RecordingResourceHandler,
+		SessionImportHandler,
+		SessionExportHandler,
+		SessionReasoningHandler,
+		SessionBudgetHandler,
+		SessionSyncHandler,
+		SessionMergeHandler,
+		SessionShareHandler,
+		SessionShareResourceHandler,
+		SharedSessionHandler,
+		SessionMessageResourceHandler,
+		SessionThreadHandler,
+		DataHandler,
+		UsageAnalyticsHandler,
+		ModelAnalyticsHandler,
+		ToolAnalyticsHandler,
+		TopicHandler,
+		ArchiveResourceHandler,
+		OpenAIChatHandler,
+		OpenAIModelHandler,
+		OpenAIEmbeddingHandler,
 	)
+
+	// Return any errors
+	return result
 }
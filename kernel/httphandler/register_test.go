@@ -0,0 +1,96 @@
+package httphandler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	// Packages
+	llmmanager "github.com/mutablelogic/go-llm/kernel/manager"
+	httprouter "github.com/mutablelogic/go-server/pkg/httprouter"
+	assert "github.com/stretchr/testify/assert"
+)
+
+func TestRegisterHandlersDescribesSpec(t *testing.T) {
+	assert := assert.New(t)
+
+	router, err := httprouter.NewRouter(context.Background(), http.NewServeMux(), "/api", "", "test", "0.0.0")
+	if !assert.NoError(err) {
+		return
+	}
+	if !assert.NoError(RegisterHandlers(router, &llmmanager.Manager{}, nil, false)) {
+		return
+	}
+
+	data, err := json.Marshal(router.Spec())
+	if !assert.NoError(err) {
+		return
+	}
+	var spec struct {
+		OpenAPI string                     `json:"openapi"`
+		Paths   map[string]json.RawMessage `json:"paths"`
+	}
+	if !assert.NoError(json.Unmarshal(data, &spec)) {
+		return
+	}
+	assert.NotEmpty(spec.OpenAPI)
+	for _, path := range []string{"/api/v1/agent", "/api/v1/session", "/api/v1/chat", "/api/v1/ask", "/api/v1/embedding", "/api/v1/model", "/api/v1/tool", "/api/v1/chat/completions"} {
+		assert.Contains(spec.Paths, path)
+	}
+
+	// Deprecated aliases without the version are left out of the spec
+	for _, path := range []string{"/api/agent", "/api/session", "/api/chat/completions"} {
+		assert.NotContains(spec.Paths, path)
+	}
+}
+
+func TestRegisterHandlersAliasesBaselinePaths(t *testing.T) {
+	assert := assert.New(t)
+
+	mux := http.NewServeMux()
+	router, err := httprouter.NewRouter(context.Background(), mux, "/api", "", "test", "0.0.0")
+	if !assert.NoError(err) {
+		return
+	}
+	if !assert.NoError(RegisterHandlers(router, &llmmanager.Manager{}, nil, false)) {
+		return
+	}
+
+	// Paths which predate the version are also served without it, and the
+	// paths which were added since are only served with it
+	for path, alias := range map[string]bool{
+		"/api/agent": true,
+		"/api/session/00000000-0000-0000-0000-000000000000/message": true,
+		"/api/chat/completions": false,
+		"/api/session/00000000-0000-0000-0000-000000000000/export":          false,
+		"/api/admin/session/00000000-0000-0000-0000-000000000000/reasoning": false,
+	} {
+		req, _ := http.NewRequest(http.MethodGet, path, nil)
+		_, pattern := mux.Handler(req)
+		if alias {
+			assert.NotEmpty(pattern, path)
+		} else {
+			assert.Empty(pattern, path)
+		}
+	}
+}
+
+func TestRegisterHandlersDescribesSecuritySchemes(t *testing.T) {
+	assert := assert.New(t)
+
+	router, err := httprouter.NewRouter(context.Background(), http.NewServeMux(), "/api", "", "test", "0.0.0")
+	if !assert.NoError(err) {
+		return
+	}
+	if !assert.NoError(RegisterHandlers(router, &llmmanager.Manager{}, nil, true)) {
+		return
+	}
+
+	// Bearer tokens and API keys are described when requests are authenticated
+	if assert.NotNil(router.Spec().Components) {
+		schemes := router.Spec().Components.SecuritySchemes
+		assert.Equal("bearer", schemes["bearerAuth"].Scheme)
+		assert.Equal("X-API-Key", schemes["apiKeyAuth"].Name)
+	}
+}
@@ -0,0 +1,43 @@
+package httphandler
+
+import (
+	"context"
+	"net/http"
+
+	// Packages
+	llmmanager "github.com/mutablelogic/go-llm/kernel/manager"
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	httprequest "github.com/mutablelogic/go-server/pkg/httprequest"
+	httpresponse "github.com/mutablelogic/go-server/pkg/httpresponse"
+	jsonschema "github.com/mutablelogic/go-server/pkg/jsonschema"
+	opts "github.com/mutablelogic/go-server/pkg/openapi"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+func ReloadHandler(manager *llmmanager.Manager) (string, *jsonschema.Schema, httprequest.PathItem) {
+	return "admin/reload", nil, httprequest.NewPathItem(
+		"Admin operations",
+		"Reload providers, connectors and agents without restarting the server",
+		"Providers",
+	).Post(
+		func(w http.ResponseWriter, r *http.Request) {
+			_ = reload(r.Context(), manager, w, r)
+		},
+		"Reload",
+		opts.WithDescription("Rebuilds the clients of providers whose settings or credentials have changed, and syncs connectors and the agent directory. Requests which started before the reload continue with the previous clients until they are done. Errors in one part are returned in the response, and do not stop the others."),
+		opts.WithJSONResponse(200, jsonschema.MustFor[schema.Reload]()),
+	)
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+func reload(ctx context.Context, manager *llmmanager.Manager, w http.ResponseWriter, r *http.Request) error {
+	result, err := manager.Reload(ctx)
+	if err != nil {
+		return httpresponse.Error(w, schema.HTTPErr(err))
+	}
+	return httpresponse.JSON(w, http.StatusOK, httprequest.Indent(r), result)
+}
@@ -9,10 +9,12 @@ import (
 	middleware "github.com/mutablelogic/go-auth/auth/middleware"
 	llmmanager "github.com/mutablelogic/go-llm/kernel/manager"
 	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	opt "github.com/mutablelogic/go-llm/pkg/opt"
 	httprequest "github.com/mutablelogic/go-server/pkg/httprequest"
 	httpresponse "github.com/mutablelogic/go-server/pkg/httpresponse"
 	jsonschema "github.com/mutablelogic/go-server/pkg/jsonschema"
 	opts "github.com/mutablelogic/go-server/pkg/openapi"
+	types "github.com/mutablelogic/go-server/pkg/types"
 )
 
 ///////////////////////////////////////////////////////////////////////////////
@@ -21,7 +23,7 @@ import (
 func SessionHandler(manager *llmmanager.Manager) (string, *jsonschema.Schema, httprequest.PathItem) {
 	return "session", nil, httprequest.NewPathItem(
 		"Session operations",
-		"List and create operations on sessions",
+		"List, create and bulk delete operations on sessions",
 		"Sessions",
 	).Get(
 		func(w http.ResponseWriter, r *http.Request) {
@@ -42,6 +44,16 @@ func SessionHandler(manager *llmmanager.Manager) (string, *jsonschema.Schema, ht
 		opts.WithErrorResponse(403, "Parent session belongs to another user."),
 		opts.WithErrorResponse(404, "Parent session, model, or provider not found."),
 		opts.WithErrorResponse(409, "Multiple models matched; specify a provider."),
+	).Delete(
+		func(w http.ResponseWriter, r *http.Request) {
+			_ = deleteSessions(r.Context(), manager, w, r)
+		},
+		"Delete sessions",
+		opts.WithQuery(jsonschema.MustFor[schema.SessionBulkRequest]()),
+		opts.WithJSONResponse(200, jsonschema.MustFor[schema.SessionBulkResult]()),
+		opts.WithTextStreamResponse(200, "SSE stream of progress, error, and result events."),
+		opts.WithErrorResponse(400, "Invalid request parameters or no filters."),
+		opts.WithErrorResponse(406, "Unsupported Accept header."),
 	)
 }
 
@@ -55,6 +67,8 @@ func SessionResourceHandler(manager *llmmanager.Manager) (string, *jsonschema.Sc
 			_ = getSession(r.Context(), manager, w, r)
 		},
 		"Get session",
+		opts.WithDescription("Returns the session without its messages, which are retrieved a page at a time from the session messages. With summary=true the generator settings are left out and the number of messages is returned."),
+		opts.WithQuery(jsonschema.MustFor[schema.SessionGetRequest]()),
 		opts.WithJSONResponse(200, jsonschema.MustFor[schema.Session]()),
 		opts.WithErrorResponse(400, "Invalid session ID."),
 		opts.WithErrorResponse(404, "Session not found."),
@@ -114,6 +128,20 @@ func getSession(ctx context.Context, manager *llmmanager.Manager, w http.Respons
 		return httpresponse.Error(w, httpresponse.ErrBadRequest, err)
 	}
 
+	var req schema.SessionGetRequest
+	if err := httprequest.Query(r.URL.Query(), &req); err != nil {
+		return httpresponse.Error(w, httpresponse.ErrBadRequest, err)
+	}
+
+	// Return the summary of the session
+	if req.Summary {
+		summary, err := manager.GetSessionSummary(ctx, id, middleware.UserFromContext(ctx))
+		if err != nil {
+			return httpresponse.Error(w, schema.HTTPErr(err))
+		}
+		return httpresponse.JSON(w, http.StatusOK, httprequest.Indent(r), summary)
+	}
+
 	session, err := manager.GetSession(ctx, id, middleware.UserFromContext(ctx))
 	if err != nil {
 		return httpresponse.Error(w, schema.HTTPErr(err))
@@ -136,6 +164,47 @@ func deleteSession(ctx context.Context, manager *llmmanager.Manager, w http.Resp
 	return httpresponse.JSON(w, http.StatusOK, httprequest.Indent(r), session)
 }
 
+func deleteSessions(ctx context.Context, manager *llmmanager.Manager, w http.ResponseWriter, r *http.Request) error {
+	var req schema.SessionBulkRequest
+	if err := httprequest.Query(r.URL.Query(), &req); err != nil {
+		return httpresponse.Error(w, httpresponse.ErrBadRequest, err)
+	}
+
+	// Determine the accepted response content type
+	accept, err := types.AcceptContentType(r)
+	if err != nil {
+		return httpresponse.Error(w, httpresponse.ErrBadRequest, err)
+	}
+
+	// Respond
+	switch accept {
+	case types.ContentTypeTextStream:
+		stream := newEventStream(ctx, w)
+		defer stream.Close()
+		ctx := stream.Context()
+
+		progressFn := opt.ProgressFn(func(status string, percent float64) {
+			stream.Write(schema.EventProgress, schema.ProgressEvent{Status: status, Percent: percent})
+		})
+
+		result, err := manager.DeleteSessions(ctx, req, middleware.UserFromContext(ctx), opt.WithProgress(progressFn))
+		if err != nil {
+			stream.Write(schema.EventError, schema.StreamError{Error: err.Error()})
+			return nil
+		}
+		stream.Write(schema.EventResult, result)
+		return nil
+	case types.ContentTypeJSON, types.ContentTypeAny:
+		result, err := manager.DeleteSessions(ctx, req, middleware.UserFromContext(ctx))
+		if err != nil {
+			return httpresponse.Error(w, schema.HTTPErr(err))
+		}
+		return httpresponse.JSON(w, http.StatusOK, httprequest.Indent(r), result)
+	default:
+		return httpresponse.Error(w, httpresponse.Err(http.StatusNotAcceptable))
+	}
+}
+
 func updateSession(ctx context.Context, manager *llmmanager.Manager, w http.ResponseWriter, r *http.Request) error {
 	id, err := uuid.Parse(r.PathValue("session"))
 	if err != nil {
@@ -0,0 +1,162 @@
+package httphandler
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+
+	// Packages
+	uuid "github.com/google/uuid"
+	middleware "github.com/mutablelogic/go-auth/auth/middleware"
+	llmmanager "github.com/mutablelogic/go-llm/kernel/manager"
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	httprequest "github.com/mutablelogic/go-server/pkg/httprequest"
+	httpresponse "github.com/mutablelogic/go-server/pkg/httpresponse"
+	jsonschema "github.com/mutablelogic/go-server/pkg/jsonschema"
+	opts "github.com/mutablelogic/go-server/pkg/openapi"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+// Headers for a shared transcript, which is read by anyone with the link:
+// it runs no scripts, loads nothing but embedded images, is not indexed and
+// the token is not sent on in the referrer
+var shareHeaders = map[string]string{
+	"Content-Security-Policy": "default-src 'none'; img-src data:; style-src 'unsafe-inline'",
+	"Referrer-Policy":         "no-referrer",
+	"X-Robots-Tag":            "noindex, nofollow",
+	"Cache-Control":           "no-store",
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+func SessionShareHandler(manager *llmmanager.Manager) (string, *jsonschema.Schema, httprequest.PathItem) {
+	return "session/{session}/share", jsonschema.MustFor[schema.SessionIDSelector](), httprequest.NewPathItem(
+		"Session share links",
+		"Create and list read-only share links for a session",
+		"Sessions",
+	).Get(
+		func(w http.ResponseWriter, r *http.Request) {
+			_ = listSessionShares(r.Context(), manager, w, r)
+		},
+		"List share links",
+		opts.WithDescription("Returns the share links of the session which have not expired, without their tokens."),
+		opts.WithJSONResponse(200, jsonschema.MustFor[schema.SessionShareList]()),
+		opts.WithErrorResponse(400, "Invalid session ID."),
+		opts.WithErrorResponse(404, "Session not found."),
+	).Post(
+		func(w http.ResponseWriter, r *http.Request) {
+			_ = createSessionShare(r.Context(), manager, w, r)
+		},
+		"Create share link",
+		opts.WithDescription("Creates a link which expires, giving read-only access to the session transcript as HTML at share/{token} without authentication. The token is only returned here, and the link is revoked by deleting it."),
+		opts.WithJSONRequest(jsonschema.MustFor[schema.SessionShareRequest]()),
+		opts.WithJSONResponse(201, jsonschema.MustFor[schema.SessionShare]()),
+		opts.WithErrorResponse(400, "Invalid session ID or expiry."),
+		opts.WithErrorResponse(404, "Session not found."),
+	)
+}
+
+func SessionShareResourceHandler(manager *llmmanager.Manager) (string, *jsonschema.Schema, httprequest.PathItem) {
+	return "session/{session}/share/{share}", jsonschema.MustFor[schema.SessionShareIDSelector](), httprequest.NewPathItem(
+		"Session share link",
+		"Revoke a read-only share link for a session",
+		"Sessions",
+	).Delete(
+		func(w http.ResponseWriter, r *http.Request) {
+			_ = deleteSessionShare(r.Context(), manager, w, r)
+		},
+		"Revoke share link",
+		opts.WithJSONResponse(200, jsonschema.MustFor[schema.SessionShare]()),
+		opts.WithErrorResponse(400, "Invalid session or share link ID."),
+		opts.WithErrorResponse(404, "Session or share link not found."),
+	)
+}
+
+func SharedSessionHandler(manager *llmmanager.Manager) (string, *jsonschema.Schema, httprequest.PathItem) {
+	return "share/{token}", jsonschema.MustFor[schema.SessionShareTokenSelector](), httprequest.NewPathItem(
+		"Shared session",
+		"Read-only transcript of a shared session",
+		"Sessions",
+	).Get(
+		func(w http.ResponseWriter, r *http.Request) {
+			_ = getSharedSession(r.Context(), manager, w, r)
+		},
+		"Get shared session",
+		opts.WithDescription("Returns the transcript of the session as HTML. The token grants access, so no authentication is required."),
+		opts.WithResponse(200, exportTypes[schema.ExportHTML], exportBody, "Session transcript, including messages, code, images and tool calls."),
+		opts.WithErrorResponse(404, "Share link not found, expired or revoked."),
+	)
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+func listSessionShares(ctx context.Context, manager *llmmanager.Manager, w http.ResponseWriter, r *http.Request) error {
+	id, err := uuid.Parse(r.PathValue("session"))
+	if err != nil {
+		return httpresponse.Error(w, httpresponse.ErrBadRequest, err)
+	}
+
+	result, err := manager.ListSessionShares(ctx, id, middleware.UserFromContext(ctx))
+	if err != nil {
+		return httpresponse.Error(w, schema.HTTPErr(err))
+	}
+
+	return httpresponse.JSON(w, http.StatusOK, httprequest.Indent(r), result)
+}
+
+func createSessionShare(ctx context.Context, manager *llmmanager.Manager, w http.ResponseWriter, r *http.Request) error {
+	id, err := uuid.Parse(r.PathValue("session"))
+	if err != nil {
+		return httpresponse.Error(w, httpresponse.ErrBadRequest, err)
+	}
+
+	var req schema.SessionShareRequest
+	if err := httprequest.Read(r, &req); err != nil {
+		return httpresponse.Error(w, httpresponse.ErrBadRequest, err)
+	}
+
+	result, err := manager.CreateSessionShare(ctx, id, req, middleware.UserFromContext(ctx))
+	if err != nil {
+		return httpresponse.Error(w, schema.HTTPErr(err))
+	}
+
+	return httpresponse.JSON(w, http.StatusCreated, httprequest.Indent(r), result)
+}
+
+func deleteSessionShare(ctx context.Context, manager *llmmanager.Manager, w http.ResponseWriter, r *http.Request) error {
+	id, err := uuid.Parse(r.PathValue("session"))
+	if err != nil {
+		return httpresponse.Error(w, httpresponse.ErrBadRequest, err)
+	}
+	share, err := uuid.Parse(r.PathValue("share"))
+	if err != nil {
+		return httpresponse.Error(w, httpresponse.ErrBadRequest, err)
+	}
+
+	result, err := manager.DeleteSessionShare(ctx, id, share, middleware.UserFromContext(ctx))
+	if err != nil {
+		return httpresponse.Error(w, schema.HTTPErr(err))
+	}
+
+	return httpresponse.JSON(w, http.StatusOK, httprequest.Indent(r), result)
+}
+
+func getSharedSession(ctx context.Context, manager *llmmanager.Manager, w http.ResponseWriter, r *http.Request) error {
+	// Render the transcript before writing, so that errors are returned as JSON
+	var buf bytes.Buffer
+	if err := manager.ExportSharedSession(ctx, r.PathValue("token"), &buf); err != nil {
+		return httpresponse.Error(w, schema.HTTPErr(err))
+	}
+
+	for key, value := range shareHeaders {
+		w.Header().Set(key, value)
+	}
+	return httpresponse.Write(w, http.StatusOK, exportTypes[schema.ExportHTML], func(writer io.Writer) (int, error) {
+		return writer.Write(buf.Bytes())
+	})
+}
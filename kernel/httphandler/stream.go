@@ -0,0 +1,244 @@
+package httphandler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	// Packages
+	types "github.com/mutablelogic/go-server/pkg/types"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// eventStream writes server-sent events to a client. Events are queued and
+// written in the background, with a heartbeat comment when the stream is
+// idle so that proxies keep the connection open. When the client goes away,
+// a write fails or stalls, or the client falls too far behind, the context
+// of the stream is cancelled so that the generation which feeds it stops.
+type eventStream struct {
+	ctx     context.Context
+	cancel  context.CancelCauseFunc
+	w       http.ResponseWriter
+	rc      *http.ResponseController
+	ready   chan struct{} // signalled when an event is queued or the stream is closed
+	drained chan struct{} // signalled when queued events are written
+	done    chan struct{} // closed when the writer returns
+
+	sync.Mutex
+	queue   [][]byte
+	pending int // bytes in the queue
+	closed  bool
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+const (
+	streamHeartbeat    = 15 * time.Second // idle time before a heartbeat
+	streamWriteTimeout = 30 * time.Second // time allowed for a write, or for the queue to drain
+	streamBufferSize   = 1 << 20          // bytes of events queued for a slow client
+)
+
+var (
+	errStreamStalled       = errors.New("client is too slow to read the stream")
+	streamHeartbeatComment = []byte(": ping\n\n")
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// LIFECYCLE
+
+// newEventStream starts a stream of events, which ends when Close is called.
+// The context of the stream is derived from ctx, and is used for the work
+// which feeds the stream.
+func newEventStream(ctx context.Context, w http.ResponseWriter) *eventStream {
+	s := &eventStream{
+		w:       w,
+		rc:      http.NewResponseController(w),
+		ready:   make(chan struct{}, 1),
+		drained: make(chan struct{}, 1),
+		done:    make(chan struct{}),
+	}
+	s.ctx, s.cancel = context.WithCancelCause(ctx)
+
+	// Disable buffering in proxies such as nginx, and start the stream
+	w.Header().Set(types.ContentTypeHeader, types.ContentTypeTextStream)
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	if err := s.flush(); err != nil {
+		s.cancel(err)
+	}
+
+	go s.run()
+	return s
+}
+
+// Close writes the events which are queued and stops the stream. It returns
+// the error which ended the stream early, if any.
+func (s *eventStream) Close() error {
+	s.Lock()
+	s.closed = true
+	s.Unlock()
+	s.signal(s.ready)
+	<-s.done
+
+	// Remove the deadline, and release the context
+	_ = s.rc.SetWriteDeadline(time.Time{})
+	err := context.Cause(s.ctx)
+	s.cancel(nil)
+	if errors.Is(err, context.Canceled) {
+		return nil
+	}
+	return err
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// Context returns a context which is cancelled when the stream ends early
+func (s *eventStream) Context() context.Context {
+	return s.ctx
+}
+
+// Write queues an event, with data which is encoded as JSON. When the queue
+// is full it waits for the client to read the stream, and ends the stream if
+// the client does not. Events written after the stream ended are discarded.
+// An event with no name is written as data only.
+func (s *eventStream) Write(name string, data any) {
+	var encoded []byte
+	if data != nil {
+		var err error
+		if encoded, err = json.Marshal(data); err != nil {
+			encoded, _ = json.Marshal(map[string]string{"error": err.Error()})
+		}
+	}
+	s.enqueue(streamEvent(name, encoded))
+}
+
+// WriteText queues an event with data which is written as it is, such as
+// the marker which ends an OpenAI-compatible stream
+func (s *eventStream) WriteText(name, text string) {
+	s.enqueue(streamEvent(name, []byte(text)))
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// streamEvent returns an event with a name and data, either of which may be
+// empty
+func streamEvent(name string, data []byte) []byte {
+	var buf bytes.Buffer
+	if name != "" {
+		buf.WriteString("event: " + name + "\n")
+	}
+	if data != nil {
+		buf.WriteString("data: ")
+		buf.Write(data)
+		buf.WriteString("\n")
+	}
+	buf.WriteString("\n")
+	return buf.Bytes()
+}
+
+// enqueue queues an event, waiting for the client when the queue is full
+func (s *eventStream) enqueue(event []byte) {
+	var timeout <-chan time.Time
+	for {
+		if s.ctx.Err() != nil {
+			return
+		}
+		s.Lock()
+		if s.pending == 0 || s.pending+len(event) <= streamBufferSize {
+			s.queue = append(s.queue, event)
+			s.pending += len(event)
+			s.Unlock()
+			s.signal(s.ready)
+			return
+		}
+		s.Unlock()
+
+		// Wait for the queue to drain
+		if timeout == nil {
+			timer := time.NewTimer(streamWriteTimeout)
+			defer timer.Stop()
+			timeout = timer.C
+		}
+		select {
+		case <-s.drained:
+		case <-timeout:
+			s.cancel(errStreamStalled)
+			return
+		case <-s.ctx.Done():
+			return
+		}
+	}
+}
+
+// run writes queued events until the stream is closed or ends early, and a
+// heartbeat when no event has been written for a while
+func (s *eventStream) run() {
+	defer close(s.done)
+	heartbeat := time.NewTimer(streamHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-heartbeat.C:
+			if err := s.write(streamHeartbeatComment); err != nil {
+				s.cancel(err)
+				return
+			}
+		case <-s.ready:
+			s.Lock()
+			queue, closed := s.queue, s.closed
+			s.queue, s.pending = nil, 0
+			s.Unlock()
+			for _, event := range queue {
+				if err := s.write(event); err != nil {
+					s.cancel(err)
+					return
+				}
+			}
+			s.signal(s.drained)
+			if closed {
+				return
+			}
+		}
+		heartbeat.Reset(streamHeartbeat)
+	}
+}
+
+// write writes and flushes data before the write deadline
+func (s *eventStream) write(data []byte) error {
+	if err := s.rc.SetWriteDeadline(time.Now().Add(streamWriteTimeout)); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		return err
+	}
+	if _, err := s.w.Write(data); err != nil {
+		return err
+	}
+	return s.flush()
+}
+
+// flush sends buffered data to the client
+func (s *eventStream) flush() error {
+	if err := s.rc.Flush(); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		return err
+	}
+	return nil
+}
+
+// signal wakes a waiting goroutine without blocking
+func (s *eventStream) signal(ch chan struct{}) {
+	select {
+	case ch <- struct{}{}:
+	default:
+	}
+}
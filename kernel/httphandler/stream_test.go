@@ -0,0 +1,69 @@
+package httphandler
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	// Packages
+	assert "github.com/stretchr/testify/assert"
+)
+
+func TestEventStreamWrite(t *testing.T) {
+	assert := assert.New(t)
+
+	// Events are written in order, and Close waits for them
+	w := httptest.NewRecorder()
+	stream := newEventStream(context.Background(), w)
+	stream.Write("assistant", map[string]string{"text": "hello"})
+	stream.Write("result", nil)
+	assert.NoError(stream.Close())
+	assert.Equal(http.StatusOK, w.Code)
+	assert.Equal("no", w.Header().Get("X-Accel-Buffering"))
+	assert.Equal("event: assistant\ndata: {\"text\":\"hello\"}\n\nevent: result\n\n", w.Body.String())
+
+	// The context is done once the stream is closed, and later events are
+	// discarded
+	assert.Error(stream.Context().Err())
+	stream.Write("result", nil)
+}
+
+func TestEventStreamDisconnect(t *testing.T) {
+	assert := assert.New(t)
+
+	// The context of the stream is cancelled when the client goes away
+	cancelled := make(chan error, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		stream := newEventStream(r.Context(), w)
+		defer stream.Close()
+		stream.Write("assistant", "hello")
+		select {
+		case <-stream.Context().Done():
+			cancelled <- nil
+		case <-time.After(5 * time.Second):
+			cancelled <- context.DeadlineExceeded
+		}
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	if !assert.NoError(err) {
+		return
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if !assert.NoError(err) {
+		return
+	}
+	line, err := bufio.NewReader(resp.Body).ReadString('\n')
+	assert.NoError(err)
+	assert.True(strings.HasPrefix(line, "event: assistant"))
+	cancel()
+	resp.Body.Close()
+	assert.NoError(<-cancelled)
+}
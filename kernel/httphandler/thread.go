@@ -0,0 +1,98 @@
+package httphandler
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	// Packages
+	uuid "github.com/google/uuid"
+	middleware "github.com/mutablelogic/go-auth/auth/middleware"
+	llmmanager "github.com/mutablelogic/go-llm/kernel/manager"
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	httprequest "github.com/mutablelogic/go-server/pkg/httprequest"
+	httpresponse "github.com/mutablelogic/go-server/pkg/httpresponse"
+	jsonschema "github.com/mutablelogic/go-server/pkg/jsonschema"
+	opts "github.com/mutablelogic/go-server/pkg/openapi"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+func SessionThreadHandler(manager *llmmanager.Manager) (string, *jsonschema.Schema, httprequest.PathItem) {
+	return "session/{session}/message/{message}/thread", jsonschema.MustFor[schema.MessageSelector](), httprequest.NewPathItem(
+		"Message threads",
+		"List and start side threads on a message in a session",
+		"Sessions",
+	).Get(
+		func(w http.ResponseWriter, r *http.Request) {
+			_ = listThreads(r.Context(), manager, w, r)
+		},
+		"List message threads",
+		opts.WithDescription("Returns the side threads on the message, most recently active first. Each thread is a child session with the session as parent and the message as parent_message."),
+		opts.WithQuery(jsonschema.MustFor[schema.ThreadListRequest]()),
+		opts.WithJSONResponse(200, jsonschema.MustFor[schema.SessionList]()),
+		opts.WithErrorResponse(400, "Invalid request parameters, session or message ID."),
+		opts.WithErrorResponse(404, "Session not found."),
+	).Post(
+		func(w http.ResponseWriter, r *http.Request) {
+			_ = createThread(r.Context(), manager, w, r)
+		},
+		"Start message thread",
+		opts.WithDescription("Creates a child session which continues from the message. The history of the session up to the message is sent with each turn of the thread, but the messages of the thread are not added to the session."),
+		opts.WithJSONRequest(jsonschema.MustFor[schema.SessionMeta]()),
+		opts.WithJSONResponse(201, jsonschema.MustFor[schema.Session]()),
+		opts.WithErrorResponse(400, "Invalid request body, session or message ID, or the message is pending input."),
+		opts.WithErrorResponse(403, "Session belongs to another user."),
+		opts.WithErrorResponse(404, "Session or message not found."),
+	)
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+func listThreads(ctx context.Context, manager *llmmanager.Manager, w http.ResponseWriter, r *http.Request) error {
+	session, err := uuid.Parse(r.PathValue("session"))
+	if err != nil {
+		return httpresponse.Error(w, httpresponse.ErrBadRequest, err)
+	}
+	message, err := strconv.ParseUint(r.PathValue("message"), 10, 64)
+	if err != nil {
+		return httpresponse.Error(w, httpresponse.ErrBadRequest, err)
+	}
+
+	var req schema.ThreadListRequest
+	if err := httprequest.Query(r.URL.Query(), &req); err != nil {
+		return httpresponse.Error(w, httpresponse.ErrBadRequest, err)
+	}
+
+	result, err := manager.ListThreads(ctx, session, message, req, middleware.UserFromContext(ctx))
+	if err != nil {
+		return httpresponse.Error(w, schema.HTTPErr(err))
+	}
+
+	return httpresponse.JSON(w, http.StatusOK, httprequest.Indent(r), result)
+}
+
+func createThread(ctx context.Context, manager *llmmanager.Manager, w http.ResponseWriter, r *http.Request) error {
+	session, err := uuid.Parse(r.PathValue("session"))
+	if err != nil {
+		return httpresponse.Error(w, httpresponse.ErrBadRequest, err)
+	}
+	message, err := strconv.ParseUint(r.PathValue("message"), 10, 64)
+	if err != nil {
+		return httpresponse.Error(w, httpresponse.ErrBadRequest, err)
+	}
+
+	var meta schema.SessionMeta
+	if err := httprequest.Read(r, &meta); err != nil {
+		return httpresponse.Error(w, httpresponse.ErrBadRequest, err)
+	}
+
+	thread, err := manager.CreateThread(ctx, session, message, meta, middleware.UserFromContext(ctx))
+	if err != nil {
+		return httpresponse.Error(w, schema.HTTPErr(err))
+	}
+
+	return httpresponse.JSON(w, http.StatusCreated, httprequest.Indent(r), thread)
+}
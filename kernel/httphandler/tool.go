@@ -1,6 +1,7 @@
 package httphandler
 
 import (
+	"bytes"
 	"context"
 	"io"
 	"net/http"
@@ -18,6 +19,15 @@ import (
 	types "github.com/mutablelogic/go-server/pkg/types"
 )
 
+///////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+// Content type for each tool documentation format
+var toolDocsTypes = map[string]string{
+	schema.ExportMarkdown: "text/markdown; charset=utf-8",
+	schema.ExportHTML:     "text/html; charset=utf-8",
+}
+
 ///////////////////////////////////////////////////////////////////////////////
 // PUBLIC METHODS
 
@@ -64,6 +74,43 @@ func ToolResourceHandler(manager *llmmanager.Manager) (string, *jsonschema.Schem
 	)
 }
 
+func ToolDocsHandler(manager *llmmanager.Manager) (string, *jsonschema.Schema, httprequest.PathItem) {
+	return "tool/docs", nil, httprequest.NewPathItem(
+		"Tool documentation",
+		"Documentation for tools, generated from their descriptions and schemas",
+		"Tools & Agents",
+	).Get(
+		func(w http.ResponseWriter, r *http.Request) {
+			_ = toolDocs(r.Context(), manager, w, r)
+		},
+		"Get tool documentation",
+		opts.WithDescription("Returns documentation for all tools which are available, with the input and output parameters of each tool, as markdown or HTML."),
+		opts.WithQuery(jsonschema.MustFor[schema.ToolDocsRequest]()),
+		opts.WithResponse(200, toolDocsTypes[schema.ExportMarkdown], jsonschema.MustFor[string](), "Tool documentation as markdown."),
+		opts.WithResponse(200, toolDocsTypes[schema.ExportHTML], jsonschema.MustFor[string](), "Tool documentation as HTML."),
+		opts.WithErrorResponse(400, "Invalid request parameters or unsupported format."),
+	)
+}
+
+func ToolTestHandler(manager *llmmanager.Manager) (string, *jsonschema.Schema, httprequest.PathItem) {
+	return "tool/{name}/test", nil, httprequest.NewPathItem(
+		"Tool test",
+		"Run a tool outside of a chat and report on the call",
+		"Tools & Agents",
+	).Post(
+		func(w http.ResponseWriter, r *http.Request) {
+			_ = testTool(r.Context(), manager, w, r)
+		},
+		"Test tool",
+		opts.WithDescription("Runs the tool with the input, validating the input and output against the tool schemas and timing the call. A failed test is returned in the report, with the stage at which it failed."),
+		opts.WithJSONRequest(jsonschema.MustFor[schema.CallToolRequest]()),
+		opts.WithJSONResponse(200, jsonschema.MustFor[schema.ToolTestReport]()),
+		opts.WithErrorResponse(400, "Invalid request body."),
+		opts.WithErrorResponse(404, "Tool not found."),
+		opts.WithErrorResponse(409, "Multiple tools matched; specify a fully-qualified tool name."),
+	)
+}
+
 ///////////////////////////////////////////////////////////////////////////////
 // PRIVATE METHODS
 
@@ -114,6 +161,49 @@ func callTool(ctx context.Context, manager *llmmanager.Manager, w http.ResponseW
 	return writeToolResource(ctx, w, resource)
 }
 
+func toolDocs(ctx context.Context, manager *llmmanager.Manager, w http.ResponseWriter, r *http.Request) error {
+	var req schema.ToolDocsRequest
+	if err := httprequest.Query(r.URL.Query(), &req); err != nil {
+		return httpresponse.Error(w, httpresponse.ErrBadRequest, err)
+	}
+	if req.Format == "" {
+		req.Format = schema.ExportMarkdown
+	}
+	contentType, exists := toolDocsTypes[req.Format]
+	if !exists {
+		return httpresponse.Error(w, httpresponse.ErrBadRequest.Withf("unsupported documentation format %q", req.Format))
+	}
+
+	// Render the documentation before writing, so that errors are returned as JSON
+	var buf bytes.Buffer
+	if err := manager.ToolDocs(ctx, req, &buf, middleware.UserFromContext(ctx)); err != nil {
+		return httpresponse.Error(w, schema.HTTPErr(err))
+	}
+
+	return httpresponse.Write(w, http.StatusOK, contentType, func(writer io.Writer) (int, error) {
+		return writer.Write(buf.Bytes())
+	})
+}
+
+func testTool(ctx context.Context, manager *llmmanager.Manager, w http.ResponseWriter, r *http.Request) error {
+	name, err := unescapePathValue(r, "name")
+	if err != nil {
+		return httpresponse.Error(w, httpresponse.ErrBadRequest, err)
+	}
+
+	var req schema.CallToolRequest
+	if err := httprequest.Read(r, &req); err != nil {
+		return httpresponse.Error(w, httpresponse.ErrBadRequest, err)
+	}
+
+	report, err := manager.TestTool(ctx, name, req, middleware.UserFromContext(ctx))
+	if err != nil {
+		return httpresponse.Error(w, schema.HTTPErr(err))
+	}
+
+	return httpresponse.JSON(w, http.StatusOK, httprequest.Indent(r), report)
+}
+
 func writeToolResource(ctx context.Context, w http.ResponseWriter, resource llm.Resource) error {
 	if resource == nil {
 		return httpresponse.Write(w, http.StatusNoContent, types.ContentTypeTextPlain, nil)
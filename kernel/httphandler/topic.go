@@ -0,0 +1,54 @@
+package httphandler
+
+import (
+	"context"
+	"net/http"
+
+	// Packages
+	middleware "github.com/mutablelogic/go-auth/auth/middleware"
+	llmmanager "github.com/mutablelogic/go-llm/kernel/manager"
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	httprequest "github.com/mutablelogic/go-server/pkg/httprequest"
+	httpresponse "github.com/mutablelogic/go-server/pkg/httpresponse"
+	jsonschema "github.com/mutablelogic/go-server/pkg/jsonschema"
+	opts "github.com/mutablelogic/go-server/pkg/openapi"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+func TopicHandler(manager *llmmanager.Manager) (string, *jsonschema.Schema, httprequest.PathItem) {
+	return "analytics/topics", nil, httprequest.NewPathItem(
+		"Topic analysis",
+		"Group sessions into topics by the similarity of their first messages",
+		"Sessions",
+	).Post(
+		func(w http.ResponseWriter, r *http.Request) {
+			_ = topics(r.Context(), manager, w, r)
+		},
+		"Analyse session topics",
+		opts.WithJSONRequest(jsonschema.MustFor[schema.TopicRequest]()),
+		opts.WithJSONResponse(200, jsonschema.MustFor[schema.TopicReport]()),
+		opts.WithErrorResponse(400, "Missing embedding model or invalid request body."),
+		opts.WithErrorResponse(404, "Model or provider not found."),
+		opts.WithErrorResponse(409, "Multiple models matched; specify a provider."),
+		opts.WithErrorResponse(501, "Provider does not support embeddings."),
+	)
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+func topics(ctx context.Context, manager *llmmanager.Manager, w http.ResponseWriter, r *http.Request) error {
+	var req schema.TopicRequest
+	if err := httprequest.Read(r, &req); err != nil {
+		return httpresponse.Error(w, httpresponse.ErrBadRequest, err)
+	}
+
+	report, err := manager.Topics(ctx, req, middleware.UserFromContext(ctx))
+	if err != nil {
+		return httpresponse.Error(w, schema.HTTPErr(err))
+	}
+
+	return httpresponse.JSON(w, http.StatusOK, httprequest.Indent(r), report)
+}
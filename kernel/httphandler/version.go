@@ -0,0 +1,103 @@
+package httphandler
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	// Packages
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	httprequest "github.com/mutablelogic/go-server/pkg/httprequest"
+	httpresponse "github.com/mutablelogic/go-server/pkg/httpresponse"
+	httprouter "github.com/mutablelogic/go-server/pkg/httprouter"
+	jsonschema "github.com/mutablelogic/go-server/pkg/jsonschema"
+	openapi "github.com/mutablelogic/go-server/pkg/openapi/schema"
+	types "github.com/mutablelogic/go-server/pkg/types"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// versionedPath serves a path item for a version of the API. When successor
+// is set, the path is a deprecated alias without the version, which is left
+// out of the OpenAPI spec and points clients at the successor prefix.
+type versionedPath struct {
+	httprequest.PathItem
+	version   string
+	prefix    string
+	successor string
+}
+
+var _ httprequest.PathItem = (*versionedPath)(nil)
+
+///////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+var (
+	// unversionedDeprecation is when the paths without a version were deprecated
+	unversionedDeprecation = time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC)
+
+	// unversionedSunset is when the paths without a version are removed
+	unversionedSunset = time.Date(2027, 4, 1, 0, 0, 0, 0, time.UTC)
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+func (p *versionedPath) Spec(path string, params *jsonschema.Schema) *openapi.PathItem {
+	if p.successor != "" {
+		return nil
+	}
+	return p.PathItem.Spec(path, params)
+}
+
+// Handler checks the API-Version header of the request against the version
+// of the path, and marks responses from deprecated aliases with the
+// Deprecation, Sunset and Link headers
+func (p *versionedPath) Handler() http.HandlerFunc {
+	next := p.PathItem.Handler()
+	if next == nil {
+		return nil
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		version, err := schema.NegotiateAPIVersion(r.Header.Get(schema.APIVersionHeader), p.version)
+		if err != nil {
+			_ = httpresponse.Error(w, schema.HTTPErr(err))
+			return
+		}
+		w.Header().Set(schema.APIVersionHeader, version)
+		if p.successor != "" {
+			w.Header().Set("Deprecation", fmt.Sprintf("@%d", unversionedDeprecation.Unix()))
+			w.Header().Set("Sunset", unversionedSunset.Format(http.TimeFormat))
+			w.Header().Set("Link", fmt.Sprintf("<%s>; rel=\"successor-version\"", types.JoinPath(p.successor, strings.TrimPrefix(r.URL.Path, p.prefix))))
+		}
+		next(w, r)
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// registerVersioned registers a path under the current version of the API,
+// and when alias is true, registers a deprecated alias without the version
+func registerVersioned(router *httprouter.Router, path string, params *jsonschema.Schema, pathitem httprequest.PathItem, alias bool) error {
+	// The versioned path is registered first, so that the alias shares any
+	// security wrappers added to the path item
+	successor := types.JoinPath(router.Prefix(), schema.APIVersion)
+	if err := router.RegisterPath(schema.APIVersion+"/"+path, params, &versionedPath{
+		PathItem: pathitem,
+		version:  schema.APIVersion,
+	}); err != nil {
+		return err
+	}
+	if !alias {
+		return nil
+	}
+	return router.RegisterPath(path, params, &versionedPath{
+		PathItem:  pathitem,
+		version:   schema.APIVersion,
+		prefix:    router.Prefix(),
+		successor: successor,
+	})
+}
@@ -0,0 +1,283 @@
+package manager
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	// Packages
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	attribute "go.opentelemetry.io/otel/attribute"
+	metric "go.opentelemetry.io/otel/metric"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// admission limits the generations which run at once to a number of workers.
+// Requests which arrive when every worker is busy wait in a queue for their
+// priority, and are admitted in priority order, then in arrival order. A
+// request is rejected when the queue for its priority is full.
+type admission struct {
+	sync.Mutex
+	workers int
+	limits  []int               // queue length for each priority, in rank order
+	queues  [][]*admissionEntry // waiting requests for each priority, in rank order
+	active  int
+	average time.Duration // moving average of the time a worker is held
+
+	// Metrics, which are nil when no meter is set
+	rejected metric.Int64Counter
+	waited   metric.Float64Histogram
+}
+
+// admissionEntry is a waiting request, which is admitted when ready is closed
+type admissionEntry struct {
+	ready chan struct{}
+}
+
+type admittedKey struct{}
+type priorityKey struct{}
+
+///////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+const (
+	// Bounds of the time after which a rejected request is retried
+	minRetryAfter = time.Second
+	maxRetryAfter = time.Minute
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// LIFECYCLE
+
+func newAdmission(workers int, limits map[schema.Priority]int) (*admission, error) {
+	if workers <= 0 {
+		return nil, fmt.Errorf("admission requires at least one worker")
+	}
+	a := &admission{
+		workers: workers,
+		limits:  make([]int, len(schema.Priorities)),
+		queues:  make([][]*admissionEntry, len(schema.Priorities)),
+	}
+	for priority, limit := range limits {
+		if !priority.Valid() {
+			return nil, fmt.Errorf("invalid priority %q", priority)
+		} else if limit < 0 {
+			return nil, fmt.Errorf("queue length for %q cannot be negative", priority)
+		}
+		a.limits[priority.Rank()] = limit
+	}
+	return a, nil
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// WithPriority returns a context whose generations are admitted with the
+// priority, when the manager limits the generations which run at once with
+// WithAdmission. Requests without a priority are interactive.
+func WithPriority(ctx context.Context, priority schema.Priority) context.Context {
+	return context.WithValue(ctx, priorityKey{}, priority)
+}
+
+// Admit waits for a worker to run a generation with the priority of the
+// context, and returns a context which is admitted and a function which
+// releases the worker. Ask and Chat admit their requests, so Admit is called
+// before them only to be rejected before a response is started, for example
+// a stream. An error with the time to retry after is returned when the queue
+// for the priority is full.
+func (m *Manager) Admit(ctx context.Context) (context.Context, func(), error) {
+	if m.admission == nil || ctx.Value(admittedKey{}) != nil {
+		return ctx, func() {}, nil
+	}
+	release, err := m.admission.acquire(ctx, priorityFromContext(ctx))
+	if err != nil {
+		return ctx, nil, err
+	}
+	return context.WithValue(ctx, admittedKey{}, true), release, nil
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// priorityFromContext returns the priority of the context, or interactive
+func priorityFromContext(ctx context.Context) schema.Priority {
+	if priority, ok := ctx.Value(priorityKey{}).(schema.Priority); ok && priority.Valid() {
+		return priority
+	}
+	return schema.PriorityInteractive
+}
+
+// acquire waits for a worker, and returns a function which releases it
+func (a *admission) acquire(ctx context.Context, priority schema.Priority) (func(), error) {
+	rank := priority.Rank()
+
+	a.Lock()
+	if a.active < a.workers && a.waiting() == 0 {
+		a.active++
+		a.Unlock()
+		return a.releaser(), nil
+	}
+	if err := a.full(priority); err != nil {
+		a.Unlock()
+		if a.rejected != nil {
+			a.rejected.Add(ctx, 1, metric.WithAttributes(attribute.String("priority", string(priority))))
+		}
+		return nil, err
+	}
+	entry := &admissionEntry{ready: make(chan struct{})}
+	a.queues[rank] = append(a.queues[rank], entry)
+	a.Unlock()
+
+	// Wait for a worker to be handed over, or the context to end
+	start := time.Now()
+	select {
+	case <-entry.ready:
+		if a.waited != nil {
+			a.waited.Record(ctx, time.Since(start).Seconds(), metric.WithAttributes(attribute.String("priority", string(priority))))
+		}
+		return a.releaser(), nil
+	case <-ctx.Done():
+		a.Lock()
+		removed := a.remove(rank, entry)
+		a.Unlock()
+		if !removed {
+			// The worker was handed over as the context ended
+			a.release(0)
+		}
+		return nil, ctx.Err()
+	}
+}
+
+// check returns an error if a request with the priority would be rejected
+func (a *admission) check(priority schema.Priority) error {
+	a.Lock()
+	defer a.Unlock()
+	if a.active < a.workers && a.waiting() == 0 {
+		return nil
+	}
+	return a.full(priority)
+}
+
+// full returns an error if the queue for the priority is full. The lock must
+// be held.
+func (a *admission) full(priority schema.Priority) error {
+	rank := priority.Rank()
+	if len(a.queues[rank]) < a.limits[rank] {
+		return nil
+	}
+	return &schema.RetryAfterError{
+		Err:   schema.ErrTooManyRequests.Withf("%d generations are running and the %s queue is full", a.active, priority),
+		After: a.retryAfter(rank),
+	}
+}
+
+// retryAfter estimates the time until the requests which are waiting with
+// the same or a higher priority have been admitted. The lock must be held.
+func (a *admission) retryAfter(rank int) time.Duration {
+	ahead := 1
+	for _, queue := range a.queues[:rank+1] {
+		ahead += len(queue)
+	}
+	after := a.average * time.Duration(ahead) / time.Duration(a.workers)
+	return min(max(after, minRetryAfter), maxRetryAfter)
+}
+
+// releaser returns a function which releases the worker once
+func (a *admission) releaser() func() {
+	var once sync.Once
+	start := time.Now()
+	return func() {
+		once.Do(func() { a.release(time.Since(start)) })
+	}
+}
+
+// release hands the worker to the first waiting request in priority order,
+// or frees it when no request is waiting. The time the worker was held is
+// added to the moving average, unless it is zero.
+func (a *admission) release(held time.Duration) {
+	a.Lock()
+	defer a.Unlock()
+	if held > 0 {
+		if a.average == 0 {
+			a.average = held
+		} else {
+			a.average = (a.average*7 + held) / 8
+		}
+	}
+	for rank, queue := range a.queues {
+		if len(queue) > 0 {
+			a.queues[rank] = queue[1:]
+			close(queue[0].ready)
+			return
+		}
+	}
+	a.active--
+}
+
+// remove removes a waiting request from its queue, and returns false if it
+// was not waiting. The lock must be held.
+func (a *admission) remove(rank int, entry *admissionEntry) bool {
+	for i, waiting := range a.queues[rank] {
+		if waiting == entry {
+			a.queues[rank] = append(a.queues[rank][:i:i], a.queues[rank][i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// waiting returns the number of waiting requests. The lock must be held.
+func (a *admission) waiting() int {
+	n := 0
+	for _, queue := range a.queues {
+		n += len(queue)
+	}
+	return n
+}
+
+// registerMetrics registers gauges of the running and waiting requests, and
+// instruments for rejected requests and the time waited
+func (a *admission) registerMetrics(meter metric.Meter) error {
+	active, err := meter.Int64ObservableGauge(
+		"llmmanager.admission.active",
+		metric.WithDescription("Number of generations which are running"),
+	)
+	if err != nil {
+		return fmt.Errorf("register llmmanager.admission.active gauge: %w", err)
+	}
+	queued, err := meter.Int64ObservableGauge(
+		"llmmanager.admission.queued",
+		metric.WithDescription("Number of generations which are waiting for a worker, by priority"),
+	)
+	if err != nil {
+		return fmt.Errorf("register llmmanager.admission.queued gauge: %w", err)
+	}
+	if a.rejected, err = meter.Int64Counter(
+		"llmmanager.admission.rejected",
+		metric.WithDescription("Number of generations rejected because the queue for their priority was full"),
+	); err != nil {
+		return fmt.Errorf("register llmmanager.admission.rejected counter: %w", err)
+	}
+	if a.waited, err = meter.Float64Histogram(
+		"llmmanager.admission.wait",
+		metric.WithDescription("Time generations waited for a worker"),
+		metric.WithUnit("s"),
+	); err != nil {
+		return fmt.Errorf("register llmmanager.admission.wait histogram: %w", err)
+	}
+	if _, err := meter.RegisterCallback(func(ctx context.Context, observer metric.Observer) error {
+		a.Lock()
+		defer a.Unlock()
+		observer.ObserveInt64(active, int64(a.active))
+		for rank, queue := range a.queues {
+			observer.ObserveInt64(queued, int64(len(queue)), metric.WithAttributes(attribute.String("priority", string(schema.Priorities[rank]))))
+		}
+		return nil
+	}, active, queued); err != nil {
+		return fmt.Errorf("register llmmanager.admission callback: %w", err)
+	}
+	return nil
+}
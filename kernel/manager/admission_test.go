@@ -0,0 +1,168 @@
+package manager
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	// Packages
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	assert "github.com/stretchr/testify/assert"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// HELPERS
+
+// waitQueued waits until the number of waiting requests is n
+func waitQueued(t *testing.T, a *admission, n int) {
+	t.Helper()
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+		a.Lock()
+		waiting := a.waiting()
+		a.Unlock()
+		if waiting == n {
+			return
+		}
+	}
+	t.Fatalf("timed out waiting for %d queued requests", n)
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// TESTS
+
+func TestAdmissionOptions(t *testing.T) {
+	assert := assert.New(t)
+
+	_, err := newAdmission(0, nil)
+	assert.Error(err)
+	_, err = newAdmission(1, map[schema.Priority]int{"urgent": 1})
+	assert.Error(err)
+	_, err = newAdmission(1, map[schema.Priority]int{schema.PriorityBatch: -1})
+	assert.Error(err)
+}
+
+func TestAdmissionPriority(t *testing.T) {
+	assert := assert.New(t)
+	a, err := newAdmission(1, map[schema.Priority]int{
+		schema.PriorityInteractive: 1,
+		schema.PriorityBatch:       1,
+		schema.PriorityScheduled:   1,
+	})
+	if !assert.NoError(err) {
+		return
+	}
+
+	// Hold the only worker
+	release, err := a.acquire(context.Background(), schema.PriorityInteractive)
+	if !assert.NoError(err) {
+		return
+	}
+
+	// Queue one request of each priority, lowest first
+	order := make(chan schema.Priority, 3)
+	for i, priority := range []schema.Priority{schema.PriorityScheduled, schema.PriorityBatch, schema.PriorityInteractive} {
+		go func() {
+			release, err := a.acquire(context.Background(), priority)
+			if err == nil {
+				order <- priority
+				release()
+			}
+		}()
+		waitQueued(t, a, i+1)
+	}
+
+	// Requests are admitted in priority order
+	release()
+	for _, priority := range schema.Priorities {
+		select {
+		case admitted := <-order:
+			assert.Equal(priority, admitted)
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for admission")
+		}
+	}
+	a.Lock()
+	assert.Equal(0, a.active)
+	a.Unlock()
+}
+
+func TestAdmissionRejected(t *testing.T) {
+	assert := assert.New(t)
+	a, err := newAdmission(1, map[schema.Priority]int{schema.PriorityInteractive: 1})
+	if !assert.NoError(err) {
+		return
+	}
+
+	release, err := a.acquire(context.Background(), schema.PriorityInteractive)
+	if !assert.NoError(err) {
+		return
+	}
+	defer release()
+	go a.acquire(context.Background(), schema.PriorityInteractive)
+	waitQueued(t, a, 1)
+
+	// The queue is full, and there is no queue for batch requests
+	for _, priority := range []schema.Priority{schema.PriorityInteractive, schema.PriorityBatch} {
+		_, err = a.acquire(context.Background(), priority)
+		assert.ErrorIs(err, schema.ErrTooManyRequests)
+		after, ok := schema.RetryAfter(err)
+		assert.True(ok)
+		assert.GreaterOrEqual(after, minRetryAfter)
+		assert.LessOrEqual(after, maxRetryAfter)
+		assert.ErrorIs(a.check(priority), schema.ErrTooManyRequests)
+	}
+}
+
+func TestAdmissionCancelled(t *testing.T) {
+	assert := assert.New(t)
+	a, err := newAdmission(1, map[schema.Priority]int{schema.PriorityInteractive: 1})
+	if !assert.NoError(err) {
+		return
+	}
+
+	release, err := a.acquire(context.Background(), schema.PriorityInteractive)
+	if !assert.NoError(err) {
+		return
+	}
+
+	// A request which is cancelled while waiting leaves the queue
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = a.acquire(ctx, schema.PriorityInteractive)
+	assert.ErrorIs(err, context.DeadlineExceeded)
+	waitQueued(t, a, 0)
+
+	// Releasing twice frees the worker once
+	release()
+	release()
+	a.Lock()
+	assert.Equal(0, a.active)
+	a.Unlock()
+	assert.NoError(a.check(schema.PriorityBatch))
+}
+
+func TestAdmit(t *testing.T) {
+	assert := assert.New(t)
+	a, err := newAdmission(1, nil)
+	if !assert.NoError(err) {
+		return
+	}
+	m := &Manager{manageropt: manageropt{admission: a}}
+
+	// A context which is admitted is not admitted again
+	ctx, release, err := m.Admit(WithPriority(context.Background(), schema.PriorityBatch))
+	if !assert.NoError(err) {
+		return
+	}
+	_, nested, err := m.Admit(ctx)
+	assert.NoError(err)
+	nested()
+
+	// Another request is rejected while the worker is held
+	_, _, err = m.Admit(context.Background())
+	assert.ErrorIs(err, schema.ErrTooManyRequests)
+	release()
+	_, release, err = m.Admit(context.Background())
+	assert.NoError(err)
+	release()
+}
@@ -3,16 +3,22 @@ package manager
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"log/slog"
 	"slices"
+	"strings"
 
 	// Packages
+	uuid "github.com/google/uuid"
 	auth "github.com/mutablelogic/go-auth/auth/schema"
 	otel "github.com/mutablelogic/go-client/pkg/otel"
 	llm "github.com/mutablelogic/go-llm"
 	schema "github.com/mutablelogic/go-llm/kernel/schema"
 	opt "github.com/mutablelogic/go-llm/pkg/opt"
 	toolkit "github.com/mutablelogic/go-llm/toolkit"
+	prompt "github.com/mutablelogic/go-llm/toolkit/prompt"
 	resource "github.com/mutablelogic/go-llm/toolkit/resource"
+	pg "github.com/mutablelogic/go-pg"
 	types "github.com/mutablelogic/go-server/pkg/types"
 	attribute "go.opentelemetry.io/otel/attribute"
 )
@@ -20,8 +26,8 @@ import (
 ///////////////////////////////////////////////////////////////////////////////
 // PUBLIC METHODS
 
-// ListAgents returns paginated prompt metadata from the current toolkit,
-// exposing prompts externally as agents.
+// ListAgents returns paginated prompt metadata from the current toolkit and
+// the agents in the store, exposing prompts externally as agents.
 func (m *Manager) ListAgents(ctx context.Context, req schema.AgentListRequest, user *auth.UserInfo) (result *schema.AgentList, err error) {
 	// Otel span
 	ctx, endSpan := otel.StartSpan(m.tracer, ctx, "ListAgents",
@@ -79,6 +85,75 @@ func (m *Manager) GetAgent(ctx context.Context, name string, user *auth.UserInfo
 	return types.Ptr(meta), nil
 }
 
+// CreateAgent validates and stores a new agent, which is then listed in the
+// builtin namespace alongside the toolkit prompts. Stored agents are shared
+// by all users, so only administrators can create them.
+func (m *Manager) CreateAgent(ctx context.Context, meta schema.AgentMeta, user *auth.UserInfo) (_ *schema.Agent, err error) {
+	// Otel span
+	ctx, endSpan := otel.StartSpan(m.tracer, ctx, "CreateAgent",
+		attribute.String("meta", meta.String()),
+		attribute.String("user", types.Stringify(user)),
+	)
+	defer func() { endSpan(err) }()
+
+	// Check the user, and validate the agent, then store it
+	if !isAdmin(user) {
+		return nil, schema.ErrForbidden.Withf("creating agents requires the %q scope", schema.ScopeAdmin)
+	}
+	if err := m.validateAgent(ctx, meta); err != nil {
+		return nil, err
+	}
+	return m.AgentStore().CreateAgent(ctx, meta, agentUser(user))
+}
+
+// UpdateAgent stores the next version of an agent. When version is non-zero,
+// the update fails with a conflict unless the agent is at that version. Only
+// administrators can update agents.
+func (m *Manager) UpdateAgent(ctx context.Context, name string, version uint, meta schema.AgentMeta, user *auth.UserInfo) (_ *schema.Agent, err error) {
+	// Otel span
+	ctx, endSpan := otel.StartSpan(m.tracer, ctx, "UpdateAgent",
+		attribute.String("name", name),
+		attribute.Int("version", int(version)),
+		attribute.String("meta", meta.String()),
+		attribute.String("user", types.Stringify(user)),
+	)
+	defer func() { endSpan(err) }()
+
+	// Check the user
+	if !isAdmin(user) {
+		return nil, schema.ErrForbidden.Withf("updating agents requires the %q scope", schema.ScopeAdmin)
+	}
+
+	// Validate the agent as it will be after the update
+	store := m.AgentStore()
+	existing, err := store.GetAgent(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.validateAgent(ctx, existing.AgentMeta.MergeFrom(meta)); err != nil {
+		return nil, err
+	}
+
+	// The store checks the version again when it inserts the next one
+	return store.UpdateAgent(ctx, name, version, meta, agentUser(user))
+}
+
+// DeleteAgent removes all versions of an agent from the store. Only
+// administrators can delete agents.
+func (m *Manager) DeleteAgent(ctx context.Context, name string, user *auth.UserInfo) (err error) {
+	// Otel span
+	ctx, endSpan := otel.StartSpan(m.tracer, ctx, "DeleteAgent",
+		attribute.String("name", name),
+		attribute.String("user", types.Stringify(user)),
+	)
+	defer func() { endSpan(err) }()
+
+	if !isAdmin(user) {
+		return schema.ErrForbidden.Withf("deleting agents requires the %q scope", schema.ScopeAdmin)
+	}
+	return m.AgentStore().DeleteAgent(ctx, name)
+}
+
 // CallAgent executes an agent by name with the given input, scoped by the user's accessible namespaces.
 func (m *Manager) CallAgent(ctx context.Context, name string, req schema.CallAgentRequest, user *auth.UserInfo) (result llm.Resource, err error) {
 	// Otel span
@@ -136,6 +211,82 @@ func newAgentMeta(prompt llm.Prompt) schema.AgentMeta {
 	}
 }
 
+// agentTools returns the tool names and patterns which an agent may use, or
+// nil if the agent does not list any
+func agentTools(prompt llm.Prompt) []string {
+	for {
+		switch p := prompt.(type) {
+		case interface{ Tools() []string }:
+			return p.Tools()
+		case interface{ Unwrap() llm.Prompt }:
+			prompt = p.Unwrap()
+		default:
+			return nil
+		}
+	}
+}
+
+// validateAgentTools returns an error if any tool name or pattern of an agent
+// does not match a tool which is registered with the toolkit
+func (m *Manager) validateAgentTools(ctx context.Context, agent string, patterns []string) error {
+	if len(patterns) == 0 {
+		return nil
+	}
+
+	// Collect the names of the registered tools
+	var names []string
+	limit := toolSelectionPageSize
+	req := schema.ToolListRequest{
+		OffsetLimit: pg.OffsetLimit{Limit: &limit},
+	}
+	for {
+		page, _, err := m.listTools(ctx, req, nil)
+		if err != nil {
+			return err
+		}
+		for _, tool := range page {
+			names = append(names, tool.Name())
+		}
+		if len(page) == 0 {
+			break
+		} else {
+			req.Offset += uint64(len(page))
+		}
+	}
+
+	// Each pattern, whether it allows or denies tools, needs to match a tool
+	var result error
+	for _, pattern := range patterns {
+		filter, err := schema.NewToolFilter(strings.TrimPrefix(strings.TrimSpace(pattern), schema.ToolFilterDeny))
+		if err != nil {
+			result = errors.Join(result, err)
+		} else if !slices.ContainsFunc(names, filter.Match) {
+			result = errors.Join(result, schema.ErrBadParameter.Withf("agent %q: unknown tool %q", agent, pattern))
+		}
+	}
+	return result
+}
+
+// checkAgentTools logs a warning for each agent in the toolkit which lists a
+// tool that is not registered. The tools of connectors are only registered
+// once the connectors are connected, so the agents are not rejected, and are
+// checked again when the connectors change.
+func (m *Manager) checkAgentTools(ctx context.Context, logger *slog.Logger) {
+	resp, err := m.Toolkit.List(ctx, toolkit.ListRequest{
+		Type:       toolkit.ListTypePrompts,
+		Namespaces: []string{schema.BuiltinNamespace},
+	})
+	if err != nil {
+		logger.ErrorContext(ctx, "failed to list agents", "error", err.Error())
+		return
+	}
+	for _, prompt := range resp.Prompts {
+		if err := m.validateAgentTools(ctx, prompt.Name(), agentTools(prompt)); err != nil {
+			logger.WarnContext(ctx, "agent lists tools which are not registered", "agent", prompt.Name(), "error", err.Error())
+		}
+	}
+}
+
 func (m *Manager) listAgents(ctx context.Context, req schema.AgentListRequest, user *auth.UserInfo) ([]llm.Prompt, uint, error) {
 	var namespaces []string
 	if user == nil {
@@ -156,22 +307,97 @@ func (m *Manager) listAgents(ctx context.Context, req schema.AgentListRequest, u
 		}
 	}
 
-	listReq := toolkit.ListRequest{
+	resp, err := m.Toolkit.List(ctx, toolkit.ListRequest{
 		Type:       toolkit.ListTypePrompts,
 		Namespaces: namespaces,
 		Name:       req.Name,
-		Offset:     uint(req.Offset),
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// The agents in the store are in the builtin namespace, and replace the
+	// toolkit prompts with the same name
+	prompts := resp.Prompts
+	if len(namespaces) == 0 || slices.Contains(namespaces, schema.BuiltinNamespace) {
+		stored, err := m.storedAgents(ctx, req.Name)
+		if err != nil {
+			return nil, 0, err
+		}
+		prompts = slices.DeleteFunc(prompts, func(p llm.Prompt) bool {
+			return slices.ContainsFunc(stored, func(s llm.Prompt) bool { return s.Name() == p.Name() })
+		})
+		prompts = append(prompts, stored...)
+		slices.SortFunc(prompts, func(a, b llm.Prompt) int {
+			return strings.Compare(a.Name(), b.Name())
+		})
+	}
+
+	// Paginate the prompts
+	count := uint(len(prompts))
+	if req.Offset >= uint64(count) {
+		return nil, count, nil
 	}
+	prompts = prompts[req.Offset:]
 	if req.Limit != nil {
-		listReq.Limit = types.Ptr(uint(types.Value(req.Limit)))
+		if limit := min(types.Value(req.Limit), schema.AgentListMax); limit < uint64(len(prompts)) {
+			prompts = prompts[:limit]
+		}
 	}
 
-	resp, err := m.Toolkit.List(ctx, listReq)
-	if err != nil {
-		return nil, 0, err
+	return prompts, count, nil
+}
+
+// storedAgents returns the latest version of each agent in the store as a
+// prompt in the builtin namespace, filtered by qualified or bare name
+func (m *Manager) storedAgents(ctx context.Context, names []string) ([]llm.Prompt, error) {
+	if m.PoolConn == nil {
+		return nil, nil
+	}
+
+	var result []llm.Prompt
+	store := m.AgentStore()
+	req := schema.ListAgentRequest{Limit: types.Ptr(uint(schema.AgentListMax))}
+	for {
+		page, err := store.ListAgents(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		for _, agent := range page.Body {
+			qualified := schema.BuiltinNamespace + "." + agent.Name
+			if len(names) > 0 && !slices.Contains(names, qualified) && !slices.Contains(names, agent.Name) {
+				continue
+			}
+			p, err := prompt.New(agent.AgentMeta)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, prompt.WithNamespace(schema.BuiltinNamespace, p))
+		}
+		req.Offset += uint(len(page.Body))
+		if len(page.Body) == 0 || req.Offset >= page.Count {
+			break
+		}
 	}
+	return result, nil
+}
 
-	return resp.Prompts, resp.Count, nil
+// agentUser returns the user recorded with a version of an agent, which is
+// nil when the agent is stored without a user
+func agentUser(user *auth.UserInfo) uuid.UUID {
+	if user == nil {
+		return uuid.Nil
+	}
+	return uuid.UUID(user.Sub)
+}
+
+// validateAgent returns an error if an agent is not a valid prompt, or uses
+// tools which are not registered
+func (m *Manager) validateAgent(ctx context.Context, meta schema.AgentMeta) error {
+	if _, err := prompt.New(meta); err != nil {
+		return err
+	}
+	return m.validateAgentTools(ctx, meta.Name, meta.Tools)
 }
 
 func (m *Manager) runAgent(ctx context.Context, prompt llm.Prompt, content string, opts []opt.Opt, resources ...llm.Resource) (_ llm.Resource, err error) {
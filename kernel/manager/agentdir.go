@@ -0,0 +1,85 @@
+package manager
+
+import (
+	"errors"
+	"time"
+
+	// Packages
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	prompt "github.com/mutablelogic/go-llm/toolkit/prompt"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// agentDir is a directory of agent definitions, and the names of the agents
+// which have been loaded from it. Only those agents are replaced or removed
+// when the directory changes, so that embedded agents are left alone.
+type agentDir struct {
+	*prompt.Dir
+	loaded map[string]struct{}
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+// How often the agent directory is re-scanned for changes
+const agentDirInterval = 5 * time.Second
+
+///////////////////////////////////////////////////////////////////////////////
+// LIFECYCLE
+
+func newAgentDir(path string) *agentDir {
+	return &agentDir{
+		Dir:    prompt.NewDir(path),
+		loaded: make(map[string]struct{}),
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// syncAgentDir re-scans the agent directory and applies any changes to the
+// builtin prompts of the toolkit. Changed agents are replaced, and agents
+// whose files have been removed are removed. An agent whose name is already
+// used by a prompt which was not loaded from the directory is rejected. The
+// names of the added or updated agents and the removed agents are returned.
+func (m *Manager) syncAgentDir(dir *agentDir) ([]string, []string, error) {
+	add, remove, result := dir.Scan()
+
+	// Remove agents whose files have gone
+	removed := make([]string, 0, len(remove))
+	for _, name := range remove {
+		if _, exists := dir.loaded[name]; !exists {
+			continue
+		}
+		if err := m.Toolkit.RemoveBuiltin(name); err != nil && !errors.Is(err, schema.ErrNotFound) {
+			result = errors.Join(result, err)
+			continue
+		}
+		delete(dir.loaded, name)
+		removed = append(removed, name)
+	}
+
+	// Add new agents, and replace changed agents
+	added := make([]string, 0, len(add))
+	for _, p := range add {
+		name := p.Name()
+		if _, exists := dir.loaded[name]; exists {
+			if err := m.Toolkit.RemoveBuiltin(name); err != nil && !errors.Is(err, schema.ErrNotFound) {
+				result = errors.Join(result, err)
+				continue
+			}
+			delete(dir.loaded, name)
+		}
+		if err := m.Toolkit.AddPrompt(p); err != nil {
+			result = errors.Join(result, schema.ErrConflict.Withf("agent %q in %q: %v", name, dir.Path(), err))
+			continue
+		}
+		dir.loaded[name] = struct{}{}
+		added = append(added, name)
+	}
+
+	// Return any errors
+	return added, removed, result
+}
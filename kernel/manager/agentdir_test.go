@@ -0,0 +1,129 @@
+package manager
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	// Packages
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	toolkit "github.com/mutablelogic/go-llm/toolkit"
+	prompt "github.com/mutablelogic/go-llm/toolkit/prompt"
+	assert "github.com/stretchr/testify/assert"
+)
+
+func TestSyncAgentDirAddsUpdatesAndRemoves(t *testing.T) {
+	assert := assert.New(t)
+	tk, err := toolkit.New()
+	if !assert.NoError(err) {
+		return
+	}
+	m := &Manager{Toolkit: tk}
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "greet.md")
+	now := time.Now()
+	if !assert.NoError(os.WriteFile(path, []byte("# Greet\nHello"), 0o600)) {
+		return
+	}
+	agents := newAgentDir(dir)
+
+	// Initial load
+	added, removed, err := m.syncAgentDir(agents)
+	assert.NoError(err)
+	assert.Equal([]string{"greet"}, added)
+	assert.Empty(removed)
+	_, err = tk.Lookup(context.Background(), "greet")
+	assert.NoError(err)
+
+	// Update replaces the existing agent
+	assert.NoError(os.WriteFile(path, []byte("# Greet\nHello again"), 0o600))
+	assert.NoError(os.Chtimes(path, now.Add(time.Second), now.Add(time.Second)))
+	added, _, err = m.syncAgentDir(agents)
+	assert.NoError(err)
+	assert.Equal([]string{"greet"}, added)
+
+	// Removing the file removes the agent
+	assert.NoError(os.Remove(path))
+	_, removed, err = m.syncAgentDir(agents)
+	assert.NoError(err)
+	assert.Equal([]string{"greet"}, removed)
+	_, err = tk.Lookup(context.Background(), "greet")
+	assert.Error(err)
+}
+
+func TestSyncAgentDirKeepsEmbeddedAgents(t *testing.T) {
+	assert := assert.New(t)
+	tk, err := toolkit.New()
+	if !assert.NoError(err) {
+		return
+	}
+	m := &Manager{Toolkit: tk}
+
+	// An embedded agent with the same name as a file in the directory
+	embedded, err := prompt.Read(strings.NewReader("---\nname: greet\n---\nEmbedded"))
+	if !assert.NoError(err) {
+		return
+	}
+	assert.NoError(tk.AddPrompt(embedded))
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "greet.md")
+	if !assert.NoError(os.WriteFile(path, []byte("# Greet\nHello"), 0o600)) {
+		return
+	}
+	agents := newAgentDir(dir)
+
+	// The file which collides with the embedded agent is rejected
+	added, _, err := m.syncAgentDir(agents)
+	assert.ErrorIs(err, schema.ErrConflict)
+	assert.Empty(added)
+
+	// Removing the file leaves the embedded agent in place
+	assert.NoError(os.Remove(path))
+	_, removed, err := m.syncAgentDir(agents)
+	assert.NoError(err)
+	assert.Empty(removed)
+	_, err = tk.Lookup(context.Background(), "greet")
+	assert.NoError(err)
+}
+
+func TestSyncAgentDirWarnsUnknownTools(t *testing.T) {
+	assert := assert.New(t)
+	tk, err := toolkit.New(toolkit.WithTool(&listToolsMockTool{name: "fs_read"}, &listToolsMockTool{name: "fs_write"}))
+	if !assert.NoError(err) {
+		return
+	}
+	m := &Manager{Toolkit: tk}
+
+	dir := t.TempDir()
+	assert.NoError(os.WriteFile(filepath.Join(dir, "reader.md"), []byte("---\ntools: [\"fs_*\", \"!fs_write\"]\n---\nRead"), 0o600))
+	assert.NoError(os.WriteFile(filepath.Join(dir, "writer.md"), []byte("---\ntools: [\"weather.*\"]\n---\nWrite"), 0o600))
+
+	// Both agents are loaded, since the tools of a connector may not be
+	// registered yet
+	added, _, err := m.syncAgentDir(newAgentDir(dir))
+	assert.NoError(err)
+	assert.ElementsMatch([]string{"reader", "writer"}, added)
+
+	// Only the agent which lists an unknown tool is warned about
+	var buf bytes.Buffer
+	m.checkAgentTools(context.Background(), slog.New(slog.NewTextHandler(&buf, nil)))
+	assert.Contains(buf.String(), "agent=builtin.writer")
+	assert.Contains(buf.String(), "weather.*")
+	assert.NotContains(buf.String(), "agent=builtin.reader")
+}
+
+func TestWithAgentDirRequiresDirectory(t *testing.T) {
+	assert := assert.New(t)
+	var o manageropt
+	assert.Error(WithAgentDir("")(&o))
+	assert.Error(WithAgentDir(filepath.Join(t.TempDir(), "missing"))(&o))
+	assert.NoError(WithAgentDir(t.TempDir())(&o))
+	assert.NotEmpty(o.agentdir)
+}
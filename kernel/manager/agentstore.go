@@ -0,0 +1,119 @@
+package manager
+
+import (
+	"context"
+	"errors"
+	"reflect"
+
+	// Packages
+	uuid "github.com/google/uuid"
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	pg "github.com/mutablelogic/go-pg"
+	types "github.com/mutablelogic/go-server/pkg/types"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// agentStore keeps the versions of agents in the database, so that they are
+// shared by the replicas of a deployment. Each update inserts a new version,
+// and fails when another replica has inserted the same version first.
+type agentStore struct {
+	conn pg.Conn
+}
+
+var _ schema.AgentStore = (*agentStore)(nil)
+
+///////////////////////////////////////////////////////////////////////////////
+// LIFECYCLE
+
+// AgentStore returns the store for agents, which is backed by the database
+func (m *Manager) AgentStore() schema.AgentStore {
+	return &agentStore{conn: m.PoolConn}
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+func (s *agentStore) CreateAgent(ctx context.Context, meta schema.AgentMeta, user uuid.UUID) (*schema.Agent, error) {
+	var agent schema.Agent
+	if err := s.conn.Insert(ctx, &agent, schema.AgentInsert{AgentMeta: meta, Version: 1, User: user}); errors.Is(err, pg.ErrUniqueViolation) {
+		return nil, schema.ErrConflict.Withf("agent %q already exists", meta.Name)
+	} else if err != nil {
+		return nil, pg.NormalizeError(err)
+	}
+	return &agent, nil
+}
+
+func (s *agentStore) GetAgent(ctx context.Context, id string) (*schema.Agent, error) {
+	var agent schema.Agent
+	if err := s.conn.Get(ctx, &agent, schema.AgentSelector(id)); errors.Is(err, pg.ErrNotFound) {
+		return nil, schema.ErrNotFound.Withf("agent %q", id)
+	} else if err != nil {
+		return nil, pg.NormalizeError(err)
+	}
+	return &agent, nil
+}
+
+func (s *agentStore) ListAgents(ctx context.Context, req schema.ListAgentRequest) (*schema.ListAgentResponse, error) {
+	var list schema.ListAgentResponse
+	if err := s.conn.List(ctx, &list, req); err != nil {
+		return nil, pg.NormalizeError(err)
+	}
+	list.Offset = req.Offset
+	list.Limit = types.Ptr(uint(schema.AgentListMax))
+	if req.Limit != nil {
+		list.Limit = types.Ptr(min(*req.Limit, schema.AgentListMax))
+	}
+	return &list, nil
+}
+
+func (s *agentStore) DeleteAgent(ctx context.Context, id string) error {
+	var agent schema.Agent
+	if err := s.conn.Delete(ctx, &agent, schema.AgentSelector(id)); errors.Is(err, pg.ErrNotFound) {
+		return schema.ErrNotFound.Withf("agent %q", id)
+	} else if err != nil {
+		return pg.NormalizeError(err)
+	}
+	return nil
+}
+
+// UpdateAgent inserts the next version of an agent, unless nothing changed.
+// When the agent is not at the expected version, or another replica inserts
+// the next version first, the update fails with a conflict and should be
+// retried with the latest version.
+func (s *agentStore) UpdateAgent(ctx context.Context, id string, version uint, meta schema.AgentMeta, user uuid.UUID) (*schema.Agent, error) {
+	var result schema.Agent
+	if err := s.conn.Tx(ctx, func(conn pg.Conn) error {
+		var existing schema.Agent
+		if err := conn.Get(ctx, &existing, schema.AgentSelector(id)); errors.Is(err, pg.ErrNotFound) {
+			return schema.ErrNotFound.Withf("agent %q", id)
+		} else if err != nil {
+			return err
+		}
+		if version != 0 && version != existing.Version {
+			return schema.ErrConflict.Withf("agent %q is at version %d, not version %d", existing.Name, existing.Version, version)
+		}
+		if meta.Name != "" && meta.Name != existing.Name {
+			return schema.ErrBadParameter.Withf("agent %q cannot be renamed", existing.Name)
+		}
+
+		// Return the existing version when nothing changed
+		merged := existing.AgentMeta.MergeFrom(meta)
+		if reflect.DeepEqual(merged, existing.AgentMeta) {
+			result = existing
+			return nil
+		}
+
+		// Insert the next version
+		if err := conn.Insert(ctx, &result, schema.AgentInsert{AgentMeta: merged, Version: existing.Version + 1, User: user}); errors.Is(err, pg.ErrUniqueViolation) {
+			return schema.ErrConflict.Withf("agent %q has been updated since version %d", existing.Name, existing.Version)
+		} else if err != nil {
+			return err
+		}
+		return nil
+	}); err != nil {
+		return nil, pg.NormalizeError(err)
+	}
+	return &result, nil
+}
@@ -0,0 +1,65 @@
+package manager
+
+import (
+	"testing"
+
+	// Packages
+	uuid "github.com/google/uuid"
+	auth "github.com/mutablelogic/go-auth/auth/schema"
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	llmtest "github.com/mutablelogic/go-llm/pkg/test"
+	assert "github.com/stretchr/testify/assert"
+)
+
+func TestAgentStoreVersionsIntegration(t *testing.T) {
+	_, m := newIntegrationManager(t)
+	ctx := llmtest.Context(t)
+	if err := m.Exec(ctx, `TRUNCATE llm.agent`); !assert.NoError(t, err) {
+		return
+	}
+
+	// Create an agent, which is listed in the builtin namespace
+	agent, err := m.CreateAgent(ctx, schema.AgentMeta{Name: "translate", Template: "# Translate\n\nTranslate into French."}, nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, uint(1), agent.Version)
+	meta, err := m.GetAgent(ctx, "translate", nil)
+	if assert.NoError(t, err) {
+		assert.Equal(t, "builtin.translate", meta.Name)
+		assert.Equal(t, "Translate", meta.Title)
+	}
+
+	// Update the agent at the expected version
+	agent, err = m.UpdateAgent(ctx, "translate", 1, schema.AgentMeta{Title: "French"}, nil)
+	if assert.NoError(t, err) {
+		assert.Equal(t, uint(2), agent.Version)
+	}
+	meta, err = m.GetAgent(ctx, "builtin.translate", nil)
+	if assert.NoError(t, err) {
+		assert.Equal(t, "French", meta.Title)
+	}
+
+	// An update at a stale version is a conflict
+	_, err = m.UpdateAgent(ctx, "translate", 1, schema.AgentMeta{Title: "German"}, nil)
+	assert.ErrorIs(t, err, schema.ErrConflict)
+
+	// Users without the admin scope cannot change agents
+	user := &auth.UserInfo{Sub: auth.UserID(uuid.New())}
+	_, err = m.CreateAgent(ctx, schema.AgentMeta{Name: "summarize"}, user)
+	assert.ErrorIs(t, err, schema.ErrForbidden)
+	_, err = m.UpdateAgent(ctx, "translate", 0, schema.AgentMeta{Title: "German"}, user)
+	assert.ErrorIs(t, err, schema.ErrForbidden)
+	assert.ErrorIs(t, m.DeleteAgent(ctx, "translate", user), schema.ErrForbidden)
+
+	// The latest version of each agent is listed
+	list, err := m.AgentStore().ListAgents(ctx, schema.ListAgentRequest{})
+	if assert.NoError(t, err) && assert.Len(t, list.Body, 1) {
+		assert.Equal(t, uint(2), list.Body[0].Version)
+	}
+
+	// Deleting the agent removes it from the list of agents
+	assert.NoError(t, m.DeleteAgent(ctx, "translate", nil))
+	_, err = m.GetAgent(ctx, "translate", nil)
+	assert.ErrorIs(t, err, schema.ErrNotFound)
+}
@@ -0,0 +1,108 @@
+package manager
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	// Packages
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	httpresponse "github.com/mutablelogic/go-server/pkg/httpresponse"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// modelAlias maps a model name to the model which is used in its place
+type modelAlias struct {
+	target     string
+	deprecated bool // true if the name has been retired by the provider
+	successor  bool // true if the target is only used once the provider rejects the name
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+// Phrases in provider errors which report that a model is not available
+var modelUnavailablePhrases = []string{
+	"not found", "does not exist", "deprecated", "decommissioned", "retired", "no longer", "invalid model",
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// resolveModel returns the model to use for a name, following aliases, and
+// a warning when a deprecated name was used
+func (m *Manager) resolveModel(name string) (string, string) {
+	var deprecated []string
+	seen := make(map[string]bool, len(m.aliases))
+	for !seen[name] {
+		seen[name] = true
+		alias, exists := m.aliases[name]
+		if !exists || alias.successor {
+			break
+		}
+		if alias.deprecated {
+			deprecated = append(deprecated, name)
+		}
+		name = alias.target
+	}
+	if len(deprecated) == 0 {
+		return name, ""
+	}
+	return name, fmt.Sprintf("model %q is deprecated; use %q instead", strings.Join(deprecated, `", "`), name)
+}
+
+// modelSuccessor returns the successor of a model when the error reports that
+// the provider does not have the model, or the circuit for the provider is
+// open, and a warning about the substitution. An empty name is returned when
+// the request should not be retried.
+func (m *Manager) modelSuccessor(name string, err error) (string, string) {
+	if !modelUnavailable(err) && !providerUnavailable(err) {
+		return "", ""
+	}
+	name, _ = m.resolveModel(name)
+	alias, exists := m.aliases[name]
+	if !exists || !alias.successor {
+		return "", ""
+	}
+	successor, _ := m.resolveModel(alias.target)
+	if successor == name {
+		return "", ""
+	}
+	return successor, fmt.Sprintf("model %q is not available; used %q instead", name, successor)
+}
+
+// modelAliases returns the names which resolve to a model, in order
+func (m *Manager) modelAliases(name string) []string {
+	var result []string
+	for alias := range m.aliases {
+		if target, _ := m.resolveModel(alias); target == name {
+			result = append(result, alias)
+		}
+	}
+	slices.Sort(result)
+	return result
+}
+
+// modelUnavailable returns true if an error from a provider reports that a
+// model does not exist or has been retired
+func modelUnavailable(err error) bool {
+	if err == nil {
+		return false
+	} else if errors.Is(err, httpresponse.ErrNotFound) || errors.Is(err, httpresponse.Err(http.StatusGone)) || errors.Is(err, schema.ErrNotFound) {
+		return true
+	}
+	message := strings.ToLower(err.Error())
+	if !strings.Contains(message, "model") {
+		return false
+	}
+	for _, phrase := range modelUnavailablePhrases {
+		if strings.Contains(message, phrase) {
+			return true
+		}
+	}
+	return false
+}
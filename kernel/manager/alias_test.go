@@ -0,0 +1,96 @@
+package manager
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	// Packages
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	httpresponse "github.com/mutablelogic/go-server/pkg/httpresponse"
+	assert "github.com/stretchr/testify/assert"
+)
+
+func TestResolveModel(t *testing.T) {
+	assert := assert.New(t)
+	var o manageropt
+	o.defaults("test", "0")
+	assert.NoError(o.apply(
+		WithModelAlias("claude-latest", "claude-sonnet"),
+		WithModelDeprecation("claude-2", "claude-latest"),
+	))
+	m := &Manager{manageropt: o}
+
+	name, warning := m.resolveModel("claude-latest")
+	assert.Equal("claude-sonnet", name)
+	assert.Empty(warning)
+
+	name, warning = m.resolveModel("claude-2")
+	assert.Equal("claude-sonnet", name)
+	assert.Equal(`model "claude-2" is deprecated; use "claude-sonnet" instead`, warning)
+
+	name, warning = m.resolveModel("gpt-4o")
+	assert.Equal("gpt-4o", name)
+	assert.Empty(warning)
+
+	assert.Equal([]string{"claude-2", "claude-latest"}, m.modelAliases("claude-sonnet"))
+}
+
+func TestResolveModelCycle(t *testing.T) {
+	var o manageropt
+	o.defaults("test", "0")
+	assert.NoError(t, o.apply(WithModelAlias("a", "b"), WithModelAlias("b", "a")))
+	m := &Manager{manageropt: o}
+
+	name, _ := m.resolveModel("a")
+	assert.Equal(t, "a", name)
+}
+
+func TestModelAliasOptions(t *testing.T) {
+	var o manageropt
+	o.defaults("test", "0")
+	assert.Error(t, o.apply(WithModelAlias("", "b")))
+	assert.Error(t, o.apply(WithModelAlias("a", "a")))
+	assert.NoError(t, o.apply(WithModelAlias("a", "b")))
+	assert.Error(t, o.apply(WithModelDeprecation("a", "c")))
+}
+
+func TestModelSuccessor(t *testing.T) {
+	assert := assert.New(t)
+	var o manageropt
+	o.defaults("test", "0")
+	assert.NoError(o.apply(
+		WithModelAlias("claude-latest", "claude-sonnet"),
+		WithModelSuccessor("claude-3-opus", "claude-latest"),
+	))
+	m := &Manager{manageropt: o}
+
+	// The model is used until the provider rejects it
+	name, warning := m.resolveModel("claude-3-opus")
+	assert.Equal("claude-3-opus", name)
+	assert.Empty(warning)
+	assert.Equal([]string{"claude-latest"}, m.modelAliases("claude-sonnet"))
+
+	successor, warning := m.modelSuccessor("claude-3-opus", httpresponse.ErrNotFound.With("model: claude-3-opus"))
+	assert.Equal("claude-sonnet", successor)
+	assert.Equal(`model "claude-3-opus" is not available; used "claude-sonnet" instead`, warning)
+
+	// Other errors, and models without a successor, are not retried
+	successor, _ = m.modelSuccessor("claude-3-opus", httpresponse.ErrNotAuthorized)
+	assert.Empty(successor)
+	successor, _ = m.modelSuccessor("claude-3-opus", nil)
+	assert.Empty(successor)
+	successor, _ = m.modelSuccessor("claude-sonnet", httpresponse.ErrNotFound)
+	assert.Empty(successor)
+}
+
+func TestModelUnavailable(t *testing.T) {
+	assert := assert.New(t)
+	assert.False(modelUnavailable(nil))
+	assert.True(modelUnavailable(httpresponse.ErrNotFound.With("not_found_error")))
+	assert.True(modelUnavailable(httpresponse.Err(http.StatusGone)))
+	assert.True(modelUnavailable(schema.ErrNotFound.Withf("model %q not found", "gpt-4")))
+	assert.True(modelUnavailable(httpresponse.ErrBadRequest.With(`The model "mixtral-8x7b" has been decommissioned`)))
+	assert.False(modelUnavailable(httpresponse.ErrBadRequest.With("max_tokens is too large")))
+	assert.False(modelUnavailable(errors.New("connection refused")))
+}
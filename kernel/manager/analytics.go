@@ -0,0 +1,98 @@
+package manager
+
+import (
+	"context"
+
+	// Packages
+	uuid "github.com/google/uuid"
+	auth "github.com/mutablelogic/go-auth/auth/schema"
+	otel "github.com/mutablelogic/go-client/pkg/otel"
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	pg "github.com/mutablelogic/go-pg"
+	types "github.com/mutablelogic/go-server/pkg/types"
+	attribute "go.opentelemetry.io/otel/attribute"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// UsageAnalytics returns the number of requests and tokens in each time
+// bucket of the period, by usage type. If user is non-nil, only usage by
+// that user is counted.
+func (m *Manager) UsageAnalytics(ctx context.Context, req schema.AnalyticsRequest, user *auth.UserInfo) (_ *schema.UsageAnalytics, err error) {
+	ctx, endSpan := otel.StartSpan(m.tracer, ctx, "UsageAnalytics",
+		attribute.String("req", req.String()),
+		attribute.String("user", types.Stringify(user)),
+	)
+	defer func() { endSpan(err) }()
+
+	return m.usageAnalytics(ctx, req, user, func(req schema.AnalyticsRequest) pg.Selector {
+		return schema.UsageAnalyticsSelector(req)
+	})
+}
+
+// ModelAnalytics returns the number of requests and tokens in each time
+// bucket of the period, by provider and model, with the most used models
+// first. If user is non-nil, only usage by that user is counted.
+func (m *Manager) ModelAnalytics(ctx context.Context, req schema.AnalyticsRequest, user *auth.UserInfo) (_ *schema.UsageAnalytics, err error) {
+	ctx, endSpan := otel.StartSpan(m.tracer, ctx, "ModelAnalytics",
+		attribute.String("req", req.String()),
+		attribute.String("user", types.Stringify(user)),
+	)
+	defer func() { endSpan(err) }()
+
+	return m.usageAnalytics(ctx, req, user, func(req schema.AnalyticsRequest) pg.Selector {
+		return schema.ModelAnalyticsSelector(req)
+	})
+}
+
+// ToolAnalytics returns the number of results and errors for each tool in
+// each time bucket of the period, with the most used tools first. If user
+// is non-nil, only tools used in sessions owned by that user are counted.
+func (m *Manager) ToolAnalytics(ctx context.Context, req schema.AnalyticsRequest, user *auth.UserInfo) (_ *schema.ToolAnalytics, err error) {
+	ctx, endSpan := otel.StartSpan(m.tracer, ctx, "ToolAnalytics",
+		attribute.String("req", req.String()),
+		attribute.String("user", types.Stringify(user)),
+	)
+	defer func() { endSpan(err) }()
+
+	req, err = req.Resolve(m.clock.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	result := schema.ToolAnalytics{AnalyticsRequest: req, Body: []schema.ToolBucket{}}
+	if err := analyticsConn(m.PoolConn, user).List(ctx, &result, schema.ToolAnalyticsSelector(req)); err != nil {
+		return nil, pg.NormalizeError(err)
+	}
+
+	// Return success
+	return types.Ptr(result), nil
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+func (m *Manager) usageAnalytics(ctx context.Context, req schema.AnalyticsRequest, user *auth.UserInfo, selector func(schema.AnalyticsRequest) pg.Selector) (*schema.UsageAnalytics, error) {
+	req, err := req.Resolve(m.clock.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	result := schema.UsageAnalytics{AnalyticsRequest: req, Body: []schema.UsageBucket{}}
+	if err := analyticsConn(m.PoolConn, user).List(ctx, &result, selector(req)); err != nil {
+		return nil, pg.NormalizeError(err)
+	}
+
+	// Return success
+	return types.Ptr(result), nil
+}
+
+// analyticsConn returns a connection which counts only the records of the
+// user, if the user is non-nil
+func analyticsConn(conn pg.Conn, user *auth.UserInfo) pg.Conn {
+	if user != nil {
+		return conn.With("user", uuid.UUID(user.Sub))
+	}
+	return conn
+}
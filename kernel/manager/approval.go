@@ -0,0 +1,58 @@
+package manager
+
+import (
+	"context"
+
+	// Packages
+	uuid "github.com/google/uuid"
+	llm "github.com/mutablelogic/go-llm"
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// ToolApprovalFn is called before a tool call in a chat turn is run. When it
+// returns an error the tool is not run, and the error is returned to the
+// model as the result of the call.
+type ToolApprovalFn func(ctx context.Context, session uuid.UUID, call schema.ToolCall) error
+
+type toolApprovalKey struct{}
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// WithToolApproval returns a context which requires each tool call made by
+// Chat to be approved by fn, for example by asking the user in a chat client.
+// Built-in tools which change things outside the server, such as the tool
+// which creates calendar events, are only run when fn is set.
+func WithToolApproval(ctx context.Context, fn ToolApprovalFn) context.Context {
+	if fn == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, toolApprovalKey{}, fn)
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// addTools adds built-in tools, and marks the tools which are not read-only
+// as requiring approval, so they are only run when an approval function is
+// set with WithToolApproval
+func (m *Manager) addTools(tools ...llm.Tool) {
+	for _, tool := range tools {
+		if !tool.Meta().ReadOnlyHint {
+			m.approval[tool.Name()] = true
+		}
+	}
+	m.tools = append(m.tools, tools...)
+}
+
+// toolApprovalFromContext returns the approval function for tool calls, or
+// nil if tool calls do not need approval
+func toolApprovalFromContext(ctx context.Context) ToolApprovalFn {
+	if fn, ok := ctx.Value(toolApprovalKey{}).(ToolApprovalFn); ok {
+		return fn
+	}
+	return nil
+}
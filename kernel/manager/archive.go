@@ -0,0 +1,99 @@
+package manager
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	// Packages
+	uuid "github.com/google/uuid"
+	auth "github.com/mutablelogic/go-auth/auth/schema"
+	otel "github.com/mutablelogic/go-client/pkg/otel"
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	pg "github.com/mutablelogic/go-pg"
+	types "github.com/mutablelogic/go-server/pkg/types"
+	attribute "go.opentelemetry.io/otel/attribute"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// GetArchive returns an archived request and response by request ID, and
+// verifies the hash of the record and its link to the previous record. If
+// user is non-nil, the request must have been made by that user.
+func (m *Manager) GetArchive(ctx context.Context, id uuid.UUID, user *auth.UserInfo) (_ *schema.Archive, err error) {
+	// OTel span
+	ctx, endSpan := otel.StartSpan(m.tracer, ctx, "GetArchive",
+		attribute.String("id", id.String()),
+		attribute.String("user", types.Stringify(user)),
+	)
+	defer func() { endSpan(err) }()
+
+	// Get the record
+	var record schema.Archive
+	if err := m.PoolConn.With("user", uuid.UUID(user.Sub)).Get(ctx, &record, schema.ArchiveIDSelector(id)); err != nil {
+		if errors.Is(err, pg.ErrNotFound) {
+			return nil, schema.ErrNotFound.Withf("request %q not found in archive", id)
+		}
+		return nil, pg.NormalizeError(err)
+	}
+
+	// Verify the record against the one before it
+	var prev schema.Archive
+	if err := m.PoolConn.Get(ctx, &prev, schema.ArchiveBeforeSelector(record.Seq)); errors.Is(err, pg.ErrNotFound) {
+		record.Verified = record.PrevHash == "" && record.Hash == record.Digest()
+	} else if err != nil {
+		return nil, pg.NormalizeError(err)
+	} else {
+		record.Verified = record.PrevHash == prev.Hash && record.Hash == record.Digest()
+	}
+
+	// Return success
+	return types.Ptr(record), nil
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// archive appends a request and its response to the archive when archiving
+// is enabled, and returns the request ID of the record. Records are appended
+// one at a time, so that each is chained to the last.
+func (m *Manager) archive(ctx context.Context, insert schema.ArchiveInsert, request, response any) (uuid.UUID, error) {
+	if !m.archiving {
+		return uuid.Nil, nil
+	}
+
+	// Encode the request and response as they were sent and returned
+	var err error
+	if insert.Request, err = json.Marshal(request); err != nil {
+		return uuid.Nil, err
+	} else if insert.Response, err = json.Marshal(response); err != nil {
+		return uuid.Nil, err
+	}
+
+	// Timestamps are stored with microsecond precision, so truncate before
+	// the record is hashed
+	record := schema.Archive{
+		ID:            m.ids.New(),
+		ArchiveInsert: insert,
+		CreatedAt:     m.clock.Now().UTC().Truncate(time.Microsecond),
+	}
+	if err := m.PoolConn.With("key", "archive").Tx(ctx, func(conn pg.Conn) error {
+		if err := conn.Exec(ctx, advisoryLockQuery); err != nil {
+			return err
+		}
+		var last schema.Archive
+		if err := conn.Get(ctx, &last, schema.ArchiveBeforeSelector(0)); err != nil && !errors.Is(err, pg.ErrNotFound) {
+			return err
+		}
+		record.PrevHash = last.Hash
+		record.Hash = record.Digest()
+		return conn.Insert(ctx, nil, record)
+	}); err != nil {
+		return uuid.Nil, pg.NormalizeError(err)
+	}
+
+	// Return success
+	return record.ID, nil
+}
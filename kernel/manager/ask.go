@@ -3,6 +3,9 @@ package manager
 import (
 	"context"
 	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
 
 	// Packages
 	uuid "github.com/google/uuid"
@@ -33,6 +36,13 @@ const (
 
 // Ask processes a message and returns a response, outside of a session context (stateless).
 // If fn is non-nil, text chunks are streamed to the callback as they arrive.
+// When the request has cascade tiers, a reply which is refused, truncated or
+// does not match the output format is retried with each tier in turn, and
+// only the last tier is streamed. When the provider reports that a model does
+// not exist or has been retired, the request is retried once with the
+// successor set with WithModelSuccessor. When the request has a draft model,
+// the reply is drafted with it, and the model keeps the paragraphs of the
+// draft which it accepts and continues from them.
 func (m *Manager) Ask(ctx context.Context, request schema.AskRequest, user *auth.UserInfo, fn opt.StreamFn) (_ *schema.AskResponse, err error) {
 	// Otel span
 	ctx, endSpan := otel.StartSpan(m.tracer, ctx, "Ask",
@@ -41,21 +51,73 @@ func (m *Manager) Ask(ctx context.Context, request schema.AskRequest, user *auth
 	)
 	defer func() { endSpan(err) }()
 
-	// Resolve model, generator, and options from the request meta
-	provider, model, generator, opts, err := m.generatorFromMeta(ctx, request.GeneratorMeta, user, generationContextAsk)
+	// Wait for a worker to run the generation
+	ctx, release, err := m.Admit(ctx)
 	if err != nil {
 		return nil, err
 	}
+	defer release()
 
-	// Enable streaming when a callback is provided
-	if fn != nil {
-		opts = append(opts, opt.WithStream(fn))
+	// Score the input for jailbreak attempts, hardening the system prompt
+	score, prompt, err := m.screenJailbreak(ctx, request.Text, request.SystemPrompt, user)
+	if err != nil {
+		return nil, err
+	}
+	request.SystemPrompt = prompt
+	if err := m.auditJailbreak(ctx, score, request.Text, uuid.Nil, user); err != nil {
+		return nil, err
+	}
+
+	// Try each tier in turn, keeping the reasons for escalating
+	tiers := askTiers(request)
+	var warnings []string
+	for tier, meta := range tiers {
+		last := tier == len(tiers)-1
+		var tierFn opt.StreamFn
+		if last {
+			tierFn = fn
+		}
+		response, err := m.ask(ctx, request, meta, user, tierFn)
+		if successor, warning := m.modelSuccessor(types.Value(meta.Model), err); successor != "" {
+			// Retry once with the successor of a model which the provider does not have
+			meta.Model = types.Ptr(successor)
+			if response, err = m.ask(ctx, request, meta, user, tierFn); err == nil {
+				response.Warnings = append([]string{warning}, response.Warnings...)
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+		if reason := cascadeReason(meta, response); reason != "" && !last {
+			warnings = append(warnings, fmt.Sprintf("escalated from model %q: %s", types.Value(meta.Model), reason))
+			continue
+		}
+		response.Tier = uint(tier)
+		response.Warnings = append(warnings, response.Warnings...)
+		return response, nil
+	}
+
+	// Not reached, since there is always at least one tier
+	return nil, schema.ErrInternalServerError.With("no model to ask")
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// ask sends the message to the model resolved from the meta, records the
+// usage and returns the response
+func (m *Manager) ask(ctx context.Context, request schema.AskRequest, meta schema.GeneratorMeta, user *auth.UserInfo, fn opt.StreamFn) (*schema.AskResponse, error) {
+	// Resolve model, generator, and options from the request meta
+	provider, model, generator, opts, err := m.generatorFromMeta(ctx, meta, user, generationContextAsk)
+	if err != nil {
+		return nil, err
 	}
 
 	// Build message options from attachments
 	var msgOpts []opt.Opt
 	for i := range request.Attachments {
 		a := request.Attachments[i]
+		a.Detect()
 		msgOpts = append(msgOpts, opt.AddAny(opt.ContentBlockKey, schema.ContentBlock{
 			Attachment: &a,
 		}))
@@ -67,11 +129,53 @@ func (m *Manager) Ask(ctx context.Context, request schema.AskRequest, user *auth
 		return nil, err
 	}
 
-	// Send the message
-	result, usage, err := generator.WithoutSession(ctx, types.Value(model), message, opts...)
+	// Send the text of images to models which do not accept them
+	message, ocrOpts, err := m.ocrFallback(ctx, model, message)
 	if err != nil {
 		return nil, err
 	}
+	opts = append(opts, ocrOpts...)
+	if err := schema.ValidateFor(provider.Provider, schema.Conversation{message}); err != nil {
+		return nil, err
+	}
+
+	// Draft the reply with a smaller model, keeping the paragraphs which the
+	// model accepts
+	draft := &drafted{prefill: request.Prefill}
+	if request.Draft != nil {
+		if draft, err = m.draft(ctx, request, meta, provider.Provider, model, message, user); err != nil {
+			return nil, err
+		}
+	}
+
+	// Start the reply with the prefill
+	prefillOpts, generator := m.prefillOpts(provider.Provider, generator, draft.prefill, generationContextAsk)
+	opts = append(opts, prefillOpts...)
+
+	// Enable streaming when a callback is provided, estimating the usage
+	// while the reply is streamed
+	var estimator *usageStream
+	if fn != nil {
+		estimator = newUsageStream(fn, message.EstimateTokens()+estimateSystemPromptTokens(types.Value(meta.SystemPrompt)))
+		fn = estimator.Write
+		opts = append(opts, opt.WithStream(fn))
+	}
+
+	// Send the message, unless the draft is the reply
+	var result *schema.Message
+	var usage *schema.UsageMeta
+	if draft.reply != nil {
+		result = draft.reply
+		if fn != nil {
+			fn(schema.RoleAssistant, result.Text())
+		}
+	} else {
+		start := time.Now()
+		if result, usage, err = generator.WithoutSession(ctx, types.Value(model), message, opts...); err != nil {
+			return nil, err
+		}
+		draft.report.Continue = time.Since(start)
+	}
 
 	// Create the response
 	response := types.Ptr(schema.AskResponse{
@@ -79,9 +183,18 @@ func (m *Manager) Ask(ctx context.Context, request schema.AskRequest, user *auth
 			Role:    result.Role,
 			Content: result.Content,
 			Result:  result.Result,
+			Markup:  schema.ParseMarkup(result.Content),
 		},
 		Usage: usage,
 	})
+	if _, warning := m.resolveModel(types.Value(meta.Model)); warning != "" {
+		response.Warnings = append(response.Warnings, warning)
+	}
+	response.Warnings = append(response.Warnings, optWarnings(opts)...)
+	if request.Draft != nil {
+		response.Draft = types.Ptr(draft.report)
+		response.Warnings = append(response.Warnings, draft.warnings...)
+	}
 
 	// Fold provider metadata into the usage metadata and include the
 	// current trace_id for downstream observability.
@@ -100,12 +213,72 @@ func (m *Manager) Ask(ctx context.Context, request schema.AskRequest, user *auth
 		}
 	}
 
+	// Report the usage with the estimate, when the reply was streamed
+	if estimator != nil {
+		response.Usage = estimator.Reconcile(response.Usage)
+	}
+
+	// Archive the request as it was sent to this model, with the response
+	sent := request
+	sent.GeneratorMeta, sent.Cascade = meta, nil
+	if response.Request, err = m.archive(ctx, schema.ArchiveInsert{
+		Type:     schema.UsageTypeAsk,
+		User:     uuid.UUID(user.Sub),
+		Provider: model.OwnedBy,
+		Model:    model.Name,
+	}, sent, response); err != nil {
+		return nil, err
+	}
+
 	// Return success
 	return response, nil
 }
 
-///////////////////////////////////////////////////////////////////////////////
-// PRIVATE METHODS
+// askTiers returns the generator settings for the requested model, followed
+// by each cascade tier merged over them. A tier which names a model without
+// a provider may use a model from any provider.
+func askTiers(request schema.AskRequest) []schema.GeneratorMeta {
+	tiers := make([]schema.GeneratorMeta, 0, len(request.Cascade)+1)
+	tiers = append(tiers, request.GeneratorMeta)
+	for _, tier := range request.Cascade {
+		base := request.GeneratorMeta
+		if tier.Model != nil && tier.Provider == nil {
+			base.Provider = nil
+		}
+		tiers = append(tiers, base.MergeFrom(tier))
+	}
+	return tiers
+}
+
+// cascadeReason returns why a reply should be retried with a stronger model,
+// or an empty string if the reply is acceptable
+func cascadeReason(meta schema.GeneratorMeta, response *schema.AskResponse) string {
+	switch response.Result {
+	case schema.ResultBlocked:
+		return "the reply was refused"
+	case schema.ResultMaxTokens:
+		return "the reply was truncated"
+	case schema.ResultError:
+		return "the reply was an error"
+	}
+	if len(meta.Format) == 0 {
+		return ""
+	}
+
+	// The reply must match the output format
+	format, err := jsonschema.FromJSON(json.RawMessage(meta.Format))
+	if err != nil {
+		return ""
+	}
+	var text strings.Builder
+	for _, block := range response.Content {
+		text.WriteString(types.Value(block.Text))
+	}
+	if err := format.Validate(json.RawMessage(text.String())); err != nil {
+		return "the reply does not match the output format"
+	}
+	return ""
+}
 
 // generatorFromMeta resolves the model and generator client from the given
 // GeneratorMeta, and returns provider-specific options derived from the meta
@@ -119,8 +292,9 @@ func (m *Manager) generatorFromMeta(ctx context.Context, meta schema.GeneratorMe
 		return nil, nil, nil, nil, schema.ErrNotFound.Withf("no providers found for model: %s", types.Value(meta.Model))
 	}
 
-	// Get the model
-	models, err := m.modelsByName(ctx, providers, types.Value(meta.Model))
+	// Get the model, resolving any alias
+	name, _ := m.resolveModel(types.Value(meta.Model))
+	models, err := m.modelsByName(ctx, providers, name)
 	if err != nil {
 		return nil, nil, nil, nil, err
 	}
@@ -133,7 +307,7 @@ func (m *Manager) generatorFromMeta(ctx context.Context, meta schema.GeneratorMe
 			return nil, nil, nil, nil, schema.ErrNotFound.Withf("model %q not found for provider %q", types.Value(meta.Model), providerName)
 		}
 		return nil, nil, nil, nil, schema.ErrNotFound.Withf("model %q not found", types.Value(meta.Model))
-	} else if len(models) > 1 {
+	} else if models = m.availableModels(models); len(models) > 1 {
 		return nil, nil, nil, nil, schema.ErrConflict.Withf("multiple models named %q found; specify a provider", types.Value(meta.Model))
 	} else {
 		model = types.Ptr(models[0])
@@ -149,8 +323,10 @@ func (m *Manager) generatorFromMeta(ctx context.Context, meta schema.GeneratorMe
 	}
 
 	// Get the provider-specific model
-	client := m.Registry.Get(model.OwnedBy)
-	if client == nil {
+	client, scoped, err := m.clientForUser(ctx, model.OwnedBy, user)
+	if err != nil {
+		return nil, nil, nil, nil, err
+	} else if client == nil {
 		return nil, nil, nil, nil, schema.ErrNotFound.Withf("no provider found for model: %s", types.Value(meta.Model))
 	}
 
@@ -159,22 +335,22 @@ func (m *Manager) generatorFromMeta(ctx context.Context, meta schema.GeneratorMe
 	if !ok {
 		return nil, nil, nil, nil, schema.ErrNotImplemented.Withf("provider %q does not support generation", model.OwnedBy)
 	}
-
-	// Build options from meta fields
-	var opts []opt.Opt
-	if meta.SystemPrompt != nil && *meta.SystemPrompt != "" {
-		opts = append(opts, withSystemPrompt(*meta.SystemPrompt))
-	}
-	if meta.MaxTokens != nil && *meta.MaxTokens > 0 {
-		opts = append(opts, withMaxTokens(*meta.MaxTokens))
+	// The failures of a client with the credentials of a user do not open
+	// the circuit for other users
+	if !scoped {
+		generator = m.breakers.wrap(client.Name(), generator)
 	}
-	if len(meta.Format) > 0 {
-		opts = append(opts, withJSONOutput(meta.Format))
+	generator = m.local.wrap(client.Name(), generator)
+	if m.dedup {
+		generator = &dedupGenerator{Generator: generator}
 	}
-	if meta.ThinkingBudget != nil && *meta.ThinkingBudget > 0 {
-		opts = append(opts, withThinkingBudget(context, *meta.ThinkingBudget))
-	} else if meta.Thinking != nil && *meta.Thinking {
-		opts = append(opts, withThinking(context))
+
+	// Build options from meta fields, applying the policy for options which
+	// the provider does not support
+	opts, generator := m.metaOpts(client.Name(), generator, meta, context)
+	generator = m.translator(generator, meta, model, user)
+	if generator, err = m.postprocessor(generator, meta); err != nil {
+		return nil, nil, nil, nil, err
 	}
 
 	// Convert options for the client
@@ -257,6 +433,22 @@ func withThinkingBudget(context generationContext, budgetTokens uint) opt.Opt {
 	})
 }
 
+// withSeed dispatches to the correct provider-specific random seed option.
+func withSeed(value uint) opt.Opt {
+	return opt.WithClient(func(provider string) opt.Opt {
+		switch provider {
+		case schema.Gemini:
+			return google.WithSeed(int(value))
+		case schema.Mistral:
+			return mistral.WithSeed(value)
+		case schema.Ollama:
+			return opt.SetUint(opt.SeedKey, value)
+		default:
+			return opt.Error(schema.ErrNotImplemented.Withf("%s: WithSeed not supported", provider))
+		}
+	})
+}
+
 // withJSONOutput dispatches to the correct provider-specific JSON output option.
 func withJSONOutput(data schema.JSONSchema) opt.Opt {
 	var s jsonschema.Schema
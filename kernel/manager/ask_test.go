@@ -112,6 +112,48 @@ func TestMergeUsageMetaCopiesConfiguredProviderMeta(t *testing.T) {
 	}
 }
 
+func TestAskTiers(t *testing.T) {
+	assert := assert.New(t)
+
+	tiers := askTiers(schema.AskRequest{
+		AskRequestCore: schema.AskRequestCore{
+			GeneratorMeta: schema.GeneratorMeta{Provider: types.Ptr("ollama"), Model: types.Ptr("small"), MaxTokens: types.Ptr(uint(100))},
+		},
+		Cascade: []schema.GeneratorMeta{
+			{Model: types.Ptr("large")},
+			{Provider: types.Ptr("anthropic"), Model: types.Ptr("largest"), MaxTokens: types.Ptr(uint(1000))},
+		},
+	})
+	if !assert.Len(tiers, 3) {
+		return
+	}
+	assert.Equal("small", types.Value(tiers[0].Model))
+	assert.Nil(tiers[1].Provider)
+	assert.Equal("large", types.Value(tiers[1].Model))
+	assert.Equal(uint(100), types.Value(tiers[1].MaxTokens))
+	assert.Equal("anthropic", types.Value(tiers[2].Provider))
+	assert.Equal(uint(1000), types.Value(tiers[2].MaxTokens))
+}
+
+func TestCascadeReason(t *testing.T) {
+	assert := assert.New(t)
+
+	reply := func(result schema.ResultType, text string) *schema.AskResponse {
+		return &schema.AskResponse{CompletionResponse: schema.CompletionResponse{
+			Result:  result,
+			Content: []schema.ContentBlock{{Text: types.Ptr(text)}},
+		}}
+	}
+	format := schema.GeneratorMeta{Format: schema.JSONSchema(`{"type":"object","required":["name"]}`)}
+
+	assert.Empty(cascadeReason(schema.GeneratorMeta{}, reply(schema.ResultStop, "hello")))
+	assert.NotEmpty(cascadeReason(schema.GeneratorMeta{}, reply(schema.ResultBlocked, "")))
+	assert.NotEmpty(cascadeReason(schema.GeneratorMeta{}, reply(schema.ResultMaxTokens, "hel")))
+	assert.Empty(cascadeReason(format, reply(schema.ResultStop, `{"name":"x"}`)))
+	assert.NotEmpty(cascadeReason(format, reply(schema.ResultStop, `{"other":"x"}`)))
+	assert.NotEmpty(cascadeReason(format, reply(schema.ResultStop, `not json`)))
+}
+
 func TestOllamaWithThinking(t *testing.T) {
 	t.Run("chat enables boolean thinking", func(t *testing.T) {
 		o, err := opt.Apply(ollama.WithThinking("chat"))
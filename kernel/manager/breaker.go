@@ -0,0 +1,280 @@
+package manager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	// Packages
+	llm "github.com/mutablelogic/go-llm"
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	opt "github.com/mutablelogic/go-llm/pkg/opt"
+	httpresponse "github.com/mutablelogic/go-server/pkg/httpresponse"
+	attribute "go.opentelemetry.io/otel/attribute"
+	metric "go.opentelemetry.io/otel/metric"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// breakerList holds a circuit breaker for each provider. A circuit opens
+// after a number of consecutive failures, and requests to the provider are
+// rejected until the cooldown has passed. Then one request is sent as a
+// probe, which closes the circuit when it succeeds or opens it again when it
+// fails.
+type breakerList struct {
+	sync.Mutex
+	clock    Clock
+	failures uint
+	cooldown time.Duration
+	breakers map[string]*breaker
+}
+
+type breaker struct {
+	state    circuitState
+	failures uint      // consecutive failures
+	opened   time.Time // when the circuit was last opened
+	probing  bool      // true while a probe is sent in the half-open state
+}
+
+type circuitState int
+
+// breakerGenerator records the outcome of each generation in the circuit
+// breaker for the provider, and rejects generations when it is open
+type breakerGenerator struct {
+	llm.Generator
+	breakers *breakerList
+	provider string
+}
+
+var _ llm.Generator = (*breakerGenerator)(nil)
+
+///////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+const (
+	circuitClosed circuitState = iota
+	circuitHalfOpen
+	circuitOpen
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// LIFECYCLE
+
+func newBreakerList(failures uint, cooldown time.Duration) (*breakerList, error) {
+	if failures == 0 {
+		return nil, fmt.Errorf("circuit breaker requires at least one failure")
+	} else if cooldown <= 0 {
+		return nil, fmt.Errorf("circuit breaker cooldown must be positive")
+	}
+	return &breakerList{
+		failures: failures,
+		cooldown: cooldown,
+		breakers: make(map[string]*breaker),
+	}, nil
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// STRINGIFY
+
+func (s circuitState) String() string {
+	switch s {
+	case circuitClosed:
+		return "closed"
+	case circuitHalfOpen:
+		return "half-open"
+	case circuitOpen:
+		return "open"
+	}
+	return fmt.Sprintf("circuitState(%d)", int(s))
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+func (g *breakerGenerator) WithoutSession(ctx context.Context, model schema.Model, message *schema.Message, opts ...opt.Opt) (*schema.Message, *schema.UsageMeta, error) {
+	done, err := g.breakers.allow(g.provider)
+	if err != nil {
+		return nil, nil, err
+	}
+	reply, usage, err := g.Generator.WithoutSession(ctx, model, message, opts...)
+	done(ctx, err)
+	return reply, usage, err
+}
+
+func (g *breakerGenerator) WithSession(ctx context.Context, model schema.Model, conversation *schema.Conversation, message *schema.Message, opts ...opt.Opt) (*schema.Message, *schema.UsageMeta, error) {
+	done, err := g.breakers.allow(g.provider)
+	if err != nil {
+		return nil, nil, err
+	}
+	reply, usage, err := g.Generator.WithSession(ctx, model, conversation, message, opts...)
+	done(ctx, err)
+	return reply, usage, err
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// wrap returns a generator which uses the circuit breaker for the provider,
+// or the generator when there are no circuit breakers
+func (l *breakerList) wrap(provider string, generator llm.Generator) llm.Generator {
+	if l == nil {
+		return generator
+	}
+	return &breakerGenerator{Generator: generator, breakers: l, provider: provider}
+}
+
+// allow returns an error when the circuit for the provider is open, or a
+// function which records the outcome of the request
+func (l *breakerList) allow(provider string) (func(context.Context, error), error) {
+	l.Lock()
+	defer l.Unlock()
+	b := l.get(provider)
+	now := l.clock.Now()
+	switch {
+	case b.state == circuitOpen && now.Sub(b.opened) >= l.cooldown:
+		// The cooldown has passed, so send a probe
+		b.state, b.probing = circuitHalfOpen, true
+	case b.state == circuitHalfOpen && !b.probing:
+		b.probing = true
+	case b.state != circuitClosed:
+		return nil, &schema.RetryAfterError{
+			Err:   schema.ErrServiceUnavailable.Withf("provider %q is unavailable after %d consecutive failures", provider, b.failures),
+			After: max(l.cooldown-now.Sub(b.opened), time.Second),
+		}
+	}
+	var once sync.Once
+	return func(ctx context.Context, err error) {
+		once.Do(func() { l.record(provider, ctx, err) })
+	}, nil
+}
+
+// record updates the circuit for the provider with the outcome of a request.
+// A request which ended because its context ended is not counted.
+func (l *breakerList) record(provider string, ctx context.Context, err error) {
+	l.Lock()
+	defer l.Unlock()
+	b := l.get(provider)
+	b.probing = false
+	switch {
+	case ctx.Err() != nil:
+		return
+	case !providerFailure(err):
+		b.state, b.failures = circuitClosed, 0
+	default:
+		b.failures++
+		if b.state == circuitHalfOpen || b.failures >= l.failures {
+			b.state, b.opened = circuitOpen, l.clock.Now()
+		}
+	}
+}
+
+// available returns false when requests to the provider would be rejected
+func (l *breakerList) available(provider string) bool {
+	if l == nil {
+		return true
+	}
+	l.Lock()
+	defer l.Unlock()
+	b, exists := l.breakers[provider]
+	switch {
+	case !exists || b.state == circuitClosed:
+		return true
+	case b.state == circuitHalfOpen:
+		return !b.probing
+	default:
+		return l.clock.Since(b.opened) >= l.cooldown
+	}
+}
+
+// availableModels returns the models whose provider would accept requests,
+// or all the models when none would, so that the error is returned when the
+// model is used
+func (m *Manager) availableModels(models []schema.Model) []schema.Model {
+	if m.breakers == nil || len(models) < 2 {
+		return models
+	}
+	result := make([]schema.Model, 0, len(models))
+	for _, model := range models {
+		if m.breakers.available(model.OwnedBy) {
+			result = append(result, model)
+		}
+	}
+	if len(result) == 0 {
+		return models
+	}
+	return result
+}
+
+// reset closes the circuits for providers whose clients were rebuilt
+func (l *breakerList) reset(providers ...string) {
+	if l == nil {
+		return
+	}
+	l.Lock()
+	defer l.Unlock()
+	for _, provider := range providers {
+		delete(l.breakers, provider)
+	}
+}
+
+// get returns the breaker for a provider. The lock must be held.
+func (l *breakerList) get(provider string) *breaker {
+	b, exists := l.breakers[provider]
+	if !exists {
+		b = new(breaker)
+		l.breakers[provider] = b
+	}
+	return b
+}
+
+// registerMetrics registers a gauge of the state of each circuit, which is
+// zero when closed, one when half-open and two when open
+func (l *breakerList) registerMetrics(meter metric.Meter) error {
+	gauge, err := meter.Int64ObservableGauge(
+		"llmmanager.provider.circuit",
+		metric.WithDescription("State of the circuit breaker for each provider: 0 closed, 1 half-open, 2 open"),
+	)
+	if err != nil {
+		return fmt.Errorf("register llmmanager.provider.circuit gauge: %w", err)
+	}
+	if _, err := meter.RegisterCallback(func(ctx context.Context, observer metric.Observer) error {
+		l.Lock()
+		defer l.Unlock()
+		for provider, b := range l.breakers {
+			observer.ObserveInt64(gauge, int64(b.state), metric.WithAttributes(attribute.String("provider", provider)))
+		}
+		return nil
+	}, gauge); err != nil {
+		return fmt.Errorf("register llmmanager.provider.circuit callback: %w", err)
+	}
+	return nil
+}
+
+// providerFailure returns true if an error shows the provider is failing,
+// rather than rejecting the request: a server error, a rate limit, a timeout
+// or an error without a status, such as a network error
+func providerFailure(err error) bool {
+	if err == nil {
+		return false
+	}
+	var httpErr httpresponse.Err
+	if errors.As(err, &httpErr) {
+		code := int(httpErr)
+		return code >= 500 || code == http.StatusTooManyRequests || code == http.StatusRequestTimeout
+	}
+	var schemaErr schema.Err
+	if errors.As(err, &schemaErr) {
+		return schemaErr == schema.ErrServiceUnavailable || schemaErr == schema.ErrInternalServerError || schemaErr == schema.ErrTooManyRequests
+	}
+	return true
+}
+
+// providerUnavailable returns true if the error is from an open circuit
+func providerUnavailable(err error) bool {
+	var retry *schema.RetryAfterError
+	return errors.As(err, &retry) && errors.Is(retry.Err, schema.ErrServiceUnavailable)
+}
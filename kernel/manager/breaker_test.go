@@ -0,0 +1,173 @@
+package manager
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	// Packages
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	opt "github.com/mutablelogic/go-llm/pkg/opt"
+	httpresponse "github.com/mutablelogic/go-server/pkg/httpresponse"
+	assert "github.com/stretchr/testify/assert"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// HELPERS
+
+// breakerTestGenerator returns err, and counts the generations
+type breakerTestGenerator struct {
+	err   error
+	calls int
+}
+
+func (g *breakerTestGenerator) WithoutSession(context.Context, schema.Model, *schema.Message, ...opt.Opt) (*schema.Message, *schema.UsageMeta, error) {
+	g.calls++
+	if g.err != nil {
+		return nil, nil, g.err
+	}
+	return &schema.Message{Role: schema.RoleAssistant}, nil, nil
+}
+
+func (g *breakerTestGenerator) WithSession(ctx context.Context, model schema.Model, _ *schema.Conversation, message *schema.Message, opts ...opt.Opt) (*schema.Message, *schema.UsageMeta, error) {
+	return g.WithoutSession(ctx, model, message, opts...)
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// TESTS
+
+func TestBreakerOpenAndRecover(t *testing.T) {
+	assert := assert.New(t)
+	breakers, err := newBreakerList(2, 20*time.Millisecond)
+	if !assert.NoError(err) {
+		return
+	}
+	inner := &breakerTestGenerator{err: httpresponse.ErrServiceUnavailable.With("overloaded")}
+	generator := breakers.wrap("test", inner)
+	ctx := context.Background()
+
+	// The circuit opens after two failures, and rejects without calling the provider
+	for range 2 {
+		_, _, err := generator.WithoutSession(ctx, schema.Model{}, nil)
+		assert.ErrorIs(err, httpresponse.ErrServiceUnavailable)
+	}
+	assert.False(breakers.available("test"))
+	_, _, err = generator.WithoutSession(ctx, schema.Model{}, nil)
+	assert.True(providerUnavailable(err))
+	after, ok := schema.RetryAfter(err)
+	assert.True(ok)
+	assert.Positive(after)
+	assert.Equal(2, inner.calls)
+
+	// After the cooldown a probe is sent, which opens the circuit again when it fails
+	time.Sleep(30 * time.Millisecond)
+	assert.True(breakers.available("test"))
+	_, _, err = generator.WithoutSession(ctx, schema.Model{}, nil)
+	assert.False(providerUnavailable(err))
+	assert.Equal(3, inner.calls)
+	assert.False(breakers.available("test"))
+
+	// A probe which succeeds closes the circuit
+	time.Sleep(30 * time.Millisecond)
+	inner.err = nil
+	_, _, err = generator.WithoutSession(ctx, schema.Model{}, nil)
+	assert.NoError(err)
+	assert.True(breakers.available("test"))
+	assert.Equal(circuitClosed, breakers.breakers["test"].state)
+}
+
+func TestBreakerProbe(t *testing.T) {
+	assert := assert.New(t)
+	breakers, err := newBreakerList(1, time.Millisecond)
+	if !assert.NoError(err) {
+		return
+	}
+	done, err := breakers.allow("test")
+	if !assert.NoError(err) {
+		return
+	}
+	done(context.Background(), errors.New("connection refused"))
+	time.Sleep(5 * time.Millisecond)
+
+	// Only one probe is sent at a time
+	probe, err := breakers.allow("test")
+	if !assert.NoError(err) {
+		return
+	}
+	_, err = breakers.allow("test")
+	assert.True(providerUnavailable(err))
+
+	// A probe whose context ended is not counted
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	probe(ctx, context.Canceled)
+	assert.Equal(circuitHalfOpen, breakers.breakers["test"].state)
+	assert.True(breakers.available("test"))
+}
+
+func TestProviderFailure(t *testing.T) {
+	assert := assert.New(t)
+	assert.False(providerFailure(nil))
+	assert.True(providerFailure(errors.New("connection reset")))
+	assert.True(providerFailure(httpresponse.ErrInternalError.With("boom")))
+	assert.True(providerFailure(httpresponse.Err(http.StatusTooManyRequests)))
+	assert.True(providerFailure(schema.ErrServiceUnavailable))
+	assert.False(providerFailure(httpresponse.ErrBadRequest.With("invalid")))
+	assert.False(providerFailure(schema.ErrRefusal))
+	assert.False(providerFailure(schema.ErrNotFound.With("model not found")))
+}
+
+func TestAvailableModels(t *testing.T) {
+	assert := assert.New(t)
+	breakers, err := newBreakerList(1, time.Hour)
+	if !assert.NoError(err) {
+		return
+	}
+	m := &Manager{manageropt: manageropt{breakers: breakers}}
+	models := []schema.Model{{Name: "model", OwnedBy: "a"}, {Name: "model", OwnedBy: "b"}}
+	assert.Len(m.availableModels(models), 2)
+
+	// A model with an open circuit is not routed to
+	done, _ := breakers.allow("a")
+	done(context.Background(), errors.New("timeout"))
+	if available := m.availableModels(models); assert.Len(available, 1) {
+		assert.Equal("b", available[0].OwnedBy)
+	}
+
+	// When every circuit is open, the models are returned
+	done, _ = breakers.allow("b")
+	done(context.Background(), errors.New("timeout"))
+	assert.Len(m.availableModels(models), 2)
+}
+
+func TestModelSuccessorCircuitOpen(t *testing.T) {
+	assert := assert.New(t)
+	m := &Manager{manageropt: manageropt{aliases: map[string]modelAlias{
+		"model": {target: "backup", successor: true},
+	}}}
+	err := &schema.RetryAfterError{Err: schema.ErrServiceUnavailable.With("provider is unavailable"), After: time.Second}
+	successor, warning := m.modelSuccessor("model", err)
+	assert.Equal("backup", successor)
+	assert.NotEmpty(warning)
+
+	// Other rejections are not retried
+	successor, _ = m.modelSuccessor("model", &schema.RetryAfterError{Err: schema.ErrTooManyRequests, After: time.Second})
+	assert.Empty(successor)
+}
+
+func TestBreakerReset(t *testing.T) {
+	assert := assert.New(t)
+	breakers, err := newBreakerList(1, time.Hour)
+	if !assert.NoError(err) {
+		return
+	}
+	done, _ := breakers.allow("test")
+	done(context.Background(), schema.ErrServiceUnavailable)
+	assert.False(breakers.available("test"))
+
+	// A provider whose client was rebuilt is tried again
+	breakers.reset("test")
+	assert.True(breakers.available("test"))
+}
@@ -0,0 +1,67 @@
+package manager
+
+import (
+	"context"
+	"errors"
+
+	// Packages
+	uuid "github.com/google/uuid"
+	auth "github.com/mutablelogic/go-auth/auth/schema"
+	otel "github.com/mutablelogic/go-client/pkg/otel"
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	types "github.com/mutablelogic/go-server/pkg/types"
+	attribute "go.opentelemetry.io/otel/attribute"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// SessionBudget returns the tokens for each message of a session against the
+// context window of the session model, and the messages which would be
+// trimmed so that the conversation fits. When the model cannot be found, or
+// does not report an input token limit, only the token counts are returned.
+// If user is non-nil, the session must be owned by that user.
+func (m *Manager) SessionBudget(ctx context.Context, session uuid.UUID, user *auth.UserInfo) (_ *schema.SessionBudget, err error) {
+	// OTel span
+	ctx, endSpan := otel.StartSpan(m.tracer, ctx, "SessionBudget",
+		attribute.String("id", session.String()),
+		attribute.String("user", types.Stringify(user)),
+	)
+	defer func() { endSpan(err) }()
+
+	// Get the session and its messages
+	result, err := m.GetSession(ctx, session, user)
+	if err != nil {
+		return nil, err
+	}
+	conversation, err := m.conversationForSession(ctx, session, user)
+	if err != nil {
+		return nil, err
+	}
+
+	// Get the context window of the model
+	var window uint
+	model, err := m.GetModel(ctx, schema.GetModelRequest{
+		Provider: types.Value(result.GeneratorMeta.Provider),
+		Name:     types.Value(result.GeneratorMeta.Model),
+	}, user)
+	if err != nil && !errors.Is(err, schema.ErrNotFound) {
+		return nil, err
+	} else if model != nil {
+		window = types.Value(model.InputTokenLimit)
+	}
+
+	// Count the tokens
+	budget := conversation.Budget(estimateSystemPromptTokens(types.Value(result.GeneratorMeta.SystemPrompt)), window)
+	budget.Session = session
+	if model != nil {
+		budget.Provider = model.OwnedBy
+		budget.Model = model.Name
+	} else {
+		budget.Provider = types.Value(result.GeneratorMeta.Provider)
+		budget.Model = types.Value(result.GeneratorMeta.Model)
+	}
+
+	// Return the budget
+	return &budget, nil
+}
@@ -0,0 +1,134 @@
+package manager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	// Packages
+	uuid "github.com/google/uuid"
+	auth "github.com/mutablelogic/go-auth/auth/schema"
+	otel "github.com/mutablelogic/go-client/pkg/otel"
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	opt "github.com/mutablelogic/go-llm/pkg/opt"
+	types "github.com/mutablelogic/go-server/pkg/types"
+	attribute "go.opentelemetry.io/otel/attribute"
+	errgroup "golang.org/x/sync/errgroup"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+const (
+	bulkDelete = "delete"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// DeleteSessions deletes the sessions matching the request, a number at a
+// time, and reports the progress to the callback set with opt.WithProgress.
+// In a dry run the matching sessions are returned without being deleted.
+// If user is non-nil, only sessions owned by that user are deleted.
+func (m *Manager) DeleteSessions(ctx context.Context, req schema.SessionBulkRequest, user *auth.UserInfo, opts ...opt.Opt) (_ *schema.SessionBulkResult, err error) {
+	// OTel span
+	ctx, endSpan := otel.StartSpan(m.tracer, ctx, "DeleteSessions",
+		attribute.String("req", req.String()),
+		attribute.String("user", types.Stringify(user)),
+	)
+	defer func() { endSpan(err) }()
+
+	return m.bulkSessions(ctx, bulkDelete, req, user, opts, func(ctx context.Context, session uuid.UUID) error {
+		// A child session may already have been deleted with its parent
+		if _, err := m.DeleteSession(ctx, session, user); err != nil && !errors.Is(err, schema.ErrNotFound) {
+			return err
+		}
+		return nil
+	})
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// bulkSessions applies fn to each session matching the request, unless the
+// request is a dry run
+func (m *Manager) bulkSessions(ctx context.Context, operation string, req schema.SessionBulkRequest, user *auth.UserInfo, opts []opt.Opt, fn func(context.Context, uuid.UUID) error) (*schema.SessionBulkResult, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	o, err := opt.Apply(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	// Match all the sessions before any are changed, so that the pages are
+	// not shifted by the operation
+	sessions, err := m.matchSessions(ctx, req.ListRequest(), user)
+	if err != nil {
+		return nil, err
+	}
+	result := &schema.SessionBulkResult{Operation: operation, DryRun: req.DryRun, Sessions: sessions}
+	if req.DryRun {
+		return result, nil
+	}
+	if err := runBulk(ctx, result, req.Limit(), o.GetProgress(), fn); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// matchSessions returns the identifiers of all the sessions for a request
+func (m *Manager) matchSessions(ctx context.Context, req schema.SessionListRequest, user *auth.UserInfo) ([]uuid.UUID, error) {
+	result := []uuid.UUID{}
+	req.Limit = types.Ptr(schema.SessionListMax)
+	for {
+		list, err := m.ListSessions(ctx, req, user)
+		if err != nil {
+			return nil, err
+		}
+		for _, session := range list.Body {
+			result = append(result, session.ID)
+		}
+		req.Offset += uint64(len(list.Body))
+		if len(list.Body) == 0 || req.Offset >= uint64(list.Count) {
+			return result, nil
+		}
+	}
+}
+
+// runBulk applies fn to the sessions of the result, with at most limit
+// running at once. The outcome for each session is recorded in the result
+// and reported to the progress callback, which is never called concurrently.
+// Sessions which have not started when the context ends are skipped, and
+// the error of the context is returned.
+func runBulk(ctx context.Context, result *schema.SessionBulkResult, limit int, progress opt.ProgressFn, fn func(context.Context, uuid.UUID) error) error {
+	var mu sync.Mutex
+	var group errgroup.Group
+	group.SetLimit(max(limit, 1))
+	for _, session := range result.Sessions {
+		if ctx.Err() != nil {
+			break
+		}
+		group.Go(func() error {
+			err := ctx.Err()
+			if err == nil {
+				err = fn(ctx, session)
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				result.Errors = append(result.Errors, schema.SessionBulkError{Session: session, Error: err.Error()})
+			} else {
+				result.Done++
+			}
+			if progress != nil {
+				progress(fmt.Sprintf("%s %s", result.Operation, session), result.Percent())
+			}
+			return nil
+		})
+	}
+	_ = group.Wait()
+	return ctx.Err()
+}
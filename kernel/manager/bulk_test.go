@@ -0,0 +1,100 @@
+package manager
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	// Packages
+	uuid "github.com/google/uuid"
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	assert "github.com/stretchr/testify/assert"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// HELPERS
+
+func bulkTestResult(n int) *schema.SessionBulkResult {
+	result := &schema.SessionBulkResult{Operation: bulkDelete}
+	for range n {
+		result.Sessions = append(result.Sessions, uuid.New())
+	}
+	return result
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// TESTS
+
+func TestRunBulkConcurrency(t *testing.T) {
+	assert := assert.New(t)
+	result := bulkTestResult(20)
+	failed := result.Sessions[3]
+
+	var running, peak atomic.Int32
+	var progress []float64
+	err := runBulk(context.Background(), result, 3, func(status string, percent float64) {
+		progress = append(progress, percent)
+	}, func(ctx context.Context, session uuid.UUID) error {
+		n := running.Add(1)
+		defer running.Add(-1)
+		for {
+			if p := peak.Load(); n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(time.Millisecond)
+		if session == failed {
+			return errors.New("failed")
+		}
+		return nil
+	})
+	assert.NoError(err)
+
+	// No more than the limit run at once, and each session is reported
+	assert.LessOrEqual(peak.Load(), int32(3))
+	assert.Equal(uint(19), result.Done)
+	if assert.Len(result.Errors, 1) {
+		assert.Equal(failed, result.Errors[0].Session)
+		assert.Equal("failed", result.Errors[0].Error)
+	}
+	if assert.Len(progress, 20) {
+		assert.Equal(float64(100), progress[19])
+		for i := 1; i < len(progress); i++ {
+			assert.Greater(progress[i], progress[i-1])
+		}
+	}
+}
+
+func TestRunBulkCancel(t *testing.T) {
+	assert := assert.New(t)
+	result := bulkTestResult(10)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Sessions which have not started when the context ends are skipped
+	var mu sync.Mutex
+	var calls int
+	err := runBulk(ctx, result, 1, nil, func(ctx context.Context, session uuid.UUID) error {
+		mu.Lock()
+		defer mu.Unlock()
+		if calls++; calls == 2 {
+			cancel()
+		}
+		return nil
+	})
+	assert.ErrorIs(err, context.Canceled)
+	assert.Equal(uint(2), result.Done)
+	assert.Less(len(result.Errors)+int(result.Done), 10)
+}
+
+func TestBulkSessionsValidate(t *testing.T) {
+	assert := assert.New(t)
+	m := &Manager{}
+
+	// Without a filter no sessions are matched
+	_, err := m.bulkSessions(context.Background(), bulkDelete, schema.SessionBulkRequest{DryRun: true}, nil, nil, nil)
+	assert.ErrorIs(err, schema.ErrBadParameter)
+}
@@ -0,0 +1,45 @@
+package manager
+
+import (
+	"context"
+	"encoding/json"
+
+	// Packages
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	calendar "github.com/mutablelogic/go-llm/pkg/calendar"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// calendarTools returns the calendar tools, and marks the tools which change
+// the calendar as needing approval
+func (m *Manager) calendarTools() error {
+	client, err := calendar.New(m.calendar.url)
+	if err != nil {
+		return err
+	} else if err := calendar.WithCredentials(m.calendarCredentials(client.URL()))(client); err != nil {
+		return err
+	}
+	tools, err := calendar.NewTools(client, m.calendar.write)
+	if err != nil {
+		return err
+	}
+	m.addTools(tools...)
+	return nil
+}
+
+// calendarCredentials returns a function which reads the credentials stored
+// for a calendar URL, which are a JSON object with a username and password,
+// or a token. The function returns nil when no credentials are stored.
+func (m *Manager) calendarCredentials(url string) calendar.CredentialsFn {
+	return func(ctx context.Context) (*calendar.Credentials, error) {
+		var credentials calendar.Credentials
+		if data, err := m.credential(ctx, url); err != nil || data == nil {
+			return nil, err
+		} else if err := json.Unmarshal(data, &credentials); err != nil {
+			return nil, schema.ErrBadParameter.Withf("calendar credentials: %v", err)
+		}
+		return &credentials, nil
+	}
+}
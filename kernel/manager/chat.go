@@ -8,6 +8,7 @@ import (
 	"slices"
 	"strings"
 	"sync"
+	"time"
 
 	// Packages
 	uuid "github.com/google/uuid"
@@ -32,6 +33,26 @@ type conversationTurn struct {
 	Overhead   uint
 	Usage      *schema.UsageMeta
 	UsageEntry *schema.UsageInsert
+	Iteration  uint
+	Trace      []schema.ToolTrace
+}
+
+// chatPlan holds the state resolved for a chat turn
+type chatPlan struct {
+	session      *schema.Session
+	conversation schema.Conversation
+	pending      schema.Conversation
+	provider     *schema.Provider
+	model        *schema.Model
+	generator    llm.Generator
+	opts         []opt.Opt
+	turnOpts     []opt.Opt // options for the message, such as OCR, and the offered tools
+	tools        toolMap   // tools which can be called
+	offered      toolMap   // tools which are offered to the model
+	message      *schema.Message
+	jailbreak    *schema.JailbreakScore
+	compaction   *schema.CompactionReport
+	warnings     []string
 }
 
 type namedTool struct {
@@ -50,6 +71,9 @@ const memorySearchToolKey = "memory__memory_search"
 
 // Chat processes a message within a session context (stateful).
 // If fn is non-nil, text chunks are streamed to the callback as they arrive.
+// When the provider reports that the model does not exist or has been
+// retired, the turn is retried once with the successor set with
+// WithModelSuccessor.
 func (m *Manager) Chat(ctx context.Context, req schema.ChatRequest, fn opt.StreamFn, user *auth.UserInfo, attachments ...llm.Resource) (_ *schema.ChatResponse, err error) {
 	// Otel span
 	ctx, endSpan := otel.StartSpan(m.tracer, ctx, "Chat",
@@ -58,62 +82,74 @@ func (m *Manager) Chat(ctx context.Context, req schema.ChatRequest, fn opt.Strea
 	)
 	defer func() { endSpan(err) }()
 
-	// Load the current session state.
-	session, err := m.GetSession(ctx, req.Session, user)
+	// Wait for any other turn in the session to complete, so that the
+	// conversation is extended in order.
+	unlock, err := m.lockSession(ctx, req.Session.String())
 	if err != nil {
 		return nil, err
 	}
+	defer unlock()
 
-	// Load the persisted conversation history in chronological order.
-	conversation, err := m.conversationForSession(ctx, req.Session, user)
+	// Wait for a worker to run the generation
+	ctx, release, err := m.Admit(ctx)
 	if err != nil {
 		return nil, err
 	}
+	defer release()
 
-	// Fold the per-request system prompt into the session prompt.
-	if prompt := strings.TrimSpace(req.SystemPrompt); prompt != "" {
-		session.GeneratorMeta.SystemPrompt = mergeSystemPrompt(session.GeneratorMeta.SystemPrompt, prompt)
-	}
+	// Record the intermediate states of the turn when requested. A turn
+	// which fails is recorded up to the error.
+	recording := m.recordings.recorder(ctx, req.Session, user)
+	defer func() {
+		if err != nil {
+			recording.step(schema.RecordingStepError, 0, err.Error())
+		}
+		m.recordings.put(recording)
+	}()
 
-	// Determine the tools we are going to use in this conversation loop.
-	tools, err := m.toolsForUser(ctx, user, req.Tools)
+	// Resolve the session, conversation, generator and tools for the turn.
+	plan, err := m.planChat(ctx, req, user, false)
 	if err != nil {
 		return nil, err
 	}
-
-	// On the first chat turn, add a memory-aware prompt when the memory connector is available.
-	if prompt, err := firstTurnMemoryPrompt(ctx, req.Session, conversation, tools); err == nil && prompt != "" {
-		session.GeneratorMeta.SystemPrompt = mergeSystemPrompt(session.GeneratorMeta.SystemPrompt, prompt)
+	if plan.compaction != nil {
+		recording.step(schema.RecordingStepCompaction, 0, plan.compaction)
+		if fn != nil {
+			fn(schema.EventCompaction, compactionFeedback(plan.compaction))
+		}
 	}
-
-	// Resolve the model, generator, and provider options for this turn.
-	provider, model, generator, opts, err := m.generatorFromMeta(ctx, session.GeneratorMeta, user, generationContextChat)
-	if err != nil {
+	if recording != nil {
+		planned, err := m.chatDryRun(req, plan)
+		if err != nil {
+			return nil, err
+		}
+		planned.Messages = nil
+		recording.step(schema.RecordingStepPlan, 0, planned)
+	}
+	session, conversation, pending := plan.session, plan.conversation, plan.pending
+	provider, model, generator, tools, message, warnings := plan.provider, plan.model, plan.generator, plan.tools, plan.message, plan.warnings
+	if err := m.auditJailbreak(ctx, plan.jailbreak, req.Text, req.Session, user); err != nil {
 		return nil, err
 	}
 
+	// Replace the tools with fixtures or simulated results when simulating
+	if toolSimulationFromContext(ctx) {
+		tools = m.simulatedTools(tools, generator, types.Value(model))
+	}
+
 	// Enable streaming when a callback is provided.
+	opts := plan.opts
 	if fn != nil {
 		opts = append(opts, opt.WithStream(fn))
 	}
 
-	// Add tools to the provider options when available.
-	if len(tools) > 0 {
-		opts = append(opts, tools.Opts()...)
-	}
-
-	// Build the next user turn.
-	// TODO: Append the attachments.
-	message, err := schema.NewMessage(schema.RoleUser, req.Text)
-	if err != nil {
-		return nil, err
-	}
-
 	// Set up the variables we use to track the conversation loop state
 	maxIterations := conversationLoopMaxIterations(req.MaxIterations)
 	conversationStart := conversation.Len()
 	usageEntries := make([]schema.UsageInsert, 0, maxIterations)
 	overhead := uint(0)
+	trace := make([]schema.ToolTrace, 0)
+	substitution := ""
 	var loopErr error
 
 	// Conversation/agent loop begins here.
@@ -129,10 +165,33 @@ func (m *Manager) Chat(ctx context.Context, req schema.ChatRequest, fn opt.Strea
 		if err := func() (err error) {
 			defer func() { endLoopSpan(err) }()
 
+			recording.step(schema.RecordingStepRequest, iteration, schema.Normalize(provider.Provider, slices.Concat(conversation, schema.Conversation{message})))
 			turn, err = m.executeConversationTurn(loopCtx, req.Session, user, provider, model, generator, types.Value(session.GeneratorMeta.SystemPrompt), &conversation, message, opts...)
+			if successor, warning := m.modelSuccessor(model.Name, err); successor != "" && substitution == "" {
+				// Retry once with the successor of a model which the provider does not have
+				meta := session.GeneratorMeta
+				meta.Model = types.Ptr(successor)
+				if provider, model, generator, opts, err = m.chatGenerator(loopCtx, meta, user, req.Prefill); err != nil {
+					return err
+				}
+				for _, warning := range optWarnings(opts) {
+					if !slices.Contains(warnings, warning) {
+						warnings = append(warnings, warning)
+					}
+				}
+				opts = append(opts, plan.turnOpts...)
+				if fn != nil {
+					opts = append(opts, opt.WithStream(fn))
+				}
+				substitution = warning
+				recording.step(schema.RecordingStepSubstitution, iteration, recordedSubstitution{Model: successor, Warning: warning})
+				turn, err = m.executeConversationTurn(loopCtx, req.Session, user, provider, model, generator, types.Value(session.GeneratorMeta.SystemPrompt), &conversation, message, opts...)
+			}
 			if err != nil {
 				return err
 			}
+			turn.Iteration = iteration
+			recording.step(schema.RecordingStepResponse, iteration, recordedResponse{Reply: turn.Reply, Usage: turn.Usage})
 			overhead += turn.Overhead
 			if turn.UsageEntry != nil {
 				usageEntries = append(usageEntries, *turn.UsageEntry)
@@ -142,11 +201,29 @@ func (m *Manager) Chat(ctx context.Context, req schema.ChatRequest, fn opt.Strea
 				return nil
 			}
 
+			// Store the turn before and after the tools run, so that it can be
+			// recovered if the server stops
+			checkpoint := schema.CheckpointInsert{
+				Session:   req.Session,
+				User:      userSub(user),
+				Iteration: iteration,
+				Request:   req,
+				Messages:  slices.Clone(conversation[conversationStart:]),
+				Pending:   checkpointIDs(pending),
+				Usage:     usageEntries,
+				Overhead:  overhead,
+			}
+			m.checkpoint(loopCtx, checkpoint)
+
 			var ok bool
 			nextMessage, ok, err = m.nextConversationIteration(loopCtx, req.Session, turn, tools, fn)
 			if err != nil {
 				return err
 			}
+			checkpoint.Messages = append(checkpoint.Messages, nextMessage)
+			m.checkpoint(loopCtx, checkpoint)
+			trace = append(trace, turn.Trace...)
+			recording.step(schema.RecordingStepTools, iteration, turn.Trace)
 			if !ok {
 				endLoop = true
 			}
@@ -161,7 +238,7 @@ func (m *Manager) Chat(ctx context.Context, req schema.ChatRequest, fn opt.Strea
 		message = nextMessage
 	}
 
-	if err := m.persistChatLoop(ctx, req.Session, chatMessagesToPersist(conversation, conversationStart, loopErr == nil), usageEntries, overhead); err != nil {
+	if err := m.persistChatLoop(ctx, req.Session, pending, chatMessagesToPersist(conversation, conversationStart, loopErr == nil), usageEntries, overhead, uuid.Nil); err != nil {
 		if loopErr != nil {
 			return nil, errors.Join(loopErr, err)
 		}
@@ -179,18 +256,248 @@ func (m *Manager) Chat(ctx context.Context, req schema.ChatRequest, fn opt.Strea
 			Role:    turn.Reply.Role,
 			Content: turn.Reply.Content,
 			Result:  turn.Reply.Result,
+			Markup:  schema.ParseMarkup(turn.Reply.Content),
 		},
-		Usage: turn.Usage,
+		Usage:      turn.Usage,
+		Trace:      trace,
+		Recording:  recording.id(),
+		Compaction: plan.compaction,
 	})
+	if _, warning := m.resolveModel(types.Value(session.GeneratorMeta.Model)); warning != "" {
+		response.Warnings = append(response.Warnings, warning)
+	}
+	if substitution != "" {
+		response.Warnings = append(response.Warnings, substitution)
+	}
+	response.Warnings = append(response.Warnings, warnings...)
+
+	// Archive the request with the response
+	if response.Request, err = m.archive(ctx, schema.ArchiveInsert{
+		Type:     schema.UsageTypeChat,
+		User:     uuid.UUID(user.Sub),
+		Session:  req.Session,
+		Provider: model.OwnedBy,
+		Model:    model.Name,
+	}, req, response); err != nil {
+		return nil, err
+	}
+	recording.step(schema.RecordingStepResult, turn.Iteration, response)
+
+	// Return the response
+	return response, nil
+}
+
+// DryRunChat returns the request which Chat would send to the provider for
+// the next turn, without calling the provider or changing the session.
+func (m *Manager) DryRunChat(ctx context.Context, req schema.ChatRequest, user *auth.UserInfo) (_ *schema.ChatDryRun, err error) {
+	// Otel span
+	ctx, endSpan := otel.StartSpan(m.tracer, ctx, "DryRunChat",
+		attribute.String("req", types.Stringify(req)),
+		attribute.String("user", types.Stringify(user)),
+	)
+	defer func() { endSpan(err) }()
+
+	// Resolve the turn in the same way as Chat
+	plan, err := m.planChat(ctx, req, user, true)
+	if err != nil {
+		return nil, err
+	}
+
+	// Return the response
+	return m.chatDryRun(req, plan)
+}
+
+// chatDryRun describes the request which is sent to the provider for the
+// first iteration of a planned turn
+func (m *Manager) chatDryRun(req schema.ChatRequest, plan *chatPlan) (*schema.ChatDryRun, error) {
+	// The conversation as the provider would receive it
+	conversation := plan.conversation
+	if m.dedup {
+		conversation = dedupToolResults(conversation)
+	}
+	messages := schema.Normalize(plan.provider.Provider, slices.Concat(conversation, schema.Conversation{plan.message}))
+	if err := schema.ValidateFor(plan.provider.Provider, messages); err != nil {
+		return nil, err
+	}
+	options, err := dryRunOptions(plan.opts)
+	if err != nil {
+		return nil, err
+	}
+
+	systemPrompt := types.Value(plan.session.GeneratorMeta.SystemPrompt)
+	response := &schema.ChatDryRun{
+		Session:      req.Session,
+		Provider:     plan.provider.Name,
+		Model:        plan.model.Name,
+		SystemPrompt: systemPrompt,
+		Options:      options,
+		Tools:        slices.Sorted(maps.Keys(plan.offered)),
+		Messages:     messages,
+		Tokens:       messages.Tokens() + estimateSystemPromptTokens(systemPrompt),
+		Compaction:   plan.compaction,
+	}
+	if _, warning := m.resolveModel(types.Value(plan.session.GeneratorMeta.Model)); warning != "" {
+		response.Warnings = append(response.Warnings, warning)
+	}
+	response.Warnings = append(response.Warnings, plan.warnings...)
 
 	// Return the response
 	return response, nil
 }
 
+// planChat resolves the state for a chat turn before the provider is called:
+// the session, the persisted and pending conversation, the generator and its
+// options, the tools offered to the model and the next user message. When
+// dryRun is true, a conversation which is compacted is not summarized.
+func (m *Manager) planChat(ctx context.Context, req schema.ChatRequest, user *auth.UserInfo, dryRun bool) (*chatPlan, error) {
+	// Load the current session state.
+	session, err := m.GetSession(ctx, req.Session, user)
+	if err != nil {
+		return nil, err
+	}
+
+	// Load the persisted conversation history in chronological order, and
+	// separate the pending input which has not yet been sent.
+	conversation, err := m.conversationForSession(ctx, req.Session, user)
+	if err != nil {
+		return nil, err
+	}
+	conversation, pending := conversation.SplitPending()
+
+	// Fold the per-request system prompt into the session prompt.
+	if prompt := strings.TrimSpace(req.SystemPrompt); prompt != "" {
+		session.GeneratorMeta.SystemPrompt = mergeSystemPrompt(session.GeneratorMeta.SystemPrompt, prompt)
+	}
+
+	// Score the input for jailbreak attempts, hardening the system prompt
+	// for the turn.
+	jailbreak, prompt, err := m.screenJailbreak(ctx, req.Text, session.GeneratorMeta.SystemPrompt, user)
+	if err != nil {
+		return nil, err
+	}
+	session.GeneratorMeta.SystemPrompt = prompt
+
+	// Determine the tools we are going to use in this conversation loop.
+	tools, err := m.toolsForUser(ctx, user, req.Tools)
+	if err != nil {
+		return nil, err
+	}
+
+	// On the first chat turn, add a memory-aware prompt when the memory connector is available.
+	if prompt, err := firstTurnMemoryPrompt(ctx, req.Session, conversation, tools); err == nil && prompt != "" {
+		session.GeneratorMeta.SystemPrompt = mergeSystemPrompt(session.GeneratorMeta.SystemPrompt, prompt)
+	}
+
+	// A side thread continues from a message of its parent session, so the
+	// parent history is sent with the thread but is not stored again
+	if session.ParentMessage != 0 {
+		history, err := m.threadHistory(ctx, session, user)
+		if err != nil {
+			return nil, err
+		}
+		conversation = slices.Concat(history, conversation)
+	}
+
+	// Compact the start of the conversation when it approaches the context
+	// window of the model, and add any summary to the system prompt
+	conversation, summary, compaction, err := m.compactConversation(ctx, session, conversation, user, dryRun)
+	if err != nil {
+		return nil, err
+	}
+	session.GeneratorMeta.SystemPrompt = mergeSystemPrompt(session.GeneratorMeta.SystemPrompt, compactionPrompt(summary))
+
+	// Resolve the model, generator, and provider options for this turn.
+	provider, model, generator, opts, err := m.chatGenerator(ctx, session.GeneratorMeta, user, req.Prefill)
+	if err != nil {
+		return nil, err
+	}
+	warnings := optWarnings(opts)
+	if dryRun && compaction != nil && compaction.Strategy == schema.CompactionSummarize {
+		warnings = append(warnings, "the removed messages are not summarized in a dry run")
+	}
+
+	// Build the next user turn, which includes any pending input.
+	// TODO: Append the attachments.
+	message, err := chatMessage(req.Text, pending)
+	if err != nil {
+		return nil, err
+	}
+
+	// Send the context of the environment before the input, when it has
+	// changed since it was last sent
+	contexts, contextWarnings, err := m.turnContext(ctx, session, conversation, user)
+	if err != nil {
+		return nil, err
+	}
+	message.Content = slices.Concat(contexts, message.Content)
+	warnings = append(warnings, contextWarnings...)
+
+	// Send the text of images to models which do not accept them
+	message, ocrOpts, err := m.ocrFallback(ctx, model, message)
+	if err != nil {
+		return nil, err
+	}
+	turnOpts := ocrOpts
+	warnings = append(warnings, optWarnings(ocrOpts)...)
+
+	// Add the tools which are relevant to the turn to the provider options.
+	// All the tools can still be called, for example by calls in the history.
+	offered, toolWarnings, err := m.selectTools(ctx, tools, message.Text(), user)
+	if err != nil {
+		return nil, err
+	}
+	warnings = append(warnings, toolWarnings...)
+	if len(offered) > 0 {
+		turnOpts = append(turnOpts, offered.Opts()...)
+	}
+
+	return &chatPlan{
+		session:      session,
+		conversation: conversation,
+		pending:      pending,
+		provider:     provider,
+		model:        model,
+		generator:    generator,
+		opts:         slices.Concat(opts, turnOpts),
+		turnOpts:     turnOpts,
+		tools:        tools,
+		offered:      offered,
+		message:      message,
+		jailbreak:    jailbreak,
+		compaction:   compaction,
+		warnings:     warnings,
+	}, nil
+}
+
+// chatGenerator resolves the model, generator and provider options for a
+// chat turn, and starts the reply with the prefill
+func (m *Manager) chatGenerator(ctx context.Context, meta schema.GeneratorMeta, user *auth.UserInfo, prefill string) (*schema.Provider, *schema.Model, llm.Generator, []opt.Opt, error) {
+	provider, model, generator, opts, err := m.generatorFromMeta(ctx, meta, user, generationContextChat)
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+	prefillOpts, generator := m.prefillOpts(provider.Provider, generator, prefill, generationContextChat)
+	return provider, model, generator, append(opts, prefillOpts...), nil
+}
+
 func (m *Manager) executeConversationTurn(ctx context.Context, session uuid.UUID, user *auth.UserInfo, provider *schema.Provider, model *schema.Model, generator llm.Generator, systemPrompt string, conversation *schema.Conversation, message *schema.Message, opts ...opt.Opt) (*conversationTurn, error) {
+	// Validate the conversation as the provider will receive it
+	if err := schema.ValidateFor(provider.Provider, schema.Normalize(provider.Provider, slices.Concat(*conversation, schema.Conversation{message}))); err != nil {
+		return nil, err
+	}
+
+	// Estimate the usage while the reply is streamed
+	var estimator *usageStream
+	if applied, err := opt.Apply(opts...); err == nil && applied.GetStream() != nil {
+		estimator = newUsageStream(applied.GetStream(), slices.Concat(*conversation, schema.Conversation{message}).EstimateTokens()+estimateSystemPromptTokens(systemPrompt))
+		opts = slices.Concat(opts, []opt.Opt{opt.WithStream(estimator.Write)})
+	}
+
 	startLen := conversation.Len()
 	reply, usage, err := generator.WithSession(ctx, types.Value(model), conversation, message, opts...)
 	if err != nil {
+		// Remove anything the generator appended, so the turn can be retried
+		*conversation = (*conversation)[:startLen]
 		return nil, err
 	}
 	if conversation.Len() < startLen+2 {
@@ -214,9 +521,37 @@ func (m *Manager) executeConversationTurn(ctx context.Context, session uuid.UUID
 		}
 	}
 
+	// Report the usage with the estimate, when the reply was streamed
+	if estimator != nil {
+		turn.Usage = estimator.Reconcile(turn.Usage)
+	}
+
 	return turn, nil
 }
 
+// dryRunOptions returns the provider options which can be reported. Tools,
+// the system prompt and warnings are reported separately, and values which
+// cannot be encoded as JSON are skipped.
+func dryRunOptions(opts []opt.Opt) (map[string]any, error) {
+	applied, err := opt.Apply(opts...)
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[string]any)
+	for _, key := range applied.Keys() {
+		switch key {
+		case opt.ToolKey, opt.SystemPromptKey, opt.WarningKey:
+			continue
+		}
+		value := applied.Get(key)
+		if _, err := json.Marshal(value); err != nil {
+			continue
+		}
+		result[key] = value
+	}
+	return result, nil
+}
+
 func conversationTurnOverhead(conversation schema.Conversation, reply *schema.Message, usage *schema.UsageMeta, systemPrompt string) uint {
 	if usage == nil || usage.InputTokens == 0 {
 		return 0
@@ -379,6 +714,7 @@ func (m *Manager) nextConversationIteration(ctx context.Context, session uuid.UU
 	// TODO: Handle the special structured-output tool here before executing normal tool calls.
 
 	content := make([]schema.ContentBlock, len(calls))
+	turn.Trace = make([]schema.ToolTrace, len(calls))
 	var wg sync.WaitGroup
 	for i, call := range calls {
 		if fn != nil {
@@ -387,7 +723,9 @@ func (m *Manager) nextConversationIteration(ctx context.Context, session uuid.UU
 		wg.Add(1)
 		go func(i int, call schema.ToolCall) {
 			defer wg.Done()
+			start := time.Now()
 			content[i] = m.runToolCall(ctx, session, tools, call, i)
+			turn.Trace[i] = schema.NewToolTrace(turn.Iteration, call, content[i], time.Since(start))
 		}(i, call)
 	}
 	wg.Wait()
@@ -418,6 +756,16 @@ func (m *Manager) runToolCall(ctx context.Context, session uuid.UUID, tools tool
 		err = schema.ErrNotFound.Withf("tool %q", call.Name)
 		return schema.NewToolError(call.ID, call.Name, err)
 	}
+	// Simulated tools do not change anything, so are run without approval
+	_, simulated := tool.(*simulatedTool)
+	if approve := toolApprovalFromContext(ctx); approve != nil && !simulated {
+		if err = approve(ctx, session, call); err != nil {
+			return schema.NewToolError(call.ID, call.Name, err)
+		}
+	} else if m.approval[call.Name] && !simulated {
+		err = schema.ErrBadParameter.Withf("tool %q requires approval, which is not available for this request", call.Name)
+		return schema.NewToolError(call.ID, call.Name, err)
+	}
 	if session != uuid.Nil {
 		ctx = toolkit.WithSession(ctx, session.String())
 	}
@@ -427,7 +775,31 @@ func (m *Manager) runToolCall(ctx context.Context, session uuid.UUID, tools tool
 		return schema.NewToolError(call.ID, call.Name, err)
 	}
 
-	return schema.NewToolResult(call.ID, call.Name, output)
+	result = schema.NewToolResult(call.ID, call.Name, output)
+	if title := tool.Meta().Title; title != "" {
+		result.Annotate(schema.AnnotationTitle, title)
+	}
+	return result
+}
+
+// chatMessage returns the user message for a chat turn. Pending input stored
+// for the session is sent first, followed by the request text.
+func chatMessage(text string, pending schema.Conversation) (*schema.Message, error) {
+	if len(pending) == 0 {
+		if strings.TrimSpace(text) == "" {
+			return nil, schema.ErrBadParameter.With("text is required when there is no pending input")
+		}
+		return schema.NewMessage(schema.RoleUser, text)
+	}
+
+	message := &schema.Message{Role: schema.RoleUser}
+	for _, input := range pending {
+		message.Content = append(message.Content, input.Content...)
+	}
+	if text = strings.TrimSpace(text); text != "" {
+		message.Content = append(message.Content, schema.ContentBlock{Text: types.Ptr(text)})
+	}
+	return message, nil
 }
 
 func chatMessagesToPersist(conversation schema.Conversation, start int, persist bool) schema.Conversation {
@@ -437,21 +809,32 @@ func chatMessagesToPersist(conversation schema.Conversation, start int, persist
 	return conversation[start:]
 }
 
-func (m *Manager) persistChatLoop(ctx context.Context, session uuid.UUID, messages schema.Conversation, usageEntries []schema.UsageInsert, overhead uint) error {
-	if len(messages) == 0 && len(usageEntries) == 0 && overhead == 0 {
+// persistChatLoop stores the messages and usage for a chat turn. Pending
+// input which was sent in the turn is removed, since it is stored again as
+// part of the user message for the turn. When a replica recovers the turn,
+// owner is the replica which claimed its checkpoint.
+func (m *Manager) persistChatLoop(ctx context.Context, session uuid.UUID, pending, messages schema.Conversation, usageEntries []schema.UsageInsert, overhead uint, owner uuid.UUID) error {
+	if len(messages) == 0 && len(usageEntries) == 0 && overhead == 0 && !m.checkpoints {
 		return nil
 	}
 
 	return m.PoolConn.Tx(ctx, func(conn pg.Conn) error {
+		if len(pending) > 0 && len(messages) > 0 {
+			ids := make([]uint64, 0, len(pending))
+			for _, message := range pending {
+				ids = append(ids, message.ID)
+			}
+			if err := conn.Delete(ctx, nil, schema.MessagePendingSelector{Session: session, IDs: ids}); err != nil {
+				return pg.NormalizeError(err)
+			}
+		}
 		for _, message := range messages {
 			if message == nil {
 				continue
 			}
-			var inserted schema.MessageInsert
-			if err := conn.Insert(ctx, &inserted, schema.MessageInsert{Session: session, Message: types.Value(message)}); err != nil {
-				return pg.NormalizeError(err)
+			if err := m.insertMessage(ctx, conn, session, message); err != nil {
+				return err
 			}
-			*message = inserted.Message
 		}
 		for _, usageEntry := range usageEntries {
 			if err := conn.Insert(ctx, nil, usageEntry); err != nil {
@@ -464,13 +847,21 @@ func (m *Manager) persistChatLoop(ctx context.Context, session uuid.UUID, messag
 			}
 		}
 
-		return nil
+		// The turn is stored, so it no longer needs to be recovered
+		return m.deleteCheckpoint(ctx, conn, session, owner)
 	})
 }
 
 func (m *Manager) conversationForSession(ctx context.Context, session uuid.UUID, user *auth.UserInfo) (schema.Conversation, error) {
+	return m.conversationUntil(ctx, session, 0, user)
+}
+
+// conversationUntil returns the messages of a session in chronological
+// order, up to and including a message ID, or all the messages when until
+// is zero
+func (m *Manager) conversationUntil(ctx context.Context, session uuid.UUID, until uint64, user *auth.UserInfo) (schema.Conversation, error) {
 	conn := m.PoolConn.With("session", session, "user", user.Sub)
-	req := schema.MessageListRequest{}
+	req := schema.MessageListRequest{Until: until}
 	conversation := make(schema.Conversation, 0)
 	for {
 		var page schema.MessageList
@@ -495,13 +886,27 @@ func (m *Manager) conversationForSession(ctx context.Context, session uuid.UUID,
 	return conversation, nil
 }
 
+// toolsForUser returns the tools available to the user, selected by a list
+// of tool patterns. A nil list selects all tools and an empty list selects
+// none. Patterns may be globs, and patterns prefixed with "!" deny tools.
 func (m *Manager) toolsForUser(ctx context.Context, user *auth.UserInfo, tools []string) (toolMap, error) {
+	result := make(toolMap)
+	if tools != nil && len(tools) == 0 {
+		return result, nil
+	}
+	filter, err := schema.NewToolFilter(tools...)
+	if err != nil {
+		return nil, err
+	}
+
+	// Look up literal names directly, otherwise list all tools and filter them
 	limit := toolSelectionPageSize
 	req := schema.ToolListRequest{
 		OffsetLimit: pg.OffsetLimit{Limit: &limit},
-		Name:        tools,
 	}
-	result := make(toolMap)
+	if names, ok := filter.Names(); ok {
+		req.Name = names
+	}
 	for {
 		page, _, err := m.listTools(ctx, req, user)
 		if err != nil {
@@ -510,7 +915,7 @@ func (m *Manager) toolsForUser(ctx context.Context, user *auth.UserInfo, tools [
 		for _, tool := range page {
 			// Normalize the tool name and add to the map
 			name := tool.Name()
-			if name == "" {
+			if name == "" || !filter.Match(name) || m.offlineTool(tool) {
 				continue
 			} else {
 				name = normalizeToolMapKey(name)
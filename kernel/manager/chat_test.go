@@ -10,6 +10,7 @@ import (
 	uuid "github.com/google/uuid"
 	schema "github.com/mutablelogic/go-llm/kernel/schema"
 	memoryschema "github.com/mutablelogic/go-llm/memory/schema"
+	opt "github.com/mutablelogic/go-llm/pkg/opt"
 	llmtest "github.com/mutablelogic/go-llm/pkg/test"
 	toolkit "github.com/mutablelogic/go-llm/toolkit"
 	types "github.com/mutablelogic/go-server/pkg/types"
@@ -305,6 +306,42 @@ func TestNextConversationIterationRunsTools(t *testing.T) {
 	assert.Equal(t, []string{"tool:builtin__echo: Echo input"}, streamed)
 }
 
+func TestNextConversationIterationRecordsTrace(t *testing.T) {
+	m := &Manager{}
+	turn := &conversationTurn{
+		Iteration: 3,
+		Reply: &schema.Message{
+			Role: schema.RoleAssistant,
+			Content: []schema.ContentBlock{
+				{ToolCall: &schema.ToolCall{ID: "call_1", Name: "builtin__echo", Input: json.RawMessage(`{"value":"hello"}`)}},
+				{ToolCall: &schema.ToolCall{ID: "call_2", Name: "missing__tool"}},
+			},
+			Result: schema.ResultToolCall,
+		},
+	}
+	tools := toolMap{
+		"builtin__echo": &listToolsMockTool{
+			name: "builtin__echo",
+			run: func(_ context.Context, input json.RawMessage) (any, error) {
+				return json.RawMessage(input), nil
+			},
+		},
+	}
+
+	_, ok, err := m.nextConversationIteration(context.Background(), uuid.New(), turn, tools, nil)
+	if !assert.NoError(t, err) || !assert.True(t, ok) {
+		return
+	}
+	if assert.Len(t, turn.Trace, 2) {
+		assert.Equal(t, uint(3), turn.Trace[0].Iteration)
+		assert.Equal(t, "call_1", turn.Trace[0].ID)
+		assert.JSONEq(t, `{"value":"hello"}`, turn.Trace[0].Result)
+		assert.Empty(t, turn.Trace[0].Error)
+		assert.Equal(t, "call_2", turn.Trace[1].ID)
+		assert.Equal(t, `not found: tool "missing__tool"`, turn.Trace[1].Error)
+	}
+}
+
 func TestNextConversationIterationReturnsToolErrorForMissingTool(t *testing.T) {
 	m := &Manager{}
 	turn := &conversationTurn{
@@ -381,3 +418,47 @@ func TestNextConversationIterationErrorsWithoutToolCalls(t *testing.T) {
 	assert.False(t, ok)
 	assert.Nil(t, message)
 }
+
+func TestToolsForUserSelectsByPattern(t *testing.T) {
+	m := newListToolsManager(t)
+
+	tools, err := m.toolsForUser(context.Background(), nil, nil)
+	if assert.NoError(t, err) {
+		assert.Len(t, tools, 3)
+	}
+
+	tools, err = m.toolsForUser(context.Background(), nil, []string{})
+	if assert.NoError(t, err) {
+		assert.Empty(t, tools)
+	}
+
+	tools, err = m.toolsForUser(context.Background(), nil, []string{"builtin.alpha", "bravo"})
+	if assert.NoError(t, err) {
+		assert.Len(t, tools, 2)
+		assert.Contains(t, tools, "builtin__alpha")
+		assert.Contains(t, tools, "builtin__bravo")
+	}
+
+	tools, err = m.toolsForUser(context.Background(), nil, []string{"builtin.*", "!charlie"})
+	if assert.NoError(t, err) {
+		assert.Len(t, tools, 2)
+		assert.NotContains(t, tools, "builtin__charlie")
+	}
+
+	_, err = m.toolsForUser(context.Background(), nil, []string{"builtin.["})
+	assert.ErrorIs(t, err, schema.ErrBadParameter)
+}
+
+func TestDryRunOptions(t *testing.T) {
+	assert := assert.New(t)
+
+	options, err := dryRunOptions([]opt.Opt{
+		opt.SetUint(opt.MaxTokensKey, 1024),
+		opt.SetString(opt.SystemPromptKey, "be brief"),
+		opt.AddString(opt.WarningKey, "ignored"),
+		opt.SetAny("callback", func() {}),
+		opt.WithStream(func(string, string) {}),
+	})
+	assert.NoError(err)
+	assert.Equal(map[string]any{opt.MaxTokensKey: "1024"}, options)
+}
@@ -0,0 +1,202 @@
+package manager
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"slices"
+	"time"
+
+	// Packages
+	uuid "github.com/google/uuid"
+	auth "github.com/mutablelogic/go-auth/auth/schema"
+	otel "github.com/mutablelogic/go-client/pkg/otel"
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	pg "github.com/mutablelogic/go-pg"
+	types "github.com/mutablelogic/go-server/pkg/types"
+	attribute "go.opentelemetry.io/otel/attribute"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+const (
+	// checkpointInterval is how often a replica marks the checkpoints of its
+	// turns as seen, and looks for checkpoints which were abandoned
+	checkpointInterval = time.Minute
+
+	// checkpointTimeout is the time after which a checkpoint which has not
+	// been seen is taken as abandoned by a replica which stopped
+	checkpointTimeout = 5 * time.Minute
+)
+
+var (
+	errToolInterrupted = errors.New("the server stopped before the tool returned a result, so it may or may not have run")
+)
+
+// checkpointInterruptedText is the reply stored for a turn which is ended
+// rather than resumed
+const checkpointInterruptedText = "This response was interrupted because the server stopped. Please send your message again."
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// checkpoint stores the state of a chat turn after an iteration of its tool
+// loop, when checkpoints are enabled. A checkpoint which cannot be stored
+// does not stop the turn.
+func (m *Manager) checkpoint(ctx context.Context, checkpoint schema.CheckpointInsert) {
+	if !m.checkpoints {
+		return
+	}
+	var err error
+	ctx, endSpan := otel.StartSpan(m.tracer, ctx, "Chat.Checkpoint",
+		attribute.String("session", checkpoint.Session.String()),
+		attribute.Int("iteration", int(checkpoint.Iteration)),
+	)
+	defer func() { endSpan(err) }()
+
+	checkpoint.Owner = m.instance
+	if err = m.PoolConn.Insert(ctx, nil, checkpoint); err != nil {
+		err = pg.NormalizeError(err)
+	}
+}
+
+// deleteCheckpoint removes the checkpoint of a session, when checkpoints are
+// enabled, in the transaction which stores the turn. When the turn is
+// recovered, the checkpoint is only removed while the replica which recovers
+// it still owns it, so that the transaction fails when another replica has
+// recovered the turn.
+func (m *Manager) deleteCheckpoint(ctx context.Context, conn pg.Conn, session, owner uuid.UUID) error {
+	if !m.checkpoints {
+		return nil
+	}
+	if owner != uuid.Nil {
+		if err := conn.Delete(ctx, nil, schema.CheckpointClaimSelector{Session: session, Owner: owner}); err != nil {
+			if err = pg.NormalizeError(err); errors.Is(err, schema.ErrNotFound) {
+				return schema.ErrConflict.Withf("chat turn for session %q was recovered by another replica", session)
+			}
+			return err
+		}
+		return nil
+	}
+	if err := conn.Delete(ctx, nil, schema.CheckpointSessionSelector(session)); err != nil && !errors.Is(pg.NormalizeError(err), schema.ErrNotFound) {
+		return pg.NormalizeError(err)
+	}
+	return nil
+}
+
+// runCheckpoints marks the checkpoints of the turns which this replica is
+// running as seen, and then resumes or ends the turns whose replica stopped.
+// Abandoned checkpoints are claimed in one statement which skips rows that
+// another replica is claiming, so each turn is recovered by one replica.
+func (m *Manager) runCheckpoints(ctx context.Context, logger *slog.Logger) {
+	if err := m.PoolConn.Update(ctx, nil, schema.CheckpointOwnerSelector(m.instance), schema.CheckpointTouch{}); err != nil && !errors.Is(pg.NormalizeError(err), schema.ErrNotFound) {
+		logger.ErrorContext(ctx, "failed to mark checkpoints as seen", "error", err.Error())
+	}
+
+	var checkpoints schema.CheckpointList
+	if err := m.PoolConn.Update(ctx, &checkpoints, schema.CheckpointStaleSelector(m.clock.Now().Add(-checkpointTimeout)), schema.CheckpointClaim(m.instance)); err != nil {
+		if err = pg.NormalizeError(err); !errors.Is(err, schema.ErrNotFound) {
+			logger.ErrorContext(ctx, "failed to claim abandoned checkpoints", "error", err.Error())
+		}
+		return
+	}
+	for _, checkpoint := range checkpoints {
+		job, err := m.recoverCheckpoint(ctx, checkpoint)
+		switch {
+		case err != nil:
+			logger.ErrorContext(ctx, "failed to recover interrupted chat turn", "session", checkpoint.Session, "error", err.Error())
+			if err := m.PoolConn.Update(ctx, nil, schema.CheckpointClaimSelector{Session: checkpoint.Session, Owner: m.instance}, schema.CheckpointRelease{}); err != nil && !errors.Is(pg.NormalizeError(err), schema.ErrNotFound) {
+				logger.ErrorContext(ctx, "failed to release checkpoint", "session", checkpoint.Session, "error", err.Error())
+			}
+		case job != nil:
+			logger.InfoContext(ctx, "resumed interrupted chat turn", "session", checkpoint.Session, "iteration", checkpoint.Iteration, "job", job.ID)
+		default:
+			logger.InfoContext(ctx, "ended interrupted chat turn", "session", checkpoint.Session, "iteration", checkpoint.Iteration)
+		}
+	}
+}
+
+// recoverCheckpoint stores the messages of an interrupted turn, with error
+// results for the tool calls which did not return. When turns are resumed,
+// the last tool results are stored as pending input and the turn continues
+// as a background job, which is returned. Otherwise a reply which says the
+// turn was interrupted ends the turn.
+func (m *Manager) recoverCheckpoint(ctx context.Context, checkpoint *schema.Checkpoint) (_ *schema.Job, err error) {
+	ctx, endSpan := otel.StartSpan(m.tracer, ctx, "RecoverCheckpoint",
+		attribute.String("checkpoint", types.Stringify(checkpoint)),
+	)
+	defer func() { endSpan(err) }()
+
+	// Wait for any other turn in the session on this replica
+	unlock, err := m.lockSession(ctx, checkpoint.Session.String())
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	// Complete the history of the turn
+	messages := checkpointMessages(checkpoint.CheckpointInsert)
+	resume := m.resume && messages[len(messages)-1].Role == schema.RoleUser
+	if resume {
+		last := messages[len(messages)-1]
+		if last.Meta == nil {
+			last.Meta = make(map[string]any)
+		}
+		last.Meta[schema.MessageMetaPending] = true
+	} else {
+		reply, err := schema.NewMessage(schema.RoleAssistant, checkpointInterruptedText)
+		if err != nil {
+			return nil, err
+		}
+		reply.Result = schema.ResultError
+		messages = append(messages, reply)
+	}
+	if err := m.persistChatLoop(ctx, checkpoint.Session, checkpointPending(checkpoint.Pending), messages, checkpoint.Usage, checkpoint.Overhead, m.instance); err != nil {
+		return nil, err
+	}
+	if !resume {
+		return nil, nil
+	}
+
+	// Continue the turn with the pending tool results
+	req := checkpoint.Request
+	req.Session, req.Text = checkpoint.Session, ""
+	user := &auth.UserInfo{Sub: auth.UserID(checkpoint.User)}
+	return m.jobs.start(context.WithoutCancel(ctx), checkpoint.Session, checkpoint.User, func(ctx context.Context) (*schema.ChatResponse, error) {
+		return m.Chat(WithPriority(ctx, schema.PriorityBatch), req, nil, user)
+	}), nil
+}
+
+// checkpointMessages returns the messages of a checkpoint, followed by
+// error results for the tool calls which did not return
+func checkpointMessages(checkpoint schema.CheckpointInsert) schema.Conversation {
+	messages := slices.Clone(checkpoint.Messages)
+	if calls := checkpoint.Interrupted(); len(calls) > 0 {
+		results := &schema.Message{Role: schema.RoleUser}
+		for _, call := range calls {
+			results.Content = append(results.Content, schema.NewToolError(call.ID, call.Name, errToolInterrupted))
+		}
+		messages = append(messages, results)
+	}
+	return messages
+}
+
+// checkpointIDs returns the IDs of the pending input sent in a turn
+func checkpointIDs(pending schema.Conversation) []uint64 {
+	result := make([]uint64, 0, len(pending))
+	for _, message := range pending {
+		result = append(result, message.ID)
+	}
+	return result
+}
+
+// checkpointPending returns the pending input sent in a turn, which is
+// removed when the turn is stored
+func checkpointPending(ids []uint64) schema.Conversation {
+	result := make(schema.Conversation, 0, len(ids))
+	for _, id := range ids {
+		result = append(result, &schema.Message{ID: id})
+	}
+	return result
+}
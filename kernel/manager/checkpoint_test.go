@@ -0,0 +1,48 @@
+package manager
+
+import (
+	"testing"
+
+	// Packages
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	types "github.com/mutablelogic/go-server/pkg/types"
+	assert "github.com/stretchr/testify/assert"
+)
+
+func TestCheckpointMessages(t *testing.T) {
+	assert := assert.New(t)
+	checkpoint := schema.CheckpointInsert{Messages: schema.Conversation{
+		{Role: schema.RoleUser, Content: []schema.ContentBlock{{Text: types.Ptr("hello")}}},
+		{Role: schema.RoleAssistant, Content: []schema.ContentBlock{
+			{ToolCall: &schema.ToolCall{ID: "call_1", Name: "search"}},
+			{ToolCall: &schema.ToolCall{ID: "call_2", Name: "fetch"}},
+		}},
+	}}
+
+	// The tool calls which did not return are given error results
+	messages := checkpointMessages(checkpoint)
+	if assert.Len(messages, 3) {
+		results := messages[2]
+		assert.Equal(schema.RoleUser, results.Role)
+		if assert.Len(results.Content, 2) {
+			assert.Equal("call_1", results.Content[0].ToolResult.ID)
+			assert.True(results.Content[0].ToolResult.IsError)
+			assert.Equal("fetch", results.Content[1].ToolResult.Name)
+		}
+	}
+	assert.Len(checkpoint.Messages, 2)
+
+	// A checkpoint with the results is unchanged
+	checkpoint.Messages = messages
+	assert.Len(checkpointMessages(checkpoint), 3)
+}
+
+func TestCheckpointIDs(t *testing.T) {
+	assert := assert.New(t)
+	pending := schema.Conversation{{ID: 3}, {ID: 4}}
+	assert.Equal([]uint64{3, 4}, checkpointIDs(pending))
+	assert.Equal([]uint64{}, checkpointIDs(nil))
+	if messages := checkpointPending([]uint64{3, 4}); assert.Len(messages, 2) {
+		assert.Equal(uint64(4), messages[1].ID)
+	}
+}
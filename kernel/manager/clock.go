@@ -0,0 +1,116 @@
+package manager
+
+import (
+	"bytes"
+	"sync"
+	"time"
+
+	// Packages
+	uuid "github.com/google/uuid"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// Clock returns the current time. A nil clock uses the system clock.
+type Clock func() time.Time
+
+// IDGenerator returns a new unique ID for a job, recording or archive
+// record. A nil generator returns random IDs.
+type IDGenerator func() uuid.UUID
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// RandomID returns a generator of random (version 4) IDs, which is the
+// default
+func RandomID() IDGenerator {
+	return uuid.New
+}
+
+// SortableID returns a generator of time-ordered (version 7) IDs, which sort
+// in the order they were created like a ULID, and so are indexed and
+// replicated in that order. The time of each ID is read from the clock, or
+// the system clock when it is nil, and IDs from the same generator increase
+// even when the clock does not.
+func SortableID(clock Clock) IDGenerator {
+	var mu sync.Mutex
+	var last uuid.UUID
+	return func() uuid.UUID {
+		mu.Lock()
+		defer mu.Unlock()
+		id, err := uuid.NewV7()
+		if err != nil {
+			return uuid.New()
+		}
+		putMillis(id[:], clock.Now())
+		if bytes.Compare(id[:], last[:]) <= 0 {
+			id = increment(last)
+		}
+		last = id
+		return id
+	}
+}
+
+// Now returns the current time
+func (c Clock) Now() time.Time {
+	if c == nil {
+		return time.Now()
+	}
+	return c()
+}
+
+// Since returns the time elapsed since t
+func (c Clock) Since(t time.Time) time.Duration {
+	return c.Now().Sub(t)
+}
+
+// New returns a new ID
+func (g IDGenerator) New() uuid.UUID {
+	if g == nil {
+		return uuid.New()
+	}
+	return g()
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// putMillis sets the timestamp of a version 7 ID, which is the first six
+// bytes in milliseconds since the epoch
+func putMillis(id []byte, t time.Time) {
+	ms := uint64(t.UnixMilli())
+	for i := 5; i >= 0; i-- {
+		id[i] = byte(ms)
+		ms >>= 8
+	}
+}
+
+// increment returns the ID which follows id in the random bits of a version
+// 7 ID, keeping its version and variant
+func increment(id uuid.UUID) uuid.UUID {
+	for i := len(id) - 1; i >= 0; i-- {
+		switch {
+		case i == 6:
+			// Low nibble only, the high nibble is the version
+			if id[i]&0x0f != 0x0f {
+				id[i]++
+				return id
+			}
+			id[i] &^= 0x0f
+		case i == 8:
+			// Low six bits only, the high bits are the variant
+			if id[i]&0x3f != 0x3f {
+				id[i]++
+				return id
+			}
+			id[i] &^= 0x3f
+		default:
+			id[i]++
+			if id[i] != 0 {
+				return id
+			}
+		}
+	}
+	return id
+}
@@ -0,0 +1,58 @@
+package manager
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	// Packages
+	uuid "github.com/google/uuid"
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	assert "github.com/stretchr/testify/assert"
+)
+
+func TestSortableID(t *testing.T) {
+	assert := assert.New(t)
+
+	// IDs from a stopped clock still increase, and carry the time of the clock
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	ids := SortableID(func() time.Time { return now })
+	var last uuid.UUID
+	for range 1000 {
+		id := ids.New()
+		assert.Equal(uuid.Version(7), id.Version())
+		assert.Equal(uuid.RFC4122, id.Variant())
+		assert.Positive(bytes.Compare(id[:], last[:]))
+		last = id
+	}
+	sec, nsec := last.Time().UnixTime()
+	assert.Equal(now, time.Unix(sec, nsec).UTC())
+
+	// A nil clock and generator use the system clock and random IDs
+	var clock Clock
+	assert.WithinDuration(time.Now(), clock.Now(), time.Second)
+	var random IDGenerator
+	assert.Equal(uuid.Version(4), random.New().Version())
+}
+
+func TestJobClock(t *testing.T) {
+	assert := assert.New(t)
+
+	// Jobs take their ID and times from the list
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	id := uuid.MustParse("00000000-0000-4000-8000-000000000001")
+	l := jobList{
+		clock: func() time.Time { return now },
+		ids:   func() uuid.UUID { return id },
+	}
+	job := l.start(context.Background(), uuid.New(), uuid.Nil, func(ctx context.Context) (*schema.ChatResponse, error) {
+		return &schema.ChatResponse{}, nil
+	})
+	assert.Equal(id, job.ID)
+	assert.Equal(now, job.CreatedAt)
+	assert.Eventually(func() bool {
+		job, err := l.get(id, uuid.Nil)
+		return err == nil && job.Status == schema.JobCompleted && job.CompletedAt != nil && job.CompletedAt.Equal(now)
+	}, time.Second, time.Millisecond)
+}
@@ -0,0 +1,58 @@
+package manager
+
+import (
+	"context"
+
+	// Packages
+	uuid "github.com/google/uuid"
+	auth "github.com/mutablelogic/go-auth/auth/schema"
+	otel "github.com/mutablelogic/go-client/pkg/otel"
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	codebase "github.com/mutablelogic/go-llm/pkg/codebase"
+	tokenizer "github.com/mutablelogic/go-llm/pkg/tokenizer"
+	types "github.com/mutablelogic/go-server/pkg/types"
+	attribute "go.opentelemetry.io/otel/attribute"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// AppendContext reads the files of a directory on the host of the manager,
+// and appends them to a session as pending input, which is sent to the model
+// with the next chat turn. Tokens are counted with the tokenizer of the
+// session provider, and the options set the token budget and which files are
+// read. If user is non-nil, the session must be owned by that user.
+func (m *Manager) AppendContext(ctx context.Context, session uuid.UUID, root string, user *auth.UserInfo, opts ...codebase.Opt) (_ *schema.Message, _ *codebase.Context, err error) {
+	ctx, endSpan := otel.StartSpan(m.tracer, ctx, "AppendContext",
+		attribute.String("session", session.String()),
+		attribute.String("root", root),
+		attribute.String("user", types.Stringify(user)),
+	)
+	defer func() { endSpan(err) }()
+
+	// Get the session, for the provider
+	result, err := m.GetSession(ctx, session, user)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Read the directory, counting tokens for the provider unless the options
+	// set a tokenizer
+	opts = append([]codebase.Opt{codebase.WithTokens(codebase.DefaultTokens, tokenizer.ForProvider(types.Value(result.GeneratorMeta.Provider)))}, opts...)
+	files, err := codebase.Read(root, opts...)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(files.Files) == 0 {
+		return nil, files, schema.ErrBadParameter.Withf("no files read from %q", root)
+	}
+
+	// Append the files
+	message, err := m.AppendMessage(ctx, session, files.Message(), user)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Return success
+	return message, files, nil
+}
@@ -0,0 +1,98 @@
+package manager
+
+import (
+	"context"
+	"testing"
+
+	// Packages
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	types "github.com/mutablelogic/go-server/pkg/types"
+	assert "github.com/stretchr/testify/assert"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// HELPERS
+
+// compactionTestConversation returns a conversation of three turns, each of
+// which uses 20 tokens
+func compactionTestConversation() schema.Conversation {
+	var result schema.Conversation
+	for i, text := range []string{"one", "two", "three"} {
+		result = append(result,
+			&schema.Message{ID: uint64(2*i + 1), Role: schema.RoleUser, Tokens: 10, Content: []schema.ContentBlock{{Text: types.Ptr(text)}}},
+			&schema.Message{ID: uint64(2*i + 2), Role: schema.RoleAssistant, Tokens: 10, Content: []schema.ContentBlock{{Text: types.Ptr("reply " + text)}}},
+		)
+	}
+	return result
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// TESTS
+
+func TestCompactionStrategyCut(t *testing.T) {
+	assert := assert.New(t)
+	conversation := compactionTestConversation()
+
+	assert.Equal(2, CompactTruncate().Cut(conversation, 40))
+	assert.Equal(4, CompactTruncate().Cut(conversation, 0))
+	assert.Equal(0, CompactTruncate().Cut(conversation, 60))
+
+	// The sliding window keeps the latest turns, or fewer when they do not fit
+	assert.Equal(2, CompactSlidingWindow(2).Cut(conversation, 60))
+	assert.Equal(4, CompactSlidingWindow(2).Cut(conversation, 20))
+	assert.Equal(4, CompactSlidingWindow(0).Cut(conversation, 60))
+	assert.Equal(0, CompactSlidingWindow(5).Cut(conversation, 60))
+}
+
+func TestCompactionStrategyCondense(t *testing.T) {
+	assert := assert.New(t)
+	removed := compactionTestConversation()[:2]
+
+	var model, prompt, text string
+	summarize := func(_ context.Context, m, p, t string) (string, error) {
+		model, prompt, text = m, p, t
+		return "the user said one", nil
+	}
+
+	// The summary continues from the previous summary
+	summary, err := CompactSummarize(" judge ").Condense(context.Background(), summarize, "earlier", removed)
+	if assert.NoError(err) {
+		assert.Equal("the user said one", summary)
+		assert.Equal("judge", model)
+		assert.Equal(compactionSummaryPrompt, prompt)
+		assert.Equal("<summary>\nearlier\n</summary>\n\n<conversation>\nuser: one\nassistant: reply one\n</conversation>", text)
+	}
+
+	// The other strategies drop the removed messages
+	summary, err = CompactTruncate().Condense(context.Background(), nil, "earlier", removed)
+	assert.NoError(err)
+	assert.Empty(summary)
+}
+
+func TestCompactedCut(t *testing.T) {
+	assert := assert.New(t)
+	conversation := compactionTestConversation()
+
+	assert.Equal(0, compactedCut(conversation, 0))
+	assert.Equal(2, compactedCut(conversation, 2))
+	assert.Equal(4, compactedCut(conversation, 3))
+	assert.Equal(4, compactedCut(conversation, 6))
+	assert.Equal(uint64(6), lastMessageID(conversation))
+}
+
+func TestCompactionPrompt(t *testing.T) {
+	assert := assert.New(t)
+	assert.Empty(compactionPrompt(" "))
+	assert.Equal(compactionSummaryHeading+"\n\nsummary", compactionPrompt("summary\n"))
+}
+
+func TestWithCompaction(t *testing.T) {
+	assert := assert.New(t)
+	var o manageropt
+	if assert.NoError(WithCompaction(CompactTruncate(), 0)(&o)) {
+		assert.Equal(compactionThreshold, o.compactor.threshold)
+	}
+	assert.Error(WithCompaction(nil, 0.5)(&o))
+	assert.Error(WithCompaction(CompactTruncate(), 1.5)(&o))
+	assert.Error(WithCompaction(CompactTruncate(), -1)(&o))
+}
@@ -0,0 +1,189 @@
+package manager
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+
+	// Packages
+	uuid "github.com/google/uuid"
+	auth "github.com/mutablelogic/go-auth/auth/schema"
+	otel "github.com/mutablelogic/go-client/pkg/otel"
+	llm "github.com/mutablelogic/go-llm"
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	opt "github.com/mutablelogic/go-llm/pkg/opt"
+	tool "github.com/mutablelogic/go-llm/toolkit/tool"
+	jsonschema "github.com/mutablelogic/go-server/pkg/jsonschema"
+	types "github.com/mutablelogic/go-server/pkg/types"
+	attribute "go.opentelemetry.io/otel/attribute"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// clientTool is a tool which is offered to the model but run by the client,
+// so calls to it are returned in the reply rather than run
+type clientTool struct {
+	tool.Base
+	definition schema.ToolDefinition
+	input      *jsonschema.Schema
+}
+
+var _ llm.Tool = (*clientTool)(nil)
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// Complete replies to a conversation which is kept by the client, outside of
+// a session context (stateless). The tools in the request are offered to the
+// model, and calls to them are returned in the reply for the client to run,
+// which sends the results in the next request. If fn is non-nil, text chunks
+// are streamed to the callback as they arrive.
+func (m *Manager) Complete(ctx context.Context, request schema.CompletionRequest, user *auth.UserInfo, fn opt.StreamFn) (_ *schema.AskResponse, err error) {
+	// Otel span
+	ctx, endSpan := otel.StartSpan(m.tracer, ctx, "Complete",
+		attribute.String("req", types.Stringify(request.GeneratorMeta)),
+		attribute.Int("messages", len(request.Messages)),
+		attribute.String("user", types.Stringify(user)),
+	)
+	defer func() { endSpan(err) }()
+
+	// Check the input
+	history, message, err := request.Input()
+	if err != nil {
+		return nil, err
+	}
+	tools, err := clientTools(request.Tools)
+	if err != nil {
+		return nil, err
+	}
+
+	// Wait for a worker to run the generation
+	ctx, release, err := m.Admit(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	// Score the input for jailbreak attempts, hardening the system prompt
+	score, prompt, err := m.screenJailbreak(ctx, message.Text(), request.SystemPrompt, user)
+	if err != nil {
+		return nil, err
+	}
+	request.SystemPrompt = prompt
+	if err := m.auditJailbreak(ctx, score, message.Text(), uuid.Nil, user); err != nil {
+		return nil, err
+	}
+
+	// Resolve model, generator, and options from the request meta
+	provider, model, generator, opts, err := m.generatorFromMeta(ctx, request.GeneratorMeta, user, generationContextAsk)
+	if err != nil {
+		return nil, err
+	}
+
+	// Send the text of images to models which do not accept them
+	message, ocrOpts, err := m.ocrFallback(ctx, model, message)
+	if err != nil {
+		return nil, err
+	}
+	opts = append(opts, ocrOpts...)
+	conversation := slices.Clone(history)
+	if err := schema.ValidateFor(provider.Provider, schema.Normalize(provider.Provider, slices.Concat(conversation, schema.Conversation{message}))); err != nil {
+		return nil, err
+	}
+
+	// Offer the tools of the client, and stream when a callback is provided
+	if len(tools) > 0 {
+		opts = append(opts, opt.WithTool(tools...))
+	}
+	if fn != nil {
+		opts = append(opts, opt.WithStream(fn))
+	}
+
+	// Send the conversation
+	result, usage, err := generator.WithSession(ctx, types.Value(model), &conversation, message, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create the response
+	response := types.Ptr(schema.AskResponse{
+		CompletionResponse: schema.CompletionResponse{
+			Role:    result.Role,
+			Content: result.Content,
+			Result:  result.Result,
+			Markup:  schema.ParseMarkup(result.Content),
+		},
+		Usage: mergeUsageMeta(ctx, usage, provider.Meta, result),
+	})
+	if _, warning := m.resolveModel(types.Value(request.Model)); warning != "" {
+		response.Warnings = append(response.Warnings, warning)
+	}
+	response.Warnings = append(response.Warnings, optWarnings(opts)...)
+
+	// Insert the usage into the database if we have usage information
+	if response.Usage != nil {
+		if _, err := m.CreateUsage(ctx, schema.UsageInsert{
+			Type:      schema.UsageTypeAsk,
+			User:      uuid.UUID(user.Sub),
+			Model:     model.Name,
+			Provider:  types.Ptr(model.OwnedBy),
+			UsageMeta: types.Value(response.Usage),
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	// Archive the request with the response
+	if response.Request, err = m.archive(ctx, schema.ArchiveInsert{
+		Type:     schema.UsageTypeAsk,
+		User:     uuid.UUID(user.Sub),
+		Provider: model.OwnedBy,
+		Model:    model.Name,
+	}, request, response); err != nil {
+		return nil, err
+	}
+
+	// Return success
+	return response, nil
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// clientTools returns the tools of the client, which are offered to the model
+func clientTools(definitions []schema.ToolDefinition) ([]llm.Tool, error) {
+	result := make([]llm.Tool, 0, len(definitions))
+	for _, definition := range definitions {
+		if definition.Name == "" {
+			return nil, schema.ErrBadParameter.With("tool name is required")
+		}
+		parameters := json.RawMessage(definition.Parameters)
+		if len(parameters) == 0 {
+			parameters = json.RawMessage(`{"type":"object"}`)
+		}
+		input, err := jsonschema.FromJSON(parameters)
+		if err != nil {
+			return nil, schema.ErrBadParameter.Withf("tool %q: %v", definition.Name, err)
+		}
+		result = append(result, &clientTool{definition: definition, input: input})
+	}
+	return result, nil
+}
+
+func (t *clientTool) Name() string {
+	return t.definition.Name
+}
+
+func (t *clientTool) Description() string {
+	return t.definition.Description
+}
+
+func (t *clientTool) InputSchema() *jsonschema.Schema {
+	return t.input
+}
+
+// Run is not called, since calls to the tool are returned to the client
+func (t *clientTool) Run(context.Context, json.RawMessage) (any, error) {
+	return nil, schema.ErrNotImplemented.Withf("tool %q is run by the client", t.definition.Name)
+}
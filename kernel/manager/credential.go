@@ -3,9 +3,15 @@ package manager
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"slices"
+	"strings"
 
 	// Packages
+	uuid "github.com/google/uuid"
+	auth "github.com/mutablelogic/go-auth/auth/schema"
 	otel "github.com/mutablelogic/go-client/pkg/otel"
+	llm "github.com/mutablelogic/go-llm"
 	schema "github.com/mutablelogic/go-llm/kernel/schema"
 	pg "github.com/mutablelogic/go-pg"
 	httpresponse "github.com/mutablelogic/go-server/pkg/httpresponse"
@@ -44,9 +50,166 @@ func (m *Manager) CreateCredential(ctx context.Context, req schema.CredentialIns
 	return types.Ptr(result), nil
 }
 
+// SetProviderCredentials stores the credentials of the user for a provider,
+// encrypted, which are then used for the requests of the user in place of
+// the credentials of the provider. The credentials are checked with the
+// provider before they are stored.
+func (m *Manager) SetProviderCredentials(ctx context.Context, name string, credentials schema.ProviderCredentials, user *auth.UserInfo) (_ *schema.ProviderCredential, err error) {
+	ctx, endSpan := otel.StartSpan(m.tracer, ctx, "SetProviderCredentials",
+		attribute.String("provider", name),
+	)
+	defer func() { endSpan(err) }()
+
+	// Check the request
+	key, err := m.providerCredentialKey(name, user)
+	if err != nil {
+		return nil, err
+	} else if len(credentials.Keys()) == 0 {
+		return nil, schema.ErrBadParameter.With("at least one API key is required")
+	} else if !credentials.KeyStrategy.Valid() {
+		return nil, schema.ErrBadParameter.Withf("invalid key strategy %q", credentials.KeyStrategy)
+	}
+
+	// Check the credentials with a provider which the user can access
+	providers, err := m.providersForUser(ctx, key.Provider, user)
+	if err != nil {
+		return nil, err
+	}
+	index := slices.IndexFunc(providers, func(provider schema.Provider) bool { return provider.Name == key.Provider })
+	if index < 0 {
+		return nil, schema.ErrNotFound.Withf("provider %q", key.Provider)
+	}
+	if err := m.Registry.Validate(ctx, providers[index], credentials); err != nil {
+		return nil, schema.ErrBadParameter.Withf("provider %q did not accept the credentials: %v", key.Provider, err)
+	}
+
+	// Encrypt the credentials
+	pv, encrypted, err := m.encryptCredentials(credentials)
+	if err != nil {
+		return nil, err
+	}
+
+	// Insert or replace the credentials
+	var result schema.ProviderCredential
+	if err := m.PoolConn.With("pv", pv).Insert(ctx, &result, schema.ProviderCredentialInsert{
+		ProviderCredentialKey: key,
+		Credentials:           encrypted,
+	}); err != nil {
+		return nil, pg.NormalizeError(err)
+	}
+
+	// Return success
+	return types.Ptr(result), nil
+}
+
+// GetProviderCredentials returns the credentials which the user has set for
+// a provider, without the keys
+func (m *Manager) GetProviderCredentials(ctx context.Context, name string, user *auth.UserInfo) (_ *schema.ProviderCredential, err error) {
+	ctx, endSpan := otel.StartSpan(m.tracer, ctx, "GetProviderCredentials",
+		attribute.String("provider", name),
+	)
+	defer func() { endSpan(err) }()
+
+	key, err := m.providerCredentialKey(name, user)
+	if err != nil {
+		return nil, err
+	}
+	var result schema.ProviderCredential
+	if err := m.PoolConn.Get(ctx, &result, key); err != nil {
+		return nil, normalizeProviderCredentialError(name, err)
+	}
+	return types.Ptr(result), nil
+}
+
+// DeleteProviderCredentials removes the credentials which the user has set
+// for a provider, so that the credentials of the provider are used again
+func (m *Manager) DeleteProviderCredentials(ctx context.Context, name string, user *auth.UserInfo) (_ *schema.ProviderCredential, err error) {
+	ctx, endSpan := otel.StartSpan(m.tracer, ctx, "DeleteProviderCredentials",
+		attribute.String("provider", name),
+	)
+	defer func() { endSpan(err) }()
+
+	key, err := m.providerCredentialKey(name, user)
+	if err != nil {
+		return nil, err
+	}
+	var result schema.ProviderCredential
+	if err := m.PoolConn.Delete(ctx, &result, key); err != nil {
+		return nil, normalizeProviderCredentialError(name, err)
+	}
+	return types.Ptr(result), nil
+}
+
 ///////////////////////////////////////////////////////////////////////////////
 // PRIVATE METHODS
 
+// clientForUser returns the client for a provider, which uses the credentials
+// of the user when they have set their own, and whether it does. Without a
+// user, or when users cannot set credentials, the client of the provider is
+// returned.
+func (m *Manager) clientForUser(ctx context.Context, name string, user *auth.UserInfo) (llm.Client, bool, error) {
+	if !m.tenants || user == nil {
+		return m.Registry.Get(name), false, nil
+	}
+
+	// Get the credentials of the user
+	var secret schema.ProviderCredentialSecret
+	if err := m.PoolConn.Get(ctx, &secret, schema.ProviderCredentialSecretKey{Provider: name, User: uuid.UUID(user.Sub)}); errors.Is(err, pg.ErrNotFound) {
+		return m.Registry.Get(name), false, nil
+	} else if err != nil {
+		return nil, false, pg.NormalizeError(err)
+	}
+	var credentials schema.ProviderCredentials
+	if err := m.decryptCredentials(secret.Credentials, secret.PV, &credentials); err != nil {
+		return nil, false, err
+	}
+
+	// Return the client with the credentials of the user
+	client, err := m.Registry.Scoped(name, credentials)
+	if err != nil {
+		return nil, false, err
+	}
+	return client, client != nil, nil
+}
+
+// providerCredentialKey returns the key for the credentials of the user for
+// a provider
+func (m *Manager) providerCredentialKey(name string, user *auth.UserInfo) (schema.ProviderCredentialKey, error) {
+	if !m.tenants {
+		return schema.ProviderCredentialKey{}, schema.ErrNotImplemented.With("provider credentials for users are not enabled")
+	} else if user == nil {
+		return schema.ProviderCredentialKey{}, schema.ErrBadParameter.With("provider credentials require a user")
+	} else if name = strings.TrimSpace(name); name == "" {
+		return schema.ProviderCredentialKey{}, schema.ErrBadParameter.With("provider is required")
+	}
+	return schema.ProviderCredentialKey{Provider: name, User: uuid.UUID(user.Sub)}, nil
+}
+
+// credential returns the decrypted payload of the credential stored for a
+// URL without a user, or nil if there is none
+func (m *Manager) credential(ctx context.Context, url string) ([]byte, error) {
+	var secret schema.CredentialSecret
+	var data []byte
+	if err := m.PoolConn.Get(ctx, &secret, schema.CredentialKey{URL: url}); errors.Is(err, pg.ErrNotFound) {
+		return nil, nil
+	} else if err != nil {
+		return nil, pg.NormalizeError(err)
+	} else if err := m.decryptCredentials(secret.Credentials, secret.PV, &data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// secret returns the credential stored for a URL as text, such as a token
+// for an authentication header, or an empty string if there is none
+func (m *Manager) secret(ctx context.Context, url string) (string, error) {
+	data, err := m.credential(ctx, url)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
 func (m *Manager) encryptCredentials(v any) (uint64, []byte, error) {
 	// Preserve the zero-value contract for raw credential payloads.
 	switch value := v.(type) {
@@ -105,3 +268,11 @@ func (m *Manager) decryptCredentials(encrypted []byte, pv uint64, decrypted any)
 		return nil
 	}
 }
+
+func normalizeProviderCredentialError(name string, err error) error {
+	err = pg.NormalizeError(err)
+	if errors.Is(err, pg.ErrNotFound) || errors.Is(err, schema.ErrNotFound) {
+		return schema.ErrNotFound.Withf("no credentials for provider %q", name)
+	}
+	return err
+}
@@ -0,0 +1,84 @@
+package manager
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	// Packages
+	uuid "github.com/google/uuid"
+	auth "github.com/mutablelogic/go-auth/auth/schema"
+	otel "github.com/mutablelogic/go-client/pkg/otel"
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	pg "github.com/mutablelogic/go-pg"
+	types "github.com/mutablelogic/go-server/pkg/types"
+	attribute "go.opentelemetry.io/otel/attribute"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+// How often retention policies are enforced
+const retentionInterval = time.Hour
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// DeleteData removes all sessions matching the request, together with their
+// child sessions, messages, memories and usage records, and returns a report
+// of what was deleted. If user is non-nil, only sessions owned by that user
+// are deleted.
+func (m *Manager) DeleteData(ctx context.Context, req schema.DataDeleteRequest, user *auth.UserInfo) (_ *schema.DataDeleteReport, err error) {
+	// OTel span
+	ctx, endSpan := otel.StartSpan(m.tracer, ctx, "DeleteData",
+		attribute.String("req", req.String()),
+		attribute.String("user", types.Stringify(user)),
+	)
+	defer func() { endSpan(err) }()
+
+	var conn pg.Conn = m.PoolConn
+	if user != nil {
+		conn = conn.With("user", uuid.UUID(user.Sub))
+	}
+
+	// Delete the data
+	result := schema.DataDeleteReport{DataDeleteRequest: req}
+	if err := conn.Delete(ctx, &result, req); err != nil {
+		return nil, pg.NormalizeError(err)
+	}
+
+	// Remove any subscriptions to the deleted sessions
+	if m.sessionfeed != nil {
+		for _, session := range result.Sessions {
+			m.sessionfeed.unsubscribeSession(session)
+		}
+	}
+
+	// Return success
+	return types.Ptr(result), nil
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// applyRetention enforces each retention policy in turn, and returns the
+// reports for policies which deleted any sessions. Policies are not enforced
+// while another replica is enforcing them.
+func (m *Manager) applyRetention(ctx context.Context, now time.Time) ([]*schema.DataDeleteReport, error) {
+	var result error
+	var reports []*schema.DataDeleteReport
+	if _, err := m.exclusive(ctx, "retention", func(ctx context.Context) error {
+		for _, policy := range m.retention {
+			report, err := m.DeleteData(ctx, policy.Request(now), nil)
+			if err != nil {
+				result = errors.Join(result, err)
+			} else if len(report.Sessions) > 0 {
+				reports = append(reports, report)
+			}
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return reports, result
+}
@@ -0,0 +1,58 @@
+package manager
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	// Packages
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	llmtest "github.com/mutablelogic/go-llm/pkg/test"
+	types "github.com/mutablelogic/go-server/pkg/types"
+	assert "github.com/stretchr/testify/assert"
+)
+
+func TestDeleteDataKeepsSessionWithRecentMessageIntegration(t *testing.T) {
+	conn, m := newIntegrationManager(t)
+	conn.RequireProvider(t)
+	ctx := llmtest.Context(t)
+	provider := llmtest.CreateProvider(t, conn.ProviderInsert(), m.CreateProvider, m.SyncProviders)
+	admin := llmtest.AdminUser(conn)
+	modelName := llmtest.ModelNameMatching(t, "", syncAndListModels(m, provider.Name, admin), func(model schema.Model) bool {
+		return model.Cap&schema.ModelCapCompletion != 0
+	}, validateAccessibleModel(m, provider.Name, admin))
+
+	// Create two sessions, which were created and modified two days ago
+	meta := schema.SessionMeta{
+		GeneratorMeta: schema.GeneratorMeta{Model: types.Ptr(modelName), Provider: types.Ptr(provider.Name)},
+		Tags:          []string{"retention"},
+	}
+	active, err := m.CreateSession(ctx, schema.SessionInsert{SessionMeta: meta}, admin)
+	if !assert.NoError(t, err) {
+		return
+	}
+	inactive, err := m.CreateSession(ctx, schema.SessionInsert{SessionMeta: meta}, admin)
+	if !assert.NoError(t, err) {
+		return
+	}
+	if err := m.Exec(ctx, fmt.Sprintf(`UPDATE llm.session SET created_at = now() - interval '2 days', modified_at = now() - interval '2 days' WHERE id IN ('%s', '%s')`, active.ID, inactive.ID)); !assert.NoError(t, err) {
+		return
+	}
+
+	// Add a message to one of the sessions now
+	if err := m.PoolConn.Insert(ctx, nil, schema.MessageInsert{
+		Session: active.ID,
+		Message: schema.Message{Role: schema.RoleUser, Content: []schema.ContentBlock{{Text: types.Ptr("still here")}}, Tokens: 2},
+	}); !assert.NoError(t, err) {
+		return
+	}
+
+	// Only the session without recent messages is deleted
+	report, err := m.DeleteData(ctx, schema.DataDeleteRequest{Label: "retention", Before: types.Ptr(time.Now().Add(-24 * time.Hour))}, admin)
+	if assert.NoError(t, err) {
+		assert.Contains(t, report.Sessions, inactive.ID)
+		assert.NotContains(t, report.Sessions, active.ID)
+	}
+	_, err = m.GetSession(ctx, active.ID, admin)
+	assert.NoError(t, err)
+}
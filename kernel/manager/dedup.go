@@ -0,0 +1,116 @@
+package manager
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+
+	// Packages
+	llm "github.com/mutablelogic/go-llm"
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	opt "github.com/mutablelogic/go-llm/pkg/opt"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// dedupGenerator sends the conversation with older copies of repeated tool
+// results replaced by a reference to the latest copy. The conversation of
+// the caller is not changed, except for the messages the generator appends,
+// so the stored history keeps every result.
+type dedupGenerator struct {
+	llm.Generator
+}
+
+var _ llm.Generator = (*dedupGenerator)(nil)
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+func (g *dedupGenerator) WithSession(ctx context.Context, model schema.Model, conversation *schema.Conversation, message *schema.Message, opts ...opt.Opt) (*schema.Message, *schema.UsageMeta, error) {
+	if conversation == nil {
+		return g.Generator.WithSession(ctx, model, conversation, message, opts...)
+	}
+	sent := dedupToolResults(*conversation)
+	start := sent.Len()
+	reply, usage, err := g.Generator.WithSession(ctx, model, &sent, message, opts...)
+	if sent.Len() > start {
+		*conversation = append(*conversation, sent[start:]...)
+	}
+	return reply, usage, err
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// dedupToolResults returns the conversation with each tool result whose
+// name and content are repeated later in the conversation replaced by a
+// reference to the later result. Messages which are changed are copied, and
+// results which are shorter than the reference are kept.
+func dedupToolResults(conversation schema.Conversation) schema.Conversation {
+	var result schema.Conversation
+	latest := make(map[string]string)
+	for i := conversation.Len() - 1; i >= 0; i-- {
+		message := conversation[i]
+		if message == nil {
+			continue
+		}
+		var content []schema.ContentBlock
+		for j, block := range message.Content {
+			if block.ToolResult == nil {
+				continue
+			}
+			key := dedupKey(block.ToolResult)
+			id, exists := latest[key]
+			if !exists {
+				latest[key] = block.ToolResult.ID
+				continue
+			}
+			marker := dedupMarker(block.ToolResult.Name, id)
+			if len(marker) >= len(block.ToolResult.Content) {
+				continue
+			}
+			if content == nil {
+				content = slices.Clone(message.Content)
+			}
+			toolResult := *block.ToolResult
+			toolResult.Content = marker
+			content[j].ToolResult = &toolResult
+		}
+		if content == nil {
+			continue
+		}
+		if result == nil {
+			result = slices.Clone(conversation)
+		}
+		changed := *message
+		changed.Content = content
+		result[i] = &changed
+	}
+	if result == nil {
+		return slices.Clone(conversation)
+	}
+	return result
+}
+
+// dedupKey returns the key which is the same for identical tool results
+func dedupKey(toolResult *schema.ToolResult) string {
+	var content bytes.Buffer
+	if err := json.Compact(&content, toolResult.Content); err != nil {
+		content.Reset()
+		content.Write(toolResult.Content)
+	}
+	return fmt.Sprintf("%s\x00%t\x00%s", toolResult.Name, toolResult.IsError, content.Bytes())
+}
+
+// dedupMarker returns the content which refers to the later copy of a result
+func dedupMarker(name, id string) json.RawMessage {
+	text := fmt.Sprintf("[Same result as the later call to %s]", name)
+	if id != "" {
+		text = fmt.Sprintf("[Same result as the later call to %s with id %s]", name, id)
+	}
+	data, _ := json.Marshal(text)
+	return data
+}
@@ -0,0 +1,102 @@
+package manager
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	// Packages
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	opt "github.com/mutablelogic/go-llm/pkg/opt"
+	types "github.com/mutablelogic/go-server/pkg/types"
+	assert "github.com/stretchr/testify/assert"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// HELPERS
+
+// dedupTestGenerator records the conversation it receives, and appends the
+// message and a reply to it
+type dedupTestGenerator struct {
+	sent schema.Conversation
+}
+
+func (g *dedupTestGenerator) WithoutSession(context.Context, schema.Model, *schema.Message, ...opt.Opt) (*schema.Message, *schema.UsageMeta, error) {
+	return &schema.Message{Role: schema.RoleAssistant}, nil, nil
+}
+
+func (g *dedupTestGenerator) WithSession(_ context.Context, _ schema.Model, conversation *schema.Conversation, message *schema.Message, _ ...opt.Opt) (*schema.Message, *schema.UsageMeta, error) {
+	g.sent = append(schema.Conversation{}, *conversation...)
+	reply := &schema.Message{Role: schema.RoleAssistant, Content: []schema.ContentBlock{{Text: types.Ptr("done")}}}
+	*conversation = append(*conversation, message, reply)
+	return reply, nil, nil
+}
+
+func dedupTestResult(id, name, content string) *schema.Message {
+	return &schema.Message{Role: schema.RoleUser, Content: []schema.ContentBlock{
+		{ToolResult: &schema.ToolResult{ID: id, Name: name, Content: json.RawMessage(content)}},
+	}}
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// TESTS
+
+func TestDedupToolResults(t *testing.T) {
+	assert := assert.New(t)
+	long := `{"status": "ok", "items": ["a", "b", "c", "d", "e", "f", "g", "h", "i", "j"]}`
+	conversation := schema.Conversation{
+		dedupTestResult("call_1", "list", long),
+		dedupTestResult("call_2", "list", `{"status":"ok","items":["a","b","c","d","e","f","g","h","i","j"]}`),
+		dedupTestResult("call_3", "other", long),
+		dedupTestResult("call_4", "list", `{"short":1}`),
+		dedupTestResult("call_5", "list", `{"short":1}`),
+		dedupTestResult("call_6", "list", long),
+	}
+	result := dedupToolResults(conversation)
+	if !assert.Len(result, 6) {
+		return
+	}
+
+	// Older copies refer to the latest copy, whitespace is ignored and the
+	// latest copy is kept
+	for _, i := range []int{0, 1} {
+		var marker string
+		assert.NoError(json.Unmarshal(result[i].Content[0].ToolResult.Content, &marker))
+		assert.True(strings.Contains(marker, "call_6"), marker)
+		assert.Equal(conversation[i].Content[0].ToolResult.ID, result[i].Content[0].ToolResult.ID)
+	}
+	assert.Same(conversation[5], result[5])
+
+	// Results from other tools, and results shorter than the marker, are kept
+	assert.Same(conversation[2], result[2])
+	assert.Same(conversation[3], result[3])
+
+	// The conversation is not changed
+	assert.JSONEq(long, string(conversation[0].Content[0].ToolResult.Content))
+}
+
+func TestDedupGenerator(t *testing.T) {
+	assert := assert.New(t)
+	long := `{"forecast":"sunny with a light breeze through the afternoon"}`
+	conversation := schema.Conversation{
+		dedupTestResult("call_1", "weather", long),
+		dedupTestResult("call_2", "weather", long),
+	}
+	inner := &dedupTestGenerator{}
+	generator := &dedupGenerator{Generator: inner}
+	message := &schema.Message{Role: schema.RoleUser, Content: []schema.ContentBlock{{Text: types.Ptr("and tomorrow?")}}}
+	reply, _, err := generator.WithSession(context.Background(), schema.Model{}, &conversation, message)
+	if !assert.NoError(err) {
+		return
+	}
+
+	// The provider receives the marker, and the stored conversation keeps the
+	// result with the message and reply appended
+	assert.NotEqual(long, string(inner.sent[0].Content[0].ToolResult.Content))
+	assert.Equal(long, string(conversation[0].Content[0].ToolResult.Content))
+	if assert.Len(conversation, 4) {
+		assert.Same(message, conversation[2])
+		assert.Same(reply, conversation[3])
+	}
+}
@@ -0,0 +1,206 @@
+package manager
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	// Packages
+	auth "github.com/mutablelogic/go-auth/auth/schema"
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	types "github.com/mutablelogic/go-server/pkg/types"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// drafted is the outcome of drafting a reply with a smaller model. Either the
+// draft is accepted as the reply, or the reply starts with the prefill, which
+// includes the paragraphs of the draft which were accepted.
+type drafted struct {
+	report   schema.DraftReport
+	reply    *schema.Message
+	prefill  string
+	warnings []string
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+// System prompt for the model which checks a draft
+const draftVerifyPrompt = `You check a draft reply to the request which follows, written by a smaller model. ` +
+	`Reply with only the number of paragraphs at the start of the draft which are correct, and which you would have written yourself. ` +
+	`Reply with 0 if the first paragraph is wrong.`
+
+// Maximum tokens for the reply which checks a draft
+const draftVerifyTokens = 16
+
+var reDraftCount = regexp.MustCompile(`\d+`)
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// draft drafts the reply to the message with the draft model of the request,
+// and asks the model to check the paragraphs of the draft. When the provider
+// of the model does not support prefill, only a draft which is accepted in
+// full is kept. A draft which cannot be made or checked is skipped with a
+// warning, and the reply is then generated without it.
+func (m *Manager) draft(ctx context.Context, request schema.AskRequest, meta schema.GeneratorMeta, provider string, model *schema.Model, message *schema.Message, user *auth.UserInfo) (*drafted, error) {
+	result := &drafted{prefill: request.Prefill}
+	skip := func(err error) (*drafted, error) {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		result.warnings = append(result.warnings, fmt.Sprintf("draft was skipped: %v", err))
+		return result, nil
+	}
+
+	// Draft the reply
+	start := time.Now()
+	reply, err := m.draftReply(ctx, request, meta, message, user, &result.report)
+	result.report.Draft = time.Since(start)
+	if err != nil {
+		return skip(err)
+	}
+	text := reply.Text()
+	if !strings.HasPrefix(text, strings.TrimRightFunc(request.Prefill, unicode.IsSpace)) {
+		return skip(fmt.Errorf("model %q did not continue the prefill", result.report.Model))
+	}
+	ends := paragraphEnds(text)
+	if len(ends) == 0 {
+		return skip(fmt.Errorf("model %q returned an empty draft", result.report.Model))
+	}
+	result.report.Paragraphs = uint(len(ends))
+
+	// Check the draft
+	start = time.Now()
+	accepted, err := m.verifyDraft(ctx, model, message, paragraphs(text, ends), user)
+	result.report.Verify = time.Since(start)
+	if err != nil {
+		return skip(err)
+	}
+
+	// Keep the accepted paragraphs
+	switch {
+	case accepted == uint(len(ends)) && reply.Result == schema.ResultStop:
+		result.reply = &schema.Message{Role: schema.RoleAssistant, Content: []schema.ContentBlock{{Text: types.Ptr(text)}}, Result: schema.ResultStop}
+	case accepted > 0 && supportsPrefill(provider, generationContextAsk):
+		result.prefill = text[:ends[accepted-1]]
+	default:
+		accepted = 0
+	}
+	result.report.Accepted = accepted
+
+	// Return success
+	return result, nil
+}
+
+// draftReply returns the reply from the draft model, which is the requested
+// model with the fields set by the draft
+func (m *Manager) draftReply(ctx context.Context, request schema.AskRequest, meta schema.GeneratorMeta, message *schema.Message, user *auth.UserInfo, report *schema.DraftReport) (*schema.Message, error) {
+	if request.Draft.Model != nil && request.Draft.Provider == nil {
+		meta.Provider = nil
+	}
+	provider, model, generator, opts, err := m.generatorFromMeta(ctx, meta.MergeFrom(types.Value(request.Draft)), user, generationContextAsk)
+	if err != nil {
+		return nil, err
+	}
+	report.Provider, report.Model = model.OwnedBy, model.Name
+
+	// Start the draft with the prefill, and send the text of images to a
+	// model which does not accept them
+	prefillOpts, generator := m.prefillOpts(provider.Provider, generator, request.Prefill, generationContextAsk)
+	opts = append(opts, prefillOpts...)
+	message, ocrOpts, err := m.ocrFallback(ctx, model, message)
+	if err != nil {
+		return nil, err
+	}
+	opts = append(opts, ocrOpts...)
+	if err := schema.ValidateFor(provider.Provider, schema.Conversation{message}); err != nil {
+		return nil, err
+	}
+
+	// Draft the reply
+	reply, usage, err := generator.WithoutSession(ctx, types.Value(model), message, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.generationUsage(ctx, model, usage, user); err != nil {
+		return nil, err
+	}
+
+	// Return success
+	return reply, nil
+}
+
+// verifyDraft asks the model how many paragraphs at the start of the draft
+// it accepts
+func (m *Manager) verifyDraft(ctx context.Context, model *schema.Model, message *schema.Message, draft []string, user *auth.UserInfo) (uint, error) {
+	_, model, generator, opts, err := m.generatorFromMeta(ctx, schema.GeneratorMeta{
+		Provider:     types.Ptr(model.OwnedBy),
+		Model:        types.Ptr(model.Name),
+		SystemPrompt: types.Ptr(draftVerifyPrompt),
+		MaxTokens:    types.Ptr(uint(draftVerifyTokens)),
+	}, user, generationContextAsk)
+	if err != nil {
+		return 0, err
+	}
+
+	// Add the numbered paragraphs of the draft to the request
+	var text strings.Builder
+	fmt.Fprintf(&text, "Draft reply, with %d paragraphs:\n", len(draft))
+	for i, paragraph := range draft {
+		fmt.Fprintf(&text, "\n[%d] %s\n", i+1, paragraph)
+	}
+	check := types.Ptr(*message)
+	check.Content = append(slices.Clone(message.Content), schema.ContentBlock{Text: types.Ptr(text.String())})
+
+	// Check the draft
+	reply, usage, err := generator.WithoutSession(ctx, types.Value(model), check, opts...)
+	if err != nil {
+		return 0, err
+	}
+	if err := m.generationUsage(ctx, model, usage, user); err != nil {
+		return 0, err
+	}
+	accepted, err := strconv.ParseUint(reDraftCount.FindString(reply.Text()), 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("model %q did not count the accepted paragraphs: %q", model.Name, reply.Text())
+	}
+
+	// Return success
+	return min(uint(accepted), uint(len(draft))), nil
+}
+
+// paragraphEnds returns the offset of the end of each paragraph of the text,
+// where paragraphs are separated by blank lines
+func paragraphEnds(text string) []int {
+	var ends []int
+	for start := 0; start < len(text); {
+		end := len(text)
+		if i := strings.Index(text[start:], "\n\n"); i >= 0 {
+			end = start + i
+		}
+		if strings.TrimSpace(text[start:end]) != "" {
+			ends = append(ends, end)
+		}
+		start = end + 2
+	}
+	return ends
+}
+
+// paragraphs returns the text of each paragraph, given their ends
+func paragraphs(text string, ends []int) []string {
+	result := make([]string, len(ends))
+	start := 0
+	for i, end := range ends {
+		result[i] = strings.TrimSpace(text[start:end])
+		start = end
+	}
+	return result
+}
@@ -0,0 +1,21 @@
+package manager
+
+import (
+	"testing"
+
+	// Packages
+	assert "github.com/stretchr/testify/assert"
+)
+
+func TestParagraphs(t *testing.T) {
+	assert := assert.New(t)
+
+	text := "First line\nsecond line\n\n\n\nSecond paragraph\n\nThird"
+	ends := paragraphEnds(text)
+	assert.Equal([]string{"First line\nsecond line", "Second paragraph", "Third"}, paragraphs(text, ends))
+	assert.Equal("First line\nsecond line\n\n\n\nSecond paragraph", text[:ends[1]])
+
+	assert.Empty(paragraphEnds(""))
+	assert.Empty(paragraphEnds("\n\n  \n\n"))
+	assert.Equal([]int{3}, paragraphEnds("abc\n\n"))
+}
@@ -40,7 +40,8 @@ func (m *Manager) Embedding(ctx context.Context, request schema.EmbeddingRequest
 	}
 
 	// Resolve the model to exactly one provider-scoped match.
-	models, err := m.modelsByName(ctx, providers, request.Model)
+	name, _ := m.resolveModel(request.Model)
+	models, err := m.modelsByName(ctx, providers, name)
 	if err != nil {
 		return nil, err
 	}
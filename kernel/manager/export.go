@@ -0,0 +1,53 @@
+package manager
+
+import (
+	"context"
+	"io"
+
+	// Packages
+	uuid "github.com/google/uuid"
+	auth "github.com/mutablelogic/go-auth/auth/schema"
+	otel "github.com/mutablelogic/go-client/pkg/otel"
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	transcript "github.com/mutablelogic/go-llm/pkg/transcript"
+	types "github.com/mutablelogic/go-server/pkg/types"
+	attribute "go.opentelemetry.io/otel/attribute"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// ExportSession writes a session and all of its messages, including tool
+// calls and results, to w in the requested format. If user is non-nil, the
+// session must be owned by that user. Administrators can include the thinking
+// which was recorded apart from the transcript.
+func (m *Manager) ExportSession(ctx context.Context, session uuid.UUID, req schema.SessionExportRequest, w io.Writer, user *auth.UserInfo) (err error) {
+	// OTel span
+	ctx, endSpan := otel.StartSpan(m.tracer, ctx, "ExportSession",
+		attribute.String("id", session.String()),
+		attribute.String("req", types.Stringify(req)),
+		attribute.String("user", types.Stringify(user)),
+	)
+	defer func() { endSpan(err) }()
+
+	// Get the session and its messages
+	result, err := m.GetSession(ctx, session, user)
+	if err != nil {
+		return err
+	}
+	conversation, err := m.conversationForSession(ctx, session, user)
+	if err != nil {
+		return err
+	}
+	conversation, err = m.exportConversation(ctx, session, conversation, req.Thinking, user)
+	if err != nil {
+		return err
+	}
+
+	// Write the export
+	format := req.Format
+	if format == "" {
+		format = schema.ExportPDF
+	}
+	return transcript.Write(format, w, result, conversation)
+}
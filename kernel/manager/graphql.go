@@ -0,0 +1,43 @@
+package manager
+
+import (
+	"context"
+
+	// Packages
+	graphql "github.com/mutablelogic/go-llm/pkg/graphql"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// graphqlopt is the GraphQL endpoint for the GraphQL tools, and the limits
+// on queries
+type graphqlopt struct {
+	url    string
+	limits graphql.Limits
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// graphqlTools adds the tools which describe and query the GraphQL
+// endpoint. The bearer token is read from the credential stored for the
+// endpoint URL.
+func (m *Manager) graphqlTools() error {
+	client, err := graphql.New(m.graphql.url)
+	if err != nil {
+		return err
+	}
+	url := client.URL()
+	if err := graphql.WithToken(func(ctx context.Context) (string, error) {
+		return m.secret(ctx, url)
+	})(client); err != nil {
+		return err
+	}
+	tools, err := graphql.NewTools(client, m.graphql.limits)
+	if err != nil {
+		return err
+	}
+	m.addTools(tools...)
+	return nil
+}
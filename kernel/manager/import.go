@@ -0,0 +1,88 @@
+package manager
+
+import (
+	"bytes"
+	"context"
+
+	// Packages
+	uuid "github.com/google/uuid"
+	auth "github.com/mutablelogic/go-auth/auth/schema"
+	otel "github.com/mutablelogic/go-client/pkg/otel"
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	transcript "github.com/mutablelogic/go-llm/pkg/transcript"
+	pg "github.com/mutablelogic/go-pg"
+	types "github.com/mutablelogic/go-server/pkg/types"
+	attribute "go.opentelemetry.io/otel/attribute"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// ImportSessions reads conversations exported from another tool and stores
+// them as sessions for the user, preserving their titles and timestamps. The
+// generator settings in the request are used to continue the imported
+// sessions, and are resolved in the same way as when creating a session. All
+// conversations are imported in a single transaction.
+func (m *Manager) ImportSessions(ctx context.Context, req schema.SessionImportRequest, user *auth.UserInfo) (_ *schema.SessionImportResponse, err error) {
+	// OTel span
+	ctx, endSpan := otel.StartSpan(m.tracer, ctx, "ImportSessions",
+		attribute.String("req", req.String()),
+		attribute.String("user", types.Stringify(user)),
+	)
+	defer func() { endSpan(err) }()
+
+	// Read the conversations
+	sessions, err := transcript.Read(req.Format, bytes.NewReader(req.Data))
+	if err != nil {
+		return nil, err
+	} else if len(sessions) == 0 {
+		return nil, schema.ErrBadParameter.With("no conversations found to import")
+	}
+
+	// Resolve provider and model
+	provider, model, _, _, err := m.generatorFromMeta(ctx, req.GeneratorMeta, user, generationContextChat)
+	if err != nil {
+		return nil, err
+	}
+	meta := req.GeneratorMeta
+	meta.Provider = types.Ptr(provider.Name)
+	meta.Model = types.Ptr(model.Name)
+
+	// Insert the sessions and their messages
+	result := schema.SessionImportResponse{
+		Body: make([]*schema.Session, 0, len(sessions)),
+	}
+	if err := m.PoolConn.Tx(ctx, func(conn pg.Conn) error {
+		var owner uuid.UUID
+		if user != nil {
+			owner = uuid.UUID(user.Sub)
+		}
+		conn = conn.With("user", owner)
+		for _, session := range sessions {
+			session.GeneratorMeta = meta
+			if req.Title != nil {
+				session.Title = req.Title
+			}
+			session.Tags = append(session.Tags, req.Tags...)
+
+			var inserted schema.Session
+			if err := conn.Insert(ctx, &inserted, session); err != nil {
+				return err
+			}
+			for _, message := range session.Messages {
+				message.Session = inserted.ID
+				if err := conn.Insert(ctx, nil, message); err != nil {
+					return err
+				}
+			}
+			result.Body = append(result.Body, types.Ptr(inserted))
+		}
+		return nil
+	}); err != nil {
+		return nil, pg.NormalizeError(err)
+	}
+
+	// Return success
+	result.Count = uint(len(result.Body))
+	return types.Ptr(result), nil
+}
@@ -0,0 +1,201 @@
+package manager
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	// Packages
+	uuid "github.com/google/uuid"
+	auth "github.com/mutablelogic/go-auth/auth/schema"
+	otel "github.com/mutablelogic/go-client/pkg/otel"
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	types "github.com/mutablelogic/go-server/pkg/types"
+	attribute "go.opentelemetry.io/otel/attribute"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// jobList holds the background jobs of this replica. Jobs which are done are
+// kept for jobTTL so their result can be read. The zero value is ready to use.
+type jobList struct {
+	sync.Mutex
+	clock Clock
+	ids   IDGenerator
+	jobs  map[uuid.UUID]*job
+}
+
+type job struct {
+	schema.Job
+	user   uuid.UUID // user who started the job, or nil
+	cancel context.CancelFunc
+}
+
+// jobFn runs a job and returns its result
+type jobFn func(ctx context.Context) (*schema.ChatResponse, error)
+
+///////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+// jobTTL is the time a job is kept after it is done
+const jobTTL = time.Hour
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// StartChat runs a chat turn in the background, and returns a job which is
+// polled with GetJob for the result. The turn continues when the context of
+// the request ends, and stops when the job is cancelled with CancelJob.
+// Jobs are held in memory, so are only found on the replica which started
+// them. If user is non-nil, the session must be owned by that user.
+func (m *Manager) StartChat(ctx context.Context, req schema.ChatRequest, user *auth.UserInfo) (_ *schema.Job, err error) {
+	ctx, endSpan := otel.StartSpan(m.tracer, ctx, "StartChat",
+		attribute.String("req", types.Stringify(req)),
+		attribute.String("user", types.Stringify(user)),
+	)
+	defer func() { endSpan(err) }()
+
+	// Check the session exists and is owned by the user, so that the error
+	// is returned now rather than from the job
+	if _, err := m.GetSession(ctx, req.Session, user); err != nil {
+		return nil, err
+	}
+
+	// Jobs are batch requests unless a priority is set, and are rejected now
+	// when the queue for their priority is full
+	if _, exists := ctx.Value(priorityKey{}).(schema.Priority); !exists {
+		ctx = WithPriority(ctx, schema.PriorityBatch)
+	}
+	if m.admission != nil {
+		if err := m.admission.check(priorityFromContext(ctx)); err != nil {
+			return nil, err
+		}
+	}
+
+	// Start the job, which keeps the values of the context such as tool
+	// simulation and approval, but not its cancellation
+	return m.jobs.start(context.WithoutCancel(ctx), req.Session, userSub(user), func(ctx context.Context) (*schema.ChatResponse, error) {
+		return m.Chat(ctx, req, nil, user)
+	}), nil
+}
+
+// GetJob returns a background job. If user is non-nil, the job must have
+// been started by that user.
+func (m *Manager) GetJob(ctx context.Context, id uuid.UUID, user *auth.UserInfo) (*schema.Job, error) {
+	return m.jobs.get(id, userSub(user))
+}
+
+// CancelJob cancels a background job which is running, and returns the job.
+// A job which is done is returned unchanged. If user is non-nil, the job must
+// have been started by that user.
+func (m *Manager) CancelJob(ctx context.Context, id uuid.UUID, user *auth.UserInfo) (*schema.Job, error) {
+	return m.jobs.cancel(id, userSub(user))
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// start runs fn in the background and returns the job
+func (l *jobList) start(ctx context.Context, session, user uuid.UUID, fn jobFn) *schema.Job {
+	ctx, cancel := context.WithCancel(ctx)
+	j := &job{
+		Job: schema.Job{
+			ID:        l.ids.New(),
+			Session:   session,
+			Status:    schema.JobRunning,
+			CreatedAt: l.clock.Now(),
+		},
+		user:   user,
+		cancel: cancel,
+	}
+
+	l.Lock()
+	l.expire(j.CreatedAt)
+	if l.jobs == nil {
+		l.jobs = make(map[uuid.UUID]*job)
+	}
+	l.jobs[j.ID] = j
+	result := j.Job
+	l.Unlock()
+
+	go func() {
+		defer cancel()
+		response, err := fn(ctx)
+		l.complete(j, response, err, ctx.Err() != nil)
+	}()
+
+	return &result
+}
+
+// complete records the result of a job
+func (l *jobList) complete(j *job, response *schema.ChatResponse, err error, cancelled bool) {
+	l.Lock()
+	defer l.Unlock()
+	j.CompletedAt = types.Ptr(l.clock.Now())
+	switch {
+	case cancelled:
+		j.Status, j.Error = schema.JobCancelled, context.Canceled.Error()
+		if err != nil && !errors.Is(err, context.Canceled) {
+			j.Error = err.Error()
+		}
+	case err != nil:
+		j.Status, j.Error = schema.JobFailed, err.Error()
+	default:
+		j.Status, j.Result = schema.JobCompleted, response
+	}
+}
+
+// get returns a copy of a job started by the user, or any job when user is
+// nil
+func (l *jobList) get(id, user uuid.UUID) (*schema.Job, error) {
+	l.Lock()
+	defer l.Unlock()
+	l.expire(l.clock.Now())
+	j, exists := l.jobs[id]
+	if !exists || (user != uuid.Nil && j.user != user) {
+		return nil, schema.ErrNotFound.Withf("job %q not found", id)
+	}
+	result := j.Job
+	return &result, nil
+}
+
+// cancel cancels a running job and returns it. The job is returned before it
+// stops, so its status may still be running.
+func (l *jobList) cancel(id, user uuid.UUID) (*schema.Job, error) {
+	l.Lock()
+	j, exists := l.jobs[id]
+	if exists && (user == uuid.Nil || j.user == user) {
+		j.cancel()
+	}
+	l.Unlock()
+	return l.get(id, user)
+}
+
+// cancelAll cancels every running job
+func (l *jobList) cancelAll() {
+	l.Lock()
+	defer l.Unlock()
+	for _, j := range l.jobs {
+		j.cancel()
+	}
+}
+
+// expire removes jobs which were done more than jobTTL before now. The lock
+// must be held.
+func (l *jobList) expire(now time.Time) {
+	for id, j := range l.jobs {
+		if j.CompletedAt != nil && now.Sub(*j.CompletedAt) > jobTTL {
+			delete(l.jobs, id)
+		}
+	}
+}
+
+// userSub returns the subject of the user, or nil when user is nil
+func userSub(user *auth.UserInfo) uuid.UUID {
+	if user == nil {
+		return uuid.Nil
+	}
+	return uuid.UUID(user.Sub)
+}
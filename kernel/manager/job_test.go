@@ -0,0 +1,121 @@
+package manager
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	// Packages
+	uuid "github.com/google/uuid"
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	assert "github.com/stretchr/testify/assert"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// HELPERS
+
+// waitJob polls a job until it is done
+func waitJob(t *testing.T, l *jobList, id, user uuid.UUID) *schema.Job {
+	t.Helper()
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(5 * time.Millisecond) {
+		job, err := l.get(id, user)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if job.Done() {
+			return job
+		}
+	}
+	t.Fatal("timed out waiting for job")
+	return nil
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// TESTS
+
+func TestJobCompleted(t *testing.T) {
+	assert := assert.New(t)
+	var l jobList
+	session, user := uuid.New(), uuid.New()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	release := make(chan struct{})
+	job := l.start(context.WithoutCancel(ctx), session, user, func(ctx context.Context) (*schema.ChatResponse, error) {
+		<-release
+		return &schema.ChatResponse{Session: session}, ctx.Err()
+	})
+	assert.Equal(schema.JobRunning, job.Status)
+	assert.Equal(session, job.Session)
+
+	// The job continues when the context which started it ends
+	cancel()
+	close(release)
+	job = waitJob(t, &l, job.ID, user)
+	assert.Equal(schema.JobCompleted, job.Status)
+	assert.NotNil(job.CompletedAt)
+	if assert.NotNil(job.Result) {
+		assert.Equal(session, job.Result.Session)
+	}
+	assert.Empty(job.Error)
+}
+
+func TestJobFailed(t *testing.T) {
+	assert := assert.New(t)
+	var l jobList
+
+	job := l.start(context.Background(), uuid.New(), uuid.Nil, func(ctx context.Context) (*schema.ChatResponse, error) {
+		return nil, errors.New("provider failed")
+	})
+	job = waitJob(t, &l, job.ID, uuid.Nil)
+	assert.Equal(schema.JobFailed, job.Status)
+	assert.Equal("provider failed", job.Error)
+	assert.Nil(job.Result)
+}
+
+func TestJobCancelled(t *testing.T) {
+	assert := assert.New(t)
+	var l jobList
+	user := uuid.New()
+
+	job := l.start(context.Background(), uuid.New(), user, func(ctx context.Context) (*schema.ChatResponse, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+
+	// Another user cannot see or cancel the job
+	_, err := l.cancel(job.ID, uuid.New())
+	assert.ErrorIs(err, schema.ErrNotFound)
+
+	_, err = l.cancel(job.ID, user)
+	assert.NoError(err)
+	job = waitJob(t, &l, job.ID, user)
+	assert.Equal(schema.JobCancelled, job.Status)
+	assert.NotEmpty(job.Error)
+}
+
+func TestJobNotFound(t *testing.T) {
+	assert := assert.New(t)
+	var l jobList
+
+	_, err := l.get(uuid.New(), uuid.Nil)
+	assert.ErrorIs(err, schema.ErrNotFound)
+}
+
+func TestJobExpire(t *testing.T) {
+	assert := assert.New(t)
+	var l jobList
+
+	job := l.start(context.Background(), uuid.New(), uuid.Nil, func(ctx context.Context) (*schema.ChatResponse, error) {
+		return &schema.ChatResponse{}, nil
+	})
+	waitJob(t, &l, job.ID, uuid.Nil)
+
+	// Jobs are kept until jobTTL after they are done
+	l.Lock()
+	l.expire(time.Now().Add(jobTTL / 2))
+	assert.Len(l.jobs, 1)
+	l.expire(time.Now().Add(2 * jobTTL))
+	assert.Empty(l.jobs)
+	l.Unlock()
+}
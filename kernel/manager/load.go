@@ -0,0 +1,165 @@
+package manager
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	// Packages
+	auth "github.com/mutablelogic/go-auth/auth/schema"
+	otel "github.com/mutablelogic/go-client/pkg/otel"
+	llm "github.com/mutablelogic/go-llm"
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	types "github.com/mutablelogic/go-server/pkg/types"
+	attribute "go.opentelemetry.io/otel/attribute"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+type loaderCandidate struct {
+	clientName string
+	loader     llm.Loader
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+// Default time between keepalive requests for warm models, which is within
+// the time after which Ollama unloads an idle model
+const modelKeepAlive = 4 * time.Minute
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// LoadModel loads a model into memory for a provider which runs models
+// locally, so that the next request does not wait for the model to load.
+// Loading a model which is already loaded keeps it loaded for longer.
+func (m *Manager) LoadModel(ctx context.Context, req schema.LoadModelRequest, user *auth.UserInfo) (result *schema.Model, err error) {
+	ctx, endSpan := otel.StartSpan(m.tracer, ctx, "LoadModel",
+		attribute.String("req", types.Stringify(req)),
+		attribute.String("user", types.Stringify(user)),
+	)
+	defer func() { endSpan(err) }()
+
+	model, candidate, err := m.loaderForModel(ctx, req.Provider, req.Name, user)
+	if err != nil {
+		return nil, err
+	}
+	if err := candidate.loader.LoadModel(ctx, candidate.runtimeModel(model)); err != nil {
+		return nil, err
+	}
+
+	// Return success
+	return types.Ptr(model), nil
+}
+
+// UnloadModel unloads a model from memory for a provider which runs models
+// locally.
+func (m *Manager) UnloadModel(ctx context.Context, req schema.UnloadModelRequest, user *auth.UserInfo) (result *schema.Model, err error) {
+	ctx, endSpan := otel.StartSpan(m.tracer, ctx, "UnloadModel",
+		attribute.String("req", types.Stringify(req)),
+		attribute.String("user", types.Stringify(user)),
+	)
+	defer func() { endSpan(err) }()
+
+	model, candidate, err := m.loaderForModel(ctx, req.Provider, req.Name, user)
+	if err != nil {
+		return nil, err
+	}
+	if err := candidate.loader.UnloadModel(ctx, candidate.runtimeModel(model)); err != nil {
+		return nil, err
+	}
+
+	// Return success
+	return types.Ptr(model), nil
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// runWarmup loads the warm models, and then loads them again after every
+// keepalive period so that they are not unloaded while idle, until the
+// context is cancelled
+func (m *Manager) runWarmup(ctx context.Context, logger *slog.Logger) {
+	m.warmModels(ctx, logger)
+	if m.keepalive <= 0 {
+		return
+	}
+	ticker := time.NewTicker(m.keepalive)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			m.warmModels(ctx, logger)
+		}
+	}
+}
+
+func (m *Manager) warmModels(ctx context.Context, logger *slog.Logger) {
+	for _, req := range m.warm {
+		start := time.Now()
+		if model, err := m.LoadModel(ctx, req, nil); err != nil {
+			if ctx.Err() == nil {
+				logger.ErrorContext(ctx, "failed to load model", "provider", req.Provider, "model", req.Name, "error", err.Error())
+			}
+		} else {
+			logger.DebugContext(ctx, "loaded model", "provider", model.OwnedBy, "model", model.Name, "duration", time.Since(start))
+		}
+	}
+}
+
+// loaderForModel returns the model with the given name, and the loader for
+// the provider which owns it. The name may be an alias.
+func (m *Manager) loaderForModel(ctx context.Context, provider, name string, user *auth.UserInfo) (schema.Model, loaderCandidate, error) {
+	providers, err := m.providersForUser(ctx, provider, user)
+	if err != nil {
+		return schema.Model{}, loaderCandidate{}, err
+	}
+	loaders := make(map[string]loaderCandidate, len(providers))
+	candidates := make([]schema.Provider, 0, len(providers))
+	for _, provider := range providers {
+		client := m.Registry.Get(provider.Name)
+		if client == nil {
+			continue
+		}
+		if loader, ok := client.Self().(llm.Loader); ok {
+			loaders[provider.Name] = loaderCandidate{clientName: client.Name(), loader: loader}
+			candidates = append(candidates, provider)
+		}
+	}
+	if len(candidates) == 0 {
+		if provider != "" {
+			return schema.Model{}, loaderCandidate{}, schema.ErrNotFound.Withf("provider %q not found or does not support loading models", provider)
+		}
+		return schema.Model{}, loaderCandidate{}, schema.ErrNotFound.With("no provider found that supports loading models")
+	}
+
+	// Resolve the named model across the candidate providers
+	resolved, _ := m.resolveModel(name)
+	models, err := m.modelsByName(ctx, candidates, resolved)
+	if err != nil {
+		return schema.Model{}, loaderCandidate{}, err
+	}
+	switch len(models) {
+	case 0:
+		return schema.Model{}, loaderCandidate{}, schema.ErrNotFound.Withf("model %q not found", name)
+	case 1:
+		if candidate, exists := loaders[models[0].OwnedBy]; exists {
+			return models[0], candidate, nil
+		}
+		return schema.Model{}, loaderCandidate{}, schema.ErrNotFound.Withf("model %q not found", name)
+	default:
+		return schema.Model{}, loaderCandidate{}, schema.ErrConflict.Withf("multiple providers own model %q; specify a provider", name)
+	}
+}
+
+// runtimeModel returns the model as it is known to the provider client
+func (c loaderCandidate) runtimeModel(model schema.Model) schema.Model {
+	if c.clientName != "" {
+		model.OwnedBy = c.clientName
+	}
+	return model
+}
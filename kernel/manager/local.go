@@ -0,0 +1,241 @@
+package manager
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+
+	// Packages
+	llm "github.com/mutablelogic/go-llm"
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	opt "github.com/mutablelogic/go-llm/pkg/opt"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// localScheduler queues generations with providers which run models locally,
+// so that the models with running generations fit in the memory of the
+// provider and each model runs no more than its limit of generations at
+// once. A model is resident while it has running generations, and uses the
+// memory hint for the model. Waiting generations are started in arrival
+// order as they fit, except that a generation which needs a model to be
+// loaded is not overtaken by later generations which also need one.
+type localScheduler struct {
+	sync.Mutex
+	capacity map[string]float64    // memory of each provider
+	models   map[string]localModel // hints for each model, by name
+	used     map[string]float64    // memory of resident models, by provider
+	active   map[localKey]int      // running generations, by provider and model
+	queue    []*localEntry         // waiting generations, in arrival order
+}
+
+// localModel is the memory used by a model when it is loaded, and the
+// number of generations which run with it at once, or zero for no limit
+type localModel struct {
+	memory      float64
+	concurrency int
+}
+
+type localKey struct {
+	provider, model string
+}
+
+// localEntry is a waiting generation, which is started when ready is closed
+type localEntry struct {
+	localKey
+	ready chan struct{}
+}
+
+// localGenerator waits for the local scheduler before each generation
+type localGenerator struct {
+	llm.Generator
+	scheduler *localScheduler
+	provider  string
+}
+
+var _ llm.Generator = (*localGenerator)(nil)
+
+///////////////////////////////////////////////////////////////////////////////
+// LIFECYCLE
+
+func newLocalScheduler() *localScheduler {
+	return &localScheduler{
+		capacity: make(map[string]float64),
+		models:   make(map[string]localModel),
+		used:     make(map[string]float64),
+		active:   make(map[localKey]int),
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+func (g *localGenerator) WithoutSession(ctx context.Context, model schema.Model, message *schema.Message, opts ...opt.Opt) (*schema.Message, *schema.UsageMeta, error) {
+	release, err := g.scheduler.acquire(ctx, g.provider, model.Name)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer release()
+	return g.Generator.WithoutSession(ctx, model, message, opts...)
+}
+
+func (g *localGenerator) WithSession(ctx context.Context, model schema.Model, conversation *schema.Conversation, message *schema.Message, opts ...opt.Opt) (*schema.Message, *schema.UsageMeta, error) {
+	release, err := g.scheduler.acquire(ctx, g.provider, model.Name)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer release()
+	return g.Generator.WithSession(ctx, model, conversation, message, opts...)
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// wrap returns a generator which waits for the scheduler, or the generator
+// when there is no scheduler
+func (s *localScheduler) wrap(provider string, generator llm.Generator) llm.Generator {
+	if s == nil {
+		return generator
+	}
+	return &localGenerator{Generator: generator, scheduler: s, provider: provider}
+}
+
+// setCapacity sets the memory of a provider
+func (s *localScheduler) setCapacity(provider string, memory float64) error {
+	if provider == "" {
+		return fmt.Errorf("local capacity requires a provider")
+	} else if memory <= 0 {
+		return fmt.Errorf("local capacity for %q must be greater than zero", provider)
+	}
+	s.capacity[provider] = memory
+	return nil
+}
+
+// setModel sets the memory and concurrency hints for a model
+func (s *localScheduler) setModel(name string, memory float64, concurrency int) error {
+	if name == "" {
+		return fmt.Errorf("local model requires a name")
+	} else if memory < 0 {
+		return fmt.Errorf("memory for local model %q cannot be negative", name)
+	} else if concurrency < 0 {
+		return fmt.Errorf("concurrency for local model %q cannot be negative", name)
+	}
+	s.models[name] = localModel{memory: memory, concurrency: concurrency}
+	return nil
+}
+
+// acquire waits until a generation with the model can run, and returns a
+// function which releases it. Generations with providers and models which
+// have no hints run immediately.
+func (s *localScheduler) acquire(ctx context.Context, provider, model string) (func(), error) {
+	key := localKey{provider: provider, model: model}
+	_, limited := s.capacity[provider]
+	if _, exists := s.models[model]; !limited && !exists {
+		return func() {}, nil
+	}
+
+	// Join the queue, and start any generations which fit
+	entry := &localEntry{localKey: key, ready: make(chan struct{})}
+	s.Lock()
+	s.queue = append(s.queue, entry)
+	s.dispatch()
+	s.Unlock()
+
+	// Wait to be started, or the context to end
+	select {
+	case <-entry.ready:
+		return s.releaser(key), nil
+	case <-ctx.Done():
+		s.Lock()
+		defer s.Unlock()
+		if i := slices.Index(s.queue, entry); i >= 0 {
+			s.queue = slices.Delete(s.queue, i, i+1)
+			s.dispatch()
+		} else {
+			// The generation was started as the context ended
+			s.finish(key)
+		}
+		return nil, ctx.Err()
+	}
+}
+
+// releaser returns a function which releases the generation once
+func (s *localScheduler) releaser(key localKey) func() {
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			s.Lock()
+			defer s.Unlock()
+			s.finish(key)
+		})
+	}
+}
+
+// finish ends a running generation, unloading the model when it has no
+// running generations, and starts any waiting generations which now fit.
+// The lock must be held.
+func (s *localScheduler) finish(key localKey) {
+	if s.active[key]--; s.active[key] <= 0 {
+		delete(s.active, key)
+		s.used[key.provider] -= s.models[key.model].memory
+		if !s.resident(key.provider) {
+			delete(s.used, key.provider)
+		}
+	}
+	s.dispatch()
+}
+
+// resident returns true if any model of the provider has running
+// generations. The lock must be held.
+func (s *localScheduler) resident(provider string) bool {
+	for key := range s.active {
+		if key.provider == provider {
+			return true
+		}
+	}
+	return false
+}
+
+// dispatch starts the waiting generations which fit, in arrival order. The
+// lock must be held.
+func (s *localScheduler) dispatch() {
+	blocked := make(map[string]bool)
+	queue := s.queue[:0]
+	for _, entry := range s.queue {
+		if s.fits(entry.localKey, blocked[entry.provider]) {
+			if s.active[entry.localKey] == 0 {
+				s.used[entry.provider] += s.models[entry.model].memory
+			}
+			s.active[entry.localKey]++
+			close(entry.ready)
+			continue
+		}
+		if s.active[entry.localKey] == 0 {
+			blocked[entry.provider] = true
+		}
+		queue = append(queue, entry)
+	}
+	clear(s.queue[len(queue):])
+	s.queue = queue
+}
+
+// fits returns true if a generation can start. A model which is not resident
+// must fit in the memory which is left, unless no model of the provider is
+// resident, and cannot be loaded when an earlier generation is waiting for
+// memory. The lock must be held.
+func (s *localScheduler) fits(key localKey, blocked bool) bool {
+	active := s.active[key]
+	if limit := s.models[key.model].concurrency; limit > 0 && active >= limit {
+		return false
+	}
+	if active > 0 {
+		return true
+	}
+	capacity, limited := s.capacity[key.provider]
+	if !limited {
+		return true
+	}
+	return !blocked && (!s.resident(key.provider) || s.used[key.provider]+s.models[key.model].memory <= capacity)
+}
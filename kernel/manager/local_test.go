@@ -0,0 +1,150 @@
+package manager
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	// Packages
+	assert "github.com/stretchr/testify/assert"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// HELPERS
+
+// waitLocalQueued waits until the number of waiting generations is n
+func waitLocalQueued(t *testing.T, s *localScheduler, n int) {
+	t.Helper()
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+		s.Lock()
+		waiting := len(s.queue)
+		s.Unlock()
+		if waiting == n {
+			return
+		}
+	}
+	t.Fatalf("timed out waiting for %d queued generations", n)
+}
+
+// acquireAsync acquires a generation in the background, and returns a channel
+// which receives the release function when it is started
+func acquireAsync(ctx context.Context, s *localScheduler, provider, model string) chan func() {
+	ch := make(chan func(), 1)
+	go func() {
+		if release, err := s.acquire(ctx, provider, model); err == nil {
+			ch <- release
+		}
+	}()
+	return ch
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// TESTS
+
+func TestLocalOptions(t *testing.T) {
+	assert := assert.New(t)
+
+	s := newLocalScheduler()
+	assert.Error(s.setCapacity("", 10))
+	assert.Error(s.setCapacity("ollama", 0))
+	assert.Error(s.setModel("", 1, 1))
+	assert.Error(s.setModel("llama3.2", -1, 1))
+	assert.Error(s.setModel("llama3.2", 1, -1))
+	assert.NoError(s.setCapacity("ollama", 10))
+	assert.NoError(s.setModel("llama3.2", 4, 0))
+}
+
+func TestLocalUnlimited(t *testing.T) {
+	assert := assert.New(t)
+
+	s := newLocalScheduler()
+	assert.NoError(s.setCapacity("ollama", 10))
+
+	// Other providers and models without hints are not scheduled
+	release, err := s.acquire(context.Background(), "mistral", "mistral-large")
+	assert.NoError(err)
+	release()
+	s.Lock()
+	assert.Empty(s.active)
+	s.Unlock()
+}
+
+func TestLocalCapacity(t *testing.T) {
+	assert := assert.New(t)
+
+	s := newLocalScheduler()
+	assert.NoError(s.setCapacity("ollama", 10))
+	assert.NoError(s.setModel("large", 8, 0))
+	assert.NoError(s.setModel("small", 2, 0))
+	assert.NoError(s.setModel("medium", 4, 0))
+
+	// The large and small models fit together, and the resident model runs
+	// more generations
+	large, err := s.acquire(context.Background(), "ollama", "large")
+	assert.NoError(err)
+	small, err := s.acquire(context.Background(), "ollama", "small")
+	assert.NoError(err)
+	large2, err := s.acquire(context.Background(), "ollama", "large")
+	assert.NoError(err)
+
+	// The medium model waits, and so does a later small generation which
+	// needs nothing to be loaded
+	medium := acquireAsync(context.Background(), s, "ollama", "medium")
+	waitLocalQueued(t, s, 1)
+	small2, err := s.acquire(context.Background(), "ollama", "small")
+	assert.NoError(err)
+	small()
+	small2()
+	select {
+	case <-medium:
+		t.Fatal("medium model started before there was memory")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	// When the large model is unloaded, the medium model starts
+	large()
+	large2()
+	select {
+	case release := <-medium:
+		release()
+	case <-time.After(5 * time.Second):
+		t.Fatal("medium model did not start")
+	}
+	s.Lock()
+	assert.Empty(s.active)
+	assert.Empty(s.used)
+	s.Unlock()
+}
+
+func TestLocalConcurrency(t *testing.T) {
+	assert := assert.New(t)
+
+	s := newLocalScheduler()
+	assert.NoError(s.setModel("llama3.2", 0, 1))
+
+	// The second generation waits for the first
+	first, err := s.acquire(context.Background(), "ollama", "llama3.2")
+	assert.NoError(err)
+	second := acquireAsync(context.Background(), s, "ollama", "llama3.2")
+	waitLocalQueued(t, s, 1)
+
+	// A cancelled generation leaves the queue
+	ctx, cancel := context.WithCancel(context.Background())
+	errs := make(chan error, 1)
+	go func() {
+		_, err := s.acquire(ctx, "ollama", "llama3.2")
+		errs <- err
+	}()
+	waitLocalQueued(t, s, 2)
+	cancel()
+	assert.ErrorIs(<-errs, context.Canceled)
+	waitLocalQueued(t, s, 1)
+
+	first()
+	select {
+	case release := <-second:
+		release()
+	case <-time.After(5 * time.Second):
+		t.Fatal("second generation did not start")
+	}
+}
@@ -0,0 +1,218 @@
+package manager
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	// Packages
+	otel "github.com/mutablelogic/go-client/pkg/otel"
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	pg "github.com/mutablelogic/go-pg"
+	attribute "go.opentelemetry.io/otel/attribute"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// keyedLock serializes callers which use the same key, while callers with
+// different keys run concurrently. Waiting callers acquire the lock in the
+// order they arrived. The zero value is ready to use.
+type keyedLock struct {
+	sync.Mutex
+	keys map[string]*queuedLock
+}
+
+type queuedLock struct {
+	token chan struct{} // holds a value while the lock is held
+	refs  int           // the holder and all waiting callers
+}
+
+// advisoryTryLock selects the lock for a key without waiting for it
+type advisoryTryLock string
+
+// advisoryLockResult is true when a lock was acquired
+type advisoryLockResult bool
+
+///////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+// Acquires a lock which is held until the end of the transaction. The key is
+// hashed to the 64-bit integer which identifies the lock.
+const advisoryLockQuery = `SELECT pg_advisory_xact_lock(hashtextextended(@key, 0))`
+
+// Acquires the same lock if it is free, and returns whether it was acquired
+const advisoryTryLockQuery = `SELECT pg_try_advisory_xact_lock(hashtextextended(@key, 0))`
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// LockLabel waits for exclusive use of a session label, such as the chat ID
+// of a bot framework, so that concurrent inbound messages for a conversation
+// are handled one at a time and in order. The returned function releases
+// the lock, and an error is returned if the context is cancelled while
+// waiting.
+func (m *Manager) LockLabel(ctx context.Context, label string) (_ func(), err error) {
+	label = strings.TrimSpace(label)
+	if label == "" {
+		return nil, schema.ErrBadParameter.With("label is required")
+	}
+
+	ctx, endSpan := otel.StartSpan(m.tracer, ctx, "LockLabel",
+		attribute.String("label", label),
+		attribute.Int("queued", m.labels.Queued("label:"+label)),
+	)
+	defer func() { endSpan(err) }()
+
+	return m.lock(ctx, &m.labels, "label:"+label)
+}
+
+// Lock waits until the lock for the key is free, or the context is cancelled
+func (l *keyedLock) Lock(ctx context.Context, key string) (func(), error) {
+	l.Mutex.Lock()
+	if l.keys == nil {
+		l.keys = make(map[string]*queuedLock)
+	}
+	q, exists := l.keys[key]
+	if !exists {
+		q = &queuedLock{token: make(chan struct{}, 1)}
+		l.keys[key] = q
+	}
+	q.refs++
+	l.Mutex.Unlock()
+
+	// Blocked senders on a channel are woken in order, which queues callers
+	select {
+	case q.token <- struct{}{}:
+		var once sync.Once
+		return func() {
+			once.Do(func() {
+				<-q.token
+				l.release(key, q)
+			})
+		}, nil
+	case <-ctx.Done():
+		l.release(key, q)
+		return nil, ctx.Err()
+	}
+}
+
+// Queued returns the number of callers waiting for the lock on a key
+func (l *keyedLock) Queued(key string) int {
+	l.Mutex.Lock()
+	defer l.Mutex.Unlock()
+	if q, exists := l.keys[key]; exists {
+		return max(q.refs-1, 0)
+	}
+	return 0
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// lockSession waits for exclusive use of a session, so that chat turns and
+// appended messages extend the conversation in order
+func (m *Manager) lockSession(ctx context.Context, session string) (func(), error) {
+	return m.lock(ctx, &m.sessions, "session:"+session)
+}
+
+// exclusive runs a background job unless a replica which shares the
+// database is already running the job with the same name, in which case the
+// job is skipped and false is returned
+func (m *Manager) exclusive(ctx context.Context, name string, fn func(context.Context) error) (bool, error) {
+	var ran bool
+	if err := m.PoolConn.Tx(ctx, func(conn pg.Conn) error {
+		var locked advisoryLockResult
+		if err := conn.Get(ctx, &locked, advisoryTryLock("job:"+name)); err != nil {
+			return err
+		} else if !locked {
+			return nil
+		}
+		ran = true
+		return fn(ctx)
+	}); err != nil {
+		return ran, pg.NormalizeError(err)
+	}
+	return ran, nil
+}
+
+// lock waits for the key in this process, and then for the advisory lock on
+// the key in the database when locks are shared between replicas
+func (m *Manager) lock(ctx context.Context, l *keyedLock, key string) (func(), error) {
+	unlock, err := l.Lock(ctx, key)
+	if err != nil || !m.sharedlocks {
+		return unlock, err
+	}
+	release, err := m.advisoryLock(ctx, key)
+	if err != nil {
+		unlock()
+		return nil, err
+	}
+	return func() {
+		release()
+		unlock()
+	}, nil
+}
+
+// advisoryLock holds an advisory lock on the key in a transaction until the
+// returned function is called, or the context is cancelled. The transaction
+// keeps a pooled connection for as long as the lock is held, which is a chat
+// turn at most, and is rolled back when the request is cancelled. Waiting
+// callers in this process are already queued, so there is at most one
+// connection for each key being locked.
+func (m *Manager) advisoryLock(ctx context.Context, key string) (func(), error) {
+	return holdTx(ctx, m.PoolConn.With("key", key).Tx, func(conn pg.Conn) error {
+		return conn.Exec(ctx, advisoryLockQuery)
+	})
+}
+
+// holdTx runs acquire in a transaction, and keeps the transaction open until
+// the returned function is called or the context is cancelled
+func holdTx(ctx context.Context, tx func(context.Context, func(pg.Conn) error) error, acquire func(pg.Conn) error) (func(), error) {
+	locked, release, done := make(chan struct{}), make(chan struct{}), make(chan error, 1)
+	go func() {
+		done <- tx(ctx, func(conn pg.Conn) error {
+			if err := acquire(conn); err != nil {
+				return err
+			}
+			close(locked)
+			select {
+			case <-release:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})
+	}()
+
+	select {
+	case <-locked:
+		var once sync.Once
+		return func() {
+			once.Do(func() {
+				close(release)
+				<-done
+			})
+		}, nil
+	case err := <-done:
+		return nil, pg.NormalizeError(err)
+	}
+}
+
+// release removes the lock for a key when it has no holder or waiters
+func (l *keyedLock) release(key string, q *queuedLock) {
+	l.Mutex.Lock()
+	defer l.Mutex.Unlock()
+	if q.refs--; q.refs == 0 {
+		delete(l.keys, key)
+	}
+}
+
+func (key advisoryTryLock) Select(bind *pg.Bind, _ pg.Op) (string, error) {
+	bind.Set("key", string(key))
+	return advisoryTryLockQuery, nil
+}
+
+func (result *advisoryLockResult) Scan(row pg.Row) error {
+	return row.Scan((*bool)(result))
+}
@@ -0,0 +1,133 @@
+package manager
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	// Packages
+	pg "github.com/mutablelogic/go-pg"
+	assert "github.com/stretchr/testify/assert"
+)
+
+func TestKeyedLockSerializesKey(t *testing.T) {
+	assert := assert.New(t)
+	var locks keyedLock
+
+	unlock, err := locks.Lock(context.Background(), "chat:1")
+	if !assert.NoError(err) {
+		return
+	}
+
+	// Queue callers one at a time, so their arrival order is known
+	var mu sync.Mutex
+	var order []int
+	var wg sync.WaitGroup
+	for i := range 3 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			unlock, err := locks.Lock(context.Background(), "chat:1")
+			if err != nil {
+				return
+			}
+			mu.Lock()
+			order = append(order, i)
+			mu.Unlock()
+			unlock()
+		}()
+		assert.Eventually(func() bool { return locks.Queued("chat:1") == i+1 }, time.Second, time.Millisecond)
+	}
+
+	unlock()
+	wg.Wait()
+	assert.Equal([]int{0, 1, 2}, order)
+	assert.Zero(locks.Queued("chat:1"))
+	assert.Empty(locks.keys)
+}
+
+func TestKeyedLockIndependentKeys(t *testing.T) {
+	assert := assert.New(t)
+	var locks keyedLock
+
+	unlock1, err := locks.Lock(context.Background(), "chat:1")
+	if !assert.NoError(err) {
+		return
+	}
+	defer unlock1()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	unlock2, err := locks.Lock(ctx, "chat:2")
+	if assert.NoError(err) {
+		unlock2()
+	}
+}
+
+func TestKeyedLockCancel(t *testing.T) {
+	assert := assert.New(t)
+	var locks keyedLock
+
+	unlock, err := locks.Lock(context.Background(), "chat:1")
+	if !assert.NoError(err) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = locks.Lock(ctx, "chat:1")
+	assert.ErrorIs(err, context.DeadlineExceeded)
+	assert.Zero(locks.Queued("chat:1"))
+
+	// Releasing twice has no effect
+	unlock()
+	unlock()
+	assert.Empty(locks.keys)
+}
+
+func TestHoldTxReleasedOnCancel(t *testing.T) {
+	assert := assert.New(t)
+
+	// The transaction returns when the lock is released
+	var finished sync.WaitGroup
+	tx := func(ctx context.Context, fn func(pg.Conn) error) error {
+		finished.Add(1)
+		defer finished.Done()
+		return fn(nil)
+	}
+	acquire := func(pg.Conn) error { return nil }
+
+	// Cancelling the request ends the transaction without calling release
+	ctx, cancel := context.WithCancel(context.Background())
+	release, err := holdTx(ctx, tx, acquire)
+	if !assert.NoError(err) {
+		cancel()
+		return
+	}
+	cancel()
+	finished.Wait()
+
+	// Releasing afterwards does not block
+	done := make(chan struct{})
+	go func() {
+		release()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("release blocked after the context was cancelled")
+	}
+}
+
+func TestHoldTxAcquireError(t *testing.T) {
+	assert := assert.New(t)
+	tx := func(ctx context.Context, fn func(pg.Conn) error) error {
+		return fn(nil)
+	}
+	_, err := holdTx(context.Background(), tx, func(pg.Conn) error {
+		return context.DeadlineExceeded
+	})
+	assert.ErrorIs(err, context.DeadlineExceeded)
+}
@@ -3,12 +3,17 @@ package manager
 import (
 	"context"
 	"fmt"
+	"slices"
 	"strings"
 	"time"
 
 	// Packages
+	uuid "github.com/google/uuid"
 	otel "github.com/mutablelogic/go-client/pkg/otel"
 	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	media "github.com/mutablelogic/go-llm/pkg/media"
+	ocr "github.com/mutablelogic/go-llm/pkg/ocr"
+	workspace "github.com/mutablelogic/go-llm/pkg/workspace"
 	providerregistry "github.com/mutablelogic/go-llm/provider/registry"
 	toolkit "github.com/mutablelogic/go-llm/toolkit"
 	pg "github.com/mutablelogic/go-pg"
@@ -28,6 +33,13 @@ type Manager struct {
 	broadcaster broadcaster.Broadcaster
 	sessionfeed *SessionFeed
 	delegate    *delegate
+	labels      keyedLock // serializes inbound messages by session label
+	sessions    keyedLock // serializes chat turns within a session
+	models      modelCache
+	jobs        jobList                  // chat turns running in the background
+	recordings  recordingList            // intermediate states of recorded chat turns
+	instance    uuid.UUID                // identifies this replica in checkpoints
+	reload      chan chan *schema.Reload // requests to reload, handled by Run
 }
 
 ///////////////////////////////////////////////////////////////////////////////
@@ -82,12 +94,79 @@ func New(ctx context.Context, name, version string, pool pg.PoolConn, opts ...Op
 	}
 
 	// Create the provider registry
-	if registry := providerregistry.New(self.clientopts...); registry == nil {
+	if registry := providerregistry.New(slices.Concat(self.clientopts, self.provideropts)...); registry == nil {
 		return nil, fmt.Errorf("unable to create provider registry")
 	} else {
 		self.Registry = registry
 	}
 
+	// Add the media tools, which transcribe audio with a provider from the
+	// registry
+	if self.media != nil {
+		var opts []media.Opt
+		if self.media.provider != "" {
+			opts = append(opts, media.WithTranscriber(&transcriber{Registry: self.Registry, provider: self.media.provider}, self.media.model))
+		}
+		if tools, err := media.NewTools(opts...); err != nil {
+			return nil, err
+		} else {
+			self.tools = append(self.tools, tools...)
+		}
+	}
+
+	// Read the text of images with a provider from the registry
+	if self.ocrprovider != nil {
+		if recognizer, err := ocr.NewReader(&documentReader{Registry: self.Registry, provider: self.ocrprovider.provider}, self.ocrprovider.model); err != nil {
+			return nil, err
+		} else {
+			self.ocr = recognizer
+		}
+	}
+
+	// Add the calendar tools, which read credentials from the database
+	if self.calendar != nil {
+		if err := self.calendarTools(); err != nil {
+			return nil, err
+		}
+	}
+
+	// Add a tool for each REST endpoint
+	if len(self.endpoints) > 0 {
+		if err := self.restTools(); err != nil {
+			return nil, err
+		}
+	}
+
+	// Add the tools which query a GraphQL endpoint
+	if self.graphql != nil {
+		if err := self.graphqlTools(); err != nil {
+			return nil, err
+		}
+	}
+
+	// Add the tools which edit files in the workspace
+	if self.workspace != "" {
+		if tools, err := workspace.NewTools(self.workspace); err != nil {
+			return nil, err
+		} else {
+			self.addTools(tools...)
+		}
+	}
+
+	// Identify this replica, which owns the checkpoints of its turns
+	self.instance = self.ids.New()
+
+	// The in-memory stores read the time and make IDs with the options
+	self.jobs.clock, self.jobs.ids = self.clock, self.ids
+	self.recordings.clock, self.recordings.ids = self.clock, self.ids
+	self.models.clock = self.clock
+	if self.breakers != nil {
+		self.breakers.clock = self.clock
+	}
+
+	// Create the channel for reload requests, which are handled by Run
+	self.reload = make(chan chan *schema.Reload)
+
 	// Create a connector delegate, which receives notifications of connector changes
 	self.delegate = NewDelegate(self.name, self.version, self.connectors, self.runAgent, self.clientopts...)
 
@@ -131,8 +210,39 @@ func bootstrap(ctx context.Context, conn pg.Conn, schemaName string) error {
 		}
 	}
 
-	// Return success
-	return nil
+	// Apply migrations to objects created by earlier releases
+	return migrate(ctx, conn)
+}
+
+// migrate applies the migrations which have not been applied, in order of
+// version. The lock is held until the end of the transaction, so replicas
+// which start together wait for each other.
+func migrate(ctx context.Context, conn pg.Conn) error {
+	migrations, err := schema.ParseMigrations(schema.Migrations)
+	if err != nil {
+		return fmt.Errorf("parse migrations.sql: %w", err)
+	}
+	return conn.Tx(ctx, func(conn pg.Conn) error {
+		if err := conn.Get(ctx, nil, schema.MigrationLock{}); err != nil {
+			return fmt.Errorf("lock migrations: %w", err)
+		}
+		var applied schema.MigrationList
+		if err := conn.List(ctx, &applied, schema.MigrationListRequest{}); err != nil {
+			return fmt.Errorf("list migrations: %w", err)
+		}
+		for _, migration := range migrations {
+			if slices.Contains(applied.Body, migration.Version) {
+				continue
+			}
+			if err := conn.Exec(ctx, migration.SQL); err != nil {
+				return fmt.Errorf("apply migration %d.%s: %w", migration.Version, migration.Name, err)
+			}
+			if err := conn.Insert(ctx, nil, migration); err != nil {
+				return fmt.Errorf("record migration %d.%s: %w", migration.Version, migration.Name, err)
+			}
+		}
+		return nil
+	})
 }
 
 func (m *Manager) registerMetrics() error {
@@ -155,5 +265,16 @@ func (m *Manager) registerMetrics() error {
 		return fmt.Errorf("register llmmanager.providers callback: %w", err)
 	}
 
+	if m.admission != nil {
+		if err := m.admission.registerMetrics(m.metrics); err != nil {
+			return err
+		}
+	}
+	if m.breakers != nil {
+		if err := m.breakers.registerMetrics(m.metrics); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
@@ -0,0 +1,39 @@
+package manager
+
+import (
+	"context"
+
+	// Packages
+	llm "github.com/mutablelogic/go-llm"
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	opt "github.com/mutablelogic/go-llm/pkg/opt"
+	providerregistry "github.com/mutablelogic/go-llm/provider/registry"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// transcriber transcribes audio with a provider from the registry. The
+// client is looked up on each call, since providers are synced after the
+// manager is created and may change while it runs.
+type transcriber struct {
+	*providerregistry.Registry
+	provider string
+}
+
+var _ llm.Transcriber = (*transcriber)(nil)
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+func (t *transcriber) Transcribe(ctx context.Context, model schema.Model, audio *schema.Attachment, opts ...opt.Opt) (*schema.Transcription, *schema.UsageMeta, error) {
+	client := t.Get(t.provider)
+	if client == nil {
+		return nil, nil, schema.ErrNotFound.Withf("provider %q not found", t.provider)
+	}
+	transcriber, ok := client.Self().(llm.Transcriber)
+	if !ok {
+		return nil, nil, schema.ErrNotImplemented.Withf("provider %q does not support transcription", t.provider)
+	}
+	return transcriber.Transcribe(ctx, model, audio, opts...)
+}
@@ -0,0 +1,115 @@
+package manager
+
+import (
+	"context"
+
+	// Packages
+	uuid "github.com/google/uuid"
+	auth "github.com/mutablelogic/go-auth/auth/schema"
+	otel "github.com/mutablelogic/go-client/pkg/otel"
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	pg "github.com/mutablelogic/go-pg"
+	types "github.com/mutablelogic/go-server/pkg/types"
+	attribute "go.opentelemetry.io/otel/attribute"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// MergeSessions copies the messages of the src session into the dst session,
+// appended after its messages or interleaved with them by time, for example
+// when a task was split across two chats by mistake. System prompts which are
+// already in dst are not copied, and the merged conversation must have an
+// answer for every tool call. The src session is not changed, and the
+// settings of dst are kept. If user is non-nil, both sessions must be owned by
+// that user.
+func (m *Manager) MergeSessions(ctx context.Context, dst, src uuid.UUID, strategy string, user *auth.UserInfo) (_ *schema.SessionMerge, err error) {
+	// OTel span
+	ctx, endSpan := otel.StartSpan(m.tracer, ctx, "MergeSessions",
+		attribute.String("dst", dst.String()),
+		attribute.String("src", src.String()),
+		attribute.String("strategy", strategy),
+		attribute.String("user", types.Stringify(user)),
+	)
+	defer func() { endSpan(err) }()
+
+	if src == uuid.Nil {
+		return nil, schema.ErrBadParameter.With("source session is required")
+	} else if dst == src {
+		return nil, schema.ErrBadParameter.With("cannot merge a session into itself")
+	}
+
+	// Wait for chat turns in both sessions to complete, locking them in the
+	// same order as any other merge of the two sessions
+	first, second := dst.String(), src.String()
+	if second < first {
+		first, second = second, first
+	}
+	for _, session := range []string{first, second} {
+		unlock, err := m.lockSession(ctx, session)
+		if err != nil {
+			return nil, err
+		}
+		defer unlock()
+	}
+
+	// Check the sessions exist and are owned by the user
+	if _, err := m.GetSession(ctx, dst, user); err != nil {
+		return nil, err
+	}
+	if _, err := m.GetSession(ctx, src, user); err != nil {
+		return nil, err
+	}
+
+	// Merge the conversations
+	to, err := m.conversationForSession(ctx, dst, user)
+	if err != nil {
+		return nil, err
+	}
+	from, err := m.conversationForSession(ctx, src, user)
+	if err != nil {
+		return nil, err
+	}
+	merged, skipped, err := schema.MergeConversations(to, from, strategy)
+	if err != nil {
+		return nil, err
+	}
+
+	// Copy the messages of src, with any thinking recorded for them
+	var copied uint
+	if err := m.PoolConn.Tx(ctx, func(conn pg.Conn) error {
+		reasoning := make(schema.ReasoningList, 0)
+		if err := conn.List(ctx, &reasoning, schema.ReasoningListSelector(src)); err != nil {
+			return err
+		}
+		thinking := make(map[uint64][]schema.ContentBlock, len(reasoning))
+		for _, r := range reasoning {
+			thinking[r.Message] = r.Content
+		}
+		for _, message := range merged {
+			if message.Session != src {
+				continue
+			}
+			var inserted schema.MessageInsert
+			if err := conn.Insert(ctx, &inserted, schema.MessageInsert{Session: dst, Message: types.Value(message)}); err != nil {
+				return err
+			}
+			if content := thinking[message.ID]; len(content) > 0 {
+				if err := conn.Insert(ctx, nil, schema.ReasoningInsert{Message: inserted.ID, Session: dst, Content: content}); err != nil {
+					return err
+				}
+			}
+			copied++
+		}
+		return nil
+	}); err != nil {
+		return nil, pg.NormalizeError(err)
+	}
+
+	// Return the merged session
+	session, err := m.GetSession(ctx, dst, user)
+	if err != nil {
+		return nil, err
+	}
+	return &schema.SessionMerge{Session: session, Copied: copied, Skipped: skipped}, nil
+}
@@ -2,9 +2,11 @@ package manager
 
 import (
 	"context"
+	"errors"
+	"strings"
 
 	// Packages
-
+	uuid "github.com/google/uuid"
 	auth "github.com/mutablelogic/go-auth/auth/schema"
 	otel "github.com/mutablelogic/go-client/pkg/otel"
 	schema "github.com/mutablelogic/go-llm/kernel/schema"
@@ -45,9 +47,105 @@ func (m *Manager) ListMessages(ctx context.Context, req schema.MessageListReques
 	return types.Ptr(result), nil
 }
 
+// AppendMessage stores user input in a session without generating a reply.
+// The message is marked as pending, and is sent to the model together with
+// any other pending messages on the next chat turn for the session.
+func (m *Manager) AppendMessage(ctx context.Context, session uuid.UUID, req schema.MessageCreateRequest, user *auth.UserInfo) (_ *schema.Message, err error) {
+	ctx, endSpan := otel.StartSpan(m.tracer, ctx, "AppendMessage",
+		attribute.String("session", session.String()),
+		attribute.String("req", req.String()),
+		attribute.String("user", types.Stringify(user)),
+	)
+	defer func() { endSpan(err) }()
+
+	// Wait for a chat turn in the session to complete, so that the message
+	// is not added while the turn sends the pending input
+	unlock, err := m.lockSession(ctx, session.String())
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	// Check the session exists and is owned by the user
+	if _, err := m.GetSession(ctx, session, user); err != nil {
+		return nil, err
+	}
+
+	// Build the message
+	message, err := pendingMessage(req)
+	if err != nil {
+		return nil, err
+	}
+
+	// Insert the message
+	var inserted schema.MessageInsert
+	if err := m.PoolConn.Insert(ctx, &inserted, schema.MessageInsert{Session: session, Message: types.Value(message)}); err != nil {
+		return nil, pg.NormalizeError(err)
+	}
+
+	// Return success
+	return types.Ptr(inserted.Message), nil
+}
+
+// UpdateMessage updates the metadata of a message in a session, for example
+// to pin it so that it is never trimmed from the context window, and returns
+// the updated message. If user is non-nil, the session must be owned by that user.
+func (m *Manager) UpdateMessage(ctx context.Context, session uuid.UUID, id uint64, meta schema.MessageMeta, user *auth.UserInfo) (_ *schema.Message, err error) {
+	ctx, endSpan := otel.StartSpan(m.tracer, ctx, "UpdateMessage",
+		attribute.String("session", session.String()),
+		attribute.Int64("id", int64(id)),
+		attribute.String("meta", meta.String()),
+		attribute.String("user", types.Stringify(user)),
+	)
+	defer func() { endSpan(err) }()
+
+	// Check the session exists and is owned by the user
+	if _, err := m.GetSession(ctx, session, user); err != nil {
+		return nil, err
+	}
+
+	// Update the message
+	var result schema.Message
+	if err := m.PoolConn.Update(ctx, &result, schema.MessageSelector{Session: session, ID: id}, meta); err != nil {
+		if err = pg.NormalizeError(err); errors.Is(err, pg.ErrNotFound) {
+			return nil, schema.ErrNotFound.Withf("message %d not found in session %s", id, session)
+		}
+		return nil, err
+	}
+
+	// Return success
+	return types.Ptr(result), nil
+}
+
 ///////////////////////////////////////////////////////////////////////////////
 // PRIVATE METHODS
 
+// pendingMessage returns a user message marked as pending
+func pendingMessage(req schema.MessageCreateRequest) (*schema.Message, error) {
+	text := strings.TrimSpace(req.Text)
+	if text == "" && len(req.Attachments) == 0 {
+		return nil, schema.ErrBadParameter.With("text is required")
+	}
+	message := &schema.Message{
+		Role: schema.RoleUser,
+		Meta: map[string]any{schema.MessageMetaPending: true},
+	}
+	if text != "" {
+		message.Content = append(message.Content, schema.ContentBlock{Text: types.Ptr(text)})
+	}
+	for i := range req.Attachments {
+		req.Attachments[i].Detect()
+		message.Content = append(message.Content, schema.ContentBlock{Attachment: &req.Attachments[i]})
+	}
+
+	// Check the attachments now, rather than when the input is sent
+	if err := schema.Validate(schema.Conversation{message}); err != nil {
+		return nil, err
+	}
+	message.Tokens = message.EstimateTokens()
+	return message, nil
+}
+
 // ListMessagesForSession returns messages for many sessions.
 func (m *Manager) listSessionMessages(ctx context.Context, req schema.MessageListRequest) (_ *schema.MessageList, err error) {
 	ctx, endSpan := otel.StartSpan(m.tracer, ctx, "ListSessionMessages",
@@ -40,39 +40,20 @@ func (m *Manager) ListModels(ctx context.Context, req schema.ModelListRequest, u
 	)
 	defer func() { endSpan(err) }()
 
-	// Get candidate providers for user, or all candidates if no user is provided.
-	providers, err := m.providersForUser(ctx, req.Provider, user)
-	if err != nil {
-		return nil, err
-	}
-
-	// Make the list of provider names for the response
-	providerNames := make([]string, 0, len(providers))
-	for _, provider := range providers {
-		providerNames = append(providerNames, provider.Name)
-	}
-
-	// Get all models for the candidate providers, then page the result for the response.
-	models, err := m.modelsForProviders(ctx, providers)
-	if err != nil {
-		return nil, err
-	}
+	return m.listModels(ctx, req, user, false)
+}
 
-	// Scope to the offset and limit
-	count := uint(len(models))
-	start := min(req.Offset, uint64(count))
-	end := uint64(count)
-	if req.Limit != nil {
-		end = min(start+types.Value(req.Limit), uint64(count))
-	}
+// RefreshModels fetches the models from the providers, bypassing the cache,
+// and returns them in the same way as ListModels
+func (m *Manager) RefreshModels(ctx context.Context, req schema.ModelListRequest, user *auth.UserInfo) (_ *schema.ModelList, err error) {
+	// Otel
+	ctx, endSpan := otel.StartSpan(m.tracer, ctx, "RefreshModels",
+		attribute.String("req", types.Stringify(req)),
+		attribute.String("user", types.Stringify(user)),
+	)
+	defer func() { endSpan(err) }()
 
-	// Return success
-	return &schema.ModelList{
-		ModelListRequest: req,
-		Provider:         providerNames,
-		Count:            count,
-		Body:             models[start:end],
-	}, nil
+	return m.listModels(ctx, req, user, true)
 }
 
 func (m *Manager) GetModel(ctx context.Context, req schema.GetModelRequest, user *auth.UserInfo) (_ *schema.Model, err error) {
@@ -90,7 +71,8 @@ func (m *Manager) GetModel(ctx context.Context, req schema.GetModelRequest, user
 	}
 
 	// Get all models for the candidate providers, to require exactly one named match.
-	models, err := m.modelsByName(ctx, providers, req.Name)
+	name, _ := m.resolveModel(req.Name)
+	models, err := m.modelsByName(ctx, providers, name)
 	if err != nil {
 		return nil, err
 	}
@@ -99,7 +81,7 @@ func (m *Manager) GetModel(ctx context.Context, req schema.GetModelRequest, user
 	} else if len(models) > 1 {
 		return nil, schema.ErrConflict.Withf("multiple models named %q found; specify a provider", req.Name)
 	}
-	return types.Ptr(models[0]), nil
+	return types.Ptr(m.withAliases(models[0])), nil
 }
 
 func (m *Manager) DownloadModel(ctx context.Context, req schema.DownloadModelRequest, user *auth.UserInfo, opts ...opt.Opt) (result *schema.Model, err error) {
@@ -125,6 +107,7 @@ func (m *Manager) DownloadModel(ctx context.Context, req schema.DownloadModelReq
 		if model != nil {
 			model.OwnedBy = downloaders[0].provider.Name
 		}
+		m.models.Remove(downloaders[0].provider.Name)
 		return model, nil
 	default:
 		return nil, schema.ErrConflict.With("multiple providers support model downloads; specify a provider")
@@ -164,6 +147,7 @@ func (m *Manager) DeleteModel(ctx context.Context, req schema.DeleteModelRequest
 		if err := deletions[0].downloader.DeleteModel(ctx, runtimeModel); err != nil {
 			return nil, err
 		}
+		m.models.Remove(model.OwnedBy)
 		return types.Ptr(model), nil
 	default:
 		return nil, schema.ErrConflict.With("multiple providers own this model; specify a provider")
@@ -173,6 +157,47 @@ func (m *Manager) DeleteModel(ctx context.Context, req schema.DeleteModelRequest
 ///////////////////////////////////////////////////////////////////////////////
 // PRIVATE METHODS
 
+func (m *Manager) listModels(ctx context.Context, req schema.ModelListRequest, user *auth.UserInfo, refresh bool) (*schema.ModelList, error) {
+	// Get candidate providers for user, or all candidates if no user is provided.
+	providers, err := m.providersForUser(ctx, req.Provider, user)
+	if err != nil {
+		return nil, err
+	}
+
+	// Make the list of provider names for the response
+	providerNames := make([]string, 0, len(providers))
+	for _, provider := range providers {
+		providerNames = append(providerNames, provider.Name)
+	}
+
+	// Get all models for the candidate providers, then page the result for the response.
+	models, err := m.modelsForProviders(ctx, providers, refresh)
+	if err != nil {
+		return nil, err
+	}
+
+	// Add the aliases for each model
+	for i := range models {
+		models[i] = m.withAliases(models[i])
+	}
+
+	// Scope to the offset and limit
+	count := uint(len(models))
+	start := min(req.Offset, uint64(count))
+	end := uint64(count)
+	if req.Limit != nil {
+		end = min(start+types.Value(req.Limit), uint64(count))
+	}
+
+	// Return success
+	return &schema.ModelList{
+		ModelListRequest: req,
+		Provider:         providerNames,
+		Count:            count,
+		Body:             models[start:end],
+	}, nil
+}
+
 func (m *Manager) providersForUser(ctx context.Context, provider string, user *auth.UserInfo) ([]schema.Provider, error) {
 	providerReq := schema.ProviderListRequest{
 		Name:    provider,
@@ -317,7 +342,7 @@ func isIgnorableGetModelError(err error) bool {
 	return false
 }
 
-func (m *Manager) modelsForProviders(ctx context.Context, providers []schema.Provider) ([]schema.Model, error) {
+func (m *Manager) modelsForProviders(ctx context.Context, providers []schema.Provider, refresh bool) ([]schema.Model, error) {
 	var mu sync.Mutex
 	var result []schema.Model
 
@@ -325,7 +350,7 @@ func (m *Manager) modelsForProviders(ctx context.Context, providers []schema.Pro
 	group, ctx := errgroup.WithContext(ctx)
 	for _, provider := range providers {
 		group.Go(func() error {
-			models, err := m.Registry.GetModels(ctx, &provider)
+			models, err := m.cachedModels(ctx, provider, refresh)
 			if err != nil {
 				return err
 			}
@@ -362,7 +387,7 @@ func (m *Manager) modelsByName(ctx context.Context, providers []schema.Provider,
 			model, err := m.Registry.GetModel(ctx, &provider, name)
 			if err != nil {
 				if isIgnorableGetModelError(err) {
-					models, listErr := m.Registry.GetModels(ctx, &provider)
+					models, listErr := m.cachedModels(ctx, provider, false)
 					if listErr != nil {
 						return listErr
 					}
@@ -395,3 +420,30 @@ func (m *Manager) modelsByName(ctx context.Context, providers []schema.Provider,
 	// Return matched models
 	return result, nil
 }
+
+// cachedModels returns the models for a provider from the cache, or fetches
+// them when refresh is true
+func (m *Manager) cachedModels(ctx context.Context, provider schema.Provider, refresh bool) ([]schema.Model, error) {
+	if refresh {
+		return m.models.Refresh(ctx, provider, m.fetchModels)
+	}
+	return m.models.Get(ctx, provider, m.modelttl, m.fetchModels)
+}
+
+// fetchModels returns the models for a provider from the registry
+func (m *Manager) fetchModels(ctx context.Context, provider schema.Provider, refresh bool) ([]schema.Model, error) {
+	if refresh {
+		if err := m.Registry.Invalidate(provider.Name); err != nil {
+			return nil, err
+		}
+	}
+	return m.Registry.GetModels(ctx, &provider)
+}
+
+// withAliases returns a model with the configured aliases which resolve to it
+func (m *Manager) withAliases(model schema.Model) schema.Model {
+	if aliases := m.modelAliases(model.Name); len(aliases) > 0 {
+		model.Aliases = slices.Concat(model.Aliases, aliases)
+	}
+	return model
+}
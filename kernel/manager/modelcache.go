@@ -0,0 +1,140 @@
+package manager
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	// Packages
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	singleflight "golang.org/x/sync/singleflight"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// modelCache holds the model list for each provider. A stale list is
+// returned while it is refreshed in the background, and concurrent fetches
+// for a provider are made once. The zero value is ready to use.
+type modelCache struct {
+	sync.Mutex
+	clock   Clock
+	group   singleflight.Group
+	entries map[string]modelCacheEntry
+}
+
+type modelCacheEntry struct {
+	provider schema.Provider // the provider, including the model filters
+	models   []schema.Model
+	fetched  time.Time
+}
+
+// modelFetchFn returns the models for a provider. When refresh is true any
+// cache in the provider client is bypassed.
+type modelFetchFn func(ctx context.Context, provider schema.Provider, refresh bool) ([]schema.Model, error)
+
+///////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+// modelCacheTTL is the default time after which a model list is refreshed
+const modelCacheTTL = 5 * time.Minute
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// Get returns the models for a provider. A missing list is fetched, and a
+// list older than ttl is returned and refreshed in the background. When ttl
+// is zero the cache is not used.
+func (c *modelCache) Get(ctx context.Context, provider schema.Provider, ttl time.Duration, fetch modelFetchFn) ([]schema.Model, error) {
+	if ttl <= 0 {
+		return fetch(ctx, provider, false)
+	}
+
+	c.Lock()
+	entry, exists := c.entries[provider.Name]
+	c.Unlock()
+	if !exists || !sameModelFilters(entry.provider, provider) {
+		return c.load(ctx, provider, false, fetch)
+	}
+	if c.clock.Since(entry.fetched) >= ttl {
+		c.Revalidate(provider, fetch)
+	}
+	return entry.models, nil
+}
+
+// Refresh fetches the models for a provider, bypassing any cache, and waits
+// for the result
+func (c *modelCache) Refresh(ctx context.Context, provider schema.Provider, fetch modelFetchFn) ([]schema.Model, error) {
+	return c.load(ctx, provider, true, fetch)
+}
+
+// Revalidate starts a refresh of the models for a provider without waiting
+// for the result. On error the cached models are kept.
+func (c *modelCache) Revalidate(provider schema.Provider, fetch modelFetchFn) {
+	c.group.DoChan(provider.Name, func() (any, error) {
+		return c.fetch(context.Background(), provider, true, fetch)
+	})
+}
+
+// Providers returns the providers which have cached models
+func (c *modelCache) Providers() []schema.Provider {
+	c.Lock()
+	defer c.Unlock()
+	result := make([]schema.Provider, 0, len(c.entries))
+	for _, entry := range c.entries {
+		result = append(result, entry.provider)
+	}
+	return result
+}
+
+// Remove discards the cached models for providers
+func (c *modelCache) Remove(names ...string) {
+	c.Lock()
+	defer c.Unlock()
+	for _, name := range names {
+		delete(c.entries, name)
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// load fetches the models for a provider, sharing the fetch with other
+// callers, and returns when the fetch completes or the context is cancelled
+func (c *modelCache) load(ctx context.Context, provider schema.Provider, refresh bool, fetch modelFetchFn) ([]schema.Model, error) {
+	result := c.group.DoChan(provider.Name, func() (any, error) {
+		return c.fetch(context.WithoutCancel(ctx), provider, refresh, fetch)
+	})
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case r := <-result:
+		if r.Err != nil {
+			return nil, r.Err
+		}
+		return r.Val.([]schema.Model), nil
+	}
+}
+
+// fetch gets the models for a provider and stores them in the cache
+func (c *modelCache) fetch(ctx context.Context, provider schema.Provider, refresh bool, fetch modelFetchFn) ([]schema.Model, error) {
+	models, err := fetch(ctx, provider, refresh)
+	if err != nil {
+		return nil, err
+	}
+
+	c.Lock()
+	defer c.Unlock()
+	if c.entries == nil {
+		c.entries = make(map[string]modelCacheEntry)
+	}
+	c.entries[provider.Name] = modelCacheEntry{provider: provider, models: models, fetched: c.clock.Now()}
+	return models, nil
+}
+
+// sameModelFilters returns true if two versions of a provider filter models
+// in the same way
+func sameModelFilters(a, b schema.Provider) bool {
+	return a.Provider == b.Provider && slices.Equal(a.Include, b.Include) && slices.Equal(a.Exclude, b.Exclude)
+}
@@ -0,0 +1,110 @@
+package manager
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	// Packages
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	assert "github.com/stretchr/testify/assert"
+)
+
+func TestModelCacheGetFetchesOnce(t *testing.T) {
+	var cache modelCache
+	var calls atomic.Int32
+	fetch := func(_ context.Context, provider schema.Provider, refresh bool) ([]schema.Model, error) {
+		calls.Add(1)
+		assert.False(t, refresh)
+		return []schema.Model{{Name: "alpha", OwnedBy: provider.Name}}, nil
+	}
+
+	provider := schema.Provider{Name: "p"}
+	for range 3 {
+		models, err := cache.Get(context.Background(), provider, time.Hour, fetch)
+		assert.NoError(t, err)
+		assert.Equal(t, []schema.Model{{Name: "alpha", OwnedBy: "p"}}, models)
+	}
+	assert.EqualValues(t, 1, calls.Load())
+}
+
+func TestModelCacheGetReturnsStaleAndRevalidates(t *testing.T) {
+	var cache modelCache
+	refreshed := make(chan struct{})
+	fetch := func(_ context.Context, _ schema.Provider, refresh bool) ([]schema.Model, error) {
+		if refresh {
+			defer close(refreshed)
+			return []schema.Model{{Name: "beta"}}, nil
+		}
+		return []schema.Model{{Name: "alpha"}}, nil
+	}
+
+	provider := schema.Provider{Name: "p"}
+	_, err := cache.Get(context.Background(), provider, time.Hour, fetch)
+	assert.NoError(t, err)
+
+	// Age the entry, so that the stale list is returned and refreshed
+	cache.Lock()
+	entry := cache.entries["p"]
+	entry.fetched = time.Now().Add(-2 * time.Hour)
+	cache.entries["p"] = entry
+	cache.Unlock()
+
+	models, err := cache.Get(context.Background(), provider, time.Hour, fetch)
+	assert.NoError(t, err)
+	assert.Equal(t, "alpha", models[0].Name)
+
+	select {
+	case <-refreshed:
+	case <-time.After(time.Second):
+		t.Fatal("stale models were not refreshed")
+	}
+	assert.Eventually(t, func() bool {
+		models, err := cache.Get(context.Background(), provider, time.Hour, fetch)
+		return err == nil && models[0].Name == "beta"
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestModelCacheKeepsModelsOnRefreshError(t *testing.T) {
+	var cache modelCache
+	fetch := func(_ context.Context, _ schema.Provider, refresh bool) ([]schema.Model, error) {
+		if refresh {
+			return nil, errors.New("unavailable")
+		}
+		return []schema.Model{{Name: "alpha"}}, nil
+	}
+
+	provider := schema.Provider{Name: "p"}
+	_, err := cache.Get(context.Background(), provider, time.Hour, fetch)
+	assert.NoError(t, err)
+	_, err = cache.Refresh(context.Background(), provider, fetch)
+	assert.Error(t, err)
+
+	models, err := cache.Get(context.Background(), provider, time.Hour, fetch)
+	assert.NoError(t, err)
+	assert.Equal(t, "alpha", models[0].Name)
+}
+
+func TestModelCacheChangedFiltersAndRemove(t *testing.T) {
+	var cache modelCache
+	var calls atomic.Int32
+	fetch := func(context.Context, schema.Provider, bool) ([]schema.Model, error) {
+		calls.Add(1)
+		return []schema.Model{{Name: "alpha"}}, nil
+	}
+
+	provider := schema.Provider{Name: "p"}
+	_, _ = cache.Get(context.Background(), provider, time.Hour, fetch)
+	provider.Include = []string{"^alpha"}
+	_, _ = cache.Get(context.Background(), provider, time.Hour, fetch)
+	assert.EqualValues(t, 2, calls.Load())
+	assert.Len(t, cache.Providers(), 1)
+
+	cache.Remove("p")
+	assert.Empty(t, cache.Providers())
+	_, _ = cache.Get(context.Background(), provider, 0, fetch)
+	assert.EqualValues(t, 3, calls.Load())
+	assert.Empty(t, cache.Providers())
+}
@@ -0,0 +1,63 @@
+package manager
+
+import (
+	"context"
+	"fmt"
+
+	// Packages
+	llm "github.com/mutablelogic/go-llm"
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	ocr "github.com/mutablelogic/go-llm/pkg/ocr"
+	opt "github.com/mutablelogic/go-llm/pkg/opt"
+	providerregistry "github.com/mutablelogic/go-llm/provider/registry"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// documentReader reads documents with a provider from the registry
+type documentReader struct {
+	*providerregistry.Registry
+	provider string
+}
+
+var _ llm.DocumentReader = (*documentReader)(nil)
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+func (r *documentReader) ReadDocument(ctx context.Context, model schema.Model, document *schema.Attachment, opts ...opt.Opt) (*schema.OCRResult, *schema.UsageMeta, error) {
+	client := r.Get(r.provider)
+	if client == nil {
+		return nil, nil, schema.ErrNotFound.Withf("provider %q not found", r.provider)
+	}
+	reader, ok := client.Self().(llm.DocumentReader)
+	if !ok {
+		return nil, nil, schema.ErrNotImplemented.Withf("provider %q does not read documents", r.provider)
+	}
+	return reader.ReadDocument(ctx, model, document, opts...)
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// ocrFallback returns the message to send to a model which reports that it
+// does not accept images. With WithOCR or WithProviderOCR, image attachments are replaced by the
+// text extracted from them. Otherwise the message is unchanged, and a warning
+// is returned so that the images are not sent silently. Models which do not
+// report their capabilities are assumed to accept images.
+func (m *Manager) ocrFallback(ctx context.Context, model *schema.Model, message *schema.Message) (*schema.Message, []opt.Opt, error) {
+	if model == nil || model.Cap == 0 || model.Cap&schema.ModelCapVision != 0 || message == nil || !ocr.HasImages(message) {
+		return message, nil, nil
+	}
+	if m.ocr == nil {
+		return message, []opt.Opt{opt.AddString(opt.WarningKey, fmt.Sprintf("model %q does not accept images, and no OCR is configured to extract their text", model.Name))}, nil
+	}
+
+	// Replace the images with their text
+	result, n, err := ocr.Substitute(ctx, m.ocr, message)
+	if err != nil {
+		return nil, nil, err
+	}
+	return result, []opt.Opt{opt.AddString(opt.WarningKey, fmt.Sprintf("model %q does not accept images, so the text of %d image(s) was extracted with OCR", model.Name, n))}, nil
+}
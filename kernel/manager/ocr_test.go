@@ -0,0 +1,58 @@
+package manager
+
+import (
+	"context"
+	"testing"
+
+	// Packages
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	types "github.com/mutablelogic/go-server/pkg/types"
+	assert "github.com/stretchr/testify/assert"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// HELPERS
+
+// ocrTestRecognizer returns the image data as its text
+type ocrTestRecognizer struct{}
+
+func (ocrTestRecognizer) Recognize(_ context.Context, image *schema.Attachment) (string, error) {
+	return string(image.Data), nil
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// TESTS
+
+func TestOCRFallback(t *testing.T) {
+	assert := assert.New(t)
+	message := &schema.Message{Role: schema.RoleUser, Content: []schema.ContentBlock{
+		{Text: types.Ptr("What does this say?")},
+		{Attachment: &schema.Attachment{ContentType: "image/png", Data: []byte("Hello")}},
+	}}
+	vision := &schema.Model{Name: "llava", Cap: schema.ModelCapCompletion | schema.ModelCapVision}
+	text := &schema.Model{Name: "llama", Cap: schema.ModelCapCompletion}
+
+	// Models which accept images, or do not report capabilities, are sent the images
+	m := &Manager{manageropt: manageropt{ocr: ocrTestRecognizer{}}}
+	for _, model := range []*schema.Model{vision, {Name: "unknown"}} {
+		result, opts, err := m.ocrFallback(context.Background(), model, message)
+		assert.NoError(err)
+		assert.Same(message, result)
+		assert.Empty(opts)
+	}
+
+	// The text of the image is sent to a model which does not accept images
+	result, opts, err := m.ocrFallback(context.Background(), text, message)
+	if assert.NoError(err) && assert.Len(result.Content, 2) {
+		assert.Nil(result.Content[1].Attachment)
+		assert.Contains(types.Value(result.Content[1].Text), "Hello")
+		assert.Equal([]string{`model "llama" does not accept images, so the text of 1 image(s) was extracted with OCR`}, optWarnings(opts))
+	}
+
+	// Without OCR, the images are sent with a warning
+	m = &Manager{}
+	result, opts, err = m.ocrFallback(context.Background(), text, message)
+	assert.NoError(err)
+	assert.Same(message, result)
+	assert.Len(optWarnings(opts), 1)
+}
@@ -0,0 +1,66 @@
+package manager
+
+import (
+	// Packages
+	llm "github.com/mutablelogic/go-llm"
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	types "github.com/mutablelogic/go-server/pkg/types"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+// Providers which run models on this server or the local network, and which
+// are enabled when the manager is offline
+var localProviders = map[string]bool{
+	schema.Ollama: true,
+	schema.Eliza:  true,
+}
+
+// Tools which only use this server and its database, and which are offered
+// when the manager is offline although they do not say so in their hints
+var localTools = map[string]bool{
+	"builtin.apply_patch":  true,
+	"builtin.git_diff":     true,
+	"memory.memory_search": true,
+	"memory.memory_write":  true,
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// offlineProviders disables the providers which do not run models locally,
+// when the manager is offline, so that they are removed from the registry
+func (m *Manager) offlineProviders(providers []*schema.Provider) {
+	if m.offline == nil {
+		return
+	}
+	for _, provider := range providers {
+		if provider != nil && !localProviders[provider.Provider] {
+			provider.Enabled = types.Ptr(false)
+		}
+	}
+}
+
+// offlineProvider returns an error if the manager is offline and the
+// provider does not run models locally
+func (m *Manager) offlineProvider(provider string) error {
+	if m.offline != nil && !localProviders[provider] {
+		return schema.ErrBadParameter.Withf("provider %q does not run models locally, and the server is offline", provider)
+	}
+	return nil
+}
+
+// offlineTool returns true if the manager is offline and the tool may reach
+// outside the server, such as a web search, so it is not offered to models.
+// As in MCP, a tool without an open-world hint may reach outside the server,
+// unless it is known to be local.
+func (m *Manager) offlineTool(tool llm.Tool) bool {
+	if m.offline == nil || localTools[tool.Name()] {
+		return false
+	}
+	if hint := tool.Meta().OpenWorldHint; hint != nil {
+		return *hint
+	}
+	return true
+}
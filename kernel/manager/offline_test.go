@@ -0,0 +1,61 @@
+package manager
+
+import (
+	"testing"
+
+	// Packages
+	llm "github.com/mutablelogic/go-llm"
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	offline "github.com/mutablelogic/go-llm/pkg/offline"
+	types "github.com/mutablelogic/go-server/pkg/types"
+	assert "github.com/stretchr/testify/assert"
+)
+
+func TestOfflineProviders(t *testing.T) {
+	assert := assert.New(t)
+	providers := func() []*schema.Provider {
+		return []*schema.Provider{
+			{Name: "local", Provider: schema.Ollama},
+			{Name: "cloud", Provider: schema.Gemini},
+		}
+	}
+
+	// Providers are unchanged when online
+	online := &Manager{}
+	list := providers()
+	online.offlineProviders(list)
+	assert.Nil(list[0].Enabled)
+	assert.Nil(list[1].Enabled)
+	assert.NoError(online.offlineProvider(schema.Gemini))
+
+	// Only local providers are enabled when offline
+	m := &Manager{manageropt: manageropt{offline: offline.New()}}
+	list = providers()
+	m.offlineProviders(list)
+	assert.Nil(list[0].Enabled)
+	assert.Equal(types.Ptr(false), list[1].Enabled)
+	assert.NoError(m.offlineProvider(schema.Ollama))
+	assert.ErrorIs(m.offlineProvider(schema.Gemini), schema.ErrBadParameter)
+}
+
+func TestOfflineTool(t *testing.T) {
+	assert := assert.New(t)
+	unannotated := &listToolsMockTool{name: "weather.forecast"}
+	openWorld := &listToolsMockTool{name: "builtin.search", meta: llm.ToolMeta{OpenWorldHint: types.Ptr(true)}}
+	closedWorld := &listToolsMockTool{name: "files.read", meta: llm.ToolMeta{OpenWorldHint: types.Ptr(false)}}
+	local := &listToolsMockTool{name: "builtin.git_diff"}
+
+	// All tools are offered when online
+	online := &Manager{}
+	for _, tool := range []llm.Tool{unannotated, openWorld, closedWorld, local} {
+		assert.False(online.offlineTool(tool), tool.Name())
+	}
+
+	// Tools without an open-world hint are not offered when offline, unless
+	// they are known to be local
+	m := &Manager{manageropt: manageropt{offline: offline.New()}}
+	assert.True(m.offlineTool(unannotated))
+	assert.True(m.offlineTool(openWorld))
+	assert.False(m.offlineTool(closedWorld))
+	assert.False(m.offlineTool(local))
+}
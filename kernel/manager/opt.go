@@ -16,12 +16,20 @@ package manager
 
 import (
 	"fmt"
+	"os"
+	"strings"
+	"time"
 
 	// Packages
 	crypto "github.com/mutablelogic/go-auth/crypto"
 	client "github.com/mutablelogic/go-client"
 	llm "github.com/mutablelogic/go-llm"
 	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	graphql "github.com/mutablelogic/go-llm/pkg/graphql"
+	ocr "github.com/mutablelogic/go-llm/pkg/ocr"
+	offline "github.com/mutablelogic/go-llm/pkg/offline"
+	postprocess "github.com/mutablelogic/go-llm/pkg/postprocess"
+	rest "github.com/mutablelogic/go-llm/pkg/rest"
 	types "github.com/mutablelogic/go-server/pkg/types"
 	metric "go.opentelemetry.io/otel/metric"
 	trace "go.opentelemetry.io/otel/trace"
@@ -35,19 +43,70 @@ type Opt func(*manageropt) error
 
 // manageropt combines all configuration options for Manager.
 type manageropt struct {
-	name        string
-	version     string
-	llmschema   string
-	authschema  string
-	channel     string
-	tracer      trace.Tracer
-	metrics     metric.Meter
-	passphrases *crypto.Passphrases
-	clientopts  []client.ClientOpt
-	tools       []llm.Tool
-	prompts     []llm.Prompt
-	resources   []llm.Resource
-	connectors  map[string]llm.Connector
+	name         string
+	version      string
+	llmschema    string
+	authschema   string
+	channel      string
+	tracer       trace.Tracer
+	metrics      metric.Meter
+	passphrases  *crypto.Passphrases
+	clientopts   []client.ClientOpt
+	provideropts []client.ClientOpt
+	tools        []llm.Tool
+	prompts      []llm.Prompt
+	resources    []llm.Resource
+	connectors   map[string]llm.Connector
+	agentdir     string
+	retention    []schema.RetentionPolicy
+	modelttl     time.Duration
+	toolttl      map[string]time.Duration
+	toolcache    int
+	aliases      map[string]modelAlias
+	unsupported  OptionPolicy
+	sharedlocks  bool
+	archiving    bool
+	media        *mediaopt
+	calendar     *calendaropt
+	endpoints    []rest.Endpoint
+	graphql      *graphqlopt
+	workspace    string
+	approval     map[string]bool
+	fixtures     []schema.ToolFixture
+	ocr          ocr.Recognizer
+	ocrprovider  *mediaopt
+	admission    *admission
+	breakers     *breakerList
+	local        *localScheduler
+	dedup        bool
+	warm         []schema.LoadModelRequest
+	keepalive    time.Duration
+	jailbreak    *jailbreakScreen
+	tenants      bool
+	reasoning    bool
+	toolselect   *toolSelector
+	contexts     []turnContext
+	processors   map[string]postprocess.Processor
+	offline      *offline.Guard
+	clock        Clock
+	ids          IDGenerator
+	checkpoints  bool
+	resume       bool
+	compactor    *compactor
+}
+
+// mediaopt selects the provider and model which transcribe audio for the
+// media tools
+type mediaopt struct {
+	provider string
+	model    string
+}
+
+// calendaropt is the calendar for the calendar tools, and whether events
+// can be created on it
+type calendaropt struct {
+	url   string
+	write bool
 }
 
 ///////////////////////////////////////////////////////////////////////////////
@@ -74,6 +133,27 @@ func (o *manageropt) defaults(name, version string) {
 	o.passphrases = crypto.NewPassphrases()
 	o.clientopts = []client.ClientOpt{}
 	o.connectors = make(map[string]llm.Connector)
+	o.modelttl = modelCacheTTL
+	o.toolttl = make(map[string]time.Duration)
+	o.aliases = make(map[string]modelAlias)
+	o.approval = make(map[string]bool)
+	o.unsupported = OptionPolicyError
+	o.keepalive = modelKeepAlive
+	o.clock = time.Now
+	o.ids = RandomID()
+}
+
+func (o *manageropt) alias(name string, alias modelAlias) error {
+	name, alias.target = strings.TrimSpace(name), strings.TrimSpace(alias.target)
+	if name == "" || alias.target == "" {
+		return fmt.Errorf("model alias requires a name and a target")
+	} else if name == alias.target {
+		return fmt.Errorf("model alias %q cannot refer to itself", name)
+	} else if _, exists := o.aliases[name]; exists {
+		return fmt.Errorf("model alias %q already exists", name)
+	}
+	o.aliases[name] = alias
+	return nil
 }
 
 ///////////////////////////////////////////////////////////////////////////////
@@ -137,6 +217,15 @@ func WithClientOpts(opts ...client.ClientOpt) Opt {
 	}
 }
 
+// WithProviderClientOpts provides client options for the LLM model providers
+// only, such as a transport which injects faults in tests
+func WithProviderClientOpts(opts ...client.ClientOpt) Opt {
+	return func(o *manageropt) error {
+		o.provideropts = append(o.provideropts, opts...)
+		return nil
+	}
+}
+
 // WithTools provides unified tool options for the LLM model
 // providers
 func WithTools(opts ...llm.Tool) Opt {
@@ -155,6 +244,537 @@ func WithPrompts(opts ...llm.Prompt) Opt {
 	}
 }
 
+// WithAgentDir loads agent definitions from markdown files with front matter
+// in the given directory. The directory is re-scanned while the manager is
+// running, so agents are added, updated and removed as files change.
+func WithAgentDir(path string) Opt {
+	return func(o *manageropt) error {
+		if path == "" {
+			return fmt.Errorf("agent directory cannot be empty")
+		} else if info, err := os.Stat(path); err != nil {
+			return fmt.Errorf("agent directory: %w", err)
+		} else if !info.IsDir() {
+			return fmt.Errorf("agent directory %q is not a directory", path)
+		}
+		o.agentdir = path
+		return nil
+	}
+}
+
+// WithRetention deletes sessions with the given label, and their messages,
+// memories and usage records, once they have had no activity for maxAge.
+// An empty label applies the policy to all sessions. Policies are enforced
+// periodically while the manager is running.
+func WithRetention(label string, maxAge time.Duration) Opt {
+	return func(o *manageropt) error {
+		if maxAge <= 0 {
+			return fmt.Errorf("retention for %q must be greater than zero", label)
+		}
+		o.retention = append(o.retention, schema.RetentionPolicy{Label: strings.TrimSpace(label), MaxAge: maxAge})
+		return nil
+	}
+}
+
+// WithModelCache sets the time after which the cached list of models for a
+// provider is refreshed. A stale list is returned while it is refreshed in
+// the background. A zero duration disables the cache.
+func WithModelCache(ttl time.Duration) Opt {
+	return func(o *manageropt) error {
+		if ttl < 0 {
+			return fmt.Errorf("model cache duration cannot be negative")
+		}
+		o.modelttl = ttl
+		return nil
+	}
+}
+
+// WithModelWarmup loads models into memory when the manager is started, for
+// providers which run models locally, so that the first request does not
+// wait for a model to load. The models are loaded again after every
+// keepalive period so they are not unloaded while idle, or only once when
+// keepalive is zero.
+func WithModelWarmup(keepalive time.Duration, models ...schema.LoadModelRequest) Opt {
+	return func(o *manageropt) error {
+		if keepalive < 0 {
+			return fmt.Errorf("model keepalive cannot be negative")
+		}
+		for _, model := range models {
+			if strings.TrimSpace(model.Name) == "" {
+				return fmt.Errorf("warm-up model name cannot be empty")
+			}
+		}
+		o.warm = append(o.warm, models...)
+		o.keepalive = keepalive
+		return nil
+	}
+}
+
+// WithToolCache keeps the results of a read-only or idempotent tool for a
+// period, so that expensive lookups are not repeated within a conversation
+// or across sessions. The name may include the namespace of the tool.
+func WithToolCache(name string, ttl time.Duration) Opt {
+	return func(o *manageropt) error {
+		if name = strings.TrimSpace(name); name == "" {
+			return fmt.Errorf("tool cache name cannot be empty")
+		} else if ttl < 0 {
+			return fmt.Errorf("tool cache duration for %q cannot be negative", name)
+		}
+		o.toolttl[name] = ttl
+		return nil
+	}
+}
+
+// WithToolCacheSize sets the number of tool results which are cached, after
+// which the least recently used results are discarded. Zero uses the
+// default size.
+func WithToolCacheSize(size int) Opt {
+	return func(o *manageropt) error {
+		if size < 0 {
+			return fmt.Errorf("tool cache size cannot be negative")
+		}
+		o.toolcache = size
+		return nil
+	}
+}
+
+// WithToolFixtures sets recorded results for tool calls, which are returned
+// instead of running the tools in chat turns simulated with
+// WithToolSimulation
+func WithToolFixtures(fixtures ...schema.ToolFixture) Opt {
+	return func(o *manageropt) error {
+		for _, fixture := range fixtures {
+			if strings.TrimSpace(fixture.Name) == "" {
+				return fmt.Errorf("tool fixture name cannot be empty")
+			}
+		}
+		o.fixtures = append(o.fixtures, fixtures...)
+		return nil
+	}
+}
+
+// WithModelAlias resolves a model name, such as "claude-latest", to another
+// model wherever a model is named in a request or a stored session.
+func WithModelAlias(name, target string) Opt {
+	return func(o *manageropt) error {
+		return o.alias(name, modelAlias{target: target})
+	}
+}
+
+// WithModelDeprecation resolves a model name which has been retired by a
+// provider to its successor, and adds a deprecation warning to responses
+// which use the retired name.
+func WithModelDeprecation(name, successor string) Opt {
+	return func(o *manageropt) error {
+		return o.alias(name, modelAlias{target: successor, deprecated: true})
+	}
+}
+
+// WithModelSuccessor sets the successor of a model which a provider is
+// expected to retire. The model is used until the provider reports that it
+// does not exist or has been retired, and then the request is retried once
+// with the successor and a warning is added to the response.
+func WithModelSuccessor(name, successor string) Opt {
+	return func(o *manageropt) error {
+		return o.alias(name, modelAlias{target: successor, successor: true})
+	}
+}
+
+// WithUnsupportedOptions sets what happens when a request sets an option
+// which the provider does not support: the request fails, the option is
+// dropped with a warning in the response, or the option is emulated where
+// possible, such as a system prompt which is prepended to the first message.
+func WithUnsupportedOptions(policy OptionPolicy) Opt {
+	return func(o *manageropt) error {
+		switch policy {
+		case OptionPolicyError, OptionPolicyWarn, OptionPolicyEmulate:
+			o.unsupported = policy
+			return nil
+		default:
+			return fmt.Errorf("invalid policy for unsupported options: %q", policy)
+		}
+	}
+}
+
+// WithSharedLocks serializes the chat turns in a session, and the messages
+// for a session label, across all replicas which share the database, using
+// PostgreSQL advisory locks. A database connection is held while each lock
+// is held, so the pool needs a connection for each session with a turn in
+// progress. The connection is returned when the request is cancelled.
+func WithSharedLocks() Opt {
+	return func(o *manageropt) error {
+		o.sharedlocks = true
+		return nil
+	}
+}
+
+// WithArchive writes every ask and chat request, with its response, to an
+// append-only archive in the database. Records are hash-chained and cannot be
+// changed or removed, and are retrieved by request ID.
+func WithArchive() Opt {
+	return func(o *manageropt) error {
+		o.archiving = true
+		return nil
+	}
+}
+
+// WithJailbreakScreen scores the text of each ask and chat request for
+// attempts to make the model ignore its instructions. Requests which score at
+// least the threshold, between zero and one, are written to the archive with
+// the score when archiving is enabled. When judge is set, the model with that
+// name also scores each request, and the higher score is used. When prompt is
+// set, it is added to the system prompt for requests which reach the
+// threshold.
+func WithJailbreakScreen(threshold float64, judge, prompt string) Opt {
+	return func(o *manageropt) error {
+		if threshold <= 0 || threshold > 1 {
+			return fmt.Errorf("jailbreak threshold must be greater than zero and at most one")
+		}
+		o.jailbreak = &jailbreakScreen{threshold: threshold, judge: strings.TrimSpace(judge), prompt: strings.TrimSpace(prompt)}
+		return nil
+	}
+}
+
+// WithTenantCredentials lets each user set their own credentials for a
+// provider, which are used for the requests of the user in place of the
+// credentials of the provider, so that usage is billed to the user. Users
+// who have not set credentials use the credentials of the provider.
+func WithTenantCredentials() Opt {
+	return func(o *manageropt) error {
+		o.tenants = true
+		return nil
+	}
+}
+
+// WithReasoningRecord records the thinking of models apart from the
+// transcript of a session. Thinking is not stored with the messages, so it is
+// not returned to users, and administrators can retrieve it to debug a
+// conversation.
+func WithReasoningRecord() Opt {
+	return func(o *manageropt) error {
+		o.reasoning = true
+		return nil
+	}
+}
+
+// WithToolSelection offers the model only the tools which are most relevant
+// to each chat turn, when more tools are available than the limit, to save
+// the tokens of the tool schemas. The description of each tool and the text
+// of the turn are embedded with the model, and the tools with the most
+// similar descriptions are offered, together with the tools which match the
+// always patterns. When the embeddings cannot be made, all tools are offered.
+func WithToolSelection(provider, model string, limit uint, always ...string) Opt {
+	return func(o *manageropt) error {
+		provider, model = strings.TrimSpace(provider), strings.TrimSpace(model)
+		if model == "" {
+			return fmt.Errorf("tool selection requires an embedding model")
+		} else if limit == 0 {
+			return fmt.Errorf("tool selection limit must be greater than zero")
+		}
+		selector := &toolSelector{provider: provider, model: model, limit: limit}
+		if len(always) > 0 {
+			filter, err := schema.NewToolFilter(always...)
+			if err != nil {
+				return err
+			}
+			selector.always = filter
+		}
+		o.toolselect = selector
+		return nil
+	}
+}
+
+// WithTurnContext adds context about the environment to each chat turn, such
+// as the current working set, the user profile or feature flags. The context
+// is sent as a block before the input of the user, which is marked as
+// generated by the system, and is only sent again when it changes.
+func WithTurnContext(name string, fn ContextFn) Opt {
+	return func(o *manageropt) error {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			return fmt.Errorf("turn context requires a name")
+		} else if fn == nil {
+			return fmt.Errorf("turn context %q requires a function", name)
+		}
+		for _, c := range o.contexts {
+			if c.name == name {
+				return fmt.Errorf("turn context %q already exists", name)
+			}
+		}
+		o.contexts = append(o.contexts, turnContext{name: name, fn: fn})
+		return nil
+	}
+}
+
+// WithPostProcessor adds a step which changes the text of replies, which
+// sessions and agents use by its name in the postprocess steps of their
+// generator meta. The names of the built-in steps cannot be used.
+func WithPostProcessor(name string, processor postprocess.Processor) Opt {
+	return func(o *manageropt) error {
+		name = strings.TrimSpace(name)
+		switch name {
+		case "":
+			return fmt.Errorf("postprocess step requires a name")
+		case schema.PostProcessReplace, schema.PostProcessMarkdown, schema.PostProcessMask, schema.PostProcessLinks:
+			return fmt.Errorf("postprocess step %q is built in", name)
+		}
+		if processor == nil {
+			return fmt.Errorf("postprocess step %q requires a processor", name)
+		}
+		if o.processors == nil {
+			o.processors = make(map[string]postprocess.Processor)
+		}
+		o.processors[name] = processor
+		return nil
+	}
+}
+
+// WithOffline runs the manager without access to the internet. Providers
+// which do not run models locally are disabled, tools which reach outside
+// the server are not offered to models, and requests made by provider and
+// connector clients to hosts which are not local are refused by the guard.
+func WithOffline(guard *offline.Guard) Opt {
+	return func(o *manageropt) error {
+		if guard == nil {
+			return schema.ErrBadParameter.With("offline guard is required")
+		}
+		o.offline = guard
+		o.clientopts = append(o.clientopts, client.OptTransport(guard.Transport))
+		return nil
+	}
+}
+
+// WithClock sets the clock which the manager reads the time from, such as
+// for the times of jobs, recordings and archive records and the expiry of
+// shares. Durations such as the latency of a generation are measured with
+// the system clock.
+func WithClock(clock Clock) Opt {
+	return func(o *manageropt) error {
+		if clock == nil {
+			return schema.ErrBadParameter.With("clock is required")
+		}
+		o.clock = clock
+		return nil
+	}
+}
+
+// WithIDGenerator sets the generator of the IDs of jobs, recordings and
+// archive records, such as SortableID for IDs which sort in the order they
+// were created. The default is RandomID. Sessions and messages are given IDs
+// by the database.
+func WithIDGenerator(ids IDGenerator) Opt {
+	return func(o *manageropt) error {
+		if ids == nil {
+			return schema.ErrBadParameter.With("ID generator is required")
+		}
+		o.ids = ids
+		return nil
+	}
+}
+
+// WithCheckpoints stores the state of a chat turn after each iteration of
+// its tool loop, so that a turn which is interrupted because a replica
+// stopped is recovered by a replica which is running. When resume is true,
+// the turn continues as a background job, with error results for the tool
+// calls which did not return. Otherwise the history of the turn is stored
+// with a reply which says it was interrupted.
+func WithCheckpoints(resume bool) Opt {
+	return func(o *manageropt) error {
+		o.checkpoints = true
+		o.resume = resume
+		return nil
+	}
+}
+
+// WithToolResultDedup replaces older copies of tool results which are
+// repeated later in a conversation with a reference to the later copy, in
+// the requests sent to providers. The stored history is not changed.
+func WithToolResultDedup() Opt {
+	return func(o *manageropt) error {
+		o.dedup = true
+		return nil
+	}
+}
+
+// WithCompaction compacts the start of the conversation of a session with
+// the strategy when the conversation uses more than the threshold of the
+// context window of the session model, between zero and one, until it uses
+// less than half of the threshold. The threshold is 0.8 when zero. Sessions
+// with models which do not report an input token limit are not compacted.
+// The stored history is not changed: the last message which is removed, and
+// any summary which replaces the removed messages, are stored with the session
+// for later turns.
+func WithCompaction(strategy CompactionStrategy, threshold float64) Opt {
+	return func(o *manageropt) error {
+		if strategy == nil {
+			return fmt.Errorf("compaction strategy is required")
+		}
+		if threshold == 0 {
+			threshold = compactionThreshold
+		} else if threshold < 0 || threshold > 1 {
+			return fmt.Errorf("compaction threshold must be greater than zero and at most one")
+		}
+		o.compactor = &compactor{strategy: strategy, threshold: threshold}
+		return nil
+	}
+}
+
+// WithMediaTools adds the media_transcript tool, which reads the captions of
+// YouTube videos. If provider is set, other audio such as podcast episodes is
+// transcribed with the provider, using the model or the provider's default
+// transcription model.
+func WithMediaTools(provider, model string) Opt {
+	return func(o *manageropt) error {
+		provider, model = strings.TrimSpace(provider), strings.TrimSpace(model)
+		if provider == "" && model != "" {
+			return fmt.Errorf("transcription model %q requires a provider", model)
+		}
+		o.media = &mediaopt{provider: provider, model: model}
+		return nil
+	}
+}
+
+// WithCalendar adds tools which list events and query free and busy time in
+// an ICS feed or CalDAV calendar. If write is true, a tool which creates
+// events on a CalDAV calendar is also added, and each call to it must be
+// approved with WithToolApproval. Credentials for the calendar are read
+// from the credential stored for its URL, when there is one.
+func WithCalendar(url string, write bool) Opt {
+	return func(o *manageropt) error {
+		if url = strings.TrimSpace(url); url == "" {
+			return fmt.Errorf("calendar url is required")
+		}
+		o.calendar = &calendaropt{url: url, write: write}
+		return nil
+	}
+}
+
+// WithRESTEndpoints adds a tool for each REST endpoint, which calls the
+// endpoint with the arguments of the tool. The authentication header of an
+// endpoint is set from the credential stored for its URL. Endpoints which
+// are not read-only must be approved with WithToolApproval.
+func WithRESTEndpoints(endpoints ...rest.Endpoint) Opt {
+	return func(o *manageropt) error {
+		o.endpoints = append(o.endpoints, endpoints...)
+		return nil
+	}
+}
+
+// WithGraphQL adds tools which describe the schema of a GraphQL endpoint
+// and run queries on it. Queries are limited in depth and number of fields,
+// and may only select the fields in the allowlist of the limits, when it is
+// set. The bearer token for the endpoint is read from the credential stored
+// for its URL.
+func WithGraphQL(url string, limits graphql.Limits) Opt {
+	return func(o *manageropt) error {
+		if url = strings.TrimSpace(url); url == "" {
+			return fmt.Errorf("graphql url is required")
+		}
+		o.graphql = &graphqlopt{url: url, limits: limits}
+		return nil
+	}
+}
+
+// WithWorkspace adds tools which edit the files within the root directory,
+// such as apply_patch. Each call to a tool which changes files must be
+// approved with WithToolApproval.
+func WithWorkspace(root string) Opt {
+	return func(o *manageropt) error {
+		if root = strings.TrimSpace(root); root == "" {
+			return fmt.Errorf("workspace root is required")
+		}
+		o.workspace = root
+		return nil
+	}
+}
+
+// WithAdmission limits the generations which run at once to a number of
+// workers. Requests which arrive when every worker is busy wait in a queue
+// for their priority, which holds at most the number of requests in queues,
+// and are rejected with ErrTooManyRequests when it is full. A priority which
+// is not in queues is rejected when every worker is busy.
+func WithAdmission(workers int, queues map[schema.Priority]int) Opt {
+	return func(o *manageropt) error {
+		admission, err := newAdmission(workers, queues)
+		if err != nil {
+			return err
+		}
+		o.admission = admission
+		return nil
+	}
+}
+
+// WithCircuitBreaker opens the circuit for a provider after a number of
+// consecutive failures, such as server errors, rate limits or network
+// errors. Generations with the provider are rejected until the cooldown has
+// passed, and then one generation is sent to probe whether it has recovered.
+// While the circuit is open, a model with other providers is routed to them,
+// and a model with a successor set with WithModelSuccessor uses the
+// successor.
+func WithCircuitBreaker(failures uint, cooldown time.Duration) Opt {
+	return func(o *manageropt) error {
+		breakers, err := newBreakerList(failures, cooldown)
+		if err != nil {
+			return err
+		}
+		o.breakers = breakers
+		return nil
+	}
+}
+
+// WithLocalCapacity sets the memory of a provider which runs models
+// locally, such as the VRAM of the GPU used by Ollama. Generations wait when
+// the models they use would not fit in the memory with the models which are
+// running other generations, using the hints set with WithLocalModel, rather
+// than run the provider out of memory. The units are the same as those of
+// the hints, for example GB.
+func WithLocalCapacity(provider string, memory float64) Opt {
+	return func(o *manageropt) error {
+		if o.local == nil {
+			o.local = newLocalScheduler()
+		}
+		return o.local.setCapacity(strings.TrimSpace(provider), memory)
+	}
+}
+
+// WithLocalModel sets the memory used by a local model when it is loaded,
+// and the number of generations which run with it at once, or zero for no
+// limit. Generations which exceed the limit wait for others to finish.
+func WithLocalModel(name string, memory float64, concurrency int) Opt {
+	return func(o *manageropt) error {
+		if o.local == nil {
+			o.local = newLocalScheduler()
+		}
+		return o.local.setModel(strings.TrimSpace(name), memory, concurrency)
+	}
+}
+
+// WithOCR extracts the text of image attachments with the recognizer, and
+// sends the text in place of the images to models which do not accept them
+func WithOCR(recognizer ocr.Recognizer) Opt {
+	return func(o *manageropt) error {
+		if recognizer == nil {
+			return fmt.Errorf("ocr recognizer is required")
+		}
+		o.ocr = recognizer
+		return nil
+	}
+}
+
+// WithProviderOCR extracts the text of image attachments with a provider
+// which reads documents, such as mistral, using the model or the default
+// model of the provider, and sends the text in place of the images to models
+// which do not accept them
+func WithProviderOCR(provider, model string) Opt {
+	return func(o *manageropt) error {
+		provider, model = strings.TrimSpace(provider), strings.TrimSpace(model)
+		if provider == "" {
+			return fmt.Errorf("ocr provider is required")
+		}
+		o.ocrprovider = &mediaopt{provider: provider, model: model}
+		return nil
+	}
+}
+
 // WithResources provides unified resource options for the LLM model
 // providers
 func WithResources(opts ...llm.Resource) Opt {
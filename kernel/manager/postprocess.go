@@ -0,0 +1,83 @@
+package manager
+
+import (
+	"context"
+	"maps"
+
+	// Packages
+	llm "github.com/mutablelogic/go-llm"
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	opt "github.com/mutablelogic/go-llm/pkg/opt"
+	postprocess "github.com/mutablelogic/go-llm/pkg/postprocess"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// postprocessGenerator changes the text of replies with a pipeline before
+// they are returned and stored. Text which was streamed has already been
+// delivered as it was generated, so a reply which is changed after it was
+// streamed has the steps of the pipeline in its meta.
+type postprocessGenerator struct {
+	llm.Generator
+	pipeline postprocess.Pipeline
+	steps    []string
+}
+
+var _ llm.Generator = (*postprocessGenerator)(nil)
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+func (g *postprocessGenerator) WithoutSession(ctx context.Context, model schema.Model, message *schema.Message, opts ...opt.Opt) (*schema.Message, *schema.UsageMeta, error) {
+	reply, usage, err := g.Generator.WithoutSession(ctx, model, message, opts...)
+	if err == nil {
+		g.process(reply, opts)
+	}
+	return reply, usage, err
+}
+
+func (g *postprocessGenerator) WithSession(ctx context.Context, model schema.Model, conversation *schema.Conversation, message *schema.Message, opts ...opt.Opt) (*schema.Message, *schema.UsageMeta, error) {
+	reply, usage, err := g.Generator.WithSession(ctx, model, conversation, message, opts...)
+	if err == nil && g.process(reply, opts) && conversation != nil && conversation.Len() > 0 {
+		// The reply appended to the conversation may be a copy
+		if last := (*conversation)[conversation.Len()-1]; last != reply && last.Role == schema.RoleAssistant {
+			last.Content = reply.Content
+			last.Meta = reply.Meta
+		}
+	}
+	return reply, usage, err
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// postprocessor returns a generator which changes the text of replies with
+// the steps of the meta, or the generator when there are no steps. Steps
+// which are not built in are the processors added to the manager.
+func (m *Manager) postprocessor(generator llm.Generator, meta schema.GeneratorMeta) (llm.Generator, error) {
+	if len(meta.PostProcess) == 0 {
+		return generator, nil
+	}
+	pipeline, err := postprocess.New(meta.PostProcess, m.processors)
+	if err != nil {
+		return nil, err
+	}
+	return &postprocessGenerator{Generator: generator, pipeline: pipeline, steps: meta.PostProcess.Types()}, nil
+}
+
+// process changes the text of the reply, and returns true if it changed
+func (g *postprocessGenerator) process(reply *schema.Message, opts []opt.Opt) bool {
+	if !g.pipeline.Message(reply) {
+		return false
+	}
+	if o, err := opt.Apply(opts...); err == nil && o.GetStream() != nil {
+		meta := maps.Clone(reply.Meta)
+		if meta == nil {
+			meta = make(map[string]any, 1)
+		}
+		meta[schema.MessageMetaPostProcess] = g.steps
+		reply.Meta = meta
+	}
+	return true
+}
@@ -0,0 +1,56 @@
+package manager
+
+import (
+	"context"
+	"testing"
+
+	// Packages
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	opt "github.com/mutablelogic/go-llm/pkg/opt"
+	assert "github.com/stretchr/testify/assert"
+)
+
+func TestPostProcessGenerator(t *testing.T) {
+	assert := assert.New(t)
+
+	m := new(Manager)
+	inner := &prefillTestGenerator{}
+	generator, err := m.postprocessor(inner, schema.GeneratorMeta{PostProcess: schema.PostProcessors{
+		{Type: schema.PostProcessReplace, Pattern: `"a"`, Replacement: `"b"`},
+	}})
+	if !assert.NoError(err) {
+		return
+	}
+	message, err := schema.NewMessage(schema.RoleUser, "Reply with JSON")
+	if !assert.NoError(err) {
+		return
+	}
+
+	// The reply and the copy in the conversation are changed before they
+	// are returned
+	var conversation schema.Conversation
+	reply, _, err := generator.WithSession(context.Background(), schema.Model{}, &conversation, message)
+	if assert.NoError(err) {
+		assert.Equal(`"b": 1}`, reply.Text())
+		assert.Equal(`"b": 1}`, conversation[len(conversation)-1].Text())
+		assert.NotContains(reply.Meta, schema.MessageMetaPostProcess)
+	}
+
+	// A reply which was streamed as it was generated has the steps in its meta
+	var streamed string
+	reply, _, err = generator.WithoutSession(context.Background(), schema.Model{}, message, opt.WithStream(func(role, text string) {
+		if role == schema.RoleAssistant {
+			streamed += text
+		}
+	}))
+	if assert.NoError(err) {
+		assert.Equal(`"a": 1}`, streamed)
+		assert.Equal(`"b": 1}`, reply.Text())
+		assert.Equal([]string{schema.PostProcessReplace}, reply.Meta[schema.MessageMetaPostProcess])
+	}
+
+	// Steps which are not built in must be added to the manager
+	_, err = m.postprocessor(inner, schema.GeneratorMeta{PostProcess: schema.PostProcessors{{Type: "shout"}}})
+	assert.ErrorIs(err, schema.ErrBadParameter)
+	assert.Error(m.apply(WithPostProcessor(schema.PostProcessMask, nil)))
+}
@@ -0,0 +1,122 @@
+package manager
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"unicode"
+
+	// Packages
+	llm "github.com/mutablelogic/go-llm"
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	opt "github.com/mutablelogic/go-llm/pkg/opt"
+	types "github.com/mutablelogic/go-server/pkg/types"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// prefillGenerator adds the text which starts the reply to the reply, since
+// providers return only the continuation. The text is streamed before the
+// continuation, and the reply appended to a conversation includes it.
+type prefillGenerator struct {
+	llm.Generator
+	prefill string
+}
+
+var _ llm.Generator = (*prefillGenerator)(nil)
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+func (g *prefillGenerator) WithoutSession(ctx context.Context, model schema.Model, message *schema.Message, opts ...opt.Opt) (*schema.Message, *schema.UsageMeta, error) {
+	reply, usage, err := g.Generator.WithoutSession(ctx, model, message, g.opts(opts)...)
+	g.prepend(reply)
+	return reply, usage, err
+}
+
+func (g *prefillGenerator) WithSession(ctx context.Context, model schema.Model, conversation *schema.Conversation, message *schema.Message, opts ...opt.Opt) (*schema.Message, *schema.UsageMeta, error) {
+	reply, usage, err := g.Generator.WithSession(ctx, model, conversation, message, g.opts(opts)...)
+	if g.prepend(reply) && conversation != nil && conversation.Len() > 0 {
+		// The reply appended to the conversation is a copy
+		if last := (*conversation)[conversation.Len()-1]; last.Role == schema.RoleAssistant {
+			last.Content = reply.Content
+		}
+	}
+	return reply, usage, err
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// prefillOpts returns the options which start the reply with text, and a
+// generator which adds the text to the reply. When the provider does not
+// support prefill, the policy for unsupported options applies, and the
+// prefill is ignored unless the policy is to fail the request.
+func (m *Manager) prefillOpts(provider string, generator llm.Generator, text string, context generationContext) ([]opt.Opt, llm.Generator) {
+	text = strings.TrimRightFunc(text, unicode.IsSpace)
+	switch {
+	case text == "":
+		return nil, generator
+	case supportsPrefill(provider, context):
+		return []opt.Opt{opt.WithPrefill(text)}, &prefillGenerator{Generator: generator, prefill: text}
+	case m.unsupported == OptionPolicyError:
+		return []opt.Opt{opt.Error(schema.ErrNotImplemented.Withf("%s: prefill not supported", provider))}, generator
+	default:
+		return []opt.Opt{opt.AddString(opt.WarningKey, fmt.Sprintf("prefill is not supported by %q and was ignored", provider))}, generator
+	}
+}
+
+// supportsPrefill returns true if the provider continues a final assistant
+// message. Ollama only supports this for chat requests.
+func supportsPrefill(provider string, context generationContext) bool {
+	switch provider {
+	case schema.Anthropic, schema.Mistral:
+		return true
+	case schema.Ollama:
+		return context == generationContextChat
+	default:
+		return false
+	}
+}
+
+// opts streams the prefill before the first text of the reply
+func (g *prefillGenerator) opts(opts []opt.Opt) []opt.Opt {
+	o, err := opt.Apply(opts...)
+	if err != nil || o.GetStream() == nil {
+		return opts
+	}
+	streamFn := o.GetStream()
+	var once sync.Once
+	return append(slices.Clone(opts), opt.WithStream(func(role, text string) {
+		if role == schema.RoleAssistant {
+			once.Do(func() { streamFn(schema.RoleAssistant, g.prefill) })
+		}
+		streamFn(role, text)
+	}))
+}
+
+// prepend adds the prefill to the start of the text of the reply, and
+// returns false if there is no reply
+func (g *prefillGenerator) prepend(reply *schema.Message) bool {
+	if reply == nil {
+		return false
+	}
+	content := slices.Clone(reply.Content)
+	for i, block := range content {
+		switch {
+		case block.Text != nil:
+			content[i].Text = types.Ptr(g.prefill + types.Value(block.Text))
+		case block.Thinking != nil:
+			continue
+		default:
+			content = slices.Insert(content, i, schema.ContentBlock{Text: types.Ptr(g.prefill)})
+		}
+		reply.Content = content
+		return true
+	}
+	reply.Content = append(content, schema.ContentBlock{Text: types.Ptr(g.prefill)})
+	return true
+}
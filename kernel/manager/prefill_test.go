@@ -0,0 +1,119 @@
+package manager
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	// Packages
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	opt "github.com/mutablelogic/go-llm/pkg/opt"
+	types "github.com/mutablelogic/go-server/pkg/types"
+	assert "github.com/stretchr/testify/assert"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// HELPERS
+
+// prefillTestGenerator continues the prefill, and records the prefill which
+// was requested
+type prefillTestGenerator struct {
+	prefill string
+}
+
+func (g *prefillTestGenerator) WithoutSession(_ context.Context, _ schema.Model, _ *schema.Message, opts ...opt.Opt) (*schema.Message, *schema.UsageMeta, error) {
+	o, err := opt.Apply(opts...)
+	if err != nil {
+		return nil, nil, err
+	}
+	g.prefill = o.GetString(opt.PrefillKey)
+	if stream := o.GetStream(); stream != nil {
+		stream(schema.RoleThinking, "JSON please")
+		stream(schema.RoleAssistant, `"a": 1}`)
+	}
+	return &schema.Message{Role: schema.RoleAssistant, Content: []schema.ContentBlock{
+		{Thinking: types.Ptr("JSON please")},
+		{Text: types.Ptr(`"a": 1}`)},
+	}, Result: schema.ResultStop}, nil, nil
+}
+
+func (g *prefillTestGenerator) WithSession(ctx context.Context, model schema.Model, conversation *schema.Conversation, message *schema.Message, opts ...opt.Opt) (*schema.Message, *schema.UsageMeta, error) {
+	conversation.Append(*message)
+	reply, usage, err := g.WithoutSession(ctx, model, message, opts...)
+	if err == nil {
+		conversation.Append(*reply)
+	}
+	return reply, usage, err
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// TESTS
+
+func TestPrefillGenerator(t *testing.T) {
+	assert := assert.New(t)
+	m := &Manager{manageropt: manageropt{unsupported: OptionPolicyError}}
+	inner := &prefillTestGenerator{}
+	opts, generator := m.prefillOpts(schema.Anthropic, inner, "{\n", generationContextChat)
+	message, err := schema.NewMessage(schema.RoleUser, "Reply with JSON")
+	if !assert.NoError(err) {
+		return
+	}
+
+	// The prefill is streamed first, and is at the start of the reply text
+	var streamed strings.Builder
+	var conversation schema.Conversation
+	reply, _, err := generator.WithSession(context.Background(), schema.Model{}, &conversation, message, append(opts, opt.WithStream(func(role, text string) {
+		if role == schema.RoleAssistant {
+			streamed.WriteString(text)
+		}
+	}))...)
+	if !assert.NoError(err) {
+		return
+	}
+	assert.Equal("{", inner.prefill)
+	assert.Equal(`{"a": 1}`, streamed.String())
+	assert.Equal(`{"a": 1}`, reply.Text())
+
+	// The reply in the conversation, which is persisted, includes the prefill
+	if assert.Len(conversation, 2) {
+		assert.Equal(`{"a": 1}`, conversation[1].Text())
+	}
+}
+
+func TestPrefillOpts(t *testing.T) {
+	assert := assert.New(t)
+	inner := &prefillTestGenerator{}
+
+	// Without a prefill, nothing changes
+	m := &Manager{manageropt: manageropt{unsupported: OptionPolicyError}}
+	opts, generator := m.prefillOpts(schema.Anthropic, inner, "  ", generationContextAsk)
+	assert.Empty(opts)
+	assert.Equal(inner, generator)
+
+	// Ollama only supports prefill in chat
+	_, generator = m.prefillOpts(schema.Ollama, inner, "{", generationContextChat)
+	assert.IsType(&prefillGenerator{}, generator)
+	opts, generator = m.prefillOpts(schema.Ollama, inner, "{", generationContextAsk)
+	assert.Equal(inner, generator)
+	_, err := opt.Apply(opts...)
+	assert.ErrorIs(err, schema.ErrNotImplemented)
+
+	// Unless the policy is to fail, an unsupported prefill is ignored
+	m.unsupported = OptionPolicyWarn
+	opts, generator = m.prefillOpts(schema.Gemini, inner, "{", generationContextChat)
+	assert.Equal(inner, generator)
+	assert.Equal([]string{`prefill is not supported by "gemini" and was ignored`}, optWarnings(opts))
+}
+
+func TestPrefillPrepend(t *testing.T) {
+	assert := assert.New(t)
+	g := &prefillGenerator{prefill: "# Summary"}
+
+	// A reply with only a tool call has the prefill before the call
+	reply := &schema.Message{Role: schema.RoleAssistant, Content: []schema.ContentBlock{{ToolCall: &schema.ToolCall{Name: "search"}}}}
+	assert.True(g.prepend(reply))
+	if assert.Len(reply.Content, 2) {
+		assert.Equal("# Summary", types.Value(reply.Content[0].Text))
+	}
+	assert.False(g.prepend(nil))
+}
@@ -20,6 +20,12 @@ func (m *Manager) CreateProvider(ctx context.Context, req schema.ProviderInsert)
 	if req.Provider == "" {
 		req.Provider = req.Name
 	}
+	if !req.KeyStrategy.Valid() {
+		return nil, schema.ErrBadParameter.Withf("invalid key strategy %q", req.KeyStrategy)
+	}
+	if err := m.offlineProvider(req.Provider); err != nil {
+		return nil, err
+	}
 
 	pv, credentials, err := m.encryptCredentials(req.ProviderCredentials)
 	if err != nil {
@@ -66,6 +72,11 @@ func (m *Manager) listProviders(ctx context.Context, req schema.ProviderListRequ
 	result.OffsetLimit = req.OffsetLimit
 	result.OffsetLimit.Clamp(result.Count)
 
+	// Add the usage of each API key, for providers with more than one
+	for _, provider := range result.Body {
+		provider.Keys = m.Registry.Keys(provider.Name)
+	}
+
 	// Return success
 	return types.Ptr(result), nil
 }
@@ -76,6 +87,7 @@ func (m *Manager) GetProvider(ctx context.Context, name string) (*schema.Provide
 	if err := m.PoolConn.Get(ctx, &result, schema.ProviderNameSelector(name)); err != nil {
 		return nil, normalizeProviderError(name, err)
 	}
+	result.Keys = m.Registry.Keys(result.Name)
 
 	// Return success
 	return types.Ptr(result), nil
@@ -0,0 +1,94 @@
+package manager
+
+import (
+	"context"
+
+	// Packages
+	uuid "github.com/google/uuid"
+	auth "github.com/mutablelogic/go-auth/auth/schema"
+	otel "github.com/mutablelogic/go-client/pkg/otel"
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	pg "github.com/mutablelogic/go-pg"
+	types "github.com/mutablelogic/go-server/pkg/types"
+	attribute "go.opentelemetry.io/otel/attribute"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// ListReasoning returns the thinking which was recorded apart from the
+// transcript of a session, oldest first. It is for administrators, and is
+// empty unless thinking is recorded with WithReasoningRecord. Other users are
+// forbidden from reading it.
+func (m *Manager) ListReasoning(ctx context.Context, session uuid.UUID, user *auth.UserInfo) (_ schema.ReasoningList, err error) {
+	// OTel span
+	ctx, endSpan := otel.StartSpan(m.tracer, ctx, "ListReasoning",
+		attribute.String("session", session.String()),
+		attribute.String("user", types.Stringify(user)),
+	)
+	defer func() { endSpan(err) }()
+
+	// Only administrators can read the recorded thinking
+	if !isAdmin(user) {
+		return nil, schema.ErrForbidden.Withf("recorded thinking requires the %q scope", schema.ScopeAdmin)
+	}
+
+	// Check the session exists, for any user
+	if _, err := m.GetSession(ctx, session, new(auth.UserInfo)); err != nil {
+		return nil, err
+	}
+
+	// Return the recorded thinking
+	result := make(schema.ReasoningList, 0)
+	if err := m.PoolConn.List(ctx, &result, schema.ReasoningListSelector(session)); err != nil {
+		return nil, pg.NormalizeError(err)
+	}
+	return result, nil
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// isAdmin returns true for administrators, which are users with the admin
+// scope, and for callers in the process which act without a user
+func isAdmin(user *auth.UserInfo) bool {
+	return user == nil || user.HasScope(schema.ScopeAdmin)
+}
+
+// exportConversation returns the conversation of a session to export. The
+// thinking of models is redacted unless it is requested, and recorded
+// thinking is only included for administrators.
+func (m *Manager) exportConversation(ctx context.Context, session uuid.UUID, conversation schema.Conversation, thinking bool, user *auth.UserInfo) (schema.Conversation, error) {
+	if !thinking {
+		return schema.RedactThinking(conversation), nil
+	} else if !m.reasoning || !isAdmin(user) {
+		return conversation, nil
+	}
+	reasoning := make(schema.ReasoningList, 0)
+	if err := m.PoolConn.List(ctx, &reasoning, schema.ReasoningListSelector(session)); err != nil {
+		return nil, pg.NormalizeError(err)
+	}
+	return schema.MergeThinking(conversation, reasoning), nil
+}
+
+// insertMessage stores a message of a session, and sets the message to the
+// stored message. When thinking is recorded, the thinking of the message is
+// stored apart from it.
+func (m *Manager) insertMessage(ctx context.Context, conn pg.Conn, session uuid.UUID, message *schema.Message) error {
+	visible, thinking := types.Value(message), []schema.ContentBlock(nil)
+	if m.reasoning {
+		visible, thinking = schema.SplitThinking(visible)
+	}
+
+	var inserted schema.MessageInsert
+	if err := conn.Insert(ctx, &inserted, schema.MessageInsert{Session: session, Message: visible}); err != nil {
+		return pg.NormalizeError(err)
+	}
+	if len(thinking) > 0 {
+		if err := conn.Insert(ctx, nil, schema.ReasoningInsert{Message: inserted.ID, Session: session, Content: thinking}); err != nil {
+			return pg.NormalizeError(err)
+		}
+	}
+	*message = inserted.Message
+	return nil
+}
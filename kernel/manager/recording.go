@@ -0,0 +1,176 @@
+package manager
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	// Packages
+	uuid "github.com/google/uuid"
+	auth "github.com/mutablelogic/go-auth/auth/schema"
+	otel "github.com/mutablelogic/go-client/pkg/otel"
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	types "github.com/mutablelogic/go-server/pkg/types"
+	attribute "go.opentelemetry.io/otel/attribute"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+type recordingKey struct{}
+
+// recorder records the intermediate states of a chat turn. The methods of a
+// nil recorder do nothing, so a turn which is not recorded needs no checks.
+type recorder struct {
+	schema.Recording
+	user  uuid.UUID // user who made the turn, or nil
+	clock Clock
+	start time.Time
+}
+
+// recordingList holds the recordings of this replica, which are kept for
+// recordingTTL. The zero value is ready to use.
+type recordingList struct {
+	sync.Mutex
+	clock      Clock
+	ids        IDGenerator
+	recordings map[uuid.UUID]*recorder
+}
+
+// recordedResponse is the reply of the provider for one iteration
+type recordedResponse struct {
+	Reply *schema.Message   `json:"reply"`
+	Usage *schema.UsageMeta `json:"usage,omitempty"`
+}
+
+// recordedSubstitution is a model which is replaced by its successor
+type recordedSubstitution struct {
+	Model   string `json:"model"`
+	Warning string `json:"warning"`
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+// recordingTTL is the time a recording is kept after the turn
+const recordingTTL = time.Hour
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// WithRecording returns a context in which Chat records every intermediate
+// state of the turn: the options resolved for it, the messages sent to the
+// provider and its reply at each iteration, and the tool calls. The ID of the
+// recording is returned in the response, and the recording is read with
+// GetRecording. Recordings are held in memory, so are only found on the
+// replica which made the turn.
+func WithRecording(ctx context.Context) context.Context {
+	return context.WithValue(ctx, recordingKey{}, true)
+}
+
+// GetRecording returns the recording of a chat turn. If step is non-nil,
+// only the step at that position is returned. If user is non-nil, the turn
+// must have been made by that user.
+func (m *Manager) GetRecording(ctx context.Context, id uuid.UUID, step *uint, user *auth.UserInfo) (_ *schema.Recording, err error) {
+	_, endSpan := otel.StartSpan(m.tracer, ctx, "GetRecording",
+		attribute.String("id", id.String()),
+		attribute.String("user", types.Stringify(user)),
+	)
+	defer func() { endSpan(err) }()
+
+	recording, err := m.recordings.get(id, userSub(user))
+	if err != nil {
+		return nil, err
+	} else if step != nil {
+		return recording.Step(*step)
+	}
+	return recording, nil
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// recorder returns a recorder for a chat turn when recording is enabled in
+// the context, or nil otherwise
+func (l *recordingList) recorder(ctx context.Context, session uuid.UUID, user *auth.UserInfo) *recorder {
+	if enabled, _ := ctx.Value(recordingKey{}).(bool); !enabled {
+		return nil
+	}
+	now := l.clock.Now()
+	return &recorder{
+		Recording: schema.Recording{
+			ID:        l.ids.New(),
+			Session:   session,
+			CreatedAt: now,
+		},
+		user:  userSub(user),
+		clock: l.clock,
+		start: now,
+	}
+}
+
+// id returns the ID of the recording, or nil when the turn is not recorded
+func (r *recorder) id() uuid.UUID {
+	if r == nil {
+		return uuid.Nil
+	}
+	return r.ID
+}
+
+// step records the state at a step of the turn. The state is encoded when it
+// is recorded, so that later changes to it are not seen in the recording.
+func (r *recorder) step(kind schema.RecordingStepType, iteration uint, state any) {
+	if r == nil {
+		return
+	}
+	data, err := json.Marshal(state)
+	if err != nil {
+		data, _ = json.Marshal(map[string]string{"error": err.Error()})
+	}
+	r.Steps = append(r.Steps, schema.RecordingStep{
+		Index:     uint(len(r.Steps)),
+		Type:      kind,
+		Iteration: iteration,
+		Elapsed:   r.clock.Since(r.start),
+		Data:      data,
+	})
+	r.Count = uint(len(r.Steps))
+}
+
+// put keeps a recording when the turn is done. A nil recorder is ignored.
+func (l *recordingList) put(r *recorder) {
+	if r == nil {
+		return
+	}
+	l.Lock()
+	defer l.Unlock()
+	l.expire(l.clock.Now())
+	if l.recordings == nil {
+		l.recordings = make(map[uuid.UUID]*recorder)
+	}
+	l.recordings[r.ID] = r
+}
+
+// get returns a recording of a turn made by the user, or any recording when
+// user is nil
+func (l *recordingList) get(id, user uuid.UUID) (*schema.Recording, error) {
+	l.Lock()
+	defer l.Unlock()
+	l.expire(l.clock.Now())
+	r, exists := l.recordings[id]
+	if !exists || (user != uuid.Nil && r.user != user) {
+		return nil, schema.ErrNotFound.Withf("recording %q not found", id)
+	}
+	return types.Ptr(r.Recording), nil
+}
+
+// expire removes recordings of turns which started more than recordingTTL
+// before now. The lock must be held.
+func (l *recordingList) expire(now time.Time) {
+	for id, r := range l.recordings {
+		if now.Sub(r.CreatedAt) > recordingTTL {
+			delete(l.recordings, id)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package manager
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	// Packages
+	uuid "github.com/google/uuid"
+	auth "github.com/mutablelogic/go-auth/auth/schema"
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	types "github.com/mutablelogic/go-server/pkg/types"
+	assert "github.com/stretchr/testify/assert"
+)
+
+func TestRecorder(t *testing.T) {
+	assert := assert.New(t)
+	session := uuid.New()
+
+	// A turn is only recorded when recording is enabled in the context
+	recording := new(recordingList).recorder(context.Background(), session, nil)
+	assert.Nil(recording)
+	assert.Equal(uuid.Nil, recording.id())
+	recording.step(schema.RecordingStepPlan, 0, "ignored")
+
+	user := &auth.UserInfo{Sub: auth.UserID(uuid.New())}
+	recording = new(recordingList).recorder(WithRecording(context.Background()), session, user)
+	if !assert.NotNil(recording) {
+		return
+	}
+	assert.Equal(session, recording.Session)
+	assert.NotEqual(uuid.Nil, recording.id())
+
+	// The state is encoded when it is recorded, so later changes are not seen
+	reply := &schema.Message{Role: schema.RoleAssistant, Content: []schema.ContentBlock{{Text: types.Ptr("first")}}}
+	recording.step(schema.RecordingStepResponse, 1, recordedResponse{Reply: reply})
+	reply.Content[0].Text = types.Ptr("second")
+	recording.step(schema.RecordingStepTools, 1, func() {})
+
+	assert.Equal(uint(2), recording.Count)
+	if assert.Len(recording.Steps, 2) {
+		assert.Equal(uint(0), recording.Steps[0].Index)
+		assert.Equal(schema.RecordingStepResponse, recording.Steps[0].Type)
+		assert.Equal(uint(1), recording.Steps[0].Iteration)
+		var response recordedResponse
+		if assert.NoError(json.Unmarshal(recording.Steps[0].Data, &response)) {
+			assert.Equal("first", response.Reply.Text())
+		}
+
+		// State which cannot be encoded is recorded as an error
+		assert.Equal(uint(1), recording.Steps[1].Index)
+		assert.Contains(string(recording.Steps[1].Data), "error")
+	}
+}
+
+func TestRecordingList(t *testing.T) {
+	assert := assert.New(t)
+	var l recordingList
+
+	// A nil recorder is not kept
+	l.put(nil)
+	assert.Empty(l.recordings)
+
+	user := uuid.New()
+	recording := l.recorder(WithRecording(context.Background()), uuid.New(), &auth.UserInfo{Sub: auth.UserID(user)})
+	recording.step(schema.RecordingStepPlan, 0, map[string]string{"model": "test"})
+	l.put(recording)
+
+	// The recording is found by the user who made the turn, or without a user
+	result, err := l.get(recording.ID, user)
+	if assert.NoError(err) {
+		assert.Equal(recording.ID, result.ID)
+		assert.Len(result.Steps, 1)
+	}
+	_, err = l.get(recording.ID, uuid.Nil)
+	assert.NoError(err)
+	_, err = l.get(recording.ID, uuid.New())
+	assert.ErrorIs(err, schema.ErrNotFound)
+	_, err = l.get(uuid.New(), user)
+	assert.ErrorIs(err, schema.ErrNotFound)
+
+	// Recordings expire
+	l.Lock()
+	l.expire(time.Now().Add(recordingTTL + time.Second))
+	l.Unlock()
+	_, err = l.get(recording.ID, user)
+	assert.ErrorIs(err, schema.ErrNotFound)
+}
@@ -0,0 +1,70 @@
+package manager
+
+import (
+	"context"
+
+	// Packages
+	otel "github.com/mutablelogic/go-client/pkg/otel"
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// Reload reloads the providers from the database, and rebuilds the clients
+// of providers whose settings or credentials have changed. Connectors and
+// the agent directory are synced at the same time. Requests which started
+// before the reload continue with the previous clients until they are done.
+// The reload runs within Run, so Reload waits until Run is running.
+func (m *Manager) Reload(ctx context.Context) (_ *schema.Reload, err error) {
+	ctx, endSpan := otel.StartSpan(m.tracer, ctx, "Reload")
+	defer func() { endSpan(err) }()
+
+	result := make(chan *schema.Reload, 1)
+	select {
+	case m.reload <- result:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	select {
+	case reload := <-result:
+		return reload, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// reloadAll syncs the providers, connectors and agent directory, and returns
+// what changed. An error in one part does not stop the others.
+func (m *Manager) reloadAll(ctx context.Context, agentDir *agentDir) *schema.Reload {
+	result := new(schema.Reload)
+
+	// Rebuild changed providers, and forget their models and failures
+	updates, deletes, err := m.syncProviders(ctx)
+	if err != nil {
+		result.Errors = append(result.Errors, err.Error())
+	}
+	result.UpdatedProviders, result.DeletedProviders = updates, deletes
+	m.models.Remove(append(updates, deletes...)...)
+	m.breakers.reset(append(updates, deletes...)...)
+
+	// Sync connectors
+	if err := m.syncConnectors(ctx); err != nil {
+		result.Errors = append(result.Errors, err.Error())
+	}
+
+	// Re-scan the agent directory
+	if agentDir != nil {
+		added, removed, err := m.syncAgentDir(agentDir)
+		if err != nil {
+			result.Errors = append(result.Errors, err.Error())
+		}
+		result.UpdatedAgents, result.RemovedAgents = added, removed
+	}
+
+	// Return the changes
+	return result
+}
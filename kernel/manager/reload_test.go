@@ -0,0 +1,35 @@
+package manager
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	// Packages
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	assert "github.com/stretchr/testify/assert"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// TESTS
+
+func TestReload(t *testing.T) {
+	assert := assert.New(t)
+	m := &Manager{reload: make(chan chan *schema.Reload)}
+
+	// The reload is handled by Run
+	go func() {
+		result := <-m.reload
+		result <- &schema.Reload{UpdatedProviders: []string{"test"}}
+	}()
+	reload, err := m.Reload(context.Background())
+	if assert.NoError(err) {
+		assert.Equal([]string{"test"}, reload.UpdatedProviders)
+	}
+
+	// Reload waits until Run is running, or the context ends
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = m.Reload(ctx)
+	assert.ErrorIs(err, context.DeadlineExceeded)
+}
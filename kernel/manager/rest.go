@@ -0,0 +1,20 @@
+package manager
+
+import (
+	// Packages
+	rest "github.com/mutablelogic/go-llm/pkg/rest"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// restTools adds a tool for each REST endpoint. Endpoints which are not
+// read-only require approval.
+func (m *Manager) restTools() error {
+	tools, err := rest.NewTools(m.endpoints, rest.WithSecrets(m.secret))
+	if err != nil {
+		return err
+	}
+	m.addTools(tools...)
+	return nil
+}
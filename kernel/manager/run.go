@@ -42,12 +42,19 @@ func (m *Manager) Run(ctx context.Context, logger *slog.Logger) error {
 	// Close the broadcaster when the manager is stopped
 	defer m.broadcaster.Close()
 
+	// Cancel background jobs when the manager is stopped
+	defer m.jobs.cancelAll()
+
 	// Create the toolkit and add any tools, prompts, and resources that were
 	// added in the options
 	toolkitOpts := []toolkit.Option{
 		toolkit.WithTracer(m.tracer),
 		toolkit.WithDelegate(m.delegate),
 		toolkit.WithLogger(logger),
+		toolkit.WithToolCacheSize(m.toolcache),
+	}
+	for name, ttl := range m.toolttl {
+		toolkitOpts = append(toolkitOpts, toolkit.WithToolCache(name, ttl))
 	}
 	toolkitOpts = append(toolkitOpts, toolkit.WithTool(m.tools...))
 	toolkitOpts = append(toolkitOpts, toolkit.WithPrompt(m.prompts...))
@@ -75,10 +82,52 @@ func (m *Manager) Run(ctx context.Context, logger *slog.Logger) error {
 		}
 	}
 
-	// Sync connectors
+	// Load agents from the agent directory, if configured, and re-scan it
+	// periodically so that changes to the files are picked up
+	var agentDir *agentDir
+	var agentDirTicker <-chan time.Time
+	if m.agentdir != "" {
+		agentDir = newAgentDir(m.agentdir)
+		if added, _, err := m.syncAgentDir(agentDir); err != nil {
+			logger.ErrorContext(ctx, "failed to load agents", "path", m.agentdir, "error", err.Error())
+		} else {
+			logger.DebugContext(ctx, "loaded agents", "path", m.agentdir, "agents", added)
+		}
+		ticker := time.NewTicker(agentDirInterval)
+		defer ticker.Stop()
+		agentDirTicker = ticker.C
+	}
+
+	// Enforce retention policies, if configured
+	var retentionTicker <-chan time.Time
+	if len(m.retention) > 0 {
+		ticker := time.NewTicker(retentionInterval)
+		defer ticker.Stop()
+		retentionTicker = ticker.C
+	}
+
+	// Refresh cached model lists in the background, if the cache is enabled
+	var modelTicker <-chan time.Time
+	if m.modelttl > 0 {
+		ticker := time.NewTicker(m.modelttl)
+		defer ticker.Stop()
+		modelTicker = ticker.C
+	}
+
+	// Keep the checkpoints of the chat turns of this replica, and recover
+	// the turns of replicas which stopped, if enabled
+	var checkpointTicker <-chan time.Time
+	if m.checkpoints {
+		ticker := time.NewTicker(checkpointInterval)
+		defer ticker.Stop()
+		checkpointTicker = ticker.C
+	}
+
+	// Sync connectors, and then check the tools which the agents list
 	if err := m.syncConnectors(ctx); err != nil {
 		return fmt.Errorf("sync connectors: %w", err)
 	}
+	m.checkAgentTools(ctx, logger)
 
 	// Subscribe to database notifications, if configured
 	// We provide a small buffered channel to avoid blocking the database listener
@@ -125,6 +174,18 @@ func (m *Manager) Run(ctx context.Context, logger *slog.Logger) error {
 		}
 	})
 
+	// Load warm models in the background, and keep them loaded
+	if len(m.warm) > 0 {
+		wg.Go(func() {
+			m.runWarmup(toolkit_ctx, logger)
+		})
+	}
+
+	// Recover interrupted chat turns once the tools are available
+	if m.checkpoints {
+		m.runCheckpoints(ctx, logger)
+	}
+
 	// Run loop
 	for {
 		select {
@@ -141,14 +202,49 @@ func (m *Manager) Run(ctx context.Context, logger *slog.Logger) error {
 			if len(deletes) > 0 {
 				logger.InfoContext(ctx, "deleted providers", "providers", deletes)
 			}
+			m.models.Remove(append(updates, deletes...)...)
 		case <-connectorChange:
 			if err := m.syncConnectors(ctx); err != nil {
 				logger.ErrorContext(ctx, "failed to sync connectors after change notification", "error", err.Error())
 			}
+			m.checkAgentTools(ctx, logger)
 		case <-messageChange:
 			if err := m.sessionfeed.update(ctx); err != nil {
 				logger.ErrorContext(ctx, "failed to update session feed after message change notification", "error", err.Error())
 			}
+		case result := <-m.reload:
+			reload := m.reloadAll(ctx, agentDir)
+			logger.InfoContext(ctx, "reloaded", "providers", reload.UpdatedProviders, "deleted_providers", reload.DeletedProviders, "agents", reload.UpdatedAgents, "removed_agents", reload.RemovedAgents)
+			for _, err := range reload.Errors {
+				logger.ErrorContext(ctx, "failed to reload", "error", err)
+			}
+			result <- reload
+		case <-agentDirTicker:
+			added, removed, err := m.syncAgentDir(agentDir)
+			if err != nil {
+				logger.ErrorContext(ctx, "failed to reload agents", "path", m.agentdir, "error", err.Error())
+			}
+			if len(added) > 0 {
+				logger.InfoContext(ctx, "updated agents", "agents", added)
+				m.checkAgentTools(ctx, logger)
+			}
+			if len(removed) > 0 {
+				logger.InfoContext(ctx, "removed agents", "agents", removed)
+			}
+		case now := <-retentionTicker:
+			reports, err := m.applyRetention(ctx, now)
+			if err != nil {
+				logger.ErrorContext(ctx, "failed to apply retention policies", "error", err.Error())
+			}
+			for _, report := range reports {
+				logger.InfoContext(ctx, "deleted expired sessions", "label", report.Label, "sessions", len(report.Sessions), "messages", report.Messages)
+			}
+		case <-checkpointTicker:
+			m.runCheckpoints(ctx, logger)
+		case <-modelTicker:
+			for _, provider := range m.models.Providers() {
+				m.models.Revalidate(provider, m.fetchModels)
+			}
 		case <-ticker.C:
 			// Ping the registry to determine status of providers
 			if err := m.Registry.Ping(ctx); err != nil {
@@ -197,6 +293,9 @@ func (m *Manager) syncProviders(ctx context.Context) ([]string, []string, error)
 		}
 	}
 
+	// Providers which do not run models locally are removed when offline
+	m.offlineProviders(providers)
+
 	// Sync the registry with the list of providers and a decrypter function to obtain credentials
 	return m.Registry.Sync(providers, func(i int) (schema.ProviderCredentials, error) {
 		var credentials schema.ProviderCredentials
@@ -3,6 +3,7 @@ package manager
 import (
 	"context"
 	"errors"
+	"time"
 
 	// Packages
 	uuid "github.com/google/uuid"
@@ -15,12 +16,21 @@ import (
 	attribute "go.opentelemetry.io/otel/attribute"
 )
 
+///////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+// syncClockSkew is subtracted from the time in a sync cursor, so that
+// changes made by replicas with a clock which is behind are not missed
+const syncClockSkew = 5 * time.Second
+
 ///////////////////////////////////////////////////////////////////////////////
 // PUBLIC METHODS
 
 // CreateSession validates and persists a new session for the authenticated user.
 // If Parent is set, the parent session must exist, belong to the same user,
 // and its generator settings are used as defaults for the child session.
+// If ParentMessage is also set, the child session is a side thread which
+// continues from that message of the parent session.
 // If user is nil, it creates a user-less session.
 func (m *Manager) CreateSession(ctx context.Context, req schema.SessionInsert, user *auth.UserInfo) (_ *schema.Session, err error) {
 	// OTel span
@@ -49,6 +59,21 @@ func (m *Manager) CreateSession(ctx context.Context, req schema.SessionInsert, u
 			req.GeneratorMeta = parent.GeneratorMeta.MergeFrom(req.GeneratorMeta)
 		}
 
+		// A side thread continues from a message of the parent session,
+		// which must have been sent
+		if req.ParentMessage != 0 && req.Parent != uuid.Nil {
+			var message schema.Message
+			if err := conn.Get(ctx, &message, schema.MessageSelector{Session: req.Parent, ID: req.ParentMessage}); err != nil {
+				if err = pg.NormalizeError(err); errors.Is(err, pg.ErrNotFound) {
+					return schema.ErrNotFound.Withf("message %d not found in session %s", req.ParentMessage, req.Parent)
+				}
+				return err
+			}
+			if message.Pending() {
+				return schema.ErrBadParameter.Withf("message %d is pending input", req.ParentMessage)
+			}
+		}
+
 		// Resolve provider and model (read-only, safe inside transaction).
 		provider, model, _, _, err := m.generatorFromMeta(ctx, req.GeneratorMeta, user, generationContextChat)
 		if err != nil {
@@ -86,6 +111,92 @@ func (m *Manager) GetSession(ctx context.Context, session uuid.UUID, user *auth.
 	return types.Ptr(result), nil
 }
 
+// GetSessionSummary returns a session with the number of messages, and
+// without the generator settings. If user is non-nil, the session must be
+// owned by that user.
+func (m *Manager) GetSessionSummary(ctx context.Context, session uuid.UUID, user *auth.UserInfo) (_ *schema.SessionSummary, err error) {
+	// OTel span
+	ctx, endSpan := otel.StartSpan(m.tracer, ctx, "GetSessionSummary",
+		attribute.String("id", session.String()),
+		attribute.String("user", types.Stringify(user)),
+	)
+	defer func() { endSpan(err) }()
+
+	result, err := m.GetSession(ctx, session, user)
+	if err != nil {
+		return nil, err
+	}
+
+	// Count the messages without returning any
+	messages := schema.MessageList{}
+	req := schema.MessageListRequest{OffsetLimit: pg.OffsetLimit{Limit: types.Ptr(uint64(0))}, Sessions: []uuid.UUID{session}}
+	if err := m.PoolConn.With("user", user.Sub).List(ctx, &messages, req); err != nil {
+		return nil, pg.NormalizeError(err)
+	}
+
+	// Return success
+	return types.Ptr(result.Summary(messages.Count)), nil
+}
+
+// SyncSession returns the changes to a session since the cursor of the
+// request: the session when its metadata changed, and the messages which
+// were added or changed. If user is non-nil, the session must be owned by
+// that user.
+func (m *Manager) SyncSession(ctx context.Context, session uuid.UUID, req schema.SessionSyncRequest, user *auth.UserInfo) (_ *schema.SessionSync, err error) {
+	// OTel span
+	ctx, endSpan := otel.StartSpan(m.tracer, ctx, "SyncSession",
+		attribute.String("id", session.String()),
+		attribute.String("req", req.String()),
+		attribute.String("user", types.Stringify(user)),
+	)
+	defer func() { endSpan(err) }()
+
+	cursor, err := schema.ParseSessionSyncCursor(req.Cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	// Changes from this time are sent with the next cursor, allowing for
+	// the clocks of replicas to differ
+	now := m.clock.Now().Add(-syncClockSkew)
+
+	// Return the session when it changed since the cursor
+	current, err := m.GetSession(ctx, session, user)
+	if err != nil {
+		return nil, err
+	}
+	result := schema.SessionSync{Pending: []uint64{}}
+	changed := current.CreatedAt
+	if current.ModifiedAt != nil {
+		changed = *current.ModifiedAt
+	}
+	if cursor.Since.IsZero() || !changed.Before(cursor.Since) {
+		result.Session = current
+	}
+
+	// Get the messages, a page at a time
+	var messages schema.MessageList
+	if err := m.PoolConn.List(ctx, &messages, schema.MessageSyncSelector{Session: session, SessionSyncCursor: cursor}); err != nil {
+		return nil, pg.NormalizeError(err)
+	}
+	result.Messages = messages.Body
+	for _, message := range messages.Body {
+		cursor.Last = max(cursor.Last, message.ID)
+		if message.Pending() {
+			result.Pending = append(result.Pending, message.ID)
+		}
+	}
+
+	// The time only moves on when all the changes have been sent
+	if result.More = uint(len(messages.Body)) < messages.Count; !result.More {
+		cursor.Since = now
+	}
+	result.Cursor = cursor.Encode()
+
+	// Return success
+	return types.Ptr(result), nil
+}
+
 // UpdateSession updates the metadata for a session and returns the updated session.
 // If user is non-nil, the session must be owned by that user.
 // The incoming GeneratorMeta is merged over the existing one (incoming fields win).
@@ -0,0 +1,134 @@
+package manager
+
+import (
+	"context"
+	"io"
+
+	// Packages
+	uuid "github.com/google/uuid"
+	auth "github.com/mutablelogic/go-auth/auth/schema"
+	otel "github.com/mutablelogic/go-client/pkg/otel"
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	transcript "github.com/mutablelogic/go-llm/pkg/transcript"
+	pg "github.com/mutablelogic/go-pg"
+	types "github.com/mutablelogic/go-server/pkg/types"
+	attribute "go.opentelemetry.io/otel/attribute"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// CreateSessionShare creates a read-only share link for a session, and
+// returns it with the token which grants access to the transcript. The token
+// is not stored, so it cannot be returned again. If user is non-nil, the
+// session must be owned by that user.
+func (m *Manager) CreateSessionShare(ctx context.Context, session uuid.UUID, req schema.SessionShareRequest, user *auth.UserInfo) (_ *schema.SessionShare, err error) {
+	// OTel span
+	ctx, endSpan := otel.StartSpan(m.tracer, ctx, "CreateSessionShare",
+		attribute.String("id", session.String()),
+		attribute.String("req", req.String()),
+		attribute.String("user", types.Stringify(user)),
+	)
+	defer func() { endSpan(err) }()
+
+	lifetime, err := req.Lifetime()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := m.GetSession(ctx, session, user); err != nil {
+		return nil, err
+	}
+
+	// Store the hash of a new token
+	token, hash, err := schema.NewShareToken()
+	if err != nil {
+		return nil, err
+	}
+	var result schema.SessionShare
+	if err := m.PoolConn.Insert(ctx, &result, schema.SessionShareInsert{
+		Session:   session,
+		Hash:      hash,
+		ExpiresAt: m.clock.Now().Add(lifetime),
+	}); err != nil {
+		return nil, pg.NormalizeError(err)
+	}
+	result.Token = token
+
+	// Return success
+	return types.Ptr(result), nil
+}
+
+// ListSessionShares returns the share links of a session which have not
+// expired, without their tokens. If user is non-nil, the session must be
+// owned by that user.
+func (m *Manager) ListSessionShares(ctx context.Context, session uuid.UUID, user *auth.UserInfo) (_ schema.SessionShareList, err error) {
+	// OTel span
+	ctx, endSpan := otel.StartSpan(m.tracer, ctx, "ListSessionShares",
+		attribute.String("id", session.String()),
+		attribute.String("user", types.Stringify(user)),
+	)
+	defer func() { endSpan(err) }()
+
+	if _, err := m.GetSession(ctx, session, user); err != nil {
+		return nil, err
+	}
+	result := schema.SessionShareList{}
+	if err := m.PoolConn.List(ctx, &result, schema.SessionShareListSelector(session)); err != nil {
+		return nil, pg.NormalizeError(err)
+	}
+
+	// Return success
+	return result, nil
+}
+
+// DeleteSessionShare revokes a share link of a session and returns it. If
+// user is non-nil, the session must be owned by that user.
+func (m *Manager) DeleteSessionShare(ctx context.Context, session, share uuid.UUID, user *auth.UserInfo) (_ *schema.SessionShare, err error) {
+	// OTel span
+	ctx, endSpan := otel.StartSpan(m.tracer, ctx, "DeleteSessionShare",
+		attribute.String("id", session.String()),
+		attribute.String("share", share.String()),
+		attribute.String("user", types.Stringify(user)),
+	)
+	defer func() { endSpan(err) }()
+
+	if _, err := m.GetSession(ctx, session, user); err != nil {
+		return nil, err
+	}
+	var result schema.SessionShare
+	if err := m.PoolConn.Delete(ctx, &result, schema.SessionShareIDSelector{Session: session, ID: share}); err != nil {
+		return nil, pg.NormalizeError(err)
+	}
+
+	// Return success
+	return types.Ptr(result), nil
+}
+
+// ExportSharedSession writes the transcript of the session shared with a
+// token to w as HTML. An unknown, expired or revoked token returns
+// ErrNotFound. Access is granted by the token alone, so no user is required,
+// and the thinking of models is always redacted.
+func (m *Manager) ExportSharedSession(ctx context.Context, token string, w io.Writer) (err error) {
+	// OTel span, which does not include the token
+	ctx, endSpan := otel.StartSpan(m.tracer, ctx, "ExportSharedSession")
+	defer func() { endSpan(err) }()
+
+	var share schema.SessionShare
+	if err := m.PoolConn.Get(ctx, &share, schema.SessionShareTokenSelector{Token: token}); err != nil {
+		return pg.NormalizeError(err)
+	}
+
+	// Get the session and its messages, without restricting them to a user
+	anyone := new(auth.UserInfo)
+	result, err := m.GetSession(ctx, share.Session, anyone)
+	if err != nil {
+		return err
+	}
+	conversation, err := m.conversationForSession(ctx, share.Session, anyone)
+	if err != nil {
+		return err
+	}
+
+	// Write the transcript, without the thinking of models
+	return transcript.WriteHTML(w, result, schema.RedactThinking(conversation))
+}
@@ -0,0 +1,191 @@
+package manager
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	// Packages
+	llm "github.com/mutablelogic/go-llm"
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+type toolSimulationKey struct{}
+
+// simulatedTool replaces a tool in a simulated chat turn. Calls return a
+// recorded fixture when one matches, and otherwise a result imagined by the
+// model of the session. The tool itself is never run.
+type simulatedTool struct {
+	llm.Tool
+	fixtures  []schema.ToolFixture
+	generator llm.Generator
+	model     schema.Model
+}
+
+// simulatedResult is the result of a simulated tool call, which is flagged
+// with the source of the result
+type simulatedResult struct {
+	value  json.RawMessage
+	source string
+}
+
+var _ schema.Annotator = simulatedResult{}
+
+///////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+// Sources of simulated results
+const (
+	simulatedFixture = "fixture"
+	simulatedModel   = "model"
+)
+
+const simulationPrompt = `You are simulating a tool called %q so that an assistant can be tested without calling real systems.
+
+Description of the tool: %s
+%s
+The assistant called the tool with this input:
+%s
+
+Reply with only the JSON result which the tool would return for this input, with realistic values. Do not explain the result or wrap it in a code block.`
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// WithToolSimulation returns a context in which Chat does not run tools.
+// Each tool call returns a fixture set with WithToolFixtures which matches
+// the call, or otherwise a result generated by the model of the session.
+// Simulated results are annotated with their source, and flagged in the
+// trace of the turn.
+func WithToolSimulation(ctx context.Context) context.Context {
+	return context.WithValue(ctx, toolSimulationKey{}, true)
+}
+
+func (r simulatedResult) MarshalJSON() ([]byte, error) {
+	return r.value, nil
+}
+
+func (r simulatedResult) Annotations() map[string]any {
+	return map[string]any{schema.AnnotationSimulated: r.source}
+}
+
+// Run returns the fixture which matches the input, or a result from the
+// model when no fixture matches
+func (t *simulatedTool) Run(ctx context.Context, input json.RawMessage) (any, error) {
+	if fixture := matchFixture(t.fixtures, input); fixture != nil {
+		if fixture.Error != "" {
+			return nil, errors.New(fixture.Error)
+		} else if fixture.Result == "" {
+			return simulatedResult{value: json.RawMessage("null"), source: simulatedFixture}, nil
+		}
+		return simulatedResult{value: json.RawMessage(fixture.Result), source: simulatedFixture}, nil
+	}
+	if t.generator == nil {
+		return nil, schema.ErrNotFound.Withf("no fixture for tool %q", t.Name())
+	}
+	return t.imagine(ctx, input)
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// toolSimulationFromContext returns true if tool calls should be simulated
+func toolSimulationFromContext(ctx context.Context) bool {
+	simulate, _ := ctx.Value(toolSimulationKey{}).(bool)
+	return simulate
+}
+
+// simulatedTools returns the tools with each replaced by a simulated tool,
+// which has the fixtures recorded for it and the generator and model which
+// imagine results for other calls
+func (m *Manager) simulatedTools(tools toolMap, generator llm.Generator, model schema.Model) toolMap {
+	result := make(toolMap, len(tools))
+	for key, tool := range tools {
+		simulated := &simulatedTool{Tool: tool, generator: generator, model: model}
+		for _, fixture := range m.fixtures {
+			if name := normalizeToolMapKey(strings.TrimSpace(fixture.Name)); name == key || strings.HasSuffix(key, "__"+name) {
+				simulated.fixtures = append(simulated.fixtures, fixture)
+			}
+		}
+		result[key] = simulated
+	}
+	return result
+}
+
+// matchFixture returns the first fixture whose input is the same as the
+// input of the call, or the first fixture without input
+func matchFixture(fixtures []schema.ToolFixture, input json.RawMessage) *schema.ToolFixture {
+	var wildcard *schema.ToolFixture
+	normalized, ok := normalizeJSON(input)
+	for i := range fixtures {
+		fixture := &fixtures[i]
+		if len(bytes.TrimSpace(fixture.Input)) == 0 {
+			if wildcard == nil {
+				wildcard = fixture
+			}
+		} else if other, valid := normalizeJSON(fixture.Input); ok && valid && other == normalized {
+			return fixture
+		}
+	}
+	return wildcard
+}
+
+// imagine asks the model for a plausible result for the call
+func (t *simulatedTool) imagine(ctx context.Context, input json.RawMessage) (any, error) {
+	var output string
+	if s := t.OutputSchema(); s != nil {
+		if data, err := json.Marshal(s); err == nil {
+			output = fmt.Sprintf("The result must match this JSON schema: %s\n", data)
+		}
+	}
+	if len(bytes.TrimSpace(input)) == 0 {
+		input = json.RawMessage("{}")
+	}
+	message, err := schema.NewMessage(schema.RoleUser, fmt.Sprintf(simulationPrompt, t.Name(), t.Description(), output, input))
+	if err != nil {
+		return nil, err
+	}
+	reply, _, err := t.generator.WithoutSession(ctx, t.model, message)
+	if err != nil {
+		return nil, fmt.Errorf("simulate tool %q: %w", t.Name(), err)
+	}
+
+	// Use the reply as JSON when it is, or otherwise as a string
+	text := strings.TrimSpace(reply.Text())
+	text = strings.TrimSpace(strings.TrimSuffix(strings.TrimPrefix(strings.TrimPrefix(text, "```json"), "```"), "```"))
+	if json.Valid([]byte(text)) {
+		return simulatedResult{value: json.RawMessage(text), source: simulatedModel}, nil
+	}
+	data, err := json.Marshal(text)
+	if err != nil {
+		return nil, err
+	}
+	return simulatedResult{value: data, source: simulatedModel}, nil
+}
+
+// normalizeJSON returns JSON with object keys sorted and without white space,
+// or false if the data is not valid JSON. Empty data is an empty object.
+func normalizeJSON(data json.RawMessage) (string, bool) {
+	var value any
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 {
+		decoder := json.NewDecoder(bytes.NewReader(trimmed))
+		decoder.UseNumber()
+		if err := decoder.Decode(&value); err != nil || decoder.More() {
+			return "", false
+		}
+	}
+	if value == nil {
+		value = map[string]any{}
+	}
+	normalized, err := json.Marshal(value)
+	if err != nil {
+		return "", false
+	}
+	return string(normalized), true
+}
@@ -0,0 +1,124 @@
+package manager
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	// Packages
+	llm "github.com/mutablelogic/go-llm"
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	opt "github.com/mutablelogic/go-llm/pkg/opt"
+	jsonschema "github.com/mutablelogic/go-server/pkg/jsonschema"
+	assert "github.com/stretchr/testify/assert"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// HELPERS
+
+// simulateTestTool fails the test if it is run
+type simulateTestTool struct {
+	t *testing.T
+}
+
+func (s *simulateTestTool) Name() string                     { return "weather" }
+func (s *simulateTestTool) Description() string              { return "Return the weather for a city" }
+func (s *simulateTestTool) InputSchema() *jsonschema.Schema  { return nil }
+func (s *simulateTestTool) OutputSchema() *jsonschema.Schema { return nil }
+func (s *simulateTestTool) Meta() llm.ToolMeta               { return llm.ToolMeta{} }
+func (s *simulateTestTool) Run(context.Context, json.RawMessage) (any, error) {
+	s.t.Error("tool was run in a simulation")
+	return nil, nil
+}
+
+// simulateTestGenerator replies with fixed text, and records the prompt
+type simulateTestGenerator struct {
+	reply  string
+	prompt string
+}
+
+func (g *simulateTestGenerator) WithoutSession(_ context.Context, _ schema.Model, message *schema.Message, _ ...opt.Opt) (*schema.Message, *schema.UsageMeta, error) {
+	g.prompt = message.Text()
+	reply, err := schema.NewMessage(schema.RoleAssistant, g.reply)
+	return reply, nil, err
+}
+
+func (g *simulateTestGenerator) WithSession(ctx context.Context, model schema.Model, _ *schema.Conversation, message *schema.Message, opts ...opt.Opt) (*schema.Message, *schema.UsageMeta, error) {
+	return g.WithoutSession(ctx, model, message, opts...)
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// TESTS
+
+func TestToolSimulationFromContext(t *testing.T) {
+	assert := assert.New(t)
+	assert.False(toolSimulationFromContext(context.Background()))
+	assert.True(toolSimulationFromContext(WithToolSimulation(context.Background())))
+}
+
+func TestSimulatedToolFixtures(t *testing.T) {
+	assert := assert.New(t)
+	m := &Manager{manageropt: manageropt{fixtures: []schema.ToolFixture{
+		{Name: "weather", Result: `{"temperature": 20}`},
+		{Name: "builtin.weather", Input: json.RawMessage(`{"units": "metric", "city": "Berlin"}`), Result: `{"temperature": 12}`},
+		{Name: "weather", Input: json.RawMessage(`{"city": "Atlantis"}`), Error: "city not found"},
+		{Name: "search", Result: `[]`},
+	}}}
+	tools := m.simulatedTools(toolMap{"builtin__weather": withToolName(&simulateTestTool{t: t}, "builtin__weather")}, nil, schema.Model{})
+	tool := tools["builtin__weather"]
+	if !assert.IsType(&simulatedTool{}, tool) {
+		return
+	}
+	assert.Len(tool.(*simulatedTool).fixtures, 3)
+	assert.Equal("builtin__weather", tool.Name())
+
+	// The fixture with the same input is preferred, whatever the order of keys
+	result, err := tool.Run(context.Background(), json.RawMessage(`{"city":"Berlin","units":"metric"}`))
+	if assert.NoError(err) {
+		block := schema.NewToolResult("call_1", tool.Name(), result)
+		assert.JSONEq(`{"temperature": 12}`, string(block.ToolResult.Content))
+		assert.Equal("fixture", block.Annotations[schema.AnnotationSimulated])
+	}
+
+	// A fixture without input matches any other input
+	result, err = tool.Run(context.Background(), json.RawMessage(`{"city":"Paris"}`))
+	if assert.NoError(err) {
+		data, _ := json.Marshal(result)
+		assert.JSONEq(`{"temperature": 20}`, string(data))
+	}
+
+	// Fixtures can return errors
+	_, err = tool.Run(context.Background(), json.RawMessage(`{"city":"Atlantis"}`))
+	assert.EqualError(err, "city not found")
+}
+
+func TestSimulatedToolModel(t *testing.T) {
+	assert := assert.New(t)
+	m := &Manager{}
+	generator := &simulateTestGenerator{reply: "```json\n{\"temperature\": 7}\n```"}
+	tool := m.simulatedTools(toolMap{"weather": &simulateTestTool{t: t}}, generator, schema.Model{Name: "test"})["weather"]
+
+	// Results are imagined by the model when there is no fixture
+	result, err := tool.Run(context.Background(), json.RawMessage(`{"city":"Oslo"}`))
+	if assert.NoError(err) {
+		block := schema.NewToolResult("call_1", "weather", result)
+		assert.JSONEq(`{"temperature": 7}`, string(block.ToolResult.Content))
+		assert.Equal("model", block.Annotations[schema.AnnotationSimulated])
+	}
+	assert.True(strings.Contains(generator.prompt, `"weather"`))
+	assert.True(strings.Contains(generator.prompt, `{"city":"Oslo"}`))
+
+	// Text which is not JSON is returned as a string
+	generator.reply = "Sunny"
+	result, err = tool.Run(context.Background(), nil)
+	if assert.NoError(err) {
+		data, _ := json.Marshal(result)
+		assert.Equal(`"Sunny"`, string(data))
+	}
+
+	// Without a generator, calls without a fixture fail
+	tool = m.simulatedTools(toolMap{"weather": &simulateTestTool{t: t}}, nil, schema.Model{})["weather"]
+	_, err = tool.Run(context.Background(), nil)
+	assert.ErrorIs(err, schema.ErrNotFound)
+}
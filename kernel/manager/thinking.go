@@ -0,0 +1,165 @@
+package manager
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	// Packages
+	llm "github.com/mutablelogic/go-llm"
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	opt "github.com/mutablelogic/go-llm/pkg/opt"
+	types "github.com/mutablelogic/go-server/pkg/types"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// thinkingGenerator limits the time a model spends thinking. The reply is
+// streamed, and when the model is still thinking at the deadline the request
+// is cancelled, and the answer is requested without thinking, with the
+// thinking so far included in the request.
+type thinkingGenerator struct {
+	llm.Generator
+	timeout  time.Duration
+	thinking []opt.Opt // options which enable thinking for the provider
+}
+
+var _ llm.Generator = (*thinkingGenerator)(nil)
+
+// generateFn makes a request to the generator which is wrapped
+type generateFn func(context.Context, *schema.Message, ...opt.Opt) (*schema.Message, *schema.UsageMeta, error)
+
+///////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+// Providers which stream thinking as it is generated, so that thinking can be
+// stopped at the deadline. For other providers the thinking budget is reduced
+// to what can be generated before the deadline.
+var thinkingStreamProviders = map[string]bool{
+	schema.Anthropic: true,
+	schema.Gemini:    true,
+	schema.Ollama:    true,
+	schema.Eliza:     true,
+}
+
+// The number of thinking tokens which are assumed to be generated each second
+const thinkingTokensPerSecond = 50
+
+const thinkingCutPrompt = `You have already spent %v thinking about this, which is all the time available. Your thinking so far was:
+
+%s
+
+Do not think any further. Give your final answer now.`
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+func (g *thinkingGenerator) WithoutSession(ctx context.Context, model schema.Model, message *schema.Message, opts ...opt.Opt) (*schema.Message, *schema.UsageMeta, error) {
+	return g.generate(ctx, message, opts, func(ctx context.Context, message *schema.Message, opts ...opt.Opt) (*schema.Message, *schema.UsageMeta, error) {
+		return g.Generator.WithoutSession(ctx, model, message, opts...)
+	})
+}
+
+func (g *thinkingGenerator) WithSession(ctx context.Context, model schema.Model, conversation *schema.Conversation, message *schema.Message, opts ...opt.Opt) (*schema.Message, *schema.UsageMeta, error) {
+	if conversation == nil {
+		conversation = new(schema.Conversation)
+	}
+	start := conversation.Len()
+	reply, usage, err := g.generate(ctx, message, opts, func(ctx context.Context, message *schema.Message, opts ...opt.Opt) (*schema.Message, *schema.UsageMeta, error) {
+		// Remove the message appended by a request which was cancelled
+		*conversation = (*conversation)[:start]
+		return g.Generator.WithSession(ctx, model, conversation, message, opts...)
+	})
+
+	// Keep the message in the conversation, rather than the request for the
+	// answer which includes the thinking so far
+	if err == nil && reply.Meta[schema.MessageMetaThinkingCut] != nil && conversation.Len() > start {
+		(*conversation)[start].Content = message.Content
+	}
+	return reply, usage, err
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// generate makes the request with thinking, and when the model is still
+// thinking at the deadline, makes the request again without thinking
+func (g *thinkingGenerator) generate(ctx context.Context, message *schema.Message, opts []opt.Opt, fn generateFn) (*schema.Message, *schema.UsageMeta, error) {
+	o, err := opt.Apply(opts...)
+	if err != nil {
+		return nil, nil, err
+	}
+	streamFn := o.GetStream()
+
+	// Cancel the request when the model has not started to answer by the deadline
+	var mu sync.Mutex
+	var thought strings.Builder
+	answering, cut := false, false
+	thinkingCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	timer := time.AfterFunc(g.timeout, func() {
+		mu.Lock()
+		defer mu.Unlock()
+		if !answering {
+			cut = true
+			cancel()
+		}
+	})
+
+	// Stream the reply, keeping the thinking so far
+	reply, usage, err := fn(thinkingCtx, message, slices.Concat(opts, g.thinking, []opt.Opt{opt.WithStream(func(role, text string) {
+		mu.Lock()
+		if cut {
+			mu.Unlock()
+			return
+		}
+		if role == schema.RoleThinking {
+			thought.WriteString(text)
+		} else {
+			answering = true
+		}
+		mu.Unlock()
+		if streamFn != nil {
+			streamFn(role, text)
+		}
+	})})...)
+	timer.Stop()
+
+	// Return the reply unless thinking was cut short
+	mu.Lock()
+	cut, text := cut && err != nil, strings.TrimSpace(thought.String())
+	mu.Unlock()
+	if !cut || ctx.Err() != nil {
+		return reply, usage, err
+	}
+
+	// Request the answer without thinking
+	if text == "" {
+		text = "(no thinking was received)"
+	}
+	request := *message
+	request.Content = append(slices.Clone(message.Content), schema.ContentBlock{Text: types.Ptr(fmt.Sprintf(thinkingCutPrompt, g.timeout, text))})
+	reply, usage, err = fn(ctx, &request, opts...)
+	if err != nil {
+		return nil, nil, err
+	}
+	if reply.Meta == nil {
+		reply.Meta = make(map[string]any)
+	}
+	reply.Meta[schema.MessageMetaThinkingCut] = uint(g.timeout / time.Second)
+	return reply, usage, nil
+}
+
+// thinkingBudget returns the thinking budget for a deadline, which is no
+// more than the budget which was set
+func thinkingBudget(timeout time.Duration, budget uint) uint {
+	limit := max(uint(timeout.Seconds()*thinkingTokensPerSecond), 1)
+	if budget == 0 {
+		return limit
+	}
+	return min(budget, limit)
+}
@@ -0,0 +1,141 @@
+package manager
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	// Packages
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	opt "github.com/mutablelogic/go-llm/pkg/opt"
+	types "github.com/mutablelogic/go-server/pkg/types"
+	assert "github.com/stretchr/testify/assert"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// HELPERS
+
+// thinkingTestGenerator thinks until the context is cancelled, or answers
+// straight away when thinking is not enabled
+type thinkingTestGenerator struct {
+	think   time.Duration // time spent thinking before answering, or zero to think forever
+	prompts []string
+}
+
+func (g *thinkingTestGenerator) WithoutSession(ctx context.Context, _ schema.Model, message *schema.Message, opts ...opt.Opt) (*schema.Message, *schema.UsageMeta, error) {
+	g.prompts = append(g.prompts, message.Text())
+	o, err := opt.Apply(opts...)
+	if err != nil {
+		return nil, nil, err
+	}
+	stream := o.GetStream()
+	if o.GetBool(opt.ThinkingKey) {
+		start := time.Now()
+		for g.think == 0 || time.Since(start) < g.think {
+			select {
+			case <-ctx.Done():
+				return nil, nil, ctx.Err()
+			case <-time.After(5 * time.Millisecond):
+				stream(schema.RoleThinking, "hmm ")
+			}
+		}
+	}
+	if stream != nil {
+		stream(schema.RoleAssistant, "42")
+	}
+	reply, err := schema.NewMessage(schema.RoleAssistant, "42")
+	return reply, nil, err
+}
+
+func (g *thinkingTestGenerator) WithSession(ctx context.Context, model schema.Model, conversation *schema.Conversation, message *schema.Message, opts ...opt.Opt) (*schema.Message, *schema.UsageMeta, error) {
+	conversation.Append(*message)
+	reply, usage, err := g.WithoutSession(ctx, model, message, opts...)
+	if err == nil {
+		conversation.Append(*reply)
+	}
+	return reply, usage, err
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// TESTS
+
+func TestThinkingGeneratorCut(t *testing.T) {
+	assert := assert.New(t)
+	inner := &thinkingTestGenerator{}
+	generator := &thinkingGenerator{Generator: inner, timeout: 50 * time.Millisecond, thinking: []opt.Opt{opt.SetBool(opt.ThinkingKey, true)}}
+	message, err := schema.NewMessage(schema.RoleUser, "What is the answer?")
+	if !assert.NoError(err) {
+		return
+	}
+
+	var streamed strings.Builder
+	var conversation schema.Conversation
+	reply, _, err := generator.WithSession(context.Background(), schema.Model{}, &conversation, message, opt.WithStream(func(role, text string) {
+		streamed.WriteString(role + ":" + text + " ")
+	}))
+	if !assert.NoError(err) {
+		return
+	}
+	assert.Equal("42", reply.Text())
+	assert.Equal(uint(0), reply.Meta[schema.MessageMetaThinkingCut])
+
+	// The thinking so far is streamed, and included in the request for the answer
+	assert.Contains(streamed.String(), "thinking:hmm")
+	assert.Contains(streamed.String(), "assistant:42")
+	if assert.Len(inner.prompts, 2) {
+		assert.Contains(inner.prompts[1], "hmm")
+		assert.Contains(inner.prompts[1], "Give your final answer now.")
+	}
+
+	// The conversation has the original message and the answer
+	if assert.Len(conversation, 2) {
+		assert.Equal("What is the answer?", conversation[0].Text())
+		assert.Equal("42", conversation[1].Text())
+	}
+}
+
+func TestThinkingGeneratorInTime(t *testing.T) {
+	assert := assert.New(t)
+	inner := &thinkingTestGenerator{think: 10 * time.Millisecond}
+	generator := &thinkingGenerator{Generator: inner, timeout: time.Second, thinking: []opt.Opt{opt.SetBool(opt.ThinkingKey, true)}}
+	message, err := schema.NewMessage(schema.RoleUser, "What is the answer?")
+	if !assert.NoError(err) {
+		return
+	}
+
+	reply, _, err := generator.WithoutSession(context.Background(), schema.Model{}, message)
+	if assert.NoError(err) {
+		assert.Equal("42", reply.Text())
+		assert.Nil(reply.Meta[schema.MessageMetaThinkingCut])
+	}
+	assert.Len(inner.prompts, 1)
+}
+
+func TestThinkingBudget(t *testing.T) {
+	assert := assert.New(t)
+	assert.Equal(uint(1500), thinkingBudget(30*time.Second, 0))
+	assert.Equal(uint(1024), thinkingBudget(30*time.Second, 1024))
+	assert.Equal(uint(1500), thinkingBudget(30*time.Second, 8192))
+	assert.Equal(uint(1), thinkingBudget(time.Millisecond, 0))
+}
+
+func TestMetaOptsThinkingTime(t *testing.T) {
+	assert := assert.New(t)
+	m := &Manager{manageropt: manageropt{unsupported: OptionPolicyError}}
+	meta := schema.GeneratorMeta{ThinkingBudget: types.Ptr(uint(4096)), ThinkingTime: types.Ptr(uint(30))}
+
+	// Thinking is stopped at the deadline for providers which stream it
+	opts, generator := m.metaOpts(schema.Anthropic, &thinkingTestGenerator{}, meta, generationContextChat)
+	assert.Empty(opts)
+	if assert.IsType(&thinkingGenerator{}, generator) {
+		assert.Equal(30*time.Second, generator.(*thinkingGenerator).timeout)
+		assert.NotEmpty(generator.(*thinkingGenerator).thinking)
+	}
+
+	// Without a deadline, the thinking option is returned
+	meta.ThinkingTime = nil
+	opts, generator = m.metaOpts(schema.Anthropic, &thinkingTestGenerator{}, meta, generationContextChat)
+	assert.Len(opts, 1)
+	assert.IsType(&thinkingTestGenerator{}, generator)
+}
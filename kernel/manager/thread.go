@@ -0,0 +1,84 @@
+package manager
+
+import (
+	"context"
+	"slices"
+
+	// Packages
+	uuid "github.com/google/uuid"
+	auth "github.com/mutablelogic/go-auth/auth/schema"
+	otel "github.com/mutablelogic/go-client/pkg/otel"
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	types "github.com/mutablelogic/go-server/pkg/types"
+	attribute "go.opentelemetry.io/otel/attribute"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// CreateThread starts a side thread on a message of a session. The thread is
+// a child session which continues from the message: the history of the
+// parent session up to the message is sent with each turn of the thread, but
+// the messages of the thread are not added to the parent session. If user is
+// non-nil, the session must be owned by that user.
+func (m *Manager) CreateThread(ctx context.Context, session uuid.UUID, message uint64, meta schema.SessionMeta, user *auth.UserInfo) (*schema.Session, error) {
+	if message == 0 {
+		return nil, schema.ErrBadParameter.With("message id is required")
+	}
+	return m.CreateSession(ctx, schema.SessionInsert{
+		Parent:        session,
+		ParentMessage: message,
+		SessionMeta:   meta,
+	}, user)
+}
+
+// ListThreads returns the side threads on a message of a session, most
+// recently active first. If user is non-nil, the session must be owned by
+// that user.
+func (m *Manager) ListThreads(ctx context.Context, session uuid.UUID, message uint64, req schema.ThreadListRequest, user *auth.UserInfo) (_ *schema.SessionList, err error) {
+	// OTel span
+	ctx, endSpan := otel.StartSpan(m.tracer, ctx, "ListThreads",
+		attribute.String("session", session.String()),
+		attribute.Int64("message", int64(message)),
+		attribute.String("user", types.Stringify(user)),
+	)
+	defer func() { endSpan(err) }()
+
+	// Check the session exists and is owned by the user
+	if _, err := m.GetSession(ctx, session, user); err != nil {
+		return nil, err
+	}
+
+	return m.ListSessions(ctx, schema.SessionListRequest{
+		OffsetLimit:   req.OffsetLimit,
+		Parent:        types.Ptr(session),
+		ParentMessage: types.Ptr(message),
+	}, user)
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// threadHistory returns the messages of the parent session of a side thread,
+// up to and including the message the thread continues from. Pending input
+// in the parent session is not included. When the parent session is itself
+// a side thread, its history is included first.
+func (m *Manager) threadHistory(ctx context.Context, thread *schema.Session, user *auth.UserInfo) (schema.Conversation, error) {
+	if thread.Parent == uuid.Nil || thread.ParentMessage == 0 {
+		return nil, nil
+	}
+	parent, err := m.GetSession(ctx, thread.Parent, user)
+	if err != nil {
+		return nil, err
+	}
+	prefix, err := m.threadHistory(ctx, parent, user)
+	if err != nil {
+		return nil, err
+	}
+	history, err := m.conversationUntil(ctx, thread.Parent, thread.ParentMessage, user)
+	if err != nil {
+		return nil, err
+	}
+	history, _ = history.SplitPending()
+	return slices.Concat(prefix, history), nil
+}
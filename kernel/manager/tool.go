@@ -3,6 +3,7 @@ package manager
 import (
 	"context"
 	"encoding/json"
+	"io"
 	"slices"
 
 	// Packages
@@ -10,8 +11,10 @@ import (
 	otel "github.com/mutablelogic/go-client/pkg/otel"
 	llm "github.com/mutablelogic/go-llm"
 	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	tooldoc "github.com/mutablelogic/go-llm/pkg/tooldoc"
 	toolkit "github.com/mutablelogic/go-llm/toolkit"
 	resource "github.com/mutablelogic/go-llm/toolkit/resource"
+	tooltest "github.com/mutablelogic/go-llm/toolkit/tooltest"
 	types "github.com/mutablelogic/go-server/pkg/types"
 	attribute "go.opentelemetry.io/otel/attribute"
 )
@@ -61,22 +64,13 @@ func (m *Manager) GetTool(ctx context.Context, name string, user *auth.UserInfo)
 	)
 	defer func() { endSpan(err) }()
 
-	// Filter tools by name, which may return multiple matches if the name is not fully qualified with a namespace.
-	tools, _, err := m.listTools(ctx, schema.ToolListRequest{Name: []string{name}}, user)
+	tool, err := m.getTool(ctx, name, user)
 	if err != nil {
 		return nil, err
 	}
 
-	// Return errors when number of matches is not exactly 1
-	if len(tools) == 0 {
-		return nil, schema.ErrNotFound.Withf("tool %q", name)
-	}
-	if len(tools) > 1 {
-		return nil, schema.ErrConflict.Withf("multiple tools matched %q; specify a fully-qualified tool name", name)
-	}
-
 	// Convert to response format
-	meta, err := newToolMeta(tools[0])
+	meta, err := newToolMeta(tool)
 	if err != nil {
 		return nil, err
 	}
@@ -95,17 +89,10 @@ func (m *Manager) CallTool(ctx context.Context, name string, req schema.CallTool
 	)
 	defer func() { endSpan(err) }()
 
-	// Filter tools by name, which may return multiple matches if the name is not fully qualified with a namespace.
-	tools, _, err := m.listTools(ctx, schema.ToolListRequest{Name: []string{name}}, user)
+	tool, err := m.getTool(ctx, name, user)
 	if err != nil {
 		return nil, err
 	}
-	if len(tools) == 0 {
-		return nil, schema.ErrNotFound.Withf("tool %q", name)
-	}
-	if len(tools) > 1 {
-		return nil, schema.ErrConflict.Withf("multiple tools matched %q; specify a fully-qualified tool name", name)
-	}
 
 	// Append the json input as a resource if provided, and call the tool
 	var resources []llm.Resource
@@ -119,12 +106,77 @@ func (m *Manager) CallTool(ctx context.Context, name string, req schema.CallTool
 
 	// Call the tool and return the resource directly so transport layers can
 	// preserve the original content type and payload.
-	return m.Toolkit.Call(ctx, tools[0], resources...)
+	return m.Toolkit.Call(ctx, tool, resources...)
+}
+
+// ToolDocs writes documentation for the tools in the user's accessible
+// namespaces to w, in the requested format.
+func (m *Manager) ToolDocs(ctx context.Context, req schema.ToolDocsRequest, w io.Writer, user *auth.UserInfo) (err error) {
+	// Otel span
+	ctx, endSpan := otel.StartSpan(m.tracer, ctx, "ToolDocs",
+		attribute.String("request", req.String()),
+		attribute.String("user", types.Stringify(user)),
+	)
+	defer func() { endSpan(err) }()
+
+	tools, _, err := m.listTools(ctx, schema.ToolListRequest{Namespace: req.Namespace}, user)
+	if err != nil {
+		return err
+	}
+	metas := make([]*schema.ToolMeta, 0, len(tools))
+	for _, tool := range tools {
+		meta, err := newToolMeta(tool)
+		if err != nil {
+			return err
+		}
+		metas = append(metas, &meta)
+	}
+
+	// Write the documentation
+	return tooldoc.Write(req.Format, w, metas)
+}
+
+// TestTool runs a tool by name outside of a chat, scoped by the user's
+// accessible namespaces, and returns a report of the call. The input and
+// output are validated against the tool schemas, and a failure is recorded
+// in the report rather than returned as an error.
+func (m *Manager) TestTool(ctx context.Context, name string, req schema.CallToolRequest, user *auth.UserInfo) (result *schema.ToolTestReport, err error) {
+	// Otel span
+	ctx, endSpan := otel.StartSpan(m.tracer, ctx, "TestTool",
+		attribute.String("name", name),
+		attribute.String("request", req.String()),
+		attribute.String("user", types.Stringify(user)),
+	)
+	defer func() { endSpan(err) }()
+
+	tool, err := m.getTool(ctx, name, user)
+	if err != nil {
+		return nil, err
+	}
+
+	// Return the report
+	return types.Ptr(tooltest.Check(ctx, tool, req.Input)), nil
 }
 
 ///////////////////////////////////////////////////////////////////////////////
 // PRIVATE METHODS
 
+// getTool returns a single tool by name. The name may match multiple tools
+// if it is not fully qualified with a namespace, which is a conflict.
+func (m *Manager) getTool(ctx context.Context, name string, user *auth.UserInfo) (llm.Tool, error) {
+	tools, _, err := m.listTools(ctx, schema.ToolListRequest{Name: []string{name}}, user)
+	if err != nil {
+		return nil, err
+	}
+	if len(tools) == 0 {
+		return nil, schema.ErrNotFound.Withf("tool %q", name)
+	}
+	if len(tools) > 1 {
+		return nil, schema.ErrConflict.Withf("multiple tools matched %q; specify a fully-qualified tool name", name)
+	}
+	return tools[0], nil
+}
+
 func (m *Manager) listTools(ctx context.Context, req schema.ToolListRequest, user *auth.UserInfo) ([]llm.Tool, uint, error) {
 	var namespaces []string
 	if user == nil {
@@ -0,0 +1,171 @@
+package manager
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+
+	// Packages
+	auth "github.com/mutablelogic/go-auth/auth/schema"
+	llm "github.com/mutablelogic/go-llm"
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	attribute "go.opentelemetry.io/otel/attribute"
+	trace "go.opentelemetry.io/otel/trace"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// toolSelector offers the model only the tools whose descriptions are
+// closest to the text of a turn, when more tools are available than the limit
+type toolSelector struct {
+	provider string             // provider of the embedding model, or empty
+	model    string             // embedding model
+	limit    uint               // maximum number of tools selected by relevance
+	always   *schema.ToolFilter // tools which are always offered, or nil
+
+	sync.Mutex
+	vectors map[string][]float64 // normalized embedding of each tool description
+}
+
+// toolEmbedFn returns the embedding of each input
+type toolEmbedFn func(ctx context.Context, input []string) ([][]float64, error)
+
+///////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+const (
+	toolSelectDescriptionLength = 2000 // maximum characters of a tool description which are embedded
+	toolSelectTextLength        = 4000 // maximum characters of the turn which are embedded
+	toolSelectCacheSize         = 4096 // maximum number of cached tool embeddings
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// selectTools returns the tools which are offered to the model for a turn.
+// All tools are returned when selection is not enabled, there are no more
+// tools than the limit or the turn has no text. When the embeddings cannot
+// be made, all tools are returned with a warning.
+func (m *Manager) selectTools(ctx context.Context, tools toolMap, text string, user *auth.UserInfo) (toolMap, []string, error) {
+	s := m.toolselect
+	if s == nil || uint(len(tools)) <= s.limit || strings.TrimSpace(text) == "" {
+		return tools, nil, nil
+	}
+	selected, err := s.selectTools(ctx, tools, text, func(ctx context.Context, input []string) ([][]float64, error) {
+		response, err := m.Embedding(ctx, schema.EmbeddingRequest{
+			Provider: s.provider,
+			Model:    s.model,
+			Input:    input,
+		}, user)
+		if err != nil {
+			return nil, err
+		} else if len(response.Output) != len(input) {
+			return nil, schema.ErrInternalServerError.Withf("expected %d embeddings, got %d", len(input), len(response.Output))
+		}
+		return response.Output, nil
+	})
+	if ctx.Err() != nil {
+		return nil, nil, ctx.Err()
+	} else if err != nil {
+		return tools, []string{fmt.Sprintf("tool selection: %v", err)}, nil
+	}
+	trace.SpanFromContext(ctx).SetAttributes(
+		attribute.Int("tools.available", len(tools)),
+		attribute.Int("tools.offered", len(selected)),
+	)
+	return selected, nil, nil
+}
+
+// selectTools returns the tools which match the always patterns, and the
+// tools with the descriptions most similar to the text, up to the limit
+func (s *toolSelector) selectTools(ctx context.Context, tools toolMap, text string, embed toolEmbedFn) (toolMap, error) {
+	names := slices.Sorted(maps.Keys(tools))
+	vectors, err := s.embeddings(ctx, tools, names, embed)
+	if err != nil {
+		return nil, err
+	}
+	output, err := embed(ctx, []string{truncateRunes(text, toolSelectTextLength)})
+	if err != nil {
+		return nil, err
+	}
+	query := topicNormalize(output[0])
+
+	// Rank the tools which are not always offered by similarity
+	type ranked struct {
+		name       string
+		similarity float64
+	}
+	result := make(toolMap, s.limit)
+	candidates := make([]ranked, 0, len(names))
+	for i, name := range names {
+		if s.always != nil && s.always.Match(strings.ReplaceAll(name, "__", ".")) {
+			result[name] = tools[name]
+			continue
+		}
+		candidates = append(candidates, ranked{name: name, similarity: topicNearest([][]float64{vectors[i]}, query).similarity})
+	}
+	slices.SortStableFunc(candidates, func(a, b ranked) int {
+		return cmp.Compare(b.similarity, a.similarity)
+	})
+	for _, candidate := range candidates[:min(len(candidates), int(s.limit))] {
+		result[candidate.name] = tools[candidate.name]
+	}
+	return result, nil
+}
+
+// embeddings returns the normalized embedding of the description of each
+// named tool, embedding those which are not cached in one request
+func (s *toolSelector) embeddings(ctx context.Context, tools toolMap, names []string, embed toolEmbedFn) ([][]float64, error) {
+	result := make([][]float64, len(names))
+	var missing []int
+	var input []string
+	s.Lock()
+	for i, name := range names {
+		key := toolSelectInput(name, tools[name])
+		if vector, exists := s.vectors[key]; exists {
+			result[i] = vector
+		} else {
+			missing, input = append(missing, i), append(input, key)
+		}
+	}
+	s.Unlock()
+	if len(missing) == 0 {
+		return result, nil
+	}
+
+	// Embed the descriptions which are not cached
+	output, err := embed(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+
+	// Cache the embeddings, starting again when the cache is full
+	s.Lock()
+	defer s.Unlock()
+	if s.vectors == nil || len(s.vectors)+len(input) > toolSelectCacheSize {
+		s.vectors = make(map[string][]float64, len(input))
+	}
+	for j, i := range missing {
+		result[i] = topicNormalize(output[j])
+		s.vectors[input[j]] = result[i]
+	}
+	return result, nil
+}
+
+// toolSelectInput returns the text which is embedded for a tool
+func toolSelectInput(name string, tool llm.Tool) string {
+	return truncateRunes(strings.ReplaceAll(name, "__", ".")+": "+strings.TrimSpace(tool.Description()), toolSelectDescriptionLength)
+}
+
+// truncateRunes returns at most n characters of the text
+func truncateRunes(text string, n int) string {
+	if runes := []rune(text); len(runes) > n {
+		return string(runes[:n])
+	}
+	return text
+}
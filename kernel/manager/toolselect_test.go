@@ -0,0 +1,99 @@
+package manager
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"slices"
+	"strings"
+	"testing"
+
+	// Packages
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	assert "github.com/stretchr/testify/assert"
+)
+
+// toolSelectEmbed embeds text by counting the words weather, file and mail
+func toolSelectEmbed(calls *int) toolEmbedFn {
+	return func(_ context.Context, input []string) ([][]float64, error) {
+		*calls++
+		result := make([][]float64, 0, len(input))
+		for _, text := range input {
+			text = strings.ToLower(text)
+			result = append(result, []float64{
+				float64(strings.Count(text, "weather")),
+				float64(strings.Count(text, "file")),
+				float64(strings.Count(text, "mail")) + 0.01,
+			})
+		}
+		return result, nil
+	}
+}
+
+func TestToolSelect(t *testing.T) {
+	assert := assert.New(t)
+
+	tools := toolMap{
+		"weather__forecast": &listToolsMockTool{name: "weather__forecast", description: "Return the weather forecast"},
+		"fs__read":          &listToolsMockTool{name: "fs__read", description: "Read a file"},
+		"fs__write":         &listToolsMockTool{name: "fs__write", description: "Write a file"},
+		"mail__send":        &listToolsMockTool{name: "mail__send", description: "Send mail"},
+	}
+	always, err := schema.NewToolFilter("mail.*")
+	if !assert.NoError(err) {
+		return
+	}
+	s := &toolSelector{limit: 2, always: always}
+
+	var calls int
+	selected, err := s.selectTools(context.Background(), tools, "Is the weather in a file, or is the weather online?", toolSelectEmbed(&calls))
+	if assert.NoError(err) {
+		assert.Equal([]string{"fs__read", "mail__send", "weather__forecast"}, slices.Sorted(maps.Keys(selected)))
+	}
+	assert.Equal(2, calls)
+
+	// Descriptions are embedded once
+	selected, err = s.selectTools(context.Background(), tools, "Save the weather to a file", toolSelectEmbed(&calls))
+	if assert.NoError(err) {
+		assert.Len(selected, 3)
+	}
+	assert.Equal(3, calls)
+	assert.Len(s.vectors, 4)
+
+	// Errors are returned
+	_, err = (&toolSelector{limit: 2}).selectTools(context.Background(), tools, "weather", func(context.Context, []string) ([][]float64, error) {
+		return nil, errors.New("unavailable")
+	})
+	assert.Error(err)
+}
+
+func TestSelectToolsDisabled(t *testing.T) {
+	assert := assert.New(t)
+
+	m := new(Manager)
+	tools := toolMap{"fs__read": &listToolsMockTool{name: "fs__read"}}
+	selected, warnings, err := m.selectTools(context.Background(), tools, "Read the file", nil)
+	assert.NoError(err)
+	assert.Empty(warnings)
+	assert.Equal(tools, selected)
+
+	// There are no more tools than the limit
+	m.toolselect = &toolSelector{limit: 1}
+	selected, _, err = m.selectTools(context.Background(), tools, "Read the file", nil)
+	assert.NoError(err)
+	assert.Equal(tools, selected)
+}
+
+func TestWithToolSelection(t *testing.T) {
+	assert := assert.New(t)
+
+	var o manageropt
+	assert.NoError(WithToolSelection("ollama", "nomic-embed-text", 10, "memory.*")(&o))
+	if assert.NotNil(o.toolselect) {
+		assert.Equal(uint(10), o.toolselect.limit)
+		assert.True(o.toolselect.always.Match("memory.search"))
+	}
+	assert.Error(WithToolSelection("", "", 10)(&o))
+	assert.Error(WithToolSelection("", "nomic-embed-text", 0)(&o))
+	assert.Error(WithToolSelection("", "nomic-embed-text", 10, "[")(&o))
+}
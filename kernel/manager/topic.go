@@ -0,0 +1,403 @@
+package manager
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"unicode"
+
+	// Packages
+	uuid "github.com/google/uuid"
+	auth "github.com/mutablelogic/go-auth/auth/schema"
+	otel "github.com/mutablelogic/go-client/pkg/otel"
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	pg "github.com/mutablelogic/go-pg"
+	types "github.com/mutablelogic/go-server/pkg/types"
+	attribute "go.opentelemetry.io/otel/attribute"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+const (
+	topicSummaryMessages = 3    // user messages in each session summary
+	topicSummaryLength   = 2000 // maximum characters in a session summary
+	topicEmbeddingBatch  = 100  // summaries embedded in each request
+	topicIterations      = 50   // maximum iterations when clustering
+	topicKeywords        = 3    // keywords for each topic
+	topicLabelKeywords   = 2    // keywords in each topic label
+)
+
+// Words which are too common to distinguish one topic from another
+var topicStopWords = map[string]bool{
+	"about": true, "after": true, "again": true, "all": true, "also": true, "and": true, "any": true,
+	"are": true, "because": true, "been": true, "before": true, "but": true, "can": true, "could": true,
+	"did": true, "does": true, "for": true, "from": true, "get": true, "give": true, "had": true,
+	"has": true, "have": true, "hello": true, "help": true, "her": true, "here": true, "him": true,
+	"his": true, "how": true, "into": true, "its": true, "just": true, "know": true, "like": true,
+	"make": true, "me": true, "more": true, "most": true, "need": true, "not": true, "now": true,
+	"one": true, "only": true, "other": true, "our": true, "out": true, "please": true, "should": true,
+	"some": true, "tell": true, "than": true, "thank": true, "thanks": true, "that": true, "the": true,
+	"their": true, "them": true, "then": true, "there": true, "these": true, "they": true, "this": true,
+	"those": true, "use": true, "using": true, "very": true, "want": true, "was": true, "way": true,
+	"were": true, "what": true, "when": true, "where": true, "which": true, "while": true, "who": true,
+	"why": true, "will": true, "with": true, "would": true, "you": true, "your": true,
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// Topics groups the sessions which match the request into topics. Each
+// session is summarised by its title and first user messages, the summaries
+// are embedded with the requested model and clustered, and each topic is
+// labelled with the keywords which distinguish it from the other topics.
+// When requested, each session is tagged with its topic. If user is non-nil,
+// only sessions owned by that user are analysed.
+func (m *Manager) Topics(ctx context.Context, req schema.TopicRequest, user *auth.UserInfo) (_ *schema.TopicReport, err error) {
+	// OTel span
+	ctx, endSpan := otel.StartSpan(m.tracer, ctx, "Topics",
+		attribute.String("req", req.String()),
+		attribute.String("user", types.Stringify(user)),
+	)
+	defer func() { endSpan(err) }()
+
+	// Check the request
+	req.Model = strings.TrimSpace(req.Model)
+	if req.Model == "" {
+		return nil, schema.ErrBadParameter.With("an embedding model is required")
+	}
+	if req.Topics == 0 {
+		req.Topics = schema.DefaultTopics
+	}
+
+	// Summarise the sessions, skipping those without any text
+	sessions, err := m.topicSessions(ctx, req.Tags, user)
+	if err != nil {
+		return nil, err
+	}
+	summaries := make([]string, 0, len(sessions))
+	for _, session := range sessions {
+		summary, err := m.topicSummary(ctx, session)
+		if err != nil {
+			return nil, err
+		} else if summary != "" {
+			sessions[len(summaries)] = session
+			summaries = append(summaries, summary)
+		}
+	}
+	sessions = sessions[:len(summaries)]
+
+	report := schema.TopicReport{TopicRequest: req, Sessions: uint(len(sessions)), Topics: []schema.Topic{}}
+	if len(sessions) == 0 {
+		return types.Ptr(report), nil
+	}
+
+	// Embed and cluster the summaries
+	vectors, usage, err := m.topicEmbeddings(ctx, req, summaries, user)
+	if err != nil {
+		return nil, err
+	}
+	report.Usage = usage
+	clusters := topicClusters(vectors, int(req.Topics))
+	keywords := topicClusterKeywords(summaries, clusters)
+
+	// Gather the sessions for each topic
+	report.Topics = make([]schema.Topic, len(keywords))
+	for i := range report.Topics {
+		report.Topics[i] = schema.Topic{Label: topicLabel(keywords[i], i), Keywords: keywords[i], Sessions: []uuid.UUID{}}
+	}
+	for i, cluster := range clusters {
+		report.Topics[cluster].Sessions = append(report.Topics[cluster].Sessions, sessions[i].ID)
+	}
+	topicUniqueLabels(report.Topics)
+
+	// Tag each session with its topic
+	if req.Apply {
+		for i, session := range sessions {
+			if err := m.applyTopic(ctx, session, report.Topics[clusters[i]].Tag(), user); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// Return the largest topics first
+	slices.SortStableFunc(report.Topics, func(a, b schema.Topic) int {
+		return cmp.Or(cmp.Compare(len(b.Sessions), len(a.Sessions)), strings.Compare(a.Label, b.Label))
+	})
+
+	// Return success
+	return types.Ptr(report), nil
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// topicSessions returns the sessions with all of the tags, up to the maximum
+// number which are analysed
+func (m *Manager) topicSessions(ctx context.Context, tags []string, user *auth.UserInfo) ([]*schema.Session, error) {
+	var sessions []*schema.Session
+	for uint64(len(sessions)) < schema.TopicSessionMax {
+		result, err := m.ListSessions(ctx, schema.SessionListRequest{
+			OffsetLimit: pg.OffsetLimit{
+				Offset: uint64(len(sessions)),
+				Limit:  types.Ptr(min(schema.SessionListMax, schema.TopicSessionMax-uint64(len(sessions)))),
+			},
+			Tags: tags,
+		}, user)
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, result.Body...)
+		if len(result.Body) == 0 || uint64(len(sessions)) >= uint64(result.Count) {
+			break
+		}
+	}
+	return sessions, nil
+}
+
+// topicSummary returns the title and first user messages of a session
+func (m *Manager) topicSummary(ctx context.Context, session *schema.Session) (string, error) {
+	messages, err := m.listSessionMessages(ctx, schema.MessageListRequest{
+		OffsetLimit: pg.OffsetLimit{Limit: types.Ptr(uint64(topicSummaryMessages))},
+		Sessions:    []uuid.UUID{session.ID},
+		Role:        schema.RoleUser,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	parts := make([]string, 0, len(messages.Body)+1)
+	if title := strings.TrimSpace(types.Value(session.Title)); title != "" {
+		parts = append(parts, title)
+	}
+	for _, message := range messages.Body {
+		if text := strings.TrimSpace(message.Text()); text != "" {
+			parts = append(parts, text)
+		}
+	}
+
+	summary := []rune(strings.Join(parts, "\n"))
+	return string(summary[:min(len(summary), topicSummaryLength)]), nil
+}
+
+// topicEmbeddings embeds the summaries in batches, and returns the vectors
+// and the total token usage
+func (m *Manager) topicEmbeddings(ctx context.Context, req schema.TopicRequest, summaries []string, user *auth.UserInfo) ([][]float64, *schema.UsageMeta, error) {
+	var usage *schema.UsageMeta
+	vectors := make([][]float64, 0, len(summaries))
+	for batch := range slices.Chunk(summaries, topicEmbeddingBatch) {
+		response, err := m.Embedding(ctx, schema.EmbeddingRequest{
+			Provider: req.Provider,
+			Model:    req.Model,
+			Input:    batch,
+		}, user)
+		if err != nil {
+			return nil, nil, err
+		} else if len(response.Output) != len(batch) {
+			return nil, nil, schema.ErrInternalServerError.Withf("expected %d embeddings, got %d", len(batch), len(response.Output))
+		}
+		vectors = append(vectors, response.Output...)
+		if response.Usage != nil {
+			if usage == nil {
+				usage = new(schema.UsageMeta)
+			}
+			usage.InputTokens += response.Usage.InputTokens
+		}
+	}
+	return vectors, usage, nil
+}
+
+// applyTopic replaces any earlier topic tag of a session with the tag
+func (m *Manager) applyTopic(ctx context.Context, session *schema.Session, tag string, user *auth.UserInfo) error {
+	tags := slices.DeleteFunc(slices.Clone(session.Tags), schema.IsTopicTag)
+	if _, err := m.UpdateSession(ctx, session.ID, schema.SessionMeta{Tags: append(tags, tag)}, user); err != nil {
+		return err
+	}
+	return nil
+}
+
+// topicClusters groups the vectors into at most k clusters by cosine
+// similarity, and returns the cluster of each vector. Clusters are numbered
+// from zero without gaps. Initial centroids are chosen by taking the vector
+// furthest from those already chosen, so the result is deterministic.
+func topicClusters(vectors [][]float64, k int) []int {
+	clusters := make([]int, len(vectors))
+	if len(vectors) == 0 || k < 1 {
+		return clusters
+	}
+	points := make([][]float64, len(vectors))
+	for i, vector := range vectors {
+		points[i] = topicNormalize(vector)
+	}
+
+	// Choose the initial centroids
+	centroids := [][]float64{points[0]}
+	for len(centroids) < min(k, len(points)) {
+		furthest, distance := -1, 0.0
+		for i, point := range points {
+			if d := 1 - topicNearest(centroids, point).similarity; d > distance+1e-9 {
+				furthest, distance = i, d
+			}
+		}
+		if furthest < 0 {
+			break
+		}
+		centroids = append(centroids, points[furthest])
+	}
+
+	// Assign each point to the nearest centroid, and move each centroid to
+	// the mean of its points, until the assignments do not change
+	for iteration := range topicIterations {
+		changed := false
+		for i, point := range points {
+			if nearest := topicNearest(centroids, point).index; iteration == 0 || nearest != clusters[i] {
+				clusters[i], changed = nearest, true
+			}
+		}
+		if !changed {
+			break
+		}
+		for c := range centroids {
+			var mean []float64
+			for i, point := range points {
+				if clusters[i] != c {
+					continue
+				}
+				if mean == nil {
+					mean = make([]float64, len(point))
+				}
+				for j := range min(len(mean), len(point)) {
+					mean[j] += point[j]
+				}
+			}
+			if mean != nil {
+				centroids[c] = topicNormalize(mean)
+			}
+		}
+	}
+
+	// Number the clusters without gaps, in order of first appearance
+	index := make(map[int]int, len(centroids))
+	for i, cluster := range clusters {
+		if _, exists := index[cluster]; !exists {
+			index[cluster] = len(index)
+		}
+		clusters[i] = index[cluster]
+	}
+	return clusters
+}
+
+type topicMatch struct {
+	index      int
+	similarity float64
+}
+
+// topicNearest returns the centroid with the highest cosine similarity to
+// the normalized point
+func topicNearest(centroids [][]float64, point []float64) topicMatch {
+	nearest := topicMatch{index: 0, similarity: math.Inf(-1)}
+	for c, centroid := range centroids {
+		var similarity float64
+		for j := range min(len(centroid), len(point)) {
+			similarity += centroid[j] * point[j]
+		}
+		if similarity > nearest.similarity {
+			nearest = topicMatch{index: c, similarity: similarity}
+		}
+	}
+	return nearest
+}
+
+// topicNormalize returns a copy of the vector with unit length
+func topicNormalize(vector []float64) []float64 {
+	var norm float64
+	for _, v := range vector {
+		norm += v * v
+	}
+	result := slices.Clone(vector)
+	if norm = math.Sqrt(norm); norm > 0 {
+		for i := range result {
+			result[i] /= norm
+		}
+	}
+	return result
+}
+
+// topicClusterKeywords returns the keywords for each cluster. Words score
+// highly when they appear in many summaries of the cluster, and in few
+// summaries overall.
+func topicClusterKeywords(summaries []string, clusters []int) [][]string {
+	n := 0
+	for _, cluster := range clusters {
+		n = max(n, cluster+1)
+	}
+
+	// Count the summaries which contain each word, overall and by cluster
+	total := make(map[string]int)
+	counts := make([]map[string]int, n)
+	for c := range counts {
+		counts[c] = make(map[string]int)
+	}
+	for i, summary := range summaries {
+		for _, word := range topicWords(summary) {
+			total[word]++
+			counts[clusters[i]][word]++
+		}
+	}
+
+	keywords := make([][]string, n)
+	for c, count := range counts {
+		words := make([]string, 0, len(count))
+		scores := make(map[string]float64, len(count))
+		for word, documents := range count {
+			words = append(words, word)
+			scores[word] = float64(documents) * math.Log(1+float64(len(summaries))/float64(total[word]))
+		}
+		slices.SortFunc(words, func(a, b string) int {
+			return cmp.Or(cmp.Compare(scores[b], scores[a]), strings.Compare(a, b))
+		})
+		keywords[c] = words[:min(len(words), topicKeywords)]
+	}
+	return keywords
+}
+
+// topicWords returns the distinct words in the text which could describe a
+// topic, in order of first appearance
+func topicWords(text string) []string {
+	var words []string
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		if len([]rune(word)) < 3 || topicStopWords[word] || strings.IndexFunc(word, unicode.IsLetter) < 0 {
+			continue
+		}
+		if !slices.Contains(words, word) {
+			words = append(words, word)
+		}
+	}
+	return words
+}
+
+// topicLabel returns the label for a topic from its keywords
+func topicLabel(keywords []string, i int) string {
+	if len(keywords) == 0 {
+		return fmt.Sprintf("topic-%d", i+1)
+	}
+	return strings.Join(keywords[:min(len(keywords), topicLabelKeywords)], "-")
+}
+
+// topicUniqueLabels adds a number to labels which are used by an earlier topic
+func topicUniqueLabels(topics []schema.Topic) {
+	seen := make(map[string]bool, len(topics))
+	for i := range topics {
+		label := topics[i].Label
+		for n := 2; seen[label]; n++ {
+			label = fmt.Sprintf("%s-%d", topics[i].Label, n)
+		}
+		topics[i].Label = label
+		seen[label] = true
+	}
+}
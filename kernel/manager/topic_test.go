@@ -0,0 +1,49 @@
+package manager
+
+import (
+	"testing"
+
+	// Packages
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	assert "github.com/stretchr/testify/assert"
+)
+
+func TestTopicClusters(t *testing.T) {
+	assert := assert.New(t)
+
+	vectors := [][]float64{
+		{1, 0.1, 0}, {0, 0, 1}, {0.9, 0, 0.1}, {0.1, 0, 2}, {0, 1, 0},
+	}
+	assert.Equal([]int{0, 1, 0, 1, 2}, topicClusters(vectors, 3))
+
+	// Fewer distinct points than clusters
+	assert.Equal([]int{0, 0, 1}, topicClusters([][]float64{{1, 0}, {2, 0}, {0, 1}}, 5))
+
+	// A single cluster, and no vectors
+	assert.Equal([]int{0, 0, 0}, topicClusters(vectors[:3], 1))
+	assert.Empty(topicClusters(nil, 3))
+}
+
+func TestTopicKeywords(t *testing.T) {
+	assert := assert.New(t)
+
+	summaries := []string{
+		"What is the weather forecast for London?",
+		"Weather forecast for Paris tomorrow",
+		"Fix my Go build error",
+	}
+	keywords := topicClusterKeywords(summaries, []int{0, 0, 1})
+	assert.Equal([]string{"forecast", "weather", "london"}, keywords[0])
+	assert.Equal([]string{"build", "error", "fix"}, keywords[1])
+	assert.Equal("forecast-weather", topicLabel(keywords[0], 0))
+	assert.Equal("topic-2", topicLabel(nil, 1))
+
+	// Stop words, short words, numbers and repeated words are ignored
+	assert.Equal([]string{"weather", "h2o"}, topicWords("What is the weather in 2024? Weather, H2O"))
+
+	topics := []schema.Topic{{Label: "a"}, {Label: "a"}, {Label: "a-2"}}
+	topicUniqueLabels(topics)
+	assert.Equal("a", topics[0].Label)
+	assert.Equal("a-2", topics[1].Label)
+	assert.Equal("a-2-2", topics[2].Label)
+}
@@ -0,0 +1,265 @@
+package manager
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+
+	// Packages
+	auth "github.com/mutablelogic/go-auth/auth/schema"
+	llm "github.com/mutablelogic/go-llm"
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	opt "github.com/mutablelogic/go-llm/pkg/opt"
+	types "github.com/mutablelogic/go-server/pkg/types"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// translateGenerator lets a model work in one language with users who write
+// in another. The text of each message is translated into the language of
+// the model, and the text of the reply is translated back into the language
+// of the user. Code is not translated. In a conversation the messages are
+// kept in the language of the model, with the text in the language of the
+// user in the message meta.
+type translateGenerator struct {
+	llm.Generator
+	sync.Mutex
+	manager    *Manager
+	user       *auth.UserInfo
+	language   string               // language of the model
+	translator schema.GeneratorMeta // model which translates
+	source     string               // language of the user, once known
+}
+
+var _ llm.Generator = (*translateGenerator)(nil)
+
+// translation is the reply from the model which translates
+type translation struct {
+	Language string `json:"language"`
+	Text     string `json:"text"`
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+const translatePrompt = `Translate the text into %[1]s. Keep names of people, places, products and organisations, URLs, ` +
+	`and placeholders such as {{code1}} as they are. If the text is already in %[1]s, return it unchanged. ` +
+	`Reply with the language of the text, as its name in English, and the translation.`
+
+// Output format for the model which translates
+var translateFormat = schema.JSONSchema(`{"type":"object","properties":{` +
+	`"language":{"type":"string","description":"Language of the text, as its name in English"},` +
+	`"text":{"type":"string","description":"Translation of the text"}},"required":["language","text"]}`)
+
+// Code blocks and inline code, which are not translated
+var reTranslateCode = regexp.MustCompile("(?s)```.*?```|`[^`\n]+`")
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+func (g *translateGenerator) WithoutSession(ctx context.Context, model schema.Model, message *schema.Message, opts ...opt.Opt) (*schema.Message, *schema.UsageMeta, error) {
+	reply, usage, _, err := g.generate(ctx, message, opts, func(ctx context.Context, message *schema.Message, opts ...opt.Opt) (*schema.Message, *schema.UsageMeta, error) {
+		return g.Generator.WithoutSession(ctx, model, message, opts...)
+	})
+	return reply, usage, err
+}
+
+func (g *translateGenerator) WithSession(ctx context.Context, model schema.Model, conversation *schema.Conversation, message *schema.Message, opts ...opt.Opt) (*schema.Message, *schema.UsageMeta, error) {
+	if conversation == nil {
+		conversation = new(schema.Conversation)
+	}
+	start := conversation.Len()
+	reply, usage, original, err := g.generate(ctx, message, opts, func(ctx context.Context, message *schema.Message, opts ...opt.Opt) (*schema.Message, *schema.UsageMeta, error) {
+		return g.Generator.WithSession(ctx, model, conversation, message, opts...)
+	})
+	if err != nil || original == nil {
+		return reply, usage, err
+	}
+
+	// Keep the text in the language of the user with the messages which were
+	// appended to the conversation
+	for _, entry := range (*conversation)[start:] {
+		var text string
+		switch entry.Role {
+		case schema.RoleUser:
+			text = original.Text()
+		case schema.RoleAssistant:
+			text = reply.Text()
+		}
+		if text != "" {
+			entry.Meta = withTranslation(entry.Meta, reply.Meta[schema.MessageMetaLanguage], text)
+		}
+	}
+	return reply, usage, nil
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// translator returns a generator which translates between the language of
+// the user and the language of the model. The translator defaults to the
+// model itself.
+func (m *Manager) translator(generator llm.Generator, meta schema.GeneratorMeta, model *schema.Model, user *auth.UserInfo) llm.Generator {
+	language := strings.TrimSpace(types.Value(meta.Language))
+	if language == "" {
+		return generator
+	}
+	translator := schema.GeneratorMeta{Provider: types.Ptr(model.OwnedBy), Model: types.Ptr(model.Name)}
+	if name := strings.TrimSpace(types.Value(meta.Translator)); name != "" {
+		translator = schema.GeneratorMeta{Model: types.Ptr(name)}
+	}
+	return &translateGenerator{Generator: generator, manager: m, user: user, language: language, translator: translator}
+}
+
+// generate translates the message into the language of the model, makes the
+// request, and translates the reply back. The reply is streamed once it has
+// been translated. The message in the language of the user is returned when
+// it was translated.
+func (g *translateGenerator) generate(ctx context.Context, message *schema.Message, opts []opt.Opt, fn generateFn) (*schema.Message, *schema.UsageMeta, *schema.Message, error) {
+	o, err := opt.Apply(opts...)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	// Translate the message, keeping the language of the user for messages
+	// without text, such as tool results
+	translated, source, err := g.translateMessage(ctx, message, g.language)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	g.Lock()
+	if source != "" {
+		g.source = source
+	}
+	source = g.source
+	g.Unlock()
+	if source == "" || strings.EqualFold(source, g.language) {
+		reply, usage, err := fn(ctx, message, opts...)
+		return reply, usage, nil, err
+	}
+
+	// Stream everything except the text of the reply
+	streamFn := o.GetStream()
+	if streamFn != nil {
+		opts = append(slices.Clone(opts), opt.WithStream(func(role, text string) {
+			if role != schema.RoleAssistant {
+				streamFn(role, text)
+			}
+		}))
+	}
+	if translated != message {
+		translated.Meta = withTranslation(translated.Meta, source, "")
+	}
+	reply, usage, err := fn(ctx, translated, opts...)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	// Translate the reply back, and stream it
+	if reply, _, err = g.translateMessage(ctx, reply, source); err != nil {
+		return nil, nil, nil, err
+	}
+	reply.Meta = withTranslation(reply.Meta, source, "")
+	if text := reply.Text(); streamFn != nil && text != "" {
+		streamFn(schema.RoleAssistant, text)
+	}
+
+	// Return success
+	return reply, usage, message, nil
+}
+
+// translateMessage returns a copy of the message with the text translated
+// into the language, and the language of the first text of the message.
+// Messages without text are returned as they are.
+func (g *translateGenerator) translateMessage(ctx context.Context, message *schema.Message, language string) (*schema.Message, string, error) {
+	if message == nil || strings.TrimSpace(message.Text()) == "" {
+		return message, "", nil
+	}
+	result := types.Ptr(*message)
+	result.Content = slices.Clone(message.Content)
+	var source string
+	for i, block := range result.Content {
+		if text := types.Value(block.Text); strings.TrimSpace(text) != "" {
+			translated, err := g.translate(ctx, text, language)
+			if err != nil {
+				return nil, "", err
+			}
+			if source == "" {
+				source = translated.Language
+			}
+			result.Content[i].Text = types.Ptr(translated.Text)
+		}
+	}
+	return result, source, nil
+}
+
+// translate asks the translator to translate the text into the language. Code
+// is replaced by placeholders, which are replaced by the code again in the
+// translation.
+func (g *translateGenerator) translate(ctx context.Context, text, language string) (*translation, error) {
+	meta := g.translator
+	meta.SystemPrompt = types.Ptr(fmt.Sprintf(translatePrompt, language))
+	meta.Format = translateFormat
+	_, model, generator, opts, err := g.manager.generatorFromMeta(ctx, meta, g.user, generationContextAsk)
+	if err != nil {
+		return nil, err
+	}
+
+	// Replace the code with placeholders
+	text, restore := protectCode(text)
+	message, err := schema.NewMessage(schema.RoleUser, text)
+	if err != nil {
+		return nil, err
+	}
+
+	// Translate the text
+	reply, usage, err := generator.WithoutSession(ctx, types.Value(model), message, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if err := g.manager.generationUsage(ctx, model, usage, g.user); err != nil {
+		return nil, err
+	}
+	var result translation
+	data := strings.TrimSpace(reply.Text())
+	data = strings.TrimSpace(strings.TrimSuffix(strings.TrimPrefix(strings.TrimPrefix(data, "```json"), "```"), "```"))
+	if err := json.Unmarshal([]byte(data), &result); err != nil {
+		return nil, schema.ErrInternalServerError.Withf("translation by model %q: %v", model.Name, err)
+	}
+
+	// Replace the placeholders with the code
+	result.Text = restore.Replace(result.Text)
+	return &result, nil
+}
+
+// protectCode replaces the code blocks and inline code in the text with
+// placeholders, and returns a replacer which puts the code back
+func protectCode(text string) (string, *strings.Replacer) {
+	var code []string // pairs of placeholder and code
+	text = reTranslateCode.ReplaceAllStringFunc(text, func(match string) string {
+		placeholder := fmt.Sprintf("{{code%d}}", len(code)/2+1)
+		code = append(code, placeholder, match)
+		return placeholder
+	})
+	return text, strings.NewReplacer(code...)
+}
+
+// withTranslation returns a copy of the message meta with the language of
+// the user, and the text of the message in that language
+func withTranslation(meta map[string]any, language any, text string) map[string]any {
+	result := maps.Clone(meta)
+	if result == nil {
+		result = make(map[string]any, 2)
+	}
+	result[schema.MessageMetaLanguage] = language
+	if text != "" {
+		result[schema.MessageMetaTranslation] = text
+	}
+	return result
+}
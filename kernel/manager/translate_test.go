@@ -0,0 +1,32 @@
+package manager
+
+import (
+	"testing"
+
+	// Packages
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	assert "github.com/stretchr/testify/assert"
+)
+
+func TestProtectCode(t *testing.T) {
+	assert := assert.New(t)
+
+	text, restore := protectCode("Run `go test` with:\n\n```sh\ngo test ./...\n```\n\nor `make`.")
+	assert.Equal("Run {{code1}} with:\n\n{{code2}}\n\nor {{code3}}.", text)
+	assert.Equal("Führen Sie `go test` aus:\n\n```sh\ngo test ./...\n```\n\noder `make`.", restore.Replace("Führen Sie {{code1}} aus:\n\n{{code2}}\n\noder {{code3}}."))
+
+	// Text without code is unchanged
+	text, restore = protectCode("Hello, world")
+	assert.Equal("Hello, world", text)
+	assert.Equal("Hallo, Welt", restore.Replace("Hallo, Welt"))
+}
+
+func TestWithTranslation(t *testing.T) {
+	assert := assert.New(t)
+
+	meta := map[string]any{schema.MessageMetaPinned: true}
+	result := withTranslation(meta, "German", "Hallo")
+	assert.Equal(map[string]any{schema.MessageMetaPinned: true, schema.MessageMetaLanguage: "German", schema.MessageMetaTranslation: "Hallo"}, result)
+	assert.Len(meta, 1)
+	assert.Equal(map[string]any{schema.MessageMetaLanguage: "German"}, withTranslation(nil, "German", ""))
+}
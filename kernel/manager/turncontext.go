@@ -0,0 +1,109 @@
+package manager
+
+import (
+	"context"
+	"fmt"
+	"maps"
+
+	// Packages
+	auth "github.com/mutablelogic/go-auth/auth/schema"
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// ContextFn returns the context about the environment of a chat turn, such
+// as the current working set, the user profile or feature flags, which is
+// encoded as JSON. It returns nil when there is no context for the turn.
+type ContextFn func(ctx context.Context, session *schema.Session, user *auth.UserInfo) (any, error)
+
+// turnContext is a named source of context for chat turns
+type turnContext struct {
+	name string
+	fn   ContextFn
+}
+
+// userProfile is the context of the user who sends a turn
+type userProfile struct {
+	Name   string   `json:"name,omitempty"`
+	Email  string   `json:"email,omitempty"`
+	Groups []string `json:"groups,omitempty"`
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// UserProfileContext returns the name, email and groups of the user who sends
+// a turn, or nil when the user has none of them
+func UserProfileContext() ContextFn {
+	return func(_ context.Context, _ *schema.Session, user *auth.UserInfo) (any, error) {
+		if user == nil || (user.Name == "" && user.Email == "" && len(user.Groups) == 0) {
+			return nil, nil
+		}
+		return userProfile{Name: user.Name, Email: user.Email, Groups: user.Groups}, nil
+	}
+}
+
+// FeatureFlagsContext returns the feature flags for every turn, or nil when
+// there are no flags
+func FeatureFlagsContext(flags map[string]any) ContextFn {
+	flags = maps.Clone(flags)
+	return func(context.Context, *schema.Session, *auth.UserInfo) (any, error) {
+		if len(flags) == 0 {
+			return nil, nil
+		}
+		return flags, nil
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// turnContext returns the context blocks for a chat turn, which are sent
+// before the input of the user. A context is left out when it is the same as
+// the last context with the same name in the conversation, so that it is
+// only sent again when it changes. A context which cannot be made is left
+// out with a warning.
+func (m *Manager) turnContext(ctx context.Context, session *schema.Session, conversation schema.Conversation, user *auth.UserInfo) ([]schema.ContentBlock, []string, error) {
+	var blocks []schema.ContentBlock
+	var warnings []string
+	for _, source := range m.contexts {
+		value, err := source.fn(ctx, session, user)
+		if ctx.Err() != nil {
+			return nil, nil, ctx.Err()
+		} else if err != nil {
+			warnings = append(warnings, fmt.Sprintf("context %q: %v", source.name, err))
+			continue
+		} else if value == nil {
+			continue
+		}
+		block, err := schema.NewContext(source.name, value)
+		if err != nil {
+			warnings = append(warnings, err.Error())
+			continue
+		}
+		if last := lastContext(conversation, source.name); last != nil && last.Equal(*block.Context) {
+			continue
+		}
+		blocks = append(blocks, *block)
+	}
+	return blocks, warnings, nil
+}
+
+// lastContext returns the last context with the name in the conversation, or
+// nil when there is none
+func lastContext(conversation schema.Conversation, name string) *schema.Context {
+	for i := len(conversation) - 1; i >= 0; i-- {
+		if conversation[i] == nil {
+			continue
+		}
+		content := conversation[i].Content
+		for j := len(content) - 1; j >= 0; j-- {
+			if c := content[j].Context; c != nil && c.Name == name {
+				return c
+			}
+		}
+	}
+	return nil
+}
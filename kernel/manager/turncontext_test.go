@@ -0,0 +1,54 @@
+package manager
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	// Packages
+	auth "github.com/mutablelogic/go-auth/auth/schema"
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	assert "github.com/stretchr/testify/assert"
+)
+
+func TestTurnContext(t *testing.T) {
+	assert := assert.New(t)
+
+	m := new(Manager)
+	assert.NoError(m.apply(
+		WithTurnContext("user_profile", UserProfileContext()),
+		WithTurnContext("feature_flags", FeatureFlagsContext(map[string]any{"beta": true})),
+		WithTurnContext("broken", func(context.Context, *schema.Session, *auth.UserInfo) (any, error) {
+			return nil, errors.New("unavailable")
+		}),
+	))
+	assert.Error(m.apply(WithTurnContext("broken", UserProfileContext())))
+	assert.Error(m.apply(WithTurnContext("", UserProfileContext())))
+
+	// A user without a profile has no profile context, and a broken source
+	// is left out with a warning
+	blocks, warnings, err := m.turnContext(context.Background(), new(schema.Session), nil, new(auth.UserInfo))
+	if assert.NoError(err) && assert.Len(blocks, 1) {
+		assert.Equal("feature_flags", blocks[0].Context.Name)
+		assert.Equal([]string{`context "broken": unavailable`}, warnings)
+	}
+
+	// Context which has been sent is not sent again until it changes
+	user := &auth.UserInfo{Name: "Alice", Groups: []string{"admin"}}
+	conversation := schema.Conversation{{Role: schema.RoleUser, Content: blocks}}
+	blocks, _, err = m.turnContext(context.Background(), new(schema.Session), conversation, user)
+	if assert.NoError(err) && assert.Len(blocks, 1) {
+		assert.Equal("user_profile", blocks[0].Context.Name)
+		assert.JSONEq(`{"name":"Alice","groups":["admin"]}`, string(blocks[0].Context.Content))
+	}
+	conversation = append(conversation, &schema.Message{Role: schema.RoleUser, Content: blocks})
+	blocks, _, err = m.turnContext(context.Background(), new(schema.Session), conversation, user)
+	if assert.NoError(err) {
+		assert.Empty(blocks)
+	}
+	user.Groups = append(user.Groups, "billing")
+	blocks, _, err = m.turnContext(context.Background(), new(schema.Session), conversation, user)
+	if assert.NoError(err) && assert.Len(blocks, 1) {
+		assert.Equal("user_profile", blocks[0].Context.Name)
+	}
+}
@@ -0,0 +1,179 @@
+package manager
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	// Packages
+	llm "github.com/mutablelogic/go-llm"
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	opt "github.com/mutablelogic/go-llm/pkg/opt"
+	types "github.com/mutablelogic/go-server/pkg/types"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// OptionPolicy determines what happens when a request sets an option, such
+// as a system prompt or structured output, which the provider does not
+// support
+type OptionPolicy string
+
+// metaOpt is an option derived from generator meta fields
+type metaOpt struct {
+	name     string
+	opt      opt.Opt
+	emulate  string // instructions to the model which emulate the option, or empty
+	thinking bool   // true if the option enables thinking
+}
+
+// preambleGenerator prepends instructions to the first user message, for
+// providers which do not support them as options
+type preambleGenerator struct {
+	llm.Generator
+	preamble string
+}
+
+var _ llm.Generator = (*preambleGenerator)(nil)
+
+///////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+const (
+	OptionPolicyError   OptionPolicy = "error"   // fail the request
+	OptionPolicyWarn    OptionPolicy = "warn"    // drop the option, with a warning in the response
+	OptionPolicyEmulate OptionPolicy = "emulate" // emulate the option where possible, otherwise drop it
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+func (g *preambleGenerator) WithoutSession(ctx context.Context, model schema.Model, message *schema.Message, opts ...opt.Opt) (*schema.Message, *schema.UsageMeta, error) {
+	return g.Generator.WithoutSession(ctx, model, g.prepend(message), opts...)
+}
+
+func (g *preambleGenerator) WithSession(ctx context.Context, model schema.Model, conversation *schema.Conversation, message *schema.Message, opts ...opt.Opt) (*schema.Message, *schema.UsageMeta, error) {
+	if conversation != nil && conversation.Len() > 0 {
+		return g.Generator.WithSession(ctx, model, conversation, message, opts...)
+	}
+	return g.Generator.WithSession(ctx, model, conversation, g.prepend(message), opts...)
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// metaOpts returns the options for the meta fields, applying the policy for
+// options which the provider does not support. A generator which emulates
+// options is returned when the policy allows it.
+func (m *Manager) metaOpts(provider string, generator llm.Generator, meta schema.GeneratorMeta, context generationContext) ([]opt.Opt, llm.Generator) {
+	var candidates []metaOpt
+	if prompt := types.Value(meta.SystemPrompt); prompt != "" {
+		candidates = append(candidates, metaOpt{name: "system prompt", opt: withSystemPrompt(prompt), emulate: prompt})
+	}
+	if meta.MaxTokens != nil && *meta.MaxTokens > 0 {
+		candidates = append(candidates, metaOpt{name: "max tokens", opt: withMaxTokens(*meta.MaxTokens)})
+	}
+	if len(meta.Format) > 0 {
+		candidates = append(candidates, metaOpt{
+			name:    "structured output",
+			opt:     withJSONOutput(meta.Format),
+			emulate: "Reply with JSON only, without code fences, which matches this JSON schema:\n" + string(meta.Format),
+		})
+	}
+	timeout := time.Duration(types.Value(meta.ThinkingTime)) * time.Second
+	switch {
+	case timeout > 0 && !thinkingStreamProviders[provider] && (types.Value(meta.ThinkingBudget) > 0 || types.Value(meta.Thinking)):
+		// Thinking cannot be stopped at the deadline, so reduce the budget
+		candidates = append(candidates, metaOpt{name: "thinking budget", opt: withThinkingBudget(context, thinkingBudget(timeout, types.Value(meta.ThinkingBudget))), thinking: true})
+	case meta.ThinkingBudget != nil && *meta.ThinkingBudget > 0:
+		candidates = append(candidates, metaOpt{name: "thinking budget", opt: withThinkingBudget(context, *meta.ThinkingBudget), thinking: true})
+	case meta.Thinking != nil && *meta.Thinking:
+		candidates = append(candidates, metaOpt{name: "thinking", opt: withThinking(context), thinking: true})
+	}
+
+	var opts []opt.Opt
+	var preamble []string
+
+	// A seed is dropped whatever the policy, since the response is still
+	// useful, but is not reproducible
+	if meta.Seed != nil {
+		if o := withSeed(*meta.Seed); supportsOpt(o, provider) {
+			opts = append(opts, o)
+		} else {
+			opts = append(opts, opt.AddString(opt.WarningKey, fmt.Sprintf("seed is not supported by %q, so the response is not deterministic", provider)))
+		}
+	}
+	var thinking []opt.Opt
+	for _, candidate := range candidates {
+		switch {
+		case candidate.thinking && timeout > 0 && thinkingStreamProviders[provider] && supportsOpt(candidate.opt, provider):
+			// Thinking is enabled by the generator which stops it at the deadline
+			thinking = resolveOpt(candidate.opt, provider)
+		case m.unsupported == OptionPolicyError || supportsOpt(candidate.opt, provider):
+			opts = append(opts, candidate.opt)
+		case m.unsupported == OptionPolicyEmulate && candidate.emulate != "":
+			preamble = append(preamble, candidate.emulate)
+			opts = append(opts, opt.AddString(opt.WarningKey, fmt.Sprintf("%s is not supported by %q and was emulated", candidate.name, provider)))
+		default:
+			opts = append(opts, opt.AddString(opt.WarningKey, fmt.Sprintf("%s is not supported by %q and was ignored", candidate.name, provider)))
+		}
+	}
+	if len(preamble) > 0 {
+		generator = &preambleGenerator{Generator: generator, preamble: strings.Join(preamble, "\n\n")}
+	}
+	if len(thinking) > 0 {
+		generator = &thinkingGenerator{Generator: generator, timeout: timeout, thinking: thinking}
+	}
+	return opts, generator
+}
+
+// supportsOpt returns false if a client-aware option fails for a provider.
+// An option which is invalid for every provider is reported as supported,
+// so that the error is returned with the request.
+func supportsOpt(o opt.Opt, provider string) bool {
+	applied, err := opt.Apply(o)
+	if err != nil {
+		return true
+	}
+	resolved, err := opt.ConvertOptsForClient(applied, provider)
+	if err != nil {
+		return false
+	}
+	_, err = opt.Apply(resolved...)
+	return err == nil
+}
+
+// resolveOpt returns a client-aware option as the options for a provider
+func resolveOpt(o opt.Opt, provider string) []opt.Opt {
+	applied, err := opt.Apply(o)
+	if err != nil {
+		return []opt.Opt{o}
+	}
+	resolved, err := opt.ConvertOptsForClient(applied, provider)
+	if err != nil {
+		return []opt.Opt{o}
+	}
+	return resolved
+}
+
+// optWarnings returns the warnings which were added to options
+func optWarnings(opts []opt.Opt) []string {
+	o, err := opt.Apply(opts...)
+	if err != nil {
+		return nil
+	}
+	return o.GetStringArray(opt.WarningKey)
+}
+
+// prepend returns a copy of a user message with the preamble before the
+// content
+func (g *preambleGenerator) prepend(message *schema.Message) *schema.Message {
+	if message == nil || message.Role != schema.RoleUser {
+		return message
+	}
+	result := *message
+	result.Content = append([]schema.ContentBlock{{Text: types.Ptr(g.preamble)}}, message.Content...)
+	return &result
+}
@@ -0,0 +1,111 @@
+package manager
+
+import (
+	"context"
+	"testing"
+
+	// Packages
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	opt "github.com/mutablelogic/go-llm/pkg/opt"
+	types "github.com/mutablelogic/go-server/pkg/types"
+	assert "github.com/stretchr/testify/assert"
+)
+
+type recordingGenerator struct {
+	messages []*schema.Message
+}
+
+func (g *recordingGenerator) WithoutSession(_ context.Context, _ schema.Model, message *schema.Message, _ ...opt.Opt) (*schema.Message, *schema.UsageMeta, error) {
+	g.messages = append(g.messages, message)
+	return message, nil, nil
+}
+
+func (g *recordingGenerator) WithSession(_ context.Context, _ schema.Model, _ *schema.Conversation, message *schema.Message, _ ...opt.Opt) (*schema.Message, *schema.UsageMeta, error) {
+	g.messages = append(g.messages, message)
+	return message, nil, nil
+}
+
+func TestSupportsOpt(t *testing.T) {
+	assert.True(t, supportsOpt(withSystemPrompt("be brief"), schema.Anthropic))
+	assert.False(t, supportsOpt(withSystemPrompt("be brief"), schema.Eliza))
+	assert.False(t, supportsOpt(withThinkingBudget(generationContextAsk, 1024), schema.Eliza))
+
+	// An option which is invalid for every provider is left for the request to report
+	assert.True(t, supportsOpt(withJSONOutput(schema.JSONSchema("{")), schema.Eliza))
+}
+
+func TestMetaOptsPolicies(t *testing.T) {
+	meta := schema.GeneratorMeta{
+		SystemPrompt: types.Ptr("be brief"),
+		MaxTokens:    types.Ptr(uint(100)),
+	}
+
+	t.Run("error", func(t *testing.T) {
+		m := &Manager{manageropt: manageropt{unsupported: OptionPolicyError}}
+		generator := new(recordingGenerator)
+		opts, result := m.metaOpts(schema.Eliza, generator, meta, generationContextAsk)
+		assert.Same(t, generator, result)
+		applied, err := opt.Apply(opts...)
+		assert.NoError(t, err)
+		resolved, err := opt.ConvertOptsForClient(applied, schema.Eliza)
+		assert.NoError(t, err)
+		_, err = opt.Apply(resolved...)
+		assert.ErrorIs(t, err, schema.ErrNotImplemented)
+	})
+
+	t.Run("warn", func(t *testing.T) {
+		m := &Manager{manageropt: manageropt{unsupported: OptionPolicyWarn}}
+		generator := new(recordingGenerator)
+		opts, result := m.metaOpts(schema.Eliza, generator, meta, generationContextAsk)
+		assert.Same(t, generator, result)
+		assert.Equal(t, []string{
+			`system prompt is not supported by "eliza" and was ignored`,
+			`max tokens is not supported by "eliza" and was ignored`,
+		}, optWarnings(opts))
+	})
+
+	t.Run("emulate", func(t *testing.T) {
+		m := &Manager{manageropt: manageropt{unsupported: OptionPolicyEmulate}}
+		generator := new(recordingGenerator)
+		opts, result := m.metaOpts(schema.Eliza, generator, meta, generationContextAsk)
+		assert.Equal(t, []string{
+			`system prompt is not supported by "eliza" and was emulated`,
+			`max tokens is not supported by "eliza" and was ignored`,
+		}, optWarnings(opts))
+
+		message, err := schema.NewMessage(schema.RoleUser, "hello")
+		assert.NoError(t, err)
+		_, _, err = result.WithoutSession(context.Background(), schema.Model{}, message)
+		assert.NoError(t, err)
+		if assert.Len(t, generator.messages, 1) {
+			assert.Equal(t, "be brief", types.Value(generator.messages[0].Content[0].Text))
+			assert.Equal(t, "hello", types.Value(generator.messages[0].Content[1].Text))
+		}
+		assert.Len(t, message.Content, 1)
+
+		// Only the first message of a conversation has the preamble
+		conversation := schema.Conversation{message}
+		_, _, err = result.WithSession(context.Background(), schema.Model{}, &conversation, message)
+		assert.NoError(t, err)
+		assert.Len(t, generator.messages[1].Content, 1)
+	})
+}
+
+func TestMetaOptsSeed(t *testing.T) {
+	m := &Manager{manageropt: manageropt{unsupported: OptionPolicyError}}
+	meta := schema.GeneratorMeta{Seed: types.Ptr(uint(42))}
+
+	opts, _ := m.metaOpts(schema.Ollama, new(recordingGenerator), meta, generationContextAsk)
+	applied, err := opt.Apply(opts...)
+	assert.NoError(t, err)
+	resolved, err := opt.ConvertOptsForClient(applied, schema.Ollama)
+	assert.NoError(t, err)
+	applied, err = opt.Apply(resolved...)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(42), applied.GetUint(opt.SeedKey))
+	assert.Empty(t, optWarnings(opts))
+
+	// A provider without seeds is not an error, even with the error policy
+	opts, _ = m.metaOpts(schema.Anthropic, new(recordingGenerator), meta, generationContextAsk)
+	assert.Equal(t, []string{`seed is not supported by "anthropic", so the response is not deterministic`}, optWarnings(opts))
+}
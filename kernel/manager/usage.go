@@ -5,6 +5,8 @@ import (
 	"maps"
 
 	// Packages
+	uuid "github.com/google/uuid"
+	auth "github.com/mutablelogic/go-auth/auth/schema"
 	otel "github.com/mutablelogic/go-client/pkg/otel"
 	schema "github.com/mutablelogic/go-llm/kernel/schema"
 	pg "github.com/mutablelogic/go-pg"
@@ -38,6 +40,25 @@ func (m *Manager) CreateUsage(ctx context.Context, req schema.UsageInsert) (_ *s
 ///////////////////////////////////////////////////////////////////////////////
 // PRIVATE METHODS
 
+// generationUsage records the usage of a generation which is made on behalf
+// of a request, such as drafting or translating the reply
+func (m *Manager) generationUsage(ctx context.Context, model *schema.Model, usage *schema.UsageMeta, user *auth.UserInfo) error {
+	if usage == nil {
+		return nil
+	}
+	insert := schema.UsageInsert{
+		Type:      schema.UsageTypeAsk,
+		Model:     model.Name,
+		Provider:  types.Ptr(model.OwnedBy),
+		UsageMeta: types.Value(usage),
+	}
+	if user != nil {
+		insert.User = uuid.UUID(user.Sub)
+	}
+	_, err := m.CreateUsage(ctx, insert)
+	return err
+}
+
 // mergeUsageMeta combines usage metadata with configured provider metadata,
 // provider metadata returned on the generated message, and the current trace_id.
 // Configured provider metadata is applied first, message metadata overrides any
@@ -0,0 +1,100 @@
+package manager
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+
+	// Packages
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	opt "github.com/mutablelogic/go-llm/pkg/opt"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// usageStream estimates the usage of a generation from the text which is
+// streamed, since most providers only report the usage at the end of a
+// stream. The estimate is passed to the stream callback with the
+// schema.EventUsage role and the usage encoded as JSON.
+type usageStream struct {
+	sync.Mutex
+	fn        opt.StreamFn
+	input     uint
+	output    int
+	reasoning int
+	last      time.Time
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+// Minimum interval between usage estimates while a reply is streamed
+const usageStreamInterval = time.Second
+
+///////////////////////////////////////////////////////////////////////////////
+// LIFECYCLE
+
+// newUsageStream returns a usage estimator which passes the streamed text to
+// fn, where input is the estimated tokens of the request
+func newUsageStream(fn opt.StreamFn, input uint) *usageStream {
+	return &usageStream{fn: fn, input: input, last: time.Now()}
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// Write passes a delta to the stream callback, and follows it with the
+// estimated usage when the interval has elapsed since the last estimate
+func (s *usageStream) Write(role, text string) {
+	s.Lock()
+	defer s.Unlock()
+
+	s.fn(role, text)
+	switch role {
+	case schema.RoleAssistant:
+		s.output += len(text)
+	case schema.RoleThinking:
+		s.reasoning += len(text)
+	default:
+		return
+	}
+	if time.Since(s.last) >= usageStreamInterval {
+		s.emit(&schema.UsageMeta{Estimate: s.estimate()})
+	}
+}
+
+// Reconcile returns the usage reported by the provider with the estimate,
+// and passes it to the stream callback as the final usage of the generation
+func (s *usageStream) Reconcile(usage *schema.UsageMeta) *schema.UsageMeta {
+	s.Lock()
+	defer s.Unlock()
+
+	usage = usage.Reconcile(*s.estimate())
+	s.emit(usage)
+	return usage
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+func (s *usageStream) estimate() *schema.UsageEstimate {
+	return &schema.UsageEstimate{
+		InputTokens:     s.input,
+		OutputTokens:    estimateTextTokens(s.output + s.reasoning),
+		ReasoningTokens: estimateTextTokens(s.reasoning),
+	}
+}
+
+func (s *usageStream) emit(usage *schema.UsageMeta) {
+	s.last = time.Now()
+	if data, err := json.Marshal(usage); err == nil {
+		s.fn(schema.EventUsage, string(data))
+	}
+}
+
+// estimateTextTokens returns the tokens for text of n bytes, at about four
+// bytes per token
+func estimateTextTokens(n int) uint {
+	return uint(n+3) / 4
+}
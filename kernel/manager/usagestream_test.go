@@ -0,0 +1,42 @@
+package manager
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	// Packages
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	assert "github.com/stretchr/testify/assert"
+)
+
+func TestUsageStream(t *testing.T) {
+	assert := assert.New(t)
+
+	var roles []string
+	var usage schema.UsageMeta
+	stream := newUsageStream(func(role, text string) {
+		roles = append(roles, role)
+		if role == schema.EventUsage {
+			assert.NoError(json.Unmarshal([]byte(text), &usage))
+		}
+	}, 20)
+
+	// Deltas are passed through, and counted towards the estimate
+	stream.Write(schema.RoleThinking, strings.Repeat("x", 8))
+	stream.Write(schema.RoleAssistant, strings.Repeat("x", 16))
+	stream.Write(schema.RoleTool, "weather")
+	assert.Equal([]string{schema.RoleThinking, schema.RoleAssistant, schema.RoleTool}, roles)
+
+	// The usage reported by the provider is kept with the estimate
+	result := stream.Reconcile(&schema.UsageMeta{InputTokens: 18, OutputTokens: 7})
+	assert.Equal(schema.EventUsage, roles[len(roles)-1])
+	assert.Equal(uint(18), result.InputTokens)
+	assert.Equal(&schema.UsageEstimate{InputTokens: 20, OutputTokens: 6, ReasoningTokens: 2}, result.Estimate)
+	assert.Equal(*result, usage)
+
+	// Without usage from the provider, only the estimate is reported
+	result = stream.Reconcile(nil)
+	assert.False(result.Reported())
+	assert.Equal(uint(6), result.Estimate.OutputTokens)
+}
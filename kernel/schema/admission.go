@@ -0,0 +1,65 @@
+package schema
+
+import (
+	"errors"
+	"slices"
+	"time"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// Priority is the class of a generation request. When the workers which run
+// generations are busy, waiting requests are admitted in priority order.
+type Priority string
+
+// RetryAfterError is an error for a request which was rejected, and which
+// can be retried after a time
+type RetryAfterError struct {
+	Err   error
+	After time.Duration
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+const (
+	PriorityInteractive Priority = "interactive"
+	PriorityBatch       Priority = "batch"
+	PriorityScheduled   Priority = "scheduled"
+)
+
+// Priorities in the order they are admitted
+var Priorities = []Priority{PriorityInteractive, PriorityBatch, PriorityScheduled}
+
+////////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// Valid returns true if the priority is known
+func (p Priority) Valid() bool {
+	return slices.Contains(Priorities, p)
+}
+
+// Rank returns the position of the priority in admission order, or -1 if
+// the priority is not known
+func (p Priority) Rank() int {
+	return slices.Index(Priorities, p)
+}
+
+func (e *RetryAfterError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *RetryAfterError) Unwrap() error {
+	return e.Err
+}
+
+// RetryAfter returns the time after which a rejected request can be retried,
+// or false if the error is not a RetryAfterError
+func RetryAfter(err error) (time.Duration, bool) {
+	var retry *RetryAfterError
+	if errors.As(err, &retry) {
+		return retry.After, true
+	}
+	return 0, false
+}
@@ -0,0 +1,36 @@
+package schema_test
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	// Packages
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	assert "github.com/stretchr/testify/assert"
+)
+
+func TestPriority(t *testing.T) {
+	assert := assert.New(t)
+	assert.True(schema.PriorityInteractive.Valid())
+	assert.False(schema.Priority("urgent").Valid())
+	assert.Less(schema.PriorityInteractive.Rank(), schema.PriorityBatch.Rank())
+	assert.Less(schema.PriorityBatch.Rank(), schema.PriorityScheduled.Rank())
+	assert.Equal(-1, schema.Priority("urgent").Rank())
+}
+
+func TestRetryAfter(t *testing.T) {
+	assert := assert.New(t)
+	err := fmt.Errorf("chat: %w", &schema.RetryAfterError{Err: schema.ErrTooManyRequests.With("queue is full"), After: 3 * time.Second})
+
+	after, ok := schema.RetryAfter(err)
+	assert.True(ok)
+	assert.Equal(3*time.Second, after)
+	assert.ErrorIs(err, schema.ErrTooManyRequests)
+	assert.Equal(http.StatusTooManyRequests, int(schema.ErrTooManyRequests.HTTP()))
+
+	_, ok = schema.RetryAfter(errors.New("other"))
+	assert.False(ok)
+}
@@ -2,11 +2,15 @@ package schema
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"net/url"
+	"regexp"
+	"strings"
 	"time"
 
 	// Packages
+	uuid "github.com/google/uuid"
 	pg "github.com/mutablelogic/go-pg"
 	types "github.com/mutablelogic/go-server/pkg/types"
 )
@@ -23,7 +27,7 @@ type AgentMeta struct {
 	Description   string     `json:"description,omitempty" yaml:"description" help:"Agent description" optional:""`
 	Template      string     `json:"template,omitempty" yaml:"-" help:"Go template for the user message" optional:""`
 	Input         JSONSchema `json:"input,omitempty" yaml:"input" help:"JSON schema for agent input" optional:""`
-	Tools         []string   `json:"tools,omitzero" yaml:"tools" help:"Tool names the agent is allowed to use" optional:""`
+	Tools         []string   `json:"tools,omitzero" yaml:"tools" help:"Tool names or glob patterns the agent is allowed to use, prefixed with ! to deny" optional:""`
 }
 
 // AgentListRequest represents a request to list externally exposed agents,
@@ -65,14 +69,26 @@ type Agent struct {
 	ID      string    `json:"id"`
 	Created time.Time `json:"created"`
 	Version uint      `json:"version"`
+	User    uuid.UUID `json:"user,omitzero"`
 	AgentMeta
 }
 
+// AgentInsert is a version of an agent which is stored
+type AgentInsert struct {
+	AgentMeta
+	Version uint      `json:"version"`
+	User    uuid.UUID `json:"user,omitempty"`
+}
+
+// AgentSelector selects a version of an agent by ID, or the latest version
+// of an agent by name
+type AgentSelector string
+
 // AgentStore is the interface for agent storage backends.
 type AgentStore interface {
-	// CreateAgent creates a new agent from the given metadata,
-	// returning the agent with a unique ID and version 1.
-	CreateAgent(ctx context.Context, meta AgentMeta) (*Agent, error)
+	// CreateAgent creates a new agent from the given metadata, recording the
+	// user who created it, and returns the agent with a unique ID and version 1.
+	CreateAgent(ctx context.Context, meta AgentMeta, user uuid.UUID) (*Agent, error)
 
 	// GetAgent retrieves an existing agent by ID or name.
 	// Returns an error if the agent does not exist.
@@ -88,8 +104,11 @@ type AgentStore interface {
 	DeleteAgent(ctx context.Context, id string) error
 
 	// UpdateAgent applies non-zero fields from the given metadata to an existing
-	// agent and increments the version. Returns the updated agent.
-	UpdateAgent(ctx context.Context, id string, meta AgentMeta) (*Agent, error)
+	// agent and increments the version. When version is non-zero, it is the
+	// version the caller expects to update, and a conflict is returned when
+	// the agent has a different version. The user who made the update is
+	// recorded with the new version. Returns the updated agent.
+	UpdateAgent(ctx context.Context, id string, version uint, meta AgentMeta, user uuid.UUID) (*Agent, error)
 }
 
 ////////////////////////////////////////////////////////////////////////////////
@@ -133,3 +152,188 @@ func (r CallAgentRequest) String() string {
 func (a Agent) String() string {
 	return types.Stringify(a)
 }
+
+////////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+// AgentListMax is the maximum number of agents returned in a list
+const AgentListMax = 100
+
+var reAgentName = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9_-]{0,63}$`)
+
+////////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// MergeFrom returns the agent with the fields which are set in override,
+// where the generator settings are merged field by field
+func (a AgentMeta) MergeFrom(override AgentMeta) AgentMeta {
+	merged := a
+	merged.GeneratorMeta = a.GeneratorMeta.MergeFrom(override.GeneratorMeta)
+	if override.Name != "" {
+		merged.Name = override.Name
+	}
+	if override.Title != "" {
+		merged.Title = override.Title
+	}
+	if override.Description != "" {
+		merged.Description = override.Description
+	}
+	if override.Template != "" {
+		merged.Template = override.Template
+	}
+	if len(override.Input) > 0 {
+		merged.Input = override.Input
+	}
+	if override.Tools != nil {
+		merged.Tools = override.Tools
+	}
+	return merged
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// SELECTORS
+
+func (id AgentSelector) Select(bind *pg.Bind, op pg.Op) (string, error) {
+	// Select by ID, or by name
+	value := strings.TrimSpace(string(id))
+	if uid, err := uuid.Parse(value); err == nil {
+		bind.Set("where", `WHERE agent.id = `+bind.Set("id", uid))
+	} else if reAgentName.MatchString(value) {
+		bind.Set("where", `WHERE agent.name = `+bind.Set("name", value))
+	} else {
+		return "", ErrBadParameter.Withf("invalid agent id or name %q", value)
+	}
+
+	switch op {
+	case pg.Get:
+		return bind.Query("agent.select"), nil
+	case pg.Delete:
+		return bind.Query("agent.delete"), nil
+	default:
+		return "", ErrNotImplemented.Withf("unsupported AgentSelector operation %q", op)
+	}
+}
+
+// Select lists the latest version of each agent, or the versions of one
+// agent when the name is set, most recent first
+func (r ListAgentRequest) Select(bind *pg.Bind, op pg.Op) (string, error) {
+	bind.Del("where")
+	if name := strings.TrimSpace(r.Name); name != "" {
+		bind.Append("where", `agent.name = `+bind.Set("name", name))
+		if r.Version != nil {
+			bind.Append("where", `agent.version = `+bind.Set("version", types.Value(r.Version)))
+		}
+	} else if r.Version != nil {
+		return "", ErrBadParameter.With("agent version requires a name")
+	} else {
+		schemaName := fmt.Sprintf("%q", bind.Get("schema"))
+		bind.Append("where", `agent.version = (SELECT MAX(latest.version) FROM `+schemaName+`.agent AS latest WHERE latest.name = agent.name)`)
+	}
+	bind.Set("where", "WHERE "+bind.Join("where", " AND "))
+	bind.Set("orderby", `ORDER BY agent.created_at DESC, agent.name ASC, agent.version DESC`)
+
+	offsetlimit := pg.OffsetLimit{Offset: uint64(r.Offset)}
+	if r.Limit != nil {
+		offsetlimit.Limit = types.Ptr(uint64(types.Value(r.Limit)))
+	}
+	offsetlimit.Bind(bind, AgentListMax)
+
+	switch op {
+	case pg.List:
+		return bind.Query("agent.list"), nil
+	default:
+		return "", ErrNotImplemented.Withf("unsupported ListAgentRequest operation %q", op)
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS - READER
+
+func (a *Agent) Scan(row pg.Row) error {
+	var id uuid.UUID
+	var user *uuid.UUID
+	var input, meta []byte
+	if err := row.Scan(&id, &a.Name, &a.Version, &a.Title, &a.Description, &a.Template, &input, &a.Tools, &meta, &user, &a.Created); err != nil {
+		return err
+	}
+	a.ID = id.String()
+	a.User = types.Value(user)
+	a.Input = nil
+	if len(input) > 0 {
+		a.Input = JSONSchema(input)
+	}
+	a.GeneratorMeta = GeneratorMeta{}
+	if len(meta) > 0 {
+		if err := json.Unmarshal(meta, &a.GeneratorMeta); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (list *ListAgentResponse) Scan(row pg.Row) error {
+	var agent Agent
+	if err := agent.Scan(row); err != nil {
+		return err
+	}
+	list.Body = append(list.Body, &agent)
+	return nil
+}
+
+func (list *ListAgentResponse) ScanCount(row pg.Row) error {
+	return row.Scan(&list.Count)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS - WRITER
+
+func (a AgentInsert) Insert(bind *pg.Bind) (string, error) {
+	name := strings.TrimSpace(a.Name)
+	if !reAgentName.MatchString(name) {
+		return "", ErrBadParameter.Withf("invalid agent name %q", a.Name)
+	} else if a.Version == 0 {
+		return "", ErrBadParameter.With("agent version is required")
+	}
+	meta, err := json.Marshal(a.GeneratorMeta)
+	if err != nil {
+		return "", err
+	}
+
+	bind.Set("name", name)
+	bind.Set("version", a.Version)
+	bind.Set("title", strings.TrimSpace(a.Title))
+	bind.Set("description", agentNullText(a.Description))
+	bind.Set("template", agentNullText(a.Template))
+	if len(a.Input) == 0 {
+		bind.Set("input", nil)
+	} else {
+		bind.Set("input", json.RawMessage(a.Input))
+	}
+	if a.Tools == nil {
+		bind.Set("tools", []string{})
+	} else {
+		bind.Set("tools", a.Tools)
+	}
+	bind.Set("meta", json.RawMessage(meta))
+	if a.User == uuid.Nil {
+		bind.Set("user", nil)
+	} else {
+		bind.Set("user", a.User)
+	}
+	return bind.Query("agent.insert"), nil
+}
+
+func (a AgentInsert) Update(_ *pg.Bind) error {
+	return ErrNotImplemented.With("agent: update: not supported, insert a new version")
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// agentNullText returns nil for empty text, which is stored as NULL
+func agentNullText(value string) any {
+	if value = strings.TrimSpace(value); value == "" {
+		return nil
+	}
+	return value
+}
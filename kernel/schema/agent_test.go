@@ -0,0 +1,103 @@
+package schema_test
+
+import (
+	"testing"
+
+	// Packages
+	uuid "github.com/google/uuid"
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	pg "github.com/mutablelogic/go-pg"
+	types "github.com/mutablelogic/go-server/pkg/types"
+	assert "github.com/stretchr/testify/assert"
+)
+
+func TestAgentSelector(t *testing.T) {
+	assert := assert.New(t)
+	id := uuid.MustParse("11111111-1111-1111-1111-111111111111")
+
+	// An agent is selected by ID, or by name
+	bind := pg.NewBind()
+	_, err := schema.AgentSelector(id.String()).Select(bind, pg.Get)
+	if assert.NoError(err) {
+		assert.Contains(bind.Get("where"), "agent.id = ")
+		assert.Equal(id, bind.Get("id"))
+	}
+	bind = pg.NewBind()
+	_, err = schema.AgentSelector("summarize").Select(bind, pg.Delete)
+	if assert.NoError(err) {
+		assert.Contains(bind.Get("where"), "agent.name = ")
+		assert.Equal("summarize", bind.Get("name"))
+	}
+	_, err = schema.AgentSelector("not a name").Select(pg.NewBind(), pg.Get)
+	assert.ErrorIs(err, schema.ErrBadParameter)
+	_, err = schema.AgentSelector("summarize").Select(pg.NewBind(), pg.Update)
+	assert.ErrorIs(err, schema.ErrNotImplemented)
+}
+
+func TestListAgentRequestSelect(t *testing.T) {
+	assert := assert.New(t)
+
+	// Without a name, the latest version of each agent is listed
+	bind := pg.NewBind("schema", "llm")
+	_, err := schema.ListAgentRequest{Limit: types.Ptr(uint(500))}.Select(bind, pg.List)
+	if assert.NoError(err) {
+		assert.Contains(bind.Get("where"), `(SELECT MAX(latest.version) FROM "llm".agent AS latest WHERE latest.name = agent.name)`)
+		assert.Equal("LIMIT 100", bind.Get("offsetlimit"))
+	}
+
+	// With a name, the versions of the agent are listed
+	bind = pg.NewBind()
+	_, err = schema.ListAgentRequest{Name: "summarize", Version: types.Ptr(uint(2)), Offset: 10}.Select(bind, pg.List)
+	if assert.NoError(err) {
+		assert.Contains(bind.Get("where"), "agent.name = ")
+		assert.Contains(bind.Get("where"), "agent.version = ")
+		assert.Equal(uint(2), bind.Get("version"))
+		assert.Equal("LIMIT 100 OFFSET 10", bind.Get("offsetlimit"))
+	}
+
+	// A version requires a name
+	_, err = schema.ListAgentRequest{Version: types.Ptr(uint(2))}.Select(pg.NewBind(), pg.List)
+	assert.ErrorIs(err, schema.ErrBadParameter)
+}
+
+func TestAgentInsert(t *testing.T) {
+	assert := assert.New(t)
+
+	bind := pg.NewBind()
+	_, err := schema.AgentInsert{AgentMeta: schema.AgentMeta{
+		GeneratorMeta: schema.GeneratorMeta{Model: types.Ptr("model")},
+		Name:          "summarize",
+		Title:         "Summarize",
+	}, Version: 1}.Insert(bind)
+	if assert.NoError(err) {
+		assert.Equal("summarize", bind.Get("name"))
+		assert.Equal(uint(1), bind.Get("version"))
+		assert.Nil(bind.Get("description"))
+		assert.Nil(bind.Get("user"))
+		assert.Equal([]string{}, bind.Get("tools"))
+	}
+
+	_, err = schema.AgentInsert{AgentMeta: schema.AgentMeta{Name: "1summarize"}, Version: 1}.Insert(pg.NewBind())
+	assert.ErrorIs(err, schema.ErrBadParameter)
+	_, err = schema.AgentInsert{AgentMeta: schema.AgentMeta{Name: "summarize"}}.Insert(pg.NewBind())
+	assert.ErrorIs(err, schema.ErrBadParameter)
+}
+
+func TestAgentMetaMergeFrom(t *testing.T) {
+	assert := assert.New(t)
+
+	agent := schema.AgentMeta{
+		GeneratorMeta: schema.GeneratorMeta{Model: types.Ptr("model")},
+		Name:          "summarize",
+		Title:         "Summarize",
+		Tools:         []string{"search"},
+	}
+	merged := agent.MergeFrom(schema.AgentMeta{Description: "Summarizes text"})
+	assert.Equal("Summarize", merged.Title)
+	assert.Equal("Summarizes text", merged.Description)
+	assert.Equal([]string{"search"}, merged.Tools)
+	assert.Equal("model", types.Value(merged.Model))
+
+	merged = agent.MergeFrom(schema.AgentMeta{Tools: []string{}})
+	assert.Empty(merged.Tools)
+}
@@ -0,0 +1,255 @@
+package schema
+
+import (
+	"net/url"
+	"strings"
+	"time"
+
+	// Packages
+	uuid "github.com/google/uuid"
+	pg "github.com/mutablelogic/go-pg"
+	types "github.com/mutablelogic/go-server/pkg/types"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// AnalyticsRequest selects the period and the size of the time buckets for
+// an analytics report. The provider and model filters apply to usage and
+// model reports.
+type AnalyticsRequest struct {
+	Since    *time.Time `json:"since,omitempty" help:"Start of the period, defaults to seven days before the end" optional:""`
+	Until    *time.Time `json:"until,omitempty" help:"End of the period, defaults to now" optional:""`
+	Bucket   string     `json:"bucket,omitempty" help:"Size of each time bucket" enum:"hour,day,week,month" default:"day"`
+	Provider string     `json:"provider,omitempty" help:"Only include usage for this provider" optional:""`
+	Model    string     `json:"model,omitempty" help:"Only include usage for this model" optional:""`
+}
+
+// UsageBucket counts the requests and tokens in a time bucket, for a usage
+// type or for a model
+type UsageBucket struct {
+	Time     time.Time `json:"time" help:"Start of the time bucket"`
+	Type     UsageType `json:"type,omitempty" help:"Usage category, in usage reports" example:"chat"`
+	Provider string    `json:"provider,omitempty" help:"Provider name, in model reports" example:"anthropic"`
+	Model    string    `json:"model,omitempty" help:"Model name, in model reports" example:"claude-sonnet-4-5"`
+	Requests uint      `json:"requests" help:"Number of requests" example:"12"`
+	UsageMeta
+}
+
+// UsageAnalytics is a usage or model report
+type UsageAnalytics struct {
+	AnalyticsRequest
+	Body []UsageBucket `json:"body"`
+}
+
+// ToolBucket counts the results returned by a tool in a time bucket
+type ToolBucket struct {
+	Time   time.Time `json:"time" help:"Start of the time bucket"`
+	Tool   string    `json:"tool" help:"Tool name" example:"get_weather"`
+	Calls  uint      `json:"calls" help:"Number of tool results" example:"8"`
+	Errors uint      `json:"errors" help:"Number of tool results which were errors" example:"1"`
+}
+
+// ToolAnalytics is a tool report, with the most used tools first in each
+// time bucket
+type ToolAnalytics struct {
+	AnalyticsRequest
+	Body []ToolBucket `json:"body"`
+}
+
+// UsageAnalyticsSelector aggregates usage records by usage type
+type UsageAnalyticsSelector AnalyticsRequest
+
+// ModelAnalyticsSelector aggregates usage records by provider and model
+type ModelAnalyticsSelector AnalyticsRequest
+
+// ToolAnalyticsSelector aggregates the tool results in stored messages
+type ToolAnalyticsSelector AnalyticsRequest
+
+////////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+const (
+	// AnalyticsPeriod is the length of the period when no start is requested
+	AnalyticsPeriod = 7 * 24 * time.Hour
+
+	// AnalyticsBucketMax is the maximum number of time buckets in a period
+	AnalyticsBucketMax = 1000
+)
+
+// Approximate length of each bucket size, used to limit the number of buckets
+var analyticsBuckets = map[string]time.Duration{
+	"hour":  time.Hour,
+	"day":   24 * time.Hour,
+	"week":  7 * 24 * time.Hour,
+	"month": 31 * 24 * time.Hour,
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// STRINGIFY
+
+func (r AnalyticsRequest) String() string {
+	return types.Stringify(r)
+}
+
+func (r UsageAnalytics) String() string {
+	return types.Stringify(r)
+}
+
+func (r ToolAnalytics) String() string {
+	return types.Stringify(r)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// Query returns the URL query values for the request
+func (r AnalyticsRequest) Query() url.Values {
+	values := url.Values{}
+	if r.Since != nil && !r.Since.IsZero() {
+		values.Set("since", r.Since.Format(time.RFC3339))
+	}
+	if r.Until != nil && !r.Until.IsZero() {
+		values.Set("until", r.Until.Format(time.RFC3339))
+	}
+	if bucket := strings.TrimSpace(r.Bucket); bucket != "" {
+		values.Set("bucket", bucket)
+	}
+	if provider := strings.TrimSpace(r.Provider); provider != "" {
+		values.Set("provider", provider)
+	}
+	if model := strings.TrimSpace(r.Model); model != "" {
+		values.Set("model", model)
+	}
+	return values
+}
+
+// Resolve returns the request with the default period and bucket size set,
+// or an error if the period is empty or has too many buckets
+func (r AnalyticsRequest) Resolve(now time.Time) (AnalyticsRequest, error) {
+	r.Bucket = strings.ToLower(strings.TrimSpace(r.Bucket))
+	if r.Bucket == "" {
+		r.Bucket = "day"
+	}
+	size, exists := analyticsBuckets[r.Bucket]
+	if !exists {
+		return r, ErrBadParameter.Withf("invalid bucket %q", r.Bucket)
+	}
+	if r.Until == nil || r.Until.IsZero() {
+		r.Until = types.Ptr(now)
+	}
+	if r.Since == nil || r.Since.IsZero() {
+		r.Since = types.Ptr(r.Until.Add(-AnalyticsPeriod))
+	}
+	if !r.Since.Before(*r.Until) {
+		return r, ErrBadParameter.With("since must be before until")
+	} else if r.Until.Sub(*r.Since) > size*AnalyticsBucketMax {
+		return r, ErrBadParameter.Withf("period has more than %d %s buckets", AnalyticsBucketMax, r.Bucket)
+	}
+	r.Provider, r.Model = strings.TrimSpace(r.Provider), strings.TrimSpace(r.Model)
+	return r, nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS - SELECTOR
+
+func (sel UsageAnalyticsSelector) Select(bind *pg.Bind, op pg.Op) (string, error) {
+	if err := AnalyticsRequest(sel).bindUsage(bind); err != nil {
+		return "", err
+	}
+	switch op {
+	case pg.List:
+		return bind.Query("analytics.usage"), nil
+	default:
+		return "", ErrNotImplemented.Withf("unsupported UsageAnalyticsSelector operation %q", op)
+	}
+}
+
+func (sel ModelAnalyticsSelector) Select(bind *pg.Bind, op pg.Op) (string, error) {
+	if err := AnalyticsRequest(sel).bindUsage(bind); err != nil {
+		return "", err
+	}
+	switch op {
+	case pg.List:
+		return bind.Query("analytics.models"), nil
+	default:
+		return "", ErrNotImplemented.Withf("unsupported ModelAnalyticsSelector operation %q", op)
+	}
+}
+
+func (sel ToolAnalyticsSelector) Select(bind *pg.Bind, op pg.Op) (string, error) {
+	if err := AnalyticsRequest(sel).bindPeriod(bind, "message"); err != nil {
+		return "", err
+	}
+	if user, ok := bind.Get("user").(uuid.UUID); ok && user != uuid.Nil {
+		bind.Append("where", `session."user" = @user`)
+	}
+	bind.Set("where", bind.Join("where", " AND "))
+
+	switch op {
+	case pg.List:
+		return bind.Query("analytics.tools"), nil
+	default:
+		return "", ErrNotImplemented.Withf("unsupported ToolAnalyticsSelector operation %q", op)
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS - READER
+
+func (r *UsageAnalytics) Scan(row pg.Row) error {
+	var bucket UsageBucket
+	if err := row.Scan(
+		&bucket.Time, &bucket.Type, &bucket.Provider, &bucket.Model, &bucket.Requests,
+		&bucket.InputTokens, &bucket.OutputTokens, &bucket.CacheReadTokens, &bucket.CacheWriteTokens, &bucket.ReasoningTokens,
+	); err != nil {
+		return err
+	}
+	r.Body = append(r.Body, bucket)
+	return nil
+}
+
+func (r *ToolAnalytics) Scan(row pg.Row) error {
+	var bucket ToolBucket
+	if err := row.Scan(&bucket.Time, &bucket.Tool, &bucket.Calls, &bucket.Errors); err != nil {
+		return err
+	}
+	r.Body = append(r.Body, bucket)
+	return nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// bindPeriod sets the bucket size, and the conditions which select the rows
+// of the table in the period
+func (r AnalyticsRequest) bindPeriod(bind *pg.Bind, table string) error {
+	if _, exists := analyticsBuckets[r.Bucket]; !exists {
+		return ErrBadParameter.Withf("invalid bucket %q", r.Bucket)
+	} else if r.Since == nil || r.Until == nil {
+		return ErrBadParameter.With("analytics period is required")
+	}
+	bind.Del("where")
+	bind.Set("bucket", r.Bucket)
+	bind.Append("where", table+`.created_at >= `+bind.Set("since", *r.Since))
+	bind.Append("where", table+`.created_at < `+bind.Set("until", *r.Until))
+	return nil
+}
+
+// bindUsage sets the conditions which select usage records
+func (r AnalyticsRequest) bindUsage(bind *pg.Bind) error {
+	if err := r.bindPeriod(bind, "usage"); err != nil {
+		return err
+	}
+	if user, ok := bind.Get("user").(uuid.UUID); ok && user != uuid.Nil {
+		bind.Append("where", `usage."user" = @user`)
+	}
+	if r.Provider != "" {
+		bind.Append("where", `usage.provider = `+bind.Set("provider", r.Provider))
+	}
+	if r.Model != "" {
+		bind.Append("where", `usage.model = `+bind.Set("model", r.Model))
+	}
+	bind.Set("where", bind.Join("where", " AND "))
+	return nil
+}
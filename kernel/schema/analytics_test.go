@@ -0,0 +1,70 @@
+package schema_test
+
+import (
+	"testing"
+	"time"
+
+	// Packages
+	uuid "github.com/google/uuid"
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	pg "github.com/mutablelogic/go-pg"
+	types "github.com/mutablelogic/go-server/pkg/types"
+	assert "github.com/stretchr/testify/assert"
+)
+
+func TestAnalyticsRequestResolve(t *testing.T) {
+	assert := assert.New(t)
+	now := time.Date(2026, 1, 31, 0, 0, 0, 0, time.UTC)
+
+	req, err := (schema.AnalyticsRequest{Model: " gpt-4o "}).Resolve(now)
+	if assert.NoError(err) {
+		assert.Equal("day", req.Bucket)
+		assert.Equal(now, *req.Until)
+		assert.Equal(now.Add(-schema.AnalyticsPeriod), *req.Since)
+		assert.Equal("gpt-4o", req.Model)
+	}
+
+	_, err = (schema.AnalyticsRequest{Bucket: "minute"}).Resolve(now)
+	assert.ErrorIs(err, schema.ErrBadParameter)
+	_, err = (schema.AnalyticsRequest{Since: types.Ptr(now)}).Resolve(now)
+	assert.ErrorIs(err, schema.ErrBadParameter)
+	_, err = (schema.AnalyticsRequest{Bucket: "hour", Since: types.Ptr(now.Add(-365 * 24 * time.Hour))}).Resolve(now)
+	assert.ErrorIs(err, schema.ErrBadParameter)
+
+	values := req.Query()
+	assert.Equal("day", values.Get("bucket"))
+	assert.Equal("2026-01-24T00:00:00Z", values.Get("since"))
+	assert.Equal("gpt-4o", values.Get("model"))
+}
+
+func TestAnalyticsSelect(t *testing.T) {
+	assert := assert.New(t)
+	user := uuid.MustParse("11111111-1111-1111-1111-111111111111")
+	req, err := (schema.AnalyticsRequest{Provider: "anthropic"}).Resolve(time.Now())
+	if !assert.NoError(err) {
+		return
+	}
+
+	b := pg.NewBind("schema", "llm", "analytics.models", "SELECT")
+	b.Set("user", user)
+	query, err := schema.ModelAnalyticsSelector(req).Select(b, pg.List)
+	if assert.NoError(err) {
+		assert.Equal("SELECT", query)
+		assert.Equal("day", b.Get("bucket"))
+		assert.Contains(b.Get("where"), `usage."user" = @user`)
+		assert.Contains(b.Get("where"), `usage.provider = @provider`)
+	}
+
+	b = pg.NewBind("schema", "llm", "analytics.tools", "SELECT")
+	query, err = schema.ToolAnalyticsSelector(req).Select(b, pg.List)
+	if assert.NoError(err) {
+		assert.Equal("SELECT", query)
+		assert.Equal(`message.created_at >= @since AND message.created_at < @until`, b.Get("where"))
+	}
+
+	// The period must be resolved first
+	_, err = schema.UsageAnalyticsSelector{Bucket: "day"}.Select(b, pg.List)
+	assert.ErrorIs(err, schema.ErrBadParameter)
+	_, err = schema.UsageAnalyticsSelector(req).Select(b, pg.Get)
+	assert.ErrorIs(err, schema.ErrNotImplemented)
+}
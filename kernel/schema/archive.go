@@ -0,0 +1,181 @@
+package schema
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	// Packages
+	uuid "github.com/google/uuid"
+	pg "github.com/mutablelogic/go-pg"
+	types "github.com/mutablelogic/go-server/pkg/types"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// ArchiveInsert is a prompt and the response to it, as sent and returned by
+// the API
+type ArchiveInsert struct {
+	Type     UsageType       `json:"type" help:"Request category" example:"ask"`
+	User     uuid.UUID       `json:"user,omitzero" help:"User who made the request" optional:""`
+	Session  uuid.UUID       `json:"session,omitzero" help:"Session for chat requests" optional:""`
+	Provider string          `json:"provider,omitempty" help:"Provider which answered" example:"anthropic"`
+	Model    string          `json:"model,omitempty" help:"Model which answered" example:"claude-sonnet-4-5"`
+	Request  json.RawMessage `json:"request" help:"Request body"`
+	Response json.RawMessage `json:"response" help:"Response body"`
+}
+
+// Archive is an immutable archive record. Each record includes the hash of
+// the record before it, so that a change to any record breaks the chain.
+type Archive struct {
+	ID  uuid.UUID `json:"id" help:"Request ID" readonly:""`
+	Seq uint64    `json:"seq" help:"Position of the record in the archive" readonly:""`
+	ArchiveInsert
+	PrevHash  string    `json:"prev_hash,omitempty" help:"Hash of the previous record, empty for the first record" readonly:""`
+	Hash      string    `json:"hash" help:"SHA-256 hash of the record and the previous hash" readonly:""`
+	CreatedAt time.Time `json:"created_at" help:"Time the record was archived" readonly:""`
+	Verified  bool      `json:"verified" help:"True when the hash matches the record and the previous record in the chain" readonly:""`
+}
+
+// ArchiveIDSelector selects an archive record by request ID
+type ArchiveIDSelector uuid.UUID
+
+// ArchiveBeforeSelector selects the last archive record before a position,
+// or the last record in the archive when the position is zero
+type ArchiveBeforeSelector uint64
+
+////////////////////////////////////////////////////////////////////////////////
+// STRINGIFY
+
+func (a Archive) String() string {
+	return types.Stringify(a)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// Digest returns the hash of the record, which covers every field except
+// the position and the hash itself. Each field is prefixed by its length so
+// that the boundaries between fields are unambiguous.
+func (a Archive) Digest() string {
+	hash := sha256.New()
+	for _, field := range [][]byte{
+		[]byte(a.PrevHash),
+		[]byte(a.ID.String()),
+		[]byte(a.CreatedAt.UTC().Format(time.RFC3339Nano)),
+		[]byte(a.Type),
+		[]byte(a.User.String()),
+		[]byte(a.Session.String()),
+		[]byte(a.Provider),
+		[]byte(a.Model),
+		a.Request,
+		a.Response,
+	} {
+		fmt.Fprintf(hash, "%d:", len(field))
+		hash.Write(field)
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS - SELECTOR
+
+func (s ArchiveIDSelector) Select(bind *pg.Bind, op pg.Op) (string, error) {
+	if id := uuid.UUID(s); id == uuid.Nil {
+		return "", ErrBadParameter.With("request ID is required")
+	} else {
+		bind.Set("id", id)
+	}
+
+	// Restrict to the records of a user, when set
+	if user, _ := bind.Get("user").(uuid.UUID); user != uuid.Nil {
+		bind.Set("userwhere", `AND archive."user" = @user`)
+	} else {
+		bind.Set("userwhere", "")
+	}
+
+	switch op {
+	case pg.Get:
+		return bind.Query("archive.select"), nil
+	default:
+		return "", ErrNotImplemented.Withf("unsupported ArchiveIDSelector operation %q", op)
+	}
+}
+
+func (s ArchiveBeforeSelector) Select(bind *pg.Bind, op pg.Op) (string, error) {
+	if s == 0 {
+		bind.Set("where", "")
+	} else {
+		bind.Set("where", `WHERE archive.seq < `+bind.Set("seq", uint64(s)))
+	}
+
+	switch op {
+	case pg.Get:
+		return bind.Query("archive.before"), nil
+	default:
+		return "", ErrNotImplemented.Withf("unsupported ArchiveBeforeSelector operation %q", op)
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS - READER
+
+func (a *Archive) Scan(row pg.Row) error {
+	var user, session *uuid.UUID
+	if err := row.Scan(
+		&a.ID, &a.Seq, &a.Type, &user, &session, &a.Provider, &a.Model,
+		&a.Request, &a.Response, &a.PrevHash, &a.Hash, &a.CreatedAt,
+	); err != nil {
+		return err
+	}
+	a.User, a.Session = types.Value(user), types.Value(session)
+	return nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS - WRITER
+
+func (a Archive) Insert(bind *pg.Bind) (string, error) {
+	if a.ID == uuid.Nil {
+		return "", ErrBadParameter.With("request ID is required")
+	} else if _, err := normalizeUsageType(a.Type); err != nil {
+		return "", err
+	} else if len(a.Request) == 0 || len(a.Response) == 0 {
+		return "", ErrBadParameter.With("request and response are required")
+	} else if a.Hash == "" {
+		return "", ErrBadParameter.With("hash is required")
+	}
+
+	bind.Set("id", a.ID)
+	bind.Set("type", a.Type)
+	bind.Set("user", archiveUUID(a.User))
+	bind.Set("session", archiveUUID(a.Session))
+	bind.Set("provider", a.Provider)
+	bind.Set("model", a.Model)
+	bind.Set("request", a.Request)
+	bind.Set("response", a.Response)
+	bind.Set("prev_hash", a.PrevHash)
+	bind.Set("hash", a.Hash)
+	bind.Set("created_at", a.CreatedAt)
+
+	return bind.Query("archive.insert"), nil
+}
+
+func (a Archive) Update(_ *pg.Bind) error {
+	return ErrNotImplemented.With("archive records cannot be updated")
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// archiveUUID returns nil for an empty user or session, so that it is stored
+// as NULL
+func archiveUUID(id uuid.UUID) any {
+	if id == uuid.Nil {
+		return nil
+	}
+	return id
+}
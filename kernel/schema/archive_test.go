@@ -0,0 +1,62 @@
+package schema_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	// Packages
+	uuid "github.com/google/uuid"
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	pg "github.com/mutablelogic/go-pg"
+	assert "github.com/stretchr/testify/assert"
+)
+
+func TestArchiveDigest(t *testing.T) {
+	assert := assert.New(t)
+
+	record := schema.Archive{
+		ID: uuid.MustParse("123e4567-e89b-12d3-a456-426614174000"),
+		ArchiveInsert: schema.ArchiveInsert{
+			Type:     schema.UsageTypeAsk,
+			Provider: "anthropic",
+			Model:    "claude-sonnet-4-5",
+			Request:  json.RawMessage(`{"text":"hello"}`),
+			Response: json.RawMessage(`{"role":"assistant"}`),
+		},
+		CreatedAt: time.Date(2026, 1, 2, 3, 4, 5, 6000, time.UTC),
+	}
+	digest := record.Digest()
+	assert.Len(digest, 64)
+
+	// The position, hash and time zone are not part of the digest
+	same := record
+	same.Seq, same.Hash, same.CreatedAt = 42, digest, record.CreatedAt.In(time.FixedZone("X", 3600))
+	assert.Equal(digest, same.Digest())
+
+	// Any change to the record or the chain changes the digest
+	changed := record
+	changed.PrevHash = digest
+	assert.NotEqual(digest, changed.Digest())
+	changed = record
+	changed.Response = json.RawMessage(`{"role":"user"}`)
+	assert.NotEqual(digest, changed.Digest())
+
+	// Moving bytes between fields changes the digest
+	changed = record
+	changed.Provider, changed.Model = "anthropicclaude", "-sonnet-4-5"
+	assert.NotEqual(digest, changed.Digest())
+}
+
+func TestArchiveSelectors(t *testing.T) {
+	assert := assert.New(t)
+
+	_, err := schema.ArchiveIDSelector(uuid.Nil).Select(pg.NewBind(), pg.Get)
+	assert.ErrorIs(err, schema.ErrBadParameter)
+
+	_, err = schema.ArchiveIDSelector(uuid.New()).Select(pg.NewBind(), pg.Update)
+	assert.ErrorIs(err, schema.ErrNotImplemented)
+
+	_, err = schema.Archive{ID: uuid.New(), ArchiveInsert: schema.ArchiveInsert{Type: schema.UsageTypeAsk}}.Insert(pg.NewBind())
+	assert.ErrorIs(err, schema.ErrBadParameter)
+}
@@ -0,0 +1,143 @@
+package schema
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// attachmentLimit is the largest attachment a provider accepts, for content
+// types with a prefix
+type attachmentLimit struct {
+	prefix string
+	size   int
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+// Content types which are reliably detected from their data, so a declared
+// type which does not match the data is an error
+var sniffedTypes = []string{
+	"image/png", "image/jpeg", "image/gif", "image/webp", "image/bmp", "application/pdf",
+}
+
+// Other names for content types
+var contentTypeAliases = map[string]string{
+	"image/jpg":   "image/jpeg",
+	"image/pjpeg": "image/jpeg",
+	"image/x-png": "image/png",
+	"image/x-bmp": "image/bmp",
+}
+
+// Size of inline attachment data accepted by each provider, with the first
+// matching prefix applying. Providers which are not listed have no limit.
+var attachmentLimits = map[string][]attachmentLimit{
+	Anthropic: {{"image/", 5 << 20}, {"application/pdf", 32 << 20}},
+	Gemini:    {{"", 20 << 20}},
+	Mistral:   {{"image/", 10 << 20}, {"application/pdf", 50 << 20}},
+	OpenAI:    {{"image/", 20 << 20}, {"", 32 << 20}},
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// DetectContentType returns the content type of the data. When the type
+// cannot be detected from the data, the extension of the name is used, and
+// otherwise application/octet-stream is returned.
+func DetectContentType(data []byte, name string) string {
+	detected := http.DetectContentType(data)
+	if detected != "application/octet-stream" && !strings.HasPrefix(detected, "text/plain") {
+		return detected
+	}
+	if ext := filepath.Ext(name); ext != "" {
+		if byExtension := mime.TypeByExtension(ext); byExtension != "" {
+			return byExtension
+		}
+	}
+	return detected
+}
+
+// Detect sets the content type of the attachment from its data or the name
+// of its URL, when the content type is not set
+func (a *Attachment) Detect() {
+	if a.ContentType != "" {
+		return
+	}
+	name := ""
+	if a.URL != nil {
+		name = a.URL.Path
+	}
+	if len(a.Data) > 0 {
+		a.ContentType = DetectContentType(a.Data, name)
+	} else if ext := filepath.Ext(name); ext != "" {
+		a.ContentType = mime.TypeByExtension(ext)
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// validateContentType returns an error if the declared content type is one
+// which is reliably detected, or the data is of such a type, and the data is
+// not of the declared type
+func validateContentType(mediatype string, data []byte) error {
+	if len(data) == 0 {
+		return nil
+	}
+	declared := normalizeContentType(mediatype)
+	detected := normalizeContentType(http.DetectContentType(data))
+	if declared == detected {
+		return nil
+	}
+	if slices.Contains(sniffedTypes, declared) {
+		return fmt.Errorf("attachment is declared as %q, but the data is %q", declared, detected)
+	}
+	if slices.Contains(sniffedTypes, detected) && (strings.HasPrefix(declared, "image/") || declared == "application/pdf") {
+		return fmt.Errorf("attachment is declared as %q, but the data is %q", declared, detected)
+	}
+	return nil
+}
+
+// validateAttachmentSize returns an error if the provider does not accept
+// attachment data of this size
+func validateAttachmentSize(provider string, attachment *Attachment) error {
+	mediatype, _, _ := mime.ParseMediaType(attachment.ContentType)
+	for _, limit := range attachmentLimits[provider] {
+		if !strings.HasPrefix(mediatype, limit.prefix) {
+			continue
+		}
+		if len(attachment.Data) > limit.size {
+			return fmt.Errorf("%s attachment is %s, which is more than the %s accepted by %s; reduce its size or send it by URL", mediatype, formatSize(len(attachment.Data)), formatSize(limit.size), provider)
+		}
+		return nil
+	}
+	return nil
+}
+
+// normalizeContentType returns the media type without parameters, with
+// aliases replaced
+func normalizeContentType(contentType string) string {
+	mediatype, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediatype = strings.ToLower(strings.TrimSpace(contentType))
+	}
+	if alias, exists := contentTypeAliases[mediatype]; exists {
+		return alias
+	}
+	return mediatype
+}
+
+// formatSize returns a size in bytes as megabytes or kilobytes
+func formatSize(size int) string {
+	if size >= 1<<20 {
+		return fmt.Sprintf("%.1f MB", float64(size)/(1<<20))
+	}
+	return fmt.Sprintf("%.1f KB", float64(size)/(1<<10))
+}
@@ -0,0 +1,131 @@
+package schema
+
+import (
+	"time"
+
+	// Packages
+	uuid "github.com/google/uuid"
+	types "github.com/mutablelogic/go-server/pkg/types"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// SessionBudget is the use of the context window of the session model by the
+// system prompt and messages of a session, and the messages which would be
+// trimmed from the start of the conversation so that it fits in the window.
+type SessionBudget struct {
+	Session      uuid.UUID       `json:"session" help:"Session ID"`
+	Provider     string          `json:"provider,omitempty" help:"Provider of the session model" optional:"" example:"anthropic"`
+	Model        string          `json:"model,omitempty" help:"Session model" optional:"" example:"claude-sonnet-4-5-20250929"`
+	Window       uint            `json:"window,omitempty" help:"Input token limit of the model, or zero when it is not known" optional:"" example:"200000"`
+	SystemPrompt uint            `json:"system_prompt,omitempty" help:"Estimated tokens for the system prompt" optional:"" example:"120"`
+	Tokens       uint            `json:"tokens" help:"Tokens for the system prompt and messages" example:"164000"`
+	Percent      float64         `json:"percent,omitempty" help:"Percentage of the context window which is used" optional:"" example:"82"`
+	Trimmed      uint            `json:"trimmed,omitempty" help:"Number of messages which would be trimmed to fit in the context window" optional:"" example:"4"`
+	Messages     []MessageBudget `json:"messages" help:"Tokens for each message, in order"`
+}
+
+// MessageBudget is the tokens for a message, and the total for the system
+// prompt and messages up to and including it.
+type MessageBudget struct {
+	ID         uint64    `json:"id,omitempty" help:"Message row ID" optional:"" example:"42"`
+	Role       string    `json:"role" help:"Message role" example:"user"`
+	Tokens     uint      `json:"tokens" help:"Tokens for the message, counted by the provider or estimated" example:"12"`
+	Estimated  bool      `json:"estimated,omitempty" help:"True if the tokens were estimated from the content" optional:""`
+	Cumulative uint      `json:"cumulative" help:"Tokens up to and including the message" example:"512"`
+	Trim       bool      `json:"trim,omitempty" help:"True if the message would be trimmed to fit in the context window" optional:""`
+	Pinned     bool      `json:"pinned,omitempty" help:"True if the message is never trimmed" optional:""`
+	CreatedAt  time.Time `json:"created_at,omitzero" help:"Creation time" optional:""`
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// STRINGIFY
+
+func (b SessionBudget) String() string {
+	return types.Stringify(b)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// Budget returns the tokens for the system prompt and each message of the
+// conversation against a context window, which is zero when it is not known.
+// Messages are trimmed from the start of the conversation until it fits, and
+// then until it starts with a user message which is not a tool result, so
+// that tool calls and their results are kept together. Pinned messages and
+// the last message are never trimmed.
+func (s Conversation) Budget(systemPrompt, window uint) SessionBudget {
+	budget := SessionBudget{
+		Window:       window,
+		SystemPrompt: systemPrompt,
+		Tokens:       systemPrompt,
+	}
+	messages := s.withoutNil()
+	budget.Messages = make([]MessageBudget, 0, len(messages))
+	for _, message := range messages {
+		entry := MessageBudget{
+			ID:        message.ID,
+			Role:      message.Role,
+			Tokens:    message.Tokens,
+			Pinned:    message.Pinned(),
+			CreatedAt: message.CreatedAt,
+		}
+		if entry.Tokens == 0 {
+			entry.Tokens = message.EstimateTokens()
+			entry.Estimated = true
+		}
+		budget.Tokens += entry.Tokens
+		entry.Cumulative = budget.Tokens
+		budget.Messages = append(budget.Messages, entry)
+	}
+	if window == 0 {
+		return budget
+	}
+	budget.Percent = float64(budget.Tokens) * 100 / float64(window)
+
+	// Trim messages from the start until the remainder fits in the window
+	remainder := budget.Tokens
+	for i := 0; i < len(budget.Messages)-1; i++ {
+		if remainder <= window && (budget.Trimmed == 0 || startsTurn(messages[i])) {
+			break
+		}
+		if budget.Messages[i].Pinned {
+			continue
+		}
+		budget.Messages[i].Trim = true
+		budget.Trimmed++
+		remainder -= budget.Messages[i].Tokens
+	}
+
+	// Return the budget
+	return budget
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// withoutNil returns the messages of the conversation which are not nil
+func (s Conversation) withoutNil() Conversation {
+	result := make(Conversation, 0, len(s))
+	for _, message := range s {
+		if message != nil {
+			result = append(result, message)
+		}
+	}
+	return result
+}
+
+// startsTurn returns true if the message is a user message which is not a
+// tool result, so a conversation can start with it
+func startsTurn(message *Message) bool {
+	if message.Role != RoleUser {
+		return false
+	}
+	for _, block := range message.Content {
+		if block.ToolResult != nil {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,96 @@
+package schema_test
+
+import (
+	"testing"
+
+	// Packages
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	assert "github.com/stretchr/testify/assert"
+)
+
+func budgetMessage(role string, tokens uint, content ...schema.ContentBlock) *schema.Message {
+	return &schema.Message{Role: role, Tokens: tokens, Content: content}
+}
+
+func TestBudgetWithoutWindow(t *testing.T) {
+	assert := assert.New(t)
+	text := "Hello"
+	conversation := schema.Conversation{
+		budgetMessage(schema.RoleUser, 0, schema.ContentBlock{Text: &text}),
+		nil,
+		budgetMessage(schema.RoleAssistant, 20),
+	}
+
+	estimate := conversation[0].EstimateTokens()
+	budget := conversation.Budget(10, 0)
+	assert.Equal(30+estimate, budget.Tokens)
+	assert.Zero(budget.Percent)
+	assert.Zero(budget.Trimmed)
+	if assert.Len(budget.Messages, 2) {
+		assert.Equal(estimate, budget.Messages[0].Tokens)
+		assert.True(budget.Messages[0].Estimated)
+		assert.Equal(10+estimate, budget.Messages[0].Cumulative)
+		assert.False(budget.Messages[1].Estimated)
+		assert.Equal(30+estimate, budget.Messages[1].Cumulative)
+	}
+}
+
+func TestBudgetFits(t *testing.T) {
+	assert := assert.New(t)
+	conversation := schema.Conversation{
+		budgetMessage(schema.RoleUser, 40),
+		budgetMessage(schema.RoleAssistant, 42),
+	}
+
+	budget := conversation.Budget(0, 100)
+	assert.Equal(uint(82), budget.Tokens)
+	assert.InDelta(82.0, budget.Percent, 0.001)
+	assert.Zero(budget.Trimmed)
+}
+
+func TestBudgetTrim(t *testing.T) {
+	assert := assert.New(t)
+	conversation := schema.Conversation{
+		budgetMessage(schema.RoleUser, 30),
+		budgetMessage(schema.RoleAssistant, 20, schema.ContentBlock{ToolCall: &schema.ToolCall{ID: "1", Name: "weather"}}),
+		budgetMessage(schema.RoleUser, 10, schema.ContentBlock{ToolResult: &schema.ToolResult{ID: "1", Name: "weather"}}),
+		budgetMessage(schema.RoleAssistant, 20),
+		budgetMessage(schema.RoleUser, 30),
+		budgetMessage(schema.RoleAssistant, 30),
+	}
+
+	// Trimming the first message fits, but the tool call and result are
+	// trimmed with it so that the conversation starts with a user turn
+	budget := conversation.Budget(10, 120)
+	assert.Equal(uint(150), budget.Tokens)
+	assert.InDelta(125.0, budget.Percent, 0.001)
+	assert.Equal(uint(4), budget.Trimmed)
+	for i, message := range budget.Messages {
+		assert.Equal(i < 4, message.Trim, i)
+	}
+
+	// The last message is never trimmed
+	budget = conversation.Budget(0, 10)
+	assert.Equal(uint(5), budget.Trimmed)
+	assert.False(budget.Messages[5].Trim)
+}
+
+func TestBudgetPinned(t *testing.T) {
+	assert := assert.New(t)
+	pinned := budgetMessage(schema.RoleUser, 30)
+	pinned.Meta = map[string]any{schema.MessageMetaPinned: true}
+	conversation := schema.Conversation{
+		pinned,
+		budgetMessage(schema.RoleAssistant, 30),
+		budgetMessage(schema.RoleUser, 30),
+		budgetMessage(schema.RoleAssistant, 30),
+	}
+
+	// The pinned message is kept, and the message after it is trimmed
+	budget := conversation.Budget(0, 90)
+	assert.Equal(uint(1), budget.Trimmed)
+	assert.True(budget.Messages[0].Pinned)
+	assert.False(budget.Messages[0].Trim)
+	assert.True(budget.Messages[1].Trim)
+	assert.False(budget.Messages[2].Trim)
+}
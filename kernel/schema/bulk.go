@@ -0,0 +1,131 @@
+package schema
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+
+	// Packages
+	uuid "github.com/google/uuid"
+	types "github.com/mutablelogic/go-server/pkg/types"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// SessionBulkRequest selects the sessions for a bulk operation, using the
+// same filters as listing sessions. At least one filter is required, so that
+// all sessions cannot be changed by accident.
+type SessionBulkRequest struct {
+	Parent      *uuid.UUID `json:"parent,omitzero" help:"Filter by parent session ID" optional:""`
+	User        *uuid.UUID `json:"user,omitzero" help:"Filter by user ID" optional:""`
+	Title       *string    `json:"title,omitempty" help:"Filter by session title (partial match)" optional:""`
+	Tags        []string   `json:"tags,omitempty" help:"Filter by tags (sessions must contain all specified tags)" optional:""`
+	Concurrency uint       `json:"concurrency,omitempty" help:"Maximum number of sessions processed at once" optional:""`
+	DryRun      bool       `json:"dry_run,omitempty" help:"Report the matching sessions without changing them" optional:""`
+}
+
+// SessionBulkResult is the outcome of a bulk operation on sessions
+type SessionBulkResult struct {
+	Operation string             `json:"operation" help:"Operation which was applied"`
+	DryRun    bool               `json:"dry_run,omitempty" help:"True if the sessions were not changed"`
+	Sessions  []uuid.UUID        `json:"sessions" help:"Sessions which matched the request"`
+	Done      uint               `json:"done" help:"Number of sessions the operation succeeded for"`
+	Errors    []SessionBulkError `json:"errors,omitempty" help:"Sessions the operation failed for"`
+}
+
+// SessionBulkError is the failure of a bulk operation for one session
+type SessionBulkError struct {
+	Session uuid.UUID `json:"session"`
+	Error   string    `json:"error"`
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+const (
+	BulkConcurrencyDefault uint = 4
+	BulkConcurrencyMax     uint = 32
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// STRINGIFY
+
+func (r SessionBulkRequest) String() string {
+	return types.Stringify(r)
+}
+
+func (r SessionBulkResult) String() string {
+	return types.Stringify(r)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// Validate returns an error when the request has no filters
+func (r SessionBulkRequest) Validate() error {
+	switch {
+	case r.Parent != nil && *r.Parent == uuid.Nil:
+		return ErrBadParameter.With("parent session id cannot be nil")
+	case r.User != nil && *r.User == uuid.Nil:
+		return ErrBadParameter.With("user id cannot be nil")
+	case r.Parent == nil && r.User == nil && strings.TrimSpace(types.Value(r.Title)) == "" && len(normalizeSessionTags(r.Tags)) == 0:
+		return ErrBadParameter.With("parent, user, title or tags are required")
+	}
+	return nil
+}
+
+// Limit returns the number of sessions to process at once
+func (r SessionBulkRequest) Limit() int {
+	switch {
+	case r.Concurrency == 0:
+		return int(BulkConcurrencyDefault)
+	case r.Concurrency > BulkConcurrencyMax:
+		return int(BulkConcurrencyMax)
+	default:
+		return int(r.Concurrency)
+	}
+}
+
+// ListRequest returns the request which lists the matching sessions
+func (r SessionBulkRequest) ListRequest() SessionListRequest {
+	return SessionListRequest{
+		Parent: r.Parent,
+		User:   r.User,
+		Title:  r.Title,
+		Tags:   r.Tags,
+	}
+}
+
+// Query returns the URL query values for the request
+func (r SessionBulkRequest) Query() url.Values {
+	values := url.Values{}
+	if r.Parent != nil && *r.Parent != uuid.Nil {
+		values.Set("parent", r.Parent.String())
+	}
+	if r.User != nil && *r.User != uuid.Nil {
+		values.Set("user", r.User.String())
+	}
+	if title := strings.TrimSpace(types.Value(r.Title)); title != "" {
+		values.Set("title", title)
+	}
+	for _, tag := range normalizeSessionTags(r.Tags) {
+		values.Add("tags", tag)
+	}
+	if r.Concurrency > 0 {
+		values.Set("concurrency", strconv.FormatUint(uint64(r.Concurrency), 10))
+	}
+	if r.DryRun {
+		values.Set("dry_run", "true")
+	}
+	return values
+}
+
+// Percent returns the percentage of the matching sessions which have been
+// processed
+func (r SessionBulkResult) Percent() float64 {
+	if len(r.Sessions) == 0 {
+		return 100
+	}
+	return float64(r.Done+uint(len(r.Errors))) * 100 / float64(len(r.Sessions))
+}
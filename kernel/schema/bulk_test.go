@@ -0,0 +1,58 @@
+package schema_test
+
+import (
+	"testing"
+
+	// Packages
+	uuid "github.com/google/uuid"
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	types "github.com/mutablelogic/go-server/pkg/types"
+	assert "github.com/stretchr/testify/assert"
+)
+
+func TestSessionBulkRequestValidate(t *testing.T) {
+	assert := assert.New(t)
+
+	// A filter is required
+	assert.ErrorIs(schema.SessionBulkRequest{}.Validate(), schema.ErrBadParameter)
+	assert.ErrorIs(schema.SessionBulkRequest{Title: types.Ptr(" "), DryRun: true}.Validate(), schema.ErrBadParameter)
+	assert.ErrorIs(schema.SessionBulkRequest{Parent: types.Ptr(uuid.Nil)}.Validate(), schema.ErrBadParameter)
+	assert.NoError(schema.SessionBulkRequest{Tags: []string{"batch"}}.Validate())
+	assert.NoError(schema.SessionBulkRequest{Title: types.Ptr("draft")}.Validate())
+}
+
+func TestSessionBulkRequestQuery(t *testing.T) {
+	assert := assert.New(t)
+	parent := uuid.MustParse("11111111-1111-1111-1111-111111111111")
+	values := schema.SessionBulkRequest{
+		Parent:      types.Ptr(parent),
+		Title:       types.Ptr(" draft "),
+		Tags:        []string{"a", "b"},
+		Concurrency: 8,
+		DryRun:      true,
+	}.Query()
+	assert.Equal(parent.String(), values.Get("parent"))
+	assert.Equal("draft", values.Get("title"))
+	assert.Equal([]string{"a", "b"}, values["tags"])
+	assert.Equal("8", values.Get("concurrency"))
+	assert.Equal("true", values.Get("dry_run"))
+	assert.Empty(values.Get("user"))
+}
+
+func TestSessionBulkRequestLimit(t *testing.T) {
+	assert := assert.New(t)
+	assert.Equal(int(schema.BulkConcurrencyDefault), schema.SessionBulkRequest{}.Limit())
+	assert.Equal(2, schema.SessionBulkRequest{Concurrency: 2}.Limit())
+	assert.Equal(int(schema.BulkConcurrencyMax), schema.SessionBulkRequest{Concurrency: 1000}.Limit())
+}
+
+func TestSessionBulkResultPercent(t *testing.T) {
+	assert := assert.New(t)
+	assert.Equal(float64(100), schema.SessionBulkResult{}.Percent())
+	result := schema.SessionBulkResult{
+		Sessions: []uuid.UUID{uuid.New(), uuid.New(), uuid.New(), uuid.New()},
+		Done:     1,
+		Errors:   []schema.SessionBulkError{{Error: "failed"}},
+	}
+	assert.Equal(float64(50), result.Percent())
+}
@@ -1,10 +1,21 @@
 package schema
 
 import (
+	"net/url"
+	"slices"
+	"time"
+
 	// Packages
 	uuid "github.com/google/uuid"
+	types "github.com/mutablelogic/go-server/pkg/types"
 )
 
+////////////////////////////////////////////////////////////////////////////////
+// CONSTANTS
+
+// ChatIncludeTrace requests the tool trace in a chat response
+const ChatIncludeTrace = "trace"
+
 ////////////////////////////////////////////////////////////////////////////////
 // TYPES
 
@@ -12,16 +23,35 @@ import (
 type ChatRequest struct {
 	Session       uuid.UUID `json:"session" help:"Session ID"`
 	Text          string    `json:"text" arg:"" help:"User input text"`
-	Tools         []string  `json:"tools,omitzero" help:"Tool names to include (nil means all, empty means none)" optional:""`
+	Tools         []string  `json:"tools,omitzero" help:"Tool names or glob patterns to include, prefixed with ! to deny (nil means all, empty means none)" optional:""`
 	MaxIterations uint      `json:"max_iterations,omitempty" help:"Maximum tool-calling iterations (0 uses default)" optional:""`
 	SystemPrompt  string    `json:"system_prompt,omitempty" help:"Per-request system prompt appended to the session prompt" optional:""`
+	Prefill       string    `json:"prefill,omitempty" help:"Text which starts the reply, and which the model continues" optional:""`
+	StreamSmoothing
+}
+
+// StreamSmoothing controls coalescing of streamed deltas, to reduce render
+// thrash in terminal and web clients and the overhead of many tiny frames.
+type StreamSmoothing struct {
+	StreamInterval uint `json:"stream_interval,omitempty" help:"Minimum milliseconds between streamed deltas (0 disables smoothing)" optional:""`
+	StreamMinChunk uint `json:"stream_min_chunk,omitempty" help:"Release buffered deltas early once they reach this many bytes" optional:""`
+}
+
+// ChatQuery contains the query parameters accepted by the chat endpoint.
+type ChatQuery struct {
+	Include  []string `json:"include,omitempty" help:"Optional response sections to include, for example trace" example:"[\"trace\"]"`
+	DryRun   bool     `json:"dry_run,omitempty" help:"Return the request which would be sent to the provider, without sending it" optional:""`
+	Simulate bool     `json:"simulate,omitempty" help:"Return recorded fixtures or simulated results for tool calls, without running the tools" optional:""`
+	Async    bool     `json:"async,omitempty" help:"Return a job immediately and run the turn in the background, polling the job for the result" optional:""`
+	Priority Priority `json:"priority,omitempty" enum:"interactive,batch,scheduled" help:"Class of the turn when generations are queued (defaults to interactive, or batch with async)" optional:""`
+	Record   bool     `json:"record,omitempty" help:"Record every intermediate state of the turn, which can be stepped through at recording/{recording}" optional:""`
 }
 
 // SessionChannelRequest represents one inbound channel frame for a session.
 // The session is selected by the path parameter, not the frame body.
 type SessionChannelRequest struct {
 	Text          string   `json:"text" arg:"" help:"User input text"`
-	Tools         []string `json:"tools,omitzero" help:"Tool names to include (nil means all, empty means none)" optional:""`
+	Tools         []string `json:"tools,omitzero" help:"Tool names or glob patterns to include, prefixed with ! to deny (nil means all, empty means none)" optional:""`
 	MaxIterations uint     `json:"max_iterations,omitempty" help:"Maximum tool-calling iterations (0 uses default)" optional:""`
 	SystemPrompt  string   `json:"system_prompt,omitempty" help:"Per-request system prompt appended to the session prompt" optional:""`
 }
@@ -31,5 +61,71 @@ type ChatResponse struct {
 	ID      uint64    `json:"id,omitempty" help:"Persisted message row ID for the final reply when available" example:"42"`
 	Session uuid.UUID `json:"session,omitzero" help:"Session owning the final reply when available" optional:""`
 	CompletionResponse
-	Usage *UsageMeta `json:"usage,omitempty"`
+	Usage      *UsageMeta        `json:"usage,omitempty"`
+	Trace      []ToolTrace       `json:"trace,omitempty" help:"Tool calls made during the turn, when requested with include=trace" optional:""`
+	Request    uuid.UUID         `json:"request,omitzero" help:"Request ID in the archive, when archiving is enabled" optional:""`
+	Warnings   []string          `json:"warnings,omitempty" help:"Warnings about the turn, such as the use of a deprecated model name" optional:""`
+	Recording  uuid.UUID         `json:"recording,omitzero" help:"Recording of the intermediate states of the turn, when requested with record" optional:""`
+	Compaction *CompactionReport `json:"compaction,omitempty" help:"Messages removed from the start of the conversation so that it fits in the context window, when it was compacted" optional:""`
+}
+
+// ChatDryRun describes the request which would be sent to the provider for a
+// chat turn, without the provider being called or the session being changed.
+type ChatDryRun struct {
+	Session      uuid.UUID         `json:"session" help:"Session ID"`
+	Provider     string            `json:"provider" help:"Provider which would receive the request" example:"anthropic"`
+	Model        string            `json:"model" help:"Model which would receive the request" example:"claude-sonnet-4-5-20250929"`
+	SystemPrompt string            `json:"system_prompt,omitempty" help:"System prompt composed from the session, request and memory prompts" optional:""`
+	Options      map[string]any    `json:"options,omitempty" help:"Provider options resolved from the session generator settings" optional:"" example:"{\"max-tokens\":1024}"`
+	Tools        []string          `json:"tools,omitempty" help:"Names of the tools offered to the model" optional:""`
+	Messages     Conversation      `json:"messages" help:"Messages which would be sent, including the new user message"`
+	Tokens       uint              `json:"tokens" help:"Estimated input tokens for the system prompt and messages" example:"512"`
+	Warnings     []string          `json:"warnings,omitempty" help:"Warnings about the turn, such as the use of a deprecated model name" optional:""`
+	Compaction   *CompactionReport `json:"compaction,omitempty" help:"Messages which would be removed from the start of the conversation so that it fits in the context window" optional:""`
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// IncludeTrace returns true if the tool trace should be included in the response
+func (q ChatQuery) IncludeTrace() bool {
+	return slices.Contains(q.Include, ChatIncludeTrace)
+}
+
+// Query returns the query parameters for the chat endpoint
+func (q ChatQuery) Query() url.Values {
+	values := url.Values{}
+	for _, include := range q.Include {
+		if include != "" {
+			values.Add("include", include)
+		}
+	}
+	if q.DryRun {
+		values.Set("dry_run", "true")
+	}
+	if q.Simulate {
+		values.Set("simulate", "true")
+	}
+	if q.Async {
+		values.Set("async", "true")
+	}
+	if q.Priority != "" {
+		values.Set("priority", string(q.Priority))
+	}
+	if q.Record {
+		values.Set("record", "true")
+	}
+	return values
+}
+
+// Interval returns the smoothing interval, or zero when smoothing is disabled
+func (s StreamSmoothing) Interval() time.Duration {
+	return time.Duration(s.StreamInterval) * time.Millisecond
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// STRINGIFY
+
+func (r ChatDryRun) String() string {
+	return types.Stringify(r)
 }
@@ -0,0 +1,253 @@
+package schema
+
+import (
+	"time"
+
+	// Packages
+	uuid "github.com/google/uuid"
+	pg "github.com/mutablelogic/go-pg"
+	types "github.com/mutablelogic/go-server/pkg/types"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// CheckpointInsert is the state of a chat turn which is running a tool loop.
+// It is stored after each iteration, so that a turn which is interrupted,
+// such as when the server stops, can be resumed or ended with an accurate
+// history rather than lost.
+type CheckpointInsert struct {
+	Session   uuid.UUID     `json:"session" help:"Session of the chat turn"`
+	User      uuid.UUID     `json:"user,omitempty" help:"User who made the turn" optional:""`
+	Owner     uuid.UUID     `json:"owner" help:"Replica which is running the turn"`
+	Iteration uint          `json:"iteration" help:"Tool-calling iteration of the turn, from zero"`
+	Request   ChatRequest   `json:"request" help:"Request for the turn"`
+	Messages  Conversation  `json:"messages" help:"Messages of the turn which are not yet stored, starting with the user message"`
+	Pending   []uint64      `json:"pending,omitempty" help:"Pending input which was sent in the turn, and is removed when the turn is stored" optional:""`
+	Usage     []UsageInsert `json:"usage,omitempty" help:"Usage of each iteration" optional:""`
+	Overhead  uint          `json:"overhead,omitempty" help:"Tokens used by the turn which are not in the messages" optional:""`
+}
+
+// Checkpoint is the stored state of a chat turn which is running a tool loop
+type Checkpoint struct {
+	CheckpointInsert
+	CreatedAt  time.Time `json:"created_at" help:"Time the first iteration was stored" readonly:""`
+	ModifiedAt time.Time `json:"modified_at" help:"Time the checkpoint was last stored or its replica was last seen" readonly:""`
+}
+
+// CheckpointList is a list of checkpoints, oldest first
+type CheckpointList []*Checkpoint
+
+// CheckpointSessionSelector selects the checkpoint of a session
+type CheckpointSessionSelector uuid.UUID
+
+// CheckpointStaleSelector selects the checkpoints which have not been
+// stored, or seen by the replica which is running the turn, since a time
+type CheckpointStaleSelector time.Time
+
+// CheckpointOwnerSelector selects the checkpoints of the turns which a
+// replica is running
+type CheckpointOwnerSelector uuid.UUID
+
+// CheckpointClaimSelector selects the checkpoint of a session which a
+// replica has claimed, so that it is only removed by that replica
+type CheckpointClaimSelector struct {
+	Session uuid.UUID
+	Owner   uuid.UUID
+}
+
+// CheckpointClaim makes a replica the owner of the checkpoints which are
+// selected, so that only that replica recovers their turns
+type CheckpointClaim uuid.UUID
+
+// CheckpointRelease gives up the claim of a replica on a checkpoint whose
+// turn it could not recover, so that it is recovered again once abandoned
+type CheckpointRelease struct{}
+
+// CheckpointTouch marks the checkpoints of a replica as seen, so that they
+// are not taken as abandoned while a tool runs for a long time
+type CheckpointTouch struct{}
+
+////////////////////////////////////////////////////////////////////////////////
+// STRINGIFY
+
+func (c CheckpointInsert) String() string {
+	return types.Stringify(c)
+}
+
+func (c Checkpoint) String() string {
+	return types.Stringify(c)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// Interrupted returns the tool calls of the last reply in the checkpoint
+// which have no results, because the turn stopped while they ran
+func (c CheckpointInsert) Interrupted() []ToolCall {
+	if len(c.Messages) == 0 {
+		return nil
+	}
+	last := c.Messages[len(c.Messages)-1]
+	if last == nil || last.Role != RoleAssistant {
+		return nil
+	}
+	return last.ToolCalls()
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS - SELECTOR
+
+func (s CheckpointSessionSelector) Select(bind *pg.Bind, op pg.Op) (string, error) {
+	if session := uuid.UUID(s); session == uuid.Nil {
+		return "", ErrBadParameter.With("session is required")
+	} else {
+		bind.Set("session", session)
+	}
+
+	switch op {
+	case pg.Get:
+		return bind.Query("checkpoint.select"), nil
+	case pg.Delete:
+		return bind.Query("checkpoint.delete"), nil
+	default:
+		return "", ErrNotImplemented.Withf("unsupported CheckpointSessionSelector operation %q", op)
+	}
+}
+
+func (s CheckpointStaleSelector) Select(bind *pg.Bind, op pg.Op) (string, error) {
+	if before := time.Time(s); before.IsZero() {
+		return "", ErrBadParameter.With("time is required")
+	} else {
+		bind.Set("before", before)
+	}
+
+	switch op {
+	case pg.List:
+		return bind.Query("checkpoint.list_stale"), nil
+	case pg.Update:
+		return bind.Query("checkpoint.claim"), nil
+	default:
+		return "", ErrNotImplemented.Withf("unsupported CheckpointStaleSelector operation %q", op)
+	}
+}
+
+func (s CheckpointOwnerSelector) Select(bind *pg.Bind, op pg.Op) (string, error) {
+	if owner := uuid.UUID(s); owner == uuid.Nil {
+		return "", ErrBadParameter.With("owner is required")
+	} else {
+		bind.Set("owner", owner)
+	}
+
+	switch op {
+	case pg.Update:
+		return bind.Query("checkpoint.touch"), nil
+	default:
+		return "", ErrNotImplemented.Withf("unsupported CheckpointOwnerSelector operation %q", op)
+	}
+}
+
+func (s CheckpointClaimSelector) Select(bind *pg.Bind, op pg.Op) (string, error) {
+	if s.Session == uuid.Nil {
+		return "", ErrBadParameter.With("session is required")
+	} else if s.Owner == uuid.Nil {
+		return "", ErrBadParameter.With("owner is required")
+	}
+	bind.Set("session", s.Session)
+	bind.Set("owner", s.Owner)
+
+	switch op {
+	case pg.Update:
+		return bind.Query("checkpoint.release"), nil
+	case pg.Delete:
+		return bind.Query("checkpoint.delete_claimed"), nil
+	default:
+		return "", ErrNotImplemented.Withf("unsupported CheckpointClaimSelector operation %q", op)
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS - READER
+
+// Expected column order: session, user, owner, iteration, request, messages, pending, usage, overhead, created_at, modified_at.
+func (c *Checkpoint) Scan(row pg.Row) error {
+	var user *uuid.UUID
+	if err := row.Scan(
+		&c.Session, &user, &c.Owner, &c.Iteration, &c.Request, &c.Messages,
+		&c.Pending, &c.Usage, &c.Overhead, &c.CreatedAt, &c.ModifiedAt,
+	); err != nil {
+		return err
+	}
+	c.User = types.Value(user)
+	return nil
+}
+
+func (l *CheckpointList) Scan(row pg.Row) error {
+	var checkpoint Checkpoint
+	if err := checkpoint.Scan(row); err != nil {
+		return err
+	}
+	*l = append(*l, &checkpoint)
+	return nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS - WRITER
+
+func (c CheckpointInsert) Insert(bind *pg.Bind) (string, error) {
+	if c.Session == uuid.Nil {
+		return "", ErrBadParameter.With("session is required")
+	} else if c.Owner == uuid.Nil {
+		return "", ErrBadParameter.With("owner is required")
+	} else if len(c.Messages) == 0 {
+		return "", ErrBadParameter.With("messages are required")
+	}
+	bind.Set("session", c.Session)
+	if c.User == uuid.Nil {
+		bind.Set("user", nil)
+	} else {
+		bind.Set("user", c.User)
+	}
+	bind.Set("owner", c.Owner)
+	bind.Set("iteration", c.Iteration)
+	bind.Set("request", c.Request)
+	bind.Set("messages", c.Messages)
+	bind.Set("pending", append([]uint64{}, c.Pending...))
+	bind.Set("usage", append([]UsageInsert{}, c.Usage...))
+	bind.Set("overhead", c.Overhead)
+
+	return bind.Query("checkpoint.upsert"), nil
+}
+
+func (c CheckpointInsert) Update(_ *pg.Bind) error {
+	return ErrNotImplemented.With("checkpoints are replaced with insert")
+}
+
+func (CheckpointTouch) Insert(_ *pg.Bind) (string, error) {
+	return "", ErrNotImplemented.With("checkpoints are touched with update")
+}
+
+func (CheckpointTouch) Update(_ *pg.Bind) error {
+	return nil
+}
+
+func (CheckpointRelease) Insert(_ *pg.Bind) (string, error) {
+	return "", ErrNotImplemented.With("checkpoints are released with update")
+}
+
+func (CheckpointRelease) Update(_ *pg.Bind) error {
+	return nil
+}
+
+func (CheckpointClaim) Insert(_ *pg.Bind) (string, error) {
+	return "", ErrNotImplemented.With("checkpoints are claimed with update")
+}
+
+func (c CheckpointClaim) Update(bind *pg.Bind) error {
+	if owner := uuid.UUID(c); owner == uuid.Nil {
+		return ErrBadParameter.With("owner is required")
+	} else {
+		bind.Set("owner", owner)
+	}
+	return nil
+}
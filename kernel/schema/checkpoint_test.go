@@ -0,0 +1,116 @@
+package schema_test
+
+import (
+	"testing"
+	"time"
+
+	// Packages
+	uuid "github.com/google/uuid"
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	pg "github.com/mutablelogic/go-pg"
+	types "github.com/mutablelogic/go-server/pkg/types"
+	assert "github.com/stretchr/testify/assert"
+)
+
+func TestCheckpointInterrupted(t *testing.T) {
+	assert := assert.New(t)
+	call := schema.ContentBlock{ToolCall: &schema.ToolCall{ID: "call_1", Name: "search"}}
+	result := schema.NewToolResult("call_1", "search", "found")
+
+	// The tool calls of the last reply have no results
+	checkpoint := schema.CheckpointInsert{Messages: schema.Conversation{
+		{Role: schema.RoleUser, Content: []schema.ContentBlock{{Text: types.Ptr("hello")}}},
+		{Role: schema.RoleAssistant, Content: []schema.ContentBlock{call}},
+	}}
+	if calls := checkpoint.Interrupted(); assert.Len(calls, 1) {
+		assert.Equal("call_1", calls[0].ID)
+	}
+
+	// Once the results are in the checkpoint, no calls are interrupted
+	checkpoint.Messages = append(checkpoint.Messages, &schema.Message{Role: schema.RoleUser, Content: []schema.ContentBlock{result}})
+	assert.Empty(checkpoint.Interrupted())
+	assert.Empty(schema.CheckpointInsert{}.Interrupted())
+}
+
+func TestCheckpointSelectors(t *testing.T) {
+	assert := assert.New(t)
+	session := uuid.New()
+
+	bind := pg.NewBind()
+	query, err := schema.CheckpointSessionSelector(session).Select(bind, pg.Get)
+	if assert.NoError(err) {
+		assert.NotEmpty(query)
+		assert.Equal(session, bind.Get("session"))
+	}
+	_, err = schema.CheckpointSessionSelector(uuid.Nil).Select(pg.NewBind(), pg.Get)
+	assert.ErrorIs(err, schema.ErrBadParameter)
+	_, err = schema.CheckpointSessionSelector(session).Select(pg.NewBind(), pg.List)
+	assert.ErrorIs(err, schema.ErrNotImplemented)
+
+	before := time.Now()
+	bind = pg.NewBind()
+	if _, err := schema.CheckpointStaleSelector(before).Select(bind, pg.List); assert.NoError(err) {
+		assert.Equal(before, bind.Get("before"))
+	}
+	_, err = schema.CheckpointStaleSelector(time.Time{}).Select(pg.NewBind(), pg.List)
+	assert.ErrorIs(err, schema.ErrBadParameter)
+
+	_, err = schema.CheckpointOwnerSelector(uuid.Nil).Select(pg.NewBind(), pg.Update)
+	assert.ErrorIs(err, schema.ErrBadParameter)
+}
+
+func TestCheckpointClaim(t *testing.T) {
+	assert := assert.New(t)
+	session, owner := uuid.New(), uuid.New()
+
+	// Abandoned checkpoints are claimed with an update, which sets the owner
+	bind := pg.NewBind("schema", "llm", "checkpoint.claim", "CLAIM", "checkpoint.delete_claimed", "DELETE")
+	if query, err := schema.CheckpointStaleSelector(time.Now()).Select(bind, pg.Update); assert.NoError(err) {
+		assert.Equal("CLAIM", query)
+	}
+	if assert.NoError(schema.CheckpointClaim(owner).Update(bind)) {
+		assert.Equal(owner, bind.Get("owner"))
+	}
+	assert.ErrorIs(schema.CheckpointClaim(uuid.Nil).Update(pg.NewBind()), schema.ErrBadParameter)
+
+	// A claimed checkpoint is only deleted by its owner
+	if query, err := (schema.CheckpointClaimSelector{Session: session, Owner: owner}).Select(bind, pg.Delete); assert.NoError(err) {
+		assert.Equal("DELETE", query)
+		assert.Equal(session, bind.Get("session"))
+		assert.Equal(owner, bind.Get("owner"))
+	}
+	bind = pg.NewBind("schema", "llm", "checkpoint.release", "RELEASE")
+	if query, err := (schema.CheckpointClaimSelector{Session: session, Owner: owner}).Select(bind, pg.Update); assert.NoError(err) {
+		assert.Equal("RELEASE", query)
+	}
+	_, err := schema.CheckpointClaimSelector{Session: session}.Select(pg.NewBind(), pg.Delete)
+	assert.ErrorIs(err, schema.ErrBadParameter)
+	_, err = schema.CheckpointClaimSelector{Session: session, Owner: owner}.Select(pg.NewBind(), pg.Get)
+	assert.ErrorIs(err, schema.ErrNotImplemented)
+}
+
+func TestCheckpointInsert(t *testing.T) {
+	assert := assert.New(t)
+	checkpoint := schema.CheckpointInsert{
+		Session:  uuid.New(),
+		Owner:    uuid.New(),
+		Messages: schema.Conversation{{Role: schema.RoleUser}},
+	}
+
+	// A checkpoint without a user stores a null user
+	bind := pg.NewBind()
+	if _, err := checkpoint.Insert(bind); assert.NoError(err) {
+		assert.Nil(bind.Get("user"))
+		assert.Equal([]uint64{}, bind.Get("pending"))
+	}
+
+	// The session, owner and messages are required
+	for _, invalid := range []schema.CheckpointInsert{
+		{Owner: checkpoint.Owner, Messages: checkpoint.Messages},
+		{Session: checkpoint.Session, Messages: checkpoint.Messages},
+		{Session: checkpoint.Session, Owner: checkpoint.Owner},
+	} {
+		_, err := invalid.Insert(pg.NewBind())
+		assert.ErrorIs(err, schema.ErrBadParameter)
+	}
+}
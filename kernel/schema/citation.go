@@ -0,0 +1,111 @@
+package schema
+
+import (
+	"mime"
+	"strings"
+
+	// Packages
+	types "github.com/mutablelogic/go-server/pkg/types"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// Citation refers to a range within a document attachment which supports
+// some text emitted by the model. Documents are numbered from zero in the
+// order they appear in the conversation (see Conversation.Documents). Start
+// is inclusive and End is exclusive; character and block ranges count from
+// zero, and page ranges count from one.
+//
+// A web citation refers to a web page found by the provider, such as with
+// search grounding, rather than a document. Its range is the byte range of
+// the supported text within the text content, and Document is not used.
+type Citation struct {
+	Type     string `json:"type" help:"Unit of the cited range" enum:"char,page,block,web" example:"char"`
+	Document uint   `json:"document" help:"Index of the cited document within the conversation" example:"0"`
+	Title    string `json:"title,omitempty" help:"Title of the cited document" optional:""`
+	URL      string `json:"url,omitempty" help:"URL of the cited web page" optional:""`
+	Text     string `json:"text,omitempty" help:"Cited text as reported by the provider" optional:"" example:"The grass is green."`
+	Start    uint   `json:"start" help:"Start of the cited range (inclusive)" example:"0"`
+	End      uint   `json:"end" help:"End of the cited range (exclusive)" example:"19"`
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// CONSTANTS
+
+// Citation range units
+const (
+	CitationChar  = "char"  // Character range within a plain text document
+	CitationPage  = "page"  // Page range within a PDF document
+	CitationBlock = "block" // Content block range within a custom document
+	CitationWeb   = "web"   // Byte range of the text content supported by a web page
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// STRINGIFY
+
+func (c Citation) String() string {
+	return types.Stringify(c)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// Documents returns the document attachments in the conversation, in the
+// order in which citations refer to them. Images and system messages are
+// not counted as documents.
+func (c Conversation) Documents() []*Attachment {
+	var result []*Attachment
+	for _, message := range c {
+		if message == nil || message.Role == RoleSystem {
+			continue
+		}
+		for i := range message.Content {
+			if attachment := message.Content[i].Attachment; attachment != nil && isDocument(attachment) {
+				result = append(result, attachment)
+			}
+		}
+	}
+	return result
+}
+
+// ResolveCitation returns the source excerpt for a citation, using the
+// documents in the conversation.
+func (c Conversation) ResolveCitation(citation Citation) (string, error) {
+	return citation.Excerpt(c.Documents())
+}
+
+// Excerpt returns the source excerpt which the citation refers to. Character
+// ranges are read from plain text documents; for other documents and web
+// pages, the cited text reported by the provider is returned.
+func (c Citation) Excerpt(documents []*Attachment) (string, error) {
+	if c.Type == CitationWeb {
+		if c.Text == "" {
+			return "", ErrNotImplemented.Withf("cannot resolve web citation for %q", c.URL)
+		}
+		return c.Text, nil
+	}
+	if c.Document >= uint(len(documents)) {
+		return "", ErrNotFound.Withf("cited document %d", c.Document)
+	}
+	document := documents[c.Document]
+	if c.Type == CitationChar && document.IsText() && len(document.Data) > 0 {
+		text := []rune(string(document.Data))
+		if c.Start > c.End || c.End > uint(len(text)) {
+			return "", ErrBadParameter.Withf("citation range %d-%d is outside document %d", c.Start, c.End, c.Document)
+		}
+		return string(text[c.Start:c.End]), nil
+	}
+	if c.Text == "" {
+		return "", ErrNotImplemented.Withf("cannot resolve %q citation for document %d", c.Type, c.Document)
+	}
+	return c.Text, nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+func isDocument(attachment *Attachment) bool {
+	mediaType, _, _ := mime.ParseMediaType(attachment.ContentType)
+	return !strings.HasPrefix(mediaType, "image/")
+}
@@ -0,0 +1,63 @@
+package schema_test
+
+import (
+	"testing"
+
+	// Packages
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	assert "github.com/stretchr/testify/assert"
+)
+
+func testCitationConversation() schema.Conversation {
+	return schema.Conversation{
+		{Role: schema.RoleSystem, Content: []schema.ContentBlock{
+			{Attachment: &schema.Attachment{ContentType: "text/plain", Data: []byte("ignored")}},
+		}},
+		{Role: schema.RoleUser, Content: []schema.ContentBlock{
+			{Attachment: &schema.Attachment{ContentType: "image/png", Data: []byte{0x89}}},
+			{Attachment: &schema.Attachment{ContentType: "text/plain; charset=utf-8", Data: []byte("Grüne Wiese. The grass is green.")}},
+			{Attachment: &schema.Attachment{ContentType: "application/pdf", Data: []byte("%PDF-1.4")}},
+		}},
+	}
+}
+
+func TestConversationDocuments(t *testing.T) {
+	assert := assert.New(t)
+	documents := testCitationConversation().Documents()
+	if assert.Len(documents, 2) {
+		assert.Equal("text/plain; charset=utf-8", documents[0].ContentType)
+		assert.Equal("application/pdf", documents[1].ContentType)
+	}
+}
+
+func TestConversationResolveCitation(t *testing.T) {
+	assert := assert.New(t)
+	conversation := testCitationConversation()
+
+	// Character ranges are read from the document
+	excerpt, err := conversation.ResolveCitation(schema.Citation{Type: schema.CitationChar, Document: 0, Start: 13, End: 32})
+	assert.NoError(err)
+	assert.Equal("The grass is green.", excerpt)
+
+	// Page ranges fall back to the cited text
+	excerpt, err = conversation.ResolveCitation(schema.Citation{Type: schema.CitationPage, Document: 1, Text: "Page two", Start: 2, End: 3})
+	assert.NoError(err)
+	assert.Equal("Page two", excerpt)
+
+	_, err = conversation.ResolveCitation(schema.Citation{Type: schema.CitationPage, Document: 1, Start: 2, End: 3})
+	assert.ErrorIs(err, schema.ErrNotImplemented)
+
+	_, err = conversation.ResolveCitation(schema.Citation{Type: schema.CitationChar, Document: 2})
+	assert.ErrorIs(err, schema.ErrNotFound)
+
+	_, err = conversation.ResolveCitation(schema.Citation{Type: schema.CitationChar, Document: 0, Start: 10, End: 100})
+	assert.ErrorIs(err, schema.ErrBadParameter)
+
+	// Web citations do not refer to a document
+	excerpt, err = conversation.ResolveCitation(schema.Citation{Type: schema.CitationWeb, Document: 5, URL: "https://example.com/", Text: "The sky is blue."})
+	assert.NoError(err)
+	assert.Equal("The sky is blue.", excerpt)
+
+	_, err = conversation.ResolveCitation(schema.Citation{Type: schema.CitationWeb, URL: "https://example.com/"})
+	assert.ErrorIs(err, schema.ErrNotImplemented)
+}
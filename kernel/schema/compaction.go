@@ -0,0 +1,195 @@
+package schema
+
+import (
+	"time"
+
+	// Packages
+	uuid "github.com/google/uuid"
+	pg "github.com/mutablelogic/go-pg"
+	types "github.com/mutablelogic/go-server/pkg/types"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// CompactionStrategy is how the start of a conversation is compacted when it
+// approaches the context window of the model
+type CompactionStrategy string
+
+// CompactionReport describes the messages which were removed from the start
+// of a conversation so that it fits in the context window of the model
+type CompactionReport struct {
+	Strategy CompactionStrategy `json:"strategy" enum:"summarize,truncate,sliding-window" help:"Strategy which compacted the conversation" example:"summarize"`
+	Messages uint               `json:"messages" help:"Number of messages removed from the start of the conversation" example:"24"`
+	Through  uint64             `json:"through,omitempty" help:"Row ID of the last message removed" optional:"" example:"42"`
+	Before   uint               `json:"tokens_before" help:"Estimated tokens for the system prompt and messages before compaction" example:"164000"`
+	After    uint               `json:"tokens_after" help:"Estimated tokens for the system prompt and messages after compaction" example:"52000"`
+	Window   uint               `json:"window" help:"Input token limit of the model" example:"200000"`
+	Summary  string             `json:"summary,omitempty" help:"Summary which replaces the removed messages, with the summarize strategy" optional:""`
+}
+
+// CompactionInsert is the last message which compaction has removed from the
+// conversation of a session, and the text which replaces the messages up to
+// it, so that later turns remove the same messages
+type CompactionInsert struct {
+	Session uuid.UUID `json:"session" help:"Session whose conversation was compacted"`
+	Through uint64    `json:"through" help:"Row ID of the last message removed"`
+	Summary string    `json:"summary,omitempty" help:"Text which replaces the removed messages, with the summarize strategy" optional:""`
+}
+
+// Compaction is the stored compaction of a session
+type Compaction struct {
+	CompactionInsert
+	ModifiedAt time.Time `json:"modified_at" help:"Time the conversation was last compacted" readonly:""`
+}
+
+// CompactionSessionSelector selects the compaction of a session
+type CompactionSessionSelector uuid.UUID
+
+////////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+const (
+	CompactionSummarize     CompactionStrategy = "summarize"      // summarize the removed messages with a model
+	CompactionTruncate      CompactionStrategy = "truncate"       // drop the oldest messages until the rest fit
+	CompactionSlidingWindow CompactionStrategy = "sliding-window" // keep a fixed number of the latest turns
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// STRINGIFY
+
+func (r CompactionReport) String() string {
+	return types.Stringify(r)
+}
+
+func (c Compaction) String() string {
+	return types.Stringify(c)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// SELECTORS
+
+func (s CompactionSessionSelector) Select(bind *pg.Bind, op pg.Op) (string, error) {
+	if session := uuid.UUID(s); session == uuid.Nil {
+		return "", ErrBadParameter.With("session is required")
+	} else {
+		bind.Set("session", session)
+	}
+
+	switch op {
+	case pg.Get:
+		return bind.Query("compaction.select"), nil
+	default:
+		return "", ErrNotImplemented.Withf("unsupported CompactionSessionSelector operation %q", op)
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS - READER
+
+// Expected column order: session, through, summary, modified_at.
+func (c *Compaction) Scan(row pg.Row) error {
+	return row.Scan(&c.Session, &c.Through, &c.Summary, &c.ModifiedAt)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS - WRITER
+
+func (c CompactionInsert) Insert(bind *pg.Bind) (string, error) {
+	if c.Session == uuid.Nil {
+		return "", ErrBadParameter.With("session is required")
+	} else if c.Through == 0 {
+		return "", ErrBadParameter.With("through is required")
+	}
+	bind.Set("session", c.Session)
+	bind.Set("through", c.Through)
+	bind.Set("summary", c.Summary)
+	return bind.Query("compaction.upsert"), nil
+}
+
+func (c CompactionInsert) Update(_ *pg.Bind) error {
+	return ErrNotImplemented.With("compactions are replaced with insert")
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// Turns returns the index of the first message of each turn, where a turn
+// starts with a user message which is not a tool result, so that tool calls
+// and their results are always in the same turn. The first index is always
+// zero for a conversation which is not empty.
+func (s Conversation) Turns() []int {
+	var result []int
+	for i, message := range s {
+		if i == 0 || (message != nil && startsTurn(message)) {
+			result = append(result, i)
+		}
+	}
+	return result
+}
+
+// CompactionCut returns the index of the first message to keep so that the
+// messages from that index use at most target tokens. The index is the start
+// of a turn, and the last turn is always kept, so zero is returned when the
+// conversation has only one turn.
+func (s Conversation) CompactionCut(target uint) int {
+	turns := s.Turns()
+	if len(turns) < 2 {
+		return 0
+	}
+	remainder := s.EstimateTokens()
+	for i, start := range turns[:len(turns)-1] {
+		if remainder <= target {
+			return start
+		}
+		remainder -= s[start:turns[i+1]].EstimateTokens()
+	}
+	return turns[len(turns)-1]
+}
+
+// Compact returns the messages from the cut index, and the messages before it
+// which are removed. A turn with a pinned message is kept whole, so that its
+// tool calls and results stay together.
+func (s Conversation) Compact(cut int) (Conversation, Conversation) {
+	cut = min(max(cut, 0), len(s))
+	turns := append(s[:cut].Turns(), cut)
+	kept, removed := make(Conversation, 0, len(s)), make(Conversation, 0, cut)
+	for i, start := range turns[:len(turns)-1] {
+		turn := s[start:turns[i+1]]
+		if turn.pinned() {
+			kept = append(kept, turn...)
+		} else {
+			removed = append(removed, turn...)
+		}
+	}
+	return append(kept, s[cut:]...), removed
+}
+
+// EstimateTokens returns the tokens for the messages, counted by the provider
+// or estimated from the content when they were not counted
+func (s Conversation) EstimateTokens() uint {
+	var total uint
+	for _, message := range s {
+		if message == nil {
+			continue
+		} else if message.Tokens > 0 {
+			total += message.Tokens
+		} else {
+			total += message.EstimateTokens()
+		}
+	}
+	return total
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// pinned returns true if any message of the conversation is pinned
+func (s Conversation) pinned() bool {
+	for _, message := range s {
+		if message != nil && message.Pinned() {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,98 @@
+package schema_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	// Packages
+	uuid "github.com/google/uuid"
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	pg "github.com/mutablelogic/go-pg"
+	types "github.com/mutablelogic/go-server/pkg/types"
+	assert "github.com/stretchr/testify/assert"
+)
+
+func compactionTestConversation() schema.Conversation {
+	text := func(id uint64, role, text string, tokens uint) *schema.Message {
+		return &schema.Message{ID: id, Role: role, Tokens: tokens, Content: []schema.ContentBlock{{Text: types.Ptr(text)}}}
+	}
+	return schema.Conversation{
+		text(1, schema.RoleUser, "weather?", 10),
+		{ID: 2, Role: schema.RoleAssistant, Tokens: 10, Content: []schema.ContentBlock{{ToolCall: &schema.ToolCall{ID: "1", Name: "weather"}}}},
+		{ID: 3, Role: schema.RoleUser, Tokens: 10, Content: []schema.ContentBlock{{ToolResult: &schema.ToolResult{ID: "1", Name: "weather", Content: json.RawMessage(`"sunny"`)}}}},
+		text(4, schema.RoleAssistant, "sunny", 10),
+		text(5, schema.RoleUser, "and tomorrow?", 10),
+		text(6, schema.RoleAssistant, "rain", 10),
+		text(7, schema.RoleUser, "thanks", 10),
+	}
+}
+
+func TestConversationTurns(t *testing.T) {
+	assert := assert.New(t)
+	conversation := compactionTestConversation()
+
+	// Tool results do not start a turn
+	assert.Equal([]int{0, 4, 6}, conversation.Turns())
+	assert.Equal([]int{0, 2, 4}, conversation[2:].Turns())
+	assert.Nil(schema.Conversation{}.Turns())
+}
+
+func TestConversationCompactionCut(t *testing.T) {
+	assert := assert.New(t)
+	conversation := compactionTestConversation()
+
+	assert.Equal(uint(70), conversation.EstimateTokens())
+	assert.Equal(0, conversation.CompactionCut(70))
+	assert.Equal(4, conversation.CompactionCut(30))
+	assert.Equal(6, conversation.CompactionCut(20))
+
+	// The last turn is always kept
+	assert.Equal(6, conversation.CompactionCut(0))
+	assert.Equal(0, conversation[6:].CompactionCut(0))
+}
+
+func TestConversationCompact(t *testing.T) {
+	assert := assert.New(t)
+	conversation := compactionTestConversation()
+
+	kept, removed := conversation.Compact(4)
+	assert.Equal(conversation[4:], kept)
+	assert.Equal(conversation[:4], removed)
+
+	// A turn with a pinned message is kept whole, with its tool call and result
+	conversation[2].Meta = map[string]any{schema.MessageMetaPinned: true}
+	kept, removed = conversation.Compact(6)
+	assert.Equal(slicesConcat(conversation[:4], conversation[6:]), kept)
+	assert.Equal(conversation[4:6], removed)
+
+	kept, removed = conversation.Compact(0)
+	assert.Equal(conversation, kept)
+	assert.Empty(removed)
+}
+
+func TestCompactionSelectorAndInsert(t *testing.T) {
+	assert := assert.New(t)
+	session := uuid.New()
+
+	bind := pg.NewBind("schema", "llm", "compaction.select", "SELECT", "compaction.upsert", "UPSERT")
+	if query, err := schema.CompactionSessionSelector(session).Select(bind, pg.Get); assert.NoError(err) {
+		assert.Equal("SELECT", query)
+		assert.Equal(session, bind.Get("session"))
+	}
+	_, err := schema.CompactionSessionSelector(uuid.Nil).Select(pg.NewBind(), pg.Get)
+	assert.ErrorIs(err, schema.ErrBadParameter)
+	_, err = schema.CompactionSessionSelector(session).Select(pg.NewBind(), pg.Delete)
+	assert.ErrorIs(err, schema.ErrNotImplemented)
+
+	if query, err := (schema.CompactionInsert{Session: session, Through: 42, Summary: "summary"}).Insert(bind); assert.NoError(err) {
+		assert.Equal("UPSERT", query)
+		assert.Equal(uint64(42), bind.Get("through"))
+		assert.Equal("summary", bind.Get("summary"))
+	}
+	_, err = schema.CompactionInsert{Session: session}.Insert(pg.NewBind())
+	assert.ErrorIs(err, schema.ErrBadParameter)
+}
+
+func slicesConcat(a, b schema.Conversation) schema.Conversation {
+	return append(append(schema.Conversation{}, a...), b...)
+}
@@ -0,0 +1,52 @@
+package schema
+
+import (
+	// Packages
+	types "github.com/mutablelogic/go-server/pkg/types"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// CompletionRequest is a stateless request to reply to a conversation which
+// is kept by the client rather than in a session. The last message is the
+// input, which is from the user or holds the results of tool calls.
+type CompletionRequest struct {
+	GeneratorMeta
+	Messages Conversation     `json:"messages" help:"Conversation to reply to, ending with the input"`
+	Tools    []ToolDefinition `json:"tools,omitempty" help:"Tools which the client runs. Calls to these tools are returned to the client rather than run." optional:""`
+}
+
+// ToolDefinition describes a tool which is run by the client
+type ToolDefinition struct {
+	Name        string     `json:"name" help:"Tool name" example:"get_weather"`
+	Description string     `json:"description,omitempty" help:"What the tool does, for the model" optional:"" example:"Get the current weather for a city"`
+	Parameters  JSONSchema `json:"parameters,omitempty" help:"JSON schema for the tool input" optional:"" example:"{\"type\":\"object\",\"properties\":{\"city\":{\"type\":\"string\"}}}"`
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// STRINGIFY
+
+func (r CompletionRequest) String() string {
+	return types.Stringify(r)
+}
+
+func (t ToolDefinition) String() string {
+	return types.Stringify(t)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// Input returns the last message of the request, which the model replies to,
+// and the messages before it. It returns an error if there is no input.
+func (r CompletionRequest) Input() (Conversation, *Message, error) {
+	if len(r.Messages) == 0 {
+		return nil, nil, ErrBadParameter.With("messages are required")
+	}
+	last := r.Messages[len(r.Messages)-1]
+	if last == nil || last.Role != RoleUser {
+		return nil, nil, ErrBadParameter.With("the last message must be from the user, or hold the results of tool calls")
+	}
+	return r.Messages[:len(r.Messages)-1], last, nil
+}
@@ -0,0 +1,68 @@
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	// Packages
+	types "github.com/mutablelogic/go-server/pkg/types"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// Context is machine-generated context about the environment of a turn, such
+// as the current working set, the user profile or feature flags. It is sent
+// with the user message of the turn, and is marked so that the model can tell
+// it apart from the input of the user.
+type Context struct {
+	Name    string          `json:"name" help:"Name of the source of the context" example:"feature_flags"`
+	Content json.RawMessage `json:"content" help:"Context as a JSON value" example:"{\"beta\":true}"`
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// LIFECYCLE
+
+// NewContext returns a context block with the value encoded as JSON
+func NewContext(name string, value any) (*ContentBlock, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, ErrBadParameter.Withf("context %q: %v", name, err)
+	}
+	return &ContentBlock{Context: &Context{Name: name, Content: data}}, nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// STRINGIFY
+
+func (c Context) String() string {
+	return types.Stringify(c)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// Text returns the context as text for providers, in an element which marks
+// it as generated by the system rather than written by the user
+func (c Context) Text() string {
+	content := bytes.TrimSpace(c.Content)
+	if len(content) == 0 {
+		content = []byte("null")
+	}
+	return fmt.Sprintf("<context name=%q source=\"system\">\n%s\n</context>", c.Name, content)
+}
+
+// Equal returns true if the contexts have the same name and the same JSON
+// value, which may be formatted differently once it has been stored
+func (c Context) Equal(other Context) bool {
+	if c.Name != other.Name {
+		return false
+	}
+	var a, b any
+	if json.Unmarshal(c.Content, &a) != nil || json.Unmarshal(other.Content, &b) != nil {
+		return bytes.Equal(bytes.TrimSpace(c.Content), bytes.TrimSpace(other.Content))
+	}
+	return reflect.DeepEqual(a, b)
+}
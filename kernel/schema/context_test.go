@@ -0,0 +1,51 @@
+package schema_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	// Packages
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	types "github.com/mutablelogic/go-server/pkg/types"
+	assert "github.com/stretchr/testify/assert"
+)
+
+func TestNewContext(t *testing.T) {
+	assert := assert.New(t)
+
+	block, err := schema.NewContext("feature_flags", map[string]any{"beta": true})
+	if assert.NoError(err) && assert.NotNil(block.Context) {
+		assert.Equal("feature_flags", block.Context.Name)
+		assert.JSONEq(`{"beta":true}`, string(block.Context.Content))
+		assert.Equal("<context name=\"feature_flags\" source=\"system\">\n{\"beta\":true}\n</context>", block.Context.Text())
+	}
+
+	// Values which cannot be encoded are rejected
+	_, err = schema.NewContext("feature_flags", func() {})
+	assert.ErrorIs(err, schema.ErrBadParameter)
+}
+
+func TestContextEqual(t *testing.T) {
+	assert := assert.New(t)
+
+	a := schema.Context{Name: "user_profile", Content: json.RawMessage(`{"name":"Alice","groups":["admin"]}`)}
+	assert.True(a.Equal(schema.Context{Name: "user_profile", Content: json.RawMessage(`{"groups": ["admin"], "name": "Alice"}`)}))
+	assert.False(a.Equal(schema.Context{Name: "user_profile", Content: json.RawMessage(`{"name":"Bob","groups":["admin"]}`)}))
+	assert.False(a.Equal(schema.Context{Name: "feature_flags", Content: a.Content}))
+}
+
+func TestValidateContext(t *testing.T) {
+	assert := assert.New(t)
+
+	context := schema.ContentBlock{Context: &schema.Context{Name: "feature_flags", Content: json.RawMessage(`{}`)}}
+	assert.NoError(schema.Validate(schema.Conversation{
+		{Role: schema.RoleUser, Content: []schema.ContentBlock{context, {Text: types.Ptr("Hello")}}},
+	}))
+	assert.Error(schema.Validate(schema.Conversation{
+		{Role: schema.RoleUser, Content: []schema.ContentBlock{{Text: types.Ptr("Hello")}}},
+		{Role: schema.RoleAssistant, Content: []schema.ContentBlock{context}},
+	}))
+	assert.Error(schema.Validate(schema.Conversation{
+		{Role: schema.RoleUser, Content: []schema.ContentBlock{{Context: &schema.Context{Content: json.RawMessage(`{}`)}}}},
+	}))
+}
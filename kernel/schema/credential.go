@@ -33,6 +33,51 @@ type CredentialInsert struct {
 	Credentials []byte `json:"credentials" help:"Encrypted credential payload"`
 }
 
+// CredentialSecret is a credential row with its passphrase version and
+// encrypted payload, which is decrypted by the manager and never returned
+// from the API.
+type CredentialSecret struct {
+	Credential
+	PV          uint64 `json:"-"`
+	Credentials []byte `json:"-"`
+}
+
+// ProviderCredentialKey selects the provider credentials of a user, which
+// are used in place of the credentials of the provider for the requests of
+// the user
+type ProviderCredentialKey struct {
+	Provider string    `json:"provider" help:"Provider name"`
+	User     uuid.UUID `json:"user" help:"Credential owner"`
+}
+
+// ProviderCredentialSecretKey selects the provider credentials of a user
+// with the passphrase version and encrypted payload, for decryption.
+type ProviderCredentialSecretKey ProviderCredentialKey
+
+// ProviderCredential is the public provider credential row returned from
+// the database, without the passphrase version and encrypted payload.
+type ProviderCredential struct {
+	ProviderCredentialKey
+	CreatedAt  time.Time  `json:"created_at" help:"Creation timestamp" readonly:""`
+	ModifiedAt *time.Time `json:"modified_at,omitempty" help:"Last modification timestamp" readonly:""`
+}
+
+// ProviderCredentialInsert contains the values required to set the provider
+// credentials of a user.
+type ProviderCredentialInsert struct {
+	ProviderCredentialKey
+	Credentials []byte `json:"credentials" help:"Encrypted credential payload"`
+}
+
+// ProviderCredentialSecret is a provider credential row with its passphrase
+// version and encrypted payload, which is decrypted by the manager and never
+// returned from the API.
+type ProviderCredentialSecret struct {
+	ProviderCredential
+	PV          uint64 `json:"-"`
+	Credentials []byte `json:"-"`
+}
+
 // OAuthCredentials bundles an OAuth token with the metadata needed to
 // refresh or reuse it later without re-discovering or re-registering.
 type OAuthCredentials struct {
@@ -69,6 +114,10 @@ func (c CredentialInsert) RedactedString() string {
 	return types.Stringify(r)
 }
 
+func (c ProviderCredential) String() string {
+	return types.Stringify(c)
+}
+
 ///////////////////////////////////////////////////////////////////////////////
 // PUBLIC METHODS - READER
 
@@ -80,6 +129,74 @@ func (c *Credential) Scan(row pg.Row) error {
 	return nil
 }
 
+// Expected column order: url, user, created_at, pv, credentials.
+func (c *CredentialSecret) Scan(row pg.Row) error {
+	if err := row.Scan(&c.URL, &c.User, &c.CreatedAt, &c.PV, &c.Credentials); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Expected column order: provider, user, created_at, modified_at.
+func (c *ProviderCredential) Scan(row pg.Row) error {
+	return row.Scan(&c.Provider, &c.User, &c.CreatedAt, &c.ModifiedAt)
+}
+
+// Expected column order: provider, user, created_at, modified_at, pv, credentials.
+func (c *ProviderCredentialSecret) Scan(row pg.Row) error {
+	return row.Scan(&c.Provider, &c.User, &c.CreatedAt, &c.ModifiedAt, &c.PV, &c.Credentials)
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS - SELECTOR
+
+func (c CredentialKey) Select(bind *pg.Bind, op pg.Op) (string, error) {
+	url, err := CanonicalURL(c.URL)
+	if err != nil {
+		return "", err
+	}
+	bind.Set("url", url)
+
+	switch op {
+	case pg.Get:
+		if c.User == nil || *c.User == uuid.Nil {
+			return bind.Query("credential.select"), nil
+		}
+		bind.Set("user", *c.User)
+		return bind.Query("credential.select_for_user"), nil
+	default:
+		return "", ErrNotImplemented.Withf("unsupported CredentialKey operation %q", op)
+	}
+}
+
+func (c ProviderCredentialKey) Select(bind *pg.Bind, op pg.Op) (string, error) {
+	if err := c.bind(bind); err != nil {
+		return "", err
+	}
+
+	switch op {
+	case pg.Get:
+		return bind.Query("provider_credential.select"), nil
+	case pg.Delete:
+		return bind.Query("provider_credential.delete"), nil
+	default:
+		return "", ErrNotImplemented.Withf("unsupported ProviderCredentialKey operation %q", op)
+	}
+}
+
+func (c ProviderCredentialSecretKey) Select(bind *pg.Bind, op pg.Op) (string, error) {
+	if err := ProviderCredentialKey(c).bind(bind); err != nil {
+		return "", err
+	}
+
+	switch op {
+	case pg.Get:
+		return bind.Query("provider_credential.select_secret"), nil
+	default:
+		return "", ErrNotImplemented.Withf("unsupported ProviderCredentialSecretKey operation %q", op)
+	}
+}
+
 ///////////////////////////////////////////////////////////////////////////////
 // PUBLIC METHODS - WRITER
 
@@ -110,3 +227,46 @@ func (c CredentialInsert) Insert(bind *pg.Bind) (string, error) {
 func (c CredentialInsert) Update(_ *pg.Bind) error {
 	return fmt.Errorf("CredentialInsert: update: not supported")
 }
+
+// Insert sets the provider credentials of a user, replacing any which were
+// set before
+func (c ProviderCredentialInsert) Insert(bind *pg.Bind) (string, error) {
+	if c.Provider == "" {
+		return "", ErrBadParameter.With("provider is required")
+	}
+	if c.User == uuid.Nil {
+		return "", ErrBadParameter.With("user is required")
+	}
+	bind.Set("provider", c.Provider)
+	bind.Set("user", c.User)
+
+	if len(c.Credentials) == 0 {
+		return "", ErrBadParameter.With("provider credentials are required")
+	}
+	if !bind.Has("pv") {
+		return "", ErrInternalServerError.With("provider credential insert requires passphrase version binding")
+	}
+	bind.Set("credentials", c.Credentials)
+
+	return bind.Query("provider_credential.upsert"), nil
+}
+
+func (c ProviderCredentialInsert) Update(_ *pg.Bind) error {
+	return fmt.Errorf("ProviderCredentialInsert: update: not supported")
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// bind sets the provider and user of the key
+func (c ProviderCredentialKey) bind(bind *pg.Bind) error {
+	if c.Provider == "" {
+		return ErrBadParameter.With("provider is required")
+	}
+	if c.User == uuid.Nil {
+		return ErrBadParameter.With("user is required")
+	}
+	bind.Set("provider", c.Provider)
+	bind.Set("user", c.User)
+	return nil
+}
@@ -167,3 +167,94 @@ func TestCredentialInsertRedactedString(t *testing.T) {
 	assert.NotContains(redacted, `"pv"`)
 	assert.NotContains(redacted, "encrypted")
 }
+
+func TestCredentialKeySelect(t *testing.T) {
+	assert := assert.New(t)
+	b := pg.NewBind("schema", "llm", "credential.select", "SELECT_GLOBAL", "credential.select_for_user", "SELECT_USER")
+
+	query, err := schema.CredentialKey{URL: "HTTPS://Cal.Example.COM/dav/"}.Select(b, pg.Get)
+	if assert.NoError(err) {
+		assert.Equal("SELECT_GLOBAL", query)
+		assert.Equal("https://cal.example.com/dav/", b.Get("url"))
+	}
+
+	user := uuid.New()
+	query, err = schema.CredentialKey{URL: "https://cal.example.com/dav", User: &user}.Select(b, pg.Get)
+	if assert.NoError(err) {
+		assert.Equal("SELECT_USER", query)
+		assert.Equal(user, b.Get("user"))
+	}
+
+	_, err = schema.CredentialKey{URL: "https://cal.example.com/dav"}.Select(b, pg.Delete)
+	assert.ErrorIs(err, schema.ErrNotImplemented)
+}
+
+func TestProviderCredentialInsert(t *testing.T) {
+	assert := assert.New(t)
+	b := pg.NewBind("schema", "llm", "provider_credential.upsert", "UPSERT", "pv", uint64(3))
+	user := uuid.New()
+
+	query, err := (schema.ProviderCredentialInsert{
+		ProviderCredentialKey: schema.ProviderCredentialKey{Provider: "anthropic", User: user},
+		Credentials:           []byte("encrypted"),
+	}).Insert(b)
+	if assert.NoError(err) {
+		assert.Equal("UPSERT", query)
+		assert.Equal("anthropic", b.Get("provider"))
+		assert.Equal(user, b.Get("user"))
+		assert.Equal([]byte("encrypted"), b.Get("credentials"))
+	}
+
+	// The user and credentials are required
+	_, err = (schema.ProviderCredentialInsert{
+		ProviderCredentialKey: schema.ProviderCredentialKey{Provider: "anthropic"},
+		Credentials:           []byte("encrypted"),
+	}).Insert(b)
+	assert.ErrorIs(err, schema.ErrBadParameter)
+	_, err = (schema.ProviderCredentialInsert{
+		ProviderCredentialKey: schema.ProviderCredentialKey{Provider: "anthropic", User: user},
+	}).Insert(b)
+	assert.ErrorIs(err, schema.ErrBadParameter)
+}
+
+func TestProviderCredentialKeySelect(t *testing.T) {
+	assert := assert.New(t)
+	b := pg.NewBind("schema", "llm", "provider_credential.select", "SELECT", "provider_credential.delete", "DELETE")
+	user := uuid.New()
+
+	query, err := schema.ProviderCredentialKey{Provider: "anthropic", User: user}.Select(b, pg.Get)
+	if assert.NoError(err) {
+		assert.Equal("SELECT", query)
+		assert.Equal("anthropic", b.Get("provider"))
+		assert.Equal(user, b.Get("user"))
+	}
+
+	query, err = schema.ProviderCredentialKey{Provider: "anthropic", User: user}.Select(b, pg.Delete)
+	if assert.NoError(err) {
+		assert.Equal("DELETE", query)
+	}
+
+	_, err = schema.ProviderCredentialKey{Provider: "anthropic", User: user}.Select(b, pg.List)
+	assert.ErrorIs(err, schema.ErrNotImplemented)
+	_, err = schema.ProviderCredentialKey{User: user}.Select(b, pg.Get)
+	assert.ErrorIs(err, schema.ErrBadParameter)
+}
+
+func TestProviderCredentialSecretKeySelect(t *testing.T) {
+	assert := assert.New(t)
+	b := pg.NewBind("schema", "llm", "provider_credential.select", "SELECT", "provider_credential.select_secret", "SELECT_SECRET")
+	user := uuid.New()
+
+	// The encrypted credentials are only selected with the secret key
+	query, err := schema.ProviderCredentialSecretKey{Provider: "anthropic", User: user}.Select(b, pg.Get)
+	if assert.NoError(err) {
+		assert.Equal("SELECT_SECRET", query)
+		assert.Equal("anthropic", b.Get("provider"))
+		assert.Equal(user, b.Get("user"))
+	}
+
+	_, err = schema.ProviderCredentialSecretKey{Provider: "anthropic", User: user}.Select(b, pg.Delete)
+	assert.ErrorIs(err, schema.ErrNotImplemented)
+	_, err = schema.ProviderCredentialSecretKey{Provider: "anthropic"}.Select(b, pg.Get)
+	assert.ErrorIs(err, schema.ErrBadParameter)
+}
@@ -0,0 +1,144 @@
+package schema
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	// Packages
+	uuid "github.com/google/uuid"
+	pg "github.com/mutablelogic/go-pg"
+	types "github.com/mutablelogic/go-server/pkg/types"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// DataDeleteRequest selects the stored data for a data subject. Sessions are
+// matched by label, which is a session tag such as "user:123", and optionally
+// by last activity. Child sessions are deleted with their parents, and
+// messages, memories and usage records are deleted with their sessions.
+type DataDeleteRequest struct {
+	Label  string     `json:"label,omitempty" help:"Session label identifying the data subject, for example user:123" example:"user:123"`
+	Before *time.Time `json:"before,omitempty" help:"Only delete sessions with no activity since this time" optional:""`
+}
+
+// DataDeleteReport describes the data removed by a deletion request.
+type DataDeleteReport struct {
+	DataDeleteRequest
+	Sessions  []uuid.UUID `json:"sessions" help:"Sessions which were deleted, including child sessions"`
+	Messages  uint        `json:"messages" help:"Number of messages deleted"`
+	Usage     uint        `json:"usage" help:"Number of usage records deleted"`
+	DeletedAt time.Time   `json:"deleted_at" help:"Time of deletion"`
+}
+
+// RetentionPolicy limits how long sessions with a label are kept after their
+// last activity. An empty label applies the policy to all sessions.
+type RetentionPolicy struct {
+	Label  string        `json:"label,omitempty" help:"Session label the policy applies to, or empty for all sessions" optional:""`
+	MaxAge time.Duration `json:"max_age" help:"Maximum time since last activity before a session is deleted"`
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// STRINGIFY
+
+func (r DataDeleteRequest) String() string {
+	return types.Stringify(r)
+}
+
+func (r DataDeleteReport) String() string {
+	return types.Stringify(r)
+}
+
+func (p RetentionPolicy) String() string {
+	return types.Stringify(p)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// Query returns the URL query values for the request
+func (r DataDeleteRequest) Query() url.Values {
+	values := url.Values{}
+	if label := strings.TrimSpace(r.Label); label != "" {
+		values.Set("label", label)
+	}
+	if r.Before != nil && !r.Before.IsZero() {
+		values.Set("before", r.Before.Format(time.RFC3339))
+	}
+	return values
+}
+
+// Request returns the deletion request which enforces the policy at the
+// given time
+func (p RetentionPolicy) Request(now time.Time) DataDeleteRequest {
+	return DataDeleteRequest{
+		Label:  strings.TrimSpace(p.Label),
+		Before: types.Ptr(now.Add(-p.MaxAge)),
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// SELECTORS
+
+func (r DataDeleteRequest) Select(bind *pg.Bind, op pg.Op) (string, error) {
+	bind.Del("where")
+
+	// A label or a cut-off time is required, so that all data cannot be
+	// deleted by accident
+	label := strings.TrimSpace(r.Label)
+	if label == "" && (r.Before == nil || r.Before.IsZero()) {
+		return "", ErrBadParameter.With("label is required")
+	}
+	if label != "" {
+		bind.Append("where", bind.Set("label", label)+` = ANY(COALESCE(session.tags, '{}'::text[]))`)
+	}
+	if r.Before != nil && !r.Before.IsZero() {
+		// The session is active until the last message was added, which does
+		// not update the modification time of the session
+		schemaName := fmt.Sprintf("%q", bind.Get("schema"))
+		bind.Append("where", `GREATEST(
+			COALESCE(session.modified_at, session.created_at),
+			(
+				SELECT MAX(message.created_at)
+				FROM `+schemaName+`.message AS message
+				WHERE message."session" = session.id
+			)
+		) < `+bind.Set("before", *r.Before))
+	}
+
+	// If a non-nil user UUID is present, restrict to that user
+	if user, _ := bind.Get("user").(uuid.UUID); user != uuid.Nil {
+		bind.Append("where", `session."user" = @user`)
+	} else {
+		bind.Del("user")
+	}
+	bind.Set("where", "WHERE "+bind.Join("where", " AND "))
+
+	switch op {
+	case pg.Delete:
+		return bind.Query("data.delete"), nil
+	default:
+		return "", ErrNotImplemented.Withf("unsupported DataDeleteRequest operation %q", op)
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS - READER
+
+func (r *DataDeleteReport) Scan(row pg.Row) error {
+	var sessions []string
+	if err := row.Scan(&sessions, &r.Messages, &r.Usage, &r.DeletedAt); err != nil {
+		return err
+	}
+	r.Sessions = make([]uuid.UUID, 0, len(sessions))
+	for _, session := range sessions {
+		id, err := uuid.Parse(session)
+		if err != nil {
+			return err
+		}
+		r.Sessions = append(r.Sessions, id)
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package schema_test
+
+import (
+	"testing"
+	"time"
+
+	// Packages
+	uuid "github.com/google/uuid"
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	pg "github.com/mutablelogic/go-pg"
+	assert "github.com/stretchr/testify/assert"
+)
+
+func TestDataDeleteRequestSelect(t *testing.T) {
+	assert := assert.New(t)
+	user := uuid.MustParse("11111111-1111-1111-1111-111111111111")
+	b := pg.NewBind("schema", "llm", "data.delete", "DELETE")
+	b.Set("user", user)
+
+	query, err := (schema.DataDeleteRequest{Label: " user:123 "}).Select(b, pg.Delete)
+	if !assert.NoError(err) {
+		return
+	}
+
+	assert.Equal("DELETE", query)
+	assert.Equal("user:123", b.Get("label"))
+	assert.Nil(b.Get("before"))
+	assert.Contains(b.Get("where").(string), `ANY(COALESCE(session.tags, '{}'::text[]))`)
+	assert.Contains(b.Get("where").(string), `session."user" = @user`)
+}
+
+func TestDataDeleteRequestSelectRequiresLabel(t *testing.T) {
+	assert := assert.New(t)
+	b := pg.NewBind("schema", "llm", "data.delete", "DELETE")
+
+	_, err := (schema.DataDeleteRequest{}).Select(b, pg.Delete)
+	assert.ErrorIs(err, schema.ErrBadParameter)
+
+	_, err = (schema.DataDeleteRequest{Label: "user:123"}).Select(b, pg.List)
+	assert.ErrorIs(err, schema.ErrNotImplemented)
+}
+
+func TestRetentionPolicyRequest(t *testing.T) {
+	assert := assert.New(t)
+	now := time.Date(2026, 1, 31, 0, 0, 0, 0, time.UTC)
+
+	req := (schema.RetentionPolicy{MaxAge: 24 * time.Hour}).Request(now)
+	assert.Empty(req.Label)
+	assert.Equal(now.Add(-24*time.Hour), *req.Before)
+
+	// An empty label with a cut-off selects all inactive sessions
+	b := pg.NewBind("schema", "llm", "data.delete", "DELETE")
+	_, err := req.Select(b, pg.Delete)
+	if assert.NoError(err) {
+		assert.Equal(*req.Before, b.Get("before"))
+		assert.Nil(b.Get("label"))
+
+		// The last message counts as activity
+		assert.Contains(b.Get("where").(string), `SELECT MAX(message.created_at)`)
+		assert.Contains(b.Get("where").(string), `FROM "llm".message AS message`)
+	}
+
+	values := req.Query()
+	assert.Equal("2026-01-30T00:00:00Z", values.Get("before"))
+	assert.False(values.Has("label"))
+}
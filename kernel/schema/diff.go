@@ -0,0 +1,374 @@
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	// Packages
+	types "github.com/mutablelogic/go-server/pkg/types"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// DiffOp is the kind of a difference between two conversations
+type DiffOp string
+
+// ConversationDiff is the difference between two conversations, as a list of
+// the messages of both in order. Messages are compared by their role, result
+// and content, so identifiers, timestamps, token counts and metadata which
+// differ between runs of the same conversation are ignored.
+type ConversationDiff struct {
+	Messages []MessageDiff `json:"messages" help:"Messages of both conversations in order, with the kind of each difference"`
+}
+
+// MessageDiff is a message which is the same in both conversations, only in
+// one of them, or changed. An unchanged message has a summary of its content,
+// a removed or added message has all the lines of its content, and a changed
+// message lists the fields which differ and the lines of its content which
+// were kept, removed and added.
+type MessageDiff struct {
+	Op      DiffOp     `json:"op" help:"Kind of difference" enum:"equal,added,removed,changed"`
+	A       int        `json:"a" help:"Index of the message in the first conversation, or -1 if it was added" example:"2"`
+	B       int        `json:"b" help:"Index of the message in the second conversation, or -1 if it was removed" example:"2"`
+	Role    string     `json:"role" help:"Role of the message" example:"assistant"`
+	Summary string     `json:"summary,omitempty" help:"Start of the content of an unchanged message" example:"Unit tests catch regressions early."`
+	Fields  []string   `json:"fields,omitempty" help:"Fields which changed" example:"[\"content\"]"`
+	Delta   []LineDiff `json:"delta,omitempty" help:"Lines of the content which were kept, removed or added"`
+}
+
+// LineDiff is a line of message content which is in both versions of a
+// message, or only one of them
+type LineDiff struct {
+	Op   DiffOp `json:"op" help:"Kind of difference" enum:"equal,added,removed"`
+	Text string `json:"text" help:"Line of content"`
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+const (
+	DiffEqual   DiffOp = "equal"
+	DiffAdded   DiffOp = "added"
+	DiffRemoved DiffOp = "removed"
+	DiffChanged DiffOp = "changed"
+)
+
+// Number of characters in the summary of an unchanged message
+const diffSummaryLength = 72
+
+////////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// DiffConversations returns the difference between two conversations. The
+// messages which are the same in both are found first, and then messages
+// removed from a and added to b between them are paired as changed messages
+// when they have the same role.
+func DiffConversations(a, b Conversation) ConversationDiff {
+	ka, kb := make([]string, len(a)), make([]string, len(b))
+	for i, message := range a {
+		ka[i] = diffKey(message)
+	}
+	for i, message := range b {
+		kb[i] = diffKey(message)
+	}
+
+	var result ConversationDiff
+	var removed, added []int
+	flush := func() {
+		result.Messages = append(result.Messages, diffRun(a, b, removed, added)...)
+		removed, added = removed[:0], added[:0]
+	}
+	for _, op := range diffSequence(ka, kb) {
+		switch op.op {
+		case DiffRemoved:
+			removed = append(removed, op.a)
+		case DiffAdded:
+			added = append(added, op.b)
+		default:
+			flush()
+			result.Messages = append(result.Messages, MessageDiff{Op: DiffEqual, A: op.a, B: op.b, Role: diffRole(a[op.a]), Summary: diffSummary(diffLines(a[op.a]))})
+		}
+	}
+	flush()
+	return result
+}
+
+// Equal returns true if there are no differences
+func (d ConversationDiff) Equal() bool {
+	for _, message := range d.Messages {
+		if message.Op != DiffEqual {
+			return false
+		}
+	}
+	return true
+}
+
+// Counts returns the number of messages which were added, removed and changed
+func (d ConversationDiff) Counts() (added, removed, changed int) {
+	for _, message := range d.Messages {
+		switch message.Op {
+		case DiffAdded:
+			added++
+		case DiffRemoved:
+			removed++
+		case DiffChanged:
+			changed++
+		}
+	}
+	return
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// STRINGIFY
+
+// Render returns the difference as text. Unchanged messages are summarized
+// on one line, and the lines of other messages are prefixed by "-" when they
+// were removed and "+" when they were added.
+func (d ConversationDiff) Render() string {
+	var out strings.Builder
+	for _, message := range d.Messages {
+		switch message.Op {
+		case DiffEqual:
+			fmt.Fprintf(&out, "  %s: %s\n", message.Role, message.Summary)
+			continue
+		case DiffRemoved:
+			fmt.Fprintf(&out, "- %s:\n", message.Role)
+		case DiffAdded:
+			fmt.Fprintf(&out, "+ %s:\n", message.Role)
+		case DiffChanged:
+			fmt.Fprintf(&out, "~ %s (%s):\n", message.Role, strings.Join(message.Fields, ", "))
+		}
+		for _, line := range message.Delta {
+			switch line.Op {
+			case DiffRemoved:
+				fmt.Fprintf(&out, "-   %s\n", line.Text)
+			case DiffAdded:
+				fmt.Fprintf(&out, "+   %s\n", line.Text)
+			default:
+				fmt.Fprintf(&out, "    %s\n", line.Text)
+			}
+		}
+	}
+	return out.String()
+}
+
+func (d ConversationDiff) String() string {
+	return types.Stringify(d)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+type diffStep struct {
+	op   DiffOp
+	a, b int
+}
+
+// diffRun returns the messages removed from a and added to b between two
+// unchanged messages. Messages with the same role are paired in order as
+// changed messages, preferring pairs whose content is most alike.
+func diffRun(a, b Conversation, removed, added []int) []MessageDiff {
+	// score[i][j] is the best total similarity of pairs from removed[i:] and added[j:]
+	n, m := len(removed), len(added)
+	score := make([][]float64, n+1)
+	for i := range score {
+		score[i] = make([]float64, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			score[i][j] = max(score[i+1][j], score[i][j+1])
+			if pair, ok := diffSimilarity(a[removed[i]], b[added[j]]); ok {
+				score[i][j] = max(score[i][j], pair+score[i+1][j+1])
+			}
+		}
+	}
+
+	var result []MessageDiff
+	i, j := 0, 0
+	for i < n || j < m {
+		switch {
+		case i < n && j < m && score[i][j] != score[i+1][j] && score[i][j] != score[i][j+1]:
+			result = append(result, diffMessage(a[removed[i]], b[added[j]], removed[i], added[j]))
+			i, j = i+1, j+1
+		case i < n && (j == m || score[i][j] == score[i+1][j]):
+			result = append(result, MessageDiff{Op: DiffRemoved, A: removed[i], B: -1, Role: diffRole(a[removed[i]]), Delta: diffLineOps(DiffRemoved, diffLines(a[removed[i]]))})
+			i++
+		default:
+			result = append(result, MessageDiff{Op: DiffAdded, A: -1, B: added[j], Role: diffRole(b[added[j]]), Delta: diffLineOps(DiffAdded, diffLines(b[added[j]]))})
+			j++
+		}
+	}
+	return result
+}
+
+// diffSimilarity returns the score for pairing two messages, which is
+// between one and two depending on how many lines of content they share, or
+// false if they have different roles and cannot be paired
+func diffSimilarity(a, b *Message) (float64, bool) {
+	if diffRole(a) != diffRole(b) {
+		return 0, false
+	}
+	la, lb := diffLines(a), diffLines(b)
+	if len(la)+len(lb) == 0 {
+		return 2, true
+	}
+	common := 0
+	for _, step := range diffSequence(la, lb) {
+		if step.op == DiffEqual {
+			common++
+		}
+	}
+	return 1 + float64(2*common)/float64(len(la)+len(lb)), true
+}
+
+// diffMessage returns the difference between two versions of a message
+func diffMessage(a, b *Message, ia, ib int) MessageDiff {
+	result := MessageDiff{Op: DiffChanged, A: ia, B: ib, Role: diffRole(b)}
+	if resultType(a) != resultType(b) {
+		result.Fields = append(result.Fields, "result")
+	}
+	la, lb := diffLines(a), diffLines(b)
+	if strings.Join(la, "\n") != strings.Join(lb, "\n") {
+		result.Fields = append(result.Fields, "content")
+		for _, step := range diffSequence(la, lb) {
+			switch step.op {
+			case DiffRemoved:
+				result.Delta = append(result.Delta, LineDiff{Op: DiffRemoved, Text: la[step.a]})
+			case DiffAdded:
+				result.Delta = append(result.Delta, LineDiff{Op: DiffAdded, Text: lb[step.b]})
+			default:
+				result.Delta = append(result.Delta, LineDiff{Op: DiffEqual, Text: la[step.a]})
+			}
+		}
+	}
+	return result
+}
+
+// diffLineOps returns lines which were all removed or all added
+func diffLineOps(op DiffOp, lines []string) []LineDiff {
+	result := make([]LineDiff, 0, len(lines))
+	for _, line := range lines {
+		result = append(result, LineDiff{Op: op, Text: line})
+	}
+	return result
+}
+
+// diffSequence returns the steps which turn a into b, using the longest
+// common subsequence of the two
+func diffSequence(a, b []string) []diffStep {
+	// lcs[i][j] is the length of the longest common subsequence of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	steps := make([]diffStep, 0, max(len(a), len(b)))
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			steps = append(steps, diffStep{op: DiffEqual, a: i, b: j})
+			i, j = i+1, j+1
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			steps = append(steps, diffStep{op: DiffRemoved, a: i, b: -1})
+			i++
+		default:
+			steps = append(steps, diffStep{op: DiffAdded, a: -1, b: j})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		steps = append(steps, diffStep{op: DiffRemoved, a: i, b: -1})
+	}
+	for ; j < len(b); j++ {
+		steps = append(steps, diffStep{op: DiffAdded, a: -1, b: j})
+	}
+	return steps
+}
+
+// diffKey returns the parts of a message which are compared
+func diffKey(message *Message) string {
+	return diffRole(message) + "\x00" + resultType(message) + "\x00" + strings.Join(diffLines(message), "\n")
+}
+
+func diffRole(message *Message) string {
+	if message == nil {
+		return ""
+	}
+	return message.Role
+}
+
+func resultType(message *Message) string {
+	if message == nil {
+		return ""
+	}
+	return message.Result.String()
+}
+
+// diffLines returns the content of a message as lines of text. Tool calls
+// and results are shown with their JSON compacted, and attachments by their
+// type and URL.
+func diffLines(message *Message) []string {
+	if message == nil {
+		return nil
+	}
+	var lines []string
+	for _, block := range message.Content {
+		switch {
+		case block.Text != nil:
+			lines = append(lines, strings.Split(*block.Text, "\n")...)
+		case block.Thinking != nil:
+			for _, line := range strings.Split(*block.Thinking, "\n") {
+				lines = append(lines, "[thinking] "+line)
+			}
+		case block.ToolCall != nil:
+			lines = append(lines, fmt.Sprintf("[tool_call %s] %s", block.ToolCall.Name, diffJSON(block.ToolCall.Input)))
+		case block.ToolResult != nil:
+			prefix := "tool_result"
+			if block.ToolResult.IsError {
+				prefix = "tool_error"
+			}
+			lines = append(lines, fmt.Sprintf("[%s %s] %s", prefix, block.ToolResult.Name, diffJSON(block.ToolResult.Content)))
+		case block.Context != nil:
+			lines = append(lines, fmt.Sprintf("[context %s] %s", block.Context.Name, diffJSON(block.Context.Content)))
+		case block.Attachment != nil:
+			attachment := block.Attachment
+			if attachment.URL != nil {
+				lines = append(lines, fmt.Sprintf("[attachment %s] %s", attachment.ContentType, attachment.URL))
+			} else {
+				lines = append(lines, fmt.Sprintf("[attachment %s] %d bytes", attachment.ContentType, len(attachment.Data)))
+			}
+		}
+	}
+	return lines
+}
+
+// diffJSON returns JSON without white space
+func diffJSON(data json.RawMessage) string {
+	var out bytes.Buffer
+	if err := json.Compact(&out, data); err != nil {
+		return string(data)
+	}
+	return out.String()
+}
+
+// diffSummary returns the start of the content of a message on one line
+func diffSummary(lines []string) string {
+	text := strings.Join(strings.Fields(strings.Join(lines, " ")), " ")
+	if runes := []rune(text); len(runes) > diffSummaryLength {
+		text = string(runes[:diffSummaryLength-1]) + "…"
+	}
+	return text
+}
@@ -0,0 +1,95 @@
+package schema_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	// Packages
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	assert "github.com/stretchr/testify/assert"
+)
+
+func diffMessage(t *testing.T, role, text string) *schema.Message {
+	t.Helper()
+	message, err := schema.NewMessage(role, text)
+	if err != nil {
+		t.Fatal(err)
+	}
+	message.Result = schema.ResultStop
+	return message
+}
+
+func TestDiffConversationsEqual(t *testing.T) {
+	assert := assert.New(t)
+	a := schema.Conversation{diffMessage(t, schema.RoleUser, "Hello"), diffMessage(t, schema.RoleAssistant, "Hi there")}
+	b := schema.Conversation{diffMessage(t, schema.RoleUser, "Hello"), diffMessage(t, schema.RoleAssistant, "Hi there")}
+
+	// Identifiers, timestamps and token counts are ignored
+	b[1].ID, b[1].Tokens, b[1].CreatedAt, b[1].Latency = 42, 3, time.Now(), time.Second
+
+	diff := schema.DiffConversations(a, b)
+	assert.True(diff.Equal())
+	assert.Len(diff.Messages, 2)
+	assert.Equal("  user: Hello\n  assistant: Hi there\n", diff.Render())
+}
+
+func TestDiffConversationsChanges(t *testing.T) {
+	assert := assert.New(t)
+	call := diffMessage(t, schema.RoleAssistant, "")
+	call.Content = []schema.ContentBlock{{ToolCall: &schema.ToolCall{ID: "1", Name: "weather", Input: json.RawMessage(`{ "city": "Berlin" }`)}}}
+	call.Result = schema.ResultToolCall
+	a := schema.Conversation{
+		diffMessage(t, schema.RoleUser, "What is the weather?"),
+		diffMessage(t, schema.RoleAssistant, "It is sunny.\nEnjoy the day."),
+		diffMessage(t, schema.RoleUser, "Thanks"),
+	}
+	b := schema.Conversation{
+		diffMessage(t, schema.RoleUser, "What is the weather?"),
+		call,
+		diffMessage(t, schema.RoleAssistant, "It is raining.\nEnjoy the day."),
+	}
+
+	diff := schema.DiffConversations(a, b)
+	assert.False(diff.Equal())
+	added, removed, changed := diff.Counts()
+	assert.Equal(1, added)
+	assert.Equal(1, removed)
+	assert.Equal(1, changed)
+	if assert.Len(diff.Messages, 4) {
+		assert.Equal(schema.DiffEqual, diff.Messages[0].Op)
+
+		// The tool call is added, and the assistant message pairs with the
+		// reply whose content is most alike
+		assert.Equal(schema.DiffAdded, diff.Messages[1].Op)
+		assert.Equal(1, diff.Messages[1].B)
+		assert.Equal(schema.DiffChanged, diff.Messages[2].Op)
+		assert.Equal(1, diff.Messages[2].A)
+		assert.Equal(2, diff.Messages[2].B)
+		assert.Equal([]string{"content"}, diff.Messages[2].Fields)
+		assert.Equal([]schema.LineDiff{
+			{Op: schema.DiffRemoved, Text: "It is sunny."},
+			{Op: schema.DiffAdded, Text: "It is raining."},
+			{Op: schema.DiffEqual, Text: "Enjoy the day."},
+		}, diff.Messages[2].Delta)
+		assert.Equal(schema.DiffRemoved, diff.Messages[3].Op)
+	}
+	assert.Contains(diff.Render(), "+   [tool_call weather] {\"city\":\"Berlin\"}\n")
+	assert.Contains(diff.Render(), "~ assistant (content):\n-   It is sunny.\n+   It is raining.\n    Enjoy the day.\n")
+	assert.Contains(diff.Render(), "- user:\n-   Thanks\n")
+}
+
+func TestDiffConversationsResult(t *testing.T) {
+	assert := assert.New(t)
+	a := schema.Conversation{diffMessage(t, schema.RoleAssistant, "Partial")}
+	b := schema.Conversation{diffMessage(t, schema.RoleAssistant, "Partial")}
+	b[0].Result = schema.ResultMaxTokens
+
+	diff := schema.DiffConversations(a, b)
+	if assert.Len(diff.Messages, 1) {
+		assert.Equal(schema.DiffChanged, diff.Messages[0].Op)
+		assert.Equal([]string{"result"}, diff.Messages[0].Fields)
+		assert.Empty(diff.Messages[0].Delta)
+	}
+	assert.True(schema.DiffConversations(nil, nil).Equal())
+}
@@ -3,6 +3,7 @@ package schema
 import (
 	"errors"
 	"fmt"
+	"net/http"
 
 	// Packages
 	pg "github.com/mutablelogic/go-pg"
@@ -23,6 +24,8 @@ const (
 	ErrRefusal
 	ErrPauseTurn
 	ErrServiceUnavailable
+	ErrTooManyRequests
+	ErrForbidden
 )
 
 ////////////////////////////////////////////////////////////////////////////////
@@ -56,6 +59,10 @@ func (e Err) Error() string {
 		return "model paused, continuation required"
 	case ErrServiceUnavailable:
 		return "service unavailable"
+	case ErrTooManyRequests:
+		return "too many requests"
+	case ErrForbidden:
+		return "forbidden"
 	}
 	return fmt.Sprintf("error code %d", int(e))
 }
@@ -74,6 +81,10 @@ func (e Err) HTTP() httpresponse.Err {
 		return httpresponse.ErrInternalError
 	case ErrServiceUnavailable:
 		return httpresponse.ErrServiceUnavailable
+	case ErrTooManyRequests:
+		return httpresponse.Err(http.StatusTooManyRequests)
+	case ErrForbidden:
+		return httpresponse.ErrForbidden
 	case ErrMaxTokens, ErrRefusal:
 		return httpresponse.ErrBadRequest
 	default:
@@ -60,3 +60,13 @@ func TestHTTPErrNil(t *testing.T) {
 	assert := assert.New(t)
 	assert.NoError(schema.HTTPErr(nil))
 }
+
+func TestHTTPErrForbidden(t *testing.T) {
+	assert := assert.New(t)
+	output := schema.HTTPErr(schema.ErrForbidden.With("admin scope required"))
+
+	var code httpresponse.Err
+	if assert.Error(output) && assert.True(errors.As(output, &code)) {
+		assert.Equal(httpresponse.ErrForbidden, code)
+	}
+}
@@ -0,0 +1,40 @@
+package schema
+
+import (
+	"net/url"
+	"strings"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// SessionExportRequest selects the format of a session export. The thinking
+// of models is redacted unless it is requested.
+type SessionExportRequest struct {
+	Format   string `json:"format,omitempty" help:"Export format" enum:"pdf,html" default:"pdf" optional:""`
+	Thinking bool   `json:"thinking,omitempty" help:"Include the thinking of models, and for administrators the recorded thinking" optional:""`
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+const (
+	ExportPDF      = "pdf"
+	ExportHTML     = "html"
+	ExportMarkdown = "markdown"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// Query returns the URL query values for the request
+func (r SessionExportRequest) Query() url.Values {
+	values := url.Values{}
+	if format := strings.TrimSpace(r.Format); format != "" {
+		values.Set("format", format)
+	}
+	if r.Thinking {
+		values.Set("thinking", "true")
+	}
+	return values
+}
@@ -16,13 +16,18 @@ import (
 // GeneratorMeta represents generator settings which are persisted on a session
 // as URL-style values within the session meta object.
 type GeneratorMeta struct {
-	Provider       *string    `json:"provider,omitempty" yaml:"provider" help:"Provider name" optional:"" example:"ollama"`
-	Model          *string    `json:"model,omitempty" yaml:"model" help:"Model name" optional:"" example:"llama3.2"`
-	SystemPrompt   *string    `json:"system_prompt,omitempty" yaml:"system_prompt" help:"System prompt" optional:"" example:"Be concise and answer in one sentence."`
-	MaxTokens      *uint      `json:"max_tokens,omitempty" yaml:"max_tokens" help:"Maximum output tokens to generate" optional:"" example:"4096"`
-	Format         JSONSchema `json:"format,omitempty" yaml:"output" help:"JSON schema for structured output" optional:"" example:"{\"type\":\"object\",\"properties\":{\"summary\":{\"type\":\"string\"}}}"`
-	Thinking       *bool      `json:"thinking,omitempty" yaml:"thinking" help:"Enable thinking/reasoning" optional:"" negatable:"" example:"true"`
-	ThinkingBudget *uint      `json:"thinking_budget,omitempty" yaml:"thinking_budget" help:"Thinking token budget (required for Anthropic, optional for Google)" optional:"" example:"2048"`
+	Provider       *string        `json:"provider,omitempty" yaml:"provider" help:"Provider name" optional:"" example:"ollama"`
+	Model          *string        `json:"model,omitempty" yaml:"model" help:"Model name" optional:"" example:"llama3.2"`
+	SystemPrompt   *string        `json:"system_prompt,omitempty" yaml:"system_prompt" help:"System prompt" optional:"" example:"Be concise and answer in one sentence."`
+	MaxTokens      *uint          `json:"max_tokens,omitempty" yaml:"max_tokens" help:"Maximum output tokens to generate" optional:"" example:"4096"`
+	Format         JSONSchema     `json:"format,omitempty" yaml:"output" help:"JSON schema for structured output" optional:"" example:"{\"type\":\"object\",\"properties\":{\"summary\":{\"type\":\"string\"}}}"`
+	Thinking       *bool          `json:"thinking,omitempty" yaml:"thinking" help:"Enable thinking/reasoning" optional:"" negatable:"" example:"true"`
+	ThinkingBudget *uint          `json:"thinking_budget,omitempty" yaml:"thinking_budget" help:"Thinking token budget (required for Anthropic, optional for Google)" optional:"" example:"2048"`
+	ThinkingTime   *uint          `json:"thinking_time,omitempty" yaml:"thinking_time" help:"Maximum seconds to spend thinking, after which the answer is requested" optional:"" example:"30"`
+	Seed           *uint          `json:"seed,omitempty" yaml:"seed" help:"Random seed for reproducible generation, where the provider supports it" optional:"" example:"42"`
+	Language       *string        `json:"language,omitempty" yaml:"language" help:"Language which the model works in. Input in other languages is translated into it, and the reply is translated back." optional:"" example:"English"`
+	Translator     *string        `json:"translator,omitempty" yaml:"translator" help:"Model which translates input and replies when the language is set (defaults to the model)" optional:"" example:"gemini-2.5-flash"`
+	PostProcess    PostProcessors `json:"postprocess,omitempty" yaml:"postprocess" help:"Steps which change the text of replies before they are stored, as a JSON array" optional:"" example:"[{\"type\":\"markdown\"},{\"type\":\"mask\"}]"`
 }
 
 ////////////////////////////////////////////////////////////////////////////////
@@ -38,7 +43,8 @@ func (g GeneratorMeta) String() string {
 // IsZero reports whether all generator fields are unset.
 func (g GeneratorMeta) IsZero() bool {
 	return g.Provider == nil && g.Model == nil && g.SystemPrompt == nil &&
-		g.MaxTokens == nil && len(g.Format) == 0 && g.Thinking == nil && g.ThinkingBudget == nil
+		g.MaxTokens == nil && len(g.Format) == 0 && g.Thinking == nil && g.ThinkingBudget == nil && g.ThinkingTime == nil && g.Seed == nil &&
+		g.Language == nil && g.Translator == nil && len(g.PostProcess) == 0
 }
 
 // Values encodes generator settings as URL values so they can be stored in a
@@ -72,6 +78,27 @@ func (g GeneratorMeta) Values() url.Values {
 	if g.ThinkingBudget != nil && *g.ThinkingBudget > 0 {
 		values.Set("thinking_budget", strconv.FormatUint(uint64(*g.ThinkingBudget), 10))
 	}
+	if g.ThinkingTime != nil && *g.ThinkingTime > 0 {
+		values.Set("thinking_time", strconv.FormatUint(uint64(*g.ThinkingTime), 10))
+	}
+	if g.Seed != nil {
+		values.Set("seed", strconv.FormatUint(uint64(*g.Seed), 10))
+	}
+	if g.Language != nil {
+		if language := strings.TrimSpace(*g.Language); language != "" {
+			values.Set("language", language)
+		}
+	}
+	if g.Translator != nil {
+		if translator := strings.TrimSpace(*g.Translator); translator != "" {
+			values.Set("translator", translator)
+		}
+	}
+	if len(g.PostProcess) > 0 {
+		if data, err := json.Marshal(g.PostProcess); err == nil {
+			values.Set("postprocess", string(data))
+		}
+	}
 	if len(values) == 0 {
 		return nil
 	}
@@ -111,6 +138,28 @@ func GeneratorMetaFromValues(values url.Values) GeneratorMeta {
 			meta.ThinkingBudget = types.Ptr(uint(parsed))
 		}
 	}
+	if seconds := strings.TrimSpace(values.Get("thinking_time")); seconds != "" {
+		if parsed, err := strconv.ParseUint(seconds, 10, 64); err == nil {
+			meta.ThinkingTime = types.Ptr(uint(parsed))
+		}
+	}
+	if seed := strings.TrimSpace(values.Get("seed")); seed != "" {
+		if parsed, err := strconv.ParseUint(seed, 10, 64); err == nil {
+			meta.Seed = types.Ptr(uint(parsed))
+		}
+	}
+	if v := strings.TrimSpace(values.Get("language")); v != "" {
+		meta.Language = types.Ptr(v)
+	}
+	if v := strings.TrimSpace(values.Get("translator")); v != "" {
+		meta.Translator = types.Ptr(v)
+	}
+	if v := strings.TrimSpace(values.Get("postprocess")); v != "" {
+		var steps PostProcessors
+		if err := json.Unmarshal([]byte(v), &steps); err == nil {
+			meta.PostProcess = steps
+		}
+	}
 	return meta
 }
 
@@ -121,7 +170,7 @@ func ApplyGeneratorMeta(values url.Values, meta GeneratorMeta) url.Values {
 	for key, vals := range values {
 		clone[key] = append([]string(nil), vals...)
 	}
-	for _, key := range []string{"provider", "model", "system_prompt", "max_tokens", "format", "thinking", "thinking_budget"} {
+	for _, key := range []string{"provider", "model", "system_prompt", "max_tokens", "format", "thinking", "thinking_budget", "thinking_time", "seed", "language", "translator", "postprocess"} {
 		delete(clone, key)
 	}
 	for key, vals := range meta.Values() {
@@ -163,5 +212,20 @@ func MergeGeneratorMeta(primary, fallback GeneratorMeta) GeneratorMeta {
 	if merged.ThinkingBudget == nil {
 		merged.ThinkingBudget = fallback.ThinkingBudget
 	}
+	if merged.ThinkingTime == nil {
+		merged.ThinkingTime = fallback.ThinkingTime
+	}
+	if merged.Seed == nil {
+		merged.Seed = fallback.Seed
+	}
+	if merged.Language == nil {
+		merged.Language = fallback.Language
+	}
+	if merged.Translator == nil {
+		merged.Translator = fallback.Translator
+	}
+	if len(merged.PostProcess) == 0 {
+		merged.PostProcess = fallback.PostProcess
+	}
 	return merged
 }
@@ -0,0 +1,67 @@
+package schema_test
+
+import (
+	"testing"
+
+	// Packages
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	types "github.com/mutablelogic/go-server/pkg/types"
+	assert "github.com/stretchr/testify/assert"
+)
+
+func TestGeneratorMetaSeed(t *testing.T) {
+	assert := assert.New(t)
+	meta := schema.GeneratorMeta{Model: types.Ptr("model"), Seed: types.Ptr(uint(0))}
+
+	values := meta.Values()
+	assert.Equal("0", values.Get("seed"))
+	assert.Equal(meta, schema.GeneratorMetaFromValues(values))
+	assert.False(schema.GeneratorMeta{Seed: types.Ptr(uint(1))}.IsZero())
+
+	merged := schema.MergeGeneratorMeta(schema.GeneratorMeta{}, schema.GeneratorMeta{Seed: types.Ptr(uint(42))})
+	assert.Equal(uint(42), types.Value(merged.Seed))
+
+	applied := schema.ApplyGeneratorMeta(values, schema.GeneratorMeta{})
+	assert.False(applied.Has("seed"))
+}
+
+func TestGeneratorMetaLanguage(t *testing.T) {
+	assert := assert.New(t)
+	meta := schema.GeneratorMeta{Language: types.Ptr("English"), Translator: types.Ptr("gemini-2.5-flash")}
+
+	values := meta.Values()
+	assert.Equal("English", values.Get("language"))
+	assert.Equal(meta, schema.GeneratorMetaFromValues(values))
+	assert.False(schema.GeneratorMeta{Language: types.Ptr("English")}.IsZero())
+
+	merged := schema.MergeGeneratorMeta(schema.GeneratorMeta{Model: types.Ptr("model")}, meta)
+	assert.Equal("gemini-2.5-flash", types.Value(merged.Translator))
+
+	applied := schema.ApplyGeneratorMeta(values, schema.GeneratorMeta{})
+	assert.False(applied.Has("language"))
+	assert.False(applied.Has("translator"))
+}
+
+func TestGeneratorMetaPostProcess(t *testing.T) {
+	assert := assert.New(t)
+	meta := schema.GeneratorMeta{PostProcess: schema.PostProcessors{
+		{Type: schema.PostProcessMarkdown},
+		{Type: schema.PostProcessLinks, Links: map[string]string{"http://intranet/": "https://intranet.example.com/"}},
+	}}
+
+	values := meta.Values()
+	assert.True(values.Has("postprocess"))
+	assert.Equal(meta, schema.GeneratorMetaFromValues(values))
+	assert.False(meta.IsZero())
+
+	merged := schema.MergeGeneratorMeta(schema.GeneratorMeta{Model: types.Ptr("model")}, meta)
+	assert.Equal(meta.PostProcess, merged.PostProcess)
+
+	applied := schema.ApplyGeneratorMeta(values, schema.GeneratorMeta{})
+	assert.False(applied.Has("postprocess"))
+
+	// Steps are validated
+	assert.NoError(meta.PostProcess.Validate())
+	assert.ErrorIs(schema.PostProcessors{{Type: schema.PostProcessReplace, Pattern: "("}}.Validate(), schema.ErrBadParameter)
+	assert.ErrorIs(schema.PostProcessors{{}}.Validate(), schema.ErrBadParameter)
+}
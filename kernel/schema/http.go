@@ -4,10 +4,11 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"net/http"
 	"net/url"
+	"time"
 
 	// Packages
+	uuid "github.com/google/uuid"
 	pg "github.com/mutablelogic/go-pg"
 	types "github.com/mutablelogic/go-server/pkg/types"
 )
@@ -19,6 +20,12 @@ const (
 	EmbeddingTaskTypeDefault = "DEFAULT"
 )
 
+const (
+	// ScopeAdmin is the scope which users need for administrative
+	// operations, such as changing stored agents
+	ScopeAdmin = "llm:admin"
+)
+
 ////////////////////////////////////////////////////////////////////////////////
 // TYPES
 
@@ -65,6 +72,18 @@ type DeleteModelRequest struct {
 	Name     string `json:"name" help:"Model name to delete"`
 }
 
+// LoadModelRequest represents a request to load a model into memory
+type LoadModelRequest struct {
+	Provider string `json:"provider,omitempty" help:"Provider name" optional:""`
+	Name     string `json:"name" help:"Model name to load"`
+}
+
+// UnloadModelRequest represents a request to unload a model from memory
+type UnloadModelRequest struct {
+	Provider string `json:"provider,omitempty" help:"Provider name" optional:""`
+	Name     string `json:"name" help:"Model name to unload"`
+}
+
 // EmbeddingRequest represents a request to embed text
 type EmbeddingRequest struct {
 	Provider             string   `json:"provider,omitempty" help:"Provider name" optional:""`
@@ -87,6 +106,7 @@ type CompletionResponse struct {
 	Role    string         `json:"role" help:"Role of the generated response, typically assistant" example:"assistant"`
 	Content []ContentBlock `json:"content" help:"Structured response content blocks returned by the model" example:"[{\"text\":\"Unit tests catch regressions early and make refactoring safer.\"}]"`
 	Result  ResultType     `json:"result" help:"Completion result status" example:"\"stop\""`
+	Markup  []Markup       `json:"markup,omitempty" help:"Math and tables found in the text content, for clients to render" optional:""`
 }
 
 // StreamDelta represents a single streamed text chunk in an SSE stream.
@@ -103,13 +123,22 @@ type StreamError struct {
 // AskRequestCore contains the core fields of an ask request without attachments.
 type AskRequestCore struct {
 	GeneratorMeta
-	Text string `json:"text" arg:"" help:"User input text" example:"Summarize the benefits of unit testing in one sentence."`
+	Text    string `json:"text" arg:"" help:"User input text" example:"Summarize the benefits of unit testing in one sentence."`
+	Prefill string `json:"prefill,omitempty" help:"Text which starts the reply, and which the model continues" optional:"" example:"{"`
 }
 
 // AskRequest represents a stateless request to generate content.
 type AskRequest struct {
 	AskRequestCore
-	Attachments []Attachment `json:"attachments,omitempty" help:"File attachments" optional:"" example:"[{\"type\":\"image/png\",\"url\":\"https://example.com/image.png\"}]"`
+	Attachments []Attachment    `json:"attachments,omitempty" help:"File attachments" optional:"" example:"[{\"type\":\"image/png\",\"url\":\"https://example.com/image.png\"}]"`
+	Cascade     []GeneratorMeta `json:"cascade,omitempty" help:"Stronger models to try in turn when a reply is refused, truncated, or does not match the output format. Each tier overrides the fields it sets, and only the last tier is streamed." optional:"" example:"[{\"model\":\"claude-sonnet-4-5\",\"max_tokens\":8192}]"`
+	Draft       *GeneratorMeta  `json:"draft,omitempty" help:"Smaller model which drafts the reply. The requested model keeps the paragraphs of the draft which it accepts, and continues from them. The draft overrides the fields it sets." optional:"" example:"{\"model\":\"llama3.2:1b\"}"`
+	StreamSmoothing
+}
+
+// AskQuery contains the query parameters accepted by the ask endpoint.
+type AskQuery struct {
+	Priority Priority `json:"priority,omitempty" enum:"interactive,batch,scheduled" help:"Class of the request when generations are queued (defaults to interactive)" optional:""`
 }
 
 // MultipartAskRequest is the HTTP-layer request type supporting both JSON
@@ -122,7 +151,23 @@ type MultipartAskRequest struct {
 // AskResponse represents the response from an ask request.
 type AskResponse struct {
 	CompletionResponse
-	Usage *UsageMeta `json:"usage,omitempty" help:"Token usage information for the request, when available" example:"{\"input_tokens\":18,\"output_tokens\":12}"`
+	Usage    *UsageMeta   `json:"usage,omitempty" help:"Token usage information for the request, when available" example:"{\"input_tokens\":18,\"output_tokens\":12}"`
+	Tier     uint         `json:"tier,omitempty" help:"Cascade tier which answered, where zero is the requested model" optional:"" example:"1"`
+	Draft    *DraftReport `json:"draft,omitempty" help:"How the reply was drafted, when the request has a draft model" optional:""`
+	Request  uuid.UUID    `json:"request,omitzero" help:"Request ID in the archive, when archiving is enabled" optional:""`
+	Warnings []string     `json:"warnings,omitempty" help:"Warnings about the request, such as the use of a deprecated model name" optional:""`
+}
+
+// DraftReport describes a reply drafted by a smaller model, which is checked
+// and continued by the requested model, with the time spent at each step.
+type DraftReport struct {
+	Provider   string        `json:"provider" help:"Provider of the draft model" example:"ollama"`
+	Model      string        `json:"model" help:"Draft model" example:"llama3.2:1b"`
+	Paragraphs uint          `json:"paragraphs" help:"Number of paragraphs in the draft" example:"6"`
+	Accepted   uint          `json:"accepted" help:"Number of paragraphs of the draft which are kept in the reply" example:"4"`
+	Draft      time.Duration `json:"draft_ns" help:"Time spent drafting the reply, in nanoseconds" example:"1200000000"`
+	Verify     time.Duration `json:"verify_ns" help:"Time spent checking the draft, in nanoseconds" example:"400000000"`
+	Continue   time.Duration `json:"continue_ns,omitempty" help:"Time spent continuing from the accepted paragraphs, in nanoseconds" optional:"" example:"2100000000"`
 }
 
 // CreateAgentSessionRequest represents the body of a request to create a
@@ -219,6 +264,14 @@ func (r DeleteModelRequest) String() string {
 	return types.Stringify(r)
 }
 
+func (r LoadModelRequest) String() string {
+	return types.Stringify(r)
+}
+
+func (r UnloadModelRequest) String() string {
+	return types.Stringify(r)
+}
+
 func (r EmbeddingRequest) String() string {
 	return types.Stringify(r)
 }
@@ -263,6 +316,10 @@ func (r AskResponse) String() string {
 	return types.Stringify(r)
 }
 
+func (r DraftReport) String() string {
+	return types.Stringify(r)
+}
+
 func (r ChatRequest) String() string {
 	return types.Stringify(r)
 }
@@ -305,7 +362,7 @@ func fileAttachment(f types.File) (*Attachment, error) {
 		return nil, nil
 	}
 	a := &Attachment{
-		ContentType: http.DetectContentType(data),
+		ContentType: DetectContentType(data, f.Path),
 		Data:        data,
 	}
 	if f.Path != "" {
@@ -0,0 +1,96 @@
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	// Packages
+	pg "github.com/mutablelogic/go-pg"
+	types "github.com/mutablelogic/go-server/pkg/types"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// SessionImportRequest imports conversations exported from another tool.
+// The generator settings and tags are applied to every imported session, and
+// the title, if set, replaces the exported titles.
+type SessionImportRequest struct {
+	SessionMeta
+	Format string          `json:"format" help:"Export format" enum:"chatgpt,claude" example:"chatgpt"`
+	Data   json.RawMessage `json:"data" help:"Exported conversations, for example the contents of conversations.json"`
+}
+
+// SessionImportResponse lists the sessions created by an import.
+type SessionImportResponse struct {
+	Count uint       `json:"count" help:"Number of sessions imported"`
+	Body  []*Session `json:"body,omitzero" help:"Imported sessions"`
+}
+
+// ImportedSession is a conversation read from an export, together with its
+// original timestamps and messages.
+type ImportedSession struct {
+	SessionInsert
+	CreatedAt  time.Time       `json:"created_at,omitzero"`
+	ModifiedAt time.Time       `json:"modified_at,omitzero"`
+	Messages   []MessageInsert `json:"messages,omitempty"`
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+const (
+	ImportChatGPT = "chatgpt"
+	ImportClaude  = "claude"
+)
+
+// ImportTagPrefix prefixes the tag added to imported sessions, so that an
+// import can be found or deleted by label, for example "import:chatgpt"
+const ImportTagPrefix = "import:"
+
+////////////////////////////////////////////////////////////////////////////////
+// STRINGIFY
+
+func (r SessionImportRequest) String() string {
+	return types.Stringify(struct {
+		SessionMeta
+		Format string `json:"format"`
+		Size   int    `json:"size"`
+	}{r.SessionMeta, r.Format, len(r.Data)})
+}
+
+func (r SessionImportResponse) String() string {
+	return types.Stringify(r)
+}
+
+func (s ImportedSession) String() string {
+	return types.Stringify(s)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS - WRITER
+
+// Insert binds the session with its original timestamps. When the creation
+// time is zero the current time is used, and when the modification time is
+// zero it is left unset.
+func (s ImportedSession) Insert(bind *pg.Bind) (string, error) {
+	if _, err := s.SessionInsert.Insert(bind); err != nil {
+		return "", err
+	}
+	if s.CreatedAt.IsZero() {
+		bind.Set("created_at", nil)
+	} else {
+		bind.Set("created_at", s.CreatedAt)
+	}
+	if s.ModifiedAt.IsZero() {
+		bind.Set("modified_at", nil)
+	} else {
+		bind.Set("modified_at", s.ModifiedAt)
+	}
+	return bind.Query("session.import"), nil
+}
+
+func (s ImportedSession) Update(_ *pg.Bind) error {
+	return fmt.Errorf("ImportedSession: update: not supported")
+}
@@ -0,0 +1,67 @@
+package schema_test
+
+import (
+	"testing"
+	"time"
+
+	// Packages
+	uuid "github.com/google/uuid"
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	pg "github.com/mutablelogic/go-pg"
+	types "github.com/mutablelogic/go-server/pkg/types"
+	assert "github.com/stretchr/testify/assert"
+)
+
+func TestImportedSessionInsertBindsTimestamps(t *testing.T) {
+	assert := assert.New(t)
+	b := pg.NewBind("schema", "llm", "session.import", "IMPORT")
+	b.Set("user", uuid.Nil)
+	created := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	modified := created.Add(time.Hour)
+
+	query, err := (schema.ImportedSession{
+		SessionInsert: schema.SessionInsert{SessionMeta: schema.SessionMeta{
+			Title: types.Ptr(" Imported "),
+			Tags:  []string{"import:claude", "import:claude"},
+		}},
+		CreatedAt:  created,
+		ModifiedAt: modified,
+	}).Insert(b)
+	if !assert.NoError(err) {
+		return
+	}
+
+	assert.Equal("IMPORT", query)
+	assert.Equal(types.Ptr("Imported"), b.Get("title"))
+	assert.Equal([]string{"import:claude"}, b.Get("tags"))
+	assert.Equal(created, b.Get("created_at"))
+	assert.Equal(modified, b.Get("modified_at"))
+}
+
+func TestImportedSessionInsertDefaultsTimestamps(t *testing.T) {
+	assert := assert.New(t)
+	b := pg.NewBind("schema", "llm", "session.import", "IMPORT")
+	b.Set("user", uuid.Nil)
+
+	_, err := (schema.ImportedSession{}).Insert(b)
+	if !assert.NoError(err) {
+		return
+	}
+	assert.Nil(b.Get("created_at"))
+	assert.Nil(b.Get("modified_at"))
+}
+
+func TestMessageInsertBindsCreatedAt(t *testing.T) {
+	assert := assert.New(t)
+	b := pg.NewBind("schema", "llm", "message.insert", "INSERT")
+	created := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+
+	_, err := (schema.MessageInsert{
+		Session: uuid.New(),
+		Message: schema.Message{Role: schema.RoleUser, CreatedAt: created},
+	}).Insert(b)
+	if !assert.NoError(err) {
+		return
+	}
+	assert.Equal(created, b.Get("created_at"))
+}
@@ -0,0 +1,34 @@
+package schema
+
+import (
+	// Packages
+	types "github.com/mutablelogic/go-server/pkg/types"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// JailbreakScore is the likelihood that user input attempts to make a model
+// ignore its instructions, from heuristics and an optional judge model.
+// Inputs which score at least the threshold are written to the archive with
+// the score.
+type JailbreakScore struct {
+	Score    float64  `json:"score" help:"Likelihood of a jailbreak attempt, from 0 to 1" example:"0.8"`
+	Signals  []string `json:"signals,omitempty" help:"Heuristics which matched the input" optional:"" example:"[\"ignore_instructions\",\"reveal_prompt\"]"`
+	Judge    *float64 `json:"judge,omitempty" help:"Score from the judge model, when one is configured" optional:"" example:"0.9"`
+	Hardened bool     `json:"hardened,omitempty" help:"True when the hardened system prompt was used for the turn" optional:""`
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+// ArchiveTypeJailbreak is the type of archive records for user input which
+// was scored as a jailbreak attempt
+const ArchiveTypeJailbreak UsageType = "jailbreak"
+
+////////////////////////////////////////////////////////////////////////////////
+// STRINGIFY
+
+func (s JailbreakScore) String() string {
+	return types.Stringify(s)
+}
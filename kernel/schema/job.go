@@ -0,0 +1,55 @@
+package schema
+
+import (
+	"time"
+
+	// Packages
+	uuid "github.com/google/uuid"
+	types "github.com/mutablelogic/go-server/pkg/types"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// JobStatus is the state of a background job
+type JobStatus string
+
+// Job is a chat turn which runs in the background. The result is set when
+// the job completes, and the error when it fails or is cancelled.
+type Job struct {
+	ID          uuid.UUID     `json:"id" help:"Job ID"`
+	Session     uuid.UUID     `json:"session" help:"Session of the chat turn"`
+	Status      JobStatus     `json:"status" enum:"running,completed,failed,cancelled" help:"State of the job"`
+	CreatedAt   time.Time     `json:"created_at" help:"Time the job started"`
+	CompletedAt *time.Time    `json:"completed_at,omitempty" help:"Time the job completed, failed or was cancelled"`
+	Result      *ChatResponse `json:"result,omitempty" help:"Response of the chat turn, when completed"`
+	Error       string        `json:"error,omitempty" help:"Error, when failed or cancelled"`
+}
+
+// JobIDSelector selects a job by ID
+type JobIDSelector uuid.UUID
+
+////////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+const (
+	JobRunning   JobStatus = "running"
+	JobCompleted JobStatus = "completed"
+	JobFailed    JobStatus = "failed"
+	JobCancelled JobStatus = "cancelled"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// Done returns true if the job is no longer running
+func (j Job) Done() bool {
+	return j.Status != JobRunning
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// STRINGIFY
+
+func (j Job) String() string {
+	return types.Stringify(j)
+}
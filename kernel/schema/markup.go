@@ -0,0 +1,546 @@
+package schema
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	// Packages
+	types "github.com/mutablelogic/go-server/pkg/types"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// Markup is math or a table within the text emitted by a model, so that
+// clients can render it without parsing the text again. Block is the index
+// of the content block, and Start (inclusive) and End (exclusive) are byte
+// offsets within its text, including any delimiters.
+type Markup struct {
+	Type    string     `json:"type" help:"Type of markup" enum:"math,table" example:"math"`
+	Block   int        `json:"block" help:"Index of the content block which contains the markup" example:"0"`
+	Start   int        `json:"start" help:"Byte offset of the start of the markup in the text" example:"12"`
+	End     int        `json:"end" help:"Byte offset of the end of the markup in the text" example:"21"`
+	Display bool       `json:"display,omitempty" help:"Math which is set apart from the text on its own line"`
+	Source  string     `json:"source" help:"LaTeX source for math, or markdown for a table" example:"E = mc^2"`
+	Text    string     `json:"text,omitempty" help:"Math as plain text with Unicode symbols" example:"E = mc²"`
+	Header  []string   `json:"header,omitempty" help:"Table column headings"`
+	Align   []string   `json:"align,omitempty" help:"Table column alignment, which is left, center, right or empty"`
+	Rows    [][]string `json:"rows,omitempty" help:"Table cells, by row then column"`
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// CONSTANTS
+
+// Markup types
+const (
+	MarkupMath  = "math"
+	MarkupTable = "table"
+)
+
+// Table column alignment
+const (
+	AlignLeft   = "left"
+	AlignCenter = "center"
+	AlignRight  = "right"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+var (
+	reTableDelimiter = regexp.MustCompile(`^\s*\|?\s*:?-+:?\s*(\|\s*:?-+:?\s*)*\|?\s*$`)
+)
+
+// Symbols for LaTeX commands, which are otherwise shown as they are written
+var latexSymbols = map[string]string{
+	// Greek letters
+	"alpha": "α", "beta": "β", "gamma": "γ", "delta": "δ", "epsilon": "ϵ", "varepsilon": "ε",
+	"zeta": "ζ", "eta": "η", "theta": "θ", "vartheta": "ϑ", "iota": "ι", "kappa": "κ",
+	"lambda": "λ", "mu": "μ", "nu": "ν", "xi": "ξ", "pi": "π", "varpi": "ϖ", "rho": "ρ",
+	"sigma": "σ", "tau": "τ", "upsilon": "υ", "phi": "ϕ", "varphi": "φ", "chi": "χ",
+	"psi": "ψ", "omega": "ω", "Gamma": "Γ", "Delta": "Δ", "Theta": "Θ", "Lambda": "Λ",
+	"Xi": "Ξ", "Pi": "Π", "Sigma": "Σ", "Upsilon": "Υ", "Phi": "Φ", "Psi": "Ψ", "Omega": "Ω",
+
+	// Operators and relations
+	"cdot": "·", "times": "×", "div": "÷", "pm": "±", "mp": "∓", "ast": "∗", "star": "⋆",
+	"circ": "∘", "bullet": "•", "leq": "≤", "le": "≤", "geq": "≥", "ge": "≥", "neq": "≠",
+	"ne": "≠", "approx": "≈", "equiv": "≡", "sim": "∼", "simeq": "≃", "cong": "≅",
+	"propto": "∝", "ll": "≪", "gg": "≫", "perp": "⊥", "parallel": "∥", "mid": "∣",
+	"sum": "∑", "prod": "∏", "int": "∫", "iint": "∬", "oint": "∮", "partial": "∂",
+	"nabla": "∇", "infty": "∞", "in": "∈", "notin": "∉", "ni": "∋", "subset": "⊂",
+	"subseteq": "⊆", "supset": "⊃", "supseteq": "⊇", "cup": "∪", "cap": "∩",
+	"setminus": "∖", "emptyset": "∅", "varnothing": "∅", "forall": "∀", "exists": "∃",
+	"neg": "¬", "lnot": "¬", "land": "∧", "wedge": "∧", "lor": "∨", "vee": "∨",
+	"oplus": "⊕", "otimes": "⊗", "angle": "∠", "degree": "°", "prime": "′",
+	"ell": "ℓ", "hbar": "ℏ", "Re": "ℜ", "Im": "ℑ", "aleph": "ℵ",
+
+	// Arrows
+	"to": "→", "rightarrow": "→", "leftarrow": "←", "gets": "←", "leftrightarrow": "↔",
+	"Rightarrow": "⇒", "Leftarrow": "⇐", "Leftrightarrow": "⇔", "implies": "⇒",
+	"iff": "⇔", "mapsto": "↦", "uparrow": "↑", "downarrow": "↓",
+
+	// Delimiters and dots
+	"langle": "⟨", "rangle": "⟩", "lfloor": "⌊", "rfloor": "⌋", "lceil": "⌈",
+	"rceil": "⌉", "lvert": "|", "rvert": "|", "vert": "|", "Vert": "‖", "|": "‖",
+	"ldots": "…", "dots": "…", "cdots": "⋯", "vdots": "⋮", "ddots": "⋱",
+
+	// Functions, which are set upright
+	"sin": "sin", "cos": "cos", "tan": "tan", "sec": "sec", "csc": "csc", "cot": "cot",
+	"arcsin": "arcsin", "arccos": "arccos", "arctan": "arctan", "sinh": "sinh",
+	"cosh": "cosh", "tanh": "tanh", "log": "log", "ln": "ln", "exp": "exp", "lim": "lim",
+	"max": "max", "min": "min", "sup": "sup", "inf": "inf", "det": "det", "gcd": "gcd",
+	"arg": "arg", "deg": "deg", "dim": "dim", "ker": "ker", "Pr": "Pr", "mod": "mod",
+
+	// Spacing
+	",": " ", ":": " ", ";": " ", " ": " ", "quad": "  ", "qquad": "    ", "!": "",
+	"\\": "\n",
+}
+
+// Commands which are removed, leaving what follows them
+var latexIgnore = map[string]bool{
+	"left": true, "right": true, "big": true, "Big": true, "bigg": true, "Bigg": true,
+	"bigl": true, "bigr": true, "Bigl": true, "Bigr": true, "displaystyle": true,
+	"textstyle": true, "limits": true, "nolimits": true,
+}
+
+// Commands which take an argument that is shown as it is
+var latexStyles = map[string]bool{
+	"mathrm": true, "mathbf": true, "mathit": true, "mathsf": true, "mathtt": true,
+	"mathcal": true, "mathscr": true, "mathfrak": true, "boldsymbol": true, "bm": true,
+	"operatorname": true, "vec": true, "hat": true, "bar": true, "overline": true,
+	"tilde": true, "dot": true, "ddot": true, "underline": true,
+}
+
+// Commands which take an argument of text rather than math
+var latexText = map[string]bool{
+	"text": true, "textrm": true, "textbf": true, "textit": true, "mbox": true,
+}
+
+// Double struck capitals, for number sets
+var latexBlackboard = map[rune]string{
+	'C': "ℂ", 'H': "ℍ", 'N': "ℕ", 'P': "ℙ", 'Q': "ℚ", 'R': "ℝ", 'Z': "ℤ",
+}
+
+var (
+	superscripts = map[rune]rune{
+		'0': '⁰', '1': '¹', '2': '²', '3': '³', '4': '⁴', '5': '⁵', '6': '⁶', '7': '⁷', '8': '⁸', '9': '⁹',
+		'+': '⁺', '-': '⁻', '−': '⁻', '=': '⁼', '(': '⁽', ')': '⁾', 'a': 'ᵃ', 'b': 'ᵇ', 'c': 'ᶜ',
+		'd': 'ᵈ', 'e': 'ᵉ', 'f': 'ᶠ', 'g': 'ᵍ', 'h': 'ʰ', 'i': 'ⁱ', 'j': 'ʲ', 'k': 'ᵏ', 'l': 'ˡ',
+		'm': 'ᵐ', 'n': 'ⁿ', 'o': 'ᵒ', 'p': 'ᵖ', 'r': 'ʳ', 's': 'ˢ', 't': 'ᵗ', 'u': 'ᵘ', 'v': 'ᵛ',
+		'w': 'ʷ', 'x': 'ˣ', 'y': 'ʸ', 'z': 'ᶻ', 'T': 'ᵀ', '′': '′', '*': '*', ' ': ' ',
+	}
+	subscripts = map[rune]rune{
+		'0': '₀', '1': '₁', '2': '₂', '3': '₃', '4': '₄', '5': '₅', '6': '₆', '7': '₇', '8': '₈', '9': '₉',
+		'+': '₊', '-': '₋', '−': '₋', '=': '₌', '(': '₍', ')': '₎', 'a': 'ₐ', 'e': 'ₑ', 'h': 'ₕ',
+		'i': 'ᵢ', 'j': 'ⱼ', 'k': 'ₖ', 'l': 'ₗ', 'm': 'ₘ', 'n': 'ₙ', 'o': 'ₒ', 'p': 'ₚ', 'r': 'ᵣ',
+		's': 'ₛ', 't': 'ₜ', 'u': 'ᵤ', 'v': 'ᵥ', 'x': 'ₓ',
+	}
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// STRINGIFY
+
+func (m Markup) String() string {
+	return types.Stringify(m)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// ParseMarkup returns the math and tables in the text of content blocks, in
+// the order they appear. Math is delimited by $...$, $$...$$, \(...\) or
+// \[...\], and tables use the markdown pipe syntax. Code spans and fenced
+// code blocks are skipped.
+func ParseMarkup(content []ContentBlock) []Markup {
+	var result []Markup
+	for i, block := range content {
+		if block.Text == nil {
+			continue
+		}
+		for _, markup := range parseMarkup(*block.Text) {
+			markup.Block = i
+			result = append(result, markup)
+		}
+	}
+	return result
+}
+
+// ReplaceMarkup returns text with each math or table replaced by the result
+// of a function, which is called with the markup found in the text
+func ReplaceMarkup(text string, fn func(Markup) string) string {
+	var b strings.Builder
+	var offset int
+	for _, markup := range parseMarkup(text) {
+		b.WriteString(text[offset:markup.Start])
+		b.WriteString(fn(markup))
+		offset = markup.End
+	}
+	b.WriteString(text[offset:])
+	return b.String()
+}
+
+// MathText returns LaTeX math as plain text, using Unicode symbols for
+// commands, superscripts and subscripts where they exist. Anything which
+// cannot be converted is shown as it is written.
+func MathText(latex string) string {
+	var b strings.Builder
+	for i := 0; i < len(latex); {
+		switch c := latex[i]; c {
+		case '\\':
+			name, next := latexCommand(latex, i)
+			i = next
+			switch {
+			case name == "frac" || name == "dfrac" || name == "tfrac":
+				num, next := latexArgument(latex, i)
+				den, next := latexArgument(latex, next)
+				i = next
+				b.WriteString(mathGroup(MathText(num)) + "/" + mathGroup(MathText(den)))
+			case name == "sqrt":
+				arg, next := latexArgument(latex, i)
+				i = next
+				b.WriteString("√" + mathGroup(MathText(arg)))
+			case name == "mathbb":
+				arg, next := latexArgument(latex, i)
+				i = next
+				for _, r := range arg {
+					if symbol, exists := latexBlackboard[r]; exists {
+						b.WriteString(symbol)
+					} else {
+						b.WriteRune(r)
+					}
+				}
+			case latexText[name]:
+				arg, next := latexArgument(latex, i)
+				i = next
+				b.WriteString(arg)
+			case latexStyles[name]:
+				arg, next := latexArgument(latex, i)
+				i = next
+				b.WriteString(MathText(arg))
+			case latexIgnore[name]:
+				continue
+			default:
+				if symbol, exists := latexSymbols[name]; exists {
+					b.WriteString(symbol)
+				} else if len(name) == 1 && !isLetter(name[0]) {
+					b.WriteString(name)
+				} else {
+					b.WriteString("\\" + name)
+				}
+			}
+		case '^', '_':
+			arg, next := latexArgument(latex, i+1)
+			i = next
+			text := MathText(arg)
+			if script, ok := mathScript(text, c == '^'); ok {
+				b.WriteString(script)
+			} else {
+				b.WriteString(string(c) + mathGroup(text))
+			}
+		case '{', '}':
+			i++
+		case '~':
+			b.WriteByte(' ')
+			i++
+		default:
+			r, size := utf8.DecodeRuneInString(latex[i:])
+			b.WriteRune(r)
+			i += size
+		}
+	}
+	return b.String()
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS - PARSE
+
+// parseMarkup returns the math and tables in text, without the block index
+func parseMarkup(text string) []Markup {
+	var result []Markup
+	var fence string
+	var prose int
+	lines := markupLines(text)
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		trimmed := strings.TrimSpace(text[line[0]:line[1]])
+
+		// Skip fenced code blocks
+		if fence != "" {
+			if strings.HasPrefix(trimmed, fence) {
+				fence, prose = "", line[2]
+			}
+			continue
+		} else if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			result = append(result, parseMath(text, prose, line[0])...)
+			fence, prose = trimmed[:3], line[2]
+			continue
+		}
+
+		// A table is a header row, a delimiter row and any number of rows
+		if i+1 < len(lines) && strings.Contains(trimmed, "|") && reTableDelimiter.MatchString(text[lines[i+1][0]:lines[i+1][1]]) {
+			header := tableCells(trimmed)
+			align := tableCells(strings.TrimSpace(text[lines[i+1][0]:lines[i+1][1]]))
+			if len(header) != len(align) {
+				continue
+			}
+			table := Markup{Type: MarkupTable, Start: line[0], Header: header, Align: make([]string, len(align))}
+			for j, delimiter := range align {
+				table.Align[j] = tableAlign(delimiter)
+			}
+			j := i + 2
+			for ; j < len(lines); j++ {
+				row := strings.TrimSpace(text[lines[j][0]:lines[j][1]])
+				if row == "" || !strings.Contains(row, "|") {
+					break
+				}
+				cells := tableCells(row)
+				for len(cells) < len(header) {
+					cells = append(cells, "")
+				}
+				table.Rows = append(table.Rows, cells[:len(header)])
+			}
+			table.End = lines[j-1][1]
+			table.Source = text[table.Start:table.End]
+			result = append(result, parseMath(text, prose, line[0])...)
+			result = append(result, table)
+			prose, i = lines[j-1][2], j-1
+		}
+	}
+	if fence == "" {
+		result = append(result, parseMath(text, prose, len(text))...)
+	}
+	return result
+}
+
+// parseMath returns the math in text between two offsets, skipping code spans
+func parseMath(text string, start, end int) []Markup {
+	var result []Markup
+	for i := start; i < end; {
+		switch {
+		case text[i] == '`':
+			// Skip a code span, which ends with the same number of backticks
+			n := countByte(text[i:end], '`')
+			if close := strings.Index(text[i+n:end], text[i:i+n]); close >= 0 {
+				i += n + close + n
+			} else {
+				i += n
+			}
+			continue
+		case strings.HasPrefix(text[i:end], "\\$"):
+			i += 2
+			continue
+		case strings.HasPrefix(text[i:end], "$$"):
+			if markup, ok := mathSpan(text, i, end, "$$", "$$", true); ok {
+				result, i = append(result, markup), markup.End
+				continue
+			}
+		case strings.HasPrefix(text[i:end], "\\["):
+			if markup, ok := mathSpan(text, i, end, "\\[", "\\]", true); ok {
+				result, i = append(result, markup), markup.End
+				continue
+			}
+		case strings.HasPrefix(text[i:end], "\\("):
+			if markup, ok := mathSpan(text, i, end, "\\(", "\\)", false); ok {
+				result, i = append(result, markup), markup.End
+				continue
+			}
+		case text[i] == '$':
+			if markup, ok := inlineMath(text, i, end); ok {
+				result, i = append(result, markup), markup.End
+				continue
+			}
+		}
+		i++
+	}
+	return result
+}
+
+// mathSpan returns math between an opening and closing delimiter
+func mathSpan(text string, start, end int, open, close string, display bool) (Markup, bool) {
+	n := strings.Index(text[start+len(open):end], close)
+	if n < 0 {
+		return Markup{}, false
+	}
+	source := strings.TrimSpace(text[start+len(open) : start+len(open)+n])
+	if source == "" {
+		return Markup{}, false
+	}
+	return Markup{
+		Type:    MarkupMath,
+		Start:   start,
+		End:     start + len(open) + n + len(close),
+		Display: display,
+		Source:  source,
+		Text:    MathText(source),
+	}, true
+}
+
+// inlineMath returns math between single dollar signs on one line. To avoid
+// matching amounts of money, the opening dollar must not be followed by a
+// space, and the closing dollar must not be preceded by a space or followed
+// by a digit.
+func inlineMath(text string, start, end int) (Markup, bool) {
+	if start+1 >= end || isSpace(text[start+1]) {
+		return Markup{}, false
+	}
+	for i := start + 1; i < end; i++ {
+		switch text[i] {
+		case '\n':
+			return Markup{}, false
+		case '\\':
+			i++
+		case '$':
+			if isSpace(text[i-1]) || (i+1 < end && text[i+1] >= '0' && text[i+1] <= '9') {
+				return Markup{}, false
+			}
+			source := text[start+1 : i]
+			return Markup{Type: MarkupMath, Start: start, End: i + 1, Source: source, Text: MathText(source)}, true
+		}
+	}
+	return Markup{}, false
+}
+
+// markupLines returns the start and end of each line, without the newline,
+// and the start of the following line
+func markupLines(text string) [][3]int {
+	var lines [][3]int
+	for start := 0; start < len(text); {
+		end := strings.IndexByte(text[start:], '\n')
+		if end < 0 {
+			lines = append(lines, [3]int{start, len(text), len(text)})
+			break
+		}
+		lines = append(lines, [3]int{start, start + end, start + end + 1})
+		start += end + 1
+	}
+	return lines
+}
+
+// tableCells splits a table row into trimmed cells at unescaped pipes
+func tableCells(row string) []string {
+	row = strings.TrimSuffix(strings.TrimPrefix(row, "|"), "|")
+	var cells []string
+	var cell strings.Builder
+	for i := 0; i < len(row); i++ {
+		switch {
+		case row[i] == '\\' && i+1 < len(row) && row[i+1] == '|':
+			cell.WriteByte('|')
+			i++
+		case row[i] == '|':
+			cells = append(cells, strings.TrimSpace(cell.String()))
+			cell.Reset()
+		default:
+			cell.WriteByte(row[i])
+		}
+	}
+	return append(cells, strings.TrimSpace(cell.String()))
+}
+
+// tableAlign returns the alignment of a column from its delimiter
+func tableAlign(delimiter string) string {
+	left, right := strings.HasPrefix(delimiter, ":"), strings.HasSuffix(delimiter, ":")
+	switch {
+	case left && right:
+		return AlignCenter
+	case right:
+		return AlignRight
+	case left:
+		return AlignLeft
+	default:
+		return ""
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS - LATEX
+
+// latexCommand returns the name of the command at a backslash, and the
+// offset after it. Names are letters, or a single other character.
+func latexCommand(latex string, i int) (string, int) {
+	j := i + 1
+	for j < len(latex) && isLetter(latex[j]) {
+		j++
+	}
+	if j == i+1 && j < len(latex) {
+		_, size := utf8.DecodeRuneInString(latex[j:])
+		j += size
+	}
+	return latex[i+1 : j], j
+}
+
+// latexArgument returns the argument at an offset, which is a group in
+// braces, a command or a single character, and the offset after it
+func latexArgument(latex string, i int) (string, int) {
+	for i < len(latex) && latex[i] == ' ' {
+		i++
+	}
+	if i >= len(latex) {
+		return "", i
+	}
+	switch latex[i] {
+	case '{':
+		depth := 0
+		for j := i; j < len(latex); j++ {
+			switch latex[j] {
+			case '\\':
+				j++
+			case '{':
+				depth++
+			case '}':
+				if depth--; depth == 0 {
+					return latex[i+1 : j], j + 1
+				}
+			}
+		}
+		return latex[i+1:], len(latex)
+	case '\\':
+		_, next := latexCommand(latex, i)
+		return latex[i:next], next
+	default:
+		_, size := utf8.DecodeRuneInString(latex[i:])
+		return latex[i : i+size], i + size
+	}
+}
+
+// mathScript returns text as superscript or subscript characters, if each
+// character has one
+func mathScript(text string, super bool) (string, bool) {
+	table := subscripts
+	if super {
+		table = superscripts
+	}
+	var b strings.Builder
+	for _, r := range text {
+		script, exists := table[r]
+		if !exists {
+			return "", false
+		}
+		b.WriteRune(script)
+	}
+	return b.String(), text != ""
+}
+
+// mathGroup returns text in parentheses, unless it is a single character
+func mathGroup(text string) string {
+	if utf8.RuneCountInString(text) <= 1 {
+		return text
+	}
+	return "(" + text + ")"
+}
+
+func isLetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n'
+}
+
+func countByte(s string, c byte) int {
+	n := 0
+	for n < len(s) && s[n] == c {
+		n++
+	}
+	return n
+}
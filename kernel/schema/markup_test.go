@@ -0,0 +1,80 @@
+package schema_test
+
+import (
+	"testing"
+
+	// Packages
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	types "github.com/mutablelogic/go-server/pkg/types"
+	assert "github.com/stretchr/testify/assert"
+)
+
+func TestParseMarkupMath(t *testing.T) {
+	assert := assert.New(t)
+
+	text := "Energy is $E = mc^2$, and\n$$\n\\int_0^1 x\\,dx = \\frac{1}{2}\n$$\nor \\(\\alpha_i\\) for `$x$`. It costs $5 or $10."
+	markup := schema.ParseMarkup([]schema.ContentBlock{
+		{Thinking: types.Ptr("$x$")},
+		{Text: types.Ptr(text)},
+	})
+	if !assert.Len(markup, 3) {
+		return
+	}
+
+	assert.Equal(schema.MarkupMath, markup[0].Type)
+	assert.Equal(1, markup[0].Block)
+	assert.False(markup[0].Display)
+	assert.Equal("E = mc^2", markup[0].Source)
+	assert.Equal("E = mc²", markup[0].Text)
+	assert.Equal("$E = mc^2$", text[markup[0].Start:markup[0].End])
+
+	assert.True(markup[1].Display)
+	assert.Equal(`\int_0^1 x\,dx = \frac{1}{2}`, markup[1].Source)
+	assert.Equal("∫₀¹ x dx = 1/2", markup[1].Text)
+
+	assert.False(markup[2].Display)
+	assert.Equal("αᵢ", markup[2].Text)
+}
+
+func TestParseMarkupTable(t *testing.T) {
+	assert := assert.New(t)
+
+	text := "Results:\n\n| Name | Score | Note |\n|:-----|------:|:---:|\n| a \\| b | $x^2$ | ok |\n| c | 2 |\n\n```\n| x | y |\n|---|---|\n```\nDone"
+	markup := schema.ParseMarkup([]schema.ContentBlock{{Text: types.Ptr(text)}})
+	if !assert.Len(markup, 1) {
+		return
+	}
+	table := markup[0]
+	assert.Equal(schema.MarkupTable, table.Type)
+	assert.Equal([]string{"Name", "Score", "Note"}, table.Header)
+	assert.Equal([]string{schema.AlignLeft, schema.AlignRight, schema.AlignCenter}, table.Align)
+	assert.Equal([][]string{{"a | b", "$x^2$", "ok"}, {"c", "2", ""}}, table.Rows)
+	assert.Equal("| Name | Score | Note |\n|:-----|------:|:---:|\n| a \\| b | $x^2$ | ok |\n| c | 2 |", table.Source)
+	assert.Equal(table.Source, text[table.Start:table.End])
+}
+
+func TestReplaceMarkup(t *testing.T) {
+	assert := assert.New(t)
+	text := schema.ReplaceMarkup("Let $\\theta \\in \\mathbb{R}$ and \\[\\sqrt{x+1} \\leq \\text{max}\\]", func(markup schema.Markup) string {
+		return "<" + markup.Text + ">"
+	})
+	assert.Equal("Let <θ ∈ ℝ> and <√(x+1) ≤ max>", text)
+}
+
+func TestMathText(t *testing.T) {
+	assert := assert.New(t)
+	tests := map[string]string{
+		`x_{n+1}`:                      "xₙ₊₁",
+		`e^{i\pi} + 1 = 0`:             "e^(iπ) + 1 = 0",
+		`\sum_{k=1}^{n} k`:             "∑ₖ₌₁ⁿ k",
+		`\left( a \cdot b \right)`:     "( a · b )",
+		`\frac{a+b}{c}`:                "(a+b)/c",
+		`\unknown{x}`:                  `\unknownx`,
+		`x_{\mathrm{max}}`:             "xₘₐₓ",
+		`\{ x \mid x > 0 \}`:           "{ x ∣ x > 0 }",
+		`A^T \Rightarrow \lim_{x\to0}`: "Aᵀ ⇒ lim_(x→0)",
+	}
+	for latex, expected := range tests {
+		assert.Equal(expected, schema.MathText(latex), latex)
+	}
+}
@@ -0,0 +1,179 @@
+package schema
+
+import (
+	"time"
+
+	// Packages
+	uuid "github.com/google/uuid"
+	types "github.com/mutablelogic/go-server/pkg/types"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// SessionMergeRequest merges the messages of a source session into a
+// session, for example when a task was split across two chats by mistake
+type SessionMergeRequest struct {
+	Source   uuid.UUID `json:"source" help:"Session whose messages are merged into the session" example:"123e4567-e89b-12d3-a456-426614174000"`
+	Strategy string    `json:"strategy,omitempty" help:"Append the messages of the source session after the messages of the session, or interleave the turns of both sessions by time" enum:"append,interleave" default:"append" optional:""`
+}
+
+// SessionMerge is the result of merging a session into another
+type SessionMerge struct {
+	Session *Session `json:"session" help:"Session the messages were merged into" readonly:""`
+	Copied  uint     `json:"copied" help:"Number of messages copied from the source session" readonly:""`
+	Skipped uint     `json:"skipped,omitempty" help:"Number of system prompts of the source session which were already in the session" readonly:""`
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+const (
+	MergeAppend     = "append"
+	MergeInterleave = "interleave"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// STRINGIFY
+
+func (r SessionMergeRequest) String() string {
+	return types.Stringify(r)
+}
+
+func (m SessionMerge) String() string {
+	return types.Stringify(m)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// MergeConversations returns the messages of dst and src merged with a
+// strategy, and the number of system prompts of src which were skipped
+// because they repeat an earlier system prompt.
+//
+// Messages are merged in turns, so that tool calls are followed by their
+// results and thinking by its reply. The messages of dst are returned
+// unchanged. The messages of src are copies, which keep their ID and session
+// and have a creation time which orders them after the message before them
+// and before the message of dst after them. The merged conversation is
+// validated, so that tool calls left unanswered by the merge are an error.
+func MergeConversations(dst, src Conversation, strategy string) (Conversation, uint, error) {
+	if strategy == "" {
+		strategy = MergeAppend
+	}
+	if strategy != MergeAppend && strategy != MergeInterleave {
+		return nil, 0, ErrBadParameter.Withf("invalid merge strategy %q", strategy)
+	}
+
+	// Copy the messages of src, without repeated system prompts
+	prompts := make(map[string]bool)
+	for _, message := range dst {
+		if message != nil && message.Role == RoleSystem {
+			prompts[message.Text()] = true
+		}
+	}
+	var skipped uint
+	copies := make(Conversation, 0, len(src))
+	for _, message := range src {
+		if message == nil {
+			continue
+		}
+		if message.Role == RoleSystem {
+			if prompts[message.Text()] {
+				skipped++
+				continue
+			}
+			prompts[message.Text()] = true
+		}
+		copies = append(copies, types.Ptr(*message))
+	}
+
+	// Merge the turns
+	var merged Conversation
+	a, b := conversationTurns(dst), conversationTurns(copies)
+	if strategy == MergeAppend {
+		merged = append(flattenTurns(a), flattenTurns(b)...)
+	} else {
+		merged = make(Conversation, 0, len(dst)+len(copies))
+		for len(a) > 0 || len(b) > 0 {
+			if len(b) == 0 || (len(a) > 0 && !b[0][0].CreatedAt.Before(a[0][0].CreatedAt)) {
+				merged, a = append(merged, a[0]...), a[1:]
+			} else {
+				merged, b = append(merged, b[0]...), b[1:]
+			}
+		}
+	}
+
+	// Order the copies by their creation time
+	copied := make(map[*Message]bool, len(copies))
+	for _, message := range copies {
+		copied[message] = true
+	}
+	var prev time.Time
+	for i, message := range merged {
+		if !copied[message] {
+			prev = latest(prev, message.CreatedAt)
+			continue
+		}
+		created := latest(prev, message.CreatedAt)
+		for _, next := range merged[i+1:] {
+			if copied[next] {
+				continue
+			}
+			if limit := next.CreatedAt.Add(-time.Microsecond); created.After(limit) {
+				created = latest(prev, limit)
+			}
+			break
+		}
+		message.CreatedAt = created
+		prev = created
+	}
+
+	// Check the tool calls are answered in the merged conversation
+	if err := Validate(merged); err != nil {
+		return nil, 0, ErrBadParameter.Withf("merged conversation: %v", err)
+	}
+
+	// Return success
+	return merged, skipped, nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// conversationTurns splits a conversation into turns which are not
+// separated by a merge: a message which follows thinking or tool calls is in
+// the same turn as them
+func conversationTurns(conversation Conversation) []Conversation {
+	var turns []Conversation
+	for i, message := range conversation {
+		if message == nil {
+			continue
+		}
+		if i > 0 && len(turns) > 0 {
+			if prev := conversation[i-1]; prev != nil && (prev.Role == RoleThinking || len(prev.ToolCalls()) > 0) {
+				turns[len(turns)-1] = append(turns[len(turns)-1], message)
+				continue
+			}
+		}
+		turns = append(turns, Conversation{message})
+	}
+	return turns
+}
+
+// flattenTurns returns the messages of the turns in order
+func flattenTurns(turns []Conversation) Conversation {
+	result := make(Conversation, 0, len(turns))
+	for _, turn := range turns {
+		result = append(result, turn...)
+	}
+	return result
+}
+
+// latest returns the later of two times
+func latest(a, b time.Time) time.Time {
+	if b.After(a) {
+		return b
+	}
+	return a
+}
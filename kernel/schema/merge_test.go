@@ -0,0 +1,105 @@
+package schema_test
+
+import (
+	"testing"
+	"time"
+
+	// Packages
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	types "github.com/mutablelogic/go-server/pkg/types"
+	assert "github.com/stretchr/testify/assert"
+)
+
+// mergeMessage returns a text message created a number of minutes after a time
+func mergeMessage(id uint64, role, text string, start time.Time, minutes int) *schema.Message {
+	return &schema.Message{ID: id, Role: role, Content: []schema.ContentBlock{{Text: types.Ptr(text)}}, CreatedAt: start.Add(time.Duration(minutes) * time.Minute)}
+}
+
+func mergeIDs(conversation schema.Conversation) []uint64 {
+	ids := make([]uint64, 0, len(conversation))
+	for _, message := range conversation {
+		ids = append(ids, message.ID)
+	}
+	return ids
+}
+
+func TestMergeConversationsAppend(t *testing.T) {
+	assert := assert.New(t)
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	dst := schema.Conversation{
+		mergeMessage(1, schema.RoleSystem, "Be brief", start, 0),
+		mergeMessage(2, schema.RoleUser, "Plan the trip", start, 10),
+		mergeMessage(3, schema.RoleAssistant, "Day one: Paris", start, 11),
+	}
+	src := schema.Conversation{
+		mergeMessage(10, schema.RoleSystem, "Be brief", start, 5),
+		mergeMessage(11, schema.RoleUser, "Book the hotel", start, 6),
+		mergeMessage(12, schema.RoleAssistant, "Booked", start, 7),
+	}
+	merged, skipped, err := schema.MergeConversations(dst, src, "")
+	if !assert.NoError(err) {
+		return
+	}
+	assert.Equal(uint(1), skipped)
+	assert.Equal([]uint64{1, 2, 3, 11, 12}, mergeIDs(merged))
+
+	// The messages of dst are not changed, and the copies are ordered after them
+	assert.Same(dst[2], merged[2])
+	assert.Equal(dst[2].CreatedAt, merged[3].CreatedAt)
+	assert.Equal(dst[2].CreatedAt, merged[4].CreatedAt)
+	assert.Equal(start.Add(6*time.Minute), src[1].CreatedAt)
+}
+
+func TestMergeConversationsInterleave(t *testing.T) {
+	assert := assert.New(t)
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	call := &schema.Message{ID: 11, Role: schema.RoleAssistant, CreatedAt: start.Add(6 * time.Minute), Content: []schema.ContentBlock{
+		{ToolCall: &schema.ToolCall{ID: "call_1", Name: "search"}},
+	}}
+	result := &schema.Message{ID: 12, Role: schema.RoleUser, CreatedAt: start.Add(20 * time.Minute), Content: []schema.ContentBlock{
+		{ToolResult: &schema.ToolResult{ID: "call_1", Name: "search"}},
+	}}
+
+	dst := schema.Conversation{
+		mergeMessage(1, schema.RoleUser, "Plan the trip", start, 0),
+		mergeMessage(2, schema.RoleAssistant, "Day one: Paris", start, 1),
+		mergeMessage(3, schema.RoleUser, "And day two?", start, 10),
+		mergeMessage(4, schema.RoleAssistant, "Day two: Lyon", start, 11),
+	}
+	src := schema.Conversation{
+		mergeMessage(10, schema.RoleUser, "Find a hotel", start, 5),
+		call,
+		result,
+		mergeMessage(13, schema.RoleAssistant, "Found one", start, 21),
+	}
+	merged, _, err := schema.MergeConversations(dst, src, schema.MergeInterleave)
+	if !assert.NoError(err) {
+		return
+	}
+
+	// The tool call is followed by its result, which is moved before the
+	// next message of dst
+	assert.Equal([]uint64{1, 2, 10, 11, 12, 3, 4, 13}, mergeIDs(merged))
+	assert.True(merged[4].CreatedAt.Before(dst[2].CreatedAt))
+	for i := 1; i < len(merged); i++ {
+		assert.False(merged[i].CreatedAt.Before(merged[i-1].CreatedAt), "message %d", i)
+	}
+}
+
+func TestMergeConversationsInvalid(t *testing.T) {
+	assert := assert.New(t)
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	// A tool call at the end of dst is not answered by src
+	dst := schema.Conversation{
+		mergeMessage(1, schema.RoleUser, "Find a hotel", start, 0),
+		{ID: 2, Role: schema.RoleAssistant, CreatedAt: start, Content: []schema.ContentBlock{{ToolCall: &schema.ToolCall{ID: "call_1", Name: "search"}}}},
+	}
+	src := schema.Conversation{mergeMessage(10, schema.RoleUser, "Plan the trip", start, 5)}
+	_, _, err := schema.MergeConversations(dst, src, schema.MergeAppend)
+	assert.ErrorIs(err, schema.ErrBadParameter)
+
+	_, _, err = schema.MergeConversations(nil, nil, "shuffle")
+	assert.ErrorIs(err, schema.ErrBadParameter)
+}
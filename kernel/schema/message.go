@@ -12,6 +12,7 @@ import (
 	"path"
 	"strconv"
 	"strings"
+	"time"
 
 	// Packages
 	uuid "github.com/google/uuid"
@@ -34,6 +35,13 @@ type Message struct {
 	Tokens  uint           `json:"tokens,omitempty" help:"Token count attributed to this message" example:"12"`
 	Result  ResultType     `json:"result" help:"Message result status encoded as a string in JSON" enum:"stop,max_tokens,blocked,tool_call,error,other,max_iterations" example:"stop"`
 	Meta    map[string]any `json:"meta,omitzero" help:"Optional provider-specific message metadata" optional:"" example:"{\"thinking_signature\":\"abc123\"}"`
+
+	// Generation details, which are set on messages returned by a generator
+	CreatedAt  time.Time     `json:"created_at,omitzero" help:"Creation time, defaults to the current time" optional:""`
+	Provider   string        `json:"provider,omitempty" help:"Provider which generated the message" optional:"" example:"anthropic"`
+	Model      string        `json:"model,omitempty" help:"Model which generated the message, as reported by the provider" optional:"" example:"claude-sonnet-4-5-20250929"`
+	ResponseID string        `json:"response_id,omitempty" help:"Provider-assigned response identifier" optional:"" example:"msg_01XFDUDYJgAACzvnptvVoYEL"`
+	Latency    time.Duration `json:"latency_ns,omitempty" help:"Time taken to generate the message, in nanoseconds" optional:"" example:"1250000000"`
 }
 
 // MessageInsert persists a message within a session conversation.
@@ -42,6 +50,38 @@ type MessageInsert struct {
 	Message `embed:""`
 }
 
+// MessageCreateRequest appends a user message to a session. Unless sending is
+// deferred, a reply is generated in the same way as a chat request.
+type MessageCreateRequest struct {
+	Text        string       `json:"text" arg:"" help:"User input text" example:"Also mention integration tests."`
+	Attachments []Attachment `json:"attachments,omitempty" help:"File attachments" optional:"" example:"[{\"type\":\"image/png\",\"url\":\"https://example.com/image.png\"}]"`
+}
+
+// MessageCreateQuery contains the query parameters accepted when appending a
+// message to a session.
+type MessageCreateQuery struct {
+	Send *bool `json:"send,omitempty" help:"Generate a reply, or store the message as pending input when false" optional:"" example:"false"`
+}
+
+// MessageSelector selects a message in a session by ID
+type MessageSelector struct {
+	Session uuid.UUID `json:"session" help:"Session ID"`
+	ID      uint64    `json:"message" help:"Message ID"`
+}
+
+// MessageMeta updates the metadata of a stored message. A pinned message is
+// never trimmed from the conversation to fit in the context window.
+type MessageMeta struct {
+	Pinned *bool `json:"pinned,omitempty" help:"Keep the message in the context window" optional:"" example:"true"`
+}
+
+// MessagePendingSelector selects pending messages in a session by ID, so
+// they can be replaced by the turn which sends them.
+type MessagePendingSelector struct {
+	Session uuid.UUID
+	IDs     []uint64
+}
+
 // MessageListRequest represents a request to list stored messages.
 type MessageListRequest struct {
 	pg.OffsetLimit
@@ -50,6 +90,7 @@ type MessageListRequest struct {
 	Until    uint64      `json:"-"`
 	Role     string      `json:"role,omitempty" help:"Filter by exact message role" optional:""`
 	Text     string      `json:"text,omitempty" help:"Case-insensitive text search over message content" optional:""`
+	Order    string      `json:"order,omitempty" help:"Order of messages: asc for oldest first (default) or desc for newest first" optional:""`
 }
 
 // MessageList represents a paginated list of stored messages.
@@ -67,6 +108,19 @@ type ContentBlock struct {
 	Attachment *Attachment `json:"attachment,omitempty" help:"Attachment content such as an image, document, or audio asset" example:"{\"type\":\"image/png\",\"url\":\"https://example.com/image.png\"}"`
 	ToolCall   *ToolCall   `json:"tool_call,omitempty" help:"Tool invocation requested by the model" example:"{\"id\":\"call_123\",\"name\":\"get_weather\",\"input\":{\"city\":\"London\"}}"`
 	ToolResult *ToolResult `json:"tool_result,omitempty" help:"Tool execution result returned to the model" example:"{\"id\":\"call_123\",\"name\":\"get_weather\",\"content\":{\"temperature_c\":18},\"is_error\":false}"`
+	Context    *Context    `json:"context,omitempty" help:"Machine-generated context about the environment of the turn, such as the user profile or feature flags" example:"{\"name\":\"feature_flags\",\"content\":{\"beta\":true}}"`
+
+	// Citations link text emitted by the model to the documents it was given
+	Citations []Citation `json:"citations,omitempty" help:"Source citations for the text content" optional:"" example:"[{\"type\":\"char\",\"document\":0,\"text\":\"The grass is green.\",\"start\":0,\"end\":19}]"`
+
+	// Annotations are rendering hints for frontends, and are not sent to the model
+	Annotations map[string]any `json:"annotations,omitempty" help:"Rendering hints such as audience, priority, mime type or display title" optional:"" example:"{\"audience\":[\"user\"],\"priority\":0.8,\"title\":\"Weather Lookup\"}"`
+}
+
+// Annotator is implemented by tool outputs which carry rendering hints for
+// the content block they produce
+type Annotator interface {
+	Annotations() map[string]any
 }
 
 // Attachment represents binary or URI-referenced media (images, documents, etc.)
@@ -265,6 +319,34 @@ const (
 	MessageListMax uint64 = 100
 )
 
+// Message list order constants
+const (
+	MessageOrderAsc  = "asc"
+	MessageOrderDesc = "desc"
+)
+
+// Message metadata keys
+const (
+	MessageMetaPending     = "pending"      // User input stored without generating a reply
+	MessageMetaRawRequest  = "raw_request"  // Request body sent to the provider, when captured
+	MessageMetaRawResponse = "raw_response" // Response body returned by the provider, when captured
+	MessageMetaThinkingCut = "thinking_cut" // Seconds after which thinking was stopped and the answer requested
+	MessageMetaPinned      = "pinned"       // Message is never trimmed from the context window
+	MessageMetaTranslation = "translation"  // Text of the message in the language of the user, when it was translated
+	MessageMetaLanguage    = "language"     // Language of the user, when the message was translated
+	MessageMetaPostProcess = "postprocess"  // Steps which changed the text of a reply after it was streamed
+)
+
+// Content block annotation keys
+const (
+	AnnotationAudience     = "audience"      // Roles the content is intended for, for example ["user"]
+	AnnotationPriority     = "priority"      // Importance from 0 (optional) to 1 (required)
+	AnnotationContentType  = "mime_type"     // Hint for the content type of the block
+	AnnotationTitle        = "title"         // Display title, for example of the tool which produced a result
+	AnnotationLastModified = "last_modified" // ISO 8601 timestamp of the underlying data
+	AnnotationSimulated    = "simulated"     // Source of a simulated tool result, either "fixture" or "model"
+)
+
 ////////////////////////////////////////////////////////////////////////////////
 // LIFECYCLE
 
@@ -294,19 +376,26 @@ func NewMessage(role string, text string, opts ...opt.Opt) (*Message, error) {
 	}), nil
 }
 
-// NewToolResult creates a content block containing a successful tool result
+// NewToolResult creates a content block containing a successful tool result.
+// If v implements Annotator, its annotations are copied to the block.
 func NewToolResult(id, name string, v any) ContentBlock {
 	data, err := json.Marshal(v)
 	if err != nil {
 		return NewToolError(id, name, err)
 	}
-	return ContentBlock{
+	block := ContentBlock{
 		ToolResult: &ToolResult{
 			ID:      id,
 			Name:    name,
 			Content: json.RawMessage(data),
 		},
 	}
+	if annotator, ok := v.(Annotator); ok {
+		for key, value := range annotator.Annotations() {
+			block.Annotate(key, value)
+		}
+	}
+	return block
 }
 
 // NewToolError creates a content block containing a tool error result
@@ -324,6 +413,19 @@ func NewToolError(id, name string, err error) ContentBlock {
 ////////////////////////////////////////////////////////////////////////////////
 // PUBLIC METHODS
 
+// Annotate sets a rendering hint on the content block. A nil value removes
+// the annotation.
+func (b *ContentBlock) Annotate(key string, value any) {
+	if value == nil {
+		delete(b.Annotations, key)
+		return
+	}
+	if b.Annotations == nil {
+		b.Annotations = make(map[string]any)
+	}
+	b.Annotations[key] = value
+}
+
 // Text returns the concatenated text content from all text blocks in the message
 func (m Message) Text() string {
 	var result []string
@@ -356,6 +458,12 @@ func (m Message) EstimateTokens() uint {
 				n = 1
 			}
 			tokens += n
+		case block.Context != nil:
+			n := uint(len(block.Context.Name)+len(block.Context.Content)+3) / 4
+			if n == 0 {
+				n = 1
+			}
+			tokens += n
 		case block.ToolCall != nil:
 			// Tool name + JSON arguments
 			n := uint(len(block.ToolCall.Name)+len(block.ToolCall.Input)+3) / 4
@@ -381,6 +489,34 @@ func (m Message) EstimateTokens() uint {
 	return tokens
 }
 
+// Pending returns true if the message is user input which has been stored
+// but not yet sent to the model
+func (m Message) Pending() bool {
+	pending, _ := m.Meta[MessageMetaPending].(bool)
+	return pending
+}
+
+// Pinned returns true if the message is never trimmed from the context window
+func (m Message) Pinned() bool {
+	pinned, _ := m.Meta[MessageMetaPinned].(bool)
+	return pinned
+}
+
+// Sending returns false if the message should be stored as pending input
+// rather than sent to the model
+func (q MessageCreateQuery) Sending() bool {
+	return q.Send == nil || *q.Send
+}
+
+// Query returns the query parameters for appending a message
+func (q MessageCreateQuery) Query() url.Values {
+	values := url.Values{}
+	if q.Send != nil {
+		values.Set("send", strconv.FormatBool(*q.Send))
+	}
+	return values
+}
+
 // ToolCalls returns all tool call blocks in the message
 func (m Message) ToolCalls() []ToolCall {
 	var result []ToolCall
@@ -407,9 +543,17 @@ func (r MessageList) String() string {
 	return types.Stringify(r)
 }
 
+func (r MessageCreateRequest) String() string {
+	return types.Stringify(r)
+}
+
+func (m MessageMeta) String() string {
+	return types.Stringify(m)
+}
+
 func (m *Message) Scan(row pg.Row) error {
 	var result string
-	if err := row.Scan(&m.ID, &m.Session, &m.Role, &m.Content, &m.Tokens, &result, &m.Meta); err != nil {
+	if err := row.Scan(&m.ID, &m.Session, &m.Role, &m.Content, &m.Tokens, &result, &m.Meta, &m.CreatedAt, &m.Provider, &m.Model, &m.ResponseID, &m.Latency); err != nil {
 		return err
 	}
 	m.Result = parseMessageResult(result)
@@ -425,7 +569,7 @@ func (m *Message) Scan(row pg.Row) error {
 func (m *MessageInsert) Scan(row pg.Row) error {
 	var result string
 
-	if err := row.Scan(&m.ID, &m.Session, &m.Role, &m.Content, &m.Tokens, &result, &m.Meta); err != nil {
+	if err := row.Scan(&m.ID, &m.Session, &m.Role, &m.Content, &m.Tokens, &result, &m.Meta, &m.CreatedAt, &m.Provider, &m.Model, &m.ResponseID, &m.Latency); err != nil {
 		return err
 	}
 	m.Message.Session = m.Session
@@ -469,6 +613,9 @@ func (req MessageListRequest) Query() url.Values {
 	if text := strings.TrimSpace(req.Text); text != "" {
 		values.Set("text", text)
 	}
+	if order := strings.TrimSpace(req.Order); order != "" {
+		values.Set("order", order)
+	}
 	return values
 }
 
@@ -493,11 +640,23 @@ func (req MessageListRequest) Select(bind *pg.Bind, op pg.Op) (string, error) {
 		bind.Append("where", `message.content::text ILIKE `+bind.Set("text", "%"+text+"%"))
 	}
 
+	// Newest first when the order is descending, so that the latest page of
+	// a long session can be retrieved without counting the messages
+	var direction string
+	switch strings.ToLower(strings.TrimSpace(req.Order)) {
+	case "", MessageOrderAsc:
+		direction = "ASC"
+	case MessageOrderDesc:
+		direction = "DESC"
+	default:
+		return "", ErrBadParameter.Withf("invalid message order %q", req.Order)
+	}
+
 	where := bind.Join("where", " AND ")
 	if len(messageListSessions(bind, req)) > 0 || messageListLast(bind, req) > 0 || messageListUntil(bind, req) > 0 {
-		bind.Set("orderby", `ORDER BY message.id ASC`)
+		bind.Set("orderby", `ORDER BY message.id `+direction)
 	} else {
-		bind.Set("orderby", `ORDER BY message.created_at ASC, message.id ASC`)
+		bind.Set("orderby", `ORDER BY message.created_at `+direction+`, message.id `+direction)
 	}
 	req.OffsetLimit.Bind(bind, MessageListMax)
 
@@ -522,9 +681,66 @@ func (req MessageListRequest) Select(bind *pg.Bind, op pg.Op) (string, error) {
 	}
 }
 
+func (sel MessagePendingSelector) Select(bind *pg.Bind, op pg.Op) (string, error) {
+	if sel.Session == uuid.Nil {
+		return "", ErrBadParameter.With("message session is required")
+	}
+	bind.Set("session", sel.Session)
+	bind.Set("ids", sel.IDs)
+
+	switch op {
+	case pg.Delete:
+		return bind.Query("message.delete_pending"), nil
+	default:
+		return "", ErrNotImplemented.Withf("unsupported MessagePendingSelector operation %q", op)
+	}
+}
+
+func (sel MessageSelector) Select(bind *pg.Bind, op pg.Op) (string, error) {
+	if sel.Session == uuid.Nil {
+		return "", ErrBadParameter.With("message session is required")
+	} else if sel.ID == 0 {
+		return "", ErrBadParameter.With("message id is required")
+	}
+	bind.Set("session", sel.Session)
+	bind.Set("id", sel.ID)
+
+	switch op {
+	case pg.Get:
+		return bind.Query("message.select"), nil
+	case pg.Update:
+		return bind.Query("message.update"), nil
+	default:
+		return "", ErrNotImplemented.Withf("unsupported MessageSelector operation %q", op)
+	}
+}
+
 ////////////////////////////////////////////////////////////////////////////////
 // PUBLIC METHODS - WRITER
 
+func (m MessageMeta) Insert(_ *pg.Bind) (string, error) {
+	return "", fmt.Errorf("MessageMeta: insert: not supported")
+}
+
+func (m MessageMeta) Update(bind *pg.Bind) error {
+	bind.Del("patch")
+
+	if m.Pinned != nil {
+		if *m.Pinned {
+			bind.Append("patch", `meta = COALESCE(meta, '{}'::jsonb) || jsonb_build_object('`+MessageMetaPinned+`', true)`)
+		} else {
+			bind.Append("patch", `meta = NULLIF(COALESCE(meta, '{}'::jsonb) - '`+MessageMetaPinned+`', '{}'::jsonb)`)
+		}
+	}
+
+	patch := bind.Join("patch", ", ")
+	if patch == "" {
+		return ErrBadParameter.With("no fields to update")
+	}
+	bind.Set("patch", patch)
+	return nil
+}
+
 func (m MessageInsert) Insert(bind *pg.Bind) (string, error) {
 	if m.Session == uuid.Nil {
 		return "", ErrBadParameter.With("message session is required")
@@ -563,6 +779,21 @@ func (m MessageInsert) Insert(bind *pg.Bind) (string, error) {
 		bind.Set("meta", m.Meta)
 	}
 
+	if m.CreatedAt.IsZero() {
+		bind.Set("created_at", nil)
+	} else {
+		bind.Set("created_at", m.CreatedAt)
+	}
+
+	bind.Set("provider", strings.TrimSpace(m.Provider))
+	bind.Set("model", strings.TrimSpace(m.Model))
+	bind.Set("response_id", strings.TrimSpace(m.ResponseID))
+	if m.Latency <= 0 {
+		bind.Set("latency_ns", nil)
+	} else {
+		bind.Set("latency_ns", int64(m.Latency))
+	}
+
 	return bind.Query("message.insert"), nil
 }
 
@@ -9,6 +9,7 @@ import (
 	"runtime"
 	"strings"
 	"testing"
+	"time"
 
 	// Packages
 	uuid "github.com/google/uuid"
@@ -40,6 +41,10 @@ func (r messageMockRow) Scan(dest ...any) error {
 			*target = r.values[i].(uint)
 		case *map[string]any:
 			*target = r.values[i].(map[string]any)
+		case *time.Time:
+			*target = r.values[i].(time.Time)
+		case *time.Duration:
+			*target = r.values[i].(time.Duration)
 		default:
 			return errors.New("unsupported scan target")
 		}
@@ -273,6 +278,30 @@ func Test_NewToolResult_001(t *testing.T) {
 	assert.False(tr.IsError)
 }
 
+type annotatedOutput string
+
+func (annotatedOutput) Annotations() map[string]any {
+	return map[string]any{schema.AnnotationAudience: []string{"user"}}
+}
+
+func Test_NewToolResult_002(t *testing.T) {
+	// Annotations are copied from the tool output and survive a round trip
+	assert := assert.New(t)
+	block := schema.NewToolResult("call_123", "get_weather", annotatedOutput("sunny"))
+	block.Annotate(schema.AnnotationTitle, "Weather Lookup")
+	assert.JSONEq(`"sunny"`, string(block.ToolResult.Content))
+
+	data, err := json.Marshal(block)
+	assert.NoError(err)
+	var decoded schema.ContentBlock
+	assert.NoError(json.Unmarshal(data, &decoded))
+	assert.Equal([]any{"user"}, decoded.Annotations[schema.AnnotationAudience])
+	assert.Equal("Weather Lookup", decoded.Annotations[schema.AnnotationTitle])
+
+	decoded.Annotate(schema.AnnotationTitle, nil)
+	assert.NotContains(decoded.Annotations, schema.AnnotationTitle)
+}
+
 func urlFromString(t *testing.T, raw string) *url.URL {
 	t.Helper()
 	u, err := url.Parse(raw)
@@ -364,6 +393,39 @@ func TestMessageInsertRequiresSessionAndRole(t *testing.T) {
 	}
 }
 
+func TestMessageSelectorUpdate(t *testing.T) {
+	assert := assert.New(t)
+	sessionID := uuid.MustParse("11111111-1111-1111-1111-111111111111")
+	b := pg.NewBind("schema", "llm", "message.update", "UPDATE")
+
+	query, err := (schema.MessageSelector{Session: sessionID, ID: 42}).Select(b, pg.Update)
+	if !assert.NoError(err) {
+		return
+	}
+	assert.Equal("UPDATE", query)
+	assert.Equal(sessionID, b.Get("session"))
+	assert.Equal(uint64(42), b.Get("id"))
+
+	_, err = (schema.MessageSelector{Session: sessionID}).Select(b, pg.Update)
+	assert.ErrorIs(err, schema.ErrBadParameter)
+}
+
+func TestMessageMetaUpdate(t *testing.T) {
+	assert := assert.New(t)
+	b := pg.NewBind("schema", "llm")
+
+	if assert.NoError((schema.MessageMeta{Pinned: types.Ptr(true)}).Update(b)) {
+		assert.Contains(b.Get("patch"), `jsonb_build_object('pinned', true)`)
+	}
+	if assert.NoError((schema.MessageMeta{Pinned: types.Ptr(false)}).Update(b)) {
+		assert.Contains(b.Get("patch"), `- 'pinned'`)
+	}
+	assert.ErrorIs((schema.MessageMeta{}).Update(b), schema.ErrBadParameter)
+
+	message := schema.Message{Meta: map[string]any{schema.MessageMetaPinned: true}}
+	assert.True(message.Pinned())
+}
+
 func TestMessageListRequestQuery(t *testing.T) {
 	assert := assert.New(t)
 	limit := uint64(25)
@@ -371,12 +433,34 @@ func TestMessageListRequestQuery(t *testing.T) {
 		OffsetLimit: pg.OffsetLimit{Offset: 5, Limit: &limit},
 		Role:        schema.RoleAssistant,
 		Text:        "release notes",
+		Order:       schema.MessageOrderDesc,
 	}).Query()
 
 	assert.Equal("5", values.Get("offset"))
 	assert.Equal("25", values.Get("limit"))
 	assert.Equal(schema.RoleAssistant, values.Get("role"))
 	assert.Equal("release notes", values.Get("text"))
+	assert.Equal("desc", values.Get("order"))
+}
+
+func TestMessageListRequestSelectOrder(t *testing.T) {
+	assert := assert.New(t)
+	b := pg.NewBind("schema", "llm", "message.list", "LIST")
+	b.Set("session", uuid.MustParse("11111111-1111-1111-1111-111111111111"))
+
+	// Newest messages first
+	_, err := (schema.MessageListRequest{Order: "DESC"}).Select(b, pg.List)
+	if assert.NoError(err) {
+		assert.Equal(`ORDER BY message.created_at DESC, message.id DESC`, b.Get("orderby"))
+	}
+	_, err = (schema.MessageListRequest{Sessions: []uuid.UUID{uuid.New()}, Order: schema.MessageOrderDesc}).Select(b, pg.List)
+	if assert.NoError(err) {
+		assert.Equal(`ORDER BY message.id DESC`, b.Get("orderby"))
+	}
+
+	// Other orders are rejected
+	_, err = (schema.MessageListRequest{Order: "random"}).Select(b, pg.List)
+	assert.ErrorIs(err, schema.ErrBadParameter)
 }
 
 func TestMessageListRequestSelect(t *testing.T) {
@@ -450,7 +534,8 @@ func TestMessageScanAndListScan(t *testing.T) {
 	message := new(schema.Message)
 	sessionID := uuid.MustParse("11111111-1111-1111-1111-111111111111")
 	text := types.Ptr("hello")
-	row := messageMockRow{values: []any{uint64(7), sessionID, schema.RoleAssistant, []schema.ContentBlock{{Text: text}}, uint(7), schema.ResultStop.String(), map[string]any{"source": "test"}}}
+	created := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	row := messageMockRow{values: []any{uint64(7), sessionID, schema.RoleAssistant, []schema.ContentBlock{{Text: text}}, uint(7), schema.ResultStop.String(), map[string]any{"source": "test"}, created, "anthropic", "claude-test", "msg_123", 1500 * time.Millisecond}}
 
 	if !assert.NoError(message.Scan(row)) {
 		return
@@ -465,9 +550,14 @@ func TestMessageScanAndListScan(t *testing.T) {
 	assert.Equal(uint(7), message.Tokens)
 	assert.Equal(schema.ResultStop, message.Result)
 	assert.Equal(map[string]any{"source": "test"}, message.Meta)
+	assert.Equal(created, message.CreatedAt)
+	assert.Equal("anthropic", message.Provider)
+	assert.Equal("claude-test", message.Model)
+	assert.Equal("msg_123", message.ResponseID)
+	assert.Equal(1500*time.Millisecond, message.Latency)
 
 	list := new(schema.MessageList)
-	if !assert.NoError(list.Scan(messageMockRow{values: []any{uint64(8), sessionID, schema.RoleUser, []schema.ContentBlock{{Text: types.Ptr("hi")}}, uint(3), "", map[string]any{}}})) {
+	if !assert.NoError(list.Scan(messageMockRow{values: []any{uint64(8), sessionID, schema.RoleUser, []schema.ContentBlock{{Text: types.Ptr("hi")}}, uint(3), "", map[string]any{}, created, "", "", "", time.Duration(0)}})) {
 		return
 	}
 	if assert.Len(list.Body, 1) {
@@ -483,6 +573,57 @@ func TestMessageScanAndListScan(t *testing.T) {
 	assert.Equal(uint(42), list.Count)
 }
 
+func TestMessageInsertBindsGeneration(t *testing.T) {
+	assert := assert.New(t)
+	b := pg.NewBind("schema", "llm", "message.insert", "INSERT")
+
+	_, err := (schema.MessageInsert{
+		Session: uuid.New(),
+		Message: schema.Message{
+			Role:       schema.RoleAssistant,
+			Provider:   "mistral",
+			Model:      "mistral-small-latest",
+			ResponseID: "cmpl-123",
+			Latency:    250 * time.Millisecond,
+		},
+	}).Insert(b)
+	if !assert.NoError(err) {
+		return
+	}
+	assert.Equal("mistral", b.Get("provider"))
+	assert.Equal("mistral-small-latest", b.Get("model"))
+	assert.Equal("cmpl-123", b.Get("response_id"))
+	assert.Equal(int64(250*time.Millisecond), b.Get("latency_ns"))
+
+	_, err = (schema.MessageInsert{
+		Session: uuid.New(),
+		Message: schema.Message{Role: schema.RoleUser},
+	}).Insert(b)
+	if !assert.NoError(err) {
+		return
+	}
+	assert.Equal("", b.Get("provider"))
+	assert.Nil(b.Get("latency_ns"))
+}
+
+func TestConversationSetLatency(t *testing.T) {
+	assert := assert.New(t)
+	created := time.Now()
+	reply := &schema.Message{Role: schema.RoleAssistant, CreatedAt: created}
+	conversation := schema.Conversation{
+		{Role: schema.RoleUser},
+	}
+	conversation.Append(*reply)
+
+	conversation.SetLatency(reply, created.Add(-time.Second))
+	assert.GreaterOrEqual(reply.Latency, time.Second)
+	assert.Equal(reply.Latency, conversation[1].Latency)
+	assert.Zero(conversation[0].Latency)
+
+	// A nil reply is ignored
+	conversation.SetLatency(nil, created)
+}
+
 func TestMessageListRequestSelectWithoutSessionBinding(t *testing.T) {
 	assert := assert.New(t)
 	b := pg.NewBind("schema", "llm", "message.list", "LIST")
@@ -495,3 +636,60 @@ func TestMessageListRequestSelectWithoutSessionBinding(t *testing.T) {
 	assert.Equal("LIST", query)
 	assert.Equal("", b.Get("where"))
 }
+
+func TestMessagePending(t *testing.T) {
+	assert := assert.New(t)
+	assert.False(schema.Message{}.Pending())
+	assert.False(schema.Message{Meta: map[string]any{schema.MessageMetaPending: false}}.Pending())
+	assert.True(schema.Message{Meta: map[string]any{schema.MessageMetaPending: true}}.Pending())
+}
+
+func TestConversationSplitPending(t *testing.T) {
+	assert := assert.New(t)
+	pending := map[string]any{schema.MessageMetaPending: true}
+	conversation := schema.Conversation{
+		{ID: 1, Role: schema.RoleUser, Meta: pending},
+		{ID: 2, Role: schema.RoleAssistant},
+		{ID: 3, Role: schema.RoleUser, Meta: pending},
+		{ID: 4, Role: schema.RoleUser, Meta: pending},
+	}
+
+	history, input := conversation.SplitPending()
+	if assert.Len(history, 2) && assert.Len(input, 2) {
+		assert.Equal(uint64(2), history[1].ID)
+		assert.Equal(uint64(3), input[0].ID)
+		assert.Equal(uint64(4), input[1].ID)
+	}
+
+	history, input = conversation[:2].SplitPending()
+	assert.Len(history, 2)
+	assert.Empty(input)
+}
+
+func TestMessageCreateQuery(t *testing.T) {
+	assert := assert.New(t)
+	assert.True(schema.MessageCreateQuery{}.Sending())
+	assert.True(schema.MessageCreateQuery{Send: types.Ptr(true)}.Sending())
+	assert.False(schema.MessageCreateQuery{Send: types.Ptr(false)}.Sending())
+	assert.Equal("send=false", schema.MessageCreateQuery{Send: types.Ptr(false)}.Query().Encode())
+	assert.Empty(schema.MessageCreateQuery{}.Query())
+}
+
+func TestMessagePendingSelector(t *testing.T) {
+	assert := assert.New(t)
+	b := pg.NewBind("schema", "llm", "message.delete_pending", "DELETE")
+	session := uuid.New()
+
+	query, err := (schema.MessagePendingSelector{Session: session, IDs: []uint64{3, 4}}).Select(b, pg.Delete)
+	if !assert.NoError(err) {
+		return
+	}
+	assert.Equal("DELETE", query)
+	assert.Equal(session, b.Get("session"))
+	assert.Equal([]uint64{3, 4}, b.Get("ids"))
+
+	_, err = (schema.MessagePendingSelector{Session: session}).Select(b, pg.List)
+	assert.Error(err)
+	_, err = (schema.MessagePendingSelector{}).Select(b, pg.Delete)
+	assert.Error(err)
+}
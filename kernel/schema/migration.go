@@ -0,0 +1,119 @@
+package schema
+
+import (
+	"strconv"
+	"strings"
+
+	// Packages
+	pg "github.com/mutablelogic/go-pg"
+	types "github.com/mutablelogic/go-server/pkg/types"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// Migration changes the objects of an existing database schema, such as
+// adding a column to a table which was created by an earlier release. Each
+// migration is applied once, in order of version.
+type Migration struct {
+	Version uint   `json:"version"`
+	Name    string `json:"name"`
+	SQL     string `json:"-"`
+}
+
+// MigrationList is the versions of the migrations which have been applied
+type MigrationList struct {
+	Body []uint `json:"body"`
+}
+
+// MigrationListRequest lists the migrations which have been applied
+type MigrationListRequest struct{}
+
+// MigrationLock holds a lock until the end of the transaction, so that
+// replicas which start together apply each migration once
+type MigrationLock struct{}
+
+////////////////////////////////////////////////////////////////////////////////
+// LIFECYCLE
+
+// ParseMigrations returns the migrations in the SQL, where each migration is
+// headed by a comment with its version and name, such as "-- 1.agent_id".
+// Versions must increase through the SQL.
+func ParseMigrations(sql string) ([]Migration, error) {
+	queries, err := pg.NewQueries(strings.NewReader(sql))
+	if err != nil {
+		return nil, err
+	}
+	result := make([]Migration, 0, len(queries.Keys()))
+	for _, key := range queries.Keys() {
+		version, name, ok := strings.Cut(key, ".")
+		if !ok || name == "" {
+			return nil, ErrBadParameter.Withf("migration %q: expected a version and name", key)
+		}
+		n, err := strconv.ParseUint(version, 10, 32)
+		if err != nil || n == 0 {
+			return nil, ErrBadParameter.Withf("migration %q: invalid version", key)
+		}
+		if len(result) > 0 && uint(n) <= result[len(result)-1].Version {
+			return nil, ErrBadParameter.Withf("migration %q: versions must increase", key)
+		}
+		result = append(result, Migration{Version: uint(n), Name: name, SQL: queries.Query(key)})
+	}
+	return result, nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// STRINGIFY
+
+func (m Migration) String() string {
+	return types.Stringify(m)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// SELECTORS
+
+func (MigrationListRequest) Select(bind *pg.Bind, op pg.Op) (string, error) {
+	switch op {
+	case pg.List:
+		return bind.Query("migration.list"), nil
+	default:
+		return "", ErrNotImplemented.Withf("unsupported MigrationListRequest operation %q", op)
+	}
+}
+
+func (MigrationLock) Select(bind *pg.Bind, op pg.Op) (string, error) {
+	switch op {
+	case pg.Get:
+		return bind.Query("migration.lock"), nil
+	default:
+		return "", ErrNotImplemented.Withf("unsupported MigrationLock operation %q", op)
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS - READER
+
+func (list *MigrationList) Scan(row pg.Row) error {
+	var version uint
+	if err := row.Scan(&version); err != nil {
+		return err
+	}
+	list.Body = append(list.Body, version)
+	return nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS - WRITER
+
+func (m Migration) Insert(bind *pg.Bind) (string, error) {
+	if m.Version == 0 || strings.TrimSpace(m.Name) == "" {
+		return "", ErrBadParameter.With("migration version and name are required")
+	}
+	bind.Set("version", m.Version)
+	bind.Set("name", strings.TrimSpace(m.Name))
+	return bind.Query("migration.insert"), nil
+}
+
+func (m Migration) Update(_ *pg.Bind) error {
+	return ErrNotImplemented.With("migration: update: not supported")
+}
@@ -0,0 +1,55 @@
+package schema_test
+
+import (
+	"testing"
+
+	// Packages
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	pg "github.com/mutablelogic/go-pg"
+	assert "github.com/stretchr/testify/assert"
+)
+
+func TestParseMigrations(t *testing.T) {
+	assert := assert.New(t)
+
+	// The embedded migrations parse, in order of version
+	migrations, err := schema.ParseMigrations(schema.Migrations)
+	if assert.NoError(err) && assert.NotEmpty(migrations) {
+		for i, migration := range migrations {
+			assert.NotEmpty(migration.Name)
+			assert.NotEmpty(migration.SQL)
+			if i > 0 {
+				assert.Greater(migration.Version, migrations[i-1].Version)
+			}
+		}
+	}
+
+	// Versions must be set and increase
+	migrations, err = schema.ParseMigrations("-- 1.first\nSELECT 1;\n\n-- 3.second\nSELECT 2;\n")
+	if assert.NoError(err) && assert.Len(migrations, 2) {
+		assert.Equal(uint(3), migrations[1].Version)
+		assert.Equal("second", migrations[1].Name)
+	}
+	_, err = schema.ParseMigrations("-- first\nSELECT 1;\n")
+	assert.ErrorIs(err, schema.ErrBadParameter)
+	_, err = schema.ParseMigrations("-- 0.first\nSELECT 1;\n")
+	assert.ErrorIs(err, schema.ErrBadParameter)
+	_, err = schema.ParseMigrations("-- 2.first\nSELECT 1;\n\n-- 1.second\nSELECT 2;\n")
+	assert.ErrorIs(err, schema.ErrBadParameter)
+}
+
+func TestMigrationInsert(t *testing.T) {
+	assert := assert.New(t)
+
+	bind := pg.NewBind()
+	_, err := schema.Migration{Version: 2, Name: " agent_id "}.Insert(bind)
+	if assert.NoError(err) {
+		assert.Equal(uint(2), bind.Get("version"))
+		assert.Equal("agent_id", bind.Get("name"))
+	}
+	_, err = schema.Migration{Name: "agent_id"}.Insert(pg.NewBind())
+	assert.ErrorIs(err, schema.ErrBadParameter)
+
+	_, err = schema.MigrationLock{}.Select(pg.NewBind(), pg.List)
+	assert.ErrorIs(err, schema.ErrNotImplemented)
+}
@@ -0,0 +1,11 @@
+-- 1.agent_id
+ALTER TABLE ${"schema"}.agent
+  ADD COLUMN IF NOT EXISTS "id" UUID NOT NULL DEFAULT gen_random_uuid();
+
+-- 2.agent_index_id
+CREATE UNIQUE INDEX IF NOT EXISTS agent_id_idx
+  ON ${"schema"}.agent ("id");
+
+-- 3.agent_index_created_at
+CREATE INDEX IF NOT EXISTS agent_created_at_idx
+  ON ${"schema"}.agent ("created_at" DESC);
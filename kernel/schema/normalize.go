@@ -0,0 +1,163 @@
+package schema
+
+import (
+	"maps"
+	"slices"
+
+	// Packages
+	types "github.com/mutablelogic/go-server/pkg/types"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+// Text of the user message inserted when a provider requires the
+// conversation to start with a user message
+const placeholderText = "Continue."
+
+////////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// Normalize returns a copy of the conversation with common history issues
+// fixed for the named provider, and should be applied before a request is
+// built. Empty assistant messages are dropped, tool results are moved to the
+// message which follows their call, and consecutive messages with the same
+// role are merged. For providers which require alternation, a placeholder user
+// message is inserted when the conversation starts with an assistant message.
+// The messages in the conversation are not modified.
+func Normalize(provider string, conversation Conversation) Conversation {
+	alternating := slices.Contains(alternatingProviders, provider)
+
+	// Copy the messages, dropping those without content
+	result := make(Conversation, 0, len(conversation))
+	for _, message := range conversation {
+		if message == nil || (message.Role == RoleAssistant && len(message.Content) == 0) {
+			continue
+		}
+		message := *message
+		message.Content = slices.Clone(message.Content)
+		result = append(result, &message)
+	}
+
+	result = normalizeToolResults(result)
+	result = normalizeMerge(result, alternating)
+	if alternating {
+		result = normalizeStart(result)
+	}
+	return result
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// normalizeToolResults moves the results for the calls of each assistant
+// message from later messages into a user message which directly follows it.
+// Results which do not answer a call are left in place, and messages which
+// are left without content are dropped.
+func normalizeToolResults(conversation Conversation) Conversation {
+	result := make(Conversation, 0, len(conversation))
+	for i, message := range conversation {
+		if message.Role != RoleAssistant && len(message.Content) == 0 {
+			continue
+		}
+		result = append(result, message)
+		calls := message.ToolCalls()
+		if message.Role != RoleAssistant || len(calls) == 0 {
+			continue
+		}
+
+		// Collect the results from the messages which follow
+		var answers []ContentBlock
+		for _, next := range conversation[i+1:] {
+			if next.Role == RoleAssistant && len(next.ToolCalls()) > 0 {
+				break
+			}
+			next.Content = slices.DeleteFunc(next.Content, func(block ContentBlock) bool {
+				if block.ToolResult == nil {
+					return false
+				}
+				j := slices.IndexFunc(calls, func(call ToolCall) bool {
+					return toolResultAnswers(call, block.ToolResult)
+				})
+				if j < 0 {
+					return false
+				}
+				calls = slices.Delete(calls, j, j+1)
+				answers = append(answers, block)
+				return true
+			})
+		}
+		if len(answers) > 0 {
+			result = append(result, &Message{Role: RoleUser, Content: answers})
+		}
+	}
+	return result
+}
+
+// normalizeMerge merges consecutive messages with the same role. Messages
+// with tool results are only merged for alternating providers, since other
+// providers send tool results as separate messages. When alternating, system
+// messages between two messages are ignored since they are sent separately.
+func normalizeMerge(conversation Conversation, alternating bool) Conversation {
+	result := make(Conversation, 0, len(conversation))
+	for _, message := range conversation {
+		prev := -1
+		for i := len(result) - 1; i >= 0; i-- {
+			if alternating && message.Role != RoleSystem && result[i].Role == RoleSystem {
+				continue
+			}
+			prev = i
+			break
+		}
+		if prev < 0 || !normalizeCanMerge(result[prev], message, alternating) {
+			result = append(result, message)
+			continue
+		}
+
+		// Merge into the earlier message, keeping its metadata
+		merged := *result[prev]
+		merged.Content = slices.Concat(merged.Content, message.Content)
+		if len(message.Meta) > 0 {
+			meta := maps.Clone(message.Meta)
+			maps.Copy(meta, merged.Meta)
+			merged.Meta = meta
+		}
+		result[prev] = &merged
+	}
+	return result
+}
+
+func normalizeCanMerge(a, b *Message, alternating bool) bool {
+	if a.Role != b.Role || (a.Role != RoleUser && a.Role != RoleAssistant) {
+		return false
+	}
+	return alternating || (!hasToolResults(a) && !hasToolResults(b))
+}
+
+// normalizeStart inserts a placeholder user message when the first message
+// which is not a system message is from the assistant.
+func normalizeStart(conversation Conversation) Conversation {
+	i := slices.IndexFunc(conversation, func(message *Message) bool {
+		return message.Role != RoleSystem
+	})
+	if i < 0 || conversation[i].Role != RoleAssistant {
+		return conversation
+	}
+	placeholder := &Message{Role: RoleUser, Content: []ContentBlock{{Text: types.Ptr(placeholderText)}}}
+	return slices.Insert(conversation, i, placeholder)
+}
+
+func hasToolResults(message *Message) bool {
+	return slices.ContainsFunc(message.Content, func(block ContentBlock) bool {
+		return block.ToolResult != nil
+	})
+}
+
+// toolResultAnswers returns true if the result answers the call. Calls
+// without an ID are matched by name.
+func toolResultAnswers(call ToolCall, result *ToolResult) bool {
+	if call.ID != "" || result.ID != "" {
+		return call.ID == result.ID
+	}
+	return call.Name == result.Name
+}
@@ -0,0 +1,100 @@
+package schema_test
+
+import (
+	"testing"
+
+	// Packages
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	assert "github.com/stretchr/testify/assert"
+)
+
+func normalizeRoles(conversation schema.Conversation) []string {
+	roles := make([]string, 0, len(conversation))
+	for _, message := range conversation {
+		roles = append(roles, message.Role)
+	}
+	return roles
+}
+
+func Test_Normalize_001(t *testing.T) {
+	// Empty assistant messages are dropped and consecutive messages merged,
+	// without modifying the original messages
+	assert := assert.New(t)
+
+	first := validateText(schema.RoleUser, "hello")
+	conversation := schema.Conversation{
+		first,
+		{Role: schema.RoleAssistant},
+		validateText(schema.RoleUser, "are you there?"),
+		validateText(schema.RoleAssistant, "yes"),
+	}
+	result := schema.Normalize(schema.Ollama, conversation)
+	assert.Equal([]string{schema.RoleUser, schema.RoleAssistant}, normalizeRoles(result))
+	assert.Equal("hello\nare you there?", result[0].Text())
+	assert.Len(first.Content, 1)
+	assert.Len(conversation, 4)
+}
+
+func Test_Normalize_002(t *testing.T) {
+	// Tool results are moved after their calls
+	assert := assert.New(t)
+
+	conversation := schema.Conversation{
+		validateText(schema.RoleUser, "weather?"),
+		validateCall("1", "weather"),
+		validateText(schema.RoleUser, "in London"),
+		validateResult("1", "weather"),
+	}
+	assert.Error(schema.Validate(conversation))
+
+	result := schema.Normalize(schema.OpenAI, conversation)
+	assert.Equal([]string{schema.RoleUser, schema.RoleAssistant, schema.RoleUser, schema.RoleUser}, normalizeRoles(result))
+	assert.NotNil(result[2].Content[0].ToolResult)
+	assert.Equal("in London", result[3].Text())
+	assert.NoError(schema.ValidateFor(schema.OpenAI, result))
+
+	// Alternating providers get the results and text in one message
+	result = schema.Normalize(schema.Anthropic, conversation)
+	assert.Equal([]string{schema.RoleUser, schema.RoleAssistant, schema.RoleUser}, normalizeRoles(result))
+	if assert.Len(result[2].Content, 2) {
+		assert.NotNil(result[2].Content[0].ToolResult)
+		assert.Equal("in London", result[2].Text())
+	}
+	assert.NoError(schema.ValidateFor(schema.Anthropic, result))
+	assert.Len(conversation[3].Content, 1)
+}
+
+func Test_Normalize_003(t *testing.T) {
+	// Results which do not answer a call are left in place
+	assert := assert.New(t)
+
+	conversation := schema.Conversation{
+		validateText(schema.RoleUser, "hi"),
+		validateResult("1", "weather"),
+	}
+	result := schema.Normalize(schema.Mistral, conversation)
+	assert.Equal([]string{schema.RoleUser, schema.RoleUser}, normalizeRoles(result))
+}
+
+func Test_Normalize_004(t *testing.T) {
+	// A placeholder user message is inserted for alternating providers
+	assert := assert.New(t)
+
+	conversation := schema.Conversation{
+		validateText(schema.RoleSystem, "be brief"),
+		validateText(schema.RoleAssistant, "hello"),
+		validateText(schema.RoleSystem, "be kind"),
+		validateText(schema.RoleAssistant, "how can I help?"),
+		validateText(schema.RoleUser, "hi"),
+	}
+	assert.Error(schema.ValidateFor(schema.Gemini, conversation))
+
+	result := schema.Normalize(schema.Gemini, conversation)
+	assert.Equal([]string{schema.RoleSystem, schema.RoleUser, schema.RoleAssistant, schema.RoleSystem, schema.RoleUser}, normalizeRoles(result))
+	assert.Equal("hello\nhow can I help?", result[2].Text())
+	assert.NoError(schema.ValidateFor(schema.Gemini, result))
+
+	// Other providers keep the conversation as it is
+	result = schema.Normalize(schema.Ollama, conversation)
+	assert.Len(result, 5)
+}
@@ -12,6 +12,13 @@ EXCEPTION
   WHEN duplicate_object THEN null;
 END $$;
 
+-- llm.migration
+CREATE TABLE IF NOT EXISTS ${"schema"}.migration (
+    "version"    INT NOT NULL PRIMARY KEY,
+    "name"       TEXT NOT NULL,
+    "applied_at" TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
 -- llm.provider
 CREATE TABLE IF NOT EXISTS ${"schema"}.provider (
     "name"        TEXT NOT NULL PRIMARY KEY CHECK ("name" ~ '^[a-zA-Z][a-zA-Z0-9_-]{0,63}$'),
@@ -34,6 +41,17 @@ CREATE TABLE IF NOT EXISTS ${"schema"}.provider_group (
   PRIMARY KEY ("provider", "group")
 );
 
+-- llm.provider_credential
+CREATE TABLE IF NOT EXISTS ${"schema"}.provider_credential (
+  "provider"    TEXT NOT NULL REFERENCES ${"schema"}.provider ("name") ON DELETE CASCADE,
+  "user"        UUID NOT NULL REFERENCES ${"auth"}."user" (id) ON DELETE CASCADE,
+  "pv"          INT NOT NULL DEFAULT 0,
+  "credentials" BYTEA NOT NULL,
+  "created_at"  TIMESTAMPTZ NOT NULL DEFAULT now(),
+  "modified_at" TIMESTAMPTZ,
+  PRIMARY KEY ("provider", "user")
+);
+
 -- llm.session
 CREATE TABLE IF NOT EXISTS ${"schema"}.session (
     "id"          UUID NOT NULL DEFAULT gen_random_uuid() PRIMARY KEY,
@@ -74,14 +92,90 @@ CREATE TABLE IF NOT EXISTS ${"schema"}.message (
     "tokens"      INT,
     "result"      ${"schema"}.MESSAGE_RESULT,
     "meta"        JSONB,
-    "created_at"  TIMESTAMPTZ NOT NULL DEFAULT now()
+    "created_at"  TIMESTAMPTZ NOT NULL DEFAULT now(),
+    "provider"    TEXT,
+    "model"       TEXT,
+    "response_id" TEXT,
+    "latency_ns"  BIGINT
 );
 
+-- llm.message_generation
+ALTER TABLE ${"schema"}.message
+  ADD COLUMN IF NOT EXISTS "provider"    TEXT,
+  ADD COLUMN IF NOT EXISTS "model"       TEXT,
+  ADD COLUMN IF NOT EXISTS "response_id" TEXT,
+  ADD COLUMN IF NOT EXISTS "latency_ns"  BIGINT;
+
+-- llm.message_modified
+ALTER TABLE ${"schema"}.message
+  ADD COLUMN IF NOT EXISTS "modified_at" TIMESTAMPTZ;
+
+-- llm.session_parent_message
+ALTER TABLE ${"schema"}.session
+  ADD COLUMN IF NOT EXISTS "parent_message" BIGINT REFERENCES ${"schema"}.message (id) ON DELETE SET NULL;
+
+-- llm.session_index_parent_message
+CREATE INDEX IF NOT EXISTS session_parent_message_idx
+  ON ${"schema"}.session ("parent_message")
+  WHERE "parent_message" IS NOT NULL;
+
 -- llm.message_index_session_created_at
 CREATE INDEX IF NOT EXISTS message_session_created_at_idx
   ON ${"schema"}.message ("session", "created_at", "id");
 
--- llm.prompt
+-- llm.session_share
+CREATE TABLE IF NOT EXISTS ${"schema"}.session_share (
+    "id"          UUID NOT NULL DEFAULT gen_random_uuid() PRIMARY KEY,
+    "session"     UUID NOT NULL REFERENCES ${"schema"}."session" (id) ON DELETE CASCADE,
+    "hash"        TEXT NOT NULL UNIQUE,
+    "expires_at"  TIMESTAMPTZ NOT NULL,
+    "created_at"  TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- llm.session_share_index_session
+CREATE INDEX IF NOT EXISTS session_share_session_idx
+  ON ${"schema"}.session_share ("session", "created_at");
+
+-- llm.reasoning
+CREATE TABLE IF NOT EXISTS ${"schema"}.reasoning (
+    "message"     BIGINT NOT NULL PRIMARY KEY REFERENCES ${"schema"}.message (id) ON DELETE CASCADE,
+    "session"     UUID NOT NULL REFERENCES ${"schema"}."session" (id) ON DELETE CASCADE,
+    "content"     JSONB NOT NULL,
+    "created_at"  TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- llm.reasoning_index_session
+CREATE INDEX IF NOT EXISTS reasoning_session_idx
+  ON ${"schema"}.reasoning ("session", "message");
+
+-- llm.checkpoint
+CREATE TABLE IF NOT EXISTS ${"schema"}.checkpoint (
+    "session"     UUID NOT NULL PRIMARY KEY REFERENCES ${"schema"}."session" (id) ON DELETE CASCADE,
+    "user"        UUID,
+    "owner"       UUID NOT NULL,
+    "iteration"   INT NOT NULL DEFAULT 0,
+    "request"     JSONB NOT NULL,
+    "messages"    JSONB NOT NULL,
+    "pending"     BIGINT[] NOT NULL DEFAULT '{}',
+    "usage"       JSONB NOT NULL DEFAULT '[]',
+    "overhead"    INT NOT NULL DEFAULT 0,
+    "created_at"  TIMESTAMPTZ NOT NULL DEFAULT now(),
+    "modified_at" TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- llm.checkpoint_index_modified_at
+CREATE INDEX IF NOT EXISTS checkpoint_modified_at_idx
+  ON ${"schema"}.checkpoint ("modified_at");
+
+-- llm.compaction
+CREATE TABLE IF NOT EXISTS ${"schema"}.compaction (
+    "session"     UUID NOT NULL PRIMARY KEY REFERENCES ${"schema"}."session" (id) ON DELETE CASCADE,
+    "through"     BIGINT NOT NULL,
+    "summary"     TEXT NOT NULL DEFAULT '',
+    "modified_at" TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- llm.agent
 CREATE TABLE IF NOT EXISTS ${"schema"}.agent (
     "name"        TEXT NOT NULL CHECK ("name" ~ '^[a-zA-Z][a-zA-Z0-9_-]{0,63}$'),
     "version"     INT NOT NULL DEFAULT 1,
@@ -123,6 +217,44 @@ CREATE INDEX IF NOT EXISTS usage_user_created_at_idx
 CREATE INDEX IF NOT EXISTS usage_session_created_at_idx
     ON ${"schema"}.usage ("session", "created_at");
 
+-- llm.archive
+CREATE TABLE IF NOT EXISTS ${"schema"}.archive (
+  "id"          UUID NOT NULL PRIMARY KEY,
+  "seq"         BIGSERIAL NOT NULL UNIQUE,
+  "type"        TEXT NOT NULL,
+  "user"        UUID,
+  "session"     UUID,
+  "provider"    TEXT NOT NULL DEFAULT '',
+  "model"       TEXT NOT NULL DEFAULT '',
+  "request"     JSON NOT NULL,
+  "response"    JSON NOT NULL,
+  "prev_hash"   TEXT NOT NULL DEFAULT '',
+  "hash"        TEXT NOT NULL,
+  "created_at"  TIMESTAMPTZ NOT NULL
+);
+
+-- llm.archive.immutable.function
+CREATE OR REPLACE FUNCTION ${"schema"}.archive_immutable()
+RETURNS trigger AS $$
+BEGIN
+  RAISE EXCEPTION 'archive records cannot be changed or removed';
+END;
+$$ LANGUAGE plpgsql;
+
+-- llm.archive.immutable.trigger
+DO $$ BEGIN
+  DROP TRIGGER IF EXISTS archive_immutable ON ${"schema"}.archive;
+  CREATE TRIGGER archive_immutable
+  BEFORE UPDATE OR DELETE ON ${"schema"}.archive
+  FOR EACH ROW
+  EXECUTE FUNCTION ${"schema"}.archive_immutable();
+  DROP TRIGGER IF EXISTS archive_immutable_truncate ON ${"schema"}.archive;
+  CREATE TRIGGER archive_immutable_truncate
+  BEFORE TRUNCATE ON ${"schema"}.archive
+  FOR EACH STATEMENT
+  EXECUTE FUNCTION ${"schema"}.archive_immutable();
+END $$;
+
 -- llm.connector
 CREATE TABLE IF NOT EXISTS ${"schema"}.connector (
   "url"                 TEXT NOT NULL PRIMARY KEY,
@@ -0,0 +1,69 @@
+package schema
+
+import (
+	"strings"
+
+	// Packages
+	types "github.com/mutablelogic/go-server/pkg/types"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// OCRResult is the structured output of reading a document, with one
+// markdown page per page of the source document.
+type OCRResult struct {
+	Model string    `json:"model,omitempty" help:"Model which read the document"`
+	Pages []OCRPage `json:"pages" help:"Pages of the document, in order"`
+}
+
+// OCRPage is a single page of a document rendered as markdown.
+type OCRPage struct {
+	Index    uint       `json:"index" help:"Zero-based page index"`
+	Markdown string     `json:"markdown" help:"Page content as markdown"`
+	Width    uint       `json:"width,omitempty" help:"Page width in pixels"`
+	Height   uint       `json:"height,omitempty" help:"Page height in pixels"`
+	DPI      uint       `json:"dpi,omitempty" help:"Resolution at which the page was rendered"`
+	Images   []OCRImage `json:"images,omitempty" help:"Images extracted from the page"`
+}
+
+// OCRImage is an image extracted from a page, located by its bounding box in
+// page pixel coordinates. The markdown refers to the image by its ID.
+type OCRImage struct {
+	ID           string      `json:"id" help:"Image identifier, as referenced in the page markdown"`
+	TopLeftX     uint        `json:"top_left_x" help:"Left edge of the bounding box"`
+	TopLeftY     uint        `json:"top_left_y" help:"Top edge of the bounding box"`
+	BottomRightX uint        `json:"bottom_right_x" help:"Right edge of the bounding box"`
+	BottomRightY uint        `json:"bottom_right_y" help:"Bottom edge of the bounding box"`
+	Attachment   *Attachment `json:"attachment,omitempty" help:"Image data, when requested"`
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// STRINGIFY
+
+func (r OCRResult) String() string {
+	return types.Stringify(r)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// Markdown returns the markdown for all pages, separated by blank lines
+func (r OCRResult) Markdown() string {
+	pages := make([]string, 0, len(r.Pages))
+	for _, page := range r.Pages {
+		if markdown := strings.TrimSpace(page.Markdown); markdown != "" {
+			pages = append(pages, markdown)
+		}
+	}
+	return strings.Join(pages, "\n\n")
+}
+
+// Attachment returns the markdown for all pages as a text/markdown
+// attachment, so that it can be included in a chat message
+func (r OCRResult) Attachment() *Attachment {
+	return &Attachment{
+		ContentType: "text/markdown",
+		Data:        []byte(r.Markdown()),
+	}
+}
@@ -0,0 +1,624 @@
+package schema
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/url"
+	"strings"
+	"time"
+
+	// Packages
+	types "github.com/mutablelogic/go-server/pkg/types"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// OpenAIChatRequest is a request to the OpenAI-compatible chat completions
+// endpoint. Fields which have no equivalent, such as temperature, are
+// accepted and ignored.
+type OpenAIChatRequest struct {
+	Model               string                `json:"model" help:"Model name" example:"llama3.2"`
+	Messages            []OpenAIMessage       `json:"messages" help:"Conversation to reply to"`
+	Tools               []OpenAITool          `json:"tools,omitempty" help:"Tools which the client runs" optional:""`
+	ToolChoice          any                   `json:"tool_choice,omitempty" help:"Set to none to offer no tools" optional:""`
+	MaxTokens           *uint                 `json:"max_tokens,omitempty" help:"Maximum output tokens to generate" optional:""`
+	MaxCompletionTokens *uint                 `json:"max_completion_tokens,omitempty" help:"Maximum output tokens to generate" optional:""`
+	Seed                *uint                 `json:"seed,omitempty" help:"Random seed" optional:""`
+	ReasoningEffort     string                `json:"reasoning_effort,omitempty" help:"Enables thinking unless none" optional:""`
+	ResponseFormat      *OpenAIResponseFormat `json:"response_format,omitempty" help:"Format of the reply" optional:""`
+	Stream              bool                  `json:"stream,omitempty" help:"Stream the reply as server-sent events" optional:""`
+	StreamOptions       *OpenAIStreamOptions  `json:"stream_options,omitempty" help:"Options for streaming" optional:""`
+}
+
+// OpenAIStreamOptions are the options for a streamed reply
+type OpenAIStreamOptions struct {
+	IncludeUsage bool `json:"include_usage,omitempty" help:"Send the usage in a chunk before the end of the stream"`
+}
+
+// OpenAIResponseFormat is the format of a reply, which is text, any JSON
+// object, or JSON which matches a schema
+type OpenAIResponseFormat struct {
+	Type       string `json:"type" enum:"text,json_object,json_schema"`
+	JSONSchema *struct {
+		Name   string     `json:"name,omitempty"`
+		Schema JSONSchema `json:"schema,omitempty"`
+		Strict bool       `json:"strict,omitempty"`
+	} `json:"json_schema,omitempty"`
+}
+
+// OpenAIMessage is a message in a conversation, or the change to the reply
+// in a chunk of a stream
+type OpenAIMessage struct {
+	Role             string           `json:"role,omitempty" enum:"system,developer,user,assistant,tool"`
+	Content          OpenAIContent    `json:"content"`
+	ReasoningContent string           `json:"reasoning_content,omitempty"`
+	Name             string           `json:"name,omitempty"`
+	ToolCalls        []OpenAIToolCall `json:"tool_calls,omitempty"`
+	ToolCallID       string           `json:"tool_call_id,omitempty"`
+}
+
+// OpenAIContent is the content of a message, which is encoded as a string
+// when it is only text, or as an array of parts
+type OpenAIContent []OpenAIContentPart
+
+// OpenAIContentPart is text or an image in the content of a message
+type OpenAIContentPart struct {
+	Type     string `json:"type" enum:"text,image_url"`
+	Text     string `json:"text,omitempty"`
+	ImageURL *struct {
+		URL    string `json:"url"`
+		Detail string `json:"detail,omitempty"`
+	} `json:"image_url,omitempty"`
+}
+
+// OpenAITool is a function which the client runs
+type OpenAITool struct {
+	Type     string `json:"type" enum:"function"`
+	Function struct {
+		Name        string     `json:"name"`
+		Description string     `json:"description,omitempty"`
+		Parameters  JSONSchema `json:"parameters,omitempty"`
+	} `json:"function"`
+}
+
+// OpenAIToolCall is a call to a function in a reply. The index is used to
+// match calls between the chunks of a stream.
+type OpenAIToolCall struct {
+	Index    int    `json:"index"`
+	ID       string `json:"id,omitempty"`
+	Type     string `json:"type,omitempty"`
+	Function struct {
+		Name      string `json:"name,omitempty"`
+		Arguments string `json:"arguments"`
+	} `json:"function"`
+}
+
+// OpenAIChatResponse is a reply from the chat completions endpoint, or a
+// chunk of a streamed reply
+type OpenAIChatResponse struct {
+	ID      string         `json:"id"`
+	Object  string         `json:"object" enum:"chat.completion,chat.completion.chunk"`
+	Created int64          `json:"created"`
+	Model   string         `json:"model"`
+	Choices []OpenAIChoice `json:"choices"`
+	Usage   *OpenAIUsage   `json:"usage,omitempty"`
+}
+
+// OpenAIChoice is a reply, or the change to a reply in a chunk of a stream
+type OpenAIChoice struct {
+	Index        int            `json:"index"`
+	Message      *OpenAIMessage `json:"message,omitempty"`
+	Delta        *OpenAIMessage `json:"delta,omitempty"`
+	FinishReason *string        `json:"finish_reason"`
+}
+
+// OpenAIUsage is the number of tokens used for a request
+type OpenAIUsage struct {
+	PromptTokens     uint `json:"prompt_tokens"`
+	CompletionTokens uint `json:"completion_tokens"`
+	TotalTokens      uint `json:"total_tokens"`
+}
+
+// OpenAIModelList is the list of models from the models endpoint
+type OpenAIModelList struct {
+	Object string        `json:"object" enum:"list"`
+	Data   []OpenAIModel `json:"data"`
+}
+
+// OpenAIModel is a model, which is owned by the provider which serves it
+type OpenAIModel struct {
+	ID      string `json:"id"`
+	Object  string `json:"object" enum:"model"`
+	Created int64  `json:"created"`
+	OwnedBy string `json:"owned_by"`
+}
+
+// OpenAIEmbeddingRequest is a request to the embeddings endpoint
+type OpenAIEmbeddingRequest struct {
+	Model          string      `json:"model" help:"Model name" example:"nomic-embed-text"`
+	Input          OpenAIInput `json:"input" help:"Text, or array of texts, to embed"`
+	Dimensions     uint        `json:"dimensions,omitempty" help:"Truncate embeddings to this many dimensions" optional:""`
+	EncodingFormat string      `json:"encoding_format,omitempty" enum:"float,base64" help:"Encoding of the embeddings (defaults to float)" optional:""`
+}
+
+// OpenAIInput is a text, or an array of texts
+type OpenAIInput []string
+
+// OpenAIEmbeddingList is the response from the embeddings endpoint
+type OpenAIEmbeddingList struct {
+	Object string            `json:"object" enum:"list"`
+	Data   []OpenAIEmbedding `json:"data"`
+	Model  string            `json:"model"`
+	Usage  OpenAIUsage       `json:"usage"`
+}
+
+// OpenAIEmbedding is the embedding of an input, as an array of numbers or
+// as base64-encoded little-endian float32 values
+type OpenAIEmbedding struct {
+	Object    string `json:"object" enum:"embedding"`
+	Index     int    `json:"index"`
+	Embedding any    `json:"embedding"`
+}
+
+// OpenAIError is the body of an error response
+type OpenAIError struct {
+	Error OpenAIErrorDetail `json:"error"`
+}
+
+// OpenAIErrorDetail describes an error
+type OpenAIErrorDetail struct {
+	Message string  `json:"message"`
+	Type    string  `json:"type"`
+	Code    *string `json:"code"`
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+const (
+	OpenAIObjectChat      = "chat.completion"
+	OpenAIObjectChunk     = "chat.completion.chunk"
+	OpenAIObjectList      = "list"
+	OpenAIObjectModel     = "model"
+	OpenAIObjectEmbedding = "embedding"
+	OpenAIStreamDone      = "[DONE]"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// LIFECYCLE
+
+// NewOpenAIChatResponse returns the reply to a chat completions request
+func NewOpenAIChatResponse(id string, created time.Time, model string, response *AskResponse) OpenAIChatResponse {
+	message := &OpenAIMessage{Role: RoleAssistant, ToolCalls: openAIToolCalls(response.Content)}
+	var text, thinking strings.Builder
+	for _, block := range response.Content {
+		if block.Text != nil {
+			text.WriteString(*block.Text)
+		}
+		if block.Thinking != nil {
+			thinking.WriteString(*block.Thinking)
+		}
+	}
+	if text.Len() > 0 || len(message.ToolCalls) == 0 {
+		message.Content = NewOpenAIText(text.String())
+	}
+	message.ReasoningContent = thinking.String()
+
+	return OpenAIChatResponse{
+		ID:      id,
+		Object:  OpenAIObjectChat,
+		Created: created.Unix(),
+		Model:   model,
+		Choices: []OpenAIChoice{{Message: message, FinishReason: types.Ptr(OpenAIFinishReason(response.Result))}},
+		Usage:   NewOpenAIUsage(response.Usage),
+	}
+}
+
+// NewOpenAIChunk returns a chunk of a streamed reply, with a change to the
+// reply, and the reason the reply finished in the last chunk
+func NewOpenAIChunk(id string, created time.Time, model string, delta OpenAIMessage, finish *string) OpenAIChatResponse {
+	return OpenAIChatResponse{
+		ID:      id,
+		Object:  OpenAIObjectChunk,
+		Created: created.Unix(),
+		Model:   model,
+		Choices: []OpenAIChoice{{Delta: &delta, FinishReason: finish}},
+	}
+}
+
+// NewOpenAIToolCallsChunk returns a chunk of a streamed reply with the tool
+// calls of the reply, or nil if there are none
+func NewOpenAIToolCallsChunk(id string, created time.Time, model string, response *AskResponse) *OpenAIChatResponse {
+	calls := openAIToolCalls(response.Content)
+	if len(calls) == 0 {
+		return nil
+	}
+	return types.Ptr(NewOpenAIChunk(id, created, model, OpenAIMessage{ToolCalls: calls}, nil))
+}
+
+// NewOpenAIUsageChunk returns the last chunk of a streamed reply, which has
+// the usage and no choices
+func NewOpenAIUsageChunk(id string, created time.Time, model string, usage *UsageMeta) OpenAIChatResponse {
+	return OpenAIChatResponse{
+		ID:      id,
+		Object:  OpenAIObjectChunk,
+		Created: created.Unix(),
+		Model:   model,
+		Choices: []OpenAIChoice{},
+		Usage:   types.Ptr(types.Value(NewOpenAIUsage(usage))),
+	}
+}
+
+// NewOpenAIUsage returns the usage of a request, or nil if it is not known
+func NewOpenAIUsage(usage *UsageMeta) *OpenAIUsage {
+	if usage == nil {
+		return nil
+	}
+	return &OpenAIUsage{
+		PromptTokens:     usage.InputTokens,
+		CompletionTokens: usage.OutputTokens,
+		TotalTokens:      usage.InputTokens + usage.OutputTokens,
+	}
+}
+
+// NewOpenAIText returns content which is only text
+func NewOpenAIText(text string) OpenAIContent {
+	return OpenAIContent{{Type: "text", Text: text}}
+}
+
+// NewOpenAIModelList returns the list of models
+func NewOpenAIModelList(models []Model) OpenAIModelList {
+	result := OpenAIModelList{Object: OpenAIObjectList, Data: make([]OpenAIModel, 0, len(models))}
+	for _, model := range models {
+		var created int64
+		if !model.Created.IsZero() {
+			created = model.Created.Unix()
+		}
+		result.Data = append(result.Data, OpenAIModel{
+			ID:      model.Name,
+			Object:  OpenAIObjectModel,
+			Created: created,
+			OwnedBy: model.OwnedBy,
+		})
+	}
+	return result
+}
+
+// NewOpenAIEmbeddingList returns the embeddings of the inputs, encoded as
+// base64 when requested
+func NewOpenAIEmbeddingList(model string, response *EmbeddingResponse, encoding string) OpenAIEmbeddingList {
+	result := OpenAIEmbeddingList{Object: OpenAIObjectList, Model: model, Data: make([]OpenAIEmbedding, 0, len(response.Output))}
+	for i, vector := range response.Output {
+		var embedding any = vector
+		if encoding == "base64" {
+			data := make([]byte, 4*len(vector))
+			for j, value := range vector {
+				binary.LittleEndian.PutUint32(data[4*j:], math.Float32bits(float32(value)))
+			}
+			embedding = base64.StdEncoding.EncodeToString(data)
+		}
+		result.Data = append(result.Data, OpenAIEmbedding{Object: OpenAIObjectEmbedding, Index: i, Embedding: embedding})
+	}
+	if usage := NewOpenAIUsage(response.Usage); usage != nil {
+		result.Usage = *usage
+	}
+	return result
+}
+
+// NewOpenAIError returns the body of an error response, with the type of
+// error for the status code
+func NewOpenAIError(status int, err error) OpenAIError {
+	kind := "server_error"
+	switch {
+	case status == 401 || status == 403:
+		kind = "authentication_error"
+	case status == 404:
+		kind = "not_found_error"
+	case status == 429:
+		kind = "rate_limit_error"
+	case status >= 400 && status < 500:
+		kind = "invalid_request_error"
+	}
+	return OpenAIError{Error: OpenAIErrorDetail{Message: err.Error(), Type: kind}}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// STRINGIFY
+
+func (r OpenAIChatRequest) String() string {
+	return types.Stringify(r)
+}
+
+func (r OpenAIEmbeddingRequest) String() string {
+	return types.Stringify(r)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// CompletionRequest returns the request to reply to the conversation. System
+// and developer messages become the system prompt, and the results of tool
+// calls are sent in a user message.
+func (r OpenAIChatRequest) CompletionRequest() (CompletionRequest, error) {
+	var result CompletionRequest
+	if r.Model == "" {
+		return result, ErrBadParameter.With("model is required")
+	}
+	result.Model = types.Ptr(r.Model)
+	if r.MaxCompletionTokens != nil {
+		result.MaxTokens = r.MaxCompletionTokens
+	} else {
+		result.MaxTokens = r.MaxTokens
+	}
+	result.Seed = r.Seed
+	switch r.ReasoningEffort {
+	case "":
+		// Use the default for the model
+	case "none":
+		result.Thinking = types.Ptr(false)
+	default:
+		result.Thinking = types.Ptr(true)
+	}
+	if format, err := r.ResponseFormat.format(); err != nil {
+		return result, err
+	} else {
+		result.Format = format
+	}
+
+	// Tools are not offered when the choice is none
+	if choice, _ := r.ToolChoice.(string); choice != "none" {
+		for _, tool := range r.Tools {
+			if tool.Type != "function" {
+				return result, ErrBadParameter.Withf("unsupported tool type %q", tool.Type)
+			}
+			result.Tools = append(result.Tools, ToolDefinition{
+				Name:        tool.Function.Name,
+				Description: tool.Function.Description,
+				Parameters:  tool.Function.Parameters,
+			})
+		}
+	}
+
+	// Translate the messages, keeping the names of the tools which were
+	// called so that they can be set on the results
+	var system []string
+	calls := make(map[string]string)
+	for _, message := range r.Messages {
+		switch message.Role {
+		case RoleSystem, "developer":
+			system = append(system, message.Content.Text())
+		case RoleUser:
+			blocks, err := message.Content.blocks()
+			if err != nil {
+				return result, err
+			}
+			result.Messages = append(result.Messages, &Message{Role: RoleUser, Content: blocks})
+		case RoleAssistant:
+			reply := &Message{Role: RoleAssistant, Result: ResultStop}
+			if text := message.Content.Text(); text != "" {
+				reply.Content = append(reply.Content, ContentBlock{Text: types.Ptr(text)})
+			}
+			for _, call := range message.ToolCalls {
+				input := json.RawMessage(call.Function.Arguments)
+				if len(bytes.TrimSpace(input)) == 0 {
+					input = json.RawMessage("{}")
+				} else if !json.Valid(input) {
+					return result, ErrBadParameter.Withf("arguments of tool call %q are not valid JSON", call.ID)
+				}
+				reply.Content = append(reply.Content, ContentBlock{ToolCall: &ToolCall{ID: call.ID, Name: call.Function.Name, Input: input}})
+				reply.Result = ResultToolCall
+				calls[call.ID] = call.Function.Name
+			}
+			result.Messages = append(result.Messages, reply)
+		case RoleTool:
+			if message.ToolCallID == "" {
+				return result, ErrBadParameter.With("tool messages require a tool_call_id")
+			}
+			block := NewToolResult(message.ToolCallID, calls[message.ToolCallID], message.Content.Text())
+
+			// Results of calls from the same reply are sent together
+			if n := len(result.Messages); n > 0 && openAIToolResults(result.Messages[n-1]) {
+				result.Messages[n-1].Content = append(result.Messages[n-1].Content, block)
+			} else {
+				result.Messages = append(result.Messages, &Message{Role: RoleUser, Content: []ContentBlock{block}})
+			}
+		default:
+			return result, ErrBadParameter.Withf("unsupported message role %q", message.Role)
+		}
+	}
+	if len(system) > 0 {
+		result.SystemPrompt = types.Ptr(strings.Join(system, "\n\n"))
+	}
+
+	// Return success
+	return result, nil
+}
+
+// Text returns the text of the content
+func (c OpenAIContent) Text() string {
+	var result strings.Builder
+	for _, part := range c {
+		if part.Type == "text" {
+			result.WriteString(part.Text)
+		}
+	}
+	return result.String()
+}
+
+// OpenAIFinishReason returns the reason a reply finished, for a result
+func OpenAIFinishReason(result ResultType) string {
+	switch result {
+	case ResultMaxTokens:
+		return "length"
+	case ResultBlocked:
+		return "content_filter"
+	case ResultToolCall:
+		return "tool_calls"
+	default:
+		return "stop"
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// JSON MARSHALLING
+
+func (c OpenAIContent) MarshalJSON() ([]byte, error) {
+	if c == nil {
+		return []byte("null"), nil
+	}
+	if len(c) == 1 && c[0].Type == "text" {
+		return json.Marshal(c[0].Text)
+	}
+	return json.Marshal([]OpenAIContentPart(c))
+}
+
+func (c *OpenAIContent) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	switch {
+	case bytes.Equal(data, []byte("null")):
+		*c = nil
+		return nil
+	case len(data) > 0 && data[0] == '"':
+		var text string
+		if err := json.Unmarshal(data, &text); err != nil {
+			return err
+		}
+		*c = NewOpenAIText(text)
+		return nil
+	default:
+		var parts []OpenAIContentPart
+		if err := json.Unmarshal(data, &parts); err != nil {
+			return err
+		}
+		*c = parts
+		return nil
+	}
+}
+
+func (i *OpenAIInput) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] == '"' {
+		var text string
+		if err := json.Unmarshal(data, &text); err != nil {
+			return err
+		}
+		*i = OpenAIInput{text}
+		return nil
+	}
+	var texts []string
+	if err := json.Unmarshal(data, &texts); err != nil {
+		return ErrBadParameter.With("input must be a string or an array of strings")
+	}
+	*i = texts
+	return nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// blocks returns the content blocks of a user message
+func (c OpenAIContent) blocks() ([]ContentBlock, error) {
+	result := make([]ContentBlock, 0, len(c))
+	for _, part := range c {
+		switch part.Type {
+		case "text":
+			result = append(result, ContentBlock{Text: types.Ptr(part.Text)})
+		case "image_url":
+			if part.ImageURL == nil {
+				return nil, ErrBadParameter.With("image_url is required")
+			}
+			attachment, err := openAIAttachment(part.ImageURL.URL)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, ContentBlock{Attachment: attachment})
+		default:
+			return nil, ErrBadParameter.Withf("unsupported content type %q", part.Type)
+		}
+	}
+	return result, nil
+}
+
+// format returns the output format for the response format
+func (f *OpenAIResponseFormat) format() (JSONSchema, error) {
+	if f == nil {
+		return nil, nil
+	}
+	switch f.Type {
+	case "", "text":
+		return nil, nil
+	case "json_object":
+		return JSONSchema(`{"type":"object"}`), nil
+	case "json_schema":
+		if f.JSONSchema == nil || len(f.JSONSchema.Schema) == 0 {
+			return nil, ErrBadParameter.With("json_schema.schema is required")
+		}
+		return f.JSONSchema.Schema, nil
+	default:
+		return nil, ErrBadParameter.Withf("unsupported response format %q", f.Type)
+	}
+}
+
+// openAIAttachment returns the attachment for an image URL, which is a link
+// or a data URL with the image encoded as base64
+func openAIAttachment(value string) (*Attachment, error) {
+	if rest, ok := strings.CutPrefix(value, "data:"); ok {
+		meta, encoded, ok := strings.Cut(rest, ",")
+		contentType, isBase64 := strings.CutSuffix(meta, ";base64")
+		if !ok || !isBase64 {
+			return nil, ErrBadParameter.With("image data URLs must be base64 encoded")
+		}
+		data, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, ErrBadParameter.Withf("image data URL: %v", err)
+		}
+		return &Attachment{ContentType: contentType, Data: data}, nil
+	}
+	u, err := url.Parse(value)
+	if err != nil || u.Scheme == "" {
+		return nil, ErrBadParameter.Withf("invalid image URL %q", value)
+	}
+	attachment := &Attachment{URL: u}
+	attachment.Detect()
+	return attachment, nil
+}
+
+// openAIToolCalls returns the tool calls in the content of a reply, with an
+// ID for calls which the provider did not identify
+func openAIToolCalls(content []ContentBlock) []OpenAIToolCall {
+	var result []OpenAIToolCall
+	for _, block := range content {
+		if block.ToolCall == nil {
+			continue
+		}
+		call := OpenAIToolCall{Index: len(result), ID: block.ToolCall.ID, Type: "function"}
+		if call.ID == "" {
+			call.ID = fmt.Sprintf("call_%d", len(result))
+		}
+		call.Function.Name = block.ToolCall.Name
+		call.Function.Arguments = string(block.ToolCall.Input)
+		if call.Function.Arguments == "" {
+			call.Function.Arguments = "{}"
+		}
+		result = append(result, call)
+	}
+	return result
+}
+
+// openAIToolResults returns true if a message holds only tool results
+func openAIToolResults(message *Message) bool {
+	if message.Role != RoleUser || len(message.Content) == 0 {
+		return false
+	}
+	for _, block := range message.Content {
+		if block.ToolResult == nil {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,188 @@
+package schema_test
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"math"
+	"testing"
+	"time"
+
+	// Packages
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	types "github.com/mutablelogic/go-server/pkg/types"
+	assert "github.com/stretchr/testify/assert"
+)
+
+func TestOpenAIChatRequestConversation(t *testing.T) {
+	assert := assert.New(t)
+
+	var req schema.OpenAIChatRequest
+	if !assert.NoError(json.Unmarshal([]byte(`{
+		"model": "llama3.2",
+		"max_tokens": 100,
+		"max_completion_tokens": 200,
+		"temperature": 0.2,
+		"messages": [
+			{"role": "system", "content": "Be brief."},
+			{"role": "user", "content": [{"type": "text", "text": "Weather in "}, {"type": "text", "text": "London?"}]},
+			{"role": "assistant", "content": null, "tool_calls": [
+				{"id": "call_1", "type": "function", "function": {"name": "get_weather", "arguments": "{\"city\":\"London\"}"}},
+				{"id": "call_2", "type": "function", "function": {"name": "get_time", "arguments": ""}}
+			]},
+			{"role": "tool", "tool_call_id": "call_1", "content": "18C"},
+			{"role": "tool", "tool_call_id": "call_2", "content": "noon"}
+		],
+		"tools": [{"type": "function", "function": {"name": "get_weather", "parameters": {"type": "object"}}}]
+	}`), &req)) {
+		return
+	}
+
+	completion, err := req.CompletionRequest()
+	if !assert.NoError(err) {
+		return
+	}
+	assert.Equal("llama3.2", types.Value(completion.Model))
+	assert.Equal("Be brief.", types.Value(completion.SystemPrompt))
+	assert.Equal(uint(200), types.Value(completion.MaxTokens))
+	if assert.Len(completion.Tools, 1) {
+		assert.Equal("get_weather", completion.Tools[0].Name)
+		assert.JSONEq(`{"type":"object"}`, string(completion.Tools[0].Parameters))
+	}
+
+	// The results of the tool calls are sent together, as the input
+	if assert.Len(completion.Messages, 3) {
+		assert.Equal("Weather in \nLondon?", completion.Messages[0].Text())
+		calls := completion.Messages[1].ToolCalls()
+		if assert.Len(calls, 2) {
+			assert.JSONEq(`{"city":"London"}`, string(calls[0].Input))
+			assert.JSONEq(`{}`, string(calls[1].Input))
+		}
+		assert.Equal(schema.ResultToolCall, completion.Messages[1].Result)
+		results := completion.Messages[2].Content
+		if assert.Len(results, 2) {
+			assert.Equal("get_weather", results[0].ToolResult.Name)
+			assert.Equal("get_time", results[1].ToolResult.Name)
+			assert.JSONEq(`"noon"`, string(results[1].ToolResult.Content))
+		}
+	}
+	history, input, err := completion.Input()
+	if assert.NoError(err) {
+		assert.Len(history, 2)
+		assert.Same(completion.Messages[2], input)
+	}
+}
+
+func TestOpenAIChatRequestErrors(t *testing.T) {
+	assert := assert.New(t)
+	user := schema.OpenAIMessage{Role: schema.RoleUser, Content: schema.NewOpenAIText("hello")}
+
+	_, err := schema.OpenAIChatRequest{Messages: []schema.OpenAIMessage{user}}.CompletionRequest()
+	assert.ErrorIs(err, schema.ErrBadParameter)
+	_, err = schema.OpenAIChatRequest{Model: "m", Messages: []schema.OpenAIMessage{{Role: "narrator"}}}.CompletionRequest()
+	assert.ErrorIs(err, schema.ErrBadParameter)
+	_, err = schema.OpenAIChatRequest{Model: "m", Messages: []schema.OpenAIMessage{user}, ResponseFormat: &schema.OpenAIResponseFormat{Type: "xml"}}.CompletionRequest()
+	assert.ErrorIs(err, schema.ErrBadParameter)
+
+	// The last message must be the input
+	completion, err := schema.OpenAIChatRequest{Model: "m", Messages: []schema.OpenAIMessage{user, {Role: schema.RoleAssistant, Content: schema.NewOpenAIText("hi")}}}.CompletionRequest()
+	if assert.NoError(err) {
+		_, _, err = completion.Input()
+		assert.ErrorIs(err, schema.ErrBadParameter)
+	}
+
+	// Tools are not offered when the choice is none
+	tool := schema.OpenAITool{Type: "function"}
+	tool.Function.Name = "search"
+	completion, err = schema.OpenAIChatRequest{Model: "m", Messages: []schema.OpenAIMessage{user}, Tools: []schema.OpenAITool{tool}, ToolChoice: "none"}.CompletionRequest()
+	if assert.NoError(err) {
+		assert.Empty(completion.Tools)
+	}
+}
+
+func TestOpenAIImageContent(t *testing.T) {
+	assert := assert.New(t)
+
+	var message schema.OpenAIMessage
+	if !assert.NoError(json.Unmarshal([]byte(`{"role":"user","content":[
+		{"type":"image_url","image_url":{"url":"data:image/png;base64,aGVsbG8="}},
+		{"type":"image_url","image_url":{"url":"https://example.com/cat.jpg"}}
+	]}`), &message)) {
+		return
+	}
+	completion, err := schema.OpenAIChatRequest{Model: "m", Messages: []schema.OpenAIMessage{message}}.CompletionRequest()
+	if assert.NoError(err) && assert.Len(completion.Messages, 1) && assert.Len(completion.Messages[0].Content, 2) {
+		first, second := completion.Messages[0].Content[0].Attachment, completion.Messages[0].Content[1].Attachment
+		assert.Equal("image/png", first.ContentType)
+		assert.Equal([]byte("hello"), first.Data)
+		assert.Equal("https://example.com/cat.jpg", second.URL.String())
+		assert.Equal("image/jpeg", second.ContentType)
+	}
+}
+
+func TestOpenAIChatResponse(t *testing.T) {
+	assert := assert.New(t)
+	created := time.Unix(1700000000, 0)
+
+	response := &schema.AskResponse{
+		CompletionResponse: schema.CompletionResponse{
+			Role: schema.RoleAssistant,
+			Content: []schema.ContentBlock{
+				{ToolCall: &schema.ToolCall{Name: "get_weather", Input: json.RawMessage(`{"city":"London"}`)}},
+			},
+			Result: schema.ResultToolCall,
+		},
+		Usage: &schema.UsageMeta{InputTokens: 10, OutputTokens: 5},
+	}
+	data, err := json.Marshal(schema.NewOpenAIChatResponse("chatcmpl-1", created, "llama3.2", response))
+	if assert.NoError(err) {
+		assert.JSONEq(`{
+			"id": "chatcmpl-1",
+			"object": "chat.completion",
+			"created": 1700000000,
+			"model": "llama3.2",
+			"choices": [{
+				"index": 0,
+				"message": {"role": "assistant", "content": null, "tool_calls": [
+					{"index": 0, "id": "call_0", "type": "function", "function": {"name": "get_weather", "arguments": "{\"city\":\"London\"}"}}
+				]},
+				"finish_reason": "tool_calls"
+			}],
+			"usage": {"prompt_tokens": 10, "completion_tokens": 5, "total_tokens": 15}
+		}`, string(data))
+	}
+
+	// Chunks which have not finished have a null finish reason
+	data, err = json.Marshal(schema.NewOpenAIChunk("chatcmpl-1", created, "llama3.2", schema.OpenAIMessage{Content: schema.NewOpenAIText("Hi")}, nil))
+	if assert.NoError(err) {
+		assert.JSONEq(`{"id":"chatcmpl-1","object":"chat.completion.chunk","created":1700000000,"model":"llama3.2","choices":[{"index":0,"delta":{"content":"Hi"},"finish_reason":null}]}`, string(data))
+	}
+	assert.Equal("length", schema.OpenAIFinishReason(schema.ResultMaxTokens))
+	assert.Equal("stop", schema.OpenAIFinishReason(schema.ResultStop))
+}
+
+func TestOpenAIEmbeddingList(t *testing.T) {
+	assert := assert.New(t)
+
+	var req schema.OpenAIEmbeddingRequest
+	if assert.NoError(json.Unmarshal([]byte(`{"model":"m","input":"hello"}`), &req)) {
+		assert.Equal(schema.OpenAIInput{"hello"}, req.Input)
+	}
+	assert.Error(json.Unmarshal([]byte(`{"model":"m","input":[1,2,3]}`), &req))
+
+	response := &schema.EmbeddingResponse{Output: [][]float64{{0.5, -1}}}
+	list := schema.NewOpenAIEmbeddingList("m", response, "")
+	if assert.Len(list.Data, 1) {
+		assert.Equal([]float64{0.5, -1}, list.Data[0].Embedding)
+	}
+
+	// Base64 embeddings are little-endian float32 values
+	list = schema.NewOpenAIEmbeddingList("m", response, "base64")
+	if assert.Len(list.Data, 1) {
+		data, err := base64.StdEncoding.DecodeString(list.Data[0].Embedding.(string))
+		if assert.NoError(err) && assert.Len(data, 8) {
+			assert.Equal(float32(0.5), math.Float32frombits(binary.LittleEndian.Uint32(data[0:])))
+			assert.Equal(float32(-1), math.Float32frombits(binary.LittleEndian.Uint32(data[4:])))
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package schema
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+
+	// Packages
+	types "github.com/mutablelogic/go-server/pkg/types"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// CONSTANTS
+
+// Steps which change the text of replies
+const (
+	PostProcessReplace  = "replace"  // replace the matches of a regular expression
+	PostProcessMarkdown = "markdown" // normalize the markdown of the text
+	PostProcessMask     = "mask"     // mask profanity
+	PostProcessLinks    = "links"    // rewrite the prefixes of links
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// PostProcessor is a step which changes the text of replies before they are
+// stored. Steps with other types are provided by the server.
+type PostProcessor struct {
+	Type        string            `json:"type" yaml:"type" help:"Type of step: replace, markdown, mask, links, or a step provided by the server" example:"replace"`
+	Pattern     string            `json:"pattern,omitempty" yaml:"pattern" help:"Regular expression which is replaced, for the replace step" example:"(?i)acme corp"`
+	Replacement string            `json:"replacement,omitempty" yaml:"replacement" help:"Text which replaces each match, which may refer to groups such as $1, for the replace step" example:"ACME Corporation"`
+	Words       []string          `json:"words,omitempty" yaml:"words" help:"Words which are masked, for the mask step, or empty for the built-in list" example:"[\"darn\"]"`
+	Links       map[string]string `json:"links,omitempty" yaml:"links" help:"Prefixes of links and the prefixes which replace them, for the links step" example:"{\"http://intranet/\":\"https://intranet.example.com/\"}"`
+}
+
+// PostProcessors are the steps which change the text of replies, in order
+type PostProcessors []PostProcessor
+
+///////////////////////////////////////////////////////////////////////////////
+// STRINGIFY
+
+func (p PostProcessor) String() string {
+	return types.Stringify(p)
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// Validate returns an error if a step has no type, or a built-in step is
+// missing the fields it needs
+func (p PostProcessors) Validate() error {
+	for i, step := range p {
+		switch strings.TrimSpace(step.Type) {
+		case "":
+			return ErrBadParameter.Withf("postprocess[%d]: missing type", i)
+		case PostProcessReplace:
+			if step.Pattern == "" {
+				return ErrBadParameter.Withf("postprocess[%d]: replace requires a pattern", i)
+			} else if _, err := regexp.Compile(step.Pattern); err != nil {
+				return ErrBadParameter.Withf("postprocess[%d]: %v", i, err)
+			}
+		case PostProcessLinks:
+			if len(step.Links) == 0 {
+				return ErrBadParameter.Withf("postprocess[%d]: links requires at least one prefix", i)
+			}
+		}
+	}
+	return nil
+}
+
+// Types returns the type of each step
+func (p PostProcessors) Types() []string {
+	result := make([]string, 0, len(p))
+	for _, step := range p {
+		result = append(result, step.Type)
+	}
+	return result
+}
+
+// UnmarshalJSON decodes the steps from a JSON array
+func (p *PostProcessors) UnmarshalJSON(data []byte) error {
+	var steps []PostProcessor
+	if err := json.Unmarshal(data, &steps); err != nil {
+		return err
+	}
+	*p = steps
+	return nil
+}
+
+// UnmarshalText decodes the steps from a JSON array, so that they can be
+// set with a command-line flag
+func (p *PostProcessors) UnmarshalText(data []byte) error {
+	return p.UnmarshalJSON(data)
+}
@@ -38,6 +38,12 @@ const (
 	ProviderListMax uint64 = 100
 )
 
+// Key strategies
+const (
+	KeyRoundRobin             KeyStrategy = "round-robin"
+	KeyLeastRecentlyThrottled KeyStrategy = "least-recently-throttled"
+)
+
 ////////////////////////////////////////////////////////////////////////////////
 // TYPES
 
@@ -55,8 +61,27 @@ type ProviderMeta struct {
 type ProviderMetaMap map[string]any
 
 // ProviderCredentials contains the secret material used to access a provider.
+// When there is more than one key, requests are spread across the keys with
+// the key strategy, and keys which hit quota errors are set aside for a time.
 type ProviderCredentials struct {
-	APIKey string `json:"api_key,omitempty" name:"api-key" help:"Provider API key" optional:"" env:"LLM_PROVIDER_API_KEY"`
+	APIKey      string      `json:"api_key,omitempty" name:"api-key" help:"Provider API key" optional:"" env:"LLM_PROVIDER_API_KEY"`
+	APIKeys     []string    `json:"api_keys,omitempty" name:"api-keys" help:"Additional provider API keys, which are rotated with the key strategy" optional:""`
+	KeyStrategy KeyStrategy `json:"key_strategy,omitempty" name:"key-strategy" enum:"round-robin,least-recently-throttled" default:"round-robin" help:"How requests are spread across API keys"`
+}
+
+// KeyStrategy selects the API key for each request to a provider with more
+// than one key
+type KeyStrategy string
+
+// ProviderKey is the usage of one API key of a provider since the provider
+// client was created. The key is masked.
+type ProviderKey struct {
+	Key              string     `json:"key" help:"Masked API key"`
+	Requests         uint64     `json:"requests" help:"Number of requests sent with the key"`
+	Throttled        uint64     `json:"throttled" help:"Number of requests rejected with a quota or rate limit error"`
+	LastUsed         *time.Time `json:"last_used,omitempty" help:"Time the key was last used"`
+	LastThrottled    *time.Time `json:"last_throttled,omitempty" help:"Time the key was last rejected with a quota or rate limit error"`
+	QuarantinedUntil *time.Time `json:"quarantined_until,omitempty" help:"Time until which the key is not used, after a quota or rate limit error"`
 }
 
 // ProviderInsert contains the fields required to insert a new provider row.
@@ -108,6 +133,7 @@ type Provider struct {
 	CreatedAt  time.Time  `json:"created_at" help:"Creation timestamp"`
 	ModifiedAt *time.Time `json:"modified_at,omitempty" help:"Last modification timestamp" optional:""`
 	ProviderMeta
+	Keys []ProviderKey `json:"keys,omitempty" help:"Usage of each API key, when the provider has more than one" readonly:""`
 }
 
 ////////////////////////////////////////////////////////////////////////////////
@@ -121,6 +147,35 @@ func (p ProviderCredentials) String() string {
 	return types.Stringify(p)
 }
 
+////////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// Keys returns the API key followed by the additional keys, without empty or
+// repeated keys
+func (p ProviderCredentials) Keys() []string {
+	keys := make([]string, 0, len(p.APIKeys)+1)
+	for _, key := range append([]string{p.APIKey}, p.APIKeys...) {
+		if key = strings.TrimSpace(key); key != "" && !slices.Contains(keys, key) {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// Equal returns true if the credentials are the same
+func (p ProviderCredentials) Equal(other ProviderCredentials) bool {
+	return slices.Equal(p.Keys(), other.Keys()) && p.KeyStrategy == other.KeyStrategy
+}
+
+// Valid returns true if the key strategy is known, or empty
+func (s KeyStrategy) Valid() bool {
+	switch s {
+	case "", KeyRoundRobin, KeyLeastRecentlyThrottled:
+		return true
+	}
+	return false
+}
+
 func (p ProviderInsert) String() string {
 	return types.Stringify(p)
 }
@@ -456,3 +456,16 @@ func TestProviderInsertPreservesPreboundCredentialsAndPV(t *testing.T) {
 	assert.Equal([]string{}, b.Get("include"))
 	assert.Equal([]string{}, b.Get("exclude"))
 }
+
+func TestProviderCredentialsKeys(t *testing.T) {
+	assert := assert.New(t)
+	credentials := schema.ProviderCredentials{APIKey: " one ", APIKeys: []string{"two", "", "one"}}
+	assert.Equal([]string{"one", "two"}, credentials.Keys())
+	assert.True(credentials.Equal(schema.ProviderCredentials{APIKey: "one", APIKeys: []string{"two"}}))
+	assert.False(credentials.Equal(schema.ProviderCredentials{APIKey: "one", APIKeys: []string{"two"}, KeyStrategy: schema.KeyLeastRecentlyThrottled}))
+	assert.False(credentials.Equal(schema.ProviderCredentials{APIKey: "two", APIKeys: []string{"one"}}))
+
+	assert.True(schema.KeyStrategy("").Valid())
+	assert.True(schema.KeyLeastRecentlyThrottled.Valid())
+	assert.False(schema.KeyStrategy("random").Valid())
+}
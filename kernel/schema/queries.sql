@@ -164,6 +164,49 @@ INSERT INTO ${"schema"}.credential (
 RETURNING
 	url, "user", created_at;
 
+-- credential.select
+SELECT
+	url, "user", created_at, pv, credentials
+FROM ${"schema"}.credential
+WHERE url=@url AND "user" IS NULL;
+
+-- credential.select_for_user
+SELECT
+	url, "user", created_at, pv, credentials
+FROM ${"schema"}.credential
+WHERE url=@url AND "user"=@user;
+
+-- provider_credential.upsert
+INSERT INTO ${"schema"}.provider_credential (
+	provider, "user", pv, credentials
+) VALUES (
+	@provider, @user, @pv, @credentials
+)
+ON CONFLICT (provider, "user") DO UPDATE SET
+	pv = EXCLUDED.pv,
+	credentials = EXCLUDED.credentials,
+	modified_at = now()
+RETURNING
+	provider, "user", created_at, modified_at;
+
+-- provider_credential.select
+SELECT
+	provider, "user", created_at, modified_at
+FROM ${"schema"}.provider_credential
+WHERE provider=@provider AND "user"=@user;
+
+-- provider_credential.select_secret
+SELECT
+	provider, "user", created_at, modified_at, pv, credentials
+FROM ${"schema"}.provider_credential
+WHERE provider=@provider AND "user"=@user;
+
+-- provider_credential.delete
+DELETE FROM ${"schema"}.provider_credential
+WHERE provider=@provider AND "user"=@user
+RETURNING
+	provider, "user", created_at, modified_at;
+
 -- connector.insert
 INSERT INTO ${"schema"}.connector (
 	url, namespace, enabled, meta
@@ -385,13 +428,34 @@ ORDER BY "group";
 
 -- session.insert
 INSERT INTO ${"schema"}.session (
-	parent, "user", title, meta, tags
+	parent, parent_message, "user", title, meta, tags
 ) VALUES (
-	@parent, @user, @title, @meta, @tags
+	@parent, @parent_message, @user, @title, @meta, @tags
 )
 RETURNING
 	id,
 	parent,
+	parent_message,
+	"user",
+	title,
+	0,
+	0,
+	COALESCE(overhead, 0),
+	COALESCE(meta, '{}'::jsonb) AS meta,
+	COALESCE(tags, '{}'::text[]) AS tags,
+	created_at,
+	modified_at;
+
+-- session.import
+INSERT INTO ${"schema"}.session (
+	parent, parent_message, "user", title, meta, tags, created_at, modified_at
+) VALUES (
+	@parent, @parent_message, @user, @title, @meta, @tags, COALESCE(@created_at::TIMESTAMPTZ, NOW()), @modified_at::TIMESTAMPTZ
+)
+RETURNING
+	id,
+	parent,
+	parent_message,
 	"user",
 	title,
 	0,
@@ -406,6 +470,7 @@ RETURNING
 SELECT
 	session.id,
 	session.parent,
+	session.parent_message,
 	session."user",
 	session.title,
 	COALESCE((
@@ -433,6 +498,7 @@ ${orderby}
 SELECT
 	session.id,
 	session.parent,
+	session.parent_message,
 	session."user",
 	session.title,
 	COALESCE((
@@ -466,6 +532,7 @@ ${userwhere}
 RETURNING
 	id,
 	parent,
+	parent_message,
 	"user",
 	title,
 	COALESCE((
@@ -501,6 +568,7 @@ ${userwhere}
 RETURNING
 	id,
 	parent,
+	parent_message,
 	"user",
 	title,
 	COALESCE((
@@ -521,11 +589,150 @@ RETURNING
 	created_at,
 	modified_at;
 
+-- session_share.insert
+INSERT INTO ${"schema"}.session_share (
+	session, hash, expires_at
+) VALUES (
+	@session, @hash, @expires_at
+)
+RETURNING
+	id, session, expires_at, created_at;
+
+-- session_share.list
+SELECT
+	id, session, expires_at, created_at
+FROM ${"schema"}.session_share
+WHERE session = @session
+AND expires_at > NOW()
+ORDER BY created_at DESC, id ASC;
+
+-- session_share.select_token
+SELECT
+	id, session, expires_at, created_at
+FROM ${"schema"}.session_share
+WHERE hash = @hash
+AND expires_at > NOW();
+
+-- session_share.delete
+DELETE FROM ${"schema"}.session_share
+WHERE id = @id
+AND session = @session
+RETURNING
+	id, session, expires_at, created_at;
+
+-- reasoning.insert
+INSERT INTO ${"schema"}.reasoning (
+	message, session, content
+) VALUES (
+	@message, @session, @content
+)
+RETURNING
+	message, session, content, created_at;
+
+-- reasoning.list
+SELECT
+	message, session, content, created_at
+FROM ${"schema"}.reasoning
+WHERE session = @session
+ORDER BY message ASC;
+
+-- checkpoint.upsert
+INSERT INTO ${"schema"}.checkpoint (
+	session, "user", owner, iteration, request, messages, pending, usage, overhead
+) VALUES (
+	@session, @user, @owner, @iteration, @request, @messages, @pending, @usage, @overhead
+)
+ON CONFLICT (session) DO UPDATE SET
+	"user" = EXCLUDED."user",
+	owner = EXCLUDED.owner,
+	iteration = EXCLUDED.iteration,
+	request = EXCLUDED.request,
+	messages = EXCLUDED.messages,
+	pending = EXCLUDED.pending,
+	usage = EXCLUDED.usage,
+	overhead = EXCLUDED.overhead,
+	modified_at = now()
+RETURNING
+	session, "user", owner, iteration, request, messages, pending, usage, overhead, created_at, modified_at;
+
+-- checkpoint.select
+SELECT
+	session, "user", owner, iteration, request, messages, pending, usage, overhead, created_at, modified_at
+FROM ${"schema"}.checkpoint
+WHERE session = @session;
+
+-- checkpoint.delete
+DELETE FROM ${"schema"}.checkpoint
+WHERE session = @session
+RETURNING
+	session, "user", owner, iteration, request, messages, pending, usage, overhead, created_at, modified_at;
+
+-- checkpoint.list_stale
+SELECT
+	session, "user", owner, iteration, request, messages, pending, usage, overhead, created_at, modified_at
+FROM ${"schema"}.checkpoint
+WHERE modified_at < @before
+ORDER BY modified_at ASC;
+
+-- checkpoint.claim
+UPDATE ${"schema"}.checkpoint
+SET
+	owner = @owner,
+	modified_at = now()
+WHERE session IN (
+	SELECT session
+	FROM ${"schema"}.checkpoint
+	WHERE modified_at < @before
+	FOR UPDATE SKIP LOCKED
+)
+RETURNING
+	session, "user", owner, iteration, request, messages, pending, usage, overhead, created_at, modified_at;
+
+-- checkpoint.release
+UPDATE ${"schema"}.checkpoint
+SET
+	owner = '00000000-0000-0000-0000-000000000000'
+WHERE session = @session AND owner = @owner
+RETURNING
+	session, "user", owner, iteration, request, messages, pending, usage, overhead, created_at, modified_at;
+
+-- checkpoint.delete_claimed
+DELETE FROM ${"schema"}.checkpoint
+WHERE session = @session AND owner = @owner
+RETURNING
+	session, "user", owner, iteration, request, messages, pending, usage, overhead, created_at, modified_at;
+
+-- checkpoint.touch
+UPDATE ${"schema"}.checkpoint
+SET
+	modified_at = now()
+WHERE owner = @owner;
+
+-- compaction.select
+SELECT
+	session, through, summary, modified_at
+FROM ${"schema"}.compaction
+WHERE session = @session;
+
+-- compaction.upsert
+INSERT INTO ${"schema"}.compaction (
+	session, through, summary
+) VALUES (
+	@session, @through, @summary
+)
+ON CONFLICT (session) DO UPDATE SET
+	through = GREATEST(${"schema"}.compaction.through, EXCLUDED.through),
+	summary = EXCLUDED.summary,
+	modified_at = now()
+RETURNING
+	session, through, summary, modified_at;
+
 -- message.insert
 INSERT INTO ${"schema"}.message (
-	session, role, content, tokens, result, meta
+	session, role, content, tokens, result, meta, created_at, provider, model, response_id, latency_ns
 ) VALUES (
-	@session, @role, @content, @tokens, @result::${"schema"}.MESSAGE_RESULT, @meta
+	@session, @role, @content, @tokens, @result::${"schema"}.MESSAGE_RESULT, @meta, COALESCE(@created_at::TIMESTAMPTZ, NOW()),
+	NULLIF(@provider, ''), NULLIF(@model, ''), NULLIF(@response_id, ''), @latency_ns
 )
 RETURNING
 	id,
@@ -534,7 +741,51 @@ RETURNING
 	COALESCE(content, '[]'::jsonb) AS content,
 	COALESCE(tokens, 0),
 	COALESCE(result::text, ''),
-	COALESCE(meta, '{}'::jsonb) AS meta;
+	COALESCE(meta, '{}'::jsonb) AS meta,
+	created_at,
+	COALESCE(provider, ''),
+	COALESCE(model, ''),
+	COALESCE(response_id, ''),
+	COALESCE(latency_ns, 0);
+
+-- message.update
+UPDATE ${"schema"}.message
+SET
+	${patch},
+	modified_at = NOW()
+WHERE session = @session
+AND id = @id
+RETURNING
+	id,
+	session,
+	role,
+	COALESCE(content, '[]'::jsonb) AS content,
+	COALESCE(tokens, 0),
+	COALESCE(result::text, ''),
+	COALESCE(meta, '{}'::jsonb) AS meta,
+	created_at,
+	COALESCE(provider, ''),
+	COALESCE(model, ''),
+	COALESCE(response_id, ''),
+	COALESCE(latency_ns, 0);
+
+-- message.select
+SELECT
+	message.id,
+	message.session,
+	message.role,
+	COALESCE(message.content, '[]'::jsonb) AS content,
+	COALESCE(message.tokens, 0),
+	COALESCE(message.result::text, ''),
+	COALESCE(message.meta, '{}'::jsonb) AS meta,
+	message.created_at,
+	COALESCE(message.provider, ''),
+	COALESCE(message.model, ''),
+	COALESCE(message.response_id, ''),
+	COALESCE(message.latency_ns, 0)
+FROM ${"schema"}.message AS message
+WHERE message.session = @session
+AND message.id = @id;
 
 -- message.list
 SELECT
@@ -544,7 +795,12 @@ SELECT
 	COALESCE(message.content, '[]'::jsonb) AS content,
 	COALESCE(message.tokens, 0),
 	COALESCE(message.result::text, ''),
-	COALESCE(message.meta, '{}'::jsonb) AS meta
+	COALESCE(message.meta, '{}'::jsonb) AS meta,
+	message.created_at,
+	COALESCE(message.provider, ''),
+	COALESCE(message.model, ''),
+	COALESCE(message.response_id, ''),
+	COALESCE(message.latency_ns, 0)
 FROM ${"schema"}.message AS message
 ${where}
 ${orderby}
@@ -557,13 +813,41 @@ SELECT
 	COALESCE(message.content, '[]'::jsonb) AS content,
 	COALESCE(message.tokens, 0),
 	COALESCE(message.result::text, ''),
-	COALESCE(message.meta, '{}'::jsonb) AS meta
+	COALESCE(message.meta, '{}'::jsonb) AS meta,
+	message.created_at,
+	COALESCE(message.provider, ''),
+	COALESCE(message.model, ''),
+	COALESCE(message.response_id, ''),
+	COALESCE(message.latency_ns, 0)
 FROM ${"schema"}.message AS message
 JOIN ${"schema"}.session AS session ON session.id = message.session
 WHERE session."user" = @user
 ${where}
 ${orderby}
 
+-- message.sync
+SELECT
+	message.id,
+	message.session,
+	message.role,
+	COALESCE(message.content, '[]'::jsonb) AS content,
+	COALESCE(message.tokens, 0),
+	COALESCE(message.result::text, ''),
+	COALESCE(message.meta, '{}'::jsonb) AS meta,
+	message.created_at,
+	COALESCE(message.provider, ''),
+	COALESCE(message.model, ''),
+	COALESCE(message.response_id, ''),
+	COALESCE(message.latency_ns, 0)
+FROM ${"schema"}.message AS message
+WHERE message.session = @session
+AND (
+	message.id > @last
+	OR message.modified_at >= @since
+	OR COALESCE(message.meta, '{}'::jsonb) @> '{"pending": true}'::jsonb
+)
+ORDER BY message.id ASC
+
 -- message.session_feed
 SELECT
 	message.id,
@@ -573,13 +857,23 @@ SELECT
 	COALESCE(message.tokens, 0),
 	COALESCE(message.result::text, ''),
 	COALESCE(message.meta, '{}'::jsonb) AS meta,
-	message.created_at
+	message.created_at,
+	COALESCE(message.provider, ''),
+	COALESCE(message.model, ''),
+	COALESCE(message.response_id, ''),
+	COALESCE(message.latency_ns, 0)
 FROM ${"schema"}.message AS message
 WHERE message.session = ANY(@sessions)
 AND message.id > @after_id
 ORDER BY message.id ASC
 ${offsetlimit}
 
+-- message.delete_pending
+DELETE FROM ${"schema"}.message
+WHERE session = @session
+AND id = ANY(@ids)
+AND COALESCE(meta, '{}'::jsonb) @> '{"pending": true}'::jsonb;
+
 -- message.last_id
 SELECT
 	COALESCE(MAX(message.id), 0)
@@ -609,3 +903,145 @@ RETURNING
 	COALESCE(reasoning_tokens, 0),
 	COALESCE(meta, '{}'::jsonb) AS meta,
 	created_at;
+
+-- archive.insert
+INSERT INTO ${"schema"}.archive (
+	id, "type", "user", "session", provider, model, request, response, prev_hash, hash, created_at
+) VALUES (
+	@id, @type, @user, @session, @provider, @model, @request, @response, @prev_hash, @hash, @created_at
+)
+RETURNING
+	id, seq, "type", "user", "session", provider, model, request, response, prev_hash, hash, created_at;
+
+-- archive.select
+SELECT
+	archive.id, archive.seq, archive."type", archive."user", archive."session", archive.provider, archive.model,
+	archive.request, archive.response, archive.prev_hash, archive.hash, archive.created_at
+FROM ${"schema"}.archive AS archive
+WHERE archive.id = @id ${userwhere};
+
+-- archive.before
+SELECT
+	archive.id, archive.seq, archive."type", archive."user", archive."session", archive.provider, archive.model,
+	archive.request, archive.response, archive.prev_hash, archive.hash, archive.created_at
+FROM ${"schema"}.archive AS archive
+${where}
+ORDER BY archive.seq DESC
+LIMIT 1;
+
+-- analytics.usage
+SELECT
+	date_trunc(@bucket, usage.created_at) AS "time",
+	usage."type"::text,
+	'' AS provider,
+	'' AS model,
+	COUNT(*),
+	COALESCE(SUM(usage.input_tokens), 0),
+	COALESCE(SUM(usage.output_tokens), 0),
+	COALESCE(SUM(usage.cache_read_tokens), 0),
+	COALESCE(SUM(usage.cache_write_tokens), 0),
+	COALESCE(SUM(usage.reasoning_tokens), 0)
+FROM ${"schema"}.usage AS usage
+WHERE ${where}
+GROUP BY 1, 2
+ORDER BY 1, 2
+
+-- analytics.models
+SELECT
+	date_trunc(@bucket, usage.created_at) AS "time",
+	'' AS "type",
+	COALESCE(usage.provider, '') AS provider,
+	usage.model,
+	COUNT(*),
+	COALESCE(SUM(usage.input_tokens), 0),
+	COALESCE(SUM(usage.output_tokens), 0),
+	COALESCE(SUM(usage.cache_read_tokens), 0),
+	COALESCE(SUM(usage.cache_write_tokens), 0),
+	COALESCE(SUM(usage.reasoning_tokens), 0)
+FROM ${"schema"}.usage AS usage
+WHERE ${where}
+GROUP BY 1, 3, 4
+ORDER BY 1, 5 DESC, 3, 4
+
+-- analytics.tools
+SELECT
+	date_trunc(@bucket, message.created_at) AS "time",
+	COALESCE(block->'tool_result'->>'name', '') AS tool,
+	COUNT(*),
+	COUNT(*) FILTER (WHERE COALESCE((block->'tool_result'->>'is_error')::boolean, false))
+FROM ${"schema"}.message AS message
+JOIN ${"schema"}.session AS session ON session.id = message.session
+CROSS JOIN LATERAL jsonb_array_elements(COALESCE(message.content, '[]'::jsonb)) AS block
+WHERE jsonb_typeof(block->'tool_result') = 'object'
+AND ${where}
+GROUP BY 1, 2
+ORDER BY 1, 3 DESC, 2
+
+-- data.delete
+WITH RECURSIVE target AS (
+	SELECT session.id
+	FROM ${"schema"}.session AS session
+	${where}
+	UNION
+	SELECT child.id
+	FROM ${"schema"}.session AS child
+	JOIN target ON child.parent = target.id
+), deleted_usage AS (
+	DELETE FROM ${"schema"}.usage
+	WHERE "session" IN (SELECT id FROM target)
+	RETURNING id
+), deleted_session AS (
+	DELETE FROM ${"schema"}.session
+	WHERE id IN (SELECT id FROM target)
+	RETURNING id
+)
+SELECT
+	COALESCE((SELECT array_agg(id::text ORDER BY id) FROM deleted_session), '{}'::text[]),
+	(SELECT COUNT(*) FROM ${"schema"}.message WHERE "session" IN (SELECT id FROM target)),
+	(SELECT COUNT(*) FROM deleted_usage),
+	NOW();
+
+-- migration.lock
+SELECT pg_advisory_xact_lock(hashtext(${'schema'} || '.migration'))
+
+-- migration.list
+SELECT "version"
+FROM ${"schema"}.migration
+ORDER BY "version"
+
+-- migration.insert
+INSERT INTO ${"schema"}.migration (
+	"version", "name"
+) VALUES (
+	@version, @name
+)
+
+-- agent.insert
+INSERT INTO ${"schema"}.agent (
+	"name", "version", "title", "description", "template", "input", "tools", "meta", "user"
+) VALUES (
+	@name, @version, @title, @description, @template, @input, @tools, @meta, @user
+)
+RETURNING
+	"id", "name", "version", "title", COALESCE("description", ''), COALESCE("template", ''), "input", "tools", "meta", "user", "created_at"
+
+-- agent.select
+SELECT
+	agent.id, agent.name, agent.version, agent.title, COALESCE(agent.description, ''), COALESCE(agent.template, ''), agent.input, agent.tools, agent.meta, agent."user", agent.created_at
+FROM ${"schema"}.agent AS agent
+${where}
+ORDER BY agent.version DESC
+LIMIT 1
+
+-- agent.delete
+DELETE FROM ${"schema"}.agent AS agent
+${where}
+RETURNING
+	agent.id, agent.name, agent.version, agent.title, COALESCE(agent.description, ''), COALESCE(agent.template, ''), agent.input, agent.tools, agent.meta, agent."user", agent.created_at
+
+-- agent.list
+SELECT
+	agent.id, agent.name, agent.version, agent.title, COALESCE(agent.description, ''), COALESCE(agent.template, ''), agent.input, agent.tools, agent.meta, agent."user", agent.created_at
+FROM ${"schema"}.agent AS agent
+${where}
+${orderby}
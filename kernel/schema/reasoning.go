@@ -0,0 +1,163 @@
+package schema
+
+import (
+	"time"
+
+	// Packages
+	uuid "github.com/google/uuid"
+	pg "github.com/mutablelogic/go-pg"
+	types "github.com/mutablelogic/go-server/pkg/types"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// Reasoning is the thinking of a model for a message, which is recorded
+// apart from the transcript so that it is not returned to users, and can be
+// retrieved by administrators to debug a conversation
+type Reasoning struct {
+	Message   uint64         `json:"message" help:"Message the thinking was generated for" readonly:""`
+	Session   uuid.UUID      `json:"session" help:"Session of the message" readonly:""`
+	Content   []ContentBlock `json:"content" help:"Thinking content blocks, in the order they were generated" readonly:""`
+	CreatedAt time.Time      `json:"created_at" help:"Time the thinking was recorded" readonly:""`
+}
+
+// ReasoningInsert records the thinking of a model for a stored message
+type ReasoningInsert struct {
+	Message uint64         `json:"message"`
+	Session uuid.UUID      `json:"session"`
+	Content []ContentBlock `json:"content"`
+}
+
+// ReasoningList is the recorded thinking of a session, oldest first
+type ReasoningList []*Reasoning
+
+// ReasoningListSelector selects the recorded thinking of a session
+type ReasoningListSelector uuid.UUID
+
+////////////////////////////////////////////////////////////////////////////////
+// STRINGIFY
+
+func (r Reasoning) String() string {
+	return types.Stringify(r)
+}
+
+func (l ReasoningList) String() string {
+	return types.Stringify(l)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// SplitThinking returns the message without its thinking content blocks, and
+// the thinking content blocks which were removed. The message is not changed.
+func SplitThinking(message Message) (Message, []ContentBlock) {
+	var thinking []ContentBlock
+	content := make([]ContentBlock, 0, len(message.Content))
+	for _, block := range message.Content {
+		if block.Thinking != nil {
+			thinking = append(thinking, block)
+		} else {
+			content = append(content, block)
+		}
+	}
+	message.Content = content
+	return message, thinking
+}
+
+// MergeThinking returns the conversation with the recorded thinking for each
+// message placed before its other content, as it was generated
+func MergeThinking(conversation Conversation, reasoning ReasoningList) Conversation {
+	thinking := make(map[uint64][]ContentBlock, len(reasoning))
+	for _, r := range reasoning {
+		if r != nil {
+			thinking[r.Message] = append(thinking[r.Message], r.Content...)
+		}
+	}
+	result := make(Conversation, 0, len(conversation))
+	for _, message := range conversation {
+		if message == nil || len(thinking[message.ID]) == 0 {
+			result = append(result, message)
+			continue
+		}
+		merged := *message
+		merged.Content = append(append([]ContentBlock{}, thinking[message.ID]...), message.Content...)
+		result = append(result, &merged)
+	}
+	return result
+}
+
+// RedactThinking returns the conversation without thinking content blocks,
+// and without messages which only contain thinking
+func RedactThinking(conversation Conversation) Conversation {
+	result := make(Conversation, 0, len(conversation))
+	for _, message := range conversation {
+		if message == nil || message.Role == RoleThinking {
+			continue
+		}
+		redacted, thinking := SplitThinking(*message)
+		if len(thinking) == 0 {
+			result = append(result, message)
+		} else if len(redacted.Content) > 0 {
+			result = append(result, &redacted)
+		}
+	}
+	return result
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS - SELECTOR
+
+func (s ReasoningListSelector) Select(bind *pg.Bind, op pg.Op) (string, error) {
+	if session := uuid.UUID(s); session == uuid.Nil {
+		return "", ErrBadParameter.With("session is required")
+	} else {
+		bind.Set("session", session)
+	}
+
+	switch op {
+	case pg.List:
+		return bind.Query("reasoning.list"), nil
+	default:
+		return "", ErrNotImplemented.Withf("unsupported ReasoningListSelector operation %q", op)
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS - READER
+
+// Expected column order: message, session, content, created_at.
+func (r *Reasoning) Scan(row pg.Row) error {
+	return row.Scan(&r.Message, &r.Session, &r.Content, &r.CreatedAt)
+}
+
+func (l *ReasoningList) Scan(row pg.Row) error {
+	var reasoning Reasoning
+	if err := reasoning.Scan(row); err != nil {
+		return err
+	}
+	*l = append(*l, &reasoning)
+	return nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS - WRITER
+
+func (r ReasoningInsert) Insert(bind *pg.Bind) (string, error) {
+	if r.Message == 0 {
+		return "", ErrBadParameter.With("message is required")
+	} else if r.Session == uuid.Nil {
+		return "", ErrBadParameter.With("session is required")
+	} else if len(r.Content) == 0 {
+		return "", ErrBadParameter.With("thinking content is required")
+	}
+	bind.Set("message", r.Message)
+	bind.Set("session", r.Session)
+	bind.Set("content", r.Content)
+
+	return bind.Query("reasoning.insert"), nil
+}
+
+func (r ReasoningInsert) Update(_ *pg.Bind) error {
+	return ErrNotImplemented.With("recorded thinking cannot be updated")
+}
@@ -0,0 +1,95 @@
+package schema_test
+
+import (
+	"testing"
+
+	// Packages
+	uuid "github.com/google/uuid"
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	pg "github.com/mutablelogic/go-pg"
+	types "github.com/mutablelogic/go-server/pkg/types"
+	assert "github.com/stretchr/testify/assert"
+)
+
+func TestSplitThinking(t *testing.T) {
+	assert := assert.New(t)
+
+	message := schema.Message{Role: schema.RoleAssistant, Content: []schema.ContentBlock{
+		{Thinking: types.Ptr("The user wants a greeting.")},
+		{Text: types.Ptr("Hello")},
+	}}
+	visible, thinking := schema.SplitThinking(message)
+	assert.Equal([]schema.ContentBlock{{Text: types.Ptr("Hello")}}, visible.Content)
+	assert.Equal([]schema.ContentBlock{{Thinking: types.Ptr("The user wants a greeting.")}}, thinking)
+
+	// The message is not changed
+	assert.Len(message.Content, 2)
+
+	// A message without thinking has nothing to split
+	_, thinking = schema.SplitThinking(visible)
+	assert.Nil(thinking)
+}
+
+func TestRedactThinking(t *testing.T) {
+	assert := assert.New(t)
+
+	conversation := schema.Conversation{
+		{ID: 1, Role: schema.RoleUser, Content: []schema.ContentBlock{{Text: types.Ptr("Hi")}}},
+		{ID: 2, Role: schema.RoleThinking, Content: []schema.ContentBlock{{Text: types.Ptr("Hmm")}}},
+		{ID: 3, Role: schema.RoleAssistant, Content: []schema.ContentBlock{{Thinking: types.Ptr("Hmm")}}},
+		{ID: 4, Role: schema.RoleAssistant, Content: []schema.ContentBlock{{Thinking: types.Ptr("Hmm")}, {Text: types.Ptr("Hello")}}},
+	}
+	redacted := schema.RedactThinking(conversation)
+	if assert.Len(redacted, 2) {
+		assert.Equal(uint64(1), redacted[0].ID)
+		assert.Equal(uint64(4), redacted[1].ID)
+		assert.Equal([]schema.ContentBlock{{Text: types.Ptr("Hello")}}, redacted[1].Content)
+	}
+
+	// The conversation is not changed
+	assert.Len(conversation[3].Content, 2)
+}
+
+func TestMergeThinking(t *testing.T) {
+	assert := assert.New(t)
+
+	conversation := schema.Conversation{
+		{ID: 1, Role: schema.RoleUser, Content: []schema.ContentBlock{{Text: types.Ptr("Hi")}}},
+		{ID: 2, Role: schema.RoleAssistant, Content: []schema.ContentBlock{{Text: types.Ptr("Hello")}}},
+	}
+	merged := schema.MergeThinking(conversation, schema.ReasoningList{
+		{Message: 2, Content: []schema.ContentBlock{{Thinking: types.Ptr("The user wants a greeting.")}}},
+		{Message: 9, Content: []schema.ContentBlock{{Thinking: types.Ptr("Deleted message")}}},
+	})
+	if assert.Len(merged, 2) {
+		assert.Same(conversation[0], merged[0])
+		assert.Equal([]schema.ContentBlock{
+			{Thinking: types.Ptr("The user wants a greeting.")},
+			{Text: types.Ptr("Hello")},
+		}, merged[1].Content)
+	}
+	assert.Len(conversation[1].Content, 1)
+}
+
+func TestReasoningSelectors(t *testing.T) {
+	assert := assert.New(t)
+	session := uuid.New()
+
+	bind := pg.NewBind()
+	_, err := schema.ReasoningListSelector(session).Select(bind, pg.List)
+	if assert.NoError(err) {
+		assert.Equal(session, bind.Get("session"))
+	}
+	_, err = schema.ReasoningListSelector(uuid.Nil).Select(pg.NewBind(), pg.List)
+	assert.ErrorIs(err, schema.ErrBadParameter)
+	_, err = schema.ReasoningListSelector(session).Select(pg.NewBind(), pg.Delete)
+	assert.ErrorIs(err, schema.ErrNotImplemented)
+
+	bind = pg.NewBind()
+	_, err = schema.ReasoningInsert{Message: 2, Session: session, Content: []schema.ContentBlock{{Thinking: types.Ptr("Hmm")}}}.Insert(bind)
+	if assert.NoError(err) {
+		assert.Equal(uint64(2), bind.Get("message"))
+	}
+	_, err = schema.ReasoningInsert{Message: 2, Session: session}.Insert(pg.NewBind())
+	assert.ErrorIs(err, schema.ErrBadParameter)
+}
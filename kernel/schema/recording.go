@@ -0,0 +1,94 @@
+package schema
+
+import (
+	"encoding/json"
+	"net/url"
+	"strconv"
+	"time"
+
+	// Packages
+	uuid "github.com/google/uuid"
+	types "github.com/mutablelogic/go-server/pkg/types"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// RecordingStepType is the kind of state recorded at a step of a chat turn
+type RecordingStepType string
+
+// Recording is every intermediate state of a chat turn, in the order they
+// happened, so that the turn can be stepped through after it completes
+type Recording struct {
+	ID        uuid.UUID       `json:"id" help:"Recording ID"`
+	Session   uuid.UUID       `json:"session" help:"Session of the chat turn"`
+	CreatedAt time.Time       `json:"created_at" help:"Time the turn started"`
+	Count     uint            `json:"count" help:"Number of steps in the recording"`
+	Steps     []RecordingStep `json:"steps" help:"Steps of the turn, or the selected step"`
+}
+
+// RecordingStep is the state of a chat turn at one step
+type RecordingStep struct {
+	Index     uint              `json:"index" help:"Position of the step in the recording, from zero"`
+	Type      RecordingStepType `json:"type" enum:"plan,compaction,request,response,tools,substitution,result,error" help:"Kind of state which is recorded"`
+	Iteration uint              `json:"iteration" help:"Tool-calling iteration of the turn, from zero"`
+	Elapsed   time.Duration     `json:"elapsed_ns" help:"Time since the turn started"`
+	Data      json.RawMessage   `json:"data,omitempty" help:"State at the step, such as the messages sent to the provider or its reply"`
+}
+
+// RecordingIDSelector selects a recording by ID
+type RecordingIDSelector uuid.UUID
+
+// RecordingQuery contains the query parameters accepted by the recording
+// endpoint
+type RecordingQuery struct {
+	Step *uint `json:"step,omitempty" help:"Return only the step at this position" optional:""`
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+const (
+	RecordingStepPlan         RecordingStepType = "plan"         // options, tools and prompt resolved for the turn
+	RecordingStepCompaction   RecordingStepType = "compaction"   // messages removed from the start of the conversation
+	RecordingStepRequest      RecordingStepType = "request"      // messages sent to the provider
+	RecordingStepResponse     RecordingStepType = "response"     // reply of the provider, with usage
+	RecordingStepTools        RecordingStepType = "tools"        // tool calls and their results
+	RecordingStepSubstitution RecordingStepType = "substitution" // model replaced by its successor
+	RecordingStepResult       RecordingStepType = "result"       // response returned to the client
+	RecordingStepError        RecordingStepType = "error"        // error which ended the turn
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// STRINGIFY
+
+func (r Recording) String() string {
+	return types.Stringify(r)
+}
+
+func (s RecordingStep) String() string {
+	return types.Stringify(s)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// Step returns a copy of the recording with only the step at the index, or
+// an error when there is no such step
+func (r Recording) Step(index uint) (*Recording, error) {
+	if index >= uint(len(r.Steps)) {
+		return nil, ErrNotFound.Withf("recording %q has no step %d", r.ID, index)
+	}
+	result := r
+	result.Steps = []RecordingStep{r.Steps[index]}
+	return &result, nil
+}
+
+// Query returns the query parameters for the recording endpoint
+func (q RecordingQuery) Query() url.Values {
+	values := url.Values{}
+	if q.Step != nil {
+		values.Set("step", strconv.FormatUint(uint64(*q.Step), 10))
+	}
+	return values
+}
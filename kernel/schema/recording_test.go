@@ -0,0 +1,40 @@
+package schema_test
+
+import (
+	"testing"
+
+	// Packages
+	uuid "github.com/google/uuid"
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	types "github.com/mutablelogic/go-server/pkg/types"
+	assert "github.com/stretchr/testify/assert"
+)
+
+func TestRecordingStep(t *testing.T) {
+	assert := assert.New(t)
+	recording := schema.Recording{
+		ID:    uuid.New(),
+		Count: 2,
+		Steps: []schema.RecordingStep{
+			{Index: 0, Type: schema.RecordingStepPlan},
+			{Index: 1, Type: schema.RecordingStepRequest},
+		},
+	}
+
+	step, err := recording.Step(1)
+	if assert.NoError(err) {
+		assert.Equal(uint(2), step.Count)
+		assert.Equal([]schema.RecordingStep{{Index: 1, Type: schema.RecordingStepRequest}}, step.Steps)
+	}
+	assert.Len(recording.Steps, 2)
+
+	_, err = recording.Step(2)
+	assert.ErrorIs(err, schema.ErrNotFound)
+}
+
+func TestRecordingQuery(t *testing.T) {
+	assert := assert.New(t)
+	assert.Empty(schema.RecordingQuery{}.Query())
+	assert.Equal("0", schema.RecordingQuery{Step: types.Ptr(uint(0))}.Query().Get("step"))
+	assert.Equal("true", schema.ChatQuery{Record: true}.Query().Get("record"))
+}
@@ -0,0 +1,71 @@
+package schema
+
+import (
+	"strings"
+
+	// Packages
+	types "github.com/mutablelogic/go-server/pkg/types"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// Refusal describes why a model refused to respond, or why its response was
+// blocked. It is returned as an error which wraps ErrRefusal, so callers can
+// test for a refusal with errors.Is and obtain the details with errors.As.
+type Refusal struct {
+	Provider string   `json:"provider,omitempty" help:"Provider which refused the request"`
+	Reason   string   `json:"reason,omitempty" help:"Provider stop, finish or block reason" example:"SAFETY"`
+	Category string   `json:"category,omitempty" help:"Normalized refusal category" enum:"safety,recitation,policy,language,other" example:"safety"`
+	Codes    []string `json:"codes,omitempty" help:"Raw provider codes, such as the harm categories which were flagged"`
+	Message  string   `json:"message,omitempty" help:"Explanation returned by the model, if any"`
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+const (
+	RefusalSafety     = "safety"     // Harmful content
+	RefusalRecitation = "recitation" // Reproduction of training data
+	RefusalPolicy     = "policy"     // Blocklists, prohibited content and personal data
+	RefusalLanguage   = "language"   // Unsupported language
+	RefusalOther      = "other"      // Other or unknown reason
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// STRINGIFY
+
+func (r Refusal) String() string {
+	return types.Stringify(r)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// Error returns a description of the refusal
+func (r *Refusal) Error() string {
+	var details []string
+	if r.Category != "" {
+		details = append(details, r.Category)
+	}
+	if r.Reason != "" && !strings.EqualFold(r.Reason, r.Category) {
+		details = append(details, r.Reason)
+	}
+	if len(r.Codes) > 0 {
+		details = append(details, strings.Join(r.Codes, ", "))
+	}
+
+	result := ErrRefusal.Error()
+	if len(details) > 0 {
+		result += " (" + strings.Join(details, ": ") + ")"
+	}
+	if message := strings.TrimSpace(r.Message); message != "" {
+		result += ": " + message
+	}
+	return result
+}
+
+// Unwrap returns ErrRefusal
+func (r *Refusal) Unwrap() error {
+	return ErrRefusal
+}
@@ -0,0 +1,52 @@
+package schema_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	// Packages
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	assert "github.com/stretchr/testify/assert"
+)
+
+func Test_Refusal_001(t *testing.T) {
+	// A refusal wraps ErrRefusal and can be recovered from a wrapped error
+	assert := assert.New(t)
+
+	var err error = &schema.Refusal{Provider: schema.Gemini, Reason: "SAFETY", Category: schema.RefusalSafety}
+	err = fmt.Errorf("generate: %w", err)
+	assert.ErrorIs(err, schema.ErrRefusal)
+
+	var refusal *schema.Refusal
+	if assert.True(errors.As(err, &refusal)) {
+		assert.Equal(schema.Gemini, refusal.Provider)
+		assert.Equal(schema.RefusalSafety, refusal.Category)
+	}
+}
+
+func Test_Refusal_002(t *testing.T) {
+	// The error message includes the category, reason, codes and explanation
+	assert := assert.New(t)
+
+	assert.Equal("model refused to respond", (&schema.Refusal{}).Error())
+	assert.Equal("model refused to respond (safety)", (&schema.Refusal{Reason: "safety", Category: schema.RefusalSafety}).Error())
+	assert.Equal(
+		"model refused to respond (policy: BLOCKLIST: HARM_CATEGORY_HARASSMENT, HARM_CATEGORY_HATE_SPEECH): not allowed",
+		(&schema.Refusal{
+			Reason:   "BLOCKLIST",
+			Category: schema.RefusalPolicy,
+			Codes:    []string{"HARM_CATEGORY_HARASSMENT", "HARM_CATEGORY_HATE_SPEECH"},
+			Message:  " not allowed ",
+		}).Error(),
+	)
+}
+
+func Test_Refusal_003(t *testing.T) {
+	// A refusal is reported to HTTP clients as a bad request with its details
+	assert := assert.New(t)
+
+	err := schema.HTTPErr(&schema.Refusal{Reason: "refusal", Category: schema.RefusalSafety, Message: "I can't help with that"})
+	assert.Error(err)
+	assert.Contains(err.Error(), "I can't help with that")
+}
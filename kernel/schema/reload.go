@@ -0,0 +1,27 @@
+package schema
+
+import (
+	// Packages
+	types "github.com/mutablelogic/go-server/pkg/types"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// Reload is the result of reloading providers, connectors and agents without
+// restarting the server. Requests which started before the reload continue
+// with the previous provider clients until they are done.
+type Reload struct {
+	UpdatedProviders []string `json:"updated_providers,omitempty" help:"Providers whose clients were rebuilt with changed settings or credentials"`
+	DeletedProviders []string `json:"deleted_providers,omitempty" help:"Providers which were removed or disabled"`
+	UpdatedAgents    []string `json:"updated_agents,omitempty" help:"Agents which were added or changed in the agent directory"`
+	RemovedAgents    []string `json:"removed_agents,omitempty" help:"Agents whose files were removed from the agent directory"`
+	Errors           []string `json:"errors,omitempty" help:"Errors for the parts which could not be reloaded"`
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// STRINGIFY
+
+func (r Reload) String() string {
+	return types.Stringify(r)
+}
@@ -13,6 +13,9 @@ var Objects string
 //go:embed queries.sql
 var Queries string
 
+//go:embed migrations.sql
+var Migrations string
+
 const (
 	DefaultSchema        = "llm"
 	DefaultAuthSchema    = "auth"
@@ -47,17 +47,19 @@ type SessionMeta struct {
 }
 
 type SessionInsert struct {
-	Parent uuid.UUID `json:"parent,omitempty" help:"Parent session for threading" optional:""`
+	Parent        uuid.UUID `json:"parent,omitempty" help:"Parent session for threading" optional:""`
+	ParentMessage uint64    `json:"parent_message,omitempty" help:"Message in the parent session which a side thread continues from" optional:""`
 	SessionMeta
 }
 
 // SessionListRequest represents a request to list sessions.
 type SessionListRequest struct {
 	pg.OffsetLimit
-	Parent *uuid.UUID `json:"parent,omitzero" help:"Filter by parent session ID" optional:""`
-	User   *uuid.UUID `json:"user,omitzero" help:"Filter by user ID" optional:""`
-	Title  *string    `json:"title,omitempty" help:"Filter by session title (partial match)" optional:""`
-	Tags   []string   `json:"tags,omitempty" help:"Filter by tags (sessions must contain all specified tags)" optional:""`
+	Parent        *uuid.UUID `json:"parent,omitzero" help:"Filter by parent session ID" optional:""`
+	ParentMessage *uint64    `json:"parent_message,omitempty" help:"Filter by the message in the parent session which a side thread continues from" optional:""`
+	User          *uuid.UUID `json:"user,omitzero" help:"Filter by user ID" optional:""`
+	Title         *string    `json:"title,omitempty" help:"Filter by session title (partial match)" optional:""`
+	Tags          []string   `json:"tags,omitempty" help:"Filter by tags (sessions must contain all specified tags)" optional:""`
 }
 
 // SessionList represents a response containing a list of sessions.
@@ -67,6 +69,36 @@ type SessionList struct {
 	Body  []*Session `json:"body,omitzero"`
 }
 
+// ThreadListRequest represents a request to list the side threads on a
+// message of a session.
+type ThreadListRequest struct {
+	pg.OffsetLimit
+}
+
+// SessionGetRequest selects how a session is returned
+type SessionGetRequest struct {
+	Summary bool `json:"summary,omitempty" help:"Return the title, tags, model and number of messages, without the generator settings" optional:""`
+}
+
+// SessionSummary is a session without its generator settings, with the
+// number of messages, for clients which list or page through sessions.
+// The messages are retrieved a page at a time from the session messages.
+type SessionSummary struct {
+	ID            uuid.UUID  `json:"id"`
+	User          uuid.UUID  `json:"user" help:"User owning the session"`
+	Parent        uuid.UUID  `json:"parent,omitempty" help:"Parent session for threading"`
+	ParentMessage uint64     `json:"parent_message,omitempty" help:"Message in the parent session which a side thread continues from"`
+	Title         *string    `json:"title,omitempty" help:"Session title"`
+	Tags          []string   `json:"tags,omitempty" help:"User-defined tags"`
+	Provider      *string    `json:"provider,omitempty" help:"Provider name"`
+	Model         *string    `json:"model,omitempty" help:"Model name"`
+	Messages      uint       `json:"messages" help:"Number of messages in the session"`
+	Input         uint       `json:"input,omitempty" help:"Cumulative input message tokens for the session"`
+	Output        uint       `json:"output,omitempty" help:"Cumulative output message tokens for the session"`
+	CreatedAt     time.Time  `json:"created_at" help:"Creation timestamp"`
+	ModifiedAt    *time.Time `json:"modified_at,omitempty" help:"Last modification timestamp"`
+}
+
 // SessionIDSelector selects a session by ID for get, update, and delete operations.
 type SessionIDSelector uuid.UUID
 
@@ -85,6 +117,28 @@ const (
 	SessionListMax uint64 = 100
 )
 
+////////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS - SESSION
+
+// Summary returns the summary of the session, with the number of messages
+func (s Session) Summary(messages uint) SessionSummary {
+	return SessionSummary{
+		ID:            s.ID,
+		User:          s.User,
+		Parent:        s.Parent,
+		ParentMessage: s.ParentMessage,
+		Title:         s.Title,
+		Tags:          s.Tags,
+		Provider:      s.Provider,
+		Model:         s.Model,
+		Messages:      messages,
+		Input:         s.Input,
+		Output:        s.Output,
+		CreatedAt:     s.CreatedAt,
+		ModifiedAt:    s.ModifiedAt,
+	}
+}
+
 ////////////////////////////////////////////////////////////////////////////////
 // PUBLIC METHODS - CONVERSATION
 
@@ -143,6 +197,10 @@ func (s SessionList) String() string {
 	return types.Stringify(s)
 }
 
+func (s SessionSummary) String() string {
+	return types.Stringify(s)
+}
+
 func (c Conversation) String() string {
 	return types.Stringify(c)
 }
@@ -187,6 +245,29 @@ func (s *Conversation) AppendWithOuput(message Message, input, output uint) {
 	*s = append(*s, &message)
 }
 
+// SplitPending returns the conversation without its trailing pending
+// messages, and the pending messages in the order they were stored
+func (s Conversation) SplitPending() (Conversation, Conversation) {
+	n := len(s)
+	for n > 0 && s[n-1] != nil && s[n-1].Pending() {
+		n--
+	}
+	return s[:n], s[n:]
+}
+
+// SetLatency sets the time taken to generate a reply, measured from when the
+// request was started. The conversation holds its own copy of the reply, so
+// the last message is also updated when it is the same reply.
+func (s Conversation) SetLatency(reply *Message, start time.Time) {
+	if reply == nil || start.IsZero() {
+		return
+	}
+	reply.Latency = time.Since(start)
+	if n := len(s); n > 0 && s[n-1].Role == reply.Role && s[n-1].CreatedAt.Equal(reply.CreatedAt) {
+		s[n-1].Latency = reply.Latency
+	}
+}
+
 // Return the total number of tokens in the conversation
 func (s Conversation) Tokens() uint {
 	total := uint(0)
@@ -228,6 +309,9 @@ func (req SessionListRequest) Query() url.Values {
 	if req.Parent != nil && *req.Parent != uuid.Nil {
 		values.Set("parent", req.Parent.String())
 	}
+	if req.ParentMessage != nil && *req.ParentMessage > 0 {
+		values.Set("parent_message", strconv.FormatUint(*req.ParentMessage, 10))
+	}
 	if req.User != nil && *req.User != uuid.Nil {
 		values.Set("user", req.User.String())
 	}
@@ -240,6 +324,11 @@ func (req SessionListRequest) Query() url.Values {
 	return values
 }
 
+// Query returns the URL query values for the request
+func (req ThreadListRequest) Query() url.Values {
+	return SessionListRequest{OffsetLimit: req.OffsetLimit}.Query()
+}
+
 ////////////////////////////////////////////////////////////////////////////////
 // SELECTORS
 
@@ -280,6 +369,12 @@ func (req SessionListRequest) Select(bind *pg.Bind, op pg.Op) (string, error) {
 		}
 		bind.Append("where", `session.parent = `+bind.Set("parent", *req.Parent))
 	}
+	if req.ParentMessage != nil {
+		if *req.ParentMessage == 0 {
+			return "", ErrBadParameter.With("parent message id cannot be zero")
+		}
+		bind.Append("where", `session.parent_message = `+bind.Set("parent_message", *req.ParentMessage))
+	}
 	if req.User != nil {
 		if *req.User == uuid.Nil {
 			return "", ErrBadParameter.With("user id cannot be nil")
@@ -336,14 +431,16 @@ func (s SessionOverheadSelector) Select(bind *pg.Bind, op pg.Op) (string, error)
 ////////////////////////////////////////////////////////////////////////////////
 // PUBLIC METHODS - READER
 
-// Expected column order: id, parent, user, title, input, output, overhead, meta, tags, created_at, modified_at.
+// Expected column order: id, parent, parent_message, user, title, input, output, overhead, meta, tags, created_at, modified_at.
 func (s *Session) Scan(row pg.Row) error {
 	var parent *uuid.UUID
+	var parentMessage *uint64
 	var user *uuid.UUID
 	var meta url.Values
 	if err := row.Scan(
 		&s.ID,
 		&parent,
+		&parentMessage,
 		&user,
 		&s.Title,
 		&s.Input,
@@ -361,6 +458,7 @@ func (s *Session) Scan(row pg.Row) error {
 	} else {
 		s.Parent = uuid.Nil
 	}
+	s.ParentMessage = types.Value(parentMessage)
 	if user != nil {
 		s.User = *user
 	} else {
@@ -401,6 +499,13 @@ func (s SessionInsert) Insert(bind *pg.Bind) (string, error) {
 	} else {
 		bind.Set("parent", s.Parent)
 	}
+	if s.ParentMessage == 0 {
+		bind.Set("parent_message", (*uint64)(nil))
+	} else if s.Parent == uuid.Nil {
+		return "", ErrBadParameter.With("parent message requires a parent session")
+	} else {
+		bind.Set("parent_message", s.ParentMessage)
+	}
 
 	var title *string
 	if s.Title != nil {
@@ -0,0 +1,68 @@
+package schema_test
+
+import (
+	"testing"
+	"time"
+
+	// Packages
+	uuid "github.com/google/uuid"
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	pg "github.com/mutablelogic/go-pg"
+	types "github.com/mutablelogic/go-server/pkg/types"
+	assert "github.com/stretchr/testify/assert"
+)
+
+func TestSessionSummary(t *testing.T) {
+	assert := assert.New(t)
+	session := schema.Session{
+		ID: uuid.MustParse("11111111-1111-1111-1111-111111111111"),
+		SessionInsert: schema.SessionInsert{SessionMeta: schema.SessionMeta{
+			GeneratorMeta: schema.GeneratorMeta{
+				Model:        types.Ptr("model"),
+				SystemPrompt: types.Ptr("A very long system prompt"),
+			},
+			Title: types.Ptr("Release notes"),
+			Tags:  []string{"work"},
+		}},
+		Input:     10,
+		CreatedAt: time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC),
+	}
+
+	summary := session.Summary(42)
+	assert.Equal(session.ID, summary.ID)
+	assert.Equal("Release notes", types.Value(summary.Title))
+	assert.Equal([]string{"work"}, summary.Tags)
+	assert.Equal("model", types.Value(summary.Model))
+	assert.Equal(uint(42), summary.Messages)
+	assert.Equal(uint(10), summary.Input)
+	assert.NotContains(summary.String(), "system prompt")
+}
+
+func TestSessionThread(t *testing.T) {
+	assert := assert.New(t)
+	parent := uuid.MustParse("11111111-1111-1111-1111-111111111111")
+
+	// A thread continues from a message of the parent session
+	bind := pg.NewBind()
+	_, err := schema.SessionInsert{Parent: parent, ParentMessage: 7}.Insert(bind)
+	if assert.NoError(err) {
+		assert.Equal(uint64(7), bind.Get("parent_message"))
+	}
+	_, err = schema.SessionInsert{ParentMessage: 7}.Insert(pg.NewBind())
+	assert.ErrorIs(err, schema.ErrBadParameter)
+
+	// Threads are listed by the message they continue from
+	req := schema.SessionListRequest{Parent: types.Ptr(parent), ParentMessage: types.Ptr(uint64(7))}
+	assert.Equal("7", req.Query().Get("parent_message"))
+	bind = pg.NewBind()
+	_, err = req.Select(bind, pg.List)
+	if assert.NoError(err) {
+		assert.Contains(bind.Get("where"), "session.parent_message = ")
+	}
+	_, err = schema.SessionListRequest{ParentMessage: types.Ptr(uint64(0))}.Select(pg.NewBind(), pg.List)
+	assert.ErrorIs(err, schema.ErrBadParameter)
+
+	// The summary includes the message
+	summary := schema.Session{SessionInsert: schema.SessionInsert{Parent: parent, ParentMessage: 7}}.Summary(0)
+	assert.Equal(uint64(7), summary.ParentMessage)
+}
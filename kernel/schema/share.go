@@ -0,0 +1,214 @@
+package schema
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	// Packages
+	uuid "github.com/google/uuid"
+	pg "github.com/mutablelogic/go-pg"
+	types "github.com/mutablelogic/go-server/pkg/types"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// SessionShareRequest sets how long a read-only share link for a session
+// is valid
+type SessionShareRequest struct {
+	Expires time.Duration `json:"expires,omitempty" help:"Time until the link expires, which is seven days by default and at most 30 days" optional:""`
+}
+
+// SessionShareInsert is a share link to store. Only the hash of the token
+// is stored, so a token cannot be recovered from the database.
+type SessionShareInsert struct {
+	Session   uuid.UUID `json:"session"`
+	Hash      string    `json:"-"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// SessionShare is a read-only share link for a session transcript. The
+// token is only returned when the link is created.
+type SessionShare struct {
+	ID        uuid.UUID `json:"id" help:"Share link ID, used to revoke the link" readonly:""`
+	Session   uuid.UUID `json:"session" help:"Shared session" readonly:""`
+	Token     string    `json:"token,omitempty" help:"Token which grants access to the transcript at share/{token}, returned only when the link is created" readonly:""`
+	ExpiresAt time.Time `json:"expires_at" help:"Time the link expires" readonly:""`
+	CreatedAt time.Time `json:"created_at" help:"Time the link was created" readonly:""`
+}
+
+// SessionShareList is the share links of a session, newest first
+type SessionShareList []*SessionShare
+
+// SessionShareIDSelector selects a share link of a session by ID
+type SessionShareIDSelector struct {
+	Session uuid.UUID `json:"session" help:"Session ID"`
+	ID      uuid.UUID `json:"share" help:"Share link ID"`
+}
+
+// SessionShareListSelector selects the share links of a session which have
+// not expired
+type SessionShareListSelector uuid.UUID
+
+// SessionShareTokenSelector selects a share link which has not expired by
+// its token
+type SessionShareTokenSelector struct {
+	Token string `json:"token" help:"Share token"`
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+const (
+	// DefaultShareExpires is the lifetime of a share link when none is
+	// requested
+	DefaultShareExpires = 7 * 24 * time.Hour
+
+	// ShareExpiresMax is the longest lifetime of a share link
+	ShareExpiresMax = 30 * 24 * time.Hour
+
+	// shareTokenBytes is the number of random bytes in a share token
+	shareTokenBytes = 32
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// STRINGIFY
+
+func (r SessionShareRequest) String() string {
+	return types.Stringify(r)
+}
+
+func (s SessionShare) String() string {
+	return types.Stringify(s)
+}
+
+func (l SessionShareList) String() string {
+	return types.Stringify(l)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// Lifetime returns the lifetime of the share link, which is the default when
+// none is requested, or an error if it is negative or too long
+func (r SessionShareRequest) Lifetime() (time.Duration, error) {
+	switch {
+	case r.Expires == 0:
+		return DefaultShareExpires, nil
+	case r.Expires < 0:
+		return 0, ErrBadParameter.With("expires cannot be negative")
+	case r.Expires > ShareExpiresMax:
+		return 0, ErrBadParameter.Withf("expires cannot be longer than %v", ShareExpiresMax)
+	default:
+		return r.Expires, nil
+	}
+}
+
+// NewShareToken returns a random token for a share link, and the hash which
+// is stored in its place
+func NewShareToken() (string, string, error) {
+	data := make([]byte, shareTokenBytes)
+	if _, err := rand.Read(data); err != nil {
+		return "", "", err
+	}
+	token := base64.RawURLEncoding.EncodeToString(data)
+	return token, ShareTokenHash(token), nil
+}
+
+// ShareTokenHash returns the hash of a share token
+func ShareTokenHash(token string) string {
+	hash := sha256.Sum256([]byte(strings.TrimSpace(token)))
+	return hex.EncodeToString(hash[:])
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS - SELECTOR
+
+func (s SessionShareIDSelector) Select(bind *pg.Bind, op pg.Op) (string, error) {
+	if s.Session == uuid.Nil {
+		return "", ErrBadParameter.With("session is required")
+	} else if s.ID == uuid.Nil {
+		return "", ErrBadParameter.With("share ID is required")
+	}
+	bind.Set("session", s.Session)
+	bind.Set("id", s.ID)
+
+	switch op {
+	case pg.Delete:
+		return bind.Query("session_share.delete"), nil
+	default:
+		return "", ErrNotImplemented.Withf("unsupported SessionShareIDSelector operation %q", op)
+	}
+}
+
+func (s SessionShareListSelector) Select(bind *pg.Bind, op pg.Op) (string, error) {
+	if session := uuid.UUID(s); session == uuid.Nil {
+		return "", ErrBadParameter.With("session is required")
+	} else {
+		bind.Set("session", session)
+	}
+
+	switch op {
+	case pg.List:
+		return bind.Query("session_share.list"), nil
+	default:
+		return "", ErrNotImplemented.Withf("unsupported SessionShareListSelector operation %q", op)
+	}
+}
+
+func (s SessionShareTokenSelector) Select(bind *pg.Bind, op pg.Op) (string, error) {
+	if strings.TrimSpace(s.Token) == "" {
+		return "", ErrBadParameter.With("token is required")
+	}
+	bind.Set("hash", ShareTokenHash(s.Token))
+
+	switch op {
+	case pg.Get:
+		return bind.Query("session_share.select_token"), nil
+	default:
+		return "", ErrNotImplemented.Withf("unsupported SessionShareTokenSelector operation %q", op)
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS - READER
+
+// Expected column order: id, session, expires_at, created_at.
+func (s *SessionShare) Scan(row pg.Row) error {
+	return row.Scan(&s.ID, &s.Session, &s.ExpiresAt, &s.CreatedAt)
+}
+
+func (l *SessionShareList) Scan(row pg.Row) error {
+	var share SessionShare
+	if err := share.Scan(row); err != nil {
+		return err
+	}
+	*l = append(*l, &share)
+	return nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS - WRITER
+
+func (s SessionShareInsert) Insert(bind *pg.Bind) (string, error) {
+	if s.Session == uuid.Nil {
+		return "", ErrBadParameter.With("session is required")
+	} else if s.Hash == "" {
+		return "", ErrBadParameter.With("token hash is required")
+	} else if s.ExpiresAt.IsZero() {
+		return "", ErrBadParameter.With("expiry time is required")
+	}
+	bind.Set("session", s.Session)
+	bind.Set("hash", s.Hash)
+	bind.Set("expires_at", s.ExpiresAt)
+
+	return bind.Query("session_share.insert"), nil
+}
+
+func (s SessionShareInsert) Update(_ *pg.Bind) error {
+	return ErrNotImplemented.With("share links cannot be updated")
+}
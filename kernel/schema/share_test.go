@@ -0,0 +1,56 @@
+package schema_test
+
+import (
+	"testing"
+	"time"
+
+	// Packages
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	pg "github.com/mutablelogic/go-pg"
+	assert "github.com/stretchr/testify/assert"
+)
+
+func TestSessionShareLifetime(t *testing.T) {
+	assert := assert.New(t)
+
+	lifetime, err := schema.SessionShareRequest{}.Lifetime()
+	if assert.NoError(err) {
+		assert.Equal(schema.DefaultShareExpires, lifetime)
+	}
+	lifetime, err = schema.SessionShareRequest{Expires: time.Hour}.Lifetime()
+	if assert.NoError(err) {
+		assert.Equal(time.Hour, lifetime)
+	}
+	_, err = schema.SessionShareRequest{Expires: -time.Hour}.Lifetime()
+	assert.ErrorIs(err, schema.ErrBadParameter)
+	_, err = schema.SessionShareRequest{Expires: schema.ShareExpiresMax + time.Second}.Lifetime()
+	assert.ErrorIs(err, schema.ErrBadParameter)
+}
+
+func TestSessionShareToken(t *testing.T) {
+	assert := assert.New(t)
+
+	token, hash, err := schema.NewShareToken()
+	if !assert.NoError(err) {
+		return
+	}
+	assert.Len(token, 43)
+	assert.Equal(hash, schema.ShareTokenHash(token))
+	assert.NotContains(hash, token)
+
+	// Each token is different
+	other, _, err := schema.NewShareToken()
+	if assert.NoError(err) {
+		assert.NotEqual(token, other)
+	}
+
+	// The token is looked up by its hash
+	bind := pg.NewBind()
+	query, err := schema.SessionShareTokenSelector{Token: token}.Select(bind, pg.Get)
+	if assert.NoError(err) {
+		assert.NotEmpty(query)
+		assert.Equal(hash, bind.Get("hash"))
+	}
+	_, err = schema.SessionShareTokenSelector{}.Select(pg.NewBind(), pg.Get)
+	assert.ErrorIs(err, schema.ErrBadParameter)
+}
@@ -0,0 +1,121 @@
+package schema
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	// Packages
+	uuid "github.com/google/uuid"
+	pg "github.com/mutablelogic/go-pg"
+	types "github.com/mutablelogic/go-server/pkg/types"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// SessionSyncRequest requests the changes to a session since a cursor
+type SessionSyncRequest struct {
+	Cursor string `json:"cursor,omitempty" help:"Cursor returned by the previous sync, or empty for the whole session" optional:""`
+}
+
+// SessionSync contains the changes to a session since a cursor. Messages are
+// identified by ID, so a client adds or replaces each message it receives,
+// and removes pending input which is no longer pending, since it was sent
+// and stored again with the reply.
+type SessionSync struct {
+	Session  *Session   `json:"session,omitempty" help:"The session, when it was changed since the cursor"`
+	Messages []*Message `json:"messages,omitempty" help:"Messages added or changed since the cursor, and pending input, oldest first"`
+	Pending  []uint64   `json:"pending" help:"Messages which are pending input, when there are no more changes"`
+	Cursor   string     `json:"cursor" help:"Cursor for the next sync"`
+	More     bool       `json:"more,omitempty" help:"True if there are more changes, which are returned by the next sync"`
+}
+
+// SessionSyncCursor is the position of a client in the changes to a session:
+// the last message it received, and the time from which changes are sent
+type SessionSyncCursor struct {
+	Last  uint64
+	Since time.Time
+}
+
+// MessageSyncSelector selects the messages in a session which were added
+// after a message or changed since a time, and any pending input
+type MessageSyncSelector struct {
+	Session uuid.UUID
+	SessionSyncCursor
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// STRINGIFY
+
+func (r SessionSyncRequest) String() string {
+	return types.Stringify(r)
+}
+
+func (s SessionSync) String() string {
+	return types.Stringify(s)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// Query returns the URL query values for the request
+func (r SessionSyncRequest) Query() url.Values {
+	values := url.Values{}
+	if cursor := strings.TrimSpace(r.Cursor); cursor != "" {
+		values.Set("cursor", cursor)
+	}
+	return values
+}
+
+// ParseSessionSyncCursor returns the cursor for an encoded cursor, or the
+// start of the session for an empty string
+func ParseSessionSyncCursor(value string) (SessionSyncCursor, error) {
+	var cursor SessionSyncCursor
+	if value = strings.TrimSpace(value); value == "" {
+		return cursor, nil
+	}
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return cursor, ErrBadParameter.Withf("invalid cursor %q", value)
+	}
+	var since int64
+	if _, err := fmt.Sscanf(string(data), "%d.%d", &cursor.Last, &since); err != nil {
+		return cursor, ErrBadParameter.Withf("invalid cursor %q", value)
+	}
+	if since > 0 {
+		cursor.Since = time.Unix(0, since).UTC()
+	}
+	return cursor, nil
+}
+
+// Encode returns the cursor as an opaque string
+func (c SessionSyncCursor) Encode() string {
+	var since int64
+	if !c.Since.IsZero() {
+		since = c.Since.UnixNano()
+	}
+	return base64.RawURLEncoding.EncodeToString(fmt.Appendf(nil, "%d.%d", c.Last, since))
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// SELECTORS
+
+func (sel MessageSyncSelector) Select(bind *pg.Bind, op pg.Op) (string, error) {
+	if sel.Session == uuid.Nil {
+		return "", ErrBadParameter.With("message session is required")
+	}
+	bind.Set("session", sel.Session)
+	bind.Set("last", sel.Last)
+	bind.Set("since", sel.Since)
+	(&pg.OffsetLimit{}).Bind(bind, MessageListMax)
+
+	switch op {
+	case pg.List:
+		return bind.Query("message.sync"), nil
+	default:
+		return "", ErrNotImplemented.Withf("unsupported MessageSyncSelector operation %q", op)
+	}
+}
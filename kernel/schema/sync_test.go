@@ -0,0 +1,72 @@
+package schema_test
+
+import (
+	"testing"
+	"time"
+
+	// Packages
+	uuid "github.com/google/uuid"
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	pg "github.com/mutablelogic/go-pg"
+	assert "github.com/stretchr/testify/assert"
+)
+
+func TestSessionSyncCursor(t *testing.T) {
+	assert := assert.New(t)
+
+	// An empty cursor is the start of the session
+	cursor, err := schema.ParseSessionSyncCursor(" ")
+	if assert.NoError(err) {
+		assert.Zero(cursor.Last)
+		assert.True(cursor.Since.IsZero())
+	}
+
+	// A cursor is returned unchanged
+	since := time.Date(2024, 5, 1, 10, 0, 0, 123456789, time.UTC)
+	encoded := schema.SessionSyncCursor{Last: 42, Since: since}.Encode()
+	cursor, err = schema.ParseSessionSyncCursor(encoded)
+	if assert.NoError(err) {
+		assert.Equal(uint64(42), cursor.Last)
+		assert.True(since.Equal(cursor.Since))
+	}
+	cursor, err = schema.ParseSessionSyncCursor(schema.SessionSyncCursor{Last: 7}.Encode())
+	if assert.NoError(err) {
+		assert.Equal(uint64(7), cursor.Last)
+		assert.True(cursor.Since.IsZero())
+	}
+
+	// Other values are rejected
+	_, err = schema.ParseSessionSyncCursor("not a cursor!")
+	assert.ErrorIs(err, schema.ErrBadParameter)
+	_, err = schema.ParseSessionSyncCursor("aGVsbG8")
+	assert.ErrorIs(err, schema.ErrBadParameter)
+}
+
+func TestSessionSyncRequestQuery(t *testing.T) {
+	assert := assert.New(t)
+	assert.Empty(schema.SessionSyncRequest{}.Query())
+	assert.Equal("abc", schema.SessionSyncRequest{Cursor: " abc "}.Query().Get("cursor"))
+}
+
+func TestMessageSyncSelector(t *testing.T) {
+	assert := assert.New(t)
+	session := uuid.MustParse("11111111-1111-1111-1111-111111111111")
+	since := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	b := pg.NewBind("schema", "llm", "message.sync", "SYNC")
+
+	query, err := (schema.MessageSyncSelector{Session: session, SessionSyncCursor: schema.SessionSyncCursor{Last: 42, Since: since}}).Select(b, pg.List)
+	if !assert.NoError(err) {
+		return
+	}
+	assert.Equal("SYNC", query)
+	assert.Equal(session, b.Get("session"))
+	assert.Equal(uint64(42), b.Get("last"))
+	assert.Equal(since, b.Get("since"))
+	assert.Equal("LIMIT 100", b.Get("offsetlimit"))
+
+	// A session is required
+	_, err = (schema.MessageSyncSelector{}).Select(b, pg.List)
+	assert.ErrorIs(err, schema.ErrBadParameter)
+	_, err = (schema.MessageSyncSelector{Session: session}).Select(b, pg.Get)
+	assert.ErrorIs(err, schema.ErrNotImplemented)
+}
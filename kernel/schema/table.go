@@ -400,6 +400,8 @@ func messageTableText(m Message) string {
 			parts = append(parts, "[tool call] "+block.ToolCall.Name)
 		case block.ToolResult != nil:
 			parts = append(parts, "[tool result] "+truncateTableText(compactTableText(string(block.ToolResult.Content)), 120))
+		case block.Context != nil:
+			parts = append(parts, "[context] "+block.Context.Name)
 		case block.Attachment != nil:
 			attachment := "[attachment]"
 			if block.Attachment.ContentType != "" {
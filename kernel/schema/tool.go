@@ -1,9 +1,13 @@
 package schema
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/url"
+	"strings"
+	"time"
 
 	// Packages
 	pg "github.com/mutablelogic/go-pg"
@@ -16,6 +20,17 @@ import (
 // BuiltinNamespace is the namespace used for locally-implemented (builtin) tools.
 const BuiltinNamespace = "builtin"
 
+// ToolTraceResultLimit is the maximum number of bytes of a tool result
+// which is included in a tool trace.
+const ToolTraceResultLimit = 512
+
+// Stages of a tool test, which are reported when a test fails.
+const (
+	ToolTestStageInput  = "input"
+	ToolTestStageRun    = "run"
+	ToolTestStageOutput = "output"
+)
+
 ///////////////////////////////////////////////////////////////////////////////
 // TYPES
 
@@ -55,6 +70,118 @@ type CallToolRequest struct {
 	Input json.RawMessage `json:"input,omitempty" help:"JSON-encoded arguments passed to the tool" example:"{\"query\":\"authentication flow\"}"`
 }
 
+// ToolTrace records a single tool call made during a chat turn.
+type ToolTrace struct {
+	Iteration uint            `json:"iteration" help:"Zero-based iteration of the tool-calling loop" example:"0"`
+	ID        string          `json:"id,omitempty" help:"Tool call identifier" example:"call_123"`
+	Name      string          `json:"name" help:"Tool name" example:"builtin__search_docs"`
+	Input     json.RawMessage `json:"input,omitempty" help:"JSON-encoded arguments passed to the tool" example:"{\"query\":\"authentication flow\"}"`
+	Duration  time.Duration   `json:"duration_ns" help:"Time spent running the tool, in nanoseconds" example:"125000000"`
+	Result    string          `json:"result,omitempty" help:"Tool result, truncated to a limited number of bytes" example:"{\"results\":[]}"`
+	Truncated bool            `json:"truncated,omitempty" help:"Whether the result was truncated" example:"false"`
+	Error     string          `json:"error,omitempty" help:"Error message when the tool call failed" example:"tool \"search\" not found"`
+	Simulated string          `json:"simulated,omitempty" help:"Source of the result when the tool was not run, either fixture or model" example:"fixture"`
+}
+
+// ToolFixture is a recorded result for a tool call, which is returned instead
+// of running the tool when a chat turn is simulated. A fixture has the same
+// fields as a ToolTrace, so the trace of a chat turn can be saved and
+// replayed. A fixture without input matches any call to the tool.
+type ToolFixture struct {
+	Name      string          `json:"name" help:"Tool name, with or without its namespace" example:"builtin.weather"`
+	Input     json.RawMessage `json:"input,omitempty" help:"Arguments which the call must have, or any arguments when empty" example:"{\"city\":\"Berlin\"}"`
+	Result    string          `json:"result,omitempty" help:"JSON-encoded tool result" example:"{\"temperature\":12}"`
+	Truncated bool            `json:"truncated,omitempty" help:"Whether the recorded result was truncated" example:"false"`
+	Error     string          `json:"error,omitempty" help:"Error message returned instead of a result" example:"city not found"`
+}
+
+// ToolDocsRequest selects the tools and format of tool documentation.
+type ToolDocsRequest struct {
+	Namespace string `json:"namespace,omitempty" help:"Restrict documentation to a single namespace" example:"builtin" optional:""`
+	Format    string `json:"format,omitempty" help:"Documentation format" enum:"markdown,html" default:"markdown" optional:""`
+}
+
+// ToolTestReport is the result of running a single tool outside of a chat,
+// with its input and output validated against the tool schemas.
+type ToolTestReport struct {
+	Name     string          `json:"name" help:"Tool name" example:"builtin.search_docs"`
+	Input    json.RawMessage `json:"input,omitempty" help:"JSON-encoded arguments passed to the tool" example:"{\"query\":\"authentication flow\"}"`
+	Duration time.Duration   `json:"duration_ns" help:"Time spent running the tool, in nanoseconds" example:"125000000"`
+	Type     string          `json:"type,omitempty" help:"Content type of the tool output" example:"application/json"`
+	Size     int             `json:"size" help:"Size of the tool output in bytes" example:"14"`
+	Result   string          `json:"result,omitempty" help:"Tool output, when it is JSON or text" example:"{\"results\":[]}"`
+	Stage    string          `json:"stage,omitempty" help:"Stage at which the test failed, either input, run or output" example:"output"`
+	Error    string          `json:"error,omitempty" help:"Error message when the test failed" example:"output validation failed"`
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// LIFECYCLE
+
+// NewToolTrace returns a trace entry for a tool call and its result block,
+// truncating the result to ToolTraceResultLimit bytes.
+func NewToolTrace(iteration uint, call ToolCall, result ContentBlock, duration time.Duration) ToolTrace {
+	trace := ToolTrace{
+		Iteration: iteration,
+		ID:        call.ID,
+		Name:      call.Name,
+		Input:     call.Input,
+		Duration:  duration,
+	}
+	if result.ToolResult == nil {
+		return trace
+	}
+	if simulated, ok := result.Annotations[AnnotationSimulated].(string); ok {
+		trace.Simulated = simulated
+	}
+	content := string(result.ToolResult.Content)
+	if result.ToolResult.IsError {
+		var message string
+		if err := json.Unmarshal(result.ToolResult.Content, &message); err == nil {
+			content = message
+		}
+		trace.Error = content
+		return trace
+	}
+	if len(content) > ToolTraceResultLimit {
+		content = strings.ToValidUTF8(content[:ToolTraceResultLimit], "")
+		trace.Truncated = true
+	}
+	trace.Result = content
+	return trace
+}
+
+// ReadToolFixtures reads tool fixtures from JSON, which is either a list of
+// fixtures or a chat response which includes a trace. Fixtures whose result
+// was truncated when it was recorded cannot be replayed, and are an error.
+func ReadToolFixtures(r io.Reader) ([]ToolFixture, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	var fixtures []ToolFixture
+	if data = bytes.TrimSpace(data); len(data) > 0 && data[0] == '{' {
+		var response struct {
+			Trace []ToolFixture `json:"trace"`
+		}
+		if err := json.Unmarshal(data, &response); err != nil {
+			return nil, ErrBadParameter.Withf("tool fixtures: %v", err)
+		}
+		fixtures = response.Trace
+	} else if err := json.Unmarshal(data, &fixtures); err != nil {
+		return nil, ErrBadParameter.Withf("tool fixtures: %v", err)
+	}
+	for i, fixture := range fixtures {
+		if strings.TrimSpace(fixture.Name) == "" {
+			return nil, ErrBadParameter.Withf("tool fixture %d: name is required", i)
+		} else if fixture.Truncated {
+			return nil, ErrBadParameter.Withf("tool fixture %d: result of %q was truncated", i, fixture.Name)
+		} else if fixture.Error == "" && fixture.Result != "" && !json.Valid([]byte(fixture.Result)) {
+			return nil, ErrBadParameter.Withf("tool fixture %d: result of %q is not valid JSON", i, fixture.Name)
+		}
+	}
+	return fixtures, nil
+}
+
 ///////////////////////////////////////////////////////////////////////////////
 // STRINGIFY
 
@@ -85,6 +212,22 @@ func (r ToolListRequest) Query() url.Values {
 	return values
 }
 
+func (r ToolDocsRequest) String() string {
+	return types.Stringify(r)
+}
+
+// Query returns the URL query values for the request
+func (r ToolDocsRequest) Query() url.Values {
+	values := url.Values{}
+	if r.Namespace != "" {
+		values.Set("namespace", r.Namespace)
+	}
+	if format := strings.TrimSpace(r.Format); format != "" {
+		values.Set("format", format)
+	}
+	return values
+}
+
 func (r ToolList) String() string {
 	return types.Stringify(r)
 }
@@ -92,3 +235,15 @@ func (r ToolList) String() string {
 func (r CallToolRequest) String() string {
 	return types.Stringify(r)
 }
+
+func (r ToolTestReport) String() string {
+	return types.Stringify(r)
+}
+
+func (r ToolTrace) String() string {
+	return types.Stringify(r)
+}
+
+func (r ToolFixture) String() string {
+	return types.Stringify(r)
+}
@@ -0,0 +1,91 @@
+package schema_test
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	// Packages
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	assert "github.com/stretchr/testify/assert"
+)
+
+func TestNewToolTraceResult(t *testing.T) {
+	assert := assert.New(t)
+	call := schema.ToolCall{ID: "call_1", Name: "search", Input: json.RawMessage(`{"q":"go"}`)}
+
+	trace := schema.NewToolTrace(2, call, schema.NewToolResult(call.ID, call.Name, map[string]int{"count": 3}), time.Second)
+	assert.Equal(uint(2), trace.Iteration)
+	assert.Equal("call_1", trace.ID)
+	assert.Equal("search", trace.Name)
+	assert.JSONEq(`{"q":"go"}`, string(trace.Input))
+	assert.Equal(time.Second, trace.Duration)
+	assert.Equal(`{"count":3}`, trace.Result)
+	assert.False(trace.Truncated)
+	assert.Empty(trace.Error)
+}
+
+func TestNewToolTraceError(t *testing.T) {
+	assert := assert.New(t)
+	call := schema.ToolCall{ID: "call_1", Name: "search"}
+
+	trace := schema.NewToolTrace(0, call, schema.NewToolError(call.ID, call.Name, errors.New("boom")), 0)
+	assert.Equal("boom", trace.Error)
+	assert.Empty(trace.Result)
+}
+
+func TestNewToolTraceTruncatesResult(t *testing.T) {
+	assert := assert.New(t)
+	call := schema.ToolCall{ID: "call_1", Name: "read"}
+
+	trace := schema.NewToolTrace(0, call, schema.NewToolResult(call.ID, call.Name, strings.Repeat("x", 2*schema.ToolTraceResultLimit)), 0)
+	assert.True(trace.Truncated)
+	assert.Len(trace.Result, schema.ToolTraceResultLimit)
+}
+
+func TestChatQueryIncludeTrace(t *testing.T) {
+	assert := assert.New(t)
+	assert.False(schema.ChatQuery{}.IncludeTrace())
+	assert.True(schema.ChatQuery{Include: []string{"usage", schema.ChatIncludeTrace}}.IncludeTrace())
+}
+
+func TestReadToolFixtures(t *testing.T) {
+	assert := assert.New(t)
+
+	// A list of fixtures, or the trace of a chat response
+	fixtures, err := schema.ReadToolFixtures(strings.NewReader(`[{"name": "builtin.weather", "input": {"city": "Berlin"}, "result": "{\"temperature\": 12}"}, {"name": "search", "error": "offline"}]`))
+	if assert.NoError(err) && assert.Len(fixtures, 2) {
+		assert.Equal("builtin.weather", fixtures[0].Name)
+		assert.JSONEq(`{"city": "Berlin"}`, string(fixtures[0].Input))
+		assert.Equal("offline", fixtures[1].Error)
+	}
+	fixtures, err = schema.ReadToolFixtures(strings.NewReader(`{"role": "assistant", "trace": [{"iteration": 0, "name": "builtin__weather", "duration_ns": 5, "result": "{}"}]}`))
+	if assert.NoError(err) && assert.Len(fixtures, 1) {
+		assert.Equal("builtin__weather", fixtures[0].Name)
+	}
+
+	// Invalid fixtures
+	for input, message := range map[string]string{
+		`[{"result": "{}"}]`: "name is required",
+		`[{"name": "read", "result": "{", "truncated": true}]`: "truncated",
+		`[{"name": "read", "result": "not json"}]`:             "not valid JSON",
+		`{"trace": 1}`: "tool fixtures",
+	} {
+		_, err := schema.ReadToolFixtures(strings.NewReader(input))
+		if assert.ErrorIs(err, schema.ErrBadParameter, input) {
+			assert.Contains(err.Error(), message, input)
+		}
+	}
+}
+
+func TestNewToolTraceSimulated(t *testing.T) {
+	assert := assert.New(t)
+	call := schema.ToolCall{ID: "call_1", Name: "search"}
+
+	result := schema.NewToolResult(call.ID, call.Name, "ok")
+	assert.Empty(schema.NewToolTrace(0, call, result, 0).Simulated)
+	result.Annotate(schema.AnnotationSimulated, "fixture")
+	assert.Equal("fixture", schema.NewToolTrace(0, call, result, 0).Simulated)
+}
@@ -0,0 +1,107 @@
+package schema
+
+import (
+	"path"
+	"strings"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// CONSTANTS
+
+// ToolFilterDeny is the prefix for a pattern which denies tools
+const ToolFilterDeny = "!"
+
+///////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// ToolFilter selects tools by name. Each pattern is a glob (for example
+// "fs.*") matched against either the qualified name ("namespace.name") or
+// the bare name of a tool. Patterns prefixed with "!" deny matching tools,
+// and deny rules always take precedence. When there are no allow patterns,
+// every tool which is not denied is allowed.
+type ToolFilter struct {
+	allow []string
+	deny  []string
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// LIFECYCLE
+
+// NewToolFilter returns a filter from a list of patterns, or an error if any
+// of the patterns is not a valid glob.
+func NewToolFilter(patterns ...string) (*ToolFilter, error) {
+	filter := new(ToolFilter)
+	for _, pattern := range patterns {
+		pattern = strings.TrimSpace(pattern)
+		deny := strings.HasPrefix(pattern, ToolFilterDeny)
+		if deny {
+			pattern = strings.TrimSpace(strings.TrimPrefix(pattern, ToolFilterDeny))
+		}
+		if pattern == "" {
+			return nil, ErrBadParameter.With("empty tool pattern")
+		}
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, ErrBadParameter.Withf("invalid tool pattern %q", pattern)
+		}
+		if deny {
+			filter.deny = append(filter.deny, pattern)
+		} else {
+			filter.allow = append(filter.allow, pattern)
+		}
+	}
+	return filter, nil
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// Match returns true if the tool with the given qualified name is allowed
+func (f *ToolFilter) Match(name string) bool {
+	if f == nil {
+		return true
+	}
+	bare := name
+	if _, after, ok := strings.Cut(name, "."); ok {
+		bare = after
+	}
+	for _, pattern := range f.deny {
+		if matchToolPattern(pattern, name, bare) {
+			return false
+		}
+	}
+	if len(f.allow) == 0 {
+		return true
+	}
+	for _, pattern := range f.allow {
+		if matchToolPattern(pattern, name, bare) {
+			return true
+		}
+	}
+	return false
+}
+
+// Names returns the allowed tool names when the filter consists only of
+// literal allow patterns, so that tools can be looked up by name rather than
+// listing them all. It returns false if the filter uses globs or deny rules.
+func (f *ToolFilter) Names() ([]string, bool) {
+	if f == nil || len(f.deny) > 0 || len(f.allow) == 0 {
+		return nil, false
+	}
+	for _, pattern := range f.allow {
+		if strings.ContainsAny(pattern, `*?[\`) {
+			return nil, false
+		}
+	}
+	return f.allow, true
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+func matchToolPattern(pattern, name, bare string) bool {
+	if ok, _ := path.Match(pattern, name); ok {
+		return true
+	}
+	ok, _ := path.Match(pattern, bare)
+	return ok
+}
@@ -0,0 +1,63 @@
+package schema_test
+
+import (
+	"testing"
+
+	// Packages
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	assert "github.com/stretchr/testify/assert"
+)
+
+func TestToolFilterAllowsAllWithoutPatterns(t *testing.T) {
+	assert := assert.New(t)
+	filter, err := schema.NewToolFilter()
+	if !assert.NoError(err) {
+		return
+	}
+	assert.True(filter.Match("builtin.alpha"))
+	_, ok := filter.Names()
+	assert.False(ok)
+}
+
+func TestToolFilterGlobAndDeny(t *testing.T) {
+	assert := assert.New(t)
+	filter, err := schema.NewToolFilter("fs.*", "!fs.write", "search")
+	if !assert.NoError(err) {
+		return
+	}
+	assert.True(filter.Match("fs.read"))
+	assert.False(filter.Match("fs.write"))
+	assert.True(filter.Match("builtin.search"))
+	assert.False(filter.Match("builtin.fetch"))
+	_, ok := filter.Names()
+	assert.False(ok)
+}
+
+func TestToolFilterDenyOnly(t *testing.T) {
+	assert := assert.New(t)
+	filter, err := schema.NewToolFilter("!*.delete_*")
+	if !assert.NoError(err) {
+		return
+	}
+	assert.True(filter.Match("builtin.list_files"))
+	assert.False(filter.Match("fs.delete_file"))
+}
+
+func TestToolFilterLiteralNames(t *testing.T) {
+	assert := assert.New(t)
+	filter, err := schema.NewToolFilter("builtin.alpha", "beta")
+	if !assert.NoError(err) {
+		return
+	}
+	names, ok := filter.Names()
+	assert.True(ok)
+	assert.Equal([]string{"builtin.alpha", "beta"}, names)
+}
+
+func TestToolFilterInvalidPattern(t *testing.T) {
+	assert := assert.New(t)
+	_, err := schema.NewToolFilter("fs.[")
+	assert.ErrorIs(err, schema.ErrBadParameter)
+	_, err = schema.NewToolFilter("!")
+	assert.ErrorIs(err, schema.ErrBadParameter)
+}
@@ -0,0 +1,78 @@
+package schema
+
+import (
+	"strings"
+
+	// Packages
+	uuid "github.com/google/uuid"
+	types "github.com/mutablelogic/go-server/pkg/types"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// TopicRequest selects the sessions to analyse, and the embedding model used
+// to compare them. Each session is summarised by its title and first user
+// messages, and sessions with similar summaries are grouped into topics.
+type TopicRequest struct {
+	Provider string   `json:"provider,omitempty" help:"Provider name" optional:""`
+	Model    string   `json:"model" help:"Embedding model used to compare sessions" example:"text-embedding-3-small"`
+	Tags     []string `json:"tags,omitempty" help:"Only analyse sessions with all of these tags" optional:""`
+	Topics   uint     `json:"topics,omitempty" help:"Maximum number of topics" optional:"" example:"8"`
+	Apply    bool     `json:"apply,omitempty" help:"Tag each session with its topic, replacing any earlier topic tag" optional:""`
+}
+
+// Topic is a group of sessions about the same subject
+type Topic struct {
+	Label    string      `json:"label" help:"Topic label, from the most distinctive keywords" example:"weather-forecast"`
+	Keywords []string    `json:"keywords,omitempty" help:"Keywords which distinguish the topic from the others"`
+	Sessions []uuid.UUID `json:"sessions" help:"Sessions about the topic"`
+}
+
+// TopicReport describes the topics found in the analysed sessions, largest
+// first
+type TopicReport struct {
+	TopicRequest
+	Sessions uint       `json:"sessions" help:"Number of sessions analysed"`
+	Topics   []Topic    `json:"topics" help:"Topics, largest first"`
+	Usage    *UsageMeta `json:"usage,omitempty" help:"Token usage for embedding the session summaries"`
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+const (
+	// TopicTagPrefix is prepended to the topic label in the tags of a
+	// session, so that sessions can be listed by topic
+	TopicTagPrefix = "topic:"
+
+	// DefaultTopics is the number of topics when none is requested
+	DefaultTopics uint = 8
+
+	// TopicSessionMax is the maximum number of sessions analysed
+	TopicSessionMax uint64 = 1000
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// STRINGIFY
+
+func (r TopicRequest) String() string {
+	return types.Stringify(r)
+}
+
+func (r TopicReport) String() string {
+	return types.Stringify(r)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// Tag returns the session tag for the topic
+func (t Topic) Tag() string {
+	return TopicTagPrefix + t.Label
+}
+
+// IsTopicTag returns true if the session tag was set by topic analysis
+func IsTopicTag(tag string) bool {
+	return strings.HasPrefix(tag, TopicTagPrefix)
+}
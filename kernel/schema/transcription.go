@@ -0,0 +1,52 @@
+package schema
+
+import (
+	"strings"
+
+	// Packages
+	types "github.com/mutablelogic/go-server/pkg/types"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// Transcription is the text of a recording, such as audio transcribed by a
+// model or the captions of a video. Segments are in order, and their times
+// are in seconds from the start of the recording.
+type Transcription struct {
+	Model    string                 `json:"model,omitempty" help:"Model which transcribed the audio, when it was transcribed"`
+	Language string                 `json:"language,omitempty" help:"Language of the transcription" example:"en"`
+	Duration float64                `json:"duration,omitempty" help:"Length of the recording in seconds"`
+	Text     string                 `json:"text" help:"Full text of the transcription"`
+	Segments []TranscriptionSegment `json:"segments,omitempty" help:"Timed segments of the transcription, when available"`
+}
+
+// TranscriptionSegment is a span of a transcription with its start and end
+// times in seconds
+type TranscriptionSegment struct {
+	Start float64 `json:"start" help:"Start time in seconds"`
+	End   float64 `json:"end" help:"End time in seconds"`
+	Text  string  `json:"text" help:"Text spoken in the segment"`
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// STRINGIFY
+
+func (t Transcription) String() string {
+	return types.Stringify(t)
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// SegmentText returns the text of the segments joined with spaces, for
+// transcriptions which have segments but no full text
+func (t Transcription) SegmentText() string {
+	parts := make([]string, 0, len(t.Segments))
+	for _, segment := range t.Segments {
+		if text := strings.TrimSpace(segment.Text); text != "" {
+			parts = append(parts, text)
+		}
+	}
+	return strings.Join(parts, " ")
+}
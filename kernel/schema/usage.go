@@ -22,6 +22,16 @@ type UsageMeta struct {
 	CacheWriteTokens uint            `json:"cache_write_tokens,omitempty" help:"Number of tokens written to cache" example:"3"`
 	ReasoningTokens  uint            `json:"reasoning_tokens,omitempty" help:"Number of tokens used for reasoning" example:"2"`
 	Meta             ProviderMetaMap `json:"meta,omitempty" help:"Optional provider-specific metadata for usage records" optional:""`
+	Estimate         *UsageEstimate  `json:"estimate,omitempty" help:"Usage estimated while the response was streamed, which the provider may not report until the end" optional:""`
+}
+
+// UsageEstimate is the usage estimated from the request and the text which
+// is streamed, at about four characters per token. Output tokens include
+// the reasoning tokens.
+type UsageEstimate struct {
+	InputTokens     uint `json:"input_tokens,omitempty" help:"Estimated number of input tokens" example:"18"`
+	OutputTokens    uint `json:"output_tokens,omitempty" help:"Estimated number of output tokens" example:"12"`
+	ReasoningTokens uint `json:"reasoning_tokens,omitempty" help:"Estimated number of reasoning tokens" example:"2"`
 }
 
 type UsageInsert struct {
@@ -49,6 +59,26 @@ const (
 	UsageTypeChat      UsageType = "chat"
 )
 
+////////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// Reconcile returns the usage reported by the provider with the usage which
+// was estimated while the response was streamed. When the provider did not
+// report the usage, the result has only the estimate.
+func (u *UsageMeta) Reconcile(estimate UsageEstimate) *UsageMeta {
+	var result UsageMeta
+	if u != nil {
+		result = *u
+	}
+	result.Estimate = &estimate
+	return &result
+}
+
+// Reported returns true when the provider reported the tokens used
+func (u *UsageMeta) Reported() bool {
+	return u != nil && (u.InputTokens > 0 || u.OutputTokens > 0 || u.CacheReadTokens > 0 || u.CacheWriteTokens > 0 || u.ReasoningTokens > 0)
+}
+
 ////////////////////////////////////////////////////////////////////////////////
 // PUBLIC METHODS - READER
 
@@ -211,3 +211,24 @@ func TestUsageInsertRequiresTypeAndModel(t *testing.T) {
 		assert.ErrorIs(err, schema.ErrBadParameter)
 	}
 }
+
+func TestUsageMetaReconcile(t *testing.T) {
+	assert := assert.New(t)
+	estimate := schema.UsageEstimate{InputTokens: 20, OutputTokens: 10, ReasoningTokens: 4}
+
+	// The usage reported by the provider is kept with the estimate
+	reported := &schema.UsageMeta{InputTokens: 18, OutputTokens: 12}
+	usage := reported.Reconcile(estimate)
+	assert.Equal(uint(18), usage.InputTokens)
+	assert.Equal(uint(12), usage.OutputTokens)
+	assert.Equal(&estimate, usage.Estimate)
+	assert.True(usage.Reported())
+	assert.Nil(reported.Estimate)
+
+	// Usage which the provider did not report has only the estimate
+	var missing *schema.UsageMeta
+	usage = missing.Reconcile(estimate)
+	assert.Equal(&schema.UsageMeta{Estimate: &estimate}, usage)
+	assert.False(usage.Reported())
+	assert.False(missing.Reported())
+}
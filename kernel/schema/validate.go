@@ -0,0 +1,205 @@
+package schema
+
+import (
+	"errors"
+	"fmt"
+	"mime"
+	"slices"
+	"strings"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+// Providers which require the conversation to start with a user message and
+// reject consecutive assistant messages
+var alternatingProviders = []string{Anthropic, Gemini}
+
+////////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// Validate checks the invariants which every provider expects of a
+// conversation: known roles, non-empty content, well-formed attachments whose
+// data matches their content type, and tool results which answer the tool
+// calls of the preceding assistant message. Tool calls in the last message
+// may be unanswered, since the results are the next message to be sent.
+func Validate(conversation Conversation) error {
+	for i, message := range conversation {
+		if err := validateMessage(i, message); err != nil {
+			return err
+		}
+	}
+	return validateToolPairs(conversation)
+}
+
+// ValidateFor checks the conversation for the invariants of Validate, and the
+// attachment sizes and role ordering required by the named provider.
+func ValidateFor(provider string, conversation Conversation) error {
+	if err := Validate(conversation); err != nil {
+		return err
+	}
+	for i, message := range conversation {
+		for j, block := range message.Content {
+			if block.Attachment == nil {
+				continue
+			}
+			if err := validateAttachmentSize(provider, block.Attachment); err != nil {
+				return ErrBadParameter.Withf("message %d, block %d: %v", i, j, err)
+			}
+		}
+	}
+	if slices.Contains(alternatingProviders, provider) {
+		return validateAlternation(provider, conversation)
+	}
+	return nil
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+func validateMessage(i int, message *Message) error {
+	if message == nil {
+		return ErrBadParameter.Withf("message %d: missing", i)
+	}
+	switch message.Role {
+	case RoleUser, RoleAssistant, RoleSystem, RoleThinking, RoleTool:
+	default:
+		return ErrBadParameter.Withf("message %d: invalid role %q", i, message.Role)
+	}
+
+	// Providers can return an assistant message without content, for example
+	// when the response is blocked, so it is skipped rather than rejected
+	if len(message.Content) == 0 {
+		if message.Role == RoleAssistant {
+			return nil
+		}
+		return ErrBadParameter.Withf("message %d: %s message has no content", i, message.Role)
+	}
+
+	for j, block := range message.Content {
+		if err := validateBlock(message.Role, block); err != nil {
+			return ErrBadParameter.Withf("message %d, block %d: %v", i, j, err)
+		}
+	}
+	return nil
+}
+
+func validateBlock(role string, block ContentBlock) error {
+	n := 0
+	for _, set := range []bool{block.Text != nil, block.Thinking != nil, block.Attachment != nil, block.ToolCall != nil, block.ToolResult != nil, block.Context != nil} {
+		if set {
+			n++
+		}
+	}
+	switch {
+	case n == 0:
+		return errors.New("empty content block")
+	case n > 1:
+		return errors.New("content block has more than one kind of content")
+	case block.ToolCall != nil && role != RoleAssistant:
+		return fmt.Errorf("tool call in %s message", role)
+	case block.ToolCall != nil && block.ToolCall.Name == "":
+		return errors.New("tool call without a name")
+	case block.ToolResult != nil && role != RoleUser && role != RoleTool:
+		return fmt.Errorf("tool result in %s message", role)
+	case block.Context != nil && role != RoleUser:
+		return fmt.Errorf("context in %s message", role)
+	case block.Context != nil && block.Context.Name == "":
+		return errors.New("context without a name")
+	case block.Attachment != nil:
+		return validateAttachment(block.Attachment)
+	}
+	return nil
+}
+
+func validateAttachment(attachment *Attachment) error {
+	if attachment.ContentType == "" {
+		return errors.New("attachment without a content type")
+	}
+	mediatype, _, err := mime.ParseMediaType(attachment.ContentType)
+	if err != nil {
+		return fmt.Errorf("attachment content type %q: %v", attachment.ContentType, err)
+	}
+	if typ, subtype, ok := strings.Cut(mediatype, "/"); !ok || typ == "" || subtype == "" || typ == "*" {
+		return fmt.Errorf("attachment content type %q is not a media type", attachment.ContentType)
+	}
+	if len(attachment.Data) == 0 && attachment.URL == nil {
+		return errors.New("attachment without data or a URL")
+	}
+	return validateContentType(mediatype, attachment.Data)
+}
+
+// validateToolPairs checks each tool result answers a call in the preceding
+// assistant message, and each call is answered by the next message.
+func validateToolPairs(conversation Conversation) error {
+	var calls []ToolCall
+	for i, message := range conversation {
+		if message.Role == RoleSystem || message.Role == RoleThinking {
+			continue
+		}
+
+		// Match results against the outstanding calls
+		for _, block := range message.Content {
+			if block.ToolResult == nil {
+				continue
+			}
+			j := slices.IndexFunc(calls, func(call ToolCall) bool {
+				return toolResultAnswers(call, block.ToolResult)
+			})
+			if j < 0 {
+				return ErrBadParameter.Withf("message %d: tool result %q does not answer a tool call", i, toolResultRef(block.ToolResult))
+			}
+			calls = slices.Delete(calls, j, j+1)
+		}
+		if len(calls) > 0 {
+			return ErrBadParameter.Withf("message %d: tool call %q has no result", i, toolCallRef(calls[0]))
+		}
+
+		// Calls made by this message are answered by the next one
+		if message.Role == RoleAssistant {
+			calls = message.ToolCalls()
+		}
+	}
+	return nil
+}
+
+// validateAlternation checks the conversation starts with a user message and
+// has no consecutive assistant messages. System messages are sent separately
+// and empty assistant messages are not sent, so both are ignored.
+func validateAlternation(provider string, conversation Conversation) error {
+	prev := ""
+	for i, message := range conversation {
+		switch {
+		case message.Role == RoleSystem || message.Role == RoleThinking:
+			continue
+		case message.Role == RoleAssistant && len(message.Content) == 0:
+			continue
+		}
+		role := message.Role
+		if role == RoleTool {
+			role = RoleUser
+		}
+		if prev == "" && role != RoleUser {
+			return ErrBadParameter.Withf("message %d: %q requires the conversation to start with a user message", i, provider)
+		}
+		if prev == RoleAssistant && role == RoleAssistant {
+			return ErrBadParameter.Withf("message %d: %q does not accept consecutive assistant messages", i, provider)
+		}
+		prev = role
+	}
+	return nil
+}
+
+func toolCallRef(call ToolCall) string {
+	if call.ID != "" {
+		return call.ID
+	}
+	return call.Name
+}
+
+func toolResultRef(result *ToolResult) string {
+	if result.ID != "" {
+		return result.ID
+	}
+	return result.Name
+}
@@ -0,0 +1,255 @@
+package schema_test
+
+import (
+	"encoding/json"
+	"net/url"
+	"testing"
+
+	// Packages
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	types "github.com/mutablelogic/go-server/pkg/types"
+	assert "github.com/stretchr/testify/assert"
+)
+
+func validateText(role, text string) *schema.Message {
+	return &schema.Message{Role: role, Content: []schema.ContentBlock{{Text: types.Ptr(text)}}}
+}
+
+func validateCall(id, name string) *schema.Message {
+	return &schema.Message{Role: schema.RoleAssistant, Content: []schema.ContentBlock{{ToolCall: &schema.ToolCall{ID: id, Name: name}}}}
+}
+
+func validateResult(id, name string) *schema.Message {
+	return &schema.Message{Role: schema.RoleUser, Content: []schema.ContentBlock{{ToolResult: &schema.ToolResult{ID: id, Name: name}}}}
+}
+
+func Test_Validate_001(t *testing.T) {
+	// Well-formed conversations are accepted
+	assert := assert.New(t)
+
+	assert.NoError(schema.Validate(nil))
+	assert.NoError(schema.Validate(schema.Conversation{
+		validateText(schema.RoleSystem, "be brief"),
+		validateText(schema.RoleUser, "weather?"),
+		validateCall("1", "weather"),
+		validateResult("1", "weather"),
+		validateText(schema.RoleAssistant, "sunny"),
+	}))
+
+	// Calls in the last message are waiting for their results
+	assert.NoError(schema.Validate(schema.Conversation{
+		validateText(schema.RoleUser, "weather?"),
+		validateCall("1", "weather"),
+	}))
+
+	// Calls without an ID are matched by name
+	assert.NoError(schema.Validate(schema.Conversation{
+		validateText(schema.RoleUser, "weather?"),
+		validateCall("", "weather"),
+		validateResult("", "weather"),
+	}))
+
+	// An empty assistant message is allowed
+	assert.NoError(schema.Validate(schema.Conversation{
+		validateText(schema.RoleUser, "hello"),
+		{Role: schema.RoleAssistant},
+	}))
+}
+
+func Test_Validate_002(t *testing.T) {
+	// Malformed messages are rejected with the message index
+	assert := assert.New(t)
+
+	tests := []schema.Conversation{
+		{nil},
+		{{Role: "robot", Content: []schema.ContentBlock{{Text: types.Ptr("hi")}}}},
+		{{Role: schema.RoleUser}},
+		{{Role: schema.RoleUser, Content: []schema.ContentBlock{{}}}},
+		{{Role: schema.RoleUser, Content: []schema.ContentBlock{{Text: types.Ptr("a"), Thinking: types.Ptr("b")}}}},
+		{{Role: schema.RoleUser, Content: []schema.ContentBlock{{ToolCall: &schema.ToolCall{ID: "1", Name: "weather"}}}}},
+		{validateText(schema.RoleUser, "hi"), {Role: schema.RoleAssistant, Content: []schema.ContentBlock{{ToolResult: &schema.ToolResult{ID: "1"}}}}},
+		{validateText(schema.RoleUser, "hi"), validateCall("1", "")},
+	}
+	for _, conversation := range tests {
+		err := schema.Validate(conversation)
+		if assert.Error(err, conversation) {
+			assert.ErrorIs(err, schema.ErrBadParameter)
+			assert.Contains(err.Error(), "message ")
+		}
+	}
+}
+
+func Test_Validate_003(t *testing.T) {
+	// Attachments need a media type, and either data or a URL
+	assert := assert.New(t)
+
+	u, err := url.Parse("https://example.com/image.png")
+	assert.NoError(err)
+
+	attachment := func(a schema.Attachment) schema.Conversation {
+		return schema.Conversation{{Role: schema.RoleUser, Content: []schema.ContentBlock{{Attachment: &a}}}}
+	}
+	assert.NoError(schema.Validate(attachment(schema.Attachment{ContentType: "image/png", URL: u})))
+	assert.NoError(schema.Validate(attachment(schema.Attachment{ContentType: "text/plain; charset=utf-8", Data: []byte("hi")})))
+	assert.Error(schema.Validate(attachment(schema.Attachment{URL: u})))
+	assert.Error(schema.Validate(attachment(schema.Attachment{ContentType: "png", URL: u})))
+	assert.Error(schema.Validate(attachment(schema.Attachment{ContentType: "*/*", URL: u})))
+	assert.Error(schema.Validate(attachment(schema.Attachment{ContentType: "image/png; =", URL: u})))
+	assert.Error(schema.Validate(attachment(schema.Attachment{ContentType: "image/png"})))
+}
+
+func Test_Validate_004(t *testing.T) {
+	// Tool calls and results are paired
+	assert := assert.New(t)
+
+	// A result without a call
+	assert.ErrorIs(schema.Validate(schema.Conversation{
+		validateText(schema.RoleUser, "hi"),
+		validateText(schema.RoleAssistant, "hello"),
+		validateResult("1", "weather"),
+	}), schema.ErrBadParameter)
+
+	// A result for a different call
+	assert.Error(schema.Validate(schema.Conversation{
+		validateText(schema.RoleUser, "weather?"),
+		validateCall("1", "weather"),
+		validateResult("2", "weather"),
+	}))
+
+	// A call which is not answered before the next message
+	assert.Error(schema.Validate(schema.Conversation{
+		validateText(schema.RoleUser, "weather?"),
+		validateCall("1", "weather"),
+		validateText(schema.RoleUser, "never mind"),
+	}))
+
+	// A result which answers a call twice
+	assert.Error(schema.Validate(schema.Conversation{
+		validateText(schema.RoleUser, "weather?"),
+		validateCall("1", "weather"),
+		{Role: schema.RoleUser, Content: []schema.ContentBlock{
+			{ToolResult: &schema.ToolResult{ID: "1"}},
+			{ToolResult: &schema.ToolResult{ID: "1"}},
+		}},
+	}))
+}
+
+func Test_Validate_005(t *testing.T) {
+	// Some providers require the conversation to alternate
+	assert := assert.New(t)
+
+	assistantFirst := schema.Conversation{
+		validateText(schema.RoleSystem, "be brief"),
+		validateText(schema.RoleAssistant, "hello"),
+		validateText(schema.RoleUser, "hi"),
+	}
+	assert.NoError(schema.ValidateFor(schema.Ollama, assistantFirst))
+	assert.ErrorIs(schema.ValidateFor(schema.Anthropic, assistantFirst), schema.ErrBadParameter)
+
+	consecutive := schema.Conversation{
+		validateText(schema.RoleUser, "hi"),
+		validateText(schema.RoleAssistant, "hello"),
+		validateText(schema.RoleAssistant, "again"),
+	}
+	assert.NoError(schema.ValidateFor(schema.OpenAI, consecutive))
+	assert.Error(schema.ValidateFor(schema.Gemini, consecutive))
+
+	// Empty assistant messages are not sent, so consecutive user messages
+	// are accepted
+	assert.NoError(schema.ValidateFor(schema.Anthropic, schema.Conversation{
+		validateText(schema.RoleUser, "hi"),
+		{Role: schema.RoleAssistant},
+		validateText(schema.RoleUser, "hello?"),
+	}))
+}
+
+func FuzzValidate(f *testing.F) {
+	f.Add(`[{"role":"user","content":[{"text":"hi"}]},{"role":"assistant","content":[{"tool_call":{"id":"1","name":"weather"}}]},{"role":"user","content":[{"tool_result":{"id":"1"}}]}]`)
+	f.Add(`[{"role":"assistant","content":[]},{"role":"user","content":[{"attachment":{"type":"image/png","url":"https://example.com/a.png"}}]}]`)
+	f.Add(`[{"role":"system","content":[{"text":"x"}]},{"role":"user","content":[{"tool_result":{"name":"a"}}]}]`)
+	f.Fuzz(func(t *testing.T, data string) {
+		var conversation schema.Conversation
+		if err := json.Unmarshal([]byte(data), &conversation); err != nil {
+			return
+		}
+
+		// Provider rules only add to the generic rules
+		err := schema.Validate(conversation)
+		for _, provider := range []string{schema.Anthropic, schema.Gemini, schema.OpenAI} {
+			if err != nil && schema.ValidateFor(provider, conversation) == nil {
+				t.Fatalf("%s accepted a conversation which is not valid: %v", provider, err)
+			}
+		}
+
+		// Normalizing a valid conversation keeps it valid
+		if err == nil {
+			for _, provider := range []string{schema.Anthropic, schema.Ollama} {
+				if err := schema.Validate(schema.Normalize(provider, conversation)); err != nil {
+					t.Fatalf("normalized conversation for %s is not valid: %v", provider, err)
+				}
+			}
+		}
+
+		// Every prefix of a valid conversation is valid
+		for _, provider := range []string{"", schema.Anthropic} {
+			if schema.ValidateFor(provider, conversation) != nil {
+				continue
+			}
+			for n := range conversation {
+				if err := schema.ValidateFor(provider, conversation[:n]); err != nil {
+					t.Fatalf("prefix %d of a valid conversation is not valid: %v", n, err)
+				}
+			}
+		}
+	})
+}
+
+func Test_Validate_006(t *testing.T) {
+	// The data of an attachment must match its declared content type
+	assert := assert.New(t)
+	png := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+	jpeg := []byte("\xff\xd8\xff\xe0\x00\x10JFIF")
+
+	attachment := func(a schema.Attachment) schema.Conversation {
+		return schema.Conversation{{Role: schema.RoleUser, Content: []schema.ContentBlock{{Attachment: &a}}}}
+	}
+	assert.NoError(schema.Validate(attachment(schema.Attachment{ContentType: "image/png", Data: png})))
+	assert.NoError(schema.Validate(attachment(schema.Attachment{ContentType: "image/jpg", Data: jpeg})))
+	assert.NoError(schema.Validate(attachment(schema.Attachment{ContentType: "text/csv", Data: []byte("a,b\n1,2\n")})))
+	assert.ErrorContains(schema.Validate(attachment(schema.Attachment{ContentType: "image/png", Data: jpeg})), `declared as "image/png", but the data is "image/jpeg"`)
+	assert.ErrorContains(schema.Validate(attachment(schema.Attachment{ContentType: "image/heic", Data: png})), `but the data is "image/png"`)
+	assert.ErrorIs(schema.Validate(attachment(schema.Attachment{ContentType: "application/pdf", Data: []byte("hello")})), schema.ErrBadParameter)
+}
+
+func Test_Validate_007(t *testing.T) {
+	// Providers limit the size of attachment data
+	assert := assert.New(t)
+	image := append([]byte("\x89PNG\r\n\x1a\n"), make([]byte, 6<<20)...)
+	conversation := schema.Conversation{{Role: schema.RoleUser, Content: []schema.ContentBlock{
+		{Text: types.Ptr("Describe this")},
+		{Attachment: &schema.Attachment{ContentType: "image/png", Data: image}},
+	}}}
+
+	assert.NoError(schema.ValidateFor(schema.Ollama, conversation))
+	assert.NoError(schema.ValidateFor(schema.Mistral, conversation))
+	err := schema.ValidateFor(schema.Anthropic, conversation)
+	assert.ErrorIs(err, schema.ErrBadParameter)
+	assert.ErrorContains(err, "message 0, block 1: image/png attachment is 6.0 MB, which is more than the 5.0 MB accepted by anthropic")
+}
+
+func TestDetectContentType(t *testing.T) {
+	assert := assert.New(t)
+	assert.Equal("image/png", schema.DetectContentType([]byte("\x89PNG\r\n\x1a\n"), "photo.jpg"))
+	assert.Equal("application/pdf", schema.DetectContentType([]byte("%PDF-1.7"), ""))
+	assert.Equal("text/csv; charset=utf-8", schema.DetectContentType([]byte("a,b\n"), "data.csv"))
+	assert.Equal("application/octet-stream", schema.DetectContentType([]byte{0, 1, 2}, ""))
+
+	// The content type is detected when it is not set
+	u, _ := url.Parse("https://example.com/scan.pdf")
+	a := schema.Attachment{URL: u}
+	a.Detect()
+	assert.Equal("application/pdf", a.ContentType)
+	a = schema.Attachment{ContentType: "image/webp", Data: []byte("%PDF-1.7")}
+	a.Detect()
+	assert.Equal("image/webp", a.ContentType)
+}
@@ -0,0 +1,50 @@
+package schema
+
+import (
+	"slices"
+	"strings"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+const (
+	// APIVersion is the current version of the HTTP API, which prefixes the
+	// paths of its routes
+	APIVersion = "v1"
+
+	// APIVersionHeader is sent by a client with the versions of the API it
+	// accepts, in order of preference, and returned by the server with the
+	// version which served the request
+	APIVersionHeader = "API-Version"
+)
+
+// APIVersions are the versions of the HTTP API which are served, oldest first
+var APIVersions = []string{APIVersion}
+
+////////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// NegotiateAPIVersion returns the first version in the value of an
+// API-Version header which is served, where the value is a comma-separated
+// list such as "v2, v1" and the "v" prefix is optional. The versions served
+// default to APIVersions. The newest version served is returned when the
+// value is empty, and an error when none of the versions are served.
+func NegotiateAPIVersion(value string, served ...string) (string, error) {
+	if len(served) == 0 {
+		served = APIVersions
+	}
+	if strings.TrimSpace(value) == "" {
+		return served[len(served)-1], nil
+	}
+	for _, version := range strings.Split(value, ",") {
+		version = strings.ToLower(strings.TrimSpace(version))
+		if version != "" && !strings.HasPrefix(version, "v") {
+			version = "v" + version
+		}
+		if slices.Contains(served, version) {
+			return version, nil
+		}
+	}
+	return "", ErrBadParameter.Withf("unsupported API version %q, expected one of %s", value, strings.Join(served, ", "))
+}
@@ -0,0 +1,40 @@
+package schema_test
+
+import (
+	"testing"
+
+	// Packages
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	assert "github.com/stretchr/testify/assert"
+)
+
+func TestNegotiateAPIVersion(t *testing.T) {
+	assert := assert.New(t)
+
+	for value, expected := range map[string]string{
+		"":          schema.APIVersion,
+		"v1":        "v1",
+		"1":         "v1",
+		" V1 ":      "v1",
+		"v3, v1":    "v1",
+		"v3,, 1,v2": "v1",
+	} {
+		version, err := schema.NegotiateAPIVersion(value)
+		if assert.NoError(err, value) {
+			assert.Equal(expected, version, value)
+		}
+	}
+
+	_, err := schema.NegotiateAPIVersion("v2")
+	assert.ErrorIs(err, schema.ErrBadParameter)
+
+	// Only the versions served are negotiated
+	version, err := schema.NegotiateAPIVersion("v2, v1", "v1", "v2")
+	if assert.NoError(err) {
+		assert.Equal("v2", version)
+	}
+	version, err = schema.NegotiateAPIVersion("", "v1", "v2")
+	if assert.NoError(err) {
+		assert.Equal("v2", version)
+	}
+}
@@ -109,6 +109,9 @@ func callToolResult(res *sdkmcp.CallToolResult) (any, error) {
 	case 0:
 		return nil, nil
 	case 1:
+		if annotations := contentAnnotations(res.Content[0]); len(annotations) > 0 {
+			return annotatedValue{v: contentValue(res.Content[0]), annotations: annotations}, nil
+		}
 		return contentValue(res.Content[0]), nil
 	default:
 		parts := make([]any, len(res.Content))
@@ -143,6 +146,65 @@ func contentValue(c sdkmcp.Content) any {
 	return c
 }
 
+// contentAnnotations maps the MCP annotations of a Content item onto
+// content block annotation keys. The MIME type is included for media
+// and resource content.
+func contentAnnotations(c sdkmcp.Content) map[string]any {
+	var a *sdkmcp.Annotations
+	var mimeType string
+	switch c := c.(type) {
+	case *sdkmcp.TextContent:
+		a = c.Annotations
+	case *sdkmcp.ImageContent:
+		a, mimeType = c.Annotations, c.MIMEType
+	case *sdkmcp.AudioContent:
+		a, mimeType = c.Annotations, c.MIMEType
+	case *sdkmcp.ResourceLink:
+		a, mimeType = c.Annotations, c.MIMEType
+	case *sdkmcp.EmbeddedResource:
+		a = c.Annotations
+		if c.Resource != nil {
+			mimeType = c.Resource.MIMEType
+		}
+	}
+
+	result := make(map[string]any)
+	if a != nil {
+		if len(a.Audience) > 0 {
+			audience := make([]string, len(a.Audience))
+			for i, role := range a.Audience {
+				audience[i] = string(role)
+			}
+			result[schema.AnnotationAudience] = audience
+		}
+		if a.Priority != 0 {
+			result[schema.AnnotationPriority] = a.Priority
+		}
+		if a.LastModified != "" {
+			result[schema.AnnotationLastModified] = a.LastModified
+		}
+	}
+	if len(result) > 0 && mimeType != "" {
+		result[schema.AnnotationContentType] = mimeType
+	}
+	return result
+}
+
+// annotatedValue carries MCP annotations alongside a tool result. It
+// marshals as the wrapped value, and implements schema.Annotator.
+type annotatedValue struct {
+	v           any
+	annotations map[string]any
+}
+
+func (a annotatedValue) MarshalJSON() ([]byte, error) {
+	return json.Marshal(a.v)
+}
+
+func (a annotatedValue) Annotations() map[string]any {
+	return a.annotations
+}
+
 // schemaFromAny re-marshals v (typically map[string]any from JSON) into a
 // resolved *jsonschema.Schema. Malformed schemas are ignored.
 func schemaFromAny(v any) *jsonschema.Schema {
@@ -0,0 +1,52 @@
+package client
+
+import (
+	"encoding/json"
+	"testing"
+
+	// Packages
+	sdkmcp "github.com/modelcontextprotocol/go-sdk/mcp"
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+)
+
+// Test_tool_annotations_001: MCP content annotations are carried with the
+// result, which still marshals as the plain value.
+func Test_tool_annotations_001(t *testing.T) {
+	got, err := callToolResult(&sdkmcp.CallToolResult{
+		Content: []sdkmcp.Content{&sdkmcp.TextContent{
+			Text:        "hello",
+			Annotations: &sdkmcp.Annotations{Audience: []sdkmcp.Role{"user"}, Priority: 0.5},
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	annotator, ok := got.(schema.Annotator)
+	if !ok {
+		t.Fatalf("expected schema.Annotator, got %T", got)
+	}
+	if a := annotator.Annotations(); a[schema.AnnotationPriority] != 0.5 {
+		t.Errorf("unexpected annotations: %v", a)
+	}
+	if data, err := json.Marshal(got); err != nil || string(data) != `"hello"` {
+		t.Errorf("unexpected marshal: %s %v", data, err)
+	}
+
+	block := schema.NewToolResult("call_1", "greet", got)
+	if audience, ok := block.Annotations[schema.AnnotationAudience].([]string); !ok || len(audience) != 1 || audience[0] != "user" {
+		t.Errorf("unexpected block annotations: %v", block.Annotations)
+	}
+}
+
+// Test_tool_annotations_002: content without annotations is returned as-is.
+func Test_tool_annotations_002(t *testing.T) {
+	got, err := callToolResult(&sdkmcp.CallToolResult{
+		Content: []sdkmcp.Content{&sdkmcp.TextContent{Text: "hello"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "hello" {
+		t.Errorf("expected plain string, got %#v", got)
+	}
+}
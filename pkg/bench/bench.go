@@ -0,0 +1,271 @@
+// Package bench measures the latency and throughput of a model by sending
+// requests at a fixed concurrency, and reports the distribution of the time
+// to the first token, the time to the full response and the output tokens
+// per second, with the rate of errors.
+package bench
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	// Packages
+	types "github.com/mutablelogic/go-server/pkg/types"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// Sample is the measurement of one request
+type Sample struct {
+	FirstToken   time.Duration // time to the first token, or zero when the response is not streamed
+	Latency      time.Duration // time to the full response
+	OutputTokens uint          // number of tokens in the response
+}
+
+// RequestFn sends one request and returns its measurement
+type RequestFn func(ctx context.Context) (Sample, error)
+
+// Distribution summarizes durations
+type Distribution struct {
+	Min  time.Duration `json:"min_ns"`
+	Mean time.Duration `json:"mean_ns"`
+	P50  time.Duration `json:"p50_ns"`
+	P90  time.Duration `json:"p90_ns"`
+	P99  time.Duration `json:"p99_ns"`
+	Max  time.Duration `json:"max_ns"`
+}
+
+// Rate summarizes output tokens per second
+type Rate struct {
+	Min  float64 `json:"min"`
+	Mean float64 `json:"mean"`
+	P50  float64 `json:"p50"`
+	Max  float64 `json:"max"`
+}
+
+// Result is the report for one model
+type Result struct {
+	Provider        string        `json:"provider,omitempty"`
+	Model           string        `json:"model"`
+	Concurrency     uint          `json:"concurrency"`
+	Requests        uint          `json:"requests"`
+	Errors          uint          `json:"errors"`
+	ErrorRate       float64       `json:"error_rate"`
+	Duration        time.Duration `json:"duration_ns"`
+	Throughput      float64       `json:"requests_per_second"`
+	FirstToken      *Distribution `json:"first_token,omitempty"`
+	Latency         *Distribution `json:"latency,omitempty"`
+	TokensPerSecond *Rate         `json:"tokens_per_second,omitempty"`
+	ErrorMessages   []string      `json:"error_messages,omitempty"`
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+// Maximum number of distinct error messages in a result
+const maxErrorMessages = 5
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// Run sends the number of requests with fn, with at most concurrency
+// requests at once, and returns the result. Requests which are not sent
+// because the context is cancelled are not counted.
+func Run(ctx context.Context, concurrency, requests uint, fn RequestFn) Result {
+	concurrency = max(min(concurrency, requests), 1)
+	result := Result{Concurrency: concurrency}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	var samples []Sample
+	queue := make(chan struct{}, requests)
+	for range requests {
+		queue <- struct{}{}
+	}
+	close(queue)
+
+	// Send the requests
+	start := time.Now()
+	for range concurrency {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range queue {
+				if ctx.Err() != nil {
+					return
+				}
+				sample, err := fn(ctx)
+				mu.Lock()
+				result.Requests++
+				if err != nil {
+					result.Errors++
+					if message := err.Error(); len(result.ErrorMessages) < maxErrorMessages && !slices.Contains(result.ErrorMessages, message) {
+						result.ErrorMessages = append(result.ErrorMessages, message)
+					}
+				} else {
+					samples = append(samples, sample)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	result.Duration = time.Since(start)
+
+	// Summarize the samples
+	if result.Requests > 0 {
+		result.ErrorRate = float64(result.Errors) / float64(result.Requests)
+	}
+	if result.Duration > 0 {
+		result.Throughput = float64(len(samples)) / result.Duration.Seconds()
+	}
+	result.summarize(samples)
+
+	// Return the result
+	return result
+}
+
+// NewDistribution returns the distribution of the values, or nil when there
+// are no values
+func NewDistribution(values []time.Duration) *Distribution {
+	if len(values) == 0 {
+		return nil
+	}
+	sorted := slices.Sorted(slices.Values(values))
+	var total time.Duration
+	for _, value := range sorted {
+		total += value
+	}
+	return &Distribution{
+		Min:  sorted[0],
+		Mean: total / time.Duration(len(sorted)),
+		P50:  percentile(sorted, 50),
+		P90:  percentile(sorted, 90),
+		P99:  percentile(sorted, 99),
+		Max:  sorted[len(sorted)-1],
+	}
+}
+
+// NewRate returns the summary of the rates, or nil when there are no rates
+func NewRate(values []float64) *Rate {
+	if len(values) == 0 {
+		return nil
+	}
+	sorted := slices.Sorted(slices.Values(values))
+	var total float64
+	for _, value := range sorted {
+		total += value
+	}
+	return &Rate{
+		Min:  sorted[0],
+		Mean: total / float64(len(sorted)),
+		P50:  percentile(sorted, 50),
+		Max:  sorted[len(sorted)-1],
+	}
+}
+
+// Markdown writes the results as markdown tables
+func Markdown(w io.Writer, results []Result) error {
+	var b strings.Builder
+	b.WriteString("| Model | Requests | Errors | Req/s | TTFT p50 | TTFT p90 | TTFT p99 | Latency p50 | Latency p90 | Latency p99 | Tokens/s p50 | Tokens/s mean |\n")
+	b.WriteString("|---|--:|--:|--:|--:|--:|--:|--:|--:|--:|--:|--:|\n")
+	for _, result := range results {
+		fmt.Fprintf(&b, "| %s | %d | %d (%.1f%%) | %.2f | %s | %s | %s | %s | %s | %s | %s | %s |\n",
+			result.name(), result.Requests, result.Errors, result.ErrorRate*100, result.Throughput,
+			distribution(result.FirstToken, func(d Distribution) time.Duration { return d.P50 }),
+			distribution(result.FirstToken, func(d Distribution) time.Duration { return d.P90 }),
+			distribution(result.FirstToken, func(d Distribution) time.Duration { return d.P99 }),
+			distribution(result.Latency, func(d Distribution) time.Duration { return d.P50 }),
+			distribution(result.Latency, func(d Distribution) time.Duration { return d.P90 }),
+			distribution(result.Latency, func(d Distribution) time.Duration { return d.P99 }),
+			rate(result.TokensPerSecond, func(r Rate) float64 { return r.P50 }),
+			rate(result.TokensPerSecond, func(r Rate) float64 { return r.Mean }),
+		)
+	}
+
+	// Add the errors for each model
+	for _, result := range results {
+		if len(result.ErrorMessages) == 0 {
+			continue
+		}
+		fmt.Fprintf(&b, "\nErrors for %s:\n\n", result.name())
+		for _, message := range result.ErrorMessages {
+			fmt.Fprintf(&b, "- %s\n", strings.ReplaceAll(message, "\n", " "))
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// STRINGIFY
+
+func (r Result) String() string {
+	return types.Stringify(r)
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// name returns the model, with the provider when it is set
+func (r Result) name() string {
+	if r.Provider != "" {
+		return r.Provider + "/" + r.Model
+	}
+	return r.Model
+}
+
+// summarize sets the distributions of the result from the samples
+func (r *Result) summarize(samples []Sample) {
+	var first, latency []time.Duration
+	var rates []float64
+	for _, sample := range samples {
+		latency = append(latency, sample.Latency)
+		if sample.FirstToken > 0 {
+			first = append(first, sample.FirstToken)
+		}
+
+		// Tokens are generated after the first token, when it is known
+		generation := sample.Latency
+		if sample.FirstToken > 0 && sample.FirstToken < sample.Latency {
+			generation -= sample.FirstToken
+		}
+		if sample.OutputTokens > 0 && generation > 0 {
+			rates = append(rates, float64(sample.OutputTokens)/generation.Seconds())
+		}
+	}
+	r.FirstToken = NewDistribution(first)
+	r.Latency = NewDistribution(latency)
+	r.TokensPerSecond = NewRate(rates)
+}
+
+// percentile returns the value at the percentile of sorted values, with the
+// nearest-rank method
+func percentile[T time.Duration | float64](sorted []T, p float64) T {
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	return sorted[min(max(rank-1, 0), len(sorted)-1)]
+}
+
+// distribution formats a field of a distribution, or a dash when there is
+// no distribution
+func distribution(d *Distribution, field func(Distribution) time.Duration) string {
+	if d == nil {
+		return "-"
+	}
+	return field(*d).Round(time.Millisecond).String()
+}
+
+// rate formats a field of a rate, or a dash when there is no rate
+func rate(r *Rate, field func(Rate) float64) string {
+	if r == nil {
+		return "-"
+	}
+	return fmt.Sprintf("%.1f", field(*r))
+}
@@ -0,0 +1,114 @@
+package bench_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	// Packages
+	bench "github.com/mutablelogic/go-llm/pkg/bench"
+	assert "github.com/stretchr/testify/assert"
+)
+
+func TestDistribution(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Nil(bench.NewDistribution(nil))
+
+	var values []time.Duration
+	for i := 100; i >= 1; i-- {
+		values = append(values, time.Duration(i)*time.Millisecond)
+	}
+	d := bench.NewDistribution(values)
+	if assert.NotNil(d) {
+		assert.Equal(time.Millisecond, d.Min)
+		assert.Equal(50*time.Millisecond+500*time.Microsecond, d.Mean)
+		assert.Equal(50*time.Millisecond, d.P50)
+		assert.Equal(90*time.Millisecond, d.P90)
+		assert.Equal(99*time.Millisecond, d.P99)
+		assert.Equal(100*time.Millisecond, d.Max)
+	}
+
+	// The values are not reordered
+	assert.Equal(100*time.Millisecond, values[0])
+}
+
+func TestRate(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Nil(bench.NewRate(nil))
+	r := bench.NewRate([]float64{30, 10, 20})
+	if assert.NotNil(r) {
+		assert.Equal(10.0, r.Min)
+		assert.Equal(20.0, r.Mean)
+		assert.Equal(20.0, r.P50)
+		assert.Equal(30.0, r.Max)
+	}
+}
+
+func TestRun(t *testing.T) {
+	assert := assert.New(t)
+
+	var running, peak, count atomic.Int32
+	result := bench.Run(context.Background(), 3, 10, func(ctx context.Context) (bench.Sample, error) {
+		n := running.Add(1)
+		defer running.Add(-1)
+		for {
+			if p := peak.Load(); n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+		if count.Add(1)%5 == 0 {
+			return bench.Sample{}, errors.New("rate limited")
+		}
+		return bench.Sample{FirstToken: 100 * time.Millisecond, Latency: 600 * time.Millisecond, OutputTokens: 50}, nil
+	})
+
+	assert.Equal(uint(10), result.Requests)
+	assert.Equal(uint(2), result.Errors)
+	assert.Equal(0.2, result.ErrorRate)
+	assert.Equal([]string{"rate limited"}, result.ErrorMessages)
+	assert.LessOrEqual(peak.Load(), int32(3))
+	if assert.NotNil(result.FirstToken) && assert.NotNil(result.Latency) && assert.NotNil(result.TokensPerSecond) {
+		assert.Equal(100*time.Millisecond, result.FirstToken.P50)
+		assert.Equal(600*time.Millisecond, result.Latency.Max)
+
+		// Tokens per second are measured after the first token
+		assert.Equal(100.0, result.TokensPerSecond.Mean)
+	}
+}
+
+func TestRunCancelled(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	result := bench.Run(ctx, 1, 10, func(ctx context.Context) (bench.Sample, error) {
+		cancel()
+		return bench.Sample{Latency: time.Second}, nil
+	})
+	assert.Equal(uint(1), result.Requests)
+	assert.Nil(result.FirstToken)
+	assert.Nil(result.TokensPerSecond)
+}
+
+func TestMarkdown(t *testing.T) {
+	assert := assert.New(t)
+
+	var b strings.Builder
+	assert.NoError(bench.Markdown(&b, []bench.Result{{
+		Provider:      "ollama",
+		Model:         "llama3.2",
+		Requests:      4,
+		Errors:        1,
+		ErrorRate:     0.25,
+		Latency:       bench.NewDistribution([]time.Duration{time.Second}),
+		ErrorMessages: []string{"timeout"},
+	}}))
+	assert.Contains(b.String(), "| ollama/llama3.2 | 4 | 1 (25.0%) |")
+	assert.Contains(b.String(), "| 1s | 1s | 1s | - | - |")
+	assert.Contains(b.String(), "Errors for ollama/llama3.2:\n\n- timeout\n")
+}
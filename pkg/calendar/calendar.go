@@ -0,0 +1,354 @@
+package calendar
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	// Packages
+	uuid "github.com/google/uuid"
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// Client reads events from a calendar, and creates events on a CalDAV
+// calendar. A URL with the webcal scheme, or whose path ends in .ics, is an
+// ICS feed; other URLs are CalDAV calendar collections.
+type Client struct {
+	url         *url.URL
+	feed        bool
+	client      *http.Client
+	credentials CredentialsFn
+}
+
+// Opt configures a Client
+type Opt func(*Client) error
+
+// Credentials authenticate requests to a calendar, with either a username
+// and password or a bearer token
+type Credentials struct {
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+	Token    string `json:"token,omitempty"`
+}
+
+// CredentialsFn returns the credentials for a calendar, or nil if requests
+// are not authenticated. It is called for each request, so credentials can
+// be changed while the client is in use.
+type CredentialsFn func(ctx context.Context) (*Credentials, error)
+
+// Period is a span of time
+type Period struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
+
+// multistatus is the part of a CalDAV REPORT response which contains the
+// calendar data of each event
+type multistatus struct {
+	Responses []struct {
+		Href     string `xml:"href"`
+		Propstat []struct {
+			Status string `xml:"status"`
+			Data   string `xml:"prop>calendar-data"`
+		} `xml:"propstat"`
+	} `xml:"response"`
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+const (
+	contentTypeCalendar = "text/calendar; charset=utf-8"
+	contentTypeXML      = "application/xml; charset=utf-8"
+	maxCalendarSize     = 16 << 20
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// LIFECYCLE
+
+// New returns a client for the calendar at a URL
+func New(rawurl string, opts ...Opt) (*Client, error) {
+	u, err := url.Parse(strings.TrimSpace(rawurl))
+	if err != nil {
+		return nil, schema.ErrBadParameter.Withf("calendar url: %v", err)
+	}
+	c := &Client{url: u, client: http.DefaultClient}
+	switch strings.ToLower(u.Scheme) {
+	case "webcal":
+		u.Scheme, c.feed = "https", true
+	case "http", "https":
+		c.feed = strings.EqualFold(path.Ext(u.Path), ".ics")
+	default:
+		return nil, schema.ErrBadParameter.Withf("calendar url: scheme %q not supported", u.Scheme)
+	}
+	if u.Host == "" {
+		return nil, schema.ErrBadParameter.With("calendar url: missing host")
+	}
+	for _, opt := range opts {
+		if err := opt(c); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// WithClient sets the HTTP client which makes requests to the calendar
+func WithClient(client *http.Client) Opt {
+	return func(c *Client) error {
+		if client == nil {
+			return schema.ErrBadParameter.With("client is required")
+		}
+		c.client = client
+		return nil
+	}
+}
+
+// WithCredentials sets the function which returns credentials for requests
+func WithCredentials(fn CredentialsFn) Opt {
+	return func(c *Client) error {
+		c.credentials = fn
+		return nil
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// URL returns the URL of the calendar. The URL of a webcal feed has the https
+// scheme.
+func (c *Client) URL() string {
+	return c.url.String()
+}
+
+// ReadOnly returns true if events cannot be created on the calendar
+func (c *Client) ReadOnly() bool {
+	return c.feed
+}
+
+// Events returns the events which overlap the period from start to end, in
+// order of their start times
+func (c *Client) Events(ctx context.Context, start, end time.Time) ([]Event, error) {
+	if !end.After(start) {
+		return nil, schema.ErrBadParameter.With("end must be after start")
+	}
+
+	var events []Event
+	var err error
+	if c.feed {
+		events, err = c.readFeed(ctx)
+	} else {
+		events, err = c.query(ctx, start, end)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Filter the events, since a feed contains all events and servers may
+	// return the whole of a recurring event
+	events = slices.DeleteFunc(events, func(event Event) bool {
+		return !event.Overlaps(start, end)
+	})
+	slices.SortStableFunc(events, func(a, b Event) int {
+		return a.Start.Compare(b.Start)
+	})
+	return events, nil
+}
+
+// FreeBusy returns the busy periods between start and end, merging events
+// which overlap. Cancelled and transparent events are not busy.
+func (c *Client) FreeBusy(ctx context.Context, start, end time.Time) ([]Period, error) {
+	events, err := c.Events(ctx, start, end)
+	if err != nil {
+		return nil, err
+	}
+	return Busy(events, start, end), nil
+}
+
+// Create creates an event on a CalDAV calendar, and returns it. An event
+// without a UID is given one. An error which wraps schema.ErrConflict is
+// returned if an event with the UID already exists.
+func (c *Client) Create(ctx context.Context, event Event) (*Event, error) {
+	if c.feed {
+		return nil, schema.ErrNotImplemented.With("events cannot be created on an ICS feed")
+	}
+	if strings.TrimSpace(event.Summary) == "" {
+		return nil, schema.ErrBadParameter.With("summary is required")
+	} else if !event.End.After(event.Start) {
+		return nil, schema.ErrBadParameter.With("end must be after start")
+	}
+	if event.UID == "" {
+		event.UID = uuid.NewString()
+	}
+
+	var body bytes.Buffer
+	if err := Encode(&body, event); err != nil {
+		return nil, err
+	}
+	resp, err := c.do(ctx, http.MethodPut, c.url.JoinPath(url.PathEscape(event.UID)+".ics"), contentTypeCalendar, &body, func(req *http.Request) {
+		req.Header.Set("If-None-Match", "*")
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusCreated, http.StatusNoContent, http.StatusOK:
+		return &event, nil
+	case http.StatusPreconditionFailed:
+		return nil, schema.ErrConflict.Withf("event %q already exists", event.UID)
+	default:
+		return nil, fmt.Errorf("%s: %s", c.url.Host, resp.Status)
+	}
+}
+
+// Busy returns the periods between start and end in which events are busy,
+// merging events which overlap
+func Busy(events []Event, start, end time.Time) []Period {
+	var periods []Period
+	for _, event := range events {
+		if event.Transparent || event.Status == "CANCELLED" || !event.Overlaps(start, end) || !event.End.After(event.Start) {
+			continue
+		}
+		periods = append(periods, Period{Start: maxTime(event.Start, start), End: minTime(event.End, end)})
+	}
+	slices.SortFunc(periods, func(a, b Period) int {
+		return a.Start.Compare(b.Start)
+	})
+
+	var merged []Period
+	for _, period := range periods {
+		if n := len(merged); n > 0 && !period.Start.After(merged[n-1].End) {
+			merged[n-1].End = maxTime(merged[n-1].End, period.End)
+		} else {
+			merged = append(merged, period)
+		}
+	}
+	return merged
+}
+
+// Free returns the periods between start and end which are not busy
+func Free(busy []Period, start, end time.Time) []Period {
+	var free []Period
+	for _, period := range busy {
+		if period.Start.After(start) {
+			free = append(free, Period{Start: start, End: period.Start})
+		}
+		start = maxTime(start, period.End)
+	}
+	if end.After(start) {
+		free = append(free, Period{Start: start, End: end})
+	}
+	return free
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// readFeed returns all the events in an ICS feed
+func (c *Client) readFeed(ctx context.Context) ([]Event, error) {
+	resp, err := c.do(ctx, http.MethodGet, c.url, "", nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: %s", c.url.Host, resp.Status)
+	}
+	return Parse(io.LimitReader(resp.Body, maxCalendarSize))
+}
+
+// query returns the events in a CalDAV calendar between start and end, with
+// recurring events expanded by the server
+func (c *Client) query(ctx context.Context, start, end time.Time) ([]Event, error) {
+	from, to := start.UTC().Format(formatUTC), end.UTC().Format(formatUTC)
+	body := strings.NewReader(`<?xml version="1.0" encoding="utf-8"?>` +
+		`<C:calendar-query xmlns:D="DAV:" xmlns:C="urn:ietf:params:xml:ns:caldav">` +
+		`<D:prop><C:calendar-data><C:expand start="` + from + `" end="` + to + `"/></C:calendar-data></D:prop>` +
+		`<C:filter><C:comp-filter name="VCALENDAR"><C:comp-filter name="VEVENT">` +
+		`<C:time-range start="` + from + `" end="` + to + `"/>` +
+		`</C:comp-filter></C:comp-filter></C:filter>` +
+		`</C:calendar-query>`)
+	resp, err := c.do(ctx, "REPORT", c.url, contentTypeXML, body, func(req *http.Request) {
+		req.Header.Set("Depth", "1")
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusMultiStatus {
+		return nil, fmt.Errorf("%s: %s", c.url.Host, resp.Status)
+	}
+
+	var result multistatus
+	if err := xml.NewDecoder(io.LimitReader(resp.Body, maxCalendarSize)).Decode(&result); err != nil {
+		return nil, fmt.Errorf("calendar query: %w", err)
+	}
+	var events []Event
+	for _, response := range result.Responses {
+		for _, propstat := range response.Propstat {
+			if propstat.Data == "" {
+				continue
+			}
+			parsed, err := Parse(strings.NewReader(propstat.Data))
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", response.Href, err)
+			}
+			events = append(events, parsed...)
+		}
+	}
+	return events, nil
+}
+
+// do makes an authenticated request to the calendar
+func (c *Client) do(ctx context.Context, method string, u *url.URL, contentType string, body io.Reader, fn func(*http.Request)) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if fn != nil {
+		fn(req)
+	}
+	if c.credentials != nil {
+		credentials, err := c.credentials(ctx)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case credentials == nil:
+			break
+		case credentials.Token != "":
+			req.Header.Set("Authorization", "Bearer "+credentials.Token)
+		case credentials.Username != "":
+			req.SetBasicAuth(credentials.Username, credentials.Password)
+		}
+	}
+	return c.client.Do(req)
+}
+
+func minTime(a, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+	return b
+}
+
+func maxTime(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
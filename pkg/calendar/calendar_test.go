@@ -0,0 +1,304 @@
+package calendar_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	// Packages
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	calendar "github.com/mutablelogic/go-llm/pkg/calendar"
+	assert "github.com/stretchr/testify/assert"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// HELPERS
+
+const ics = "BEGIN:VCALENDAR\r\n" +
+	"VERSION:2.0\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:standup@example.com\r\n" +
+	"SUMMARY:Stand-up\\, daily\r\n" +
+	"DTSTART;TZID=Europe/Berlin:20260105T090000\r\n" +
+	"DURATION:PT30M\r\n" +
+	"BEGIN:VALARM\r\n" +
+	"SUMMARY:Reminder\r\n" +
+	"END:VALARM\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:review@example.com\r\n" +
+	"SUMMARY:Review\r\n" +
+	"DESCRIPTION:First line\\nsecond line which is long enough to be folded acr\r\n" +
+	" oss lines\r\n" +
+	"DTSTART:20260105T081500Z\r\n" +
+	"DTEND:20260105T093000Z\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:holiday@example.com\r\n" +
+	"SUMMARY:Holiday\r\n" +
+	"DTSTART;VALUE=DATE:20260106\r\n" +
+	"TRANSP:TRANSPARENT\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:lunch@example.com\r\n" +
+	"SUMMARY:Lunch\r\n" +
+	"STATUS:CANCELLED\r\n" +
+	"DTSTART:20260105T120000Z\r\n" +
+	"DTEND:20260105T130000Z\r\n" +
+	"END:VEVENT\r\n" +
+	"END:VCALENDAR\r\n"
+
+// server returns a server which serves the calendar as a feed at /feed.ics,
+// and as a CalDAV collection at /dav/, and records the events put to it
+func server(t *testing.T, put map[string]string) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, password, ok := r.BasicAuth(); !ok || user != "user" || password != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/feed.ics":
+			w.Header().Set("Content-Type", "text/calendar")
+			fmt.Fprint(w, ics)
+		case r.Method == "REPORT" && r.URL.Path == "/dav/":
+			body, _ := io.ReadAll(r.Body)
+			if r.Header.Get("Depth") != "1" || !bytes.Contains(body, []byte(`<C:time-range start="20260105T000000Z" end="20260106T000000Z"/>`)) {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			var data strings.Builder
+			assert.NoError(t, xmlEscape(&data, ics))
+			w.WriteHeader(http.StatusMultiStatus)
+			fmt.Fprintf(w, `<?xml version="1.0"?><d:multistatus xmlns:d="DAV:" xmlns:cal="urn:ietf:params:xml:ns:caldav">`+
+				`<d:response><d:href>/dav/all.ics</d:href><d:propstat><d:prop><cal:calendar-data>%s</cal:calendar-data></d:prop><d:status>HTTP/1.1 200 OK</d:status></d:propstat></d:response>`+
+				`</d:multistatus>`, data.String())
+		case r.Method == http.MethodPut && strings.HasPrefix(r.URL.Path, "/dav/"):
+			if _, exists := put[r.URL.Path]; exists && r.Header.Get("If-None-Match") == "*" {
+				w.WriteHeader(http.StatusPreconditionFailed)
+				return
+			}
+			body, _ := io.ReadAll(r.Body)
+			put[r.URL.Path] = string(body)
+			w.WriteHeader(http.StatusCreated)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func xmlEscape(w io.Writer, text string) error {
+	return xml.EscapeText(w, []byte(text))
+}
+
+// utc returns periods with their times in UTC, for comparison
+func utc(periods []calendar.Period) []calendar.Period {
+	for i := range periods {
+		periods[i].Start, periods[i].End = periods[i].Start.UTC(), periods[i].End.UTC()
+	}
+	return periods
+}
+
+func credentials(context.Context) (*calendar.Credentials, error) {
+	return &calendar.Credentials{Username: "user", Password: "secret"}, nil
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// TESTS
+
+func TestParse(t *testing.T) {
+	assert := assert.New(t)
+	events, err := calendar.Parse(strings.NewReader(ics))
+	if !assert.NoError(err) || !assert.Len(events, 4) {
+		return
+	}
+
+	berlin, _ := time.LoadLocation("Europe/Berlin")
+	assert.Equal("standup@example.com", events[0].UID)
+	assert.Equal("Stand-up, daily", events[0].Summary)
+	assert.True(events[0].Start.Equal(time.Date(2026, 1, 5, 9, 0, 0, 0, berlin)))
+	assert.Equal(30*time.Minute, events[0].End.Sub(events[0].Start))
+
+	assert.Equal("First line\nsecond line which is long enough to be folded across lines", events[1].Description)
+	assert.Equal(time.Date(2026, 1, 5, 9, 30, 0, 0, time.UTC), events[1].End)
+
+	assert.True(events[2].AllDay)
+	assert.True(events[2].Transparent)
+	assert.Equal(time.Date(2026, 1, 7, 0, 0, 0, 0, time.UTC), events[2].End)
+
+	assert.Equal("CANCELLED", events[3].Status)
+
+	// Lines without a value are an error
+	_, err = calendar.Parse(strings.NewReader("BEGIN:VEVENT\r\nSUMMARY\r\n"))
+	assert.Error(err)
+}
+
+func TestEncode(t *testing.T) {
+	assert := assert.New(t)
+	event := calendar.Event{
+		UID:         "a@example.com",
+		Summary:     "Planning; quarterly, with notes",
+		Description: strings.Repeat("é", 60) + "\nend",
+		Start:       time.Date(2026, 1, 5, 10, 0, 0, 0, time.UTC),
+		End:         time.Date(2026, 1, 5, 11, 0, 0, 0, time.UTC),
+	}
+
+	var buf bytes.Buffer
+	if !assert.NoError(calendar.Encode(&buf, event)) {
+		return
+	}
+	for _, line := range strings.Split(strings.TrimSuffix(buf.String(), "\r\n"), "\r\n") {
+		assert.LessOrEqual(len(line), 75)
+	}
+	assert.Contains(buf.String(), "SUMMARY:Planning\\; quarterly\\, with notes\r\n")
+
+	// Events are read back unchanged
+	events, err := calendar.Parse(&buf)
+	if assert.NoError(err) && assert.Len(events, 1) {
+		assert.Equal(event, events[0])
+	}
+}
+
+func TestBusy(t *testing.T) {
+	assert := assert.New(t)
+	events, err := calendar.Parse(strings.NewReader(ics))
+	if !assert.NoError(err) {
+		return
+	}
+
+	// Overlapping events are merged, and cancelled and transparent events are
+	// free
+	start := time.Date(2026, 1, 5, 8, 0, 0, 0, time.UTC)
+	end := time.Date(2026, 1, 5, 18, 0, 0, 0, time.UTC)
+	busy := calendar.Busy(events, start, end)
+	assert.Equal([]calendar.Period{
+		{Start: start, End: time.Date(2026, 1, 5, 9, 30, 0, 0, time.UTC)},
+	}, utc(busy))
+	assert.Equal([]calendar.Period{
+		{Start: time.Date(2026, 1, 5, 9, 30, 0, 0, time.UTC), End: end},
+	}, utc(calendar.Free(busy, start, end)))
+}
+
+func TestFeed(t *testing.T) {
+	assert := assert.New(t)
+	server := server(t, nil)
+
+	client, err := calendar.New(server.URL+"/feed.ics", calendar.WithCredentials(credentials))
+	if !assert.NoError(err) {
+		return
+	}
+	assert.True(client.ReadOnly())
+
+	// Events are filtered to the period, and sorted
+	events, err := client.Events(context.Background(), time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC), time.Date(2026, 1, 6, 0, 0, 0, 0, time.UTC))
+	if assert.NoError(err) && assert.Len(events, 3) {
+		assert.Equal("standup@example.com", events[0].UID)
+		assert.Equal("review@example.com", events[1].UID)
+		assert.Equal("lunch@example.com", events[2].UID)
+	}
+
+	// Events cannot be created in a feed
+	_, err = client.Create(context.Background(), calendar.Event{Summary: "x", Start: time.Now(), End: time.Now().Add(time.Hour)})
+	assert.ErrorIs(err, schema.ErrNotImplemented)
+
+	// Requests without credentials fail
+	client, _ = calendar.New(server.URL + "/feed.ics")
+	_, err = client.Events(context.Background(), time.Now(), time.Now().Add(time.Hour))
+	assert.Error(err)
+
+	// Other schemes are not supported
+	_, err = calendar.New("ftp://example.com/feed.ics")
+	assert.ErrorIs(err, schema.ErrBadParameter)
+	client, err = calendar.New("webcal://example.com/feed")
+	if assert.NoError(err) {
+		assert.True(client.ReadOnly())
+		assert.Equal("https://example.com/feed", client.URL())
+	}
+}
+
+func TestCalDAV(t *testing.T) {
+	assert := assert.New(t)
+	put := map[string]string{}
+	server := server(t, put)
+
+	client, err := calendar.New(server.URL+"/dav/", calendar.WithCredentials(credentials))
+	if !assert.NoError(err) {
+		return
+	}
+	assert.False(client.ReadOnly())
+
+	events, err := client.Events(context.Background(), time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC), time.Date(2026, 1, 6, 0, 0, 0, 0, time.UTC))
+	if assert.NoError(err) {
+		assert.Len(events, 3)
+	}
+
+	// Events are created with a new UID, and cannot be created twice
+	event, err := client.Create(context.Background(), calendar.Event{
+		Summary: "Lunch with Sam",
+		Start:   time.Date(2026, 1, 5, 12, 0, 0, 0, time.UTC),
+		End:     time.Date(2026, 1, 5, 13, 0, 0, 0, time.UTC),
+	})
+	if assert.NoError(err) && assert.NotEmpty(event.UID) {
+		assert.Contains(put["/dav/"+event.UID+".ics"], "SUMMARY:Lunch with Sam\r\n")
+		_, err = client.Create(context.Background(), *event)
+		assert.ErrorIs(err, schema.ErrConflict)
+	}
+
+	_, err = client.Create(context.Background(), calendar.Event{Summary: "Backwards", Start: time.Now(), End: time.Now().Add(-time.Hour)})
+	assert.ErrorIs(err, schema.ErrBadParameter)
+}
+
+func TestTools(t *testing.T) {
+	assert := assert.New(t)
+	put := map[string]string{}
+	server := server(t, put)
+
+	// The tool which creates events is only returned when writing is enabled
+	feed, _ := calendar.New(server.URL+"/feed.ics", calendar.WithCredentials(credentials))
+	tools, err := calendar.NewTools(feed, true)
+	if assert.NoError(err) {
+		assert.Len(tools, 2)
+	}
+	dav, _ := calendar.New(server.URL+"/dav/", calendar.WithCredentials(credentials))
+	tools, err = calendar.NewTools(dav, true)
+	if !assert.NoError(err) || !assert.Len(tools, 3) {
+		return
+	}
+	assert.Equal("calendar_events", tools[0].Name())
+	assert.Equal("calendar_freebusy", tools[1].Name())
+	assert.Equal("calendar_create_event", tools[2].Name())
+	assert.False(tools[2].Meta().ReadOnlyHint)
+
+	result, err := tools[1].Run(context.Background(), json.RawMessage(`{"start":"2026-01-05T00:00:00Z","end":"2026-01-06T00:00:00Z"}`))
+	if assert.NoError(err) {
+		var response struct {
+			Busy []calendar.Period `json:"busy"`
+			Free []calendar.Period `json:"free"`
+		}
+		data, _ := json.Marshal(result)
+		assert.NoError(json.Unmarshal(data, &response))
+		assert.Equal([]calendar.Period{
+			{Start: time.Date(2026, 1, 5, 8, 0, 0, 0, time.UTC), End: time.Date(2026, 1, 5, 9, 30, 0, 0, time.UTC)},
+		}, utc(response.Busy))
+		assert.Equal([]calendar.Period{
+			{Start: time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC), End: time.Date(2026, 1, 5, 8, 0, 0, 0, time.UTC)},
+			{Start: time.Date(2026, 1, 5, 9, 30, 0, 0, time.UTC), End: time.Date(2026, 1, 6, 0, 0, 0, 0, time.UTC)},
+		}, utc(response.Free))
+	}
+
+	result, err = tools[2].Run(context.Background(), json.RawMessage(`{"summary":"Call","start":"2026-01-05T14:00:00Z","end":"2026-01-05T14:30:00Z"}`))
+	if assert.NoError(err) {
+		assert.Equal("Call", result.(*calendar.Event).Summary)
+		assert.Len(put, 1)
+	}
+}
@@ -0,0 +1,305 @@
+// Package calendar reads and writes events in iCalendar calendars, which are
+// either published as an ICS feed or served by a CalDAV server. Feeds are
+// read-only, and events are created on CalDAV calendars. The package also
+// provides tools which list events, query free and busy time and create
+// events, for scheduling assistants.
+package calendar
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// Event is an event in a calendar. All-day events start at midnight on their
+// first day and end at midnight after their last day.
+type Event struct {
+	UID         string    `json:"uid" help:"Unique identifier of the event"`
+	Summary     string    `json:"summary" help:"Title of the event"`
+	Description string    `json:"description,omitempty" help:"Description of the event"`
+	Location    string    `json:"location,omitempty" help:"Location of the event"`
+	Start       time.Time `json:"start" help:"Start time of the event"`
+	End         time.Time `json:"end" help:"End time of the event"`
+	AllDay      bool      `json:"all_day,omitempty" help:"Whether the event lasts all day"`
+	Status      string    `json:"status,omitempty" help:"Status of the event, which is TENTATIVE, CONFIRMED or CANCELLED"`
+	Transparent bool      `json:"transparent,omitempty" help:"Whether the event does not block time when free and busy time is queried"`
+}
+
+// property is a content line, with its name, parameters and value
+type property struct {
+	name   string
+	params map[string]string
+	value  string
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+const (
+	prodID        = "-//mutablelogic//go-llm//EN"
+	formatUTC     = "20060102T150405Z"
+	formatLocal   = "20060102T150405"
+	formatDate    = "20060102"
+	maxLineOctets = 75
+)
+
+var (
+	reDuration = regexp.MustCompile(`^([+-])?P(?:(\d+)W)?(?:(\d+)D)?(?:T(?:(\d+)H)?(?:(\d+)M)?(?:(\d+)S)?)?$`)
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// Parse returns the events in an iCalendar stream, which may contain more
+// than one calendar. Recurring events are not expanded, so only their first
+// occurrence is returned. Times in an unknown time zone, and floating times,
+// are read as UTC.
+func Parse(r io.Reader) ([]Event, error) {
+	var events []Event
+	var event *Event
+	var duration time.Duration
+	var depth int
+	lines, err := unfold(r)
+	if err != nil {
+		return nil, err
+	}
+	for i, line := range lines {
+		prop, err := parseLine(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", i+1, err)
+		}
+		switch {
+		case prop.name == "BEGIN" && strings.EqualFold(prop.value, "VEVENT") && event == nil:
+			event, duration, depth = new(Event), 0, 0
+		case event == nil:
+			continue
+		case prop.name == "BEGIN":
+			// Skip the properties of components within the event, such as alarms
+			depth++
+		case prop.name == "END" && depth > 0:
+			depth--
+		case prop.name == "END":
+			if event.End.IsZero() {
+				switch {
+				case duration != 0:
+					event.End = event.Start.Add(duration)
+				case event.AllDay:
+					event.End = event.Start.AddDate(0, 0, 1)
+				default:
+					event.End = event.Start
+				}
+			}
+			if !event.Start.IsZero() {
+				events = append(events, *event)
+			}
+			event = nil
+		case depth > 0:
+			continue
+		default:
+			if err := event.set(prop, &duration); err != nil {
+				return nil, fmt.Errorf("line %d: %w", i+1, err)
+			}
+		}
+	}
+	return events, nil
+}
+
+// Encode writes events as an iCalendar calendar, with times in UTC and
+// dates for all-day events
+func Encode(w io.Writer, events ...Event) error {
+	var buf bytes.Buffer
+	writeLine(&buf, "BEGIN", "VCALENDAR")
+	writeLine(&buf, "VERSION", "2.0")
+	writeLine(&buf, "PRODID", prodID)
+	stamp := time.Now().UTC().Format(formatUTC)
+	for _, event := range events {
+		writeLine(&buf, "BEGIN", "VEVENT")
+		writeLine(&buf, "UID", escape(event.UID))
+		writeLine(&buf, "DTSTAMP", stamp)
+		if event.AllDay {
+			writeLine(&buf, "DTSTART;VALUE=DATE", event.Start.Format(formatDate))
+			writeLine(&buf, "DTEND;VALUE=DATE", event.End.Format(formatDate))
+		} else {
+			writeLine(&buf, "DTSTART", event.Start.UTC().Format(formatUTC))
+			writeLine(&buf, "DTEND", event.End.UTC().Format(formatUTC))
+		}
+		writeLine(&buf, "SUMMARY", escape(event.Summary))
+		if event.Description != "" {
+			writeLine(&buf, "DESCRIPTION", escape(event.Description))
+		}
+		if event.Location != "" {
+			writeLine(&buf, "LOCATION", escape(event.Location))
+		}
+		if event.Status != "" {
+			writeLine(&buf, "STATUS", strings.ToUpper(event.Status))
+		}
+		if event.Transparent {
+			writeLine(&buf, "TRANSP", "TRANSPARENT")
+		}
+		writeLine(&buf, "END", "VEVENT")
+	}
+	writeLine(&buf, "END", "VCALENDAR")
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// Overlaps returns true if the event overlaps the period from start to end.
+// An event without a duration overlaps a period which contains its start.
+func (e Event) Overlaps(start, end time.Time) bool {
+	if e.End.Equal(e.Start) {
+		return !e.Start.Before(start) && e.Start.Before(end)
+	}
+	return e.Start.Before(end) && e.End.After(start)
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// set sets a field of the event from a property
+func (e *Event) set(prop property, duration *time.Duration) error {
+	var err error
+	switch prop.name {
+	case "UID":
+		e.UID = unescape(prop.value)
+	case "SUMMARY":
+		e.Summary = unescape(prop.value)
+	case "DESCRIPTION":
+		e.Description = unescape(prop.value)
+	case "LOCATION":
+		e.Location = unescape(prop.value)
+	case "STATUS":
+		e.Status = strings.ToUpper(prop.value)
+	case "TRANSP":
+		e.Transparent = strings.EqualFold(prop.value, "TRANSPARENT")
+	case "DTSTART":
+		e.Start, e.AllDay, err = parseTime(prop)
+	case "DTEND":
+		e.End, _, err = parseTime(prop)
+	case "DURATION":
+		*duration, err = parseDuration(prop.value)
+	}
+	return err
+}
+
+// unfold reads content lines, joining lines which continue with a space or
+// tab onto the line before
+func unfold(r io.Reader) ([]string, error) {
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1<<20)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if len(lines) > 0 && (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) {
+			lines[len(lines)-1] += line[1:]
+		} else if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines, scanner.Err()
+}
+
+// parseLine splits a content line into its name, parameters and value.
+// Parameter values may be quoted, and quoted values may contain colons.
+func parseLine(line string) (property, error) {
+	var prop property
+	var quoted bool
+	colon := -1
+	for i, r := range line {
+		if r == '"' {
+			quoted = !quoted
+		} else if r == ':' && !quoted {
+			colon = i
+			break
+		}
+	}
+	if colon < 0 {
+		return prop, fmt.Errorf("missing value in %q", line)
+	}
+	prop.value = line[colon+1:]
+	parts := strings.Split(line[:colon], ";")
+	prop.name = strings.ToUpper(parts[0])
+	for _, param := range parts[1:] {
+		if key, value, ok := strings.Cut(param, "="); ok {
+			if prop.params == nil {
+				prop.params = make(map[string]string)
+			}
+			prop.params[strings.ToUpper(key)] = strings.Trim(value, `"`)
+		}
+	}
+	return prop, nil
+}
+
+// parseTime returns a date or date-time value, and true if it is a date
+func parseTime(prop property) (time.Time, bool, error) {
+	value := prop.value
+	if strings.EqualFold(prop.params["VALUE"], "DATE") || len(value) == len(formatDate) {
+		t, err := time.Parse(formatDate, value)
+		return t, true, err
+	}
+	if strings.HasSuffix(value, "Z") {
+		t, err := time.Parse(formatUTC, value)
+		return t, false, err
+	}
+	location := time.UTC
+	if tzid := prop.params["TZID"]; tzid != "" {
+		if loc, err := time.LoadLocation(tzid); err == nil {
+			location = loc
+		}
+	}
+	t, err := time.ParseInLocation(formatLocal, value, location)
+	return t, false, err
+}
+
+// parseDuration returns an iCalendar duration, such as PT1H30M or P1D
+func parseDuration(value string) (time.Duration, error) {
+	match := reDuration.FindStringSubmatch(strings.ToUpper(value))
+	if match == nil || value == "P" || value == "PT" {
+		return 0, fmt.Errorf("invalid duration %q", value)
+	}
+	var duration time.Duration
+	for i, unit := range []time.Duration{7 * 24 * time.Hour, 24 * time.Hour, time.Hour, time.Minute, time.Second} {
+		if n, err := strconv.Atoi(match[i+2]); err == nil {
+			duration += time.Duration(n) * unit
+		}
+	}
+	if match[1] == "-" {
+		duration = -duration
+	}
+	return duration, nil
+}
+
+// writeLine writes a content line, folding it so that no line is longer
+// than 75 octets, without breaking a character
+func writeLine(buf *bytes.Buffer, name, value string) {
+	line := name + ":" + value
+	for width := maxLineOctets; len(line) > width; width = maxLineOctets - 1 {
+		i := width
+		for i > 0 && !utf8.RuneStart(line[i]) {
+			i--
+		}
+		buf.WriteString(line[:i])
+		buf.WriteString("\r\n ")
+		line = line[i:]
+	}
+	buf.WriteString(line)
+	buf.WriteString("\r\n")
+}
+
+// escape escapes text for a property value
+func escape(text string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`).Replace(text)
+}
+
+// unescape reverses escape
+func unescape(text string) string {
+	return strings.NewReplacer(`\\`, `\`, `\;`, ";", `\,`, ",", `\n`, "\n", `\N`, "\n").Replace(text)
+}
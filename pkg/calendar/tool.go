@@ -0,0 +1,208 @@
+package calendar
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	// Packages
+	llm "github.com/mutablelogic/go-llm"
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	tool "github.com/mutablelogic/go-llm/toolkit/tool"
+	jsonschema "github.com/mutablelogic/go-server/pkg/jsonschema"
+	types "github.com/mutablelogic/go-server/pkg/types"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+type eventsTool struct {
+	tool.Base
+	client *Client
+}
+
+type freeBusyTool struct {
+	tool.Base
+	client *Client
+}
+
+type createTool struct {
+	tool.Base
+	client *Client
+}
+
+type rangeRequest struct {
+	Start time.Time `json:"start,omitzero" help:"Start of the period, in RFC 3339 format (defaults to now)"`
+	End   time.Time `json:"end,omitzero" help:"End of the period, in RFC 3339 format (defaults to seven days after the start)"`
+}
+
+type eventsResponse struct {
+	Events []Event `json:"events"`
+}
+
+type freeBusyResponse struct {
+	Busy []Period `json:"busy" help:"Periods in which the calendar is busy"`
+	Free []Period `json:"free" help:"Periods in which the calendar is free"`
+}
+
+type createRequest struct {
+	Summary     string    `json:"summary" help:"Title of the event"`
+	Start       time.Time `json:"start" help:"Start time of the event, in RFC 3339 format"`
+	End         time.Time `json:"end" help:"End time of the event, in RFC 3339 format"`
+	Description string    `json:"description,omitempty" help:"Description of the event"`
+	Location    string    `json:"location,omitempty" help:"Location of the event"`
+}
+
+var _ llm.Tool = (*eventsTool)(nil)
+var _ llm.Tool = (*freeBusyTool)(nil)
+var _ llm.Tool = (*createTool)(nil)
+
+///////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+const (
+	defaultPeriod = 7 * 24 * time.Hour
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// LIFECYCLE
+
+// NewTools returns tools which list events and query free and busy time in
+// a calendar. If write is true and the calendar is not an ICS feed, a tool
+// which creates events is also returned. Creating an event changes the
+// calendar, so callers should require each call to the tool to be approved.
+func NewTools(client *Client, write bool) ([]llm.Tool, error) {
+	if client == nil {
+		return nil, schema.ErrBadParameter.With("client is required")
+	}
+	tools := []llm.Tool{
+		&eventsTool{client: client},
+		&freeBusyTool{client: client},
+	}
+	if write && !client.ReadOnly() {
+		tools = append(tools, &createTool{client: client})
+	}
+	return tools, nil
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// llm.Tool INTERFACE
+
+func (*eventsTool) Name() string {
+	return "calendar_events"
+}
+
+func (*eventsTool) Description() string {
+	return "List the events in the calendar between a start and end time, in order of their start times."
+}
+
+func (*eventsTool) InputSchema() *jsonschema.Schema {
+	return jsonschema.MustFor[rangeRequest]()
+}
+
+func (*eventsTool) OutputSchema() *jsonschema.Schema {
+	return jsonschema.MustFor[eventsResponse]()
+}
+
+func (*eventsTool) Meta() llm.ToolMeta {
+	return llm.ToolMeta{Title: "Calendar Events", ReadOnlyHint: true, IdempotentHint: true, OpenWorldHint: types.Ptr(true)}
+}
+
+func (t *eventsTool) Run(ctx context.Context, input json.RawMessage) (any, error) {
+	start, end, err := parseRange(input)
+	if err != nil {
+		return nil, err
+	}
+	events, err := t.client.Events(ctx, start, end)
+	if err != nil {
+		return nil, err
+	}
+	return eventsResponse{Events: events}, nil
+}
+
+func (*freeBusyTool) Name() string {
+	return "calendar_freebusy"
+}
+
+func (*freeBusyTool) Description() string {
+	return "Return the periods in which the calendar is busy and free between a start and end time, for finding a time to meet."
+}
+
+func (*freeBusyTool) InputSchema() *jsonschema.Schema {
+	return jsonschema.MustFor[rangeRequest]()
+}
+
+func (*freeBusyTool) OutputSchema() *jsonschema.Schema {
+	return jsonschema.MustFor[freeBusyResponse]()
+}
+
+func (*freeBusyTool) Meta() llm.ToolMeta {
+	return llm.ToolMeta{Title: "Calendar Free/Busy", ReadOnlyHint: true, IdempotentHint: true, OpenWorldHint: types.Ptr(true)}
+}
+
+func (t *freeBusyTool) Run(ctx context.Context, input json.RawMessage) (any, error) {
+	start, end, err := parseRange(input)
+	if err != nil {
+		return nil, err
+	}
+	busy, err := t.client.FreeBusy(ctx, start, end)
+	if err != nil {
+		return nil, err
+	}
+	return freeBusyResponse{Busy: busy, Free: Free(busy, start, end)}, nil
+}
+
+func (*createTool) Name() string {
+	return "calendar_create_event"
+}
+
+func (*createTool) Description() string {
+	return "Create an event in the calendar, and return it with its unique identifier. Check free and busy time first, and confirm the details with the user."
+}
+
+func (*createTool) InputSchema() *jsonschema.Schema {
+	return jsonschema.MustFor[createRequest]()
+}
+
+func (*createTool) OutputSchema() *jsonschema.Schema {
+	return jsonschema.MustFor[Event]()
+}
+
+func (*createTool) Meta() llm.ToolMeta {
+	return llm.ToolMeta{Title: "Create Calendar Event", DestructiveHint: types.Ptr(false), OpenWorldHint: types.Ptr(true)}
+}
+
+func (t *createTool) Run(ctx context.Context, input json.RawMessage) (any, error) {
+	var req createRequest
+	if err := json.Unmarshal(input, &req); err != nil {
+		return nil, schema.ErrBadParameter.Withf("failed to unmarshal input: %v", err)
+	}
+	return t.client.Create(ctx, Event{
+		Summary:     req.Summary,
+		Description: req.Description,
+		Location:    req.Location,
+		Start:       req.Start,
+		End:         req.End,
+	})
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// parseRange returns the period of a request, defaulting to the next seven
+// days
+func parseRange(input json.RawMessage) (time.Time, time.Time, error) {
+	var req rangeRequest
+	if len(input) > 0 {
+		if err := json.Unmarshal(input, &req); err != nil {
+			return time.Time{}, time.Time{}, schema.ErrBadParameter.Withf("failed to unmarshal input: %v", err)
+		}
+	}
+	if req.Start.IsZero() {
+		req.Start = time.Now().Truncate(time.Minute)
+	}
+	if req.End.IsZero() {
+		req.End = req.Start.Add(defaultPeriod)
+	}
+	return req.Start, req.End, nil
+}
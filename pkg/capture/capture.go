@@ -0,0 +1,245 @@
+// Package capture records the raw request and response bodies sent to a
+// provider, so they can be attached to the reply when debugging option
+// mapping or reporting provider bugs.
+package capture
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+
+	// Packages
+	client "github.com/mutablelogic/go-client"
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	opt "github.com/mutablelogic/go-llm/pkg/opt"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// Recorder keeps the bodies of the requests made with its options. A nil
+// recorder records nothing.
+type Recorder struct {
+	sync.Mutex
+	request  []byte
+	response []byte
+}
+
+type roundTripper func(*http.Request) (*http.Response, error)
+
+// teeBody copies the response body to the recorder as it is read
+type teeBody struct {
+	io.ReadCloser
+	recorder *Recorder
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+// Maximum number of bytes kept for each body
+const maxBody = 1 << 20
+
+// Value which replaces redacted fields
+const redacted = "[redacted]"
+
+// Fields which are redacted, compared without case, dashes or underscores
+var secrets = map[string]bool{
+	"apikey":        true,
+	"xapikey":       true,
+	"xgoogapikey":   true,
+	"authorization": true,
+	"accesstoken":   true,
+	"refreshtoken":  true,
+	"clientsecret":  true,
+	"secret":        true,
+	"password":      true,
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// LIFECYCLE
+
+// New returns a recorder when the options request raw capture, or nil
+func New(options opt.Options) *Recorder {
+	if options == nil || !options.GetBool(opt.CaptureRawKey) {
+		return nil
+	}
+	return new(Recorder)
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// Opts returns the request options which record the request and response,
+// followed by any other options
+func (r *Recorder) Opts(opts ...client.RequestOpt) []client.RequestOpt {
+	if r == nil {
+		return opts
+	}
+	return append([]client.RequestOpt{client.OptReqTransport(r.transport)}, opts...)
+}
+
+// Request returns the recorded request body with secrets redacted
+func (r *Recorder) Request() any {
+	if r == nil {
+		return nil
+	}
+	r.Lock()
+	defer r.Unlock()
+	return redact(r.request)
+}
+
+// Response returns the recorded response body with secrets redacted. A
+// streamed response is returned as text.
+func (r *Recorder) Response() any {
+	if r == nil {
+		return nil
+	}
+	r.Lock()
+	defer r.Unlock()
+	return redact(r.response)
+}
+
+// Annotate sets the recorded request and response on the metadata of the
+// reply, and on the copy of the reply held by the conversation
+func (r *Recorder) Annotate(session schema.Conversation, reply *schema.Message) {
+	if r == nil || reply == nil {
+		return
+	}
+	request, response := r.Request(), r.Response()
+	for _, message := range []*schema.Message{reply, last(session, reply)} {
+		if message == nil {
+			continue
+		}
+		if message.Meta == nil {
+			message.Meta = make(map[string]any, 2)
+		}
+		message.Meta[schema.MessageMetaRawRequest] = request
+		message.Meta[schema.MessageMetaRawResponse] = response
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+func (r *Recorder) transport(next http.RoundTripper) http.RoundTripper {
+	return roundTripper(func(req *http.Request) (*http.Response, error) {
+		if req.Body != nil {
+			body, err := io.ReadAll(req.Body)
+			req.Body.Close()
+			if err != nil {
+				return nil, err
+			}
+			r.Lock()
+			r.request = truncate(body)
+			r.response = nil
+			r.Unlock()
+			req.Body = io.NopCloser(bytes.NewReader(body))
+		}
+		resp, err := next.RoundTrip(req)
+		if err != nil || resp.Body == nil {
+			return resp, err
+		}
+		resp.Body = &teeBody{ReadCloser: resp.Body, recorder: r}
+		return resp, nil
+	})
+}
+
+func (fn roundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	return fn(req)
+}
+
+func (b *teeBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if n > 0 {
+		b.recorder.Lock()
+		if remaining := maxBody - len(b.recorder.response); remaining > 0 {
+			b.recorder.response = append(b.recorder.response, p[:min(n, remaining)]...)
+		}
+		b.recorder.Unlock()
+	}
+	return n, err
+}
+
+// last returns the last message in the conversation when it is a copy of the
+// reply
+func last(session schema.Conversation, reply *schema.Message) *schema.Message {
+	n := len(session)
+	if n == 0 || session[n-1] == reply {
+		return nil
+	}
+	if message := session[n-1]; message.Role == reply.Role && message.CreatedAt.Equal(reply.CreatedAt) {
+		return message
+	}
+	return nil
+}
+
+func truncate(body []byte) []byte {
+	if len(body) > maxBody {
+		return body[:maxBody]
+	}
+	return body
+}
+
+// redact returns a JSON body with secret fields replaced. Other bodies, such
+// as event streams, are returned as text with each JSON line redacted.
+func redact(body []byte) any {
+	if len(body) == 0 {
+		return nil
+	}
+	if value, ok := redactJSON(body); ok {
+		return value
+	}
+	lines := strings.Split(string(body), "\n")
+	for i, line := range lines {
+		prefix, data := "", line
+		if rest, ok := strings.CutPrefix(line, "data:"); ok {
+			prefix, data = "data: ", strings.TrimSpace(rest)
+		}
+		if value, ok := redactJSON([]byte(data)); ok {
+			lines[i] = prefix + string(value)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func redactJSON(data []byte) (json.RawMessage, bool) {
+	var value any
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&value); err != nil {
+		return nil, false
+	} else if _, err := decoder.Token(); err != io.EOF {
+		return nil, false
+	}
+	switch value.(type) {
+	case map[string]any, []any:
+	default:
+		return nil, false
+	}
+	data, err := json.Marshal(redactValue(value))
+	if err != nil {
+		return nil, false
+	}
+	return data, true
+}
+
+func redactValue(value any) any {
+	switch value := value.(type) {
+	case map[string]any:
+		for key, v := range value {
+			if secrets[strings.NewReplacer("-", "", "_", "").Replace(strings.ToLower(key))] {
+				value[key] = redacted
+			} else {
+				value[key] = redactValue(v)
+			}
+		}
+	case []any:
+		for i, v := range value {
+			value[i] = redactValue(v)
+		}
+	}
+	return value
+}
@@ -0,0 +1,98 @@
+package capture_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	// Packages
+	client "github.com/mutablelogic/go-client"
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	capture "github.com/mutablelogic/go-llm/pkg/capture"
+	opt "github.com/mutablelogic/go-llm/pkg/opt"
+	assert "github.com/stretchr/testify/assert"
+)
+
+func newServer(t *testing.T, body string) *client.Client {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+
+	c, err := client.New(client.OptEndpoint(server.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestNew(t *testing.T) {
+	assert := assert.New(t)
+
+	options, err := opt.Apply()
+	assert.NoError(err)
+	assert.Nil(capture.New(options))
+
+	options, err = opt.Apply(opt.WithCaptureRaw())
+	assert.NoError(err)
+	assert.NotNil(capture.New(options))
+
+	// A nil recorder passes options through and records nothing
+	var recorder *capture.Recorder
+	assert.Len(recorder.Opts(client.OptPath("a")), 1)
+	assert.Nil(recorder.Request())
+	reply := &schema.Message{Role: schema.RoleAssistant}
+	recorder.Annotate(nil, reply)
+	assert.Nil(reply.Meta)
+}
+
+func TestRecorder(t *testing.T) {
+	assert := assert.New(t)
+	c := newServer(t, `{"id":"msg_1","api_key":"secret","n":12345678901234567890}`)
+
+	options, err := opt.Apply(opt.WithCaptureRaw())
+	assert.NoError(err)
+	recorder := capture.New(options)
+
+	payload, err := client.NewJSONRequest(map[string]any{
+		"model":   "test",
+		"headers": map[string]any{"Authorization": "Bearer token", "x-api-key": "key"},
+	})
+	assert.NoError(err)
+	var response map[string]any
+	assert.NoError(c.DoWithContext(context.Background(), payload, &response, recorder.Opts(client.OptPath("messages"))...))
+	assert.Equal("msg_1", response["id"])
+
+	assert.JSONEq(`{"model":"test","headers":{"Authorization":"[redacted]","x-api-key":"[redacted]"}}`, string(recorder.Request().(json.RawMessage)))
+	assert.JSONEq(`{"id":"msg_1","api_key":"[redacted]","n":12345678901234567890}`, string(recorder.Response().(json.RawMessage)))
+
+	// The reply and the copy in the conversation are annotated
+	reply := &schema.Message{Role: schema.RoleAssistant}
+	session := schema.Conversation{{Role: schema.RoleUser}}
+	session.Append(*reply)
+	recorder.Annotate(session, reply)
+	assert.NotNil(reply.Meta[schema.MessageMetaRawRequest])
+	assert.NotNil(reply.Meta[schema.MessageMetaRawResponse])
+	assert.Equal(reply.Meta, session[1].Meta)
+	assert.Nil(session[0].Meta)
+}
+
+func TestRecorderStream(t *testing.T) {
+	assert := assert.New(t)
+	c := newServer(t, "{\"done\":false,\"password\":\"x\"}\n{\"done\":true}\n")
+
+	options, err := opt.Apply(opt.WithCaptureRaw())
+	assert.NoError(err)
+	recorder := capture.New(options)
+
+	payload, err := client.NewJSONRequest(map[string]any{"stream": true})
+	assert.NoError(err)
+	var discard map[string]any
+	_ = c.DoWithContext(context.Background(), payload, &discard, recorder.Opts(client.OptPath("chat"))...)
+
+	assert.Equal("{\"done\":false,\"password\":\"[redacted]\"}\n{\"done\":true}\n", recorder.Response())
+}
@@ -0,0 +1,288 @@
+// Package chaos injects faults into the HTTP requests made to providers, so
+// that retries, key rotation, failover and circuit breakers can be tested.
+// Each request has a chance of a timeout, a rate limit response, a response
+// body which ends early, or a response body with malformed JSON. The faults
+// come from a seeded random source, so that the same requests made in the
+// same order have the same faults.
+package chaos
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"maps"
+	"math/rand/v2"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// Faults are the chance of each fault for a request, between zero and one.
+// The chances must not add up to more than one.
+type Faults struct {
+	Timeout   float64 // the request fails with a timeout, and is not sent
+	Throttle  float64 // the provider returns a rate limit error, and the request is not sent
+	Truncate  float64 // the response body ends early
+	Malformed float64 // the first JSON object of the response body is malformed
+	Seed      uint64  // seed for the random source
+}
+
+// Fault is a fault which is injected into a request
+type Fault string
+
+// Injector injects faults into requests
+type Injector struct {
+	sync.Mutex
+	faults Faults
+	rand   *rand.Rand
+	counts map[Fault]uint64
+}
+
+type transport struct {
+	*Injector
+	parent http.RoundTripper
+}
+
+// timeoutError is returned for a request which times out
+type timeoutError struct{}
+
+// truncatedBody returns an unexpected EOF after a number of bytes, or at the
+// end of the body when it is shorter
+type truncatedBody struct {
+	io.ReadCloser
+	remaining int
+}
+
+// malformedBody inserts text after the first opening brace
+type malformedBody struct {
+	io.ReadCloser
+	injected bool
+	pending  []byte
+	err      error
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+const (
+	FaultTimeout   Fault = "timeout"
+	FaultThrottle  Fault = "throttle"
+	FaultTruncate  Fault = "truncate"
+	FaultMalformed Fault = "malformed"
+)
+
+// Maximum number of bytes of a truncated response body
+const truncateMax = 256
+
+// Text which is inserted into a response body to make the JSON malformed
+const malformedText = `]"chaos":`
+
+// Body of a rate limit response
+const throttleBody = `{"error":{"type":"rate_limit_error","message":"chaos: injected rate limit"}}`
+
+///////////////////////////////////////////////////////////////////////////////
+// LIFECYCLE
+
+// New returns an injector for the faults
+func New(faults Faults) (*Injector, error) {
+	total := 0.0
+	for name, chance := range faults.chances() {
+		if chance < 0 || chance > 1 {
+			return nil, fmt.Errorf("chaos: chance of %s must be between zero and one", name)
+		}
+		total += chance
+	}
+	if total > 1 {
+		return nil, fmt.Errorf("chaos: chances must not add up to more than one")
+	}
+	return &Injector{
+		faults: faults,
+		rand:   rand.New(rand.NewPCG(faults.Seed, faults.Seed)),
+		counts: make(map[Fault]uint64, 4),
+	}, nil
+}
+
+// Parse returns the faults from a comma-separated list of name=value pairs,
+// such as "timeout=0.05,throttle=0.1,seed=42"
+func Parse(spec string) (Faults, error) {
+	var faults Faults
+	for _, field := range strings.Split(spec, ",") {
+		if field = strings.TrimSpace(field); field == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(field, "=")
+		if !ok {
+			return Faults{}, fmt.Errorf("chaos: expected name=value, got %q", field)
+		}
+		name, value = strings.ToLower(strings.TrimSpace(name)), strings.TrimSpace(value)
+		if name == "seed" {
+			seed, err := strconv.ParseUint(value, 10, 64)
+			if err != nil {
+				return Faults{}, fmt.Errorf("chaos: seed: %w", err)
+			}
+			faults.Seed = seed
+			continue
+		}
+		chance, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return Faults{}, fmt.Errorf("chaos: %s: %w", name, err)
+		}
+		switch Fault(name) {
+		case FaultTimeout:
+			faults.Timeout = chance
+		case FaultThrottle, "429":
+			faults.Throttle = chance
+		case FaultTruncate:
+			faults.Truncate = chance
+		case FaultMalformed:
+			faults.Malformed = chance
+		default:
+			return Faults{}, fmt.Errorf("chaos: unknown fault %q", name)
+		}
+	}
+	return faults, nil
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// Transport returns a transport which injects faults into the requests sent
+// with the parent transport, for use with client.OptTransport
+func (i *Injector) Transport(parent http.RoundTripper) http.RoundTripper {
+	if parent == nil {
+		parent = http.DefaultTransport
+	}
+	return &transport{Injector: i, parent: parent}
+}
+
+// Counts returns the number of times each fault has been injected
+func (i *Injector) Counts() map[Fault]uint64 {
+	i.Lock()
+	defer i.Unlock()
+	return maps.Clone(i.counts)
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	fault, n := t.next()
+	switch fault {
+	case FaultTimeout:
+		closeBody(req)
+		return nil, timeoutError{}
+	case FaultThrottle:
+		closeBody(req)
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", http.StatusTooManyRequests, http.StatusText(http.StatusTooManyRequests)),
+			StatusCode:    http.StatusTooManyRequests,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        http.Header{"Content-Type": {"application/json"}, "Retry-After": {"1"}},
+			Body:          io.NopCloser(strings.NewReader(throttleBody)),
+			ContentLength: int64(len(throttleBody)),
+			Request:       req,
+		}, nil
+	}
+
+	// Send the request, and change the body of a successful response
+	response, err := t.parent.RoundTrip(req)
+	if err != nil || response.StatusCode >= http.StatusBadRequest || fault == "" {
+		return response, err
+	}
+	switch fault {
+	case FaultTruncate:
+		response.Body = &truncatedBody{ReadCloser: response.Body, remaining: n}
+	case FaultMalformed:
+		response.Body = &malformedBody{ReadCloser: response.Body}
+	}
+	response.ContentLength = -1
+	response.Header.Del("Content-Length")
+	return response, nil
+}
+
+func (timeoutError) Error() string   { return "chaos: injected timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+func (timeoutError) Unwrap() error   { return os.ErrDeadlineExceeded }
+
+func (b *truncatedBody) Read(p []byte) (int, error) {
+	if b.remaining <= 0 {
+		return 0, io.ErrUnexpectedEOF
+	}
+	n, err := b.ReadCloser.Read(p[:min(len(p), b.remaining)])
+	b.remaining -= n
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	return n, err
+}
+
+func (b *malformedBody) Read(p []byte) (int, error) {
+	if len(b.pending) > 0 {
+		n := copy(p, b.pending)
+		b.pending = b.pending[n:]
+		return n, nil
+	} else if b.err != nil {
+		return 0, b.err
+	}
+	n, err := b.ReadCloser.Read(p)
+	if b.injected {
+		return n, err
+	}
+	index := bytes.IndexByte(p[:n], '{')
+	if index < 0 {
+		return n, err
+	}
+
+	// Keep the bytes after the brace, with the text before them, for the
+	// next read
+	b.injected = true
+	b.pending = append([]byte(malformedText), p[index+1:n]...)
+	b.err = err
+	return index + 1, nil
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// chances returns the chance of each fault, by name
+func (f Faults) chances() map[Fault]float64 {
+	return map[Fault]float64{
+		FaultTimeout:   f.Timeout,
+		FaultThrottle:  f.Throttle,
+		FaultTruncate:  f.Truncate,
+		FaultMalformed: f.Malformed,
+	}
+}
+
+// next returns the fault for the next request, or an empty fault, and the
+// number of bytes after which a truncated response body ends
+func (i *Injector) next() (Fault, int) {
+	i.Lock()
+	defer i.Unlock()
+
+	// Draw both numbers for every request, so that the faults of later
+	// requests do not depend on the faults of earlier ones
+	value, n := i.rand.Float64(), i.rand.IntN(truncateMax)
+	chances := i.faults.chances()
+	for _, fault := range []Fault{FaultTimeout, FaultThrottle, FaultTruncate, FaultMalformed} {
+		chance := chances[fault]
+		if value < chance {
+			i.counts[fault]++
+			return fault, n
+		}
+		value -= chance
+	}
+	return "", n
+}
+
+// closeBody closes the body of a request which is not sent
+func closeBody(req *http.Request) {
+	if req.Body != nil {
+		req.Body.Close()
+	}
+}
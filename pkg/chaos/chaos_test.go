@@ -0,0 +1,169 @@
+package chaos_test
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	// Packages
+	chaos "github.com/mutablelogic/go-llm/pkg/chaos"
+	assert "github.com/stretchr/testify/assert"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// HELPERS
+
+const body = `{"id":"msg_1","content":"` + "The quick brown fox jumps over the lazy dog, and then runs away into the forest where nobody can find it. The quick brown fox jumps over the lazy dog, and then runs away into the forest where nobody can find it." + `"}`
+
+// get sends a request to a server which returns the body, through a
+// transport with the faults, and returns the response and the body which
+// was read
+func get(t *testing.T, faults chaos.Faults) (*http.Response, string, error) {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, body)
+	}))
+	t.Cleanup(server.Close)
+
+	injector, err := chaos.New(faults)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := &http.Client{Transport: injector.Transport(nil)}
+	response, err := client.Get(server.URL)
+	if err != nil {
+		return nil, "", err
+	}
+	defer response.Body.Close()
+	data, err := io.ReadAll(response.Body)
+	return response, string(data), err
+}
+
+// countStatus returns the number of responses with the status code
+func countStatus(codes []int, code int) int {
+	var n int
+	for _, c := range codes {
+		if c == code {
+			n++
+		}
+	}
+	return n
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// TESTS
+
+func TestNew(t *testing.T) {
+	assert := assert.New(t)
+
+	_, err := chaos.New(chaos.Faults{Timeout: 0.5, Throttle: 0.5})
+	assert.NoError(err)
+	_, err = chaos.New(chaos.Faults{Timeout: -0.1})
+	assert.Error(err)
+	_, err = chaos.New(chaos.Faults{Timeout: 0.6, Malformed: 0.6})
+	assert.Error(err)
+}
+
+func TestParse(t *testing.T) {
+	assert := assert.New(t)
+
+	faults, err := chaos.Parse("timeout=0.1, 429=0.2,truncate=0.05,malformed=0.05,seed=42")
+	if assert.NoError(err) {
+		assert.Equal(chaos.Faults{Timeout: 0.1, Throttle: 0.2, Truncate: 0.05, Malformed: 0.05, Seed: 42}, faults)
+	}
+	_, err = chaos.Parse("timeout")
+	assert.Error(err)
+	_, err = chaos.Parse("latency=0.1")
+	assert.Error(err)
+	_, err = chaos.Parse("seed=-1")
+	assert.Error(err)
+}
+
+func TestNoFaults(t *testing.T) {
+	assert := assert.New(t)
+
+	response, data, err := get(t, chaos.Faults{})
+	if assert.NoError(err) {
+		assert.Equal(http.StatusOK, response.StatusCode)
+		assert.Equal(body, data)
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	assert := assert.New(t)
+
+	_, _, err := get(t, chaos.Faults{Timeout: 1})
+	assert.ErrorIs(err, os.ErrDeadlineExceeded)
+	var timeout interface{ Timeout() bool }
+	if assert.True(errors.As(err, &timeout)) {
+		assert.True(timeout.Timeout())
+	}
+}
+
+func TestThrottle(t *testing.T) {
+	assert := assert.New(t)
+
+	response, data, err := get(t, chaos.Faults{Throttle: 1})
+	if assert.NoError(err) {
+		assert.Equal(http.StatusTooManyRequests, response.StatusCode)
+		assert.Equal("1", response.Header.Get("Retry-After"))
+		assert.True(json.Valid([]byte(data)))
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	assert := assert.New(t)
+
+	_, data, err := get(t, chaos.Faults{Truncate: 1})
+	assert.ErrorIs(err, io.ErrUnexpectedEOF)
+	assert.True(strings.HasPrefix(body, data))
+	assert.LessOrEqual(len(data), len(body))
+}
+
+func TestMalformed(t *testing.T) {
+	assert := assert.New(t)
+
+	response, data, err := get(t, chaos.Faults{Malformed: 1})
+	if assert.NoError(err) {
+		assert.Equal(http.StatusOK, response.StatusCode)
+		assert.False(json.Valid([]byte(data)))
+		assert.Equal(body, strings.Replace(data, `]"chaos":`, "", 1))
+	}
+}
+
+func TestRepeatable(t *testing.T) {
+	assert := assert.New(t)
+
+	// The same seed injects the same faults into the same requests
+	sequence := func(seed uint64) []int {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = io.WriteString(w, body)
+		}))
+		defer server.Close()
+		injector, err := chaos.New(chaos.Faults{Timeout: 0.3, Throttle: 0.3, Seed: seed})
+		if !assert.NoError(err) {
+			return nil
+		}
+		client := &http.Client{Transport: injector.Transport(nil)}
+		var result []int
+		for range 20 {
+			if response, err := client.Get(server.URL); err != nil {
+				result = append(result, 0)
+			} else {
+				response.Body.Close()
+				result = append(result, response.StatusCode)
+			}
+		}
+		counts := injector.Counts()
+		assert.Equal(20, int(counts[chaos.FaultTimeout]+counts[chaos.FaultThrottle])+countStatus(result, http.StatusOK))
+		return result
+	}
+	assert.Equal(sequence(7), sequence(7))
+	assert.NotEqual(sequence(7), sequence(8))
+}
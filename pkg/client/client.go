@@ -0,0 +1,39 @@
+// Package client is the Go client for a go-llm server, for services which
+// talk to a centrally deployed server. It lists and calls agents, manages
+// sessions, streams chat responses, creates embeddings and calls tools.
+// The methods are those of the kernel HTTP client, which the command-line
+// tools also use, so that requests and streams are handled in one place.
+package client
+
+import (
+	// Packages
+	client "github.com/mutablelogic/go-client"
+	httpclient "github.com/mutablelogic/go-llm/kernel/httpclient"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// Client is a typed client for the go-llm HTTP API
+type Client = httpclient.Client
+
+// Opt is an option for the client, such as the bearer token or a timeout
+type Opt = client.ClientOpt
+
+///////////////////////////////////////////////////////////////////////////////
+// LIFECYCLE
+
+// New creates a client for the server API endpoint, e.g.
+// "http://localhost:8084/api", with a bearer token or API key set by the
+// options.
+func New(endpoint string, opts ...Opt) (*Client, error) {
+	return httpclient.New(endpoint, opts...)
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// OPTIONS
+
+// WithToken sets the bearer token or API key sent with each request
+func WithToken(token string) Opt {
+	return client.OptReqToken(client.Token{Scheme: client.Bearer, Value: token})
+}
@@ -0,0 +1,55 @@
+package client_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	// Packages
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	client "github.com/mutablelogic/go-llm/pkg/client"
+	types "github.com/mutablelogic/go-server/pkg/types"
+	assert "github.com/stretchr/testify/assert"
+)
+
+func Test_client_001(t *testing.T) {
+	assert := assert.New(t)
+
+	// The server checks the token and version of the API
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/agent", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		assert.Equal(schema.APIVersion, r.Header.Get(schema.APIVersionHeader))
+		w.Header().Set(types.ContentTypeHeader, types.ContentTypeJSON)
+		_ = json.NewEncoder(w).Encode(schema.AgentList{
+			Count: 1,
+			Body:  []*schema.AgentMeta{{Name: "echo", Title: "Echo Agent"}},
+		})
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	// List the agents with the token
+	c, err := client.New(server.URL+"/api", client.WithToken("secret"))
+	if !assert.NoError(err) {
+		t.FailNow()
+	}
+	agents, err := c.ListAgents(context.Background(), schema.AgentListRequest{})
+	if assert.NoError(err) {
+		assert.Equal(uint(1), agents.Count)
+		assert.Equal("echo", agents.Body[0].Name)
+	}
+
+	// Without the token, the request fails
+	c, err = client.New(server.URL + "/api")
+	if !assert.NoError(err) {
+		t.FailNow()
+	}
+	_, err = c.ListAgents(context.Background(), schema.AgentListRequest{})
+	assert.Error(err)
+}
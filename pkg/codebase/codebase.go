@@ -0,0 +1,357 @@
+/*
+codebase packs the files of a directory into message content, so that a
+model can answer questions about a source tree. Files ignored by .gitignore,
+binary files and files which do not fit the token budget are left out, and a
+tree summary lists the files which were read and those which were omitted.
+*/
+package codebase
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	// Packages
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	tokenizer "github.com/mutablelogic/go-llm/pkg/tokenizer"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// Context is the files read from a directory
+type Context struct {
+	Root    string `json:"root"`
+	Files   []File `json:"files,omitempty"`
+	Omitted []File `json:"omitted,omitempty"`
+	Tokens  uint   `json:"tokens"`
+	Tree    string `json:"tree,omitempty"`
+}
+
+// File is a file in the directory, with a path relative to the root. For an
+// omitted file, the reason it was omitted is set.
+type File struct {
+	Path   string `json:"path"`
+	Size   int64  `json:"size"`
+	Tokens uint   `json:"tokens,omitempty"`
+	Reason string `json:"reason,omitempty"`
+	Data   []byte `json:"-"`
+}
+
+// Opt sets an option for reading a directory
+type Opt func(*opts) error
+
+type opts struct {
+	include   []string
+	exclude   []string
+	tokens    uint
+	maxSize   int64
+	tree      bool
+	tokenizer tokenizer.Tokenizer
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+const (
+	// Default token budget for the files
+	DefaultTokens = 32000
+
+	// Default largest file which is read
+	DefaultMaxSize = 256 << 10
+
+	// Bytes at the start of a file which are checked for binary content
+	sniffSize = 8000
+)
+
+const (
+	ReasonIgnored = "ignored"
+	ReasonBinary  = "binary"
+	ReasonSize    = "too large"
+	ReasonBudget  = "over token budget"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// OPTIONS
+
+// WithInclude reads only files whose path or name matches one of the glob
+// patterns, for example *.go or cmd/**
+func WithInclude(patterns ...string) Opt {
+	return func(o *opts) error {
+		for _, pattern := range patterns {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return schema.ErrBadParameter.Withf("invalid pattern %q", pattern)
+			}
+		}
+		o.include = append(o.include, patterns...)
+		return nil
+	}
+}
+
+// WithExclude omits files and directories whose path or name matches one of
+// the glob patterns, in addition to those ignored by .gitignore
+func WithExclude(patterns ...string) Opt {
+	return func(o *opts) error {
+		for _, pattern := range patterns {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return schema.ErrBadParameter.Withf("invalid pattern %q", pattern)
+			}
+		}
+		o.exclude = append(o.exclude, patterns...)
+		return nil
+	}
+}
+
+// WithTokens sets the token budget for the files, counted with the
+// tokenizer, or the default tokenizer when nil. A budget of zero reads
+// files without a limit.
+func WithTokens(tokens uint, t tokenizer.Tokenizer) Opt {
+	return func(o *opts) error {
+		o.tokens = tokens
+		if t != nil {
+			o.tokenizer = t
+		}
+		return nil
+	}
+}
+
+// WithMaxSize sets the largest file in bytes which is read
+func WithMaxSize(size int64) Opt {
+	return func(o *opts) error {
+		if size <= 0 {
+			return schema.ErrBadParameter.With("max size must be positive")
+		}
+		o.maxSize = size
+		return nil
+	}
+}
+
+// WithTree includes a tree summary of the directory
+func WithTree(tree bool) Opt {
+	return func(o *opts) error {
+		o.tree = tree
+		return nil
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// LIFECYCLE
+
+// Read returns the text files in a directory, in path order, until the token
+// budget is used. Files and directories ignored by .gitignore files in the
+// directory are skipped, as is the .git directory.
+func Read(root string, opt ...Opt) (*Context, error) {
+	o := opts{
+		tokens:    DefaultTokens,
+		maxSize:   DefaultMaxSize,
+		tokenizer: tokenizer.ForProvider(""),
+	}
+	for _, fn := range opt {
+		if err := fn(&o); err != nil {
+			return nil, err
+		}
+	}
+	if info, err := os.Stat(root); err != nil {
+		return nil, schema.ErrNotFound.Withf("%v", err)
+	} else if !info.IsDir() {
+		return nil, schema.ErrBadParameter.Withf("%q is not a directory", root)
+	}
+
+	// Walk the directory. The rules of .gitignore files apply to the
+	// directory they are in, and are read before its entries.
+	result := &Context{Root: root}
+	rules := map[string]ignore{}
+	if err := filepath.WalkDir(root, func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, name)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+
+		// The root directory
+		if rel == "." {
+			rules[rel], err = ignore(nil).read(root, "")
+			return err
+		}
+
+		// Rules of the parent directory
+		parent := rules[path.Dir(rel)]
+		if d.IsDir() {
+			if d.Name() == ".git" || parent.match(rel, true) || matchAny(o.exclude, rel) {
+				return filepath.SkipDir
+			}
+			rules[rel], err = parent.read(root, rel)
+			return err
+		}
+		if !d.Type().IsRegular() || parent.match(rel, false) || matchAny(o.exclude, rel) {
+			return nil
+		}
+		if len(o.include) > 0 && !matchAny(o.include, rel) {
+			return nil
+		}
+
+		// Read the file
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		file := File{Path: rel, Size: info.Size()}
+		if file.Size > o.maxSize {
+			file.Reason = ReasonSize
+			result.Omitted = append(result.Omitted, file)
+			return nil
+		}
+		data, err := os.ReadFile(name)
+		if err != nil {
+			return err
+		}
+		if isBinary(data) {
+			file.Reason = ReasonBinary
+			result.Omitted = append(result.Omitted, file)
+			return nil
+		}
+
+		// Check the token budget
+		file.Tokens = tokenizer.Count(o.tokenizer, string(data))
+		if o.tokens > 0 && result.Tokens+file.Tokens > o.tokens {
+			file.Reason = ReasonBudget
+			result.Omitted = append(result.Omitted, file)
+			return nil
+		}
+		file.Data = data
+		result.Files = append(result.Files, file)
+		result.Tokens += file.Tokens
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	// Set the tree summary
+	if o.tree {
+		result.Tree = result.tree()
+	}
+
+	// Return success
+	return result, nil
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// STRINGIFY
+
+func (c Context) String() string {
+	return fmt.Sprintf("%s (%d files, %d omitted, %d tokens)", c.Root, len(c.Files), len(c.Omitted), c.Tokens)
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// Attachments returns a text attachment for each file which was read, with
+// the path of the file as the URL
+func (c *Context) Attachments() []schema.Attachment {
+	result := make([]schema.Attachment, 0, len(c.Files))
+	for _, file := range c.Files {
+		result = append(result, schema.Attachment{
+			ContentType: "text/plain; charset=utf-8",
+			Data:        file.Data,
+			URL:         &url.URL{Scheme: "file", Path: file.Path},
+		})
+	}
+	return result
+}
+
+// Message returns a request to append the files to a session, with the tree
+// summary as the text, or a line naming the directory when there is no tree
+// summary
+func (c *Context) Message() schema.MessageCreateRequest {
+	text := c.Tree
+	if text == "" {
+		text = fmt.Sprintf("Files from %s (%d files, %d omitted)", c.Root, len(c.Files), len(c.Omitted))
+	}
+	return schema.MessageCreateRequest{
+		Text:        text,
+		Attachments: c.Attachments(),
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// tree returns an indented listing of the files, where omitted files are
+// marked with the reason
+func (c *Context) tree() string {
+	reasons := make(map[string]string, len(c.Files)+len(c.Omitted))
+	paths := make([]string, 0, len(c.Files)+len(c.Omitted))
+	for _, file := range c.Files {
+		paths = append(paths, file.Path)
+	}
+	for _, file := range c.Omitted {
+		reasons[file.Path] = file.Reason
+		paths = append(paths, file.Path)
+	}
+	slices.Sort(paths)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Directory %s (%d files included, %d omitted, %d tokens)\n", filepath.Base(c.Root), len(c.Files), len(c.Omitted), c.Tokens)
+	var prev []string
+	for _, name := range paths {
+		dirs := strings.Split(name, "/")
+		base := dirs[len(dirs)-1]
+		dirs = dirs[:len(dirs)-1]
+
+		// Write the directories which differ from the previous file
+		common := 0
+		for common < len(dirs) && common < len(prev) && dirs[common] == prev[common] {
+			common++
+		}
+		for i := common; i < len(dirs); i++ {
+			fmt.Fprintf(&b, "%s%s/\n", strings.Repeat("  ", i+1), dirs[i])
+		}
+		prev = dirs
+
+		// Write the file
+		fmt.Fprintf(&b, "%s%s", strings.Repeat("  ", len(dirs)+1), base)
+		if reason, exists := reasons[name]; exists {
+			fmt.Fprintf(&b, " (omitted: %s)", reason)
+		}
+		b.WriteString("\n")
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// matchAny returns true if the path, or its name, matches one of the glob
+// patterns, where ** matches any number of directories
+func matchAny(patterns []string, name string) bool {
+	segments := strings.Split(name, "/")
+	for _, pattern := range patterns {
+		if matchSegments(strings.Split(pattern, "/"), segments) {
+			return true
+		}
+		if !strings.Contains(pattern, "/") && matchSegments([]string{pattern}, segments[len(segments)-1:]) {
+			return true
+		}
+	}
+	return false
+}
+
+// isBinary returns true if the data is not valid UTF-8 text, judged from the
+// start of the data
+func isBinary(data []byte) bool {
+	if len(data) > sniffSize {
+		data = data[:sniffSize]
+		// Do not judge a multi-byte character cut at the end
+		for i := 0; i < utf8.UTFMax && len(data) > 0 && !utf8.Valid(data); i++ {
+			data = data[:len(data)-1]
+		}
+	}
+	return bytes.IndexByte(data, 0) >= 0 || !utf8.Valid(data)
+}
@@ -0,0 +1,205 @@
+package codebase_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	// Packages
+	codebase "github.com/mutablelogic/go-llm/pkg/codebase"
+	tokenizer "github.com/mutablelogic/go-llm/pkg/tokenizer"
+	assert "github.com/stretchr/testify/assert"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// HELPERS
+
+func writeFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	root := t.TempDir()
+	for name, data := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+func paths(files []codebase.File) []string {
+	result := make([]string, 0, len(files))
+	for _, file := range files {
+		result = append(result, file.Path)
+	}
+	return result
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// TESTS
+
+func TestReadGitignore(t *testing.T) {
+	assert := assert.New(t)
+	root := writeFiles(t, map[string]string{
+		".gitignore":        "*.log\nbuild/\n/secret.txt\n!keep.log\n",
+		"main.go":           "package main\n",
+		"debug.log":         "log\n",
+		"keep.log":          "keep\n",
+		"secret.txt":        "secret\n",
+		"build/out.txt":     "out\n",
+		"pkg/secret.txt":    "not anchored here\n",
+		"pkg/.gitignore":    "*.tmp\n",
+		"pkg/a.tmp":         "tmp\n",
+		"pkg/util/util.go":  "package util\n",
+		".git/HEAD":         "ref: refs/heads/main\n",
+		"other/b.tmp":       "tmp outside pkg\n",
+		"docs/guide/doc.md": "# Guide\n",
+	})
+
+	result, err := codebase.Read(root)
+	if !assert.NoError(err) {
+		return
+	}
+	assert.Equal([]string{
+		".gitignore", "docs/guide/doc.md", "keep.log", "main.go",
+		"other/b.tmp", "pkg/.gitignore", "pkg/secret.txt", "pkg/util/util.go",
+	}, paths(result.Files))
+	assert.Empty(result.Omitted)
+	assert.Empty(result.Tree)
+}
+
+func TestReadBinary(t *testing.T) {
+	assert := assert.New(t)
+	root := writeFiles(t, map[string]string{
+		"a.txt":   "hello\n",
+		"b.bin":   "\x00\x01\x02",
+		"c.latin": "caf\xe9",
+	})
+
+	result, err := codebase.Read(root)
+	if !assert.NoError(err) {
+		return
+	}
+	assert.Equal([]string{"a.txt"}, paths(result.Files))
+	assert.Equal([]string{"b.bin", "c.latin"}, paths(result.Omitted))
+	assert.Equal(codebase.ReasonBinary, result.Omitted[0].Reason)
+}
+
+func TestReadBudget(t *testing.T) {
+	assert := assert.New(t)
+	root := writeFiles(t, map[string]string{
+		"a.txt": strings.Repeat("word ", 100),
+		"b.txt": strings.Repeat("word ", 100),
+		"c.txt": "short",
+	})
+
+	// Room for one of the long files and the short file
+	long := tokenizer.Count(tokenizer.ForProvider(""), strings.Repeat("word ", 100))
+	result, err := codebase.Read(root, codebase.WithTokens(long+10, nil))
+	if !assert.NoError(err) {
+		return
+	}
+	assert.Equal([]string{"a.txt", "c.txt"}, paths(result.Files))
+	assert.Equal([]string{"b.txt"}, paths(result.Omitted))
+	assert.Equal(codebase.ReasonBudget, result.Omitted[0].Reason)
+	assert.LessOrEqual(result.Tokens, long+10)
+}
+
+func TestReadIncludeExclude(t *testing.T) {
+	assert := assert.New(t)
+	root := writeFiles(t, map[string]string{
+		"main.go":            "package main\n",
+		"main_test.go":       "package main\n",
+		"README.md":          "# Readme\n",
+		"vendor/x/x.go":      "package x\n",
+		"cmd/tool/main.go":   "package main\n",
+		"cmd/tool/README.md": "# Tool\n",
+	})
+
+	result, err := codebase.Read(root, codebase.WithInclude("*.go"), codebase.WithExclude("*_test.go", "vendor"))
+	if !assert.NoError(err) {
+		return
+	}
+	assert.Equal([]string{"cmd/tool/main.go", "main.go"}, paths(result.Files))
+
+	result, err = codebase.Read(root, codebase.WithInclude("cmd/**"))
+	if !assert.NoError(err) {
+		return
+	}
+	assert.Equal([]string{"cmd/tool/README.md", "cmd/tool/main.go"}, paths(result.Files))
+
+	_, err = codebase.Read(root, codebase.WithInclude("["))
+	assert.Error(err)
+}
+
+func TestReadMaxSize(t *testing.T) {
+	assert := assert.New(t)
+	root := writeFiles(t, map[string]string{
+		"small.txt": "small",
+		"large.txt": strings.Repeat("x", 100),
+	})
+
+	result, err := codebase.Read(root, codebase.WithMaxSize(50))
+	if !assert.NoError(err) {
+		return
+	}
+	assert.Equal([]string{"small.txt"}, paths(result.Files))
+	assert.Equal(codebase.ReasonSize, result.Omitted[0].Reason)
+}
+
+func TestReadTree(t *testing.T) {
+	assert := assert.New(t)
+	root := writeFiles(t, map[string]string{
+		"main.go":          "package main\n",
+		"pkg/util/util.go": "package util\n",
+		"pkg/util/data":    "\x00",
+	})
+
+	result, err := codebase.Read(root, codebase.WithTree(true))
+	if !assert.NoError(err) {
+		return
+	}
+	lines := strings.Split(result.Tree, "\n")
+	assert.Equal([]string{
+		"  main.go",
+		"  pkg/",
+		"    util/",
+		"      data (omitted: binary)",
+		"      util.go",
+	}, lines[1:])
+	assert.Contains(lines[0], "2 files included, 1 omitted")
+}
+
+func TestMessage(t *testing.T) {
+	assert := assert.New(t)
+	root := writeFiles(t, map[string]string{
+		"src/main.go": "package main\n",
+	})
+
+	result, err := codebase.Read(root)
+	if !assert.NoError(err) {
+		return
+	}
+	message := result.Message()
+	assert.Contains(message.Text, "1 files")
+	if assert.Len(message.Attachments, 1) {
+		attachment := message.Attachments[0]
+		assert.True(attachment.IsText())
+		assert.Equal("src/main.go", attachment.URL.Path)
+		assert.Contains(attachment.TextContent(), "File: src/main.go")
+		assert.Contains(attachment.TextContent(), "package main")
+	}
+}
+
+func TestReadNotDirectory(t *testing.T) {
+	assert := assert.New(t)
+	root := writeFiles(t, map[string]string{"a.txt": "a"})
+
+	_, err := codebase.Read(filepath.Join(root, "a.txt"))
+	assert.Error(err)
+	_, err = codebase.Read(filepath.Join(root, "missing"))
+	assert.Error(err)
+}
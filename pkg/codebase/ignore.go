@@ -0,0 +1,126 @@
+package codebase
+
+import (
+	"bufio"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// ignore is the rules read from .gitignore files, in the order they apply
+type ignore []rule
+
+// rule is a pattern from a .gitignore file, relative to the directory of
+// the file
+type rule struct {
+	base     string   // directory of the .gitignore file, relative to the root
+	segments []string // pattern split on slashes
+	anchored bool     // pattern matches the path from the base, rather than the name
+	dir      bool     // pattern only matches directories
+	negate   bool     // pattern re-includes a path
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// read appends the rules of the .gitignore file in the directory, which is
+// relative to the root, and returns the rules
+func (i ignore) read(root, dir string) (ignore, error) {
+	f, err := os.Open(filepath.Join(root, filepath.FromSlash(dir), ".gitignore"))
+	if os.IsNotExist(err) {
+		return i, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if rule, ok := parseRule(dir, scanner.Text()); ok {
+			i = append(i, rule)
+		}
+	}
+	return i, scanner.Err()
+}
+
+// match returns true if the path, relative to the root and separated by
+// slashes, is ignored. The last rule which matches applies.
+func (i ignore) match(name string, dir bool) bool {
+	ignored := false
+	for _, rule := range i {
+		if rule.match(name, dir) {
+			ignored = !rule.negate
+		}
+	}
+	return ignored
+}
+
+// parseRule returns the rule for a line of a .gitignore file, or false if
+// the line is blank or a comment
+func parseRule(base, line string) (rule, bool) {
+	line = strings.TrimRight(line, " \t\r")
+	if line == "" || strings.HasPrefix(line, "#") {
+		return rule{}, false
+	}
+	r := rule{base: base}
+	if strings.HasPrefix(line, "!") {
+		r.negate, line = true, line[1:]
+	} else if strings.HasPrefix(line, `\`) {
+		line = line[1:]
+	}
+	if strings.HasSuffix(line, "/") {
+		r.dir, line = true, strings.TrimRight(line, "/")
+	}
+	if strings.Contains(line, "/") {
+		r.anchored, line = true, strings.TrimPrefix(line, "/")
+	}
+	if line == "" {
+		return rule{}, false
+	}
+	r.segments = strings.Split(line, "/")
+	return r, true
+}
+
+// match returns true if the rule matches the path, relative to the root
+func (r rule) match(name string, dir bool) bool {
+	if r.dir && !dir {
+		return false
+	}
+	if r.base != "" {
+		if !strings.HasPrefix(name, r.base+"/") {
+			return false
+		}
+		name = strings.TrimPrefix(name, r.base+"/")
+	}
+	if !r.anchored {
+		return matchSegments(r.segments, []string{path.Base(name)})
+	}
+	return matchSegments(r.segments, strings.Split(name, "/"))
+}
+
+// matchSegments matches path segments against pattern segments, where **
+// matches any number of segments
+func matchSegments(pattern, segments []string) bool {
+	if len(pattern) == 0 {
+		return len(segments) == 0
+	}
+	if pattern[0] == "**" {
+		for i := 0; i <= len(segments); i++ {
+			if matchSegments(pattern[1:], segments[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(segments) == 0 {
+		return false
+	}
+	if ok, err := path.Match(pattern[0], segments[0]); err != nil || !ok {
+		return false
+	}
+	return matchSegments(pattern[1:], segments[1:])
+}
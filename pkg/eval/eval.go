@@ -0,0 +1,431 @@
+// Package eval runs scenarios in which a user converses with an agent for a
+// number of turns, and checks the behaviour of the agent over the whole
+// conversation: the tools which it called, its final reply, and the scores
+// which a judge gives the transcript against a rubric. The user is scripted,
+// simulated by a model, or scripted for the first turns and then simulated.
+package eval
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+
+	// Packages
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	types "github.com/mutablelogic/go-server/pkg/types"
+	yaml "gopkg.in/yaml.v3"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// Scenario is a conversation between a user and an agent, and what is
+// expected of the agent
+type Scenario struct {
+	Name     string               `json:"name" yaml:"name"`
+	Agent    schema.GeneratorMeta `json:"agent" yaml:"agent"`                   // model and settings of the agent
+	Tools    []string             `json:"tools,omitempty" yaml:"tools"`         // tools which the agent may use, or all tools when empty
+	Turns    []string             `json:"turns,omitempty" yaml:"turns"`         // scripted input of the user, in order
+	User     *User                `json:"user,omitempty" yaml:"user"`           // user who is simulated after the scripted turns, or nil
+	MaxTurns uint                 `json:"max_turns,omitempty" yaml:"max_turns"` // maximum number of turns, when the user is simulated
+	Expect   Expect               `json:"expect" yaml:"expect"`                 // what is expected of the agent
+	Judge    schema.GeneratorMeta `json:"judge,omitzero" yaml:"judge"`          // model which scores the rubric, or the agent model
+}
+
+// User is a user who is simulated by a model
+type User struct {
+	schema.GeneratorMeta `yaml:",inline"`
+	Persona              string `json:"persona" yaml:"persona"`     // who the user is, and what they want from the agent
+	Stop                 string `json:"stop,omitempty" yaml:"stop"` // reply of the user when they have what they want
+}
+
+// Expect is what is expected of the agent
+type Expect struct {
+	Tools  []ToolExpect `json:"tools,omitempty" yaml:"tools"`
+	Final  *ReplyExpect `json:"final,omitempty" yaml:"final"`
+	Rubric []Criterion  `json:"rubric,omitempty" yaml:"rubric"`
+}
+
+// ToolExpect is the number of times tools which match a name or glob are
+// called over the conversation. With neither min nor max, the tools must be
+// called at least once.
+type ToolExpect struct {
+	Name string `json:"name" yaml:"name"`
+	Min  *uint  `json:"min,omitempty" yaml:"min"`
+	Max  *uint  `json:"max,omitempty" yaml:"max"`
+}
+
+// ReplyExpect is the text of the final reply of the agent
+type ReplyExpect struct {
+	Contains []string `json:"contains,omitempty" yaml:"contains"` // text which the reply contains, without regard to case
+	Excludes []string `json:"excludes,omitempty" yaml:"excludes"` // text which the reply does not contain, without regard to case
+	Matches  string   `json:"matches,omitempty" yaml:"matches"`   // regular expression which matches the reply
+}
+
+// Criterion is a statement about the conversation which the judge scores
+// from one to five, and the lowest score which passes
+type Criterion struct {
+	Criterion string `json:"criterion" yaml:"criterion"`
+	Min       uint   `json:"min,omitempty" yaml:"min"`
+}
+
+// Reply is the reply of the agent to one turn, with the tools it called
+type Reply struct {
+	Text  string
+	Tools []schema.ToolTrace
+}
+
+// Exchange is one turn of the conversation
+type Exchange struct {
+	User  string   `json:"user"`
+	Agent string   `json:"agent"`
+	Tools []string `json:"tools,omitempty"`
+}
+
+// Score is the score of the judge for a criterion
+type Score struct {
+	Score  uint   `json:"score"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// Check is the result of one expectation
+type Check struct {
+	Name   string `json:"name"`
+	Passed bool   `json:"passed"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// Result is the report for one scenario
+type Result struct {
+	Name     string          `json:"name"`
+	Passed   bool            `json:"passed"`
+	Error    string          `json:"error,omitempty"`
+	Duration time.Duration   `json:"duration_ns"`
+	Turns    []Exchange      `json:"turns"`
+	Tools    map[string]uint `json:"tools,omitempty"`
+	Checks   []Check         `json:"checks,omitempty"`
+}
+
+// AgentFn sends the input of the user to the agent, and returns its reply
+type AgentFn func(ctx context.Context, text string) (Reply, error)
+
+// UserFn returns the next input of the simulated user from the transcript
+// so far, in which the last turn is the last reply of the agent
+type UserFn func(ctx context.Context, user User, transcript []Exchange) (string, error)
+
+// JudgeFn scores the transcript against each criterion, and returns the
+// scores in the same order as the criteria
+type JudgeFn func(ctx context.Context, criteria []string, transcript []Exchange) ([]Score, error)
+
+///////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+const (
+	defaultMaxTurns = 10 // maximum number of turns with a simulated user
+	defaultMinScore = 3  // lowest score of a criterion which passes
+	maxScore        = 5  // highest score of a criterion
+)
+
+// DefaultStop is the reply of a simulated user who has what they want, when
+// the user has no stop text
+const DefaultStop = "[DONE]"
+
+///////////////////////////////////////////////////////////////////////////////
+// LIFECYCLE
+
+// Read returns a scenario from YAML, and validates it
+func Read(r io.Reader) (Scenario, error) {
+	var scenario Scenario
+	if err := yaml.NewDecoder(r).Decode(&scenario); err != nil {
+		return Scenario{}, fmt.Errorf("eval: %w", err)
+	}
+	if err := scenario.Validate(); err != nil {
+		return Scenario{}, err
+	}
+	return scenario, nil
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// STRINGIFY
+
+func (s Scenario) String() string {
+	return types.Stringify(s)
+}
+
+func (r Result) String() string {
+	return types.Stringify(r)
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// Validate returns an error if the scenario has no turns, or an expectation
+// cannot be checked
+func (s Scenario) Validate() error {
+	if strings.TrimSpace(s.Name) == "" {
+		return fmt.Errorf("eval: scenario requires a name")
+	} else if len(s.Turns) == 0 && s.User == nil {
+		return fmt.Errorf("eval: %s: scenario requires scripted turns or a simulated user", s.Name)
+	} else if s.User != nil && strings.TrimSpace(s.User.Persona) == "" {
+		return fmt.Errorf("eval: %s: simulated user requires a persona", s.Name)
+	}
+	for _, tool := range s.Expect.Tools {
+		if _, err := path.Match(tool.Name, ""); err != nil || tool.Name == "" {
+			return fmt.Errorf("eval: %s: invalid tool pattern %q", s.Name, tool.Name)
+		} else if tool.Min != nil && tool.Max != nil && *tool.Min > *tool.Max {
+			return fmt.Errorf("eval: %s: tool %q has min greater than max", s.Name, tool.Name)
+		}
+	}
+	if final := s.Expect.Final; final != nil && final.Matches != "" {
+		if _, err := regexp.Compile(final.Matches); err != nil {
+			return fmt.Errorf("eval: %s: %w", s.Name, err)
+		}
+	}
+	for _, criterion := range s.Expect.Rubric {
+		if strings.TrimSpace(criterion.Criterion) == "" {
+			return fmt.Errorf("eval: %s: rubric criterion is empty", s.Name)
+		} else if criterion.Min > maxScore {
+			return fmt.Errorf("eval: %s: rubric minimum must be at most %d", s.Name, maxScore)
+		}
+	}
+	return nil
+}
+
+// Run converses with the agent for the scripted turns, and then for the
+// turns of the simulated user until they stop or the maximum number of turns
+// is reached, and checks the expectations. The user function is only called
+// when the user is simulated, and the judge function when there is a rubric.
+func Run(ctx context.Context, scenario Scenario, agent AgentFn, user UserFn, judge JudgeFn) Result {
+	start := time.Now()
+	result := Result{Name: scenario.Name, Tools: make(map[string]uint)}
+	err := result.converse(ctx, scenario, agent, user)
+	if err == nil {
+		err = result.check(ctx, scenario.Expect, judge)
+	}
+	if err != nil {
+		result.Error = err.Error()
+	}
+	result.Passed = err == nil
+	for _, check := range result.Checks {
+		result.Passed = result.Passed && check.Passed
+	}
+	result.Duration = time.Since(start)
+	return result
+}
+
+// Markdown writes the results as a markdown table, followed by the checks
+// which failed
+func Markdown(w io.Writer, results []Result) error {
+	var b strings.Builder
+	b.WriteString("| Scenario | Result | Turns | Tool calls | Checks | Duration |\n")
+	b.WriteString("|---|---|--:|--:|--:|--:|\n")
+	for _, result := range results {
+		status := "pass"
+		if !result.Passed {
+			status = "**fail**"
+		}
+		var calls uint
+		for _, n := range result.Tools {
+			calls += n
+		}
+		passed := 0
+		for _, check := range result.Checks {
+			if check.Passed {
+				passed++
+			}
+		}
+		fmt.Fprintf(&b, "| %s | %s | %d | %d | %d/%d | %s |\n",
+			result.Name, status, len(result.Turns), calls, passed, len(result.Checks), result.Duration.Round(time.Millisecond))
+	}
+
+	// Add the failures of each scenario
+	for _, result := range results {
+		if result.Passed {
+			continue
+		}
+		fmt.Fprintf(&b, "\nFailures for %s:\n\n", result.Name)
+		if result.Error != "" {
+			fmt.Fprintf(&b, "- error: %s\n", oneLine(result.Error))
+		}
+		for _, check := range result.Checks {
+			if !check.Passed {
+				fmt.Fprintf(&b, "- %s: %s\n", check.Name, oneLine(check.Detail))
+			}
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// converse runs the turns of the conversation
+func (r *Result) converse(ctx context.Context, scenario Scenario, agent AgentFn, user UserFn) error {
+	turns := uint(len(scenario.Turns))
+	if scenario.User != nil {
+		turns = max(scenario.MaxTurns, turns)
+		if scenario.MaxTurns == 0 {
+			turns = max(defaultMaxTurns, uint(len(scenario.Turns)))
+		}
+	}
+	stop := DefaultStop
+	if scenario.User != nil && strings.TrimSpace(scenario.User.Stop) != "" {
+		stop = strings.TrimSpace(scenario.User.Stop)
+	}
+	for i := range turns {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		// Script the input of the user, or simulate it
+		var text string
+		if i < uint(len(scenario.Turns)) {
+			text = scenario.Turns[i]
+		} else if user == nil {
+			return fmt.Errorf("turn %d: no simulated user", i+1)
+		} else if input, err := user(ctx, *scenario.User, r.Turns); err != nil {
+			return fmt.Errorf("turn %d: user: %w", i+1, err)
+		} else if text = strings.TrimSpace(input); text == "" || strings.Contains(text, stop) {
+			break
+		}
+
+		// Send it to the agent
+		reply, err := agent(ctx, text)
+		if err != nil {
+			return fmt.Errorf("turn %d: agent: %w", i+1, err)
+		}
+		exchange := Exchange{User: text, Agent: reply.Text}
+		for _, call := range reply.Tools {
+			exchange.Tools = append(exchange.Tools, call.Name)
+			r.Tools[call.Name]++
+		}
+		r.Turns = append(r.Turns, exchange)
+	}
+	if len(r.Turns) == 0 {
+		return fmt.Errorf("no turns")
+	}
+	return nil
+}
+
+// check checks the expectations against the conversation
+func (r *Result) check(ctx context.Context, expect Expect, judge JudgeFn) error {
+	for _, tool := range expect.Tools {
+		r.Checks = append(r.Checks, r.checkTool(tool))
+	}
+	if expect.Final != nil {
+		r.Checks = append(r.Checks, checkReply(*expect.Final, r.Turns[len(r.Turns)-1].Agent)...)
+	}
+	if len(expect.Rubric) == 0 {
+		return nil
+	} else if judge == nil {
+		return fmt.Errorf("no judge for the rubric")
+	}
+
+	// Score the transcript against the rubric
+	criteria := make([]string, 0, len(expect.Rubric))
+	for _, criterion := range expect.Rubric {
+		criteria = append(criteria, criterion.Criterion)
+	}
+	scores, err := judge(ctx, criteria, r.Turns)
+	if err != nil {
+		return fmt.Errorf("judge: %w", err)
+	} else if len(scores) != len(criteria) {
+		return fmt.Errorf("judge: expected %d scores, got %d", len(criteria), len(scores))
+	}
+	for i, criterion := range expect.Rubric {
+		min := criterion.Min
+		if min == 0 {
+			min = defaultMinScore
+		}
+		detail := fmt.Sprintf("scored %d of %d, needs %d", scores[i].Score, maxScore, min)
+		if reason := strings.TrimSpace(scores[i].Reason); reason != "" {
+			detail += ": " + reason
+		}
+		r.Checks = append(r.Checks, Check{
+			Name:   "rubric: " + criterion.Criterion,
+			Passed: scores[i].Score >= min,
+			Detail: detail,
+		})
+	}
+	return nil
+}
+
+// checkTool checks the number of calls to the tools which match the name
+func (r *Result) checkTool(expect ToolExpect) Check {
+	var calls uint
+	for name, n := range r.Tools {
+		if matchTool(expect.Name, name) {
+			calls += n
+		}
+	}
+	check := Check{Name: "tool: " + expect.Name, Passed: true, Detail: fmt.Sprintf("called %d times", calls)}
+	switch {
+	case expect.Min == nil && expect.Max == nil:
+		check.Passed = calls > 0
+	case expect.Min != nil && calls < *expect.Min:
+		check.Passed = false
+		check.Detail += fmt.Sprintf(", expected at least %d", *expect.Min)
+	case expect.Max != nil && calls > *expect.Max:
+		check.Passed = false
+		check.Detail += fmt.Sprintf(", expected at most %d", *expect.Max)
+	}
+	return check
+}
+
+// checkReply checks the text of the final reply
+func checkReply(expect ReplyExpect, text string) []Check {
+	var checks []Check
+	lower := strings.ToLower(text)
+	for _, s := range expect.Contains {
+		check := Check{Name: fmt.Sprintf("final contains %q", s), Passed: strings.Contains(lower, strings.ToLower(s))}
+		if !check.Passed {
+			check.Detail = truncate(text)
+		}
+		checks = append(checks, check)
+	}
+	for _, s := range expect.Excludes {
+		check := Check{Name: fmt.Sprintf("final excludes %q", s), Passed: !strings.Contains(lower, strings.ToLower(s))}
+		if !check.Passed {
+			check.Detail = truncate(text)
+		}
+		checks = append(checks, check)
+	}
+	if expect.Matches != "" {
+		check := Check{Name: fmt.Sprintf("final matches %q", expect.Matches), Passed: regexp.MustCompile(expect.Matches).MatchString(text)}
+		if !check.Passed {
+			check.Detail = truncate(text)
+		}
+		checks = append(checks, check)
+	}
+	return checks
+}
+
+// matchTool returns true if the tool name, or the name with its namespace
+// separated by a dot, matches the pattern
+func matchTool(pattern, name string) bool {
+	if ok, _ := path.Match(pattern, name); ok {
+		return true
+	}
+	ok, _ := path.Match(pattern, strings.Replace(name, "__", ".", 1))
+	return ok
+}
+
+// truncate returns the start of the text, for a failed check
+func truncate(text string) string {
+	const n = 200
+	if runes := []rune(text); len(runes) > n {
+		return string(runes[:n]) + "…"
+	}
+	return text
+}
+
+// oneLine returns the text on one line
+func oneLine(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
@@ -0,0 +1,191 @@
+package eval_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	// Packages
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	eval "github.com/mutablelogic/go-llm/pkg/eval"
+	types "github.com/mutablelogic/go-server/pkg/types"
+	assert "github.com/stretchr/testify/assert"
+)
+
+const scenarioYAML = `
+name: refund
+agent:
+  model: claude-sonnet-4
+  system_prompt: You are a support agent.
+tools: ["builtin.*"]
+turns:
+  - I want a refund for order 42.
+user:
+  model: gemini-2.5-flash
+  persona: A customer who wants a refund, and gives the order number when asked.
+max_turns: 4
+expect:
+  tools:
+    - name: builtin.refund
+      min: 1
+      max: 1
+  final:
+    contains: ["refund"]
+  rubric:
+    - criterion: The agent is polite.
+      min: 4
+`
+
+func TestRead(t *testing.T) {
+	assert := assert.New(t)
+
+	scenario, err := eval.Read(strings.NewReader(scenarioYAML))
+	if !assert.NoError(err) {
+		return
+	}
+	assert.Equal("refund", scenario.Name)
+	assert.Equal("claude-sonnet-4", types.Value(scenario.Agent.Model))
+	assert.Equal([]string{"builtin.*"}, scenario.Tools)
+	assert.Len(scenario.Turns, 1)
+	if assert.NotNil(scenario.User) {
+		assert.Equal("gemini-2.5-flash", types.Value(scenario.User.Model))
+		assert.Contains(scenario.User.Persona, "customer")
+	}
+	assert.Equal(uint(4), scenario.MaxTurns)
+	if assert.Len(scenario.Expect.Tools, 1) {
+		assert.Equal(uint(1), types.Value(scenario.Expect.Tools[0].Min))
+	}
+	assert.Equal(uint(4), scenario.Expect.Rubric[0].Min)
+
+	// A scenario needs turns or a simulated user
+	_, err = eval.Read(strings.NewReader("name: empty\n"))
+	assert.Error(err)
+
+	// Expectations are validated
+	_, err = eval.Read(strings.NewReader("name: bad\nturns: [hi]\nexpect:\n  final:\n    matches: \"(\"\n"))
+	assert.Error(err)
+	_, err = eval.Read(strings.NewReader("name: bad\nturns: [hi]\nexpect:\n  rubric:\n    - criterion: x\n      min: 6\n"))
+	assert.Error(err)
+}
+
+func TestRunScripted(t *testing.T) {
+	assert := assert.New(t)
+
+	scenario := eval.Scenario{
+		Name:  "scripted",
+		Turns: []string{"hello", "search for bicycles"},
+		Expect: eval.Expect{
+			Tools: []eval.ToolExpect{
+				{Name: "builtin.search", Min: types.Ptr(uint(1))},
+				{Name: "builtin.delete", Max: types.Ptr(uint(0))},
+			},
+			Final: &eval.ReplyExpect{Contains: []string{"BICYCLES"}, Excludes: []string{"sorry"}, Matches: `\d+ results`},
+		},
+	}
+	agent := func(_ context.Context, text string) (eval.Reply, error) {
+		if strings.HasPrefix(text, "search") {
+			return eval.Reply{Text: "Found 3 results about bicycles.", Tools: []schema.ToolTrace{{Name: "builtin__search"}}}, nil
+		}
+		return eval.Reply{Text: "Hi!"}, nil
+	}
+
+	result := eval.Run(context.Background(), scenario, agent, nil, nil)
+	assert.True(result.Passed, result.String())
+	assert.Len(result.Turns, 2)
+	assert.Equal("hello", result.Turns[0].User)
+	assert.Equal([]string{"builtin__search"}, result.Turns[1].Tools)
+	assert.Equal(uint(1), result.Tools["builtin__search"])
+	assert.Len(result.Checks, 5)
+}
+
+func TestRunSimulated(t *testing.T) {
+	assert := assert.New(t)
+
+	scenario := eval.Scenario{
+		Name:     "simulated",
+		Turns:    []string{"I need help"},
+		User:     &eval.User{Persona: "A customer"},
+		MaxTurns: 5,
+		Expect: eval.Expect{
+			Rubric: []eval.Criterion{{Criterion: "helpful"}, {Criterion: "brief", Min: 5}},
+		},
+	}
+	agent := func(_ context.Context, text string) (eval.Reply, error) {
+		return eval.Reply{Text: "reply to " + text}, nil
+	}
+
+	// The simulated user stops after two turns of their own
+	var calls int
+	user := func(_ context.Context, user eval.User, transcript []eval.Exchange) (string, error) {
+		calls++
+		assert.Equal("A customer", user.Persona)
+		assert.Len(transcript, calls)
+		if calls > 2 {
+			return "Thanks! [DONE]", nil
+		}
+		return "more", nil
+	}
+	judge := func(_ context.Context, criteria []string, transcript []eval.Exchange) ([]eval.Score, error) {
+		assert.Equal([]string{"helpful", "brief"}, criteria)
+		assert.Len(transcript, 3)
+		return []eval.Score{{Score: 3}, {Score: 4, Reason: "a little long"}}, nil
+	}
+
+	result := eval.Run(context.Background(), scenario, agent, user, judge)
+	assert.False(result.Passed)
+	assert.Empty(result.Error)
+	assert.Len(result.Turns, 3)
+	if assert.Len(result.Checks, 2) {
+		assert.True(result.Checks[0].Passed)
+		assert.False(result.Checks[1].Passed)
+		assert.Contains(result.Checks[1].Detail, "a little long")
+	}
+
+	// The simulated user is limited to the maximum number of turns
+	result = eval.Run(context.Background(), scenario, agent, func(context.Context, eval.User, []eval.Exchange) (string, error) {
+		return "more", nil
+	}, func(context.Context, []string, []eval.Exchange) ([]eval.Score, error) {
+		return []eval.Score{{Score: 5}, {Score: 5}}, nil
+	})
+	assert.True(result.Passed, result.String())
+	assert.Len(result.Turns, 5)
+}
+
+func TestRunError(t *testing.T) {
+	assert := assert.New(t)
+
+	scenario := eval.Scenario{
+		Name:   "error",
+		Turns:  []string{"hello"},
+		Expect: eval.Expect{Rubric: []eval.Criterion{{Criterion: "helpful"}}},
+	}
+	agent := func(context.Context, string) (eval.Reply, error) {
+		return eval.Reply{}, errors.New("unavailable")
+	}
+	result := eval.Run(context.Background(), scenario, agent, nil, nil)
+	assert.False(result.Passed)
+	assert.Contains(result.Error, "unavailable")
+
+	// A rubric needs a judge
+	agent = func(context.Context, string) (eval.Reply, error) {
+		return eval.Reply{Text: "hi"}, nil
+	}
+	result = eval.Run(context.Background(), scenario, agent, nil, nil)
+	assert.False(result.Passed)
+	assert.Contains(result.Error, "judge")
+}
+
+func TestMarkdown(t *testing.T) {
+	assert := assert.New(t)
+
+	var buf bytes.Buffer
+	assert.NoError(eval.Markdown(&buf, []eval.Result{
+		{Name: "ok", Passed: true, Turns: make([]eval.Exchange, 2), Checks: []eval.Check{{Name: "tool: x", Passed: true}}},
+		{Name: "bad", Turns: make([]eval.Exchange, 1), Checks: []eval.Check{{Name: "tool: y", Detail: "called 0 times"}}},
+	}))
+	assert.Contains(buf.String(), "| ok | pass | 2 | 0 | 1/1 |")
+	assert.Contains(buf.String(), "| bad | **fail** | 1 | 0 | 0/1 |")
+	assert.Contains(buf.String(), "- tool: y: called 0 times")
+}
@@ -0,0 +1,124 @@
+package git
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// Split divides a patch into chunks of at most max bytes, keeping the
+// changes to each file together where possible. The changes to a file which
+// is larger than max are split between hunks, and each part repeats the
+// header for the file. A single hunk larger than max is truncated.
+func Split(patch string, max int) []string {
+	if max <= 0 {
+		max = maxPatch
+	}
+	var chunks []string
+	var chunk strings.Builder
+	add := func(text string) {
+		if chunk.Len() > 0 && chunk.Len()+len(text) > max {
+			chunks = append(chunks, chunk.String())
+			chunk.Reset()
+		}
+		if len(text) > max {
+			text = truncate(text, max)
+		}
+		chunk.WriteString(text)
+	}
+	for _, file := range sections(patch, "diff --git ") {
+		if len(file) <= max {
+			add(file)
+			continue
+		}
+		hunks := sections(file, "@@ ")
+		header := hunks[0]
+		for _, hunk := range hunks[1:] {
+			add(header + hunk)
+		}
+	}
+	if chunk.Len() > 0 {
+		chunks = append(chunks, chunk.String())
+	}
+	return chunks
+}
+
+// Number prefixes the lines of each hunk in a patch with their line number
+// in the new version of the file, so that comments can refer to them.
+// Removed lines have no number.
+func Number(patch string) string {
+	var result strings.Builder
+	line, inHunk := 0, false
+	for _, text := range strings.SplitAfter(patch, "\n") {
+		switch {
+		case text == "":
+			continue
+		case strings.HasPrefix(text, "diff --git "):
+			inHunk = false
+		case strings.HasPrefix(text, "@@ "):
+			line, inHunk = hunkStart(text), true
+			result.WriteString(text)
+			continue
+		}
+		if !inHunk {
+			result.WriteString(text)
+			continue
+		}
+		switch text[0] {
+		case '+', ' ':
+			fmt.Fprintf(&result, "%5d %s", line, text)
+			line++
+		default:
+			fmt.Fprintf(&result, "%5s %s", "", text)
+		}
+	}
+	return result.String()
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// sections splits text before each line which starts with prefix. The first
+// section contains any text before the first such line.
+func sections(text, prefix string) []string {
+	var result []string
+	start := 0
+	for i := 0; i < len(text); {
+		end := strings.IndexByte(text[i:], '\n')
+		if end < 0 {
+			end = len(text)
+		} else {
+			end += i + 1
+		}
+		if i > 0 && strings.HasPrefix(text[i:], prefix) {
+			result = append(result, text[start:i])
+			start = i
+		}
+		i = end
+	}
+	if start < len(text) || len(result) == 0 {
+		result = append(result, text[start:])
+	}
+	if len(result) > 0 && result[0] == "" {
+		result = result[1:]
+	}
+	return result
+}
+
+// hunkStart returns the first line of the new file in a hunk header such as
+// "@@ -1,4 +10,6 @@"
+func hunkStart(header string) int {
+	fields := strings.Fields(header)
+	if len(fields) < 3 || !strings.HasPrefix(fields[2], "+") {
+		return 0
+	}
+	start, _, _ := strings.Cut(fields[2][1:], ",")
+	n, err := strconv.Atoi(start)
+	if err != nil {
+		return 0
+	}
+	return n
+}
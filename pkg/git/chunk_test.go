@@ -0,0 +1,48 @@
+package git_test
+
+import (
+	"strings"
+	"testing"
+
+	// Packages
+	git "github.com/mutablelogic/go-llm/pkg/git"
+	assert "github.com/stretchr/testify/assert"
+)
+
+const testPatch = `diff --git a/a.txt b/a.txt
+--- a/a.txt
++++ b/a.txt
+@@ -1,2 +1,2 @@
+ one
+-two
++TWO
+diff --git a/b.txt b/b.txt
+--- a/b.txt
++++ b/b.txt
+@@ -1 +1 @@
+-x
++y
+@@ -10,2 +10,3 @@
+ ten
++new
+ eleven
+`
+
+func TestSplitKeepsFilesTogether(t *testing.T) {
+	assert.Equal(t, []string{testPatch}, git.Split(testPatch, 1<<10))
+
+	chunks := git.Split(testPatch, 100)
+	assert.Len(t, chunks, 3)
+	assert.True(t, strings.HasPrefix(chunks[0], "diff --git a/a.txt"))
+	for _, chunk := range chunks[1:] {
+		assert.True(t, strings.HasPrefix(chunk, "diff --git a/b.txt b/b.txt\n--- a/b.txt\n+++ b/b.txt\n@@ "))
+	}
+	assert.Contains(t, chunks[2], "+new")
+	assert.Empty(t, git.Split("", 100))
+}
+
+func TestNumber(t *testing.T) {
+	numbered := git.Number(testPatch)
+	assert.Contains(t, numbered, "+++ b/a.txt\n@@ -1,2 +1,2 @@\n    1  one\n      -two\n    2 +TWO\n")
+	assert.Contains(t, numbered, "   10  ten\n   11 +new\n   12  eleven\n")
+}
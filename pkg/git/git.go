@@ -0,0 +1,241 @@
+/*
+git reads changes from local git repositories, for tools and commands which
+describe or review them. Repositories are opened within a root directory,
+and paths which resolve outside the root are rejected.
+*/
+package git
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	// Packages
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// Repo is a git working tree
+type Repo struct {
+	root string
+}
+
+// DiffRequest selects the changes to return
+type DiffRequest struct {
+	Staged  bool     `json:"staged,omitempty" help:"Return staged changes, rather than unstaged changes in the working tree"`
+	Base    string   `json:"base,omitempty" help:"Return the changes on the current branch since it diverged from this branch or commit, such as main"`
+	Paths   []string `json:"paths,omitempty" help:"Limit the diff to these files or directories, relative to the repository"`
+	Context uint     `json:"context,omitempty" help:"Lines of context around each change (defaults to 3)"`
+}
+
+// Diff is the result of a diff
+type Diff struct {
+	Stat      string `json:"stat"`
+	Patch     string `json:"patch"`
+	Truncated bool   `json:"truncated,omitempty"`
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+// maxPatch is the default limit on the size of a patch in bytes
+const maxPatch = 256 << 10
+
+///////////////////////////////////////////////////////////////////////////////
+// LIFECYCLE
+
+// Open returns the repository at path, which must be root or a directory
+// within it
+func Open(ctx context.Context, root, path string) (*Repo, error) {
+	dir, err := within(root, path)
+	if err != nil {
+		return nil, err
+	}
+
+	// The top of the working tree must also be within the root
+	repo, err := Find(ctx, dir)
+	if err != nil {
+		return nil, err
+	} else if _, err := within(root, repo.root); err != nil {
+		return nil, err
+	}
+
+	// Return the repository
+	return repo, nil
+}
+
+// Find returns the repository which contains path, without restricting it
+// to a root directory
+func Find(ctx context.Context, path string) (*Repo, error) {
+	top, err := run(ctx, path, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, schema.ErrBadParameter.Withf("%q is not a git repository", path)
+	}
+	return &Repo{root: strings.TrimSpace(top)}, nil
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// Root returns the top directory of the working tree
+func (r *Repo) Root() string {
+	return r.root
+}
+
+// Diff returns the changes selected by the request. The patch is truncated
+// at max bytes, or a default limit when max is zero.
+func (r *Repo) Diff(ctx context.Context, req DiffRequest, max int) (*Diff, error) {
+	args := []string{"diff", "--no-color", "--no-ext-diff"}
+	if req.Context > 0 {
+		args = append(args, "--unified="+strconv.FormatUint(uint64(req.Context), 10))
+	}
+	switch {
+	case req.Base != "" && req.Staged:
+		return nil, schema.ErrBadParameter.With("staged and base cannot both be set")
+	case req.Base != "":
+		if err := checkRef(req.Base); err != nil {
+			return nil, err
+		}
+		args = append(args, req.Base+"...HEAD")
+	case req.Staged:
+		args = append(args, "--cached")
+	}
+
+	// Paths are relative to the repository, and must be within it
+	paths := []string{"--"}
+	for _, path := range req.Paths {
+		abs, err := within(r.root, filepath.Join(r.root, path))
+		if err != nil {
+			return nil, err
+		}
+		paths = append(paths, abs)
+	}
+
+	// Return the summary and the patch
+	stat, err := run(ctx, r.root, append(append(args, "--stat"), paths...)...)
+	if err != nil {
+		return nil, err
+	}
+	patch, err := run(ctx, r.root, append(args, paths...)...)
+	if err != nil {
+		return nil, err
+	}
+	if max <= 0 {
+		max = maxPatch
+	}
+	diff := &Diff{Stat: strings.TrimRight(stat, "\n"), Patch: patch}
+	if len(patch) > max {
+		diff.Patch, diff.Truncated = truncate(patch, max), true
+	}
+	return diff, nil
+}
+
+// Empty returns true if there are no changes
+func (d *Diff) Empty() bool {
+	return strings.TrimSpace(d.Patch) == ""
+}
+
+// Commit records the staged changes with a message. When edit is true, the
+// editor for git is opened with the message so it can be changed first.
+func (r *Repo) Commit(ctx context.Context, message string, edit bool) error {
+	file, err := os.CreateTemp("", "commit-*.txt")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+	if _, err := file.WriteString(message); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+
+	args := []string{"-C", r.root, "commit", "-F", file.Name()}
+	if edit {
+		args = append(args, "--edit")
+	}
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	return cmd.Run()
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// within returns the absolute path, with symbolic links resolved, if it is
+// the root or within it
+func within(root, path string) (string, error) {
+	root, err := resolve(root)
+	if err != nil {
+		return "", err
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(root, path)
+	}
+	path, err = resolve(path)
+	if err != nil {
+		return "", err
+	}
+	if rel, err := filepath.Rel(root, path); err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", schema.ErrBadParameter.Withf("%q is outside %q", path, root)
+	}
+	return path, nil
+}
+
+// resolve returns an absolute path with symbolic links resolved. A path which
+// does not exist, such as a deleted file, is resolved from its parent.
+func resolve(path string) (string, error) {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		return resolved, nil
+	} else if !os.IsNotExist(err) {
+		return "", err
+	}
+	parent, err := resolve(filepath.Dir(path))
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(parent, filepath.Base(path)), nil
+}
+
+// checkRef returns an error if a branch or commit could be read as an option
+// or a range
+func checkRef(ref string) error {
+	if strings.HasPrefix(ref, "-") || strings.Contains(ref, "..") || strings.ContainsAny(ref, " \t\n:") {
+		return schema.ErrBadParameter.Withf("invalid branch or commit %q", ref)
+	}
+	return nil
+}
+
+// run runs a git command in a directory and returns the output
+func run(ctx context.Context, dir string, args ...string) (string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "git", append([]string{"-C", dir}, args...)...)
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", schema.ErrBadParameter.Withf("git %s: %s", args[0], msg)
+		}
+		return "", err
+	}
+	return stdout.String(), nil
+}
+
+// truncate cuts text at the last line break before max bytes
+func truncate(text string, max int) string {
+	text = text[:max]
+	if i := strings.LastIndexByte(text, '\n'); i > 0 {
+		text = text[:i+1]
+	}
+	return text
+}
@@ -0,0 +1,126 @@
+package git_test
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	// Packages
+	git "github.com/mutablelogic/go-llm/pkg/git"
+	assert "github.com/stretchr/testify/assert"
+)
+
+// newRepo returns a root directory with a repository in the "repo"
+// subdirectory, which has one commit on main and a staged change
+func newRepo(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	root := t.TempDir()
+	dir := filepath.Join(root, "repo")
+	run := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+		cmd.Env = append(os.Environ(), "GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com", "GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com")
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v: %s", args, err, out)
+		}
+	}
+	write := func(name, text string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(text), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Mkdir(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	run("init", "-q", "-b", "main")
+	write("a.txt", "one\n")
+	run("add", "a.txt")
+	run("commit", "-q", "-m", "first")
+	run("checkout", "-q", "-b", "feature")
+	write("b.txt", "two\n")
+	run("add", "b.txt")
+	run("commit", "-q", "-m", "second")
+	write("a.txt", "one\nstaged\n")
+	run("add", "a.txt")
+	write("a.txt", "one\nstaged\nunstaged\n")
+	return root
+}
+
+func Test_git_001(t *testing.T) {
+	assert := assert.New(t)
+	root := newRepo(t)
+	repo, err := git.Open(context.Background(), root, "repo")
+	if !assert.NoError(err) {
+		t.FailNow()
+	}
+
+	// Staged changes
+	diff, err := repo.Diff(context.Background(), git.DiffRequest{Staged: true}, 0)
+	assert.NoError(err)
+	assert.Contains(diff.Patch, "+staged")
+	assert.NotContains(diff.Patch, "+unstaged")
+	assert.Contains(diff.Stat, "a.txt")
+
+	// Unstaged changes
+	diff, err = repo.Diff(context.Background(), git.DiffRequest{}, 0)
+	assert.NoError(err)
+	assert.Contains(diff.Patch, "+unstaged")
+
+	// Changes on the branch
+	diff, err = repo.Diff(context.Background(), git.DiffRequest{Base: "main"}, 0)
+	assert.NoError(err)
+	assert.Contains(diff.Patch, "+two")
+	assert.NotContains(diff.Patch, "staged")
+
+	// Truncated patch
+	diff, err = repo.Diff(context.Background(), git.DiffRequest{Base: "main"}, 20)
+	assert.NoError(err)
+	assert.True(diff.Truncated)
+	assert.LessOrEqual(len(diff.Patch), 20)
+}
+
+func Test_git_002(t *testing.T) {
+	assert := assert.New(t)
+	root := newRepo(t)
+
+	// Repositories and paths outside the root are rejected
+	_, err := git.Open(context.Background(), filepath.Join(root, "repo", "sub"), ".")
+	assert.Error(err)
+	_, err = git.Open(context.Background(), root, "..")
+	assert.Error(err)
+	repo, err := git.Open(context.Background(), root, "repo")
+	if !assert.NoError(err) {
+		t.FailNow()
+	}
+	_, err = repo.Diff(context.Background(), git.DiffRequest{Paths: []string{"../../etc"}}, 0)
+	assert.Error(err)
+
+	// Refs which could be read as options are rejected
+	_, err = repo.Diff(context.Background(), git.DiffRequest{Base: "--output=/tmp/x"}, 0)
+	assert.Error(err)
+	_, err = repo.Diff(context.Background(), git.DiffRequest{Base: "main", Staged: true}, 0)
+	assert.Error(err)
+}
+
+func Test_git_003(t *testing.T) {
+	assert := assert.New(t)
+	root := newRepo(t)
+	tools, err := git.NewTools(root)
+	if !assert.NoError(err) || !assert.Len(tools, 1) {
+		t.FailNow()
+	}
+	assert.Equal("git_diff", tools[0].Name())
+	assert.True(tools[0].Meta().ReadOnlyHint)
+
+	result, err := tools[0].Run(context.Background(), json.RawMessage(`{"repository":"repo","staged":true}`))
+	if assert.NoError(err) {
+		assert.Contains(result.(*git.Diff).Patch, "+staged")
+	}
+}
@@ -0,0 +1,76 @@
+package git
+
+import (
+	"context"
+	"encoding/json"
+
+	// Packages
+	llm "github.com/mutablelogic/go-llm"
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	tool "github.com/mutablelogic/go-llm/toolkit/tool"
+	jsonschema "github.com/mutablelogic/go-server/pkg/jsonschema"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+type diffTool struct {
+	tool.Base
+	root string
+}
+
+type diffToolRequest struct {
+	Repository string `json:"repository,omitempty" help:"Path of the repository, relative to the root directory (defaults to the root)"`
+	DiffRequest
+}
+
+var _ llm.Tool = (*diffTool)(nil)
+
+///////////////////////////////////////////////////////////////////////////////
+// LIFECYCLE
+
+// NewTools returns tools which read repositories within the root directory
+func NewTools(root string) ([]llm.Tool, error) {
+	if _, err := resolve(root); err != nil {
+		return nil, err
+	}
+	return []llm.Tool{
+		&diffTool{root: root},
+	}, nil
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// llm.Tool INTERFACE
+
+func (*diffTool) Name() string {
+	return "git_diff"
+}
+
+func (*diffTool) Description() string {
+	return "Return the changes in a git repository as a unified diff with a summary of changed files. By default returns unstaged changes; set staged for the changes to be committed, or base for the changes on the current branch."
+}
+
+func (*diffTool) InputSchema() *jsonschema.Schema {
+	return jsonschema.MustFor[diffToolRequest]()
+}
+
+func (*diffTool) Meta() llm.ToolMeta {
+	return llm.ToolMeta{Title: "Git Diff", ReadOnlyHint: true, IdempotentHint: true}
+}
+
+func (t *diffTool) Run(ctx context.Context, input json.RawMessage) (any, error) {
+	var req diffToolRequest
+	if len(input) > 0 {
+		if err := json.Unmarshal(input, &req); err != nil {
+			return nil, schema.ErrBadParameter.Withf("failed to unmarshal input: %v", err)
+		}
+	}
+	if req.Repository == "" {
+		req.Repository = "."
+	}
+	repo, err := Open(ctx, t.root, req.Repository)
+	if err != nil {
+		return nil, err
+	}
+	return repo.Diff(ctx, req.DiffRequest, 0)
+}
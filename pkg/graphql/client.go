@@ -0,0 +1,256 @@
+// Package graphql provides tools which describe and query a GraphQL
+// endpoint. The schema of the endpoint is read by introspection, and
+// queries are checked against it before they are sent: only queries are
+// allowed, their depth and number of fields are limited, and the fields
+// they select can be restricted to an allowlist. Results are made compact
+// for the model by removing empty values and connection wrappers.
+package graphql
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	// Packages
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// Client queries a GraphQL endpoint
+type Client struct {
+	url    string
+	client *http.Client
+	token  TokenFn
+
+	mu     sync.Mutex
+	schema *Schema
+}
+
+// Opt configures a Client
+type Opt func(*Client) error
+
+// TokenFn returns the bearer token for requests, or an empty string if
+// requests are not authenticated
+type TokenFn func(ctx context.Context) (string, error)
+
+// Error is an error returned by the endpoint
+type Error struct {
+	Message string `json:"message"`
+	Path    []any  `json:"path,omitempty"`
+}
+
+type request struct {
+	Query     string         `json:"query"`
+	Variables map[string]any `json:"variables,omitempty"`
+}
+
+type response struct {
+	Data   json.RawMessage `json:"data"`
+	Errors []Error         `json:"errors"`
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+const (
+	maxResponseSize = 8 << 20
+)
+
+// introspection reads the types of a schema, with type references up to
+// seven levels deep, which is enough for types such as [[Int!]!]!
+const introspection = `query {
+  __schema {
+    queryType { name }
+    types {
+      kind name description
+      fields { name description args { name type { ...TypeRef } } type { ...TypeRef } }
+      inputFields { name type { ...TypeRef } }
+      enumValues { name }
+      possibleTypes { name }
+    }
+  }
+}
+fragment TypeRef on __Type {
+  kind name ofType { kind name ofType { kind name ofType { kind name ofType { kind name ofType { kind name ofType { kind name ofType { kind name } } } } } } }
+}`
+
+///////////////////////////////////////////////////////////////////////////////
+// LIFECYCLE
+
+// New returns a client for the endpoint at a URL
+func New(endpoint string, opts ...Opt) (*Client, error) {
+	u, err := url.Parse(strings.TrimSpace(endpoint))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, schema.ErrBadParameter.Withf("invalid graphql url %q", endpoint)
+	}
+	c := &Client{url: u.String(), client: http.DefaultClient}
+	for _, opt := range opts {
+		if err := opt(c); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// WithClient sets the HTTP client which makes requests to the endpoint
+func WithClient(client *http.Client) Opt {
+	return func(c *Client) error {
+		if client == nil {
+			return schema.ErrBadParameter.With("client is required")
+		}
+		c.client = client
+		return nil
+	}
+}
+
+// WithToken sets the function which returns the bearer token for requests
+func WithToken(fn TokenFn) Opt {
+	return func(c *Client) error {
+		c.token = fn
+		return nil
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// URL returns the URL of the endpoint
+func (c *Client) URL() string {
+	return c.url
+}
+
+// Schema returns the schema of the endpoint, which is read by introspection
+// on the first call
+func (c *Client) Schema(ctx context.Context) (*Schema, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.schema != nil {
+		return c.schema, nil
+	}
+
+	var result struct {
+		Schema struct {
+			QueryType Named   `json:"queryType"`
+			Types     []*Type `json:"types"`
+		} `json:"__schema"`
+	}
+	data, errs, err := c.Do(ctx, introspection, nil)
+	if err != nil {
+		return nil, err
+	} else if len(errs) > 0 {
+		return nil, fmt.Errorf("introspection: %s", errs[0].Message)
+	} else if err := json.Unmarshal(data, &result); err != nil {
+		return nil, fmt.Errorf("introspection: %w", err)
+	} else if result.Schema.QueryType.Name == "" {
+		return nil, schema.ErrNotImplemented.With("introspection: schema has no query type")
+	}
+
+	s := &Schema{QueryType: result.Schema.QueryType.Name, Types: make(map[string]*Type, len(result.Schema.Types))}
+	for _, typ := range result.Schema.Types {
+		s.Types[typ.Name] = typ
+	}
+	c.schema = s
+	return s, nil
+}
+
+// Do sends a query with variables to the endpoint, and returns the data and
+// errors of the response
+func (c *Client) Do(ctx context.Context, query string, variables map[string]any) (json.RawMessage, []Error, error) {
+	body, err := json.Marshal(request{Query: query, Variables: variables})
+	if err != nil {
+		return nil, nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/graphql-response+json, application/json")
+	if c.token != nil {
+		if token, err := c.token(ctx); err != nil {
+			return nil, nil, err
+		} else if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+	var result response
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxResponseSize)).Decode(&result); err != nil {
+		if resp.StatusCode != http.StatusOK {
+			return nil, nil, fmt.Errorf("%s: %s", req.URL.Host, resp.Status)
+		}
+		return nil, nil, fmt.Errorf("%s: %w", req.URL.Host, err)
+	}
+	if len(result.Data) == 0 && len(result.Errors) == 0 && resp.StatusCode != http.StatusOK {
+		return nil, nil, fmt.Errorf("%s: %s", req.URL.Host, resp.Status)
+	}
+	return result.Data, result.Errors, nil
+}
+
+// Compact returns a value without null values, empty lists and objects, and
+// with connections simplified: an edges list of objects with only a node
+// becomes a list of the nodes
+func Compact(value any) any {
+	switch value := value.(type) {
+	case map[string]any:
+		for key, v := range value {
+			if v = Compact(v); isEmpty(v) {
+				delete(value, key)
+			} else {
+				value[key] = v
+			}
+		}
+		if edges, ok := value["edges"].([]any); ok && value["nodes"] == nil {
+			nodes := make([]any, 0, len(edges))
+			for _, edge := range edges {
+				if edge, ok := edge.(map[string]any); ok && len(edge) == 1 && edge["node"] != nil {
+					nodes = append(nodes, edge["node"])
+				}
+			}
+			if len(nodes) == len(edges) {
+				delete(value, "edges")
+				value["nodes"] = nodes
+			}
+		}
+		return value
+	case []any:
+		result := value[:0]
+		for _, v := range value {
+			if v = Compact(v); !isEmpty(v) {
+				result = append(result, v)
+			}
+		}
+		return result
+	default:
+		return value
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+func isEmpty(value any) bool {
+	switch value := value.(type) {
+	case nil:
+		return true
+	case map[string]any:
+		return len(value) == 0
+	case []any:
+		return len(value) == 0
+	default:
+		return false
+	}
+}
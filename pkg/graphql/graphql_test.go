@@ -0,0 +1,207 @@
+package graphql_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	// Packages
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	graphql "github.com/mutablelogic/go-llm/pkg/graphql"
+	assert "github.com/stretchr/testify/assert"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// HELPERS
+
+const introspection = `{"data": {"__schema": {
+	"queryType": {"name": "Query"},
+	"types": [
+		{"kind": "OBJECT", "name": "Query", "fields": [
+			{"name": "repository", "description": "Look up a repository. Returns null if not found.", "args": [
+				{"name": "name", "type": {"kind": "NON_NULL", "ofType": {"kind": "SCALAR", "name": "String"}}}
+			], "type": {"kind": "OBJECT", "name": "Repository"}},
+			{"name": "viewer", "args": [], "type": {"kind": "NON_NULL", "ofType": {"kind": "OBJECT", "name": "User"}}}
+		]},
+		{"kind": "OBJECT", "name": "Repository", "description": "A repository", "fields": [
+			{"name": "name", "args": [], "type": {"kind": "SCALAR", "name": "String"}},
+			{"name": "visibility", "args": [], "type": {"kind": "ENUM", "name": "Visibility"}},
+			{"name": "owner", "args": [], "type": {"kind": "OBJECT", "name": "User"}},
+			{"name": "issues", "args": [], "type": {"kind": "OBJECT", "name": "IssueConnection"}}
+		]},
+		{"kind": "OBJECT", "name": "IssueConnection", "fields": [
+			{"name": "edges", "args": [], "type": {"kind": "LIST", "ofType": {"kind": "OBJECT", "name": "IssueEdge"}}}
+		]},
+		{"kind": "OBJECT", "name": "IssueEdge", "fields": [
+			{"name": "node", "args": [], "type": {"kind": "OBJECT", "name": "Issue"}}
+		]},
+		{"kind": "OBJECT", "name": "Issue", "fields": [
+			{"name": "title", "args": [], "type": {"kind": "SCALAR", "name": "String"}},
+			{"name": "body", "args": [], "type": {"kind": "SCALAR", "name": "String"}}
+		]},
+		{"kind": "OBJECT", "name": "User", "fields": [
+			{"name": "login", "args": [], "type": {"kind": "SCALAR", "name": "String"}},
+			{"name": "repository", "args": [], "type": {"kind": "OBJECT", "name": "Repository"}}
+		]},
+		{"kind": "ENUM", "name": "Visibility", "enumValues": [{"name": "PUBLIC"}, {"name": "PRIVATE"}]},
+		{"kind": "SCALAR", "name": "String"}
+	]
+}}}`
+
+// server returns a server which answers introspection queries, and returns
+// a fixed result for other queries
+func server(t *testing.T) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Query string `json:"query"`
+		}
+		if r.Header.Get("Authorization") != "Bearer t0ken" || json.NewDecoder(r.Body).Decode(&req) != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case strings.Contains(req.Query, "__schema"):
+			w.Write([]byte(introspection))
+		case strings.Contains(req.Query, "missing"):
+			w.Write([]byte(`{"data": null, "errors": [{"message": "repository not found"}]}`))
+		default:
+			w.Write([]byte(`{"data": {"repository": {"name": "go-llm", "owner": null, "issues": {"edges": [{"node": {"title": "One", "body": null}}, {"node": {"title": "Two", "body": ""}}]}}}}`))
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func token(context.Context) (string, error) {
+	return "t0ken", nil
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// TESTS
+
+func TestCheck(t *testing.T) {
+	assert := assert.New(t)
+	client, err := graphql.New(server(t).URL, graphql.WithToken(token))
+	if !assert.NoError(err) {
+		return
+	}
+	s, err := client.Schema(context.Background())
+	if !assert.NoError(err) {
+		return
+	}
+
+	// Valid queries, with aliases, arguments, variables, directives and
+	// fragments
+	for _, query := range []string{
+		`{ viewer { login } }`,
+		`query Repo($name: String!) { repo: repository(name: $name) @include(if: true) { name, visibility, __typename } }`,
+		`query { repository(name: "go-llm \"quoted\"") { ...Repo issues { edges { node { title } } } } } fragment Repo on Repository { name owner { login } }`,
+		`{ repository(name: """block "string" """) { ... on Repository { name } } } # comment`,
+	} {
+		assert.NoError(s.Check(query, graphql.Limits{}), query)
+	}
+
+	// Invalid queries
+	for query, message := range map[string]string{
+		`mutation { viewer { login } }`:                     "mutation operations are not allowed",
+		`{ viewer { login } } { viewer { login } }`:         "one operation",
+		`{ viewer { email } }`:                              `has no field "email"`,
+		`{ viewer }`:                                        "must select fields",
+		`{ viewer { ...Missing } }`:                         "unknown fragment",
+		`{ viewer { ...A } } fragment A on User { ...A }`:   "refers to itself",
+		`{ __schema { types { name } } }`:                   "introspection field",
+		`{ viewer { login }`:                                "unexpected end",
+		`{ viewer { login } } fragment A on User { login }`: "",
+	} {
+		err := s.Check(query, graphql.Limits{})
+		if message == "" {
+			assert.NoError(err, query)
+		} else if assert.Error(err, query) {
+			assert.Contains(err.Error(), message, query)
+		}
+	}
+
+	// Limits on depth, fields and allowed fields
+	deep := `{ viewer { repository { owner { repository { owner { login } } } } } }`
+	assert.NoError(s.Check(deep, graphql.Limits{Depth: 6}))
+	assert.ErrorContains(s.Check(deep, graphql.Limits{Depth: 5}), "more than 5 fields deep")
+	assert.ErrorContains(s.Check(`{ viewer { login } repository(name: "x") { name } }`, graphql.Limits{Fields: 3}), "more than 3 fields")
+	allow := graphql.Limits{Allow: []string{"Query.repository", "Repository.*"}}
+	assert.NoError(s.Check(`{ repository(name: "x") { name visibility } }`, allow))
+	assert.ErrorContains(s.Check(`{ viewer { login } }`, allow), "Query.viewer is not allowed")
+	assert.ErrorContains(s.Check(`{ repository(name: "x") { owner { login } } }`, allow), "User.login is not allowed")
+}
+
+func TestDescribe(t *testing.T) {
+	assert := assert.New(t)
+	client, _ := graphql.New(server(t).URL, graphql.WithToken(token))
+	s, err := client.Schema(context.Background())
+	if !assert.NoError(err) {
+		return
+	}
+
+	// Only types reachable through allowed fields are described
+	description := s.Describe(graphql.Limits{Allow: []string{"Query.repository", "Repository.name", "Repository.visibility"}})
+	assert.Equal("type Query {\n"+
+		"  repository(name: String!): Repository # Look up a repository.\n"+
+		"}\n"+
+		"\n"+
+		"scalar String\n"+
+		"\n"+
+		"# A repository\n"+
+		"type Repository {\n"+
+		"  name: String\n"+
+		"  visibility: Visibility\n"+
+		"}\n"+
+		"\n"+
+		"enum Visibility { PUBLIC PRIVATE }\n", description)
+	assert.Contains(s.Describe(graphql.Limits{}), "type IssueEdge {\n  node: Issue\n}")
+}
+
+func TestCompact(t *testing.T) {
+	assert := assert.New(t)
+	var value any
+	assert.NoError(json.Unmarshal([]byte(`{"a": null, "b": [], "c": {"d": null}, "e": [null, 1, {}], "f": {"edges": [{"node": {"x": 1}}, {"node": {"x": 2}}]}, "g": {"edges": [{"node": 1, "cursor": "c"}]}}`), &value))
+	data, _ := json.Marshal(graphql.Compact(value))
+	assert.JSONEq(`{"e": [1], "f": {"nodes": [{"x": 1}, {"x": 2}]}, "g": {"edges": [{"node": 1, "cursor": "c"}]}}`, string(data))
+}
+
+func TestTools(t *testing.T) {
+	assert := assert.New(t)
+	client, _ := graphql.New(server(t).URL, graphql.WithToken(token))
+
+	_, err := graphql.NewTools(client, graphql.Limits{Allow: []string{"repository"}})
+	assert.ErrorIs(err, schema.ErrBadParameter)
+	tools, err := graphql.NewTools(client, graphql.Limits{})
+	if !assert.NoError(err) || !assert.Len(tools, 2) {
+		return
+	}
+	assert.Equal("graphql_schema", tools[0].Name())
+	assert.Equal("graphql_query", tools[1].Name())
+
+	result, err := tools[0].Run(context.Background(), nil)
+	if assert.NoError(err) {
+		data, _ := json.Marshal(result)
+		assert.Contains(string(data), "type Query")
+	}
+
+	// Results are compact
+	result, err = tools[1].Run(context.Background(), json.RawMessage(`{"query": "{ repository(name: \"go-llm\") { name owner { login } issues { edges { node { title body } } } } }"}`))
+	if assert.NoError(err) {
+		data, _ := json.Marshal(result)
+		assert.JSONEq(`{"data": {"repository": {"name": "go-llm", "issues": {"nodes": [{"title": "One"}, {"title": "Two", "body": ""}]}}}}`, string(data))
+	}
+
+	// Invalid queries are not sent, and failed queries are errors
+	_, err = tools[1].Run(context.Background(), json.RawMessage(`{"query": "mutation { viewer { login } }"}`))
+	assert.ErrorIs(err, schema.ErrBadParameter)
+	_, err = tools[1].Run(context.Background(), json.RawMessage(`{"query": "{ repository(name: \"missing\") { name } }"}`))
+	if assert.ErrorIs(err, schema.ErrBadParameter) {
+		assert.Contains(err.Error(), "repository not found")
+	}
+}
@@ -0,0 +1,366 @@
+package graphql
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// document is a parsed query document. Only the structure of selections is
+// kept; argument and variable values are skipped.
+type document struct {
+	operations []*operation
+	fragments  map[string]*fragment
+}
+
+type operation struct {
+	kind       string
+	name       string
+	selections []*selection
+}
+
+type fragment struct {
+	name       string
+	on         string
+	selections []*selection
+}
+
+// selection is a field, a fragment spread or an inline fragment
+type selection struct {
+	field      string
+	spread     string
+	on         string
+	selections []*selection
+}
+
+type token struct {
+	kind  rune // 'n' for names, 'v' for values, or the punctuator
+	value string
+	pos   int
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+const (
+	tokenName   = 'n'
+	tokenValue  = 'v'
+	tokenEOF    = 0
+	tokenSpread = '.'
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS - PARSER
+
+// parse returns the structure of a query document
+func parse(query string) (*document, error) {
+	tokens, err := lex(query)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	doc := &document{fragments: make(map[string]*fragment)}
+	for p.peek().kind != tokenEOF {
+		switch tok := p.peek(); {
+		case tok.kind == '{':
+			selections, err := p.selectionSet()
+			if err != nil {
+				return nil, err
+			}
+			doc.operations = append(doc.operations, &operation{kind: "query", selections: selections})
+		case tok.kind == tokenName && tok.value == "fragment":
+			fragment, err := p.fragment()
+			if err != nil {
+				return nil, err
+			} else if _, exists := doc.fragments[fragment.name]; exists {
+				return nil, fmt.Errorf("fragment %q is defined more than once", fragment.name)
+			}
+			doc.fragments[fragment.name] = fragment
+		case tok.kind == tokenName && (tok.value == "query" || tok.value == "mutation" || tok.value == "subscription"):
+			operation, err := p.operation()
+			if err != nil {
+				return nil, err
+			}
+			doc.operations = append(doc.operations, operation)
+		default:
+			return nil, p.unexpected()
+		}
+	}
+	if len(doc.operations) == 0 {
+		return nil, fmt.Errorf("query has no operations")
+	}
+	return doc, nil
+}
+
+func (p *parser) operation() (*operation, error) {
+	op := &operation{kind: p.next().value}
+	if p.peek().kind == tokenName {
+		op.name = p.next().value
+	}
+	if p.peek().kind == '(' {
+		if err := p.skipParens(); err != nil {
+			return nil, err
+		}
+	}
+	if err := p.directives(); err != nil {
+		return nil, err
+	}
+	selections, err := p.selectionSet()
+	if err != nil {
+		return nil, err
+	}
+	op.selections = selections
+	return op, nil
+}
+
+func (p *parser) fragment() (*fragment, error) {
+	p.next()
+	name := p.next()
+	if name.kind != tokenName || name.value == "on" {
+		return nil, p.unexpectedAt(name)
+	}
+	if on := p.next(); on.kind != tokenName || on.value != "on" {
+		return nil, p.unexpectedAt(on)
+	}
+	typ := p.next()
+	if typ.kind != tokenName {
+		return nil, p.unexpectedAt(typ)
+	}
+	if err := p.directives(); err != nil {
+		return nil, err
+	}
+	selections, err := p.selectionSet()
+	if err != nil {
+		return nil, err
+	}
+	return &fragment{name: name.value, on: typ.value, selections: selections}, nil
+}
+
+func (p *parser) selectionSet() ([]*selection, error) {
+	if tok := p.next(); tok.kind != '{' {
+		return nil, p.unexpectedAt(tok)
+	}
+	var selections []*selection
+	for p.peek().kind != '}' {
+		var sel *selection
+		var err error
+		if p.peek().kind == tokenSpread {
+			sel, err = p.spread()
+		} else {
+			sel, err = p.field()
+		}
+		if err != nil {
+			return nil, err
+		}
+		selections = append(selections, sel)
+	}
+	p.next()
+	if len(selections) == 0 {
+		return nil, fmt.Errorf("empty selection set")
+	}
+	return selections, nil
+}
+
+func (p *parser) field() (*selection, error) {
+	name := p.next()
+	if name.kind != tokenName {
+		return nil, p.unexpectedAt(name)
+	}
+	sel := &selection{field: name.value}
+
+	// An alias is followed by the name of the field
+	if p.peek().kind == ':' {
+		p.next()
+		if name = p.next(); name.kind != tokenName {
+			return nil, p.unexpectedAt(name)
+		}
+		sel.field = name.value
+	}
+	if p.peek().kind == '(' {
+		if err := p.skipParens(); err != nil {
+			return nil, err
+		}
+	}
+	if err := p.directives(); err != nil {
+		return nil, err
+	}
+	if p.peek().kind == '{' {
+		selections, err := p.selectionSet()
+		if err != nil {
+			return nil, err
+		}
+		sel.selections = selections
+	}
+	return sel, nil
+}
+
+func (p *parser) spread() (*selection, error) {
+	p.next()
+	sel := &selection{}
+	if tok := p.peek(); tok.kind == tokenName && tok.value != "on" {
+		sel.spread = p.next().value
+		return sel, p.directives()
+	}
+	if tok := p.peek(); tok.kind == tokenName && tok.value == "on" {
+		p.next()
+		typ := p.next()
+		if typ.kind != tokenName {
+			return nil, p.unexpectedAt(typ)
+		}
+		sel.on = typ.value
+	}
+	if err := p.directives(); err != nil {
+		return nil, err
+	}
+	selections, err := p.selectionSet()
+	if err != nil {
+		return nil, err
+	}
+	sel.selections = selections
+	return sel, nil
+}
+
+func (p *parser) directives() error {
+	for p.peek().kind == '@' {
+		p.next()
+		if tok := p.next(); tok.kind != tokenName {
+			return p.unexpectedAt(tok)
+		}
+		if p.peek().kind == '(' {
+			if err := p.skipParens(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// skipParens skips arguments or variable definitions between parentheses
+func (p *parser) skipParens() error {
+	depth := 0
+	for {
+		switch tok := p.next(); tok.kind {
+		case '(':
+			depth++
+		case ')':
+			if depth--; depth == 0 {
+				return nil
+			}
+		case tokenEOF:
+			return fmt.Errorf("unexpected end of query")
+		}
+	}
+}
+
+func (p *parser) peek() token {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	return token{kind: tokenEOF}
+}
+
+func (p *parser) next() token {
+	tok := p.peek()
+	if p.pos < len(p.tokens) {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *parser) unexpected() error {
+	return p.unexpectedAt(p.peek())
+}
+
+func (p *parser) unexpectedAt(tok token) error {
+	if tok.kind == tokenEOF {
+		return fmt.Errorf("unexpected end of query")
+	}
+	return fmt.Errorf("unexpected %q at offset %d", tok.value, tok.pos)
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS - LEXER
+
+// lex splits a query into names, values and punctuators, skipping white
+// space, commas and comments
+func lex(query string) ([]token, error) {
+	var tokens []token
+	runes := []rune(query)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r) || r == ',' || r == '\uFEFF':
+			i++
+		case r == '#':
+			for i < len(runes) && runes[i] != '\n' && runes[i] != '\r' {
+				i++
+			}
+		case strings.ContainsRune("!$&():=@[]{}|", r):
+			tokens = append(tokens, token{kind: r, value: string(r), pos: i})
+			i++
+		case r == '.':
+			if i+2 >= len(runes) || runes[i+1] != '.' || runes[i+2] != '.' {
+				return nil, fmt.Errorf("unexpected %q at offset %d", r, i)
+			}
+			tokens = append(tokens, token{kind: tokenSpread, value: "...", pos: i})
+			i += 3
+		case r == '_' || unicode.IsLetter(r):
+			start := i
+			for i < len(runes) && (runes[i] == '_' || unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i])) {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenName, value: string(runes[start:i]), pos: start})
+		case r == '-' || unicode.IsDigit(r):
+			start := i
+			for i++; i < len(runes) && (unicode.IsDigit(runes[i]) || strings.ContainsRune(".eE+-", runes[i])); i++ {
+			}
+			tokens = append(tokens, token{kind: tokenValue, value: string(runes[start:i]), pos: start})
+		case r == '"':
+			start := i
+			end, err := lexString(runes, i)
+			if err != nil {
+				return nil, err
+			}
+			i = end
+			tokens = append(tokens, token{kind: tokenValue, value: string(runes[start:i]), pos: start})
+		default:
+			return nil, fmt.Errorf("unexpected %q at offset %d", r, i)
+		}
+	}
+	return tokens, nil
+}
+
+// lexString returns the offset after a string or block string which starts
+// at offset i
+func lexString(runes []rune, i int) (int, error) {
+	start := i
+	if i+2 < len(runes) && runes[i+1] == '"' && runes[i+2] == '"' {
+		for i += 3; i+2 < len(runes); i++ {
+			if runes[i] == '\\' && i+3 < len(runes) && string(runes[i+1:i+4]) == `"""` {
+				i += 3
+			} else if string(runes[i:i+3]) == `"""` {
+				return i + 3, nil
+			}
+		}
+		return 0, fmt.Errorf("unterminated string at offset %d", start)
+	}
+	for i++; i < len(runes); i++ {
+		switch runes[i] {
+		case '\\':
+			i++
+		case '"':
+			return i + 1, nil
+		case '\n', '\r':
+			return 0, fmt.Errorf("unterminated string at offset %d", start)
+		}
+	}
+	return 0, fmt.Errorf("unterminated string at offset %d", start)
+}
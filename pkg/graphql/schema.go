@@ -0,0 +1,298 @@
+package graphql
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// Schema is the part of an introspected schema which describes the types
+// and fields which can be queried
+type Schema struct {
+	QueryType string
+	Types     map[string]*Type
+}
+
+// Type is a named type in a schema
+type Type struct {
+	Kind          string     `json:"kind"`
+	Name          string     `json:"name"`
+	Description   string     `json:"description"`
+	Fields        []Field    `json:"fields"`
+	EnumValues    []Named    `json:"enumValues"`
+	PossibleTypes []Named    `json:"possibleTypes"`
+	InputFields   []Argument `json:"inputFields"`
+}
+
+// Field is a field of an object or interface type
+type Field struct {
+	Name        string     `json:"name"`
+	Description string     `json:"description"`
+	Args        []Argument `json:"args"`
+	Type        TypeRef    `json:"type"`
+}
+
+// Argument is an argument of a field, or a field of an input type
+type Argument struct {
+	Name string  `json:"name"`
+	Type TypeRef `json:"type"`
+}
+
+// Named is a reference to a type or enum value by name
+type Named struct {
+	Name string `json:"name"`
+}
+
+// TypeRef is a reference to a type, which may be wrapped in lists and
+// non-null types
+type TypeRef struct {
+	Kind   string   `json:"kind"`
+	Name   string   `json:"name"`
+	OfType *TypeRef `json:"ofType"`
+}
+
+// Limits constrain the queries which can be made. A query may not select
+// fields more deeply nested than Depth, or select more than Fields fields
+// in total, counting fields in fragments each time they are used. If Allow
+// is not empty, only the fields it lists may be selected; each entry is a
+// type and field such as Query.repository, or a type and * for all the
+// fields of the type.
+type Limits struct {
+	Depth  uint
+	Fields uint
+	Allow  []string
+}
+
+// checker walks the selections of a query, counting fields and checking
+// them against the schema and limits
+type checker struct {
+	*Schema
+	Limits
+	fragments map[string]*fragment
+	visiting  map[string]bool
+	fields    uint
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+const (
+	defaultDepth  = 6
+	defaultFields = 200
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// String returns the type in GraphQL notation, such as [String!]!
+func (t TypeRef) String() string {
+	switch {
+	case t.Kind == "NON_NULL" && t.OfType != nil:
+		return t.OfType.String() + "!"
+	case t.Kind == "LIST" && t.OfType != nil:
+		return "[" + t.OfType.String() + "]"
+	default:
+		return t.Name
+	}
+}
+
+// Named returns the name of the type without lists and non-null types
+func (t TypeRef) Named() string {
+	for ref := &t; ref != nil; ref = ref.OfType {
+		if ref.Name != "" {
+			return ref.Name
+		}
+	}
+	return ""
+}
+
+// Allowed returns true if a field of a type may be selected
+func (l Limits) Allowed(typ, field string) bool {
+	if len(l.Allow) == 0 || strings.HasPrefix(field, "__") {
+		return true
+	}
+	return slices.Contains(l.Allow, typ+"."+field) || slices.Contains(l.Allow, typ+".*")
+}
+
+// Check returns an error if a query is not a single query operation which
+// selects fields of the schema within the limits
+func (s *Schema) Check(query string, limits Limits) error {
+	doc, err := parse(query)
+	if err != nil {
+		return err
+	} else if len(doc.operations) != 1 {
+		return fmt.Errorf("query must contain one operation")
+	} else if op := doc.operations[0]; op.kind != "query" {
+		return fmt.Errorf("%s operations are not allowed", op.kind)
+	}
+	if limits.Depth == 0 {
+		limits.Depth = defaultDepth
+	}
+	if limits.Fields == 0 {
+		limits.Fields = defaultFields
+	}
+	c := &checker{Schema: s, Limits: limits, fragments: doc.fragments, visiting: make(map[string]bool)}
+	return c.check(doc.operations[0].selections, s.QueryType, 1)
+}
+
+// Describe returns the types which can be reached from the query type
+// through allowed fields, in the schema definition language. Descriptions
+// are shortened to their first sentence.
+func (s *Schema) Describe(limits Limits) string {
+	var b strings.Builder
+	seen := map[string]bool{}
+	queue := []string{s.QueryType}
+	for len(queue) > 0 {
+		name := queue[0]
+		queue = queue[1:]
+		typ, ok := s.Types[name]
+		if !ok || seen[name] || strings.HasPrefix(name, "__") {
+			continue
+		}
+		seen[name] = true
+		if b.Len() > 0 {
+			b.WriteString("\n")
+		}
+		if description := summary(typ.Description); description != "" {
+			fmt.Fprintf(&b, "# %s\n", description)
+		}
+		switch typ.Kind {
+		case "OBJECT", "INTERFACE":
+			keyword := "type"
+			if typ.Kind == "INTERFACE" {
+				keyword = "interface"
+			}
+			fmt.Fprintf(&b, "%s %s {\n", keyword, typ.Name)
+			for _, field := range typ.Fields {
+				if !limits.Allowed(typ.Name, field.Name) {
+					continue
+				}
+				b.WriteString("  " + field.Name)
+				if len(field.Args) > 0 {
+					args := make([]string, 0, len(field.Args))
+					for _, arg := range field.Args {
+						args = append(args, arg.Name+": "+arg.Type.String())
+						queue = append(queue, arg.Type.Named())
+					}
+					b.WriteString("(" + strings.Join(args, ", ") + ")")
+				}
+				b.WriteString(": " + field.Type.String())
+				if description := summary(field.Description); description != "" {
+					b.WriteString(" # " + description)
+				}
+				b.WriteString("\n")
+				queue = append(queue, field.Type.Named())
+			}
+			b.WriteString("}\n")
+			for _, possible := range typ.PossibleTypes {
+				queue = append(queue, possible.Name)
+			}
+		case "UNION":
+			names := make([]string, 0, len(typ.PossibleTypes))
+			for _, possible := range typ.PossibleTypes {
+				names = append(names, possible.Name)
+				queue = append(queue, possible.Name)
+			}
+			fmt.Fprintf(&b, "union %s = %s\n", typ.Name, strings.Join(names, " | "))
+		case "ENUM":
+			names := make([]string, 0, len(typ.EnumValues))
+			for _, value := range typ.EnumValues {
+				names = append(names, value.Name)
+			}
+			fmt.Fprintf(&b, "enum %s { %s }\n", typ.Name, strings.Join(names, " "))
+		case "INPUT_OBJECT":
+			fields := make([]string, 0, len(typ.InputFields))
+			for _, field := range typ.InputFields {
+				fields = append(fields, field.Name+": "+field.Type.String())
+				queue = append(queue, field.Type.Named())
+			}
+			fmt.Fprintf(&b, "input %s { %s }\n", typ.Name, strings.Join(fields, ", "))
+		case "SCALAR":
+			fmt.Fprintf(&b, "scalar %s\n", typ.Name)
+		}
+	}
+	return b.String()
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// check checks selections of a type at a depth
+func (c *checker) check(selections []*selection, typeName string, depth uint) error {
+	typ, ok := c.Types[typeName]
+	if !ok {
+		return fmt.Errorf("unknown type %q", typeName)
+	}
+	for _, sel := range selections {
+		switch {
+		case sel.spread != "":
+			fragment, ok := c.fragments[sel.spread]
+			if !ok {
+				return fmt.Errorf("unknown fragment %q", sel.spread)
+			} else if c.visiting[sel.spread] {
+				return fmt.Errorf("fragment %q refers to itself", sel.spread)
+			}
+			c.visiting[sel.spread] = true
+			err := c.check(fragment.selections, fragment.on, depth)
+			c.visiting[sel.spread] = false
+			if err != nil {
+				return err
+			}
+		case sel.field == "":
+			on := sel.on
+			if on == "" {
+				on = typeName
+			}
+			if err := c.check(sel.selections, on, depth); err != nil {
+				return err
+			}
+		default:
+			if err := c.checkField(typ, sel, depth); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// checkField checks a field of a type, and its selections
+func (c *checker) checkField(typ *Type, sel *selection, depth uint) error {
+	if c.fields++; c.fields > c.Limits.Fields {
+		return fmt.Errorf("query selects more than %d fields", c.Limits.Fields)
+	} else if depth > c.Limits.Depth {
+		return fmt.Errorf("query is nested more than %d fields deep", c.Limits.Depth)
+	} else if sel.field == "__typename" {
+		return nil
+	} else if strings.HasPrefix(sel.field, "__") {
+		return fmt.Errorf("introspection field %q is not allowed", sel.field)
+	} else if !c.Limits.Allowed(typ.Name, sel.field) {
+		return fmt.Errorf("field %s.%s is not allowed", typ.Name, sel.field)
+	}
+	i := slices.IndexFunc(typ.Fields, func(field Field) bool { return field.Name == sel.field })
+	if i < 0 {
+		return fmt.Errorf("type %s has no field %q", typ.Name, sel.field)
+	}
+	named := typ.Fields[i].Type.Named()
+	if len(sel.selections) == 0 {
+		if kind := c.Types[named]; kind != nil && (kind.Kind == "OBJECT" || kind.Kind == "INTERFACE" || kind.Kind == "UNION") {
+			return fmt.Errorf("field %s.%s of type %s must select fields", typ.Name, sel.field, named)
+		}
+		return nil
+	}
+	return c.check(sel.selections, named, depth+1)
+}
+
+// summary returns the first sentence or line of a description
+func summary(description string) string {
+	description = strings.TrimSpace(description)
+	if i := strings.IndexAny(description, "\r\n"); i >= 0 {
+		description = description[:i]
+	}
+	if i := strings.Index(description, ". "); i >= 0 {
+		description = description[:i+1]
+	}
+	return description
+}
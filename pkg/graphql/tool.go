@@ -0,0 +1,151 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	// Packages
+	llm "github.com/mutablelogic/go-llm"
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	tool "github.com/mutablelogic/go-llm/toolkit/tool"
+	jsonschema "github.com/mutablelogic/go-server/pkg/jsonschema"
+	types "github.com/mutablelogic/go-server/pkg/types"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+type schemaTool struct {
+	tool.Base
+	client *Client
+	limits Limits
+}
+
+type queryTool struct {
+	tool.Base
+	client *Client
+	limits Limits
+}
+
+type schemaRequest struct{}
+
+type schemaResponse struct {
+	Schema string `json:"schema" help:"Types which can be queried, in the schema definition language"`
+}
+
+type queryRequest struct {
+	Query     string         `json:"query" help:"GraphQL query, which must be a single query operation"`
+	Variables map[string]any `json:"variables,omitempty" help:"Values of the variables of the query"`
+}
+
+type queryResponse struct {
+	Data   any     `json:"data,omitempty" help:"Result of the query, without null values"`
+	Errors []Error `json:"errors,omitempty" help:"Errors for parts of the query which failed"`
+}
+
+var _ llm.Tool = (*schemaTool)(nil)
+var _ llm.Tool = (*queryTool)(nil)
+
+///////////////////////////////////////////////////////////////////////////////
+// LIFECYCLE
+
+// NewTools returns tools which describe the schema of an endpoint, and
+// query it within limits
+func NewTools(client *Client, limits Limits) ([]llm.Tool, error) {
+	if client == nil {
+		return nil, schema.ErrBadParameter.With("client is required")
+	}
+	for _, allow := range limits.Allow {
+		if typ, field, ok := strings.Cut(allow, "."); !ok || typ == "" || field == "" {
+			return nil, schema.ErrBadParameter.Withf("invalid allowed field %q, which should be a type and field such as Query.viewer", allow)
+		}
+	}
+	return []llm.Tool{
+		&schemaTool{client: client, limits: limits},
+		&queryTool{client: client, limits: limits},
+	}, nil
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// llm.Tool INTERFACE
+
+func (*schemaTool) Name() string {
+	return "graphql_schema"
+}
+
+func (*schemaTool) Description() string {
+	return "Return the types and fields which can be queried with graphql_query, in the GraphQL schema definition language. Read the schema before writing a query."
+}
+
+func (*schemaTool) InputSchema() *jsonschema.Schema {
+	return jsonschema.MustFor[schemaRequest]()
+}
+
+func (*schemaTool) OutputSchema() *jsonschema.Schema {
+	return jsonschema.MustFor[schemaResponse]()
+}
+
+func (*schemaTool) Meta() llm.ToolMeta {
+	return llm.ToolMeta{Title: "GraphQL Schema", ReadOnlyHint: true, IdempotentHint: true, OpenWorldHint: types.Ptr(true)}
+}
+
+func (t *schemaTool) Run(ctx context.Context, _ json.RawMessage) (any, error) {
+	s, err := t.client.Schema(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return schemaResponse{Schema: s.Describe(t.limits)}, nil
+}
+
+func (*queryTool) Name() string {
+	return "graphql_query"
+}
+
+func (t *queryTool) Description() string {
+	return "Run a GraphQL query and return its result without null values. Only queries are allowed, not mutations, and queries are limited in depth and number of fields."
+}
+
+func (*queryTool) InputSchema() *jsonschema.Schema {
+	return jsonschema.MustFor[queryRequest]()
+}
+
+func (*queryTool) OutputSchema() *jsonschema.Schema {
+	return jsonschema.MustFor[queryResponse]()
+}
+
+func (*queryTool) Meta() llm.ToolMeta {
+	return llm.ToolMeta{Title: "GraphQL Query", ReadOnlyHint: true, IdempotentHint: true, OpenWorldHint: types.Ptr(true)}
+}
+
+func (t *queryTool) Run(ctx context.Context, input json.RawMessage) (any, error) {
+	var req queryRequest
+	if err := json.Unmarshal(input, &req); err != nil {
+		return nil, schema.ErrBadParameter.Withf("failed to unmarshal input: %v", err)
+	}
+
+	// Check the query against the schema
+	s, err := t.client.Schema(ctx)
+	if err != nil {
+		return nil, err
+	} else if err := s.Check(req.Query, t.limits); err != nil {
+		return nil, schema.ErrBadParameter.With(err.Error())
+	}
+
+	// Run the query, and return compact data with any errors
+	data, errs, err := t.client.Do(ctx, req.Query, req.Variables)
+	if err != nil {
+		return nil, err
+	}
+	var value any
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &value); err != nil {
+			return nil, err
+		}
+	}
+	response := queryResponse{Data: Compact(value), Errors: errs}
+	if response.Data == nil && len(errs) > 0 {
+		return nil, schema.ErrBadParameter.Withf("query failed: %s", errs[0].Message)
+	}
+	return response, nil
+}
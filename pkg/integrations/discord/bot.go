@@ -0,0 +1,320 @@
+package discord
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+
+	// Packages
+	auth "github.com/mutablelogic/go-auth/auth/schema"
+	llm "github.com/mutablelogic/go-llm"
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	integrations "github.com/mutablelogic/go-llm/pkg/integrations"
+	types "github.com/mutablelogic/go-server/pkg/types"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// Manager is the subset of the LLM manager used by the bot, which adds
+// agents to the manager used by other integrations
+type Manager interface {
+	integrations.Manager
+	ListAgents(ctx context.Context, req schema.AgentListRequest, user *auth.UserInfo) (*schema.AgentList, error)
+	CallAgent(ctx context.Context, name string, req schema.CallAgentRequest, user *auth.UserInfo) (llm.Resource, error)
+}
+
+// Bot answers mentions and direct messages in Discord, and runs agents with
+// slash commands. Each channel or thread is a session, which is found by a
+// label tag and created on the first mention.
+type Bot struct {
+	*Client
+	manager Manager
+	user    *auth.UserInfo
+	logger  *slog.Logger
+	meta    schema.GeneratorMeta
+	tools   []string
+
+	// Set when the bot is ready
+	sync.RWMutex
+	id, application string
+}
+
+// Opt configures a Bot
+type Opt func(*Bot) error
+
+///////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+const (
+	commandAgents = "agents"
+	commandAgent  = "agent"
+)
+
+var commands = []Command{
+	{Name: commandAgents, Description: "List the agents which can be run"},
+	{Name: commandAgent, Description: "Run an agent", Options: []CommandOption{
+		{Type: optionString, Name: "name", Description: "Agent name", Required: true},
+		{Type: optionString, Name: "input", Description: "Agent input as a JSON object"},
+	}},
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// LIFECYCLE
+
+// NewBot returns a bot which chats with the manager as the given user
+func NewBot(client *Client, manager Manager, user *auth.UserInfo, opts ...Opt) (*Bot, error) {
+	if client == nil || manager == nil {
+		return nil, schema.ErrBadParameter.With("client and manager are required")
+	}
+	if user == nil {
+		return nil, schema.ErrBadParameter.With("user is required")
+	}
+	bot := &Bot{
+		Client:  client,
+		manager: manager,
+		user:    user,
+		logger:  slog.Default(),
+	}
+	for _, fn := range opts {
+		if err := fn(bot); err != nil {
+			return nil, err
+		}
+	}
+	return bot, nil
+}
+
+// WithLogger sets the logger for errors which cannot be posted to Discord
+func WithLogger(logger *slog.Logger) Opt {
+	return func(b *Bot) error {
+		if logger != nil {
+			b.logger = logger
+		}
+		return nil
+	}
+}
+
+// WithGenerator sets the provider, model and system prompt for new sessions
+func WithGenerator(meta schema.GeneratorMeta) Opt {
+	return func(b *Bot) error {
+		b.meta = meta
+		return nil
+	}
+}
+
+// WithTools sets the tool names or glob patterns available in chats
+func WithTools(tools ...string) Opt {
+	return func(b *Bot) error {
+		b.tools = tools
+		return nil
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// Run receives events until the context is cancelled
+func (b *Bot) Run(ctx context.Context) error {
+	// Handle each event in the background, as a chat may wait for the lock
+	// on its channel
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	return b.Listen(ctx, func(ctx context.Context, event string, data json.RawMessage) {
+		switch event {
+		case EventReady:
+			var ready Ready
+			if err := json.Unmarshal(data, &ready); err != nil {
+				b.logger.ErrorContext(ctx, "discord: invalid ready event", "error", err)
+				return
+			}
+			b.Lock()
+			b.id, b.application = ready.User.ID, ready.Application.ID
+			b.Unlock()
+			wg.Go(func() {
+				if err := b.RegisterCommands(ctx, ready.Application.ID, commands); err != nil {
+					b.logger.ErrorContext(ctx, "discord: register commands", "error", err)
+				}
+			})
+		case EventMessageCreate:
+			var message Message
+			if err := json.Unmarshal(data, &message); err != nil {
+				b.logger.ErrorContext(ctx, "discord: invalid message", "error", err)
+				return
+			}
+			if !b.accept(message) {
+				return
+			}
+			wg.Go(func() {
+				if err := b.chat(ctx, message); err != nil {
+					b.logger.ErrorContext(ctx, "discord: chat failed", "error", err)
+				}
+			})
+		case EventInteractionCreate:
+			var interaction Interaction
+			if err := json.Unmarshal(data, &interaction); err != nil {
+				b.logger.ErrorContext(ctx, "discord: invalid interaction", "error", err)
+				return
+			}
+			if interaction.Type != interactionCommand {
+				return
+			}
+			wg.Go(func() {
+				if err := b.command(ctx, interaction); err != nil {
+					b.logger.ErrorContext(ctx, "discord: command failed", "error", err)
+				}
+			})
+		}
+	})
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// self returns the user ID of the bot
+func (b *Bot) self() string {
+	b.RLock()
+	defer b.RUnlock()
+	return b.id
+}
+
+// accept returns true if the message should start a chat turn: a mention of
+// the bot, or a direct message to it
+func (b *Bot) accept(message Message) bool {
+	id := b.self()
+	if message.Author == nil || message.Author.Bot || message.Author.ID == id {
+		return false
+	}
+	return message.IsDirect() || message.Mentioned(id)
+}
+
+// chat runs a chat turn for a message, and streams the reply into a message
+// which is edited as text arrives
+func (b *Bot) chat(ctx context.Context, message Message) error {
+	label := message.Label()
+	prompt := message.Prompt(b.self())
+
+	// Handle messages in the channel one at a time
+	unlock, err := b.manager.LockLabel(ctx, label)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	// Reply to the message
+	reply := newStream(b.Client, message)
+	text, err := b.turn(ctx, label, prompt, message, reply)
+	if err != nil {
+		text = ":warning: " + err.Error()
+	}
+	return reply.Finish(ctx, text)
+}
+
+// turn sends the content and attachments of a message to the session for
+// the channel, and returns the reply
+func (b *Bot) turn(ctx context.Context, label, prompt string, message Message, reply *stream) (string, error) {
+	session, err := integrations.Session(ctx, b.manager, label, b.meta, prompt, b.user)
+	if err != nil {
+		return "", err
+	}
+
+	// Store attachments as pending input, which is sent with the text
+	if len(message.Attachments) > 0 {
+		req := schema.MessageCreateRequest{}
+		for _, attachment := range message.Attachments {
+			data, err := b.Download(ctx, attachment)
+			if err != nil {
+				return "", err
+			}
+			req.Attachments = append(req.Attachments, schema.Attachment{ContentType: attachment.ContentType, Data: data})
+		}
+		if _, err := b.manager.AppendMessage(ctx, session.ID, req, b.user); err != nil {
+			return "", err
+		}
+	}
+
+	// Run the chat turn, editing the reply as text is streamed
+	stop := reply.Start(ctx)
+	response, err := b.manager.Chat(ctx, schema.ChatRequest{
+		Session: session.ID,
+		Text:    prompt,
+		Tools:   b.tools,
+	}, reply.Write, b.user)
+	stop()
+	if err != nil {
+		return "", err
+	}
+	return integrations.Reply(response), nil
+}
+
+// command runs a slash command. The response is deferred, since an agent
+// may take longer than Discord waits for a response.
+func (b *Bot) command(ctx context.Context, interaction Interaction) error {
+	if err := b.Respond(ctx, interaction, InteractionResponse{Type: responseDeferredMessage}); err != nil {
+		return err
+	}
+	var text string
+	var err error
+	switch interaction.Data.Name {
+	case commandAgents:
+		text, err = b.agents(ctx)
+	case commandAgent:
+		text, err = b.agent(ctx, interaction.Data.Option("name"), interaction.Data.Option("input"))
+	default:
+		err = schema.ErrNotFound.Withf("command %q", interaction.Data.Name)
+	}
+	if err != nil {
+		text = ":warning: " + err.Error()
+	}
+	return b.EditResponse(ctx, interaction, Split(text)[0])
+}
+
+// agents returns a list of the agents which can be run
+func (b *Bot) agents(ctx context.Context) (string, error) {
+	list, err := b.manager.ListAgents(ctx, schema.AgentListRequest{}, b.user)
+	if err != nil {
+		return "", err
+	} else if len(list.Body) == 0 {
+		return "No agents are available", nil
+	}
+	var text strings.Builder
+	for _, agent := range list.Body {
+		fmt.Fprintf(&text, "- `%s`", agent.Name)
+		if title := agent.Title; title != "" {
+			fmt.Fprintf(&text, " %s", title)
+		}
+		text.WriteString("\n")
+	}
+	return text.String(), nil
+}
+
+// agent runs an agent with JSON input, and returns the output
+func (b *Bot) agent(ctx context.Context, name, input string) (string, error) {
+	req := schema.CallAgentRequest{}
+	if input = strings.TrimSpace(input); input != "" {
+		if !json.Valid([]byte(input)) {
+			return "", schema.ErrBadParameter.With("input should be a JSON object")
+		}
+		req.Input = json.RawMessage(input)
+	}
+	result, err := b.manager.CallAgent(ctx, name, req, b.user)
+	if err != nil {
+		return "", err
+	} else if result == nil {
+		return "", nil
+	}
+	data, err := result.Read(ctx)
+	if err != nil {
+		return "", err
+	}
+	if result.Type() == types.ContentTypeJSON {
+		var indented bytes.Buffer
+		if err := json.Indent(&indented, data, "", "  "); err == nil {
+			return "```json\n" + indented.String() + "\n```", nil
+		}
+	}
+	return string(data), nil
+}
@@ -0,0 +1,139 @@
+/*
+discord connects sessions to Discord, receiving messages and slash
+commands over the gateway and replying with the REST API.
+https://discord.com/developers/docs/reference
+*/
+package discord
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+
+	// Packages
+	client "github.com/mutablelogic/go-client"
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	types "github.com/mutablelogic/go-server/pkg/types"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// Client calls the Discord REST API with a bot token
+type Client struct {
+	*client.Client
+	token string
+}
+
+type gatewayResponse struct {
+	URL string `json:"url"`
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+const (
+	endPoint = "https://discord.com/api/v10/"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// LIFECYCLE
+
+// New returns a client for a bot token
+func New(token string, opts ...client.ClientOpt) (*Client, error) {
+	if token == "" {
+		return nil, schema.ErrBadParameter.With("discord bot token is required")
+	}
+
+	// Create client
+	c, err := client.New(append(opts, client.OptEndpoint(endPoint), client.OptReqToken(client.Token{
+		Scheme: "Bot",
+		Value:  token,
+	}))...)
+	if err != nil {
+		return nil, err
+	}
+
+	// Return the client
+	return &Client{Client: c, token: token}, nil
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// API CALLS
+
+// CreateMessage posts a message to a channel, and returns it with its ID
+func (c *Client) CreateMessage(ctx context.Context, channel string, message Message) (*Message, error) {
+	payload, err := client.NewJSONRequest(message)
+	if err != nil {
+		return nil, err
+	}
+	var response Message
+	if err := c.DoWithContext(ctx, payload, &response, client.OptPath("channels", channel, "messages")); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// EditMessage replaces the content of a message
+func (c *Client) EditMessage(ctx context.Context, channel, id, content string) error {
+	payload, err := client.NewJSONRequestEx(http.MethodPatch, Message{Content: content}, types.ContentTypeJSON)
+	if err != nil {
+		return err
+	}
+	var response Message
+	return c.DoWithContext(ctx, payload, &response, client.OptPath("channels", channel, "messages", id))
+}
+
+// RegisterCommands replaces the slash commands for an application
+func (c *Client) RegisterCommands(ctx context.Context, application string, commands []Command) error {
+	payload, err := client.NewJSONRequestEx(http.MethodPut, commands, types.ContentTypeJSON)
+	if err != nil {
+		return err
+	}
+	var response json.RawMessage
+	return c.DoWithContext(ctx, payload, &response, client.OptPath("applications", application, "commands"))
+}
+
+// Respond answers an interaction
+func (c *Client) Respond(ctx context.Context, interaction Interaction, response InteractionResponse) error {
+	payload, err := client.NewJSONRequest(response)
+	if err != nil {
+		return err
+	}
+	return c.DoWithContext(ctx, payload, nil, client.OptPath("interactions", interaction.ID, interaction.Token, "callback"))
+}
+
+// EditResponse replaces the content of a deferred interaction response
+func (c *Client) EditResponse(ctx context.Context, interaction Interaction, content string) error {
+	payload, err := client.NewJSONRequestEx(http.MethodPatch, Message{Content: content}, types.ContentTypeJSON)
+	if err != nil {
+		return err
+	}
+	var response Message
+	return c.DoWithContext(ctx, payload, &response, client.OptPath("webhooks", interaction.Application, interaction.Token, "messages", "@original"))
+}
+
+// Download returns the contents of an attachment
+func (c *Client) Download(ctx context.Context, attachment Attachment) ([]byte, error) {
+	if attachment.URL == "" {
+		return nil, schema.ErrBadParameter.Withf("attachment %q has no URL", attachment.Filename)
+	}
+	var data bytes.Buffer
+	if err := c.DoWithContext(ctx, client.MethodGet, &data, client.OptReqEndpoint(attachment.URL)); err != nil {
+		return nil, err
+	}
+	return data.Bytes(), nil
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// gateway returns the URL for a gateway connection
+func (c *Client) gateway(ctx context.Context) (string, error) {
+	var response gatewayResponse
+	if err := c.DoWithContext(ctx, nil, &response, client.OptPath("gateway", "bot")); err != nil {
+		return "", err
+	}
+	return response.URL, nil
+}
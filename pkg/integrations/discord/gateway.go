@@ -0,0 +1,174 @@
+package discord
+
+import (
+	"context"
+	"encoding/json"
+	"runtime"
+	"sync"
+	"time"
+
+	// Packages
+	websocket "golang.org/x/net/websocket"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// EventFn is called for each event dispatched on the gateway. It should not
+// block.
+type EventFn func(ctx context.Context, event string, data json.RawMessage)
+
+// conn is a gateway connection, which is written by the heartbeat and the
+// receive loop
+type conn struct {
+	sync.Mutex
+	*websocket.Conn
+	seq *int64
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+const (
+	gatewayQuery   = "?v=10&encoding=json"
+	origin         = "https://discord.com/"
+	reconnectDelay = 5 * time.Second
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// Listen receives events from the gateway until the context is cancelled,
+// identifying again when the connection is lost or Discord asks the client
+// to reconnect
+func (c *Client) Listen(ctx context.Context, fn EventFn) error {
+	for {
+		err := c.listen(ctx, fn)
+		if ctx.Err() != nil {
+			return nil
+		}
+		if err != nil {
+			// Wait before reconnecting after an error
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-time.After(reconnectDelay):
+			}
+		}
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// listen handles a single connection, returning nil when Discord asks the
+// client to reconnect
+func (c *Client) listen(ctx context.Context, fn EventFn) error {
+	url, err := c.gateway(ctx)
+	if err != nil {
+		return err
+	}
+	config, err := websocket.NewConfig(url+gatewayQuery, origin)
+	if err != nil {
+		return err
+	}
+	ws, err := config.DialContext(ctx)
+	if err != nil {
+		return err
+	}
+	conn := &conn{Conn: ws}
+	defer conn.Close()
+
+	// The first payload has the heartbeat interval
+	var payload Payload
+	if err := websocket.JSON.Receive(ws, &payload); err != nil {
+		return err
+	}
+	var hello hello
+	if payload.Op != opHello {
+		return errUnexpected(payload)
+	} else if err := json.Unmarshal(payload.Data, &hello); err != nil {
+		return err
+	}
+
+	// Send heartbeats, and close the connection to unblock the receive when
+	// the context is done
+	done := make(chan struct{})
+	defer close(done)
+	go conn.heartbeat(ctx, time.Duration(hello.Interval)*time.Millisecond, done)
+
+	// Identify
+	if err := conn.send(opIdentify, identify{
+		Token:   c.token,
+		Intents: intents,
+		Properties: identifyProperties{
+			OS:      runtime.GOOS,
+			Browser: "go-llm",
+			Device:  "go-llm",
+		},
+	}); err != nil {
+		return err
+	}
+
+	for {
+		var payload Payload
+		if err := websocket.JSON.Receive(ws, &payload); err != nil {
+			return err
+		}
+		switch payload.Op {
+		case opDispatch:
+			conn.Lock()
+			conn.seq = payload.Seq
+			conn.Unlock()
+			fn(ctx, payload.Type, payload.Data)
+		case opHeartbeat:
+			if err := conn.send(opHeartbeat, conn.last()); err != nil {
+				return err
+			}
+		case opReconnect, opInvalidSession:
+			return nil
+		}
+	}
+}
+
+// heartbeat sends a heartbeat at the interval until done, and closes the
+// connection if the context is cancelled
+func (c *conn) heartbeat(ctx context.Context, interval time.Duration, done <-chan struct{}) {
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			c.Close()
+			return
+		case <-done:
+			return
+		case <-ticker.C:
+			if err := c.send(opHeartbeat, c.last()); err != nil {
+				c.Close()
+				return
+			}
+		}
+	}
+}
+
+// last returns the sequence number of the last event received
+func (c *conn) last() *int64 {
+	c.Lock()
+	defer c.Unlock()
+	return c.seq
+}
+
+// send writes a payload to the connection
+func (c *conn) send(op int, data any) error {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	c.Lock()
+	defer c.Unlock()
+	return websocket.JSON.Send(c.Conn, Payload{Op: op, Data: raw})
+}
@@ -0,0 +1,245 @@
+package discord
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	// Packages
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// Payload is a message sent or received on the gateway
+type Payload struct {
+	Op   int             `json:"op"`
+	Data json.RawMessage `json:"d,omitempty"`
+	Seq  *int64          `json:"s,omitempty"`
+	Type string          `json:"t,omitempty"`
+}
+
+type hello struct {
+	Interval int64 `json:"heartbeat_interval"`
+}
+
+type identify struct {
+	Token      string             `json:"token"`
+	Intents    int                `json:"intents"`
+	Properties identifyProperties `json:"properties"`
+}
+
+type identifyProperties struct {
+	OS      string `json:"os"`
+	Browser string `json:"browser"`
+	Device  string `json:"device"`
+}
+
+// Ready is dispatched when the bot has connected
+type Ready struct {
+	User        User        `json:"user"`
+	Application Application `json:"application"`
+}
+
+type Application struct {
+	ID string `json:"id"`
+}
+
+type User struct {
+	ID       string `json:"id"`
+	Username string `json:"username"`
+	Bot      bool   `json:"bot,omitempty"`
+}
+
+// Message is a message in a channel or thread
+type Message struct {
+	ID          string       `json:"id,omitempty"`
+	Channel     string       `json:"channel_id,omitempty"`
+	Guild       string       `json:"guild_id,omitempty"`
+	Author      *User        `json:"author,omitempty"`
+	Content     string       `json:"content"`
+	Mentions    []User       `json:"mentions,omitempty"`
+	Attachments []Attachment `json:"attachments,omitempty"`
+	Reference   *Reference   `json:"message_reference,omitempty"`
+}
+
+// Reference makes a message a reply to another message
+type Reference struct {
+	Message string `json:"message_id"`
+}
+
+// Attachment is a file attached to a message
+type Attachment struct {
+	ID          string `json:"id"`
+	Filename    string `json:"filename"`
+	ContentType string `json:"content_type,omitempty"`
+	Size        int64  `json:"size"`
+	URL         string `json:"url"`
+}
+
+// Interaction is dispatched when a user runs a slash command
+type Interaction struct {
+	ID          string          `json:"id"`
+	Application string          `json:"application_id"`
+	Type        int             `json:"type"`
+	Token       string          `json:"token"`
+	Channel     string          `json:"channel_id,omitempty"`
+	Guild       string          `json:"guild_id,omitempty"`
+	Data        InteractionData `json:"data"`
+}
+
+type InteractionData struct {
+	Name    string   `json:"name"`
+	Options []Option `json:"options,omitempty"`
+}
+
+type Option struct {
+	Name  string `json:"name"`
+	Value any    `json:"value"`
+}
+
+// Command is a slash command registered for the application
+type Command struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description"`
+	Options     []CommandOption `json:"options,omitempty"`
+}
+
+type CommandOption struct {
+	Type        int    `json:"type"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Required    bool   `json:"required,omitempty"`
+}
+
+// InteractionResponse answers an interaction
+type InteractionResponse struct {
+	Type int      `json:"type"`
+	Data *Message `json:"data,omitempty"`
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+// Gateway opcodes
+const (
+	opDispatch       = 0
+	opHeartbeat      = 1
+	opIdentify       = 2
+	opReconnect      = 7
+	opInvalidSession = 9
+	opHello          = 10
+	opHeartbeatAck   = 11
+)
+
+// Gateway events
+const (
+	EventReady             = "READY"
+	EventMessageCreate     = "MESSAGE_CREATE"
+	EventInteractionCreate = "INTERACTION_CREATE"
+)
+
+// Intents for guild and direct messages, including their content
+const (
+	intentGuilds         = 1 << 0
+	intentGuildMessages  = 1 << 9
+	intentDirectMessages = 1 << 12
+	intentMessageContent = 1 << 15
+	intents              = intentGuilds | intentGuildMessages | intentDirectMessages | intentMessageContent
+)
+
+const (
+	interactionCommand      = 2
+	responseDeferredMessage = 5
+	optionString            = 3
+	maxContent              = 2000
+	labelPrefix             = "discord:"
+	directGuild             = "@me"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// STRINGIFY
+
+func (m Message) String() string {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err.Error()
+	}
+	return string(data)
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// Label returns the session label for the channel of the message. Threads
+// are channels in Discord, so each thread is a separate session.
+func (m Message) Label() string {
+	guild := m.Guild
+	if guild == "" {
+		guild = directGuild
+	}
+	return labelPrefix + strings.Join([]string{guild, m.Channel}, ":")
+}
+
+// IsDirect returns true if the message was sent in a direct message channel
+func (m Message) IsDirect() bool {
+	return m.Guild == ""
+}
+
+// Mentioned returns true if the message mentions the user
+func (m Message) Mentioned(user string) bool {
+	for _, mention := range m.Mentions {
+		if mention.ID == user {
+			return true
+		}
+	}
+	return false
+}
+
+// Prompt returns the content of the message with mentions of the user removed
+func (m Message) Prompt(user string) string {
+	text := m.Content
+	if user != "" {
+		text = strings.ReplaceAll(text, fmt.Sprintf("<@%s>", user), "")
+		text = strings.ReplaceAll(text, fmt.Sprintf("<@!%s>", user), "")
+	}
+	return strings.TrimSpace(text)
+}
+
+// Option returns the string value of a command option, or an empty string
+func (d InteractionData) Option(name string) string {
+	for _, option := range d.Options {
+		if option.Name == name {
+			if value, ok := option.Value.(string); ok {
+				return value
+			}
+			return fmt.Sprint(option.Value)
+		}
+	}
+	return ""
+}
+
+// Split divides text into parts which fit in a message, preferring to break
+// at a newline
+func Split(text string) []string {
+	var parts []string
+	for utf8.RuneCountInString(text) > maxContent {
+		head := string([]rune(text)[:maxContent])
+		if i := strings.LastIndex(head, "\n"); i > 0 {
+			head = head[:i]
+		}
+		parts = append(parts, head)
+		text = strings.TrimLeft(text[len(head):], "\n")
+	}
+	if text != "" || len(parts) == 0 {
+		parts = append(parts, text)
+	}
+	return parts
+}
+
+// errUnexpected returns an error for a payload which was not expected
+func errUnexpected(payload Payload) error {
+	return schema.ErrInternalServerError.Withf("discord: unexpected opcode %d", payload.Op)
+}
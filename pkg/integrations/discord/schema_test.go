@@ -0,0 +1,74 @@
+package discord_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	// Packages
+	discord "github.com/mutablelogic/go-llm/pkg/integrations/discord"
+	assert "github.com/stretchr/testify/assert"
+)
+
+func Test_schema_001(t *testing.T) {
+	assert := assert.New(t)
+
+	var message discord.Message
+	assert.NoError(json.Unmarshal([]byte(`{
+		"id": "M1",
+		"channel_id": "C1",
+		"guild_id": "G1",
+		"author": {"id": "U1", "username": "user"},
+		"content": "<@B1> what is in this picture?",
+		"mentions": [{"id": "B1", "username": "bot", "bot": true}],
+		"attachments": [{"id": "A1", "filename": "a.png", "content_type": "image/png", "size": 10, "url": "https://cdn.discordapp.com/a.png"}]
+	}`), &message))
+	assert.Equal("discord:G1:C1", message.Label())
+	assert.False(message.IsDirect())
+	assert.True(message.Mentioned("B1"))
+	assert.False(message.Mentioned("U1"))
+	assert.Equal("what is in this picture?", message.Prompt("B1"))
+	assert.Len(message.Attachments, 1)
+}
+
+func Test_schema_002(t *testing.T) {
+	assert := assert.New(t)
+	message := discord.Message{Channel: "D1", Content: "<@!B1> hi"}
+	assert.True(message.IsDirect())
+	assert.Equal("discord:@me:D1", message.Label())
+	assert.Equal("hi", message.Prompt("B1"))
+}
+
+func Test_schema_003(t *testing.T) {
+	assert := assert.New(t)
+	assert.Equal([]string{""}, discord.Split(""))
+	assert.Equal([]string{"hello"}, discord.Split("hello"))
+
+	// Long text is split at a newline
+	first := strings.Repeat("a", 1500)
+	second := strings.Repeat("b", 1000)
+	assert.Equal([]string{first, second}, discord.Split(first+"\n"+second))
+
+	// Text without a newline is split at the limit
+	parts := discord.Split(strings.Repeat("é", 4500))
+	if assert.Len(parts, 3) {
+		assert.Equal(2000, len([]rune(parts[0])))
+		assert.Equal(500, len([]rune(parts[2])))
+	}
+}
+
+func Test_schema_004(t *testing.T) {
+	assert := assert.New(t)
+	var interaction discord.Interaction
+	assert.NoError(json.Unmarshal([]byte(`{
+		"id": "I1",
+		"application_id": "P1",
+		"type": 2,
+		"token": "tok",
+		"data": {"name": "agent", "options": [{"name": "name", "type": 3, "value": "summarize"}, {"name": "limit", "type": 4, "value": 3}]}
+	}`), &interaction))
+	assert.Equal("agent", interaction.Data.Name)
+	assert.Equal("summarize", interaction.Data.Option("name"))
+	assert.Equal("3", interaction.Data.Option("limit"))
+	assert.Equal("", interaction.Data.Option("input"))
+}
@@ -0,0 +1,130 @@
+package discord
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	// Packages
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// stream is a reply which is posted when the first text arrives, and then
+// edited as more text is streamed
+type stream struct {
+	sync.Mutex
+	client  *Client
+	channel string
+	source  string
+	id      string
+	text    strings.Builder
+	sent    string
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+// editInterval is the minimum time between edits, to stay within the rate
+// limits for a channel
+const editInterval = time.Second
+
+///////////////////////////////////////////////////////////////////////////////
+// LIFECYCLE
+
+func newStream(client *Client, source Message) *stream {
+	return &stream{client: client, channel: source.Channel, source: source.ID}
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// Write appends streamed assistant text to the reply
+func (s *stream) Write(role, text string) {
+	if role != schema.RoleAssistant {
+		return
+	}
+	s.Lock()
+	defer s.Unlock()
+	s.text.WriteString(text)
+}
+
+// Start edits the reply at an interval until the returned function is called
+func (s *stream) Start(ctx context.Context) func() {
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Go(func() {
+		ticker := time.NewTicker(editInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				s.Lock()
+				text := s.text.String()
+				s.Unlock()
+				if text == "" {
+					continue
+				}
+				// Show the start of a long reply, which is split when finished
+				if parts := Split(text); len(parts) > 1 {
+					text = parts[0]
+				}
+				// An edit which fails is retried on the next tick, and any
+				// error is returned when the reply is finished
+				_ = s.update(ctx, text)
+			}
+		}
+	})
+	return func() {
+		close(done)
+		wg.Wait()
+	}
+}
+
+// Finish replaces the reply with the final text, splitting it into several
+// messages if it is too long for one
+func (s *stream) Finish(ctx context.Context, text string) error {
+	if text = strings.TrimSpace(text); text == "" {
+		return nil
+	}
+	parts := Split(text)
+	if err := s.update(ctx, parts[0]); err != nil {
+		return err
+	}
+	for _, part := range parts[1:] {
+		if _, err := s.client.CreateMessage(ctx, s.channel, Message{Content: part}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// update posts the reply, or edits it if the text has changed
+func (s *stream) update(ctx context.Context, text string) error {
+	if s.id == "" {
+		message, err := s.client.CreateMessage(ctx, s.channel, Message{Content: text, Reference: &Reference{Message: s.source}})
+		if err != nil {
+			return err
+		}
+		s.id, s.sent = message.ID, text
+		return nil
+	}
+	if text == s.sent {
+		return nil
+	}
+	if err := s.client.EditMessage(ctx, s.channel, s.id, text); err != nil {
+		return err
+	}
+	s.sent = text
+	return nil
+}
@@ -0,0 +1,334 @@
+/*
+email answers emails with chat turns. It polls an IMAP mailbox for unseen
+emails from allowed senders, and replies over SMTP. Each email thread is a
+session, labelled with the Message-ID of the first email in the thread.
+*/
+package email
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"net/mail"
+	"strings"
+	"time"
+
+	// Packages
+	auth "github.com/mutablelogic/go-auth/auth/schema"
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	integrations "github.com/mutablelogic/go-llm/pkg/integrations"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// Gateway answers emails from allowed senders
+type Gateway struct {
+	manager       integrations.Manager
+	user          *auth.UserInfo
+	logger        *slog.Logger
+	imap          server
+	smtp          server
+	from          *mail.Address
+	mailbox       string
+	interval      time.Duration
+	allow         []string
+	maxAttachment int
+	meta          schema.GeneratorMeta
+	tools         []string
+}
+
+type server struct {
+	addr, username, password string
+}
+
+// Opt configures a Gateway
+type Opt func(*Gateway) error
+
+///////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+const (
+	defaultMailbox       = "INBOX"
+	defaultInterval      = time.Minute
+	defaultMaxAttachment = 10 << 20
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// LIFECYCLE
+
+// New returns a gateway which chats with the manager as the given user. The
+// IMAP and SMTP servers, the reply address and at least one allowed sender
+// are required.
+func New(manager integrations.Manager, user *auth.UserInfo, opts ...Opt) (*Gateway, error) {
+	if manager == nil || user == nil {
+		return nil, schema.ErrBadParameter.With("manager and user are required")
+	}
+	g := &Gateway{
+		manager:       manager,
+		user:          user,
+		logger:        slog.Default(),
+		mailbox:       defaultMailbox,
+		interval:      defaultInterval,
+		maxAttachment: defaultMaxAttachment,
+	}
+	for _, fn := range opts {
+		if err := fn(g); err != nil {
+			return nil, err
+		}
+	}
+
+	// Check required options
+	switch {
+	case g.imap.addr == "":
+		return nil, schema.ErrBadParameter.With("imap server is required")
+	case g.smtp.addr == "":
+		return nil, schema.ErrBadParameter.With("smtp server is required")
+	case g.from == nil:
+		return nil, schema.ErrBadParameter.With("reply address is required")
+	case len(g.allow) == 0:
+		return nil, schema.ErrBadParameter.With("at least one allowed sender is required")
+	}
+
+	return g, nil
+}
+
+// WithIMAP sets the IMAP server, as host:port with TLS, and the credentials
+func WithIMAP(addr, username, password string) Opt {
+	return func(g *Gateway) error {
+		g.imap = server{addr: addr, username: username, password: password}
+		return nil
+	}
+}
+
+// WithSMTP sets the SMTP server, as host:port, and the credentials. No
+// authentication is used when the username is empty.
+func WithSMTP(addr, username, password string) Opt {
+	return func(g *Gateway) error {
+		g.smtp = server{addr: addr, username: username, password: password}
+		return nil
+	}
+}
+
+// WithAddress sets the address which replies are sent from
+func WithAddress(address string) Opt {
+	return func(g *Gateway) error {
+		from, err := mail.ParseAddress(address)
+		if err != nil {
+			return schema.ErrBadParameter.Withf("address: %v", err)
+		}
+		g.from = from
+		return nil
+	}
+}
+
+// WithMailbox sets the mailbox which is polled, which is INBOX by default
+func WithMailbox(mailbox string) Opt {
+	return func(g *Gateway) error {
+		if mailbox = strings.TrimSpace(mailbox); mailbox != "" {
+			g.mailbox = mailbox
+		}
+		return nil
+	}
+}
+
+// WithInterval sets the time between polls of the mailbox
+func WithInterval(interval time.Duration) Opt {
+	return func(g *Gateway) error {
+		if interval < time.Second {
+			return schema.ErrBadParameter.With("interval should be at least one second")
+		}
+		g.interval = interval
+		return nil
+	}
+}
+
+// WithAllow adds senders which may chat, either as an address such as
+// user@example.com or a domain such as @example.com
+func WithAllow(senders ...string) Opt {
+	return func(g *Gateway) error {
+		for _, sender := range senders {
+			if sender = strings.ToLower(strings.TrimSpace(sender)); sender != "" {
+				g.allow = append(g.allow, sender)
+			}
+		}
+		return nil
+	}
+}
+
+// WithMaxAttachment sets the largest attachment in bytes which is sent to
+// the model. Larger attachments are left out, and the model is told so.
+func WithMaxAttachment(size int) Opt {
+	return func(g *Gateway) error {
+		if size < 0 {
+			return schema.ErrBadParameter.With("attachment size should not be negative")
+		}
+		g.maxAttachment = size
+		return nil
+	}
+}
+
+// WithLogger sets the logger for errors which cannot be sent in a reply
+func WithLogger(logger *slog.Logger) Opt {
+	return func(g *Gateway) error {
+		if logger != nil {
+			g.logger = logger
+		}
+		return nil
+	}
+}
+
+// WithGenerator sets the provider, model and system prompt for new sessions
+func WithGenerator(meta schema.GeneratorMeta) Opt {
+	return func(g *Gateway) error {
+		g.meta = meta
+		return nil
+	}
+}
+
+// WithTools sets the tool names or glob patterns available in chats
+func WithTools(tools ...string) Opt {
+	return func(g *Gateway) error {
+		g.tools = tools
+		return nil
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// Run polls the mailbox until the context is cancelled
+func (g *Gateway) Run(ctx context.Context) error {
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-timer.C:
+			if err := g.poll(ctx); err != nil && ctx.Err() == nil {
+				g.logger.ErrorContext(ctx, "email: poll failed", "error", err)
+			}
+			timer.Reset(g.interval)
+		}
+	}
+}
+
+// Allowed returns true if the sender may chat
+func (g *Gateway) Allowed(sender string) bool {
+	sender = strings.ToLower(strings.TrimSpace(sender))
+	for _, allow := range g.allow {
+		if strings.HasPrefix(allow, "@") && strings.HasSuffix(sender, allow) {
+			return true
+		} else if allow == sender {
+			return true
+		}
+	}
+	return false
+}
+
+// Handle answers an email from an allowed sender, and ignores others
+func (g *Gateway) Handle(ctx context.Context, email *Email) error {
+	if !g.Allowed(email.From.Address) {
+		g.logger.WarnContext(ctx, "email: sender not allowed", "from", email.From.Address)
+		return nil
+	}
+
+	// Handle emails in the thread one at a time
+	label := email.Label()
+	unlock, err := g.manager.LockLabel(ctx, label)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	// Reply with the error if the chat failed
+	text, err := g.turn(ctx, label, email)
+	if err != nil {
+		text = "Sorry, your email could not be answered: " + err.Error()
+	}
+	if text == "" {
+		return nil
+	}
+	return send(g.smtp.addr, g.smtp.username, g.smtp.password, g.from, email.From, email.Reply(g.from, text))
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// poll answers the unseen emails in the mailbox, marking each as seen once
+// it has been handled
+func (g *Gateway) poll(ctx context.Context) error {
+	client, err := dialIMAP(ctx, g.imap.addr)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	if err := client.Login(g.imap.username, g.imap.password); err != nil {
+		return err
+	}
+	if err := client.Select(g.mailbox); err != nil {
+		return err
+	}
+	uids, err := client.Unseen()
+	if err != nil {
+		return err
+	}
+	for _, uid := range uids {
+		data, err := client.Fetch(uid)
+		if err != nil {
+			return err
+		}
+		if email, err := Parse(bytes.NewReader(data)); err != nil {
+			g.logger.WarnContext(ctx, "email: invalid email", "uid", uid, "error", err)
+		} else if err := g.Handle(ctx, email); err != nil {
+			g.logger.ErrorContext(ctx, "email: reply failed", "uid", uid, "error", err)
+		}
+		// Mark the email as seen even if it failed, so it is not answered twice
+		if err := client.Seen(uid); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// turn sends the text and attachments of an email to the session for the
+// thread, and returns the reply
+func (g *Gateway) turn(ctx context.Context, label string, email *Email) (string, error) {
+	session, err := integrations.Session(ctx, g.manager, label, g.meta, email.Subject, g.user)
+	if err != nil {
+		return "", err
+	}
+
+	// Store attachments within the size limit as pending input, and tell the
+	// model about the others
+	text := email.Text
+	req := schema.MessageCreateRequest{}
+	for _, file := range email.Attachments {
+		if len(file.Data) > g.maxAttachment {
+			text += fmt.Sprintf("\n\n[The attachment %q was not included because it is larger than %d bytes]", file.Name, g.maxAttachment)
+			continue
+		}
+		req.Attachments = append(req.Attachments, schema.Attachment{ContentType: file.ContentType, Data: file.Data})
+	}
+	if len(req.Attachments) > 0 {
+		if _, err := g.manager.AppendMessage(ctx, session.ID, req, g.user); err != nil {
+			return "", err
+		}
+	}
+	if strings.TrimSpace(text) == "" && len(req.Attachments) == 0 {
+		text = email.Subject
+	}
+
+	// Run the chat turn
+	response, err := g.manager.Chat(ctx, schema.ChatRequest{
+		Session: session.ID,
+		Text:    text,
+		Tools:   g.tools,
+	}, nil, g.user)
+	if err != nil {
+		return "", err
+	}
+	return integrations.Reply(response), nil
+}
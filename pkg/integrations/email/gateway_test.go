@@ -0,0 +1,47 @@
+package email_test
+
+import (
+	"testing"
+
+	// Packages
+	auth "github.com/mutablelogic/go-auth/auth/schema"
+	integrations "github.com/mutablelogic/go-llm/pkg/integrations"
+	email "github.com/mutablelogic/go-llm/pkg/integrations/email"
+	assert "github.com/stretchr/testify/assert"
+)
+
+type manager struct {
+	integrations.Manager
+}
+
+func Test_gateway_001(t *testing.T) {
+	assert := assert.New(t)
+	opts := []email.Opt{
+		email.WithIMAP("imap.example.com:993", "bot", "secret"),
+		email.WithSMTP("smtp.example.com:587", "bot", "secret"),
+		email.WithAddress("Bot <bot@example.com>"),
+	}
+
+	// An allowed sender is required
+	_, err := email.New(manager{}, &auth.UserInfo{}, opts...)
+	assert.Error(err)
+
+	gateway, err := email.New(manager{}, &auth.UserInfo{}, append(opts, email.WithAllow("Alice@Example.com", "@corp.example.com"))...)
+	if !assert.NoError(err) {
+		t.FailNow()
+	}
+	assert.True(gateway.Allowed("alice@example.com"))
+	assert.True(gateway.Allowed("bob@corp.example.com"))
+	assert.False(gateway.Allowed("bob@example.com"))
+	assert.False(gateway.Allowed("bob@evilcorp.example.com"))
+}
+
+func Test_gateway_002(t *testing.T) {
+	assert := assert.New(t)
+	_, err := email.New(manager{}, &auth.UserInfo{}, email.WithAddress("not an address"))
+	assert.Error(err)
+	_, err = email.New(manager{}, &auth.UserInfo{}, email.WithMaxAttachment(-1))
+	assert.Error(err)
+	_, err = email.New(nil, &auth.UserInfo{})
+	assert.Error(err)
+}
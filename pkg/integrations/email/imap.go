@@ -0,0 +1,234 @@
+package email
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+
+	// Packages
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// imap is a minimal IMAP4rev1 client over TLS, with the commands needed to
+// read unseen messages from a mailbox
+type imap struct {
+	conn net.Conn
+	r    *bufio.Reader
+	tag  int
+}
+
+// response is an untagged response, with the contents of any literals
+type response struct {
+	Text     string
+	Literals [][]byte
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+// maxLiteral is the largest literal which is read from the server
+const maxLiteral = 64 << 20
+
+///////////////////////////////////////////////////////////////////////////////
+// LIFECYCLE
+
+// dialIMAP connects to an IMAP server with TLS
+func dialIMAP(ctx context.Context, addr string) (*imap, error) {
+	conn, err := new(tls.Dialer).DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	// Close the connection if the context is cancelled
+	context.AfterFunc(ctx, func() { conn.Close() })
+
+	return newIMAP(conn)
+}
+
+// newIMAP returns a client for a connection, after reading the greeting
+func newIMAP(conn net.Conn) (*imap, error) {
+	c := &imap{conn: conn, r: bufio.NewReader(conn)}
+	if line, err := c.line(); err != nil {
+		conn.Close()
+		return nil, err
+	} else if !strings.HasPrefix(line, "* OK") && !strings.HasPrefix(line, "* PREAUTH") {
+		conn.Close()
+		return nil, schema.ErrServiceUnavailable.Withf("imap: %s", line)
+	}
+	return c, nil
+}
+
+// Close logs out and closes the connection
+func (c *imap) Close() error {
+	_, _ = c.command("LOGOUT")
+	return c.conn.Close()
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// Login authenticates with a username and password
+func (c *imap) Login(username, password string) error {
+	_, err := c.command("LOGIN " + quote(username) + " " + quote(password))
+	return err
+}
+
+// Select opens a mailbox
+func (c *imap) Select(mailbox string) error {
+	_, err := c.command("SELECT " + quote(mailbox))
+	return err
+}
+
+// Unseen returns the UIDs of messages without the \Seen flag
+func (c *imap) Unseen() ([]uint32, error) {
+	responses, err := c.command("UID SEARCH UNSEEN")
+	if err != nil {
+		return nil, err
+	}
+	var result []uint32
+	for _, r := range responses {
+		fields := strings.Fields(r.Text)
+		if len(fields) == 0 || !strings.EqualFold(fields[0], "SEARCH") {
+			continue
+		}
+		for _, field := range fields[1:] {
+			uid, err := strconv.ParseUint(field, 10, 32)
+			if err != nil {
+				return nil, schema.ErrInternalServerError.Withf("imap: invalid uid %q", field)
+			}
+			result = append(result, uint32(uid))
+		}
+	}
+	return result, nil
+}
+
+// Fetch returns a message without setting the \Seen flag
+func (c *imap) Fetch(uid uint32) ([]byte, error) {
+	responses, err := c.command(fmt.Sprintf("UID FETCH %d BODY.PEEK[]", uid))
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range responses {
+		if strings.Contains(strings.ToUpper(r.Text), "FETCH") && len(r.Literals) > 0 {
+			return r.Literals[0], nil
+		}
+	}
+	return nil, schema.ErrNotFound.Withf("imap: message %d", uid)
+}
+
+// Seen sets the \Seen flag on a message
+func (c *imap) Seen(uid uint32) error {
+	_, err := c.command(fmt.Sprintf("UID STORE %d +FLAGS.SILENT (\\Seen)", uid))
+	return err
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// command sends a command and returns the untagged responses, or an error
+// if the command did not complete with OK
+func (c *imap) command(command string) ([]response, error) {
+	c.tag++
+	tag := fmt.Sprintf("a%03d", c.tag)
+	if _, err := fmt.Fprintf(c.conn, "%s %s\r\n", tag, command); err != nil {
+		return nil, err
+	}
+
+	var responses []response
+	for {
+		r, err := c.response()
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case strings.HasPrefix(r.Text, "* "):
+			r.Text = strings.TrimPrefix(r.Text, "* ")
+			responses = append(responses, r)
+		case strings.HasPrefix(r.Text, tag+" "):
+			status := strings.TrimPrefix(r.Text, tag+" ")
+			if !strings.HasPrefix(strings.ToUpper(status), "OK") {
+				return nil, schema.ErrBadParameter.Withf("imap %s: %s", verb(command), status)
+			}
+			return responses, nil
+		}
+	}
+}
+
+// response reads a response line, including the literals within it
+func (c *imap) response() (response, error) {
+	var r response
+	for {
+		line, err := c.line()
+		if err != nil {
+			return r, err
+		}
+		r.Text += line
+
+		// A line which ends with {n} is followed by n bytes
+		n, ok := literal(line)
+		if !ok {
+			return r, nil
+		}
+		if n > maxLiteral {
+			return r, schema.ErrBadParameter.Withf("imap: literal of %d bytes is too large", n)
+		}
+		data := make([]byte, n)
+		if _, err := io.ReadFull(c.r, data); err != nil {
+			return r, err
+		}
+		r.Literals = append(r.Literals, data)
+	}
+}
+
+// line reads a line without the line ending
+func (c *imap) line() (string, error) {
+	line, err := c.r.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// literal returns the length of the literal at the end of a line
+func literal(line string) (int, bool) {
+	if !strings.HasSuffix(line, "}") {
+		return 0, false
+	}
+	i := strings.LastIndexByte(line, '{')
+	if i < 0 {
+		return 0, false
+	}
+	n, err := strconv.Atoi(line[i+1 : len(line)-1])
+	if err != nil || n < 0 {
+		return 0, false
+	}
+	return n, true
+}
+
+// quote returns a quoted string
+func quote(value string) string {
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	value = strings.ReplaceAll(value, `"`, `\"`)
+	return `"` + value + `"`
+}
+
+// verb returns the command name, without arguments which may be secret
+func verb(command string) string {
+	if i := strings.IndexByte(command, ' '); i > 0 {
+		if strings.HasPrefix(command, "UID ") {
+			if j := strings.IndexByte(command[i+1:], ' '); j > 0 {
+				return command[:i+1+j]
+			}
+		}
+		return command[:i]
+	}
+	return command
+}
@@ -0,0 +1,82 @@
+package email
+
+import (
+	"bufio"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+
+	// Packages
+	assert "github.com/stretchr/testify/assert"
+)
+
+// fakeIMAP answers commands on a connection with the scripted responses,
+// keyed by the command without its tag
+func fakeIMAP(t *testing.T, conn net.Conn, script map[string]string) {
+	t.Helper()
+	go func() {
+		defer conn.Close()
+		conn.Write([]byte("* OK ready\r\n"))
+		r := bufio.NewReader(conn)
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			tag, command, _ := strings.Cut(strings.TrimRight(line, "\r\n"), " ")
+			response, exists := script[command]
+			if !exists {
+				conn.Write([]byte(tag + " BAD unknown command\r\n"))
+				continue
+			}
+			conn.Write([]byte(response + tag + " OK done\r\n"))
+		}
+	}()
+}
+
+func Test_imap_001(t *testing.T) {
+	assert := assert.New(t)
+	server, conn := net.Pipe()
+	body := "From: a@b\r\nMessage-ID: <1@b>\r\n\r\nHi\r\n"
+	fakeIMAP(t, server, map[string]string{
+		`LOGIN "user" "p\"w"`:                "",
+		`SELECT "INBOX"`:                     "* 2 EXISTS\r\n",
+		"UID SEARCH UNSEEN":                  "* SEARCH 4 7\r\n",
+		"UID FETCH 4 BODY.PEEK[]":            "* 1 FETCH (UID 4 BODY[] {" + strconv.Itoa(len(body)) + "}\r\n" + body + ")\r\n",
+		"UID STORE 4 +FLAGS.SILENT (\\Seen)": "",
+		"LOGOUT":                             "* BYE\r\n",
+	})
+
+	client, err := newIMAP(conn)
+	if !assert.NoError(err) {
+		t.FailNow()
+	}
+	defer client.Close()
+	assert.NoError(client.Login("user", `p"w`))
+	assert.NoError(client.Select("INBOX"))
+	uids, err := client.Unseen()
+	assert.NoError(err)
+	assert.Equal([]uint32{4, 7}, uids)
+	data, err := client.Fetch(4)
+	assert.NoError(err)
+	assert.Equal(body, string(data))
+	assert.NoError(client.Seen(4))
+
+	// Failed commands return an error without the arguments
+	err = client.Select("Missing")
+	if assert.Error(err) {
+		assert.Contains(err.Error(), "SELECT")
+	}
+}
+
+func Test_imap_002(t *testing.T) {
+	assert := assert.New(t)
+	n, ok := literal("* 1 FETCH (BODY[] {42}")
+	assert.True(ok)
+	assert.Equal(42, n)
+	_, ok = literal("* 1 FETCH (FLAGS (\\Seen))")
+	assert.False(ok)
+	assert.Equal("UID FETCH", verb("UID FETCH 4 BODY.PEEK[]"))
+	assert.Equal("LOGIN", verb(`LOGIN "user" "secret"`))
+}
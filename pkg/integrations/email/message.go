@@ -0,0 +1,251 @@
+package email
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"strings"
+
+	// Packages
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// Email is an inbound email
+type Email struct {
+	MessageID   string
+	InReplyTo   string
+	References  []string
+	From        *mail.Address
+	Subject     string
+	Text        string
+	Attachments []File
+}
+
+// File is a file attached to an email
+type File struct {
+	Name        string
+	ContentType string
+	Data        []byte
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+const (
+	labelPrefix = "email:"
+	maxParts    = 100
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// LIFECYCLE
+
+// Parse reads an email in RFC 5322 format
+func Parse(r io.Reader) (*Email, error) {
+	msg, err := mail.ReadMessage(r)
+	if err != nil {
+		return nil, schema.ErrBadParameter.Withf("email: %v", err)
+	}
+
+	// Read the headers
+	email := &Email{
+		MessageID:  messageID(msg.Header.Get("Message-Id")),
+		InReplyTo:  messageID(msg.Header.Get("In-Reply-To")),
+		References: messageIDs(msg.Header.Get("References")),
+		Subject:    decodeHeader(msg.Header.Get("Subject")),
+	}
+	if from, err := msg.Header.AddressList("From"); err != nil || len(from) == 0 {
+		return nil, schema.ErrBadParameter.With("email: missing sender")
+	} else {
+		email.From = from[0]
+	}
+	if email.MessageID == "" {
+		return nil, schema.ErrBadParameter.With("email: missing Message-ID")
+	}
+
+	// Read the body
+	var html string
+	parts := 0
+	if err := email.walk(msg.Header.Get("Content-Type"), msg.Header.Get("Content-Transfer-Encoding"), "", msg.Body, &html, &parts); err != nil {
+		return nil, err
+	}
+	if email.Text == "" {
+		email.Text = html
+	}
+	email.Text = stripQuoted(email.Text)
+
+	// Return success
+	return email, nil
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// STRINGIFY
+
+func (f File) String() string {
+	return fmt.Sprintf("%s (%s, %d bytes)", f.Name, f.ContentType, len(f.Data))
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// Thread returns the Message-ID of the first email in the thread
+func (e *Email) Thread() string {
+	if len(e.References) > 0 {
+		return e.References[0]
+	}
+	if e.InReplyTo != "" {
+		return e.InReplyTo
+	}
+	return e.MessageID
+}
+
+// Label returns the session label for the thread of the email
+func (e *Email) Label() string {
+	return labelPrefix + e.Thread()
+}
+
+// ReplySubject returns the subject for a reply
+func (e *Email) ReplySubject() string {
+	subject := strings.TrimSpace(e.Subject)
+	if strings.HasPrefix(strings.ToLower(subject), "re:") {
+		return subject
+	}
+	return "Re: " + subject
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// walk reads the text and attachments from a part of the body
+func (e *Email) walk(contentType, encoding, disposition string, body io.Reader, html *string, parts *int) error {
+	if *parts++; *parts > maxParts {
+		return schema.ErrBadParameter.With("email: too many parts")
+	}
+	mediatype, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediatype, params = "text/plain", map[string]string{}
+	}
+
+	// Descend into multipart bodies
+	if strings.HasPrefix(mediatype, "multipart/") {
+		reader := multipart.NewReader(body, params["boundary"])
+		for {
+			part, err := reader.NextRawPart()
+			if err == io.EOF {
+				return nil
+			} else if err != nil {
+				return schema.ErrBadParameter.Withf("email: %v", err)
+			}
+			if err := e.walk(part.Header.Get("Content-Type"), part.Header.Get("Content-Transfer-Encoding"), part.Header.Get("Content-Disposition"), part, html, parts); err != nil {
+				return err
+			}
+		}
+	}
+
+	// Decode the part
+	data, err := io.ReadAll(decode(encoding, body))
+	if err != nil {
+		return schema.ErrBadParameter.Withf("email: %v", err)
+	}
+
+	// Attachments have a filename or are marked as attachments
+	name := params["name"]
+	if kind, dparams, err := mime.ParseMediaType(disposition); err == nil {
+		if filename := dparams["filename"]; filename != "" {
+			name = filename
+		}
+		if kind == "attachment" && name == "" {
+			name = "attachment"
+		}
+	}
+	switch {
+	case name != "":
+		e.Attachments = append(e.Attachments, File{Name: decodeHeader(name), ContentType: mediatype, Data: data})
+	case mediatype == "text/plain" && e.Text == "":
+		e.Text = string(data)
+	case mediatype == "text/html" && *html == "":
+		*html = string(data)
+	}
+	return nil
+}
+
+// decode returns a reader which decodes the transfer encoding of a part
+func decode(encoding string, r io.Reader) io.Reader {
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "base64":
+		return base64.NewDecoder(base64.StdEncoding, &lineReader{r: r})
+	case "quoted-printable":
+		return quotedprintable.NewReader(r)
+	default:
+		return r
+	}
+}
+
+// lineReader removes line breaks, which base64 decoding does not allow
+type lineReader struct {
+	r io.Reader
+}
+
+func (l *lineReader) Read(p []byte) (int, error) {
+	n, err := l.r.Read(p)
+	n = copy(p, bytes.Map(func(r rune) rune {
+		if r == '\r' || r == '\n' {
+			return -1
+		}
+		return r
+	}, p[:n]))
+	return n, err
+}
+
+// decodeHeader decodes encoded words in a header
+func decodeHeader(value string) string {
+	decoded, err := new(mime.WordDecoder).DecodeHeader(value)
+	if err != nil {
+		return value
+	}
+	return decoded
+}
+
+// messageID returns a Message-ID without whitespace
+func messageID(value string) string {
+	if ids := messageIDs(value); len(ids) > 0 {
+		return ids[0]
+	}
+	return ""
+}
+
+// messageIDs returns the Message-IDs in a header
+func messageIDs(value string) []string {
+	var result []string
+	for _, id := range strings.Fields(value) {
+		if id = strings.TrimSpace(id); id != "" {
+			result = append(result, id)
+		}
+	}
+	return result
+}
+
+// stripQuoted removes text quoted from earlier emails in the thread, which
+// is already in the session
+func stripQuoted(text string) string {
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	result := make([]string, 0, len(lines))
+	for i, line := range lines {
+		if strings.HasPrefix(line, ">") {
+			continue
+		}
+		// Drop the attribution line before a quote, such as "On ... wrote:"
+		if strings.HasSuffix(strings.TrimSpace(line), "wrote:") && i+1 < len(lines) && strings.HasPrefix(strings.TrimSpace(lines[i+1]), ">") {
+			continue
+		}
+		result = append(result, line)
+	}
+	return strings.TrimSpace(strings.Join(result, "\n"))
+}
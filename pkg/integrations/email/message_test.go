@@ -0,0 +1,96 @@
+package email_test
+
+import (
+	"net/mail"
+	"strings"
+	"testing"
+
+	// Packages
+	email "github.com/mutablelogic/go-llm/pkg/integrations/email"
+	assert "github.com/stretchr/testify/assert"
+)
+
+const multipartEmail = "From: Alice <alice@example.com>\r\n" +
+	"To: bot@example.com\r\n" +
+	"Subject: =?utf-8?q?Caf=C3=A9_report?=\r\n" +
+	"Message-ID: <2@example.com>\r\n" +
+	"In-Reply-To: <1@example.com>\r\n" +
+	"References: <0@example.com> <1@example.com>\r\n" +
+	"MIME-Version: 1.0\r\n" +
+	"Content-Type: multipart/mixed; boundary=XYZ\r\n" +
+	"\r\n" +
+	"--XYZ\r\n" +
+	"Content-Type: multipart/alternative; boundary=ABC\r\n" +
+	"\r\n" +
+	"--ABC\r\n" +
+	"Content-Type: text/plain; charset=utf-8\r\n" +
+	"Content-Transfer-Encoding: quoted-printable\r\n" +
+	"\r\n" +
+	"Please summarize the caf=C3=A9 report.\r\n" +
+	"\r\n" +
+	"On Monday, Bot wrote:\r\n" +
+	"> Earlier reply\r\n" +
+	"--ABC\r\n" +
+	"Content-Type: text/html\r\n" +
+	"\r\n" +
+	"<p>Please summarize</p>\r\n" +
+	"--ABC--\r\n" +
+	"--XYZ\r\n" +
+	"Content-Type: text/plain; name=report.txt\r\n" +
+	"Content-Disposition: attachment; filename=report.txt\r\n" +
+	"Content-Transfer-Encoding: base64\r\n" +
+	"\r\n" +
+	"aGVsbG8g\r\n" +
+	"d29ybGQ=\r\n" +
+	"--XYZ--\r\n"
+
+func Test_message_001(t *testing.T) {
+	assert := assert.New(t)
+	msg, err := email.Parse(strings.NewReader(multipartEmail))
+	if !assert.NoError(err) {
+		t.FailNow()
+	}
+	assert.Equal("alice@example.com", msg.From.Address)
+	assert.Equal("Café report", msg.Subject)
+	assert.Equal("<2@example.com>", msg.MessageID)
+	assert.Equal("Please summarize the café report.", msg.Text)
+	assert.Equal("<0@example.com>", msg.Thread())
+	assert.Equal("email:<0@example.com>", msg.Label())
+	if assert.Len(msg.Attachments, 1) {
+		assert.Equal("report.txt", msg.Attachments[0].Name)
+		assert.Equal("text/plain", msg.Attachments[0].ContentType)
+		assert.Equal("hello world", string(msg.Attachments[0].Data))
+	}
+}
+
+func Test_message_002(t *testing.T) {
+	assert := assert.New(t)
+	msg, err := email.Parse(strings.NewReader("From: bob@example.com\r\nSubject: Hi\r\nMessage-ID: <a@b>\r\n\r\nHello\r\n"))
+	if !assert.NoError(err) {
+		t.FailNow()
+	}
+	assert.Equal("Hello", msg.Text)
+	assert.Equal("email:<a@b>", msg.Label())
+	assert.Equal("Re: Hi", msg.ReplySubject())
+
+	// A Message-ID is required to thread replies
+	_, err = email.Parse(strings.NewReader("From: bob@example.com\r\nSubject: Hi\r\n\r\nHello\r\n"))
+	assert.Error(err)
+}
+
+func Test_message_003(t *testing.T) {
+	assert := assert.New(t)
+	msg, err := email.Parse(strings.NewReader("From: bob@example.com\r\nSubject: Re: Hi\r\nMessage-ID: <b@b>\r\nIn-Reply-To: <a@b>\r\n\r\nThanks\r\n"))
+	if !assert.NoError(err) {
+		t.FailNow()
+	}
+	assert.Equal("email:<a@b>", msg.Label())
+
+	reply := string(msg.Reply(&mail.Address{Name: "Bot", Address: "bot@example.com"}, "You're welcome"))
+	assert.Contains(reply, "To: <bob@example.com>\r\n")
+	assert.Contains(reply, "Subject: Re: Hi\r\n")
+	assert.Contains(reply, "In-Reply-To: <b@b>\r\n")
+	assert.Contains(reply, "References: <a@b> <b@b>\r\n")
+	assert.Contains(reply, "@example.com>\r\n")
+	assert.True(strings.HasSuffix(reply, "\r\n\r\nYou're welcome"))
+}
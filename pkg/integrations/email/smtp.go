@@ -0,0 +1,74 @@
+package email
+
+import (
+	"bytes"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strings"
+	"time"
+
+	// Packages
+	uuid "github.com/google/uuid"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// Reply returns a reply to an email, from the given address, which threads
+// with the email in mail clients
+func (e *Email) Reply(from *mail.Address, text string) []byte {
+	var buf bytes.Buffer
+	domain := "localhost"
+	if i := strings.LastIndexByte(from.Address, '@'); i >= 0 {
+		domain = from.Address[i+1:]
+	}
+	references := append([]string{}, e.References...)
+	if len(references) == 0 && e.InReplyTo != "" {
+		references = append(references, e.InReplyTo)
+	}
+	references = append(references, e.MessageID)
+
+	// Headers
+	header := func(key, value string) {
+		fmt.Fprintf(&buf, "%s: %s\r\n", key, value)
+	}
+	header("From", from.String())
+	header("To", e.From.String())
+	header("Subject", mime.QEncoding.Encode("utf-8", e.ReplySubject()))
+	header("Date", time.Now().Format(time.RFC1123Z))
+	header("Message-ID", fmt.Sprintf("<%s@%s>", uuid.NewString(), domain))
+	header("In-Reply-To", e.MessageID)
+	header("References", strings.Join(references, " "))
+	header("MIME-Version", "1.0")
+	header("Content-Type", "text/plain; charset=utf-8")
+	header("Content-Transfer-Encoding", "quoted-printable")
+	buf.WriteString("\r\n")
+
+	// Body
+	w := quotedprintable.NewWriter(&buf)
+	w.Write([]byte(strings.ReplaceAll(text, "\n", "\r\n")))
+	w.Close()
+
+	return buf.Bytes()
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// send delivers a message over SMTP, using STARTTLS when the server
+// supports it, and authenticating when a username is set
+func send(addr, username, password string, from, to *mail.Address, message []byte) error {
+	var auth smtp.Auth
+	if username != "" {
+		host, _, err := net.SplitHostPort(addr)
+		if err != nil {
+			return err
+		}
+		auth = smtp.PlainAuth("", username, password, host)
+	}
+	return smtp.SendMail(addr, auth, from.Address, []string{to.Address}, message)
+}
@@ -0,0 +1,84 @@
+/*
+integrations connects sessions to chat platforms. Each conversation on a
+platform, such as a thread or channel, is identified by a label which is
+stored as a session tag, and inbound messages for a label are handled one
+at a time.
+*/
+package integrations
+
+import (
+	"context"
+	"strings"
+
+	// Packages
+	uuid "github.com/google/uuid"
+	auth "github.com/mutablelogic/go-auth/auth/schema"
+	llm "github.com/mutablelogic/go-llm"
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	opt "github.com/mutablelogic/go-llm/pkg/opt"
+	pg "github.com/mutablelogic/go-pg"
+	types "github.com/mutablelogic/go-server/pkg/types"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// Manager is the subset of the LLM manager used by integrations
+type Manager interface {
+	LockLabel(ctx context.Context, label string) (func(), error)
+	ListSessions(ctx context.Context, req schema.SessionListRequest, user *auth.UserInfo) (*schema.SessionList, error)
+	CreateSession(ctx context.Context, req schema.SessionInsert, user *auth.UserInfo) (*schema.Session, error)
+	AppendMessage(ctx context.Context, session uuid.UUID, req schema.MessageCreateRequest, user *auth.UserInfo) (*schema.Message, error)
+	Chat(ctx context.Context, req schema.ChatRequest, fn opt.StreamFn, user *auth.UserInfo, attachments ...llm.Resource) (*schema.ChatResponse, error)
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+const maxTitle = 60
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// Session returns the session tagged with a label, creating it with the
+// generator settings and a title from the first message if it does not exist.
+// The caller should hold the lock for the label.
+func Session(ctx context.Context, manager Manager, label string, meta schema.GeneratorMeta, text string, user *auth.UserInfo) (*schema.Session, error) {
+	list, err := manager.ListSessions(ctx, schema.SessionListRequest{
+		OffsetLimit: pg.OffsetLimit{Limit: types.Ptr(uint64(1))},
+		Tags:        []string{label},
+	}, user)
+	if err != nil {
+		return nil, err
+	} else if len(list.Body) > 0 {
+		return list.Body[0], nil
+	}
+	return manager.CreateSession(ctx, schema.SessionInsert{
+		SessionMeta: schema.SessionMeta{
+			GeneratorMeta: meta,
+			Title:         types.Ptr(Title(text, label)),
+			Tags:          []string{label},
+		},
+	}, user)
+}
+
+// Title returns a session title from the first message of a conversation,
+// or the fallback when the message has no text
+func Title(text, fallback string) string {
+	text = strings.Join(strings.Fields(text), " ")
+	if text == "" {
+		return fallback
+	}
+	if runes := []rune(text); len(runes) > maxTitle {
+		return string(runes[:maxTitle-1]) + "…"
+	}
+	return text
+}
+
+// Reply returns the text of a chat response
+func Reply(response *schema.ChatResponse) string {
+	if response == nil {
+		return ""
+	}
+	return strings.TrimSpace(schema.Message{Content: response.Content}.Text())
+}
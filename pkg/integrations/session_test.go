@@ -0,0 +1,28 @@
+package integrations_test
+
+import (
+	"strings"
+	"testing"
+
+	// Packages
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	integrations "github.com/mutablelogic/go-llm/pkg/integrations"
+	assert "github.com/stretchr/testify/assert"
+)
+
+func Test_session_001(t *testing.T) {
+	assert := assert.New(t)
+	assert.Equal("label", integrations.Title("  ", "label"))
+	assert.Equal("hello world", integrations.Title(" hello\n  world ", "label"))
+	title := integrations.Title(strings.Repeat("a", 100), "label")
+	assert.Equal(60, len([]rune(title)))
+	assert.True(strings.HasSuffix(title, "…"))
+}
+
+func Test_session_002(t *testing.T) {
+	assert := assert.New(t)
+	assert.Equal("", integrations.Reply(nil))
+	text := " hello "
+	response := &schema.ChatResponse{CompletionResponse: schema.CompletionResponse{Content: []schema.ContentBlock{{Text: &text}}}}
+	assert.Equal("hello", integrations.Reply(response))
+}
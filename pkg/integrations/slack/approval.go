@@ -0,0 +1,136 @@
+package slack
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	// Packages
+	uuid "github.com/google/uuid"
+	manager "github.com/mutablelogic/go-llm/kernel/manager"
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// decision is the answer to an approval request
+type decision struct {
+	approved bool
+	user     string
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+// approvalTimeout is how long to wait for an answer before denying a call
+const approvalTimeout = 10 * time.Minute
+
+// maxInput is the maximum length of tool input shown in an approval request
+const maxInput = 2000
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// withApproval returns a context in which each tool call is approved by
+// pressing a button posted to the thread
+func withApproval(ctx context.Context, b *Bot, channel, thread string) context.Context {
+	return manager.WithToolApproval(ctx, func(ctx context.Context, _ uuid.UUID, call schema.ToolCall) error {
+		return b.approval(ctx, channel, thread, call)
+	})
+}
+
+// approval posts buttons to approve or deny a tool call, and waits for one
+// to be pressed
+func (b *Bot) approval(ctx context.Context, channel, thread string, call schema.ToolCall) error {
+	id := uuid.NewString()
+	ch := make(chan decision, 1)
+	b.Lock()
+	b.approvals[id] = ch
+	b.Unlock()
+	defer func() {
+		b.Lock()
+		delete(b.approvals, id)
+		b.Unlock()
+	}()
+
+	// Post the request
+	text := approvalText(call)
+	ts, err := b.PostMessage(ctx, Message{
+		Channel:  channel,
+		ThreadTS: thread,
+		Text:     text,
+		Blocks:   []Block{NewTextBlock(text), NewButtonBlock(id)},
+	})
+	if err != nil {
+		return err
+	}
+
+	// Wait for the answer
+	var answer decision
+	var result error
+	select {
+	case answer = <-ch:
+		if !answer.approved {
+			result = schema.ErrBadParameter.Withf("the call to %q was denied by the user", call.Name)
+		}
+	case <-time.After(approvalTimeout):
+		result = schema.ErrBadParameter.Withf("the call to %q was not approved in time", call.Name)
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	// Replace the buttons with the outcome
+	status := ":x: Denied"
+	switch {
+	case answer.approved:
+		status = ":white_check_mark: Approved"
+	case answer.user == "":
+		status = ":hourglass: Timed out"
+	}
+	if answer.user != "" {
+		status += fmt.Sprintf(" by <@%s>", answer.user)
+	}
+	if err := b.UpdateMessage(ctx, Message{
+		Channel: channel,
+		TS:      ts,
+		Text:    text,
+		Blocks:  []Block{NewTextBlock(text), NewTextBlock(status)},
+	}); err != nil {
+		b.logger.ErrorContext(ctx, "slack: update approval", "error", err)
+	}
+
+	return result
+}
+
+// resolve passes the buttons pressed in an interaction to the waiting
+// approval requests
+func (b *Bot) resolve(interaction Interaction) {
+	b.Lock()
+	defer b.Unlock()
+	for _, action := range interaction.Actions {
+		if action.ID != ActionApprove && action.ID != ActionDeny {
+			continue
+		}
+		ch, exists := b.approvals[action.Value]
+		if !exists {
+			continue
+		}
+		select {
+		case ch <- decision{approved: action.ID == ActionApprove, user: interaction.User.ID}:
+		default:
+			// Already answered
+		}
+	}
+}
+
+// approvalText describes a tool call
+func approvalText(call schema.ToolCall) string {
+	input := string(call.Input)
+	if input == "" {
+		input = "{}"
+	} else if runes := []rune(input); len(runes) > maxInput {
+		input = string(runes[:maxInput]) + "…"
+	}
+	return fmt.Sprintf("Run tool `%s`?\n```%s```", call.Name, input)
+}
@@ -0,0 +1,33 @@
+package slack
+
+import (
+	"testing"
+
+	// Packages
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	assert "github.com/stretchr/testify/assert"
+)
+
+func Test_approval_001(t *testing.T) {
+	assert := assert.New(t)
+	bot := &Bot{approvals: map[string]chan decision{"a": make(chan decision, 1), "b": make(chan decision, 1)}}
+
+	bot.resolve(Interaction{User: User{ID: "U1"}, Actions: []Action{{ID: ActionDeny, Value: "b"}, {ID: "other", Value: "a"}}})
+	assert.Len(bot.approvals["a"], 0)
+	if assert.Len(bot.approvals["b"], 1) {
+		answer := <-bot.approvals["b"]
+		assert.False(answer.approved)
+		assert.Equal("U1", answer.user)
+	}
+
+	// A second press is ignored until the first is read
+	bot.resolve(Interaction{Actions: []Action{{ID: ActionApprove, Value: "a"}}})
+	bot.resolve(Interaction{Actions: []Action{{ID: ActionDeny, Value: "a"}}})
+	assert.True((<-bot.approvals["a"]).approved)
+}
+
+func Test_approval_002(t *testing.T) {
+	assert := assert.New(t)
+	assert.Equal("Run tool `get_weather`?\n```{\"city\":\"London\"}```", approvalText(schema.ToolCall{Name: "get_weather", Input: []byte(`{"city":"London"}`)}))
+	assert.Equal("Run tool `now`?\n```{}```", approvalText(schema.ToolCall{Name: "now"}))
+}
@@ -0,0 +1,229 @@
+package slack
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"sync"
+
+	// Packages
+	auth "github.com/mutablelogic/go-auth/auth/schema"
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	integrations "github.com/mutablelogic/go-llm/pkg/integrations"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// Bot answers mentions and direct messages in Slack. Each thread is a
+// session, which is found by a label tag and created on the first mention.
+type Bot struct {
+	*Client
+	manager integrations.Manager
+	user    *auth.UserInfo
+	logger  *slog.Logger
+	meta    schema.GeneratorMeta
+	tools   []string
+	approve bool
+
+	// Set when the bot runs
+	team, id string
+
+	// Tool calls waiting for approval
+	sync.Mutex
+	approvals map[string]chan decision
+}
+
+// Opt configures a Bot
+type Opt func(*Bot) error
+
+///////////////////////////////////////////////////////////////////////////////
+// LIFECYCLE
+
+// NewBot returns a bot which chats with the manager as the given user
+func NewBot(client *Client, manager integrations.Manager, user *auth.UserInfo, opts ...Opt) (*Bot, error) {
+	if client == nil || manager == nil {
+		return nil, schema.ErrBadParameter.With("client and manager are required")
+	}
+	if user == nil {
+		return nil, schema.ErrBadParameter.With("user is required")
+	}
+	bot := &Bot{
+		Client:    client,
+		manager:   manager,
+		user:      user,
+		logger:    slog.Default(),
+		approvals: make(map[string]chan decision),
+	}
+	for _, fn := range opts {
+		if err := fn(bot); err != nil {
+			return nil, err
+		}
+	}
+	return bot, nil
+}
+
+// WithLogger sets the logger for errors which cannot be posted to Slack
+func WithLogger(logger *slog.Logger) Opt {
+	return func(b *Bot) error {
+		if logger != nil {
+			b.logger = logger
+		}
+		return nil
+	}
+}
+
+// WithGenerator sets the provider, model and system prompt for new sessions
+func WithGenerator(meta schema.GeneratorMeta) Opt {
+	return func(b *Bot) error {
+		b.meta = meta
+		return nil
+	}
+}
+
+// WithTools sets the tool names or glob patterns available in chats
+func WithTools(tools ...string) Opt {
+	return func(b *Bot) error {
+		b.tools = tools
+		return nil
+	}
+}
+
+// WithApproval asks for approval in the thread before each tool call is run
+func WithApproval() Opt {
+	return func(b *Bot) error {
+		b.approve = true
+		return nil
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// Run receives events until the context is cancelled
+func (b *Bot) Run(ctx context.Context) error {
+	team, id, err := b.AuthTest(ctx)
+	if err != nil {
+		return err
+	}
+	b.team, b.id = team, id
+
+	// Handle each chat in the background, as the envelope is already
+	// acknowledged and the chat may wait for the thread lock
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	return b.Listen(ctx, func(ctx context.Context, envelope Envelope) {
+		switch envelope.Type {
+		case EnvelopeEvents:
+			var callback EventCallback
+			if err := json.Unmarshal(envelope.Payload, &callback); err != nil {
+				b.logger.ErrorContext(ctx, "slack: invalid event", "error", err)
+				return
+			}
+			if !b.accept(callback.Event) {
+				return
+			}
+			team := callback.Team
+			if team == "" {
+				team = b.team
+			}
+			wg.Go(func() {
+				if err := b.chat(ctx, team, callback.Event); err != nil {
+					b.logger.ErrorContext(ctx, "slack: chat failed", "error", err)
+				}
+			})
+		case EnvelopeInteractive:
+			var interaction Interaction
+			if err := json.Unmarshal(envelope.Payload, &interaction); err != nil {
+				b.logger.ErrorContext(ctx, "slack: invalid interaction", "error", err)
+				return
+			}
+			b.resolve(interaction)
+		}
+	})
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// accept returns true if the event should start a chat turn: a mention of
+// the bot, or a direct message to it
+func (b *Bot) accept(event Event) bool {
+	if event.FromBot() || (b.id != "" && event.User == b.id) {
+		return false
+	}
+	return event.Type == EventAppMention || event.IsDirect()
+}
+
+// chat runs a chat turn for an event and posts the reply in its thread
+func (b *Bot) chat(ctx context.Context, team string, event Event) error {
+	label := event.Label(team)
+
+	// Handle messages in the thread one at a time
+	unlock, err := b.manager.LockLabel(ctx, label)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	// Post any error to the thread
+	if err := b.turn(ctx, label, event); err != nil {
+		_, perr := b.PostMessage(ctx, Message{
+			Channel:  event.Channel,
+			ThreadTS: event.Thread(),
+			Text:     ":warning: " + err.Error(),
+		})
+		if perr != nil {
+			return perr
+		}
+	}
+	return nil
+}
+
+// turn sends the text and files of an event to the session for the thread
+func (b *Bot) turn(ctx context.Context, label string, event Event) error {
+	session, err := integrations.Session(ctx, b.manager, label, b.meta, event.Prompt(b.id), b.user)
+	if err != nil {
+		return err
+	}
+
+	// Store files as pending input, which is sent with the text
+	if len(event.Files) > 0 {
+		req := schema.MessageCreateRequest{}
+		for _, file := range event.Files {
+			data, err := b.Download(ctx, file)
+			if err != nil {
+				return err
+			}
+			req.Attachments = append(req.Attachments, schema.Attachment{ContentType: file.Mimetype, Data: data})
+		}
+		if _, err := b.manager.AppendMessage(ctx, session.ID, req, b.user); err != nil {
+			return err
+		}
+	}
+
+	// Run the chat turn, with approval for tool calls if enabled
+	if b.approve {
+		ctx = withApproval(ctx, b, event.Channel, event.Thread())
+	}
+	response, err := b.manager.Chat(ctx, schema.ChatRequest{
+		Session: session.ID,
+		Text:    event.Prompt(b.id),
+		Tools:   b.tools,
+	}, nil, b.user)
+	if err != nil {
+		return err
+	}
+
+	// Post the reply
+	text := integrations.Reply(response)
+	if text == "" {
+		return nil
+	}
+	_, err = b.PostMessage(ctx, Message{
+		Channel:  event.Channel,
+		ThreadTS: event.Thread(),
+		Text:     text,
+	})
+	return err
+}
@@ -0,0 +1,170 @@
+/*
+slack connects sessions to Slack, using Socket Mode to receive events
+so that no public endpoint is required.
+https://api.slack.com/apis/socket-mode
+*/
+package slack
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"strings"
+
+	// Packages
+	client "github.com/mutablelogic/go-client"
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	types "github.com/mutablelogic/go-server/pkg/types"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// Client calls the Slack Web API with a bot token, and opens Socket Mode
+// connections with an app-level token
+type Client struct {
+	*client.Client
+	appToken string
+}
+
+type response struct {
+	OK    bool   `json:"ok"`
+	Error string `json:"error,omitempty"`
+}
+
+type connectResponse struct {
+	response
+	URL string `json:"url"`
+}
+
+type authResponse struct {
+	response
+	Team string `json:"team_id"`
+	User string `json:"user_id"`
+}
+
+type messageResponse struct {
+	response
+	Channel string `json:"channel"`
+	TS      string `json:"ts"`
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+const (
+	endPoint = "https://slack.com/api/"
+)
+
+var (
+	methodPost = client.NewRequestEx(http.MethodPost, types.ContentTypeJSON)
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// LIFECYCLE
+
+// New returns a client with an app-level token (xapp-) used for Socket Mode
+// and a bot token (xoxb-) used for the Web API
+func New(appToken, botToken string, opts ...client.ClientOpt) (*Client, error) {
+	if !strings.HasPrefix(appToken, "xapp-") {
+		return nil, schema.ErrBadParameter.With("slack app token should start with xapp-")
+	}
+	if !strings.HasPrefix(botToken, "xoxb-") {
+		return nil, schema.ErrBadParameter.With("slack bot token should start with xoxb-")
+	}
+
+	// Create client
+	c, err := client.New(append(opts, client.OptEndpoint(endPoint), client.OptReqToken(client.Token{
+		Scheme: client.Bearer,
+		Value:  botToken,
+	}))...)
+	if err != nil {
+		return nil, err
+	}
+
+	// Return the client
+	return &Client{Client: c, appToken: appToken}, nil
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// API CALLS
+
+// AuthTest returns the team and user ID of the bot
+func (c *Client) AuthTest(ctx context.Context) (string, string, error) {
+	var response authResponse
+	if err := c.DoWithContext(ctx, methodPost, &response, client.OptPath("auth.test")); err != nil {
+		return "", "", err
+	} else if err := response.Err("auth.test"); err != nil {
+		return "", "", err
+	}
+	return response.Team, response.User, nil
+}
+
+// PostMessage posts a message to a channel or thread, and returns the
+// timestamp which identifies the message
+func (c *Client) PostMessage(ctx context.Context, message Message) (string, error) {
+	payload, err := client.NewJSONRequest(message)
+	if err != nil {
+		return "", err
+	}
+	var response messageResponse
+	if err := c.DoWithContext(ctx, payload, &response, client.OptPath("chat.postMessage")); err != nil {
+		return "", err
+	} else if err := response.Err("chat.postMessage"); err != nil {
+		return "", err
+	}
+	return response.TS, nil
+}
+
+// UpdateMessage replaces the text and blocks of a posted message
+func (c *Client) UpdateMessage(ctx context.Context, message Message) error {
+	if message.TS == "" {
+		return schema.ErrBadParameter.With("message timestamp is required")
+	}
+	payload, err := client.NewJSONRequest(message)
+	if err != nil {
+		return err
+	}
+	var response messageResponse
+	if err := c.DoWithContext(ctx, payload, &response, client.OptPath("chat.update")); err != nil {
+		return err
+	}
+	return response.Err("chat.update")
+}
+
+// Download returns the contents of a file shared in a message
+func (c *Client) Download(ctx context.Context, file File) ([]byte, error) {
+	if file.URL == "" {
+		return nil, schema.ErrBadParameter.Withf("file %q has no download URL", file.Name)
+	}
+	var data bytes.Buffer
+	if err := c.DoWithContext(ctx, client.MethodGet, &data, client.OptReqEndpoint(file.URL)); err != nil {
+		return nil, err
+	}
+	return data.Bytes(), nil
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// connect returns the URL for a new Socket Mode connection
+func (c *Client) connect(ctx context.Context) (string, error) {
+	var response connectResponse
+	if err := c.DoWithContext(ctx, methodPost, &response, client.OptPath("apps.connections.open"), client.OptToken(client.Token{
+		Scheme: client.Bearer,
+		Value:  c.appToken,
+	})); err != nil {
+		return "", err
+	} else if err := response.Err("apps.connections.open"); err != nil {
+		return "", err
+	}
+	return response.URL, nil
+}
+
+// Err returns an error if the Web API call was not successful
+func (r response) Err(method string) error {
+	if r.OK {
+		return nil
+	}
+	return schema.ErrInternalServerError.Withf("slack %s: %s", method, r.Error)
+}
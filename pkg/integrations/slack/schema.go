@@ -0,0 +1,204 @@
+package slack
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// Envelope is a message received on a Socket Mode connection. Every
+// envelope with an ID must be acknowledged.
+type Envelope struct {
+	ID      string          `json:"envelope_id,omitempty"`
+	Type    string          `json:"type"`
+	Reason  string          `json:"reason,omitempty"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+// EventCallback is the payload of an events_api envelope
+type EventCallback struct {
+	Team  string `json:"team_id"`
+	Event Event  `json:"event"`
+}
+
+// Event is a message or mention in a channel
+type Event struct {
+	Type        string `json:"type"`
+	Subtype     string `json:"subtype,omitempty"`
+	User        string `json:"user,omitempty"`
+	Bot         string `json:"bot_id,omitempty"`
+	Channel     string `json:"channel"`
+	ChannelType string `json:"channel_type,omitempty"`
+	Text        string `json:"text"`
+	TS          string `json:"ts"`
+	ThreadTS    string `json:"thread_ts,omitempty"`
+	Files       []File `json:"files,omitempty"`
+}
+
+// File is a file shared in a message
+type File struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	Mimetype string `json:"mimetype"`
+	Size     int64  `json:"size"`
+	URL      string `json:"url_private_download"`
+}
+
+// Interaction is the payload of an interactive envelope, sent when a user
+// presses a button
+type Interaction struct {
+	Type    string   `json:"type"`
+	User    User     `json:"user"`
+	Channel Channel  `json:"channel"`
+	Actions []Action `json:"actions"`
+}
+
+type User struct {
+	ID   string `json:"id"`
+	Name string `json:"username,omitempty"`
+}
+
+type Channel struct {
+	ID string `json:"id"`
+}
+
+// Action is a button press within an interaction
+type Action struct {
+	ID    string `json:"action_id"`
+	Value string `json:"value"`
+}
+
+// Message is a message posted to a channel
+type Message struct {
+	Channel  string  `json:"channel"`
+	TS       string  `json:"ts,omitempty"`
+	ThreadTS string  `json:"thread_ts,omitempty"`
+	Text     string  `json:"text"`
+	Blocks   []Block `json:"blocks,omitempty"`
+}
+
+// Block is a layout block in a message
+type Block struct {
+	Type     string    `json:"type"`
+	Text     *Text     `json:"text,omitempty"`
+	Elements []Element `json:"elements,omitempty"`
+}
+
+// Element is an interactive element within an actions block
+type Element struct {
+	Type     string `json:"type"`
+	Text     *Text  `json:"text,omitempty"`
+	ActionID string `json:"action_id,omitempty"`
+	Value    string `json:"value,omitempty"`
+	Style    string `json:"style,omitempty"`
+}
+
+type Text struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+const (
+	EnvelopeHello       = "hello"
+	EnvelopeDisconnect  = "disconnect"
+	EnvelopeEvents      = "events_api"
+	EnvelopeInteractive = "interactive"
+)
+
+const (
+	EventAppMention = "app_mention"
+	EventMessage    = "message"
+)
+
+const (
+	ActionApprove = "tool_approve"
+	ActionDeny    = "tool_deny"
+)
+
+// labelPrefix is the prefix for session tags which identify a thread
+const labelPrefix = "slack:"
+
+///////////////////////////////////////////////////////////////////////////////
+// STRINGIFY
+
+func (e Envelope) String() string {
+	data, err := json.MarshalIndent(e, "", "  ")
+	if err != nil {
+		return err.Error()
+	}
+	return string(data)
+}
+
+func (e Event) String() string {
+	data, err := json.MarshalIndent(e, "", "  ")
+	if err != nil {
+		return err.Error()
+	}
+	return string(data)
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// Thread returns the timestamp of the thread for the event. A message which
+// is not in a thread starts a new thread.
+func (e Event) Thread() string {
+	if e.ThreadTS != "" {
+		return e.ThreadTS
+	}
+	return e.TS
+}
+
+// Label returns the session label for the thread of the event
+func (e Event) Label(team string) string {
+	return labelPrefix + strings.Join([]string{team, e.Channel, e.Thread()}, ":")
+}
+
+// IsDirect returns true if the event is a message in a direct conversation
+// with the bot
+func (e Event) IsDirect() bool {
+	return e.Type == EventMessage && e.ChannelType == "im"
+}
+
+// FromBot returns true if the event was posted by a bot, or is an edit or
+// other change to an existing message, and should not start a chat
+func (e Event) FromBot() bool {
+	if e.Bot != "" {
+		return true
+	}
+	switch e.Subtype {
+	case "", "file_share", "thread_broadcast":
+		return false
+	default:
+		return true
+	}
+}
+
+// Prompt returns the text of the event with mentions of the bot removed
+func (e Event) Prompt(bot string) string {
+	text := e.Text
+	if bot != "" {
+		text = strings.ReplaceAll(text, fmt.Sprintf("<@%s>", bot), "")
+	}
+	return strings.TrimSpace(text)
+}
+
+// NewTextBlock returns a section block with markdown text
+func NewTextBlock(text string) Block {
+	return Block{Type: "section", Text: &Text{Type: "mrkdwn", Text: text}}
+}
+
+// NewButtonBlock returns an actions block with buttons to approve or deny a
+// request with the given value
+func NewButtonBlock(value string) Block {
+	return Block{Type: "actions", Elements: []Element{
+		{Type: "button", Text: &Text{Type: "plain_text", Text: "Approve"}, ActionID: ActionApprove, Value: value, Style: "primary"},
+		{Type: "button", Text: &Text{Type: "plain_text", Text: "Deny"}, ActionID: ActionDeny, Value: value, Style: "danger"},
+	}}
+}
@@ -0,0 +1,65 @@
+package slack_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	// Packages
+	slack "github.com/mutablelogic/go-llm/pkg/integrations/slack"
+	assert "github.com/stretchr/testify/assert"
+)
+
+func Test_schema_001(t *testing.T) {
+	assert := assert.New(t)
+
+	var callback slack.EventCallback
+	assert.NoError(json.Unmarshal([]byte(`{
+		"team_id": "T1",
+		"event": {
+			"type": "app_mention",
+			"user": "U1",
+			"channel": "C1",
+			"text": "<@B1> hello there",
+			"ts": "1700000000.000100",
+			"files": [{"id": "F1", "name": "a.png", "mimetype": "image/png", "url_private_download": "https://files.slack.com/a.png"}]
+		}
+	}`), &callback))
+	assert.Equal("T1", callback.Team)
+	assert.Equal(slack.EventAppMention, callback.Event.Type)
+	assert.Equal("hello there", callback.Event.Prompt("B1"))
+	assert.Len(callback.Event.Files, 1)
+	assert.Equal("image/png", callback.Event.Files[0].Mimetype)
+	assert.False(callback.Event.FromBot())
+}
+
+func Test_schema_002(t *testing.T) {
+	assert := assert.New(t)
+
+	// A message which is not in a thread starts one
+	event := slack.Event{Channel: "C1", TS: "1.2"}
+	assert.Equal("1.2", event.Thread())
+	assert.Equal("slack:T1:C1:1.2", event.Label("T1"))
+
+	// A reply in the thread maps to the same label
+	event = slack.Event{Channel: "C1", TS: "1.5", ThreadTS: "1.2"}
+	assert.Equal("slack:T1:C1:1.2", event.Label("T1"))
+}
+
+func Test_schema_003(t *testing.T) {
+	assert := assert.New(t)
+	assert.True(slack.Event{Bot: "B1"}.FromBot())
+	assert.True(slack.Event{Subtype: "message_changed"}.FromBot())
+	assert.False(slack.Event{Subtype: "file_share"}.FromBot())
+	assert.True(slack.Event{Type: slack.EventMessage, ChannelType: "im"}.IsDirect())
+	assert.False(slack.Event{Type: slack.EventMessage, ChannelType: "channel"}.IsDirect())
+}
+
+func Test_schema_004(t *testing.T) {
+	assert := assert.New(t)
+	block := slack.NewButtonBlock("id")
+	assert.Equal("actions", block.Type)
+	assert.Len(block.Elements, 2)
+	assert.Equal(slack.ActionApprove, block.Elements[0].ActionID)
+	assert.Equal(slack.ActionDeny, block.Elements[1].ActionID)
+	assert.Equal("id", block.Elements[1].Value)
+}
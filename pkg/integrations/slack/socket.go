@@ -0,0 +1,102 @@
+package slack
+
+import (
+	"context"
+	"time"
+
+	// Packages
+	websocket "golang.org/x/net/websocket"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// EnvelopeFn is called for each envelope received on a Socket Mode
+// connection, after it has been acknowledged. It should not block.
+type EnvelopeFn func(context.Context, Envelope)
+
+type ack struct {
+	ID string `json:"envelope_id"`
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+const (
+	origin         = "https://api.slack.com/"
+	reconnectDelay = 5 * time.Second
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// Listen receives envelopes over Socket Mode until the context is cancelled,
+// reconnecting when Slack asks the client to or the connection is lost
+func (c *Client) Listen(ctx context.Context, fn EnvelopeFn) error {
+	for {
+		err := c.listen(ctx, fn)
+		if ctx.Err() != nil {
+			return nil
+		}
+		if err != nil {
+			// Wait before reconnecting after an error
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-time.After(reconnectDelay):
+			}
+		}
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// listen handles a single connection, returning nil when Slack asks for the
+// connection to be refreshed
+func (c *Client) listen(ctx context.Context, fn EnvelopeFn) error {
+	url, err := c.connect(ctx)
+	if err != nil {
+		return err
+	}
+	config, err := websocket.NewConfig(url, origin)
+	if err != nil {
+		return err
+	}
+	ws, err := config.DialContext(ctx)
+	if err != nil {
+		return err
+	}
+	defer ws.Close()
+
+	// Close the connection to unblock the receive when the context is done
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			ws.Close()
+		case <-done:
+		}
+	}()
+
+	for {
+		var envelope Envelope
+		if err := websocket.JSON.Receive(ws, &envelope); err != nil {
+			return err
+		}
+		if envelope.ID != "" {
+			if err := websocket.JSON.Send(ws, ack{ID: envelope.ID}); err != nil {
+				return err
+			}
+		}
+		switch envelope.Type {
+		case EnvelopeHello:
+			continue
+		case EnvelopeDisconnect:
+			return nil
+		default:
+			fn(ctx, envelope)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+// Package jailbreak scores text for attempts to make a model ignore its
+// instructions, such as requests to disregard the system prompt, to reveal
+// it, or to play a persona without restrictions. The score comes from
+// heuristics, each of which has a weight, and is between zero and one.
+package jailbreak
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// signal is a heuristic, with the weight given to a match
+type signal struct {
+	name   string
+	weight float64
+	match  func(text string) bool
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+// Heuristics, in the order in which they are reported
+var signals = []signal{
+	{name: "ignore_instructions", weight: 0.6, match: pattern(`(ignore|disregard|forget|override|bypass|skip)\s+(all\s+|any\s+|the\s+|your\s+|my\s+)*(previous|prior|above|earlier|preceding|original|initial|system)?\s*(instructions|rules|prompts?|directives|guidelines|constraints|restrictions)`)},
+	{name: "reveal_prompt", weight: 0.5, match: pattern(`(reveal|show|print|repeat|output|display|tell\s+me|what\s+(is|are))\s+(me\s+)?(your|the)\s+(system\s+prompt|initial\s+prompt|hidden\s+(prompt|instructions)|instructions\s+above)`)},
+	{name: "unrestricted_persona", weight: 0.5, match: pattern(`\b((?-i:DAN)|do\s+anything\s+now|developer\s+mode|jailbreak(ed)?|god\s+mode|no\s+(ethical\s+|moral\s+)?(restrictions|limits|filters|guidelines))\b`)},
+	{name: "role_override", weight: 0.4, match: pattern(`(you\s+are\s+no\s+longer|from\s+now\s+on\s*,?\s+you\s+(are|will)|pretend\s+(that\s+)?you\s+(are|have)|act\s+as\s+(if\s+you\s+(are|were)\s+)?an?\s+(unfiltered|uncensored|unrestricted))`)},
+	{name: "fake_system_message", weight: 0.4, match: pattern(`(^|\n)\s*(\[|<\|?|###\s*)?(system|assistant)(\]|\|?>|:)`)},
+	{name: "hypothetical_framing", weight: 0.2, match: pattern(`(hypothetical(ly)?|for\s+a\s+(story|novel|movie)|purely\s+fictional|in\s+a\s+fictional\s+world)\b.*\b(how\s+to|instructions|step[-\s]by[-\s]step)`)},
+	{name: "encoded_payload", weight: 0.2, match: encodedPayload},
+}
+
+// Runs of characters which may be an encoded payload
+var reEncoded = regexp.MustCompile(`[A-Za-z0-9+/=]{80,}`)
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// Score returns the likelihood that the text is a jailbreak attempt, between
+// zero and one, with the names of the heuristics which matched. Each match
+// reduces the chance that the text is benign by the weight of the heuristic.
+func Score(text string) (float64, []string) {
+	text = normalize(text)
+	if text == "" {
+		return 0, nil
+	}
+	benign := 1.0
+	var matched []string
+	for _, signal := range signals {
+		if signal.match(text) {
+			benign *= 1 - signal.weight
+			matched = append(matched, signal.name)
+		}
+	}
+	return 1 - benign, matched
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// pattern returns a heuristic which matches a case-insensitive expression
+func pattern(expr string) func(string) bool {
+	re := regexp.MustCompile(`(?is)` + expr)
+	return re.MatchString
+}
+
+// encodedPayload returns true if the text includes a long run of base64
+// characters which is not a plain word
+func encodedPayload(text string) bool {
+	for _, match := range reEncoded.FindAllString(text, -1) {
+		if strings.IndexFunc(match, unicode.IsDigit) >= 0 && strings.IndexFunc(match, unicode.IsUpper) >= 0 && strings.IndexFunc(match, unicode.IsLower) >= 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// normalize removes characters which are invisible or which are used to
+// break up words, so that they do not hide a match
+func normalize(text string) string {
+	return strings.TrimSpace(strings.Map(func(r rune) rune {
+		switch {
+		case r == '\u200b' || r == '\u200c' || r == '\u200d' || r == '\u2060' || r == '\ufeff':
+			return -1
+		case unicode.IsSpace(r) && r != '\n':
+			return ' '
+		default:
+			return r
+		}
+	}, text))
+}
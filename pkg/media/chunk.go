@@ -0,0 +1,106 @@
+// Package media reads transcripts of videos and podcasts. The captions of
+// YouTube videos are read directly, and other audio is transcribed with an
+// llm.Transcriber. Long transcripts are split into chunks of a number of
+// tokens, so that each chunk can be summarized in turn.
+package media
+
+import (
+	"math"
+	"strings"
+	"unicode/utf8"
+
+	// Packages
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	tokenizer "github.com/mutablelogic/go-llm/pkg/tokenizer"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// Chunk is a part of a transcript, with the times in seconds of the first
+// and last segments it contains, when known
+type Chunk struct {
+	Start  float64 `json:"start" help:"Start time in seconds"`
+	End    float64 `json:"end" help:"End time in seconds"`
+	Tokens uint    `json:"tokens" help:"Approximate number of tokens in the text"`
+	Text   string  `json:"text" help:"Text of the transcript"`
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// Split divides a transcription into chunks of at most limit tokens, breaking
+// between segments. A transcription without segments is broken between
+// sentences. A segment or sentence which is longer than limit tokens is
+// broken across chunks. A limit of zero returns the transcription in one
+// chunk.
+func Split(t tokenizer.Tokenizer, transcription *schema.Transcription, limit uint) []Chunk {
+	if limit == 0 {
+		limit = math.MaxUint
+	}
+	segments := transcription.Segments
+	if len(segments) == 0 {
+		for _, sentence := range sentences(transcription.Text) {
+			segments = append(segments, schema.TranscriptionSegment{Text: sentence})
+		}
+	}
+
+	var chunks []Chunk
+	var chunk Chunk
+	var parts []string
+	flush := func() {
+		if len(parts) > 0 {
+			chunk.Text = strings.Join(parts, " ")
+			chunks = append(chunks, chunk)
+		}
+		chunk, parts = Chunk{}, nil
+	}
+	for _, segment := range segments {
+		for text := strings.TrimSpace(segment.Text); text != ""; {
+			part, tokens := text, tokenizer.Count(t, text)
+			if tokens > limit {
+				// Break at the last space which fits, so words are kept whole
+				part = tokenizer.Truncate(t, text, limit)
+				if i := strings.LastIndexByte(part, ' '); i > 0 {
+					part = part[:i]
+				}
+				tokens = tokenizer.Count(t, part)
+			}
+			if part == "" {
+				// The first token does not fit, so take a whole character
+				_, size := utf8.DecodeRuneInString(text)
+				part, tokens = text[:size], 1
+			}
+			text = strings.TrimSpace(text[len(part):])
+			if len(parts) > 0 && chunk.Tokens+tokens > limit {
+				flush()
+			}
+			if len(parts) == 0 {
+				chunk.Start = segment.Start
+			}
+			chunk.End = max(chunk.End, segment.End)
+			chunk.Tokens += tokens
+			parts = append(parts, strings.TrimSpace(part))
+		}
+	}
+	flush()
+	return chunks
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// sentences splits text after full stops, question marks and exclamation
+// marks which are followed by a space
+func sentences(text string) []string {
+	var result []string
+	fields := strings.Fields(text)
+	start := 0
+	for i, field := range fields {
+		if strings.HasSuffix(field, ".") || strings.HasSuffix(field, "?") || strings.HasSuffix(field, "!") || i == len(fields)-1 {
+			result = append(result, strings.Join(fields[start:i+1], " "))
+			start = i + 1
+		}
+	}
+	return result
+}
@@ -0,0 +1,220 @@
+package media_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	// Packages
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	media "github.com/mutablelogic/go-llm/pkg/media"
+	opt "github.com/mutablelogic/go-llm/pkg/opt"
+	tokenizer "github.com/mutablelogic/go-llm/pkg/tokenizer"
+	assert "github.com/stretchr/testify/assert"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// HELPERS
+
+// youtube returns a client which sends requests for any host to a server
+// which serves a watch page and caption tracks
+func youtube(t *testing.T) *http.Client {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/watch":
+			if r.URL.Query().Get("v") != "dQw4w9WgXcQ" {
+				fmt.Fprint(w, `<script>var ytInitialPlayerResponse = {"playabilityStatus":{"status":"ERROR","reason":"Video unavailable"}};</script>`)
+				return
+			}
+			fmt.Fprint(w, `<html><script>var ytInitialPlayerResponse = {
+				"playabilityStatus": {"status": "OK"},
+				"videoDetails": {"title": "Never Gonna", "author": "Rick", "lengthSeconds": "212"},
+				"captions": {"playerCaptionsTracklistRenderer": {"captionTracks": [
+					{"baseUrl": "https://www.youtube.com/api/timedtext?lang=en&kind=asr", "languageCode": "en", "kind": "asr"},
+					{"baseUrl": "https://www.youtube.com/api/timedtext?lang=en", "languageCode": "en"},
+					{"baseUrl": "https://www.youtube.com/api/timedtext?lang=fr&fmt=srv3", "languageCode": "fr"}
+				]}}
+			};var meta = {};</script></html>`)
+		case "/api/timedtext":
+			switch {
+			case r.URL.Query().Get("fmt") == "srv3":
+				fmt.Fprint(w, `<?xml version="1.0" encoding="utf-8" ?><timedtext format="3"><body><p t="1500" d="2000"><s>Jamais</s><s> abandonner</s></p></body></timedtext>`)
+			case r.URL.Query().Get("kind") == "asr":
+				fmt.Fprint(w, `<transcript><text start="0" dur="1">generated</text></transcript>`)
+			default:
+				fmt.Fprint(w, `<?xml version="1.0" encoding="utf-8" ?><transcript><text start="0.5" dur="2.5">Never gonna give you up</text><text start="3" dur="2">never gonna let &amp;#39;you&amp;#39; down</text><text start="5" dur="1">
+</text></transcript>`)
+			}
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+	target, _ := url.Parse(server.URL)
+	return &http.Client{Transport: roundTripper(func(r *http.Request) (*http.Response, error) {
+		r.URL.Scheme, r.URL.Host = target.Scheme, target.Host
+		return http.DefaultTransport.RoundTrip(r)
+	})}
+}
+
+type roundTripper func(*http.Request) (*http.Response, error)
+
+func (fn roundTripper) RoundTrip(r *http.Request) (*http.Response, error) {
+	return fn(r)
+}
+
+// transcriber returns a fixed transcription, and records the audio and
+// options it was called with
+type transcriber struct {
+	audio   *schema.Attachment
+	model   string
+	options opt.Options
+}
+
+func (t *transcriber) Transcribe(_ context.Context, model schema.Model, audio *schema.Attachment, opts ...opt.Opt) (*schema.Transcription, *schema.UsageMeta, error) {
+	options, err := opt.Apply(opts...)
+	if err != nil {
+		return nil, nil, err
+	}
+	t.audio, t.model, t.options = audio, model.Name, options
+	return &schema.Transcription{Language: "en", Duration: 30, Text: "Welcome to the show. Today we talk about Go. Goodbye!"}, nil, nil
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// TESTS
+
+func TestYouTubeID(t *testing.T) {
+	assert := assert.New(t)
+	for input, expected := range map[string]string{
+		"https://www.youtube.com/watch?v=dQw4w9WgXcQ&t=42":   "dQw4w9WgXcQ",
+		"https://youtu.be/dQw4w9WgXcQ?si=abc":                "dQw4w9WgXcQ",
+		"https://m.youtube.com/shorts/dQw4w9WgXcQ":           "dQw4w9WgXcQ",
+		"https://www.youtube-nocookie.com/embed/dQw4w9WgXcQ": "dQw4w9WgXcQ",
+		"https://www.youtube.com/watch?v=short":              "",
+		"https://www.youtube.com/channel/dQw4w9WgXcQ":        "",
+		"https://example.com/watch?v=dQw4w9WgXcQ":            "",
+	} {
+		u, _ := url.Parse(input)
+		id, ok := media.YouTubeID(u)
+		assert.Equal(expected, id, input)
+		assert.Equal(expected != "", ok, input)
+	}
+}
+
+func TestCaptions(t *testing.T) {
+	assert := assert.New(t)
+	client := youtube(t)
+
+	// Captions written by people are preferred
+	video, err := media.Captions(context.Background(), client, "dQw4w9WgXcQ", "en")
+	if assert.NoError(err) {
+		assert.Equal("Never Gonna", video.Title)
+		assert.Equal("Rick", video.Author)
+		assert.Equal("en", video.Transcription.Language)
+		assert.Equal(212.0, video.Transcription.Duration)
+		assert.Equal([]schema.TranscriptionSegment{
+			{Start: 0.5, End: 3, Text: "Never gonna give you up"},
+			{Start: 3, End: 5, Text: "never gonna let 'you' down"},
+		}, video.Transcription.Segments)
+		assert.Equal("Never gonna give you up never gonna let 'you' down", video.Transcription.Text)
+	}
+
+	// Paragraphs are timed in milliseconds
+	video, err = media.Captions(context.Background(), client, "dQw4w9WgXcQ", "fr")
+	if assert.NoError(err) {
+		assert.Equal([]schema.TranscriptionSegment{{Start: 1.5, End: 3.5, Text: "Jamais abandonner"}}, video.Transcription.Segments)
+	}
+
+	// Unavailable videos are not found
+	_, err = media.Captions(context.Background(), client, "aaaaaaaaaaa", "")
+	assert.ErrorIs(err, schema.ErrNotFound)
+	_, err = media.Captions(context.Background(), client, "bad", "")
+	assert.ErrorIs(err, schema.ErrBadParameter)
+}
+
+func TestSplit(t *testing.T) {
+	assert := assert.New(t)
+	tok := tokenizer.ForProvider("")
+
+	// Chunks break between segments, and keep their times
+	transcription := &schema.Transcription{Segments: []schema.TranscriptionSegment{
+		{Start: 0, End: 2, Text: "one two three"},
+		{Start: 2, End: 4, Text: "four five six"},
+		{Start: 4, End: 6, Text: "seven"},
+	}}
+	first := tokenizer.Count(tok, "one two three") + tokenizer.Count(tok, "four five six")
+	chunks := media.Split(tok, transcription, first)
+	if assert.Len(chunks, 2) {
+		assert.Equal(media.Chunk{Start: 0, End: 4, Tokens: first, Text: "one two three four five six"}, chunks[0])
+		assert.Equal(media.Chunk{Start: 4, End: 6, Tokens: tokenizer.Count(tok, "seven"), Text: "seven"}, chunks[1])
+	}
+
+	// Without segments, text breaks between sentences, and long sentences
+	// are broken across chunks without losing text
+	long := strings.Repeat("word ", 50)
+	chunks = media.Split(tok, &schema.Transcription{Text: "Hello there. " + long}, 20)
+	assert.Greater(len(chunks), 2)
+	var text []string
+	for _, chunk := range chunks {
+		assert.LessOrEqual(chunk.Tokens, uint(20))
+		text = append(text, chunk.Text)
+	}
+	assert.Equal(strings.Fields("Hello there. "+long), strings.Fields(strings.Join(text, " ")))
+
+	// No limit returns one chunk
+	assert.Len(media.Split(tok, &schema.Transcription{Text: "Hello there. " + long}, 0), 1)
+}
+
+func TestTool(t *testing.T) {
+	assert := assert.New(t)
+	audio := new(transcriber)
+
+	tok := tokenizer.ForProvider("")
+	tools, err := media.NewTools(media.WithClient(youtube(t)), media.WithTranscriber(audio, "voxtral-mini-latest"))
+	if !assert.NoError(err) || !assert.Len(tools, 1) {
+		return
+	}
+	tool := tools[0]
+	assert.Equal("media_transcript", tool.Name())
+	assert.NotNil(tool.InputSchema())
+
+	// YouTube videos are read from their captions
+	tokens := tokenizer.Count(tok, "never gonna let 'you' down")
+	result, err := tool.Run(context.Background(), fmt.Appendf(nil, `{"url":"https://youtu.be/dQw4w9WgXcQ","tokens":%d,"part":2}`, tokens))
+	if assert.NoError(err) {
+		data, _ := json.Marshal(result)
+		assert.JSONEq(fmt.Sprintf(`{"source":"captions","title":"Never Gonna","author":"Rick","language":"en","duration":212,"part":2,"parts":2,"start":3,"end":5,"tokens":%d,"text":"never gonna let 'you' down"}`, tokens), string(data))
+	}
+	_, err = tool.Run(context.Background(), fmt.Appendf(nil, `{"url":"https://youtu.be/dQw4w9WgXcQ","tokens":%d,"part":3}`, tokens))
+	assert.ErrorIs(err, schema.ErrBadParameter)
+
+	// Other audio is transcribed from its URL
+	result, err = tool.Run(context.Background(), json.RawMessage(`{"url":"https://example.com/episode.ogg","language":"en"}`))
+	if assert.NoError(err) {
+		data, _ := json.Marshal(result)
+		var response map[string]any
+		assert.NoError(json.Unmarshal(data, &response))
+		assert.Equal("transcription", response["source"])
+		assert.Equal(1.0, response["parts"])
+		assert.Equal("Welcome to the show. Today we talk about Go. Goodbye!", response["text"])
+		assert.Equal("voxtral-mini-latest", audio.model)
+		assert.Equal("https://example.com/episode.ogg", audio.audio.URL.String())
+		assert.Equal("audio/ogg", audio.audio.ContentType)
+		assert.Equal("en", audio.options.GetString(opt.LanguageKey))
+	}
+
+	// Without a transcriber, only YouTube videos can be read
+	tools, err = media.NewTools()
+	if assert.NoError(err) {
+		_, err = tools[0].Run(context.Background(), json.RawMessage(`{"url":"https://example.com/episode.mp3"}`))
+		assert.ErrorIs(err, schema.ErrNotImplemented)
+		_, err = tools[0].Run(context.Background(), json.RawMessage(`{"url":"file:///etc/passwd"}`))
+		assert.ErrorIs(err, schema.ErrBadParameter)
+	}
+}
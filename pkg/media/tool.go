@@ -0,0 +1,191 @@
+package media
+
+import (
+	"context"
+	"encoding/json"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+
+	// Packages
+	llm "github.com/mutablelogic/go-llm"
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	opt "github.com/mutablelogic/go-llm/pkg/opt"
+	tokenizer "github.com/mutablelogic/go-llm/pkg/tokenizer"
+	tool "github.com/mutablelogic/go-llm/toolkit/tool"
+	jsonschema "github.com/mutablelogic/go-server/pkg/jsonschema"
+	types "github.com/mutablelogic/go-server/pkg/types"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// Opt configures the media tools
+type Opt func(*transcriptTool) error
+
+type transcriptTool struct {
+	tool.Base
+	client      *http.Client
+	transcriber llm.Transcriber
+	model       string
+}
+
+type transcriptRequest struct {
+	URL      string `json:"url" help:"URL of a YouTube video, or of an audio file such as a podcast episode"`
+	Language string `json:"language,omitempty" help:"Language of the transcript as an ISO 639-1 code, such as en"`
+	Tokens   uint   `json:"tokens,omitempty" help:"Maximum number of tokens in each part of the transcript (defaults to 4000)"`
+	Part     uint   `json:"part,omitempty" help:"Part of the transcript to return, counting from one (defaults to the first part)"`
+}
+
+type transcriptResponse struct {
+	Source   string  `json:"source" help:"Where the transcript came from, which is captions or transcription"`
+	Title    string  `json:"title,omitempty"`
+	Author   string  `json:"author,omitempty"`
+	Language string  `json:"language,omitempty"`
+	Duration float64 `json:"duration,omitempty" help:"Length of the recording in seconds"`
+	Part     uint    `json:"part" help:"Part of the transcript which is returned, counting from one"`
+	Parts    uint    `json:"parts" help:"Number of parts in the transcript"`
+	Chunk
+}
+
+var _ llm.Tool = (*transcriptTool)(nil)
+
+///////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+const (
+	defaultChunkTokens = 4000
+	sourceCaptions     = "captions"
+	sourceTranscribed  = "transcription"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// LIFECYCLE
+
+// NewTools returns a tool which reads the transcript of a YouTube video or a
+// podcast. Audio other than YouTube videos is only transcribed when a
+// transcriber is set with WithTranscriber.
+func NewTools(opts ...Opt) ([]llm.Tool, error) {
+	t := &transcriptTool{client: http.DefaultClient}
+	for _, opt := range opts {
+		if err := opt(t); err != nil {
+			return nil, err
+		}
+	}
+	return []llm.Tool{t}, nil
+}
+
+// WithTranscriber sets the transcriber and model which transcribe audio.
+// An empty model uses the default model of the transcriber.
+func WithTranscriber(transcriber llm.Transcriber, model string) Opt {
+	return func(t *transcriptTool) error {
+		if transcriber == nil {
+			return schema.ErrBadParameter.With("transcriber is required")
+		}
+		t.transcriber, t.model = transcriber, model
+		return nil
+	}
+}
+
+// WithClient sets the HTTP client which reads captions from YouTube
+func WithClient(client *http.Client) Opt {
+	return func(t *transcriptTool) error {
+		if client == nil {
+			return schema.ErrBadParameter.With("client is required")
+		}
+		t.client = client
+		return nil
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// llm.Tool INTERFACE
+
+func (*transcriptTool) Name() string {
+	return "media_transcript"
+}
+
+func (*transcriptTool) Description() string {
+	return "Return the transcript of a YouTube video, or of an audio file such as a podcast episode, for summarizing or answering questions about it. Long transcripts are split into parts; the response includes the number of parts, and each further part is read by setting part."
+}
+
+func (*transcriptTool) InputSchema() *jsonschema.Schema {
+	return jsonschema.MustFor[transcriptRequest]()
+}
+
+func (*transcriptTool) OutputSchema() *jsonschema.Schema {
+	return jsonschema.MustFor[transcriptResponse]()
+}
+
+func (*transcriptTool) Meta() llm.ToolMeta {
+	return llm.ToolMeta{Title: "Media Transcript", ReadOnlyHint: true, IdempotentHint: true, OpenWorldHint: types.Ptr(true)}
+}
+
+func (t *transcriptTool) Run(ctx context.Context, input json.RawMessage) (any, error) {
+	var req transcriptRequest
+	if len(input) > 0 {
+		if err := json.Unmarshal(input, &req); err != nil {
+			return nil, schema.ErrBadParameter.Withf("failed to unmarshal input: %v", err)
+		}
+	}
+	u, err := url.Parse(strings.TrimSpace(req.URL))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, schema.ErrBadParameter.Withf("invalid url %q", req.URL)
+	}
+
+	// Read captions for YouTube videos, and transcribe other audio
+	var response transcriptResponse
+	var transcription *schema.Transcription
+	if id, ok := YouTubeID(u); ok {
+		video, err := Captions(ctx, t.client, id, req.Language)
+		if err != nil {
+			return nil, err
+		}
+		response.Source, response.Title, response.Author = sourceCaptions, video.Title, video.Author
+		transcription = &video.Transcription
+	} else if t.transcriber == nil {
+		return nil, schema.ErrNotImplemented.With("audio transcription is not enabled; only YouTube videos can be read")
+	} else {
+		var opts []opt.Opt
+		if req.Language != "" {
+			opts = append(opts, opt.SetString(opt.LanguageKey, req.Language))
+		}
+		audio := &schema.Attachment{ContentType: audioType(u), URL: u}
+		transcription, _, err = t.transcriber.Transcribe(ctx, schema.Model{Name: t.model}, audio, opts...)
+		if err != nil {
+			return nil, err
+		}
+		response.Source = sourceTranscribed
+	}
+	response.Language, response.Duration = transcription.Language, transcription.Duration
+
+	// Return one part of the transcript
+	if req.Tokens == 0 {
+		req.Tokens = defaultChunkTokens
+	}
+	chunks := Split(tokenizer.ForProvider(""), transcription, req.Tokens)
+	if len(chunks) == 0 {
+		return nil, schema.ErrNotFound.With("transcript is empty")
+	}
+	if req.Part == 0 {
+		req.Part = 1
+	} else if req.Part > uint(len(chunks)) {
+		return nil, schema.ErrBadParameter.Withf("part %d does not exist; the transcript has %d parts", req.Part, len(chunks))
+	}
+	response.Part, response.Parts, response.Chunk = req.Part, uint(len(chunks)), chunks[req.Part-1]
+	return response, nil
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// audioType returns the content type of audio from the extension of its
+// URL, or audio/mpeg when it is not known
+func audioType(u *url.URL) string {
+	if contentType := mime.TypeByExtension(strings.ToLower(path.Ext(u.Path))); contentType != "" {
+		return contentType
+	}
+	return "audio/mpeg"
+}
@@ -0,0 +1,275 @@
+package media
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	// Packages
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// Video is a YouTube video with its captions
+type Video struct {
+	ID            string               `json:"id"`
+	Title         string               `json:"title,omitempty"`
+	Author        string               `json:"author,omitempty"`
+	Transcription schema.Transcription `json:"transcription"`
+}
+
+// playerResponse is the part of the player data embedded in a watch page
+// which describes the video and its caption tracks
+type playerResponse struct {
+	PlayabilityStatus struct {
+		Status string `json:"status"`
+		Reason string `json:"reason"`
+	} `json:"playabilityStatus"`
+	VideoDetails struct {
+		Title         string `json:"title"`
+		Author        string `json:"author"`
+		LengthSeconds string `json:"lengthSeconds"`
+	} `json:"videoDetails"`
+	Captions struct {
+		Renderer struct {
+			Tracks []captionTrack `json:"captionTracks"`
+		} `json:"playerCaptionsTracklistRenderer"`
+	} `json:"captions"`
+}
+
+// captionTrack is a caption track in one language. Kind is "asr" for
+// captions which were generated by speech recognition.
+type captionTrack struct {
+	BaseURL      string `json:"baseUrl"`
+	LanguageCode string `json:"languageCode"`
+	Kind         string `json:"kind"`
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+const (
+	youtubeWatch  = "https://www.youtube.com/watch"
+	youtubeMarker = "ytInitialPlayerResponse = "
+	maxPageSize   = 8 << 20
+)
+
+var (
+	reVideoID = regexp.MustCompile(`^[A-Za-z0-9_-]{11}$`)
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// YouTubeID returns the video ID from a YouTube watch, short, embed or
+// live URL, or false if the URL is not for a YouTube video
+func YouTubeID(u *url.URL) (string, bool) {
+	if u == nil {
+		return "", false
+	}
+	host := strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+	path := strings.Split(strings.Trim(u.Path, "/"), "/")
+	var id string
+	switch host {
+	case "youtu.be":
+		id = path[0]
+	case "youtube.com", "m.youtube.com", "music.youtube.com", "youtube-nocookie.com":
+		switch {
+		case path[0] == "watch":
+			id = u.Query().Get("v")
+		case len(path) > 1 && (path[0] == "shorts" || path[0] == "embed" || path[0] == "live" || path[0] == "v"):
+			id = path[1]
+		}
+	}
+	if !reVideoID.MatchString(id) {
+		return "", false
+	}
+	return id, true
+}
+
+// Captions returns the captions of a YouTube video in a language, such as
+// "en". Captions written by people are preferred to those generated by
+// speech recognition. If there are no captions in the language, or the
+// language is empty, captions in another language are returned. An error
+// which wraps schema.ErrNotFound is returned if the video has no captions.
+func Captions(ctx context.Context, client *http.Client, id, language string) (*Video, error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	if !reVideoID.MatchString(id) {
+		return nil, schema.ErrBadParameter.Withf("invalid video ID %q", id)
+	}
+
+	// Read the player data from the watch page
+	page, err := fetch(ctx, client, youtubeWatch+"?"+url.Values{"v": {id}, "hl": {"en"}}.Encode())
+	if err != nil {
+		return nil, err
+	}
+	player, err := parsePlayer(page)
+	if err != nil {
+		return nil, err
+	} else if status := player.PlayabilityStatus.Status; status != "" && status != "OK" {
+		return nil, schema.ErrNotFound.Withf("video %q is not available: %s", id, strings.TrimSpace(status+" "+player.PlayabilityStatus.Reason))
+	}
+	track := chooseTrack(player.Captions.Renderer.Tracks, language)
+	if track == nil {
+		return nil, schema.ErrNotFound.Withf("video %q has no captions", id)
+	}
+
+	// Read the caption track
+	data, err := fetch(ctx, client, track.BaseURL)
+	if err != nil {
+		return nil, err
+	}
+	segments, err := parseCaptions(data)
+	if err != nil {
+		return nil, err
+	}
+
+	video := &Video{
+		ID:     id,
+		Title:  player.VideoDetails.Title,
+		Author: player.VideoDetails.Author,
+		Transcription: schema.Transcription{
+			Language: track.LanguageCode,
+			Segments: segments,
+		},
+	}
+	video.Transcription.Text = video.Transcription.SegmentText()
+	if seconds, err := strconv.ParseFloat(player.VideoDetails.LengthSeconds, 64); err == nil {
+		video.Transcription.Duration = seconds
+	}
+	return video, nil
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// fetch returns the body of a GET request
+func fetch(ctx context.Context, client *http.Client, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept-Language", "en")
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: %s", req.URL.Host, resp.Status)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, maxPageSize))
+}
+
+// parsePlayer decodes the player data which follows the marker in a watch
+// page. The decoder stops at the end of the object, ignoring the script
+// which follows it.
+func parsePlayer(page []byte) (*playerResponse, error) {
+	i := bytes.Index(page, []byte(youtubeMarker))
+	if i < 0 {
+		return nil, schema.ErrNotFound.With("no player data in watch page")
+	}
+	var player playerResponse
+	if err := json.NewDecoder(bytes.NewReader(page[i+len(youtubeMarker):])).Decode(&player); err != nil {
+		return nil, fmt.Errorf("player data: %w", err)
+	}
+	return &player, nil
+}
+
+// chooseTrack returns the caption track for a language, preferring captions
+// which were not generated by speech recognition
+func chooseTrack(tracks []captionTrack, language string) *captionTrack {
+	var best *captionTrack
+	rank := func(track *captionTrack) int {
+		r := 0
+		if language != "" && (strings.EqualFold(track.LanguageCode, language) || strings.HasPrefix(strings.ToLower(track.LanguageCode), strings.ToLower(language)+"-")) {
+			r += 2
+		}
+		if track.Kind != "asr" {
+			r++
+		}
+		return r
+	}
+	for i := range tracks {
+		if tracks[i].BaseURL == "" {
+			continue
+		}
+		if best == nil || rank(&tracks[i]) > rank(best) {
+			best = &tracks[i]
+		}
+	}
+	return best
+}
+
+// parseCaptions decodes a caption track. Both the original format, with
+// text elements timed in seconds, and format 3, with paragraphs timed in
+// milliseconds, are read.
+func parseCaptions(data []byte) ([]schema.TranscriptionSegment, error) {
+	var segments []schema.TranscriptionSegment
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("captions: %w", err)
+		}
+		element, ok := token.(xml.StartElement)
+		if !ok || (element.Name.Local != "text" && element.Name.Local != "p") {
+			continue
+		}
+
+		// Times are in seconds for text elements, and milliseconds for paragraphs
+		var start, duration float64
+		scale := 1.0
+		if element.Name.Local == "p" {
+			scale = 1000
+		}
+		for _, attr := range element.Attr {
+			switch attr.Name.Local {
+			case "start", "t":
+				start, _ = strconv.ParseFloat(attr.Value, 64)
+			case "dur", "d":
+				duration, _ = strconv.ParseFloat(attr.Value, 64)
+			}
+		}
+
+		// Read the text of the element, including any child elements
+		var text strings.Builder
+		for depth := 1; depth > 0; {
+			token, err := decoder.Token()
+			if err != nil {
+				return nil, fmt.Errorf("captions: %w", err)
+			}
+			switch token := token.(type) {
+			case xml.StartElement:
+				depth++
+			case xml.EndElement:
+				depth--
+			case xml.CharData:
+				text.Write(token)
+			}
+		}
+		if text := strings.Join(strings.Fields(html.UnescapeString(text.String())), " "); text != "" {
+			segments = append(segments, schema.TranscriptionSegment{
+				Start: start / scale,
+				End:   (start + duration) / scale,
+				Text:  text,
+			})
+		}
+	}
+	return segments, nil
+}
@@ -0,0 +1,172 @@
+/*
+ocr extracts the text of scanned documents and other images, so that it can
+be sent to models which do not accept images. Images are recognized with the
+tesseract command, with a provider which reads documents, or with any other
+Recognizer.
+*/
+package ocr
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	// Packages
+	llm "github.com/mutablelogic/go-llm"
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	types "github.com/mutablelogic/go-server/pkg/types"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// Recognizer returns the text in an image
+type Recognizer interface {
+	Recognize(ctx context.Context, image *schema.Attachment) (string, error)
+}
+
+// Tesseract recognizes text with the tesseract command
+type Tesseract struct {
+	path string
+	lang string
+}
+
+// reader recognizes text with a provider which reads documents
+type reader struct {
+	llm.DocumentReader
+	model string
+}
+
+var _ Recognizer = (*Tesseract)(nil)
+var _ Recognizer = (*reader)(nil)
+
+///////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+const (
+	// Notice before the text extracted from an image
+	noticeText = "[The text below was extracted by OCR from an image attachment, since the model does not accept images]"
+
+	// Notice in place of an image with no text
+	noticeEmpty = "[An image attachment was removed, since the model does not accept images and no text could be extracted from it]"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// LIFECYCLE
+
+// NewTesseract returns a recognizer which runs the tesseract command, which
+// is found in the path when path is empty. The languages are set with lang,
+// for example eng+deu, or the tesseract default is used when lang is empty.
+func NewTesseract(path, lang string) (*Tesseract, error) {
+	if path == "" {
+		path = "tesseract"
+	}
+	path, err := exec.LookPath(path)
+	if err != nil {
+		return nil, schema.ErrNotFound.Withf("tesseract: %v", err)
+	}
+	return &Tesseract{path: path, lang: strings.TrimSpace(lang)}, nil
+}
+
+// NewReader returns a recognizer which reads images with a provider, using
+// the model or the default model of the provider when model is empty
+func NewReader(documentReader llm.DocumentReader, model string) (Recognizer, error) {
+	if documentReader == nil {
+		return nil, schema.ErrBadParameter.With("document reader is required")
+	}
+	return &reader{DocumentReader: documentReader, model: strings.TrimSpace(model)}, nil
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// Recognize returns the text in an image, which must be inline data
+func (t *Tesseract) Recognize(ctx context.Context, image *schema.Attachment) (string, error) {
+	if len(image.Data) == 0 {
+		return "", schema.ErrBadParameter.With("tesseract: image data is required")
+	}
+	args := []string{"stdin", "stdout"}
+	if t.lang != "" {
+		args = append(args, "-l", t.lang)
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, t.path, args...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = bytes.NewReader(image.Data), &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return "", fmt.Errorf("tesseract: %s", message)
+		}
+		return "", fmt.Errorf("tesseract: %w", err)
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+// Recognize returns the text in an image as markdown
+func (r *reader) Recognize(ctx context.Context, image *schema.Attachment) (string, error) {
+	result, _, err := r.ReadDocument(ctx, schema.Model{Name: r.model}, image)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(result.Markdown()), nil
+}
+
+// Substitute returns a copy of the message where each image attachment is
+// replaced by a notice and the text extracted from it, and the number of
+// images which were replaced. An image which cannot be read is replaced by a
+// notice that it was removed. When the recognizer is nil, images are removed
+// without extracting text.
+func Substitute(ctx context.Context, r Recognizer, message *schema.Message) (*schema.Message, int, error) {
+	if message == nil || !HasImages(message) {
+		return message, 0, nil
+	}
+
+	result := types.Value(message)
+	result.Content = make([]schema.ContentBlock, 0, len(message.Content))
+	n := 0
+	for _, block := range message.Content {
+		if !isImage(block) {
+			result.Content = append(result.Content, block)
+			continue
+		}
+		text := ""
+		if r != nil {
+			recognized, err := r.Recognize(ctx, block.Attachment)
+			if err != nil && ctx.Err() != nil {
+				return nil, 0, ctx.Err()
+			} else if err == nil {
+				text = recognized
+			}
+		}
+		if text == "" {
+			text = noticeEmpty
+		} else {
+			text = noticeText + "\n" + text
+		}
+		result.Content = append(result.Content, schema.ContentBlock{Text: types.Ptr(text)})
+		n++
+	}
+
+	// Return the message with the images replaced
+	return &result, n, nil
+}
+
+// HasImages returns true if the message has image attachments
+func HasImages(message *schema.Message) bool {
+	for _, block := range message.Content {
+		if isImage(block) {
+			return true
+		}
+	}
+	return false
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// isImage returns true if the block is an image attachment
+func isImage(block schema.ContentBlock) bool {
+	return block.Attachment != nil && strings.HasPrefix(strings.ToLower(block.Attachment.ContentType), "image/")
+}
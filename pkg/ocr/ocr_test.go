@@ -0,0 +1,91 @@
+package ocr_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	// Packages
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	ocr "github.com/mutablelogic/go-llm/pkg/ocr"
+	opt "github.com/mutablelogic/go-llm/pkg/opt"
+	types "github.com/mutablelogic/go-server/pkg/types"
+	assert "github.com/stretchr/testify/assert"
+)
+
+// recognizer returns the image data as its text, or fails for empty text
+type recognizer struct{}
+
+func (recognizer) Recognize(_ context.Context, image *schema.Attachment) (string, error) {
+	if text := strings.TrimSpace(string(image.Data)); text != "" {
+		return text, nil
+	}
+	return "", errors.New("no text")
+}
+
+func TestSubstitute(t *testing.T) {
+	assert := assert.New(t)
+	message := &schema.Message{Role: schema.RoleUser, Content: []schema.ContentBlock{
+		{Text: types.Ptr("Summarize this invoice")},
+		{Attachment: &schema.Attachment{ContentType: "image/png", Data: []byte("Invoice 42")}},
+		{Attachment: &schema.Attachment{ContentType: "image/jpeg", Data: []byte("  ")}},
+		{Attachment: &schema.Attachment{ContentType: "application/pdf", Data: []byte("%PDF")}},
+	}}
+	assert.True(ocr.HasImages(message))
+
+	result, n, err := ocr.Substitute(context.Background(), recognizer{}, message)
+	if !assert.NoError(err) {
+		return
+	}
+	assert.Equal(2, n)
+	assert.False(ocr.HasImages(result))
+	if assert.Len(result.Content, 4) {
+		assert.Equal("Summarize this invoice", types.Value(result.Content[0].Text))
+		assert.Contains(types.Value(result.Content[1].Text), "extracted by OCR")
+		assert.True(strings.HasSuffix(types.Value(result.Content[1].Text), "\nInvoice 42"))
+		assert.Contains(types.Value(result.Content[2].Text), "was removed")
+		assert.NotNil(result.Content[3].Attachment)
+	}
+
+	// The original message is unchanged
+	assert.NotNil(message.Content[1].Attachment)
+}
+
+func TestSubstituteWithoutImages(t *testing.T) {
+	assert := assert.New(t)
+	message, err := schema.NewMessage(schema.RoleUser, "Hello")
+	if !assert.NoError(err) {
+		return
+	}
+	result, n, err := ocr.Substitute(context.Background(), recognizer{}, message)
+	assert.NoError(err)
+	assert.Zero(n)
+	assert.Same(message, result)
+}
+
+// documentReader returns a page of markdown for each document
+type documentReader struct {
+	model string
+}
+
+func (r *documentReader) ReadDocument(_ context.Context, model schema.Model, _ *schema.Attachment, _ ...opt.Opt) (*schema.OCRResult, *schema.UsageMeta, error) {
+	r.model = model.Name
+	return &schema.OCRResult{Pages: []schema.OCRPage{{Markdown: "# Invoice\n"}, {Index: 1, Markdown: "Total: 42"}}}, nil, nil
+}
+
+func TestReader(t *testing.T) {
+	assert := assert.New(t)
+	documents := &documentReader{}
+	r, err := ocr.NewReader(documents, "mistral-ocr-latest")
+	if !assert.NoError(err) {
+		return
+	}
+	text, err := r.Recognize(context.Background(), &schema.Attachment{ContentType: "image/png", Data: []byte{0x89}})
+	assert.NoError(err)
+	assert.Equal("# Invoice\n\nTotal: 42", text)
+	assert.Equal("mistral-ocr-latest", documents.model)
+
+	_, err = ocr.NewReader(nil, "")
+	assert.ErrorIs(err, schema.ErrBadParameter)
+}
@@ -0,0 +1,144 @@
+// Package offline guards a server which runs without access to the internet,
+// such as an air-gapped deployment with local models. Requests to hosts
+// which are not on the local network are refused before they are sent.
+package offline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// Guard decides which hosts are local. Loopback, private and link-local
+// addresses are local, as are host names which resolve only to them, and
+// the hosts which are allowed.
+type Guard struct {
+	allow    []string // host names, or suffixes which start with a dot
+	resolver Resolver
+}
+
+// Resolver looks up the addresses of a host name
+type Resolver interface {
+	LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error)
+}
+
+type transport struct {
+	guard  *Guard
+	parent http.RoundTripper
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+// ErrBlocked is returned for a request to a host which is not local
+var ErrBlocked = errors.New("offline: request to a host which is not local")
+
+///////////////////////////////////////////////////////////////////////////////
+// LIFECYCLE
+
+// New returns a guard which also allows the hosts, such as ollama or
+// .internal.example.com, where a name which starts with a dot allows the
+// hosts with that suffix. Host names are resolved with the default resolver.
+func New(allow ...string) *Guard {
+	guard := &Guard{resolver: net.DefaultResolver}
+	for _, host := range allow {
+		if host = strings.ToLower(strings.TrimSpace(host)); host != "" {
+			guard.allow = append(guard.allow, host)
+		}
+	}
+	return guard
+}
+
+// WithResolver returns a copy of the guard which resolves host names with
+// the resolver
+func (g *Guard) WithResolver(resolver Resolver) *Guard {
+	result := *g
+	result.resolver = resolver
+	return &result
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// Local returns true if the host, which may include a port, is local
+func (g *Guard) Local(ctx context.Context, host string) bool {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.ToLower(strings.Trim(host, "[]"))
+	if host == "" {
+		return false
+	} else if g.allowed(host) {
+		return true
+	} else if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return true
+	} else if ip := net.ParseIP(host); ip != nil {
+		return localIP(ip)
+	}
+
+	// Every address of the host name must be local
+	addrs, err := g.resolver.LookupIPAddr(ctx, host)
+	if err != nil || len(addrs) == 0 {
+		return false
+	}
+	for _, addr := range addrs {
+		if !localIP(addr.IP) {
+			return false
+		}
+	}
+	return true
+}
+
+// Check returns an error wrapping ErrBlocked if the host, which may include
+// a port, is not local
+func (g *Guard) Check(ctx context.Context, host string) error {
+	if !g.Local(ctx, host) {
+		return fmt.Errorf("%w: %s", ErrBlocked, host)
+	}
+	return nil
+}
+
+// Transport returns a transport which refuses requests to hosts which are
+// not local, and sends other requests with the parent transport, for use
+// with client.OptTransport or as the default transport
+func (g *Guard) Transport(parent http.RoundTripper) http.RoundTripper {
+	if parent == nil {
+		parent = http.DefaultTransport
+	}
+	return &transport{guard: g, parent: parent}
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := t.guard.Check(req.Context(), req.URL.Host); err != nil {
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		return nil, err
+	}
+	return t.parent.RoundTrip(req)
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// allowed returns true if the host name is allowed
+func (g *Guard) allowed(host string) bool {
+	return slices.ContainsFunc(g.allow, func(allow string) bool {
+		if strings.HasPrefix(allow, ".") {
+			return strings.HasSuffix(host, allow)
+		}
+		return host == allow
+	})
+}
+
+// localIP returns true if the address is on this host or the local network
+func localIP(ip net.IP) bool {
+	return ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsUnspecified()
+}
@@ -0,0 +1,82 @@
+package offline_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	// Packages
+	offline "github.com/mutablelogic/go-llm/pkg/offline"
+	assert "github.com/stretchr/testify/assert"
+)
+
+// resolver returns fixed addresses for host names
+type resolver map[string][]string
+
+func (r resolver) LookupIPAddr(_ context.Context, host string) ([]net.IPAddr, error) {
+	addrs, exists := r[host]
+	if !exists {
+		return nil, errors.New("no such host")
+	}
+	result := make([]net.IPAddr, 0, len(addrs))
+	for _, addr := range addrs {
+		result = append(result, net.IPAddr{IP: net.ParseIP(addr)})
+	}
+	return result, nil
+}
+
+func TestLocal(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+	guard := offline.New("models.example.com", ".corp.example.com").WithResolver(resolver{
+		"ollama":          {"172.18.0.2"},
+		"api.example.com": {"93.184.216.34"},
+		"mixed":           {"10.0.0.1", "8.8.8.8"},
+	})
+
+	for host, local := range map[string]bool{
+		"localhost:11434":         true,
+		"app.localhost":           true,
+		"127.0.0.1:11434":         true,
+		"[::1]:8080":              true,
+		"192.168.1.10":            true,
+		"169.254.0.1":             true,
+		"ollama:11434":            true,
+		"models.example.com:443":  true,
+		"gpu.corp.example.com":    true,
+		"8.8.8.8":                 false,
+		"api.example.com":         false,
+		"mixed":                   false,
+		"unknown.example.com":     false,
+		"example.com":             false,
+		"corp.example.com.evil.x": false,
+		"":                        false,
+	} {
+		assert.Equal(local, guard.Local(ctx, host), host)
+	}
+}
+
+func TestTransport(t *testing.T) {
+	assert := assert.New(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: offline.New().WithResolver(resolver{}).Transport(nil)}
+
+	// Requests to the local server are sent
+	response, err := client.Get(server.URL)
+	if assert.NoError(err) {
+		response.Body.Close()
+		assert.Equal(http.StatusNoContent, response.StatusCode)
+	}
+
+	// Requests to other hosts are refused before they are sent
+	_, err = client.Get("https://api.example.com/v1/models")
+	assert.ErrorIs(err, offline.ErrBlocked)
+	assert.ErrorContains(err, "api.example.com")
+}
@@ -28,11 +28,32 @@ const (
 	CacheControlKey         = "cache-control"
 	OutputConfigKey         = "output-config"
 	ToolKey                 = "tool"
+	ToolFilterKey           = "tool-filter"
 	ToolChoiceKey           = "tool-choice"
 	ToolChoiceNameKey       = "tool-choice-name"
+	ParallelToolCallsKey    = "parallel-tool-calls"
+	CitationsKey            = "citations"
+	GoogleSearchKey         = "google-search"
+	URLContextKey           = "url-context"
+	PagesKey                = "pages"
+	IncludeImagesKey        = "include-images"
+	LanguageKey             = "language"
 	MaxIterationsKey        = "max-iterations"
 	LabelKey                = "label"
 	NameKey                 = "name"
 	ModelKey                = "model"
 	VersionKey              = "version"
+	WarningKey              = "warning"
+	CaptureRawKey           = "capture-raw"
+	PrefillKey              = "prefill"
+	StripStopKey            = "strip-stop"
+	StripEchoKey            = "strip-echo"
+	TrimSpaceKey            = "trim-space"
+)
+
+// White space policies for WithTrimSpace
+const (
+	TrimLeading  = "leading"
+	TrimTrailing = "trailing"
+	TrimBoth     = "both"
 )
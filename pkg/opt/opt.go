@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"net/url"
 	"reflect"
+	"sort"
 	"strconv"
 	"strings"
 )
@@ -243,6 +244,16 @@ func (o *opts) Has(key string) bool {
 	return ok
 }
 
+// Keys returns the keys which have been set, in sorted order
+func (o *opts) Keys() []string {
+	keys := make([]string, 0, len(o.values))
+	for key := range o.values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
 // Get returns the arbitrary value for key, or nil if not set
 func (o *opts) Get(key string) any {
 	// Check arbitrary map first (for non-string objects)
@@ -443,6 +454,42 @@ func WithStream(fn StreamFn) Opt {
 	}
 }
 
+// WithCaptureRaw attaches the raw request and response bodies exchanged with
+// the provider to the metadata of the reply, with secrets redacted
+func WithCaptureRaw() Opt {
+	return SetBool(CaptureRawKey, true)
+}
+
+// WithPrefill starts the reply with text, which the model continues. Generators
+// which support it send the text as the start of an assistant message, and the
+// reply contains only the continuation.
+func WithPrefill(text string) Opt {
+	return SetString(PrefillKey, text)
+}
+
+// WithStripStop removes a stop sequence, and any text after it, from the
+// reply, for providers which include it
+func WithStripStop() Opt {
+	return SetBool(StripStopKey, true)
+}
+
+// WithStripEcho removes an echo of the prompt, or of the role of the
+// assistant such as "Assistant:", from the start of the reply
+func WithStripEcho() Opt {
+	return SetBool(StripEchoKey, true)
+}
+
+// WithTrimSpace removes leading, trailing or both leading and trailing white
+// space from the text of the reply
+func WithTrimSpace(policy string) Opt {
+	switch policy {
+	case TrimLeading, TrimTrailing, TrimBoth:
+		return SetString(TrimSpaceKey, policy)
+	default:
+		return Error(fmt.Errorf("invalid white space policy %q (expected %q, %q or %q)", policy, TrimLeading, TrimTrailing, TrimBoth))
+	}
+}
+
 // GetStream returns the streaming callback function, or nil if not set
 func (o *opts) GetStream() StreamFn {
 	return o.stream
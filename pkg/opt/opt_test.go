@@ -162,3 +162,10 @@ func TestWithToolNoOpOnEmptyInput(t *testing.T) {
 	assert.NoError(err)
 	assert.False(opts.Has(opt.ToolKey))
 }
+
+func TestKeys(t *testing.T) {
+	assert := assert.New(t)
+	opts, err := opt.Apply(opt.SetString("b", "1"), opt.SetUint("a", 2), opt.WithStream(func(string, string) {}))
+	assert.NoError(err)
+	assert.Equal([]string{"a", "b"}, opts.Keys())
+}
@@ -0,0 +1,124 @@
+package opt
+
+import (
+	"sync"
+	"time"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// SmoothStream coalesces streamed deltas before passing them to a StreamFn,
+// so that a chatty provider does not emit one callback (or SSE frame) per
+// token. Deltas for the same role are buffered and released at most once per
+// interval, or sooner when the buffer reaches minChunk bytes. A change of role
+// always releases the pending buffer first, so ordering is preserved.
+type SmoothStream struct {
+	sync.Mutex
+	fn       StreamFn
+	interval time.Duration
+	minChunk int
+	role     string
+	buf      []byte
+	last     time.Time
+	timer    *time.Timer
+}
+
+// Roles which are never coalesced, because each delta is a discrete event
+var unbufferedRoles = map[string]bool{
+	"tool":  true,
+	"usage": true,
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// LIFECYCLE
+
+// NewSmoothStream returns a smoother which invokes fn at most once per
+// interval for each run of deltas with the same role. If minChunk is
+// greater than zero, a buffer of at least minChunk bytes is released
+// without waiting for the interval to elapse. Call Flush once streaming
+// is complete to release any remaining text.
+func NewSmoothStream(fn StreamFn, interval time.Duration, minChunk int) *SmoothStream {
+	return &SmoothStream{
+		fn:       fn,
+		interval: interval,
+		minChunk: minChunk,
+	}
+}
+
+// SmoothStreamFn wraps fn with a smoother and returns the wrapped callback
+// together with a flush function. When interval is zero, fn is returned
+// unchanged and flush is a no-op.
+func SmoothStreamFn(fn StreamFn, interval time.Duration, minChunk int) (StreamFn, func()) {
+	if fn == nil || interval <= 0 {
+		return fn, func() {}
+	}
+	s := NewSmoothStream(fn, interval, minChunk)
+	return s.Write, s.Flush
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// Write buffers a delta, releasing buffered text when the role changes,
+// the interval has elapsed or the buffer has reached minChunk bytes.
+func (s *SmoothStream) Write(role, text string) {
+	s.Lock()
+	defer s.Unlock()
+
+	// Release pending text for a different role before accepting this one
+	if role != s.role {
+		s.flush()
+		s.role = role
+	}
+
+	// Discrete events are passed straight through
+	if unbufferedRoles[role] {
+		s.emit(role, text)
+		return
+	}
+
+	s.buf = append(s.buf, text...)
+	if time.Since(s.last) >= s.interval || (s.minChunk > 0 && len(s.buf) >= s.minChunk) {
+		s.flush()
+		return
+	}
+
+	// Make sure trailing text is released even if no further deltas arrive
+	if s.timer == nil {
+		s.timer = time.AfterFunc(s.interval-time.Since(s.last), func() {
+			s.Lock()
+			defer s.Unlock()
+			s.timer = nil
+			s.flush()
+		})
+	}
+}
+
+// Flush releases any buffered text immediately
+func (s *SmoothStream) Flush() {
+	s.Lock()
+	defer s.Unlock()
+	s.flush()
+}
+
+////////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+func (s *SmoothStream) flush() {
+	if s.timer != nil {
+		s.timer.Stop()
+		s.timer = nil
+	}
+	if len(s.buf) == 0 {
+		return
+	}
+	text := string(s.buf)
+	s.buf = s.buf[:0]
+	s.emit(s.role, text)
+}
+
+func (s *SmoothStream) emit(role, text string) {
+	s.last = time.Now()
+	s.fn(role, text)
+}
@@ -0,0 +1,91 @@
+package opt_test
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	// Packages
+	opt "github.com/mutablelogic/go-llm/pkg/opt"
+	assert "github.com/stretchr/testify/assert"
+)
+
+type streamRecorder struct {
+	sync.Mutex
+	roles []string
+	texts []string
+}
+
+func (r *streamRecorder) fn(role, text string) {
+	r.Lock()
+	defer r.Unlock()
+	r.roles = append(r.roles, role)
+	r.texts = append(r.texts, text)
+}
+
+func TestSmoothStreamFnDisabled(t *testing.T) {
+	assert := assert.New(t)
+	var rec streamRecorder
+	fn, flush := opt.SmoothStreamFn(rec.fn, 0, 0)
+	fn("assistant", "a")
+	fn("assistant", "b")
+	flush()
+	assert.Equal([]string{"a", "b"}, rec.texts)
+}
+
+func TestSmoothStreamCoalesces(t *testing.T) {
+	assert := assert.New(t)
+	var rec streamRecorder
+	fn, flush := opt.SmoothStreamFn(rec.fn, time.Hour, 0)
+
+	// The first delta is released immediately, the rest are coalesced
+	fn("assistant", "Hel")
+	fn("assistant", "lo ")
+	fn("assistant", "world")
+	flush()
+	assert.Equal([]string{"Hel", "lo world"}, rec.texts)
+}
+
+func TestSmoothStreamRoleChangeFlushes(t *testing.T) {
+	assert := assert.New(t)
+	var rec streamRecorder
+	fn, flush := opt.SmoothStreamFn(rec.fn, time.Hour, 0)
+
+	fn("thinking", "a")
+	fn("thinking", "b")
+	fn("thinking", "c")
+	fn("tool", "search")
+	fn("assistant", "d")
+	fn("assistant", "e")
+	flush()
+	assert.Equal([]string{"thinking", "thinking", "tool", "assistant"}, rec.roles)
+	assert.Equal([]string{"a", "bc", "search", "de"}, rec.texts)
+}
+
+func TestSmoothStreamMinChunk(t *testing.T) {
+	assert := assert.New(t)
+	var rec streamRecorder
+	fn, flush := opt.SmoothStreamFn(rec.fn, time.Hour, 4)
+
+	fn("assistant", "x")
+	fn("assistant", "ab")
+	fn("assistant", "cd")
+	fn("assistant", "e")
+	flush()
+	assert.Equal([]string{"x", "abcd", "e"}, rec.texts)
+}
+
+func TestSmoothStreamTimerReleasesTrailingText(t *testing.T) {
+	assert := assert.New(t)
+	var rec streamRecorder
+	fn, _ := opt.SmoothStreamFn(rec.fn, 20*time.Millisecond, 0)
+
+	fn("assistant", "a")
+	fn("assistant", "b")
+	assert.Eventually(func() bool {
+		rec.Lock()
+		defer rec.Unlock()
+		return len(rec.texts) == 2
+	}, time.Second, 5*time.Millisecond)
+	assert.Equal([]string{"a", "b"}, rec.texts)
+}
@@ -0,0 +1,153 @@
+package pdf
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// font is one of the standard fonts, with the widths of its printable ASCII
+// characters in thousandths of the font size
+type font struct {
+	name   string
+	base   string
+	widths *[95]float64
+	fixed  float64
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+// Width of characters outside printable ASCII in proportional fonts
+const defaultWidth = 556
+
+var (
+	fontBody   = &font{name: "F1", base: "Helvetica", widths: &helveticaWidths}
+	fontBold   = &font{name: "F2", base: "Helvetica-Bold", widths: &helveticaBoldWidths}
+	fontItalic = &font{name: "F3", base: "Helvetica-Oblique", widths: &helveticaWidths}
+	fontCode   = &font{name: "F4", base: "Courier", fixed: 600}
+)
+
+var helveticaWidths = [95]float64{
+	278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278, // space to /
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556, // 0 to ?
+	1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778, // @ to O
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556, // P to _
+	333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556, // ` to o
+	556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584, // p to ~
+}
+
+var helveticaBoldWidths = [95]float64{
+	278, 333, 474, 556, 556, 889, 722, 238, 333, 333, 389, 584, 278, 333, 278, 278, // space to /
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 333, 333, 584, 584, 584, 611, // 0 to ?
+	975, 722, 722, 722, 722, 667, 611, 778, 722, 278, 556, 722, 611, 833, 722, 778, // @ to O
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 333, 278, 333, 584, 556, // P to _
+	333, 556, 611, 556, 611, 556, 333, 611, 611, 278, 278, 556, 278, 889, 611, 611, // ` to o
+	611, 611, 389, 556, 333, 611, 556, 778, 556, 556, 500, 389, 280, 389, 584, // p to ~
+}
+
+// Characters in Windows-1252 which are not at the same position in Unicode
+var winAnsi = map[rune]byte{
+	'€': 0x80, '‚': 0x82, 'ƒ': 0x83, '„': 0x84, '…': 0x85, '†': 0x86, '‡': 0x87, 'ˆ': 0x88,
+	'‰': 0x89, 'Š': 0x8A, '‹': 0x8B, 'Œ': 0x8C, 'Ž': 0x8E, '‘': 0x91, '’': 0x92, '“': 0x93,
+	'”': 0x94, '•': 0x95, '–': 0x96, '—': 0x97, '˜': 0x98, '™': 0x99, 'š': 0x9A, '›': 0x9B,
+	'œ': 0x9C, 'ž': 0x9E, 'Ÿ': 0x9F,
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// encode converts text to Windows-1252, expanding tabs and removing other
+// control characters except newlines
+func encode(text string) string {
+	var b strings.Builder
+	b.Grow(len(text))
+	for _, r := range strings.ReplaceAll(text, "\r\n", "\n") {
+		switch {
+		case r == '\n':
+			b.WriteByte('\n')
+		case r == '\t':
+			b.WriteString("    ")
+		case r >= 0x20 && r < 0x7F, r >= 0xA0 && r <= 0xFF:
+			b.WriteByte(byte(r))
+		case r < 0x20, r == 0x7F, r >= 0x80 && r < 0xA0, r == utf8.RuneError:
+			continue
+		default:
+			if c, exists := winAnsi[r]; exists {
+				b.WriteByte(c)
+			} else {
+				b.WriteByte('?')
+			}
+		}
+	}
+	return b.String()
+}
+
+// width returns the width of encoded text in points
+func (f *font) width(text string, size float64) float64 {
+	var total float64
+	for i := 0; i < len(text); i++ {
+		total += f.charWidth(text[i])
+	}
+	return total * size / 1000
+}
+
+func (f *font) charWidth(c byte) float64 {
+	switch {
+	case f.widths == nil:
+		return f.fixed
+	case c >= 0x20 && c < 0x7F:
+		return f.widths[c-0x20]
+	default:
+		return defaultWidth
+	}
+}
+
+// fit returns the number of leading characters of the text which fit in the
+// width, and at least one
+func (f *font) fit(text string, size, width float64) int {
+	var total float64
+	for i := 0; i < len(text); i++ {
+		if total += f.charWidth(text[i]) * size / 1000; total > width {
+			return max(i, 1)
+		}
+	}
+	return len(text)
+}
+
+// wrap breaks encoded text into lines which fit in the width, at spaces
+// where possible
+func (f *font) wrap(text string, size, width float64) []string {
+	var lines []string
+	var line string
+	for i, word := range strings.Split(text, " ") {
+		candidate := word
+		if i > 0 {
+			candidate = line + " " + word
+		}
+		if f.width(candidate, size) <= width {
+			line = candidate
+			continue
+		}
+		if i > 0 {
+			lines = append(lines, line)
+		}
+		for f.width(word, size) > width {
+			n := f.fit(word, size, width)
+			lines, word = append(lines, word[:n]), word[n:]
+		}
+		line = word
+	}
+	return append(lines, line)
+}
+
+// truncate shortens encoded text to fit in the width
+func (f *font) truncate(text string, size, width float64) string {
+	if f.width(text, size) <= width {
+		return text
+	}
+	ellipsis := string([]byte{winAnsi['…']})
+	return text[:f.fit(text, size, width-f.width(ellipsis, size))] + ellipsis
+}
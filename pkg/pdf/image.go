@@ -0,0 +1,75 @@
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// picture is an image encoded for a PDF image object
+type picture struct {
+	width, height int
+	colorspace    string
+	filter        string
+	data          []byte
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+// Images with more pixels than this are not decoded
+const maxPixels = 25_000_000
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// decodePicture embeds greyscale and colour JPEG images as they are, and
+// converts other images to compressed RGB on a white background
+func decodePicture(data []byte) (*picture, error) {
+	config, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	} else if config.Width <= 0 || config.Height <= 0 || config.Width*config.Height > maxPixels {
+		return nil, fmt.Errorf("unsupported image size %dx%d", config.Width, config.Height)
+	}
+	if format == "jpeg" {
+		switch config.ColorModel {
+		case color.GrayModel:
+			return &picture{config.Width, config.Height, "DeviceGray", "DCTDecode", data}, nil
+		case color.YCbCrModel:
+			return &picture{config.Width, config.Height, "DeviceRGB", "DCTDecode", data}, nil
+		}
+	}
+
+	// Decode the image and flatten any transparency onto white
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	bounds := src.Bounds()
+	var rgb bytes.Buffer
+	zw := zlib.NewWriter(&rgb)
+	row := make([]byte, 0, bounds.Dx()*3)
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		row = row[:0]
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			r, g, b, a := src.At(x, y).RGBA()
+			row = append(row, byte((r+0xFFFF-a)>>8), byte((g+0xFFFF-a)>>8), byte((b+0xFFFF-a)>>8))
+		}
+		if _, err := zw.Write(row); err != nil {
+			return nil, err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return &picture{bounds.Dx(), bounds.Dy(), "DeviceRGB", "FlateDecode", rgb.Bytes()}, nil
+}
@@ -0,0 +1,325 @@
+// Package pdf lays out simple documents of text, code and images and writes
+// them as PDF. The standard fonts which every PDF reader provides are used,
+// so no fonts are embedded. Text is encoded as Windows-1252, and characters
+// outside it are replaced with a question mark.
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"strings"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// Document is a PDF document which is laid out as content is added
+type Document struct {
+	title  string
+	pages  []*bytes.Buffer
+	images []*picture
+	y      float64
+}
+
+// Style selects the font, size and colour of text
+type Style int
+
+type style struct {
+	font    *font
+	size    float64
+	leading float64
+	gray    float64
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+const (
+	Body    Style = iota // Body text
+	Bold                 // Bold body text
+	Italic               // Grey italic text, for asides
+	Heading              // Document heading
+	Small                // Small grey text, for captions and details
+)
+
+const (
+	pageWidth    = 595.0 // A4, in points
+	pageHeight   = 842.0
+	margin       = 56.0
+	contentWidth = pageWidth - 2*margin
+	codeSize     = 8.5
+	codeLeading  = 11.0
+	codePadding  = 4.0
+	codeGray     = 0.94
+)
+
+var styles = map[Style]style{
+	Body:    {fontBody, 10, 14, 0},
+	Bold:    {fontBold, 10, 14, 0},
+	Italic:  {fontItalic, 10, 14, 0.4},
+	Heading: {fontBold, 16, 22, 0},
+	Small:   {fontBody, 8, 11, 0.4},
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// LIFECYCLE
+
+// New returns an empty document with a title, which is set in the document
+// information and repeated in the footer of each page
+func New(title string) *Document {
+	return &Document{title: title}
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// Text adds text in a style. Lines are wrapped to the width of the page, and
+// each newline starts a new line.
+func (d *Document) Text(s Style, text string) {
+	st, exists := styles[s]
+	if !exists {
+		st = styles[Body]
+	}
+	for _, paragraph := range strings.Split(encode(text), "\n") {
+		for _, line := range st.font.wrap(paragraph, st.size, contentWidth) {
+			top := d.line(st.leading)
+			fmt.Fprintf(d.page(), "BT %s g /%s %s Tf %s %s Td %s Tj ET\n", num(st.gray), st.font.name, num(st.size), num(margin), num(top-st.size), literal(line))
+		}
+	}
+}
+
+// Code adds preformatted text in a fixed pitch font on a shaded background.
+// Lines which are wider than the page are broken.
+func (d *Document) Code(text string) {
+	cols := int((contentWidth - 2*codePadding) / (codeSize * fontCode.fixed / 1000))
+	for _, line := range strings.Split(strings.TrimRight(encode(text), "\n"), "\n") {
+		for {
+			chunk := line
+			if len(chunk) > cols {
+				chunk, line = line[:cols], line[cols:]
+			} else {
+				line = ""
+			}
+			top := d.line(codeLeading)
+			fmt.Fprintf(d.page(), "%s g %s %s %s %s re f\n", num(codeGray), num(margin), num(top-codeLeading), num(contentWidth), num(codeLeading))
+			fmt.Fprintf(d.page(), "BT 0 g /%s %s Tf %s %s Td %s Tj ET\n", fontCode.name, num(codeSize), num(margin+codePadding), num(top-codeSize), literal(chunk))
+			if line == "" {
+				break
+			}
+		}
+	}
+}
+
+// Image adds a JPEG, PNG or GIF image, scaled down to fit the page. An error
+// is returned if the image cannot be decoded.
+func (d *Document) Image(data []byte) error {
+	picture, err := decodePicture(data)
+	if err != nil {
+		return err
+	}
+
+	// Images are shown at 96 pixels to the inch, unless they are too large
+	width, height := float64(picture.width)*0.75, float64(picture.height)*0.75
+	if width > contentWidth {
+		width, height = contentWidth, height*contentWidth/width
+	}
+	if maxHeight := (pageHeight - 2*margin) / 2; height > maxHeight {
+		width, height = width*maxHeight/height, maxHeight
+	}
+
+	d.images = append(d.images, picture)
+	top := d.line(height)
+	fmt.Fprintf(d.page(), "q %s 0 0 %s %s %s cm /Im%d Do Q\n", num(width), num(height), num(margin), num(top-height), len(d.images))
+	return nil
+}
+
+// Space adds vertical space, unless at the top of a page
+func (d *Document) Space(height float64) {
+	if len(d.pages) > 0 && d.y < pageHeight-margin {
+		d.y = max(d.y-height, margin)
+	}
+}
+
+// WriteTo writes the document as PDF
+func (d *Document) WriteTo(w io.Writer) (int64, error) {
+	if len(d.pages) == 0 {
+		d.newPage()
+	}
+
+	// Objects are numbered in the order they are written: the catalog, page
+	// tree, shared resources, information, fonts, images, then each page and
+	// its content
+	fonts := []*font{fontBody, fontBold, fontItalic, fontCode}
+	firstFont := 5
+	firstImage := firstFont + len(fonts)
+	firstPage := firstImage + len(d.images)
+
+	pw := &writer{w: w}
+	pw.printf("%%PDF-1.4\n%%\xe2\xe3\xcf\xd3\n")
+
+	// Catalog and page tree
+	pw.object("<< /Type /Catalog /Pages 2 0 R >>")
+	kids := make([]string, len(d.pages))
+	for i := range d.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", firstPage+2*i)
+	}
+	pw.object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages)))
+
+	// Resources shared by all pages
+	var resources strings.Builder
+	resources.WriteString("<< /Font <<")
+	for i, f := range fonts {
+		fmt.Fprintf(&resources, " /%s %d 0 R", f.name, firstFont+i)
+	}
+	resources.WriteString(" >> /XObject <<")
+	for i := range d.images {
+		fmt.Fprintf(&resources, " /Im%d %d 0 R", i+1, firstImage+i)
+	}
+	resources.WriteString(" >> >>")
+	pw.object(resources.String())
+
+	// Information
+	pw.object(fmt.Sprintf("<< /Title %s /Producer (go-llm) >>", textString(d.title)))
+
+	// Fonts and images
+	for _, f := range fonts {
+		pw.object(fmt.Sprintf("<< /Type /Font /Subtype /Type1 /BaseFont /%s /Encoding /WinAnsiEncoding >>", f.base))
+	}
+	for _, picture := range d.images {
+		pw.stream(fmt.Sprintf("/Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace /%s /BitsPerComponent 8 /Filter /%s", picture.width, picture.height, picture.colorspace, picture.filter), picture.data)
+	}
+
+	// Pages, with a footer on each
+	for i, page := range d.pages {
+		footer := styles[Small]
+		number := encode(fmt.Sprintf("Page %d of %d", i+1, len(d.pages)))
+		fmt.Fprintf(page, "BT %s g /%s %s Tf %s %s Td %s Tj ET\n", num(footer.gray), footer.font.name, num(footer.size), num(margin), num(margin/2), literal(footer.font.truncate(encode(strings.Join(strings.Fields(d.title), " ")), footer.size, contentWidth/2)))
+		fmt.Fprintf(page, "BT %s g /%s %s Tf %s %s Td %s Tj ET\n", num(footer.gray), footer.font.name, num(footer.size), num(pageWidth-margin-footer.font.width(number, footer.size)), num(margin/2), literal(number))
+
+		var content bytes.Buffer
+		zw := zlib.NewWriter(&content)
+		if _, err := zw.Write(page.Bytes()); err != nil {
+			return pw.n, err
+		} else if err := zw.Close(); err != nil {
+			return pw.n, err
+		}
+		pw.object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %s %s] /Resources 3 0 R /Contents %d 0 R >>", num(pageWidth), num(pageHeight), firstPage+2*i+1))
+		pw.stream("/Filter /FlateDecode", content.Bytes())
+	}
+
+	// Cross-reference table and trailer
+	xref := pw.n
+	pw.printf("xref\n0 %d\n0000000000 65535 f \n", len(pw.offsets)+1)
+	for _, offset := range pw.offsets {
+		pw.printf("%010d 00000 n \n", offset)
+	}
+	pw.printf("trailer\n<< /Size %d /Root 1 0 R /Info 4 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(pw.offsets)+1, xref)
+
+	return pw.n, pw.err
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// page returns the content of the current page
+func (d *Document) page() *bytes.Buffer {
+	if len(d.pages) == 0 {
+		d.newPage()
+	}
+	return d.pages[len(d.pages)-1]
+}
+
+// newPage starts a new page
+func (d *Document) newPage() {
+	d.pages = append(d.pages, new(bytes.Buffer))
+	d.y = pageHeight - margin
+}
+
+// line reserves a line of the given height, starting a new page when it does
+// not fit on the current page, and returns the top of the line
+func (d *Document) line(height float64) float64 {
+	if len(d.pages) == 0 || (d.y-height < margin && d.y < pageHeight-margin) {
+		d.newPage()
+	}
+	top := d.y
+	d.y -= height
+	return top
+}
+
+// num formats a number for a content stream
+func num(v float64) string {
+	return strings.TrimSuffix(strings.TrimRight(fmt.Sprintf("%.2f", v), "0"), ".")
+}
+
+// literal returns a string as a PDF literal string
+func literal(s string) string {
+	var b strings.Builder
+	b.WriteByte('(')
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '(', ')', '\\':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	b.WriteByte(')')
+	return b.String()
+}
+
+// textString returns a string as a UTF-16 PDF text string, for the document
+// information
+func textString(s string) string {
+	var b strings.Builder
+	b.WriteString("<FEFF")
+	for _, r := range s {
+		if r > 0xFFFF {
+			r -= 0x10000
+			fmt.Fprintf(&b, "%04X%04X", 0xD800+(r>>10), 0xDC00+(r&0x3FF))
+		} else {
+			fmt.Fprintf(&b, "%04X", r)
+		}
+	}
+	b.WriteByte('>')
+	return b.String()
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// WRITER
+
+// writer writes numbered objects and records their offsets for the
+// cross-reference table
+type writer struct {
+	w       io.Writer
+	n       int64
+	offsets []int64
+	err     error
+}
+
+func (w *writer) printf(format string, args ...any) {
+	w.write([]byte(fmt.Sprintf(format, args...)))
+}
+
+func (w *writer) write(data []byte) {
+	if w.err != nil {
+		return
+	}
+	n, err := w.w.Write(data)
+	w.n += int64(n)
+	w.err = err
+}
+
+func (w *writer) object(dict string) {
+	w.offsets = append(w.offsets, w.n)
+	w.printf("%d 0 obj\n%s\nendobj\n", len(w.offsets), dict)
+}
+
+func (w *writer) stream(dict string, data []byte) {
+	w.offsets = append(w.offsets, w.n)
+	w.printf("%d 0 obj\n<< %s /Length %d >>\nstream\n", len(w.offsets), dict, len(data))
+	w.write(data)
+	w.printf("\nendstream\nendobj\n")
+}
@@ -0,0 +1,135 @@
+package pdf_test
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	// Packages
+	pdf "github.com/mutablelogic/go-llm/pkg/pdf"
+	assert "github.com/stretchr/testify/assert"
+)
+
+// check verifies the cross-reference table, and returns the decompressed
+// page contents
+func check(t *testing.T, data []byte) []string {
+	t.Helper()
+	assert := assert.New(t)
+	assert.True(bytes.HasPrefix(data, []byte("%PDF-1.4\n")))
+	assert.True(bytes.HasSuffix(data, []byte("%%EOF\n")))
+
+	// The startxref offset points at the table, and each entry points at
+	// its object
+	match := regexp.MustCompile(`startxref\n(\d+)\n`).FindSubmatch(data)
+	if !assert.NotNil(match) {
+		return nil
+	}
+	xref, _ := strconv.Atoi(string(match[1]))
+	assert.True(bytes.HasPrefix(data[xref:], []byte("xref\n")))
+	entries := regexp.MustCompile(`(\d{10}) 00000 n `).FindAllSubmatch(data[xref:], -1)
+	for i, entry := range entries {
+		offset, _ := strconv.Atoi(string(entry[1]))
+		assert.True(bytes.HasPrefix(data[offset:], fmt.Appendf(nil, "%d 0 obj\n", i+1)), "object %d", i+1)
+	}
+
+	// Decompress the page contents
+	var pages []string
+	for _, stream := range regexp.MustCompile(`(?s)<< /Filter /FlateDecode /Length (\d+) >>\nstream\n`).FindAllSubmatchIndex(data, -1) {
+		length, _ := strconv.Atoi(string(data[stream[2]:stream[3]]))
+		r, err := zlib.NewReader(bytes.NewReader(data[stream[1] : stream[1]+length]))
+		if !assert.NoError(err) {
+			return nil
+		}
+		content, err := io.ReadAll(r)
+		assert.NoError(err)
+		pages = append(pages, string(content))
+	}
+	return pages
+}
+
+func TestEmpty(t *testing.T) {
+	var buf bytes.Buffer
+	n, err := pdf.New("Empty").WriteTo(&buf)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(buf.Len()), n)
+	pages := check(t, buf.Bytes())
+	assert.Len(t, pages, 1)
+	assert.Contains(t, pages[0], "(Page 1 of 1)")
+}
+
+func TestText(t *testing.T) {
+	assert := assert.New(t)
+
+	doc := pdf.New("Text (with) \\ escapes")
+	doc.Text(pdf.Heading, "Heading")
+	doc.Text(pdf.Body, "Café “quoted” ☃ (paren)")
+	doc.Code("func main() {\n\tfmt.Println(\"hello\")\n}")
+
+	var buf bytes.Buffer
+	_, err := doc.WriteTo(&buf)
+	assert.NoError(err)
+	pages := check(t, buf.Bytes())
+	if !assert.Len(pages, 1) {
+		return
+	}
+	assert.Contains(pages[0], "/F2 16 Tf")
+	assert.Contains(pages[0], "(Caf\xe9 \x93quoted\x94 ? \\(paren\\))")
+	assert.Contains(pages[0], "(    fmt.Println\\(\"hello\"\\))")
+	assert.Contains(pages[0], "(Text \\(with\\) \\\\ escapes)")
+	assert.Contains(buf.String(), "/Title <FEFF")
+}
+
+func TestWrapAndPages(t *testing.T) {
+	assert := assert.New(t)
+
+	// A long paragraph wraps, and many paragraphs run onto more pages
+	doc := pdf.New("Pages")
+	for range 100 {
+		doc.Text(pdf.Body, strings.Repeat("lorem ipsum ", 40))
+	}
+	doc.Code(strings.Repeat("x", 200))
+
+	var buf bytes.Buffer
+	_, err := doc.WriteTo(&buf)
+	assert.NoError(err)
+	pages := check(t, buf.Bytes())
+	assert.Greater(len(pages), 5)
+	assert.Contains(buf.String(), fmt.Sprintf("/Count %d", len(pages)))
+	assert.Contains(pages[len(pages)-1], fmt.Sprintf("(Page %d of %d)", len(pages), len(pages)))
+
+	// Each paragraph is 480 characters, which wraps to five lines of about
+	// 100 characters
+	lines := regexp.MustCompile(`/F1 10 Tf [\d.]+ [\d.]+ Td \((.*)\) Tj`).FindAllStringSubmatch(strings.Join(pages, ""), -1)
+	assert.Len(lines, 500)
+	for _, line := range lines {
+		assert.LessOrEqual(len(line[1]), 102)
+	}
+}
+
+func TestImage(t *testing.T) {
+	assert := assert.New(t)
+
+	img := image.NewNRGBA(image.Rect(0, 0, 4, 2))
+	img.Set(0, 0, color.NRGBA{255, 0, 0, 255})
+	var data bytes.Buffer
+	assert.NoError(png.Encode(&data, img))
+
+	doc := pdf.New("Image")
+	assert.NoError(doc.Image(data.Bytes()))
+	assert.Error(doc.Image([]byte("not an image")))
+
+	var buf bytes.Buffer
+	_, err := doc.WriteTo(&buf)
+	assert.NoError(err)
+	pages := check(t, buf.Bytes())
+	assert.Contains(buf.String(), "/Subtype /Image /Width 4 /Height 2 /ColorSpace /DeviceRGB")
+	assert.Contains(pages[0], "/Im1 Do")
+}
@@ -0,0 +1,283 @@
+package postprocess
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	// Packages
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	types "github.com/mutablelogic/go-server/pkg/types"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// Processor changes the text of a reply
+type Processor interface {
+	Process(text string) string
+}
+
+// ProcessorFunc changes the text of a reply
+type ProcessorFunc func(text string) string
+
+// Pipeline is the processors which change the text of a reply, in order
+type Pipeline []Processor
+
+type replace struct {
+	pattern     *regexp.Regexp
+	replacement string
+}
+
+type mask struct {
+	pattern *regexp.Regexp
+}
+
+type links struct {
+	prefixes []string // longest first
+	rules    map[string]string
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+// Words which are masked when a mask step has no words
+var maskWords = []string{
+	"arsehole", "asshole", "bastard", "bitch", "bollocks", "bullshit", "cunt",
+	"dickhead", "fuck", "fucked", "fucker", "fucking", "motherfucker", "shit",
+	"shitty",
+}
+
+// Links in text, which end at white space or a closing bracket or quote
+var reLink = regexp.MustCompile(`https?://[^\s<>()\[\]"'` + "`" + `]+`)
+
+// Code fences in markdown
+var reFence = regexp.MustCompile("^\\s*(```|~~~)")
+
+// List bullets which are normalized to a dash
+var reBullet = regexp.MustCompile(`^(\s*)[*+](\s+)`)
+
+// Thematic breaks, which are not list items
+var reRule = regexp.MustCompile(`^\s*(?:\*\s*){3,}$|^\s*(?:\+\s*){3,}$`)
+
+///////////////////////////////////////////////////////////////////////////////
+// LIFECYCLE
+
+// New returns the pipeline for the steps. Steps which are not built in are
+// looked up in custom by their type.
+func New(steps schema.PostProcessors, custom map[string]Processor) (Pipeline, error) {
+	if err := steps.Validate(); err != nil {
+		return nil, err
+	}
+	pipeline := make(Pipeline, 0, len(steps))
+	for _, step := range steps {
+		switch kind := strings.TrimSpace(step.Type); kind {
+		case schema.PostProcessReplace:
+			processor, err := Replace(step.Pattern, step.Replacement)
+			if err != nil {
+				return nil, err
+			}
+			pipeline = append(pipeline, processor)
+		case schema.PostProcessMarkdown:
+			pipeline = append(pipeline, Markdown())
+		case schema.PostProcessMask:
+			pipeline = append(pipeline, Mask(step.Words...))
+		case schema.PostProcessLinks:
+			pipeline = append(pipeline, Links(step.Links))
+		default:
+			processor, exists := custom[kind]
+			if !exists || processor == nil {
+				return nil, schema.ErrBadParameter.Withf("unknown postprocess step %q", kind)
+			}
+			pipeline = append(pipeline, processor)
+		}
+	}
+	return pipeline, nil
+}
+
+// Replace returns a processor which replaces the matches of a regular
+// expression. The replacement may refer to groups such as $1.
+func Replace(pattern, replacement string) (Processor, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, schema.ErrBadParameter.Withf("replace: %v", err)
+	}
+	return &replace{pattern: re, replacement: replacement}, nil
+}
+
+// Markdown returns a processor which normalizes markdown: line endings are
+// LF, white space at the end of lines is removed except for hard line
+// breaks, runs of blank lines are reduced to one, list bullets are dashes,
+// and a code fence which is not closed is closed. The text of code blocks
+// is not changed.
+func Markdown() Processor {
+	return ProcessorFunc(markdown)
+}
+
+// Mask returns a processor which masks the words, or the built-in list of
+// profanity when there are no words, keeping the first letter of each
+// match. Words are matched whole and without regard to case.
+func Mask(words ...string) Processor {
+	if len(words) == 0 {
+		words = maskWords
+	}
+	quoted := make([]string, 0, len(words))
+	for _, word := range words {
+		if word = strings.TrimSpace(word); word != "" {
+			quoted = append(quoted, regexp.QuoteMeta(word))
+		}
+	}
+	if len(quoted) == 0 {
+		return ProcessorFunc(func(text string) string { return text })
+	}
+	return &mask{pattern: regexp.MustCompile(`(?i)\b(?:` + strings.Join(quoted, "|") + `)\b`)}
+}
+
+// Links returns a processor which rewrites the links which start with a
+// prefix, replacing the longest matching prefix
+func Links(rules map[string]string) Processor {
+	prefixes := make([]string, 0, len(rules))
+	for prefix := range rules {
+		if prefix != "" {
+			prefixes = append(prefixes, prefix)
+		}
+	}
+	sort.Slice(prefixes, func(i, j int) bool {
+		if len(prefixes[i]) != len(prefixes[j]) {
+			return len(prefixes[i]) > len(prefixes[j])
+		}
+		return prefixes[i] < prefixes[j]
+	})
+	return &links{prefixes: prefixes, rules: rules}
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// Process changes the text with the function
+func (fn ProcessorFunc) Process(text string) string {
+	return fn(text)
+}
+
+// Process changes the text with each processor in turn
+func (p Pipeline) Process(text string) string {
+	for _, processor := range p {
+		text = processor.Process(text)
+	}
+	return text
+}
+
+// Message changes the text blocks of the message with the pipeline, and
+// returns true if the text changed. Text which is empty after processing is
+// removed.
+func (p Pipeline) Message(message *schema.Message) bool {
+	if len(p) == 0 || message == nil {
+		return false
+	}
+	changed := false
+	content := make([]schema.ContentBlock, 0, len(message.Content))
+	for _, block := range message.Content {
+		if block.Text == nil {
+			content = append(content, block)
+			continue
+		}
+		text := p.Process(types.Value(block.Text))
+		if text == types.Value(block.Text) {
+			content = append(content, block)
+			continue
+		}
+		changed = true
+		if text == "" {
+			continue
+		}
+		block.Text = types.Ptr(text)
+		content = append(content, block)
+	}
+	if changed {
+		message.Content = content
+	}
+	return changed
+}
+
+func (r *replace) Process(text string) string {
+	return r.pattern.ReplaceAllString(text, r.replacement)
+}
+
+func (m *mask) Process(text string) string {
+	return m.pattern.ReplaceAllStringFunc(text, func(word string) string {
+		_, n := utf8.DecodeRuneInString(word)
+		return word[:n] + strings.Repeat("*", utf8.RuneCountInString(word[n:]))
+	})
+}
+
+func (l *links) Process(text string) string {
+	if len(l.prefixes) == 0 {
+		return text
+	}
+	return reLink.ReplaceAllStringFunc(text, func(link string) string {
+		for _, prefix := range l.prefixes {
+			if strings.HasPrefix(link, prefix) {
+				return l.rules[prefix] + strings.TrimPrefix(link, prefix)
+			}
+		}
+		return link
+	})
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// markdown normalizes the markdown of the text
+func markdown(text string) string {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	lines := strings.Split(text, "\n")
+	result := make([]string, 0, len(lines))
+	fence, blank := "", false
+	for _, line := range lines {
+		// Keep the text of code blocks
+		if match := reFence.FindStringSubmatch(line); match != nil {
+			if fence == "" {
+				fence = match[1]
+			} else if match[1] == fence {
+				fence = ""
+			}
+			result = append(result, strings.TrimRight(line, " \t"))
+			blank = false
+			continue
+		} else if fence != "" {
+			result = append(result, line)
+			continue
+		}
+
+		// Reduce runs of blank lines to one
+		if strings.TrimSpace(line) == "" {
+			if !blank && len(result) > 0 {
+				result = append(result, "")
+			}
+			blank = true
+			continue
+		}
+		blank = false
+
+		// Keep two spaces at the end of a line, which are a hard line break
+		if trimmed := strings.TrimRight(line, " \t"); strings.HasSuffix(line, "  ") {
+			line = trimmed + "  "
+		} else {
+			line = trimmed
+		}
+		if !reRule.MatchString(line) {
+			line = reBullet.ReplaceAllString(line, "$1-$2")
+		}
+		result = append(result, line)
+	}
+
+	// Remove blank lines at the end, and close a code fence
+	for len(result) > 0 && result[len(result)-1] == "" {
+		result = result[:len(result)-1]
+	}
+	if fence != "" {
+		result = append(result, fence)
+	}
+	return strings.Join(result, "\n")
+}
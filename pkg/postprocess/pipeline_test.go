@@ -0,0 +1,78 @@
+package postprocess_test
+
+import (
+	"testing"
+
+	// Packages
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	postprocess "github.com/mutablelogic/go-llm/pkg/postprocess"
+	types "github.com/mutablelogic/go-server/pkg/types"
+	assert "github.com/stretchr/testify/assert"
+)
+
+func TestReplace(t *testing.T) {
+	assert := assert.New(t)
+
+	processor, err := postprocess.Replace(`(?i)acme (corp|inc)`, "ACME $1")
+	if assert.NoError(err) {
+		assert.Equal("Ask ACME corp or ACME Inc.", processor.Process("Ask acme corp or Acme Inc."))
+	}
+	_, err = postprocess.Replace("(", "")
+	assert.ErrorIs(err, schema.ErrBadParameter)
+}
+
+func TestMarkdown(t *testing.T) {
+	assert := assert.New(t)
+
+	text := "# Title \r\n\r\n\r\n* one\t\n+ two\n\n* * *\n\n```go\nx := 1   \n\n\n```\nline  \nend\n\n```\nopen"
+	assert.Equal("# Title\n\n- one\n- two\n\n* * *\n\n```go\nx := 1   \n\n\n```\nline  \nend\n\n```\nopen\n```", postprocess.Markdown().Process(text))
+}
+
+func TestMask(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal("What the f***, that is b*******.", postprocess.Mask().Process("What the fuck, that is bullshit."))
+	assert.Equal("Scunthorpe is fine", postprocess.Mask().Process("Scunthorpe is fine"))
+	assert.Equal("Oh d***, oh D***", postprocess.Mask("darn").Process("Oh darn, oh DARN"))
+}
+
+func TestLinks(t *testing.T) {
+	assert := assert.New(t)
+
+	processor := postprocess.Links(map[string]string{
+		"http://intranet/":      "https://intranet.example.com/",
+		"http://intranet/wiki/": "https://wiki.example.com/",
+	})
+	assert.Equal(
+		"See [the page](https://wiki.example.com/Home) and <https://intranet.example.com/a>, not http://example.com/.",
+		processor.Process("See [the page](http://intranet/wiki/Home) and <http://intranet/a>, not http://example.com/."),
+	)
+}
+
+func TestPipeline(t *testing.T) {
+	assert := assert.New(t)
+
+	custom := postprocess.ProcessorFunc(func(text string) string { return text + "!" })
+	pipeline, err := postprocess.New(schema.PostProcessors{
+		{Type: schema.PostProcessReplace, Pattern: "cat", Replacement: "dog"},
+		{Type: "shout"},
+	}, map[string]postprocess.Processor{"shout": custom})
+	if !assert.NoError(err) {
+		return
+	}
+
+	// Only text blocks are changed
+	message := &schema.Message{Role: schema.RoleAssistant, Content: []schema.ContentBlock{
+		{Thinking: types.Ptr("a cat")},
+		{Text: types.Ptr("a cat")},
+	}}
+	assert.True(pipeline.Message(message))
+	assert.Equal("a cat", types.Value(message.Content[0].Thinking))
+	assert.Equal("a dog!", message.Text())
+
+	// Unknown and invalid steps are rejected
+	_, err = postprocess.New(schema.PostProcessors{{Type: "shout"}}, nil)
+	assert.ErrorIs(err, schema.ErrBadParameter)
+	_, err = postprocess.New(schema.PostProcessors{{Type: schema.PostProcessLinks}}, nil)
+	assert.ErrorIs(err, schema.ErrBadParameter)
+}
@@ -0,0 +1,134 @@
+// Package postprocess normalizes the text of replies from providers, which
+// may end with the stop sequence, start by echoing the prompt or the role of
+// the assistant, or have surrounding white space. Each step is enabled with
+// an option, and the text which was streamed is not changed.
+package postprocess
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+
+	// Packages
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	opt "github.com/mutablelogic/go-llm/pkg/opt"
+	types "github.com/mutablelogic/go-server/pkg/types"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+// Role labels which a model may echo at the start of a reply
+var reRoleEcho = regexp.MustCompile(`(?i)^\s*(assistant|ai|bot|model|answer)\s*:\s*`)
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// Apply normalizes the text of the reply as set by the options, and the copy
+// of the reply held by the conversation. The prompt which is stripped when it
+// is echoed is the last user message in the conversation.
+func Apply(options opt.Options, session schema.Conversation, reply *schema.Message) {
+	if options == nil || reply == nil {
+		return
+	}
+	stop := options.GetBool(opt.StripStopKey)
+	echo := options.GetBool(opt.StripEchoKey)
+	trim := options.GetString(opt.TrimSpaceKey)
+	if !stop && !echo && trim == "" {
+		return
+	}
+
+	// Normalize the text blocks of the reply
+	content := make([]schema.ContentBlock, 0, len(reply.Content))
+	first := true
+	for _, block := range reply.Content {
+		if block.Text == nil {
+			content = append(content, block)
+			continue
+		}
+		text := types.Value(block.Text)
+		if echo && first {
+			text = stripEcho(text, prompt(session, reply))
+		}
+		if stop {
+			text = stripStop(text, options.GetStringArray(opt.StopSequencesKey))
+		}
+		text = trimSpace(text, trim)
+		first = false
+
+		// Drop text which is now empty
+		if text == "" && types.Value(block.Text) != "" {
+			continue
+		}
+		block.Text = types.Ptr(text)
+		content = append(content, block)
+	}
+	reply.Content = content
+
+	// Update the copy of the reply in the conversation
+	if message := last(session, reply); message != nil {
+		message.Content = content
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// stripEcho removes the prompt and a role label from the start of the text
+func stripEcho(text, prompt string) string {
+	if prompt = strings.TrimSpace(prompt); prompt != "" {
+		if trimmed := strings.TrimLeftFunc(text, unicode.IsSpace); strings.HasPrefix(trimmed, prompt) {
+			text = strings.TrimLeftFunc(strings.TrimPrefix(trimmed, prompt), unicode.IsSpace)
+		}
+	}
+	return reRoleEcho.ReplaceAllString(text, "")
+}
+
+// stripStop removes the first stop sequence in the text, and anything after it
+func stripStop(text string, sequences []string) string {
+	for _, sequence := range sequences {
+		if sequence == "" {
+			continue
+		}
+		if i := strings.Index(text, sequence); i >= 0 {
+			text = text[:i]
+		}
+	}
+	return text
+}
+
+// trimSpace removes white space from the text as set by the policy
+func trimSpace(text, policy string) string {
+	switch policy {
+	case opt.TrimLeading:
+		return strings.TrimLeftFunc(text, unicode.IsSpace)
+	case opt.TrimTrailing:
+		return strings.TrimRightFunc(text, unicode.IsSpace)
+	case opt.TrimBoth:
+		return strings.TrimSpace(text)
+	default:
+		return text
+	}
+}
+
+// prompt returns the text of the last user message in the conversation
+func prompt(session schema.Conversation, reply *schema.Message) string {
+	for i := len(session) - 1; i >= 0; i-- {
+		if message := session[i]; message != reply && message.Role == schema.RoleUser {
+			return message.Text()
+		}
+	}
+	return ""
+}
+
+// last returns the copy of the reply at the end of the conversation, or nil
+func last(session schema.Conversation, reply *schema.Message) *schema.Message {
+	n := len(session)
+	if n == 0 || session[n-1] == reply {
+		return nil
+	}
+	if message := session[n-1]; message.Role == reply.Role && message.CreatedAt.Equal(reply.CreatedAt) {
+		return message
+	}
+	return nil
+}
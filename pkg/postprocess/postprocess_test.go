@@ -0,0 +1,104 @@
+package postprocess_test
+
+import (
+	"testing"
+	"time"
+
+	// Packages
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	opt "github.com/mutablelogic/go-llm/pkg/opt"
+	postprocess "github.com/mutablelogic/go-llm/pkg/postprocess"
+	types "github.com/mutablelogic/go-server/pkg/types"
+	assert "github.com/stretchr/testify/assert"
+)
+
+func reply(text ...string) *schema.Message {
+	message := &schema.Message{Role: schema.RoleAssistant, CreatedAt: time.Now()}
+	for _, t := range text {
+		message.Content = append(message.Content, schema.ContentBlock{Text: types.Ptr(t)})
+	}
+	return message
+}
+
+func apply(t *testing.T, session schema.Conversation, message *schema.Message, opts ...opt.Opt) {
+	t.Helper()
+	options, err := opt.Apply(opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	postprocess.Apply(options, session, message)
+}
+
+func TestApplyNoOptions(t *testing.T) {
+	message := reply("  Assistant: Hello END ")
+	apply(t, nil, message)
+	assert.Equal(t, "  Assistant: Hello END ", message.Text())
+}
+
+func TestApplyStripStop(t *testing.T) {
+	assert := assert.New(t)
+	message := reply("Hello\nEND\nmore text")
+	apply(t, nil, message, opt.AddString(opt.StopSequencesKey, "END"), opt.WithStripStop())
+	assert.Equal("Hello\n", message.Text())
+
+	// Without stop sequences, nothing is removed
+	message = reply("Hello\nEND")
+	apply(t, nil, message, opt.WithStripStop())
+	assert.Equal("Hello\nEND", message.Text())
+}
+
+func TestApplyStripEcho(t *testing.T) {
+	assert := assert.New(t)
+	prompt, err := schema.NewMessage(schema.RoleUser, "What is the capital of France?")
+	if !assert.NoError(err) {
+		return
+	}
+
+	// The prompt and role are removed from the first text block only
+	message := reply("What is the capital of France?\nAssistant: Paris", "Assistant: is the answer")
+	apply(t, schema.Conversation{prompt}, message, opt.WithStripEcho())
+	if assert.Len(message.Content, 2) {
+		assert.Equal("Paris", types.Value(message.Content[0].Text))
+		assert.Equal("Assistant: is the answer", types.Value(message.Content[1].Text))
+	}
+
+	message = reply("AI:  Paris")
+	apply(t, nil, message, opt.WithStripEcho())
+	assert.Equal("Paris", message.Text())
+}
+
+func TestApplyTrimSpace(t *testing.T) {
+	assert := assert.New(t)
+	for policy, expected := range map[string]string{
+		opt.TrimLeading:  "Hello \n",
+		opt.TrimTrailing: "\n Hello",
+		opt.TrimBoth:     "Hello",
+	} {
+		message := reply("\n Hello \n")
+		apply(t, nil, message, opt.WithTrimSpace(policy))
+		assert.Equal(expected, message.Text(), policy)
+	}
+	_, err := opt.Apply(opt.WithTrimSpace("middle"))
+	assert.Error(err)
+}
+
+func TestApplyConversationCopy(t *testing.T) {
+	assert := assert.New(t)
+	prompt, err := schema.NewMessage(schema.RoleUser, "Hi")
+	if !assert.NoError(err) {
+		return
+	}
+	message := reply("  ", "Hello ")
+	message.Content = append([]schema.ContentBlock{{Thinking: types.Ptr("greet")}}, message.Content...)
+	session := schema.Conversation{prompt}
+	session.Append(*message)
+
+	// Text which is only white space is removed, and the copy of the reply
+	// in the conversation is updated
+	apply(t, session, message, opt.WithTrimSpace(opt.TrimBoth))
+	if assert.Len(message.Content, 2) {
+		assert.Equal("greet", types.Value(message.Content[0].Thinking))
+		assert.Equal("Hello", types.Value(message.Content[1].Text))
+	}
+	assert.Equal("Hello", session[1].Text())
+}
@@ -0,0 +1,233 @@
+// Package rest provides tools which call HTTP endpoints declared in a
+// configuration file. Each endpoint has a method, a URL template whose
+// placeholders are filled from parameters, and a parameter schema from
+// which the JSON schema of the tool is generated. An authentication header
+// can be set from a secret, so that internal APIs can be called without
+// writing a tool for each.
+package rest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+
+	// Packages
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	jsonschema "github.com/mutablelogic/go-server/pkg/jsonschema"
+	types "github.com/mutablelogic/go-server/pkg/types"
+	yaml "gopkg.in/yaml.v3"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// Endpoint is an HTTP endpoint which is called by a tool
+type Endpoint struct {
+	Name        string               `yaml:"name" json:"name"`
+	Description string               `yaml:"description" json:"description"`
+	Method      string               `yaml:"method,omitempty" json:"method,omitempty"`
+	URL         string               `yaml:"url" json:"url"`
+	Headers     map[string]string    `yaml:"headers,omitempty" json:"headers,omitempty"`
+	Auth        *Auth                `yaml:"auth,omitempty" json:"auth,omitempty"`
+	ReadOnly    *bool                `yaml:"readonly,omitempty" json:"readonly,omitempty"`
+	Parameters  map[string]Parameter `yaml:"parameters,omitempty" json:"parameters,omitempty"`
+}
+
+// Auth sets a header from a secret. The secret is read from the credential
+// for a URL, which defaults to the scheme and host of the endpoint URL.
+type Auth struct {
+	Header     string `yaml:"header,omitempty" json:"header,omitempty"`
+	Prefix     string `yaml:"prefix,omitempty" json:"prefix,omitempty"`
+	Credential string `yaml:"credential,omitempty" json:"credential,omitempty"`
+}
+
+// Parameter is a parameter of an endpoint, which is placed in the path, the
+// query, a header or the JSON body of the request. Parameters named in the
+// URL template are in the path and are required. Other parameters are in
+// the query for GET, HEAD and DELETE requests, and in the body otherwise.
+type Parameter struct {
+	Type        string `yaml:"type,omitempty" json:"type,omitempty"`
+	Description string `yaml:"description,omitempty" json:"description,omitempty"`
+	In          string `yaml:"in,omitempty" json:"in,omitempty"`
+	Required    bool   `yaml:"required,omitempty" json:"required,omitempty"`
+	Enum        []any  `yaml:"enum,omitempty" json:"enum,omitempty"`
+	Default     any    `yaml:"default,omitempty" json:"default,omitempty"`
+}
+
+// config is the structure of a configuration file
+type config struct {
+	Endpoints []Endpoint `yaml:"endpoints"`
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+const (
+	InPath   = "path"
+	InQuery  = "query"
+	InHeader = "header"
+	InBody   = "body"
+)
+
+var (
+	reTemplate = regexp.MustCompile(`\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+	paramTypes = []string{"string", "integer", "number", "boolean", "array", "object"}
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// Read returns the endpoints in a YAML or JSON configuration file, which has
+// a list of endpoints under the endpoints key
+func Read(r io.Reader) ([]Endpoint, error) {
+	var cfg config
+	decoder := yaml.NewDecoder(r)
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&cfg); err != nil && err != io.EOF {
+		return nil, schema.ErrBadParameter.Withf("rest endpoints: %v", err)
+	}
+	for i := range cfg.Endpoints {
+		if err := cfg.Endpoints[i].Validate(); err != nil {
+			return nil, err
+		}
+	}
+	return cfg.Endpoints, nil
+}
+
+// ReadFile returns the endpoints in a configuration file
+func ReadFile(path string) ([]Endpoint, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Read(f)
+}
+
+// Validate normalizes the method and parameter locations of the endpoint,
+// and returns an error if it is not valid
+func (e *Endpoint) Validate() error {
+	if !types.IsIdentifier(e.Name) {
+		return schema.ErrBadParameter.Withf("rest endpoint: invalid name %q", e.Name)
+	}
+	if e.Method = strings.ToUpper(strings.TrimSpace(e.Method)); e.Method == "" {
+		e.Method = http.MethodGet
+	}
+	if !slices.Contains([]string{http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete}, e.Method) {
+		return schema.ErrBadParameter.Withf("rest endpoint %q: method %q not supported", e.Name, e.Method)
+	}
+	if u, err := url.Parse(reTemplate.ReplaceAllString(e.URL, "x")); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return schema.ErrBadParameter.Withf("rest endpoint %q: invalid url %q", e.Name, e.URL)
+	}
+
+	// Parameters named in the template are in the path
+	for _, match := range reTemplate.FindAllStringSubmatch(e.URL, -1) {
+		param := e.Parameters[match[1]]
+		if param.In != "" && param.In != InPath {
+			return schema.ErrBadParameter.Withf("rest endpoint %q: parameter %q is in the url", e.Name, match[1])
+		}
+		if e.Parameters == nil {
+			e.Parameters = make(map[string]Parameter)
+		}
+		param.In, param.Required = InPath, true
+		e.Parameters[match[1]] = param
+	}
+
+	// Set the location and type of other parameters
+	for name, param := range e.Parameters {
+		switch param.In {
+		case "":
+			param.In = InBody
+			if e.Method == http.MethodGet || e.Method == http.MethodHead || e.Method == http.MethodDelete {
+				param.In = InQuery
+			}
+		case InPath:
+			if !strings.Contains(e.URL, "{"+name+"}") {
+				return schema.ErrBadParameter.Withf("rest endpoint %q: parameter %q is not in the url", e.Name, name)
+			}
+		case InQuery, InHeader, InBody:
+			break
+		default:
+			return schema.ErrBadParameter.Withf("rest endpoint %q: parameter %q has invalid location %q", e.Name, name, param.In)
+		}
+		if param.Type == "" {
+			param.Type = "string"
+		} else if !slices.Contains(paramTypes, param.Type) {
+			return schema.ErrBadParameter.Withf("rest endpoint %q: parameter %q has invalid type %q", e.Name, name, param.Type)
+		}
+		e.Parameters[name] = param
+	}
+
+	// Set the header for authentication
+	if e.Auth != nil && e.Auth.Header == "" {
+		e.Auth.Header = "Authorization"
+	}
+	return nil
+}
+
+// IsReadOnly returns true if calling the endpoint does not change anything.
+// GET and HEAD requests are read-only unless the endpoint says otherwise.
+func (e Endpoint) IsReadOnly() bool {
+	if e.ReadOnly != nil {
+		return *e.ReadOnly
+	}
+	return e.Method == http.MethodGet || e.Method == http.MethodHead
+}
+
+// CredentialURL returns the URL of the credential for the authentication
+// header, or an empty string if the endpoint is not authenticated
+func (e Endpoint) CredentialURL() string {
+	if e.Auth == nil {
+		return ""
+	} else if e.Auth.Credential != "" {
+		return e.Auth.Credential
+	}
+	u, err := url.Parse(reTemplate.ReplaceAllString(e.URL, "x"))
+	if err != nil {
+		return ""
+	}
+	return u.Scheme + "://" + u.Host
+}
+
+// Schema returns the JSON schema for the parameters of the endpoint
+func (e Endpoint) Schema() (*jsonschema.Schema, error) {
+	properties := make(map[string]any, len(e.Parameters))
+	required := []string{}
+	for name, param := range e.Parameters {
+		property := map[string]any{"type": param.Type}
+		if param.Description != "" {
+			property["description"] = param.Description
+		}
+		if len(param.Enum) > 0 {
+			property["enum"] = param.Enum
+		}
+		if param.Default != nil {
+			property["default"] = param.Default
+		}
+		properties[name] = property
+		if param.Required {
+			required = append(required, name)
+		}
+	}
+	slices.Sort(required)
+	data, err := json.Marshal(map[string]any{
+		"type":                 "object",
+		"properties":           properties,
+		"required":             required,
+		"additionalProperties": false,
+	})
+	if err != nil {
+		return nil, err
+	}
+	s, err := jsonschema.FromJSON(data)
+	if err != nil {
+		return nil, fmt.Errorf("rest endpoint %q: %w", e.Name, err)
+	}
+	return s, nil
+}
@@ -0,0 +1,195 @@
+package rest_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	// Packages
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	rest "github.com/mutablelogic/go-llm/pkg/rest"
+	assert "github.com/stretchr/testify/assert"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// HELPERS
+
+const config = `
+endpoints:
+  - name: get_issue
+    description: Return an issue
+    url: %[1]s/issues/{key}
+    auth:
+      prefix: "Bearer "
+    parameters:
+      key:
+        description: Key of the issue
+      fields:
+        type: array
+      X-Trace:
+        in: header
+  - name: add_comment
+    description: Add a comment to an issue
+    method: post
+    url: %[1]s/issues/{key}/comments
+    headers:
+      X-Source: assistant
+    parameters:
+      text:
+        required: true
+      internal:
+        type: boolean
+        default: false
+`
+
+// server returns a server which returns the method, path, query, headers
+// and body of each request
+func server(t *testing.T) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/issues/missing") {
+			http.Error(w, "no such issue", http.StatusNotFound)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{
+			"method":        r.Method,
+			"path":          r.URL.EscapedPath(),
+			"query":         r.URL.Query(),
+			"authorization": r.Header.Get("Authorization"),
+			"trace":         r.Header.Get("X-Trace"),
+			"source":        r.Header.Get("X-Source"),
+			"body":          string(body),
+		})
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func secrets(_ context.Context, url string) (string, error) {
+	if strings.HasPrefix(url, "http://127.0.0.1") {
+		return "s3cret", nil
+	}
+	return "", nil
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// TESTS
+
+func TestRead(t *testing.T) {
+	assert := assert.New(t)
+	endpoints, err := rest.Read(strings.NewReader(fmt.Sprintf(config, "https://api.example.com/v1")))
+	if !assert.NoError(err) || !assert.Len(endpoints, 2) {
+		return
+	}
+
+	// Parameters in the url are in the path and required, and others are in
+	// the query or body depending on the method
+	get, post := endpoints[0], endpoints[1]
+	assert.Equal(http.MethodGet, get.Method)
+	assert.Equal(rest.Parameter{Type: "string", Description: "Key of the issue", In: rest.InPath, Required: true}, get.Parameters["key"])
+	assert.Equal(rest.InQuery, get.Parameters["fields"].In)
+	assert.Equal(rest.InHeader, get.Parameters["X-Trace"].In)
+	assert.Equal("Authorization", get.Auth.Header)
+	assert.Equal("https://api.example.com", get.CredentialURL())
+	assert.True(get.IsReadOnly())
+
+	assert.Equal(http.MethodPost, post.Method)
+	assert.Equal(rest.InBody, post.Parameters["text"].In)
+	assert.Equal("", post.CredentialURL())
+	assert.False(post.IsReadOnly())
+
+	// The schema is generated from the parameters
+	s, err := post.Schema()
+	if assert.NoError(err) {
+		data, _ := json.Marshal(s)
+		assert.JSONEq(`{
+			"type": "object",
+			"properties": {
+				"key": {"type": "string"},
+				"text": {"type": "string"},
+				"internal": {"type": "boolean", "default": false}
+			},
+			"required": ["key", "text"],
+			"additionalProperties": false
+		}`, string(data))
+	}
+
+	// Invalid endpoints are an error
+	for _, config := range []string{
+		"endpoints:\n  - name: bad name\n    url: https://example.com\n",
+		"endpoints:\n  - name: x\n    url: ftp://example.com\n",
+		"endpoints:\n  - name: x\n    method: TRACE\n    url: https://example.com\n",
+		"endpoints:\n  - name: x\n    url: https://example.com/{id}\n    parameters:\n      id:\n        in: query\n",
+		"endpoints:\n  - name: x\n    url: https://example.com\n    parameters:\n      id:\n        type: date\n",
+		"endpoints:\n  - name: x\n    url: https://example.com\n    unknown: true\n",
+	} {
+		_, err := rest.Read(strings.NewReader(config))
+		assert.ErrorIs(err, schema.ErrBadParameter, config)
+	}
+}
+
+func TestTools(t *testing.T) {
+	assert := assert.New(t)
+	server := server(t)
+	endpoints, err := rest.Read(strings.NewReader(fmt.Sprintf(config, server.URL+"/api")))
+	if !assert.NoError(err) {
+		return
+	}
+
+	// Authentication requires secrets
+	_, err = rest.NewTools(endpoints)
+	assert.ErrorIs(err, schema.ErrBadParameter)
+	_, err = rest.NewTools(append(endpoints, endpoints[1]), rest.WithSecrets(secrets))
+	assert.ErrorIs(err, schema.ErrConflict)
+
+	tools, err := rest.NewTools(endpoints, rest.WithSecrets(secrets))
+	if !assert.NoError(err) || !assert.Len(tools, 2) {
+		return
+	}
+	assert.Equal("get_issue", tools[0].Name())
+	assert.True(tools[0].Meta().ReadOnlyHint)
+	assert.False(tools[1].Meta().ReadOnlyHint)
+
+	// Parameters are placed in the path, query and headers
+	result, err := tools[0].Run(context.Background(), json.RawMessage(`{"key":"ABC 1","fields":["a","b"],"X-Trace":"t1"}`))
+	if assert.NoError(err) {
+		response := result.(*rest.Response)
+		assert.Equal(http.StatusOK, response.Status)
+		data, _ := json.Marshal(response.Body)
+		assert.JSONEq(`{"method":"GET","path":"/api/issues/ABC%201","query":{"fields":["a","b"]},"authorization":"Bearer s3cret","trace":"t1","source":"","body":""}`, string(data))
+	}
+
+	// Parameters are placed in the body, with defaults
+	result, err = tools[1].Run(context.Background(), json.RawMessage(`{"key":"ABC-1","text":"Looks good"}`))
+	if assert.NoError(err) {
+		var response struct {
+			Method string `json:"method"`
+			Source string `json:"source"`
+			Body   string `json:"body"`
+		}
+		data, _ := json.Marshal(result.(*rest.Response).Body)
+		assert.NoError(json.Unmarshal(data, &response))
+		assert.Equal("POST", response.Method)
+		assert.Equal("assistant", response.Source)
+		assert.JSONEq(`{"text":"Looks good","internal":false}`, response.Body)
+	}
+
+	// Arguments are validated against the schema
+	_, err = tools[1].Run(context.Background(), json.RawMessage(`{"key":"ABC-1"}`))
+	assert.ErrorIs(err, schema.ErrBadParameter)
+	_, err = tools[1].Run(context.Background(), json.RawMessage(`{"key":"ABC-1","text":"x","other":1}`))
+	assert.ErrorIs(err, schema.ErrBadParameter)
+
+	// Error responses are returned as errors
+	_, err = tools[0].Run(context.Background(), json.RawMessage(`{"key":"missing"}`))
+	if assert.Error(err) {
+		assert.Contains(err.Error(), "no such issue")
+	}
+}
@@ -0,0 +1,278 @@
+package rest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+
+	// Packages
+	llm "github.com/mutablelogic/go-llm"
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	tool "github.com/mutablelogic/go-llm/toolkit/tool"
+	jsonschema "github.com/mutablelogic/go-server/pkg/jsonschema"
+	types "github.com/mutablelogic/go-server/pkg/types"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// Opt configures the REST tools
+type Opt func(*opts) error
+
+// SecretFn returns the secret stored for a credential URL, or an empty
+// string if there is none
+type SecretFn func(ctx context.Context, url string) (string, error)
+
+type opts struct {
+	client  *http.Client
+	secrets SecretFn
+}
+
+type restTool struct {
+	tool.Base
+	*opts
+	endpoint Endpoint
+	schema   *jsonschema.Schema
+}
+
+// Response is the response from an endpoint. The body is JSON when the
+// response is JSON, and text otherwise.
+type Response struct {
+	Status      int    `json:"status"`
+	ContentType string `json:"content_type,omitempty"`
+	Body        any    `json:"body,omitempty"`
+	Truncated   bool   `json:"truncated,omitempty"`
+}
+
+var _ llm.Tool = (*restTool)(nil)
+
+///////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+const (
+	maxResponseSize = 256 << 10
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// LIFECYCLE
+
+// NewTools returns a tool for each endpoint. Endpoints which are not
+// read-only change things outside the server, so callers should require
+// each call to them to be approved.
+func NewTools(endpoints []Endpoint, opt ...Opt) ([]llm.Tool, error) {
+	o := &opts{client: http.DefaultClient}
+	for _, fn := range opt {
+		if err := fn(o); err != nil {
+			return nil, err
+		}
+	}
+	tools := make([]llm.Tool, 0, len(endpoints))
+	names := make(map[string]bool, len(endpoints))
+	for _, endpoint := range endpoints {
+		if err := endpoint.Validate(); err != nil {
+			return nil, err
+		} else if names[endpoint.Name] {
+			return nil, schema.ErrConflict.Withf("rest endpoint %q is declared more than once", endpoint.Name)
+		} else if endpoint.Auth != nil && o.secrets == nil {
+			return nil, schema.ErrBadParameter.Withf("rest endpoint %q: authentication requires secrets", endpoint.Name)
+		}
+		s, err := endpoint.Schema()
+		if err != nil {
+			return nil, err
+		}
+		names[endpoint.Name] = true
+		tools = append(tools, &restTool{opts: o, endpoint: endpoint, schema: s})
+	}
+	return tools, nil
+}
+
+// WithClient sets the HTTP client which calls the endpoints
+func WithClient(client *http.Client) Opt {
+	return func(o *opts) error {
+		if client == nil {
+			return schema.ErrBadParameter.With("client is required")
+		}
+		o.client = client
+		return nil
+	}
+}
+
+// WithSecrets sets the function which returns secrets for authentication
+// headers
+func WithSecrets(fn SecretFn) Opt {
+	return func(o *opts) error {
+		o.secrets = fn
+		return nil
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// llm.Tool INTERFACE
+
+func (t *restTool) Name() string {
+	return t.endpoint.Name
+}
+
+func (t *restTool) Description() string {
+	return t.endpoint.Description
+}
+
+func (t *restTool) InputSchema() *jsonschema.Schema {
+	return t.schema
+}
+
+func (*restTool) OutputSchema() *jsonschema.Schema {
+	return jsonschema.MustFor[Response]()
+}
+
+func (t *restTool) Meta() llm.ToolMeta {
+	readonly := t.endpoint.IsReadOnly()
+	return llm.ToolMeta{ReadOnlyHint: readonly, IdempotentHint: readonly, OpenWorldHint: types.Ptr(true)}
+}
+
+func (t *restTool) Run(ctx context.Context, input json.RawMessage) (any, error) {
+	if len(input) == 0 {
+		input = json.RawMessage(`{}`)
+	}
+	var args map[string]any
+	if err := t.schema.Decode(input, &args); err != nil {
+		return nil, schema.ErrBadParameter.Withf("%s: %v", t.endpoint.Name, err)
+	}
+	req, err := t.request(ctx, args)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	response, err := readResponse(resp)
+	if err != nil {
+		return nil, err
+	} else if resp.StatusCode >= http.StatusBadRequest {
+		return nil, fmt.Errorf("%s: %s: %v", t.endpoint.Name, resp.Status, response.Body)
+	}
+	return response, nil
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// request returns the request for the endpoint with arguments
+func (t *restTool) request(ctx context.Context, args map[string]any) (*http.Request, error) {
+	// Fill the URL template, and set the query, headers and body
+	query := url.Values{}
+	headers := http.Header{}
+	body := map[string]any{}
+	rawurl := t.endpoint.URL
+	for name, param := range t.endpoint.Parameters {
+		value, ok := args[name]
+		if !ok {
+			continue
+		}
+		switch param.In {
+		case InPath:
+			rawurl = strings.ReplaceAll(rawurl, "{"+name+"}", url.PathEscape(text(value)))
+		case InQuery:
+			if values, ok := value.([]any); ok {
+				for _, value := range values {
+					query.Add(name, text(value))
+				}
+			} else {
+				query.Set(name, text(value))
+			}
+		case InHeader:
+			headers.Set(name, text(value))
+		case InBody:
+			body[name] = value
+		}
+	}
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return nil, schema.ErrBadParameter.Withf("%s: %v", t.endpoint.Name, err)
+	}
+	if len(query) > 0 {
+		values := u.Query()
+		for key, value := range query {
+			values[key] = value
+		}
+		u.RawQuery = values.Encode()
+	}
+
+	// Encode the body
+	var reader io.Reader
+	if len(body) > 0 {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, t.endpoint.Method, u.String(), reader)
+	if err != nil {
+		return nil, err
+	}
+	if reader != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Accept", "application/json, text/*;q=0.9")
+	for key, value := range t.endpoint.Headers {
+		req.Header.Set(key, value)
+	}
+	for key, value := range headers {
+		req.Header[key] = value
+	}
+
+	// Set the authentication header from the secret
+	if auth := t.endpoint.Auth; auth != nil {
+		secret, err := t.secrets(ctx, t.endpoint.CredentialURL())
+		if err != nil {
+			return nil, err
+		} else if secret == "" {
+			return nil, schema.ErrNotFound.Withf("%s: no credential for %q", t.endpoint.Name, t.endpoint.CredentialURL())
+		}
+		req.Header.Set(auth.Header, auth.Prefix+secret)
+	}
+	return req, nil
+}
+
+// readResponse reads the body of a response, which is decoded when it is
+// JSON and returned as text otherwise. Bodies which are too long are
+// truncated.
+func readResponse(resp *http.Response) (*Response, error) {
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize+1))
+	if err != nil {
+		return nil, err
+	}
+	response := &Response{Status: resp.StatusCode, ContentType: resp.Header.Get("Content-Type")}
+	if len(data) > maxResponseSize {
+		data, response.Truncated = data[:maxResponseSize], true
+	}
+	mediatype, _, _ := mime.ParseMediaType(response.ContentType)
+	if !response.Truncated && (mediatype == "application/json" || strings.HasSuffix(mediatype, "+json")) && json.Valid(data) {
+		response.Body = json.RawMessage(data)
+	} else if len(data) > 0 {
+		response.Body = strings.ToValidUTF8(string(data), "\uFFFD")
+	}
+	return response, nil
+}
+
+// text returns a parameter value as text
+func text(value any) string {
+	switch value := value.(type) {
+	case string:
+		return value
+	case nil:
+		return ""
+	default:
+		data, _ := json.Marshal(value)
+		return string(data)
+	}
+}
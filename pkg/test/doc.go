@@ -5,4 +5,9 @@
 // provider-specific environment variables, exposes a go-pg/pkg/test-style
 // Main/Conn lifecycle, and provides common helpers for runtime loops,
 // bounded contexts, synthetic auth users, and provider/model setup.
+//
+// Golden runs request and response fixtures in testdata against an HTTP
+// handler, with IDs and times replaced by placeholders so that responses can
+// be compared between runs. Run the tests with -update to rewrite the
+// responses in the fixtures when the behaviour of the API changes.
 package test
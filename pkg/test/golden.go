@@ -0,0 +1,294 @@
+package test
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	// Packages
+	assert "github.com/stretchr/testify/assert"
+	yaml "gopkg.in/yaml.v3"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// GoldenStep is a request in a golden fixture, and the response which is
+// expected. The steps of a fixture run in order, so a step can refer to the
+// IDs returned by an earlier step.
+type GoldenStep struct {
+	Name     string          `yaml:"name"`
+	Request  GoldenRequest   `yaml:"request"`
+	Response *GoldenResponse `yaml:"response,omitempty"`
+}
+
+// GoldenRequest is a request to the handler. The body is encoded as JSON,
+// and placeholders such as {{uuid:1}} in the path and body are replaced with
+// the values they stand for in earlier responses.
+type GoldenRequest struct {
+	Method string            `yaml:"method"`
+	Path   string            `yaml:"path"`
+	Header map[string]string `yaml:"header,omitempty"`
+	Body   any               `yaml:"body,omitempty"`
+}
+
+// GoldenResponse is the response which is expected from the handler. IDs
+// and times are replaced with placeholders before the response is compared,
+// and {{any}} in the body matches any value. Only the headers in the fixture
+// are compared.
+type GoldenResponse struct {
+	Status int               `yaml:"status"`
+	Header map[string]string `yaml:"header,omitempty"`
+	Body   any               `yaml:"body,omitempty"`
+}
+
+// goldenValues maps the IDs in responses to placeholders, and back
+type goldenValues struct {
+	placeholders map[string]string // value to placeholder
+	values       map[string]string // placeholder to value
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+// Run the tests with -update to rewrite the responses in golden fixtures
+var update = flag.Bool("update", false, "rewrite the responses in golden fixtures")
+
+const goldenAny = "{{any}}"
+
+var (
+	goldenUUID        = regexp.MustCompile(`[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`)
+	goldenTime        = regexp.MustCompile(`\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}(\.\d+)?(Z|[+-]\d{2}:\d{2})`)
+	goldenPlaceholder = regexp.MustCompile(`\{\{uuid:\d+\}\}`)
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// Golden runs the requests in each fixture file which matches the pattern,
+// such as testdata/golden/*.yaml, against the handler, and compares the
+// responses with those in the fixture. When the tests are run with -update,
+// or a step has no response, the responses are written to the fixture
+// instead.
+func Golden(t *testing.T, handler http.Handler, pattern string) {
+	t.Helper()
+	paths, err := filepath.Glob(pattern)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(paths) == 0 {
+		t.Fatalf("no golden fixtures match %q", pattern)
+	}
+	for _, path := range paths {
+		t.Run(strings.TrimSuffix(filepath.Base(path), filepath.Ext(path)), func(t *testing.T) {
+			goldenFile(t, handler, path)
+		})
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// goldenFile runs the steps of a fixture file in order
+func goldenFile(t *testing.T, handler http.Handler, path string) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var steps []*GoldenStep
+	if err := yaml.Unmarshal(data, &steps); err != nil {
+		t.Fatalf("%s: %v", path, err)
+	}
+
+	values := &goldenValues{placeholders: make(map[string]string), values: make(map[string]string)}
+	changed := false
+	for i, step := range steps {
+		name := step.Name
+		if name == "" {
+			name = fmt.Sprint(i)
+		}
+		ok := t.Run(name, func(t *testing.T) {
+			actual, err := goldenServe(handler, step.Request, values)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if *update || step.Response == nil {
+				step.Response, changed = actual.recorded(step.Response), true
+				return
+			}
+			step.Response.compare(t, actual)
+		})
+		if !ok {
+			// Later steps depend on this one
+			break
+		}
+	}
+
+	// Write the recorded responses
+	if changed {
+		var buf bytes.Buffer
+		enc := yaml.NewEncoder(&buf)
+		enc.SetIndent(2)
+		if err := enc.Encode(steps); err != nil {
+			t.Fatal(err)
+		} else if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		t.Logf("updated %s", path)
+	}
+}
+
+// goldenServe sends the request to the handler, and returns the response
+// with its IDs and times replaced with placeholders
+func goldenServe(handler http.Handler, req GoldenRequest, values *goldenValues) (*GoldenResponse, error) {
+	var body io.Reader
+	if req.Body != nil {
+		data, err := json.Marshal(req.Body)
+		if err != nil {
+			return nil, err
+		}
+		body = strings.NewReader(values.expand(string(data)))
+	}
+	r := httptest.NewRequest(req.Method, values.expand(req.Path), body)
+	if body != nil {
+		r.Header.Set("Content-Type", "application/json")
+	}
+	for key, value := range req.Header {
+		r.Header.Set(key, values.expand(value))
+	}
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+
+	// Decode JSON responses, so that they are compared by value
+	response := &GoldenResponse{Status: w.Code, Header: make(map[string]string)}
+	for key := range w.Header() {
+		response.Header[key] = w.Header().Get(key)
+	}
+	text := values.normalize(w.Body.String())
+	if mediatype, _, _ := mime.ParseMediaType(w.Header().Get("Content-Type")); mediatype == "application/json" && strings.TrimSpace(text) != "" {
+		if err := json.Unmarshal([]byte(text), &response.Body); err != nil {
+			return nil, fmt.Errorf("decode response: %w", err)
+		}
+	} else if text != "" {
+		response.Body = text
+	}
+	return response, nil
+}
+
+// recorded returns the response to write to a fixture, with the headers
+// which were compared before, or the content type
+func (r *GoldenResponse) recorded(previous *GoldenResponse) *GoldenResponse {
+	result := &GoldenResponse{Status: r.Status, Body: r.Body, Header: make(map[string]string)}
+	keys := []string{"Content-Type"}
+	if previous != nil && len(previous.Header) > 0 {
+		keys = keys[:0]
+		for key := range previous.Header {
+			keys = append(keys, key)
+		}
+	}
+	for _, key := range keys {
+		if value, exists := r.Header[http.CanonicalHeaderKey(key)]; exists {
+			result.Header[key] = value
+		}
+	}
+	return result
+}
+
+// compare checks the actual response against the expected one
+func (r *GoldenResponse) compare(t *testing.T, actual *GoldenResponse) {
+	t.Helper()
+	assert.Equal(t, r.Status, actual.Status, "status")
+	for key, value := range r.Header {
+		assert.Equal(t, value, actual.Header[http.CanonicalHeaderKey(key)], "header %q", key)
+	}
+
+	// Compare the bodies as JSON values, so that numbers decoded from YAML
+	// and JSON are the same
+	expected, err := goldenJSON(r.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, expected, goldenMatch(expected, actual.Body), "body")
+}
+
+// expand replaces placeholders with the values they stand for
+func (v *goldenValues) expand(text string) string {
+	return goldenPlaceholder.ReplaceAllStringFunc(text, func(placeholder string) string {
+		if value, exists := v.values[placeholder]; exists {
+			return value
+		}
+		return placeholder
+	})
+}
+
+// normalize replaces IDs with numbered placeholders, in the order they are
+// first seen in the fixture, and times with a placeholder
+func (v *goldenValues) normalize(text string) string {
+	text = goldenUUID.ReplaceAllStringFunc(text, func(id string) string {
+		id = strings.ToLower(id)
+		if placeholder, exists := v.placeholders[id]; exists {
+			return placeholder
+		}
+		placeholder := fmt.Sprintf("{{uuid:%d}}", len(v.placeholders)+1)
+		v.placeholders[id], v.values[placeholder] = placeholder, id
+		return placeholder
+	})
+	return goldenTime.ReplaceAllString(text, "{{time}}")
+}
+
+// goldenJSON returns a value as it is decoded from JSON
+func goldenJSON(v any) (any, error) {
+	if v == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var result any
+	return result, json.Unmarshal(data, &result)
+}
+
+// goldenMatch returns the actual value, with the values which the expected
+// value matches with {{any}} replaced by {{any}}
+func goldenMatch(expected, actual any) any {
+	if expected == goldenAny {
+		return goldenAny
+	}
+	switch expected := expected.(type) {
+	case map[string]any:
+		actual, ok := actual.(map[string]any)
+		if !ok {
+			return actual
+		}
+		result := make(map[string]any, len(actual))
+		for key, value := range actual {
+			result[key] = goldenMatch(expected[key], value)
+		}
+		return result
+	case []any:
+		actual, ok := actual.([]any)
+		if !ok {
+			return actual
+		}
+		result := make([]any, len(actual))
+		for i, value := range actual {
+			if i < len(expected) {
+				result[i] = goldenMatch(expected[i], value)
+			} else {
+				result[i] = value
+			}
+		}
+		return result
+	}
+	return actual
+}
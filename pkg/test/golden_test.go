@@ -0,0 +1,91 @@
+package test
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	// Packages
+	uuid "github.com/google/uuid"
+	assert "github.com/stretchr/testify/assert"
+)
+
+func TestGoldenNormalize(t *testing.T) {
+	assert := assert.New(t)
+	values := &goldenValues{placeholders: make(map[string]string), values: make(map[string]string)}
+
+	a, b := uuid.New(), uuid.New()
+	text := values.normalize(`{"a":"` + a.String() + `","b":"` + b.String() + `","c":"` + a.String() + `","at":"2026-01-02T03:04:05.678Z"}`)
+	assert.Equal(`{"a":"{{uuid:1}}","b":"{{uuid:2}}","c":"{{uuid:1}}","at":"{{time}}"}`, text)
+	assert.Equal("/session/"+b.String()+"/{{uuid:3}}", values.expand("/session/{{uuid:2}}/{{uuid:3}}"))
+}
+
+func TestGoldenMatch(t *testing.T) {
+	assert := assert.New(t)
+	expected := map[string]any{"id": goldenAny, "items": []any{goldenAny, "b"}}
+	actual := map[string]any{"id": "x", "items": []any{"a", "b"}, "extra": true}
+	assert.Equal(map[string]any{"id": goldenAny, "items": []any{goldenAny, "b"}, "extra": true}, goldenMatch(expected, actual))
+}
+
+func TestGoldenRecord(t *testing.T) {
+	assert := assert.New(t)
+
+	// A handler which creates an item, and returns it by ID
+	items := make(map[string]string)
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /item", func(w http.ResponseWriter, r *http.Request) {
+		var req struct{ Name string }
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		id := uuid.NewString()
+		items[id] = req.Name
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(map[string]any{"id": id, "name": req.Name})
+	})
+	mux.HandleFunc("GET /item/{id}", func(w http.ResponseWriter, r *http.Request) {
+		name, exists := items[r.PathValue("id")]
+		if !exists {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{"id": r.PathValue("id"), "name": name})
+	})
+
+	// Responses are recorded for steps which have none
+	path := filepath.Join(t.TempDir(), "item.yaml")
+	assert.NoError(os.WriteFile(path, []byte(`
+- name: create
+  request:
+    method: POST
+    path: /item
+    body:
+      name: first
+- name: get
+  request:
+    method: GET
+    path: /item/{{uuid:1}}
+- name: missing
+  request:
+    method: GET
+    path: /item/00000000-0000-0000-0000-000000000000
+`), 0o644))
+	Golden(t, mux, path)
+
+	data, err := os.ReadFile(path)
+	assert.NoError(err)
+	assert.Contains(string(data), "id: '{{uuid:1}}'")
+	assert.Contains(string(data), "status: 201")
+	assert.Contains(string(data), "404 page not found")
+
+	// The recorded responses then match
+	Golden(t, mux, path)
+	after, err := os.ReadFile(path)
+	assert.NoError(err)
+	assert.Equal(string(data), string(after))
+}
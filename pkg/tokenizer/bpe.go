@@ -0,0 +1,208 @@
+package tokenizer
+
+import (
+	"bufio"
+	"encoding/base64"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+
+	// Packages
+	regexp2 "github.com/dlclark/regexp2"
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// BPE is a byte-pair encoding compatible with tiktoken. Text is split with
+// the pattern for the encoding, and each piece is encoded by repeatedly
+// merging the adjacent pair of parts with the lowest rank.
+type BPE struct {
+	name    string
+	pattern *regexp2.Regexp
+	ranks   map[string]int
+}
+
+var _ Tokenizer = (*BPE)(nil)
+
+///////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+// Encoding names, which determine how text is split before encoding
+const (
+	Cl100kBase = "cl100k_base"
+	O200kBase  = "o200k_base"
+)
+
+var patterns = map[string]string{
+	Cl100kBase: `(?i:'s|'t|'re|'ve|'m|'ll|'d)|[^\r\n\p{L}\p{N}]?\p{L}+|\p{N}{1,3}| ?[^\s\p{L}\p{N}]+[\r\n]*|\s*[\r\n]+|\s+(?!\S)|\s+`,
+	O200kBase: strings.Join([]string{
+		`[^\r\n\p{L}\p{N}]?[\p{Lu}\p{Lt}\p{Lm}\p{Lo}\p{M}]*[\p{Ll}\p{Lm}\p{Lo}\p{M}]+(?i:'s|'t|'re|'ve|'m|'ll|'d)?`,
+		`[^\r\n\p{L}\p{N}]?[\p{Lu}\p{Lt}\p{Lm}\p{Lo}\p{M}]+[\p{Ll}\p{Lm}\p{Lo}\p{M}]*(?i:'s|'t|'re|'ve|'m|'ll|'d)?`,
+		`\p{N}{1,3}`,
+		` ?[^\s\p{L}\p{N}]+[\r\n/]*`,
+		`\s*[\r\n]+`,
+		`\s+(?!\S)`,
+		`\s+`,
+	}, "|"),
+}
+
+// Compiled patterns, shared by encodings and heuristics. These are compiled
+// in a variable initializer rather than init, so that they are ready for the
+// default tokenizers which are also created during initialization.
+var compiled = compilePatterns()
+
+func compilePatterns() map[string]*regexp2.Regexp {
+	result := make(map[string]*regexp2.Regexp, len(patterns))
+	for name, pattern := range patterns {
+		result[name] = regexp2.MustCompile(pattern, regexp2.None)
+	}
+	return result
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// LIFECYCLE
+
+// NewBPE returns an encoding with the given ranks, which map each token to
+// its rank
+func NewBPE(encoding string, ranks map[string]int) (*BPE, error) {
+	pattern, exists := compiled[encoding]
+	if !exists {
+		return nil, schema.ErrBadParameter.Withf("unsupported encoding %q", encoding)
+	} else if len(ranks) == 0 {
+		return nil, schema.ErrBadParameter.Withf("no ranks for encoding %q", encoding)
+	}
+	return &BPE{
+		name:    encoding,
+		pattern: pattern,
+		ranks:   ranks,
+	}, nil
+}
+
+// ReadEncoding reads ranks in the tiktoken file format, where each line is a
+// base64-encoded token and its rank separated by a space
+func ReadEncoding(encoding string, r io.Reader) (*BPE, error) {
+	ranks := make(map[string]int)
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		token, rank, found := strings.Cut(text, " ")
+		if !found {
+			return nil, schema.ErrBadParameter.Withf("%s: line %d: missing rank", encoding, line)
+		}
+		data, err := base64.StdEncoding.DecodeString(token)
+		if err != nil {
+			return nil, schema.ErrBadParameter.Withf("%s: line %d: %v", encoding, line, err)
+		}
+		value, err := strconv.Atoi(rank)
+		if err != nil {
+			return nil, schema.ErrBadParameter.Withf("%s: line %d: %v", encoding, line, err)
+		}
+		ranks[string(data)] = value
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return NewBPE(encoding, ranks)
+}
+
+// OpenEncoding reads ranks from a tiktoken file, such as cl100k_base.tiktoken
+func OpenEncoding(encoding, path string) (*BPE, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadEncoding(encoding, f)
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// Name returns the name of the encoding
+func (e *BPE) Name() string {
+	return e.name
+}
+
+// Tokens splits text into tokens
+func (e *BPE) Tokens(text string) []string {
+	var result []string
+	for _, piece := range split(e.pattern, text) {
+		if _, exists := e.ranks[piece]; exists {
+			result = append(result, piece)
+		} else {
+			result = append(result, e.merge(piece)...)
+		}
+	}
+	return result
+}
+
+// Encode returns the ranks of the tokens in the text. Bytes which are not
+// in the ranks are skipped, which does not occur with complete encodings.
+func (e *BPE) Encode(text string) []int {
+	tokens := e.Tokens(text)
+	result := make([]int, 0, len(tokens))
+	for _, token := range tokens {
+		if rank, exists := e.ranks[token]; exists {
+			result = append(result, rank)
+		}
+	}
+	return result
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// merge splits a piece into single bytes, and then merges the adjacent pair
+// with the lowest rank until no pair can be merged
+func (e *BPE) merge(piece string) []string {
+	parts := make([]string, len(piece))
+	for i := range len(piece) {
+		parts[i] = piece[i : i+1]
+	}
+	for len(parts) > 1 {
+		best, index := math.MaxInt, -1
+		for i := 0; i < len(parts)-1; i++ {
+			if rank, exists := e.ranks[parts[i]+parts[i+1]]; exists && rank < best {
+				best, index = rank, i
+			}
+		}
+		if index < 0 {
+			break
+		}
+		parts[index] += parts[index+1]
+		parts = append(parts[:index+1], parts[index+2:]...)
+	}
+	return parts
+}
+
+// split returns the pieces of text matched by the pattern. Text between
+// matches, which the tiktoken patterns do not leave, is returned as a piece
+// so that the pieces always concatenate back to the text.
+func split(pattern *regexp2.Regexp, text string) []string {
+	var result []string
+	runes := []rune(text)
+	pos := 0
+	match, err := pattern.FindRunesMatch(runes)
+	for err == nil && match != nil {
+		if match.Index > pos {
+			result = append(result, string(runes[pos:match.Index]))
+		}
+		if match.Length == 0 {
+			break
+		}
+		result = append(result, string(runes[match.Index:match.Index+match.Length]))
+		pos = match.Index + match.Length
+		match, err = pattern.FindNextMatch(match)
+	}
+	if pos < len(runes) {
+		result = append(result, string(runes[pos:]))
+	}
+	return result
+}
@@ -0,0 +1,96 @@
+package tokenizer
+
+import (
+	"math"
+	"unicode"
+	"unicode/utf8"
+
+	// Packages
+	regexp2 "github.com/dlclark/regexp2"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// Heuristic estimates tokens without ranks. Text is split with the pattern
+// of an encoding, and each piece is divided into tokens of roughly the
+// average number of characters per token for the provider. Characters from
+// scripts without spaces between words, such as Chinese and Japanese, are
+// counted as one token each.
+type Heuristic struct {
+	pattern *regexp2.Regexp
+	chars   float64
+}
+
+var _ Tokenizer = (*Heuristic)(nil)
+
+///////////////////////////////////////////////////////////////////////////////
+// LIFECYCLE
+
+// NewHeuristic returns a tokenizer which splits text with the pattern for an
+// encoding, with the given average number of characters per token. It falls
+// back to cl100k_base for an unknown encoding, and four characters per token
+// when chars is not positive.
+func NewHeuristic(encoding string, chars float64) *Heuristic {
+	pattern, exists := compiled[encoding]
+	if !exists {
+		pattern = compiled[Cl100kBase]
+	}
+	if chars <= 0 {
+		chars = 4
+	}
+	return &Heuristic{pattern: pattern, chars: chars}
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// Name returns the name of the tokenizer
+func (h *Heuristic) Name() string {
+	return "heuristic"
+}
+
+// Tokens splits text into estimated tokens
+func (h *Heuristic) Tokens(text string) []string {
+	var result []string
+	for _, piece := range split(h.pattern, text) {
+		result = append(result, h.divide(piece)...)
+	}
+	return result
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// divide splits a piece into tokens of roughly equal length
+func (h *Heuristic) divide(piece string) []string {
+	if ideographic(piece) {
+		result := make([]string, 0, utf8.RuneCountInString(piece))
+		for _, r := range piece {
+			result = append(result, string(r))
+		}
+		return result
+	}
+
+	runes := []rune(piece)
+	n := int(math.Ceil(float64(len(runes)) / h.chars))
+	if n <= 1 {
+		return []string{piece}
+	}
+	result := make([]string, 0, n)
+	for i := range n {
+		result = append(result, string(runes[i*len(runes)/n:(i+1)*len(runes)/n]))
+	}
+	return result
+}
+
+// ideographic returns true if the piece contains characters from scripts
+// which are written without spaces between words
+func ideographic(piece string) bool {
+	for _, r := range piece {
+		if unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul, unicode.Thai) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,185 @@
+// Package tokenizer counts and truncates text by tokens rather than by
+// characters. Text is split with the pre-tokenization rules used by tiktoken
+// encodings, and then either encoded with byte-pair ranks loaded from a
+// tiktoken file, or estimated with a per-provider heuristic when no ranks
+// are available for the provider.
+package tokenizer
+
+import (
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	// Packages
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// Tokenizer splits text into tokens. The tokens returned by Tokens
+// concatenate back to the original text, so that text can be truncated on a
+// token boundary.
+type Tokenizer interface {
+	// Return the name of the encoding
+	Name() string
+
+	// Split text into tokens
+	Tokens(text string) []string
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+const (
+	// Fixed token cost for an attachment, when its content is not text
+	attachmentTokens = 256
+)
+
+var (
+	mu sync.RWMutex
+
+	// Default tokenizers for each provider, which are calibrated estimates
+	// until an encoding is registered for the provider
+	providers = map[string]Tokenizer{
+		schema.OpenAI:    NewHeuristic(Cl100kBase, 4.0),
+		schema.Anthropic: NewHeuristic(Cl100kBase, 3.5),
+		schema.Gemini:    NewHeuristic(Cl100kBase, 4.0),
+		schema.Mistral:   NewHeuristic(Cl100kBase, 3.5),
+		schema.Ollama:    NewHeuristic(Cl100kBase, 3.8),
+	}
+
+	// Tokenizer for providers without their own
+	defaultTokenizer = NewHeuristic(Cl100kBase, 4.0)
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// Register sets the tokenizer for a provider, for example an encoding loaded
+// with OpenEncoding. A nil tokenizer restores the default.
+func Register(provider string, tokenizer Tokenizer) {
+	mu.Lock()
+	defer mu.Unlock()
+	if tokenizer == nil {
+		delete(providers, provider)
+	} else {
+		providers[provider] = tokenizer
+	}
+}
+
+// ForProvider returns the tokenizer for a provider, or a default tokenizer
+// if none is registered
+func ForProvider(provider string) Tokenizer {
+	mu.RLock()
+	defer mu.RUnlock()
+	if tokenizer, exists := providers[provider]; exists {
+		return tokenizer
+	}
+	return defaultTokenizer
+}
+
+// Count returns the number of tokens in the text
+func Count(t Tokenizer, text string) uint {
+	if text == "" {
+		return 0
+	}
+	return uint(len(t.Tokens(text)))
+}
+
+// Truncate returns the text cut to at most max tokens. If the cut falls
+// within a multi-byte character, the partial character is removed.
+func Truncate(t Tokenizer, text string, max uint) string {
+	tokens := t.Tokens(text)
+	if uint(len(tokens)) <= max {
+		return text
+	}
+	result := strings.Join(tokens[:max], "")
+	for len(result) > 0 && !utf8.ValidString(result) {
+		result = result[:len(result)-1]
+	}
+	return result
+}
+
+// CountMessage returns the number of tokens in the content of a message.
+// Text attachments are counted by their content, and other attachments have
+// a fixed cost.
+func CountMessage(t Tokenizer, message *schema.Message) uint {
+	if message == nil {
+		return 0
+	}
+	var tokens uint
+	for _, block := range message.Content {
+		switch {
+		case block.Text != nil:
+			tokens += Count(t, *block.Text)
+		case block.Thinking != nil:
+			tokens += Count(t, *block.Thinking)
+		case block.ToolCall != nil:
+			tokens += Count(t, block.ToolCall.Name) + Count(t, string(block.ToolCall.Input))
+		case block.ToolResult != nil:
+			tokens += Count(t, string(block.ToolResult.Content))
+		case block.Context != nil:
+			tokens += Count(t, block.Context.Text())
+		case block.Attachment != nil:
+			if block.Attachment.IsText() {
+				tokens += Count(t, block.Attachment.TextContent())
+			} else {
+				tokens += attachmentTokens
+			}
+		}
+	}
+	return tokens
+}
+
+// CountConversation returns the number of tokens in all messages
+func CountConversation(t Tokenizer, conversation schema.Conversation) uint {
+	var tokens uint
+	for _, message := range conversation {
+		tokens += CountMessage(t, message)
+	}
+	return tokens
+}
+
+// TruncateConversation returns the most recent messages which fit within the
+// token budget. A tool result is not returned without the message which
+// called the tool, and the last message is always returned, even if it
+// exceeds the budget on its own.
+func TruncateConversation(t Tokenizer, conversation schema.Conversation, budget uint) schema.Conversation {
+	if len(conversation) == 0 {
+		return conversation
+	}
+
+	// Find the oldest message which fits
+	start := len(conversation) - 1
+	total := CountMessage(t, conversation[start])
+	for start > 0 {
+		tokens := CountMessage(t, conversation[start-1])
+		if total+tokens > budget {
+			break
+		}
+		total += tokens
+		start--
+	}
+
+	// Drop leading tool results whose calls were removed
+	for start < len(conversation)-1 && isToolResult(conversation[start]) {
+		start++
+	}
+	return conversation[start:]
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+func isToolResult(message *schema.Message) bool {
+	if message == nil {
+		return false
+	}
+	for _, block := range message.Content {
+		if block.ToolResult != nil {
+			return true
+		}
+	}
+	return false
+}
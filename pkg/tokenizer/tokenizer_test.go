@@ -0,0 +1,143 @@
+package tokenizer_test
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	// Packages
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	tokenizer "github.com/mutablelogic/go-llm/pkg/tokenizer"
+	types "github.com/mutablelogic/go-server/pkg/types"
+	assert "github.com/stretchr/testify/assert"
+)
+
+// testEncoding returns a tiktoken file with every byte, and the given merged
+// tokens ranked in order after the bytes
+func testEncoding(tokens ...string) string {
+	var lines []string
+	for i := range 256 {
+		lines = append(lines, fmt.Sprintf("%s %d", base64.StdEncoding.EncodeToString([]byte{byte(i)}), i))
+	}
+	for i, token := range tokens {
+		lines = append(lines, fmt.Sprintf("%s %d", base64.StdEncoding.EncodeToString([]byte(token)), 256+i))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func Test_bpe_001(t *testing.T) {
+	// Pieces are merged by rank, and tokens concatenate back to the text
+	assert := assert.New(t)
+
+	bpe, err := tokenizer.ReadEncoding(tokenizer.Cl100kBase, strings.NewReader(testEncoding("ll", "he", "hell", " w", " wo", " wor")))
+	if !assert.NoError(err) {
+		return
+	}
+	assert.Equal(tokenizer.Cl100kBase, bpe.Name())
+
+	tokens := bpe.Tokens("hello world's 12345")
+	assert.Equal([]string{"hell", "o", " wor", "l", "d", "'", "s", " ", "1", "2", "3", "4", "5"}, tokens)
+	assert.Equal("hello world's 12345", strings.Join(tokens, ""))
+	assert.Equal([]int{258, 'o', 261, 'l', 'd'}, bpe.Encode("hello world"))
+}
+
+func Test_bpe_002(t *testing.T) {
+	// Whole pieces in the ranks are returned as a single token
+	assert := assert.New(t)
+
+	bpe, err := tokenizer.ReadEncoding(tokenizer.Cl100kBase, strings.NewReader(testEncoding("hello", " world", "'s", "123")))
+	if !assert.NoError(err) {
+		return
+	}
+	assert.Equal([]string{"hello", " world", "'s", " ", "123", "4", "5"}, bpe.Tokens("hello world's 12345"))
+	assert.Equal(uint(7), tokenizer.Count(bpe, "hello world's 12345"))
+}
+
+func Test_bpe_003(t *testing.T) {
+	// Invalid encodings are rejected
+	assert := assert.New(t)
+
+	_, err := tokenizer.ReadEncoding("unknown", strings.NewReader(testEncoding()))
+	assert.ErrorIs(err, schema.ErrBadParameter)
+	_, err = tokenizer.ReadEncoding(tokenizer.O200kBase, strings.NewReader("aGVsbG8="))
+	assert.ErrorIs(err, schema.ErrBadParameter)
+	_, err = tokenizer.ReadEncoding(tokenizer.O200kBase, strings.NewReader(""))
+	assert.ErrorIs(err, schema.ErrBadParameter)
+}
+
+func Test_heuristic_001(t *testing.T) {
+	// Words are divided by the average characters per token
+	assert := assert.New(t)
+
+	h := tokenizer.NewHeuristic(tokenizer.Cl100kBase, 4)
+	assert.Equal([]string{"The", " cat", " sat", "."}, h.Tokens("The cat sat."))
+	assert.Equal([]string{"int", "ern", "ati", "onal"}, h.Tokens("international"))
+	assert.Equal([]string{"日", "本", "語"}, h.Tokens("日本語"))
+
+	text := "A longer sentence, with punctuation; and numbers 2024!"
+	assert.Equal(text, strings.Join(h.Tokens(text), ""))
+}
+
+func Test_truncate_001(t *testing.T) {
+	// Text is cut on a token boundary without splitting characters
+	assert := assert.New(t)
+
+	h := tokenizer.NewHeuristic(tokenizer.Cl100kBase, 4)
+	assert.Equal("The cat", tokenizer.Truncate(h, "The cat sat on the mat", 2))
+	assert.Equal("short", tokenizer.Truncate(h, "short", 10))
+	assert.Equal("", tokenizer.Truncate(h, "short", 0))
+
+	bpe, err := tokenizer.ReadEncoding(tokenizer.Cl100kBase, strings.NewReader(testEncoding()))
+	if assert.NoError(err) {
+		// "é" is two bytes, so two tokens without merges
+		assert.Equal("caf", tokenizer.Truncate(bpe, "café", 4))
+	}
+}
+
+func Test_provider_001(t *testing.T) {
+	// Providers have a default tokenizer, which can be replaced
+	assert := assert.New(t)
+
+	assert.NotNil(tokenizer.ForProvider(schema.Anthropic))
+	assert.NotNil(tokenizer.ForProvider("unknown"))
+	assert.NotZero(tokenizer.Count(tokenizer.ForProvider(schema.Anthropic), "hello world"))
+	assert.NotZero(tokenizer.Count(tokenizer.ForProvider("unknown"), "hello world"))
+
+	bpe, err := tokenizer.ReadEncoding(tokenizer.Cl100kBase, strings.NewReader(testEncoding()))
+	if !assert.NoError(err) {
+		return
+	}
+	tokenizer.Register(schema.OpenAI, bpe)
+	assert.Equal(bpe, tokenizer.ForProvider(schema.OpenAI))
+	tokenizer.Register(schema.OpenAI, nil)
+	assert.Equal("heuristic", tokenizer.ForProvider(schema.OpenAI).Name())
+}
+
+func Test_conversation_001(t *testing.T) {
+	// The most recent messages which fit in the budget are kept
+	assert := assert.New(t)
+
+	h := tokenizer.NewHeuristic(tokenizer.Cl100kBase, 4)
+	conversation := schema.Conversation{
+		{Role: schema.RoleUser, Content: []schema.ContentBlock{{Text: types.Ptr("What is the weather?")}}},
+		{Role: schema.RoleAssistant, Content: []schema.ContentBlock{{ToolCall: &schema.ToolCall{ID: "1", Name: "weather", Input: json.RawMessage(`{}`)}}}},
+		{Role: schema.RoleUser, Content: []schema.ContentBlock{{ToolResult: &schema.ToolResult{ID: "1", Content: json.RawMessage(`"rain"`)}}}},
+		{Role: schema.RoleAssistant, Content: []schema.ContentBlock{{Text: types.Ptr("It is raining.")}}},
+	}
+	assert.Equal(uint(6), tokenizer.CountMessage(h, conversation[0]))
+	assert.Equal(uint(17), tokenizer.CountConversation(h, conversation))
+
+	assert.Len(tokenizer.TruncateConversation(h, conversation, 100), 4)
+	assert.Len(tokenizer.TruncateConversation(h, conversation, 11), 3)
+
+	// The tool result is dropped when its call does not fit
+	result := tokenizer.TruncateConversation(h, conversation, 8)
+	if assert.Len(result, 1) {
+		assert.Equal("It is raining.", result[0].Text())
+	}
+
+	// The last message is always kept
+	assert.Len(tokenizer.TruncateConversation(h, conversation, 0), 1)
+}
@@ -0,0 +1,96 @@
+package tooldoc
+
+import (
+	"bufio"
+	"fmt"
+	"html"
+	"io"
+	"strings"
+
+	// Packages
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+// Style sheet for the documentation, which has no external resources
+const htmlStyle = `body{font-family:system-ui,sans-serif;max-width:56em;margin:2em auto;padding:0 1em;line-height:1.5;color:#222}
+.details{color:#666;font-size:.85em}
+.tool{margin-top:2em}
+code{font-size:.9em}
+table{border-collapse:collapse}
+th,td{border:1px solid #ccc;padding:.2em .5em;text-align:left;vertical-align:top}`
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// WriteHTML writes documentation for the tools as a single HTML page, with
+// a list of contents and a section for each tool in the order given.
+func WriteHTML(w io.Writer, tools []*schema.ToolMeta) error {
+	doc := bufio.NewWriter(w)
+	fmt.Fprintf(doc, "<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n<title>Tools</title>\n<style>\n%s\n</style>\n</head>\n<body>\n", htmlStyle)
+	fmt.Fprintf(doc, "<h1>Tools</h1>\n<p class=\"details\">%s</p>\n", count(len(tools)))
+
+	// Contents
+	doc.WriteString("<ul>\n")
+	for _, tool := range tools {
+		if tool != nil {
+			fmt.Fprintf(doc, "<li><a href=\"#%s\">%s</a></li>\n", html.EscapeString(tool.Name), html.EscapeString(title(tool)))
+		}
+	}
+	doc.WriteString("</ul>\n")
+
+	// Tools
+	for _, tool := range tools {
+		if tool == nil {
+			continue
+		}
+		fmt.Fprintf(doc, "<div class=\"tool\" id=\"%s\">\n<h2>%s</h2>\n", html.EscapeString(tool.Name), html.EscapeString(title(tool)))
+		if description := strings.TrimSpace(tool.Description); description != "" {
+			for paragraph := range strings.SplitSeq(description, "\n\n") {
+				fmt.Fprintf(doc, "<p>%s</p>\n", html.EscapeString(strings.TrimSpace(paragraph)))
+			}
+		}
+		if len(tool.Hints) > 0 {
+			fmt.Fprintf(doc, "<p class=\"details\">Hints: %s</p>\n", html.EscapeString(strings.Join(tool.Hints, ", ")))
+		}
+		if err := htmlParameters(doc, "Input", tool.Input, "No input."); err != nil {
+			return fmt.Errorf("%s: %w", tool.Name, err)
+		}
+		if len(tool.Output) > 0 {
+			if err := htmlParameters(doc, "Output", tool.Output, "No structured output."); err != nil {
+				return fmt.Errorf("%s: %w", tool.Name, err)
+			}
+		}
+		doc.WriteString("</div>\n")
+	}
+
+	doc.WriteString("</body>\n</html>\n")
+	return doc.Flush()
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+func htmlParameters(doc *bufio.Writer, heading string, data schema.JSONSchema, empty string) error {
+	params, err := parameters(data)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(doc, "<h3>%s</h3>\n", heading)
+	if len(params) == 0 {
+		fmt.Fprintf(doc, "<p>%s</p>\n", empty)
+		return nil
+	}
+	doc.WriteString("<table>\n<tr><th>Name</th><th>Type</th><th>Required</th><th>Description</th></tr>\n")
+	for _, param := range params {
+		name := "-"
+		if param.Name != "" {
+			name = "<code>" + html.EscapeString(param.Name) + "</code>"
+		}
+		fmt.Fprintf(doc, "<tr><td>%s</td><td>%s</td><td>%s</td><td>%s</td></tr>\n", name, html.EscapeString(param.Type), required(param.Required), html.EscapeString(param.Description))
+	}
+	doc.WriteString("</table>\n")
+	return nil
+}
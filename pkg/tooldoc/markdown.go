@@ -0,0 +1,87 @@
+package tooldoc
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	// Packages
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+// Characters which are escaped in a markdown table cell
+var markdownCell = strings.NewReplacer("|", `\|`, "\r", " ", "\n", " ")
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// WriteMarkdown writes documentation for the tools as a markdown document,
+// with a section for each tool in the order given.
+func WriteMarkdown(w io.Writer, tools []*schema.ToolMeta) error {
+	doc := bufio.NewWriter(w)
+	fmt.Fprintf(doc, "# Tools\n\n%s\n", count(len(tools)))
+	for _, tool := range tools {
+		if tool == nil {
+			continue
+		}
+		fmt.Fprintf(doc, "\n## %s\n\n", title(tool))
+		if description := strings.TrimSpace(tool.Description); description != "" {
+			fmt.Fprintf(doc, "%s\n\n", description)
+		}
+		if len(tool.Hints) > 0 {
+			fmt.Fprintf(doc, "Hints: %s\n\n", strings.Join(tool.Hints, ", "))
+		}
+		if err := markdownParameters(doc, "Input", tool.Input, "No input."); err != nil {
+			return fmt.Errorf("%s: %w", tool.Name, err)
+		}
+		if len(tool.Output) > 0 {
+			if err := markdownParameters(doc, "Output", tool.Output, "No structured output."); err != nil {
+				return fmt.Errorf("%s: %w", tool.Name, err)
+			}
+		}
+	}
+	return doc.Flush()
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+func markdownParameters(doc *bufio.Writer, heading string, data schema.JSONSchema, empty string) error {
+	params, err := parameters(data)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(doc, "### %s\n\n", heading)
+	if len(params) == 0 {
+		fmt.Fprintf(doc, "%s\n\n", empty)
+		return nil
+	}
+	doc.WriteString("| Name | Type | Required | Description |\n| --- | --- | --- | --- |\n")
+	for _, param := range params {
+		name := "-"
+		if param.Name != "" {
+			name = "`" + param.Name + "`"
+		}
+		fmt.Fprintf(doc, "| %s | %s | %s | %s |\n", markdownCell.Replace(name), markdownCell.Replace(param.Type), required(param.Required), markdownCell.Replace(param.Description))
+	}
+	doc.WriteString("\n")
+	return nil
+}
+
+func required(v bool) string {
+	if v {
+		return "yes"
+	}
+	return "no"
+}
+
+func count(n int) string {
+	if n == 1 {
+		return "1 tool."
+	}
+	return fmt.Sprintf("%d tools.", n)
+}
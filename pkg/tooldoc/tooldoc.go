@@ -0,0 +1,188 @@
+// Package tooldoc writes documentation for tools from their names,
+// descriptions and schemas, so that people can read what an agent is able to
+// do. The input and output of each tool are shown as a table of parameters,
+// with nested object properties flattened into dotted names.
+package tooldoc
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	// Packages
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// parameter is a single property of a tool input or output
+type parameter struct {
+	Name        string
+	Type        string
+	Required    bool
+	Description string
+}
+
+// jsonSchema is the part of a JSON schema which is documented
+type jsonSchema struct {
+	Type        any                    `json:"type"`
+	Description string                 `json:"description"`
+	Properties  map[string]*jsonSchema `json:"properties"`
+	Required    []string               `json:"required"`
+	Items       *jsonSchema            `json:"items"`
+	Enum        []any                  `json:"enum"`
+	Default     any                    `json:"default"`
+	Format      string                 `json:"format"`
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+// Nested object properties deeper than this are not documented
+const maxDepth = 4
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// Write writes documentation for the tools in the given format, which is
+// schema.ExportMarkdown or schema.ExportHTML
+func Write(format string, w io.Writer, tools []*schema.ToolMeta) error {
+	switch strings.ToLower(strings.TrimSpace(format)) {
+	case schema.ExportMarkdown:
+		return WriteMarkdown(w, tools)
+	case schema.ExportHTML:
+		return WriteHTML(w, tools)
+	default:
+		return schema.ErrBadParameter.Withf("unsupported documentation format %q", format)
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// parameters returns the properties of an object schema, with required
+// properties first. A schema which is not an object is returned as a single
+// unnamed parameter, and an empty schema returns nil.
+func parameters(data schema.JSONSchema) ([]parameter, error) {
+	if len(data) == 0 {
+		return nil, nil
+	}
+	var s jsonSchema
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, schema.ErrBadParameter.Withf("invalid schema: %v", err)
+	}
+	if len(s.Properties) == 0 {
+		if schemaType(&s) == "object" {
+			return nil, nil
+		}
+		return []parameter{{Type: schemaType(&s), Required: true, Description: description(&s)}}, nil
+	}
+	return properties(nil, "", &s, 0), nil
+}
+
+func properties(result []parameter, prefix string, s *jsonSchema, depth int) []parameter {
+	names := make([]string, 0, len(s.Properties))
+	for name := range s.Properties {
+		names = append(names, name)
+	}
+	slices.SortFunc(names, func(a, b string) int {
+		if ra, rb := slices.Contains(s.Required, a), slices.Contains(s.Required, b); ra != rb {
+			if ra {
+				return -1
+			}
+			return 1
+		}
+		return strings.Compare(a, b)
+	})
+	for _, name := range names {
+		property := s.Properties[name]
+		if property == nil {
+			continue
+		}
+		result = append(result, parameter{
+			Name:        prefix + name,
+			Type:        schemaType(property),
+			Required:    slices.Contains(s.Required, name),
+			Description: description(property),
+		})
+
+		// Document the properties of nested objects, and arrays of objects
+		if depth >= maxDepth {
+			continue
+		}
+		if len(property.Properties) > 0 {
+			result = properties(result, prefix+name+".", property, depth+1)
+		} else if property.Items != nil && len(property.Items.Properties) > 0 {
+			result = properties(result, prefix+name+"[].", property.Items, depth+1)
+		}
+	}
+	return result
+}
+
+// schemaType returns the type of a schema, such as "string" or
+// "array of integer"
+func schemaType(s *jsonSchema) string {
+	var result string
+	switch t := s.Type.(type) {
+	case string:
+		result = t
+	case []any:
+		types := make([]string, 0, len(t))
+		for _, v := range t {
+			if v, ok := v.(string); ok && v != "null" {
+				types = append(types, v)
+			}
+		}
+		result = strings.Join(types, " or ")
+	}
+	if result == "array" && s.Items != nil {
+		if items := schemaType(s.Items); items != "" {
+			result = "array of " + items
+		}
+	}
+	if s.Format != "" && result != "" {
+		result += " (" + s.Format + ")"
+	}
+	return result
+}
+
+// description returns the description of a schema, with the allowed and
+// default values
+func description(s *jsonSchema) string {
+	parts := []string{}
+	if text := strings.Join(strings.Fields(s.Description), " "); text != "" {
+		parts = append(parts, text)
+	}
+	if len(s.Enum) > 0 {
+		values := make([]string, 0, len(s.Enum))
+		for _, v := range s.Enum {
+			values = append(values, value(v))
+		}
+		parts = append(parts, "One of: "+strings.Join(values, ", ")+".")
+	}
+	if s.Default != nil {
+		parts = append(parts, "Default: "+value(s.Default)+".")
+	}
+	return strings.Join(parts, " ")
+}
+
+func value(v any) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	if data, err := json.Marshal(v); err == nil {
+		return string(data)
+	}
+	return fmt.Sprint(v)
+}
+
+// title returns the heading of a tool
+func title(tool *schema.ToolMeta) string {
+	if tool.Title != "" && tool.Title != tool.Name {
+		return tool.Title + " (" + tool.Name + ")"
+	}
+	return tool.Name
+}
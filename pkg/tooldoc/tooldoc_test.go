@@ -0,0 +1,71 @@
+package tooldoc_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	// Packages
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	tooldoc "github.com/mutablelogic/go-llm/pkg/tooldoc"
+	assert "github.com/stretchr/testify/assert"
+)
+
+var tools = []*schema.ToolMeta{
+	{
+		Name:        "builtin.weather",
+		Title:       "Weather",
+		Description: "Returns the weather for a city.",
+		Input: schema.JSONSchema(json.RawMessage(`{"type":"object","required":["city"],"properties":{
+			"units":{"type":"string","enum":["metric","imperial"],"default":"metric"},
+			"city":{"type":"string","description":"City | region"},
+			"when":{"type":"object","properties":{"day":{"type":"integer"}}},
+			"tags":{"type":"array","items":{"type":"string"}}
+		}}`)),
+		Output: schema.JSONSchema(json.RawMessage(`{"type":"object","properties":{"temperature":{"type":["number","null"]}}}`)),
+		Hints:  []string{"readonly"},
+	},
+	{
+		Name:        "builtin.clock",
+		Description: "Returns the <time>.",
+	},
+}
+
+func TestWriteMarkdown(t *testing.T) {
+	assert := assert.New(t)
+
+	var buf bytes.Buffer
+	assert.NoError(tooldoc.Write(schema.ExportMarkdown, &buf, tools))
+	doc := buf.String()
+	assert.Contains(doc, "2 tools.")
+	assert.Contains(doc, "## Weather (builtin.weather)")
+	assert.Contains(doc, "Hints: readonly")
+	assert.Contains(doc, "| `city` | string | yes | City \\| region |")
+	assert.Contains(doc, "| `units` | string | no | One of: metric, imperial. Default: metric. |")
+	assert.Contains(doc, "| `when.day` | integer | no |  |")
+	assert.Contains(doc, "| `tags` | array of string | no |  |")
+	assert.Contains(doc, "| `temperature` | number | no |  |")
+	assert.Contains(doc, "## builtin.clock\n\nReturns the <time>.\n\n### Input\n\nNo input.")
+	assert.Less(bytes.Index(buf.Bytes(), []byte("`city`")), bytes.Index(buf.Bytes(), []byte("`tags`")))
+}
+
+func TestWriteHTML(t *testing.T) {
+	assert := assert.New(t)
+
+	var buf bytes.Buffer
+	assert.NoError(tooldoc.Write(schema.ExportHTML, &buf, tools))
+	doc := buf.String()
+	assert.Contains(doc, `<a href="#builtin.weather">Weather (builtin.weather)</a>`)
+	assert.Contains(doc, `<div class="tool" id="builtin.clock">`)
+	assert.Contains(doc, "<p>Returns the &lt;time&gt;.</p>")
+	assert.Contains(doc, "<tr><td><code>city</code></td><td>string</td><td>yes</td><td>City | region</td></tr>")
+	assert.NotContains(doc, "<time>")
+}
+
+func TestWriteErrors(t *testing.T) {
+	assert := assert.New(t)
+
+	var buf bytes.Buffer
+	assert.ErrorIs(tooldoc.Write("pdf", &buf, tools), schema.ErrBadParameter)
+	assert.Error(tooldoc.WriteMarkdown(&buf, []*schema.ToolMeta{{Name: "bad", Input: schema.JSONSchema(`{`)}}))
+}
@@ -0,0 +1,215 @@
+package transcript
+
+import (
+	"encoding/json"
+	"io"
+	"math"
+	"slices"
+	"strings"
+	"time"
+
+	// Packages
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// chatgptConversation is an entry in a ChatGPT conversations.json export.
+// Messages form a tree, as edited prompts and regenerated responses create
+// branches, and the current node is the leaf of the visible branch.
+type chatgptConversation struct {
+	Title       string                  `json:"title"`
+	CreateTime  float64                 `json:"create_time"`
+	UpdateTime  float64                 `json:"update_time"`
+	Mapping     map[string]*chatgptNode `json:"mapping"`
+	CurrentNode string                  `json:"current_node"`
+}
+
+type chatgptNode struct {
+	ID       string          `json:"id"`
+	Message  *chatgptMessage `json:"message"`
+	Parent   string          `json:"parent"`
+	Children []string        `json:"children"`
+}
+
+type chatgptMessage struct {
+	Author struct {
+		Role string `json:"role"`
+	} `json:"author"`
+	CreateTime *float64 `json:"create_time"`
+	Content    struct {
+		ContentType string            `json:"content_type"`
+		Parts       []json.RawMessage `json:"parts"`
+		Text        string            `json:"text"`
+		Language    string            `json:"language"`
+		Thoughts    []struct {
+			Summary string `json:"summary"`
+			Content string `json:"content"`
+		} `json:"thoughts"`
+	} `json:"content"`
+	Recipient string `json:"recipient"`
+	Metadata  struct {
+		IsHidden bool `json:"is_visually_hidden_from_conversation"`
+	} `json:"metadata"`
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+const (
+	chatgptContentText       = "text"
+	chatgptContentMultimodal = "multimodal_text"
+	chatgptContentCode       = "code"
+	chatgptContentThoughts   = "thoughts"
+	chatgptRecipientAll      = "all"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// ReadChatGPT decodes a ChatGPT conversations.json export. Only the visible
+// branch of each conversation is imported.
+func ReadChatGPT(r io.Reader) ([]*schema.ImportedSession, error) {
+	conversations, err := decode[chatgptConversation](r)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*schema.ImportedSession, 0, len(conversations))
+	for _, conversation := range conversations {
+		session := newSession(schema.ImportChatGPT, conversation.Title, chatgptTime(conversation.CreateTime), chatgptTime(conversation.UpdateTime))
+		for _, message := range conversation.branch() {
+			role, content := message.blocks()
+			var created time.Time
+			if message.CreateTime != nil {
+				created = chatgptTime(*message.CreateTime)
+			}
+			appendMessage(session, role, content, created)
+		}
+		if len(session.Messages) > 0 {
+			result = append(result, session)
+		}
+	}
+	return result, nil
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// branch returns the messages from the root of the conversation to the
+// current node. If the current node is not set, the branch follows the last
+// child of each node, which is the most recent edit or regeneration.
+func (c chatgptConversation) branch() []*chatgptMessage {
+	leaf := c.CurrentNode
+	if _, exists := c.Mapping[leaf]; !exists {
+		leaf = c.lastLeaf()
+	}
+
+	// Walk up the tree, guarding against cycles
+	var result []*chatgptMessage
+	seen := make(map[string]bool, len(c.Mapping))
+	for id := leaf; id != "" && !seen[id]; {
+		seen[id] = true
+		node, exists := c.Mapping[id]
+		if !exists || node == nil {
+			break
+		}
+		if node.Message != nil {
+			result = append(result, node.Message)
+		}
+		id = node.Parent
+	}
+	slices.Reverse(result)
+	return result
+}
+
+// lastLeaf follows the last child of each node from the root
+func (c chatgptConversation) lastLeaf() string {
+	var root string
+	for id, node := range c.Mapping {
+		if node != nil && node.Parent == "" {
+			root = id
+			break
+		}
+	}
+	seen := make(map[string]bool, len(c.Mapping))
+	for id := root; id != "" && !seen[id]; {
+		seen[id] = true
+		node, exists := c.Mapping[id]
+		if !exists || node == nil || len(node.Children) == 0 {
+			return id
+		}
+		id = node.Children[len(node.Children)-1]
+	}
+	return root
+}
+
+// blocks returns the role and content of a visible message, or no content
+// for system and hidden messages, tool invocations and tool output
+func (m *chatgptMessage) blocks() (string, []schema.ContentBlock) {
+	if m.Metadata.IsHidden {
+		return "", nil
+	}
+
+	var role string
+	switch m.Author.Role {
+	case schema.RoleUser:
+		role = schema.RoleUser
+	case schema.RoleAssistant:
+		if m.Recipient != "" && m.Recipient != chatgptRecipientAll {
+			return "", nil
+		}
+		role = schema.RoleAssistant
+	default:
+		return "", nil
+	}
+
+	var content []schema.ContentBlock
+	switch m.Content.ContentType {
+	case chatgptContentText, chatgptContentMultimodal:
+		var text []string
+		for _, part := range m.Content.Parts {
+			// Parts are either strings or objects such as image asset
+			// pointers, which reference files not included in the export
+			var s string
+			if err := json.Unmarshal(part, &s); err == nil && strings.TrimSpace(s) != "" {
+				text = append(text, s)
+			}
+		}
+		if block := textBlock(strings.Join(text, "\n")); block != nil {
+			content = append(content, *block)
+		}
+	case chatgptContentCode:
+		if strings.TrimSpace(m.Content.Text) != "" {
+			language := m.Content.Language
+			if language == "unknown" {
+				language = ""
+			}
+			content = append(content, *textBlock("```" + language + "\n" + strings.TrimRight(m.Content.Text, "\n") + "\n```"))
+		}
+	case chatgptContentThoughts:
+		var thoughts []string
+		for _, thought := range m.Content.Thoughts {
+			if text := strings.TrimSpace(thought.Content); text != "" {
+				thoughts = append(thoughts, text)
+			} else if text := strings.TrimSpace(thought.Summary); text != "" {
+				thoughts = append(thoughts, text)
+			}
+		}
+		if len(thoughts) > 0 {
+			text := strings.Join(thoughts, "\n\n")
+			content = append(content, schema.ContentBlock{Thinking: &text})
+		}
+	}
+	return role, content
+}
+
+// chatgptTime converts fractional seconds since the epoch to a time
+func chatgptTime(seconds float64) time.Time {
+	if seconds <= 0 {
+		return time.Time{}
+	}
+	whole, frac := math.Modf(seconds)
+	return time.Unix(int64(whole), int64(frac*float64(time.Second))).UTC()
+}
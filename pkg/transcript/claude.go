@@ -0,0 +1,129 @@
+package transcript
+
+import (
+	"io"
+	"net/url"
+	"strings"
+	"time"
+
+	// Packages
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	types "github.com/mutablelogic/go-server/pkg/types"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// claudeConversation is an entry in a Claude conversations.json export
+type claudeConversation struct {
+	Name         string          `json:"name"`
+	CreatedAt    time.Time       `json:"created_at"`
+	UpdatedAt    time.Time       `json:"updated_at"`
+	ChatMessages []claudeMessage `json:"chat_messages"`
+}
+
+type claudeMessage struct {
+	Sender    string    `json:"sender"`
+	Text      string    `json:"text"`
+	CreatedAt time.Time `json:"created_at"`
+	Content   []struct {
+		Type     string `json:"type"`
+		Text     string `json:"text"`
+		Thinking string `json:"thinking"`
+	} `json:"content"`
+	Attachments []struct {
+		FileName         string `json:"file_name"`
+		FileType         string `json:"file_type"`
+		ExtractedContent string `json:"extracted_content"`
+	} `json:"attachments"`
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+const (
+	claudeSenderHuman     = "human"
+	claudeSenderAssistant = "assistant"
+	claudeContentText     = "text"
+	claudeContentThinking = "thinking"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// ReadClaude decodes a Claude conversations.json export
+func ReadClaude(r io.Reader) ([]*schema.ImportedSession, error) {
+	conversations, err := decode[claudeConversation](r)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*schema.ImportedSession, 0, len(conversations))
+	for _, conversation := range conversations {
+		session := newSession(schema.ImportClaude, conversation.Name, conversation.CreatedAt, conversation.UpdatedAt)
+		for _, message := range conversation.ChatMessages {
+			role, content := message.blocks()
+			appendMessage(session, role, content, message.CreatedAt)
+		}
+		if len(session.Messages) > 0 {
+			result = append(result, session)
+		}
+	}
+	return result, nil
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// blocks returns the role and content of a message. Attachments come first,
+// as they were provided with the prompt, and the text extracted from them is
+// imported as a text attachment. Tool use and results are skipped.
+func (m claudeMessage) blocks() (string, []schema.ContentBlock) {
+	var role string
+	switch m.Sender {
+	case claudeSenderHuman:
+		role = schema.RoleUser
+	case claudeSenderAssistant:
+		role = schema.RoleAssistant
+	default:
+		return "", nil
+	}
+
+	var content []schema.ContentBlock
+	for _, attachment := range m.Attachments {
+		if strings.TrimSpace(attachment.ExtractedContent) == "" {
+			continue
+		}
+		block := schema.Attachment{
+			ContentType: "text/plain",
+			Data:        []byte(attachment.ExtractedContent),
+		}
+		if name := strings.TrimSpace(attachment.FileName); name != "" {
+			block.URL = types.Ptr(url.URL{Scheme: "file", Path: name})
+		}
+		content = append(content, schema.ContentBlock{Attachment: &block})
+	}
+
+	// Prefer the structured content, falling back to the plain text for
+	// older exports
+	var text bool
+	for _, block := range m.Content {
+		switch block.Type {
+		case claudeContentText:
+			if b := textBlock(block.Text); b != nil {
+				content = append(content, *b)
+				text = true
+			}
+		case claudeContentThinking:
+			if strings.TrimSpace(block.Thinking) != "" {
+				content = append(content, schema.ContentBlock{Thinking: types.Ptr(block.Thinking)})
+			}
+		}
+	}
+	if !text {
+		if b := textBlock(m.Text); b != nil {
+			content = append(content, *b)
+		}
+	}
+	return role, content
+}
@@ -0,0 +1,224 @@
+package transcript
+
+import (
+	"bufio"
+	"encoding/base64"
+	"fmt"
+	"html"
+	"io"
+	"strings"
+
+	// Packages
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	types "github.com/mutablelogic/go-server/pkg/types"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+// Style sheet for exported documents, which have no external resources
+const htmlStyle = `body{font-family:system-ui,sans-serif;max-width:48em;margin:2em auto;padding:0 1em;line-height:1.5;color:#222}
+.details{color:#666;font-size:.85em}
+.message{margin-top:1.5em}
+.role{font-weight:bold}
+.thinking{font-style:italic;color:#555}
+pre{background:#f4f4f4;padding:.5em;overflow-x:auto;font-size:.85em}
+table{border-collapse:collapse}
+th,td{border:1px solid #ccc;padding:.2em .5em}
+img{max-width:100%}`
+
+// Alignment of table columns
+var htmlAlign = map[string]string{
+	schema.AlignRight:  ` style="text-align:right"`,
+	schema.AlignCenter: ` style="text-align:center"`,
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// WriteHTML writes a session and its messages as a single HTML page. Fenced
+// code and display math are shown preformatted, tables are shown as tables,
+// inline images are embedded, and tool calls and results are included with
+// their arguments and output.
+func WriteHTML(w io.Writer, session *schema.Session, messages schema.Conversation) error {
+	title := strings.TrimSpace(types.Value(session.Title))
+	if title == "" {
+		title = "Session " + session.ID.String()
+	}
+	doc := bufio.NewWriter(w)
+
+	// Heading and session details
+	fmt.Fprintf(doc, "<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n<title>%s</title>\n<style>\n%s\n</style>\n</head>\n<body>\n", html.EscapeString(title), htmlStyle)
+	fmt.Fprintf(doc, "<h1>%s</h1>\n", html.EscapeString(title))
+	details := []string{"Session " + session.ID.String(), "Created " + session.CreatedAt.UTC().Format(pdfTimeFormat)}
+	if model := pdfModel(types.Value(session.Provider), types.Value(session.Model)); model != "" {
+		details = append(details, model)
+	}
+	fmt.Fprintf(doc, "<p class=\"details\">%s</p>\n", html.EscapeString(strings.Join(details, " · ")))
+	if len(session.Tags) > 0 {
+		fmt.Fprintf(doc, "<p class=\"details\">Tags: %s</p>\n", html.EscapeString(strings.Join(session.Tags, ", ")))
+	}
+
+	// Messages, with the role and time of each
+	for _, message := range messages {
+		if message == nil || len(message.Content) == 0 {
+			continue
+		}
+		role, exists := pdfRoles[message.Role]
+		if !exists {
+			role = message.Role
+		}
+		fmt.Fprintf(doc, "<div class=\"message\">\n<div class=\"role\">%s</div>\n", html.EscapeString(role))
+		details := []string{}
+		if !message.CreatedAt.IsZero() {
+			details = append(details, message.CreatedAt.UTC().Format(pdfTimeFormat))
+		}
+		if model := pdfModel(message.Provider, message.Model); model != "" {
+			details = append(details, model)
+		}
+		if len(details) > 0 {
+			fmt.Fprintf(doc, "<div class=\"details\">%s</div>\n", html.EscapeString(strings.Join(details, " · ")))
+		}
+		for _, block := range message.Content {
+			htmlBlock(doc, block)
+		}
+		doc.WriteString("</div>\n")
+	}
+
+	doc.WriteString("</body>\n</html>\n")
+	return doc.Flush()
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// htmlBlock writes a content block
+func htmlBlock(w io.Writer, block schema.ContentBlock) {
+	switch {
+	case block.Text != nil:
+		htmlMarkdown(w, *block.Text)
+	case block.Thinking != nil:
+		htmlParagraphs(w, "thinking", strings.TrimSpace(*block.Thinking))
+	case block.Attachment != nil:
+		htmlAttachment(w, block.Attachment)
+	case block.ToolCall != nil:
+		fmt.Fprintf(w, "<p class=\"details\">Tool call: %s</p>\n", html.EscapeString(block.ToolCall.Name))
+		if len(block.ToolCall.Input) > 0 {
+			htmlCode(w, pdfJSON(block.ToolCall.Input))
+		}
+	case block.ToolResult != nil:
+		label := "Tool result"
+		if name := block.ToolResult.Name; name != "" {
+			label += ": " + name
+		}
+		if block.ToolResult.IsError {
+			label += " (error)"
+		}
+		fmt.Fprintf(w, "<p class=\"details\">%s</p>\n", html.EscapeString(label))
+		if len(block.ToolResult.Content) > 0 {
+			htmlCode(w, pdfJSON(block.ToolResult.Content))
+		}
+	case block.Context != nil:
+		fmt.Fprintf(w, "<p class=\"details\">Context: %s</p>\n", html.EscapeString(block.Context.Name))
+		if len(block.Context.Content) > 0 {
+			htmlCode(w, pdfJSON(block.Context.Content))
+		}
+	}
+}
+
+// htmlMarkdown writes text, showing fenced code blocks preformatted
+func htmlMarkdown(w io.Writer, text string) {
+	var prose, code []string
+	var fenced bool
+	flush := func() {
+		htmlProse(w, strings.Join(prose, "\n"))
+		if len(code) > 0 {
+			htmlCode(w, strings.Join(code, "\n"))
+		}
+		prose, code = nil, nil
+	}
+	for _, line := range strings.Split(text, "\n") {
+		if trimmed := strings.TrimSpace(line); strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			flush()
+			fenced = !fenced
+			continue
+		}
+		if fenced {
+			code = append(code, line)
+		} else {
+			prose = append(prose, line)
+		}
+	}
+	flush()
+}
+
+// htmlProse writes text, showing tables as tables and the LaTeX source of
+// math set on its own line preformatted
+func htmlProse(w io.Writer, text string) {
+	var offset int
+	for _, markup := range schema.ParseMarkup([]schema.ContentBlock{{Text: &text}}) {
+		switch {
+		case markup.Type == schema.MarkupTable:
+			htmlParagraphs(w, "", text[offset:markup.Start])
+			htmlTable(w, markup)
+		case markup.Display:
+			htmlParagraphs(w, "", text[offset:markup.Start])
+			htmlCode(w, markup.Source)
+		default:
+			continue
+		}
+		offset = markup.End
+	}
+	htmlParagraphs(w, "", text[offset:])
+}
+
+// htmlParagraphs writes a paragraph for each run of lines separated by a
+// blank line, keeping the line breaks within a paragraph
+func htmlParagraphs(w io.Writer, class, text string) {
+	if class != "" {
+		class = ` class="` + class + `"`
+	}
+	for _, paragraph := range strings.Split(strings.Trim(text, "\n"), "\n\n") {
+		if paragraph = strings.Trim(paragraph, "\n"); strings.TrimSpace(paragraph) == "" {
+			continue
+		}
+		fmt.Fprintf(w, "<p%s>%s</p>\n", class, strings.ReplaceAll(html.EscapeString(paragraph), "\n", "<br>\n"))
+	}
+}
+
+// htmlCode writes preformatted text
+func htmlCode(w io.Writer, text string) {
+	fmt.Fprintf(w, "<pre><code>%s</code></pre>\n", html.EscapeString(text))
+}
+
+// htmlTable writes a table with a header row
+func htmlTable(w io.Writer, table schema.Markup) {
+	row := func(cell string, cells []string) {
+		io.WriteString(w, "<tr>")
+		for i, value := range cells {
+			fmt.Fprintf(w, "<%s%s>%s</%s>", cell, htmlAlign[table.Align[i]], html.EscapeString(value), cell)
+		}
+		io.WriteString(w, "</tr>\n")
+	}
+	io.WriteString(w, "<table>\n")
+	row("th", table.Header)
+	for _, cells := range table.Rows {
+		row("td", cells)
+	}
+	io.WriteString(w, "</table>\n")
+}
+
+// htmlAttachment embeds an inline image, or describes any other attachment
+func htmlAttachment(w io.Writer, attachment *schema.Attachment) {
+	if strings.HasPrefix(attachment.ContentType, "image/") && !strings.Contains(attachment.ContentType, "svg") && len(attachment.Data) > 0 {
+		fmt.Fprintf(w, "<p><img src=\"data:%s;base64,%s\" alt=\"\"></p>\n", html.EscapeString(attachment.ContentType), base64.StdEncoding.EncodeToString(attachment.Data))
+		return
+	}
+	description := "Attachment: " + attachment.ContentType
+	if attachment.URL != nil {
+		description += " " + attachment.URL.String()
+	} else if len(attachment.Data) > 0 {
+		description += fmt.Sprintf(" (%d bytes)", len(attachment.Data))
+	}
+	fmt.Fprintf(w, "<p class=\"details\">%s</p>\n", html.EscapeString(description))
+}
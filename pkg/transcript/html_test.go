@@ -0,0 +1,64 @@
+package transcript_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	// Packages
+	uuid "github.com/google/uuid"
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	transcript "github.com/mutablelogic/go-llm/pkg/transcript"
+	types "github.com/mutablelogic/go-server/pkg/types"
+	assert "github.com/stretchr/testify/assert"
+)
+
+func TestWriteHTML(t *testing.T) {
+	assert := assert.New(t)
+
+	created := time.Date(2026, 3, 4, 5, 6, 0, 0, time.UTC)
+	session := &schema.Session{
+		ID:        uuid.MustParse("123e4567-e89b-12d3-a456-426614174000"),
+		CreatedAt: created,
+		SessionInsert: schema.SessionInsert{SessionMeta: schema.SessionMeta{
+			Title:         types.Ptr("Weather <today>"),
+			GeneratorMeta: schema.GeneratorMeta{Provider: types.Ptr("anthropic"), Model: types.Ptr("claude-sonnet-4-5")},
+		}},
+	}
+	messages := schema.Conversation{
+		{Role: schema.RoleUser, CreatedAt: created, Content: []schema.ContentBlock{
+			{Text: types.Ptr("What is the weather?\n<script>alert(1)</script>")},
+			{Attachment: &schema.Attachment{ContentType: "image/png", Data: []byte{1, 2, 3}}},
+		}},
+		{Role: schema.RoleAssistant, Model: "claude-sonnet-4-5", Content: []schema.ContentBlock{
+			{ToolCall: &schema.ToolCall{Name: "get_weather", Input: json.RawMessage(`{"city":"London"}`)}},
+		}},
+		{Role: schema.RoleTool, Content: []schema.ContentBlock{
+			{ToolResult: &schema.ToolResult{Name: "get_weather", Content: json.RawMessage(`"Sunny"`), IsError: true}},
+		}},
+		{Role: schema.RoleAssistant, Content: []schema.ContentBlock{
+			{Text: types.Ptr("It is sunny.\n```go\nfmt.Println(\"sunny\")\n```\nEnjoy!\n\n| City | Temp |\n|---|--:|\n| London | 18 |\n\n$$T = 18 \\pm 2$$")},
+		}},
+	}
+
+	var buf bytes.Buffer
+	if !assert.NoError(transcript.Write(schema.ExportHTML, &buf, session, messages)) {
+		return
+	}
+	content := buf.String()
+	assert.Contains(content, "<title>Weather &lt;today&gt;</title>")
+	assert.Contains(content, "4 Mar 2026 05:06 UTC · anthropic/claude-sonnet-4-5")
+	assert.Contains(content, `<div class="role">User</div>`)
+	assert.Contains(content, "<p>What is the weather?<br>\n&lt;script&gt;alert(1)&lt;/script&gt;</p>")
+	assert.NotContains(content, "<script>")
+	assert.Contains(content, `<img src="data:image/png;base64,AQID" alt="">`)
+	assert.Contains(content, "Tool call: get_weather")
+	assert.Contains(content, "&#34;city&#34;: &#34;London&#34;")
+	assert.Contains(content, "Tool result: get_weather (error)")
+	assert.Contains(content, "<pre><code>fmt.Println(&#34;sunny&#34;)</code></pre>")
+	assert.Contains(content, "<p>Enjoy!</p>")
+	assert.Contains(content, "<tr><th>City</th><th style=\"text-align:right\">Temp</th></tr>")
+	assert.Contains(content, "<tr><td>London</td><td style=\"text-align:right\">18</td></tr>")
+	assert.Contains(content, `<pre><code>T = 18 \pm 2</code></pre>`)
+}
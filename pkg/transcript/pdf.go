@@ -0,0 +1,258 @@
+package transcript
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	// Packages
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	pdf "github.com/mutablelogic/go-llm/pkg/pdf"
+	types "github.com/mutablelogic/go-server/pkg/types"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+// Layout of times in exported documents, which are shown in UTC
+const pdfTimeFormat = "2 Jan 2006 15:04 MST"
+
+// Headings for each message role
+var pdfRoles = map[string]string{
+	schema.RoleUser:      "User",
+	schema.RoleAssistant: "Assistant",
+	schema.RoleSystem:    "System",
+	schema.RoleThinking:  "Thinking",
+	schema.RoleTool:      "Tool",
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// WritePDF writes a session and its messages as a PDF document. Fenced code,
+// tables and display math in text are shown in a fixed pitch font, inline
+// images are embedded, and tool calls and results are included with their
+// arguments and output.
+func WritePDF(w io.Writer, session *schema.Session, messages schema.Conversation) error {
+	title := strings.TrimSpace(types.Value(session.Title))
+	if title == "" {
+		title = "Session " + session.ID.String()
+	}
+	doc := pdf.New(title)
+
+	// Heading and session details
+	doc.Text(pdf.Heading, title)
+	details := []string{"Session " + session.ID.String(), "Created " + session.CreatedAt.UTC().Format(pdfTimeFormat)}
+	if model := pdfModel(types.Value(session.Provider), types.Value(session.Model)); model != "" {
+		details = append(details, model)
+	}
+	doc.Text(pdf.Small, strings.Join(details, " · "))
+	if len(session.Tags) > 0 {
+		doc.Text(pdf.Small, "Tags: "+strings.Join(session.Tags, ", "))
+	}
+
+	// Messages, with the role and time of each
+	for _, message := range messages {
+		if message == nil || len(message.Content) == 0 {
+			continue
+		}
+		doc.Space(12)
+		role, exists := pdfRoles[message.Role]
+		if !exists {
+			role = message.Role
+		}
+		doc.Text(pdf.Bold, role)
+		details := []string{}
+		if !message.CreatedAt.IsZero() {
+			details = append(details, message.CreatedAt.UTC().Format(pdfTimeFormat))
+		}
+		if model := pdfModel(message.Provider, message.Model); model != "" {
+			details = append(details, model)
+		}
+		if len(details) > 0 {
+			doc.Text(pdf.Small, strings.Join(details, " · "))
+		}
+		for _, block := range message.Content {
+			pdfBlock(doc, block)
+		}
+	}
+
+	_, err := doc.WriteTo(w)
+	return err
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// pdfBlock adds a content block to the document
+func pdfBlock(doc *pdf.Document, block schema.ContentBlock) {
+	switch {
+	case block.Text != nil:
+		pdfMarkdown(doc, *block.Text)
+	case block.Thinking != nil:
+		doc.Text(pdf.Italic, strings.TrimSpace(*block.Thinking))
+	case block.Attachment != nil:
+		pdfAttachment(doc, block.Attachment)
+	case block.ToolCall != nil:
+		doc.Space(4)
+		doc.Text(pdf.Small, "Tool call: "+block.ToolCall.Name)
+		if len(block.ToolCall.Input) > 0 {
+			doc.Code(pdfJSON(block.ToolCall.Input))
+		}
+	case block.ToolResult != nil:
+		label := "Tool result"
+		if name := block.ToolResult.Name; name != "" {
+			label += ": " + name
+		}
+		if block.ToolResult.IsError {
+			label += " (error)"
+		}
+		doc.Space(4)
+		doc.Text(pdf.Small, label)
+		if len(block.ToolResult.Content) > 0 {
+			doc.Code(pdfJSON(block.ToolResult.Content))
+		}
+	case block.Context != nil:
+		doc.Space(4)
+		doc.Text(pdf.Small, "Context: "+block.Context.Name)
+		if len(block.Context.Content) > 0 {
+			doc.Code(pdfJSON(block.Context.Content))
+		}
+	}
+}
+
+// pdfMarkdown adds text, showing fenced code blocks in a fixed pitch font
+func pdfMarkdown(doc *pdf.Document, text string) {
+	var prose, code []string
+	var fenced bool
+	flush := func() {
+		pdfProse(doc, strings.Join(prose, "\n"))
+		if len(code) > 0 {
+			doc.Space(2)
+			doc.Code(strings.Join(code, "\n"))
+			doc.Space(2)
+		}
+		prose, code = nil, nil
+	}
+	for _, line := range strings.Split(text, "\n") {
+		if trimmed := strings.TrimSpace(line); strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			flush()
+			fenced = !fenced
+			continue
+		}
+		if fenced {
+			code = append(code, line)
+		} else {
+			prose = append(prose, line)
+		}
+	}
+	flush()
+}
+
+// pdfProse adds text, showing tables and math set on its own line in a fixed
+// pitch font. The standard fonts cannot show most math symbols, so the LaTeX
+// source is shown.
+func pdfProse(doc *pdf.Document, text string) {
+	paragraph := func(text string) {
+		if text := strings.Trim(text, "\n"); text != "" {
+			doc.Text(pdf.Body, text)
+		}
+	}
+	var offset int
+	for _, markup := range schema.ParseMarkup([]schema.ContentBlock{{Text: &text}}) {
+		switch {
+		case markup.Type == schema.MarkupTable:
+			paragraph(text[offset:markup.Start])
+			doc.Space(2)
+			doc.Code(pdfTable(markup))
+			doc.Space(2)
+		case markup.Display:
+			paragraph(text[offset:markup.Start])
+			doc.Space(2)
+			doc.Code(markup.Source)
+			doc.Space(2)
+		default:
+			continue
+		}
+		offset = markup.End
+	}
+	paragraph(text[offset:])
+}
+
+// pdfTable returns a table with its columns aligned and a rule under the header
+func pdfTable(table schema.Markup) string {
+	widths := make([]int, len(table.Header))
+	for _, row := range append([][]string{table.Header}, table.Rows...) {
+		for i, cell := range row {
+			widths[i] = max(widths[i], utf8.RuneCountInString(cell))
+		}
+	}
+	line := func(row []string) string {
+		cells := make([]string, len(row))
+		for i, cell := range row {
+			pad := widths[i] - utf8.RuneCountInString(cell)
+			switch table.Align[i] {
+			case schema.AlignRight:
+				cells[i] = strings.Repeat(" ", pad) + cell
+			case schema.AlignCenter:
+				cells[i] = strings.Repeat(" ", pad/2) + cell + strings.Repeat(" ", pad-pad/2)
+			default:
+				cells[i] = cell + strings.Repeat(" ", pad)
+			}
+		}
+		return strings.TrimRight(strings.Join(cells, "  "), " ")
+	}
+	rules := make([]string, len(widths))
+	for i, width := range widths {
+		rules[i] = strings.Repeat("-", width)
+	}
+	lines := []string{line(table.Header), strings.Join(rules, "  ")}
+	for _, row := range table.Rows {
+		lines = append(lines, line(row))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// pdfAttachment embeds an inline image, or describes any other attachment
+func pdfAttachment(doc *pdf.Document, attachment *schema.Attachment) {
+	if strings.HasPrefix(attachment.ContentType, "image/") && len(attachment.Data) > 0 {
+		if err := doc.Image(attachment.Data); err == nil {
+			return
+		}
+	}
+	description := "Attachment: " + attachment.ContentType
+	if attachment.URL != nil {
+		description += " " + attachment.URL.String()
+	} else if len(attachment.Data) > 0 {
+		description += fmt.Sprintf(" (%d bytes)", len(attachment.Data))
+	}
+	doc.Text(pdf.Small, description)
+}
+
+// pdfJSON returns JSON indented, or the value of a JSON string
+func pdfJSON(data json.RawMessage) string {
+	var text string
+	if err := json.Unmarshal(data, &text); err == nil {
+		return text
+	}
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, data, "", "  "); err != nil {
+		return string(data)
+	}
+	return buf.String()
+}
+
+// pdfModel returns the provider and model, when known
+func pdfModel(provider, model string) string {
+	switch {
+	case model == "":
+		return ""
+	case provider == "":
+		return model
+	default:
+		return provider + "/" + model
+	}
+}
@@ -0,0 +1,101 @@
+package transcript_test
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/json"
+	"image"
+	"image/png"
+	"io"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	// Packages
+	uuid "github.com/google/uuid"
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	transcript "github.com/mutablelogic/go-llm/pkg/transcript"
+	types "github.com/mutablelogic/go-server/pkg/types"
+	assert "github.com/stretchr/testify/assert"
+)
+
+// pdfContent returns the decompressed page contents of a PDF document
+func pdfContent(t *testing.T, data []byte) string {
+	t.Helper()
+	var content strings.Builder
+	for _, stream := range regexp.MustCompile(`<< /Filter /FlateDecode /Length (\d+) >>\nstream\n`).FindAllSubmatchIndex(data, -1) {
+		length, _ := strconv.Atoi(string(data[stream[2]:stream[3]]))
+		r, err := zlib.NewReader(bytes.NewReader(data[stream[1] : stream[1]+length]))
+		if !assert.NoError(t, err) {
+			return ""
+		}
+		page, err := io.ReadAll(r)
+		assert.NoError(t, err)
+		content.Write(page)
+	}
+	return content.String()
+}
+
+func TestWritePDF(t *testing.T) {
+	assert := assert.New(t)
+
+	var img bytes.Buffer
+	assert.NoError(png.Encode(&img, image.NewGray(image.Rect(0, 0, 8, 8))))
+	created := time.Date(2026, 3, 4, 5, 6, 0, 0, time.UTC)
+	session := &schema.Session{
+		ID:        uuid.MustParse("123e4567-e89b-12d3-a456-426614174000"),
+		CreatedAt: created,
+		SessionInsert: schema.SessionInsert{SessionMeta: schema.SessionMeta{
+			Title:         types.Ptr("Weather"),
+			GeneratorMeta: schema.GeneratorMeta{Provider: types.Ptr("anthropic"), Model: types.Ptr("claude-sonnet-4-5")},
+		}},
+	}
+	messages := schema.Conversation{
+		{Role: schema.RoleUser, CreatedAt: created, Content: []schema.ContentBlock{
+			{Text: types.Ptr("What is the weather?")},
+			{Attachment: &schema.Attachment{ContentType: "image/png", Data: img.Bytes()}},
+			{Attachment: &schema.Attachment{ContentType: "application/pdf", URL: &url.URL{Scheme: "https", Host: "example.com", Path: "/a.pdf"}}},
+		}},
+		{Role: schema.RoleAssistant, Model: "claude-sonnet-4-5", Content: []schema.ContentBlock{
+			{ToolCall: &schema.ToolCall{Name: "get_weather", Input: json.RawMessage(`{"city":"London"}`)}},
+		}},
+		{Role: schema.RoleTool, Content: []schema.ContentBlock{
+			{ToolResult: &schema.ToolResult{Name: "get_weather", Content: json.RawMessage(`"Sunny"`), IsError: true}},
+		}},
+		{Role: schema.RoleAssistant, Content: []schema.ContentBlock{
+			{Text: types.Ptr("It is sunny.\n```go\nfmt.Println(\"sunny\")\n```\nEnjoy!\n\n| City | Temp |\n|---|--:|\n| London | 18 |\n| Rome | 7 |\n\n$$T = 18 \\pm 2$$\nAt $t_0$.")},
+		}},
+	}
+
+	var buf bytes.Buffer
+	if !assert.NoError(transcript.Write(schema.ExportPDF, &buf, session, messages)) {
+		return
+	}
+	assert.True(bytes.HasPrefix(buf.Bytes(), []byte("%PDF-")))
+	assert.Contains(buf.String(), "/Subtype /Image /Width 8 /Height 8")
+
+	content := pdfContent(t, buf.Bytes())
+	assert.Contains(content, "(Weather)")
+	assert.Contains(content, "4 Mar 2026 05:06 UTC \xb7 anthropic/claude-sonnet-4-5")
+	assert.Contains(content, "(User)")
+	assert.Contains(content, "(What is the weather?)")
+	assert.Contains(content, "(Attachment: application/pdf https://example.com/a.pdf)")
+	assert.Contains(content, "(Tool call: get_weather)")
+	assert.Contains(content, `(  "city": "London")`)
+	assert.Contains(content, "(Tool result: get_weather \\(error\\))")
+	assert.Contains(content, "(Sunny)")
+	assert.Contains(content, "/F4 8.5 Tf 60 ")
+	assert.Contains(content, `(fmt.Println\("sunny"\))`)
+	assert.Contains(content, "(Enjoy!)")
+	assert.Contains(content, "(City    Temp)")
+	assert.Contains(content, "(------  ----)")
+	assert.Contains(content, "(Rome       7)")
+	assert.Contains(content, `(T = 18 \\pm 2)`)
+	assert.Contains(content, "(At $t_0$.)")
+
+	// Unsupported formats are rejected
+	assert.ErrorIs(transcript.Write("docx", io.Discard, session, messages), schema.ErrBadParameter)
+}
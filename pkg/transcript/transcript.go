@@ -0,0 +1,112 @@
+// Package transcript reads conversations exported from other chat tools, such
+// as ChatGPT and Claude, and converts them into sessions which can be stored.
+// It also writes stored sessions as documents, for sharing transcripts with
+// people who do not use the API.
+//
+// Only the visible conversation is imported: hidden system messages, tool
+// invocations and their outputs are skipped. Exports do not contain the
+// binary content of uploaded files, so only text extracted from attachments
+// is imported.
+package transcript
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+	"time"
+
+	// Packages
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	types "github.com/mutablelogic/go-server/pkg/types"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// Read decodes exported conversations in the given format, which is one of
+// schema.ImportChatGPT or schema.ImportClaude. Conversations without any
+// messages are skipped.
+func Read(format string, r io.Reader) ([]*schema.ImportedSession, error) {
+	switch strings.ToLower(strings.TrimSpace(format)) {
+	case schema.ImportChatGPT:
+		return ReadChatGPT(r)
+	case schema.ImportClaude:
+		return ReadClaude(r)
+	default:
+		return nil, schema.ErrBadParameter.Withf("unsupported import format %q", format)
+	}
+}
+
+// Write writes a session and its messages in the given format, which is
+// schema.ExportPDF or schema.ExportHTML
+func Write(format string, w io.Writer, session *schema.Session, messages schema.Conversation) error {
+	switch strings.ToLower(strings.TrimSpace(format)) {
+	case schema.ExportPDF:
+		return WritePDF(w, session, messages)
+	case schema.ExportHTML:
+		return WriteHTML(w, session, messages)
+	default:
+		return schema.ErrBadParameter.Withf("unsupported export format %q", format)
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// decode reads a JSON array of conversations
+func decode[T any](r io.Reader) ([]T, error) {
+	var result []T
+	if err := json.NewDecoder(r).Decode(&result); err != nil {
+		return nil, schema.ErrBadParameter.Withf("invalid export: %v", err)
+	}
+	return result, nil
+}
+
+// newSession returns a session with a title and the tag which identifies the
+// import format
+func newSession(format, title string, created, modified time.Time) *schema.ImportedSession {
+	session := &schema.ImportedSession{
+		CreatedAt:  created,
+		ModifiedAt: modified,
+	}
+	if title = strings.TrimSpace(title); title != "" {
+		session.Title = types.Ptr(title)
+	}
+	session.Tags = []string{schema.ImportTagPrefix + format}
+	return session
+}
+
+// appendMessage adds a message to the session, unless it has no content.
+// Consecutive messages with the same role are merged into a single turn, and
+// the token count is estimated so that the imported history can be compacted.
+func appendMessage(session *schema.ImportedSession, role string, content []schema.ContentBlock, created time.Time) {
+	if len(content) == 0 {
+		return
+	}
+	if n := len(session.Messages); n > 0 && session.Messages[n-1].Role == role {
+		last := &session.Messages[n-1]
+		last.Content = append(last.Content, content...)
+		last.Tokens = last.EstimateTokens()
+		return
+	}
+	message := schema.MessageInsert{
+		Message: schema.Message{
+			Role:      role,
+			Content:   content,
+			CreatedAt: created,
+		},
+	}
+	if role == schema.RoleAssistant {
+		message.Result = schema.ResultStop
+	}
+	message.Tokens = message.EstimateTokens()
+	session.Messages = append(session.Messages, message)
+}
+
+// textBlock returns a text content block, or nil if the text is empty
+func textBlock(text string) *schema.ContentBlock {
+	if strings.TrimSpace(text) == "" {
+		return nil
+	}
+	return &schema.ContentBlock{Text: types.Ptr(text)}
+}
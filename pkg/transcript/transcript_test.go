@@ -0,0 +1,115 @@
+package transcript_test
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	// Packages
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	transcript "github.com/mutablelogic/go-llm/pkg/transcript"
+	assert "github.com/stretchr/testify/assert"
+)
+
+func readTestdata(t *testing.T, format, path string) []*schema.ImportedSession {
+	t.Helper()
+	f, err := os.Open(path)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	defer f.Close()
+	sessions, err := transcript.Read(format, f)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	return sessions
+}
+
+func Test_transcript_001(t *testing.T) {
+	// Unsupported formats and invalid JSON are rejected
+	assert := assert.New(t)
+
+	_, err := transcript.Read("other", strings.NewReader("[]"))
+	assert.ErrorIs(err, schema.ErrBadParameter)
+
+	_, err = transcript.Read(schema.ImportChatGPT, strings.NewReader("{"))
+	assert.ErrorIs(err, schema.ErrBadParameter)
+}
+
+func Test_transcript_chatgpt_001(t *testing.T) {
+	// The visible branch is imported, skipping hidden, tool and empty messages
+	assert := assert.New(t)
+
+	sessions := readTestdata(t, schema.ImportChatGPT, "testdata/chatgpt.json")
+	if !assert.Len(sessions, 1) {
+		return
+	}
+	session := sessions[0]
+	if assert.NotNil(session.Title) {
+		assert.Equal("Weather in London", *session.Title)
+	}
+	assert.Equal([]string{"import:chatgpt"}, session.Tags)
+	assert.Equal(time.Unix(1700000000, 500000000).UTC(), session.CreatedAt)
+	assert.Equal(time.Unix(1700000100, 250000000).UTC(), session.ModifiedAt)
+
+	if !assert.Len(session.Messages, 2) {
+		return
+	}
+	user := session.Messages[0]
+	assert.Equal(schema.RoleUser, user.Role)
+	assert.Equal("What is the weather in London?", user.Text())
+	assert.Equal(time.Unix(1700000010, 0).UTC(), user.CreatedAt)
+	assert.NotZero(user.Tokens)
+
+	// Reasoning and the answer are merged into a single assistant turn
+	assistant := session.Messages[1]
+	assert.Equal(schema.RoleAssistant, assistant.Role)
+	assert.Equal(schema.ResultStop, assistant.Result)
+	if assert.Len(assistant.Content, 2) && assert.NotNil(assistant.Content[0].Thinking) {
+		assert.Equal("The user wants the weather.", *assistant.Content[0].Thinking)
+	}
+	assert.Equal("It is raining in London.", assistant.Text())
+	assert.Equal(time.Unix(1700000030, 0).UTC(), assistant.CreatedAt)
+}
+
+func Test_transcript_claude_001(t *testing.T) {
+	// Messages, thinking and extracted attachment text are imported
+	assert := assert.New(t)
+
+	sessions := readTestdata(t, schema.ImportClaude, "testdata/claude.json")
+	if !assert.Len(sessions, 1) {
+		return
+	}
+	session := sessions[0]
+	if assert.NotNil(session.Title) {
+		assert.Equal("Summarize a report", *session.Title)
+	}
+	assert.Equal([]string{"import:claude"}, session.Tags)
+	assert.Equal(time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC), session.CreatedAt)
+	assert.Equal(time.Date(2024, 5, 1, 10, 5, 0, 0, time.UTC), session.ModifiedAt)
+
+	if !assert.Len(session.Messages, 3) {
+		return
+	}
+	user := session.Messages[0]
+	assert.Equal(schema.RoleUser, user.Role)
+	if assert.Len(user.Content, 2) && assert.NotNil(user.Content[0].Attachment) {
+		attachment := user.Content[0].Attachment
+		assert.Equal("text/plain", attachment.ContentType)
+		assert.Equal("Sales rose by 10 percent", string(attachment.Data))
+		assert.Equal("report.txt", attachment.Name())
+	}
+	assert.Equal("Please summarize this report", user.Text())
+
+	assistant := session.Messages[1]
+	assert.Equal(schema.RoleAssistant, assistant.Role)
+	if assert.Len(assistant.Content, 2) && assert.NotNil(assistant.Content[0].Thinking) {
+		assert.Equal("The report is short.", *assistant.Content[0].Thinking)
+	}
+	assert.Equal("Sales rose by ten percent.", assistant.Text())
+	assert.Equal(time.Date(2024, 5, 1, 10, 0, 5, 0, time.UTC), assistant.CreatedAt)
+
+	// Older exports have no structured content
+	assert.Equal("Thanks", session.Messages[2].Text())
+}
@@ -7,6 +7,7 @@ import (
 	// Packages
 	glamour "github.com/charmbracelet/glamour"
 	termenv "github.com/muesli/termenv"
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
 )
 
 ///////////////////////////////////////////////////////////////////////////////
@@ -16,6 +17,15 @@ type markdown struct {
 	renderer *glamour.TermRenderer
 }
 
+///////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+// Characters which are escaped in math, so they are not read as markdown
+var markdownEscaper = strings.NewReplacer(
+	"\\", "\\\\", "*", "\\*", "_", "\\_", "`", "\\`", "[", "\\[", "]", "\\]",
+	"<", "\\<", ">", "\\>", "|", "\\|",
+)
+
 ///////////////////////////////////////////////////////////////////////////////
 // LIFECYCLE
 
@@ -77,10 +87,32 @@ func renderMarkdown(renderer *glamour.TermRenderer, text string) (string, error)
 		return text, nil
 	}
 
-	out, err := renderer.Render(text)
+	out, err := renderer.Render(markdownMath(text))
 	if err != nil {
 		return text, err
 	}
 
 	return strings.TrimSpace(out), nil
 }
+
+// markdownMath replaces LaTeX math with Unicode text, which the terminal can
+// show. Math which is set on its own line becomes a paragraph, and math in
+// the cells of tables is replaced in place.
+func markdownMath(text string) string {
+	return schema.ReplaceMarkup(text, func(markup schema.Markup) string {
+		switch {
+		case markup.Type == schema.MarkupTable:
+			lines := strings.Split(markup.Source, "\n")
+			for i, line := range lines {
+				lines[i] = schema.ReplaceMarkup(line, func(markup schema.Markup) string {
+					return markdownEscaper.Replace(markup.Text)
+				})
+			}
+			return strings.Join(lines, "\n")
+		case markup.Display:
+			return "\n\n" + strings.ReplaceAll(markdownEscaper.Replace(markup.Text), "\n", "  \n") + "\n\n"
+		default:
+			return markdownEscaper.Replace(markup.Text)
+		}
+	})
+}
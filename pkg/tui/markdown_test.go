@@ -37,3 +37,14 @@ func TestMarkdownWriteEmpty(t *testing.T) {
 		t.Fatalf("expected empty output, got %q", got)
 	}
 }
+
+func TestMarkdownMath(t *testing.T) {
+	got := markdownMath("Where $x_i^2$ and\n$$a \\cdot b$$\n\n| A | B |\n|---|---|\n| $\\alpha$ | `$x$` |")
+	want := "Where xᵢ² and\n\n\na · b\n\n\n\n| A | B |\n|---|---|\n| α | `$x$` |"
+	if got != want {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+	if got := markdownMath("$a_{\\text{x y}}$"); got != "a\\_(x y)" {
+		t.Fatalf("expected escaped subscript, got %q", got)
+	}
+}
@@ -0,0 +1,219 @@
+package workspace
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	// Packages
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// FilePatch is the changes to one file in a unified diff. A file which is
+// created has an empty OldPath, and a file which is deleted has an empty
+// NewPath.
+type FilePatch struct {
+	OldPath string
+	NewPath string
+	Hunks   []Hunk
+}
+
+// Hunk is a change to a range of lines. Each line starts with a space for
+// context, - for a removed line or + for an added line.
+type Hunk struct {
+	OldStart, OldLines int
+	NewStart, NewLines int
+	Lines              []string
+
+	// The old or new side does not end with a newline
+	NoNewlineOld, NoNewlineNew bool
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+const devNull = "/dev/null"
+
+var reHunk = regexp.MustCompile(`^@@ -(\d+)(?:,(\d+))? \+(\d+)(?:,(\d+))? @@`)
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// ParsePatch returns the file changes in a unified diff, as written by diff
+// -u or git diff. Text before and between the changes to each file is
+// ignored.
+func ParsePatch(patch string) ([]FilePatch, error) {
+	var result []FilePatch
+	var file *FilePatch
+	var renameFrom, renameTo string
+	lines := strings.Split(strings.TrimSuffix(strings.ReplaceAll(patch, "\r\n", "\n"), "\n"), "\n")
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			file, renameFrom, renameTo = nil, "", ""
+		case strings.HasPrefix(line, "rename from "):
+			renameFrom = strings.TrimPrefix(line, "rename from ")
+		case strings.HasPrefix(line, "rename to "):
+			renameTo = strings.TrimPrefix(line, "rename to ")
+			if renameFrom != "" {
+				// A rename without changes has no hunks
+				result = append(result, FilePatch{OldPath: renameFrom, NewPath: renameTo})
+				file = &result[len(result)-1]
+			}
+		case strings.HasPrefix(line, "--- ") && i+1 < len(lines) && strings.HasPrefix(lines[i+1], "+++ "):
+			oldPath, newPath := parsePath(line[4:], "a/"), parsePath(lines[i+1][4:], "b/")
+			i++
+			if file != nil && renameTo != "" && file.OldPath == renameFrom {
+				// Hunks follow the rename header
+				continue
+			}
+			if oldPath == "" && newPath == "" {
+				return nil, schema.ErrBadParameter.Withf("line %d: both files are %s", i, devNull)
+			}
+			result = append(result, FilePatch{OldPath: oldPath, NewPath: newPath})
+			file = &result[len(result)-1]
+		case strings.HasPrefix(line, "@@"):
+			if file == nil {
+				return nil, schema.ErrBadParameter.Withf("line %d: hunk without a file header", i+1)
+			}
+			hunk, next, err := parseHunk(lines, i)
+			if err != nil {
+				return nil, err
+			}
+			file.Hunks = append(file.Hunks, hunk)
+			i = next - 1
+		}
+	}
+	if len(result) == 0 {
+		return nil, schema.ErrBadParameter.With("patch does not change any files")
+	}
+	return result, nil
+}
+
+// Path returns the path of the file after the patch, or before the patch if
+// the file is deleted
+func (f FilePatch) Path() string {
+	if f.NewPath != "" {
+		return f.NewPath
+	}
+	return f.OldPath
+}
+
+// Header returns the @@ line of the hunk
+func (h Hunk) Header() string {
+	return fmt.Sprintf("@@ -%d,%d +%d,%d @@", h.OldStart, h.OldLines, h.NewStart, h.NewLines)
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// parsePath returns the path of a --- or +++ line without a timestamp or the
+// prefix, or an empty string for /dev/null
+func parsePath(path, prefix string) string {
+	if i := strings.IndexByte(path, '\t'); i >= 0 {
+		path = path[:i]
+	}
+	path = strings.TrimSpace(path)
+	if path == devNull {
+		return ""
+	}
+	if unquoted, err := strconv.Unquote(path); err == nil {
+		path = unquoted
+	}
+	return strings.TrimPrefix(path, prefix)
+}
+
+// parseHunk returns the hunk which starts at line i, and the index of the
+// line after it. An empty line in a hunk is read as an empty context line,
+// since editors and models often strip the trailing space.
+func parseHunk(lines []string, i int) (Hunk, int, error) {
+	match := reHunk.FindStringSubmatch(lines[i])
+	if match == nil {
+		return Hunk{}, 0, schema.ErrBadParameter.Withf("line %d: invalid hunk header %q", i+1, lines[i])
+	}
+	hunk := Hunk{OldStart: atoi(match[1]), OldLines: 1, NewStart: atoi(match[3]), NewLines: 1}
+	if match[2] != "" {
+		hunk.OldLines = atoi(match[2])
+	}
+	if match[4] != "" {
+		hunk.NewLines = atoi(match[4])
+	}
+
+	// Read lines until both sides are complete
+	old, new := 0, 0
+	for i++; i < len(lines) && (old < hunk.OldLines || new < hunk.NewLines); i++ {
+		line := lines[i]
+		switch {
+		case line == "" || line[0] == ' ':
+			old, new = old+1, new+1
+			hunk.Lines = append(hunk.Lines, " "+strings.TrimPrefix(line, " "))
+		case line[0] == '-':
+			old++
+			hunk.Lines = append(hunk.Lines, line)
+		case line[0] == '+':
+			new++
+			hunk.Lines = append(hunk.Lines, line)
+		case line[0] == '\\':
+			hunk.markNoNewline()
+		default:
+			return Hunk{}, 0, schema.ErrBadParameter.Withf("line %d: unexpected line in hunk %q", i+1, line)
+		}
+	}
+	if old != hunk.OldLines || new != hunk.NewLines {
+		return Hunk{}, 0, schema.ErrBadParameter.Withf("hunk %s: expected %d old and %d new lines, got %d and %d", hunk.Header(), hunk.OldLines, hunk.NewLines, old, new)
+	}
+
+	// A marker for the last line
+	if i < len(lines) && strings.HasPrefix(lines[i], `\`) {
+		hunk.markNoNewline()
+		i++
+	}
+	return hunk, i, nil
+}
+
+// markNoNewline records that the previous line does not end with a newline
+func (h *Hunk) markNoNewline() {
+	if len(h.Lines) == 0 {
+		return
+	}
+	switch h.Lines[len(h.Lines)-1][0] {
+	case '-':
+		h.NoNewlineOld = true
+	case '+':
+		h.NoNewlineNew = true
+	default:
+		h.NoNewlineOld, h.NoNewlineNew = true, true
+	}
+}
+
+// old returns the lines of the old side of the hunk
+func (h Hunk) old() []string {
+	result := make([]string, 0, h.OldLines)
+	for _, line := range h.Lines {
+		if line[0] != '+' {
+			result = append(result, line[1:])
+		}
+	}
+	return result
+}
+
+// new returns the lines of the new side of the hunk
+func (h Hunk) new() []string {
+	result := make([]string, 0, h.NewLines)
+	for _, line := range h.Lines {
+		if line[0] != '-' {
+			result = append(result, line[1:])
+		}
+	}
+	return result
+}
+
+func atoi(s string) int {
+	n, _ := strconv.Atoi(s)
+	return n
+}
@@ -0,0 +1,74 @@
+package workspace
+
+import (
+	"context"
+	"encoding/json"
+
+	// Packages
+	llm "github.com/mutablelogic/go-llm"
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	tool "github.com/mutablelogic/go-llm/toolkit/tool"
+	jsonschema "github.com/mutablelogic/go-server/pkg/jsonschema"
+	types "github.com/mutablelogic/go-server/pkg/types"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+type patchTool struct {
+	tool.Base
+	*Workspace
+}
+
+type patchToolRequest struct {
+	Patch  string `json:"patch" help:"Unified diff to apply, with --- and +++ lines for each file and paths relative to the workspace. Use /dev/null as the old file to create a file, or as the new file to delete one."`
+	DryRun bool   `json:"dry_run,omitempty" help:"Check that the patch applies without changing any files"`
+}
+
+var _ llm.Tool = (*patchTool)(nil)
+
+///////////////////////////////////////////////////////////////////////////////
+// LIFECYCLE
+
+// NewTools returns tools which edit the files of the workspace
+func NewTools(root string) ([]llm.Tool, error) {
+	workspace, err := Open(root)
+	if err != nil {
+		return nil, err
+	}
+	return []llm.Tool{
+		&patchTool{Workspace: workspace},
+	}, nil
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// llm.Tool INTERFACE
+
+func (*patchTool) Name() string {
+	return "apply_patch"
+}
+
+func (*patchTool) Description() string {
+	return "Apply a unified diff to files in the workspace. Every hunk is checked before any file is changed, so either the whole patch applies or no files change. Returns whether each hunk applied, and the number of lines it moved from its header; when a hunk does not apply, read the file again and send a corrected patch."
+}
+
+func (*patchTool) InputSchema() *jsonschema.Schema {
+	return jsonschema.MustFor[patchToolRequest]()
+}
+
+func (*patchTool) Meta() llm.ToolMeta {
+	return llm.ToolMeta{Title: "Apply Patch", DestructiveHint: types.Ptr(true)}
+}
+
+func (t *patchTool) Run(ctx context.Context, input json.RawMessage) (any, error) {
+	var req patchToolRequest
+	if len(input) > 0 {
+		if err := json.Unmarshal(input, &req); err != nil {
+			return nil, schema.ErrBadParameter.Withf("failed to unmarshal input: %v", err)
+		}
+	}
+	if req.Patch == "" {
+		return nil, schema.ErrBadParameter.With("patch is required")
+	}
+	return t.Apply(req.Patch, req.DryRun)
+}
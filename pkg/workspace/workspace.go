@@ -0,0 +1,451 @@
+/*
+workspace edits files within a root directory, for tools which change code.
+Paths which resolve outside the root, or into a .git directory, are rejected.
+Patches are checked against every file before any file is written, and the
+files which were written are restored if a later write fails.
+*/
+package workspace
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	// Packages
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// Workspace is a directory whose files are edited
+type Workspace struct {
+	root string
+}
+
+// PatchResult is the result of applying a patch. The files are only written
+// when every hunk applies.
+type PatchResult struct {
+	Applied bool         `json:"applied"`
+	DryRun  bool         `json:"dry_run,omitempty"`
+	Files   []FileResult `json:"files"`
+	Error   string       `json:"error,omitempty"`
+}
+
+// FileResult is the result of the changes to one file
+type FileResult struct {
+	Path      string       `json:"path"`
+	Operation string       `json:"operation"`
+	From      string       `json:"from,omitempty"`
+	Hunks     []HunkResult `json:"hunks,omitempty"`
+	Error     string       `json:"error,omitempty"`
+}
+
+// HunkResult is the result of one hunk. Offset is the number of lines the
+// hunk moved from the line in its header.
+type HunkResult struct {
+	Header  string `json:"header"`
+	Applied bool   `json:"applied"`
+	Offset  int    `json:"offset,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// change is a file to write or remove
+type change struct {
+	path   string // absolute path
+	data   []byte // new content, or nil to remove the file
+	remove bool
+	mode   fs.FileMode
+}
+
+// backup is the state of a file before it was changed
+type backup struct {
+	path   string
+	exists bool
+	data   []byte
+	mode   fs.FileMode
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+const (
+	OpCreate = "create"
+	OpModify = "modify"
+	OpDelete = "delete"
+	OpRename = "rename"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// LIFECYCLE
+
+// Open returns the workspace for the root directory
+func Open(root string) (*Workspace, error) {
+	root, err := resolve(root)
+	if err != nil {
+		return nil, err
+	}
+	if info, err := os.Stat(root); err != nil {
+		return nil, schema.ErrNotFound.Withf("%v", err)
+	} else if !info.IsDir() {
+		return nil, schema.ErrBadParameter.Withf("%q is not a directory", root)
+	}
+	return &Workspace{root: root}, nil
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// Root returns the root directory of the workspace
+func (w *Workspace) Root() string {
+	return w.root
+}
+
+// Path returns the absolute path of a file, which is relative to the root
+// directory and must be within it
+func (w *Workspace) Path(name string) (string, error) {
+	if name == "" {
+		return "", schema.ErrBadParameter.With("path is required")
+	}
+	if filepath.IsAbs(name) {
+		return "", schema.ErrBadParameter.Withf("%q is not relative to the workspace", name)
+	}
+	path, err := resolve(filepath.Join(w.root, filepath.FromSlash(name)))
+	if err != nil {
+		return "", err
+	}
+	rel, err := filepath.Rel(w.root, path)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", schema.ErrBadParameter.Withf("%q is outside the workspace", name)
+	}
+	if slices.Contains(strings.Split(rel, string(filepath.Separator)), ".git") {
+		return "", schema.ErrBadParameter.Withf("%q is within a .git directory", name)
+	}
+	return path, nil
+}
+
+// Apply applies a unified diff to the files in the workspace. Every hunk is
+// checked before any file is written, and the result reports each hunk. When
+// a hunk does not apply, or dryRun is true, no files are written. An error is
+// returned only when the patch cannot be read or the files cannot be written,
+// in which case the files which were written are restored.
+func (w *Workspace) Apply(patch string, dryRun bool) (*PatchResult, error) {
+	files, err := ParsePatch(patch)
+	if err != nil {
+		return nil, err
+	}
+
+	// Check each file and hunk, and collect the changes
+	result := &PatchResult{DryRun: dryRun, Files: make([]FileResult, 0, len(files))}
+	changes := make([]change, 0, len(files))
+	failed := false
+	for _, file := range files {
+		fileResult, fileChanges := w.check(file)
+		if fileResult.Error != "" {
+			failed = true
+		}
+		result.Files = append(result.Files, fileResult)
+		changes = append(changes, fileChanges...)
+	}
+
+	// Two changes to the same file cannot both apply to the original
+	if !failed {
+		seen := make(map[string]bool, len(changes))
+		for _, change := range changes {
+			if seen[change.path] {
+				failed = true
+				result.Error = fmt.Sprintf("%q is changed more than once", change.path)
+				break
+			}
+			seen[change.path] = true
+		}
+	}
+	if failed {
+		if result.Error == "" {
+			result.Error = "the patch does not apply, so no files were changed"
+		}
+		return result, nil
+	} else if dryRun {
+		return result, nil
+	}
+
+	// Write the changes
+	if err := write(changes); err != nil {
+		return nil, err
+	}
+
+	// Return success
+	result.Applied = true
+	return result, nil
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// check returns the result of applying the changes to one file, and the
+// changes to write when every hunk applies
+func (w *Workspace) check(file FilePatch) (FileResult, []change) {
+	result := FileResult{Path: file.Path(), Operation: OpModify}
+	switch {
+	case file.OldPath == "":
+		result.Operation = OpCreate
+	case file.NewPath == "":
+		result.Operation = OpDelete
+	case file.OldPath != file.NewPath:
+		result.Operation, result.From = OpRename, file.OldPath
+	}
+	fail := func(err error) (FileResult, []change) {
+		result.Error = err.Error()
+		return result, nil
+	}
+
+	// Read the original file
+	var data []byte
+	mode := fs.FileMode(0o644)
+	var oldPath, newPath string
+	var err error
+	if file.OldPath != "" {
+		if oldPath, err = w.Path(file.OldPath); err != nil {
+			return fail(err)
+		}
+		info, err := os.Stat(oldPath)
+		if err != nil {
+			return fail(fmt.Errorf("%s does not exist", file.OldPath))
+		} else if !info.Mode().IsRegular() {
+			return fail(fmt.Errorf("%s is not a file", file.OldPath))
+		}
+		mode = info.Mode().Perm()
+		if data, err = os.ReadFile(oldPath); err != nil {
+			return fail(err)
+		}
+	}
+	if file.NewPath != "" {
+		if newPath, err = w.Path(file.NewPath); err != nil {
+			return fail(err)
+		}
+		if newPath != oldPath {
+			if _, err := os.Lstat(newPath); err == nil {
+				return fail(fmt.Errorf("%s already exists", file.NewPath))
+			}
+		}
+	}
+
+	// Apply the hunks
+	text, ok := applyHunks(string(data), file.Hunks, &result)
+	if !ok {
+		result.Error = "one or more hunks do not apply"
+		return result, nil
+	}
+
+	// Return the changes
+	switch result.Operation {
+	case OpDelete:
+		if text != "" {
+			return fail(fmt.Errorf("%s is not empty after the patch, so it cannot be deleted", file.OldPath))
+		}
+		return result, []change{{path: oldPath, remove: true}}
+	case OpRename:
+		return result, []change{{path: newPath, data: []byte(text), mode: mode}, {path: oldPath, remove: true}}
+	default:
+		return result, []change{{path: newPath, data: []byte(text), mode: mode}}
+	}
+}
+
+// applyHunks returns the text with the hunks applied, and false if any hunk
+// does not apply. The result of each hunk is appended to the file result.
+func applyHunks(text string, hunks []Hunk, result *FileResult) (string, bool) {
+	lines, eol := splitLines(text)
+	var out []string
+	next, ok := 0, true // next is the first line not yet copied to out
+	delta := 0          // lines the previous hunk moved from its header
+	for _, hunk := range hunks {
+		hunkResult := HunkResult{Header: hunk.Header()}
+		old := hunk.old()
+
+		// The line in the header, where a hunk without old lines inserts
+		// after its start line. Search from where the previous hunk was
+		// found, relative to its header.
+		start := hunk.OldStart - 1
+		if hunk.OldLines == 0 {
+			start = hunk.OldStart
+		}
+		pos := find(lines, old, next, start+delta)
+		if pos < 0 {
+			hunkResult.Error = "the lines to change were not found"
+			result.Hunks = append(result.Hunks, hunkResult)
+			ok = false
+			continue
+		}
+		delta = pos - start
+		hunkResult.Applied, hunkResult.Offset = true, delta
+		result.Hunks = append(result.Hunks, hunkResult)
+
+		// Copy the lines before the hunk, then the new lines
+		out = append(out, lines[next:pos]...)
+		out = append(out, hunk.new()...)
+		next = pos + len(old)
+
+		// The end of the file
+		if next == len(lines) {
+			if hunk.NoNewlineNew {
+				eol = false
+			} else if hunk.NoNewlineOld || len(lines) == 0 {
+				eol = true
+			}
+		}
+	}
+	if !ok {
+		return "", false
+	}
+	out = append(out, lines[next:]...)
+	return joinLines(out, eol), true
+}
+
+// find returns the first line at or after min where the lines match, closest
+// to the expected line, or -1 if the lines are not found. Lines which match
+// except for trailing whitespace are found when there is no exact match.
+func find(lines, match []string, min, expected int) int {
+	for _, equal := range []func(a, b string) bool{
+		func(a, b string) bool { return a == b },
+		func(a, b string) bool { return strings.TrimRight(a, " \t") == strings.TrimRight(b, " \t") },
+	} {
+		matches := func(pos int) bool {
+			if pos < min || pos+len(match) > len(lines) {
+				return false
+			}
+			for i := range match {
+				if !equal(lines[pos+i], match[i]) {
+					return false
+				}
+			}
+			return true
+		}
+		for offset := 0; expected-offset >= min || expected+offset <= len(lines); offset++ {
+			if matches(expected - offset) {
+				return expected - offset
+			} else if matches(expected + offset) {
+				return expected + offset
+			}
+		}
+	}
+	return -1
+}
+
+// write makes the changes, and restores the files which were changed if a
+// change fails
+func write(changes []change) error {
+	backups := make([]backup, 0, len(changes))
+	rollback := func(err error) error {
+		for i := len(backups) - 1; i >= 0; i-- {
+			b := backups[i]
+			if b.exists {
+				err = errors.Join(err, writeFile(b.path, b.data, b.mode))
+			} else if rmErr := os.Remove(b.path); rmErr != nil && !os.IsNotExist(rmErr) {
+				err = errors.Join(err, rmErr)
+			}
+		}
+		return err
+	}
+	for _, change := range changes {
+		// Back up the file
+		b := backup{path: change.path}
+		if data, err := os.ReadFile(change.path); err == nil {
+			info, err := os.Stat(change.path)
+			if err != nil {
+				return rollback(err)
+			}
+			b.exists, b.data, b.mode = true, data, info.Mode().Perm()
+		} else if !os.IsNotExist(err) {
+			return rollback(err)
+		}
+		backups = append(backups, b)
+
+		// Change the file
+		if change.remove {
+			if err := os.Remove(change.path); err != nil {
+				return rollback(err)
+			}
+		} else if err := os.MkdirAll(filepath.Dir(change.path), 0o755); err != nil {
+			return rollback(err)
+		} else if err := writeFile(change.path, change.data, change.mode); err != nil {
+			return rollback(err)
+		}
+	}
+	return nil
+}
+
+// writeFile replaces a file by writing a temporary file in the same
+// directory and renaming it
+func writeFile(path string, data []byte, mode fs.FileMode) error {
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	name := f.Name()
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		os.Remove(name)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(name)
+		return err
+	}
+	if err := os.Chmod(name, mode); err != nil {
+		os.Remove(name)
+		return err
+	}
+	if err := os.Rename(name, path); err != nil {
+		os.Remove(name)
+		return err
+	}
+	return nil
+}
+
+// splitLines returns the lines of the text, and whether the last line ends
+// with a newline
+func splitLines(text string) ([]string, bool) {
+	if text == "" {
+		return nil, false
+	}
+	eol := strings.HasSuffix(text, "\n")
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n"), eol
+}
+
+// joinLines returns the lines as text
+func joinLines(lines []string, eol bool) string {
+	if len(lines) == 0 {
+		return ""
+	}
+	text := strings.Join(lines, "\n")
+	if eol {
+		text += "\n"
+	}
+	return text
+}
+
+// resolve returns an absolute path with symbolic links resolved. A path which
+// does not exist, such as a file to create, is resolved from its parent.
+func resolve(path string) (string, error) {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		return resolved, nil
+	} else if !os.IsNotExist(err) {
+		return "", err
+	}
+	parent := filepath.Dir(path)
+	if parent == path {
+		return path, nil
+	}
+	if parent, err = resolve(parent); err != nil {
+		return "", err
+	}
+	return filepath.Join(parent, filepath.Base(path)), nil
+}
@@ -0,0 +1,283 @@
+package workspace_test
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	// Packages
+	workspace "github.com/mutablelogic/go-llm/pkg/workspace"
+	assert "github.com/stretchr/testify/assert"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// HELPERS
+
+func newWorkspace(t *testing.T, files map[string]string) *workspace.Workspace {
+	t.Helper()
+	root := t.TempDir()
+	for name, data := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	w, err := workspace.Open(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return w
+}
+
+func readFile(t *testing.T, w *workspace.Workspace, name string) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(w.Root(), name))
+	if err != nil {
+		return "<" + err.Error() + ">"
+	}
+	return string(data)
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// TESTS
+
+func TestParsePatch(t *testing.T) {
+	assert := assert.New(t)
+	files, err := workspace.ParsePatch(`diff --git a/a.txt b/a.txt
+index 1111111..2222222 100644
+--- a/a.txt	2024-01-01 00:00:00
++++ b/a.txt	2024-01-01 00:00:00
+@@ -1,2 +1,2 @@
+ one
+-two
++TWO
+\ No newline at end of file
+--- /dev/null
++++ b/new.txt
+@@ -0,0 +1 @@
++new
+diff --git a/old.txt b/renamed.txt
+similarity index 100%
+rename from old.txt
+rename to renamed.txt
+`)
+	if !assert.NoError(err) {
+		return
+	}
+	if assert.Len(files, 3) {
+		assert.Equal("a.txt", files[0].OldPath)
+		assert.Equal("a.txt", files[0].NewPath)
+		if assert.Len(files[0].Hunks, 1) {
+			hunk := files[0].Hunks[0]
+			assert.Equal([]string{" one", "-two", "+TWO"}, hunk.Lines)
+			assert.True(hunk.NoNewlineNew)
+			assert.False(hunk.NoNewlineOld)
+		}
+		assert.Equal("", files[1].OldPath)
+		assert.Equal("new.txt", files[1].Path())
+		assert.Equal("old.txt", files[2].OldPath)
+		assert.Equal("renamed.txt", files[2].NewPath)
+		assert.Empty(files[2].Hunks)
+	}
+
+	_, err = workspace.ParsePatch("no changes here")
+	assert.Error(err)
+	_, err = workspace.ParsePatch("--- a/x\n+++ b/x\n@@ -1,2 +1,2 @@\n-one\n+ONE\n")
+	assert.Error(err)
+}
+
+func TestApplyModify(t *testing.T) {
+	assert := assert.New(t)
+	w := newWorkspace(t, map[string]string{
+		"main.go": "package main\n\nimport \"fmt\"\n\nfunc main() {\n\tfmt.Println(\"hello\")\n}\n",
+	})
+
+	result, err := w.Apply(`--- a/main.go
++++ b/main.go
+@@ -4,3 +4,3 @@
+
+ func main() {
+-	fmt.Println("hello")
++	fmt.Println("world")
+`, false)
+	if !assert.NoError(err) {
+		return
+	}
+	assert.True(result.Applied)
+	if assert.Len(result.Files, 1) {
+		assert.Equal(workspace.OpModify, result.Files[0].Operation)
+		assert.Len(result.Files[0].Hunks, 1)
+		assert.True(result.Files[0].Hunks[0].Applied)
+		assert.Equal(0, result.Files[0].Hunks[0].Offset)
+	}
+	assert.Equal("package main\n\nimport \"fmt\"\n\nfunc main() {\n\tfmt.Println(\"world\")\n}\n", readFile(t, w, "main.go"))
+}
+
+func TestApplyOffset(t *testing.T) {
+	assert := assert.New(t)
+	w := newWorkspace(t, map[string]string{
+		"a.txt": "zero\none\ntwo\nthree\nfour\nfive\n",
+	})
+
+	// The header is two lines before the change
+	result, err := w.Apply("--- a/a.txt\n+++ b/a.txt\n@@ -1,2 +1,2 @@\n three\n-four\n+FOUR\n@@ -3,1 +3,1 @@\n-five\n+FIVE\n", false)
+	if !assert.NoError(err) {
+		return
+	}
+	assert.True(result.Applied)
+	assert.Equal(3, result.Files[0].Hunks[0].Offset)
+	assert.Equal(3, result.Files[0].Hunks[1].Offset)
+	assert.Equal("zero\none\ntwo\nthree\nFOUR\nFIVE\n", readFile(t, w, "a.txt"))
+}
+
+func TestApplyFailureIsAtomic(t *testing.T) {
+	assert := assert.New(t)
+	w := newWorkspace(t, map[string]string{
+		"a.txt": "one\n",
+		"b.txt": "two\n",
+	})
+
+	result, err := w.Apply(`--- a/a.txt
++++ b/a.txt
+@@ -1 +1 @@
+-one
++ONE
+--- a/b.txt
++++ b/b.txt
+@@ -1 +1 @@
+-missing
++TWO
+`, false)
+	if !assert.NoError(err) {
+		return
+	}
+	assert.False(result.Applied)
+	assert.NotEmpty(result.Error)
+	assert.True(result.Files[0].Hunks[0].Applied)
+	assert.False(result.Files[1].Hunks[0].Applied)
+	assert.NotEmpty(result.Files[1].Hunks[0].Error)
+	assert.Equal("one\n", readFile(t, w, "a.txt"))
+	assert.Equal("two\n", readFile(t, w, "b.txt"))
+}
+
+func TestApplyDryRun(t *testing.T) {
+	assert := assert.New(t)
+	w := newWorkspace(t, map[string]string{"a.txt": "one\n"})
+
+	result, err := w.Apply("--- a/a.txt\n+++ b/a.txt\n@@ -1 +1 @@\n-one\n+ONE\n", true)
+	if !assert.NoError(err) {
+		return
+	}
+	assert.False(result.Applied)
+	assert.True(result.DryRun)
+	assert.Empty(result.Error)
+	assert.True(result.Files[0].Hunks[0].Applied)
+	assert.Equal("one\n", readFile(t, w, "a.txt"))
+}
+
+func TestApplyCreateDeleteRename(t *testing.T) {
+	assert := assert.New(t)
+	w := newWorkspace(t, map[string]string{
+		"delete.txt": "gone\n",
+		"old.txt":    "one\ntwo\n",
+	})
+
+	result, err := w.Apply(`--- /dev/null
++++ b/dir/new.txt
+@@ -0,0 +1,2 @@
++hello
++world
+--- a/delete.txt
++++ /dev/null
+@@ -1 +0,0 @@
+-gone
+diff --git a/old.txt b/renamed.txt
+rename from old.txt
+rename to renamed.txt
+--- a/old.txt
++++ b/renamed.txt
+@@ -1,2 +1,2 @@
+ one
+-two
++TWO
+`, false)
+	if !assert.NoError(err) {
+		return
+	}
+	assert.True(result.Applied, result.Error)
+	assert.Equal(workspace.OpCreate, result.Files[0].Operation)
+	assert.Equal(workspace.OpDelete, result.Files[1].Operation)
+	assert.Equal(workspace.OpRename, result.Files[2].Operation)
+	assert.Equal("old.txt", result.Files[2].From)
+	assert.Equal("hello\nworld\n", readFile(t, w, "dir/new.txt"))
+	assert.NoFileExists(filepath.Join(w.Root(), "delete.txt"))
+	assert.NoFileExists(filepath.Join(w.Root(), "old.txt"))
+	assert.Equal("one\nTWO\n", readFile(t, w, "renamed.txt"))
+}
+
+func TestApplyNoNewline(t *testing.T) {
+	assert := assert.New(t)
+	w := newWorkspace(t, map[string]string{"a.txt": "one\ntwo"})
+
+	result, err := w.Apply("--- a/a.txt\n+++ b/a.txt\n@@ -1,2 +1,3 @@\n one\n-two\n\\ No newline at end of file\n+two\n+three\n", false)
+	if !assert.NoError(err) {
+		return
+	}
+	assert.True(result.Applied, result.Error)
+	assert.Equal("one\ntwo\nthree\n", readFile(t, w, "a.txt"))
+}
+
+func TestApplyOutsideWorkspace(t *testing.T) {
+	assert := assert.New(t)
+	w := newWorkspace(t, map[string]string{"a.txt": "one\n"})
+
+	for _, name := range []string{"../escape.txt", ".git/config", "/etc/passwd"} {
+		result, err := w.Apply("--- /dev/null\n+++ b/"+name+"\n@@ -0,0 +1 @@\n+bad\n", false)
+		if !assert.NoError(err, name) {
+			continue
+		}
+		assert.False(result.Applied, name)
+		assert.NotEmpty(result.Files[0].Error, name)
+	}
+	assert.NoFileExists(filepath.Join(filepath.Dir(w.Root()), "escape.txt"))
+}
+
+func TestApplyCreateExisting(t *testing.T) {
+	assert := assert.New(t)
+	w := newWorkspace(t, map[string]string{"a.txt": "one\n"})
+
+	result, err := w.Apply("--- /dev/null\n+++ b/a.txt\n@@ -0,0 +1 @@\n+two\n", false)
+	if !assert.NoError(err) {
+		return
+	}
+	assert.False(result.Applied)
+	assert.Contains(result.Files[0].Error, "already exists")
+	assert.Equal("one\n", readFile(t, w, "a.txt"))
+}
+
+func TestPatchTool(t *testing.T) {
+	assert := assert.New(t)
+	w := newWorkspace(t, map[string]string{"a.txt": "one\n"})
+
+	tools, err := workspace.NewTools(w.Root())
+	if !assert.NoError(err) || !assert.Len(tools, 1) {
+		return
+	}
+	assert.Equal("apply_patch", tools[0].Name())
+
+	input, _ := json.Marshal(map[string]any{"patch": "--- a/a.txt\n+++ b/a.txt\n@@ -1 +1 @@\n-one\n+ONE\n"})
+	result, err := tools[0].Run(context.Background(), input)
+	if !assert.NoError(err) {
+		return
+	}
+	assert.True(result.(*workspace.PatchResult).Applied)
+	assert.Equal("ONE\n", readFile(t, w, "a.txt"))
+
+	_, err = tools[0].Run(context.Background(), json.RawMessage(`{}`))
+	assert.Error(err)
+}
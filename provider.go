@@ -38,6 +38,18 @@ type Embedder interface {
 	BatchEmbedding(context.Context, schema.Model, []string, ...opt.Opt) ([][]float64, *schema.UsageMeta, error)
 }
 
+// DocumentReader is an interface for reading documents and images with OCR
+type DocumentReader interface {
+	// ReadDocument returns the content of a PDF or image attachment as markdown pages
+	ReadDocument(context.Context, schema.Model, *schema.Attachment, ...opt.Opt) (*schema.OCRResult, *schema.UsageMeta, error)
+}
+
+// Transcriber is an interface for transcribing speech in audio
+type Transcriber interface {
+	// Transcribe returns the text of an audio attachment, with timed segments where available
+	Transcribe(context.Context, schema.Model, *schema.Attachment, ...opt.Opt) (*schema.Transcription, *schema.UsageMeta, error)
+}
+
 // Downloader is an interface for managing model files
 type Downloader interface {
 	// DownloadModel downloads the specified model, and otherwise loads the model if already present
@@ -47,6 +59,16 @@ type Downloader interface {
 	DeleteModel(context.Context, schema.Model) error
 }
 
+// Loader is an interface for loading models into memory, for providers which
+// run models locally
+type Loader interface {
+	// LoadModel loads the specified model into memory, or keeps it loaded if it already is
+	LoadModel(context.Context, schema.Model) error
+
+	// UnloadModel unloads the specified model from memory
+	UnloadModel(context.Context, schema.Model) error
+}
+
 // Generator is an interface for generating response messages and conducting conversations
 type Generator interface {
 	// WithoutSession sends a single message and returns the response (stateless)
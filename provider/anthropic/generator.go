@@ -3,14 +3,18 @@ package anthropic
 import (
 	"context"
 	"encoding/json"
-	"io"
+	"strings"
+	"time"
+	"unicode"
 
 	// Packages
 	jsonschema "github.com/google/jsonschema-go/jsonschema"
 	client "github.com/mutablelogic/go-client"
 	llm "github.com/mutablelogic/go-llm"
 	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	capture "github.com/mutablelogic/go-llm/pkg/capture"
 	opt "github.com/mutablelogic/go-llm/pkg/opt"
+	postprocess "github.com/mutablelogic/go-llm/pkg/postprocess"
 )
 
 ///////////////////////////////////////////////////////////////////////////////
@@ -53,6 +57,7 @@ func (c *Client) generate(ctx context.Context, model string, session *schema.Con
 		return nil, nil, err
 	}
 	streamFn := options.GetStream()
+	recorder := capture.New(options)
 
 	// Build request
 	request, err := generateRequestFromOpts(model, session, options)
@@ -72,127 +77,58 @@ func (c *Client) generate(ctx context.Context, model string, session *schema.Con
 	}
 
 	// Streaming path
+	start := time.Now()
 	if streamFn != nil {
-		return c.generateStream(ctx, payload, session, streamFn)
+		message, usage, err := c.generateStream(ctx, payload, session, streamFn, recorder)
+		session.SetLatency(message, start)
+		postprocess.Apply(options, *session, message)
+		recorder.Annotate(*session, message)
+		return message, usage, err
 	}
 
 	// Non-streaming path
 	var response messagesResponse
-	if err := c.DoWithContext(ctx, payload, &response, client.OptPath("messages")); err != nil {
+	if err := c.DoWithContext(ctx, payload, &response, recorder.Opts(client.OptPath("messages"))...); err != nil {
 		return nil, nil, err
 	}
 
-	return c.processResponse(&response, session)
+	message, usage, err := c.processResponse(&response, session)
+	session.SetLatency(message, start)
+	postprocess.Apply(options, *session, message)
+	recorder.Annotate(*session, message)
+	return message, usage, err
 }
 
 // generateStream handles the SSE streaming response from the Anthropic API
-func (c *Client) generateStream(ctx context.Context, payload client.Payload, session *schema.Conversation, streamFn opt.StreamFn) (*schema.Message, *schema.UsageMeta, error) {
-	// Accumulators for building the final response
-	var (
-		role       string
-		stopReason string
-		usage      messagesUsage
-		blocks     []anthropicContentBlock // one per content_block_start
-		curIndex   int                     // index of the block currently being streamed
-	)
-
+func (c *Client) generateStream(ctx context.Context, payload client.Payload, session *schema.Conversation, streamFn opt.StreamFn, recorder *capture.Recorder) (*schema.Message, *schema.UsageMeta, error) {
+	// Accumulate the response from the events
+	stream := newStreamAccumulator(streamFn)
 	callback := func(event client.TextStreamEvent) error {
-		// Parse the SSE data as JSON
 		var ev streamEvent
 		if err := event.Json(&ev); err != nil {
 			return err
 		}
-
-		switch ev.Type {
-		case eventMessageStart:
-			if ev.Message != nil {
-				role = ev.Message.Role
-				usage = ev.Message.Usage
-			}
-
-		case eventContentBlockStart:
-			curIndex = ev.Index
-			if ev.ContentBlock != nil {
-				// Grow the blocks slice to fit
-				for len(blocks) <= curIndex {
-					blocks = append(blocks, anthropicContentBlock{})
-				}
-				blocks[curIndex] = *ev.ContentBlock
-				// Clear the Input field for tool_use blocks — the API sends
-				// "input": {} as a placeholder, but the real content arrives
-				// via input_json_delta events.
-				if blocks[curIndex].Type == blockTypeToolUse {
-					blocks[curIndex].Input = nil
-				}
-			}
-
-		case eventContentBlockDelta:
-			if ev.Delta == nil {
-				break
-			}
-			// Grow the blocks slice if needed
-			for len(blocks) <= ev.Index {
-				blocks = append(blocks, anthropicContentBlock{})
-			}
-
-			switch ev.Delta.Type {
-			case deltaTypeText:
-				blocks[ev.Index].Text += ev.Delta.Text
-				streamFn("assistant", ev.Delta.Text)
-			case deltaTypeThinking:
-				blocks[ev.Index].Thinking += ev.Delta.Thinking
-				streamFn("thinking", ev.Delta.Thinking)
-			case deltaTypeSignature:
-				blocks[ev.Index].Signature += ev.Delta.Signature
-			case deltaTypeInputJSON:
-				// Accumulate partial JSON for tool_use input
-				blocks[ev.Index].Input = append(blocks[ev.Index].Input, []byte(ev.Delta.PartialJSON)...)
-			}
-			curIndex = ev.Index
-
-		case eventContentBlockStop:
-			// Nothing to do — block is already accumulated
-
-		case eventMessageDelta:
-			if ev.Delta != nil {
-				stopReason = ev.Delta.StopReason
-			}
-			if ev.Usage != nil {
-				usage.OutputTokens = ev.Usage.OutputTokens
-			}
-
-		case eventMessageStop:
-			return io.EOF // Signal end of stream
-
-		case eventPing:
-			// Ignore keepalive
-
-		case eventError:
-			// Return error from stream
-			if ev.Delta != nil {
-				return schema.ErrInternalServerError.Withf("stream error: %s", ev.Delta.Text)
-			}
-		}
-
-		return nil
+		return stream.event(ev)
 	}
 
 	// Execute with streaming
 	var discard messagesResponse
-	if err := c.DoWithContext(ctx, payload, &discard, client.OptPath("messages"), client.OptTextStreamCallback(callback)); err != nil {
+	if err := c.DoWithContext(ctx, payload, &discard, recorder.Opts(client.OptPath("messages"), client.OptTextStreamCallback(callback))...); err != nil {
 		return nil, nil, err
 	}
 
 	// Refusal — no message to append
+	blocks, stopReason, usage := stream.content(), stream.stopReason, stream.usage
 	if stopReason == stopReasonRefusal {
-		return nil, nil, schema.ErrRefusal
+		return nil, nil, refusalFromAnthropic(stopReason, blocks)
 	}
 
 	// Build final message from accumulated blocks
-	message, err := messageFromAnthropicResponse(role, blocks, stopReason)
+	message, err := messageFromAnthropicResponse(stream.role, blocks, stopReason)
 	if err != nil {
 		return nil, nil, err
 	}
+	c.setGeneration(message, stream.id, stream.model)
 
 	// Append the message to the session with token counts
 	session.AppendWithOuput(*message, usage.InputTokens, usage.OutputTokens)
@@ -218,7 +154,7 @@ func (c *Client) generateStream(ctx context.Context, payload client.Payload, ses
 func (c *Client) processResponse(response *messagesResponse, session *schema.Conversation) (*schema.Message, *schema.UsageMeta, error) {
 	// Refusal — no message to append
 	if response.StopReason == stopReasonRefusal {
-		return nil, nil, schema.ErrRefusal
+		return nil, nil, refusalFromAnthropic(response.StopReason, response.Content)
 	}
 
 	// Convert response to schema message
@@ -226,6 +162,7 @@ func (c *Client) processResponse(response *messagesResponse, session *schema.Con
 	if err != nil {
 		return nil, nil, err
 	}
+	c.setGeneration(message, response.Id, response.Model)
 
 	// Append the message to the session with token counts
 	session.AppendWithOuput(*message, response.Usage.InputTokens, response.Usage.OutputTokens)
@@ -247,17 +184,35 @@ func (c *Client) processResponse(response *messagesResponse, session *schema.Con
 	return message, usageResult, nil
 }
 
+// setGeneration records the provider, model and response identifier on a
+// generated message
+func (c *Client) setGeneration(message *schema.Message, id, model string) {
+	message.CreatedAt = time.Now()
+	message.Provider = c.Name()
+	message.Model = model
+	message.ResponseID = id
+}
+
 ///////////////////////////////////////////////////////////////////////////////
 // REQUEST BUILDING
 
 // generateRequestFromOpts builds a messagesRequest from the session and applied options
 func generateRequestFromOpts(model string, session *schema.Conversation, options opt.Options) (*messagesRequest, error) {
 	// Convert session to Anthropic message format
-	messages, err := anthropicMessagesFromSession(session)
+	messages, err := anthropicMessagesFromSession(session, options.GetBool(opt.CitationsKey))
 	if err != nil {
 		return nil, err
 	}
 
+	// Prefill — the reply continues a final assistant message, which must
+	// not end with white space
+	if prefill := strings.TrimRightFunc(options.GetString(opt.PrefillKey), unicode.IsSpace); prefill != "" {
+		messages = append(messages, anthropicMessage{
+			Role:    schema.RoleAssistant,
+			Content: []anthropicContentBlock{{Type: blockTypeText, Text: prefill}},
+		})
+	}
+
 	// Metadata
 	var metadata *messagesMetadata
 	if userId := options.GetString(opt.UserIdKey); userId != "" {
@@ -348,6 +303,16 @@ func generateRequestFromOpts(model string, session *schema.Conversation, options
 		}
 	}
 
+	// Parallel tool use can only be disabled when tools may be called
+	if options.Has(opt.ParallelToolCallsKey) && !options.GetBool(opt.ParallelToolCallsKey) {
+		if toolCh == nil {
+			toolCh = &toolChoice{Type: "auto"}
+		} else if toolCh.Type == "none" {
+			return nil, schema.ErrBadParameter.With("parallel tool calls cannot be disabled when tool choice is none")
+		}
+		toolCh.DisableParallelToolUse = true
+	}
+
 	// Collect tools from toolkit and individual WithTool opts
 	var allTools []llm.Tool
 	if v := options.Get(opt.ToolKey); v != nil {
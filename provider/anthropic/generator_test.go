@@ -422,6 +422,82 @@ func Test_generateRequest_021(t *testing.T) {
 	assert.Equal(uint(4096), req.Thinking.BudgetTokens)
 }
 
+func Test_generateRequest_022(t *testing.T) {
+	// Test tool choice required maps to any
+	assert := assert.New(t)
+
+	msg := &schema.Message{Role: "user", Content: []schema.ContentBlock{{Text: types.Ptr("Hi")}}}
+	session := schema.Conversation{msg}
+	o, err := opt.Apply(WithToolChoiceRequired())
+	assert.NoError(err)
+
+	req, err := generateRequestFromOpts(testModel, &session, o)
+	assert.NoError(err)
+	assert.NotNil(req.ToolChoice)
+	assert.Equal("any", req.ToolChoice.Type)
+	assert.False(req.ToolChoice.DisableParallelToolUse)
+}
+
+func Test_generateRequest_023(t *testing.T) {
+	// Test disabling parallel tool calls defaults the tool choice to auto
+	assert := assert.New(t)
+
+	msg := &schema.Message{Role: "user", Content: []schema.ContentBlock{{Text: types.Ptr("Hi")}}}
+	session := schema.Conversation{msg}
+	o, err := opt.Apply(WithParallelToolCalls(false))
+	assert.NoError(err)
+
+	req, err := generateRequestFromOpts(testModel, &session, o)
+	assert.NoError(err)
+	assert.NotNil(req.ToolChoice)
+	assert.Equal("auto", req.ToolChoice.Type)
+	assert.True(req.ToolChoice.DisableParallelToolUse)
+
+	// Enabling parallel tool calls is the default, so no tool choice is sent
+	o, err = opt.Apply(WithParallelToolCalls(true))
+	assert.NoError(err)
+	req, err = generateRequestFromOpts(testModel, &session, o)
+	assert.NoError(err)
+	assert.Nil(req.ToolChoice)
+}
+
+func Test_generateRequest_024(t *testing.T) {
+	// Test disabling parallel tool calls with a tool choice of none is rejected
+	assert := assert.New(t)
+
+	msg := &schema.Message{Role: "user", Content: []schema.ContentBlock{{Text: types.Ptr("Hi")}}}
+	session := schema.Conversation{msg}
+	o, err := opt.Apply(WithToolChoiceNone(), WithParallelToolCalls(false))
+	assert.NoError(err)
+
+	_, err = generateRequestFromOpts(testModel, &session, o)
+	assert.Error(err)
+
+	o, err = opt.Apply(WithToolChoice("get_weather"), WithParallelToolCalls(false))
+	assert.NoError(err)
+	req, err := generateRequestFromOpts(testModel, &session, o)
+	assert.NoError(err)
+	assert.Equal("tool", req.ToolChoice.Type)
+	assert.True(req.ToolChoice.DisableParallelToolUse)
+}
+
+func Test_generateRequest_025(t *testing.T) {
+	// Test prefill is sent as a final assistant message without trailing white space
+	assert := assert.New(t)
+
+	msg := &schema.Message{Role: "user", Content: []schema.ContentBlock{{Text: types.Ptr("List three colours as JSON")}}}
+	session := schema.Conversation{msg}
+	o, err := opt.Apply(opt.WithPrefill("{\n"))
+	assert.NoError(err)
+
+	req, err := generateRequestFromOpts(testModel, &session, o)
+	assert.NoError(err)
+	if assert.Len(req.Messages, 2) {
+		assert.Equal("assistant", req.Messages[1].Role)
+		assert.Equal("{", req.Messages[1].Content[0].Text)
+	}
+}
+
 ///////////////////////////////////////////////////////////////////////////////
 // UNIT TESTS — option validation
 
@@ -501,9 +577,14 @@ func Test_processResponse_001(t *testing.T) {
 	assert.Len(result.Content, 1)
 	assert.Equal("Hello!", *result.Content[0].Text)
 	assert.Equal(schema.ResultStop, result.Result)
+	assert.Equal("anthropic", result.Provider)
+	assert.Equal(testModel, result.Model)
+	assert.Equal("msg_001", result.ResponseID)
+	assert.False(result.CreatedAt.IsZero())
 
 	// Session should now have 2 messages
 	assert.Len(session, 2)
+	assert.Equal("msg_001", session[1].ResponseID)
 }
 
 func Test_processResponse_002(t *testing.T) {
@@ -556,6 +637,32 @@ func Test_processResponse_003(t *testing.T) {
 	assert.Len(session, 1)
 }
 
+func Test_processResponse_refusal_001(t *testing.T) {
+	// Test refusal details include any explanation returned by the model
+	assert := assert.New(t)
+
+	c, err := New("test-key")
+	assert.NoError(err)
+
+	msg := &schema.Message{Role: "user", Content: []schema.ContentBlock{{Text: types.Ptr("Hi")}}}
+	session := schema.Conversation{msg}
+
+	response := &messagesResponse{
+		Role:       "assistant",
+		StopReason: stopReasonRefusal,
+		Content:    []anthropicContentBlock{{Type: blockTypeText, Text: "I can't help with that."}},
+	}
+
+	_, _, err = c.processResponse(response, &session)
+	var refusal *schema.Refusal
+	if assert.ErrorAs(err, &refusal) {
+		assert.Equal(schema.Anthropic, refusal.Provider)
+		assert.Equal(stopReasonRefusal, refusal.Reason)
+		assert.Equal(schema.RefusalSafety, refusal.Category)
+		assert.Equal("I can't help with that.", refusal.Message)
+	}
+}
+
 func Test_processResponse_004(t *testing.T) {
 	// Test tool_use stop reason with tool call
 	assert := assert.New(t)
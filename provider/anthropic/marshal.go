@@ -18,22 +18,19 @@ import (
 
 // anthropicMessagesFromSession converts a schema.Conversation to Anthropic message format.
 // System messages are skipped (handled separately via the system parameter).
-func anthropicMessagesFromSession(session *schema.Conversation) ([]anthropicMessage, error) {
+// When citations is true, document attachments are sent with citations enabled.
+func anthropicMessagesFromSession(session *schema.Conversation, citations bool) ([]anthropicMessage, error) {
 	if session == nil {
 		return nil, nil
 	}
 
-	messages := make([]anthropicMessage, 0, len(*session))
-	for _, msg := range *session {
+	conversation := schema.Normalize(schema.Anthropic, *session)
+	messages := make([]anthropicMessage, 0, len(conversation))
+	for _, msg := range conversation {
 		if msg.Role == schema.RoleSystem {
 			continue
 		}
-		// Skip empty assistant messages (no content blocks) — these can
-		// occur when another provider returns a tool call with no text.
-		if msg.Role == schema.RoleAssistant && len(msg.Content) == 0 {
-			continue
-		}
-		am, err := anthropicMessageFromMessage(msg)
+		am, err := anthropicMessageFromMessage(msg, citations)
 		if err != nil {
 			return nil, err
 		}
@@ -43,7 +40,7 @@ func anthropicMessagesFromSession(session *schema.Conversation) ([]anthropicMess
 }
 
 // anthropicMessageFromMessage converts a single schema.Message to Anthropic format.
-func anthropicMessageFromMessage(msg *schema.Message) (anthropicMessage, error) {
+func anthropicMessageFromMessage(msg *schema.Message, citations bool) (anthropicMessage, error) {
 	blocks := make([]anthropicContentBlock, 0, len(msg.Content))
 
 	for i := range msg.Content {
@@ -62,7 +59,7 @@ func anthropicMessageFromMessage(msg *schema.Message) (anthropicMessage, error)
 			continue
 		}
 
-		ab, err := anthropicBlockFromContentBlock(block)
+		ab, err := anthropicBlockFromContentBlock(block, citations)
 		if err != nil {
 			return anthropicMessage{}, err
 		}
@@ -78,7 +75,7 @@ func anthropicMessageFromMessage(msg *schema.Message) (anthropicMessage, error)
 }
 
 // anthropicBlockFromContentBlock converts a schema.ContentBlock to an Anthropic content block
-func anthropicBlockFromContentBlock(block *schema.ContentBlock) (*anthropicContentBlock, error) {
+func anthropicBlockFromContentBlock(block *schema.ContentBlock, citations bool) (*anthropicContentBlock, error) {
 	// Text content
 	if block.Text != nil {
 		return &anthropicContentBlock{
@@ -87,9 +84,21 @@ func anthropicBlockFromContentBlock(block *schema.ContentBlock) (*anthropicConte
 		}, nil
 	}
 
-	// Attachment — convert text/* to a text block since Anthropic
+	// Context from the system, sent as marked text
+	if block.Context != nil {
+		return &anthropicContentBlock{
+			Type: blockTypeText,
+			Text: block.Context.Text(),
+		}, nil
+	}
+
+	// Attachment — when citations are enabled, documents are sent as citable
+	// document blocks. Otherwise convert text/* to a text block since Anthropic
 	// only supports image and PDF attachments
 	if block.Attachment != nil {
+		if citations && blockTypeForMIME(block.Attachment.ContentType) == blockTypeDocument {
+			return anthropicCitableDocument(block.Attachment)
+		}
 		if block.Attachment.IsText() && len(block.Attachment.Data) > 0 {
 			return &anthropicContentBlock{
 				Type: blockTypeText,
@@ -164,6 +173,33 @@ func anthropicBlockFromAttachment(att *schema.Attachment) (*anthropicContentBloc
 	return nil, nil
 }
 
+// anthropicCitableDocument converts a document attachment to a document block
+// with citations enabled. Text attachments are sent as plain text documents,
+// so that citations refer to character ranges of the attachment data.
+func anthropicCitableDocument(att *schema.Attachment) (*anthropicContentBlock, error) {
+	var ab *anthropicContentBlock
+	if att.IsText() && len(att.Data) > 0 {
+		ab = &anthropicContentBlock{
+			Type: blockTypeDocument,
+			Source: &anthropicSource{
+				Type:      sourceTypeText,
+				MediaType: "text/plain",
+				Data:      string(att.Data),
+			},
+		}
+	} else if block, err := anthropicBlockFromAttachment(att); err != nil || block == nil {
+		return block, err
+	} else {
+		ab = block
+	}
+	data, err := json.Marshal(citationsConfig{Enabled: true})
+	if err != nil {
+		return nil, err
+	}
+	ab.Citations = data
+	return ab, nil
+}
+
 // blockTypeForMIME returns the Anthropic block type for a given MIME type.
 func blockTypeForMIME(mimeType string) string {
 	mediaType, _, _ := mime.ParseMediaType(mimeType)
@@ -207,7 +243,8 @@ func contentBlockFromAnthropicBlock(ab *anthropicContentBlock) (schema.ContentBl
 	switch ab.Type {
 	case blockTypeText:
 		return schema.ContentBlock{
-			Text: &ab.Text,
+			Text:      &ab.Text,
+			Citations: citationsFromAnthropic(ab.Citations),
 		}, nil
 
 	case blockTypeThinking:
@@ -255,6 +292,38 @@ func contentBlockFromAnthropicBlock(ab *anthropicContentBlock) (schema.ContentBl
 	return schema.ContentBlock{Text: &empty}, nil
 }
 
+// citationsFromAnthropic converts the citations on a response text block.
+// Citations which cannot be decoded, or are of an unknown type, are skipped.
+func citationsFromAnthropic(data json.RawMessage) []schema.Citation {
+	var citations []anthropicCitation
+	if len(data) == 0 || json.Unmarshal(data, &citations) != nil {
+		return nil
+	}
+	result := make([]schema.Citation, 0, len(citations))
+	for _, c := range citations {
+		citation := schema.Citation{
+			Document: c.DocumentIndex,
+			Title:    c.DocumentTitle,
+			Text:     c.CitedText,
+		}
+		switch c.Type {
+		case citationTypeChar:
+			citation.Type, citation.Start, citation.End = schema.CitationChar, c.StartCharIndex, c.EndCharIndex
+		case citationTypePage:
+			citation.Type, citation.Start, citation.End = schema.CitationPage, c.StartPageNumber, c.EndPageNumber
+		case citationTypeBlock:
+			citation.Type, citation.Start, citation.End = schema.CitationBlock, c.StartBlockIndex, c.EndBlockIndex
+		default:
+			continue
+		}
+		result = append(result, citation)
+	}
+	if len(result) == 0 {
+		return nil
+	}
+	return result
+}
+
 // attachmentFromSource converts an Anthropic source to a schema.Attachment
 func attachmentFromSource(src *anthropicSource) *schema.Attachment {
 	if src.Type == sourceTypeBase64 && src.Data != "" {
@@ -323,3 +392,20 @@ func resultFromStopReason(reason string) schema.ResultType {
 		return schema.ResultOther
 	}
 }
+
+// refusalFromAnthropic describes a refusal, including any explanation the
+// model returned before stopping
+func refusalFromAnthropic(reason string, blocks []anthropicContentBlock) *schema.Refusal {
+	var text []string
+	for _, block := range blocks {
+		if block.Type == blockTypeText && strings.TrimSpace(block.Text) != "" {
+			text = append(text, strings.TrimSpace(block.Text))
+		}
+	}
+	return &schema.Refusal{
+		Provider: schema.Anthropic,
+		Reason:   reason,
+		Category: schema.RefusalSafety,
+		Message:  strings.Join(text, "\n"),
+	}
+}
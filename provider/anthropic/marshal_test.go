@@ -51,7 +51,7 @@ func Test_marshal_schema_to_anthropic_text_user(t *testing.T) {
 	msg := decodeSchemaMessage(t, schemaJSON)
 	assert.Equal("user", msg.Role)
 
-	am, err := anthropicMessageFromMessage(msg)
+	am, err := anthropicMessageFromMessage(msg, false)
 	assert.NoError(err)
 	assertAnthropicMessageEquals(t, anthropicJSON, &am)
 }
@@ -63,7 +63,7 @@ func Test_marshal_schema_to_anthropic_text_assistant(t *testing.T) {
 	msg := decodeSchemaMessage(t, schemaJSON)
 	assert.Equal("assistant", msg.Role)
 
-	am, err := anthropicMessageFromMessage(msg)
+	am, err := anthropicMessageFromMessage(msg, false)
 	assert.NoError(err)
 	assertAnthropicMessageEquals(t, anthropicJSON, &am)
 }
@@ -73,12 +73,26 @@ func Test_marshal_schema_to_anthropic_text_multipart(t *testing.T) {
 	assert := assert.New(t)
 
 	msg := decodeSchemaMessage(t, schemaJSON)
-	am, err := anthropicMessageFromMessage(msg)
+	am, err := anthropicMessageFromMessage(msg, false)
 	assert.NoError(err)
 	assert.Len(am.Content, 2)
 	assertAnthropicMessageEquals(t, anthropicJSON, &am)
 }
 
+func Test_marshal_schema_to_anthropic_context(t *testing.T) {
+	anthropicJSON, schemaJSON := loadTestPair(t, "message_context.json")
+	assert := assert.New(t)
+
+	msg := decodeSchemaMessage(t, schemaJSON)
+	assert.NotNil(msg.Content[0].Context)
+
+	am, err := anthropicMessageFromMessage(msg, false)
+	assert.NoError(err)
+	assert.Len(am.Content, 2)
+	assert.Equal(blockTypeText, am.Content[0].Type)
+	assertAnthropicMessageEquals(t, anthropicJSON, &am)
+}
+
 func Test_marshal_schema_to_anthropic_thinking(t *testing.T) {
 	anthropicJSON, schemaJSON := loadTestPair(t, "message_thinking.json")
 	assert := assert.New(t)
@@ -87,7 +101,7 @@ func Test_marshal_schema_to_anthropic_thinking(t *testing.T) {
 	assert.NotNil(msg.Meta)
 	assert.Equal(true, msg.Meta["thought"])
 
-	am, err := anthropicMessageFromMessage(msg)
+	am, err := anthropicMessageFromMessage(msg, false)
 	assert.NoError(err)
 	assert.Equal(blockTypeThinking, am.Content[0].Type)
 	assert.Equal("c2lnbmF0dXJlLWRhdGE=", am.Content[0].Signature)
@@ -103,7 +117,7 @@ func Test_marshal_schema_to_anthropic_image_base64(t *testing.T) {
 	assert.Len(msg.Content, 2)
 	assert.NotNil(msg.Content[1].Attachment)
 
-	am, err := anthropicMessageFromMessage(msg)
+	am, err := anthropicMessageFromMessage(msg, false)
 	assert.NoError(err)
 	assert.Len(am.Content, 2)
 	assert.Equal(blockTypeImage, am.Content[1].Type)
@@ -120,7 +134,7 @@ func Test_marshal_schema_to_anthropic_image_url(t *testing.T) {
 	assert.NotNil(msg.Content[1].Attachment)
 	assert.NotNil(msg.Content[1].Attachment.URL)
 
-	am, err := anthropicMessageFromMessage(msg)
+	am, err := anthropicMessageFromMessage(msg, false)
 	assert.NoError(err)
 	assert.NotNil(am.Content[1].Source)
 	assert.Equal(sourceTypeURL, am.Content[1].Source.Type)
@@ -134,7 +148,7 @@ func Test_marshal_schema_to_anthropic_document(t *testing.T) {
 	msg := decodeSchemaMessage(t, schemaJSON)
 	assert.NotNil(msg.Content[1].Attachment)
 
-	am, err := anthropicMessageFromMessage(msg)
+	am, err := anthropicMessageFromMessage(msg, false)
 	assert.NoError(err)
 	assert.Equal(blockTypeDocument, am.Content[1].Type)
 	assert.NotNil(am.Content[1].Source)
@@ -149,7 +163,7 @@ func Test_marshal_schema_to_anthropic_tool_use(t *testing.T) {
 	msg := decodeSchemaMessage(t, schemaJSON)
 	assert.NotNil(msg.Content[1].ToolCall)
 
-	am, err := anthropicMessageFromMessage(msg)
+	am, err := anthropicMessageFromMessage(msg, false)
 	assert.NoError(err)
 	assert.Equal(blockTypeToolUse, am.Content[1].Type)
 	assert.Equal("get_weather", am.Content[1].Name)
@@ -163,7 +177,7 @@ func Test_marshal_schema_to_anthropic_tool_result(t *testing.T) {
 	msg := decodeSchemaMessage(t, schemaJSON)
 	assert.NotNil(msg.Content[0].ToolResult)
 
-	am, err := anthropicMessageFromMessage(msg)
+	am, err := anthropicMessageFromMessage(msg, false)
 	assert.NoError(err)
 	assert.Equal(blockTypeToolResult, am.Content[0].Type)
 	assert.Equal("toolu_01A", am.Content[0].ToolUseID)
@@ -178,7 +192,7 @@ func Test_marshal_schema_to_anthropic_tool_error(t *testing.T) {
 	assert.NotNil(msg.Content[0].ToolResult)
 	assert.True(msg.Content[0].ToolResult.IsError)
 
-	am, err := anthropicMessageFromMessage(msg)
+	am, err := anthropicMessageFromMessage(msg, false)
 	assert.NoError(err)
 	assert.Equal(blockTypeToolResult, am.Content[0].Type)
 	assert.True(am.Content[0].IsError)
@@ -192,7 +206,7 @@ func Test_marshal_schema_to_anthropic_multi_tool_use(t *testing.T) {
 	msg := decodeSchemaMessage(t, schemaJSON)
 	assert.Len(msg.Content, 2)
 
-	am, err := anthropicMessageFromMessage(msg)
+	am, err := anthropicMessageFromMessage(msg, false)
 	assert.NoError(err)
 	assert.Len(am.Content, 2)
 	assert.Equal(blockTypeToolUse, am.Content[0].Type)
@@ -215,6 +229,19 @@ func Test_marshal_anthropic_to_schema_response_text(t *testing.T) {
 	assertSchemaMessageEquals(t, schemaJSON, msg)
 }
 
+func Test_marshal_anthropic_to_schema_response_citations(t *testing.T) {
+	anthropicJSON, schemaJSON := loadTestPair(t, "response_citations.json")
+	assert := assert.New(t)
+
+	resp := decodeAnthropicResponse(t, anthropicJSON)
+	msg, err := messageFromAnthropicResponse(resp.Role, resp.Content, resp.StopReason)
+	assert.NoError(err)
+	assert.Len(msg.Content, 2)
+	assert.Nil(msg.Content[0].Citations)
+	assert.Len(msg.Content[1].Citations, 2)
+	assertSchemaMessageEquals(t, schemaJSON, msg)
+}
+
 func Test_marshal_anthropic_to_schema_response_tool_use(t *testing.T) {
 	anthropicJSON, schemaJSON := loadTestPair(t, "response_tool_use.json")
 	assert := assert.New(t)
@@ -341,7 +368,7 @@ func Test_marshal_session_skips_system(t *testing.T) {
 		{Role: schema.RoleUser, Content: []schema.ContentBlock{{Text: &userText}}},
 	}
 
-	messages, err := anthropicMessagesFromSession(session)
+	messages, err := anthropicMessagesFromSession(session, false)
 	assert.NoError(err)
 	assert.Len(messages, 1)
 	assert.Equal("user", messages[0].Role)
@@ -359,7 +386,7 @@ func Test_marshal_session_multi_turn(t *testing.T) {
 		{Role: schema.RoleUser, Content: []schema.ContentBlock{{Text: &followUp}}},
 	}
 
-	messages, err := anthropicMessagesFromSession(session)
+	messages, err := anthropicMessagesFromSession(session, false)
 	assert.NoError(err)
 	assert.Len(messages, 3)
 	assert.Equal("user", messages[0].Role)
@@ -368,11 +395,43 @@ func Test_marshal_session_multi_turn(t *testing.T) {
 }
 
 func Test_marshal_session_nil(t *testing.T) {
-	messages, err := anthropicMessagesFromSession(nil)
+	messages, err := anthropicMessagesFromSession(nil, false)
 	assert.NoError(t, err)
 	assert.Nil(t, messages)
 }
 
+func Test_marshal_session_citations(t *testing.T) {
+	assert := assert.New(t)
+	pdf := []byte("%PDF-1.4")
+	session := &schema.Conversation{
+		{Role: "user", Content: []schema.ContentBlock{
+			{Attachment: &schema.Attachment{ContentType: "text/plain", Data: []byte("The grass is green.")}},
+			{Attachment: &schema.Attachment{ContentType: "application/pdf", Data: pdf}},
+			{Attachment: &schema.Attachment{ContentType: "image/png", Data: []byte{0x89}}},
+		}},
+	}
+
+	// Without citations, text attachments are sent as text blocks
+	messages, err := anthropicMessagesFromSession(session, false)
+	assert.NoError(err)
+	assert.Equal(blockTypeText, messages[0].Content[0].Type)
+	assert.Nil(messages[0].Content[1].Citations)
+
+	// With citations, documents are citable and images are unchanged
+	messages, err = anthropicMessagesFromSession(session, true)
+	assert.NoError(err)
+	blocks := messages[0].Content
+	assert.Equal(blockTypeDocument, blocks[0].Type)
+	assert.Equal(sourceTypeText, blocks[0].Source.Type)
+	assert.Equal("The grass is green.", blocks[0].Source.Data)
+	assert.JSONEq(`{"enabled":true}`, string(blocks[0].Citations))
+	assert.Equal(blockTypeDocument, blocks[1].Type)
+	assert.Equal(base64.StdEncoding.EncodeToString(pdf), blocks[1].Source.Data)
+	assert.JSONEq(`{"enabled":true}`, string(blocks[1].Citations))
+	assert.Equal(blockTypeImage, blocks[2].Type)
+	assert.Nil(blocks[2].Citations)
+}
+
 ///////////////////////////////////////////////////////////////////////////////
 // DECODE HELPERS
 
@@ -384,11 +443,12 @@ func decodeSchemaMessage(t *testing.T, data json.RawMessage) *schema.Message {
 	var raw struct {
 		Role    string `json:"role"`
 		Content []struct {
-			Text       *string        `json:"text,omitempty"`
-			Thinking   *string        `json:"thinking,omitempty"`
-			Attachment *rawAttachment `json:"attachment,omitempty"`
-			ToolCall   *rawToolCall   `json:"tool_call,omitempty"`
-			ToolResult *rawToolResult `json:"tool_result,omitempty"`
+			Text       *string         `json:"text,omitempty"`
+			Thinking   *string         `json:"thinking,omitempty"`
+			Attachment *rawAttachment  `json:"attachment,omitempty"`
+			ToolCall   *rawToolCall    `json:"tool_call,omitempty"`
+			ToolResult *rawToolResult  `json:"tool_result,omitempty"`
+			Context    *schema.Context `json:"context,omitempty"`
 		} `json:"content"`
 		Meta map[string]any `json:"meta,omitempty"`
 	}
@@ -410,6 +470,9 @@ func decodeSchemaMessage(t *testing.T, data json.RawMessage) *schema.Message {
 		if c.Thinking != nil {
 			block.Thinking = c.Thinking
 		}
+		if c.Context != nil {
+			block.Context = c.Context
+		}
 		if c.Attachment != nil {
 			att := &schema.Attachment{ContentType: c.Attachment.Type}
 			if c.Attachment.Data != "" {
@@ -542,7 +605,7 @@ func roundTripMessage(t *testing.T, original *schema.Message) {
 	assert := assert.New(t)
 
 	// schema -> anthropic
-	am, err := anthropicMessageFromMessage(original)
+	am, err := anthropicMessageFromMessage(original, false)
 	assert.NoError(err)
 
 	// anthropic -> schema (wrap as a response with end_turn stop reason)
@@ -109,6 +109,12 @@ func WithOutputConfig(value string) opt.Opt {
 	return opt.SetString(opt.OutputConfigKey, value)
 }
 
+// WithCitations sends document attachments with citations enabled, so that
+// text in the response carries citations back to the documents
+func WithCitations() opt.Opt {
+	return opt.SetBool(opt.CitationsKey, true)
+}
+
 // WithJSONOutput sets the output format to JSON with the given schema
 func WithJSONOutput(outputSchema *jsonschema.Schema) opt.Opt {
 	if outputSchema == nil {
@@ -134,6 +140,12 @@ func WithToolChoiceAny() opt.Opt {
 	return opt.SetString(opt.ToolChoiceKey, "any")
 }
 
+// WithToolChoiceRequired forces the model to use one of the available tools
+// (equivalent to "any")
+func WithToolChoiceRequired() opt.Opt {
+	return WithToolChoiceAny()
+}
+
 // WithToolChoiceNone prevents the model from using any tools
 func WithToolChoiceNone() opt.Opt {
 	return opt.SetString(opt.ToolChoiceKey, "none")
@@ -149,3 +161,9 @@ func WithToolChoice(name string) opt.Opt {
 		opt.SetString(opt.ToolChoiceNameKey, name),
 	)
 }
+
+// WithParallelToolCalls allows or prevents the model from calling several
+// tools in a single response
+func WithParallelToolCalls(enabled bool) opt.Opt {
+	return opt.SetBool(opt.ParallelToolCallsKey, enabled)
+}
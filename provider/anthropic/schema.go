@@ -50,8 +50,9 @@ type thinkingConfig struct {
 
 // toolChoice specifies which tool(s) the model may use.
 type toolChoice struct {
-	Type string `json:"type"`
-	Name string `json:"name,omitempty"`
+	Type                   string `json:"type"`
+	Name                   string `json:"name,omitempty"`
+	DisableParallelToolUse bool   `json:"disable_parallel_tool_use,omitempty"`
 }
 
 // outputConfig controls output configuration (effort level and/or format).
@@ -109,6 +110,10 @@ type anthropicContentBlock struct {
 	// image/document source
 	Source *anthropicSource `json:"source,omitempty"`
 
+	// citations: {"enabled":true} on a document block in a request, or a list
+	// of anthropicCitation on a text block in a response
+	Citations json.RawMessage `json:"citations,omitempty"`
+
 	// tool_use block
 	ID    string          `json:"id,omitempty"`
 	Name  string          `json:"name,omitempty"`
@@ -132,6 +137,26 @@ type anthropicSource struct {
 	URL       string `json:"url,omitempty"`        // URL reference
 }
 
+// anthropicCitation is a citation on a text block in a response. Which
+// range fields are set depends on the citation type.
+type anthropicCitation struct {
+	Type            string `json:"type"`
+	CitedText       string `json:"cited_text"`
+	DocumentIndex   uint   `json:"document_index"`
+	DocumentTitle   string `json:"document_title,omitempty"`
+	StartCharIndex  uint   `json:"start_char_index,omitempty"`
+	EndCharIndex    uint   `json:"end_char_index,omitempty"`
+	StartPageNumber uint   `json:"start_page_number,omitempty"`
+	EndPageNumber   uint   `json:"end_page_number,omitempty"`
+	StartBlockIndex uint   `json:"start_block_index,omitempty"`
+	EndBlockIndex   uint   `json:"end_block_index,omitempty"`
+}
+
+// citationsConfig enables citations on a document block in a request.
+type citationsConfig struct {
+	Enabled bool `json:"enabled"`
+}
+
 ///////////////////////////////////////////////////////////////////////////////
 // SYSTEM PROMPT
 
@@ -159,18 +184,27 @@ type streamEvent struct {
 	ContentBlock *anthropicContentBlock `json:"content_block,omitempty"`
 	Delta        *streamDelta           `json:"delta,omitempty"`
 	Usage        *messagesUsage         `json:"usage,omitempty"`
+	Error        *streamError           `json:"error,omitempty"`
 }
 
 // streamDelta carries the incremental content within content_block_delta
 // and message_delta events.
 type streamDelta struct {
-	Type         string `json:"type"`
-	Text         string `json:"text,omitempty"`
-	PartialJSON  string `json:"partial_json,omitempty"`
-	Thinking     string `json:"thinking,omitempty"`
-	Signature    string `json:"signature,omitempty"`
-	StopReason   string `json:"stop_reason,omitempty"`
-	StopSequence string `json:"stop_sequence,omitempty"`
+	Type         string          `json:"type"`
+	Text         string          `json:"text,omitempty"`
+	PartialJSON  string          `json:"partial_json,omitempty"`
+	Thinking     string          `json:"thinking,omitempty"`
+	Signature    string          `json:"signature,omitempty"`
+	StopReason   string          `json:"stop_reason,omitempty"`
+	StopSequence string          `json:"stop_sequence,omitempty"`
+	Citation     json.RawMessage `json:"citation,omitempty"`
+}
+
+// streamError is the error in an error event, which may be sent after the
+// stream has started, such as when the API is overloaded.
+type streamError struct {
+	Type    string `json:"type"`
+	Message string `json:"message"`
 }
 
 ///////////////////////////////////////////////////////////////////////////////
@@ -246,6 +280,7 @@ const (
 	deltaTypeThinking  = "thinking_delta"
 	deltaTypeSignature = "signature_delta"
 	deltaTypeInputJSON = "input_json_delta"
+	deltaTypeCitations = "citations_delta"
 )
 
 ///////////////////////////////////////////////////////////////////////////////
@@ -254,4 +289,14 @@ const (
 const (
 	sourceTypeBase64 = "base64"
 	sourceTypeURL    = "url"
+	sourceTypeText   = "text"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// CITATION TYPE CONSTANTS
+
+const (
+	citationTypeChar  = "char_location"
+	citationTypePage  = "page_location"
+	citationTypeBlock = "content_block_location"
 )
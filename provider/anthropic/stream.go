@@ -0,0 +1,151 @@
+package anthropic
+
+import (
+	"encoding/json"
+	"io"
+
+	// Packages
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	opt "github.com/mutablelogic/go-llm/pkg/opt"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// streamAccumulator reconstructs the response from the events of a stream,
+// passing text and thinking deltas to the stream callback as they arrive
+type streamAccumulator struct {
+	id         string
+	model      string
+	role       string
+	stopReason string
+	usage      messagesUsage
+	blocks     []anthropicContentBlock   // one per content_block_start
+	citations  map[int][]json.RawMessage // citations for each text block
+	streamFn   opt.StreamFn
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// LIFECYCLE
+
+func newStreamAccumulator(streamFn opt.StreamFn) *streamAccumulator {
+	return &streamAccumulator{
+		citations: make(map[int][]json.RawMessage),
+		streamFn:  streamFn,
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// event accumulates an event, and returns io.EOF at the end of the stream
+func (s *streamAccumulator) event(ev streamEvent) error {
+	switch ev.Type {
+	case eventMessageStart:
+		if ev.Message != nil {
+			s.id = ev.Message.Id
+			s.model = ev.Message.Model
+			s.role = ev.Message.Role
+			s.usage = ev.Message.Usage
+		}
+
+	case eventContentBlockStart:
+		if ev.ContentBlock == nil {
+			break
+		}
+		block := s.block(ev.Index)
+		*block = *ev.ContentBlock
+
+		// The API sends "input": {} as a placeholder for tool_use blocks, and
+		// the input arrives in input_json_delta events
+		if block.Type == blockTypeToolUse {
+			block.Input = nil
+		}
+
+		// Citations arrive in citations_delta events, but keep any which are
+		// sent with the start of the block
+		if len(block.Citations) > 0 {
+			var citations []json.RawMessage
+			if json.Unmarshal(block.Citations, &citations) == nil {
+				s.citations[ev.Index] = citations
+			}
+			block.Citations = nil
+		}
+
+	case eventContentBlockDelta:
+		if ev.Delta == nil {
+			break
+		}
+		block := s.block(ev.Index)
+		switch ev.Delta.Type {
+		case deltaTypeText:
+			block.Text += ev.Delta.Text
+			s.streamFn(schema.RoleAssistant, ev.Delta.Text)
+		case deltaTypeThinking:
+			block.Thinking += ev.Delta.Thinking
+			s.streamFn(schema.RoleThinking, ev.Delta.Thinking)
+		case deltaTypeSignature:
+			block.Signature += ev.Delta.Signature
+		case deltaTypeInputJSON:
+			block.Input = append(block.Input, ev.Delta.PartialJSON...)
+		case deltaTypeCitations:
+			if len(ev.Delta.Citation) > 0 {
+				s.citations[ev.Index] = append(s.citations[ev.Index], ev.Delta.Citation)
+			}
+		}
+
+	case eventContentBlockStop:
+		// The input of a tool call is complete, and must be a JSON object
+		if ev.Index < len(s.blocks) && s.blocks[ev.Index].Type == blockTypeToolUse {
+			if input := s.blocks[ev.Index].Input; len(input) > 0 && !json.Valid(input) {
+				return schema.ErrInternalServerError.Withf("stream error: invalid input for tool %q", s.blocks[ev.Index].Name)
+			}
+		}
+
+	case eventMessageDelta:
+		if ev.Delta != nil {
+			s.stopReason = ev.Delta.StopReason
+		}
+		if ev.Usage != nil {
+			s.usage.OutputTokens = ev.Usage.OutputTokens
+			if ev.Usage.InputTokens > 0 {
+				s.usage.InputTokens = ev.Usage.InputTokens
+			}
+		}
+
+	case eventMessageStop:
+		return io.EOF
+
+	case eventPing:
+		// Ignore keepalive
+
+	case eventError:
+		if ev.Error != nil {
+			return schema.ErrInternalServerError.Withf("stream error: %s: %s", ev.Error.Type, ev.Error.Message)
+		}
+		return schema.ErrInternalServerError.With("stream error")
+	}
+
+	return nil
+}
+
+// content returns the content blocks of the response, with their citations
+func (s *streamAccumulator) content() []anthropicContentBlock {
+	for index, citations := range s.citations {
+		if index >= len(s.blocks) {
+			continue
+		}
+		if data, err := json.Marshal(citations); err == nil {
+			s.blocks[index].Citations = data
+		}
+	}
+	return s.blocks
+}
+
+// block returns the content block at index, growing the blocks to fit
+func (s *streamAccumulator) block(index int) *anthropicContentBlock {
+	for len(s.blocks) <= index {
+		s.blocks = append(s.blocks, anthropicContentBlock{})
+	}
+	return &s.blocks[index]
+}
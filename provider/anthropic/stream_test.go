@@ -0,0 +1,132 @@
+package anthropic
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	// Packages
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	assert "github.com/stretchr/testify/assert"
+	require "github.com/stretchr/testify/require"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// HELPERS
+
+// streamFixture feeds the events of a recorded SSE stream in testdata/ to an
+// accumulator, and returns the accumulator, the streamed deltas and the
+// error which ended the stream, which is nil at message_stop
+func streamFixture(t *testing.T, name string) (*streamAccumulator, []string, error) {
+	t.Helper()
+	file, err := os.Open(testdataPath(name))
+	require.NoError(t, err)
+	defer file.Close()
+
+	var deltas []string
+	stream := newStreamAccumulator(func(role, text string) {
+		deltas = append(deltas, role+":"+text)
+	})
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data: ")
+		if !ok {
+			continue
+		}
+		var ev streamEvent
+		require.NoError(t, json.Unmarshal([]byte(data), &ev))
+		if err := stream.event(ev); errors.Is(err, io.EOF) {
+			return stream, deltas, nil
+		} else if err != nil {
+			return stream, deltas, err
+		}
+	}
+	require.NoError(t, scanner.Err())
+	return stream, deltas, io.ErrUnexpectedEOF
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// TESTS
+
+func Test_stream_citations(t *testing.T) {
+	assert := assert.New(t)
+	stream, deltas, err := streamFixture(t, "stream_citations.sse")
+	require.NoError(t, err)
+	assert.Equal([]string{"assistant:According to ", "assistant:the document, ", "assistant:the grass ", "assistant:is green"}, deltas)
+
+	// Each text block is kept separate, with the citations for the second
+	message, err := messageFromAnthropicResponse(stream.role, stream.content(), stream.stopReason)
+	require.NoError(t, err)
+	require.Len(t, message.Content, 2)
+	assert.Equal("According to the document, ", *message.Content[0].Text)
+	assert.Nil(message.Content[0].Citations)
+	assert.Equal("the grass is green", *message.Content[1].Text)
+	if assert.Len(message.Content[1].Citations, 2) {
+		assert.Equal("The grass is green.", message.Content[1].Citations[0].Text)
+		assert.Equal("Example Document", message.Content[1].Citations[0].Title)
+		assert.Equal(uint(19), message.Content[1].Citations[0].End)
+		assert.Equal(uint(1), message.Content[1].Citations[1].Document)
+		assert.Equal(uint(2), message.Content[1].Citations[1].Start)
+	}
+	assert.Equal(schema.ResultStop, message.Result)
+	assert.Equal(messagesUsage{InputTokens: 610, OutputTokens: 20}, stream.usage)
+}
+
+func Test_stream_thinking(t *testing.T) {
+	assert := assert.New(t)
+	stream, deltas, err := streamFixture(t, "stream_thinking.sse")
+	require.NoError(t, err)
+	assert.Equal([]string{"thinking:The user wants ", "thinking:a number.", "assistant:42"}, deltas)
+
+	// The signature is kept with the thinking, and is not streamed
+	message, err := messageFromAnthropicResponse(stream.role, stream.content(), stream.stopReason)
+	require.NoError(t, err)
+	require.Len(t, message.Content, 2)
+	assert.Equal("The user wants a number.", *message.Content[0].Thinking)
+	assert.Equal("EqQBCgIYAhIM1gbcDa9GJwZA2b3hGgxBdjrkzLoky3dl1pkiMOYds", message.Meta["thought_signature"])
+	assert.Equal("42", *message.Content[1].Text)
+	assert.Equal(messagesUsage{InputTokens: 42, OutputTokens: 18}, stream.usage)
+}
+
+func Test_stream_tool_use(t *testing.T) {
+	assert := assert.New(t)
+	stream, _, err := streamFixture(t, "stream_tool_use.sse")
+	require.NoError(t, err)
+
+	// The input is joined from the partial JSON, and is an empty object when
+	// the tool has no input
+	message, err := messageFromAnthropicResponse(stream.role, stream.content(), stream.stopReason)
+	require.NoError(t, err)
+	require.Len(t, message.Content, 3)
+	assert.Equal("Checking the weather.", *message.Content[0].Text)
+	if call := message.Content[1].ToolCall; assert.NotNil(call) {
+		assert.Equal("toolu_01Weather", call.ID)
+		assert.Equal("get_weather", call.Name)
+		assert.JSONEq(`{"location":"San Francisco, CA","unit":"celsius"}`, string(call.Input))
+	}
+	if call := message.Content[2].ToolCall; assert.NotNil(call) {
+		assert.Equal("get_time", call.Name)
+		assert.JSONEq(`{}`, string(call.Input))
+	}
+	assert.Equal(schema.ResultToolCall, message.Result)
+}
+
+func Test_stream_error(t *testing.T) {
+	assert := assert.New(t)
+	_, deltas, err := streamFixture(t, "stream_error.sse")
+	assert.ErrorIs(err, schema.ErrInternalServerError)
+	assert.ErrorContains(err, "overloaded_error: Overloaded")
+	assert.Equal([]string{"assistant:Hello"}, deltas)
+}
+
+func Test_stream_invalid_input(t *testing.T) {
+	assert := assert.New(t)
+	stream := newStreamAccumulator(func(string, string) {})
+	assert.NoError(stream.event(streamEvent{Type: eventContentBlockStart, ContentBlock: &anthropicContentBlock{Type: blockTypeToolUse, Name: "get_time", Input: json.RawMessage(`{}`)}}))
+	assert.NoError(stream.event(streamEvent{Type: eventContentBlockDelta, Delta: &streamDelta{Type: deltaTypeInputJSON, PartialJSON: `{"zone": "UT`}}))
+	assert.ErrorContains(stream.event(streamEvent{Type: eventContentBlockStop}), `invalid input for tool "get_time"`)
+}
@@ -17,6 +17,7 @@ import (
 	llm "github.com/mutablelogic/go-llm"
 	schema "github.com/mutablelogic/go-llm/kernel/schema"
 	opt "github.com/mutablelogic/go-llm/pkg/opt"
+	postprocess "github.com/mutablelogic/go-llm/pkg/postprocess"
 	types "github.com/mutablelogic/go-server/pkg/types"
 )
 
@@ -178,7 +179,10 @@ func (c *Client) WithoutSession(ctx context.Context, model schema.Model, message
 		Content: []schema.ContentBlock{
 			{Text: types.Ptr(response)},
 		},
-		Result: schema.ResultStop,
+		Result:    schema.ResultStop,
+		CreatedAt: time.Now(),
+		Provider:  c.Name(),
+		Model:     model.Name,
 	}
 
 	// Estimate token usage (ELIZA doesn't have real tokens, but we estimate)
@@ -199,6 +203,7 @@ func (c *Client) WithoutSession(ctx context.Context, model schema.Model, message
 		streamWords(streamFn, response)
 	}
 
+	postprocess.Apply(options, schema.Conversation{message}, responseMsg)
 	return responseMsg, usage, nil
 }
 
@@ -252,9 +257,12 @@ func (c *Client) WithSession(ctx context.Context, model schema.Model, session *s
 	}
 	content = append(content, schema.ContentBlock{Text: types.Ptr(response)})
 	responseMsg := &schema.Message{
-		Role:    schema.RoleAssistant,
-		Content: content,
-		Result:  schema.ResultStop,
+		Role:      schema.RoleAssistant,
+		Content:   content,
+		Result:    schema.ResultStop,
+		CreatedAt: time.Now(),
+		Provider:  c.Name(),
+		Model:     model.Name,
 	}
 
 	// Estimate token usage
@@ -280,6 +288,7 @@ func (c *Client) WithSession(ctx context.Context, model schema.Model, session *s
 		streamWords(streamFn, response)
 	}
 
+	postprocess.Apply(options, *session, responseMsg)
 	return responseMsg, usage, nil
 }
 
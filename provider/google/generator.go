@@ -4,12 +4,15 @@ import (
 	"context"
 	"encoding/json"
 	"io"
+	"time"
 
 	// Packages
 	client "github.com/mutablelogic/go-client"
 	llm "github.com/mutablelogic/go-llm"
 	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	capture "github.com/mutablelogic/go-llm/pkg/capture"
 	opt "github.com/mutablelogic/go-llm/pkg/opt"
+	postprocess "github.com/mutablelogic/go-llm/pkg/postprocess"
 )
 
 ///////////////////////////////////////////////////////////////////////////////
@@ -52,6 +55,7 @@ func (c *Client) generate(ctx context.Context, model string, session *schema.Con
 		return nil, nil, err
 	}
 	streamFn := options.GetStream()
+	recorder := capture.New(options)
 
 	// Build request
 	request, err := generateRequestFromOpts(model, session, options)
@@ -66,27 +70,43 @@ func (c *Client) generate(ctx context.Context, model string, session *schema.Con
 	}
 
 	// Streaming path
+	start := time.Now()
 	if streamFn != nil {
-		return c.generateStream(ctx, model, payload, session, streamFn)
+		message, usage, err := c.generateStream(ctx, model, payload, session, streamFn, recorder)
+		session.SetLatency(message, start)
+		postprocess.Apply(options, *session, message)
+		recorder.Annotate(*session, message)
+		return message, usage, err
 	}
 
 	// Non-streaming path
 	var response geminiGenerateResponse
-	if err := c.DoWithContext(ctx, payload, &response, client.OptPath("models", model+":generateContent")); err != nil {
+	if err := c.DoWithContext(ctx, payload, &response, recorder.Opts(client.OptPath("models", model+":generateContent"))...); err != nil {
 		return nil, nil, err
 	}
 
-	return c.processResponse(&response, session)
+	message, usage, err := c.processResponse(&response, session)
+	session.SetLatency(message, start)
+	postprocess.Apply(options, *session, message)
+	recorder.Annotate(*session, message)
+	return message, usage, err
 }
 
 // generateStream handles the SSE streaming response from the Gemini API
-func (c *Client) generateStream(ctx context.Context, model string, payload client.Payload, session *schema.Conversation, streamFn opt.StreamFn) (*schema.Message, *schema.UsageMeta, error) {
+func (c *Client) generateStream(ctx context.Context, model string, payload client.Payload, session *schema.Conversation, streamFn opt.StreamFn, recorder *capture.Recorder) (*schema.Message, *schema.UsageMeta, error) {
 	// Accumulators for building the final response from streamed chunks
 	var (
-		role        string
-		finishReson string
-		usage       *geminiUsageMetadata
-		allParts    []*geminiPart
+		role          string
+		finishReson   string
+		finishMessage string
+		safety        []*geminiSafetyRating
+		grounding     *geminiGroundingMetadata
+		urlContext    *geminiURLContextMetadata
+		feedback      *geminiPromptFeedback
+		usage         *geminiUsageMetadata
+		allParts      []*geminiPart
+		modelVersion  string
+		responseID    string
 	)
 
 	callback := func(event client.TextStreamEvent) error {
@@ -100,6 +120,19 @@ func (c *Client) generateStream(ctx context.Context, model string, payload clien
 			usage = chunk.UsageMetadata
 		}
 
+		// Capture prompt feedback, which reports a blocked prompt
+		if chunk.PromptFeedback != nil {
+			feedback = chunk.PromptFeedback
+		}
+
+		// Capture the model and response identifier
+		if chunk.ModelVersion != "" {
+			modelVersion = chunk.ModelVersion
+		}
+		if chunk.ResponseID != "" {
+			responseID = chunk.ResponseID
+		}
+
 		// Process candidates
 		if len(chunk.Candidates) == 0 {
 			return nil
@@ -110,6 +143,21 @@ func (c *Client) generateStream(ctx context.Context, model string, payload clien
 		if candidate.FinishReason != "" {
 			finishReson = candidate.FinishReason
 		}
+		if candidate.FinishMessage != "" {
+			finishMessage = candidate.FinishMessage
+		}
+		if len(candidate.SafetyRatings) > 0 {
+			safety = candidate.SafetyRatings
+		}
+
+		// Capture grounding, which usually arrives with the last chunk and
+		// refers to the text of the whole response
+		if candidate.GroundingMetadata != nil {
+			grounding = candidate.GroundingMetadata
+		}
+		if candidate.URLContextMetadata != nil {
+			urlContext = candidate.URLContextMetadata
+		}
 
 		if candidate.Content == nil {
 			return nil
@@ -138,11 +186,11 @@ func (c *Client) generateStream(ctx context.Context, model string, payload clien
 
 	// Execute with SSE streaming (Gemini uses ?alt=sse)
 	var discard geminiGenerateResponse
-	if err := c.DoWithContext(ctx, payload, &discard,
+	if err := c.DoWithContext(ctx, payload, &discard, recorder.Opts(
 		client.OptPath("models", model+":streamGenerateContent"),
 		client.OptQuery(map[string][]string{"alt": {"sse"}}),
 		client.OptTextStreamCallback(callback),
-	); err != nil {
+	)...); err != nil {
 		// io.EOF signals normal end of stream
 		if err != io.EOF {
 			return nil, nil, err
@@ -156,9 +204,16 @@ func (c *Client) generateStream(ctx context.Context, model string, payload clien
 				Parts: allParts,
 				Role:  role,
 			},
-			FinishReason: finishReson,
+			FinishReason:       finishReson,
+			FinishMessage:      finishMessage,
+			SafetyRatings:      safety,
+			GroundingMetadata:  grounding,
+			URLContextMetadata: urlContext,
 		}},
-		UsageMetadata: usage,
+		PromptFeedback: feedback,
+		UsageMetadata:  usage,
+		ModelVersion:   modelVersion,
+		ResponseID:     responseID,
 	}
 
 	return c.processResponse(response, session)
@@ -170,6 +225,10 @@ func (c *Client) processResponse(response *geminiGenerateResponse, session *sche
 	if err != nil {
 		return nil, nil, err
 	}
+	message.CreatedAt = time.Now()
+	message.Provider = c.Name()
+	message.Model = response.ModelVersion
+	message.ResponseID = response.ResponseID
 
 	// Append the message to the session with token counts
 	var inputTokens, outputTokens uint
@@ -185,13 +244,16 @@ func (c *Client) processResponse(response *geminiGenerateResponse, session *sche
 		OutputTokens: outputTokens,
 	}
 
+	// Return error for a blocked prompt or response
+	if refusal := refusalFromGemini(response); refusal != nil {
+		return message, usageResult, refusal
+	}
+
 	// Return error for finish reasons that need caller attention
 	if len(response.Candidates) > 0 {
 		switch response.Candidates[0].FinishReason {
 		case geminiFinishReasonMaxTokens:
 			return message, usageResult, schema.ErrMaxTokens
-		case geminiFinishReasonSafety, geminiFinishReasonImageSafety:
-			return message, usageResult, schema.ErrRefusal
 		}
 	}
 
@@ -284,6 +346,14 @@ func generateRequestFromOpts(model string, session *schema.Conversation, options
 		}
 	}
 
+	// Grounding tools, which are run by the API
+	if options.GetBool(opt.GoogleSearchKey) {
+		request.Tools = append(request.Tools, &geminiTool{GoogleSearch: &geminiGoogleSearch{}})
+	}
+	if options.GetBool(opt.URLContextKey) {
+		request.Tools = append(request.Tools, &geminiTool{URLContext: &geminiURLContext{}})
+	}
+
 	return request, nil
 }
 
@@ -367,6 +367,27 @@ func Test_generateRequest_020(t *testing.T) {
 	assert.NotNil(req.GenerationConfig.ResponseJSONSchema)
 }
 
+func Test_generateRequest_021(t *testing.T) {
+	// Test grounding with Google Search and URL context
+	assert := assert.New(t)
+
+	msg := &schema.Message{Role: "user", Content: []schema.ContentBlock{{Text: types.Ptr("Who won Euro 2024?")}}}
+	session := schema.Conversation{msg}
+	o, err := opt.Apply(WithGoogleSearch(), WithURLContext())
+	assert.NoError(err)
+
+	req, err := generateRequestFromOpts("gemini-2.5-flash", &session, o)
+	assert.NoError(err)
+	if assert.Len(req.Tools, 2) {
+		assert.NotNil(req.Tools[0].GoogleSearch)
+		assert.NotNil(req.Tools[1].URLContext)
+	}
+
+	data, err := json.Marshal(req)
+	assert.NoError(err)
+	assert.Contains(string(data), `"tools":[{"googleSearch":{}},{"urlContext":{}}]`)
+}
+
 ///////////////////////////////////////////////////////////////////////////////
 // UNIT TESTS — processResponse
 
@@ -393,6 +414,8 @@ func Test_processResponse_001(t *testing.T) {
 			CandidatesTokenCount: 5,
 			TotalTokenCount:      15,
 		},
+		ModelVersion: "gemini-2.5-flash",
+		ResponseID:   "resp-001",
 	}
 
 	result, _, err := c.processResponse(response, &session)
@@ -402,6 +425,9 @@ func Test_processResponse_001(t *testing.T) {
 	assert.Len(result.Content, 1)
 	assert.Equal("Hello!", *result.Content[0].Text)
 	assert.Equal(schema.ResultStop, result.Result)
+	assert.Equal("gemini", result.Provider)
+	assert.Equal("gemini-2.5-flash", result.Model)
+	assert.Equal("resp-001", result.ResponseID)
 
 	// Session should now have 2 messages
 	assert.Len(session, 2)
@@ -458,6 +484,68 @@ func Test_processResponse_003(t *testing.T) {
 	assert.ErrorIs(err, schema.ErrRefusal)
 }
 
+func Test_processResponse_refusal_001(t *testing.T) {
+	// Test blocked finish reasons return refusal details with flagged categories
+	assert := assert.New(t)
+
+	c, err := New("test-key")
+	assert.NoError(err)
+
+	msg := &schema.Message{Role: "user", Content: []schema.ContentBlock{{Text: types.Ptr("Hi")}}}
+	session := schema.Conversation{msg}
+
+	response := &geminiGenerateResponse{
+		Candidates: []*geminiCandidate{{
+			Content:       &geminiContent{Parts: []*geminiPart{}, Role: "model"},
+			FinishReason:  geminiFinishReasonProhibitedContent,
+			FinishMessage: "Content was blocked",
+			SafetyRatings: []*geminiSafetyRating{
+				{Category: "HARM_CATEGORY_HARASSMENT", Probability: "NEGLIGIBLE"},
+				{Category: "HARM_CATEGORY_DANGEROUS_CONTENT", Probability: "HIGH", Blocked: true},
+			},
+		}},
+	}
+
+	_, _, err = c.processResponse(response, &session)
+	assert.ErrorIs(err, schema.ErrRefusal)
+	var refusal *schema.Refusal
+	if assert.ErrorAs(err, &refusal) {
+		assert.Equal(schema.Gemini, refusal.Provider)
+		assert.Equal(geminiFinishReasonProhibitedContent, refusal.Reason)
+		assert.Equal(schema.RefusalPolicy, refusal.Category)
+		assert.Equal([]string{"HARM_CATEGORY_DANGEROUS_CONTENT"}, refusal.Codes)
+		assert.Equal("Content was blocked", refusal.Message)
+	}
+}
+
+func Test_processResponse_refusal_002(t *testing.T) {
+	// Test a blocked prompt returns refusal details from the prompt feedback
+	assert := assert.New(t)
+
+	c, err := New("test-key")
+	assert.NoError(err)
+
+	msg := &schema.Message{Role: "user", Content: []schema.ContentBlock{{Text: types.Ptr("Hi")}}}
+	session := schema.Conversation{msg}
+
+	response := &geminiGenerateResponse{
+		PromptFeedback: &geminiPromptFeedback{
+			BlockReason: geminiFinishReasonSafety,
+			SafetyRatings: []*geminiSafetyRating{
+				{Category: "HARM_CATEGORY_HATE_SPEECH", Probability: "HIGH", Blocked: true},
+			},
+		},
+	}
+
+	_, _, err = c.processResponse(response, &session)
+	var refusal *schema.Refusal
+	if assert.ErrorAs(err, &refusal) {
+		assert.Equal(geminiFinishReasonSafety, refusal.Reason)
+		assert.Equal(schema.RefusalSafety, refusal.Category)
+		assert.Equal([]string{"HARM_CATEGORY_HATE_SPEECH"}, refusal.Codes)
+	}
+}
+
 func Test_processResponse_004(t *testing.T) {
 	// Test empty response (no candidates)
 	assert := assert.New(t)
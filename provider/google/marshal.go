@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"maps"
 	"net/url"
+	"slices"
 
 	// Packages
 	"github.com/google/uuid"
@@ -22,16 +23,12 @@ func geminiContentsFromSession(session *schema.Conversation) ([]*geminiContent,
 		return nil, nil
 	}
 
-	contents := make([]*geminiContent, 0, len(*session))
-	for _, msg := range *session {
+	conversation := schema.Normalize(schema.Gemini, *session)
+	contents := make([]*geminiContent, 0, len(conversation))
+	for _, msg := range conversation {
 		if msg.Role == schema.RoleSystem {
 			continue
 		}
-		// Skip empty assistant messages (no content blocks) — these can
-		// occur when the model returns a tool call with no text.
-		if msg.Role == schema.RoleAssistant && len(msg.Content) == 0 {
-			continue
-		}
 		c, err := geminiContentFromMessage(msg)
 		if err != nil {
 			return nil, err
@@ -76,6 +73,12 @@ func geminiContentFromMessage(msg *schema.Message) (*geminiContent, error) {
 			continue
 		}
 
+		// Context from the system, sent as marked text
+		if block.Context != nil {
+			parts = append(parts, &geminiPart{Text: block.Context.Text()})
+			continue
+		}
+
 		// Attachment — convert text/* to a text part since Gemini
 		// doesn't support text MIME types as inline data
 		if block.Attachment != nil {
@@ -216,11 +219,13 @@ func messageFromGeminiResponse(response *geminiGenerateResponse) (*schema.Messag
 
 	// Convert parts to content blocks, collecting provider-specific metadata
 	content := make([]schema.ContentBlock, 0, len(candidate.Content.Parts))
+	parts := make([]int, 0, len(candidate.Content.Parts))
 	var meta map[string]any
-	for _, part := range candidate.Content.Parts {
+	for i, part := range candidate.Content.Parts {
 		block, partMeta := blockFromGeminiPart(part)
 		if !isEmptyBlock(block) {
 			content = append(content, block)
+			parts = append(parts, i)
 		}
 		if partMeta != nil {
 			if meta == nil {
@@ -230,6 +235,9 @@ func messageFromGeminiResponse(response *geminiGenerateResponse) (*schema.Messag
 		}
 	}
 
+	// Add citations and metadata from grounding
+	meta = groundingFromGemini(candidate, content, parts, meta)
+
 	// Role mapping: "model" → "assistant"
 	role := candidate.Content.Role
 	if role == "model" {
@@ -359,6 +367,112 @@ func isEmptyBlock(b schema.ContentBlock) bool {
 		b.ToolCall == nil && b.ToolResult == nil
 }
 
+///////////////////////////////////////////////////////////////////////////////
+// GROUNDING → CITATIONS
+
+// groundingFromGemini adds web citations to the text blocks which are
+// supported by grounding chunks, and returns the message metadata with the
+// search queries, the search suggestions and the status of retrieved URLs.
+// The index of the part each content block was made from is in parts.
+func groundingFromGemini(candidate *geminiCandidate, content []schema.ContentBlock, parts []int, meta map[string]any) map[string]any {
+	set := func(key string, value any) {
+		if meta == nil {
+			meta = make(map[string]any)
+		}
+		meta[key] = value
+	}
+	if grounding := candidate.GroundingMetadata; grounding != nil {
+		citationsFromGeminiGrounding(grounding, content, parts)
+		if len(grounding.WebSearchQueries) > 0 {
+			set("web_search_queries", grounding.WebSearchQueries)
+		}
+		if entry := grounding.SearchEntryPoint; entry != nil && entry.RenderedContent != "" {
+			set("search_entry_point", entry.RenderedContent)
+		}
+	}
+	if urlContext := candidate.URLContextMetadata; urlContext != nil {
+		urls := make([]map[string]any, 0, len(urlContext.URLMetadata))
+		for _, metadata := range urlContext.URLMetadata {
+			if metadata != nil && metadata.RetrievedURL != "" {
+				urls = append(urls, map[string]any{"url": metadata.RetrievedURL, "status": metadata.URLRetrievalStatus})
+			}
+		}
+		if len(urls) > 0 {
+			set("url_context", urls)
+		}
+	}
+	return meta
+}
+
+// citationsFromGeminiGrounding adds a web citation for each chunk which
+// supports a segment of the response. Segments are byte ranges from the start
+// of the part they name, which may continue into the text blocks which follow
+// when streamed, so each citation is added to the block where its segment
+// starts, with the range clamped to that block.
+func citationsFromGeminiGrounding(grounding *geminiGroundingMetadata, content []schema.ContentBlock, parts []int) {
+	type span struct{ index, part, start, end int }
+	var spans []span
+	var offset int
+	for i, block := range content {
+		if block.Text == nil {
+			continue
+		}
+		spans = append(spans, span{index: i, part: parts[i], start: offset, end: offset + len(*block.Text)})
+		offset += len(*block.Text)
+	}
+	if len(spans) == 0 {
+		return
+	}
+
+	for _, support := range grounding.GroundingSupports {
+		if support == nil || support.Segment == nil {
+			continue
+		}
+		segment := support.Segment
+
+		// Offset the segment from the first text block of the part it names
+		first := slices.IndexFunc(spans, func(span span) bool {
+			return span.part >= segment.PartIndex
+		})
+		if first < 0 {
+			continue
+		}
+		startIndex := spans[first].start + segment.StartIndex
+		endIndex := spans[first].start + segment.EndIndex
+
+		// Find the block where the segment starts
+		block := spans[len(spans)-1]
+		for _, span := range spans[first:] {
+			if startIndex < span.end {
+				block = span
+				break
+			}
+		}
+		length := block.end - block.start
+		start := min(max(startIndex-block.start, 0), length)
+		end := min(max(endIndex-block.start, start), length)
+
+		// Cite each web page which supports the segment
+		for _, index := range support.GroundingChunkIndices {
+			if index < 0 || index >= len(grounding.GroundingChunks) {
+				continue
+			}
+			chunk := grounding.GroundingChunks[index]
+			if chunk == nil || chunk.Web == nil {
+				continue
+			}
+			content[block.index].Citations = append(content[block.index].Citations, schema.Citation{
+				Type:  schema.CitationWeb,
+				Title: chunk.Web.Title,
+				URL:   chunk.Web.URI,
+				Text:  segment.Text,
+				Start: uint(start),
+				End:   uint(end),
+			})
+		}
+	}
+}
+
 ///////////////////////////////////////////////////////////////////////////////
 // FINISH REASON → RESULT TYPE
 
@@ -386,3 +500,64 @@ func resultFromGeminiFinishReason(reason string) schema.ResultType {
 		return schema.ResultOther
 	}
 }
+
+// refusalFromGemini describes a blocked prompt or response, or returns nil if
+// neither was blocked. The prompt feedback block reason takes precedence over
+// the candidate finish reason.
+func refusalFromGemini(response *geminiGenerateResponse) *schema.Refusal {
+	if response == nil {
+		return nil
+	}
+	if feedback := response.PromptFeedback; feedback != nil && feedback.BlockReason != "" {
+		return &schema.Refusal{
+			Provider: schema.Gemini,
+			Reason:   feedback.BlockReason,
+			Category: refusalCategoryFromGemini(feedback.BlockReason),
+			Codes:    blockedGeminiCategories(feedback.SafetyRatings),
+		}
+	}
+	if len(response.Candidates) == 0 || response.Candidates[0] == nil {
+		return nil
+	}
+	candidate := response.Candidates[0]
+	if resultFromGeminiFinishReason(candidate.FinishReason) != schema.ResultBlocked {
+		return nil
+	}
+	return &schema.Refusal{
+		Provider: schema.Gemini,
+		Reason:   candidate.FinishReason,
+		Category: refusalCategoryFromGemini(candidate.FinishReason),
+		Codes:    blockedGeminiCategories(candidate.SafetyRatings),
+		Message:  candidate.FinishMessage,
+	}
+}
+
+// refusalCategoryFromGemini maps Gemini finish and block reasons, which
+// share the same values, to a refusal category
+func refusalCategoryFromGemini(reason string) string {
+	switch reason {
+	case geminiFinishReasonSafety, geminiFinishReasonImageSafety:
+		return schema.RefusalSafety
+	case geminiFinishReasonRecitation, geminiFinishReasonImageRecitation:
+		return schema.RefusalRecitation
+	case geminiFinishReasonBlocklist, geminiFinishReasonProhibitedContent,
+		geminiFinishReasonSPII, geminiFinishReasonImageProhibitedContent:
+		return schema.RefusalPolicy
+	case geminiFinishReasonLanguage:
+		return schema.RefusalLanguage
+	default:
+		return schema.RefusalOther
+	}
+}
+
+// blockedGeminiCategories returns the harm categories which caused content
+// to be blocked
+func blockedGeminiCategories(ratings []*geminiSafetyRating) []string {
+	var result []string
+	for _, rating := range ratings {
+		if rating != nil && rating.Blocked {
+			result = append(result, rating.Category)
+		}
+	}
+	return result
+}
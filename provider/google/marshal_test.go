@@ -265,6 +265,99 @@ func Test_marshal_google_to_schema_response_empty(t *testing.T) {
 	assert.Empty(msg.Content)
 }
 
+func Test_marshal_google_to_schema_response_grounding(t *testing.T) {
+	googleJSON, schemaJSON := loadTestPair(t, "response_grounding.json")
+	assert := assert.New(t)
+
+	resp := decodeGeminiResponse(t, googleJSON)
+	msg, err := messageFromGeminiResponse(resp)
+	assert.NoError(err)
+	assertSchemaMessageEquals(t, schemaJSON, msg)
+
+	// Each chunk which supports a segment is cited
+	var expected struct {
+		Content []struct {
+			Citations []schema.Citation `json:"citations"`
+		} `json:"content"`
+	}
+	assert.NoError(json.Unmarshal(schemaJSON, &expected))
+	if assert.Len(msg.Content, 1) {
+		assert.Equal(expected.Content[0].Citations, msg.Content[0].Citations)
+	}
+
+	// Search queries and retrieved URLs are in the metadata
+	assert.Equal([]string{"UEFA Euro 2024 winner", "who won euro 2024"}, msg.Meta["web_search_queries"])
+	assert.Equal([]map[string]any{{"url": "https://www.uefa.com/euro2024/", "status": "URL_RETRIEVAL_STATUS_SUCCESS"}}, msg.Meta["url_context"])
+}
+
+func Test_marshal_google_to_schema_response_grounding_parts(t *testing.T) {
+	googleJSON, schemaJSON := loadTestPair(t, "response_grounding_parts.json")
+	assert := assert.New(t)
+
+	resp := decodeGeminiResponse(t, googleJSON)
+	msg, err := messageFromGeminiResponse(resp)
+	assert.NoError(err)
+
+	// Segments are offsets from the start of the part they name
+	var expected struct {
+		Content []struct {
+			Citations []schema.Citation `json:"citations"`
+		} `json:"content"`
+	}
+	assert.NoError(json.Unmarshal(schemaJSON, &expected))
+	if assert.Len(msg.Content, 3) {
+		for i, block := range msg.Content {
+			assert.Equal(expected.Content[i].Citations, block.Citations, "content %d", i)
+		}
+	}
+	assert.Equal([]string{"UEFA Euro 2024 final"}, msg.Meta["web_search_queries"])
+}
+
+func Test_marshal_google_to_schema_response_grounding_streamed(t *testing.T) {
+	assert := assert.New(t)
+
+	// When streamed, the text is split across parts, and segments refer to
+	// the text of the whole response
+	resp := &geminiGenerateResponse{
+		Candidates: []*geminiCandidate{{
+			Content: &geminiContent{Role: "model", Parts: []*geminiPart{
+				{Text: "Thinking about it", Thought: true},
+				{Text: "Spain won "},
+				{Text: "Euro 2024. England lost."},
+			}},
+			GroundingMetadata: &geminiGroundingMetadata{
+				GroundingChunks: []*geminiGroundingChunk{
+					{Web: &geminiWebChunk{URI: "https://example.com/euro", Title: "example.com"}},
+					{},
+				},
+				GroundingSupports: []*geminiGroundingSupport{
+					{Segment: &geminiSegment{StartIndex: 0, EndIndex: 19, Text: "Spain won Euro 2024"}, GroundingChunkIndices: []int{0, 1, 5}},
+					{Segment: &geminiSegment{StartIndex: 21, EndIndex: 34, Text: "England lost."}, GroundingChunkIndices: []int{0}},
+				},
+			},
+		}},
+	}
+	msg, err := messageFromGeminiResponse(resp)
+	assert.NoError(err)
+	if assert.Len(msg.Content, 3) {
+		assert.Empty(msg.Content[0].Citations)
+		if assert.Len(msg.Content[1].Citations, 1) {
+			citation := msg.Content[1].Citations[0]
+			assert.Equal(schema.CitationWeb, citation.Type)
+			assert.Equal("https://example.com/euro", citation.URL)
+			assert.Equal("example.com", citation.Title)
+			assert.Equal(uint(0), citation.Start)
+			assert.Equal(uint(10), citation.End)
+		}
+		if assert.Len(msg.Content[2].Citations, 1) {
+			citation := msg.Content[2].Citations[0]
+			assert.Equal("England lost.", citation.Text)
+			assert.Equal(uint(11), citation.Start)
+			assert.Equal(uint(24), citation.End)
+		}
+	}
+}
+
 ///////////////////////////////////////////////////////////////////////////////
 // FINISH REASON TESTS
 
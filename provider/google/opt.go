@@ -137,6 +137,27 @@ func WithFrequencyPenalty(value float64) opt.Opt {
 	return opt.SetFloat64(opt.FrequencyPenaltyKey, value)
 }
 
+///////////////////////////////////////////////////////////////////////////////
+// GROUNDING OPTIONS
+
+// WithGoogleSearch grounds the response with Google Search. The model decides
+// when to search, and text supported by the search results carries web
+// citations. The search queries are returned in the message metadata.
+//
+// See: https://ai.google.dev/gemini-api/docs/google-search
+func WithGoogleSearch() opt.Opt {
+	return opt.SetBool(opt.GoogleSearchKey, true)
+}
+
+// WithURLContext allows the model to retrieve the URLs in the prompt, and
+// ground the response with their content. The status of each retrieval is
+// returned in the message metadata.
+//
+// See: https://ai.google.dev/gemini-api/docs/url-context
+func WithURLContext() opt.Opt {
+	return opt.SetBool(opt.URLContextKey, true)
+}
+
 ///////////////////////////////////////////////////////////////////////////////
 // EMBEDDING OPTIONS
 //
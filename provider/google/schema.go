@@ -94,10 +94,15 @@ type geminiGenerateResponse struct {
 type geminiCandidate struct {
 	Content       *geminiContent        `json:"content,omitempty"`
 	FinishReason  string                `json:"finishReason,omitempty"`
+	FinishMessage string                `json:"finishMessage,omitempty"`
 	SafetyRatings []*geminiSafetyRating `json:"safetyRatings,omitempty"`
 	TokenCount    int                   `json:"tokenCount,omitempty"`
 	AvgLogprobs   float64               `json:"avgLogprobs,omitempty"`
 	Index         int                   `json:"index,omitempty"`
+
+	// Grounding with Google Search and URL context
+	GroundingMetadata  *geminiGroundingMetadata  `json:"groundingMetadata,omitempty"`
+	URLContextMetadata *geminiURLContextMetadata `json:"urlContextMetadata,omitempty"`
 }
 
 // geminiPromptFeedback reports whether the prompt was blocked
@@ -142,6 +147,7 @@ type geminiThinkingConfig struct {
 type geminiTool struct {
 	FunctionDeclarations []*geminiFunctionDeclaration `json:"functionDeclarations,omitempty"`
 	GoogleSearch         *geminiGoogleSearch          `json:"googleSearch,omitempty"`
+	URLContext           *geminiURLContext            `json:"urlContext,omitempty"`
 	CodeExecution        *geminiCodeExecution         `json:"codeExecution,omitempty"`
 }
 
@@ -167,9 +173,66 @@ type geminiFunctionCallingConfig struct {
 // geminiGoogleSearch enables Google Search grounding
 type geminiGoogleSearch struct{}
 
+// geminiURLContext enables retrieval of the URLs in the prompt
+type geminiURLContext struct{}
+
 // geminiCodeExecution enables code execution
 type geminiCodeExecution struct{}
 
+///////////////////////////////////////////////////////////////////////////////
+// GROUNDING
+
+// geminiGroundingMetadata links the response to the sources which ground it
+type geminiGroundingMetadata struct {
+	WebSearchQueries  []string                  `json:"webSearchQueries,omitempty"`
+	SearchEntryPoint  *geminiSearchEntryPoint   `json:"searchEntryPoint,omitempty"`
+	GroundingChunks   []*geminiGroundingChunk   `json:"groundingChunks,omitempty"`
+	GroundingSupports []*geminiGroundingSupport `json:"groundingSupports,omitempty"`
+}
+
+// geminiSearchEntryPoint is the search suggestions to display with the response
+type geminiSearchEntryPoint struct {
+	RenderedContent string `json:"renderedContent,omitempty"` // HTML and CSS
+}
+
+// geminiGroundingChunk is a source which grounds the response
+type geminiGroundingChunk struct {
+	Web *geminiWebChunk `json:"web,omitempty"`
+}
+
+// geminiWebChunk is a web page which grounds the response
+type geminiWebChunk struct {
+	URI   string `json:"uri,omitempty"`
+	Title string `json:"title,omitempty"`
+}
+
+// geminiGroundingSupport links a segment of the response to the chunks which
+// support it
+type geminiGroundingSupport struct {
+	Segment               *geminiSegment `json:"segment,omitempty"`
+	GroundingChunkIndices []int          `json:"groundingChunkIndices,omitempty"`
+	ConfidenceScores      []float64      `json:"confidenceScores,omitempty"`
+}
+
+// geminiSegment is a byte range of the text of the response
+type geminiSegment struct {
+	PartIndex  int    `json:"partIndex,omitempty"`
+	StartIndex int    `json:"startIndex,omitempty"`
+	EndIndex   int    `json:"endIndex,omitempty"`
+	Text       string `json:"text,omitempty"`
+}
+
+// geminiURLContextMetadata reports the retrieval of URLs in the prompt
+type geminiURLContextMetadata struct {
+	URLMetadata []*geminiURLMetadata `json:"urlMetadata,omitempty"`
+}
+
+// geminiURLMetadata is the status of the retrieval of a URL
+type geminiURLMetadata struct {
+	RetrievedURL       string `json:"retrievedUrl,omitempty"`
+	URLRetrievalStatus string `json:"urlRetrievalStatus,omitempty"`
+}
+
 ///////////////////////////////////////////////////////////////////////////////
 // SAFETY
 
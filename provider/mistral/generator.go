@@ -5,12 +5,16 @@ import (
 	"encoding/json"
 	"io"
 	"strings"
+	"time"
 
 	// Packages
 	client "github.com/mutablelogic/go-client"
 	llm "github.com/mutablelogic/go-llm"
 	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	capture "github.com/mutablelogic/go-llm/pkg/capture"
 	opt "github.com/mutablelogic/go-llm/pkg/opt"
+	postprocess "github.com/mutablelogic/go-llm/pkg/postprocess"
+	types "github.com/mutablelogic/go-server/pkg/types"
 )
 
 ///////////////////////////////////////////////////////////////////////////////
@@ -53,6 +57,7 @@ func (c *Client) generate(ctx context.Context, model string, session *schema.Con
 		return nil, nil, err
 	}
 	streamFn := options.GetStream()
+	recorder := capture.New(options)
 
 	// Build request
 	request, err := generateRequestFromOpts(model, session, options)
@@ -72,21 +77,30 @@ func (c *Client) generate(ctx context.Context, model string, session *schema.Con
 	}
 
 	// Streaming path
+	start := time.Now()
 	if streamFn != nil {
-		return c.generateStream(ctx, payload, session, streamFn)
+		message, usage, err := c.generateStream(ctx, payload, session, streamFn, recorder)
+		session.SetLatency(message, start)
+		postprocess.Apply(options, *session, message)
+		recorder.Annotate(*session, message)
+		return message, usage, err
 	}
 
 	// Non-streaming path
 	var response chatCompletionResponse
-	if err := c.DoWithContext(ctx, payload, &response, client.OptPath("chat", "completions")); err != nil {
+	if err := c.DoWithContext(ctx, payload, &response, recorder.Opts(client.OptPath("chat", "completions"))...); err != nil {
 		return nil, nil, err
 	}
 
-	return c.processResponse(&response, session)
+	message, usage, err := c.processResponse(&response, session)
+	session.SetLatency(message, start)
+	postprocess.Apply(options, *session, message)
+	recorder.Annotate(*session, message)
+	return message, usage, err
 }
 
 // generateStream handles the SSE streaming response from the Mistral API
-func (c *Client) generateStream(ctx context.Context, payload client.Payload, session *schema.Conversation, streamFn opt.StreamFn) (*schema.Message, *schema.UsageMeta, error) {
+func (c *Client) generateStream(ctx context.Context, payload client.Payload, session *schema.Conversation, streamFn opt.StreamFn, recorder *capture.Recorder) (*schema.Message, *schema.UsageMeta, error) {
 	// Accumulators for building the final response from streamed chunks
 	var (
 		role         string
@@ -94,6 +108,8 @@ func (c *Client) generateStream(ctx context.Context, payload client.Payload, ses
 		usage        *chatUsage
 		content      strings.Builder
 		toolCalls    []mistralToolCall
+		id           string
+		model        string
 	)
 
 	callback := func(event client.TextStreamEvent) error {
@@ -109,6 +125,14 @@ func (c *Client) generateStream(ctx context.Context, payload client.Payload, ses
 			return err
 		}
 
+		// Capture the response identifier and model
+		if chunk.Id != "" {
+			id = chunk.Id
+		}
+		if chunk.Model != "" {
+			model = chunk.Model
+		}
+
 		// Extract usage (typically in the final chunk)
 		if chunk.Usage != nil {
 			usage = chunk.Usage
@@ -159,10 +183,10 @@ func (c *Client) generateStream(ctx context.Context, payload client.Payload, ses
 
 	// Execute with streaming
 	var discard chatCompletionResponse
-	if err := c.DoWithContext(ctx, payload, &discard,
+	if err := c.DoWithContext(ctx, payload, &discard, recorder.Opts(
 		client.OptPath("chat", "completions"),
 		client.OptTextStreamCallback(callback),
-	); err != nil {
+	)...); err != nil {
 		if err != io.EOF {
 			return nil, nil, err
 		}
@@ -176,6 +200,8 @@ func (c *Client) generateStream(ctx context.Context, payload client.Payload, ses
 	}
 
 	response := &chatCompletionResponse{
+		Id:    id,
+		Model: model,
 		Choices: []chatChoice{{
 			Message:      msg,
 			FinishReason: finishReason,
@@ -195,6 +221,10 @@ func (c *Client) processResponse(response *chatCompletionResponse, session *sche
 	if err != nil {
 		return nil, nil, err
 	}
+	message.CreatedAt = time.Now()
+	message.Provider = c.Name()
+	message.Model = response.Model
+	message.ResponseID = response.Id
 
 	// Append the message to the session with token counts
 	inputTokens := uint(response.Usage.PromptTokens)
@@ -231,6 +261,11 @@ func generateRequestFromOpts(model string, session *schema.Conversation, options
 		return nil, err
 	}
 
+	// Prefill — the reply continues a final assistant message
+	if prefill := options.GetString(opt.PrefillKey); prefill != "" {
+		messages = append(messages, mistralMessage{Role: roleAssistant, Content: prefill, Prefix: true})
+	}
+
 	request := &chatCompletionRequest{
 		Model:    model,
 		Messages: messages,
@@ -309,6 +344,9 @@ func generateRequestFromOpts(model string, session *schema.Conversation, options
 	if tc := options.GetString(opt.ToolChoiceKey); tc != "" {
 		request.ToolChoice = tc
 	}
+	if options.Has(opt.ParallelToolCallsKey) {
+		request.ParallelToolCalls = types.Ptr(options.GetBool(opt.ParallelToolCallsKey))
+	}
 
 	// Collect tools from toolkit and individual WithTool opts
 	var allTools []llm.Tool
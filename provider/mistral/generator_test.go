@@ -359,6 +359,7 @@ func Test_generateRequest_018(t *testing.T) {
 	assert.NotContains(m, "random_seed")
 	assert.NotContains(m, "tools")
 	assert.NotContains(m, "tool_choice")
+	assert.NotContains(m, "parallel_tool_calls")
 	assert.NotContains(m, "response_format")
 	assert.NotContains(m, "presence_penalty")
 	assert.NotContains(m, "frequency_penalty")
@@ -369,6 +370,45 @@ func Test_generateRequest_018(t *testing.T) {
 	assert.Contains(m, "max_tokens")
 }
 
+func Test_generateRequest_019(t *testing.T) {
+	// Test parallel tool calls is sent when set, including when disabled
+	assert := assert.New(t)
+
+	msg := &schema.Message{Role: "user", Content: []schema.ContentBlock{{Text: types.Ptr("Hi")}}}
+	session := schema.Conversation{msg}
+	o, err := opt.Apply(WithToolChoiceRequired(), WithParallelToolCalls(false))
+	assert.NoError(err)
+
+	req, err := generateRequestFromOpts("mistral-small-latest", &session, o)
+	assert.NoError(err)
+	assert.Equal(toolChoiceRequired, req.ToolChoice)
+	if assert.NotNil(req.ParallelToolCalls) {
+		assert.False(*req.ParallelToolCalls)
+	}
+
+	data, err := json.Marshal(req)
+	assert.NoError(err)
+	assert.Contains(string(data), `"parallel_tool_calls":false`)
+}
+
+func Test_generateRequest_020(t *testing.T) {
+	// Test prefill is sent as a final assistant message with the prefix flag
+	assert := assert.New(t)
+
+	msg := &schema.Message{Role: "user", Content: []schema.ContentBlock{{Text: types.Ptr("List three colours as JSON")}}}
+	session := schema.Conversation{msg}
+	o, err := opt.Apply(opt.WithPrefill("{"))
+	assert.NoError(err)
+
+	req, err := generateRequestFromOpts("mistral-small-latest", &session, o)
+	assert.NoError(err)
+	if assert.Len(req.Messages, 2) {
+		assert.Equal("assistant", req.Messages[1].Role)
+		assert.Equal("{", req.Messages[1].Content)
+		assert.True(req.Messages[1].Prefix)
+	}
+}
+
 ///////////////////////////////////////////////////////////////////////////////
 // UNIT TESTS — option validation
 
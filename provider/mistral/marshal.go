@@ -31,8 +31,9 @@ func mistralMessagesFromSession(session *schema.Conversation) ([]mistralMessage,
 	// the tool call it corresponds to.
 	var pendingIDs []string
 
-	messages := make([]mistralMessage, 0, len(*session))
-	for _, msg := range *session {
+	conversation := schema.Normalize(schema.Mistral, *session)
+	messages := make([]mistralMessage, 0, len(conversation))
+	for _, msg := range conversation {
 		// Tool-result messages must be split: Mistral requires one message per
 		// tool_call_id, so a schema.Message with multiple ToolResult blocks
 		// becomes multiple mistralMessages with role "tool".
@@ -128,6 +129,18 @@ func mistralMessagesFromMessage(msg *schema.Message) ([]mistralMessage, error) {
 			continue
 		}
 
+		// Context from the system, sent as marked text
+		if block.Context != nil {
+			text := block.Context.Text()
+			textCount++
+			singleText = &text
+			parts = append(parts, contentPart{
+				Type: "text",
+				Text: text,
+			})
+			continue
+		}
+
 		if block.Attachment != nil {
 			otherCount++
 			mediaType, _, _ := mime.ParseMediaType(block.Attachment.ContentType)
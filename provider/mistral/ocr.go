@@ -0,0 +1,173 @@
+package mistral
+
+import (
+	"context"
+	"encoding/base64"
+	"mime"
+	"strconv"
+	"strings"
+
+	// Packages
+	client "github.com/mutablelogic/go-client"
+	llm "github.com/mutablelogic/go-llm"
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	opt "github.com/mutablelogic/go-llm/pkg/opt"
+	types "github.com/mutablelogic/go-server/pkg/types"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// INTERFACE CHECK
+
+var _ llm.DocumentReader = (*Client)(nil)
+
+///////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+const (
+	// Model used for OCR when none is specified
+	DefaultOCRModel = "mistral-ocr-latest"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// ReadDocument reads a PDF or image attachment with OCR and returns the
+// content of each page as markdown, together with the bounding boxes of any
+// images on the page. If the model name is empty, DefaultOCRModel is used.
+func (c *Client) ReadDocument(ctx context.Context, model schema.Model, document *schema.Attachment, opts ...opt.Opt) (*schema.OCRResult, *schema.UsageMeta, error) {
+	options, err := opt.Apply(opts...)
+	if err != nil {
+		return nil, nil, err
+	}
+	req, err := ocrRequestFromAttachment(model.Name, document, options)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	payload, err := client.NewJSONRequest(req)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var resp ocrResponse
+	if err := c.DoWithContext(ctx, payload, &resp, client.OptPath("ocr")); err != nil {
+		return nil, nil, err
+	}
+
+	usage := &schema.UsageMeta{
+		Meta: schema.ProviderMetaMap{
+			"pages_processed": resp.UsageInfo.PagesProcessed,
+			"doc_size_bytes":  resp.UsageInfo.DocSizeBytes,
+		},
+	}
+	return ocrResultFromResponse(&resp), usage, nil
+}
+
+// AskDocument reads a document with OCR using ocrModel, then asks a question
+// about it using model. The markdown output of the OCR is attached to the
+// question, so the chat model does not need to support documents itself.
+// The options apply to the chat request, and the OCR usage is not included
+// in the returned usage.
+func (c *Client) AskDocument(ctx context.Context, ocrModel, model schema.Model, document *schema.Attachment, question string, opts ...opt.Opt) (*schema.Message, *schema.UsageMeta, error) {
+	result, _, err := c.ReadDocument(ctx, ocrModel, document)
+	if err != nil {
+		return nil, nil, err
+	}
+	message := &schema.Message{
+		Role: schema.RoleUser,
+		Content: []schema.ContentBlock{
+			{Text: types.Ptr(question)},
+			{Attachment: result.Attachment()},
+		},
+	}
+	return c.WithoutSession(ctx, model, message, opts...)
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// ocrRequestFromAttachment builds an OCR request for an attachment. Images are
+// sent as image_url and all other documents as document_url, using a data URI
+// when the attachment has inline data.
+func ocrRequestFromAttachment(model string, document *schema.Attachment, options opt.Options) (*ocrRequest, error) {
+	if document == nil {
+		return nil, schema.ErrBadParameter.With("document is required")
+	}
+	if model == "" {
+		model = DefaultOCRModel
+	}
+
+	var uri string
+	switch {
+	case len(document.Data) > 0:
+		if document.ContentType == "" {
+			return nil, schema.ErrBadParameter.With("document content type is required")
+		}
+		uri = "data:" + document.ContentType + ";base64," + base64.StdEncoding.EncodeToString(document.Data)
+	case document.URL != nil:
+		uri = document.URL.String()
+	default:
+		return nil, schema.ErrBadParameter.With("document has no data or URL")
+	}
+
+	req := &ocrRequest{
+		Model:              model,
+		IncludeImageBase64: options.GetBool(opt.IncludeImagesKey),
+	}
+	if mediaType, _, _ := mime.ParseMediaType(document.ContentType); strings.HasPrefix(mediaType, "image/") {
+		req.Document = ocrDocument{Type: ocrImageURL, ImageURL: uri}
+	} else {
+		req.Document = ocrDocument{Type: ocrDocumentURL, DocumentURL: uri}
+	}
+	for _, page := range options.GetStringArray(opt.PagesKey) {
+		index, err := strconv.ParseUint(page, 10, 32)
+		if err != nil {
+			return nil, schema.ErrBadParameter.Withf("invalid page %q", page)
+		}
+		req.Pages = append(req.Pages, uint(index))
+	}
+	return req, nil
+}
+
+// ocrResultFromResponse converts an OCR response to the schema representation
+func ocrResultFromResponse(resp *ocrResponse) *schema.OCRResult {
+	result := &schema.OCRResult{
+		Model: resp.Model,
+		Pages: make([]schema.OCRPage, 0, len(resp.Pages)),
+	}
+	for _, page := range resp.Pages {
+		p := schema.OCRPage{
+			Index:    page.Index,
+			Markdown: page.Markdown,
+		}
+		if page.Dimensions != nil {
+			p.Width, p.Height, p.DPI = page.Dimensions.Width, page.Dimensions.Height, page.Dimensions.DPI
+		}
+		for _, image := range page.Images {
+			p.Images = append(p.Images, schema.OCRImage{
+				ID:           image.Id,
+				TopLeftX:     image.TopLeftX,
+				TopLeftY:     image.TopLeftY,
+				BottomRightX: image.BottomRightX,
+				BottomRightY: image.BottomRightY,
+				Attachment:   attachmentFromDataURI(image.ImageBase64),
+			})
+		}
+		result.Pages = append(result.Pages, p)
+	}
+	return result
+}
+
+// attachmentFromDataURI decodes a base64 data URI, returning nil if the
+// value is empty or cannot be decoded
+func attachmentFromDataURI(uri string) *schema.Attachment {
+	header, data, ok := strings.Cut(strings.TrimPrefix(uri, "data:"), ";base64,")
+	if !ok || !strings.HasPrefix(uri, "data:") {
+		return nil
+	}
+	bytes, err := base64.StdEncoding.DecodeString(data)
+	if err != nil {
+		return nil
+	}
+	return &schema.Attachment{ContentType: header, Data: bytes}
+}
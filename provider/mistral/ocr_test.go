@@ -0,0 +1,93 @@
+package mistral
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/url"
+	"testing"
+
+	// Packages
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	opt "github.com/mutablelogic/go-llm/pkg/opt"
+	assert "github.com/stretchr/testify/assert"
+)
+
+func Test_ocr_request_pdf(t *testing.T) {
+	a := assert.New(t)
+	pdf := []byte("%PDF-1.4")
+	options, err := opt.Apply(WithPages(0, 2), WithIncludeImages())
+	a.NoError(err)
+
+	req, err := ocrRequestFromAttachment("", &schema.Attachment{ContentType: "application/pdf", Data: pdf}, options)
+	if a.NoError(err) {
+		a.Equal(DefaultOCRModel, req.Model)
+		a.Equal(ocrDocumentURL, req.Document.Type)
+		a.Equal("data:application/pdf;base64,"+base64.StdEncoding.EncodeToString(pdf), req.Document.DocumentURL)
+		a.Empty(req.Document.ImageURL)
+		a.Equal([]uint{0, 2}, req.Pages)
+		a.True(req.IncludeImageBase64)
+	}
+}
+
+func Test_ocr_request_image_url(t *testing.T) {
+	a := assert.New(t)
+	u, _ := url.Parse("https://example.com/receipt.png")
+	options, err := opt.Apply()
+	a.NoError(err)
+
+	req, err := ocrRequestFromAttachment("mistral-ocr-2505", &schema.Attachment{ContentType: "image/png", URL: u}, options)
+	if a.NoError(err) {
+		a.Equal("mistral-ocr-2505", req.Model)
+		a.Equal(ocrImageURL, req.Document.Type)
+		a.Equal("https://example.com/receipt.png", req.Document.ImageURL)
+		a.Nil(req.Pages)
+		a.False(req.IncludeImageBase64)
+	}
+
+	_, err = ocrRequestFromAttachment("", &schema.Attachment{ContentType: "image/png"}, options)
+	a.ErrorIs(err, schema.ErrBadParameter)
+	_, err = ocrRequestFromAttachment("", nil, options)
+	a.ErrorIs(err, schema.ErrBadParameter)
+}
+
+func Test_ocr_response(t *testing.T) {
+	a := assert.New(t)
+	var resp ocrResponse
+	a.NoError(json.Unmarshal([]byte(`{
+		"model": "mistral-ocr-2505",
+		"pages": [
+			{
+				"index": 0,
+				"markdown": "# Invoice\n\n![img-0.jpeg](img-0.jpeg)",
+				"images": [{
+					"id": "img-0.jpeg",
+					"top_left_x": 10, "top_left_y": 20, "bottom_right_x": 110, "bottom_right_y": 220,
+					"image_base64": "data:image/jpeg;base64,/9j/"
+				}],
+				"dimensions": {"dpi": 200, "height": 2200, "width": 1700}
+			},
+			{"index": 1, "markdown": "Total: 42", "images": []}
+		],
+		"usage_info": {"pages_processed": 2, "doc_size_bytes": 1024}
+	}`), &resp))
+
+	result := ocrResultFromResponse(&resp)
+	a.Equal("mistral-ocr-2505", result.Model)
+	if a.Len(result.Pages, 2) {
+		page := result.Pages[0]
+		a.Equal(uint(1700), page.Width)
+		a.Equal(uint(2200), page.Height)
+		a.Equal(uint(200), page.DPI)
+		if a.Len(page.Images, 1) {
+			a.Equal("img-0.jpeg", page.Images[0].ID)
+			a.Equal(uint(110), page.Images[0].BottomRightX)
+			if a.NotNil(page.Images[0].Attachment) {
+				a.Equal("image/jpeg", page.Images[0].Attachment.ContentType)
+				a.Equal([]byte{0xff, 0xd8, 0xff}, page.Images[0].Attachment.Data)
+			}
+		}
+		a.Empty(result.Pages[1].Images)
+	}
+	a.Equal("# Invoice\n\n![img-0.jpeg](img-0.jpeg)\n\nTotal: 42", result.Markdown())
+	a.Equal("text/markdown", result.Attachment().ContentType)
+}
@@ -2,6 +2,7 @@ package mistral
 
 import (
 	"encoding/json"
+	"strconv"
 
 	// Packages
 	opt "github.com/mutablelogic/go-llm/pkg/opt"
@@ -118,3 +119,46 @@ func WithToolChoiceAny() opt.Opt {
 func WithToolChoiceRequired() opt.Opt {
 	return opt.SetString(opt.ToolChoiceKey, toolChoiceRequired)
 }
+
+// WithParallelToolCalls allows or prevents the model from calling several
+// tools in a single response.
+func WithParallelToolCalls(enabled bool) opt.Opt {
+	return opt.SetBool(opt.ParallelToolCallsKey, enabled)
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// OCR OPTIONS
+//
+// See: https://docs.mistral.ai/api/#tag/ocr
+
+// WithPages restricts OCR to the given zero-based page indexes.
+func WithPages(pages ...uint) opt.Opt {
+	if len(pages) == 0 {
+		return opt.Error(schema.ErrBadParameter.With("at least one page is required"))
+	}
+	values := make([]string, len(pages))
+	for i, page := range pages {
+		values[i] = strconv.FormatUint(uint64(page), 10)
+	}
+	return opt.AddString(opt.PagesKey, values...)
+}
+
+// WithIncludeImages returns the data for images extracted by OCR, in
+// addition to their bounding boxes.
+func WithIncludeImages() opt.Opt {
+	return opt.SetBool(opt.IncludeImagesKey, true)
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// TRANSCRIPTION OPTIONS
+//
+// See: https://docs.mistral.ai/api/#tag/audio.transcriptions
+
+// WithLanguage sets the language of the audio to transcribe, as an ISO 639-1
+// code such as "en". By default the language is detected.
+func WithLanguage(language string) opt.Opt {
+	if language == "" {
+		return opt.Error(schema.ErrBadParameter.With("language is required"))
+	}
+	return opt.SetString(opt.LanguageKey, language)
+}
@@ -2,6 +2,9 @@ package mistral
 
 import (
 	"encoding/json"
+
+	// Packages
+	types "github.com/mutablelogic/go-server/pkg/types"
 )
 
 ///////////////////////////////////////////////////////////////////////////////
@@ -15,21 +18,22 @@ import (
 
 // chatCompletionRequest is the request body for POST /v1/chat/completions.
 type chatCompletionRequest struct {
-	Model            string           `json:"model"`
-	Messages         []mistralMessage `json:"messages"`
-	Temperature      *float64         `json:"temperature,omitempty"`
-	TopP             *float64         `json:"top_p,omitempty"`
-	MaxTokens        *int             `json:"max_tokens,omitempty"`
-	Stream           bool             `json:"stream,omitempty"`
-	Stop             []string         `json:"stop,omitempty"`
-	RandomSeed       *uint            `json:"random_seed,omitempty"`
-	Tools            []toolDefinition `json:"tools,omitempty"`
-	ToolChoice       any              `json:"tool_choice,omitempty"`
-	ResponseFormat   *responseFormat  `json:"response_format,omitempty"`
-	PresencePenalty  *float64         `json:"presence_penalty,omitempty"`
-	FrequencyPenalty *float64         `json:"frequency_penalty,omitempty"`
-	NumChoices       *int             `json:"n,omitempty"`
-	SafePrompt       bool             `json:"safe_prompt,omitempty"`
+	Model             string           `json:"model"`
+	Messages          []mistralMessage `json:"messages"`
+	Temperature       *float64         `json:"temperature,omitempty"`
+	TopP              *float64         `json:"top_p,omitempty"`
+	MaxTokens         *int             `json:"max_tokens,omitempty"`
+	Stream            bool             `json:"stream,omitempty"`
+	Stop              []string         `json:"stop,omitempty"`
+	RandomSeed        *uint            `json:"random_seed,omitempty"`
+	Tools             []toolDefinition `json:"tools,omitempty"`
+	ToolChoice        any              `json:"tool_choice,omitempty"`
+	ParallelToolCalls *bool            `json:"parallel_tool_calls,omitempty"`
+	ResponseFormat    *responseFormat  `json:"response_format,omitempty"`
+	PresencePenalty   *float64         `json:"presence_penalty,omitempty"`
+	FrequencyPenalty  *float64         `json:"frequency_penalty,omitempty"`
+	NumChoices        *int             `json:"n,omitempty"`
+	SafePrompt        bool             `json:"safe_prompt,omitempty"`
 }
 
 ///////////////////////////////////////////////////////////////////////////////
@@ -72,6 +76,7 @@ type mistralMessage struct {
 	Content    any               `json:"content,omitempty"`      // string or []contentPart; nil omitted for tool-call-only assistant messages
 	ToolCalls  []mistralToolCall `json:"tool_calls,omitempty"`   // assistant only
 	ToolCallID string            `json:"tool_call_id,omitempty"` // tool role only
+	Prefix     bool              `json:"prefix,omitempty"`       // assistant only, when the reply continues the message
 }
 
 // contentPart represents one element in a multi-part content array
@@ -243,3 +248,115 @@ type embeddingsUsage struct {
 	PromptTokens int `json:"prompt_tokens"`
 	TotalTokens  int `json:"total_tokens"`
 }
+
+///////////////////////////////////////////////////////////////////////////////
+// OCR — REQUEST
+//
+// Reference: https://docs.mistral.ai/api/#tag/ocr
+
+// ocrRequest is the request body for POST /v1/ocr.
+type ocrRequest struct {
+	Model              string      `json:"model"`
+	Document           ocrDocument `json:"document"`
+	Pages              []uint      `json:"pages,omitempty"`
+	IncludeImageBase64 bool        `json:"include_image_base64,omitempty"`
+}
+
+// ocrDocument references the document to read, either as a URL or a
+// data URI. Exactly one of DocumentURL and ImageURL is set, matching Type.
+type ocrDocument struct {
+	Type        string `json:"type"`
+	DocumentURL string `json:"document_url,omitempty"`
+	ImageURL    string `json:"image_url,omitempty"`
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// OCR — RESPONSE
+
+// ocrResponse is the response body from POST /v1/ocr.
+type ocrResponse struct {
+	Model     string       `json:"model"`
+	Pages     []ocrPage    `json:"pages"`
+	UsageInfo ocrUsageInfo `json:"usage_info"`
+}
+
+// ocrPage is one page of the OCR output.
+type ocrPage struct {
+	Index      uint           `json:"index"`
+	Markdown   string         `json:"markdown"`
+	Images     []ocrImage     `json:"images"`
+	Dimensions *ocrDimensions `json:"dimensions,omitempty"`
+}
+
+// ocrImage is an image extracted from a page, with its bounding box.
+type ocrImage struct {
+	Id           string `json:"id"`
+	TopLeftX     uint   `json:"top_left_x"`
+	TopLeftY     uint   `json:"top_left_y"`
+	BottomRightX uint   `json:"bottom_right_x"`
+	BottomRightY uint   `json:"bottom_right_y"`
+	ImageBase64  string `json:"image_base64,omitempty"`
+}
+
+// ocrDimensions describes the rendered size of a page.
+type ocrDimensions struct {
+	DPI    uint `json:"dpi"`
+	Height uint `json:"height"`
+	Width  uint `json:"width"`
+}
+
+// ocrUsageInfo reports the pages and bytes processed by an OCR request.
+type ocrUsageInfo struct {
+	PagesProcessed uint `json:"pages_processed"`
+	DocSizeBytes   uint `json:"doc_size_bytes"`
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// OCR DOCUMENT TYPE CONSTANTS
+
+const (
+	ocrDocumentURL = "document_url"
+	ocrImageURL    = "image_url"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// TRANSCRIPTION — REQUEST
+//
+// Reference: https://docs.mistral.ai/api/#tag/audio.transcriptions
+
+// transcriptionRequest is the multipart request body for
+// POST /v1/audio/transcriptions. Exactly one of File and FileURL is set.
+type transcriptionRequest struct {
+	Model                  string      `json:"model"`
+	File                   *types.File `json:"file,omitempty"`
+	FileURL                string      `json:"file_url,omitempty"`
+	Language               string      `json:"language,omitempty"`
+	TimestampGranularities []string    `json:"timestamp_granularities,omitempty"`
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// TRANSCRIPTION — RESPONSE
+
+// transcriptionResponse is the response body from POST /v1/audio/transcriptions.
+type transcriptionResponse struct {
+	Model    string                 `json:"model"`
+	Text     string                 `json:"text"`
+	Language string                 `json:"language"`
+	Segments []transcriptionSegment `json:"segments"`
+	Usage    transcriptionUsage     `json:"usage"`
+}
+
+// transcriptionSegment is a timed span of the transcription, in seconds.
+type transcriptionSegment struct {
+	Text  string  `json:"text"`
+	Start float64 `json:"start"`
+	End   float64 `json:"end"`
+}
+
+// transcriptionUsage reports the audio length and tokens of a transcription.
+type transcriptionUsage struct {
+	PromptAudioSeconds float64 `json:"prompt_audio_seconds"`
+	PromptTokens       uint    `json:"prompt_tokens"`
+	CompletionTokens   uint    `json:"completion_tokens"`
+	TotalTokens        uint    `json:"total_tokens"`
+}
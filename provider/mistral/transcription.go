@@ -0,0 +1,146 @@
+package mistral
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"mime"
+	"strings"
+
+	// Packages
+	client "github.com/mutablelogic/go-client"
+	llm "github.com/mutablelogic/go-llm"
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	opt "github.com/mutablelogic/go-llm/pkg/opt"
+	types "github.com/mutablelogic/go-server/pkg/types"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// INTERFACE CHECK
+
+var _ llm.Transcriber = (*Client)(nil)
+
+///////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+const (
+	// Model used for transcription when none is specified
+	DefaultTranscriptionModel = "voxtral-mini-latest"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// Transcribe returns the text of an audio attachment, with timed segments.
+// Audio with inline data is uploaded, and otherwise the URL of the audio is
+// sent for the API to fetch. If the model name is empty,
+// DefaultTranscriptionModel is used.
+func (c *Client) Transcribe(ctx context.Context, model schema.Model, audio *schema.Attachment, opts ...opt.Opt) (*schema.Transcription, *schema.UsageMeta, error) {
+	options, err := opt.Apply(opts...)
+	if err != nil {
+		return nil, nil, err
+	}
+	req, err := transcriptionRequestFromAttachment(model.Name, audio, options)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	payload, err := client.NewMultipartRequest(req, types.ContentTypeJSON)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var resp transcriptionResponse
+	if err := c.DoWithContext(ctx, payload, &resp, client.OptPath("audio", "transcriptions")); err != nil {
+		return nil, nil, err
+	}
+
+	usage := &schema.UsageMeta{
+		InputTokens:  resp.Usage.PromptTokens,
+		OutputTokens: resp.Usage.CompletionTokens,
+		Meta: schema.ProviderMetaMap{
+			"prompt_audio_seconds": resp.Usage.PromptAudioSeconds,
+		},
+	}
+	return transcriptionFromResponse(&resp), usage, nil
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// transcriptionRequestFromAttachment builds a transcription request for an
+// audio attachment, which has either inline data or a URL
+func transcriptionRequestFromAttachment(model string, audio *schema.Attachment, options opt.Options) (*transcriptionRequest, error) {
+	if audio == nil {
+		return nil, schema.ErrBadParameter.With("audio is required")
+	}
+	if model == "" {
+		model = DefaultTranscriptionModel
+	}
+
+	req := &transcriptionRequest{
+		Model:                  model,
+		Language:               options.GetString(opt.LanguageKey),
+		TimestampGranularities: []string{"segment"},
+	}
+	switch {
+	case len(audio.Data) > 0:
+		req.File = &types.File{
+			Path:        "audio" + audioExtension(audio.ContentType),
+			Body:        io.NopCloser(bytes.NewReader(audio.Data)),
+			ContentType: audio.ContentType,
+		}
+	case audio.URL != nil:
+		req.FileURL = audio.URL.String()
+	default:
+		return nil, schema.ErrBadParameter.With("audio has no data or URL")
+	}
+	return req, nil
+}
+
+// transcriptionFromResponse converts a transcription response to the schema
+// representation
+func transcriptionFromResponse(resp *transcriptionResponse) *schema.Transcription {
+	result := &schema.Transcription{
+		Model:    resp.Model,
+		Language: resp.Language,
+		Duration: resp.Usage.PromptAudioSeconds,
+		Text:     strings.TrimSpace(resp.Text),
+		Segments: make([]schema.TranscriptionSegment, 0, len(resp.Segments)),
+	}
+	for _, segment := range resp.Segments {
+		result.Segments = append(result.Segments, schema.TranscriptionSegment{
+			Start: segment.Start,
+			End:   segment.End,
+			Text:  strings.TrimSpace(segment.Text),
+		})
+	}
+	if result.Text == "" {
+		result.Text = result.SegmentText()
+	}
+	return result
+}
+
+// audioExtension returns a file extension for an audio content type, so the
+// API can tell the format of an upload
+func audioExtension(contentType string) string {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch mediaType {
+	case "audio/mpeg", "audio/mp3":
+		return ".mp3"
+	case "audio/wav", "audio/x-wav", "audio/wave":
+		return ".wav"
+	case "audio/ogg":
+		return ".ogg"
+	case "audio/flac", "audio/x-flac":
+		return ".flac"
+	case "audio/mp4", "audio/m4a", "audio/x-m4a":
+		return ".m4a"
+	case "audio/webm":
+		return ".webm"
+	}
+	if extensions, _ := mime.ExtensionsByType(mediaType); len(extensions) > 0 {
+		return extensions[0]
+	}
+	return ""
+}
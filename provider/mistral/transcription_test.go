@@ -0,0 +1,79 @@
+package mistral
+
+import (
+	"encoding/json"
+	"io"
+	"net/url"
+	"testing"
+
+	// Packages
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	opt "github.com/mutablelogic/go-llm/pkg/opt"
+	assert "github.com/stretchr/testify/assert"
+)
+
+func Test_transcription_request_data(t *testing.T) {
+	a := assert.New(t)
+	options, err := opt.Apply(WithLanguage("en"))
+	a.NoError(err)
+
+	req, err := transcriptionRequestFromAttachment("", &schema.Attachment{ContentType: "audio/mpeg", Data: []byte("ID3")}, options)
+	if a.NoError(err) {
+		a.Equal(DefaultTranscriptionModel, req.Model)
+		a.Equal("en", req.Language)
+		a.Equal([]string{"segment"}, req.TimestampGranularities)
+		a.Empty(req.FileURL)
+		if a.NotNil(req.File) {
+			a.Equal("audio.mp3", req.File.Path)
+			a.Equal("audio/mpeg", req.File.ContentType)
+			data, _ := io.ReadAll(req.File.Body)
+			a.Equal("ID3", string(data))
+		}
+	}
+}
+
+func Test_transcription_request_url(t *testing.T) {
+	a := assert.New(t)
+	u, _ := url.Parse("https://example.com/episode.mp3")
+	options, err := opt.Apply()
+	a.NoError(err)
+
+	req, err := transcriptionRequestFromAttachment("voxtral-small-latest", &schema.Attachment{ContentType: "audio/mpeg", URL: u}, options)
+	if a.NoError(err) {
+		a.Equal("voxtral-small-latest", req.Model)
+		a.Equal("https://example.com/episode.mp3", req.FileURL)
+		a.Nil(req.File)
+		a.Empty(req.Language)
+	}
+
+	_, err = transcriptionRequestFromAttachment("", &schema.Attachment{ContentType: "audio/mpeg"}, options)
+	a.ErrorIs(err, schema.ErrBadParameter)
+	_, err = transcriptionRequestFromAttachment("", nil, options)
+	a.ErrorIs(err, schema.ErrBadParameter)
+	_, err = opt.Apply(WithLanguage(""))
+	a.ErrorIs(err, schema.ErrBadParameter)
+}
+
+func Test_transcription_response(t *testing.T) {
+	a := assert.New(t)
+	var resp transcriptionResponse
+	a.NoError(json.Unmarshal([]byte(`{
+		"model": "voxtral-mini-2507",
+		"text": "",
+		"language": "en",
+		"segments": [
+			{"text": " Hello there.", "start": 0.0, "end": 1.5},
+			{"text": "Welcome to the show. ", "start": 1.5, "end": 3.25}
+		],
+		"usage": {"prompt_audio_seconds": 4, "prompt_tokens": 10, "completion_tokens": 8, "total_tokens": 18}
+	}`), &resp))
+
+	result := transcriptionFromResponse(&resp)
+	a.Equal("voxtral-mini-2507", result.Model)
+	a.Equal("en", result.Language)
+	a.Equal(4.0, result.Duration)
+	a.Equal("Hello there. Welcome to the show.", result.Text)
+	if a.Len(result.Segments, 2) {
+		a.Equal(schema.TranscriptionSegment{Start: 1.5, End: 3.25, Text: "Welcome to the show."}, result.Segments[1])
+	}
+}
@@ -25,6 +25,11 @@ func chatRequestFromOpts(model string, session *schema.Conversation, options opt
 		return nil, err
 	}
 
+	// Prefill — the reply continues a final assistant message
+	if prefill := options.GetString(opt.PrefillKey); prefill != "" {
+		messages = append(messages, chatMessage{Role: schema.RoleAssistant, Content: prefill})
+	}
+
 	request := &chatRequest{
 		Model:    model,
 		Messages: messages,
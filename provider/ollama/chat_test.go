@@ -278,6 +278,22 @@ func Test_chatRequest_015(t *testing.T) {
 	a.Error(err)
 }
 
+func Test_chatRequest_016(t *testing.T) {
+	// Prefill is sent as a final assistant message
+	a := assert.New(t)
+	msg := &schema.Message{Role: "user", Content: []schema.ContentBlock{{Text: types.Ptr("List three colours as JSON")}}}
+	session := schema.Conversation{msg}
+	o, err := opt.Apply(opt.WithPrefill("{"))
+	a.NoError(err)
+
+	req, err := chatRequestFromOpts("llama3.2", &session, o)
+	a.NoError(err)
+	if a.Len(req.Messages, 2) {
+		a.Equal("assistant", req.Messages[1].Role)
+		a.Equal("{", req.Messages[1].Content)
+	}
+}
+
 func Test_chatStreamAccumulator_PreservesToolCallsAcrossDoneStop(t *testing.T) {
 	a := assert.New(t)
 	acc := new(chatStreamAccumulator)
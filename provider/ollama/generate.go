@@ -23,9 +23,15 @@ func generateRequestFromOpts(model string, message *schema.Message, options opt.
 	if options.Has(opt.ToolChoiceKey) {
 		return nil, schema.ErrBadParameter.With("/api/generate does not support tool_choice: use /api/chat instead")
 	}
+	if options.Has(opt.ParallelToolCallsKey) {
+		return nil, schema.ErrBadParameter.With("/api/generate does not support parallel tool calls")
+	}
 	if options.Has(opt.ThinkingKey) {
 		return nil, schema.ErrBadParameter.With("/api/generate does not support thinking: use /api/chat instead")
 	}
+	if options.Has(opt.PrefillKey) {
+		return nil, schema.ErrBadParameter.With("/api/generate does not support prefill: use /api/chat instead")
+	}
 
 	prompt := generatePromptFromMessage(message)
 
@@ -255,3 +255,14 @@ func Test_generateRequest_016(t *testing.T) {
 	_, err = generateRequestFromOpts("llama3.2", msg, o)
 	a.Error(err)
 }
+
+func Test_generateRequest_017(t *testing.T) {
+	// Parallel tool calls are rejected by generateRequestFromOpts
+	a := assert.New(t)
+	msg := &schema.Message{Role: "user", Content: []schema.ContentBlock{{Text: types.Ptr("Hi")}}}
+	o, err := opt.Apply(opt.SetBool(opt.ParallelToolCallsKey, false))
+	a.NoError(err)
+
+	_, err = generateRequestFromOpts("llama3.2", msg, o)
+	a.Error(err)
+}
@@ -4,12 +4,15 @@ import (
 	"context"
 	"encoding/json"
 	"strings"
+	"time"
 
 	// Packages
 	client "github.com/mutablelogic/go-client"
 	llm "github.com/mutablelogic/go-llm"
 	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	capture "github.com/mutablelogic/go-llm/pkg/capture"
 	opt "github.com/mutablelogic/go-llm/pkg/opt"
+	postprocess "github.com/mutablelogic/go-llm/pkg/postprocess"
 )
 
 ///////////////////////////////////////////////////////////////////////////////
@@ -59,6 +62,7 @@ func (c *Client) generate(ctx context.Context, model string, message *schema.Mes
 		return nil, nil, err
 	}
 	streamFn := options.GetStream()
+	recorder := capture.New(options)
 
 	request, err := generateRequestFromOpts(model, message, options)
 	if err != nil {
@@ -73,21 +77,35 @@ func (c *Client) generate(ctx context.Context, model string, message *schema.Mes
 		return nil, nil, err
 	}
 
+	prompt := schema.Conversation{message}
+	start := time.Now()
 	if streamFn != nil {
-		return c.generateStream(ctx, payload, streamFn)
+		message, usage, err := c.generateStream(ctx, payload, streamFn, recorder)
+		if message != nil {
+			message.Latency = time.Since(start)
+		}
+		postprocess.Apply(options, prompt, message)
+		recorder.Annotate(nil, message)
+		return message, usage, err
 	}
 
 	var response generateResponse
-	if err := c.DoWithContext(ctx, payload, &response, client.OptPath("generate")); err != nil {
+	if err := c.DoWithContext(ctx, payload, &response, recorder.Opts(client.OptPath("generate"))...); err != nil {
 		return nil, nil, err
 	}
 
-	return c.processGenerateResponse(&response)
+	message, usage, err := c.processGenerateResponse(&response)
+	if message != nil {
+		message.Latency = time.Since(start)
+	}
+	postprocess.Apply(options, prompt, message)
+	recorder.Annotate(nil, message)
+	return message, usage, err
 }
 
 // generateStream handles ndjson streaming for /api/generate.
 // Ollama sends one JSON object per line; the final object has done=true.
-func (c *Client) generateStream(ctx context.Context, payload client.Payload, streamFn opt.StreamFn) (*schema.Message, *schema.UsageMeta, error) {
+func (c *Client) generateStream(ctx context.Context, payload client.Payload, streamFn opt.StreamFn, recorder *capture.Recorder) (*schema.Message, *schema.UsageMeta, error) {
 	var final generateResponse
 	var accResponse strings.Builder
 
@@ -113,7 +131,7 @@ func (c *Client) generateStream(ctx context.Context, payload client.Payload, str
 	}
 
 	var discard generateResponse
-	if err := c.DoWithContext(ctx, payload, &discard, client.OptPath("generate"), client.OptJsonStreamCallback(callback)); err != nil {
+	if err := c.DoWithContext(ctx, payload, &discard, recorder.Opts(client.OptPath("generate"), client.OptJsonStreamCallback(callback))...); err != nil {
 		return nil, nil, err
 	}
 
@@ -129,6 +147,7 @@ func (c *Client) processGenerateResponse(resp *generateResponse) (*schema.Messag
 	if err != nil {
 		return nil, nil, err
 	}
+	c.setGeneration(message, resp.Model)
 	usage := &schema.UsageMeta{
 		InputTokens:  uint(resp.PromptEvalCount),
 		OutputTokens: uint(resp.EvalCount),
@@ -148,6 +167,7 @@ func (c *Client) chat(ctx context.Context, model string, session *schema.Convers
 		return nil, nil, err
 	}
 	streamFn := options.GetStream()
+	recorder := capture.New(options)
 
 	request, err := chatRequestFromOpts(model, session, options)
 	if err != nil {
@@ -162,20 +182,29 @@ func (c *Client) chat(ctx context.Context, model string, session *schema.Convers
 		return nil, nil, err
 	}
 
+	start := time.Now()
 	if streamFn != nil {
-		return c.chatStream(ctx, payload, session, streamFn)
+		message, usage, err := c.chatStream(ctx, payload, session, streamFn, recorder)
+		session.SetLatency(message, start)
+		postprocess.Apply(options, *session, message)
+		recorder.Annotate(*session, message)
+		return message, usage, err
 	}
 
 	var response chatResponse
-	if err := c.DoWithContext(ctx, payload, &response, client.OptPath("chat")); err != nil {
+	if err := c.DoWithContext(ctx, payload, &response, recorder.Opts(client.OptPath("chat"))...); err != nil {
 		return nil, nil, err
 	}
 
-	return c.processChatResponse(session, &response)
+	message, usage, err := c.processChatResponse(session, &response)
+	session.SetLatency(message, start)
+	postprocess.Apply(options, *session, message)
+	recorder.Annotate(*session, message)
+	return message, usage, err
 }
 
 // chatStream handles ndjson streaming for /api/chat.
-func (c *Client) chatStream(ctx context.Context, payload client.Payload, session *schema.Conversation, streamFn opt.StreamFn) (*schema.Message, *schema.UsageMeta, error) {
+func (c *Client) chatStream(ctx context.Context, payload client.Payload, session *schema.Conversation, streamFn opt.StreamFn, recorder *capture.Recorder) (*schema.Message, *schema.UsageMeta, error) {
 	var final chatResponse
 	var acc chatStreamAccumulator
 
@@ -192,7 +221,7 @@ func (c *Client) chatStream(ctx context.Context, payload client.Payload, session
 	}
 
 	var discard chatResponse
-	if err := c.DoWithContext(ctx, payload, &discard, client.OptPath("chat"), client.OptJsonStreamCallback(callback)); err != nil {
+	if err := c.DoWithContext(ctx, payload, &discard, recorder.Opts(client.OptPath("chat"), client.OptJsonStreamCallback(callback))...); err != nil {
 		return nil, nil, err
 	}
 
@@ -259,6 +288,7 @@ func (c *Client) processChatResponse(session *schema.Conversation, resp *chatRes
 	if err != nil {
 		return nil, nil, err
 	}
+	c.setGeneration(message, resp.Model)
 	usage := &schema.UsageMeta{
 		InputTokens:  uint(resp.PromptEvalCount),
 		OutputTokens: uint(resp.EvalCount),
@@ -269,3 +299,11 @@ func (c *Client) processChatResponse(session *schema.Conversation, resp *chatRes
 	}
 	return message, usage, nil
 }
+
+// setGeneration records the provider and model on a generated message. Ollama
+// does not assign identifiers to responses.
+func (c *Client) setGeneration(message *schema.Message, model string) {
+	message.CreatedAt = time.Now()
+	message.Provider = c.Name()
+	message.Model = model
+}
@@ -22,8 +22,9 @@ func ollamaMessagesFromSession(session *schema.Conversation) ([]chatMessage, err
 	if session == nil {
 		return nil, nil
 	}
-	msgs := make([]chatMessage, 0, len(*session))
-	for _, msg := range *session {
+	conversation := schema.Normalize(schema.Ollama, *session)
+	msgs := make([]chatMessage, 0, len(conversation))
+	for _, msg := range conversation {
 		mm, err := ollamaChatMessagesFromMessage(msg)
 		if err != nil {
 			return nil, err
@@ -84,6 +85,12 @@ func ollamaChatMessageFromMessage(msg *schema.Message) (chatMessage, error) {
 			continue
 		}
 
+		// Context from the system, sent as marked text
+		if block.Context != nil {
+			textParts = append(textParts, block.Context.Text())
+			continue
+		}
+
 		// Thinking block
 		if block.Thinking != nil {
 			cm.Thinking = *block.Thinking
@@ -63,10 +63,11 @@ func decodeSchemaMessage(t *testing.T, data json.RawMessage) *schema.Message {
 	var raw struct {
 		Role    string `json:"role"`
 		Content []struct {
-			Text       *string        `json:"text,omitempty"`
-			Attachment *rawAttachment `json:"attachment,omitempty"`
-			ToolCall   *rawToolCall   `json:"tool_call,omitempty"`
-			ToolResult *rawToolResult `json:"tool_result,omitempty"`
+			Text       *string         `json:"text,omitempty"`
+			Attachment *rawAttachment  `json:"attachment,omitempty"`
+			ToolCall   *rawToolCall    `json:"tool_call,omitempty"`
+			ToolResult *rawToolResult  `json:"tool_result,omitempty"`
+			Context    *schema.Context `json:"context,omitempty"`
 		} `json:"content"`
 		Meta map[string]any `json:"meta,omitempty"`
 	}
@@ -82,6 +83,9 @@ func decodeSchemaMessage(t *testing.T, data json.RawMessage) *schema.Message {
 		if c.Text != nil {
 			block.Text = c.Text
 		}
+		if c.Context != nil {
+			block.Context = c.Context
+		}
 		if c.Attachment != nil {
 			att := &schema.Attachment{ContentType: c.Attachment.Type}
 			if c.Attachment.Data != "" {
@@ -206,6 +210,17 @@ func Test_marshal_schema_to_ollama_text_multipart(t *testing.T) {
 	assertOllamaMessageEquals(t, ollamaJSON, &mms[0])
 }
 
+func Test_marshal_schema_to_ollama_context(t *testing.T) {
+	ollamaJSON, schemaJSON := loadTestPair(t, "message_context.json")
+	a := assert.New(t)
+	msg := decodeSchemaMessage(t, schemaJSON)
+	a.NotNil(msg.Content[0].Context)
+	mms, err := ollamaChatMessagesFromMessage(msg)
+	a.NoError(err)
+	a.Len(mms, 1)
+	assertOllamaMessageEquals(t, ollamaJSON, &mms[0])
+}
+
 func Test_marshal_schema_to_ollama_tool_use(t *testing.T) {
 	ollamaJSON, schemaJSON := loadTestPair(t, "message_tool_use.json")
 	a := assert.New(t)
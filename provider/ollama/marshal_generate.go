@@ -24,6 +24,10 @@ func generatePromptFromMessage(msg *schema.Message) string {
 			parts = append(parts, *block.Text)
 			continue
 		}
+		if block.Context != nil {
+			parts = append(parts, block.Context.Text())
+			continue
+		}
 		if block.Attachment != nil && block.Attachment.IsText() && len(block.Attachment.Data) > 0 {
 			parts = append(parts, block.Attachment.TextContent())
 		}
@@ -112,6 +112,14 @@ func (c *CachedClient) DownloadModel(ctx context.Context, name string, opts ...o
 	return nil, schema.ErrNotImplemented.Withf("client does not support downloading models")
 }
 
+// Invalidate discards the cached models, so the next call to ListModels
+// is made to the provider
+func (c *CachedClient) Invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.last = time.Time{}
+}
+
 ///////////////////////////////////////////////////////////////////////////////
 // PRIVATE METHODS
 
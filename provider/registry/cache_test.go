@@ -137,3 +137,24 @@ func TestCachedClientGetModelFallsBackWithoutCache(t *testing.T) {
 		t.Fatalf("expected provider GetModel call without cache, got %d", client.gets)
 	}
 }
+
+func TestCachedClientInvalidate(t *testing.T) {
+	client := &cachedListClient{models: []schema.Model{{Name: "alpha"}}}
+	cache := NewCachedClient(client, time.Hour)
+
+	if _, err := cache.ListModels(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	cache.Invalidate()
+	client.models = append(client.models, schema.Model{Name: "beta"})
+	models, err := cache.ListModels(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if client.calls != 2 {
+		t.Fatalf("expected invalidate to force a provider call, got %d calls", client.calls)
+	}
+	if len(models) != 2 {
+		t.Fatalf("expected 2 models after invalidate, got %d", len(models))
+	}
+}
@@ -0,0 +1,228 @@
+package registry
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	// Packages
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	types "github.com/mutablelogic/go-server/pkg/types"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// keyRing spreads the requests of a provider across its API keys. The client
+// is created with the first key, and the transport replaces it in each
+// request with the key selected by the strategy. A key which is rejected
+// with a quota or rate limit error is quarantined, and the request is sent
+// again with another key when the body can be replayed.
+type keyRing struct {
+	sync.Mutex
+	strategy schema.KeyStrategy
+	keys     []*ringKey
+	next     int // next key for round-robin
+}
+
+type ringKey struct {
+	value string
+	stats schema.ProviderKey
+}
+
+type keyTransport struct {
+	ring   *keyRing
+	parent http.RoundTripper
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+// keyQuarantine is the time a key is not used after a quota or rate limit
+// error, unless the provider returns a longer Retry-After
+const keyQuarantine = time.Minute
+
+///////////////////////////////////////////////////////////////////////////////
+// LIFECYCLE
+
+// newKeyRing returns a key ring for the keys, or nil if there is only one key
+func newKeyRing(keys []string, strategy schema.KeyStrategy) *keyRing {
+	if len(keys) < 2 {
+		return nil
+	}
+	if strategy == "" {
+		strategy = schema.KeyRoundRobin
+	}
+	ring := &keyRing{strategy: strategy, keys: make([]*ringKey, 0, len(keys))}
+	for _, key := range keys {
+		ring.keys = append(ring.keys, &ringKey{value: key, stats: schema.ProviderKey{Key: maskKey(key)}})
+	}
+	return ring
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+func (t *keyTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	tried := make(map[*ringKey]bool, len(t.ring.keys))
+	for {
+		key := t.ring.selectKey(time.Now(), tried)
+		tried[key] = true
+		response, err := t.parent.RoundTrip(t.ring.withKey(req, key.value))
+		if err != nil || !throttled(response.StatusCode) {
+			return response, err
+		}
+		t.ring.quarantine(key, time.Now(), retryAfter(response.Header))
+
+		// Send the request again with another key, if the body can be replayed
+		if len(tried) == len(t.ring.keys) || (req.Body != nil && req.GetBody == nil) {
+			return response, nil
+		}
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return response, nil
+			}
+			req = req.Clone(req.Context())
+			req.Body = body
+		}
+		response.Body.Close()
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// transport returns the transport which rotates the keys
+func (r *keyRing) transport(parent http.RoundTripper) http.RoundTripper {
+	if parent == nil {
+		parent = http.DefaultTransport
+	}
+	return &keyTransport{ring: r, parent: parent}
+}
+
+// selectKey returns the key for the next request, skipping the keys which
+// have been tried. Keys which are quarantined are used only when every key
+// is quarantined, in which case the key whose quarantine ends first is used.
+func (r *keyRing) selectKey(now time.Time, tried map[*ringKey]bool) *ringKey {
+	r.Lock()
+	defer r.Unlock()
+
+	var result *ringKey
+	for i := range r.keys {
+		// Round-robin starts from the next key, other strategies compare every key
+		key := r.keys[(r.next+i)%len(r.keys)]
+		if tried[key] {
+			continue
+		}
+		if result == nil || r.better(key, result, now) {
+			result = key
+		}
+		if r.strategy == schema.KeyRoundRobin && !quarantined(key, now) {
+			break
+		}
+	}
+	if result == nil {
+		result = r.keys[r.next%len(r.keys)]
+	}
+	for i, key := range r.keys {
+		if key == result {
+			r.next = i + 1
+		}
+	}
+
+	// Record the use of the key
+	result.stats.Requests++
+	result.stats.LastUsed = types.Ptr(now)
+	return result
+}
+
+// better returns true if key a should be used in place of key b
+func (r *keyRing) better(a, b *ringKey, now time.Time) bool {
+	qa, qb := quarantined(a, now), quarantined(b, now)
+	switch {
+	case qa && qb:
+		return a.stats.QuarantinedUntil.Before(*b.stats.QuarantinedUntil)
+	case qa != qb:
+		return qb
+	case r.strategy == schema.KeyLeastRecentlyThrottled:
+		ta, tb := types.Value(a.stats.LastThrottled), types.Value(b.stats.LastThrottled)
+		if !ta.Equal(tb) {
+			return ta.Before(tb)
+		}
+		return types.Value(a.stats.LastUsed).Before(types.Value(b.stats.LastUsed))
+	}
+	return false
+}
+
+// quarantine sets a key aside after a quota or rate limit error
+func (r *keyRing) quarantine(key *ringKey, now time.Time, after time.Duration) {
+	r.Lock()
+	defer r.Unlock()
+	key.stats.Throttled++
+	key.stats.LastThrottled = types.Ptr(now)
+	key.stats.QuarantinedUntil = types.Ptr(now.Add(max(after, keyQuarantine)))
+}
+
+// withKey returns a copy of the request which uses the key in place of the
+// first key, in the headers and query
+func (r *keyRing) withKey(req *http.Request, key string) *http.Request {
+	first := r.keys[0].value
+	if key == first {
+		return req
+	}
+	req = req.Clone(req.Context())
+	for name, values := range req.Header {
+		for i, value := range values {
+			if strings.Contains(value, first) {
+				req.Header[name][i] = strings.ReplaceAll(value, first, key)
+			}
+		}
+	}
+	if strings.Contains(req.URL.RawQuery, first) {
+		req.URL.RawQuery = strings.ReplaceAll(req.URL.RawQuery, first, key)
+	}
+	return req
+}
+
+// usage returns the usage of each key
+func (r *keyRing) usage() []schema.ProviderKey {
+	if r == nil {
+		return nil
+	}
+	r.Lock()
+	defer r.Unlock()
+	result := make([]schema.ProviderKey, 0, len(r.keys))
+	for _, key := range r.keys {
+		result = append(result, key.stats)
+	}
+	return result
+}
+
+// quarantined returns true if the key is set aside at the time
+func quarantined(key *ringKey, now time.Time) bool {
+	return key.stats.QuarantinedUntil != nil && now.Before(*key.stats.QuarantinedUntil)
+}
+
+// throttled returns true for a quota or rate limit error
+func throttled(status int) bool {
+	return status == http.StatusTooManyRequests || status == http.StatusPaymentRequired
+}
+
+// retryAfter returns the delay in the Retry-After header, in seconds, or zero
+func retryAfter(header http.Header) time.Duration {
+	if seconds, err := strconv.Atoi(strings.TrimSpace(header.Get("Retry-After"))); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	return 0
+}
+
+// maskKey returns the last four characters of a key
+func maskKey(key string) string {
+	if len(key) <= 8 {
+		return "****"
+	}
+	return "****" + key[len(key)-4:]
+}
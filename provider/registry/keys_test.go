@@ -0,0 +1,153 @@
+package registry
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	// Packages
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	assert "github.com/stretchr/testify/assert"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// HELPERS
+
+// keyServer records the key and body of each request, and rejects the
+// throttled keys with a rate limit error
+type keyServer struct {
+	sync.Mutex
+	keys      []string
+	bodies    []string
+	throttled map[string]bool
+}
+
+func (s *keyServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	key := r.Header.Get("x-api-key")
+	s.Lock()
+	s.keys = append(s.keys, key)
+	s.bodies = append(s.bodies, string(body))
+	throttled := s.throttled[key]
+	s.Unlock()
+	if throttled {
+		w.WriteHeader(http.StatusTooManyRequests)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func keyRequest(t *testing.T, client *http.Client, url, body string) int {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodPost, url, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("x-api-key", "key-one-0001")
+	response, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	response.Body.Close()
+	return response.StatusCode
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// TESTS
+
+func TestKeyRingSingleKey(t *testing.T) {
+	assert := assert.New(t)
+	assert.Nil(newKeyRing([]string{"one"}, schema.KeyRoundRobin))
+	assert.Nil(newKeyRing(nil, ""))
+}
+
+func TestKeyRingRoundRobin(t *testing.T) {
+	assert := assert.New(t)
+	server := &keyServer{}
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	ring := newKeyRing([]string{"key-one-0001", "key-two-0002", "key-three-0003"}, "")
+	client := &http.Client{Transport: ring.transport(nil)}
+	for range 4 {
+		assert.Equal(http.StatusOK, keyRequest(t, client, ts.URL, "hello"))
+	}
+	assert.Equal([]string{"key-one-0001", "key-two-0002", "key-three-0003", "key-one-0001"}, server.keys)
+
+	usage := ring.usage()
+	if assert.Len(usage, 3) {
+		assert.Equal("****0001", usage[0].Key)
+		assert.Equal(uint64(2), usage[0].Requests)
+		assert.Equal(uint64(1), usage[1].Requests)
+		assert.NotNil(usage[1].LastUsed)
+	}
+}
+
+func TestKeyRingQuarantine(t *testing.T) {
+	assert := assert.New(t)
+	server := &keyServer{throttled: map[string]bool{"key-one-0001": true}}
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	ring := newKeyRing([]string{"key-one-0001", "key-two-0002"}, schema.KeyRoundRobin)
+	client := &http.Client{Transport: ring.transport(nil)}
+
+	// The throttled key is quarantined, and the request is sent again with the body
+	assert.Equal(http.StatusOK, keyRequest(t, client, ts.URL, "hello"))
+	assert.Equal([]string{"key-one-0001", "key-two-0002"}, server.keys)
+	assert.Equal([]string{"hello", "hello"}, server.bodies)
+
+	// The quarantined key is not used
+	assert.Equal(http.StatusOK, keyRequest(t, client, ts.URL, "again"))
+	assert.Equal("key-two-0002", server.keys[2])
+	usage := ring.usage()
+	assert.Equal(uint64(1), usage[0].Throttled)
+	if assert.NotNil(usage[0].QuarantinedUntil) {
+		assert.True(usage[0].QuarantinedUntil.After(time.Now()))
+	}
+
+	// When every key is throttled, the error is returned
+	server.throttled["key-two-0002"] = true
+	assert.Equal(http.StatusTooManyRequests, keyRequest(t, client, ts.URL, "last"))
+}
+
+func TestKeyRingLeastRecentlyThrottled(t *testing.T) {
+	assert := assert.New(t)
+	ring := newKeyRing([]string{"key-one-0001", "key-two-0002", "key-three-0003"}, schema.KeyLeastRecentlyThrottled)
+	now := time.Now()
+
+	// Keys which were throttled long ago are used before keys throttled recently
+	ring.quarantine(ring.keys[0], now.Add(-time.Hour), 0)
+	ring.quarantine(ring.keys[1], now.Add(-2*time.Hour), 0)
+	ring.quarantine(ring.keys[2], now.Add(-30*time.Minute), 0)
+	assert.Equal("key-two-0002", ring.selectKey(now, nil).value)
+
+	// Otherwise the least recently used key is selected
+	ring.keys[1].stats.LastThrottled = ring.keys[0].stats.LastThrottled
+	assert.Equal("key-one-0001", ring.selectKey(now.Add(time.Second), nil).value)
+
+	// A quarantined key is used last
+	ring.quarantine(ring.keys[0], now, 0)
+	ring.quarantine(ring.keys[1], now, 0)
+	assert.Equal("key-three-0003", ring.selectKey(now, nil).value)
+}
+
+func TestRegistryKeys(t *testing.T) {
+	assert := assert.New(t)
+	r := New()
+	_, _, err := r.Set(&schema.Provider{Name: "openai", Provider: schema.OpenAI}, schema.ProviderCredentials{
+		APIKey:  "key-one-0001",
+		APIKeys: []string{"key-two-0002", "key-one-0001"},
+	})
+	if !assert.NoError(err) {
+		return
+	}
+	if keys := r.Keys("openai"); assert.Len(keys, 2) {
+		assert.Equal("****0002", keys[1].Key)
+	}
+	assert.Nil(r.Keys("missing"))
+}
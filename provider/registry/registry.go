@@ -2,6 +2,8 @@ package registry
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"regexp"
@@ -34,9 +36,12 @@ type Registry struct {
 }
 
 type provider struct {
-	schema schema.Provider
-	client *CachedClient
-	up     bool
+	schema      schema.Provider
+	credentials schema.ProviderCredentials
+	client      *CachedClient
+	keys        *keyRing                 // rotates the API keys, or nil for one key
+	scoped      map[string]*CachedClient // clients with other credentials, by fingerprint
+	up          bool
 }
 
 ///////////////////////////////////////////////////////////////////////////////
@@ -55,7 +60,7 @@ func New(opts ...client.ClientOpt) *Registry {
 
 // Validate checks the connectivity of a provider with a ping
 func (r *Registry) Validate(ctx context.Context, provider schema.Provider, credentials schema.ProviderCredentials) error {
-	client, err := createClient(types.Ptr(provider), credentials, r.clientopts...)
+	client, _, err := createClient(types.Ptr(provider), credentials, r.clientopts...)
 	if err != nil {
 		return err
 	}
@@ -131,6 +136,52 @@ func (r *Registry) Get(name string) llm.Client {
 	return nil
 }
 
+// Scoped returns a client for a provider which uses other credentials, such
+// as those of a user, or nil if the provider is not found. A client is created
+// once for each set of credentials, and is replaced when the provider changes.
+func (r *Registry) Scoped(name string, credentials schema.ProviderCredentials) (llm.Client, error) {
+	key := fingerprint(credentials)
+
+	// Return the client from the cache
+	r.mu.RLock()
+	provider, exists := r.providers[name]
+	client := provider.scoped[key]
+	r.mu.RUnlock()
+	if !exists {
+		return nil, nil
+	} else if client != nil {
+		return client, nil
+	}
+
+	// Create the client
+	client, _, err := createClient(types.Ptr(provider.schema), credentials, r.clientopts...)
+	if err != nil {
+		return nil, err
+	}
+
+	// Cache the client, unless the provider has changed in the meantime
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if current, exists := r.providers[name]; !exists || current.client != provider.client {
+		return client, nil
+	} else if cached := current.scoped[key]; cached != nil {
+		return cached, nil
+	}
+	provider.scoped[key] = client
+	return client, nil
+}
+
+// Keys returns the usage of each API key of a provider, or nil when the
+// provider is not found or has only one key
+func (r *Registry) Keys(name string) []schema.ProviderKey {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if provider, ok := r.providers[name]; ok {
+		return provider.keys.usage()
+	}
+	return nil
+}
+
 // Count returns the number of providers currently loaded in the registry.
 func (r *Registry) Count() int {
 	r.mu.RLock()
@@ -139,16 +190,19 @@ func (r *Registry) Count() int {
 	return len(r.providers)
 }
 
-// ListModels returns filtered models for multiple providers
-/*
-func (r *Registry) ListModels(ctx context.Context, req schema.ListModelsRequest) (*schema.ListModelsResponse, error) {
+// GetModels returns the models for a provider, after include/exclude regex
+// filtering has been applied.
+func (r *Registry) GetModels(ctx context.Context, provider *schema.Provider) ([]schema.Model, error) {
+	if provider == nil {
+		return nil, schema.ErrBadParameter.Withf("provider is nil")
+	}
 
-	client := r.Get(provider)
+	client := r.Get(provider.Name)
 	if client == nil {
-		return nil, schema.ErrNotFound.Withf("provider %q not found", provider)
+		return nil, schema.ErrNotFound.Withf("provider %q not found", provider.Name)
 	}
 
-	includePatterns, err := r.compiledModelPatterns(client.Name, "include", provider.Include)
+	includePatterns, err := r.compiledModelPatterns(provider.Name, "include", provider.Include)
 	if err != nil {
 		return nil, err
 	}
@@ -173,7 +227,19 @@ func (r *Registry) ListModels(ctx context.Context, req schema.ListModelsRequest)
 
 	return result, nil
 }
-*/
+
+// Invalidate discards the cached model list for a provider, so that the
+// next request for models is made to the provider
+func (r *Registry) Invalidate(name string) error {
+	r.mu.RLock()
+	provider, exists := r.providers[name]
+	r.mu.RUnlock()
+	if !exists {
+		return schema.ErrNotFound.Withf("provider %q not found", name)
+	}
+	provider.client.Invalidate()
+	return nil
+}
 
 // GetModel returns a single model for a provider when the exact model name matches
 // after include/exclude regex filtering has been applied.
@@ -239,23 +305,28 @@ func (r *Registry) setLocked(schema *schema.Provider, credentials schema.Provide
 		return false, true, nil
 	}
 
-	// If the provider has been created but not modified, do not update the client
+	// If the provider has been created but not modified, and the credentials
+	// are the same, do not update the client
 	existing, exists := r.providers[schema.Name]
-	if exists && sameModifiedAt(existing.schema.ModifiedAt, schema.ModifiedAt) {
+	if exists && sameModifiedAt(existing.schema.ModifiedAt, schema.ModifiedAt) && existing.credentials.Equal(credentials) {
 		// No update needed, return early
 		return false, false, nil
 	}
 
 	// Create a new client for the provider
-	client, err := createClient(schema, credentials, r.clientopts...)
+	client, keys, err := createClient(schema, credentials, r.clientopts...)
 	if err != nil {
 		return false, false, err
 	}
 
-	// Update the registry with the new provider and client
+	// Update the registry with the new provider and client. Requests which
+	// hold the previous client continue with it until they are done.
 	r.providers[schema.Name] = provider{
-		schema: types.Value(schema),
-		client: client,
+		schema:      types.Value(schema),
+		credentials: credentials,
+		client:      client,
+		keys:        keys,
+		scoped:      make(map[string]*CachedClient),
 	}
 
 	// Return success
@@ -265,47 +336,65 @@ func (r *Registry) setLocked(schema *schema.Provider, credentials schema.Provide
 ///////////////////////////////////////////////////////////////////////////////
 // PRIVATE METHODS
 
-func createClient(provider *schema.Provider, credentials schema.ProviderCredentials, opts ...client.ClientOpt) (*CachedClient, error) {
+func createClient(provider *schema.Provider, credentials schema.ProviderCredentials, opts ...client.ClientOpt) (*CachedClient, *keyRing, error) {
+	// With more than one key, the client is created with the first key, and
+	// the transport rotates the keys. The transport is the outermost, so that
+	// rate limits from other transports, such as injected faults, also
+	// rotate the keys.
+	var apiKey string
+	keys := credentials.Keys()
+	if len(keys) > 0 {
+		apiKey = keys[0]
+	}
+	ring := newKeyRing(keys, credentials.KeyStrategy)
+	if ring != nil {
+		opts = append([]client.ClientOpt{client.OptTransport(ring.transport)}, opts...)
+	}
+
+	var result *CachedClient
 	switch provider.Provider {
 	case schema.Anthropic:
-		if client, err := anthropic.New(credentials.APIKey, opts...); err != nil {
-			return nil, err
+		if client, err := anthropic.New(apiKey, opts...); err != nil {
+			return nil, nil, err
 		} else {
-			return NewCachedClient(client, time.Minute*60), nil
+			result = NewCachedClient(client, time.Minute*60)
 		}
 	case schema.Ollama:
 		if client, err := ollama.New(types.Value(provider.URL), opts...); err != nil {
-			return nil, err
+			return nil, nil, err
 		} else {
-			return NewCachedClient(client, time.Minute*5), nil
+			result = NewCachedClient(client, time.Minute*5)
 		}
 	case schema.Mistral:
-		if client, err := mistral.New(credentials.APIKey, opts...); err != nil {
-			return nil, err
+		if client, err := mistral.New(apiKey, opts...); err != nil {
+			return nil, nil, err
 		} else {
-			return NewCachedClient(client, time.Minute*60), nil
+			result = NewCachedClient(client, time.Minute*60)
 		}
 	case schema.Gemini:
-		if client, err := gemini.New(credentials.APIKey, opts...); err != nil {
-			return nil, err
+		if client, err := gemini.New(apiKey, opts...); err != nil {
+			return nil, nil, err
 		} else {
-			return NewCachedClient(client, time.Minute*60), nil
+			result = NewCachedClient(client, time.Minute*60)
 		}
 	case schema.Eliza:
 		if client, err := eliza.New(); err != nil {
-			return nil, err
+			return nil, nil, err
 		} else {
-			return NewCachedClient(client, 0), nil
+			result = NewCachedClient(client, 0)
 		}
 	case schema.OpenAI:
-		if client, err := openai.New(credentials.APIKey, opts...); err != nil {
-			return nil, err
+		if client, err := openai.New(apiKey, opts...); err != nil {
+			return nil, nil, err
 		} else {
-			return NewCachedClient(client, time.Minute*60), nil
+			result = NewCachedClient(client, time.Minute*60)
 		}
 	default:
-		return nil, httpresponse.ErrBadRequest.Withf("unsupported provider: %s", provider.Provider)
+		return nil, nil, httpresponse.ErrBadRequest.Withf("unsupported provider: %s", provider.Provider)
 	}
+
+	// Return the client, and the key ring when the keys are rotated
+	return result, ring, nil
 }
 
 // Syncronizes the registry with the provided list of provider schemas and a decrypter function to obtain credentials.
@@ -365,6 +454,18 @@ func matchesModelPattern(patterns []*regexp.Regexp, name string) bool {
 	return false
 }
 
+// fingerprint returns a hash of the credentials, so that keys are not held
+// in the registry as map keys
+func fingerprint(credentials schema.ProviderCredentials) string {
+	hash := sha256.New()
+	for _, key := range credentials.Keys() {
+		hash.Write([]byte(key))
+		hash.Write([]byte{0})
+	}
+	hash.Write([]byte(credentials.KeyStrategy))
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
 func sameModifiedAt(left, right *time.Time) bool {
 	if left == nil || right == nil {
 		return left == right
@@ -291,3 +291,68 @@ func TestRegistrySetUpdatesWhenModifiedAtChanges(t *testing.T) {
 	assert.True(updated)
 	assert.False(deleted)
 }
+
+func TestRegistrySetUpdatesWhenCredentialsChange(t *testing.T) {
+	assert := assert.New(t)
+
+	r := New()
+	modifiedAt := time.Unix(1710000000, 0).UTC()
+	provider := &schema.Provider{
+		Name:       "eliza",
+		Provider:   schema.Eliza,
+		ModifiedAt: &modifiedAt,
+	}
+
+	updated, _, err := r.Set(provider, schema.ProviderCredentials{APIKey: "first"})
+	if !assert.NoError(err) {
+		return
+	}
+	assert.True(updated)
+	first := r.Get("eliza")
+
+	// A rotated key rebuilds the client, and the previous client is unchanged
+	updated, _, err = r.Set(provider, schema.ProviderCredentials{APIKey: "second"})
+	if !assert.NoError(err) {
+		return
+	}
+	assert.True(updated)
+	assert.NotSame(first, r.Get("eliza"))
+
+	updated, _, err = r.Set(provider, schema.ProviderCredentials{APIKey: "second"})
+	if !assert.NoError(err) {
+		return
+	}
+	assert.False(updated)
+}
+
+func TestRegistryScoped(t *testing.T) {
+	assert := assert.New(t)
+
+	r := New()
+	_, _, err := r.Set(&schema.Provider{Name: "mistral", Provider: schema.Mistral}, schema.ProviderCredentials{APIKey: "server"})
+	if !assert.NoError(err) {
+		return
+	}
+
+	// A client is created once for each set of credentials
+	first, err := r.Scoped("mistral", schema.ProviderCredentials{APIKey: "user"})
+	if !assert.NoError(err) || !assert.NotNil(first) {
+		return
+	}
+	second, err := r.Scoped("mistral", schema.ProviderCredentials{APIKey: " user "})
+	assert.NoError(err)
+	assert.Same(first, second)
+	assert.NotSame(r.Get("mistral"), first)
+
+	// The clients are replaced with the provider
+	_, _, err = r.Set(&schema.Provider{Name: "mistral", Provider: schema.Mistral}, schema.ProviderCredentials{APIKey: "rotated"})
+	assert.NoError(err)
+	third, err := r.Scoped("mistral", schema.ProviderCredentials{APIKey: "user"})
+	assert.NoError(err)
+	assert.NotSame(first, third)
+
+	// Unknown providers return nil
+	client, err := r.Scoped("anthropic", schema.ProviderCredentials{APIKey: "user"})
+	assert.NoError(err)
+	assert.Nil(client)
+}
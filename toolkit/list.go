@@ -126,7 +126,7 @@ func (tk *toolkit) List(ctx context.Context, req ListRequest) (*ListResponse, er
 					}
 					var wrapped []llm.Tool
 					for _, t := range tools {
-						w := tool.WithNamespace(c.namespace, t)
+						w := tool.WithNamespace(c.namespace, tool.WithCache(tk.cache, c.namespace, t))
 						if matcher.matchQualified(w.Name(), t.Name()) {
 							wrapped = append(wrapped, w)
 						}
@@ -3,8 +3,11 @@ package toolkit
 import (
 	// Packages
 	"log/slog"
+	"strings"
+	"time"
 
 	llm "github.com/mutablelogic/go-llm"
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
 	trace "go.opentelemetry.io/otel/trace"
 )
 
@@ -38,6 +41,35 @@ func WithResource(items ...llm.Resource) Option {
 	}
 }
 
+// WithToolCache keeps the results of a read-only or idempotent tool for a
+// period, so that calls with the same input are not repeated. The name may
+// be qualified with a namespace, such as "builtin.weather", or unqualified
+// to apply to the tool in any namespace. Results are shared between
+// sessions.
+func WithToolCache(name string, ttl time.Duration) Option {
+	return func(tk *toolkit) error {
+		if name = strings.TrimSpace(name); name == "" {
+			return schema.ErrBadParameter.With("tool cache: name is required")
+		} else if ttl < 0 {
+			return schema.ErrBadParameter.Withf("tool cache: duration for %q cannot be negative", name)
+		}
+		tk.cache.SetTTL(name, ttl)
+		return nil
+	}
+}
+
+// WithToolCacheSize sets the number of tool results which are cached,
+// after which the least recently used results are discarded.
+func WithToolCacheSize(size int) Option {
+	return func(tk *toolkit) error {
+		if size < 0 {
+			return schema.ErrBadParameter.With("tool cache: size cannot be negative")
+		}
+		tk.cache.SetSize(size)
+		return nil
+	}
+}
+
 // WithDelegate sets the ToolkitDelegate that receives connector lifecycle callbacks,
 // executes prompts, serves the "user" namespace, and creates connectors.
 func WithDelegate(h ToolkitDelegate) Option {
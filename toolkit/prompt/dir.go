@@ -0,0 +1,129 @@
+package prompt
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	// Packages
+	llm "github.com/mutablelogic/go-llm"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// Dir tracks a directory of markdown prompt files. Each call to Scan reports
+// the prompts which have been added or changed, and the names of prompts
+// whose files have been removed, since the previous scan.
+type Dir struct {
+	path  string
+	files map[string]dirFile
+}
+
+// dirFile records the state of a file at the last scan
+type dirFile struct {
+	modTime time.Time
+	size    int64
+	name    string
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// LIFECYCLE
+
+// NewDir returns a Dir for the given path. The directory is not read until
+// Scan is called.
+func NewDir(path string) *Dir {
+	return &Dir{
+		path:  path,
+		files: make(map[string]dirFile),
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// Path returns the directory path
+func (d *Dir) Path() string {
+	return d.path
+}
+
+// Scan walks the directory for markdown files. It returns the prompts from
+// files which are new or have changed, and the names of prompts which should
+// be removed, either because their file was deleted or because a changed file
+// now declares a different name. Files which fail to parse are reported in
+// the error and keep their previous state, so they are retried on the next scan.
+func (d *Dir) Scan() ([]llm.Prompt, []string, error) {
+	var result error
+	var add []llm.Prompt
+	var remove []string
+
+	seen := make(map[string]struct{}, len(d.files))
+	if err := filepath.WalkDir(d.path, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() || !isPromptFile(entry.Name()) {
+			return nil
+		}
+		seen[path] = struct{}{}
+
+		info, err := entry.Info()
+		if err != nil {
+			result = errors.Join(result, err)
+			return nil
+		}
+		prev, exists := d.files[path]
+		if exists && prev.modTime.Equal(info.ModTime()) && prev.size == info.Size() {
+			return nil
+		}
+
+		prompt, err := readFile(path)
+		if err != nil {
+			result = errors.Join(result, fmt.Errorf("%s: %w", path, err))
+			return nil
+		}
+		if exists && prev.name != prompt.Name() {
+			remove = append(remove, prev.name)
+		}
+		d.files[path] = dirFile{modTime: info.ModTime(), size: info.Size(), name: prompt.Name()}
+		add = append(add, prompt)
+		return nil
+	}); err != nil {
+		return nil, nil, err
+	}
+
+	// Files which have disappeared since the last scan
+	for path, file := range d.files {
+		if _, ok := seen[path]; !ok {
+			remove = append(remove, file.name)
+			delete(d.files, path)
+		}
+	}
+	slices.Sort(remove)
+
+	return add, remove, result
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+func isPromptFile(name string) bool {
+	if strings.HasPrefix(name, ".") || strings.EqualFold(name, "README.md") {
+		return false
+	}
+	return strings.EqualFold(filepath.Ext(name), ".md")
+}
+
+func readFile(path string) (llm.Prompt, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Read(f)
+}
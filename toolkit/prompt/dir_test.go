@@ -0,0 +1,113 @@
+package prompt_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	// Packages
+	prompt "github.com/mutablelogic/go-llm/toolkit/prompt"
+	assert "github.com/stretchr/testify/assert"
+)
+
+func writePromptFile(t *testing.T, path, body string, modTime time.Time) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(body), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_Dir_001(t *testing.T) {
+	// Initial scan loads markdown files and skips everything else
+	assert := assert.New(t)
+	dir := t.TempDir()
+	now := time.Now()
+	writePromptFile(t, filepath.Join(dir, "greet.md"), "Hello {{ .name }}", now)
+	writePromptFile(t, filepath.Join(dir, "README.md"), "# Readme", now)
+	writePromptFile(t, filepath.Join(dir, "notes.txt"), "ignored", now)
+
+	add, remove, err := prompt.NewDir(dir).Scan()
+	assert.NoError(err)
+	assert.Empty(remove)
+	if assert.Len(add, 1) {
+		assert.Equal("greet", add[0].Name())
+	}
+}
+
+func Test_Dir_002(t *testing.T) {
+	// Unchanged files are not reported again, changed and removed files are
+	assert := assert.New(t)
+	dir := t.TempDir()
+	now := time.Now()
+	writePromptFile(t, filepath.Join(dir, "greet.md"), "Hello", now)
+	writePromptFile(t, filepath.Join(dir, "bye.md"), "Bye", now)
+
+	d := prompt.NewDir(dir)
+	add, _, err := d.Scan()
+	assert.NoError(err)
+	assert.Len(add, 2)
+
+	add, remove, err := d.Scan()
+	assert.NoError(err)
+	assert.Empty(add)
+	assert.Empty(remove)
+
+	writePromptFile(t, filepath.Join(dir, "greet.md"), "Hello again", now.Add(time.Second))
+	assert.NoError(os.Remove(filepath.Join(dir, "bye.md")))
+	add, remove, err = d.Scan()
+	assert.NoError(err)
+	if assert.Len(add, 1) {
+		assert.Equal("greet", add[0].Name())
+	}
+	assert.Equal([]string{"bye"}, remove)
+}
+
+func Test_Dir_003(t *testing.T) {
+	// Renaming a prompt in front matter removes the old name
+	assert := assert.New(t)
+	dir := t.TempDir()
+	now := time.Now()
+	path := filepath.Join(dir, "greet.md")
+	writePromptFile(t, path, "Hello", now)
+
+	d := prompt.NewDir(dir)
+	_, _, err := d.Scan()
+	assert.NoError(err)
+
+	writePromptFile(t, path, "---\nname: welcome\n---\nHello", now.Add(time.Second))
+	add, remove, err := d.Scan()
+	assert.NoError(err)
+	if assert.Len(add, 1) {
+		assert.Equal("welcome", add[0].Name())
+	}
+	assert.Equal([]string{"greet"}, remove)
+}
+
+func Test_Dir_004(t *testing.T) {
+	// Files which fail to parse are reported and retried on the next scan
+	assert := assert.New(t)
+	dir := t.TempDir()
+	now := time.Now()
+	path := filepath.Join(dir, "broken.md")
+	writePromptFile(t, path, "---\nname: [\n---\nHello", now)
+
+	d := prompt.NewDir(dir)
+	add, _, err := d.Scan()
+	assert.Error(err)
+	assert.Empty(add)
+
+	writePromptFile(t, path, "---\nname: fixed\n---\nHello", now.Add(time.Second))
+	add, _, err = d.Scan()
+	assert.NoError(err)
+	assert.Len(add, 1)
+}
+
+func Test_Dir_005(t *testing.T) {
+	// A missing directory is an error
+	_, _, err := prompt.NewDir(filepath.Join(t.TempDir(), "missing")).Scan()
+	assert.Error(t, err)
+}
@@ -78,20 +78,35 @@ func Read(r io.Reader) (llm.Prompt, error) {
 	if p.m.Title == "" {
 		p.m.Title = extractH1(p.m.Template)
 	}
-	if !types.IsIdentifier(p.m.Name) {
-		return nil, schema.ErrBadParameter.Withf("name: must be a non-empty identifier, got %q", p.m.Name)
-	}
-	if err := validateJSONSchema(p.m.Input); err != nil {
-		return nil, schema.ErrBadParameter.Withf("input: %v", err)
-	}
-	if err := validateJSONSchema(schema.JSONSchema(p.m.Format)); err != nil {
-		return nil, schema.ErrBadParameter.Withf("output: %v", err)
+	if err := p.validate(); err != nil {
+		return nil, err
 	}
 
 	// Return the prompt with the parsed metadata and template
 	return p, nil
 }
 
+// New returns an llm.Prompt for agent metadata, such as an agent which is
+// stored in the database. The title is derived from the template when empty.
+func New(agent schema.AgentMeta) (llm.Prompt, error) {
+	p := &prompt{m: meta{
+		GeneratorMeta: agent.GeneratorMeta,
+		Name:          agent.Name,
+		Title:         agent.Title,
+		Description:   agent.Description,
+		Template:      strings.TrimSpace(agent.Template),
+		Input:         agent.Input,
+		Tools:         agent.Tools,
+	}}
+	if p.m.Title == "" {
+		p.m.Title = extractH1(p.m.Template)
+	}
+	if err := p.validate(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
 ///////////////////////////////////////////////////////////////////////////////
 // PUBLIC METHODS - PROMPT
 
@@ -107,6 +122,12 @@ func (p *prompt) Description() string {
 	return p.m.Description
 }
 
+// Tools returns the tool names and patterns which the prompt may use, or nil
+// if the front matter does not list any
+func (p *prompt) Tools() []string {
+	return p.m.Tools
+}
+
 func (p *prompt) MarshalJSON() ([]byte, error) {
 	type promptJSON struct {
 		Name        string           `json:"name"`
@@ -129,6 +150,27 @@ func (p *prompt) String() string {
 ///////////////////////////////////////////////////////////////////////////////
 // PRIVATE METHODS
 
+// validate returns an error if the name, schemas, tools or post-processing
+// of the prompt are invalid
+func (p *prompt) validate() error {
+	if !types.IsIdentifier(p.m.Name) {
+		return schema.ErrBadParameter.Withf("name: must be a non-empty identifier, got %q", p.m.Name)
+	}
+	if err := validateJSONSchema(p.m.Input); err != nil {
+		return schema.ErrBadParameter.Withf("input: %v", err)
+	}
+	if err := validateJSONSchema(schema.JSONSchema(p.m.Format)); err != nil {
+		return schema.ErrBadParameter.Withf("output: %v", err)
+	}
+	if _, err := schema.NewToolFilter(p.m.Tools...); err != nil {
+		return schema.ErrBadParameter.Withf("tools: %v", err)
+	}
+	if err := p.m.PostProcess.Validate(); err != nil {
+		return schema.ErrBadParameter.Withf("postprocess: %v", err)
+	}
+	return nil
+}
+
 // validateJSONSchema returns an error if the schema bytes are non-empty but
 // not a valid JSON schema with a "type" field.
 func validateJSONSchema(v schema.JSONSchema) error {
@@ -7,6 +7,7 @@ import (
 	"testing"
 
 	// Packages
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
 	prompt "github.com/mutablelogic/go-llm/toolkit/prompt"
 	assert "github.com/stretchr/testify/assert"
 )
@@ -119,6 +120,34 @@ func Test_Read_010(t *testing.T) {
 	assert.Error(err)
 }
 
+func Test_Read_011(t *testing.T) {
+	// bad_tools.md: tool pattern is not a valid glob
+	assert := assert.New(t)
+	_, err := prompt.Read(openFile(t, "testdata/bad_tools.md"))
+	assert.Error(err)
+	assert.Contains(err.Error(), "tools")
+}
+
+func Test_Read_012(t *testing.T) {
+	// tool globs and deny rules are accepted
+	assert := assert.New(t)
+	p, err := prompt.Read(strings.NewReader("---\nname: globs\ntools: [\"fs.*\", \"!fs.write\"]\n---\nHello"))
+	assert.NoError(err)
+	assert.NotNil(p)
+}
+
+func Test_Read_013(t *testing.T) {
+	// postprocess steps are read from the front matter, and validated
+	assert := assert.New(t)
+	p, err := prompt.Read(strings.NewReader("---\nname: steps\npostprocess:\n  - type: markdown\n  - type: links\n    links:\n      \"http://intranet/\": \"https://intranet.example.com/\"\n---\nHello"))
+	assert.NoError(err)
+	assert.NotNil(p)
+
+	_, err = prompt.Read(strings.NewReader("---\nname: steps\npostprocess:\n  - type: replace\n    pattern: \"(\"\n---\nHello"))
+	assert.Error(err)
+	assert.Contains(err.Error(), "postprocess")
+}
+
 ///////////////////////////////////////////////////////////////////////////////
 // MarshalJSON tests
 
@@ -228,3 +257,23 @@ func Test_JSON_005(t *testing.T) {
 	assert.Equal("myns.minimal", got["name"])
 	assert.Equal("Minimal Agent", got["title"])
 }
+
+func Test_New_001(t *testing.T) {
+	// New: the title is derived from the template, and the metadata is validated
+	assert := assert.New(t)
+	p, err := prompt.New(schema.AgentMeta{
+		Name:     "translate",
+		Template: "# Translate\n\nTranslate the text into French.",
+		Tools:    []string{"builtin.*"},
+	})
+	if assert.NoError(err) {
+		assert.Equal("translate", p.Name())
+		assert.Equal("Translate", p.Title())
+		assert.Equal([]string{"builtin.*"}, p.(interface{ Tools() []string }).Tools())
+	}
+
+	_, err = prompt.New(schema.AgentMeta{Name: "translate", Input: schema.JSONSchema(`{"properties":{}}`)})
+	assert.ErrorIs(err, schema.ErrBadParameter)
+	_, err = prompt.New(schema.AgentMeta{})
+	assert.ErrorIs(err, schema.ErrBadParameter)
+}
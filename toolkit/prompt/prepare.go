@@ -80,8 +80,11 @@ func (p *prompt) options() ([]opt.Opt, error) {
 	if p.m.ThinkingBudget != nil && *p.m.ThinkingBudget > 0 {
 		opts = append(opts, opt.SetUint(opt.ThinkingBudgetKey, *p.m.ThinkingBudget))
 	}
+	if p.m.Seed != nil {
+		opts = append(opts, opt.SetUint(opt.SeedKey, *p.m.Seed))
+	}
 	if len(p.m.Tools) > 0 {
-		opts = append(opts, opt.AddString(opt.ToolKey, p.m.Tools...))
+		opts = append(opts, opt.AddString(opt.ToolFilterKey, p.m.Tools...))
 	}
 
 	// Return options
@@ -0,0 +1,240 @@
+package tool
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	// Packages
+	llm "github.com/mutablelogic/go-llm"
+	singleflight "golang.org/x/sync/singleflight"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// Cache holds the results of tool calls, keyed by the qualified name of the
+// tool and its normalized input. Results are kept for a time set for each
+// tool, and the least recently used results are discarded when the cache is
+// full. Concurrent calls with the same key run the tool once. A cache is
+// shared between sessions, so it should only be used for tools whose results
+// do not depend on the caller.
+type Cache struct {
+	mu      sync.Mutex
+	group   singleflight.Group
+	size    int
+	ttl     map[string]time.Duration
+	entries map[string]*list.Element
+	lru     *list.List
+}
+
+type cacheEntry struct {
+	key     string
+	value   any
+	expires time.Time
+}
+
+// cachedTool wraps an llm.Tool and returns cached results for its calls,
+// delegating all other methods to the underlying tool.
+type cachedTool struct {
+	llm.Tool
+	cache *Cache
+	name  string
+}
+
+var _ llm.Tool = (*cachedTool)(nil)
+
+///////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+// DefaultCacheSize is the number of results held by a cache when no size
+// is set
+const DefaultCacheSize = 1024
+
+///////////////////////////////////////////////////////////////////////////////
+// LIFECYCLE
+
+// NewCache returns a cache which holds up to size results. A size of zero
+// uses DefaultCacheSize.
+func NewCache(size int) *Cache {
+	if size <= 0 {
+		size = DefaultCacheSize
+	}
+	return &Cache{
+		size:    size,
+		ttl:     make(map[string]time.Duration),
+		entries: make(map[string]*list.Element),
+		lru:     list.New(),
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// WithCache returns t with the results of its calls held in the cache, when
+// the tool is read-only or idempotent. Other tools are returned unchanged.
+// The namespace and name of the tool are used to look up the time for which
+// results are kept, so that a time can be set for all tools with a name or
+// for the tool in one namespace.
+func WithCache(cache *Cache, namespace string, t llm.Tool) llm.Tool {
+	if cache == nil || t == nil {
+		return t
+	} else if meta := t.Meta(); !meta.ReadOnlyHint && !meta.IdempotentHint {
+		return t
+	}
+	name := t.Name()
+	if namespace != "" {
+		name = namespace + "." + name
+	}
+	return &cachedTool{Tool: t, cache: cache, name: name}
+}
+
+// SetTTL sets the time for which the results of a tool are kept, where name
+// is the name of the tool with or without its namespace. A zero duration
+// stops caching the results of the tool.
+func (c *Cache) SetTTL(name string, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if ttl <= 0 {
+		delete(c.ttl, name)
+	} else {
+		c.ttl[name] = ttl
+	}
+}
+
+// SetSize sets the number of results held by the cache, discarding the least
+// recently used results if there are more. A size of zero uses
+// DefaultCacheSize.
+func (c *Cache) SetSize(size int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if size <= 0 {
+		size = DefaultCacheSize
+	}
+	c.size = size
+	c.trim()
+}
+
+// Len returns the number of results in the cache, including any which have
+// expired but not yet been discarded
+func (c *Cache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lru.Len()
+}
+
+// Unwrap returns the underlying tool, stripping the cache wrapper.
+func (t *cachedTool) Unwrap() llm.Tool { return t.Tool }
+
+// Run returns the cached result for the input, or runs the tool and caches
+// a successful result
+func (t *cachedTool) Run(ctx context.Context, input json.RawMessage) (any, error) {
+	ttl := t.cache.lookupTTL(t.name, t.Tool.Name())
+	if ttl <= 0 {
+		return t.Tool.Run(ctx, input)
+	}
+	key, ok := cacheKey(t.name, input)
+	if !ok {
+		return t.Tool.Run(ctx, input)
+	}
+	if value, ok := t.cache.get(key); ok {
+		return value, nil
+	}
+
+	// Run the tool once for concurrent calls with the same key, and return
+	// when the call completes or the context is cancelled
+	result := t.cache.group.DoChan(key, func() (any, error) {
+		value, err := t.Tool.Run(context.WithoutCancel(ctx), input)
+		if err == nil {
+			t.cache.put(key, value, ttl)
+		}
+		return value, err
+	})
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case r := <-result:
+		return r.Val, r.Err
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// lookupTTL returns the time for which results are kept for the first name
+// which has one set
+func (c *Cache) lookupTTL(names ...string) time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, name := range names {
+		if ttl, exists := c.ttl[name]; exists {
+			return ttl
+		}
+	}
+	return 0
+}
+
+// get returns a result which has not expired, and marks it as recently used
+func (c *Cache) get(key string) (any, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	elem, exists := c.entries[key]
+	if !exists {
+		return nil, false
+	}
+	entry := elem.Value.(*cacheEntry)
+	if time.Now().After(entry.expires) {
+		c.lru.Remove(elem)
+		delete(c.entries, key)
+		return nil, false
+	}
+	c.lru.MoveToFront(elem)
+	return entry.value, true
+}
+
+// put adds a result, discarding the least recently used results when the
+// cache is full
+func (c *Cache) put(key string, value any, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem, exists := c.entries[key]; exists {
+		c.lru.Remove(elem)
+	}
+	c.entries[key] = c.lru.PushFront(&cacheEntry{key: key, value: value, expires: time.Now().Add(ttl)})
+	c.trim()
+}
+
+// trim discards the least recently used results until the cache is within
+// its size
+func (c *Cache) trim() {
+	for c.lru.Len() > c.size {
+		elem := c.lru.Back()
+		c.lru.Remove(elem)
+		delete(c.entries, elem.Value.(*cacheEntry).key)
+	}
+}
+
+// cacheKey returns the key for a call, with the input normalized so that
+// the order of object keys and white space do not matter. It returns false
+// if the input is not valid JSON.
+func cacheKey(name string, input json.RawMessage) (string, bool) {
+	var value any
+	if trimmed := bytes.TrimSpace(input); len(trimmed) > 0 {
+		decoder := json.NewDecoder(bytes.NewReader(trimmed))
+		decoder.UseNumber()
+		if err := decoder.Decode(&value); err != nil || decoder.More() {
+			return "", false
+		}
+	}
+	if value == nil {
+		value = map[string]any{}
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return "", false
+	}
+	return name + "\x00" + string(data), true
+}
@@ -0,0 +1,193 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	// Packages
+	llm "github.com/mutablelogic/go-llm"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// helpers
+
+// countingToolForTest returns the number of times it has run, or an error
+// when the input asks for one
+type countingToolForTest struct {
+	simpleToolForTest
+	meta  llm.ToolMeta
+	runs  atomic.Int32
+	delay time.Duration
+}
+
+func (c *countingToolForTest) Meta() llm.ToolMeta { return c.meta }
+
+func (c *countingToolForTest) Run(_ context.Context, input json.RawMessage) (any, error) {
+	n := c.runs.Add(1)
+	time.Sleep(c.delay)
+	if string(input) == `{"fail":true}` {
+		return nil, errors.New("failed")
+	}
+	return n, nil
+}
+
+func newCountingTool(meta llm.ToolMeta) *countingToolForTest {
+	return &countingToolForTest{simpleToolForTest: simpleToolForTest{name: "weather"}, meta: meta}
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// TESTS
+
+func Test_Cache_001_only_readonly_or_idempotent_tools_are_wrapped(t *testing.T) {
+	cache := NewCache(0)
+	plain := newCountingTool(llm.ToolMeta{})
+	if got := WithCache(cache, "builtin", plain); got != llm.Tool(plain) {
+		t.Fatal("expected tool without hints to be returned unchanged")
+	}
+	if got := WithCache(nil, "builtin", newCountingTool(llm.ToolMeta{ReadOnlyHint: true})); got == nil {
+		t.Fatal("expected tool to be returned with nil cache")
+	}
+	for _, meta := range []llm.ToolMeta{{ReadOnlyHint: true}, {IdempotentHint: true}} {
+		wrapped := WithCache(cache, "builtin", newCountingTool(meta))
+		if _, ok := wrapped.(*cachedTool); !ok {
+			t.Fatalf("expected cached tool for %+v, got %T", meta, wrapped)
+		}
+		if wrapped.Name() != "weather" {
+			t.Errorf("expected name to be unchanged, got %q", wrapped.Name())
+		}
+	}
+}
+
+func Test_Cache_002_results_are_cached_by_normalized_input(t *testing.T) {
+	cache := NewCache(0)
+	cache.SetTTL("weather", time.Minute)
+	inner := newCountingTool(llm.ToolMeta{ReadOnlyHint: true})
+	wrapped := WithCache(cache, "builtin", inner)
+
+	for _, input := range []string{`{"city":"Berlin","units":"metric"}`, `{ "units": "metric", "city": "Berlin" }`} {
+		result, err := wrapped.Run(context.Background(), json.RawMessage(input))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result != int32(1) {
+			t.Errorf("expected cached result 1 for %s, got %v", input, result)
+		}
+	}
+	if result, _ := wrapped.Run(context.Background(), json.RawMessage(`{"city":"Paris"}`)); result != int32(2) {
+		t.Errorf("expected new result for different input, got %v", result)
+	}
+
+	// Empty and null input are the same as an empty object
+	first, _ := wrapped.Run(context.Background(), nil)
+	second, _ := wrapped.Run(context.Background(), json.RawMessage(`{}`))
+	third, _ := wrapped.Run(context.Background(), json.RawMessage(`null`))
+	if first != second || second != third {
+		t.Errorf("expected empty inputs to share a result, got %v %v %v", first, second, third)
+	}
+	if n := inner.runs.Load(); n != 3 {
+		t.Errorf("expected 3 runs, got %d", n)
+	}
+}
+
+func Test_Cache_003_ttl_by_qualified_name(t *testing.T) {
+	cache := NewCache(0)
+	cache.SetTTL("weather", time.Hour)
+	cache.SetTTL("builtin.weather", -1)
+	cache.SetTTL("remote.weather", time.Minute)
+
+	// Qualified names take precedence; a removed ttl falls back to the bare name
+	builtin := newCountingTool(llm.ToolMeta{ReadOnlyHint: true})
+	wrapped := WithCache(cache, "builtin", builtin)
+	wrapped.Run(context.Background(), nil)
+	wrapped.Run(context.Background(), nil)
+	if n := builtin.runs.Load(); n != 1 {
+		t.Errorf("expected 1 run, got %d", n)
+	}
+
+	// No ttl means no caching
+	other := &countingToolForTest{simpleToolForTest: simpleToolForTest{name: "search"}, meta: llm.ToolMeta{ReadOnlyHint: true}}
+	wrapped = WithCache(cache, "builtin", other)
+	wrapped.Run(context.Background(), nil)
+	wrapped.Run(context.Background(), nil)
+	if n := other.runs.Load(); n != 2 {
+		t.Errorf("expected 2 runs, got %d", n)
+	}
+}
+
+func Test_Cache_004_errors_and_invalid_input_are_not_cached(t *testing.T) {
+	cache := NewCache(0)
+	cache.SetTTL("weather", time.Minute)
+	inner := newCountingTool(llm.ToolMeta{ReadOnlyHint: true})
+	wrapped := WithCache(cache, "", inner)
+
+	for range 2 {
+		if _, err := wrapped.Run(context.Background(), json.RawMessage(`{"fail":true}`)); err == nil {
+			t.Error("expected error")
+		}
+		wrapped.Run(context.Background(), json.RawMessage(`{"city":`))
+	}
+	if n := inner.runs.Load(); n != 4 {
+		t.Errorf("expected 4 runs, got %d", n)
+	}
+	if n := cache.Len(); n != 0 {
+		t.Errorf("expected empty cache, got %d", n)
+	}
+}
+
+func Test_Cache_005_expiry_and_size(t *testing.T) {
+	cache := NewCache(2)
+	cache.SetTTL("weather", 20*time.Millisecond)
+	inner := newCountingTool(llm.ToolMeta{IdempotentHint: true})
+	wrapped := WithCache(cache, "builtin", inner)
+
+	wrapped.Run(context.Background(), json.RawMessage(`{"city":"a"}`))
+	wrapped.Run(context.Background(), json.RawMessage(`{"city":"b"}`))
+	wrapped.Run(context.Background(), json.RawMessage(`{"city":"c"}`))
+	if n := cache.Len(); n != 2 {
+		t.Errorf("expected 2 entries, got %d", n)
+	}
+
+	// The least recently used result was discarded
+	if result, _ := wrapped.Run(context.Background(), json.RawMessage(`{"city":"a"}`)); result != int32(4) {
+		t.Errorf("expected a to be run again, got %v", result)
+	}
+
+	// Results expire
+	time.Sleep(30 * time.Millisecond)
+	if result, _ := wrapped.Run(context.Background(), json.RawMessage(`{"city":"a"}`)); result != int32(5) {
+		t.Errorf("expected expired result to be run again, got %v", result)
+	}
+
+	cache.SetSize(1)
+	if n := cache.Len(); n != 1 {
+		t.Errorf("expected 1 entry after resize, got %d", n)
+	}
+}
+
+func Test_Cache_006_concurrent_calls_run_once(t *testing.T) {
+	cache := NewCache(0)
+	cache.SetTTL("weather", time.Minute)
+	inner := newCountingTool(llm.ToolMeta{ReadOnlyHint: true})
+	inner.delay = 20 * time.Millisecond
+	wrapped := WithCache(cache, "builtin", inner)
+
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if result, err := wrapped.Run(context.Background(), json.RawMessage(`{"city":"Berlin"}`)); err != nil || result != int32(1) {
+				t.Errorf("expected shared result, got %v %v", result, err)
+			}
+		}()
+	}
+	wg.Wait()
+	if n := inner.runs.Load(); n != 1 {
+		t.Errorf("expected 1 run, got %d", n)
+	}
+}
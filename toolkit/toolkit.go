@@ -32,6 +32,9 @@ type toolkit struct {
 	prompts   map[string]llm.Prompt
 	resources map[string]llm.Resource
 
+	// Results of read-only and idempotent tool calls
+	cache *tool.Cache
+
 	// Connectors by URL and namespace
 	connectors map[string]*connector
 	namespace  map[string]*connector
@@ -74,6 +77,7 @@ func New(opts ...Option) (*toolkit, error) {
 	toolkit.resources = make(map[string]llm.Resource, 200)
 	toolkit.connectors = make(map[string]*connector, 10)
 	toolkit.namespace = make(map[string]*connector, 10)
+	toolkit.cache = tool.NewCache(0)
 
 	// Apply options
 	for _, opt := range opts {
@@ -114,7 +118,7 @@ func (tk *toolkit) AddTool(tools ...llm.Tool) error {
 		}
 		for _, t := range tools {
 			if t != nil {
-				tk.tools[t.Name()] = tool.WithNamespace(BuiltinNamespace, t)
+				tk.tools[t.Name()] = tool.WithNamespace(BuiltinNamespace, tool.WithCache(tk.cache, BuiltinNamespace, t))
 			}
 		}
 		return tk.delegate, nil
@@ -346,7 +350,7 @@ func (tk *toolkit) lookupTool(ctx context.Context, namespace, name string) (llm.
 		}
 		for _, t := range tools {
 			if t.Name() == name {
-				return tool.WithNamespace(c.namespace, t), nil
+				return tool.WithNamespace(c.namespace, tool.WithCache(tk.cache, c.namespace, t)), nil
 			}
 		}
 	}
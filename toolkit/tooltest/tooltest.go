@@ -0,0 +1,133 @@
+// Package tooltest runs a single tool outside of a chat, validating its
+// input and output against the tool schemas and timing the call. It is
+// used by the "tool-test" command and by tests of tool implementations.
+package tooltest
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	// Packages
+	llm "github.com/mutablelogic/go-llm"
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	resource "github.com/mutablelogic/go-llm/toolkit/resource"
+	types "github.com/mutablelogic/go-server/pkg/types"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// Check runs the tool with the input and returns a report. A failure is
+// recorded in the report with the stage at which it occurred, rather than
+// returned as an error: the input does not match the input schema, the tool
+// returns an error, or the output does not have the shape the toolkit
+// expects or does not match the output schema.
+func Check(ctx context.Context, t llm.Tool, input json.RawMessage) schema.ToolTestReport {
+	report := schema.ToolTestReport{
+		Name:  t.Name(),
+		Input: input,
+	}
+
+	// Validate the input
+	if len(input) > 0 {
+		if !json.Valid(input) {
+			return fail(report, schema.ToolTestStageInput, "input is not valid JSON")
+		}
+		if s := t.InputSchema(); s != nil {
+			if err := s.Validate(input); err != nil {
+				return fail(report, schema.ToolTestStageInput, err.Error())
+			}
+		}
+	}
+
+	// Run the tool
+	start := time.Now()
+	result, err := t.Run(ctx, input)
+	report.Duration = time.Since(start)
+	if err != nil {
+		return fail(report, schema.ToolTestStageRun, err.Error())
+	}
+
+	// Check the output shape
+	output, err := wrap(t.Name(), result)
+	if err != nil {
+		return fail(report, schema.ToolTestStageOutput, err.Error())
+	}
+	if output == nil {
+		if t.OutputSchema() != nil {
+			return fail(report, schema.ToolTestStageOutput, "tool returned nil but an output schema is defined")
+		}
+		return report
+	}
+	data, err := output.Read(ctx)
+	if err != nil {
+		return fail(report, schema.ToolTestStageOutput, err.Error())
+	}
+	report.Type = output.Type()
+	report.Size = len(data)
+	if report.Type == types.ContentTypeJSON || strings.HasPrefix(report.Type, "text/") {
+		report.Result = string(data)
+	}
+
+	// Validate the output
+	if s := t.OutputSchema(); s != nil {
+		if report.Type != types.ContentTypeJSON {
+			return fail(report, schema.ToolTestStageOutput, "output schema is defined but tool did not return JSON content")
+		}
+		if err := s.Validate(data); err != nil {
+			return fail(report, schema.ToolTestStageOutput, err.Error())
+		}
+	}
+
+	// Return success
+	return report
+}
+
+// Run runs the tool with the input, which is a JSON string or empty, and
+// reports an error on tb when the check fails. The report is returned for
+// further assertions on the output.
+func Run(tb testing.TB, t llm.Tool, input string) schema.ToolTestReport {
+	tb.Helper()
+	var raw json.RawMessage
+	if input = strings.TrimSpace(input); input != "" {
+		raw = json.RawMessage(input)
+	}
+	report := Check(tb.Context(), t, raw)
+	if report.Error != "" {
+		tb.Errorf("%s: %s: %s", report.Name, report.Stage, report.Error)
+	}
+	return report
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+func fail(r schema.ToolTestReport, stage, message string) schema.ToolTestReport {
+	r.Stage = stage
+	r.Error = message
+	return r
+}
+
+// wrap returns the tool output as a resource, in the same way as the toolkit
+// does when a tool is called.
+func wrap(name string, result any) (llm.Resource, error) {
+	switch v := result.(type) {
+	case nil:
+		return nil, nil
+	case llm.Resource:
+		return v, nil
+	case []byte:
+		return resource.Data(name, v)
+	case string:
+		return resource.Text(name, v)
+	default:
+		if r, err := resource.JSON(name, v); err != nil {
+			return nil, schema.ErrBadParameter.Withf("tool output must be nil, llm.Resource, string, []byte, or a JSON-marshalable value, got %T", result)
+		} else {
+			return r, nil
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package tooltest_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	// Packages
+	llm "github.com/mutablelogic/go-llm"
+	schema "github.com/mutablelogic/go-llm/kernel/schema"
+	tooltest "github.com/mutablelogic/go-llm/toolkit/tooltest"
+	jsonschema "github.com/mutablelogic/go-server/pkg/jsonschema"
+	types "github.com/mutablelogic/go-server/pkg/types"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// MOCK TYPES
+
+type addInput struct {
+	A int `json:"a"`
+	B int `json:"b,omitempty"`
+}
+
+type addOutput struct {
+	Sum int `json:"sum"`
+}
+
+type addTool struct {
+	result any
+	err    error
+}
+
+func (*addTool) Name() string                     { return "add" }
+func (*addTool) Description() string              { return "Add two numbers" }
+func (*addTool) InputSchema() *jsonschema.Schema  { return jsonschema.MustFor[addInput]() }
+func (*addTool) OutputSchema() *jsonschema.Schema { return jsonschema.MustFor[addOutput]() }
+func (*addTool) Meta() llm.ToolMeta               { return llm.ToolMeta{} }
+func (t *addTool) Run(_ context.Context, input json.RawMessage) (any, error) {
+	if t.result != nil || t.err != nil {
+		return t.result, t.err
+	}
+	var req addInput
+	if err := json.Unmarshal(input, &req); err != nil {
+		return nil, err
+	}
+	return addOutput{Sum: req.A + req.B}, nil
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// TESTS
+
+func Test_Run_001_success(t *testing.T) {
+	report := tooltest.Run(t, new(addTool), `{"a":1,"b":2}`)
+	if report.Type != types.ContentTypeJSON {
+		t.Fatalf("expected JSON output, got %q", report.Type)
+	}
+	if report.Result != `{"sum":3}` {
+		t.Fatalf("unexpected result %q", report.Result)
+	}
+	if report.Size != len(report.Result) {
+		t.Fatalf("expected size %d, got %d", len(report.Result), report.Size)
+	}
+}
+
+func Test_Check_001_invalid_json(t *testing.T) {
+	report := tooltest.Check(context.Background(), new(addTool), json.RawMessage(`{"a":`))
+	if report.Stage != schema.ToolTestStageInput {
+		t.Fatalf("expected input stage, got %q (%s)", report.Stage, report.Error)
+	}
+}
+
+func Test_Check_002_invalid_input(t *testing.T) {
+	report := tooltest.Check(context.Background(), new(addTool), json.RawMessage(`{"a":"one"}`))
+	if report.Stage != schema.ToolTestStageInput {
+		t.Fatalf("expected input stage, got %q (%s)", report.Stage, report.Error)
+	}
+}
+
+func Test_Check_003_run_error(t *testing.T) {
+	report := tooltest.Check(context.Background(), &addTool{err: errors.New("overflow")}, json.RawMessage(`{"a":1}`))
+	if report.Stage != schema.ToolTestStageRun || report.Error != "overflow" {
+		t.Fatalf("expected run stage error, got %q (%s)", report.Stage, report.Error)
+	}
+}
+
+func Test_Check_004_nil_output(t *testing.T) {
+	report := tooltest.Check(context.Background(), &nilTool{new(addTool)}, nil)
+	if report.Stage != schema.ToolTestStageOutput {
+		t.Fatalf("expected output stage, got %q (%s)", report.Stage, report.Error)
+	}
+}
+
+func Test_Check_005_text_output(t *testing.T) {
+	report := tooltest.Check(context.Background(), &addTool{result: "three"}, json.RawMessage(`{"a":1}`))
+	if report.Stage != schema.ToolTestStageOutput {
+		t.Fatalf("expected output stage, got %q (%s)", report.Stage, report.Error)
+	}
+	if report.Result != "three" || report.Type != types.ContentTypeTextPlain {
+		t.Fatalf("unexpected output %q of type %q", report.Result, report.Type)
+	}
+}
+
+func Test_Check_006_output_schema(t *testing.T) {
+	report := tooltest.Check(context.Background(), &addTool{result: map[string]any{"sum": "three"}}, json.RawMessage(`{"a":1}`))
+	if report.Stage != schema.ToolTestStageOutput {
+		t.Fatalf("expected output stage, got %q (%s)", report.Stage, report.Error)
+	}
+}
+
+// nilTool returns nil from Run, while keeping the output schema
+type nilTool struct {
+	*addTool
+}
+
+func (*nilTool) Run(context.Context, json.RawMessage) (any, error) { return nil, nil }